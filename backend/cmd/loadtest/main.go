@@ -0,0 +1,106 @@
+// Command loadtest puts a running server under a fixed request rate,
+// reports latency percentiles per endpoint and, given a baseline, fails
+// when p99 latency regressed beyond a threshold.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/loadtest"
+)
+
+func main() {
+	var target = flag.String("target", "http://localhost:8080", "Base URL of the server under load")
+	var endpoints = flag.String("endpoints", "register,login,list", "Comma-separated endpoints to load: register, login, list")
+	var rate = flag.Int("rate", 50, "Requests started per second, per endpoint")
+	var duration = flag.Duration("duration", 30*time.Second, "How long each endpoint is loaded")
+	var workers = flag.Int("workers", 50, "Maximum requests in flight")
+	var timeout = flag.Duration("timeout", 5*time.Second, "Per-request timeout")
+	var out = flag.String("out", "", "Write the report as JSON to this file, e.g. to record a baseline")
+	var baseline = flag.String("baseline", "", "Compare with the report stored in this file and exit 1 on regression")
+	var maxRegression = flag.Float64("max-regression", 0.2, "Tolerated p99 increase over the baseline, as a fraction")
+	var slack = flag.Duration("slack", 5*time.Millisecond, "Tolerated absolute p99 increase over the baseline")
+	var maxErrors = flag.Float64("max-errors", 0.01, "Tolerated fraction of failed requests")
+	var maxDropped = flag.Float64("max-dropped", 0.01, "Tolerated fraction of requests dropped because every worker was busy")
+	flag.Parse()
+
+	if *rate <= 0 || *workers <= 0 || *duration <= 0 {
+		log.Fatal("-rate, -workers and -duration must be positive")
+	}
+
+	var selected []loadtest.Endpoint
+	for _, name := range strings.Split(*endpoints, ",") {
+		endpoint, ok := loadtest.Endpoints[strings.TrimSpace(name)]
+		if !ok {
+			log.Fatalf("Unknown endpoint %q", name)
+		}
+		selected = append(selected, endpoint)
+	}
+
+	var base *loadtest.Report
+	if *baseline != "" {
+		var err error
+		if base, err = loadtest.ReadReport(*baseline); err != nil {
+			log.Fatalf("Failed to read baseline: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client := &http.Client{
+		Timeout:   *timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *workers},
+	}
+	t := loadtest.NewTarget(*target, client)
+	opts := loadtest.Options{Rate: *rate, Duration: *duration, Workers: *workers}
+	report := &loadtest.Report{Rate: *rate, Duration: *duration, Workers: *workers, CreatedAt: time.Now().UTC()}
+
+	fmt.Printf("%-10s %8s %7s %7s %8s %9s %9s %9s %9s\n", "ENDPOINT", "REQS", "ERRORS", "DROPPED", "RPS", "P50", "P90", "P99", "MAX")
+	for _, endpoint := range selected {
+		result, err := loadtest.Run(ctx, t, endpoint, opts)
+		if err != nil {
+			log.Fatalf("Failed to prepare %s: %v", endpoint.Name, err)
+		}
+		report.Results = append(report.Results, result)
+		fmt.Printf("%-10s %8d %7d %7d %8.1f %9s %9s %9s %9s\n",
+			result.Endpoint, result.Requests, result.Errors, result.Dropped, result.Throughput,
+			round(result.Latency.P50), round(result.Latency.P90), round(result.Latency.P99), round(result.Latency.Max))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if *out != "" {
+		if err := loadtest.WriteReport(*out, report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	}
+
+	if base == nil {
+		return
+	}
+	gate := loadtest.Gate{MaxRegression: *maxRegression, Slack: *slack, MaxErrorRate: *maxErrors, MaxDropRate: *maxDropped}
+	regressions := gate.Check(base, report)
+	if len(regressions) == 0 {
+		fmt.Println("PASS no regression against", *baseline)
+		return
+	}
+	for _, r := range regressions {
+		fmt.Println("FAIL", r)
+	}
+	os.Exit(1)
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
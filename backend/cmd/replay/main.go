@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/replay"
+)
+
+func main() {
+	var dir = flag.String("dir", "replay", "Directory containing captured envelopes")
+	var list = flag.Bool("list", false, "List captured envelopes and exit")
+	var id = flag.String("id", "", "Envelope ID to replay")
+	var all = flag.Bool("all", false, "Replay every captured envelope")
+	var target = flag.String("target", "", "Base URL of the staging environment (e.g. https://staging.example.com)")
+	var bodyOverrides = flag.String("body", "", "JSON object overlaid on the synthesized request body")
+	var allowWrites = flag.Bool("allow-writes", false, "Allow replaying non-GET requests")
+	var timeout = flag.Duration("timeout", 30*time.Second, "Per-request timeout")
+	flag.Parse()
+
+	store, err := replay.NewFileStore(*dir)
+	if err != nil {
+		log.Fatalf("Failed to open replay store: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if *list {
+		envelopes, err := store.List(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list envelopes: %v", err)
+		}
+		for _, env := range envelopes {
+			fmt.Printf("%s  %s  %d  %-6s %s\n", env.ID, env.CapturedAt.Format(time.RFC3339), env.Status, env.Method, env.Path)
+		}
+		return
+	}
+
+	if *target == "" {
+		log.Fatal("-target is required to replay requests")
+	}
+	if *id == "" && !*all {
+		log.Fatal("specify -id or -all")
+	}
+
+	var overrides map[string]interface{}
+	if *bodyOverrides != "" {
+		if err := json.Unmarshal([]byte(*bodyOverrides), &overrides); err != nil {
+			log.Fatalf("Invalid -body JSON: %v", err)
+		}
+	}
+
+	var envelopes []*replay.Envelope
+	if *all {
+		envelopes, err = store.List(ctx)
+	} else {
+		var env *replay.Envelope
+		env, err = store.Get(ctx, *id)
+		envelopes = []*replay.Envelope{env}
+	}
+	if err != nil {
+		log.Fatalf("Failed to load envelopes: %v", err)
+	}
+
+	replayer := replay.NewReplayer(*target, &http.Client{Timeout: *timeout})
+
+	// Fresh credentials for the target environment; captured envelopes never carry tokens
+	if email := os.Getenv("REPLAY_EMAIL"); email != "" {
+		if err := replayer.Login(ctx, email, os.Getenv("REPLAY_PASSWORD")); err != nil {
+			log.Fatalf("Failed to authenticate against target: %v", err)
+		}
+	}
+
+	failed := 0
+	for _, env := range envelopes {
+		if env.Method != http.MethodGet && !*allowWrites {
+			fmt.Printf("SKIP %s %s %s (use -allow-writes to replay)\n", env.ID, env.Method, env.Path)
+			continue
+		}
+
+		result, err := replayer.Replay(ctx, env, overrides)
+		if err != nil {
+			failed++
+			fmt.Printf("ERR  %s %s %s: %v\n", env.ID, env.Method, env.Path, err)
+			continue
+		}
+
+		fmt.Printf("%-4d %s %s %s (originally %d, %s)\n",
+			result.Status, result.EnvelopeID, result.Method, result.Path, result.OriginalStatus, result.Duration.Round(time.Millisecond))
+		if result.Status >= http.StatusInternalServerError {
+			fmt.Printf("     %s\n", result.Body)
+		}
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
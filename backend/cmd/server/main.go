@@ -3,42 +3,43 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
+
+	"github.com/spf13/pflag"
 
 	"github.com/cctw-zed/wonder/internal/container"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
 	"github.com/cctw-zed/wonder/internal/server"
+	"github.com/cctw-zed/wonder/pkg/buildinfo"
+	"github.com/cctw-zed/wonder/pkg/jsoncodec"
+	"github.com/cctw-zed/wonder/pkg/logger"
 )
 
 func main() {
-	// Parse command line flags
-	var configPath = flag.String("config", "", "Path to configuration file")
-	var environment = flag.String("env", "", "Environment (development, testing, production)")
-	flag.Parse()
+	// Schema migrations run as a subcommand so deployments can apply them
+	// before starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	loadOptions, watchConfig, validateConfig := parseFlags(os.Args[1:])
+
+	if validateConfig {
+		os.Exit(runValidateConfig(loadOptions))
+	}
 
 	// Create application context
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Initialize container with configuration
-	var c *container.Container
-	var err error
-
-	if *environment != "" {
-		// Load environment-specific configuration from configs directory
-		c, err = container.NewContainerForEnvironment(ctx, *environment)
-	} else if *configPath != "" {
-		// Load configuration from custom path
-		c, err = container.NewContainerWithConfig(ctx, *configPath)
-	} else {
-		// Load default configuration from configs directory
-		c, err = container.NewContainer()
-	}
-
+	c, err := container.New(ctx, container.WithLoadOptions(loadOptions))
 	if err != nil {
 		log.Fatalf("Failed to initialize container: %v", err)
 	}
@@ -49,20 +50,77 @@ func main() {
 	}()
 
 	// Create and start server
-	srv := server.New(c)
+	srv, err := server.New(c)
+	if err != nil {
+		log.Fatalf("Failed to create server: %v", err)
+	}
+
+	// The server stops first on shutdown, before the components it uses
+	shutdownTimeout := c.Config().Server.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = config.DefaultShutdownTimeout
+	}
+	c.OnShutdown(container.PhaseServer, "http_server", shutdownTimeout, srv.Shutdown)
+
+	// Watch configuration file and rotated secrets for changes
+	onConfigChange := func(event config.ChangeEvent) {
+		c.ApplyConfigChange(event)
+		srv.ApplyConfigChange(event)
+	}
+	watching := false
+	if watchConfig {
+		if err := config.Watch(onConfigChange); err != nil {
+			log.Printf("Config hot-reload disabled: %v", err)
+		} else {
+			watching = true
+		}
+	}
+	if !watching {
+		if err := config.Subscribe(onConfigChange); err != nil {
+			log.Printf("Config change notifications disabled: %v", err)
+		}
+	}
+	config.WatchSecrets(ctx, c.Config().Secrets.RefreshInterval)
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Starting %s server on %s (environment: %s)",
-			c.Config.App.Name,
+		scheme := "http"
+		if srv.TLSEnabled() {
+			scheme = "https"
+		}
+		build := buildinfo.Get()
+		log.Printf("Starting %s server %s (commit %s, built %s, %s, JSON %s) on %s://%s (environment: %s)",
+			c.Config().App.Name,
+			build.Version,
+			build.GitCommit,
+			build.BuildTime,
+			build.GoVersion,
+			jsoncodec.Engine,
+			scheme,
 			srv.GetAddr(),
-			c.Config.App.Environment)
+			c.Config().App.Environment)
 
 		if err := srv.Start(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed to start: %v", err)
 		}
 	}()
 
+	// Reload renewed TLS certificates on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := srv.ReloadCertificates(); err != nil {
+				log.Printf("TLS certificate reload failed, keeping the current certificate: %v", err)
+				continue
+			}
+			log.Println("TLS certificates reloaded")
+		}
+	}()
+
+	// Raise the log level on SIGUSR1 and lower it on SIGUSR2
+	watchLevelSignals(c.Config().Log.SignalLevelTTL)
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -70,14 +128,117 @@ func main() {
 
 	log.Println("Shutting down server...")
 
-	// Give outstanding requests a deadline for completion
-	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Shutdown server
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+	// Drain requests, then stop workers, event delivery and connections;
+	// every component gets its own deadline
+	if err := c.Shutdown(context.Background()); err != nil {
+		log.Printf("Shutdown completed with errors: %v", err)
 	}
 
 	log.Println("Server exited")
 }
+
+// parseFlags parses the server's command line. Every configuration key can
+// also be set with a flag named after it, e.g. --server.port=9090.
+func parseFlags(args []string) (opts config.LoadOptions, watchConfig, validateConfig bool) {
+	flags := pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	configPath := flags.String("config", "", "Configuration file, or a directory searched for one")
+	environment := flags.String("env", "", "Environment (development, testing, production)")
+	envFiles := flags.StringArray("env-file", nil, "Read environment variables from this file; repeatable (default .env when it exists)")
+	remote := flags.String("remote-config", os.Getenv("CONFIG_REMOTE_URL"), "Read a YAML document from etcd or Consul, e.g. etcd://localhost:2379/wonder/config or consul://localhost:8500/wonder/config")
+	flags.BoolVar(&watchConfig, "watch-config", true, "Reload configuration when the config file changes")
+	flags.BoolVar(&validateConfig, "validate-config", false, "Print the effective configuration, report every validation error and exit")
+	config.RegisterFlags(flags)
+	_ = flags.Parse(longFlags(args))
+
+	opts = config.LoadOptions{
+		Environment: *environment,
+		ConfigPath:  *configPath,
+		EnvFiles:    *envFiles,
+		Flags:       flags,
+	}
+	if !flags.Changed("env-file") {
+		opts.EnvFiles = config.DefaultEnvFiles()
+	}
+	if *remote != "" {
+		source, err := config.ParseRemoteSource(*remote)
+		if err != nil {
+			log.Fatalf("Invalid --remote-config: %v", err)
+		}
+		opts.Remote = source
+	}
+	return opts, watchConfig, validateConfig
+}
+
+// longFlags rewrites single-dash flags such as -env=testing to their
+// double-dash form, which earlier releases accepted
+func longFlags(args []string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			copy(out[i:], args[i:])
+			break
+		}
+		if len(arg) > 2 && arg[0] == '-' && arg[1] != '-' {
+			arg = "-" + arg
+		}
+		out[i] = arg
+	}
+	return out
+}
+
+// runValidateConfig loads the configuration the server would start with,
+// prints it with secrets masked and returns the exit code
+func runValidateConfig(opts config.LoadOptions) int {
+	report, err := config.ValidateOptions(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	report.Print(os.Stdout)
+	if !report.Valid() {
+		return 1
+	}
+	return 0
+}
+
+// runMigrate implements the migrate subcommand and returns the exit code
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, database.MigrateUsage) }
+	configPath := flags.String("config", "", "Path to configuration file")
+	environment := flags.String("env", "", "Environment (development, testing, production)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg, err := config.LoadWithOptions(config.LoadOptions{
+		Environment: *environment,
+		ConfigPath:  *configPath,
+		EnvFiles:    config.DefaultEnvFiles(),
+	})
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+	logConfig := cfg.Log.LoggerConfig()
+	logConfig.Output = "stdout"
+	logger.InitializeWithConfig(logConfig)
+
+	conn, err := database.NewConnection(cfg.Database)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return 1
+	}
+	defer conn.Close()
+
+	if err := database.NewMigrator(conn.DB()).RunCommand(context.Background(), flags.Args(), os.Stdout); err != nil {
+		log.Printf("migrate: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// watchLevelSignals makes logging one level more verbose on SIGUSR1 and one
+// level less verbose on SIGUSR2, for ttl when it is positive
+func watchLevelSignals(ttl time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range signals {
+			steps := -1
+			if sig == syscall.SIGUSR2 {
+				steps = 1
+			}
+			o, err := logger.ShiftLevel(steps, ttl)
+			if err != nil {
+				log.Printf("Log level change on %s failed: %v", sig, err)
+				continue
+			}
+			if o.ExpiresAt.IsZero() {
+				log.Printf("Log level set to %s on %s", o.Level, sig)
+			} else {
+				log.Printf("Log level set to %s on %s until %s", o.Level, sig, o.ExpiresAt.Format(time.RFC3339))
+			}
+		}
+	}()
+}
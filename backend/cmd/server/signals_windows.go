@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+import "time"
+
+// watchLevelSignals does nothing; Windows has no SIGUSR1 or SIGUSR2
+func watchLevelSignals(time.Duration) {}
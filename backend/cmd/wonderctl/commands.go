@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/health"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func newCreateUserCommand(opts *globalOptions) *cobra.Command {
+	var email, name, password string
+	cmd := &cobra.Command{
+		Use:   "create-user --email EMAIL --name NAME [--password PASSWORD]",
+		Short: "Register a new user",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if email == "" || name == "" {
+				return usageError{errors.New("--email and --name are required")}
+			}
+
+			secret, err := passwordOrStdin(cmd, password)
+			if err != nil {
+				return err
+			}
+
+			c, err := opts.openContainer(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			u, err := c.UserService().Register(cmd.Context(), email, name, secret)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "created user %s (%s)\n", u.ID, u.Email)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "", "Email address of the new user")
+	cmd.Flags().StringVar(&name, "name", "", "Display name of the new user")
+	cmd.Flags().StringVar(&password, "password", "", "Password; read from stdin when omitted")
+	return cmd
+}
+
+func newResetPasswordCommand(opts *globalOptions) *cobra.Command {
+	var id, email, password string
+	cmd := &cobra.Command{
+		Use:   "reset-password (--id ID | --email EMAIL) [--password PASSWORD]",
+		Short: "Set a new password for a user",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (id == "") == (email == "") {
+				return usageError{errors.New("exactly one of --id and --email is required")}
+			}
+
+			secret, err := passwordOrStdin(cmd, password)
+			if err != nil {
+				return err
+			}
+
+			c, err := opts.openContainer(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			userID := id
+			if userID == "" {
+				u, err := findUserByEmail(cmd.Context(), c.UserService(), email)
+				if err != nil {
+					return err
+				}
+				userID = u.ID
+			}
+
+			if err := c.UserService().ResetPassword(cmd.Context(), userID, secret); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "password reset for user %s\n", userID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&id, "id", "", "ID of the user")
+	cmd.Flags().StringVar(&email, "email", "", "Email address of the user")
+	cmd.Flags().StringVar(&password, "password", "", "New password; read from stdin when omitted")
+	return cmd
+}
+
+func newListUsersCommand(opts *globalOptions) *cobra.Command {
+	var email, name string
+	var page, pageSize int
+	cmd := &cobra.Command{
+		Use:   "list-users [--email TEXT] [--name TEXT] [--page N] [--page-size N]",
+		Short: "List users, optionally filtered by email or name",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.openContainer(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			resp, err := c.UserService().ListUsers(cmd.Context(), &user.ListUsersRequest{
+				Page:     page,
+				PageSize: pageSize,
+				Email:    email,
+				Name:     name,
+			})
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tEMAIL\tNAME\tROLE\tCREATED")
+			for _, u := range resp.Users {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", u.ID, u.Email, u.Name, u.Role, u.CreatedAt.UTC().Format(time.RFC3339))
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "\npage %d of %d (%d users)\n", resp.Page, resp.TotalPages, resp.Total)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "", "Only users whose email contains this text")
+	cmd.Flags().StringVar(&name, "name", "", "Only users whose name contains this text")
+	cmd.Flags().IntVar(&page, "page", 1, "Page number")
+	cmd.Flags().IntVar(&pageSize, "page-size", 20, "Users per page (max 100)")
+	return cmd
+}
+
+func newValidateConfigCommand(opts *globalOptions) *cobra.Command {
+	var show bool
+	cmd := &cobra.Command{
+		Use:   "validate-config [--show]",
+		Short: "Load and validate the configuration",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := config.ValidateOptions(opts.loadOptions())
+			if err != nil {
+				return err
+			}
+
+			if show {
+				report.Print(cmd.OutOrStdout())
+			} else if !report.Valid() {
+				for _, err := range report.Errors {
+					fmt.Fprintf(cmd.ErrOrStderr(), "  - %v\n", err)
+				}
+			}
+			if !report.Valid() {
+				return fmt.Errorf("configuration is invalid (%d error(s))", len(report.Errors))
+			}
+
+			if !show {
+				source := report.Source
+				if source == "" {
+					source = "defaults and environment"
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "configuration is valid (loaded from %s)\n", source)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&show, "show", false, "Print the effective values with secrets masked")
+	return cmd
+}
+
+func newGenerateConfigCommand(opts *globalOptions) *cobra.Command {
+	var out string
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "generate-config [--out FILE] [--force]",
+		Short: "Write a configuration file with default values",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !force {
+				if _, err := os.Stat(out); err == nil {
+					return fmt.Errorf("%s already exists; use --force to overwrite it", out)
+				}
+			}
+
+			cfg := config.DefaultConfig()
+			if opts.environment != "" {
+				cfg.App.Environment = opts.environment
+			}
+			if err := config.WriteConfig(cfg, out); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "wrote default configuration to %s\n", out)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&out, "out", "config.yaml", "File to write; must end in .yaml or .yml")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing file")
+	return cmd
+}
+
+func newDiffConfigCommand(opts *globalOptions) *cobra.Command {
+	var dir string
+	var strict bool
+	cmd := &cobra.Command{
+		Use:   "diff-config [--dir DIR] [--strict] [ENV...]",
+		Short: "Compare the per-environment config files with the schema",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := config.DiffEnvironments(dir, args)
+			if err != nil {
+				return err
+			}
+			report.Print(cmd.OutOrStdout())
+			if report.Drifted(strict) {
+				return fmt.Errorf("config files have drifted from the schema")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "./configs", "Directory holding config.<env>.yaml")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Also fail when a key is set in some environments but not others")
+	return cmd
+}
+
+// newMigrateCommand is the only command that changes the schema. Without a
+// subcommand it applies every pending migration.
+func newMigrateCommand(opts *globalOptions) *cobra.Command {
+	// runMigrator runs one migrator command; the container is not used
+	// because it refuses to start on an outdated schema
+	runMigrator := func(cmd *cobra.Command, migrateArgs ...string) error {
+		cfg, err := opts.loadConfig()
+		if err != nil {
+			return err
+		}
+		logger.InitializeWithConfig(cfg.Log.LoggerConfig())
+
+		conn, err := database.NewConnection(cfg.Database)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		return database.NewMigrator(conn.DB()).RunCommand(cmd.Context(), migrateArgs, cmd.OutOrStdout())
+	}
+
+	cmd := &cobra.Command{
+		Use:     "migrate [up | down [N] | version | status | force VERSION]",
+		Aliases: []string{"run-migrations"},
+		Short:   "Apply or inspect database schema migrations",
+		Args:    noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrator(cmd, "up")
+		},
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply all pending migrations",
+			Args:  noArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrator(cmd, "up")
+			},
+		},
+		&cobra.Command{
+			Use:   "down [N]",
+			Short: "Roll back the last N migrations (default 1)",
+			Args:  maxArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrator(cmd, append([]string{"down"}, args...)...)
+			},
+		},
+		&cobra.Command{
+			Use:   "version",
+			Short: "Print the applied version and dirty state",
+			Args:  noArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrator(cmd, "version")
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "List migrations and whether each is applied",
+			Args:  noArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrator(cmd, "status")
+			},
+		},
+		&cobra.Command{
+			Use:   "force VERSION",
+			Short: "Record VERSION as applied and clear the dirty flag",
+			Args:  exactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrator(cmd, "force", args[0])
+			},
+		},
+	)
+	return cmd
+}
+
+func newSeedCommand(opts *globalOptions) *cobra.Command {
+	var dir, password string
+	var generate int
+	var randomSeed int64
+	cmd := &cobra.Command{
+		Use:   "seed [--dir DIR] | --generate N [--random-seed N] [--password PASSWORD]",
+		Short: "Load the environment's seed data or generate fake users",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			out := cmd.OutOrStdout()
+			c, err := opts.openContainer(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			seeder := database.NewSeeder(c.Database().DB(), c.UserTransferService())
+			report := &database.SeedReport{}
+			if generate > 0 {
+				err = seeder.SeedUsers(ctx, database.GenerateUsers(generate, randomSeed, password), report)
+			} else {
+				env := c.Config().App.Environment
+				report, err = seeder.Run(ctx, os.DirFS(dir), env)
+				if report != nil {
+					for _, file := range report.Files {
+						fmt.Fprintf(out, "applied %s\n", file)
+					}
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "%d SQL statement(s); users: %d created, %d already present, %d invalid\n",
+				report.Statements, report.Users.Created, report.Users.Duplicates, report.Users.Invalid)
+			for _, rowErr := range report.Users.Errors {
+				if len(rowErr.Fields) > 0 && rowErr.Fields[0].Code != wonderErrors.CodeDuplicateEntry {
+					fmt.Fprintf(cmd.ErrOrStderr(), "  - row %d (%s): %s\n", rowErr.Row, rowErr.Email, rowErr.Fields[0].Message)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "./seeds", "Directory with common/ and one subdirectory per environment")
+	cmd.Flags().IntVar(&generate, "generate", 0, "Create N fake users instead of loading seed files")
+	cmd.Flags().Int64Var(&randomSeed, "random-seed", 1, "Seed for --generate; the same seed gives the same users")
+	cmd.Flags().StringVar(&password, "password", "", "Password of generated users (default "+database.DefaultSeedPassword+")")
+	return cmd
+}
+
+func newCheckHealthCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "check-health",
+		Short: "Run the readiness checks against configured dependencies",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.openContainer(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			report := c.Health().Check(cmd.Context())
+
+			names := make([]string, 0, len(report.Checks))
+			for name := range report.Checks {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			out := cmd.OutOrStdout()
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			for _, name := range names {
+				result := report.Checks[name]
+				fmt.Fprintf(w, "%s\t%s\t%.1fms\t%s\n", name, result.Status, result.LatencyMS, result.Error)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "overall: %s\n", report.Status)
+
+			if report.Status == health.StatusDown {
+				return fmt.Errorf("critical dependencies are down")
+			}
+			return nil
+		},
+	}
+}
+
+// maxArgs accepts at most n positional arguments
+func maxArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) > n {
+			return usageError{fmt.Errorf("accepts at most %d argument(s), got %d", n, len(args))}
+		}
+		return nil
+	}
+}
+
+// exactArgs accepts exactly n positional arguments
+func exactArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != n {
+			return usageError{fmt.Errorf("accepts %d argument(s), got %d", n, len(args))}
+		}
+		return nil
+	}
+}
+
+// passwordOrStdin returns password, or reads it from the first line of
+// stdin so it does not end up in shell history
+func passwordOrStdin(cmd *cobra.Command, password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(cmd.ErrOrStderr(), "Password: ")
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password from stdin: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// findUserByEmail resolves an exact email match through the list filter,
+// which matches substrings
+func findUserByEmail(ctx context.Context, users user.UserService, email string) (*user.User, error) {
+	req := &user.ListUsersRequest{Page: 1, PageSize: 100, Email: email}
+	for {
+		resp, err := users.ListUsers(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range resp.Users {
+			if strings.EqualFold(u.Email, email) {
+				return u, nil
+			}
+		}
+		if resp.NextCursor == "" {
+			return nil, fmt.Errorf("no user with email %s", email)
+		}
+		req.Cursor = resp.NextCursor
+	}
+}
@@ -0,0 +1,135 @@
+// Command wonderctl administers a wonder deployment directly against its
+// database and dependencies, without going through the HTTP API.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cctw-zed/wonder/internal/container"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+)
+
+// globalOptions select the configuration every subcommand works against
+type globalOptions struct {
+	configPath  string
+	environment string
+}
+
+// usageError reports invalid arguments; the command's usage is printed
+// with it
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+
+func (e usageError) Unwrap() error { return e.err }
+
+func main() {
+	// Keep logs out of command output unless the operator chose otherwise
+	if _, ok := os.LookupEnv("LOG_OUTPUT"); !ok {
+		os.Setenv("LOG_OUTPUT", "stderr")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the exit status: 2 for
+// invalid usage, 1 when the command failed
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	root := newRootCommand()
+	root.SetArgs(args)
+	root.SetIn(stdin)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+
+	cmd, err := root.ExecuteContextC(ctx)
+	if err == nil {
+		return 0
+	}
+
+	var usage usageError
+	if errors.As(err, &usage) {
+		fmt.Fprintf(stderr, "%s: %v\n\n%s", cmd.CommandPath(), err, cmd.UsageString())
+		return 2
+	}
+	fmt.Fprintf(stderr, "%s: %v\n", cmd.CommandPath(), err)
+	return 1
+}
+
+// newRootCommand builds the command tree
+func newRootCommand() *cobra.Command {
+	opts := &globalOptions{}
+	root := &cobra.Command{
+		Use:   "wonderctl",
+		Short: "Administer a wonder deployment",
+		Long: "wonderctl runs operations against the configured database and dependencies\n" +
+			"using the same wiring as the server. Only the migrate command changes the\n" +
+			"schema; the others refuse to run against an outdated one.",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		Args:          noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return usageError{errors.New("missing command")}
+		},
+	}
+	root.CompletionOptions.DisableDefaultCmd = true
+	root.PersistentFlags().StringVar(&opts.configPath, "config", "", "Path to configuration file")
+	root.PersistentFlags().StringVar(&opts.environment, "env", "", "Environment (development, testing, production)")
+	root.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError{err}
+	})
+
+	root.AddCommand(
+		newCreateUserCommand(opts),
+		newResetPasswordCommand(opts),
+		newListUsersCommand(opts),
+		newValidateConfigCommand(opts),
+		newGenerateConfigCommand(opts),
+		newDiffConfigCommand(opts),
+		newMigrateCommand(opts),
+		newSeedCommand(opts),
+		newCheckHealthCommand(opts),
+	)
+	return root
+}
+
+// noArgs rejects positional arguments, naming them
+func noArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return usageError{fmt.Errorf("unknown command or argument %q", args[0])}
+	}
+	return nil
+}
+
+// loadOptions selects configuration sources the same way the server does
+func (o *globalOptions) loadOptions() config.LoadOptions {
+	return config.LoadOptions{
+		Environment: o.environment,
+		ConfigPath:  o.configPath,
+		EnvFiles:    config.DefaultEnvFiles(),
+	}
+}
+
+// loadConfig loads configuration the same way the server does
+func (o *globalOptions) loadConfig() (*config.Config, error) {
+	return config.LoadWithOptions(o.loadOptions())
+}
+
+// openContainer wires the application as the server does, except that it
+// never migrates: an outdated schema is an error until migrate is run.
+// Callers must Close the container.
+func (o *globalOptions) openContainer(ctx context.Context) (*container.Container, error) {
+	return container.New(ctx, container.WithLoadOptions(o.loadOptions()), container.WithoutAutoMigrate())
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+)
+
+// runCommand runs wonderctl with args and returns its exit status and
+// output
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no command", nil, "missing command"},
+		{"unknown command", []string{"frobnicate"}, `unknown command or argument "frobnicate"`},
+		{"unknown flag", []string{"list-users", "--colour"}, "unknown flag: --colour"},
+		{"bad flag value", []string{"list-users", "--page", "two"}, `invalid argument "two"`},
+		{"create-user without a name", []string{"create-user", "--email", "ada@example.com"}, "--email and --name are required"},
+		{"reset-password without a user", []string{"reset-password"}, "exactly one of --id and --email is required"},
+		{"reset-password with two users", []string{"reset-password", "--id", "1", "--email", "ada@example.com"}, "exactly one of --id and --email is required"},
+		{"stray argument", []string{"check-health", "now"}, `unknown command or argument "now"`},
+		{"migrate down twice", []string{"migrate", "down", "1", "2"}, "accepts at most 1 argument(s), got 2"},
+		{"migrate force without a version", []string{"migrate", "force"}, "accepts 1 argument(s), got 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(t, "", tt.args...)
+			assert.Equal(t, 2, code)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, tt.want)
+			assert.Contains(t, stderr, "Usage:")
+		})
+	}
+}
+
+func TestRun_Help(t *testing.T) {
+	code, stdout, _ := runCommand(t, "", "--help")
+	assert.Equal(t, 0, code)
+	for _, name := range []string{"create-user", "list-users", "migrate", "seed", "check-health"} {
+		assert.Contains(t, stdout, name)
+	}
+}
+
+// TestRun_SQLite runs commands against a SQLite database configured to
+// migrate automatically, which only the migrate command may do
+func TestRun_SQLite(t *testing.T) {
+	t.Setenv("LOG_OUTPUT", "stderr")
+	t.Setenv("LOG_LEVEL", "error")
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.App.Environment = "testing"
+	cfg.Database.Driver = config.DriverSQLite
+	cfg.Database.Database = filepath.Join(dir, "wonder.db")
+	cfg.Database.AutoMigrate = true
+	cfg.JWT.SigningKey = "wonderctl-test-signing-key-0123456789"
+	configPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, config.WriteConfig(cfg, configPath))
+
+	code, _, stderr := runCommand(t, "", "--config", configPath, "list-users")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "database schema check failed", "other commands do not migrate")
+
+	code, stdout, stderr := runCommand(t, "", "--config", configPath, "migrate", "version")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "version 0 ")
+
+	code, _, stderr = runCommand(t, "", "--config", configPath, "migrate")
+	require.Equal(t, 0, code, stderr)
+
+	code, stdout, stderr = runCommand(t, "Str0ng!Passw0rd\n", "--config", configPath,
+		"create-user", "--email", "ada@example.com", "--name", "Ada Lovelace")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "(ada@example.com)")
+	assert.Contains(t, stderr, "Password: ", "the password is read from stdin")
+
+	code, stdout, stderr = runCommand(t, "", "--config", configPath, "list-users", "--email", "ada@")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Ada Lovelace")
+	assert.Contains(t, stdout, "(1 users)")
+
+	code, stdout, stderr = runCommand(t, "", "--config", configPath, "reset-password", "--email", "ada@example.com", "--password", "An0ther!Passw0rd")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "password reset for user ")
+
+	code, _, stderr = runCommand(t, "", "--config", configPath, "reset-password", "--email", "nobody@example.com", "--password", "An0ther!Passw0rd")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "wonderctl reset-password: no user with email nobody@example.com")
+}
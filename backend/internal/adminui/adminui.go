@@ -0,0 +1,22 @@
+// Package adminui embeds the built management UI served under
+// server.admin_ui.path. `make admin-ui` exports the frontend into dist;
+// the committed dist holds a placeholder page until then.
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// FS returns the UI's files with index.html at the root
+func FS() fs.FS {
+	files, err := fs.Sub(dist, "dist")
+	if err != nil {
+		// dist is embedded, so this cannot fail
+		panic(err)
+	}
+	return files
+}
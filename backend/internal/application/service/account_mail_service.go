@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+)
+
+// Account email templates
+const (
+	MailWelcome       = "welcome"
+	MailVerification  = "verification"
+	MailPasswordReset = "password_reset"
+
+	MailDeletionScheduled = "deletion_scheduled"
+	MailDeletionCancelled = "deletion_cancelled"
+	MailAccountDeleted    = "account_deleted"
+
+	MailDataExportReady = "data_export_ready"
+
+	MailNotification = "notification"
+
+	MailInvitation = "invitation"
+)
+
+// AccountMailService sends the emails of the account lifecycle
+type AccountMailService interface {
+	// SendWelcome greets a newly registered user
+	SendWelcome(ctx context.Context, email, name string) error
+	// SendVerification asks the user to confirm their address by opening link
+	SendVerification(ctx context.Context, email, name, link string) error
+	// SendPasswordReset tells the user an administrator set a new password
+	SendPasswordReset(ctx context.Context, email, name string) error
+	// SendDeletionScheduled tells the user their account will be deleted at
+	// deleteAt unless they cancel. prefs are the user's, to show deleteAt in.
+	SendDeletionScheduled(ctx context.Context, email, name string, deleteAt time.Time, prefs user.LocalePrefs) error
+	// SendDeletionCancelled confirms that a scheduled deletion was cancelled
+	SendDeletionCancelled(ctx context.Context, email, name string) error
+	// SendAccountDeleted confirms that a scheduled deletion took effect
+	SendAccountDeleted(ctx context.Context, email, name string) error
+	// SendDataExportReady tells the user their data export can be
+	// downloaded until expiresAt, shown in the user's prefs
+	SendDataExportReady(ctx context.Context, email, name string, expiresAt time.Time, prefs user.LocalePrefs) error
+	// SendNotification emails a notification already rendered in the
+	// user's locale
+	SendNotification(ctx context.Context, email, name, title, body string) error
+	// SendInvitation asks email to join organization by opening link. name
+	// is the invitee's if they have an account and may be empty.
+	SendInvitation(ctx context.Context, email, name, inviter, organization, link string) error
+}
+
+// accountMail is the data the account email templates are rendered with
+type accountMail struct {
+	Name   string
+	Email  string
+	AppURL string
+	Link   string
+	// DeleteOn is when a scheduled deletion takes effect or an export
+	// expires, in the reader's time zone and locale
+	DeleteOn string
+	// Title and Body are the text of a notification
+	Title string
+	Body  string
+	// Inviter and Organization are who invited the reader to which
+	// organization
+	Inviter      string
+	Organization string
+}
+
+// AccountMailServiceOption configures optional account mail service
+// collaborators
+type AccountMailServiceOption func(*accountMailService)
+
+// WithMailBundle formats times in emails with the date layouts of bundle
+// instead of the process-wide bundle
+func WithMailBundle(bundle *i18n.Bundle) AccountMailServiceOption {
+	return func(s *accountMailService) {
+		s.bundle = bundle
+	}
+}
+
+type accountMailService struct {
+	mailer   mailer.Mailer
+	renderer *mailer.Renderer
+	appURL   string
+	bundle   *i18n.Bundle
+	log      logger.Logger
+}
+
+// NewAccountMailService creates a new account mail service. appURL is the
+// public URL of the application linked from the emails and may be empty.
+func NewAccountMailService(m mailer.Mailer, renderer *mailer.Renderer, appURL string, opts ...AccountMailServiceOption) AccountMailService {
+	return NewAccountMailServiceWithLogger(m, renderer, appURL, logger.Get().WithLayer("application").WithComponent("account_mail_service"), opts...)
+}
+
+func NewAccountMailServiceWithLogger(m mailer.Mailer, renderer *mailer.Renderer, appURL string, log logger.Logger, opts ...AccountMailServiceOption) AccountMailService {
+	if m == nil {
+		panic("mailer cannot be nil")
+	}
+	if renderer == nil {
+		panic("mail renderer cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	s := &accountMailService{
+		mailer:   m,
+		renderer: renderer,
+		appURL:   appURL,
+		bundle:   i18n.Get(),
+		log:      log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *accountMailService) SendWelcome(ctx context.Context, email, name string) error {
+	return s.send(ctx, MailWelcome, accountMail{Name: name, Email: email, AppURL: s.appURL})
+}
+
+func (s *accountMailService) SendVerification(ctx context.Context, email, name, link string) error {
+	return s.send(ctx, MailVerification, accountMail{Name: name, Email: email, AppURL: s.appURL, Link: link})
+}
+
+func (s *accountMailService) SendPasswordReset(ctx context.Context, email, name string) error {
+	return s.send(ctx, MailPasswordReset, accountMail{Name: name, Email: email, AppURL: s.appURL})
+}
+
+func (s *accountMailService) SendDeletionScheduled(ctx context.Context, email, name string, deleteAt time.Time, prefs user.LocalePrefs) error {
+	return s.send(ctx, MailDeletionScheduled, accountMail{
+		Name:     name,
+		Email:    email,
+		AppURL:   s.appURL,
+		DeleteOn: formatMailTime(s.bundle, deleteAt, prefs),
+	})
+}
+
+func (s *accountMailService) SendDeletionCancelled(ctx context.Context, email, name string) error {
+	return s.send(ctx, MailDeletionCancelled, accountMail{Name: name, Email: email, AppURL: s.appURL})
+}
+
+func (s *accountMailService) SendAccountDeleted(ctx context.Context, email, name string) error {
+	return s.send(ctx, MailAccountDeleted, accountMail{Name: name, Email: email, AppURL: s.appURL})
+}
+
+func (s *accountMailService) SendDataExportReady(ctx context.Context, email, name string, expiresAt time.Time, prefs user.LocalePrefs) error {
+	return s.send(ctx, MailDataExportReady, accountMail{
+		Name:     name,
+		Email:    email,
+		AppURL:   s.appURL,
+		DeleteOn: formatMailTime(s.bundle, expiresAt, prefs),
+	})
+}
+
+func (s *accountMailService) SendNotification(ctx context.Context, email, name, title, body string) error {
+	return s.send(ctx, MailNotification, accountMail{Name: name, Email: email, AppURL: s.appURL, Title: title, Body: body})
+}
+
+func (s *accountMailService) SendInvitation(ctx context.Context, email, name, inviter, organization, link string) error {
+	return s.send(ctx, MailInvitation, accountMail{
+		Name:         name,
+		Email:        email,
+		AppURL:       s.appURL,
+		Link:         link,
+		Inviter:      inviter,
+		Organization: organization,
+	})
+}
+
+// formatMailTime formats t in the reader's time zone with the date layout
+// of the bundle language closest to their locale
+func formatMailTime(bundle *i18n.Bundle, t time.Time, prefs user.LocalePrefs) string {
+	layout := bundle.Translate(bundle.Negotiate(prefs.Locale), "format.datetime", nil)
+	return t.In(prefs.Location()).Format(layout)
+}
+
+func (s *accountMailService) send(ctx context.Context, template string, data accountMail) error {
+	msg, err := s.renderer.Render(template, data.Email, data)
+	if err != nil {
+		s.log.Error(ctx, "failed to render email", "error", err, "template", template)
+		return err
+	}
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		s.log.Error(ctx, "failed to send email", "error", err, "template", template, "to", data.Email)
+		return err
+	}
+
+	s.log.Info(ctx, "email sent", "template", template, "to", data.Email)
+	return nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+)
+
+type recordingMailer struct {
+	sent []*mailer.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg *mailer.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestAccountMailService(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	renderer, err := mailer.NewRenderer(mailer.Templates)
+	require.NoError(t, err)
+	sender := &recordingMailer{}
+	svc := NewAccountMailService(sender, renderer, "https://wonder.example.com")
+
+	require.NoError(t, svc.SendWelcome(ctx, "ada@example.com", "Ada"))
+	require.NoError(t, svc.SendVerification(ctx, "ada@example.com", "Ada", "https://wonder.example.com/verify?token=t"))
+	require.NoError(t, svc.SendPasswordReset(ctx, "ada@example.com", "Ada"))
+	require.NoError(t, svc.SendDeletionScheduled(ctx, "ada@example.com", "Ada", time.Date(2026, 11, 15, 4, 0, 0, 0, time.UTC), user.LocalePrefs{}))
+	require.NoError(t, svc.SendDataExportReady(ctx, "ada@example.com", "Ada", time.Date(2026, 11, 15, 4, 0, 0, 0, time.UTC),
+		user.LocalePrefs{Timezone: "Asia/Shanghai", Locale: "zh-CN"}))
+	require.NoError(t, svc.SendDataExportReady(ctx, "ada@example.com", "Ada", time.Date(2026, 11, 15, 4, 0, 0, 0, time.UTC),
+		user.LocalePrefs{Timezone: "America/New_York", Locale: "fr"}))
+
+	require.Len(t, sender.sent, 6)
+	assert.Equal(t, "ada@example.com", sender.sent[0].To)
+	assert.Equal(t, "Welcome to Wonder, Ada", sender.sent[0].Subject)
+	assert.Contains(t, sender.sent[0].Text, "https://wonder.example.com")
+	assert.Contains(t, sender.sent[1].HTML, `href="https://wonder.example.com/verify?token=t"`)
+	assert.Equal(t, "Your Wonder password was reset", sender.sent[2].Subject)
+	assert.Contains(t, sender.sent[3].Text, "deleted permanently on 15 November 2026 04:00 UTC")
+	// Times are shown in the reader's time zone, with their locale's layout
+	// or English when there is no catalog for it
+	assert.Contains(t, sender.sent[4].Text, "2026年11月15日 12:00 CST")
+	assert.Contains(t, sender.sent[5].Text, "14 November 2026 23:00 EST")
+
+	sender.err = assert.AnError
+	assert.ErrorIs(t, svc.SendWelcome(ctx, "ada@example.com", "Ada"), assert.AnError)
+
+	// Layouts come from the injected bundle, not the process-wide one
+	english, err := i18n.New(nil)
+	require.NoError(t, err)
+	sender = &recordingMailer{}
+	svc = NewAccountMailService(sender, renderer, "", WithMailBundle(english))
+	require.NoError(t, svc.SendDataExportReady(ctx, "ada@example.com", "Ada", time.Date(2026, 11, 15, 4, 0, 0, 0, time.UTC),
+		user.LocalePrefs{Timezone: "Asia/Shanghai", Locale: "zh-CN"}))
+	require.Len(t, sender.sent, 1)
+	assert.Contains(t, sender.sent[0].Text, "15 November 2026 12:00 CST")
+}
+
+func TestNewAccountMailService_PanicsOnNilDependencies(t *testing.T) {
+	renderer, err := mailer.NewRenderer(mailer.Templates)
+	require.NoError(t, err)
+
+	assert.Panics(t, func() { NewAccountMailService(nil, renderer, "") })
+	assert.Panics(t, func() { NewAccountMailService(mailer.Nop{}, nil, "") })
+}
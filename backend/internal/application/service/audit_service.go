@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// AuditService queries the audit log
+type AuditService interface {
+	// ListAuditLogs returns audit entries matching the request, newest first
+	ListAuditLogs(ctx context.Context, req *audit.ListRequest) (*audit.ListResponse, error)
+}
+
+type auditService struct {
+	repo audit.Repository
+	log  logger.Logger
+}
+
+// NewAuditService creates a new audit query service
+func NewAuditService(repo audit.Repository) AuditService {
+	return NewAuditServiceWithLogger(repo, logger.Get().WithLayer("application").WithComponent("audit_service"))
+}
+
+func NewAuditServiceWithLogger(repo audit.Repository, log logger.Logger) AuditService {
+	if repo == nil {
+		panic("audit repository cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &auditService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (s *auditService) ListAuditLogs(ctx context.Context, req *audit.ListRequest) (*audit.ListResponse, error) {
+	if req == nil {
+		return nil, errors.NewRequiredFieldError("request", "nil")
+	}
+	if !req.From.IsZero() && !req.To.IsZero() && req.To.Before(req.From) {
+		return nil, errors.NewInvalidValueError("to", req.To, "must not be before from")
+	}
+
+	response, err := s.repo.List(ctx, req)
+	if err != nil {
+		s.log.Error(ctx, "failed to list audit logs", "error", err)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "audit logs listed successfully", "total", response.Total, "page", response.Page, "returned", len(response.Entries))
+	return response, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
 	"github.com/cctw-zed/wonder/internal/domain/user"
 	"github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/jwt"
@@ -12,7 +13,22 @@ import (
 
 // AuthService provides authentication functionality
 type AuthService interface {
+	// Login checks the password. When the user must use a second factor
+	// the response carries an MFA token instead of an access token, unless
+	// ctx carries the token of a device the user trusted (see
+	// WithDeviceToken).
 	Login(ctx context.Context, email, password string) (*LoginResponse, error)
+	// VerifyMFA exchanges an MFA token and a two-factor code for an
+	// access token. With remember set the device is trusted and the
+	// response carries its device token.
+	VerifyMFA(ctx context.Context, mfaToken, code string, remember *DeviceInfo) (*LoginResponse, error)
+	// BeginMFAEnrollment starts enrolling an authenticator for a user who
+	// must enroll before their login completes
+	BeginMFAEnrollment(ctx context.Context, mfaToken string) (*MFASetup, error)
+	// CompleteMFAEnrollment confirms the authenticator and completes the
+	// login; the response carries the recovery codes. remember works as
+	// for VerifyMFA.
+	CompleteMFAEnrollment(ctx context.Context, mfaToken, code string, remember *DeviceInfo) (*LoginResponse, error)
 	Logout(ctx context.Context, token string) error
 	ValidateToken(ctx context.Context, token string) (*jwt.Claims, error)
 }
@@ -23,20 +39,61 @@ type LoginResponse struct {
 	AccessToken string     `json:"access_token"`
 	TokenType   string     `json:"token_type"`
 	ExpiresIn   int64      `json:"expires_in"`
+	// MFARequired means the password was right but a two-factor code is
+	// needed; MFAToken carries the login to the second step
+	MFARequired bool `json:"mfa_required,omitempty"`
+	// MFAEnrollmentRequired means the user must enroll an authenticator
+	// with MFAToken before the login completes
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string `json:"mfa_token,omitempty"`
+	// RecoveryCodes are shown once, when enrollment completes a login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// DeviceToken lets this device skip the second step of later logins
+	DeviceToken string `json:"device_token,omitempty"`
+}
+
+type deviceTokenKey struct{}
+
+// WithDeviceToken returns a context carrying the trusted device token a
+// login was made with
+func WithDeviceToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, deviceTokenKey{}, token)
+}
+
+func deviceToken(ctx context.Context) string {
+	token, _ := ctx.Value(deviceTokenKey{}).(string)
+	return token
+}
+
+// AuthServiceOption configures optional auth service collaborators
+type AuthServiceOption func(*authService)
+
+// WithMFA adds the two-factor step to logins. MFA tokens can be exchanged
+// for an access token for tokenTTL.
+func WithMFA(mfaService MFAService, tokenTTL time.Duration) AuthServiceOption {
+	return func(s *authService) {
+		s.mfa = mfaService
+		s.mfaTokenTTL = tokenTTL
+	}
 }
 
 type authService struct {
 	userService  user.UserService
 	tokenService jwt.TokenService
+	mfa          MFAService
+	mfaTokenTTL  time.Duration
 	log          logger.Logger
 }
 
 // NewAuthService creates a new authentication service
-func NewAuthService(userService user.UserService, tokenService jwt.TokenService) AuthService {
-	return NewAuthServiceWithLogger(userService, tokenService, logger.Get().WithLayer("application").WithComponent("auth_service"))
+func NewAuthService(userService user.UserService, tokenService jwt.TokenService, opts ...AuthServiceOption) AuthService {
+	return NewAuthServiceWithLogger(userService, tokenService, logger.Get().WithLayer("application").WithComponent("auth_service"), opts...)
 }
 
-func NewAuthServiceWithLogger(userService user.UserService, tokenService jwt.TokenService, log logger.Logger) AuthService {
+func NewAuthServiceWithLogger(userService user.UserService, tokenService jwt.TokenService, log logger.Logger, opts ...AuthServiceOption) AuthService {
 	if userService == nil {
 		panic("user service cannot be nil")
 	}
@@ -47,11 +104,16 @@ func NewAuthServiceWithLogger(userService user.UserService, tokenService jwt.Tok
 		panic("logger cannot be nil")
 	}
 
-	return &authService{
+	s := &authService{
 		userService:  userService,
 		tokenService: tokenService,
 		log:          log,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Login authenticates user and returns access token
@@ -65,14 +127,123 @@ func (s *authService) Login(ctx context.Context, email, password string) (*Login
 		return nil, err
 	}
 
-	// Generate access token
-	accessToken, err := s.tokenService.GenerateToken(u.ID)
+	if s.mfa != nil {
+		enabled, err := s.mfa.Enabled(ctx, u.ID)
+		if err != nil {
+			s.log.Error(ctx, "failed to check two-factor enrollment", "error", err, "user_id", u.ID)
+			return nil, err
+		}
+		if enabled && s.mfa.DeviceTrusted(ctx, u.ID, deviceToken(ctx)) {
+			s.log.Info(ctx, "login successful", "user_id", u.ID, "trusted_device", true)
+			return s.issue(ctx, u)
+		}
+		if enabled || s.mfa.EnrollmentRequired(u) {
+			mfaToken, err := s.tokenService.GeneratePurposeToken(u.ID, tokenTenant(u), jwt.PurposeMFA, s.mfaTokenTTL)
+			if err != nil {
+				s.log.Error(ctx, "failed to generate mfa token", "error", err, "user_id", u.ID)
+				return nil, err
+			}
+			s.log.Info(ctx, "login awaiting second factor", "user_id", u.ID, "enrolled", enabled)
+			return &LoginResponse{
+				MFARequired:           enabled,
+				MFAEnrollmentRequired: !enabled,
+				MFAToken:              mfaToken,
+				ExpiresIn:             int64(s.mfaTokenTTL.Seconds()),
+			}, nil
+		}
+	}
+
+	s.log.Info(ctx, "login successful", "user_id", u.ID, "email", email)
+	return s.issue(ctx, u)
+}
+
+// VerifyMFA completes a login with a two-factor code
+func (s *authService) VerifyMFA(ctx context.Context, mfaToken, code string, remember *DeviceInfo) (*LoginResponse, error) {
+	u, err := s.pendingLogin(ctx, mfaToken)
 	if err != nil {
-		s.log.Error(ctx, "failed to generate access token", "error", err, "user_id", u.ID)
+		return nil, err
+	}
+	if err := s.mfa.Verify(ctx, u.ID, code); err != nil {
+		s.log.Warn(ctx, "two-factor verification failed", "error", err, "user_id", u.ID)
 		return nil, err
 	}
 
-	s.log.Info(ctx, "login successful", "user_id", u.ID, "email", email)
+	s.log.Info(ctx, "login successful", "user_id", u.ID, "mfa", true)
+	return s.issueTrusted(ctx, u, remember)
+}
+
+// BeginMFAEnrollment starts enrolling the authenticator of a pending login
+func (s *authService) BeginMFAEnrollment(ctx context.Context, mfaToken string) (*MFASetup, error) {
+	u, err := s.pendingLogin(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.mfa.BeginEnrollment(ctx, u.ID)
+}
+
+// CompleteMFAEnrollment confirms the authenticator of a pending login and
+// issues its access token
+func (s *authService) CompleteMFAEnrollment(ctx context.Context, mfaToken, code string, remember *DeviceInfo) (*LoginResponse, error) {
+	u, err := s.pendingLogin(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes, err := s.mfa.ConfirmEnrollment(ctx, u.ID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "login successful", "user_id", u.ID, "mfa", true)
+	resp, err := s.issueTrusted(ctx, u, remember)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+	return resp, nil
+}
+
+// pendingLogin returns the user of a login waiting for its second factor
+func (s *authService) pendingLogin(ctx context.Context, mfaToken string) (*user.User, error) {
+	if s.mfa == nil {
+		return nil, errors.NewBusinessLogicError("mfa_login", "two-factor authentication is not enabled")
+	}
+	if mfaToken == "" {
+		return nil, errors.NewRequiredFieldError("mfa_token", mfaToken)
+	}
+
+	claims, err := s.tokenService.ValidatePurposeToken(mfaToken, jwt.PurposeMFA)
+	if err != nil {
+		s.log.Warn(ctx, "invalid mfa token", "error", err)
+		return nil, err
+	}
+	// Like access tokens, MFA tokens are only good in the tenant they were
+	// issued in
+	claimTenant := claims.TenantID
+	if claimTenant == "" {
+		claimTenant = tenant.DefaultID
+	}
+	if claimTenant != tenant.IDFromContext(ctx) {
+		return nil, errors.NewUnauthorizedError("mfa_login", "", "token was not issued for this tenant")
+	}
+
+	u, err := s.userService.GetProfile(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// The account may have been suspended since the password step
+	if !u.IsActive() {
+		return nil, errors.NewAccountInactiveError(u.ID, u.CurrentStatus())
+	}
+	return u, nil
+}
+
+// issue generates the access token of a completed login
+func (s *authService) issue(ctx context.Context, u *user.User) (*LoginResponse, error) {
+	accessToken, err := s.tokenService.GenerateTokenForTenant(u.ID, tokenTenant(u))
+	if err != nil {
+		s.log.Error(ctx, "failed to generate access token", "error", err, "user_id", u.ID)
+		return nil, err
+	}
 
 	return &LoginResponse{
 		User:        u,
@@ -82,6 +253,33 @@ func (s *authService) Login(ctx context.Context, email, password string) (*Login
 	}, nil
 }
 
+// issueTrusted issues the access token of a login that passed the second
+// step and, when asked to, trusts the device it came from
+func (s *authService) issueTrusted(ctx context.Context, u *user.User, remember *DeviceInfo) (*LoginResponse, error) {
+	resp, err := s.issue(ctx, u)
+	if err != nil || remember == nil {
+		return resp, err
+	}
+	// The code is already used up, so a device that cannot be remembered
+	// still gets its login
+	deviceToken, err := s.mfa.TrustDevice(ctx, u.ID, remember)
+	if err != nil {
+		s.log.Warn(ctx, "failed to trust device", "error", err, "user_id", u.ID)
+		return resp, nil
+	}
+	resp.DeviceToken = deviceToken
+	return resp, nil
+}
+
+// tokenTenant returns the tenant claim of u's tokens; default tenant tokens
+// carry none
+func tokenTenant(u *user.User) string {
+	if u.TenantID == tenant.DefaultID {
+		return ""
+	}
+	return u.TenantID
+}
+
 // Logout invalidates the access token
 func (s *authService) Logout(ctx context.Context, token string) error {
 	s.log.Info(ctx, "processing logout request")
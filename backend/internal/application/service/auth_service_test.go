@@ -10,8 +10,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
+	"github.com/cctw-zed/wonder/internal/domain/mfa"
+	mfaMocks "github.com/cctw-zed/wonder/internal/domain/mfa/mocks"
 	"github.com/cctw-zed/wonder/internal/domain/user"
 	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
 	apperrors "github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/jwt"
 	"github.com/cctw-zed/wonder/pkg/logger"
@@ -355,3 +358,102 @@ func TestAuthService_TokenExpiry(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid token")
 	assert.Nil(t, claims)
 }
+
+func TestAuthService_Login_MFA(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	tokenService := jwt.NewTokenService("test-signing-key-32-chars-minimum", 24*time.Hour)
+	member := &user.User{ID: "user-1", Email: "ada@example.com", Role: user.RoleUser, TenantID: "default"}
+	admin := &user.User{ID: "admin-1", Email: "root@example.com", Role: user.RoleAdmin, TenantID: "default"}
+
+	newServices := func(t *testing.T) (AuthService, *mocks.MockUserService, *mfaMocks.MockRepository) {
+		ctrl := gomock.NewController(t)
+		userService := mocks.NewMockUserService(ctrl)
+		repo := mfaMocks.NewMockRepository(ctrl)
+		mfaService := NewMFAService(repo, mocks.NewMockUserRepository(ctrl), fake.NewIDGenerator(1), testMFAPolicy)
+		return NewAuthService(userService, tokenService, WithMFA(mfaService, 5*time.Minute)), userService, repo
+	}
+
+	t.Run("users without a second factor get an access token", func(t *testing.T) {
+		authService, userService, repo := newServices(t)
+		userService.EXPECT().Login(gomock.Any(), "ada@example.com", "password123").Return(member, nil)
+		repo.EXPECT().Get(gomock.Any(), "user-1").Return(nil, nil)
+
+		resp, err := authService.Login(ctx, "ada@example.com", "password123")
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.AccessToken)
+		assert.False(t, resp.MFARequired)
+	})
+
+	t.Run("enrolled users exchange the mfa token with a code", func(t *testing.T) {
+		authService, userService, repo := newServices(t)
+		e := confirmedEnrollment()
+		userService.EXPECT().Login(gomock.Any(), "ada@example.com", "password123").Return(member, nil)
+		repo.EXPECT().Get(gomock.Any(), "user-1").Return(e, nil).Times(2)
+
+		resp, err := authService.Login(ctx, "ada@example.com", "password123")
+		require.NoError(t, err)
+		assert.True(t, resp.MFARequired)
+		assert.Empty(t, resp.AccessToken)
+		require.NotEmpty(t, resp.MFAToken)
+		_, err = tokenService.ValidateToken(resp.MFAToken)
+		assert.Error(t, err, "the mfa token is not an access token")
+
+		userService.EXPECT().GetProfile(gomock.Any(), "user-1").Return(member, nil)
+		repo.EXPECT().Update(gomock.Any(), e).Return(nil)
+		verified, err := authService.VerifyMFA(ctx, resp.MFAToken, currentCode(t, time.Now()), nil)
+		require.NoError(t, err)
+		claims, err := tokenService.ValidateToken(verified.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", claims.UserID)
+	})
+
+	t.Run("remembered devices skip the second step", func(t *testing.T) {
+		authService, userService, repo := newServices(t)
+		e := confirmedEnrollment()
+		userService.EXPECT().Login(gomock.Any(), "ada@example.com", "password123").Return(member, nil).Times(2)
+		repo.EXPECT().Get(gomock.Any(), "user-1").Return(e, nil).Times(3)
+		userService.EXPECT().GetProfile(gomock.Any(), "user-1").Return(member, nil)
+		repo.EXPECT().Update(gomock.Any(), e).Return(nil)
+		var device *mfa.TrustedDevice
+		repo.EXPECT().CreateDevice(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d *mfa.TrustedDevice) error {
+			device = d
+			return nil
+		})
+
+		resp, err := authService.Login(ctx, "ada@example.com", "password123")
+		require.NoError(t, err)
+		verified, err := authService.VerifyMFA(ctx, resp.MFAToken, currentCode(t, time.Now()), &DeviceInfo{Name: "Laptop"})
+		require.NoError(t, err)
+		require.NotEmpty(t, verified.DeviceToken)
+
+		repo.EXPECT().GetDeviceByTokenHash(gomock.Any(), mfa.HashDeviceToken(verified.DeviceToken)).Return(device, nil)
+		repo.EXPECT().TouchDevice(gomock.Any(), device.ID, gomock.Any()).Return(nil)
+		resp, err = authService.Login(WithDeviceToken(ctx, verified.DeviceToken), "ada@example.com", "password123")
+		require.NoError(t, err)
+		assert.False(t, resp.MFARequired)
+		assert.NotEmpty(t, resp.AccessToken)
+	})
+
+	t.Run("admins must enroll before the login completes", func(t *testing.T) {
+		authService, userService, repo := newServices(t)
+		userService.EXPECT().Login(gomock.Any(), "root@example.com", "password123").Return(admin, nil)
+		repo.EXPECT().Get(gomock.Any(), "admin-1").Return(nil, nil)
+
+		resp, err := authService.Login(ctx, "root@example.com", "password123")
+		require.NoError(t, err)
+		assert.True(t, resp.MFAEnrollmentRequired)
+		assert.NotEmpty(t, resp.MFAToken)
+		assert.Empty(t, resp.AccessToken)
+	})
+
+	t.Run("mfa tokens are only good in their tenant", func(t *testing.T) {
+		authService, _, _ := newServices(t)
+		mfaToken, err := tokenService.GeneratePurposeToken("user-1", "acme", jwt.PurposeMFA, time.Minute)
+		require.NoError(t, err)
+
+		_, err = authService.VerifyMFA(ctx, mfaToken, "123456", nil)
+		var unauthorized *apperrors.UnauthorizedError
+		assert.ErrorAs(t, err, &unauthorized)
+	})
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/imaging"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/storage"
+)
+
+// AvatarKeyPrefix is the object storage prefix avatars are kept under
+const AvatarKeyPrefix = "avatars/"
+
+// maxAvatarPixels bounds the images avatars are made from, so a small
+// compressed upload cannot expand into a huge bitmap
+const maxAvatarPixels = 25_000_000
+
+// AvatarService manages the profile pictures users upload
+type AvatarService interface {
+	// UploadAvatar makes a square thumbnail of the image in data the user's
+	// avatar, replacing the previous one
+	UploadAvatar(ctx context.Context, userID string, data []byte) (*user.User, error)
+	// DeleteAvatar removes the user's avatar
+	DeleteAvatar(ctx context.Context, userID string) (*user.User, error)
+	// AvatarURL returns a short-lived URL reading the avatar stored as file
+	AvatarURL(ctx context.Context, file string) (string, error)
+}
+
+type avatarService struct {
+	users  user.UserRepository
+	store  storage.ObjectStorage
+	size   int
+	urlTTL time.Duration
+	log    logger.Logger
+}
+
+// NewAvatarService creates a new avatar service. Avatars are scaled down to
+// size×size pixels and their URLs stay valid for urlTTL.
+func NewAvatarService(users user.UserRepository, store storage.ObjectStorage, size int, urlTTL time.Duration) AvatarService {
+	return NewAvatarServiceWithLogger(users, store, size, urlTTL, logger.Get().WithLayer("application").WithComponent("avatar_service"))
+}
+
+func NewAvatarServiceWithLogger(users user.UserRepository, store storage.ObjectStorage, size int, urlTTL time.Duration, log logger.Logger) AvatarService {
+	if users == nil {
+		panic("user repository cannot be nil")
+	}
+	if store == nil {
+		panic("object storage cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+	return &avatarService{users: users, store: store, size: size, urlTTL: urlTTL, log: log}
+}
+
+// UploadAvatar stores the new avatar before pointing the user at it, so
+// the user never references a missing object; the object is removed again
+// when the update fails. Each avatar gets a new random key, which keeps
+// cached copies of the old one from being served as the new one.
+func (s *avatarService) UploadAvatar(ctx context.Context, userID string, data []byte) (*user.User, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	thumb, err := imaging.SquareThumbnail(data, s.size, maxAvatarPixels)
+	switch {
+	case stderrors.Is(err, imaging.ErrUnsupportedFormat):
+		return nil, errors.NewInvalidValueError("avatar", "", "must be a JPEG, PNG or GIF image")
+	case stderrors.Is(err, imaging.ErrTooLarge):
+		return nil, errors.NewInvalidValueError("avatar", "", fmt.Sprintf("must have at most %d pixels", maxAvatarPixels))
+	case err != nil:
+		return nil, errors.NewBusinessLogicError("avatar_processing", err.Error())
+	}
+
+	key, err := newAvatarKey(thumb.Ext)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, key, bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType); err != nil {
+		return nil, errors.NewExternalServiceError("object_storage", "put", 0, "", err, true)
+	}
+
+	u, previous, err := s.changeAvatar(ctx, u, key)
+	if err != nil {
+		s.deleteObject(ctx, key)
+		return nil, err
+	}
+	s.deleteObject(ctx, previous)
+
+	s.log.Info(ctx, "user avatar uploaded", "user_id", u.ID, "key", key, "bytes", len(thumb.Data))
+	return u, nil
+}
+
+func (s *avatarService) DeleteAvatar(ctx context.Context, userID string) (*user.User, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.AvatarKey == "" {
+		return u, nil
+	}
+
+	u, previous, err := s.changeAvatar(ctx, u, "")
+	if err != nil {
+		return nil, err
+	}
+	s.deleteObject(ctx, previous)
+
+	s.log.Info(ctx, "user avatar deleted", "user_id", u.ID, "key", previous)
+	return u, nil
+}
+
+// maxAvatarChangeRetries bounds how often an avatar change starts over
+// after a concurrent one got there first
+const maxAvatarChangeRetries = 3
+
+// changeAvatar points u at key and returns the key of the avatar it
+// replaced. Only the avatar column is written, and only while it still
+// holds the avatar u was read with, so every replaced object is known and
+// deleted exactly once; after a concurrent change u is read again.
+func (s *avatarService) changeAvatar(ctx context.Context, u *user.User, key string) (*user.User, string, error) {
+	for retry := 0; ; retry++ {
+		previous := u.ChangeAvatar(key)
+		err := s.users.UpdateAvatar(ctx, u, previous)
+		if err == nil {
+			return u, previous, nil
+		}
+
+		var conflict *errors.ConflictError
+		if !stderrors.As(err, &conflict) || conflict.Code() != errors.CodeVersionMismatch || retry == maxAvatarChangeRetries {
+			s.log.Error(ctx, "failed to update user avatar", "error", err, "user_id", u.ID)
+			return nil, "", err
+		}
+		s.log.Debug(ctx, "user avatar changed concurrently, retrying", "user_id", u.ID)
+		if u, err = s.users.GetByID(ctx, u.ID); err != nil {
+			return nil, "", err
+		}
+	}
+}
+
+func (s *avatarService) AvatarURL(ctx context.Context, file string) (string, error) {
+	key := AvatarKeyPrefix + file
+	if strings.Contains(file, "/") || storage.ValidateKey(key) != nil {
+		return "", errors.NewEntityNotFoundError("avatar", file)
+	}
+	url, err := s.store.PresignGet(ctx, key, s.urlTTL)
+	if err != nil {
+		return "", errors.NewExternalServiceError("object_storage", "presign", 0, "", err, false)
+	}
+	return url, nil
+}
+
+// deleteObject removes an avatar that is no longer referenced. Failures are
+// only logged: the user is already updated, and a leftover object is not
+// reachable without its random key.
+func (s *avatarService) deleteObject(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := s.store.Delete(ctx, key); err != nil {
+		s.log.Warn(ctx, "failed to delete avatar object", "key", key, "error", err)
+	}
+}
+
+// newAvatarKey returns a random key for an avatar with file extension ext
+func newAvatarKey(ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate avatar key: %w", err)
+	}
+	return AvatarKeyPrefix + hex.EncodeToString(b) + "." + ext, nil
+}
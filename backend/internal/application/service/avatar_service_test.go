@@ -0,0 +1,160 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	userMocks "github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// memoryStorage is an in-memory storage.ObjectStorage
+type memoryStorage struct {
+	objects map[string][]byte
+	putErr  error
+}
+
+func (m *memoryStorage) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	if m.putErr != nil {
+		return m.putErr
+	}
+	data, err := io.ReadAll(body)
+	m.objects[key] = data
+	return err
+}
+
+func (m *memoryStorage) Delete(_ context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memoryStorage) PresignGet(_ context.Context, key string, ttl time.Duration) (string, error) {
+	return "https://objects.example.com/" + key + "?ttl=" + ttl.String(), nil
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+func TestAvatarService(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (AvatarService, *userMocks.MockUserRepository, *memoryStorage) {
+		ctrl := gomock.NewController(t)
+		repo := userMocks.NewMockUserRepository(ctrl)
+		store := &memoryStorage{objects: map[string][]byte{}}
+		return NewAvatarService(repo, store, 64, 10*time.Minute), repo, store
+	}
+
+	t.Run("uploads a thumbnail and deletes the previous avatar", func(t *testing.T) {
+		svc, repo, store := setup(t)
+		store.objects["avatars/old.jpg"] = []byte("old")
+		u := &user.User{ID: "u-1", AvatarKey: "avatars/old.jpg"}
+		repo.EXPECT().GetByID(ctx, "u-1").Return(u, nil)
+		repo.EXPECT().UpdateAvatar(ctx, u, "avatars/old.jpg").Return(nil)
+
+		got, err := svc.UploadAvatar(ctx, "u-1", testPNG(t, 300, 200))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(got.AvatarKey, AvatarKeyPrefix))
+		assert.True(t, strings.HasSuffix(got.AvatarKey, ".jpg"), "opaque images are stored as JPEG")
+		assert.NotContains(t, store.objects, "avatars/old.jpg")
+
+		img, _, err := image.Decode(bytes.NewReader(store.objects[got.AvatarKey]))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 64, 64), img.Bounds())
+	})
+
+	t.Run("removes the new object when the user cannot be updated", func(t *testing.T) {
+		svc, repo, store := setup(t)
+		u := &user.User{ID: "u-1", AvatarKey: "avatars/old.jpg"}
+		dbErr := errors.NewDatabaseError("update", "users", assert.AnError, true)
+		repo.EXPECT().GetByID(ctx, "u-1").Return(u, nil)
+		repo.EXPECT().UpdateAvatar(ctx, u, "avatars/old.jpg").Return(dbErr)
+
+		_, err := svc.UploadAvatar(ctx, "u-1", testPNG(t, 10, 10))
+		assert.ErrorIs(t, err, dbErr)
+		assert.Empty(t, store.objects)
+	})
+
+	t.Run("replaces the avatar a concurrent upload just set", func(t *testing.T) {
+		svc, repo, store := setup(t)
+		store.objects["avatars/other.jpg"] = []byte("other")
+		stale := &user.User{ID: "u-1", AvatarKey: "avatars/old.jpg"}
+		current := &user.User{ID: "u-1", AvatarKey: "avatars/other.jpg"}
+		gomock.InOrder(
+			repo.EXPECT().GetByID(ctx, "u-1").Return(stale, nil),
+			repo.EXPECT().UpdateAvatar(ctx, stale, "avatars/old.jpg").Return(errors.NewVersionMismatchError("user", "u-1", "")),
+			repo.EXPECT().GetByID(ctx, "u-1").Return(current, nil),
+			repo.EXPECT().UpdateAvatar(ctx, current, "avatars/other.jpg").Return(nil),
+		)
+
+		got, err := svc.UploadAvatar(ctx, "u-1", testPNG(t, 10, 10))
+		require.NoError(t, err)
+		assert.NotContains(t, store.objects, "avatars/other.jpg")
+		assert.Contains(t, store.objects, got.AvatarKey)
+		assert.Len(t, store.objects, 1)
+	})
+
+	t.Run("rejects data that is not an image", func(t *testing.T) {
+		svc, repo, store := setup(t)
+		repo.EXPECT().GetByID(ctx, "u-1").Return(&user.User{ID: "u-1"}, nil)
+
+		_, err := svc.UploadAvatar(ctx, "u-1", []byte("GIF89a but not really"))
+		var validationErr *errors.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "avatar", validationErr.Field)
+		assert.Empty(t, store.objects)
+	})
+
+	t.Run("reports storage failures", func(t *testing.T) {
+		svc, repo, store := setup(t)
+		store.putErr = assert.AnError
+		repo.EXPECT().GetByID(ctx, "u-1").Return(&user.User{ID: "u-1"}, nil)
+
+		_, err := svc.UploadAvatar(ctx, "u-1", testPNG(t, 10, 10))
+		var externalErr *errors.ExternalServiceError
+		assert.ErrorAs(t, err, &externalErr)
+	})
+
+	t.Run("deletes the avatar", func(t *testing.T) {
+		svc, repo, store := setup(t)
+		store.objects["avatars/a.png"] = []byte("png")
+		u := &user.User{ID: "u-1", AvatarKey: "avatars/a.png"}
+		repo.EXPECT().GetByID(ctx, "u-1").Return(u, nil)
+		repo.EXPECT().UpdateAvatar(ctx, u, "avatars/a.png").Return(nil)
+
+		got, err := svc.DeleteAvatar(ctx, "u-1")
+		require.NoError(t, err)
+		assert.Empty(t, got.AvatarKey)
+		assert.Empty(t, store.objects)
+	})
+
+	t.Run("presigns avatar files only", func(t *testing.T) {
+		svc, _, _ := setup(t)
+		url, err := svc.AvatarURL(ctx, "0f3a.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, "https://objects.example.com/avatars/0f3a.jpg?ttl=10m0s", url)
+
+		for _, file := range []string{"", "..", "../secret", "a/b.jpg"} {
+			_, err := svc.AvatarURL(ctx, file)
+			var notFound *errors.EntityNotFoundError
+			assert.ErrorAs(t, err, &notFound, file)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/transaction"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// AdminBootstrapStep identifies the initial admin bootstrap marker
+const AdminBootstrapStep = "initial_admin"
+
+// BootstrapService creates the initial admin account on fresh deployments
+type BootstrapService interface {
+	// Available reports whether the admin bootstrap is configured and has not run yet
+	Available(ctx context.Context) (bool, error)
+	// BootstrapAdmin creates the configured admin account exactly once
+	BootstrapAdmin(ctx context.Context, setupToken, password string) (*user.User, error)
+}
+
+// AdminBootstrapSettings holds the operator-provided initial admin identity
+type AdminBootstrapSettings struct {
+	Email      string
+	Name       string
+	SetupToken string
+}
+
+type bootstrapService struct {
+	settings AdminBootstrapSettings
+	users    user.UserRepository
+	markers  user.BootstrapRepository
+	idGen    id.Generator
+	uow      transaction.UnitOfWork
+	events   event.Bus
+	log      logger.Logger
+}
+
+// NewBootstrapService creates a new admin bootstrap service. A nil uow runs
+// steps without a transaction; a nil bus skips event publication.
+func NewBootstrapService(settings AdminBootstrapSettings, users user.UserRepository, markers user.BootstrapRepository, idGen id.Generator, uow transaction.UnitOfWork, bus event.Bus) BootstrapService {
+	return NewBootstrapServiceWithLogger(settings, users, markers, idGen, uow, bus, logger.Get().WithLayer("application").WithComponent("bootstrap_service"))
+}
+
+func NewBootstrapServiceWithLogger(settings AdminBootstrapSettings, users user.UserRepository, markers user.BootstrapRepository, idGen id.Generator, uow transaction.UnitOfWork, bus event.Bus, log logger.Logger) BootstrapService {
+	if users == nil {
+		panic("user repository cannot be nil")
+	}
+	if markers == nil {
+		panic("bootstrap repository cannot be nil")
+	}
+	if idGen == nil {
+		panic("ID generator cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+	if uow == nil {
+		uow = noopUnitOfWork{}
+	}
+
+	return &bootstrapService{
+		settings: settings,
+		users:    users,
+		markers:  markers,
+		idGen:    idGen,
+		uow:      uow,
+		events:   bus,
+		log:      log,
+	}
+}
+
+func (s *bootstrapService) Available(ctx context.Context) (bool, error) {
+	if s.settings.Email == "" {
+		return false, nil
+	}
+
+	completed, err := s.markers.IsCompleted(ctx, AdminBootstrapStep)
+	if err != nil {
+		return false, err
+	}
+	return !completed, nil
+}
+
+func (s *bootstrapService) BootstrapAdmin(ctx context.Context, setupToken, password string) (*user.User, error) {
+	if s.settings.Email == "" {
+		return nil, errors.NewInvalidStateError(errors.CodeInvalidState, "admin_bootstrap", "disabled",
+			"admin bootstrap is not configured")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(setupToken), []byte(s.settings.SetupToken)) != 1 {
+		s.log.Warn(ctx, "admin bootstrap rejected: invalid setup token")
+		return nil, errors.NewUnauthorizedError("admin_bootstrap", "", "invalid setup token")
+	}
+
+	var u *user.User
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		completed, err := s.markers.IsCompleted(ctx, AdminBootstrapStep)
+		if err != nil {
+			return err
+		}
+		if completed {
+			return errors.NewInvalidStateError(errors.CodeInvalidState, "admin_bootstrap", "completed",
+				"admin bootstrap has already been completed")
+		}
+
+		// Never promote a pre-existing account: whoever registered it chose its password
+		existing, err := s.users.GetByEmail(ctx, s.settings.Email)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return errors.NewDuplicateEntryError("user", "email", s.settings.Email, existing.ID)
+		}
+
+		now := time.Now()
+		u = &user.User{
+			ID:        s.idGen.Generate(),
+			Email:     s.settings.Email,
+			Name:      s.settings.Name,
+			Role:      user.RoleUser,
+			Status:    user.StatusActive,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := u.SetPassword(ctx, password); err != nil {
+			return err
+		}
+		if err := u.Validate(ctx); err != nil {
+			return err
+		}
+
+		u.PromoteToAdmin()
+		if err := s.users.Create(ctx, u); err != nil {
+			return err
+		}
+
+		// The marker insert fails on a concurrent bootstrap, rolling back the user
+		return s.markers.MarkCompleted(ctx, AdminBootstrapStep, u.ID)
+	})
+	if err != nil {
+		s.log.Warn(ctx, "admin bootstrap failed", "error", err, "email", s.settings.Email)
+		return nil, err
+	}
+
+	if events := u.PullEvents(); s.events != nil && len(events) > 0 {
+		if err := s.events.Publish(ctx, events...); err != nil {
+			s.log.Error(ctx, "failed to publish domain events", "error", err, "user_id", u.ID, "count", len(events))
+		}
+	}
+
+	s.log.Info(ctx, "initial admin bootstrapped", "user_id", u.ID, "email", u.Email)
+	return u, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+var testBootstrapSettings = AdminBootstrapSettings{
+	Email:      "admin@example.com",
+	Name:       "Administrator",
+	SetupToken: "0123456789abcdef",
+}
+
+type bootstrapFixture struct {
+	users   *mocks.MockUserRepository
+	markers *mocks.MockBootstrapRepository
+	idGen   *idMocks.MockGenerator
+	uow     *recordingUnitOfWork
+	bus     *recordingBus
+}
+
+func newBootstrapFixture(t *testing.T) *bootstrapFixture {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+
+	return &bootstrapFixture{
+		users:   mocks.NewMockUserRepository(ctrl),
+		markers: mocks.NewMockBootstrapRepository(ctrl),
+		idGen:   idMocks.NewMockGenerator(ctrl),
+		uow:     &recordingUnitOfWork{},
+		bus:     &recordingBus{},
+	}
+}
+
+func (f *bootstrapFixture) service(settings AdminBootstrapSettings) BootstrapService {
+	return NewBootstrapService(settings, f.users, f.markers, f.idGen, f.uow, f.bus)
+}
+
+func TestBootstrapService_BootstrapAdmin_Success(t *testing.T) {
+	f := newBootstrapFixture(t)
+	svc := f.service(testBootstrapSettings)
+
+	f.markers.EXPECT().IsCompleted(inTx, AdminBootstrapStep).Return(false, nil)
+	f.users.EXPECT().GetByEmail(inTx, "admin@example.com").Return(nil, nil)
+	f.idGen.EXPECT().Generate().Return("admin-1")
+	f.users.EXPECT().Create(inTx, gomock.Any()).DoAndReturn(func(_ context.Context, u *user.User) error {
+		assert.True(t, u.IsAdmin())
+		return nil
+	})
+	f.markers.EXPECT().MarkCompleted(inTx, AdminBootstrapStep, "admin-1").Return(nil)
+
+	u, err := svc.BootstrapAdmin(context.Background(), testBootstrapSettings.SetupToken, "S3cure-admin-pass")
+	require.NoError(t, err)
+	assert.Equal(t, "admin-1", u.ID)
+	assert.Equal(t, user.RoleAdmin, u.Role)
+	assert.NoError(t, u.CheckPassword(context.Background(), "S3cure-admin-pass"))
+	assert.Equal(t, 1, f.uow.calls)
+	assert.Equal(t, []string{user.EventUserAdminBootstrapped}, eventNames(f.bus.published))
+}
+
+func TestBootstrapService_BootstrapAdmin_InvalidToken(t *testing.T) {
+	f := newBootstrapFixture(t)
+	svc := f.service(testBootstrapSettings)
+
+	_, err := svc.BootstrapAdmin(context.Background(), "wrong-token", "S3cure-admin-pass")
+	require.Error(t, err)
+	var unauthorized *errors.UnauthorizedError
+	assert.ErrorAs(t, err, &unauthorized)
+	assert.Equal(t, 0, f.uow.calls)
+}
+
+func TestBootstrapService_BootstrapAdmin_AlreadyCompleted(t *testing.T) {
+	f := newBootstrapFixture(t)
+	svc := f.service(testBootstrapSettings)
+
+	f.markers.EXPECT().IsCompleted(inTx, AdminBootstrapStep).Return(true, nil)
+
+	_, err := svc.BootstrapAdmin(context.Background(), testBootstrapSettings.SetupToken, "S3cure-admin-pass")
+	require.Error(t, err)
+	var invalidState *errors.InvalidStateError
+	require.ErrorAs(t, err, &invalidState)
+	assert.Equal(t, "completed", invalidState.State)
+	assert.Empty(t, f.bus.published)
+}
+
+func TestBootstrapService_BootstrapAdmin_ExistingAccountNotPromoted(t *testing.T) {
+	f := newBootstrapFixture(t)
+	svc := f.service(testBootstrapSettings)
+
+	f.markers.EXPECT().IsCompleted(inTx, AdminBootstrapStep).Return(false, nil)
+	f.users.EXPECT().GetByEmail(inTx, "admin@example.com").Return(&user.User{ID: "squatter"}, nil)
+
+	_, err := svc.BootstrapAdmin(context.Background(), testBootstrapSettings.SetupToken, "S3cure-admin-pass")
+	require.Error(t, err)
+	var conflict *errors.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+}
+
+func TestBootstrapService_Disabled(t *testing.T) {
+	f := newBootstrapFixture(t)
+	svc := f.service(AdminBootstrapSettings{})
+
+	available, err := svc.Available(context.Background())
+	require.NoError(t, err)
+	assert.False(t, available)
+
+	_, err = svc.BootstrapAdmin(context.Background(), "", "S3cure-admin-pass")
+	var invalidState *errors.InvalidStateError
+	require.ErrorAs(t, err, &invalidState)
+	assert.Equal(t, "disabled", invalidState.State)
+}
+
+func TestBootstrapService_Available(t *testing.T) {
+	f := newBootstrapFixture(t)
+	svc := f.service(testBootstrapSettings)
+
+	f.markers.EXPECT().IsCompleted(gomock.Any(), AdminBootstrapStep).Return(false, nil)
+	available, err := svc.Available(context.Background())
+	require.NoError(t, err)
+	assert.True(t, available)
+
+	f.markers.EXPECT().IsCompleted(gomock.Any(), AdminBootstrapStep).Return(true, nil)
+	available, err = svc.Available(context.Background())
+	require.NoError(t, err)
+	assert.False(t, available)
+}
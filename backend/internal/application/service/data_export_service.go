@@ -0,0 +1,369 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/dataexport"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/jobs"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// staleExportAfter is how long an export may stay pending before the user
+// can request another, e.g. after its job was dead-lettered
+const staleExportAfter = 24 * time.Hour
+
+// auditPageSize is how many audit entries an export reads at a time
+const auditPageSize = 100
+
+// ExportUserDataPayload is the payload of an export-user-data job
+type ExportUserDataPayload struct {
+	ExportID string `json:"export_id"`
+	TenantID string `json:"tenant_id"`
+}
+
+// DataExportService generates the archives of personal data users request
+// about themselves
+type DataExportService interface {
+	// RequestExport queues an export of the user's data, or returns the
+	// export that is already pending
+	RequestExport(ctx context.Context, userID string) (*dataexport.Export, error)
+	// LatestExport returns the user's most recent export that has not expired
+	LatestExport(ctx context.Context, userID string) (*dataexport.Export, error)
+	// ExportContent returns the ZIP archive of a ready export
+	ExportContent(ctx context.Context, exportID string) ([]byte, error)
+	// GenerateExport builds the archive of a pending export and tells the
+	// user it is ready. It runs as the export-user-data job.
+	GenerateExport(ctx context.Context, exportID string) error
+	// DeleteExpired removes expired exports of every tenant. It runs with
+	// the account purge, which runs even when the scheduler is disabled.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// DataExportServiceOption configures optional data export service collaborators
+type DataExportServiceOption func(*dataExportService)
+
+// WithExportMail emails users when their export is ready
+func WithExportMail(mail AccountMailService) DataExportServiceOption {
+	return func(s *dataExportService) {
+		s.mail = mail
+	}
+}
+
+type dataExportService struct {
+	repo  dataexport.Repository
+	users user.UserRepository
+	audit audit.Repository
+	queue jobs.Queue
+	idGen id.Generator
+	ttl   time.Duration
+	mail  AccountMailService
+	now   func() time.Time
+	log   logger.Logger
+}
+
+// NewDataExportService creates a new data export service. Ready exports
+// can be downloaded for ttl.
+func NewDataExportService(repo dataexport.Repository, users user.UserRepository, auditRepo audit.Repository, queue jobs.Queue, idGen id.Generator, ttl time.Duration, opts ...DataExportServiceOption) DataExportService {
+	return NewDataExportServiceWithLogger(repo, users, auditRepo, queue, idGen, ttl, logger.Get().WithLayer("application").WithComponent("data_export_service"), opts...)
+}
+
+func NewDataExportServiceWithLogger(repo dataexport.Repository, users user.UserRepository, auditRepo audit.Repository, queue jobs.Queue, idGen id.Generator, ttl time.Duration, log logger.Logger, opts ...DataExportServiceOption) DataExportService {
+	if repo == nil {
+		panic("data export repository cannot be nil")
+	}
+	if users == nil {
+		panic("user repository cannot be nil")
+	}
+	if auditRepo == nil {
+		panic("audit repository cannot be nil")
+	}
+	if queue == nil {
+		panic("job queue cannot be nil")
+	}
+	if idGen == nil {
+		panic("ID generator cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	s := &dataExportService{
+		repo:  repo,
+		users: users,
+		audit: auditRepo,
+		queue: queue,
+		idGen: idGen,
+		ttl:   ttl,
+		now:   time.Now,
+		log:   log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *dataExportService) RequestExport(ctx context.Context, userID string) (*dataexport.Export, error) {
+	if userID == "" {
+		return nil, errors.NewRequiredFieldError("user_id", userID)
+	}
+
+	latest, err := s.repo.Latest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Status == dataexport.StatusPending && s.now().Sub(latest.CreatedAt) < staleExportAfter {
+		return latest, nil
+	}
+
+	e := &dataexport.Export{
+		ID:        s.idGen.Generate(),
+		UserID:    userID,
+		Status:    dataexport.StatusPending,
+		CreatedAt: s.now(),
+	}
+	if err := s.repo.Create(ctx, e); err != nil {
+		return nil, err
+	}
+
+	job, err := jobs.NewJob(JobExportUserData, ExportUserDataPayload{ExportID: e.ID, TenantID: tenant.IDFromContext(ctx)})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		s.log.Error(ctx, "failed to enqueue data export", "error", err, "export_id", e.ID)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "data export requested", "export_id", e.ID, "user_id", userID)
+	return e, nil
+}
+
+func (s *dataExportService) LatestExport(ctx context.Context, userID string) (*dataexport.Export, error) {
+	latest, err := s.repo.Latest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil || latest.Expired(s.now()) {
+		return nil, errors.NewEntityNotFoundError("data_export", userID)
+	}
+	return latest, nil
+}
+
+func (s *dataExportService) ExportContent(ctx context.Context, exportID string) ([]byte, error) {
+	return s.repo.Content(ctx, exportID)
+}
+
+func (s *dataExportService) GenerateExport(ctx context.Context, exportID string) error {
+	e, err := s.repo.GetByID(ctx, exportID)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return jobs.Permanent(errors.NewEntityNotFoundError("data_export", exportID))
+	}
+	// A retried job finds the export already finished
+	if e.Status != dataexport.StatusPending {
+		return nil
+	}
+
+	u, err := s.users.GetByID(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		e.MarkFailed("user no longer exists", s.now())
+		return s.repo.Update(ctx, e)
+	}
+
+	entries, err := s.auditEntries(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	archive, err := buildExportArchive(u, entries, now)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	e.MarkReady(archive, now, now.Add(s.ttl))
+	if err := s.repo.Update(ctx, e); err != nil {
+		return err
+	}
+	s.log.Info(ctx, "data export generated", "export_id", e.ID, "user_id", u.ID, "size", e.Size)
+
+	// The export can be downloaded either way, so a lost email is not retried
+	if s.mail != nil {
+		if err := s.mail.SendDataExportReady(ctx, u.Email, u.Name, *e.ExpiresAt, u.LocalePrefs()); err != nil {
+			s.log.Warn(ctx, "failed to send data export notification", "error", err, "export_id", e.ID)
+		}
+	}
+	return nil
+}
+
+func (s *dataExportService) DeleteExpired(ctx context.Context) (int64, error) {
+	deleted, err := s.repo.DeleteExpired(ctx, s.now())
+	if err == nil {
+		s.log.Info(ctx, "expired data exports deleted", "deleted", deleted)
+	}
+	return deleted, err
+}
+
+// auditEntries returns the audit entries about the user or made by them,
+// oldest first
+func (s *dataExportService) auditEntries(ctx context.Context, userID string) ([]*audit.Entry, error) {
+	seen := map[string]bool{}
+	var entries []*audit.Entry
+	for _, filter := range []audit.ListRequest{
+		{EntityType: "user", EntityID: userID},
+		{ActorID: userID},
+	} {
+		req := filter
+		req.PageSize = auditPageSize
+		for req.Page = 1; ; req.Page++ {
+			page, err := s.audit.List(ctx, &req)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range page.Entries {
+				if !seen[entry.ID] {
+					seen[entry.ID] = true
+					entries = append(entries, entry)
+				}
+			}
+			if req.Page >= page.TotalPages {
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries, nil
+}
+
+// exportProfile is the profile.json of an export
+type exportProfile struct {
+	ID                  string     `json:"id"`
+	TenantID            string     `json:"tenant_id"`
+	Email               string     `json:"email"`
+	Name                string     `json:"name"`
+	Role                string     `json:"role"`
+	Status              string     `json:"status"`
+	Timezone            string     `json:"timezone,omitempty"`
+	Locale              string     `json:"locale,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+// exportSession is one sign-in in the sessions.json of an export. Access
+// tokens are not stored, so sign-ins recorded in the audit log are the
+// sessions the service knows of.
+type exportSession struct {
+	SignedInAt time.Time `json:"signed_in_at"`
+	Outcome    string    `json:"outcome"`
+	TraceID    string    `json:"trace_id,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+}
+
+// buildExportArchive writes the user's profile, sign-ins and audit entries
+// as JSON files of a ZIP archive. Times are in the user's time zone, and
+// audit entries are redacted of other parties.
+func buildExportArchive(u *user.User, entries []*audit.Entry, now time.Time) ([]byte, error) {
+	loc := u.LocalePrefs().Location()
+	localEntries := make([]*audit.Entry, len(entries))
+	for i, entry := range entries {
+		local := redactOtherParties(entry, u.ID)
+		local.CreatedAt = entry.CreatedAt.In(loc)
+		localEntries[i] = local
+	}
+	entries = localEntries
+
+	sessions := []exportSession{}
+	for _, entry := range entries {
+		if entry.Action == audit.ActionLogin && entry.EntityID == u.ID {
+			sessions = append(sessions, exportSession{
+				SignedInAt: entry.CreatedAt,
+				Outcome:    entry.Outcome,
+				TraceID:    entry.TraceID,
+				IPAddress:  entry.IPAddress,
+			})
+		}
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", exportProfile{
+			ID:                  u.ID,
+			TenantID:            u.TenantID,
+			Email:               u.Email,
+			Name:                u.Name,
+			Role:                u.Role,
+			Status:              u.CurrentStatus(),
+			Timezone:            u.Timezone,
+			Locale:              u.Locale,
+			CreatedAt:           u.CreatedAt.In(loc),
+			UpdatedAt:           u.UpdatedAt.In(loc),
+			DeletionScheduledAt: inLocation(u.DeletionScheduledAt, loc),
+		}},
+		{"sessions.json", sessions},
+		{"audit_log.json", entries},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// redactOtherParties returns a copy of entry holding only the user's own
+// data. Of what the user did to others it keeps the action but not whom it
+// concerned or what changed; of what others did to the user it keeps the
+// change but not who made it or from where.
+func redactOtherParties(entry *audit.Entry, userID string) *audit.Entry {
+	redacted := *entry
+	if entry.EntityType != "user" || entry.EntityID != userID {
+		redacted.EntityID = ""
+		redacted.Changes = nil
+	}
+	if entry.ActorID != "" && entry.ActorID != userID {
+		redacted.ActorID = ""
+		redacted.IPAddress = ""
+	}
+	return &redacted
+}
+
+// inLocation returns t in loc, or nil when t is nil
+func inLocation(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.In(loc)
+	return &local
+}
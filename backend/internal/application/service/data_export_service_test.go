@@ -0,0 +1,227 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/dataexport"
+	"github.com/cctw-zed/wonder/internal/domain/dataexport/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/jobs"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+)
+
+// pagedAuditRepository serves entries matching a list request in pages
+type pagedAuditRepository struct {
+	audit.Repository
+	entries []*audit.Entry
+}
+
+func (r *pagedAuditRepository) List(_ context.Context, req *audit.ListRequest) (*audit.ListResponse, error) {
+	var matched []*audit.Entry
+	for _, e := range r.entries {
+		if (req.ActorID == "" || e.ActorID == req.ActorID) &&
+			(req.EntityType == "" || e.EntityType == req.EntityType) &&
+			(req.EntityID == "" || e.EntityID == req.EntityID) {
+			matched = append(matched, e)
+		}
+	}
+
+	totalPages := (len(matched) + req.PageSize - 1) / req.PageSize
+	start := min((req.Page-1)*req.PageSize, len(matched))
+	end := min(start+req.PageSize, len(matched))
+	return &audit.ListResponse{Entries: matched[start:end], Total: int64(len(matched)), Page: req.Page, PageSize: req.PageSize, TotalPages: totalPages}, nil
+}
+
+func TestDataExportService_RequestExport(t *testing.T) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	repo := mocks.NewMockRepository(ctrl)
+	queue := jobs.NewMemoryQueue()
+	svc := NewDataExportService(repo, fake.NewUserRepository(), &pagedAuditRepository{}, queue, fake.NewIDGenerator(1), time.Hour)
+
+	repo.EXPECT().Latest(ctx, "u-1").Return(nil, nil)
+	repo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+
+	e, err := svc.RequestExport(ctx, "u-1")
+	require.NoError(t, err)
+	assert.Equal(t, dataexport.StatusPending, e.Status)
+
+	job, err := queue.Claim(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, JobExportUserData, job.Type)
+	var payload ExportUserDataPayload
+	require.NoError(t, job.Decode(&payload))
+	assert.Equal(t, ExportUserDataPayload{ExportID: e.ID, TenantID: "acme"}, payload)
+
+	// A pending export is returned rather than queued again
+	repo.EXPECT().Latest(ctx, "u-1").Return(e, nil)
+	again, err := svc.RequestExport(ctx, "u-1")
+	require.NoError(t, err)
+	assert.Same(t, e, again)
+
+	stats, err := queue.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Pending)
+}
+
+func TestDataExportService_LatestExport(t *testing.T) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	repo := mocks.NewMockRepository(ctrl)
+	svc := NewDataExportService(repo, fake.NewUserRepository(), &pagedAuditRepository{}, jobs.NewMemoryQueue(), fake.NewIDGenerator(1), time.Hour)
+
+	expired := &dataexport.Export{ID: "exp-1", Status: dataexport.StatusReady}
+	expired.MarkReady([]byte("zip"), time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+
+	var notFound *errors.EntityNotFoundError
+	repo.EXPECT().Latest(ctx, "u-1").Return(nil, nil)
+	_, err := svc.LatestExport(ctx, "u-1")
+	assert.ErrorAs(t, err, &notFound)
+
+	repo.EXPECT().Latest(ctx, "u-1").Return(expired, nil)
+	_, err = svc.LatestExport(ctx, "u-1")
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestDataExportService_GenerateExport(t *testing.T) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	users := fake.NewUserRepository()
+	ada := &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser, Timezone: "Asia/Tokyo"}
+	require.NoError(t, users.Create(ctx, ada))
+
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	auditRepo := &pagedAuditRepository{}
+	for i := 0; i < 150; i++ {
+		auditRepo.entries = append(auditRepo.entries, &audit.Entry{
+			ID: fmt.Sprintf("login-%d", i), ActorID: "u-1", Action: audit.ActionLogin,
+			EntityType: "user", EntityID: "u-1", Outcome: audit.OutcomeSuccess, CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	auditRepo.entries = append(auditRepo.entries,
+		// Made by an admin about the user
+		&audit.Entry{ID: "role", ActorID: "admin", Action: audit.ActionUpdate, EntityType: "user", EntityID: "u-1", IPAddress: "10.0.0.9",
+			Changes: audit.ChangeSet{"role": {From: "user", To: "admin"}}, CreatedAt: start.Add(-time.Hour)},
+		// Made by the user about someone else
+		&audit.Entry{ID: "grace", ActorID: "u-1", Action: audit.ActionUpdate, EntityType: "user", EntityID: "u-3", IPAddress: "192.0.2.1",
+			Changes: audit.ChangeSet{"email": {From: "grace@example.com", To: "grace@example.org"}}, CreatedAt: start.Add(-2 * time.Hour)},
+		// Unrelated
+		&audit.Entry{ID: "other", ActorID: "admin", Action: audit.ActionDelete, EntityType: "user", EntityID: "u-2", CreatedAt: start},
+	)
+
+	renderer, err := mailer.NewRenderer(mailer.Templates)
+	require.NoError(t, err)
+	sender := &recordingMailer{}
+
+	repo := mocks.NewMockRepository(ctrl)
+	svc := NewDataExportService(repo, users, auditRepo, jobs.NewMemoryQueue(), fake.NewIDGenerator(1), 7*24*time.Hour,
+		WithExportMail(NewAccountMailService(sender, renderer, "")))
+
+	pending := &dataexport.Export{ID: "exp-1", UserID: "u-1", Status: dataexport.StatusPending, CreatedAt: start}
+	var stored *dataexport.Export
+	repo.EXPECT().GetByID(ctx, "exp-1").Return(pending, nil)
+	repo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, e *dataexport.Export) error {
+		stored = e
+		return nil
+	})
+
+	require.NoError(t, svc.GenerateExport(ctx, "exp-1"))
+	require.NotNil(t, stored)
+	assert.Equal(t, dataexport.StatusReady, stored.Status)
+	require.NotNil(t, stored.ExpiresAt)
+	assert.Equal(t, 7*24*time.Hour, stored.ExpiresAt.Sub(*stored.CompletedAt))
+
+	archive, err := zip.NewReader(bytes.NewReader(stored.Content), int64(len(stored.Content)))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+	}
+	require.Len(t, files, 3)
+
+	var profile map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+	assert.Equal(t, "ada@example.com", profile["email"])
+	assert.Equal(t, "Asia/Tokyo", profile["timezone"])
+	assert.NotContains(t, string(files["profile.json"]), "password")
+
+	var sessions []exportSession
+	require.NoError(t, json.Unmarshal(files["sessions.json"], &sessions))
+	assert.Len(t, sessions, 150)
+	assert.Equal(t, "2026-10-01T18:00:00+09:00", sessions[0].SignedInAt.Format(time.RFC3339), "times are in the user's time zone")
+
+	var entries []*audit.Entry
+	require.NoError(t, json.Unmarshal(files["audit_log.json"], &entries))
+	require.Len(t, entries, 152)
+	assert.Equal(t, "grace", entries[0].ID, "oldest first")
+	assert.Equal(t, "role", entries[1].ID)
+
+	// Other parties are redacted
+	assert.Equal(t, "u-1", entries[0].ActorID)
+	assert.Equal(t, "192.0.2.1", entries[0].IPAddress)
+	assert.Empty(t, entries[0].EntityID)
+	assert.Empty(t, entries[0].Changes)
+	assert.Equal(t, "u-1", entries[1].EntityID)
+	assert.Equal(t, audit.ChangeSet{"role": {From: "user", To: "admin"}}, entries[1].Changes)
+	assert.Empty(t, entries[1].ActorID)
+	assert.Empty(t, entries[1].IPAddress)
+	for _, banned := range []string{"u-3", "grace@", "10.0.0.9"} {
+		assert.NotContains(t, string(files["audit_log.json"]), banned)
+	}
+
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "Your Wonder data export is ready", sender.sent[0].Subject)
+
+	// A retried job leaves a finished export alone
+	repo.EXPECT().GetByID(ctx, "exp-1").Return(stored, nil)
+	require.NoError(t, svc.GenerateExport(ctx, "exp-1"))
+	assert.Len(t, sender.sent, 1)
+}
+
+func TestDataExportService_GenerateExport_UserGone(t *testing.T) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	repo := mocks.NewMockRepository(ctrl)
+	svc := NewDataExportService(repo, fake.NewUserRepository(), &pagedAuditRepository{}, jobs.NewMemoryQueue(), fake.NewIDGenerator(1), time.Hour)
+
+	repo.EXPECT().GetByID(ctx, "exp-1").Return(&dataexport.Export{ID: "exp-1", UserID: "u-1", Status: dataexport.StatusPending}, nil)
+	repo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, e *dataexport.Export) error {
+		assert.Equal(t, dataexport.StatusFailed, e.Status)
+		assert.Nil(t, e.Content)
+		return nil
+	})
+	require.NoError(t, svc.GenerateExport(ctx, "exp-1"))
+
+	// Unknown exports are not retried
+	repo.EXPECT().GetByID(ctx, "exp-2").Return(nil, nil)
+	assert.True(t, jobs.IsPermanent(svc.GenerateExport(ctx, "exp-2")))
+}
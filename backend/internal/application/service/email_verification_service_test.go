@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+)
+
+func newTestEmailVerificationService(t *testing.T) (EmailVerificationService, *fake.UserRepository, *recordingMailer) {
+	logger.Initialize()
+	users := fake.NewUserRepository()
+	require.NoError(t, users.Create(context.Background(), &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser}))
+
+	renderer, err := mailer.NewRenderer(mailer.Templates)
+	require.NoError(t, err)
+	sender := &recordingMailer{}
+	policy := EmailVerificationPolicy{TTL: 48 * time.Hour, URL: "https://wonder.example.com/verify-email"}
+	svc := NewEmailVerificationService(users, fake.NewTokenService(), NewAccountMailService(sender, renderer, ""), policy)
+	return svc, users, sender
+}
+
+// verificationToken returns the token of the verification link in an email
+func verificationToken(t *testing.T, msg *mailer.Message) string {
+	for _, line := range strings.Split(msg.Text, "\n") {
+		if strings.HasPrefix(line, "https://wonder.example.com/verify-email?") {
+			u, err := url.Parse(line)
+			require.NoError(t, err)
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatalf("no verification link in %q", msg.Text)
+	return ""
+}
+
+func TestEmailVerificationService_SendAndVerify(t *testing.T) {
+	ctx := context.Background()
+	svc, users, sender := newTestEmailVerificationService(t)
+
+	require.NoError(t, svc.Send(ctx, "u-1"))
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "ada@example.com", sender.sent[0].To)
+	token := verificationToken(t, sender.sent[0])
+
+	u, err := svc.Verify(ctx, token)
+	require.NoError(t, err)
+	assert.True(t, u.EmailVerified())
+	stored, err := users.GetByID(ctx, "u-1")
+	require.NoError(t, err)
+	assert.True(t, stored.EmailVerified())
+
+	// Opening the link again is harmless, and verified users get no email
+	_, err = svc.Verify(ctx, token)
+	require.NoError(t, err)
+	require.NoError(t, svc.Send(ctx, "u-1"))
+	assert.Len(t, sender.sent, 1)
+
+	// Deleted users are skipped
+	require.NoError(t, svc.Send(ctx, "u-9"))
+	assert.Len(t, sender.sent, 1)
+}
+
+func TestEmailVerificationService_EmailChanged(t *testing.T) {
+	ctx := context.Background()
+	svc, users, sender := newTestEmailVerificationService(t)
+
+	require.NoError(t, svc.Send(ctx, "u-1"))
+	old := verificationToken(t, sender.sent[0])
+
+	u, err := users.GetByID(ctx, "u-1")
+	require.NoError(t, err)
+	require.NoError(t, u.UpdateEmail(ctx, "lovelace@example.com"))
+	require.NoError(t, users.Update(ctx, u))
+
+	var rule *errors.DomainRuleError
+	_, err = svc.Verify(ctx, old)
+	assert.ErrorAs(t, err, &rule, "a link to the previous address")
+
+	require.NoError(t, svc.Send(ctx, "u-1"))
+	require.Len(t, sender.sent, 2)
+	assert.Equal(t, "lovelace@example.com", sender.sent[1].To)
+	u, err = svc.Verify(ctx, verificationToken(t, sender.sent[1]))
+	require.NoError(t, err)
+	assert.Equal(t, "lovelace@example.com", u.Email)
+	assert.True(t, u.EmailVerified())
+}
+
+func TestEmailVerificationService_InvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, _, sender := newTestEmailVerificationService(t)
+	require.NoError(t, svc.Send(ctx, "u-1"))
+	token := verificationToken(t, sender.sent[0])
+
+	var rule *errors.DomainRuleError
+	for _, tc := range []struct {
+		name  string
+		ctx   context.Context
+		token string
+	}{
+		{"forged", ctx, "not-a-token"},
+		{"access token", ctx, fake.Token("u-1", "")},
+		{"other tenant", tenant.WithID(ctx, "globex"), token},
+	} {
+		_, err := svc.Verify(tc.ctx, tc.token)
+		assert.ErrorAs(t, err, &rule, tc.name)
+	}
+
+	_, err := svc.Verify(ctx, "")
+	var validation *errors.ValidationError
+	assert.ErrorAs(t, err, &validation)
+}
@@ -0,0 +1,515 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/transaction"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/jwt"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// InvitationPolicy configures invitation links
+type InvitationPolicy struct {
+	// TTL is how long an invitation link can be accepted
+	TTL time.Duration
+	// URL is the page invitation links open; the token is appended as
+	// the token query parameter
+	URL string
+}
+
+// SentInvitation is an invitation that was just issued a link. Link is
+// only returned when the invitation was not emailed, for the owner to pass
+// on themselves.
+type SentInvitation struct {
+	*organization.Invitation
+	Emailed bool   `json:"emailed"`
+	Link    string `json:"link,omitempty"`
+}
+
+// InvitationPreview is what an invitation link shows before it is
+// accepted, such as the email to pre-fill registration with
+type InvitationPreview struct {
+	Email        string            `json:"email"`
+	OrgID        string            `json:"org_id"`
+	Organization string            `json:"organization"`
+	Role         organization.Role `json:"role"`
+	InvitedBy    string            `json:"invited_by"`
+	ExpiresAt    time.Time         `json:"expires_at"`
+	// Registered reports whether the email already has an account, so
+	// the invitee signs in instead of registering
+	Registered bool `json:"registered"`
+}
+
+// InvitationService invites people to organizations by emailing them a
+// signed link that expires. Only an organization's owner manages its
+// invitations; whoever holds a link may preview it, and accepts it signed
+// in with the invited email or by registering with it.
+type InvitationService interface {
+	// Invite invites whoever owns email to join as a member
+	Invite(ctx context.Context, userID, orgID, email string) (*SentInvitation, error)
+	// List lists an organization's invitations, newest first
+	List(ctx context.Context, userID, orgID string) ([]*organization.Invitation, error)
+	// Resend issues a pending or expired invitation a new link, valid for
+	// the full TTL; earlier links stop working
+	Resend(ctx context.Context, userID, invitationID string) (*SentInvitation, error)
+	// Revoke withdraws an invitation so its link stops working
+	Revoke(ctx context.Context, userID, invitationID string) error
+	// Preview describes the invitation a link was issued for
+	Preview(ctx context.Context, token string) (*InvitationPreview, error)
+	// Accept makes the user a member through an invitation to their email
+	Accept(ctx context.Context, userID, token string) (*organization.Member, error)
+	// Register registers a user with the invited email and makes them a
+	// member
+	Register(ctx context.Context, token, name, password string) (*user.User, *organization.Member, error)
+}
+
+// InvitationServiceOption configures optional invitation service
+// collaborators
+type InvitationServiceOption func(*invitationService)
+
+// WithInvitationMail emails invitation links to the invitees
+func WithInvitationMail(mail AccountMailService) InvitationServiceOption {
+	return func(s *invitationService) {
+		s.mail = mail
+	}
+}
+
+// WithInvitationUnitOfWork makes accepting invitations, and registering
+// through them, atomic. The registrar's unit of work must join it.
+func WithInvitationUnitOfWork(uow transaction.UnitOfWork) InvitationServiceOption {
+	return func(s *invitationService) {
+		if uow != nil {
+			s.uow = uow
+		}
+	}
+}
+
+// WithInvitationEmailRules matches invitees to accounts by rules, which
+// must be the ones the user repository uses
+func WithInvitationEmailRules(rules user.EmailRules) InvitationServiceOption {
+	return func(s *invitationService) {
+		s.emailRules = rules
+	}
+}
+
+type invitationService struct {
+	repo       organization.Repository
+	users      user.UserRepository
+	registrar  user.UserService
+	tokens     jwt.TokenService
+	idGen      id.Generator
+	policy     InvitationPolicy
+	mail       AccountMailService // nil unless invitations are emailed
+	uow        transaction.UnitOfWork
+	emailRules user.EmailRules
+	now        func() time.Time
+	log        logger.Logger
+}
+
+// NewInvitationService creates a new invitation service. Invitees who
+// register through a link are registered by registrar.
+func NewInvitationService(repo organization.Repository, users user.UserRepository, registrar user.UserService, tokens jwt.TokenService, idGen id.Generator, policy InvitationPolicy, opts ...InvitationServiceOption) InvitationService {
+	return NewInvitationServiceWithLogger(repo, users, registrar, tokens, idGen, policy, logger.Get().WithLayer("application").WithComponent("invitation_service"), opts...)
+}
+
+func NewInvitationServiceWithLogger(repo organization.Repository, users user.UserRepository, registrar user.UserService, tokens jwt.TokenService, idGen id.Generator, policy InvitationPolicy, log logger.Logger, opts ...InvitationServiceOption) InvitationService {
+	if repo == nil {
+		panic("organization repository cannot be nil")
+	}
+	if users == nil {
+		panic("user repository cannot be nil")
+	}
+	if registrar == nil {
+		panic("user service cannot be nil")
+	}
+	if tokens == nil {
+		panic("token service cannot be nil")
+	}
+	if idGen == nil {
+		panic("ID generator cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	s := &invitationService{
+		repo:      repo,
+		users:     users,
+		registrar: registrar,
+		tokens:    tokens,
+		idGen:     idGen,
+		policy:    policy,
+		uow:       noopUnitOfWork{},
+		now:       time.Now,
+		log:       log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *invitationService) Invite(ctx context.Context, userID, orgID, email string) (*SentInvitation, error) {
+	if _, err := ownership(ctx, s.repo, "invite_member", userID, orgID); err != nil {
+		return nil, err
+	}
+	if email == "" {
+		return nil, errors.NewRequiredFieldError("email", email)
+	}
+
+	invitee, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if invitee != nil {
+		m, err := s.repo.GetMember(ctx, orgID, invitee.ID)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			return nil, errors.NewDuplicateEntryError("member", "email", email, invitee.ID)
+		}
+	}
+
+	now := s.now()
+	inv := &organization.Invitation{
+		ID:        s.idGen.Generate(),
+		OrgID:     orgID,
+		Email:     s.emailRules.Canonicalize(email),
+		Role:      organization.RoleMember,
+		InvitedBy: userID,
+		ExpiresAt: now.Add(s.policy.TTL),
+		CreatedAt: now,
+	}
+	token, err := s.issue(ctx, inv.ID)
+	if err != nil {
+		return nil, err
+	}
+	inv.TokenHash = organization.HashInviteToken(token)
+	if err := s.repo.CreateInvitation(ctx, inv); err != nil {
+		s.log.Error(ctx, "failed to create invitation", "error", err, "org_id", orgID)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "organization invitation created", "org_id", orgID, "invitation_id", inv.ID, "invited_by", userID)
+	return s.send(ctx, inv, token, invitee)
+}
+
+func (s *invitationService) List(ctx context.Context, userID, orgID string) ([]*organization.Invitation, error) {
+	if _, err := ownership(ctx, s.repo, "list_invitations", userID, orgID); err != nil {
+		return nil, err
+	}
+	invitations, err := s.repo.ListInvitations(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for _, inv := range invitations {
+		inv.Status = inv.StatusAt(now)
+	}
+	return invitations, nil
+}
+
+func (s *invitationService) Resend(ctx context.Context, userID, invitationID string) (*SentInvitation, error) {
+	inv, err := s.owned(ctx, "resend_invitation", userID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.issue(ctx, inv.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := inv.Renew(organization.HashInviteToken(token), s.now().Add(s.policy.TTL)); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateInvitation(ctx, inv); err != nil {
+		if current := s.reread(ctx, inv.ID, err); current != nil {
+			return nil, current.Pending()
+		}
+		s.log.Error(ctx, "failed to renew invitation", "error", err, "invitation_id", inv.ID)
+		return nil, err
+	}
+
+	invitee, err := s.users.GetByEmail(ctx, inv.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "organization invitation resent", "org_id", inv.OrgID, "invitation_id", inv.ID, "user_id", userID)
+	return s.send(ctx, inv, token, invitee)
+}
+
+func (s *invitationService) Revoke(ctx context.Context, userID, invitationID string) error {
+	inv, err := s.owned(ctx, "revoke_invitation", userID, invitationID)
+	if err != nil {
+		return err
+	}
+	if inv.Revoked() {
+		return nil
+	}
+	if err := inv.Revoke(s.now()); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateInvitation(ctx, inv); err != nil {
+		// Accepted or revoked by someone else since it was read
+		if current := s.reread(ctx, inv.ID, err); current != nil {
+			if current.Revoked() {
+				return nil
+			}
+			return current.Pending()
+		}
+		s.log.Error(ctx, "failed to revoke invitation", "error", err, "invitation_id", inv.ID)
+		return err
+	}
+
+	s.log.Info(ctx, "organization invitation revoked", "org_id", inv.OrgID, "invitation_id", inv.ID, "user_id", userID)
+	return nil
+}
+
+func (s *invitationService) Preview(ctx context.Context, token string) (*InvitationPreview, error) {
+	inv, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := inv.Acceptable(s.now()); err != nil {
+		return nil, err
+	}
+
+	o, err := s.repo.Get(ctx, inv.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, errors.NewEntityNotFoundError("invitation", inv.ID)
+	}
+	inviter, err := s.users.GetByID(ctx, inv.InvitedBy)
+	if err != nil {
+		return nil, err
+	}
+	invitee, err := s.users.GetByEmail(ctx, inv.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &InvitationPreview{
+		Email:        inv.Email,
+		OrgID:        o.ID,
+		Organization: o.Name,
+		Role:         inv.Role,
+		ExpiresAt:    inv.ExpiresAt,
+		Registered:   invitee != nil,
+	}
+	if inviter != nil {
+		preview.InvitedBy = inviter.Name
+	}
+	return preview, nil
+}
+
+func (s *invitationService) Accept(ctx context.Context, userID, token string) (*organization.Member, error) {
+	inv, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, errors.NewEntityNotFoundError("user", userID)
+	}
+	// The invitation may predate a change of rules
+	if s.emailRules.Canonicalize(u.Email) != s.emailRules.Canonicalize(inv.Email) {
+		return nil, errors.NewBusinessRuleError("invitation_email_mismatch", "invitation was sent to another email address")
+	}
+
+	return s.accept(ctx, userID, inv)
+}
+
+func (s *invitationService) Register(ctx context.Context, token, name, password string) (*user.User, *organization.Member, error) {
+	inv, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Checked up front so an unusable link does not leave an account
+	// behind
+	if err := inv.Acceptable(s.now()); err != nil {
+		return nil, nil, err
+	}
+
+	// The account is only kept if the invitation is accepted with it
+	var u *user.User
+	var m *organization.Member
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if u, err = s.registrar.Register(ctx, inv.Email, name, password); err != nil {
+			return err
+		}
+		m, err = s.accept(ctx, u.ID, inv)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, m, nil
+}
+
+// accept makes userID a member through inv
+func (s *invitationService) accept(ctx context.Context, userID string, inv *organization.Invitation) (*organization.Member, error) {
+	now := s.now()
+	if err := inv.Accept(userID, now); err != nil {
+		return nil, err
+	}
+	m := &organization.Member{
+		OrgID:     inv.OrgID,
+		UserID:    userID,
+		Role:      inv.Role,
+		CreatedAt: now,
+	}
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddMember(ctx, m); err != nil {
+			return err
+		}
+		return s.repo.UpdateInvitation(ctx, inv)
+	})
+	if err != nil {
+		// Accepted or revoked by someone else since it was read
+		if current := s.reread(ctx, inv.ID, err); current != nil {
+			return nil, current.Pending()
+		}
+		s.log.Error(ctx, "failed to accept invitation", "error", err, "invitation_id", inv.ID, "user_id", userID)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "organization invitation accepted", "org_id", inv.OrgID, "invitation_id", inv.ID, "user_id", userID)
+	return m, nil
+}
+
+// owned returns an invitation of an organization the user owns. Other
+// users' invitations are reported as not found.
+func (s *invitationService) owned(ctx context.Context, operation, userID, invitationID string) (*organization.Invitation, error) {
+	inv, err := s.repo.GetInvitation(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, errors.NewEntityNotFoundError("invitation", invitationID)
+	}
+	if _, err := ownership(ctx, s.repo, operation, userID, inv.OrgID); err != nil {
+		var notFound *errors.EntityNotFoundError
+		if stderrors.As(err, &notFound) {
+			return nil, errors.NewEntityNotFoundError("invitation", invitationID)
+		}
+		return nil, err
+	}
+	return inv, nil
+}
+
+// reread returns the stored invitation when err reports that it stopped
+// being pending after it was read, so callers can report why. It returns
+// nil for other errors or when the invitation cannot be read.
+func (s *invitationService) reread(ctx context.Context, invitationID string, err error) *organization.Invitation {
+	var conflict *errors.ConflictError
+	if !stderrors.As(err, &conflict) || conflict.Code() != errors.CodeVersionMismatch {
+		return nil
+	}
+	current, getErr := s.repo.GetInvitation(ctx, invitationID)
+	if getErr != nil || current == nil || current.Pending() == nil {
+		return nil
+	}
+	return current
+}
+
+// issue signs a link token for an invitation, valid for the TTL
+func (s *invitationService) issue(ctx context.Context, invitationID string) (string, error) {
+	token, err := s.tokens.GenerateInvitationToken(invitationID, tenant.IDFromContext(ctx), s.policy.TTL)
+	if err != nil {
+		s.log.Error(ctx, "failed to sign invitation token", "error", err, "invitation_id", invitationID)
+		return "", err
+	}
+	return token, nil
+}
+
+// resolve returns the invitation a link token was issued for. Tokens that
+// are forged, expired, from another tenant or replaced by a resend are
+// rejected alike.
+func (s *invitationService) resolve(ctx context.Context, token string) (*organization.Invitation, error) {
+	if token == "" {
+		return nil, errors.NewRequiredFieldError("token", token)
+	}
+	invalid := errors.NewBusinessRuleError("invitation_invalid", "invitation link is invalid or has expired")
+
+	claims, err := s.tokens.ValidatePurposeToken(token, jwt.PurposeInvitation)
+	if err != nil || claims.TenantID != tenant.IDFromContext(ctx) {
+		return nil, invalid
+	}
+	inv, err := s.repo.GetInvitationByTokenHash(ctx, organization.HashInviteToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil || inv.ID != claims.InvitationID {
+		return nil, invalid
+	}
+	return inv, nil
+}
+
+// send emails the link to inv unless mail is off or fails, in which case
+// the link is returned to the owner instead. invitee is the account of
+// the invited email, if any.
+func (s *invitationService) send(ctx context.Context, inv *organization.Invitation, token string, invitee *user.User) (*SentInvitation, error) {
+	inv.Status = inv.StatusAt(s.now())
+	sent := &SentInvitation{Invitation: inv}
+	link := s.link(token)
+	if s.mail == nil {
+		sent.Link = link
+		return sent, nil
+	}
+
+	o, err := s.repo.Get(ctx, inv.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	inviter, err := s.users.GetByID(ctx, inv.InvitedBy)
+	if err != nil {
+		return nil, err
+	}
+	var orgName, inviterName, name string
+	if o != nil {
+		orgName = o.Name
+	}
+	if inviter != nil {
+		inviterName = inviter.Name
+	}
+	if invitee != nil {
+		name = invitee.Name
+	}
+
+	if err := s.mail.SendInvitation(ctx, inv.Email, name, inviterName, orgName, link); err != nil {
+		s.log.Warn(ctx, "failed to email invitation", "error", err, "invitation_id", inv.ID)
+		sent.Link = link
+		return sent, nil
+	}
+	sent.Emailed = true
+	return sent, nil
+}
+
+// link returns the invitation page URL carrying token
+func (s *invitationService) link(token string) string {
+	sep := "?"
+	if strings.Contains(s.policy.URL, "?") {
+		sep = "&"
+	}
+	return s.policy.URL + sep + "token=" + url.QueryEscape(token)
+}
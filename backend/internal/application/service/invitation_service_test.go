@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	orgMocks "github.com/cctw-zed/wonder/internal/domain/organization/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+)
+
+type invitationFixture struct {
+	repo   *orgMocks.MockRepository
+	users  *fake.UserRepository
+	tokens *fake.TokenService
+	sender *recordingMailer
+}
+
+// newTestInvitationService creates an invitation service that emails
+// invitations when emailed is set
+func newTestInvitationService(t *testing.T, emailed bool) (InvitationService, *invitationFixture) {
+	logger.Initialize()
+	f := &invitationFixture{
+		repo:   orgMocks.NewMockRepository(gomock.NewController(t)),
+		users:  fake.NewUserRepository(),
+		tokens: fake.NewTokenService(),
+		sender: &recordingMailer{},
+	}
+	require.NoError(t, f.users.Create(context.Background(), &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser}))
+	require.NoError(t, f.users.Create(context.Background(), &user.User{ID: "u-2", Email: "grace@example.com", Name: "Grace", Role: user.RoleUser}))
+
+	var opts []InvitationServiceOption
+	if emailed {
+		renderer, err := mailer.NewRenderer(mailer.Templates)
+		require.NoError(t, err)
+		opts = append(opts, WithInvitationMail(NewAccountMailService(f.sender, renderer, "")))
+	}
+	policy := InvitationPolicy{TTL: 7 * 24 * time.Hour, URL: "https://wonder.example.com/invite"}
+	svc := NewInvitationService(f.repo, f.users, NewUserService(f.users, fake.NewIDGenerator(100)), f.tokens, fake.NewIDGenerator(1), policy, opts...)
+	return svc, f
+}
+
+// inviteToken returns the token of the invitation link in an email
+func inviteToken(t *testing.T, msg *mailer.Message) string {
+	for _, line := range strings.Split(msg.Text, "\n") {
+		if strings.HasPrefix(line, "https://wonder.example.com/invite?") {
+			u, err := url.Parse(line)
+			require.NoError(t, err)
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatalf("no invitation link in %q", msg.Text)
+	return ""
+}
+
+func TestInvitationService_InviteAndAccept(t *testing.T) {
+	ctx := context.Background()
+	svc, f := newTestInvitationService(t, true)
+	owner := &organization.Member{OrgID: "o-1", UserID: "u-1", Role: organization.RoleOwner}
+	org := &organization.Organization{ID: "o-1", Name: "Research"}
+
+	var stored *organization.Invitation
+	f.repo.EXPECT().GetMember(ctx, "o-1", "u-1").Return(owner, nil)
+	f.repo.EXPECT().GetMember(ctx, "o-1", "u-2").Return(nil, nil)
+	f.repo.EXPECT().CreateInvitation(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, i *organization.Invitation) error {
+		stored = i
+		return nil
+	})
+	f.repo.EXPECT().Get(ctx, "o-1").Return(org, nil).Times(2)
+
+	sent, err := svc.Invite(ctx, "u-1", "o-1", "Grace@Example.com")
+	require.NoError(t, err)
+	assert.True(t, sent.Emailed)
+	assert.Empty(t, sent.Link, "emailed links are not shown to the owner")
+	assert.Equal(t, organization.InvitationPending, sent.Status)
+	assert.Equal(t, "grace@example.com", stored.Email)
+	require.Len(t, f.sender.sent, 1)
+	assert.Equal(t, "Ada invited you to join Research", f.sender.sent[0].Subject)
+	assert.Contains(t, f.sender.sent[0].Text, "Hi Grace,")
+	token := inviteToken(t, f.sender.sent[0])
+	assert.Equal(t, organization.HashInviteToken(token), stored.TokenHash)
+
+	f.repo.EXPECT().GetInvitationByTokenHash(ctx, stored.TokenHash).Return(stored, nil).Times(3)
+	preview, err := svc.Preview(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "grace@example.com", preview.Email)
+	assert.Equal(t, "Research", preview.Organization)
+	assert.Equal(t, "Ada", preview.InvitedBy)
+	assert.True(t, preview.Registered)
+
+	var rule *errors.DomainRuleError
+	_, err = svc.Accept(ctx, "u-1", token)
+	assert.ErrorAs(t, err, &rule, "only the invited email can accept")
+	assert.False(t, stored.Accepted())
+
+	f.repo.EXPECT().AddMember(ctx, gomock.Any()).Return(nil)
+	f.repo.EXPECT().UpdateInvitation(ctx, stored).Return(nil)
+	m, err := svc.Accept(ctx, "u-2", token)
+	require.NoError(t, err)
+	assert.Equal(t, "o-1", m.OrgID)
+	assert.Equal(t, "u-2", stored.AcceptedBy)
+}
+
+func TestInvitationService_InvalidLinks(t *testing.T) {
+	ctx := context.Background()
+	svc, f := newTestInvitationService(t, false)
+	var rule *errors.DomainRuleError
+
+	_, err := svc.Preview(ctx, "forged")
+	assert.ErrorAs(t, err, &rule)
+
+	login, err := f.tokens.GenerateToken("u-2")
+	require.NoError(t, err)
+	_, err = svc.Accept(ctx, "u-2", login)
+	assert.ErrorAs(t, err, &rule, "tokens for other purposes are rejected")
+
+	other, err := f.tokens.GenerateInvitationToken("i-1", "globex", time.Hour)
+	require.NoError(t, err)
+	_, err = svc.Accept(ctx, "u-2", other)
+	assert.ErrorAs(t, err, &rule, "links of other tenants are rejected")
+
+	replaced, err := f.tokens.GenerateInvitationToken("i-1", tenant.DefaultID, time.Hour)
+	require.NoError(t, err)
+	f.repo.EXPECT().GetInvitationByTokenHash(ctx, organization.HashInviteToken(replaced)).Return(nil, nil)
+	_, err = svc.Accept(ctx, "u-2", replaced)
+	assert.ErrorAs(t, err, &rule, "links replaced by a resend are rejected")
+}
+
+func TestInvitationService_ResendAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	svc, f := newTestInvitationService(t, false)
+	now := time.Now()
+	inv := &organization.Invitation{ID: "i-1", OrgID: "o-1", Email: "alan@example.com", Role: organization.RoleMember, InvitedBy: "u-1", ExpiresAt: now.Add(-time.Hour)}
+	f.repo.EXPECT().GetInvitation(ctx, "i-1").Return(inv, nil).AnyTimes()
+	f.repo.EXPECT().GetMember(ctx, "o-1", "u-1").Return(&organization.Member{OrgID: "o-1", UserID: "u-1", Role: organization.RoleOwner}, nil).AnyTimes()
+
+	t.Run("only the owner manages invitations", func(t *testing.T) {
+		f.repo.EXPECT().GetMember(ctx, "o-1", "u-2").Return(&organization.Member{OrgID: "o-1", UserID: "u-2", Role: organization.RoleMember}, nil)
+		var forbidden *errors.UnauthorizedError
+		assert.ErrorAs(t, svc.Revoke(ctx, "u-2", "i-1"), &forbidden)
+
+		f.repo.EXPECT().GetMember(ctx, "o-1", "u-9").Return(nil, nil)
+		var notFound *errors.EntityNotFoundError
+		_, err := svc.Resend(ctx, "u-9", "i-1")
+		assert.ErrorAs(t, err, &notFound)
+	})
+
+	f.repo.EXPECT().UpdateInvitation(ctx, inv).Return(nil).Times(2)
+	sent, err := svc.Resend(ctx, "u-1", "i-1")
+	require.NoError(t, err)
+	assert.False(t, sent.Emailed)
+	assert.True(t, strings.HasPrefix(sent.Link, "https://wonder.example.com/invite?token="), sent.Link)
+	assert.Equal(t, organization.InvitationPending, sent.Status, "resending renews expired invitations")
+	assert.True(t, inv.ExpiresAt.After(now.Add(6*24*time.Hour)))
+
+	require.NoError(t, svc.Revoke(ctx, "u-1", "i-1"))
+	assert.True(t, inv.Revoked())
+	var rule *errors.DomainRuleError
+	_, err = svc.Resend(ctx, "u-1", "i-1")
+	assert.ErrorAs(t, err, &rule)
+
+	f.repo.EXPECT().ListInvitations(ctx, "o-1").Return([]*organization.Invitation{inv}, nil)
+	invitations, err := svc.List(ctx, "u-1", "o-1")
+	require.NoError(t, err)
+	require.Len(t, invitations, 1)
+	assert.Equal(t, organization.InvitationRevoked, invitations[0].Status)
+}
+
+func TestInvitationService_Register(t *testing.T) {
+	ctx := context.Background()
+	svc, f := newTestInvitationService(t, false)
+
+	token, err := f.tokens.GenerateInvitationToken("i-1", tenant.DefaultID, time.Hour)
+	require.NoError(t, err)
+	inv := &organization.Invitation{ID: "i-1", OrgID: "o-1", Email: "alan@example.com", Role: organization.RoleMember, TokenHash: organization.HashInviteToken(token), ExpiresAt: time.Now().Add(time.Hour)}
+	f.repo.EXPECT().GetInvitationByTokenHash(ctx, inv.TokenHash).Return(inv, nil).Times(2)
+
+	f.repo.EXPECT().AddMember(ctx, gomock.Any()).Return(nil)
+	f.repo.EXPECT().UpdateInvitation(ctx, inv).Return(nil)
+	u, m, err := svc.Register(ctx, token, "Alan", "password123")
+	require.NoError(t, err)
+	assert.Equal(t, "alan@example.com", u.Email)
+	assert.Equal(t, u.ID, m.UserID)
+	assert.Equal(t, organization.RoleMember, m.Role)
+
+	// A used link does not register another account
+	var rule *errors.DomainRuleError
+	_, _, err = svc.Register(ctx, token, "Alan", "password123")
+	assert.ErrorAs(t, err, &rule)
+}
+
+// nestingUnitOfWork records, for every unit of work, whether it ran
+// inside another one
+type nestingUnitOfWork struct {
+	nested []bool
+}
+
+func (u *nestingUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	u.nested = append(u.nested, ctx.Value(txCtxKey{}) == true)
+	return fn(context.WithValue(ctx, txCtxKey{}, true))
+}
+
+func TestInvitationService_RegisterIsAtomic(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	repo := orgMocks.NewMockRepository(gomock.NewController(t))
+	users := fake.NewUserRepository()
+	tokens := fake.NewTokenService()
+	uow := &nestingUnitOfWork{}
+	policy := InvitationPolicy{TTL: time.Hour, URL: "https://wonder.example.com/invite"}
+	svc := NewInvitationService(repo, users, NewUserService(users, fake.NewIDGenerator(100), WithUnitOfWork(uow)), tokens, fake.NewIDGenerator(1), policy, WithInvitationUnitOfWork(uow))
+
+	token, err := tokens.GenerateInvitationToken("i-1", tenant.DefaultID, time.Hour)
+	require.NoError(t, err)
+	inv := &organization.Invitation{ID: "i-1", OrgID: "o-1", Email: "alan@example.com", Role: organization.RoleMember, TokenHash: organization.HashInviteToken(token), ExpiresAt: time.Now().Add(time.Hour)}
+	repo.EXPECT().GetInvitationByTokenHash(ctx, inv.TokenHash).Return(inv, nil)
+
+	// The invitation was revoked between reading and accepting it
+	revoked := *inv
+	revokedAt := time.Now()
+	revoked.RevokedAt = &revokedAt
+	repo.EXPECT().AddMember(inTx, gomock.Any()).Return(nil)
+	repo.EXPECT().UpdateInvitation(inTx, inv).Return(errors.NewVersionMismatchError("invitation", "i-1", ""))
+	repo.EXPECT().GetInvitation(inTx, "i-1").Return(&revoked, nil)
+
+	_, _, err = svc.Register(ctx, token, "Alan", "password123")
+	var rule *errors.DomainRuleError
+	require.ErrorAs(t, err, &rule)
+	assert.Equal(t, "invitation_revoked", rule.Rule)
+	// Registration and acceptance ran inside the one unit of work that
+	// failed, which rolls the new account back
+	assert.Equal(t, []bool{false, true, true}, uow.nested)
+}
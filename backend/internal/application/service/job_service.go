@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/textproto"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/jobs"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// Background job types
+const (
+	JobSendEmail    = "send-email"
+	JobRebuildStats = "rebuild-stats"
+	// JobExportUserData generates a user's data export
+	JobExportUserData = "export-user-data"
+	// JobReencryptPII re-encrypts personal data with the active key
+	JobReencryptPII = "reencrypt-pii"
+	// JobReindexUserSearch rebuilds the user search index from the database
+	JobReindexUserSearch = "reindex-user-search"
+	// JobCanonicalizeEmails recomputes the canonical email of every user
+	JobCanonicalizeEmails = "canonicalize-emails"
+	// JobDeliverNotification delivers a notification on its channels
+	JobDeliverNotification = "deliver-notification"
+)
+
+// PIIReencryptor rewrites stored personal data encrypted with retired keys,
+// or not encrypted yet, with the active key
+type PIIReencryptor interface {
+	Reencrypt(ctx context.Context) (int, error)
+}
+
+// UserSearchReindexer rebuilds the user search index from the users table
+type UserSearchReindexer interface {
+	Reindex(ctx context.Context) (int, error)
+}
+
+// EmailCanonicalizer recomputes canonical emails after the email rules
+// change
+type EmailCanonicalizer interface {
+	Canonicalize(ctx context.Context) (int, error)
+}
+
+// SendEmailPayload is the payload of a send-email job. Template is one of
+// the account email templates.
+type SendEmailPayload struct {
+	Template string `json:"template"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Link     string `json:"link,omitempty"`
+	// DeleteAt is set for deletion_scheduled and data_export_ready emails,
+	// along with the recipient's locale preferences to show it in
+	DeleteAt time.Time `json:"delete_at,omitzero"`
+	user.LocalePrefs
+	// Title and Body are the text of notification emails
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	// Inviter and Organization are set for invitation emails
+	Inviter      string `json:"inviter,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// RebuildStatsPayload is the payload of a rebuild-stats job. An empty
+// tenant is the tenant of whoever enqueued the job; zero days selects the
+// default window.
+type RebuildStatsPayload struct {
+	TenantID string `json:"tenant_id,omitempty"`
+	Days     int    `json:"days,omitempty"`
+}
+
+// RegisterJobHandlers registers the handlers of the application's job
+// types with worker. A nil service leaves its job type unhandled.
+func RegisterJobHandlers(worker *jobs.Worker, mail AccountMailService, reporting ReportingService, exports DataExportService, reencryptor PIIReencryptor, reindexer UserSearchReindexer, canonicalizer EmailCanonicalizer, notifications NotificationService) {
+	if mail != nil {
+		worker.Register(JobSendEmail, func(ctx context.Context, job *jobs.Job) error {
+			var payload SendEmailPayload
+			if err := job.Decode(&payload); err != nil {
+				return err
+			}
+			var err error
+			switch payload.Template {
+			case MailWelcome:
+				err = mail.SendWelcome(ctx, payload.Email, payload.Name)
+			case MailVerification:
+				err = mail.SendVerification(ctx, payload.Email, payload.Name, payload.Link)
+			case MailPasswordReset:
+				err = mail.SendPasswordReset(ctx, payload.Email, payload.Name)
+			case MailDeletionScheduled:
+				err = mail.SendDeletionScheduled(ctx, payload.Email, payload.Name, payload.DeleteAt, payload.LocalePrefs)
+			case MailDeletionCancelled:
+				err = mail.SendDeletionCancelled(ctx, payload.Email, payload.Name)
+			case MailAccountDeleted:
+				err = mail.SendAccountDeleted(ctx, payload.Email, payload.Name)
+			case MailDataExportReady:
+				err = mail.SendDataExportReady(ctx, payload.Email, payload.Name, payload.DeleteAt, payload.LocalePrefs)
+			case MailNotification:
+				err = mail.SendNotification(ctx, payload.Email, payload.Name, payload.Title, payload.Body)
+			case MailInvitation:
+				err = mail.SendInvitation(ctx, payload.Email, payload.Name, payload.Inviter, payload.Organization, payload.Link)
+			default:
+				return jobs.Permanent(fmt.Errorf("unknown email template %q", payload.Template))
+			}
+			// A 5xx reply, such as an unknown recipient, fails every time
+			var reply *textproto.Error
+			if stderrors.As(err, &reply) && reply.Code >= 500 {
+				return jobs.Permanent(err)
+			}
+			return err
+		})
+	}
+
+	if reporting != nil {
+		worker.Register(JobRebuildStats, func(ctx context.Context, job *jobs.Job) error {
+			var payload RebuildStatsPayload
+			if err := job.Decode(&payload); err != nil {
+				return err
+			}
+			if payload.TenantID != "" {
+				ctx = tenant.WithID(ctx, payload.TenantID)
+			}
+			return reporting.RebuildStats(ctx, payload.Days)
+		})
+	}
+
+	if exports != nil {
+		worker.Register(JobExportUserData, func(ctx context.Context, job *jobs.Job) error {
+			var payload ExportUserDataPayload
+			if err := job.Decode(&payload); err != nil {
+				return err
+			}
+			if payload.ExportID == "" {
+				return jobs.Permanent(errors.NewRequiredFieldError("export_id", payload.ExportID))
+			}
+			if payload.TenantID != "" {
+				ctx = tenant.WithID(ctx, payload.TenantID)
+			}
+			return exports.GenerateExport(ctx, payload.ExportID)
+		})
+	}
+
+	if reencryptor != nil {
+		worker.Register(JobReencryptPII, func(ctx context.Context, job *jobs.Job) error {
+			_, err := reencryptor.Reencrypt(ctx)
+			return err
+		})
+	}
+
+	if reindexer != nil {
+		worker.Register(JobReindexUserSearch, func(ctx context.Context, job *jobs.Job) error {
+			_, err := reindexer.Reindex(ctx)
+			return err
+		})
+	}
+
+	if canonicalizer != nil {
+		worker.Register(JobCanonicalizeEmails, func(ctx context.Context, job *jobs.Job) error {
+			_, err := canonicalizer.Canonicalize(ctx)
+			return err
+		})
+	}
+
+	if notifications != nil {
+		worker.Register(JobDeliverNotification, func(ctx context.Context, job *jobs.Job) error {
+			var payload DeliverNotificationPayload
+			if err := job.Decode(&payload); err != nil {
+				return err
+			}
+			if payload.ID == "" || payload.UserID == "" {
+				return jobs.Permanent(errors.NewRequiredFieldError("id", payload.ID))
+			}
+			if payload.TenantID != "" {
+				ctx = tenant.WithID(ctx, payload.TenantID)
+			}
+			return notifications.Deliver(ctx, &payload)
+		})
+	}
+}
+
+// queuedAccountMailService sends account emails through send-email jobs,
+// so a mail server outage is retried and, failing that, dead-lettered
+// instead of losing the email
+type queuedAccountMailService struct {
+	queue jobs.Queue
+}
+
+// NewQueuedAccountMailService creates an AccountMailService that enqueues
+// each email as a send-email job
+func NewQueuedAccountMailService(queue jobs.Queue) AccountMailService {
+	if queue == nil {
+		panic("job queue cannot be nil")
+	}
+	return &queuedAccountMailService{queue: queue}
+}
+
+func (s *queuedAccountMailService) SendWelcome(ctx context.Context, email, name string) error {
+	return s.enqueue(ctx, SendEmailPayload{Template: MailWelcome, Email: email, Name: name})
+}
+
+func (s *queuedAccountMailService) SendVerification(ctx context.Context, email, name, link string) error {
+	return s.enqueue(ctx, SendEmailPayload{Template: MailVerification, Email: email, Name: name, Link: link})
+}
+
+func (s *queuedAccountMailService) SendPasswordReset(ctx context.Context, email, name string) error {
+	return s.enqueue(ctx, SendEmailPayload{Template: MailPasswordReset, Email: email, Name: name})
+}
+
+func (s *queuedAccountMailService) SendDeletionScheduled(ctx context.Context, email, name string, deleteAt time.Time, prefs user.LocalePrefs) error {
+	return s.enqueue(ctx, SendEmailPayload{Template: MailDeletionScheduled, Email: email, Name: name, DeleteAt: deleteAt, LocalePrefs: prefs})
+}
+
+func (s *queuedAccountMailService) SendDeletionCancelled(ctx context.Context, email, name string) error {
+	return s.enqueue(ctx, SendEmailPayload{Template: MailDeletionCancelled, Email: email, Name: name})
+}
+
+func (s *queuedAccountMailService) SendAccountDeleted(ctx context.Context, email, name string) error {
+	return s.enqueue(ctx, SendEmailPayload{Template: MailAccountDeleted, Email: email, Name: name})
+}
+
+func (s *queuedAccountMailService) SendDataExportReady(ctx context.Context, email, name string, expiresAt time.Time, prefs user.LocalePrefs) error {
+	return s.enqueue(ctx, SendEmailPayload{Template: MailDataExportReady, Email: email, Name: name, DeleteAt: expiresAt, LocalePrefs: prefs})
+}
+
+func (s *queuedAccountMailService) SendNotification(ctx context.Context, email, name, title, body string) error {
+	return s.enqueue(ctx, SendEmailPayload{Template: MailNotification, Email: email, Name: name, Title: title, Body: body})
+}
+
+func (s *queuedAccountMailService) SendInvitation(ctx context.Context, email, name, inviter, organization, link string) error {
+	return s.enqueue(ctx, SendEmailPayload{Template: MailInvitation, Email: email, Name: name, Inviter: inviter, Organization: organization, Link: link})
+}
+
+func (s *queuedAccountMailService) enqueue(ctx context.Context, payload SendEmailPayload) error {
+	job, err := jobs.NewJob(JobSendEmail, payload)
+	if err != nil {
+		return err
+	}
+	return s.queue.Enqueue(ctx, job)
+}
+
+// DeadJobPage is one page of dead-lettered jobs, most recently failed first
+type DeadJobPage struct {
+	Jobs       []*jobs.Job `json:"jobs"`
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	PageSize   int         `json:"page_size"`
+	TotalPages int         `json:"total_pages"`
+}
+
+// JobService lets administrators inspect and manage background jobs
+type JobService interface {
+	// Stats counts the jobs in each state
+	Stats(ctx context.Context) (jobs.Stats, error)
+	// ListDead lists dead-lettered jobs
+	ListDead(ctx context.Context, page, pageSize int) (*DeadJobPage, error)
+	// RetryDead makes a dead job due now with its attempts reset
+	RetryDead(ctx context.Context, id string) error
+	// DeleteDead discards a dead job
+	DeleteDead(ctx context.Context, id string) error
+	// Enqueue adds a job of a type the worker handles
+	Enqueue(ctx context.Context, jobType string, payload json.RawMessage) (*jobs.Job, error)
+}
+
+type jobService struct {
+	queue  jobs.Queue
+	worker *jobs.Worker
+	log    logger.Logger
+}
+
+// NewJobService creates a new job service. worker decides which job types
+// may be enqueued.
+func NewJobService(queue jobs.Queue, worker *jobs.Worker) JobService {
+	return NewJobServiceWithLogger(queue, worker, logger.Get().WithLayer("application").WithComponent("job_service"))
+}
+
+func NewJobServiceWithLogger(queue jobs.Queue, worker *jobs.Worker, log logger.Logger) JobService {
+	if queue == nil {
+		panic("job queue cannot be nil")
+	}
+	if worker == nil {
+		panic("job worker cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &jobService{
+		queue:  queue,
+		worker: worker,
+		log:    log,
+	}
+}
+
+func (s *jobService) Stats(ctx context.Context) (jobs.Stats, error) {
+	return s.queue.Stats(ctx)
+}
+
+func (s *jobService) ListDead(ctx context.Context, page, pageSize int) (*DeadJobPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	dead, total, err := s.queue.Dead(ctx, (page-1)*pageSize, pageSize)
+	if err != nil {
+		s.log.Error(ctx, "failed to list dead jobs", "error", err)
+		return nil, err
+	}
+
+	return &DeadJobPage{
+		Jobs:       dead,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+func (s *jobService) RetryDead(ctx context.Context, id string) error {
+	if err := s.queue.RetryDead(ctx, id); err != nil {
+		return s.mapError(ctx, err, id, "retry")
+	}
+	s.log.Info(ctx, "dead job requeued", "job_id", id)
+	return nil
+}
+
+func (s *jobService) DeleteDead(ctx context.Context, id string) error {
+	if err := s.queue.DeleteDead(ctx, id); err != nil {
+		return s.mapError(ctx, err, id, "delete")
+	}
+	s.log.Info(ctx, "dead job deleted", "job_id", id)
+	return nil
+}
+
+func (s *jobService) Enqueue(ctx context.Context, jobType string, payload json.RawMessage) (*jobs.Job, error) {
+	if jobType == "" {
+		return nil, errors.NewRequiredFieldError("type", jobType)
+	}
+	if !s.worker.Handles(jobType) {
+		return nil, errors.NewInvalidValueError("type", jobType, "no handler registered for this job type")
+	}
+	if len(payload) > 0 && !json.Valid(payload) {
+		return nil, errors.NewInvalidValueError("payload", string(payload), "must be valid JSON")
+	}
+
+	job := &jobs.Job{Type: jobType, Payload: payload}
+	if jobType == JobRebuildStats {
+		var stats RebuildStatsPayload
+		if err := job.Decode(&stats); err != nil {
+			return nil, errors.NewInvalidValueError("payload", string(payload), err.Error())
+		}
+		if stats.TenantID == "" {
+			stats.TenantID = tenant.IDFromContext(ctx)
+		}
+		job.Payload, _ = json.Marshal(stats)
+	}
+
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		s.log.Error(ctx, "failed to enqueue job", "error", err, "type", jobType)
+		return nil, err
+	}
+	s.log.Info(ctx, "job enqueued", "job_id", job.ID, "type", jobType)
+	return job, nil
+}
+
+func (s *jobService) mapError(ctx context.Context, err error, id, operation string) error {
+	if stderrors.Is(err, jobs.ErrNotFound) {
+		return errors.NewEntityNotFoundError("job", id)
+	}
+	s.log.Error(ctx, "failed to "+operation+" dead job", "error", err, "job_id", id)
+	return err
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/stats"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/jobs"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+)
+
+// tenantRecordingReporting records the tenant stats were rebuilt for
+type tenantRecordingReporting struct {
+	tenantID string
+	days     int
+}
+
+func (r *tenantRecordingReporting) GetStats(ctx context.Context, days int) (*stats.Stats, error) {
+	return &stats.Stats{}, nil
+}
+
+func (r *tenantRecordingReporting) RebuildStats(ctx context.Context, days int) error {
+	r.tenantID = tenant.IDFromContext(ctx)
+	r.days = days
+	return nil
+}
+
+func TestJobs_SendEmailThroughQueue(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	renderer, err := mailer.NewRenderer(mailer.Templates)
+	require.NoError(t, err)
+	sender := &recordingMailer{}
+	queue := jobs.NewMemoryQueue()
+	worker := jobs.NewWorker(queue)
+	RegisterJobHandlers(worker, NewAccountMailService(sender, renderer, ""), nil, nil, nil, nil, nil, nil)
+
+	queued := NewQueuedAccountMailService(queue)
+	require.NoError(t, queued.SendWelcome(ctx, "ada@example.com", "Ada"))
+	assert.Empty(t, sender.sent)
+
+	ran, err := worker.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "Welcome to Wonder, Ada", sender.sent[0].Subject)
+
+	// A rejected recipient is dead-lettered without retries
+	sender.err = &textproto.Error{Code: 550, Msg: "no such user"}
+	require.NoError(t, queued.SendPasswordReset(ctx, "nobody@example.com", "Nobody"))
+	_, err = worker.RunOnce(ctx)
+	require.NoError(t, err)
+	counts, err := queue.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counts.Dead)
+}
+
+func TestJobService(t *testing.T) {
+	logger.Initialize()
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	queue := jobs.NewMemoryQueue()
+	worker := jobs.NewWorker(queue)
+	reporting := &tenantRecordingReporting{}
+	RegisterJobHandlers(worker, nil, reporting, nil, nil, nil, nil, nil)
+	svc := NewJobService(queue, worker)
+
+	t.Run("rejects unhandled job types", func(t *testing.T) {
+		_, err := svc.Enqueue(ctx, JobSendEmail, nil)
+		var verr *errors.ValidationError
+		assert.ErrorAs(t, err, &verr)
+
+		_, err = svc.Enqueue(ctx, "", nil)
+		assert.ErrorAs(t, err, &verr)
+	})
+
+	t.Run("rebuilds stats for the enqueueing tenant", func(t *testing.T) {
+		job, err := svc.Enqueue(ctx, JobRebuildStats, json.RawMessage(`{"days":7}`))
+		require.NoError(t, err)
+		assert.NotEmpty(t, job.ID)
+
+		ran, err := worker.RunOnce(context.Background())
+		require.NoError(t, err)
+		assert.True(t, ran)
+		assert.Equal(t, "acme", reporting.tenantID)
+		assert.Equal(t, 7, reporting.days)
+	})
+
+	t.Run("manages dead jobs", func(t *testing.T) {
+		require.NoError(t, queue.Enqueue(ctx, &jobs.Job{ID: "orphan", Type: "unknown"}))
+		_, err := worker.RunOnce(ctx)
+		require.NoError(t, err)
+
+		page, err := svc.ListDead(ctx, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), page.Total)
+		assert.Equal(t, 1, page.TotalPages)
+		require.Len(t, page.Jobs, 1)
+		assert.Contains(t, page.Jobs[0].LastError, "no handler")
+
+		require.NoError(t, svc.RetryDead(ctx, "orphan"))
+		counts, err := svc.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, jobs.Stats{Pending: 1}, counts)
+
+		var notFound *errors.EntityNotFoundError
+		assert.ErrorAs(t, svc.DeleteDead(ctx, "orphan"), &notFound)
+		assert.ErrorAs(t, svc.RetryDead(ctx, "missing"), &notFound)
+	})
+}
@@ -0,0 +1,522 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	stderrors "errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/mfa"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+	"github.com/cctw-zed/wonder/pkg/totp"
+)
+
+// MFAEnforcement decides who must enroll a second factor before a login
+// completes
+type MFAEnforcement string
+
+const (
+	// MFAEnforcementOptional lets every user choose
+	MFAEnforcementOptional MFAEnforcement = "optional"
+	// MFAEnforcementAdmins requires administrators to enroll
+	MFAEnforcementAdmins MFAEnforcement = "admins"
+	// MFAEnforcementAll requires every user to enroll
+	MFAEnforcementAll MFAEnforcement = "all"
+)
+
+// MFAPolicy configures codes, enforcement and the limit on wrong codes
+type MFAPolicy struct {
+	Enforcement MFAEnforcement
+	// Issuer names the service in authenticator apps
+	Issuer string
+	// Skew is how many time steps of clock drift codes may have
+	Skew int
+	// MaxAttempts wrong codes in a row lock code checks for LockDuration
+	MaxAttempts  int
+	LockDuration time.Duration
+	// RecoveryCodes is how many recovery codes are issued at a time
+	RecoveryCodes int
+	// TrustedDeviceTTL is how long a remembered device may skip the second
+	// step; zero disables remembering devices
+	TrustedDeviceTTL time.Duration
+}
+
+// DeviceInfo describes the device a login comes from
+type DeviceInfo struct {
+	Name      string
+	UserAgent string
+	IPAddress string
+}
+
+// MFASetup is a new authenticator secret, as text to type in and as the
+// URI to show as a QR code
+type MFASetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAStatus describes a user's second factor
+type MFAStatus struct {
+	Enabled     bool       `json:"enabled"`
+	Required    bool       `json:"required"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// RecoveryCodesRemaining is how many recovery codes are still unused
+	RecoveryCodesRemaining int `json:"recovery_codes_remaining"`
+}
+
+// MFAService manages users' authenticator enrollments and checks their
+// codes
+type MFAService interface {
+	Status(ctx context.Context, userID string) (*MFAStatus, error)
+	// Enabled reports whether the user has a confirmed authenticator
+	Enabled(ctx context.Context, userID string) (bool, error)
+	// EnrollmentRequired reports whether the policy requires u to use a
+	// second factor
+	EnrollmentRequired(u *user.User) bool
+	// BeginEnrollment generates a new secret for the user. It replaces an
+	// unconfirmed one and fails once an authenticator is confirmed.
+	BeginEnrollment(ctx context.Context, userID string) (*MFASetup, error)
+	// ConfirmEnrollment enables the second factor once code shows the
+	// authenticator works, and returns the recovery codes. They are not
+	// shown again.
+	ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error)
+	// Verify checks a code from the authenticator or an unused recovery
+	// code, which is used up
+	Verify(ctx context.Context, userID, code string) error
+	// RegenerateRecoveryCodes replaces the recovery codes after checking
+	// an authenticator code
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+	// Disable removes the authenticator and forgets trusted devices after
+	// checking a code. Users the policy requires a second factor of cannot
+	// disable it.
+	Disable(ctx context.Context, userID, code string) error
+	// TrustDevice remembers a device that just completed the second step
+	// and returns its token. Only a hash of the token is stored.
+	TrustDevice(ctx context.Context, userID string, device *DeviceInfo) (string, error)
+	// DeviceTrusted reports whether token belongs to an unexpired device
+	// of the user
+	DeviceTrusted(ctx context.Context, userID, token string) bool
+	ListTrustedDevices(ctx context.Context, userID string) ([]*mfa.TrustedDevice, error)
+	RevokeTrustedDevice(ctx context.Context, userID, deviceID string) error
+	// DeleteExpiredDevices removes expired trusted devices of every tenant
+	DeleteExpiredDevices(ctx context.Context) (int64, error)
+}
+
+// MFAServiceOption configures optional MFA service collaborators
+type MFAServiceOption func(*mfaService)
+
+// WithMFAAuditLog records enabling and disabling second factors
+func WithMFAAuditLog(recorder audit.Recorder) MFAServiceOption {
+	return func(s *mfaService) {
+		s.audit = recorder
+	}
+}
+
+type mfaService struct {
+	repo   mfa.Repository
+	users  user.UserRepository
+	idGen  id.Generator
+	policy MFAPolicy
+	audit  audit.Recorder
+	now    func() time.Time
+	log    logger.Logger
+}
+
+// NewMFAService creates a new MFA service
+func NewMFAService(repo mfa.Repository, users user.UserRepository, idGen id.Generator, policy MFAPolicy, opts ...MFAServiceOption) MFAService {
+	return NewMFAServiceWithLogger(repo, users, idGen, policy, logger.Get().WithLayer("application").WithComponent("mfa_service"), opts...)
+}
+
+func NewMFAServiceWithLogger(repo mfa.Repository, users user.UserRepository, idGen id.Generator, policy MFAPolicy, log logger.Logger, opts ...MFAServiceOption) MFAService {
+	if repo == nil {
+		panic("mfa repository cannot be nil")
+	}
+	if users == nil {
+		panic("user repository cannot be nil")
+	}
+	if idGen == nil {
+		panic("ID generator cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	s := &mfaService{
+		repo:   repo,
+		users:  users,
+		idGen:  idGen,
+		policy: policy,
+		now:    time.Now,
+		log:    log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *mfaService) Status(ctx context.Context, userID string) (*MFAStatus, error) {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{Required: s.EnrollmentRequired(u)}
+	if e != nil && e.Confirmed() {
+		status.Enabled = true
+		status.ConfirmedAt = e.ConfirmedAt
+		status.RecoveryCodesRemaining = len(e.RecoveryCodes)
+	}
+	return status, nil
+}
+
+func (s *mfaService) Enabled(ctx context.Context, userID string) (bool, error) {
+	e, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return e != nil && e.Confirmed(), nil
+}
+
+func (s *mfaService) EnrollmentRequired(u *user.User) bool {
+	switch s.policy.Enforcement {
+	case MFAEnforcementAll:
+		return true
+	case MFAEnforcementAdmins:
+		return u.IsAdmin()
+	default:
+		return false
+	}
+}
+
+func (s *mfaService) BeginEnrollment(ctx context.Context, userID string) (*MFASetup, error) {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Confirmed() {
+		return nil, errors.NewConflictError("mfa_enrollment", "two-factor authentication is already enabled", userID)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Save(ctx, &mfa.Enrollment{UserID: userID, Secret: secret}); err != nil {
+		s.log.Error(ctx, "failed to save mfa enrollment", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "mfa enrollment started", "user_id", userID)
+	return &MFASetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.policy.Issuer, u.Email, secret),
+	}, nil
+}
+
+func (s *mfaService) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	codes := generateRecoveryCodes(s.policy.RecoveryCodes)
+	_, err := s.checkCode(ctx, userID, code, false, s.pendingEnrollment, func(e *mfa.Enrollment) {
+		now := s.now()
+		e.ConfirmedAt = &now
+		e.SetRecoveryCodes(codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(ctx, userID, audit.ActionEnableMFA)
+	s.log.Info(ctx, "mfa enabled", "user_id", userID)
+	return codes, nil
+}
+
+func (s *mfaService) Verify(ctx context.Context, userID, code string) error {
+	_, err := s.checkCode(ctx, userID, code, true, s.confirmedEnrollment, nil)
+	return err
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	codes := generateRecoveryCodes(s.policy.RecoveryCodes)
+	_, err := s.checkCode(ctx, userID, code, false, s.confirmedEnrollment, func(e *mfa.Enrollment) {
+		e.SetRecoveryCodes(codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "mfa recovery codes regenerated", "user_id", userID)
+	return codes, nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, userID, code string) error {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.EnrollmentRequired(u) {
+		return errors.NewBusinessLogicError("disable_mfa", "two-factor authentication is required for this account")
+	}
+	if _, err := s.checkCode(ctx, userID, code, true, s.confirmedEnrollment, nil); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		s.log.Error(ctx, "failed to delete mfa enrollment", "error", err, "user_id", userID)
+		return err
+	}
+	// Remembered devices only skipped the factor that is now gone
+	if _, err := s.repo.DeleteDevices(ctx, userID); err != nil {
+		s.log.Error(ctx, "failed to forget trusted devices", "error", err, "user_id", userID)
+		return err
+	}
+
+	s.recordAudit(ctx, userID, audit.ActionDisableMFA)
+	s.log.Info(ctx, "mfa disabled", "user_id", userID)
+	return nil
+}
+
+func (s *mfaService) TrustDevice(ctx context.Context, userID string, device *DeviceInfo) (string, error) {
+	if s.policy.TrustedDeviceTTL <= 0 {
+		return "", errors.NewBusinessLogicError("trust_device", "remembering devices is disabled")
+	}
+	if device == nil {
+		device = &DeviceInfo{}
+	}
+
+	token := rand.Text()
+	now := s.now()
+	d := &mfa.TrustedDevice{
+		ID:         s.idGen.Generate(),
+		UserID:     userID,
+		TokenHash:  mfa.HashDeviceToken(token),
+		Name:       truncate(device.Name, 255),
+		UserAgent:  truncate(device.UserAgent, 512),
+		IPAddress:  truncate(device.IPAddress, 64),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.policy.TrustedDeviceTTL),
+	}
+	if err := s.repo.CreateDevice(ctx, d); err != nil {
+		s.log.Error(ctx, "failed to save trusted device", "error", err, "user_id", userID)
+		return "", err
+	}
+
+	s.log.Info(ctx, "device trusted", "user_id", userID, "device_id", d.ID, "expires_at", d.ExpiresAt)
+	return token, nil
+}
+
+func (s *mfaService) DeviceTrusted(ctx context.Context, userID, token string) bool {
+	if token == "" || s.policy.TrustedDeviceTTL <= 0 {
+		return false
+	}
+	d, err := s.repo.GetDeviceByTokenHash(ctx, mfa.HashDeviceToken(token))
+	if err != nil {
+		// Failing closed only costs the user a code
+		s.log.Warn(ctx, "trusted device lookup failed", "error", err, "user_id", userID)
+		return false
+	}
+	now := s.now()
+	if d == nil || d.UserID != userID || d.Expired(now) {
+		return false
+	}
+
+	if err := s.repo.TouchDevice(ctx, d.ID, now); err != nil {
+		s.log.Warn(ctx, "failed to record trusted device use", "error", err, "device_id", d.ID)
+	}
+	return true
+}
+
+func (s *mfaService) ListTrustedDevices(ctx context.Context, userID string) ([]*mfa.TrustedDevice, error) {
+	if userID == "" {
+		return nil, errors.NewRequiredFieldError("user_id", userID)
+	}
+	return s.repo.ListDevices(ctx, userID, s.now())
+}
+
+func (s *mfaService) RevokeTrustedDevice(ctx context.Context, userID, deviceID string) error {
+	if err := s.repo.DeleteDevice(ctx, userID, deviceID); err != nil {
+		return err
+	}
+	s.log.Info(ctx, "trusted device revoked", "user_id", userID, "device_id", deviceID)
+	return nil
+}
+
+func (s *mfaService) DeleteExpiredDevices(ctx context.Context) (int64, error) {
+	deleted, err := s.repo.DeleteExpiredDevices(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		s.log.Info(ctx, "expired trusted devices deleted", "deleted", deleted)
+	}
+	return deleted, nil
+}
+
+func (s *mfaService) getUser(ctx context.Context, userID string) (*user.User, error) {
+	if userID == "" {
+		return nil, errors.NewRequiredFieldError("user_id", userID)
+	}
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, errors.NewEntityNotFoundError("user", userID)
+	}
+	return u, nil
+}
+
+func (s *mfaService) confirmedEnrollment(ctx context.Context, userID string) (*mfa.Enrollment, error) {
+	e, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil || !e.Confirmed() {
+		return nil, errors.NewEntityNotFoundError("mfa_enrollment", userID)
+	}
+	return e, nil
+}
+
+// pendingEnrollment returns the user's enrollment that is not confirmed
+// yet
+func (s *mfaService) pendingEnrollment(ctx context.Context, userID string) (*mfa.Enrollment, error) {
+	e, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, errors.NewEntityNotFoundError("mfa_enrollment", userID)
+	}
+	if e.Confirmed() {
+		return nil, errors.NewConflictError("mfa_enrollment", "two-factor authentication is already enabled", userID)
+	}
+	return e, nil
+}
+
+// maxCodeCheckRetries bounds how often a code check starts over after a
+// parallel request changed the enrollment
+const maxCodeCheckRetries = 3
+
+// checkCode loads the user's enrollment with load and accepts an
+// authenticator code not used before and, when allowRecovery is set, an
+// unused recovery code. onAccept may change the enrollment further before
+// it is written back. Wrong codes are counted and lock further checks once
+// MaxAttempts is reached.
+//
+// The enrollment is only written back if no other request changed it
+// since it was read. Otherwise the check starts over on the current state,
+// so parallel requests cannot use one code twice or get past MaxAttempts.
+func (s *mfaService) checkCode(ctx context.Context, userID, code string, allowRecovery bool,
+	load func(ctx context.Context, userID string) (*mfa.Enrollment, error), onAccept func(e *mfa.Enrollment)) (*mfa.Enrollment, error) {
+	if code == "" {
+		return nil, errors.NewRequiredFieldError("code", code)
+	}
+
+	for retry := 0; ; retry++ {
+		e, err := load(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		accepted, err := s.applyCode(ctx, e, code, allowRecovery)
+		if err != nil {
+			return nil, err
+		}
+		if accepted && onAccept != nil {
+			onAccept(e)
+		}
+
+		err = s.repo.Update(ctx, e)
+		var conflict *errors.ConflictError
+		if stderrors.As(err, &conflict) && conflict.Code() == errors.CodeVersionMismatch && retry < maxCodeCheckRetries {
+			s.log.Debug(ctx, "mfa enrollment changed during code check, retrying", "user_id", userID)
+			continue
+		}
+		if err != nil {
+			s.log.Error(ctx, "failed to save mfa enrollment", "error", err, "user_id", userID)
+			return nil, err
+		}
+		if !accepted {
+			s.log.Warn(ctx, "invalid mfa code", "user_id", userID)
+			return nil, errors.NewUnauthorizedError("mfa_verify", userID, "invalid two-factor code")
+		}
+		return e, nil
+	}
+}
+
+// applyCode checks code against e and records the outcome on it
+func (s *mfaService) applyCode(ctx context.Context, e *mfa.Enrollment, code string, allowRecovery bool) (bool, error) {
+	now := s.now()
+	if e.Locked(now) {
+		return false, errors.NewAccountLockedError(e.LockedUntil.Sub(now))
+	}
+
+	accepted := false
+	if step, ok := totp.Validate(e.Secret, code, now, s.policy.Skew); ok && step > e.LastUsedStep {
+		e.LastUsedStep = step
+		accepted = true
+	} else if allowRecovery && e.UseRecoveryCode(code) {
+		s.log.Info(ctx, "mfa recovery code used", "user_id", e.UserID, "remaining", len(e.RecoveryCodes))
+		accepted = true
+	}
+
+	if accepted {
+		e.FailedAttempts = 0
+		e.LockedUntil = nil
+	} else {
+		e.FailedAttempts++
+		if s.policy.MaxAttempts > 0 && e.FailedAttempts >= s.policy.MaxAttempts {
+			lockedUntil := now.Add(s.policy.LockDuration)
+			e.LockedUntil = &lockedUntil
+			e.FailedAttempts = 0
+			s.log.Warn(ctx, "mfa locked after failed codes", "user_id", e.UserID, "locked_until", lockedUntil)
+		}
+	}
+	return accepted, nil
+}
+
+func (s *mfaService) recordAudit(ctx context.Context, userID, action string) {
+	if s.audit == nil {
+		return
+	}
+	s.audit.Record(ctx, &audit.Entry{
+		ActorID:    userID,
+		Action:     action,
+		EntityType: "user",
+		EntityID:   userID,
+	})
+}
+
+// generateRecoveryCodes returns n random codes formatted as XXXXX-XXXXX
+func generateRecoveryCodes(n int) []string {
+	codes := make([]string, n)
+	for i := range codes {
+		text := rand.Text()
+		codes[i] = text[:5] + "-" + text[5:10]
+	}
+	return codes
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/mfa"
+	mfaMocks "github.com/cctw-zed/wonder/internal/domain/mfa/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/totp"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+var testMFAPolicy = MFAPolicy{
+	Enforcement:      MFAEnforcementAdmins,
+	Issuer:           "Wonder",
+	Skew:             1,
+	MaxAttempts:      3,
+	LockDuration:     15 * time.Minute,
+	RecoveryCodes:    5,
+	TrustedDeviceTTL: 30 * 24 * time.Hour,
+}
+
+func newTestMFAService(t *testing.T, opts ...MFAServiceOption) (*mfaService, *mfaMocks.MockRepository, *mocks.MockUserRepository) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+	repo := mfaMocks.NewMockRepository(ctrl)
+	users := mocks.NewMockUserRepository(ctrl)
+	svc := NewMFAService(repo, users, fake.NewIDGenerator(1), testMFAPolicy, opts...).(*mfaService)
+	return svc, repo, users
+}
+
+func confirmedEnrollment() *mfa.Enrollment {
+	confirmedAt := time.Now().Add(-time.Hour)
+	return &mfa.Enrollment{UserID: "user-1", Secret: testTOTPSecret, ConfirmedAt: &confirmedAt}
+}
+
+func currentCode(t *testing.T, now time.Time) string {
+	t.Helper()
+	code, err := totp.Code(testTOTPSecret, totp.Step(now))
+	require.NoError(t, err)
+	return code
+}
+
+func TestMFAService_Verify(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("a code is accepted once", func(t *testing.T) {
+		svc, repo, _ := newTestMFAService(t)
+		svc.now = func() time.Time { return now }
+		e := confirmedEnrollment()
+		repo.EXPECT().Get(gomock.Any(), "user-1").Return(e, nil).Times(2)
+		repo.EXPECT().Update(gomock.Any(), e).Return(nil).Times(2)
+
+		code := currentCode(t, now)
+		require.NoError(t, svc.Verify(ctx, "user-1", code))
+		assert.Equal(t, totp.Step(now), e.LastUsedStep)
+
+		var unauthorized *wonderErrors.UnauthorizedError
+		assert.ErrorAs(t, svc.Verify(ctx, "user-1", code), &unauthorized, "replayed codes are refused")
+	})
+
+	t.Run("recovery codes are used up", func(t *testing.T) {
+		svc, repo, _ := newTestMFAService(t)
+		e := confirmedEnrollment()
+		e.SetRecoveryCodes([]string{"AAAAA-BBBBB"})
+		repo.EXPECT().Get(gomock.Any(), "user-1").Return(e, nil).Times(2)
+		repo.EXPECT().Update(gomock.Any(), e).Return(nil).Times(2)
+
+		require.NoError(t, svc.Verify(ctx, "user-1", "aaaaa-bbbbb"))
+		assert.Empty(t, e.RecoveryCodes)
+		assert.Error(t, svc.Verify(ctx, "user-1", "AAAAA-BBBBB"))
+	})
+
+	t.Run("wrong codes lock the second step", func(t *testing.T) {
+		svc, repo, _ := newTestMFAService(t)
+		svc.now = func() time.Time { return now }
+		e := confirmedEnrollment()
+		repo.EXPECT().Get(gomock.Any(), "user-1").Return(e, nil).AnyTimes()
+		repo.EXPECT().Update(gomock.Any(), e).Return(nil).Times(3)
+
+		for range 3 {
+			assert.Error(t, svc.Verify(ctx, "user-1", "000000"))
+		}
+		require.NotNil(t, e.LockedUntil)
+
+		// Even the right code is refused while locked
+		err := svc.Verify(ctx, "user-1", currentCode(t, now))
+		var conflict *wonderErrors.ConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, wonderErrors.CodeResourceLocked, conflict.Code())
+	})
+
+	t.Run("a code used by a parallel request is refused", func(t *testing.T) {
+		svc, repo, _ := newTestMFAService(t)
+		svc.now = func() time.Time { return now }
+		stale := confirmedEnrollment()
+		current := confirmedEnrollment()
+		current.LastUsedStep = totp.Step(now)
+		current.Version = 1
+		gomock.InOrder(
+			repo.EXPECT().Get(gomock.Any(), "user-1").Return(stale, nil),
+			repo.EXPECT().Update(gomock.Any(), stale).Return(wonderErrors.NewVersionMismatchError("mfa_enrollment", "user-1", "")),
+			repo.EXPECT().Get(gomock.Any(), "user-1").Return(current, nil),
+			repo.EXPECT().Update(gomock.Any(), current).Return(nil),
+		)
+
+		var unauthorized *wonderErrors.UnauthorizedError
+		assert.ErrorAs(t, svc.Verify(ctx, "user-1", currentCode(t, now)), &unauthorized)
+		assert.Equal(t, 1, current.FailedAttempts, "the check starts over on the current state")
+	})
+
+	t.Run("unconfirmed enrollments do not verify", func(t *testing.T) {
+		svc, repo, _ := newTestMFAService(t)
+		repo.EXPECT().Get(gomock.Any(), "user-1").Return(&mfa.Enrollment{UserID: "user-1", Secret: testTOTPSecret}, nil)
+
+		var notFound *wonderErrors.EntityNotFoundError
+		assert.ErrorAs(t, svc.Verify(ctx, "user-1", currentCode(t, time.Now())), &notFound)
+	})
+}
+
+func TestMFAService_Enrollment(t *testing.T) {
+	ctx := context.Background()
+	auditLog := &recordingAuditLog{}
+	svc, repo, users := newTestMFAService(t, WithMFAAuditLog(auditLog))
+
+	users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Email: "ada@example.com"}, nil)
+	repo.EXPECT().Get(gomock.Any(), "user-1").Return(confirmedEnrollment(), nil)
+	_, err := svc.BeginEnrollment(ctx, "user-1")
+	var conflict *wonderErrors.ConflictError
+	assert.ErrorAs(t, err, &conflict, "a confirmed authenticator must be disabled first")
+
+	pending := &mfa.Enrollment{UserID: "user-1", Secret: testTOTPSecret}
+	repo.EXPECT().Get(gomock.Any(), "user-1").Return(pending, nil)
+	repo.EXPECT().Update(gomock.Any(), pending).Return(nil)
+	codes, err := svc.ConfirmEnrollment(ctx, "user-1", currentCode(t, time.Now()))
+	require.NoError(t, err)
+	assert.Len(t, codes, 5)
+	assert.Regexp(t, `^[A-Z2-7]{5}-[A-Z2-7]{5}$`, codes[0])
+	assert.True(t, pending.Confirmed())
+	assert.True(t, pending.UseRecoveryCode(codes[0]))
+
+	require.Len(t, auditLog.entries, 1)
+	assert.Equal(t, audit.ActionEnableMFA, auditLog.entries[0].Action)
+}
+
+func TestMFAService_Disable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("required second factors cannot be disabled", func(t *testing.T) {
+		svc, _, users := newTestMFAService(t)
+		users.EXPECT().GetByID(gomock.Any(), "admin-1").Return(&user.User{ID: "admin-1", Role: user.RoleAdmin}, nil)
+
+		var business *wonderErrors.BusinessLogicError
+		assert.ErrorAs(t, svc.Disable(ctx, "admin-1", "123456"), &business)
+	})
+
+	t.Run("a valid code removes the enrollment", func(t *testing.T) {
+		svc, repo, users := newTestMFAService(t)
+		e := confirmedEnrollment()
+		users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Role: user.RoleUser}, nil)
+		repo.EXPECT().Get(gomock.Any(), "user-1").Return(e, nil)
+		repo.EXPECT().Update(gomock.Any(), e).Return(nil)
+		repo.EXPECT().Delete(gomock.Any(), "user-1").Return(nil)
+		repo.EXPECT().DeleteDevices(gomock.Any(), "user-1").Return(int64(2), nil)
+
+		require.NoError(t, svc.Disable(ctx, "user-1", currentCode(t, time.Now())))
+	})
+}
+
+func TestMFAService_TrustedDevices(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("only the hash of the token is stored", func(t *testing.T) {
+		svc, repo, _ := newTestMFAService(t)
+		svc.now = func() time.Time { return now }
+		var saved *mfa.TrustedDevice
+		repo.EXPECT().CreateDevice(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d *mfa.TrustedDevice) error {
+			saved = d
+			return nil
+		})
+
+		token, err := svc.TrustDevice(ctx, "user-1", &DeviceInfo{Name: "Laptop", UserAgent: "Firefox", IPAddress: "192.0.2.1"})
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+		assert.Equal(t, mfa.HashDeviceToken(token), saved.TokenHash)
+		assert.NotContains(t, saved.TokenHash, token)
+		assert.Equal(t, "Laptop", saved.Name)
+		assert.Equal(t, now.Add(testMFAPolicy.TrustedDeviceTTL), saved.ExpiresAt)
+	})
+
+	t.Run("a trusted device is recognised and touched", func(t *testing.T) {
+		svc, repo, _ := newTestMFAService(t)
+		svc.now = func() time.Time { return now }
+		device := &mfa.TrustedDevice{ID: "device-1", UserID: "user-1", ExpiresAt: now.Add(time.Hour)}
+		repo.EXPECT().GetDeviceByTokenHash(gomock.Any(), mfa.HashDeviceToken("token")).Return(device, nil)
+		repo.EXPECT().TouchDevice(gomock.Any(), "device-1", now).Return(nil)
+
+		assert.True(t, svc.DeviceTrusted(ctx, "user-1", "token"))
+	})
+
+	t.Run("expired devices and devices of other users are not trusted", func(t *testing.T) {
+		svc, repo, _ := newTestMFAService(t)
+		svc.now = func() time.Time { return now }
+		repo.EXPECT().GetDeviceByTokenHash(gomock.Any(), mfa.HashDeviceToken("expired")).
+			Return(&mfa.TrustedDevice{ID: "device-1", UserID: "user-1", ExpiresAt: now.Add(-time.Second)}, nil)
+		repo.EXPECT().GetDeviceByTokenHash(gomock.Any(), mfa.HashDeviceToken("other")).
+			Return(&mfa.TrustedDevice{ID: "device-2", UserID: "user-2", ExpiresAt: now.Add(time.Hour)}, nil)
+
+		assert.False(t, svc.DeviceTrusted(ctx, "user-1", "expired"))
+		assert.False(t, svc.DeviceTrusted(ctx, "user-1", "other"))
+		assert.False(t, svc.DeviceTrusted(ctx, "user-1", ""))
+	})
+
+	t.Run("remembering devices can be disabled", func(t *testing.T) {
+		svc, _, _ := newTestMFAService(t)
+		svc.policy.TrustedDeviceTTL = 0
+
+		_, err := svc.TrustDevice(ctx, "user-1", nil)
+		var business *wonderErrors.BusinessLogicError
+		assert.ErrorAs(t, err, &business)
+		assert.False(t, svc.DeviceTrusted(ctx, "user-1", "token"))
+	})
+}
+
+func TestMFAService_EnrollmentRequired(t *testing.T) {
+	svc, _, _ := newTestMFAService(t)
+	admin := &user.User{Role: user.RoleAdmin}
+	member := &user.User{Role: user.RoleUser}
+
+	assert.True(t, svc.EnrollmentRequired(admin))
+	assert.False(t, svc.EnrollmentRequired(member))
+
+	svc.policy.Enforcement = MFAEnforcementAll
+	assert.True(t, svc.EnrollmentRequired(member))
+	svc.policy.Enforcement = MFAEnforcementOptional
+	assert.False(t, svc.EnrollmentRequired(admin))
+}
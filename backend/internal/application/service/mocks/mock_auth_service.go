@@ -42,6 +42,36 @@ func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
 	return m.recorder
 }
 
+// BeginMFAEnrollment mocks base method.
+func (m *MockAuthService) BeginMFAEnrollment(ctx context.Context, mfaToken string) (*service.MFASetup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginMFAEnrollment", ctx, mfaToken)
+	ret0, _ := ret[0].(*service.MFASetup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginMFAEnrollment indicates an expected call of BeginMFAEnrollment.
+func (mr *MockAuthServiceMockRecorder) BeginMFAEnrollment(ctx, mfaToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginMFAEnrollment", reflect.TypeOf((*MockAuthService)(nil).BeginMFAEnrollment), ctx, mfaToken)
+}
+
+// CompleteMFAEnrollment mocks base method.
+func (m *MockAuthService) CompleteMFAEnrollment(ctx context.Context, mfaToken, code string, remember *service.DeviceInfo) (*service.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteMFAEnrollment", ctx, mfaToken, code, remember)
+	ret0, _ := ret[0].(*service.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteMFAEnrollment indicates an expected call of CompleteMFAEnrollment.
+func (mr *MockAuthServiceMockRecorder) CompleteMFAEnrollment(ctx, mfaToken, code, remember any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMFAEnrollment", reflect.TypeOf((*MockAuthService)(nil).CompleteMFAEnrollment), ctx, mfaToken, code, remember)
+}
+
 // Login mocks base method.
 func (m *MockAuthService) Login(ctx context.Context, email, password string) (*service.LoginResponse, error) {
 	m.ctrl.T.Helper()
@@ -85,3 +115,18 @@ func (mr *MockAuthServiceMockRecorder) ValidateToken(ctx, token any) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockAuthService)(nil).ValidateToken), ctx, token)
 }
+
+// VerifyMFA mocks base method.
+func (m *MockAuthService) VerifyMFA(ctx context.Context, mfaToken, code string, remember *service.DeviceInfo) (*service.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFA", ctx, mfaToken, code, remember)
+	ret0, _ := ret[0].(*service.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockAuthServiceMockRecorder) VerifyMFA(ctx, mfaToken, code, remember any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockAuthService)(nil).VerifyMFA), ctx, mfaToken, code, remember)
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/notification"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+	"github.com/cctw-zed/wonder/pkg/jobs"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// DeliverNotificationPayload is the payload of a deliver-notification job:
+// a notification whose channels were decided when it was raised. Its ID
+// is kept across retries so the in-app notification is stored once.
+type DeliverNotificationPayload struct {
+	ID       string                 `json:"id"`
+	TenantID string                 `json:"tenant_id,omitempty"`
+	UserID   string                 `json:"user_id"`
+	Type     string                 `json:"type"`
+	Channels []notification.Channel `json:"channels"`
+	// Data fills in the type's message. Values of keys ending in "_at" are
+	// RFC 3339 times, shown in the user's time zone.
+	Data      map[string]string `json:"data,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// NotificationPage is one page of a user's notifications, newest first
+type NotificationPage struct {
+	Notifications []*notification.Notification `json:"notifications"`
+	Total         int64                        `json:"total"`
+	Page          int                          `json:"page"`
+	PageSize      int                          `json:"page_size"`
+	TotalPages    int                          `json:"total_pages"`
+}
+
+// NotificationService tells users about changes to their account on the
+// channels their preferences choose, and lets them read their in-app
+// notifications
+type NotificationService interface {
+	// Notify raises a notification of type t for the user. Its channels
+	// are decided now; delivery goes through a deliver-notification job
+	// when a job queue is configured. eventID identifies the cause, so a
+	// redelivered event notifies once; a new ID is generated when it is
+	// empty.
+	Notify(ctx context.Context, eventID, userID, t string, data map[string]string) error
+	// Deliver renders a notification in the user's locale and delivers it
+	// on its channels
+	Deliver(ctx context.Context, payload *DeliverNotificationPayload) error
+	// HandleUserEvent notifies the user of the user events that have a
+	// notification type
+	HandleUserEvent(ctx context.Context, eventID string, e event.Event) error
+
+	List(ctx context.Context, userID string, unreadOnly bool, page, pageSize int) (*NotificationPage, error)
+	UnreadCount(ctx context.Context, userID string) (int64, error)
+	// MarkRead marks one notification read and returns it
+	MarkRead(ctx context.Context, userID, id string) (*notification.Notification, error)
+	// MarkAllRead marks every unread notification read and returns how many
+	// there were
+	MarkAllRead(ctx context.Context, userID string) (int64, error)
+}
+
+// NotificationServiceOption configures optional notification service
+// collaborators
+type NotificationServiceOption func(*notificationService)
+
+// WithNotificationQueue delivers notifications through deliver-notification
+// jobs instead of while the event is handled
+func WithNotificationQueue(queue jobs.Queue) NotificationServiceOption {
+	return func(s *notificationService) {
+		s.queue = queue
+	}
+}
+
+// WithNotificationMail enables the email channel
+func WithNotificationMail(mail AccountMailService) NotificationServiceOption {
+	return func(s *notificationService) {
+		s.mail = mail
+	}
+}
+
+// WithNotificationPreferences lets users choose the channels of each type
+// through their preferences; without it every type uses its defaults
+func WithNotificationPreferences(prefs PreferenceService) NotificationServiceOption {
+	return func(s *notificationService) {
+		s.prefs = prefs
+	}
+}
+
+// WithNotificationBundle renders notifications with the catalogs of bundle
+// instead of the process-wide bundle
+func WithNotificationBundle(bundle *i18n.Bundle) NotificationServiceOption {
+	return func(s *notificationService) {
+		s.bundle = bundle
+	}
+}
+
+type notificationService struct {
+	repo   notification.Repository
+	users  user.UserRepository
+	idGen  id.Generator
+	queue  jobs.Queue
+	mail   AccountMailService
+	prefs  PreferenceService
+	bundle *i18n.Bundle
+	now    func() time.Time
+	log    logger.Logger
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(repo notification.Repository, users user.UserRepository, idGen id.Generator, opts ...NotificationServiceOption) NotificationService {
+	return NewNotificationServiceWithLogger(repo, users, idGen, logger.Get().WithLayer("application").WithComponent("notification_service"), opts...)
+}
+
+func NewNotificationServiceWithLogger(repo notification.Repository, users user.UserRepository, idGen id.Generator, log logger.Logger, opts ...NotificationServiceOption) NotificationService {
+	if repo == nil {
+		panic("notification repository cannot be nil")
+	}
+	if users == nil {
+		panic("user repository cannot be nil")
+	}
+	if idGen == nil {
+		panic("ID generator cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	s := &notificationService{
+		repo:   repo,
+		users:  users,
+		idGen:  idGen,
+		bundle: i18n.Get(),
+		now:    time.Now,
+		log:    log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *notificationService) Notify(ctx context.Context, eventID, userID, t string, data map[string]string) error {
+	if !notification.KnownType(t) {
+		return errors.NewInvalidValueError("type", t, "unknown notification type")
+	}
+
+	channels := notification.ResolveChannels(t, s.channelOverrides(ctx, userID, t))
+	if s.mail == nil {
+		channels = slices.DeleteFunc(channels, func(ch notification.Channel) bool { return ch == notification.ChannelEmail })
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+
+	if eventID == "" {
+		eventID = s.idGen.Generate()
+	}
+	payload := &DeliverNotificationPayload{
+		ID:        eventID,
+		TenantID:  tenant.IDFromContext(ctx),
+		UserID:    userID,
+		Type:      t,
+		Channels:  channels,
+		Data:      data,
+		CreatedAt: s.now(),
+	}
+	if s.queue == nil {
+		return s.Deliver(ctx, payload)
+	}
+
+	job, err := jobs.NewJob(JobDeliverNotification, payload)
+	if err != nil {
+		return err
+	}
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		s.log.Error(ctx, "failed to queue notification", "error", err, "user_id", userID, "type", t)
+		return err
+	}
+	return nil
+}
+
+// channelOverrides reads the user's choice of channels for type t. A
+// preference that cannot be read leaves the defaults.
+func (s *notificationService) channelOverrides(ctx context.Context, userID, t string) map[notification.Channel]bool {
+	if s.prefs == nil {
+		return nil
+	}
+	overrides, _, err := GetPreference[map[notification.Channel]bool](ctx, s.prefs, userID, notification.PreferenceKey(t))
+	if err != nil {
+		s.log.Warn(ctx, "ignoring unreadable notification preference", "error", err, "user_id", userID, "type", t)
+		return nil
+	}
+	return overrides
+}
+
+func (s *notificationService) Deliver(ctx context.Context, payload *DeliverNotificationPayload) error {
+	u, err := s.users.GetByID(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		s.log.Info(ctx, "dropping notification of missing user", "user_id", payload.UserID, "type", payload.Type)
+		return nil
+	}
+
+	title, body := renderNotification(s.bundle, payload.Type, payload.Data, u.LocalePrefs())
+	for _, channel := range payload.Channels {
+		switch channel {
+		case notification.ChannelInApp:
+			err = s.repo.Create(ctx, &notification.Notification{
+				ID:        payload.ID,
+				UserID:    u.ID,
+				Type:      payload.Type,
+				Title:     title,
+				Body:      body,
+				Data:      payload.Data,
+				CreatedAt: payload.CreatedAt,
+			})
+		case notification.ChannelEmail:
+			if s.mail == nil {
+				break
+			}
+			for _, to := range notificationRecipients(payload, u) {
+				if err = s.mail.SendNotification(ctx, to, u.Name, title, body); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			s.log.Error(ctx, "failed to deliver notification", "error", err, "user_id", u.ID, "type", payload.Type, "channel", channel)
+			return err
+		}
+	}
+
+	s.log.Info(ctx, "notification delivered", "user_id", u.ID, "type", payload.Type, "channels", payload.Channels)
+	return nil
+}
+
+// notificationRecipients returns the addresses a notification is emailed
+// to. A changed email address is reported to the address it replaced, as
+// the new one may belong to whoever took over the account, and to the new
+// address.
+func notificationRecipients(payload *DeliverNotificationPayload, u *user.User) []string {
+	if payload.Type == notification.TypeEmailChanged {
+		if old := payload.Data["old_email"]; old != "" && old != u.Email {
+			return []string{old, u.Email}
+		}
+	}
+	return []string{u.Email}
+}
+
+// renderNotification renders the title and body of a notification in the
+// bundle language closest to the user's locale
+func renderNotification(bundle *i18n.Bundle, t string, data map[string]string, prefs user.LocalePrefs) (string, string) {
+	lang := bundle.Negotiate(prefs.Locale)
+
+	args := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		args[k] = v
+		if strings.HasSuffix(k, "_at") {
+			if at, err := time.Parse(time.RFC3339, v); err == nil {
+				args[k] = formatMailTime(bundle, at, prefs)
+			}
+		}
+	}
+	return bundle.Translate(lang, "notification."+t+".title", args),
+		bundle.Translate(lang, "notification."+t+".body", args)
+}
+
+func (s *notificationService) HandleUserEvent(ctx context.Context, eventID string, e event.Event) error {
+	t, data, ok := userEventNotification(e)
+	if !ok {
+		return nil
+	}
+	return s.Notify(ctx, eventID, e.AggregateID(), t, data)
+}
+
+// userEventNotification returns the notification type of a user event
+// and the data of its message
+func userEventNotification(e event.Event) (string, map[string]string, bool) {
+	switch e := e.(type) {
+	case user.UserRegistered:
+		return notification.TypeWelcome, map[string]string{"name": e.Name}, true
+	case user.UserEmailChanged:
+		return notification.TypeEmailChanged, map[string]string{"old_email": e.OldEmail, "new_email": e.NewEmail}, true
+	case user.UserPasswordReset:
+		return notification.TypePasswordReset, nil, true
+	case user.UserHandleChanged:
+		// Removing a handle is not worth a notification
+		if e.NewHandle == "" {
+			return "", nil, false
+		}
+		return notification.TypeHandleChanged, map[string]string{"old_handle": e.OldHandle, "new_handle": e.NewHandle}, true
+	case user.UserReactivated:
+		return notification.TypeReactivated, nil, true
+	case user.UserDeletionScheduled:
+		return notification.TypeDeletionScheduled, map[string]string{"delete_at": e.DeleteAt.UTC().Format(time.RFC3339)}, true
+	case user.UserDeletionCancelled:
+		return notification.TypeDeletionCancelled, nil, true
+	}
+	return "", nil, false
+}
+
+func (s *notificationService) List(ctx context.Context, userID string, unreadOnly bool, page, pageSize int) (*NotificationPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	notifications, total, err := s.repo.List(ctx, userID, notification.ListFilter{
+		UnreadOnly: unreadOnly,
+		Offset:     (page - 1) * pageSize,
+		Limit:      pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationPage{
+		Notifications: notifications,
+		Total:         total,
+		Page:          page,
+		PageSize:      pageSize,
+		TotalPages:    int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+func (s *notificationService) UnreadCount(ctx context.Context, userID string) (int64, error) {
+	return s.repo.CountUnread(ctx, userID)
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, userID, id string) (*notification.Notification, error) {
+	n, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, errors.NewEntityNotFoundError("notification", id)
+	}
+	if n.Read() {
+		return n, nil
+	}
+
+	now := s.now()
+	if _, err := s.repo.MarkRead(ctx, userID, id, now); err != nil {
+		return nil, err
+	}
+	n.ReadAt = &now
+	return n, nil
+}
+
+func (s *notificationService) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	return s.repo.MarkRead(ctx, userID, "", s.now())
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/notification"
+	notificationMocks "github.com/cctw-zed/wonder/internal/domain/notification/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/preference"
+	prefMocks "github.com/cctw-zed/wonder/internal/domain/preference/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+	"github.com/cctw-zed/wonder/pkg/jobs"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+)
+
+type notificationFixture struct {
+	repo   *notificationMocks.MockRepository
+	prefs  *prefMocks.MockRepository
+	users  *fake.UserRepository
+	sender *recordingMailer
+}
+
+func newTestNotificationService(t *testing.T, opts ...NotificationServiceOption) (NotificationService, *notificationFixture) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+
+	renderer, err := mailer.NewRenderer(mailer.Templates)
+	require.NoError(t, err)
+	f := &notificationFixture{
+		repo:   notificationMocks.NewMockRepository(ctrl),
+		prefs:  prefMocks.NewMockRepository(ctrl),
+		users:  fake.NewUserRepository(),
+		sender: &recordingMailer{},
+	}
+	opts = append([]NotificationServiceOption{
+		WithNotificationMail(NewAccountMailService(f.sender, renderer, "")),
+		WithNotificationPreferences(NewPreferenceService(f.prefs, PreferencePolicy{MaxValueBytes: 256, MaxKeys: 10})),
+	}, opts...)
+	return NewNotificationService(f.repo, f.users, fake.NewIDGenerator(1), opts...), f
+}
+
+func TestNotificationService_Notify(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	t.Run("delivers in app by default", func(t *testing.T) {
+		svc, f := newTestNotificationService(t)
+		require.NoError(t, f.users.Create(ctx, &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser}))
+
+		f.prefs.EXPECT().Get(ctx, "u-1", notification.PreferenceKey(notification.TypeWelcome)).Return(nil, nil)
+		f.repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, n *notification.Notification) error {
+			assert.Equal(t, "evt-1", n.ID)
+			assert.Equal(t, "u-1", n.UserID)
+			assert.Equal(t, "Welcome to Wonder", n.Title)
+			assert.Equal(t, "Hi Ada, your account is ready.", n.Body)
+			return nil
+		})
+
+		require.NoError(t, svc.Notify(ctx, "evt-1", "u-1", notification.TypeWelcome, map[string]string{"name": "Ada"}))
+		assert.Empty(t, f.sender.sent)
+	})
+
+	t.Run("queues deliveries on the resolved channels", func(t *testing.T) {
+		queue := jobs.NewMemoryQueue()
+		svc, f := newTestNotificationService(t, WithNotificationQueue(queue))
+		require.NoError(t, f.users.Create(ctx, &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser, Locale: "zh-CN"}))
+
+		f.prefs.EXPECT().Get(ctx, "u-1", notification.PreferenceKey(notification.TypeEmailChanged)).
+			Return(&preference.Preference{Key: notification.PreferenceKey(notification.TypeEmailChanged), Value: json.RawMessage(`{"in_app":false}`)}, nil)
+		require.NoError(t, svc.Notify(ctx, "", "u-1", notification.TypeEmailChanged, map[string]string{"old_email": "ada@old.example.com", "new_email": "ada@example.com"}))
+
+		job, err := queue.Claim(ctx, time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		assert.Equal(t, JobDeliverNotification, job.Type)
+		var payload DeliverNotificationPayload
+		require.NoError(t, job.Decode(&payload))
+		assert.Equal(t, "acme", payload.TenantID)
+		assert.Equal(t, []notification.Channel{notification.ChannelInApp, notification.ChannelEmail}, payload.Channels,
+			"security notifications cannot be turned off")
+		assert.NotEmpty(t, payload.ID, "an ID is generated without an event ID")
+
+		f.repo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		require.NoError(t, svc.Deliver(ctx, &payload))
+		require.Len(t, f.sender.sent, 2)
+		assert.Equal(t, "ada@old.example.com", f.sender.sent[0].To, "the replaced address is warned")
+		assert.Equal(t, "ada@example.com", f.sender.sent[1].To)
+		assert.Equal(t, "您的邮箱地址已更改", f.sender.sent[0].Subject)
+		assert.Contains(t, f.sender.sent[0].Text, "ada@old.example.com")
+	})
+
+	t.Run("renders with the injected bundle", func(t *testing.T) {
+		english, err := i18n.New(nil)
+		require.NoError(t, err)
+		svc, f := newTestNotificationService(t, WithNotificationBundle(english))
+		require.NoError(t, f.users.Create(ctx, &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser, Locale: "zh-CN"}))
+
+		f.repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, n *notification.Notification) error {
+			assert.Equal(t, "Welcome to Wonder", n.Title, "the bundle serves only English")
+			return nil
+		})
+		require.NoError(t, svc.Deliver(ctx, &DeliverNotificationPayload{
+			ID:       "evt-1",
+			UserID:   "u-1",
+			Type:     notification.TypeWelcome,
+			Channels: []notification.Channel{notification.ChannelInApp},
+			Data:     map[string]string{"name": "Ada"},
+		}))
+	})
+
+	t.Run("skips users who turned every channel off", func(t *testing.T) {
+		svc, f := newTestNotificationService(t)
+		f.prefs.EXPECT().Get(ctx, "u-1", gomock.Any()).
+			Return(&preference.Preference{Value: json.RawMessage(`{"in_app":false}`)}, nil)
+		require.NoError(t, svc.Notify(ctx, "evt-1", "u-1", notification.TypeWelcome, nil))
+	})
+
+	t.Run("rejects unknown types", func(t *testing.T) {
+		svc, _ := newTestNotificationService(t)
+		var invalid *errors.ValidationError
+		assert.ErrorAs(t, svc.Notify(ctx, "evt-1", "u-1", "unknown.type", nil), &invalid)
+	})
+}
+
+func TestNotificationService_HandleUserEvent(t *testing.T) {
+	ctx := context.Background()
+	svc, f := newTestNotificationService(t)
+	require.NoError(t, f.users.Create(ctx, &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser, Timezone: "Asia/Tokyo"}))
+
+	// Removing a handle and events without a type notify nobody
+	require.NoError(t, svc.HandleUserEvent(ctx, "evt-1", user.UserHandleChanged{Base: event.NewBase("u-1"), OldHandle: "ada"}))
+	require.NoError(t, svc.HandleUserEvent(ctx, "evt-2", user.UserNameChanged{Base: event.NewBase("u-1")}))
+
+	f.prefs.EXPECT().Get(ctx, "u-1", notification.PreferenceKey(notification.TypeDeletionScheduled)).Return(nil, nil)
+	f.repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, n *notification.Notification) error {
+		assert.Equal(t, notification.TypeDeletionScheduled, n.Type)
+		assert.Contains(t, n.Body, "16 November 2026 13:00 JST", "times are shown in the user's time zone")
+		return nil
+	})
+	require.NoError(t, svc.HandleUserEvent(ctx, "evt-3", user.UserDeletionScheduled{
+		Base: event.NewBase("u-1"), DeleteAt: time.Date(2026, 11, 16, 4, 0, 0, 0, time.UTC),
+	}))
+}
+
+func TestNotificationService_MarkRead(t *testing.T) {
+	ctx := context.Background()
+	svc, f := newTestNotificationService(t)
+
+	f.repo.EXPECT().Get(ctx, "u-1", "n-1").Return(&notification.Notification{ID: "n-1", UserID: "u-1"}, nil)
+	f.repo.EXPECT().MarkRead(ctx, "u-1", "n-1", gomock.Any()).Return(int64(1), nil)
+	n, err := svc.MarkRead(ctx, "u-1", "n-1")
+	require.NoError(t, err)
+	assert.True(t, n.Read())
+
+	// Already read notifications keep their read time
+	readAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	f.repo.EXPECT().Get(ctx, "u-1", "n-2").Return(&notification.Notification{ID: "n-2", UserID: "u-1", ReadAt: &readAt}, nil)
+	n, err = svc.MarkRead(ctx, "u-1", "n-2")
+	require.NoError(t, err)
+	assert.Equal(t, readAt, *n.ReadAt)
+
+	f.repo.EXPECT().Get(ctx, "u-1", "missing").Return(nil, nil)
+	_, err = svc.MarkRead(ctx, "u-1", "missing")
+	var notFound *errors.EntityNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+
+	f.repo.EXPECT().MarkRead(ctx, "u-1", "", gomock.Any()).Return(int64(3), nil)
+	marked, err := svc.MarkAllRead(ctx, "u-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), marked)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	"github.com/cctw-zed/wonder/internal/domain/transaction"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// OrganizationService manages organizations on behalf of a signed-in
+// user. Organizations the user is not a member of are reported as not
+// found; members who are not the owner may only read. Members join through
+// the InvitationService.
+type OrganizationService interface {
+	// CreateOrganization creates an organization owned by the user
+	CreateOrganization(ctx context.Context, userID, name string) (*organization.Organization, error)
+	ListOrganizations(ctx context.Context, userID string) ([]*organization.Organization, error)
+	GetOrganization(ctx context.Context, userID, orgID string) (*organization.Organization, error)
+	ListMembers(ctx context.Context, userID, orgID string) ([]*organization.Member, error)
+	// TransferOwnership makes another member the owner; the current owner
+	// stays on as a member
+	TransferOwnership(ctx context.Context, userID, orgID, newOwnerID string) error
+}
+
+// OrganizationServiceOption configures optional organization service
+// collaborators
+type OrganizationServiceOption func(*organizationService)
+
+// WithOrganizationUnitOfWork makes creating organizations and transferring
+// ownership atomic. Transfers lock both memberships, so they need it to be
+// safe against concurrent transfers.
+func WithOrganizationUnitOfWork(uow transaction.UnitOfWork) OrganizationServiceOption {
+	return func(s *organizationService) {
+		if uow != nil {
+			s.uow = uow
+		}
+	}
+}
+
+type organizationService struct {
+	repo  organization.Repository
+	users user.UserRepository
+	idGen id.Generator
+	uow   transaction.UnitOfWork
+	now   func() time.Time
+	log   logger.Logger
+}
+
+// NewOrganizationService creates a new organization service
+func NewOrganizationService(repo organization.Repository, users user.UserRepository, idGen id.Generator, opts ...OrganizationServiceOption) OrganizationService {
+	return NewOrganizationServiceWithLogger(repo, users, idGen, logger.Get().WithLayer("application").WithComponent("organization_service"), opts...)
+}
+
+func NewOrganizationServiceWithLogger(repo organization.Repository, users user.UserRepository, idGen id.Generator, log logger.Logger, opts ...OrganizationServiceOption) OrganizationService {
+	if repo == nil {
+		panic("organization repository cannot be nil")
+	}
+	if users == nil {
+		panic("user repository cannot be nil")
+	}
+	if idGen == nil {
+		panic("ID generator cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	s := &organizationService{
+		repo:  repo,
+		users: users,
+		idGen: idGen,
+		uow:   noopUnitOfWork{},
+		now:   time.Now,
+		log:   log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// membership returns the user's membership of the organization, reporting
+// the organization as not found to users outside it
+func membership(ctx context.Context, repo organization.Repository, userID, orgID string) (*organization.Member, error) {
+	m, err := repo.GetMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, errors.NewEntityNotFoundError("organization", orgID)
+	}
+	return m, nil
+}
+
+// ownership returns the user's membership of the organization, failing
+// unless they own it
+func ownership(ctx context.Context, repo organization.Repository, operation, userID, orgID string) (*organization.Member, error) {
+	m, err := membership(ctx, repo, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Owner() {
+		return nil, errors.NewInsufficientRoleError(operation, userID, string(organization.RoleOwner))
+	}
+	return m, nil
+}
+
+func (s *organizationService) CreateOrganization(ctx context.Context, userID, name string) (*organization.Organization, error) {
+	now := s.now()
+	o := &organization.Organization{
+		ID:        s.idGen.Generate(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := o.Rename(name); err != nil {
+		return nil, err
+	}
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, o); err != nil {
+			return err
+		}
+		return s.repo.AddMember(ctx, &organization.Member{
+			OrgID:     o.ID,
+			UserID:    userID,
+			Role:      organization.RoleOwner,
+			CreatedAt: now,
+		})
+	})
+	if err != nil {
+		s.log.Error(ctx, "failed to create organization", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "organization created", "org_id", o.ID, "owner_id", userID)
+	return o, nil
+}
+
+func (s *organizationService) ListOrganizations(ctx context.Context, userID string) ([]*organization.Organization, error) {
+	return s.repo.ListForUser(ctx, userID)
+}
+
+func (s *organizationService) GetOrganization(ctx context.Context, userID, orgID string) (*organization.Organization, error) {
+	if _, err := membership(ctx, s.repo, userID, orgID); err != nil {
+		return nil, err
+	}
+	o, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, errors.NewEntityNotFoundError("organization", orgID)
+	}
+	return o, nil
+}
+
+func (s *organizationService) ListMembers(ctx context.Context, userID, orgID string) ([]*organization.Member, error) {
+	if _, err := membership(ctx, s.repo, userID, orgID); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.ListMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(members))
+	for i, m := range members {
+		ids[i] = m.UserID
+	}
+	users, err := s.users.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if u := users[m.UserID]; u != nil {
+			m.Name = u.Name
+			m.Email = u.Email
+		}
+	}
+	return members, nil
+}
+
+func (s *organizationService) TransferOwnership(ctx context.Context, userID, orgID, newOwnerID string) error {
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		// Both memberships are locked, in ID order so that concurrent
+		// transfers cannot deadlock, before either is checked
+		ids := []string{userID, newOwnerID}
+		sort.Strings(ids)
+		locked := make(map[string]*organization.Member, 2)
+		for _, id := range ids {
+			if _, ok := locked[id]; ok {
+				continue
+			}
+			m, err := s.repo.GetMemberForUpdate(ctx, orgID, id)
+			if err != nil {
+				return err
+			}
+			locked[id] = m
+		}
+
+		current := locked[userID]
+		if current == nil {
+			return errors.NewEntityNotFoundError("organization", orgID)
+		}
+		if !current.Owner() {
+			return errors.NewInsufficientRoleError("transfer_ownership", userID, string(organization.RoleOwner))
+		}
+		if newOwnerID == userID {
+			return errors.NewBusinessRuleError("already_owner", "user already owns the organization")
+		}
+		if locked[newOwnerID] == nil {
+			return errors.NewEntityNotFoundError("member", newOwnerID)
+		}
+
+		if err := s.repo.UpdateMemberRole(ctx, orgID, newOwnerID, organization.RoleOwner); err != nil {
+			return err
+		}
+		return s.repo.UpdateMemberRole(ctx, orgID, userID, organization.RoleMember)
+	})
+	if err != nil {
+		s.log.Error(ctx, "failed to transfer ownership", "error", err, "org_id", orgID)
+		return err
+	}
+
+	s.log.Info(ctx, "organization ownership transferred", "org_id", orgID, "from", userID, "to", newOwnerID)
+	return nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	orgMocks "github.com/cctw-zed/wonder/internal/domain/organization/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func newTestOrganizationService(t *testing.T) (OrganizationService, *orgMocks.MockRepository) {
+	logger.Initialize()
+	repo := orgMocks.NewMockRepository(gomock.NewController(t))
+	users := fake.NewUserRepository()
+	require.NoError(t, users.Create(context.Background(), &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser}))
+	require.NoError(t, users.Create(context.Background(), &user.User{ID: "u-2", Email: "grace@example.com", Name: "Grace", Role: user.RoleUser}))
+	return NewOrganizationService(repo, users, fake.NewIDGenerator(1)), repo
+}
+
+func TestOrganizationService_CreateOrganization(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestOrganizationService(t)
+
+	repo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+	repo.EXPECT().AddMember(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, m *organization.Member) error {
+		assert.Equal(t, "u-1", m.UserID)
+		assert.Equal(t, organization.RoleOwner, m.Role)
+		return nil
+	})
+	o, err := svc.CreateOrganization(ctx, "u-1", " Research ")
+	require.NoError(t, err)
+	assert.Equal(t, "Research", o.Name)
+
+	_, err = svc.CreateOrganization(ctx, "u-1", " ")
+	var invalid *errors.ValidationError
+	assert.ErrorAs(t, err, &invalid)
+}
+
+func TestOrganizationService_Authorization(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestOrganizationService(t)
+
+	// Organizations of others are not found
+	repo.EXPECT().GetMember(ctx, "o-1", "u-9").Return(nil, nil).Times(2)
+	var notFound *errors.EntityNotFoundError
+	_, err := svc.GetOrganization(ctx, "u-9", "o-1")
+	assert.ErrorAs(t, err, &notFound)
+	_, err = svc.ListMembers(ctx, "u-9", "o-1")
+	assert.ErrorAs(t, err, &notFound)
+
+	// Members may read but not manage
+	member := &organization.Member{OrgID: "o-1", UserID: "u-2", Role: organization.RoleMember}
+	repo.EXPECT().GetMember(ctx, "o-1", "u-2").Return(member, nil)
+	repo.EXPECT().GetMemberForUpdate(ctx, "o-1", "u-2").Return(member, nil)
+	repo.EXPECT().ListMembers(ctx, "o-1").Return([]*organization.Member{
+		{OrgID: "o-1", UserID: "u-1", Role: organization.RoleOwner}, member,
+	}, nil)
+	members, err := svc.ListMembers(ctx, "u-2", "o-1")
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, "Ada", members[0].Name)
+	assert.Equal(t, "grace@example.com", members[1].Email)
+
+	var forbidden *errors.UnauthorizedError
+	assert.ErrorAs(t, svc.TransferOwnership(ctx, "u-2", "o-1", "u-2"), &forbidden)
+}
+
+func TestOrganizationService_TransferOwnership(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestOrganizationService(t)
+	owner := &organization.Member{OrgID: "o-1", UserID: "u-1", Role: organization.RoleOwner}
+
+	// Memberships are locked in ID order before they are checked
+	gomock.InOrder(
+		repo.EXPECT().GetMemberForUpdate(ctx, "o-1", "u-1").Return(owner, nil),
+		repo.EXPECT().GetMemberForUpdate(ctx, "o-1", "u-2").Return(&organization.Member{OrgID: "o-1", UserID: "u-2", Role: organization.RoleMember}, nil),
+		repo.EXPECT().UpdateMemberRole(ctx, "o-1", "u-2", organization.RoleOwner).Return(nil),
+		repo.EXPECT().UpdateMemberRole(ctx, "o-1", "u-1", organization.RoleMember).Return(nil),
+	)
+	require.NoError(t, svc.TransferOwnership(ctx, "u-1", "o-1", "u-2"))
+
+	gomock.InOrder(
+		repo.EXPECT().GetMemberForUpdate(ctx, "o-1", "u-1").Return(owner, nil),
+		repo.EXPECT().GetMemberForUpdate(ctx, "o-1", "u-9").Return(nil, nil),
+	)
+	var notFound *errors.EntityNotFoundError
+	assert.ErrorAs(t, svc.TransferOwnership(ctx, "u-1", "o-1", "u-9"), &notFound, "ownership only goes to members")
+
+	// An owner demoted by a transfer that committed first may not transfer
+	gomock.InOrder(
+		repo.EXPECT().GetMemberForUpdate(ctx, "o-1", "u-0").Return(&organization.Member{OrgID: "o-1", UserID: "u-0", Role: organization.RoleMember}, nil),
+		repo.EXPECT().GetMemberForUpdate(ctx, "o-1", "u-1").Return(&organization.Member{OrgID: "o-1", UserID: "u-1", Role: organization.RoleMember}, nil),
+	)
+	var forbidden *errors.UnauthorizedError
+	assert.ErrorAs(t, svc.TransferOwnership(ctx, "u-1", "o-1", "u-0"), &forbidden)
+}
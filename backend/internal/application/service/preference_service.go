@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/cctw-zed/wonder/internal/domain/preference"
+	"github.com/cctw-zed/wonder/internal/domain/transaction"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// PreferencePolicy limits what a user may store
+type PreferencePolicy struct {
+	// MaxValueBytes is the largest compacted JSON value; zero means no
+	// limit
+	MaxValueBytes int
+	// MaxKeys is how many preferences a user may have; zero means no limit
+	MaxKeys int
+}
+
+// PreferenceService stores per-user settings as JSON values under
+// namespaced keys. GetPreference and SetPreference read and write them as
+// Go values.
+type PreferenceService interface {
+	// List returns the user's preferences ordered by key, only those in
+	// namespace unless it is empty
+	List(ctx context.Context, userID, namespace string) ([]*preference.Preference, error)
+	// Get returns one preference, failing with a not found error when the
+	// user has not set it
+	Get(ctx context.Context, userID, key string) (*preference.Preference, error)
+	// Set stores value under key, replacing any previous value
+	Set(ctx context.Context, userID, key string, value json.RawMessage) (*preference.Preference, error)
+	Delete(ctx context.Context, userID, key string) error
+}
+
+// PreferenceServiceOption configures optional preference service
+// collaborators
+type PreferenceServiceOption func(*preferenceService)
+
+// WithPreferenceUnitOfWork counts a user's preferences and stores a new one
+// in one transaction that locks the user, so that concurrent writes cannot
+// exceed MaxKeys
+func WithPreferenceUnitOfWork(uow transaction.UnitOfWork) PreferenceServiceOption {
+	return func(s *preferenceService) {
+		if uow != nil {
+			s.uow = uow
+		}
+	}
+}
+
+type preferenceService struct {
+	repo   preference.Repository
+	policy PreferencePolicy
+	uow    transaction.UnitOfWork
+	log    logger.Logger
+}
+
+// NewPreferenceService creates a new preference service
+func NewPreferenceService(repo preference.Repository, policy PreferencePolicy, opts ...PreferenceServiceOption) PreferenceService {
+	return NewPreferenceServiceWithLogger(repo, policy, logger.Get().WithLayer("application").WithComponent("preference_service"), opts...)
+}
+
+func NewPreferenceServiceWithLogger(repo preference.Repository, policy PreferencePolicy, log logger.Logger, opts ...PreferenceServiceOption) PreferenceService {
+	if repo == nil {
+		panic("preference repository cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	s := &preferenceService{
+		repo:   repo,
+		policy: policy,
+		uow:    noopUnitOfWork{},
+		log:    log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *preferenceService) List(ctx context.Context, userID, namespace string) ([]*preference.Preference, error) {
+	if namespace != "" {
+		if err := preference.ValidateNamespace(namespace); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.List(ctx, userID, namespace)
+}
+
+func (s *preferenceService) Get(ctx context.Context, userID, key string) (*preference.Preference, error) {
+	if err := preference.ValidateKey(key); err != nil {
+		return nil, err
+	}
+	p, err := s.repo.Get(ctx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, errors.NewEntityNotFoundError("preference", key)
+	}
+	return p, nil
+}
+
+func (s *preferenceService) Set(ctx context.Context, userID, key string, value json.RawMessage) (*preference.Preference, error) {
+	if err := preference.ValidateKey(key); err != nil {
+		return nil, err
+	}
+	value, err := preference.NormalizeValue(value, s.policy.MaxValueBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &preference.Preference{UserID: userID, Key: key, Value: value}
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.checkLimit(ctx, userID, key); err != nil {
+			return err
+		}
+		return s.repo.Set(ctx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.log.Debug(ctx, "preference set", "user_id", userID, "key", key)
+	return p, nil
+}
+
+// checkLimit fails when storing key would give the user more than MaxKeys
+// preferences. The user is locked before counting, so the key is looked up
+// only once no other write can add or remove it.
+func (s *preferenceService) checkLimit(ctx context.Context, userID, key string) error {
+	if s.policy.MaxKeys <= 0 {
+		return nil
+	}
+	count, err := s.repo.CountForUpdate(ctx, userID)
+	if err != nil || count < int64(s.policy.MaxKeys) {
+		return err
+	}
+	existing, err := s.repo.Get(ctx, userID, key)
+	if err != nil || existing != nil {
+		return err
+	}
+	return errors.NewBusinessRuleError("preference_limit",
+		fmt.Sprintf("a user can have at most %d preferences", s.policy.MaxKeys),
+		map[string]interface{}{"max_keys": s.policy.MaxKeys})
+}
+
+func (s *preferenceService) Delete(ctx context.Context, userID, key string) error {
+	if err := preference.ValidateKey(key); err != nil {
+		return err
+	}
+	deleted, err := s.repo.Delete(ctx, userID, key)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.NewEntityNotFoundError("preference", key)
+	}
+	return nil
+}
+
+// GetPreference decodes the user's preference under key into a T. It
+// reports false, with the zero T, when the user has not set it.
+func GetPreference[T any](ctx context.Context, s PreferenceService, userID, key string) (T, bool, error) {
+	var value T
+	p, err := s.Get(ctx, userID, key)
+	var notFound *errors.EntityNotFoundError
+	if stderrors.As(err, &notFound) {
+		return value, false, nil
+	}
+	if err != nil {
+		return value, false, err
+	}
+	if err := json.Unmarshal(p.Value, &value); err != nil {
+		return value, false, errors.NewInvalidFormatError(key, string(p.Value), fmt.Sprintf("%T", value))
+	}
+	return value, true, nil
+}
+
+// SetPreference stores value as JSON under key
+func SetPreference[T any](ctx context.Context, s PreferenceService, userID, key string, value T) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.NewInvalidValueError(key, nil, err.Error())
+	}
+	_, err = s.Set(ctx, userID, key, data)
+	return err
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/preference"
+	prefMocks "github.com/cctw-zed/wonder/internal/domain/preference/mocks"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func newTestPreferenceService(t *testing.T) (PreferenceService, *prefMocks.MockRepository) {
+	logger.Initialize()
+	repo := prefMocks.NewMockRepository(gomock.NewController(t))
+	return NewPreferenceService(repo, PreferencePolicy{MaxValueBytes: 32, MaxKeys: 2}), repo
+}
+
+func TestPreferenceService_Set(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores the compacted value", func(t *testing.T) {
+		svc, repo := newTestPreferenceService(t)
+		repo.EXPECT().CountForUpdate(ctx, "user-1").Return(int64(1), nil)
+		repo.EXPECT().Set(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, p *preference.Preference) error {
+			assert.Equal(t, "user-1", p.UserID)
+			assert.Equal(t, `{"mode":"dark"}`, string(p.Value))
+			return nil
+		})
+
+		p, err := svc.Set(ctx, "user-1", "editor.theme", json.RawMessage(`{ "mode": "dark" }`))
+		require.NoError(t, err)
+		assert.Equal(t, "editor.theme", p.Key)
+	})
+
+	t.Run("rejects invalid keys and values", func(t *testing.T) {
+		svc, _ := newTestPreferenceService(t)
+		_, err := svc.Set(ctx, "user-1", "theme", json.RawMessage(`"dark"`))
+		assert.Error(t, err)
+		_, err = svc.Set(ctx, "user-1", "editor.theme", json.RawMessage(`{"mode":`))
+		assert.Error(t, err)
+		_, err = svc.Set(ctx, "user-1", "editor.theme", json.RawMessage(`"a very long value that does not fit"`))
+		assert.Error(t, err)
+	})
+
+	t.Run("limits the number of keys", func(t *testing.T) {
+		svc, repo := newTestPreferenceService(t)
+		repo.EXPECT().CountForUpdate(ctx, "user-1").Return(int64(2), nil)
+		repo.EXPECT().Get(ctx, "user-1", "editor.theme").Return(nil, nil)
+
+		_, err := svc.Set(ctx, "user-1", "editor.theme", json.RawMessage(`"dark"`))
+		var ruleErr *wonderErrors.DomainRuleError
+		require.ErrorAs(t, err, &ruleErr)
+	})
+
+	t.Run("counts and stores in one unit of work", func(t *testing.T) {
+		repo := prefMocks.NewMockRepository(gomock.NewController(t))
+		uow := &recordingUnitOfWork{}
+		svc := NewPreferenceService(repo, PreferencePolicy{MaxKeys: 2}, WithPreferenceUnitOfWork(uow))
+		repo.EXPECT().CountForUpdate(inTx, "user-1").Return(int64(1), nil)
+		repo.EXPECT().Set(inTx, gomock.Any()).Return(nil)
+
+		_, err := svc.Set(ctx, "user-1", "editor.theme", json.RawMessage(`"dark"`))
+		require.NoError(t, err)
+		assert.Equal(t, 1, uow.calls)
+	})
+
+	t.Run("replacing a value does not count against the limit", func(t *testing.T) {
+		svc, repo := newTestPreferenceService(t)
+		repo.EXPECT().CountForUpdate(ctx, "user-1").Return(int64(2), nil)
+		repo.EXPECT().Get(ctx, "user-1", "editor.theme").Return(&preference.Preference{Key: "editor.theme"}, nil)
+		repo.EXPECT().Set(ctx, gomock.Any()).Return(nil)
+
+		_, err := svc.Set(ctx, "user-1", "editor.theme", json.RawMessage(`"light"`))
+		require.NoError(t, err)
+	})
+}
+
+func TestPreferenceService_GetAndDelete(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestPreferenceService(t)
+	var notFound *wonderErrors.EntityNotFoundError
+
+	repo.EXPECT().Get(ctx, "user-1", "editor.theme").Return(nil, nil)
+	_, err := svc.Get(ctx, "user-1", "editor.theme")
+	require.ErrorAs(t, err, &notFound)
+
+	repo.EXPECT().Delete(ctx, "user-1", "editor.theme").Return(false, nil)
+	require.ErrorAs(t, svc.Delete(ctx, "user-1", "editor.theme"), &notFound)
+
+	_, err = svc.List(ctx, "user-1", "Editor")
+	assert.Error(t, err, "namespaces are validated")
+}
+
+func TestTypedPreferences(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestPreferenceService(t)
+
+	type editor struct {
+		Theme    string `json:"theme"`
+		FontSize int    `json:"font_size"`
+	}
+
+	repo.EXPECT().Get(ctx, "user-1", "editor.settings").Return(nil, nil)
+	value, ok, err := GetPreference[editor](ctx, svc, "user-1", "editor.settings")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, editor{}, value)
+
+	repo.EXPECT().CountForUpdate(ctx, "user-1").Return(int64(0), nil)
+	repo.EXPECT().Set(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, p *preference.Preference) error {
+		assert.JSONEq(t, `{"theme":"dark","font_size":14}`, string(p.Value))
+		return nil
+	})
+	require.NoError(t, SetPreference(ctx, svc, "user-1", "editor.settings", editor{Theme: "dark", FontSize: 14}))
+
+	repo.EXPECT().Get(ctx, "user-1", "editor.settings").Return(&preference.Preference{Key: "editor.settings", Value: json.RawMessage(`{"theme":"dark","font_size":14}`)}, nil)
+	value, ok, err = GetPreference[editor](ctx, svc, "user-1", "editor.settings")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, editor{Theme: "dark", FontSize: 14}, value)
+
+	repo.EXPECT().Get(ctx, "user-1", "editor.font").Return(&preference.Preference{Key: "editor.font", Value: json.RawMessage(`"mono"`)}, nil)
+	_, _, err = GetPreference[int](ctx, svc, "user-1", "editor.font")
+	assert.Error(t, err, "values of another type")
+}
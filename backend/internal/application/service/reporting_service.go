@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/stats"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// ReportingService computes statistics for administrators
+type ReportingService interface {
+	// GetStats summarizes users and their activity over the last days days,
+	// today included. Zero days selects stats.DefaultDays.
+	GetStats(ctx context.Context, days int) (*stats.Stats, error)
+	// RebuildStats recomputes the stats over the last days days and
+	// replaces the cached copy, so the next GetStats is served warm
+	RebuildStats(ctx context.Context, days int) error
+}
+
+// ReportingServiceOption configures optional reporting service behaviour
+type ReportingServiceOption func(*reportingService)
+
+// WithStatsCache serves stats from cache for ttl after computing them.
+// Cache failures are logged and the stats computed directly.
+func WithStatsCache(cache stats.Cache, ttl time.Duration) ReportingServiceOption {
+	return func(s *reportingService) {
+		if cache != nil && ttl > 0 {
+			s.cache = cache
+			s.cacheTTL = ttl
+		}
+	}
+}
+
+type reportingService struct {
+	repo       stats.Repository
+	sessionTTL time.Duration
+	cache      stats.Cache
+	cacheTTL   time.Duration
+	now        func() time.Time
+	log        logger.Logger
+}
+
+// NewReportingService creates a new reporting service. sessionTTL is the
+// access token lifetime: logins within it count as active sessions.
+func NewReportingService(repo stats.Repository, sessionTTL time.Duration, opts ...ReportingServiceOption) ReportingService {
+	return NewReportingServiceWithLogger(repo, sessionTTL, logger.Get().WithLayer("application").WithComponent("reporting_service"), opts...)
+}
+
+func NewReportingServiceWithLogger(repo stats.Repository, sessionTTL time.Duration, log logger.Logger, opts ...ReportingServiceOption) ReportingService {
+	if repo == nil {
+		panic("stats repository cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	s := &reportingService{
+		repo:       repo,
+		sessionTTL: sessionTTL,
+		now:        time.Now,
+		log:        log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *reportingService) GetStats(ctx context.Context, days int) (*stats.Stats, error) {
+	if days == 0 {
+		days = stats.DefaultDays
+	}
+	if days < 1 || days > stats.MaxDays {
+		return nil, errors.NewOutOfRangeError("days", days, 1, stats.MaxDays)
+	}
+
+	key := statsKey(ctx, days)
+	if s.cache != nil {
+		cached, err := s.cache.Get(ctx, key)
+		if err != nil {
+			s.log.Warn(ctx, "stats cache read failed", "error", err)
+		} else if cached != nil {
+			return cached, nil
+		}
+	}
+
+	result, err := s.compute(ctx, days)
+	if err != nil {
+		s.log.Error(ctx, "failed to compute stats", "error", err, "days", days)
+		return nil, err
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Set(ctx, key, result, s.cacheTTL); err != nil {
+			s.log.Warn(ctx, "stats cache write failed", "error", err)
+		}
+	}
+	s.log.Info(ctx, "stats computed", "days", days, "total_users", result.TotalUsers)
+	return result, nil
+}
+
+func (s *reportingService) RebuildStats(ctx context.Context, days int) error {
+	if days == 0 {
+		days = stats.DefaultDays
+	}
+	if days < 1 || days > stats.MaxDays {
+		return errors.NewOutOfRangeError("days", days, 1, stats.MaxDays)
+	}
+
+	result, err := s.compute(ctx, days)
+	if err != nil {
+		s.log.Error(ctx, "failed to compute stats", "error", err, "days", days)
+		return err
+	}
+	if s.cache != nil {
+		if err := s.cache.Set(ctx, statsKey(ctx, days), result, s.cacheTTL); err != nil {
+			s.log.Error(ctx, "stats cache write failed", "error", err)
+			return err
+		}
+	}
+	s.log.Info(ctx, "stats rebuilt", "days", days, "total_users", result.TotalUsers)
+	return nil
+}
+
+// statsKey caches stats per tenant and window
+func statsKey(ctx context.Context, days int) string {
+	return fmt.Sprintf("%s:%d", tenant.IDFromContext(ctx), days)
+}
+
+func (s *reportingService) compute(ctx context.Context, days int) (*stats.Stats, error) {
+	now := s.now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := today.AddDate(0, 0, -(days - 1))
+
+	byRole, err := s.repo.CountUsersByRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signups, err := s.repo.SignupsPerDay(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	deletions, err := s.repo.DeletionsPerDay(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &stats.Stats{
+		Days:            days,
+		From:            from,
+		GeneratedAt:     now,
+		UsersByRole:     byRole,
+		SignupsPerDay:   fillDays(signups, from, days),
+		DeletionsPerDay: fillDays(deletions, from, days),
+	}
+	for _, count := range byRole {
+		result.TotalUsers += count
+	}
+	for _, day := range result.SignupsPerDay {
+		result.Signups += day.Count
+	}
+	for _, day := range result.DeletionsPerDay {
+		result.Deletions += day.Count
+	}
+	result.SignupsPerWeek = perWeek(result.SignupsPerDay)
+
+	if s.sessionTTL > 0 {
+		result.ActiveSessions, result.ActiveUsers, err = s.repo.CountLogins(ctx, now.Add(-s.sessionTTL))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// fillDays returns one count per day from from on, with zeros for days
+// the sparse counts omit
+func fillDays(counts []stats.DailyCount, from time.Time, days int) []stats.DailyCount {
+	byDate := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		byDate[c.Date] = c.Count
+	}
+
+	filled := make([]stats.DailyCount, days)
+	for i := range filled {
+		date := from.AddDate(0, 0, i).Format(stats.DateLayout)
+		filled[i] = stats.DailyCount{Date: date, Count: byDate[date]}
+	}
+	return filled
+}
+
+// perWeek sums consecutive daily counts by ISO week. The first week may
+// start before the first day and only counts the days given.
+func perWeek(daily []stats.DailyCount) []stats.WeeklyCount {
+	var weeks []stats.WeeklyCount
+	for _, day := range daily {
+		date, err := time.Parse(stats.DateLayout, day.Date)
+		if err != nil {
+			continue
+		}
+		monday := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7)).Format(stats.DateLayout)
+		if n := len(weeks); n > 0 && weeks[n-1].WeekStart == monday {
+			weeks[n-1].Count += day.Count
+			continue
+		}
+		weeks = append(weeks, stats.WeeklyCount{WeekStart: monday, Count: day.Count})
+	}
+	return weeks
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/stats"
+	statsMocks "github.com/cctw-zed/wonder/internal/domain/stats/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestReportingService_GetStats(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	// A Wednesday
+	now := time.Date(2026, 3, 11, 15, 30, 0, 0, time.UTC)
+
+	setup := func(t *testing.T, opts ...ReportingServiceOption) (*reportingService, *statsMocks.MockRepository) {
+		ctrl := gomock.NewController(t)
+		repo := statsMocks.NewMockRepository(ctrl)
+		svc := NewReportingService(repo, time.Hour, opts...).(*reportingService)
+		svc.now = func() time.Time { return now }
+		return svc, repo
+	}
+
+	t.Run("aggregates and fills the window", func(t *testing.T) {
+		svc, repo := setup(t)
+		from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+		repo.EXPECT().CountUsersByRole(ctx).Return(map[string]int64{"user": 7, "admin": 1}, nil)
+		repo.EXPECT().SignupsPerDay(ctx, from).Return([]stats.DailyCount{{Date: "2026-03-02", Count: 2}, {Date: "2026-03-10", Count: 3}}, nil)
+		repo.EXPECT().DeletionsPerDay(ctx, from).Return([]stats.DailyCount{{Date: "2026-03-11", Count: 1}}, nil)
+		repo.EXPECT().CountLogins(ctx, now.Add(-time.Hour)).Return(int64(5), int64(4), nil)
+
+		result, err := svc.GetStats(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, from, result.From)
+		assert.Equal(t, int64(8), result.TotalUsers)
+		assert.Equal(t, int64(5), result.Signups)
+		assert.Equal(t, int64(1), result.Deletions)
+		assert.Equal(t, int64(5), result.ActiveSessions)
+		assert.Equal(t, int64(4), result.ActiveUsers)
+
+		require.Len(t, result.SignupsPerDay, 10)
+		assert.Equal(t, stats.DailyCount{Date: "2026-03-03", Count: 0}, result.SignupsPerDay[1])
+		assert.Equal(t, "2026-03-11", result.DeletionsPerDay[9].Date)
+		assert.Equal(t, []stats.WeeklyCount{{WeekStart: "2026-03-02", Count: 2}, {WeekStart: "2026-03-09", Count: 3}}, result.SignupsPerWeek)
+	})
+
+	t.Run("rejects windows out of range", func(t *testing.T) {
+		svc, _ := setup(t)
+		_, err := svc.GetStats(ctx, stats.MaxDays+1)
+		var verr *errors.ValidationError
+		assert.ErrorAs(t, err, &verr)
+	})
+
+	t.Run("serves cached stats", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cache := statsMocks.NewMockCache(ctrl)
+		svc, _ := setup(t, WithStatsCache(cache, time.Minute))
+
+		cached := &stats.Stats{Days: stats.DefaultDays, TotalUsers: 42}
+		cache.EXPECT().Get(ctx, "default:30").Return(cached, nil)
+
+		result, err := svc.GetStats(ctx, 0)
+		require.NoError(t, err)
+		assert.Same(t, cached, result)
+	})
+
+	t.Run("computes and caches on a miss, ignoring cache errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cache := statsMocks.NewMockCache(ctrl)
+		svc, repo := setup(t, WithStatsCache(cache, time.Minute))
+
+		cache.EXPECT().Get(ctx, "default:7").Return(nil, assert.AnError)
+		repo.EXPECT().CountUsersByRole(ctx).Return(map[string]int64{"user": 1}, nil)
+		repo.EXPECT().SignupsPerDay(ctx, gomock.Any()).Return(nil, nil)
+		repo.EXPECT().DeletionsPerDay(ctx, gomock.Any()).Return(nil, nil)
+		repo.EXPECT().CountLogins(ctx, gomock.Any()).Return(int64(0), int64(0), nil)
+		cache.EXPECT().Set(ctx, "default:7", gomock.Any(), time.Minute).Return(assert.AnError)
+
+		result, err := svc.GetStats(ctx, 7)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.TotalUsers)
+		assert.Len(t, result.SignupsPerDay, 7)
+	})
+
+	t.Run("propagates repository errors", func(t *testing.T) {
+		svc, repo := setup(t)
+		repo.EXPECT().CountUsersByRole(ctx).Return(nil, assert.AnError)
+
+		_, err := svc.GetStats(ctx, 1)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestReportingService_RebuildStats(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	repo := statsMocks.NewMockRepository(ctrl)
+	cache := statsMocks.NewMockCache(ctrl)
+	svc := NewReportingService(repo, 0, WithStatsCache(cache, time.Minute))
+
+	repo.EXPECT().CountUsersByRole(ctx).Return(map[string]int64{"user": 3}, nil)
+	repo.EXPECT().SignupsPerDay(ctx, gomock.Any()).Return(nil, nil)
+	repo.EXPECT().DeletionsPerDay(ctx, gomock.Any()).Return(nil, nil)
+	cache.EXPECT().Set(ctx, "default:30", gomock.Any(), time.Minute).DoAndReturn(
+		func(_ context.Context, _ string, result *stats.Stats, _ time.Duration) error {
+			assert.Equal(t, int64(3), result.TotalUsers)
+			return nil
+		})
+	require.NoError(t, svc.RebuildStats(ctx, 0))
+
+	cache.EXPECT().Set(ctx, "default:7", gomock.Any(), time.Minute).Return(assert.AnError)
+	repo.EXPECT().CountUsersByRole(ctx).Return(map[string]int64{}, nil)
+	repo.EXPECT().SignupsPerDay(ctx, gomock.Any()).Return(nil, nil)
+	repo.EXPECT().DeletionsPerDay(ctx, gomock.Any()).Return(nil, nil)
+	assert.ErrorIs(t, svc.RebuildStats(ctx, 7), assert.AnError)
+}
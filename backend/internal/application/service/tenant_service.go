@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+type tenantService struct {
+	repo  tenant.Repository
+	idGen id.Generator
+	log   logger.Logger
+}
+
+// NewTenantService creates a new tenant management service
+func NewTenantService(repo tenant.Repository, idGen id.Generator) tenant.Service {
+	return NewTenantServiceWithLogger(repo, idGen, logger.Get().WithLayer("application").WithComponent("tenant_service"))
+}
+
+func NewTenantServiceWithLogger(repo tenant.Repository, idGen id.Generator, log logger.Logger) tenant.Service {
+	if repo == nil {
+		panic("tenant repository cannot be nil")
+	}
+	if idGen == nil {
+		panic("id generator cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &tenantService{
+		repo:  repo,
+		idGen: idGen,
+		log:   log,
+	}
+}
+
+func (s *tenantService) CreateTenant(ctx context.Context, slug, name string) (*tenant.Tenant, error) {
+	t := &tenant.Tenant{
+		ID:   s.idGen.Generate(),
+		Slug: strings.ToLower(strings.TrimSpace(slug)),
+		Name: strings.TrimSpace(name),
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, t); err != nil {
+		s.log.Warn(ctx, "failed to create tenant", "error", err, "slug", t.Slug)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "tenant created", "tenant_id", t.ID, "slug", t.Slug)
+	return t, nil
+}
+
+func (s *tenantService) GetTenant(ctx context.Context, tenantID string) (*tenant.Tenant, error) {
+	if tenantID == "" {
+		return nil, errors.NewRequiredFieldError("id", tenantID)
+	}
+
+	t, err := s.repo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, errors.NewEntityNotFoundError("tenant", tenantID)
+	}
+	return t, nil
+}
+
+func (s *tenantService) ListTenants(ctx context.Context) ([]*tenant.Tenant, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *tenantService) DeleteTenant(ctx context.Context, tenantID string) error {
+	if tenantID == "" {
+		return errors.NewRequiredFieldError("id", tenantID)
+	}
+	if tenantID == tenant.DefaultID {
+		return errors.NewBusinessRuleError("default_tenant", "the default tenant cannot be deleted")
+	}
+
+	hasUsers, err := s.repo.HasUsers(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if hasUsers {
+		return errors.NewConflictError("tenant", "tenant still has users", tenantID)
+	}
+
+	if err := s.repo.Delete(ctx, tenantID); err != nil {
+		return err
+	}
+
+	s.log.Info(ctx, "tenant deleted", "tenant_id", tenantID)
+	return nil
+}
+
+func (s *tenantService) Resolve(ctx context.Context, ref string) (*tenant.Tenant, error) {
+	if ref == "" {
+		return nil, errors.NewRequiredFieldError("tenant", ref)
+	}
+
+	t, err := s.repo.GetByID(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil && tenant.IsValidSlug(ref) {
+		if t, err = s.repo.GetBySlug(ctx, ref); err != nil {
+			return nil, err
+		}
+	}
+	if t == nil {
+		return nil, errors.NewEntityNotFoundError("tenant", ref)
+	}
+	return t, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	tenantMocks "github.com/cctw-zed/wonder/internal/domain/tenant/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+func TestTenantService(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (tenant.Service, *tenantMocks.MockRepository, *idMocks.MockGenerator) {
+		ctrl := gomock.NewController(t)
+		repo := tenantMocks.NewMockRepository(ctrl)
+		idGen := idMocks.NewMockGenerator(ctrl)
+		return NewTenantService(repo, idGen), repo, idGen
+	}
+
+	t.Run("create normalizes the slug", func(t *testing.T) {
+		svc, repo, idGen := setup(t)
+		idGen.EXPECT().Generate().Return("t-1")
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		created, err := svc.CreateTenant(ctx, " Acme ", "Acme Corp")
+		require.NoError(t, err)
+		assert.Equal(t, "acme", created.Slug)
+		assert.Equal(t, "t-1", created.ID)
+	})
+
+	t.Run("create rejects slugs that are not DNS labels", func(t *testing.T) {
+		svc, _, idGen := setup(t)
+		idGen.EXPECT().Generate().Return("t-1")
+
+		_, err := svc.CreateTenant(ctx, "acme.corp", "Acme Corp")
+		var validationErr *errors.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("resolve falls back to the slug", func(t *testing.T) {
+		svc, repo, _ := setup(t)
+		acme := &tenant.Tenant{ID: "t-1", Slug: "acme", Name: "Acme"}
+		repo.EXPECT().GetByID(gomock.Any(), "acme").Return(nil, nil)
+		repo.EXPECT().GetBySlug(gomock.Any(), "acme").Return(acme, nil)
+
+		resolved, err := svc.Resolve(ctx, "acme")
+		require.NoError(t, err)
+		assert.Equal(t, acme, resolved)
+	})
+
+	t.Run("resolve reports unknown tenants", func(t *testing.T) {
+		svc, repo, _ := setup(t)
+		repo.EXPECT().GetByID(gomock.Any(), "nobody").Return(nil, nil)
+		repo.EXPECT().GetBySlug(gomock.Any(), "nobody").Return(nil, nil)
+
+		_, err := svc.Resolve(ctx, "nobody")
+		var notFound *errors.EntityNotFoundError
+		assert.ErrorAs(t, err, &notFound)
+	})
+
+	t.Run("delete refuses the default tenant and tenants with users", func(t *testing.T) {
+		svc, repo, _ := setup(t)
+		assert.Error(t, svc.DeleteTenant(ctx, tenant.DefaultID))
+
+		repo.EXPECT().HasUsers(gomock.Any(), "t-1").Return(true, nil)
+		var conflict *errors.ConflictError
+		assert.ErrorAs(t, svc.DeleteTenant(ctx, "t-1"), &conflict)
+
+		repo.EXPECT().HasUsers(gomock.Any(), "t-2").Return(false, nil)
+		repo.EXPECT().Delete(gomock.Any(), "t-2").Return(nil)
+		assert.NoError(t, svc.DeleteTenant(ctx, "t-2"))
+	})
+}
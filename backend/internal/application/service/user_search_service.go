@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// UserSearchService finds users by free text
+type UserSearchService interface {
+	// SearchUsers returns one page of users whose name or email matches
+	// req.Query, best match first, with the matches highlighted
+	SearchUsers(ctx context.Context, req *user.SearchRequest) (*user.SearchResponse, error)
+}
+
+type userSearchService struct {
+	searcher user.Searcher
+	log      logger.Logger
+}
+
+// NewUserSearchService creates a new user search service
+func NewUserSearchService(searcher user.Searcher) UserSearchService {
+	return NewUserSearchServiceWithLogger(searcher, logger.Get().WithLayer("application").WithComponent("user_search_service"))
+}
+
+func NewUserSearchServiceWithLogger(searcher user.Searcher, log logger.Logger) UserSearchService {
+	if searcher == nil {
+		panic("user searcher cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+	return &userSearchService{searcher: searcher, log: log}
+}
+
+func (s *userSearchService) SearchUsers(ctx context.Context, req *user.SearchRequest) (*user.SearchResponse, error) {
+	if req == nil {
+		return nil, errors.NewRequiredFieldError("request", "nil")
+	}
+
+	// Set default values
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = 10
+	}
+	if req.PageSize > 100 {
+		return nil, errors.NewOutOfRangeError("page_size", req.PageSize, 1, 100)
+	}
+
+	terms := user.SearchTerms(req.Query)
+	if len(terms) == 0 {
+		return nil, errors.NewInvalidValueError("q", req.Query, "must contain at least one letter or digit")
+	}
+
+	result, err := s.searcher.Search(ctx, terms, req.Page, req.PageSize)
+	if err != nil {
+		s.log.Error(ctx, "user search failed", "error", err, "terms", len(terms))
+		return nil, err
+	}
+
+	for _, hit := range result.Hits {
+		highlights := make(map[string][]user.Fragment, 2)
+		if fragments := user.Highlight(hit.User.Name, terms); fragments != nil {
+			highlights["name"] = fragments
+		}
+		if fragments := user.Highlight(hit.User.Email, terms); fragments != nil {
+			highlights["email"] = fragments
+		}
+		if len(highlights) > 0 {
+			hit.Highlights = highlights
+		}
+	}
+
+	s.log.Info(ctx, "users searched", "terms", len(terms), "total", result.Total, "returned", len(result.Hits))
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	userMocks "github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUserSearchService_SearchUsers(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (UserSearchService, *userMocks.MockSearcher) {
+		ctrl := gomock.NewController(t)
+		searcher := userMocks.NewMockSearcher(ctrl)
+		return NewUserSearchService(searcher), searcher
+	}
+
+	t.Run("searches terms and highlights matches", func(t *testing.T) {
+		svc, searcher := setup(t)
+		searcher.EXPECT().Search(ctx, []string{"alice", "example"}, 1, 10).Return(&user.SearchResponse{
+			Hits: []*user.SearchHit{
+				{User: &user.User{ID: "u-1", Name: "Alice Smith", Email: "alice@example.com"}, Rank: 1},
+				{User: &user.User{ID: "u-2", Name: "Bob", Email: "bob@other.org"}, Rank: 0.2},
+			},
+			Total: 2, Page: 1, PageSize: 10, TotalPages: 1,
+		}, nil)
+
+		result, err := svc.SearchUsers(ctx, &user.SearchRequest{Query: "Alice example"})
+		require.NoError(t, err)
+		require.Len(t, result.Hits, 2)
+
+		highlights := result.Hits[0].Highlights
+		assert.Equal(t, []user.Fragment{{Text: "Alice", Match: true}, {Text: " Smith"}}, highlights["name"])
+		assert.Equal(t, []user.Fragment{
+			{Text: "alice", Match: true}, {Text: "@"}, {Text: "example", Match: true}, {Text: ".com"},
+		}, highlights["email"])
+		assert.Nil(t, result.Hits[1].Highlights)
+	})
+
+	t.Run("rejects queries without words", func(t *testing.T) {
+		svc, _ := setup(t)
+		_, err := svc.SearchUsers(ctx, &user.SearchRequest{Query: "@@", Page: 1, PageSize: 10})
+		var validationErr *errors.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "q", validationErr.Field)
+	})
+
+	t.Run("passes search errors through", func(t *testing.T) {
+		svc, searcher := setup(t)
+		dbErr := errors.NewDatabaseError("search", "users", assert.AnError, false)
+		searcher.EXPECT().Search(ctx, []string{"al"}, 2, 5).Return(nil, dbErr)
+
+		_, err := svc.SearchUsers(ctx, &user.SearchRequest{Query: "al", Page: 2, PageSize: 5})
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
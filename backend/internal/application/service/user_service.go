@@ -2,25 +2,284 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/transaction"
 	"github.com/cctw-zed/wonder/internal/domain/user"
 	"github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/logger"
 	"github.com/cctw-zed/wonder/pkg/snowflake/id"
 )
 
+// BreachPolicy controls how breached passwords are handled
+type BreachPolicy string
+
+const (
+	// BreachPolicyReject refuses passwords found in known breaches
+	BreachPolicyReject BreachPolicy = "reject"
+	// BreachPolicyWarn accepts breached passwords but logs a warning
+	BreachPolicyWarn BreachPolicy = "warn"
+)
+
+// LockoutPolicy limits failed logins per account and per client IP
+type LockoutPolicy struct {
+	// Threshold is the number of failures within Window that locks an account
+	Threshold int
+	// IPThreshold is the number of failures from one IP that blocks it; zero disables it
+	IPThreshold int
+	Window      time.Duration
+	Duration    time.Duration
+}
+
+// CaptchaPolicy decides which requests must carry a solved CAPTCHA
+type CaptchaPolicy struct {
+	// Register requires a CAPTCHA to register
+	Register bool
+	// LoginThreshold failed logins of an account or client IP, as counted
+	// by the login lockout, require a CAPTCHA to log in; zero never does
+	LoginThreshold int
+}
+
+// SignupPolicy throttles registrations per email domain and per client IP
+type SignupPolicy struct {
+	// DomainLimit is the number of registrations per email domain within
+	// Window; zero disables it
+	DomainLimit int
+	// IPLimit is the number of registrations per client IP within Window;
+	// zero disables it
+	IPLimit int
+	Window  time.Duration
+	// ExemptDomains are not throttled per domain, e.g. large mail providers
+	// many users share
+	ExemptDomains []string
+}
+
+// clientIPKey is the request context key holding the caller's IP address
+const clientIPKey = "client_ip"
+
+type captchaTokenKey struct{}
+
+// WithCaptchaToken returns a context carrying the CAPTCHA solution sent
+// with a registration or login
+func WithCaptchaToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, captchaTokenKey{}, token)
+}
+
+func captchaToken(ctx context.Context) string {
+	token, _ := ctx.Value(captchaTokenKey{}).(string)
+	return token
+}
+
+type honeypotKey struct{}
+
+// WithHoneypotValue returns a context carrying the value of the hidden form
+// field sent with a registration. People never see the field, so only bots
+// fill it in.
+func WithHoneypotValue(ctx context.Context, value string) context.Context {
+	if value == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, honeypotKey{}, value)
+}
+
+func honeypotValue(ctx context.Context) string {
+	value, _ := ctx.Value(honeypotKey{}).(string)
+	return value
+}
+
 type userService struct {
 	repo  user.UserRepository
 	idGen id.Generator
 	log   logger.Logger
+
+	uow           transaction.UnitOfWork
+	events        event.Bus
+	outbox        event.Outbox
+	breachChecker user.PasswordBreachChecker
+	breachPolicy  BreachPolicy
+	audit         audit.Recorder
+	attempts      user.LoginAttemptStore
+	lockout       LockoutPolicy
+	deletionGrace time.Duration
+	orgs          organization.Repository
+	emailRules    user.EmailRules
+	handlePolicy  user.HandlePolicy
+
+	authProvider   user.AuthProvider
+	provisionUsers bool
+
+	captcha       user.CaptchaVerifier
+	captchaPolicy CaptchaPolicy
+
+	signups           user.SignupCounter
+	signupPolicy      SignupPolicy
+	disposableDomains user.DisposableDomainProvider
+	honeypot          bool
+}
+
+// UserServiceOption configures optional user service collaborators
+type UserServiceOption func(*userService)
+
+// WithUnitOfWork runs multi-step repository operations inside a transaction
+func WithUnitOfWork(uow transaction.UnitOfWork) UserServiceOption {
+	return func(s *userService) {
+		if uow != nil {
+			s.uow = uow
+		}
+	}
+}
+
+// WithEventBus publishes domain events raised by the user aggregate after
+// each successful write
+func WithEventBus(bus event.Bus) UserServiceOption {
+	return func(s *userService) {
+		s.events = bus
+	}
+}
+
+// WithOutbox stores domain events in the outbox inside the write
+// transaction instead of publishing them directly; a relay delivers them
+// after commit
+func WithOutbox(outbox event.Outbox) UserServiceOption {
+	return func(s *userService) {
+		s.outbox = outbox
+	}
+}
+
+// WithPasswordBreachCheck enables breached-password screening on
+// registration and password change
+func WithPasswordBreachCheck(checker user.PasswordBreachChecker, policy BreachPolicy) UserServiceOption {
+	return func(s *userService) {
+		s.breachChecker = checker
+		s.breachPolicy = policy
+	}
+}
+
+// WithAuditLog records mutating operations and login attempts
+func WithAuditLog(recorder audit.Recorder) UserServiceOption {
+	return func(s *userService) {
+		s.audit = recorder
+	}
+}
+
+// WithLoginLockout locks accounts and client IPs after repeated failed logins
+func WithLoginLockout(store user.LoginAttemptStore, policy LockoutPolicy) UserServiceOption {
+	return func(s *userService) {
+		s.attempts = store
+		s.lockout = policy
+	}
+}
+
+// WithDeletionGracePeriod delays self-service account deletion by grace,
+// during which the user can cancel it. Without it, or with zero, accounts
+// are deleted as soon as the user asks.
+func WithDeletionGracePeriod(grace time.Duration) UserServiceOption {
+	return func(s *userService) {
+		s.deletionGrace = grace
+	}
 }
 
-func NewUserService(repo user.UserRepository, idGen id.Generator) user.UserService {
-	return NewUserServiceWithLogger(repo, idGen, logger.Get().WithLayer("application").WithComponent("user_service"))
+// WithOrganizations keeps accounts that own organizations with other
+// members from being deleted until ownership is transferred. Organizations
+// the user is the only member of are deleted with the account.
+func WithOrganizations(repo organization.Repository) UserServiceOption {
+	return func(s *userService) {
+		s.orgs = repo
+	}
+}
+
+// WithEmailRules matches imported and locked out emails by rules, which
+// must be the ones the user repository uses
+func WithEmailRules(rules user.EmailRules) UserServiceOption {
+	return func(s *userService) {
+		s.emailRules = rules
+	}
 }
 
-func NewUserServiceWithLogger(repo user.UserRepository, idGen id.Generator, log logger.Logger) user.UserService {
+// WithHandlePolicy refuses the handles policy reserves in addition to the
+// built-in ones
+func WithHandlePolicy(policy user.HandlePolicy) UserServiceOption {
+	return func(s *userService) {
+		s.handlePolicy = policy
+	}
+}
+
+// WithAuthProvider checks login passwords with provider instead of the
+// stored hash. With provision, users the provider accepts but who have no
+// account yet get one on their first login.
+func WithAuthProvider(provider user.AuthProvider, provision bool) UserServiceOption {
+	return func(s *userService) {
+		s.authProvider = provider
+		s.provisionUsers = provision
+	}
+}
+
+// WithCaptcha requires a solved CAPTCHA where policy says so. Clients send
+// it in the request context with WithCaptchaToken.
+func WithCaptcha(verifier user.CaptchaVerifier, policy CaptchaPolicy) UserServiceOption {
+	return func(s *userService) {
+		s.captcha = verifier
+		s.captchaPolicy = policy
+	}
+}
+
+// WithSignupThrottle limits how many accounts may be registered per email
+// domain and per client IP within the policy's window
+func WithSignupThrottle(counter user.SignupCounter, policy SignupPolicy) UserServiceOption {
+	exempt := make([]string, len(policy.ExemptDomains))
+	for i, domain := range policy.ExemptDomains {
+		exempt[i] = strings.ToLower(domain)
+	}
+	policy.ExemptDomains = exempt
+	return func(s *userService) {
+		s.signups = counter
+		s.signupPolicy = policy
+	}
+}
+
+// WithDisposableEmailBlock refuses registrations with an email address of a
+// domain provider lists as disposable
+func WithDisposableEmailBlock(provider user.DisposableDomainProvider) UserServiceOption {
+	return func(s *userService) {
+		s.disposableDomains = provider
+	}
+}
+
+// WithSignupHoneypot refuses registrations that filled in the hidden form
+// field. Clients send its value in the request context with
+// WithHoneypotValue.
+func WithSignupHoneypot() UserServiceOption {
+	return func(s *userService) {
+		s.honeypot = true
+	}
+}
+
+// purgeBatchSize is how many due accounts PurgeDueDeletions loads at a time
+const purgeBatchSize = 100
+
+// noopUnitOfWork runs functions directly when no transaction manager is configured
+type noopUnitOfWork struct{}
+
+func (noopUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func NewUserService(repo user.UserRepository, idGen id.Generator, opts ...UserServiceOption) user.UserService {
+	return NewUserServiceWithLogger(repo, idGen, logger.Get().WithLayer("application").WithComponent("user_service"), opts...)
+}
+
+func NewUserServiceWithLogger(repo user.UserRepository, idGen id.Generator, log logger.Logger, opts ...UserServiceOption) user.UserService {
 	if repo == nil {
 		panic("user repository cannot be nil")
 	}
@@ -31,11 +290,17 @@ func NewUserServiceWithLogger(repo user.UserRepository, idGen id.Generator, log
 		panic("logger cannot be nil")
 	}
 
-	return &userService{
+	s := &userService{
 		repo:  repo,
 		idGen: idGen,
 		log:   log,
+		uow:   noopUnitOfWork{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 func (s *userService) Register(ctx context.Context, email, name, password string) (*user.User, error) {
@@ -47,51 +312,202 @@ func (s *userService) Register(ctx context.Context, email, name, password string
 		return nil, err
 	}
 
-	// Check if email already exists
-	existingUser, err := s.repo.GetByEmail(ctx, email)
-	if err != nil {
-		s.log.Error(ctx, "failed to check existing email", "error", err, "email", email)
+	if err := s.checkSignupAbuse(ctx, email); err != nil {
 		return nil, err
 	}
-	if existingUser != nil {
-		s.log.Warn(ctx, "email already exists", "email", email, "existing_user_id", existingUser.ID)
-		return nil, errors.NewDuplicateEntryError("user", "email", email, existingUser.ID)
-	}
 
-	// Create user aggregate
-	userID := s.idGen.Generate()
-	u := &user.User{
-		ID:        userID,
-		Email:     email,
-		Name:      name,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	if s.captcha != nil && s.captchaPolicy.Register {
+		if err := s.checkCaptcha(ctx, "register"); err != nil {
+			return nil, err
+		}
 	}
 
-	// Set password
-	if err := u.SetPassword(ctx, password); err != nil {
-		s.log.Warn(ctx, "password validation failed", "error", err, "user_id", userID)
+	// Only registrations that got past every other check use up the
+	// throttle, so bots failing the CAPTCHA cannot lock people out. The
+	// slots are reserved before the breach and duplicate checks, so
+	// parallel registrations cannot all slip under the limit, and are
+	// given back if the registration fails.
+	release, err := s.throttleSignup(ctx, email)
+	if err != nil {
 		return nil, err
 	}
+	registered := false
+	defer func() {
+		if !registered {
+			release()
+		}
+	}()
 
-	if s.log.DebugEnabled() {
-		s.log.Debug(ctx, "created user aggregate", "user_id", userID, "email", email, "name", name)
+	// Screen the password before opening a transaction; the check may call out over the network
+	if err := s.checkPasswordBreach(ctx, "password", password); err != nil {
+		return nil, err
 	}
 
-	// Validate the aggregate before persisting
-	if err := u.Validate(ctx); err != nil {
-		s.log.Warn(ctx, "user aggregate validation failed", "error", err, "user_id", userID)
+	var u *user.User
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		// Check if email already exists
+		existingUser, err := s.repo.GetByEmail(ctx, email)
+		if err != nil {
+			s.log.Error(ctx, "failed to check existing email", "error", err, "email", email)
+			return err
+		}
+		if existingUser != nil {
+			s.log.Warn(ctx, "email already exists", "email", email, "existing_user_id", existingUser.ID)
+			return errors.NewDuplicateEntryError("user", "email", email, existingUser.ID)
+		}
+
+		// Create user aggregate
+		userID := s.idGen.Generate()
+		u = &user.User{
+			ID:        userID,
+			Email:     email,
+			Name:      name,
+			Role:      user.RoleUser,
+			Status:    user.StatusActive,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		// Set password
+		if err := u.SetPassword(ctx, password); err != nil {
+			s.log.Warn(ctx, "password validation failed", "error", err, "user_id", userID)
+			return err
+		}
+
+		if s.log.DebugEnabled() {
+			s.log.Debug(ctx, "created user aggregate", "user_id", userID, "email", email, "name", name)
+		}
+
+		// Validate the aggregate before persisting
+		if err := u.Validate(ctx); err != nil {
+			s.log.Warn(ctx, "user aggregate validation failed", "error", err, "user_id", userID)
+			return err
+		}
+
+		// Persist the user
+		if err := s.repo.Create(ctx, u); err != nil {
+			s.log.Error(ctx, "failed to persist user", "error", err, "user_id", userID)
+			return err
+		}
+
+		u.MarkRegistered()
+		return s.stageEvents(ctx, u)
+	})
+	if err != nil {
 		return nil, err
 	}
+	registered = true
 
-	// Persist the user
-	if err := s.repo.Create(ctx, u); err != nil {
-		s.log.Error(ctx, "failed to persist user", "error", err, "user_id", userID)
+	s.publishEvents(ctx, u)
+	s.recordAudit(ctx, &audit.Entry{
+		ActorID:  u.ID,
+		Action:   audit.ActionRegister,
+		EntityID: u.ID,
+		Changes:  audit.Diff(nil, auditSnapshot(u)),
+	})
+
+	s.log.Info(ctx, "user registered successfully", "user_id", u.ID, "email", email)
+	return u, nil
+}
+
+// RegisterImported creates the users of rows whose email is not yet
+// registered the way Register does, without the checks meant for
+// self-service sign-ups. Passwords are screened and hashed before the
+// transaction opens, so a long import does not hold it; the users and their
+// events are written in it, and each registration is audited after commit.
+func (s *userService) RegisterImported(ctx context.Context, rows []user.ImportRow) (*user.ImportResult, error) {
+	result := &user.ImportResult{}
+
+	candidates := make([]*user.User, 0, len(rows))
+	rowOf := make(map[*user.User]user.ImportRow, len(rows))
+	now := time.Now()
+	for _, row := range rows {
+		if err := s.checkPasswordBreach(ctx, "password", row.Password); err != nil {
+			var validation *errors.ValidationError
+			if !stderrors.As(err, &validation) {
+				return nil, err
+			}
+			result.Rejected = append(result.Rejected, user.ImportRowError{
+				Row:    row.Row,
+				Email:  row.Email,
+				Fields: []errors.FieldError{{Field: validation.Field, Code: validation.ErrorCode, Message: validation.Message}},
+			})
+			continue
+		}
+
+		u := &user.User{
+			Email:     row.Email,
+			Name:      row.Name,
+			Role:      user.RoleUser,
+			Status:    user.StatusActive,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := u.SetPassword(ctx, row.Password); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, u)
+		rowOf[u] = row
+	}
+	if len(candidates) == 0 {
+		return result, nil
+	}
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		emails := make([]string, len(candidates))
+		for i, u := range candidates {
+			emails[i] = u.Email
+		}
+		existing, err := s.repo.ExistingEmails(ctx, emails)
+		if err != nil {
+			return err
+		}
+
+		var duplicates []user.ImportRowError
+		created := make([]*user.User, 0, len(candidates))
+		for _, u := range candidates {
+			if existing[s.emailRules.Canonicalize(u.Email)] {
+				row := rowOf[u]
+				duplicates = append(duplicates, user.ImportRowError{
+					Row:    row.Row,
+					Email:  row.Email,
+					Fields: []errors.FieldError{{Field: "email", Code: errors.CodeDuplicateEntry, Message: "email is already registered"}},
+				})
+				continue
+			}
+			u.ID = s.idGen.Generate()
+			created = append(created, u)
+		}
+
+		if err := s.repo.CreateBatch(ctx, created); err != nil {
+			s.log.Error(ctx, "failed to persist imported users", "error", err, "count", len(created))
+			return err
+		}
+		for _, u := range created {
+			u.MarkRegistered()
+			if err := s.stageEvents(ctx, u); err != nil {
+				return err
+			}
+		}
+
+		result.Created = created
+		result.Duplicates = duplicates
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	s.log.Info(ctx, "user registered successfully", "user_id", userID, "email", email)
-	return u, nil
+	for _, u := range result.Created {
+		s.publishEvents(ctx, u)
+		// The actor is the administrator running the import
+		s.recordAudit(ctx, &audit.Entry{
+			Action:   audit.ActionRegister,
+			EntityID: u.ID,
+			Changes:  audit.Diff(nil, auditSnapshot(u)),
+		})
+	}
+	return result, nil
 }
 
 func (s *userService) validateEmail(ctx context.Context, email string) error {
@@ -111,6 +527,180 @@ func (s *userService) validateEmail(ctx context.Context, email string) error {
 	return nil
 }
 
+// checkSignupAbuse refuses registrations that filled in the honeypot field
+// or use a disposable email domain. An unavailable domain list lets the
+// registration through.
+func (s *userService) checkSignupAbuse(ctx context.Context, email string) error {
+	if s.honeypot && honeypotValue(ctx) != "" {
+		s.log.Warn(ctx, "registration filled in the honeypot field", "email", email, "client_ip", clientIP(ctx))
+		return errors.NewSignupRejectedError("automated", "")
+	}
+
+	if s.disposableDomains == nil {
+		return nil
+	}
+	domain := emailDomain(email)
+	disposable, err := s.disposableDomains.IsDisposable(ctx, domain)
+	if err != nil {
+		s.log.Warn(ctx, "disposable email check unavailable, skipping", "error", err, "domain", domain)
+		return nil
+	}
+	if disposable {
+		s.log.Warn(ctx, "rejecting disposable email domain", "domain", domain)
+		return errors.NewSignupRejectedError("disposable_email", domain)
+	}
+	return nil
+}
+
+// throttleSignup counts a registration against its email domain and client
+// IP, refusing it once either is over the limit. Counter failures let the
+// registration through. The returned func gives the counted slots back; it
+// is called for refused registrations here and by the caller for those
+// that fail later.
+func (s *userService) throttleSignup(ctx context.Context, email string) (func(), error) {
+	var keys []string
+	release := func() {
+		// Give slots back even when the request was cancelled
+		ctx := context.WithoutCancel(ctx)
+		for _, key := range keys {
+			if err := s.signups.Release(ctx, key); err != nil {
+				s.log.Warn(ctx, "failed to release signup throttle slot", "error", err, "key", key)
+			}
+		}
+	}
+	if s.signups == nil {
+		return release, nil
+	}
+
+	count := func(scope, value string, limit int) error {
+		key := scope + ":" + value
+		n, retryAfter, err := s.signups.Increment(ctx, key, s.signupPolicy.Window)
+		if err != nil {
+			s.log.Warn(ctx, "signup throttle unavailable, skipping", "error", err, "scope", scope)
+			return nil
+		}
+		keys = append(keys, key)
+		if n > int64(limit) {
+			s.log.Warn(ctx, "registration throttled", "scope", scope, "value", value, "count", n, "retry_after", retryAfter)
+			return errors.NewSignupThrottledError(scope, retryAfter)
+		}
+		return nil
+	}
+
+	domain := emailDomain(email)
+	if s.signupPolicy.DomainLimit > 0 && !slices.Contains(s.signupPolicy.ExemptDomains, domain) {
+		if err := count("domain", domain, s.signupPolicy.DomainLimit); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	if ip := clientIP(ctx); s.signupPolicy.IPLimit > 0 && ip != "" {
+		if err := count("ip", ip, s.signupPolicy.IPLimit); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// emailDomain returns the lower-cased domain of email
+func emailDomain(email string) string {
+	return strings.ToLower(email[strings.LastIndexByte(email, '@')+1:])
+}
+
+// stageEvents moves the aggregate's pending events into the outbox within
+// the current transaction. Without an outbox the events stay on the
+// aggregate for publishEvents.
+func (s *userService) stageEvents(ctx context.Context, u *user.User) error {
+	if s.outbox == nil {
+		return nil
+	}
+
+	events := u.PendingEvents()
+	if len(events) == 0 {
+		return nil
+	}
+	if err := s.outbox.Append(ctx, events...); err != nil {
+		s.log.Error(ctx, "failed to stage domain events", "error", err, "user_id", u.ID, "count", len(events))
+		return err
+	}
+	u.PullEvents()
+	return nil
+}
+
+// publishEvents hands the aggregate's pending events to the event bus.
+// Publication failures are logged; the write has already been committed.
+func (s *userService) publishEvents(ctx context.Context, u *user.User) {
+	events := u.PullEvents()
+	if s.events == nil || len(events) == 0 {
+		return
+	}
+
+	if err := s.events.Publish(ctx, events...); err != nil {
+		s.log.Error(ctx, "failed to publish domain events", "error", err, "user_id", u.ID, "count", len(events))
+	}
+}
+
+// recordAudit hands an entry for the user entity to the audit recorder
+func (s *userService) recordAudit(ctx context.Context, entry *audit.Entry) {
+	if s.audit == nil {
+		return
+	}
+	entry.EntityType = "user"
+	s.audit.Record(ctx, entry)
+}
+
+// auditSnapshot returns the audited user fields. Credentials are never included.
+func auditSnapshot(u *user.User) map[string]interface{} {
+	if u == nil {
+		return nil
+	}
+	snapshot := map[string]interface{}{
+		"email":  u.Email,
+		"name":   u.Name,
+		"role":   u.Role,
+		"status": u.CurrentStatus(),
+	}
+	if u.Handle != nil {
+		snapshot["handle"] = *u.Handle
+	}
+	if u.Timezone != "" {
+		snapshot["timezone"] = u.Timezone
+	}
+	if u.Locale != "" {
+		snapshot["locale"] = u.Locale
+	}
+	if u.DeletionPending() {
+		snapshot["deletion_scheduled_at"] = *u.DeletionScheduledAt
+	}
+	return snapshot
+}
+
+// checkPasswordBreach screens a password against known breaches. Lookup
+// failures are logged and do not block the caller.
+func (s *userService) checkPasswordBreach(ctx context.Context, field, password string) error {
+	if s.breachChecker == nil {
+		return nil
+	}
+
+	count, err := s.breachChecker.BreachCount(ctx, password)
+	if err != nil {
+		s.log.Warn(ctx, "password breach check unavailable, skipping", "error", err)
+		return nil
+	}
+	if count == 0 {
+		return nil
+	}
+
+	if s.breachPolicy == BreachPolicyWarn {
+		s.log.Warn(ctx, "password found in known data breaches", "breach_count", count)
+		return nil
+	}
+
+	s.log.Warn(ctx, "rejecting password found in known data breaches", "breach_count", count)
+	return errors.NewInvalidValueError(field, "", "password has appeared in a known data breach")
+}
+
 // Login authenticates user with email and password
 func (s *userService) Login(ctx context.Context, email, password string) (*user.User, error) {
 	s.log.Info(ctx, "authenticating user", "email", email)
@@ -123,27 +713,315 @@ func (s *userService) Login(ctx context.Context, email, password string) (*user.
 		return nil, errors.NewRequiredFieldError("password", password)
 	}
 
-	// Get user by email
+	// Refuse locked accounts before checking the password so guesses
+	// cannot be confirmed while the lock holds
+	if err := s.checkLockout(ctx, email); err != nil {
+		return nil, err
+	}
+	if s.loginNeedsCaptcha(ctx, email) {
+		if err := s.checkCaptcha(ctx, "login"); err != nil {
+			return nil, err
+		}
+	}
+
+	u, err := s.authenticate(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// Suspended and deactivated accounts are refused only after the
+	// password matched, so their status is not revealed to guessers
+	if !u.IsActive() {
+		s.log.Warn(ctx, "login refused for inactive user", "user_id", u.ID, "status", u.CurrentStatus())
+		s.recordAudit(ctx, &audit.Entry{
+			ActorID:  u.ID,
+			Action:   audit.ActionLogin,
+			EntityID: u.ID,
+			Outcome:  audit.OutcomeFailure,
+		})
+		return nil, errors.NewAccountInactiveError(u.ID, u.CurrentStatus())
+	}
+
+	// Only the account counter is cleared; a successful login must not
+	// reset failures counted against the client IP
+	if s.attempts != nil {
+		if err := s.attempts.Reset(ctx, s.accountLockoutKey(ctx, email)); err != nil {
+			s.log.Warn(ctx, "failed to reset login failures", "error", err, "user_id", u.ID)
+		}
+	}
+
+	s.recordAudit(ctx, &audit.Entry{
+		ActorID:  u.ID,
+		Action:   audit.ActionLogin,
+		EntityID: u.ID,
+		Outcome:  audit.OutcomeSuccess,
+	})
+
+	s.log.Info(ctx, "user authenticated successfully", "user_id", u.ID, "email", email)
+	return u, nil
+}
+
+// authenticate checks the password against the stored hash, or with the
+// provider when one is configured. Accounts with a local password keep
+// signing in locally; only unknown and provider-managed ones go to it.
+func (s *userService) authenticate(ctx context.Context, email, password string) (*user.User, error) {
 	u, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
 		s.log.Error(ctx, "failed to get user by email", "error", err, "email", email)
 		return nil, err
 	}
+	if s.authProvider != nil && (u == nil || u.ExternalAuth != "") {
+		return s.authenticateWithProvider(ctx, email, password)
+	}
 	if u == nil {
 		s.log.Warn(ctx, "user not found for email", "email", email)
+		s.recordLoginFailure(ctx, "", email)
 		return nil, errors.NewEntityNotFoundError("user", email)
 	}
 
-	// Check password
 	if err := u.CheckPassword(ctx, password); err != nil {
 		s.log.Warn(ctx, "password check failed", "error", err, "user_id", u.ID)
+		s.recordLoginFailure(ctx, u.ID, email)
 		return nil, err
 	}
+	return u, nil
+}
 
-	s.log.Info(ctx, "user authenticated successfully", "user_id", u.ID, "email", email)
+// authenticateWithProvider checks the password with the external provider
+// and returns the matching local user, creating it on first login when
+// provisioning is enabled. A local account whose email the directory
+// happens to share is not the provider's to sign in.
+func (s *userService) authenticateWithProvider(ctx context.Context, login, password string) (*user.User, error) {
+	identity, err := s.authProvider.Authenticate(ctx, login, password)
+	if err != nil {
+		var unauthorized *errors.UnauthorizedError
+		if stderrors.As(err, &unauthorized) {
+			s.log.Warn(ctx, "provider rejected credentials", "provider", s.authProvider.Name(), "login", login)
+			s.recordLoginFailure(ctx, "", login)
+		} else {
+			s.log.Error(ctx, "authentication provider failed", "error", err, "provider", s.authProvider.Name())
+		}
+		return nil, err
+	}
+
+	u, err := s.repo.GetByEmail(ctx, identity.Email)
+	if err != nil {
+		s.log.Error(ctx, "failed to get user by email", "error", err, "email", identity.Email)
+		return nil, err
+	}
+	if u != nil {
+		if u.ExternalAuth != s.authProvider.Name() {
+			s.log.Warn(ctx, "provider identity matches an account it does not manage",
+				"provider", s.authProvider.Name(), "user_id", u.ID, "subject", identity.Subject)
+			s.recordLoginFailure(ctx, u.ID, login)
+			return nil, errors.NewUnauthorizedError("provider_login", u.ID, "account is not linked to "+s.authProvider.Name())
+		}
+		return u, nil
+	}
+	if !s.provisionUsers {
+		s.log.Warn(ctx, "no local user for provider identity", "provider", s.authProvider.Name(), "email", identity.Email)
+		s.recordLoginFailure(ctx, "", login)
+		return nil, errors.NewEntityNotFoundError("user", identity.Email)
+	}
+	return s.provisionUser(ctx, identity)
+}
+
+// provisionUser creates the local account of a user the provider
+// authenticated for the first time. It is marked as managed by the
+// provider and has no password, so it can only sign in through it.
+func (s *userService) provisionUser(ctx context.Context, identity *user.Identity) (*user.User, error) {
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	now := time.Now()
+	u := &user.User{
+		ID:           s.idGen.Generate(),
+		Email:        identity.Email,
+		Name:         name,
+		Role:         user.RoleUser,
+		Status:       user.StatusActive,
+		ExternalAuth: s.authProvider.Name(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := u.Validate(ctx); err != nil {
+		s.log.Warn(ctx, "provider identity cannot be provisioned", "error", err, "provider", s.authProvider.Name(), "subject", identity.Subject)
+		return nil, err
+	}
+
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, u); err != nil {
+			s.log.Error(ctx, "failed to persist provisioned user", "error", err, "user_id", u.ID)
+			return err
+		}
+		u.MarkRegistered()
+		return s.stageEvents(ctx, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEvents(ctx, u)
+	s.recordAudit(ctx, &audit.Entry{
+		ActorID:  u.ID,
+		Action:   audit.ActionRegister,
+		EntityID: u.ID,
+		Changes:  audit.Diff(nil, auditSnapshot(u)),
+	})
+
+	s.log.Info(ctx, "user provisioned on first login", "user_id", u.ID, "email", u.Email, "provider", s.authProvider.Name(), "subject", identity.Subject)
 	return u, nil
 }
 
+// recordLoginFailure audits a failed login and counts it towards a
+// lockout. The attempted email is kept so failures against unknown
+// accounts can still be traced.
+func (s *userService) recordLoginFailure(ctx context.Context, userID, email string) {
+	s.recordAudit(ctx, &audit.Entry{
+		Action:   audit.ActionLogin,
+		EntityID: userID,
+		Outcome:  audit.OutcomeFailure,
+		Changes:  audit.ChangeSet{"email": {To: email}},
+	})
+
+	if s.attempts == nil {
+		return
+	}
+	// Unknown emails are counted too, so probing does not reveal which accounts exist
+	s.countFailure(ctx, s.accountLockoutKey(ctx, email), s.lockout.Threshold, userID, "email", email)
+	if ip := clientIP(ctx); ip != "" && s.lockout.IPThreshold > 0 {
+		s.countFailure(ctx, ipLockoutKey(ip), s.lockout.IPThreshold, userID, "ip", ip)
+	}
+}
+
+// countFailure increments the failures for key and locks it once threshold
+// is reached. Store errors are logged and do not block the login.
+func (s *userService) countFailure(ctx context.Context, key string, threshold int, userID, field, value string) {
+	count, err := s.attempts.RecordFailure(ctx, key, s.lockout.Window)
+	if err != nil {
+		s.log.Warn(ctx, "failed to record login failure", "error", err, field, value)
+		return
+	}
+	if count < int64(threshold) {
+		return
+	}
+
+	if err := s.attempts.Lock(ctx, key, s.lockout.Duration); err != nil {
+		s.log.Warn(ctx, "failed to lock login", "error", err, field, value)
+		return
+	}
+
+	lockedUntil := time.Now().Add(s.lockout.Duration).UTC()
+	s.log.Warn(ctx, "login locked after repeated failures", field, value, "failures", count, "locked_until", lockedUntil)
+	s.recordAudit(ctx, &audit.Entry{
+		Action:   audit.ActionLockout,
+		EntityID: userID,
+		Outcome:  audit.OutcomeSuccess,
+		Changes: audit.ChangeSet{
+			field:          {To: value},
+			"locked_until": {To: lockedUntil.Format(time.RFC3339)},
+		},
+	})
+}
+
+// checkLockout returns an account locked error while the account or the
+// client IP is locked. Store errors fail open.
+func (s *userService) checkLockout(ctx context.Context, email string) error {
+	if s.attempts == nil {
+		return nil
+	}
+
+	keys := []string{s.accountLockoutKey(ctx, email)}
+	if ip := clientIP(ctx); ip != "" && s.lockout.IPThreshold > 0 {
+		keys = append(keys, ipLockoutKey(ip))
+	}
+
+	var remaining time.Duration
+	for _, key := range keys {
+		d, err := s.attempts.LockedFor(ctx, key)
+		if err != nil {
+			s.log.Warn(ctx, "failed to check login lockout", "error", err, "email", email)
+			continue
+		}
+		if d > remaining {
+			remaining = d
+		}
+	}
+	if remaining <= 0 {
+		return nil
+	}
+
+	s.log.Warn(ctx, "login refused while locked", "email", email, "retry_after", remaining)
+	return errors.NewAccountLockedError(remaining)
+}
+
+// loginNeedsCaptcha reports whether the account or the client IP failed
+// enough logins to require a CAPTCHA. Store errors fail open, like the
+// lockout itself.
+func (s *userService) loginNeedsCaptcha(ctx context.Context, email string) bool {
+	if s.captcha == nil || s.captchaPolicy.LoginThreshold <= 0 || s.attempts == nil {
+		return false
+	}
+
+	keys := []string{s.accountLockoutKey(ctx, email)}
+	if ip := clientIP(ctx); ip != "" {
+		keys = append(keys, ipLockoutKey(ip))
+	}
+	for _, key := range keys {
+		failures, err := s.attempts.Failures(ctx, key)
+		if err != nil {
+			s.log.Warn(ctx, "failed to count login failures", "error", err, "email", email)
+			continue
+		}
+		if failures >= int64(s.captchaPolicy.LoginThreshold) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCaptcha verifies the CAPTCHA solution in ctx. Verifier errors are
+// returned, so requests are refused while the provider is unavailable.
+func (s *userService) checkCaptcha(ctx context.Context, operation string) error {
+	token := captchaToken(ctx)
+	if token == "" {
+		s.log.Info(ctx, "captcha required", "operation", operation)
+		return errors.NewCaptchaRequiredError(operation, "a solved captcha is required")
+	}
+
+	ok, err := s.captcha.Verify(ctx, token, clientIP(ctx))
+	if err != nil {
+		s.log.Error(ctx, "captcha verification failed", "error", err, "operation", operation)
+		return err
+	}
+	if !ok {
+		s.log.Warn(ctx, "captcha rejected", "operation", operation)
+		return errors.NewCaptchaRequiredError(operation, "the captcha was not solved")
+	}
+	return nil
+}
+
+// accountLockoutKey names the lockout counter of an account. Keys of the
+// default tenant carry no tenant so they survive enabling multi-tenancy.
+func (s *userService) accountLockoutKey(ctx context.Context, email string) string {
+	email = s.emailRules.Canonicalize(email)
+	if tenantID := tenant.IDFromContext(ctx); tenantID != tenant.DefaultID {
+		return "account:" + tenantID + ":" + email
+	}
+	return "account:" + email
+}
+
+func ipLockoutKey(ip string) string {
+	return "ip:" + ip
+}
+
+func clientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
+
 // ChangePassword changes user password
 func (s *userService) ChangePassword(ctx context.Context, id string, oldPassword, newPassword string) error {
 	s.log.Info(ctx, "changing user password", "user_id", id)
@@ -158,6 +1036,9 @@ func (s *userService) ChangePassword(ctx context.Context, id string, oldPassword
 		return errors.NewRequiredFieldError("new_password", newPassword)
 	}
 
+	// The user is saved back in full, so it must not be read from a lagging replica
+	ctx = transaction.WithConsistentReads(ctx)
+
 	// Get existing user
 	u, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -181,6 +1062,10 @@ func (s *userService) ChangePassword(ctx context.Context, id string, oldPassword
 		return err
 	}
 
+	if err := s.checkPasswordBreach(ctx, "new_password", newPassword); err != nil {
+		return err
+	}
+
 	// Update timestamp
 	u.UpdatedAt = time.Now()
 
@@ -196,10 +1081,234 @@ func (s *userService) ChangePassword(ctx context.Context, id string, oldPassword
 		return err
 	}
 
+	s.recordAudit(ctx, &audit.Entry{Action: audit.ActionChangePassword, EntityID: id})
+
 	s.log.Info(ctx, "user password changed successfully", "user_id", id)
 	return nil
 }
 
+// ResetPassword replaces a user's password without the old one. Callers
+// must have already authorized the operation.
+func (s *userService) ResetPassword(ctx context.Context, id string, newPassword string) error {
+	s.log.Info(ctx, "resetting user password", "user_id", id)
+
+	if id == "" {
+		return errors.NewRequiredFieldError("id", id)
+	}
+	if newPassword == "" {
+		return errors.NewRequiredFieldError("new_password", newPassword)
+	}
+
+	// The user is saved back in full, so it must not be read from a lagging replica
+	ctx = transaction.WithConsistentReads(ctx)
+
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.log.Error(ctx, "failed to get user for password reset", "error", err, "user_id", id)
+		return err
+	}
+	if u == nil {
+		s.log.Warn(ctx, "user not found for password reset", "user_id", id)
+		return errors.NewEntityNotFoundError("user", id)
+	}
+
+	if err := u.SetPassword(ctx, newPassword); err != nil {
+		s.log.Warn(ctx, "new password validation failed", "error", err, "user_id", id)
+		return err
+	}
+
+	if err := s.checkPasswordBreach(ctx, "new_password", newPassword); err != nil {
+		return err
+	}
+
+	u.UpdatedAt = time.Now()
+
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, u); err != nil {
+			s.log.Error(ctx, "failed to persist password reset", "error", err, "user_id", id)
+			return err
+		}
+
+		u.MarkPasswordReset()
+		return s.stageEvents(ctx, u)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publishEvents(ctx, u)
+	s.recordAudit(ctx, &audit.Entry{Action: audit.ActionResetPassword, EntityID: id})
+
+	s.log.Info(ctx, "user password reset successfully", "user_id", id)
+	return nil
+}
+
+// SuspendUser blocks a user from signing in until reactivated
+func (s *userService) SuspendUser(ctx context.Context, id, reason string) (*user.User, error) {
+	s.log.Info(ctx, "suspending user", "user_id", id)
+	return s.changeState(ctx, id, audit.ActionSuspend, func(u *user.User) error {
+		return u.Suspend(reason)
+	})
+}
+
+// DeactivateUser closes an account without deleting it
+func (s *userService) DeactivateUser(ctx context.Context, id, reason string) (*user.User, error) {
+	s.log.Info(ctx, "deactivating user", "user_id", id)
+	return s.changeState(ctx, id, audit.ActionDeactivate, func(u *user.User) error {
+		return u.Deactivate(reason)
+	})
+}
+
+// ReactivateUser lets a suspended or deactivated user sign in again
+func (s *userService) ReactivateUser(ctx context.Context, id string) (*user.User, error) {
+	s.log.Info(ctx, "reactivating user", "user_id", id)
+	return s.changeState(ctx, id, audit.ActionReactivate, func(u *user.User) error {
+		return u.Reactivate()
+	})
+}
+
+// ScheduleDeletion deletes the user's own account once the grace period
+// has passed, or at once when there is none
+func (s *userService) ScheduleDeletion(ctx context.Context, id string) (*user.User, error) {
+	if s.deletionGrace <= 0 {
+		return nil, s.DeleteUser(ctx, id)
+	}
+	// Refused now rather than when the purge comes round
+	if _, err := s.soleOwnedOrganizations(ctx, id); err != nil {
+		return nil, err
+	}
+
+	s.log.Info(ctx, "scheduling user deletion", "user_id", id, "grace_period", s.deletionGrace)
+	return s.changeState(ctx, id, audit.ActionScheduleDelete, func(u *user.User) error {
+		return u.ScheduleDeletion(time.Now().Add(s.deletionGrace))
+	})
+}
+
+// CancelDeletion keeps an account whose deletion is still pending
+func (s *userService) CancelDeletion(ctx context.Context, id string) (*user.User, error) {
+	s.log.Info(ctx, "cancelling user deletion", "user_id", id)
+	return s.changeState(ctx, id, audit.ActionCancelDelete, func(u *user.User) error {
+		return u.CancelDeletion()
+	})
+}
+
+// PurgeDueDeletions deletes accounts of every tenant whose grace period has
+// passed. Accounts that fail to delete are logged and retried on the next
+// run; the first such error is returned with the number deleted.
+func (s *userService) PurgeDueDeletions(ctx context.Context) (int, error) {
+	now := time.Now()
+	var (
+		deleted  int
+		firstErr error
+	)
+	for {
+		due, err := s.repo.ListDueForDeletion(ctx, now, purgeBatchSize)
+		if err != nil {
+			s.log.Error(ctx, "failed to list users due for deletion", "error", err)
+			return deleted, err
+		}
+
+		failed := 0
+		for _, u := range due {
+			// The repository scopes deletes to the tenant of the context
+			removed, err := s.deleteUser(tenant.WithID(ctx, u.TenantID), u.ID, func(current *user.User) bool {
+				return current.DeletionDue(now)
+			})
+			if err != nil {
+				s.log.Error(ctx, "failed to purge user", "error", err, "user_id", u.ID, "tenant_id", u.TenantID)
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			if removed {
+				deleted++
+			}
+		}
+		// Failed accounts would be listed again, so stop rather than loop
+		if len(due) < purgeBatchSize || failed > 0 {
+			break
+		}
+	}
+
+	s.log.Info(ctx, "purged users due for deletion", "deleted", deleted)
+	return deleted, firstErr
+}
+
+// soleOwnedOrganizations returns the organizations the user owns and is
+// the only member of. It fails when the user owns one with other members,
+// since deleting the account would leave it without an owner.
+func (s *userService) soleOwnedOrganizations(ctx context.Context, id string) ([]*organization.Organization, error) {
+	if s.orgs == nil {
+		return nil, nil
+	}
+	owned, err := s.orgs.ListOwnedBy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var sole []*organization.Organization
+	for _, o := range owned {
+		members, err := s.orgs.ListMembers(ctx, o.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) > 1 {
+			return nil, errors.NewBusinessRuleError("organization_owner",
+				fmt.Sprintf("transfer ownership of organization %q before deleting the account", o.Name))
+		}
+		sole = append(sole, o)
+	}
+	return sole, nil
+}
+
+// changeState applies a state change such as a status transition to the
+// user, persists it with its events and audits it as action
+func (s *userService) changeState(ctx context.Context, id, action string, transition func(*user.User) error) (*user.User, error) {
+	if id == "" {
+		return nil, errors.NewRequiredFieldError("id", id)
+	}
+
+	var (
+		u      *user.User
+		before map[string]interface{}
+	)
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		u, err = s.repo.GetByID(ctx, id)
+		if err != nil {
+			s.log.Error(ctx, "failed to get user for state change", "error", err, "user_id", id)
+			return err
+		}
+		if u == nil {
+			s.log.Warn(ctx, "user not found for state change", "user_id", id)
+			return errors.NewEntityNotFoundError("user", id)
+		}
+		before = auditSnapshot(u)
+
+		if err := transition(u); err != nil {
+			s.log.Warn(ctx, "user state change refused", "error", err, "user_id", id, "action", action)
+			return err
+		}
+		u.UpdatedAt = time.Now()
+
+		if err := s.repo.Update(ctx, u); err != nil {
+			s.log.Error(ctx, "failed to persist user state", "error", err, "user_id", id)
+			return err
+		}
+		return s.stageEvents(ctx, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEvents(ctx, u)
+	s.recordAudit(ctx, &audit.Entry{Action: action, EntityID: id, Changes: audit.Diff(before, auditSnapshot(u))})
+
+	s.log.Info(ctx, "user state changed", "user_id", id, "action", action, "status", u.CurrentStatus())
+	return u, nil
+}
+
 // GetProfile retrieves user profile by ID
 func (s *userService) GetProfile(ctx context.Context, id string) (*user.User, error) {
 	s.log.Info(ctx, "getting user profile", "user_id", id)
@@ -224,6 +1333,53 @@ func (s *userService) GetProfile(ctx context.Context, id string) (*user.User, er
 	return u, nil
 }
 
+// GetByHandle returns the profile of the user holding handle
+func (s *userService) GetByHandle(ctx context.Context, handle string) (*user.User, error) {
+	handle = user.NormalizeHandle(handle)
+	if handle == "" {
+		return nil, errors.NewRequiredFieldError("handle", handle)
+	}
+
+	u, err := s.repo.GetByHandle(ctx, handle)
+	if err != nil {
+		s.log.Error(ctx, "failed to get user by handle", "error", err, "handle", handle)
+		return nil, err
+	}
+	if u == nil {
+		return nil, errors.NewEntityNotFoundError("user", handle)
+	}
+	return u, nil
+}
+
+// CheckHandleAvailability tells whether handle is valid, not reserved and
+// not held by a user of the tenant
+func (s *userService) CheckHandleAvailability(ctx context.Context, handle string) (*user.HandleAvailability, error) {
+	availability := &user.HandleAvailability{Handle: user.NormalizeHandle(handle)}
+	if availability.Handle == "" {
+		return nil, errors.NewRequiredFieldError("handle", handle)
+	}
+
+	if err := s.handlePolicy.Validate(availability.Handle); err != nil {
+		availability.Reason = user.HandleInvalid
+		if s.handlePolicy.IsReserved(availability.Handle) {
+			availability.Reason = user.HandleReserved
+		}
+		return availability, nil
+	}
+
+	holder, err := s.repo.GetByHandle(ctx, availability.Handle)
+	if err != nil {
+		s.log.Error(ctx, "failed to check handle availability", "error", err, "handle", availability.Handle)
+		return nil, err
+	}
+	if holder != nil {
+		availability.Reason = user.HandleTaken
+		return availability, nil
+	}
+	availability.Available = true
+	return availability, nil
+}
+
 // UpdateProfile updates user profile information
 func (s *userService) UpdateProfile(ctx context.Context, id string, req *user.UpdateProfileRequest) (*user.User, error) {
 	s.log.Info(ctx, "updating user profile", "user_id", id)
@@ -236,57 +1392,123 @@ func (s *userService) UpdateProfile(ctx context.Context, id string, req *user.Up
 		return nil, errors.NewRequiredFieldError("request", "nil")
 	}
 
-	// Get existing user
-	u, err := s.repo.GetByID(ctx, id)
-	if err != nil {
-		s.log.Error(ctx, "failed to get user for update", "error", err, "user_id", id)
-		return nil, err
-	}
+	// The email uniqueness check and the update must be atomic
+	var (
+		u      *user.User
+		before map[string]interface{}
+	)
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		// Get existing user
+		var err error
+		u, err = s.repo.GetByID(ctx, id)
+		if err != nil {
+			s.log.Error(ctx, "failed to get user for update", "error", err, "user_id", id)
+			return err
+		}
 
-	if u == nil {
-		s.log.Warn(ctx, "user not found for update", "user_id", id)
-		return nil, errors.NewEntityNotFoundError("user", id)
-	}
+		if u == nil {
+			s.log.Warn(ctx, "user not found for update", "user_id", id)
+			return errors.NewEntityNotFoundError("user", id)
+		}
+		before = auditSnapshot(u)
+		unmodifiedSince := u.UpdatedAt
 
-	// Update fields if provided
-	if req.Name != "" {
-		if err := u.UpdateName(ctx, req.Name); err != nil {
-			s.log.Warn(ctx, "failed to update user name", "error", err, "user_id", id)
-			return nil, err
+		// Optimistic concurrency: refuse to overwrite changes the caller has
+		// not seen. The write below is conditional on the version read here,
+		// so a concurrent update with the same version cannot slip in between.
+		if len(req.IfVersion) > 0 && !slices.Contains(req.IfVersion, u.Version()) {
+			s.log.Warn(ctx, "user version mismatch on update", "user_id", id)
+			return errors.NewVersionMismatchError("user", id, u.Version())
 		}
-	}
 
-	if req.Email != "" {
-		// Check if new email already exists (but not for the same user)
-		existingUser, err := s.repo.GetByEmail(ctx, req.Email)
-		if err != nil {
-			s.log.Error(ctx, "failed to check existing email", "error", err, "email", req.Email)
-			return nil, err
+		// Update fields if provided
+		if req.Name != nil {
+			if err := u.UpdateName(ctx, *req.Name); err != nil {
+				s.log.Warn(ctx, "failed to update user name", "error", err, "user_id", id)
+				return err
+			}
 		}
-		if existingUser != nil && existingUser.ID != id {
-			s.log.Warn(ctx, "email already exists for another user", "email", req.Email, "existing_user_id", existingUser.ID)
-			return nil, errors.NewDuplicateEntryError("user", "email", req.Email, existingUser.ID)
+
+		if req.Email != nil {
+			// Check if new email already exists (but not for the same user)
+			existingUser, err := s.repo.GetByEmail(ctx, *req.Email)
+			if err != nil {
+				s.log.Error(ctx, "failed to check existing email", "error", err, "email", *req.Email)
+				return err
+			}
+			if existingUser != nil && existingUser.ID != id {
+				s.log.Warn(ctx, "email already exists for another user", "email", *req.Email, "existing_user_id", existingUser.ID)
+				return errors.NewDuplicateEntryError("user", "email", *req.Email, existingUser.ID)
+			}
+
+			if err := u.UpdateEmail(ctx, *req.Email); err != nil {
+				s.log.Warn(ctx, "failed to update user email", "error", err, "user_id", id)
+				return err
+			}
 		}
 
-		if err := u.UpdateEmail(ctx, req.Email); err != nil {
-			s.log.Warn(ctx, "failed to update user email", "error", err, "user_id", id)
-			return nil, err
+		if req.Handle != nil {
+			// Handles are unique per tenant; the index catches races
+			if handle := user.NormalizeHandle(*req.Handle); handle != "" && handle != u.CurrentHandle() {
+				holder, err := s.repo.GetByHandle(ctx, handle)
+				if err != nil {
+					s.log.Error(ctx, "failed to check existing handle", "error", err, "handle", handle)
+					return err
+				}
+				if holder != nil && holder.ID != id {
+					s.log.Warn(ctx, "handle already taken", "handle", handle, "existing_user_id", holder.ID)
+					return errors.NewDuplicateEntryError("user", "handle", handle, holder.ID)
+				}
+			}
+
+			if err := u.ChangeHandle(ctx, *req.Handle, s.handlePolicy); err != nil {
+				s.log.Warn(ctx, "failed to update user handle", "error", err, "user_id", id)
+				return err
+			}
 		}
-	}
 
-	// Update timestamp
-	u.UpdatedAt = time.Now()
+		if req.Timezone != nil {
+			if err := u.ChangeTimezone(ctx, *req.Timezone); err != nil {
+				s.log.Warn(ctx, "failed to update user timezone", "error", err, "user_id", id)
+				return err
+			}
+		}
 
-	// Validate the updated aggregate
-	if err := u.Validate(ctx); err != nil {
-		s.log.Warn(ctx, "user aggregate validation failed after update", "error", err, "user_id", id)
+		if req.Locale != nil {
+			if err := u.ChangeLocale(ctx, *req.Locale); err != nil {
+				s.log.Warn(ctx, "failed to update user locale", "error", err, "user_id", id)
+				return err
+			}
+		}
+
+		// Update timestamp
+		u.UpdatedAt = time.Now()
+
+		// Validate the updated aggregate
+		if err := u.Validate(ctx); err != nil {
+			s.log.Warn(ctx, "user aggregate validation failed after update", "error", err, "user_id", id)
+			return err
+		}
+
+		// Persist the updated user
+		if len(req.IfVersion) > 0 {
+			err = s.repo.UpdateIfUnmodified(ctx, u, unmodifiedSince)
+		} else {
+			err = s.repo.Update(ctx, u)
+		}
+		if err != nil {
+			s.log.Error(ctx, "failed to persist user update", "error", err, "user_id", id)
+			return err
+		}
+		return s.stageEvents(ctx, u)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Persist the updated user
-	if err := s.repo.Update(ctx, u); err != nil {
-		s.log.Error(ctx, "failed to persist user update", "error", err, "user_id", id)
-		return nil, err
+	s.publishEvents(ctx, u)
+	if changes := audit.Diff(before, auditSnapshot(u)); changes != nil {
+		s.recordAudit(ctx, &audit.Entry{Action: audit.ActionUpdate, EntityID: id, Changes: changes})
 	}
 
 	s.log.Info(ctx, "user profile updated successfully", "user_id", id)
@@ -310,6 +1532,16 @@ func (s *userService) ListUsers(ctx context.Context, req *user.ListUsersRequest)
 	if req.PageSize < 1 {
 		req.PageSize = 10
 	}
+	if req.SortBy == "" {
+		req.SortBy = user.SortByCreatedAt
+	}
+	if req.SortOrder == "" {
+		req.SortOrder = user.SortDesc
+	}
+
+	if !req.CreatedAfter.IsZero() && !req.CreatedBefore.IsZero() && !req.CreatedAfter.Before(req.CreatedBefore) {
+		return nil, errors.NewInvalidValueError("created_after", req.CreatedAfter, "must be before created_before")
+	}
 
 	response, err := s.repo.List(ctx, req)
 	if err != nil {
@@ -329,24 +1561,72 @@ func (s *userService) DeleteUser(ctx context.Context, id string) error {
 		return errors.NewRequiredFieldError("id", id)
 	}
 
-	// Check if user exists before deleting
-	u, err := s.repo.GetByID(ctx, id)
+	_, err := s.deleteUser(ctx, id, nil)
+	return err
+}
+
+// deleteUser removes the user if due reports true for its current state,
+// or unconditionally when due is nil, and reports whether it was removed
+func (s *userService) deleteUser(ctx context.Context, id string, due func(*user.User) bool) (bool, error) {
+	var (
+		u       *user.User
+		skipped bool
+	)
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		// Check if user exists before deleting
+		var err error
+		u, err = s.repo.GetByID(ctx, id)
+		if err != nil {
+			s.log.Error(ctx, "failed to get user for deletion", "error", err, "user_id", id)
+			return err
+		}
+
+		if u == nil {
+			s.log.Warn(ctx, "user not found for deletion", "user_id", id)
+			return errors.NewEntityNotFoundError("user", id)
+		}
+
+		// A scheduled deletion may have been cancelled since it was listed
+		if due != nil && !due(u) {
+			skipped = true
+			return nil
+		}
+
+		sole, err := s.soleOwnedOrganizations(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, o := range sole {
+			if err := s.orgs.Delete(ctx, o.ID); err != nil {
+				return err
+			}
+		}
+
+		// Delete the user
+		if err := s.repo.Delete(ctx, id); err != nil {
+			s.log.Error(ctx, "failed to delete user", "error", err, "user_id", id)
+			return err
+		}
+
+		// Only the purge, which passes due, carries out a user's request
+		u.MarkDeleted(due != nil)
+		return s.stageEvents(ctx, u)
+	})
 	if err != nil {
-		s.log.Error(ctx, "failed to get user for deletion", "error", err, "user_id", id)
-		return err
+		return false, err
 	}
-
-	if u == nil {
-		s.log.Warn(ctx, "user not found for deletion", "user_id", id)
-		return errors.NewEntityNotFoundError("user", id)
+	if skipped {
+		s.log.Info(ctx, "user no longer due for deletion", "user_id", id)
+		return false, nil
 	}
 
-	// Delete the user
-	if err := s.repo.Delete(ctx, id); err != nil {
-		s.log.Error(ctx, "failed to delete user", "error", err, "user_id", id)
-		return err
-	}
+	s.publishEvents(ctx, u)
+	s.recordAudit(ctx, &audit.Entry{
+		Action:   audit.ActionDelete,
+		EntityID: id,
+		Changes:  audit.Diff(auditSnapshot(u), nil),
+	})
 
 	s.log.Info(ctx, "user deleted successfully", "user_id", id)
-	return nil
+	return true, nil
 }
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+// recordingAuditLog captures audit entries synchronously
+type recordingAuditLog struct {
+	entries []*audit.Entry
+}
+
+func (r *recordingAuditLog) Record(ctx context.Context, entry *audit.Entry) {
+	r.entries = append(r.entries, entry)
+}
+
+func TestUserService_RecordsAuditEntries(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockIDGen := idMocks.NewMockGenerator(ctrl)
+	auditLog := &recordingAuditLog{}
+	svc := NewUserService(mockRepo, mockIDGen, WithAuditLog(auditLog))
+	ctx := context.Background()
+
+	// Register
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(nil, nil)
+	mockIDGen.EXPECT().Generate().Return("user-1")
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	registered, err := svc.Register(ctx, "test@example.com", "Test User", "testpassword123")
+	require.NoError(t, err)
+
+	// Login, once failing and once succeeding
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(registered, nil).Times(2)
+	_, err = svc.Login(ctx, "test@example.com", "wrong-password")
+	require.Error(t, err)
+	_, err = svc.Login(ctx, "test@example.com", "testpassword123")
+	require.NoError(t, err)
+
+	// Update
+	mockRepo.EXPECT().GetByID(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Email: "test@example.com", Name: "Test User", Role: user.RoleUser}, nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+	_, err = svc.UpdateProfile(ctx, "user-1", &user.UpdateProfileRequest{Name: ptr("Renamed")})
+	require.NoError(t, err)
+
+	// Delete
+	mockRepo.EXPECT().GetByID(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Email: "test@example.com", Name: "Renamed", Role: user.RoleUser}, nil)
+	mockRepo.EXPECT().Delete(gomock.Any(), "user-1").Return(nil)
+	require.NoError(t, svc.DeleteUser(ctx, "user-1"))
+
+	require.Len(t, auditLog.entries, 5)
+
+	reg := auditLog.entries[0]
+	assert.Equal(t, audit.ActionRegister, reg.Action)
+	assert.Equal(t, "user", reg.EntityType)
+	assert.Equal(t, "user-1", reg.ActorID)
+	assert.Equal(t, audit.Change{To: "test@example.com"}, reg.Changes["email"])
+	assert.NotContains(t, reg.Changes, "password_hash")
+
+	assert.Equal(t, audit.ActionLogin, auditLog.entries[1].Action)
+	assert.Equal(t, audit.OutcomeFailure, auditLog.entries[1].Outcome)
+	assert.Equal(t, audit.ActionLogin, auditLog.entries[2].Action)
+	assert.Equal(t, audit.OutcomeSuccess, auditLog.entries[2].Outcome)
+
+	update := auditLog.entries[3]
+	assert.Equal(t, audit.ActionUpdate, update.Action)
+	assert.Equal(t, audit.ChangeSet{"name": {From: "Test User", To: "Renamed"}}, update.Changes)
+
+	del := auditLog.entries[4]
+	assert.Equal(t, audit.ActionDelete, del.Action)
+	assert.Equal(t, audit.Change{From: "Renamed"}, del.Changes["name"])
+}
+
+func TestUserService_NoAuditEntryOnFailedWrite(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockIDGen := idMocks.NewMockGenerator(ctrl)
+	auditLog := &recordingAuditLog{}
+	svc := NewUserService(mockRepo, mockIDGen, WithAuditLog(auditLog))
+
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&user.User{ID: "existing"}, nil)
+	_, err := svc.Register(context.Background(), "test@example.com", "Test User", "testpassword123")
+	require.Error(t, err)
+
+	assert.Empty(t, auditLog.entries)
+}
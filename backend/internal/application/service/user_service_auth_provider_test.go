@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+func TestUserService_Login_AuthProvider(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	identity := &user.Identity{Subject: "uid=alice,dc=example,dc=com", Email: "alice@example.com", Name: "Alice"}
+
+	newProvider := func(ctrl *gomock.Controller) *mocks.MockAuthProvider {
+		provider := mocks.NewMockAuthProvider(ctrl)
+		provider.EXPECT().Name().Return("ldap").AnyTimes()
+		return provider
+	}
+
+	t.Run("existing user signs in without a local password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		provider := newProvider(ctrl)
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithAuthProvider(provider, false))
+
+		existing := &user.User{ID: "user-1", Email: "alice@example.com", Name: "Alice", Role: user.RoleUser, ExternalAuth: "ldap"}
+		provider.EXPECT().Authenticate(gomock.Any(), "alice@example.com", "directory-password").Return(identity, nil)
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "alice@example.com").Return(existing, nil).Times(2)
+
+		u, err := svc.Login(ctx, "alice@example.com", "directory-password")
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", u.ID)
+	})
+
+	t.Run("first login provisions the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		mockIDGen := idMocks.NewMockGenerator(ctrl)
+		provider := newProvider(ctrl)
+		bus := &recordingBus{}
+		auditLog := &recordingAuditLog{}
+		svc := NewUserService(mockRepo, mockIDGen, WithAuthProvider(provider, true), WithEventBus(bus), WithAuditLog(auditLog))
+
+		provider.EXPECT().Authenticate(gomock.Any(), "alice@example.com", "directory-password").Return(identity, nil)
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "alice@example.com").Return(nil, nil).Times(2)
+		mockIDGen.EXPECT().Generate().Return("user-2")
+		var created *user.User
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u *user.User) error {
+			created = u
+			return nil
+		})
+
+		u, err := svc.Login(ctx, "alice@example.com", "directory-password")
+		require.NoError(t, err)
+		require.NotNil(t, created)
+		assert.Equal(t, "user-2", u.ID)
+		assert.Equal(t, "Alice", u.Name)
+		assert.Empty(t, created.PasswordHash)
+		assert.Equal(t, "ldap", created.ExternalAuth)
+		assert.Equal(t, []string{"user.registered"}, eventNames(bus.published))
+
+		require.Len(t, auditLog.entries, 2)
+		assert.Equal(t, audit.ActionRegister, auditLog.entries[0].Action)
+		assert.Equal(t, audit.ActionLogin, auditLog.entries[1].Action)
+		assert.Equal(t, audit.OutcomeSuccess, auditLog.entries[1].Outcome)
+	})
+
+	t.Run("unknown identity is refused when provisioning is off", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		provider := newProvider(ctrl)
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithAuthProvider(provider, false))
+
+		provider.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Return(identity, nil)
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "alice@example.com").Return(nil, nil).Times(2)
+
+		_, err := svc.Login(ctx, "alice@example.com", "directory-password")
+		var notFound *wonderErrors.EntityNotFoundError
+		assert.ErrorAs(t, err, &notFound)
+	})
+
+	t.Run("rejected credentials are audited as a failure", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		provider := newProvider(ctrl)
+		auditLog := &recordingAuditLog{}
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithAuthProvider(provider, true), WithAuditLog(auditLog))
+
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "alice@example.com").Return(nil, nil)
+		provider.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, wonderErrors.NewUnauthorizedError("ldap_login", "", "invalid credentials"))
+
+		_, err := svc.Login(ctx, "alice@example.com", "wrong-password")
+		var unauthorized *wonderErrors.UnauthorizedError
+		require.ErrorAs(t, err, &unauthorized)
+		require.Len(t, auditLog.entries, 1)
+		assert.Equal(t, audit.OutcomeFailure, auditLog.entries[0].Outcome)
+	})
+
+	t.Run("provider outage is not counted as a failed login", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		provider := newProvider(ctrl)
+		auditLog := &recordingAuditLog{}
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithAuthProvider(provider, true), WithAuditLog(auditLog))
+
+		outage := errors.New("connection refused")
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "alice@example.com").Return(nil, nil)
+		provider.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, outage)
+
+		_, err := svc.Login(ctx, "alice@example.com", "directory-password")
+		assert.ErrorIs(t, err, outage)
+		assert.Empty(t, auditLog.entries)
+	})
+
+	t.Run("local accounts keep signing in locally", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		provider := newProvider(ctrl)
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithAuthProvider(provider, true))
+
+		local := &user.User{ID: "admin-1", Email: "admin@example.com", Name: "Admin", Role: user.RoleAdmin}
+		require.NoError(t, local.SetPassword(ctx, "local-password"))
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "admin@example.com").Return(local, nil)
+
+		u, err := svc.Login(ctx, "admin@example.com", "local-password")
+		require.NoError(t, err)
+		assert.Equal(t, "admin-1", u.ID)
+	})
+
+	t.Run("directory identity cannot sign in as a local account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		provider := newProvider(ctrl)
+		auditLog := &recordingAuditLog{}
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithAuthProvider(provider, true), WithAuditLog(auditLog))
+
+		// The directory login differs from the email its entry carries
+		local := &user.User{ID: "admin-1", Email: "alice@example.com", Name: "Admin", Role: user.RoleAdmin}
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "alice").Return(nil, nil)
+		provider.EXPECT().Authenticate(gomock.Any(), "alice", "directory-password").Return(identity, nil)
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "alice@example.com").Return(local, nil)
+
+		_, err := svc.Login(ctx, "alice", "directory-password")
+		var unauthorized *wonderErrors.UnauthorizedError
+		require.ErrorAs(t, err, &unauthorized)
+		require.Len(t, auditLog.entries, 1)
+		assert.Equal(t, audit.OutcomeFailure, auditLog.entries[0].Outcome)
+		assert.Equal(t, "admin-1", auditLog.entries[0].EntityID)
+	})
+
+	t.Run("directory users cannot sign in with a local password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		auditLog := &recordingAuditLog{}
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithAuditLog(auditLog))
+
+		provisioned := &user.User{ID: "user-2", Email: "alice@example.com", Name: "Alice", Role: user.RoleUser, ExternalAuth: "ldap"}
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "alice@example.com").Return(provisioned, nil)
+
+		_, err := svc.Login(ctx, "alice@example.com", "directory-password")
+		var unauthorized *wonderErrors.UnauthorizedError
+		require.ErrorAs(t, err, &unauthorized)
+		require.Len(t, auditLog.entries, 1)
+		assert.Equal(t, audit.OutcomeFailure, auditLog.entries[0].Outcome)
+	})
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+func TestUserService_Register_PasswordBreachCheck(t *testing.T) {
+	logger.Initialize()
+
+	tests := []struct {
+		name        string
+		policy      BreachPolicy
+		count       int
+		checkErr    error
+		wantErr     bool
+		expectStore bool
+	}{
+		{name: "clean password", policy: BreachPolicyReject, count: 0, expectStore: true},
+		{name: "breached password rejected", policy: BreachPolicyReject, count: 42, wantErr: true},
+		{name: "breached password warned", policy: BreachPolicyWarn, count: 42, expectStore: true},
+		{name: "checker failure fails open", policy: BreachPolicyReject, checkErr: errors.New("timeout"), expectStore: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockUserRepository(ctrl)
+			mockIDGen := idMocks.NewMockGenerator(ctrl)
+			mockChecker := mocks.NewMockPasswordBreachChecker(ctrl)
+
+			svc := NewUserService(mockRepo, mockIDGen, WithPasswordBreachCheck(mockChecker, tt.policy))
+
+			mockChecker.EXPECT().BreachCount(gomock.Any(), "testpassword123").Return(tt.count, tt.checkErr)
+			if tt.expectStore {
+				mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(nil, nil)
+				mockIDGen.EXPECT().Generate().Return("test-id-123")
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			result, err := svc.Register(context.Background(), "test@example.com", "Test User", "testpassword123")
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "known data breach")
+				assert.NotContains(t, err.Error(), "testpassword123")
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, result)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+var testCaptchaPolicy = CaptchaPolicy{Register: true, LoginThreshold: 2}
+
+func assertCaptchaRequired(t *testing.T, err error) {
+	t.Helper()
+	var unauthorized *wonderErrors.UnauthorizedError
+	require.ErrorAs(t, err, &unauthorized)
+	assert.Equal(t, wonderErrors.CodeCaptchaRequired, unauthorized.Code())
+}
+
+func TestUserService_Register_Captcha(t *testing.T) {
+	logger.Initialize()
+	ctx := context.WithValue(context.Background(), clientIPKey, "10.0.0.1")
+
+	t.Run("registration without a captcha is refused", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		verifier := mocks.NewMockCaptchaVerifier(ctrl)
+		svc := NewUserService(mocks.NewMockUserRepository(ctrl), idMocks.NewMockGenerator(ctrl), WithCaptcha(verifier, testCaptchaPolicy))
+
+		_, err := svc.Register(ctx, "new@example.com", "New User", "password123")
+		assertCaptchaRequired(t, err)
+	})
+
+	t.Run("a rejected captcha is refused", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		verifier := mocks.NewMockCaptchaVerifier(ctrl)
+		svc := NewUserService(mocks.NewMockUserRepository(ctrl), idMocks.NewMockGenerator(ctrl), WithCaptcha(verifier, testCaptchaPolicy))
+		verifier.EXPECT().Verify(gomock.Any(), "forged", "10.0.0.1").Return(false, nil)
+
+		_, err := svc.Register(WithCaptchaToken(ctx, "forged"), "new@example.com", "New User", "password123")
+		assertCaptchaRequired(t, err)
+	})
+
+	t.Run("a solved captcha registers the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockUserRepository(ctrl)
+		idGen := idMocks.NewMockGenerator(ctrl)
+		verifier := mocks.NewMockCaptchaVerifier(ctrl)
+		svc := NewUserService(repo, idGen, WithCaptcha(verifier, testCaptchaPolicy))
+		verifier.EXPECT().Verify(gomock.Any(), "solved", "10.0.0.1").Return(true, nil)
+		repo.EXPECT().GetByEmail(gomock.Any(), "new@example.com").Return(nil, nil)
+		idGen.EXPECT().Generate().Return("user-1")
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		u, err := svc.Register(WithCaptchaToken(ctx, "solved"), "new@example.com", "New User", "password123")
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", u.ID)
+	})
+}
+
+func TestUserService_Login_Captcha(t *testing.T) {
+	logger.Initialize()
+	ctx := context.WithValue(context.Background(), clientIPKey, "10.0.0.1")
+
+	newService := func(t *testing.T) (*mocks.MockUserRepository, *mocks.MockLoginAttemptStore, *mocks.MockCaptchaVerifier, func(ctx context.Context) error) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockUserRepository(ctrl)
+		store := mocks.NewMockLoginAttemptStore(ctrl)
+		verifier := mocks.NewMockCaptchaVerifier(ctrl)
+		svc := NewUserService(repo, idMocks.NewMockGenerator(ctrl), WithLoginLockout(store, testLockoutPolicy), WithCaptcha(verifier, testCaptchaPolicy))
+		store.EXPECT().LockedFor(gomock.Any(), gomock.Any()).Return(time.Duration(0), nil).Times(2)
+		login := func(ctx context.Context) error {
+			_, err := svc.Login(ctx, "test@example.com", "testpassword123")
+			return err
+		}
+		return repo, store, verifier, login
+	}
+
+	t.Run("no captcha is needed before failures", func(t *testing.T) {
+		repo, store, _, login := newService(t)
+		store.EXPECT().Failures(gomock.Any(), "account:test@example.com").Return(int64(1), nil)
+		store.EXPECT().Failures(gomock.Any(), "ip:10.0.0.1").Return(int64(0), nil)
+		repo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(lockoutTestUser(t), nil)
+		store.EXPECT().Reset(gomock.Any(), "account:test@example.com").Return(nil)
+
+		require.NoError(t, login(ctx))
+	})
+
+	t.Run("repeated failures require a captcha", func(t *testing.T) {
+		_, store, _, login := newService(t)
+		store.EXPECT().Failures(gomock.Any(), "account:test@example.com").Return(int64(2), nil)
+
+		assertCaptchaRequired(t, login(ctx))
+	})
+
+	t.Run("failures from the client IP count too", func(t *testing.T) {
+		repo, store, verifier, login := newService(t)
+		store.EXPECT().Failures(gomock.Any(), "account:test@example.com").Return(int64(0), nil)
+		store.EXPECT().Failures(gomock.Any(), "ip:10.0.0.1").Return(int64(5), nil)
+		verifier.EXPECT().Verify(gomock.Any(), "solved", "10.0.0.1").Return(true, nil)
+		repo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(lockoutTestUser(t), nil)
+		store.EXPECT().Reset(gomock.Any(), "account:test@example.com").Return(nil)
+
+		require.NoError(t, login(WithCaptchaToken(ctx, "solved")))
+	})
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	orgMocks "github.com/cctw-zed/wonder/internal/domain/organization/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUserService_ScheduleAndCancelDeletion(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	repo := fake.NewUserRepository()
+	bus := &recordingBus{}
+	svc := NewUserService(repo, fake.NewIDGenerator(1), WithEventBus(bus), WithDeletionGracePeriod(30*24*time.Hour))
+
+	registered, err := svc.Register(ctx, "ada@example.com", "Ada", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+
+	before := time.Now()
+	pending, err := svc.ScheduleDeletion(ctx, registered.ID)
+	require.NoError(t, err)
+	require.NotNil(t, pending.DeletionScheduledAt)
+	assert.WithinDuration(t, before.Add(30*24*time.Hour), *pending.DeletionScheduledAt, time.Minute)
+
+	// The account stays usable during the grace period
+	_, err = svc.Login(ctx, "ada@example.com", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+
+	_, err = svc.ScheduleDeletion(ctx, registered.ID)
+	var stateErr *errors.InvalidStateError
+	assert.ErrorAs(t, err, &stateErr)
+
+	kept, err := svc.CancelDeletion(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Nil(t, kept.DeletionScheduledAt)
+
+	_, err = svc.CancelDeletion(ctx, registered.ID)
+	assert.ErrorAs(t, err, &stateErr)
+
+	assert.Equal(t, []string{
+		user.EventUserRegistered,
+		user.EventUserDeletionScheduled,
+		user.EventUserDeletionCancelled,
+	}, eventNames(bus.published))
+}
+
+func TestUserService_ScheduleDeletion_NoGracePeriod(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	repo := fake.NewUserRepository()
+	svc := NewUserService(repo, fake.NewIDGenerator(1))
+
+	registered, err := svc.Register(ctx, "ada@example.com", "Ada", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+
+	deleted, err := svc.ScheduleDeletion(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Nil(t, deleted)
+	assert.Equal(t, 0, repo.Len())
+}
+
+func TestUserService_DeletionOfOrganizationOwners(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	repo := fake.NewUserRepository()
+	orgs := orgMocks.NewMockRepository(gomock.NewController(t))
+	svc := NewUserService(repo, fake.NewIDGenerator(1), WithOrganizations(orgs), WithDeletionGracePeriod(time.Hour))
+
+	ada, err := svc.Register(ctx, "ada@example.com", "Ada", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+	research := &organization.Organization{ID: "o-1", Name: "Research"}
+	solo := &organization.Organization{ID: "o-2", Name: "Solo"}
+	owner := &organization.Member{OrgID: "o-1", UserID: ada.ID, Role: organization.RoleOwner}
+
+	// Owners of organizations with other members must hand them over first
+	orgs.EXPECT().ListOwnedBy(gomock.Any(), ada.ID).Return([]*organization.Organization{research}, nil).Times(2)
+	orgs.EXPECT().ListMembers(gomock.Any(), "o-1").Return([]*organization.Member{owner, {OrgID: "o-1", UserID: "u-2"}}, nil).Times(2)
+	var rule *errors.DomainRuleError
+	_, err = svc.ScheduleDeletion(ctx, ada.ID)
+	require.ErrorAs(t, err, &rule)
+	assert.Contains(t, err.Error(), `"Research"`)
+	require.ErrorAs(t, svc.DeleteUser(ctx, ada.ID), &rule)
+	assert.Equal(t, 1, repo.Len())
+
+	// Organizations only the user belongs to go with the account
+	orgs.EXPECT().ListOwnedBy(gomock.Any(), ada.ID).Return([]*organization.Organization{solo}, nil)
+	orgs.EXPECT().ListMembers(gomock.Any(), "o-2").Return([]*organization.Member{{OrgID: "o-2", UserID: ada.ID, Role: organization.RoleOwner}}, nil)
+	orgs.EXPECT().Delete(gomock.Any(), "o-2").Return(nil)
+	require.NoError(t, svc.DeleteUser(ctx, ada.ID))
+	assert.Equal(t, 0, repo.Len())
+}
+
+func TestUserService_PurgeDueDeletions(t *testing.T) {
+	logger.Initialize()
+
+	repo := fake.NewUserRepository()
+	bus := &recordingBus{}
+	auditLog := &recordingAuditLog{}
+	svc := NewUserService(repo, fake.NewIDGenerator(1), WithEventBus(bus), WithAuditLog(auditLog), WithDeletionGracePeriod(time.Hour))
+
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	schedule := func(ctx context.Context, email string, due bool) *user.User {
+		u, err := svc.Register(ctx, email, "Someone", "Str0ng!Passw0rd")
+		require.NoError(t, err)
+		u, err = svc.ScheduleDeletion(ctx, u.ID)
+		require.NoError(t, err)
+		if due {
+			past := time.Now().Add(-time.Minute)
+			u.DeletionScheduledAt = &past
+			require.NoError(t, repo.Update(ctx, u))
+		}
+		return u
+	}
+
+	dueAcme := schedule(acme, "ada@example.com", true)
+	dueGlobex := schedule(globex, "grace@example.com", true)
+	notDue := schedule(acme, "linus@example.com", false)
+	_, err := svc.Register(acme, "kept@example.com", "Kept", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+
+	bus.published = nil
+	deleted, err := svc.PurgeDueDeletions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, 2, repo.Len())
+
+	for _, gone := range []struct {
+		ctx context.Context
+		id  string
+	}{{acme, dueAcme.ID}, {globex, dueGlobex.ID}} {
+		u, err := repo.GetByID(gone.ctx, gone.id)
+		require.NoError(t, err)
+		assert.Nil(t, u)
+	}
+	u, err := repo.GetByID(acme, notDue.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, u)
+
+	// Purged accounts are announced so their owners can be told
+	require.Len(t, bus.published, 2)
+	for _, e := range bus.published {
+		deletedEvent, ok := e.(user.UserDeleted)
+		require.True(t, ok)
+		assert.True(t, deletedEvent.Scheduled)
+		assert.Equal(t, "Someone", deletedEvent.Name)
+	}
+
+	// Nothing is left to purge
+	deleted, err = svc.PurgeDueDeletions(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	// An admin removing an account whose deletion is pending is not the
+	// deletion its owner asked for
+	bus.published = nil
+	require.NoError(t, svc.DeleteUser(acme, notDue.ID))
+	require.Len(t, bus.published, 1)
+	deletedEvent, ok := bus.published[0].(user.UserDeleted)
+	require.True(t, ok)
+	assert.False(t, deletedEvent.Scheduled)
+}
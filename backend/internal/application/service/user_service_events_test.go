@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+// recordingBus captures published events synchronously
+type recordingBus struct {
+	published []event.Event
+}
+
+func (b *recordingBus) Publish(ctx context.Context, events ...event.Event) error {
+	b.published = append(b.published, events...)
+	return nil
+}
+
+func (b *recordingBus) Subscribe(string, event.Handler) {}
+
+func eventNames(events []event.Event) []string {
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, e.EventName())
+	}
+	return names
+}
+
+func TestUserService_PublishesDomainEvents(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockIDGen := idMocks.NewMockGenerator(ctrl)
+	bus := &recordingBus{}
+	svc := NewUserService(mockRepo, mockIDGen, WithEventBus(bus))
+	ctx := context.Background()
+
+	// Register
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(nil, nil)
+	mockIDGen.EXPECT().Generate().Return("user-1")
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	_, err := svc.Register(ctx, "test@example.com", "Test User", "testpassword123")
+	require.NoError(t, err)
+
+	// Email change
+	mockRepo.EXPECT().GetByID(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Email: "test@example.com", Name: "Test User"}, nil)
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "new@example.com").Return(nil, nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+	_, err = svc.UpdateProfile(ctx, "user-1", &user.UpdateProfileRequest{Email: ptr("new@example.com")})
+	require.NoError(t, err)
+
+	// Password reset
+	mockRepo.EXPECT().GetByID(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Email: "new@example.com", Name: "Test User"}, nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+	require.NoError(t, svc.ResetPassword(ctx, "user-1", "newpassword123"))
+
+	// Delete
+	mockRepo.EXPECT().GetByID(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Email: "new@example.com", Name: "Test User"}, nil)
+	mockRepo.EXPECT().Delete(gomock.Any(), "user-1").Return(nil)
+	require.NoError(t, svc.DeleteUser(ctx, "user-1"))
+
+	assert.Equal(t, []string{user.EventUserRegistered, user.EventUserEmailChanged, user.EventUserPasswordReset, user.EventUserDeleted}, eventNames(bus.published))
+}
+
+func TestUserService_NoEventsOnFailedWrite(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockIDGen := idMocks.NewMockGenerator(ctrl)
+	bus := &recordingBus{}
+	svc := NewUserService(mockRepo, mockIDGen, WithEventBus(bus))
+
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&user.User{ID: "existing"}, nil)
+	_, err := svc.Register(context.Background(), "test@example.com", "Test User", "testpassword123")
+	require.Error(t, err)
+
+	assert.Empty(t, bus.published)
+}
+
+// recordingOutbox captures staged events and whether they were staged inside the unit of work
+type recordingOutbox struct {
+	staged []event.Event
+	inTx   []bool
+	err    error
+}
+
+func (o *recordingOutbox) Append(ctx context.Context, events ...event.Event) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.staged = append(o.staged, events...)
+	o.inTx = append(o.inTx, ctx.Value(txCtxKey{}) == true)
+	return nil
+}
+
+func TestUserService_OutboxStagesEventsInTransaction(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockIDGen := idMocks.NewMockGenerator(ctrl)
+	bus := &recordingBus{}
+	outbox := &recordingOutbox{}
+	svc := NewUserService(mockRepo, mockIDGen, WithUnitOfWork(&recordingUnitOfWork{}), WithEventBus(bus), WithOutbox(outbox))
+	ctx := context.Background()
+
+	mockRepo.EXPECT().GetByEmail(inTx, "test@example.com").Return(nil, nil)
+	mockIDGen.EXPECT().Generate().Return("user-1")
+	mockRepo.EXPECT().Create(inTx, gomock.Any()).Return(nil)
+	_, err := svc.Register(ctx, "test@example.com", "Test User", "testpassword123")
+	require.NoError(t, err)
+
+	mockRepo.EXPECT().GetByID(inTx, "user-1").Return(&user.User{ID: "user-1", Email: "test@example.com", Name: "Test User"}, nil)
+	mockRepo.EXPECT().Delete(inTx, "user-1").Return(nil)
+	require.NoError(t, svc.DeleteUser(ctx, "user-1"))
+
+	assert.Equal(t, []string{user.EventUserRegistered, user.EventUserDeleted}, eventNames(outbox.staged))
+	assert.Equal(t, []bool{true, true}, outbox.inTx)
+	assert.Empty(t, bus.published, "events must only reach the bus through the relay")
+}
+
+func TestUserService_OutboxFailureAbortsWrite(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockIDGen := idMocks.NewMockGenerator(ctrl)
+	outbox := &recordingOutbox{err: errors.New("outbox unavailable")}
+	svc := NewUserService(mockRepo, mockIDGen, WithOutbox(outbox))
+
+	mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(nil, nil)
+	mockIDGen.EXPECT().Generate().Return("user-1")
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	_, err := svc.Register(context.Background(), "test@example.com", "Test User", "testpassword123")
+	assert.EqualError(t, err, "outbox unavailable")
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUserService_Handles(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	repo := fake.NewUserRepository()
+	svc := NewUserService(repo, fake.NewIDGenerator(1))
+
+	ada, err := svc.Register(ctx, "ada@example.com", "Ada", "password123")
+	require.NoError(t, err)
+	grace, err := svc.Register(ctx, "grace@example.com", "Grace", "password123")
+	require.NoError(t, err)
+
+	available, err := svc.CheckHandleAvailability(ctx, "@Ada")
+	require.NoError(t, err)
+	assert.Equal(t, &user.HandleAvailability{Handle: "ada", Available: true}, available)
+
+	updated, err := svc.UpdateProfile(ctx, ada.ID, &user.UpdateProfileRequest{Handle: ptr("@Ada")})
+	require.NoError(t, err)
+	assert.Equal(t, "ada", updated.CurrentHandle())
+
+	found, err := svc.GetByHandle(ctx, "ADA")
+	require.NoError(t, err)
+	assert.Equal(t, ada.ID, found.ID)
+
+	for handle, reason := range map[string]string{"ada": user.HandleTaken, "admin": user.HandleReserved, "a!": user.HandleInvalid} {
+		availability, err := svc.CheckHandleAvailability(ctx, handle)
+		require.NoError(t, err)
+		assert.False(t, availability.Available, handle)
+		assert.Equal(t, reason, availability.Reason, handle)
+	}
+
+	// Another user cannot take it, and its holder may set it again
+	_, err = svc.UpdateProfile(ctx, grace.ID, &user.UpdateProfileRequest{Handle: ptr("ada")})
+	var conflict *wonderErrors.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+	_, err = svc.UpdateProfile(ctx, ada.ID, &user.UpdateProfileRequest{Handle: ptr("ada")})
+	require.NoError(t, err)
+
+	// Removing the handle frees it
+	_, err = svc.UpdateProfile(ctx, ada.ID, &user.UpdateProfileRequest{Handle: ptr("")})
+	require.NoError(t, err)
+	_, err = svc.GetByHandle(ctx, "ada")
+	var notFound *wonderErrors.EntityNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+
+	_, err = svc.CheckHandleAvailability(ctx, " ")
+	assert.Error(t, err)
+}
+
+func TestUserService_HandlePolicy(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	svc := NewUserService(fake.NewUserRepository(), fake.NewIDGenerator(1),
+		WithHandlePolicy(user.HandlePolicy{Reserved: []string{"Billing"}}))
+
+	availability, err := svc.CheckHandleAvailability(ctx, "billing")
+	require.NoError(t, err)
+	assert.Equal(t, user.HandleReserved, availability.Reason)
+
+	ada, err := svc.Register(ctx, "ada@example.com", "Ada", "password123")
+	require.NoError(t, err)
+	_, err = svc.UpdateProfile(ctx, ada.ID, &user.UpdateProfileRequest{Handle: ptr("bill_ing")})
+	var invalid *wonderErrors.ValidationError
+	assert.ErrorAs(t, err, &invalid)
+
+	// Without the policy only the built-in names are reserved
+	availability, err = NewUserService(fake.NewUserRepository(), fake.NewIDGenerator(1)).CheckHandleAvailability(ctx, "billing")
+	require.NoError(t, err)
+	assert.True(t, availability.Available)
+}
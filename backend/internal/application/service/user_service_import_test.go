@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+func TestUserService_RegisterImported(t *testing.T) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	idGen := idMocks.NewMockGenerator(ctrl)
+	checker := mocks.NewMockPasswordBreachChecker(ctrl)
+	uow := &recordingUnitOfWork{}
+	outbox := &recordingOutbox{}
+	bus := &recordingBus{}
+	auditLog := &recordingAuditLog{}
+	svc := NewUserService(repo, idGen,
+		WithUnitOfWork(uow), WithOutbox(outbox), WithEventBus(bus), WithAuditLog(auditLog),
+		WithPasswordBreachCheck(checker, BreachPolicyReject))
+
+	rows := []user.ImportRow{
+		{Row: 1, Email: "alice@example.com", Name: "Alice", Password: "secret1"},
+		{Row: 2, Email: "bob@example.com", Name: "Bob", Password: "password"},
+		{Row: 3, Email: "taken@example.com", Name: "Taken", Password: "secret2"},
+	}
+
+	// Screening happens before the transaction opens
+	notInTx := gomock.Cond(func(x any) bool { return x.(context.Context).Value(txCtxKey{}) == nil })
+	checker.EXPECT().BreachCount(notInTx, "secret1").Return(0, nil)
+	checker.EXPECT().BreachCount(notInTx, "password").Return(12345, nil)
+	checker.EXPECT().BreachCount(notInTx, "secret2").Return(0, nil)
+	repo.EXPECT().ExistingEmails(inTx, []string{"alice@example.com", "taken@example.com"}).
+		Return(map[string]bool{"taken@example.com": true}, nil)
+	idGen.EXPECT().Generate().Return("user-1")
+	repo.EXPECT().CreateBatch(inTx, gomock.Any()).DoAndReturn(func(_ context.Context, users []*user.User) error {
+		require.Len(t, users, 1)
+		assert.Equal(t, "user-1", users[0].ID)
+		assert.NotEmpty(t, users[0].PasswordHash)
+		assert.Equal(t, user.RoleUser, users[0].Role)
+		return nil
+	})
+
+	result, err := svc.RegisterImported(context.Background(), rows)
+	require.NoError(t, err)
+
+	require.Len(t, result.Created, 1)
+	assert.Equal(t, "alice@example.com", result.Created[0].Email)
+	require.Len(t, result.Duplicates, 1)
+	assert.Equal(t, 3, result.Duplicates[0].Row)
+	assert.Equal(t, errors.CodeDuplicateEntry, result.Duplicates[0].Fields[0].Code)
+	require.Len(t, result.Rejected, 1)
+	assert.Equal(t, 2, result.Rejected[0].Row)
+	assert.Equal(t, "password", result.Rejected[0].Fields[0].Field)
+
+	assert.Equal(t, 1, uow.calls)
+	assert.Equal(t, []string{user.EventUserRegistered}, eventNames(outbox.staged), "events are staged in the outbox")
+	assert.Equal(t, []bool{true}, outbox.inTx)
+	assert.Empty(t, bus.published, "staged events are left to the relay")
+	require.Len(t, auditLog.entries, 1)
+	assert.Equal(t, audit.ActionRegister, auditLog.entries[0].Action)
+	assert.Equal(t, "user-1", auditLog.entries[0].EntityID)
+}
+
+func TestUserService_RegisterImported_NothingToCreate(t *testing.T) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+
+	checker := mocks.NewMockPasswordBreachChecker(ctrl)
+	uow := &recordingUnitOfWork{}
+	svc := NewUserService(mocks.NewMockUserRepository(ctrl), idMocks.NewMockGenerator(ctrl),
+		WithUnitOfWork(uow), WithPasswordBreachCheck(checker, BreachPolicyReject))
+
+	checker.EXPECT().BreachCount(gomock.Any(), "password").Return(3, nil)
+
+	result, err := svc.RegisterImported(context.Background(), []user.ImportRow{
+		{Row: 1, Email: "bob@example.com", Name: "Bob", Password: "password"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Len(t, result.Rejected, 1)
+	assert.Zero(t, uow.calls, "no transaction is opened")
+}
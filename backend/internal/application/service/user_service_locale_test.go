@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUserService_LocalePrefs(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	svc := NewUserService(fake.NewUserRepository(), fake.NewIDGenerator(1))
+
+	ada, err := svc.Register(ctx, "ada@example.com", "Ada", "password123")
+	require.NoError(t, err)
+
+	updated, err := svc.UpdateProfile(ctx, ada.ID, &user.UpdateProfileRequest{Timezone: ptr("Europe/London"), Locale: ptr("en-gb")})
+	require.NoError(t, err)
+	assert.Equal(t, user.LocalePrefs{Timezone: "Europe/London", Locale: "en-GB"}, updated.LocalePrefs())
+
+	var invalid *wonderErrors.ValidationError
+	_, err = svc.UpdateProfile(ctx, ada.ID, &user.UpdateProfileRequest{Timezone: ptr("Europe/Atlantis")})
+	assert.ErrorAs(t, err, &invalid)
+	_, err = svc.UpdateProfile(ctx, ada.ID, &user.UpdateProfileRequest{Locale: ptr("not a tag")})
+	assert.ErrorAs(t, err, &invalid)
+
+	// Other updates leave the preferences alone, and empty values remove them
+	updated, err = svc.UpdateProfile(ctx, ada.ID, &user.UpdateProfileRequest{Name: ptr("Ada L")})
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/London", updated.Timezone)
+	updated, err = svc.UpdateProfile(ctx, ada.ID, &user.UpdateProfileRequest{Timezone: ptr(""), Locale: ptr("")})
+	require.NoError(t, err)
+	assert.Equal(t, user.LocalePrefs{}, updated.LocalePrefs())
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+var testLockoutPolicy = LockoutPolicy{Threshold: 3, IPThreshold: 10, Window: 15 * time.Minute, Duration: 5 * time.Minute}
+
+func lockoutTestUser(t *testing.T) *user.User {
+	t.Helper()
+	u := &user.User{ID: "user-1", Email: "test@example.com", Name: "Test User", Role: user.RoleUser}
+	require.NoError(t, u.SetPassword(context.Background(), "testpassword123"))
+	return u
+}
+
+func TestUserService_Login_Lockout(t *testing.T) {
+	logger.Initialize()
+	ctx := context.WithValue(context.Background(), clientIPKey, "10.0.0.1")
+
+	t.Run("locked account is refused without checking the password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		store := mocks.NewMockLoginAttemptStore(ctrl)
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithLoginLockout(store, testLockoutPolicy))
+
+		store.EXPECT().LockedFor(gomock.Any(), "account:test@example.com").Return(2*time.Minute, nil)
+		store.EXPECT().LockedFor(gomock.Any(), "ip:10.0.0.1").Return(time.Duration(0), nil)
+
+		_, err := svc.Login(ctx, "Test@Example.com", "testpassword123")
+		require.Error(t, err)
+		assert.Equal(t, wonderErrors.CodeResourceLocked, err.(*wonderErrors.ConflictError).Code())
+		assert.Equal(t, 120, err.(*wonderErrors.ConflictError).Details()["retry_after_seconds"])
+	})
+
+	t.Run("reaching the threshold locks and audits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		store := mocks.NewMockLoginAttemptStore(ctrl)
+		auditLog := &recordingAuditLog{}
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithLoginLockout(store, testLockoutPolicy), WithAuditLog(auditLog))
+
+		store.EXPECT().LockedFor(gomock.Any(), gomock.Any()).Return(time.Duration(0), nil).Times(2)
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(lockoutTestUser(t), nil)
+		store.EXPECT().RecordFailure(gomock.Any(), "account:test@example.com", 15*time.Minute).Return(int64(3), nil)
+		store.EXPECT().Lock(gomock.Any(), "account:test@example.com", 5*time.Minute).Return(nil)
+		store.EXPECT().RecordFailure(gomock.Any(), "ip:10.0.0.1", 15*time.Minute).Return(int64(3), nil)
+
+		_, err := svc.Login(ctx, "test@example.com", "wrong-password")
+		require.Error(t, err)
+
+		require.Len(t, auditLog.entries, 2)
+		lockout := auditLog.entries[1]
+		assert.Equal(t, audit.ActionLockout, lockout.Action)
+		assert.Equal(t, "user-1", lockout.EntityID)
+		assert.Equal(t, "test@example.com", lockout.Changes["email"].To)
+		assert.NotEmpty(t, lockout.Changes["locked_until"].To)
+	})
+
+	t.Run("successful login resets only the account counter", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		store := mocks.NewMockLoginAttemptStore(ctrl)
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithLoginLockout(store, testLockoutPolicy))
+
+		store.EXPECT().LockedFor(gomock.Any(), gomock.Any()).Return(time.Duration(0), nil).Times(2)
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(lockoutTestUser(t), nil)
+		store.EXPECT().Reset(gomock.Any(), "account:test@example.com").Return(nil)
+
+		_, err := svc.Login(ctx, "test@example.com", "testpassword123")
+		require.NoError(t, err)
+	})
+
+	t.Run("store failures fail open", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockUserRepository(ctrl)
+		store := mocks.NewMockLoginAttemptStore(ctrl)
+		svc := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl), WithLoginLockout(store, testLockoutPolicy))
+
+		unavailable := errors.New("connection refused")
+		store.EXPECT().LockedFor(gomock.Any(), gomock.Any()).Return(time.Duration(0), unavailable).Times(2)
+		mockRepo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(lockoutTestUser(t), nil)
+		store.EXPECT().Reset(gomock.Any(), gomock.Any()).Return(unavailable)
+
+		_, err := svc.Login(ctx, "test@example.com", "testpassword123")
+		require.NoError(t, err)
+	})
+}
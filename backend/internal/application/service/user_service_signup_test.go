@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+var testSignupPolicy = SignupPolicy{DomainLimit: 5, IPLimit: 3, Window: time.Hour, ExemptDomains: []string{"Gmail.com"}}
+
+func expectRegistration(repo *mocks.MockUserRepository, idGen *idMocks.MockGenerator, email string) {
+	repo.EXPECT().GetByEmail(gomock.Any(), email).Return(nil, nil)
+	idGen.EXPECT().Generate().Return("user-1")
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+}
+
+func assertSignupRejected(t *testing.T, err error, reason string) {
+	t.Helper()
+	var rejected *wonderErrors.BusinessLogicError
+	require.ErrorAs(t, err, &rejected)
+	assert.Equal(t, wonderErrors.CodeSignupRejected, rejected.Code())
+	assert.Equal(t, reason, rejected.Details()["reason"])
+}
+
+func TestUserService_Register_SignupThrottle(t *testing.T) {
+	logger.Initialize()
+	ctx := context.WithValue(context.Background(), clientIPKey, "10.0.0.1")
+
+	setup := func(t *testing.T) (*mocks.MockUserRepository, *idMocks.MockGenerator, *mocks.MockSignupCounter, func(ctx context.Context, email string) error) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockUserRepository(ctrl)
+		idGen := idMocks.NewMockGenerator(ctrl)
+		counter := mocks.NewMockSignupCounter(ctrl)
+		svc := NewUserService(repo, idGen, WithSignupThrottle(counter, testSignupPolicy))
+		register := func(ctx context.Context, email string) error {
+			_, err := svc.Register(ctx, email, "New User", "password123")
+			return err
+		}
+		return repo, idGen, counter, register
+	}
+
+	t.Run("registrations under the limits pass", func(t *testing.T) {
+		repo, idGen, counter, register := setup(t)
+		counter.EXPECT().Increment(gomock.Any(), "domain:example.com", time.Hour).Return(int64(5), time.Minute, nil)
+		counter.EXPECT().Increment(gomock.Any(), "ip:10.0.0.1", time.Hour).Return(int64(3), time.Minute, nil)
+		expectRegistration(repo, idGen, "new@Example.com")
+
+		require.NoError(t, register(ctx, "new@Example.com"))
+	})
+
+	t.Run("too many registrations from a domain are throttled", func(t *testing.T) {
+		_, _, counter, register := setup(t)
+		counter.EXPECT().Increment(gomock.Any(), "domain:example.com", time.Hour).Return(int64(6), 90*time.Second, nil)
+		counter.EXPECT().Release(gomock.Any(), "domain:example.com").Return(nil)
+
+		err := register(ctx, "new@example.com")
+		var throttled *wonderErrors.BusinessLogicError
+		require.ErrorAs(t, err, &throttled)
+		assert.Equal(t, wonderErrors.CodeRateLimitExceeded, throttled.Code())
+		assert.Equal(t, "domain", throttled.Details()["scope"])
+		assert.Equal(t, 90, throttled.Details()["retry_after_seconds"])
+	})
+
+	t.Run("too many registrations from an IP are throttled", func(t *testing.T) {
+		_, _, counter, register := setup(t)
+		counter.EXPECT().Increment(gomock.Any(), "domain:example.com", time.Hour).Return(int64(1), time.Hour, nil)
+		counter.EXPECT().Increment(gomock.Any(), "ip:10.0.0.1", time.Hour).Return(int64(4), time.Minute, nil)
+		// Neither slot is held by a refused registration
+		counter.EXPECT().Release(gomock.Any(), "domain:example.com").Return(nil)
+		counter.EXPECT().Release(gomock.Any(), "ip:10.0.0.1").Return(nil)
+
+		err := register(ctx, "new@example.com")
+		assert.Equal(t, wonderErrors.CodeRateLimitExceeded, err.(*wonderErrors.BusinessLogicError).Code())
+	})
+
+	t.Run("exempt domains are counted per IP only", func(t *testing.T) {
+		repo, idGen, counter, register := setup(t)
+		counter.EXPECT().Increment(gomock.Any(), "ip:10.0.0.1", time.Hour).Return(int64(1), time.Hour, nil)
+		expectRegistration(repo, idGen, "new@gmail.com")
+
+		require.NoError(t, register(ctx, "new@gmail.com"))
+	})
+
+	t.Run("an unavailable counter lets registrations through", func(t *testing.T) {
+		repo, idGen, counter, register := setup(t)
+		counter.EXPECT().Increment(gomock.Any(), gomock.Any(), time.Hour).Return(int64(0), time.Duration(0), assert.AnError).Times(2)
+		expectRegistration(repo, idGen, "new@example.com")
+
+		require.NoError(t, register(ctx, "new@example.com"))
+	})
+
+	t.Run("registrations that fail after being counted give their slots back", func(t *testing.T) {
+		repo, _, counter, register := setup(t)
+		counter.EXPECT().Increment(gomock.Any(), "domain:example.com", time.Hour).Return(int64(5), time.Minute, nil)
+		counter.EXPECT().Increment(gomock.Any(), "ip:10.0.0.1", time.Hour).Return(int64(3), time.Minute, nil)
+		repo.EXPECT().GetByEmail(gomock.Any(), "taken@example.com").Return(&user.User{ID: "user-0"}, nil)
+		counter.EXPECT().Release(gomock.Any(), "domain:example.com").Return(nil)
+		counter.EXPECT().Release(gomock.Any(), "ip:10.0.0.1").Return(nil)
+
+		var duplicate *wonderErrors.ConflictError
+		require.ErrorAs(t, register(ctx, "taken@example.com"), &duplicate)
+	})
+
+	t.Run("invalid emails are not counted", func(t *testing.T) {
+		_, _, _, register := setup(t)
+		assert.Error(t, register(ctx, "not-an-email"))
+	})
+}
+
+func TestUserService_Register_DisposableEmail(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	t.Run("disposable domains are rejected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		provider := mocks.NewMockDisposableDomainProvider(ctrl)
+		svc := NewUserService(mocks.NewMockUserRepository(ctrl), idMocks.NewMockGenerator(ctrl), WithDisposableEmailBlock(provider))
+		provider.EXPECT().IsDisposable(gomock.Any(), "mailinator.com").Return(true, nil)
+
+		_, err := svc.Register(ctx, "bot@Mailinator.com", "Bot", "password123")
+		assertSignupRejected(t, err, "disposable_email")
+	})
+
+	t.Run("an unavailable list lets registrations through", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockUserRepository(ctrl)
+		idGen := idMocks.NewMockGenerator(ctrl)
+		provider := mocks.NewMockDisposableDomainProvider(ctrl)
+		svc := NewUserService(repo, idGen, WithDisposableEmailBlock(provider))
+		provider.EXPECT().IsDisposable(gomock.Any(), "example.com").Return(false, assert.AnError)
+		expectRegistration(repo, idGen, "new@example.com")
+
+		_, err := svc.Register(ctx, "new@example.com", "New User", "password123")
+		require.NoError(t, err)
+	})
+}
+
+func TestUserService_Register_Honeypot(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockUserRepository(ctrl)
+	idGen := idMocks.NewMockGenerator(ctrl)
+	svc := NewUserService(repo, idGen, WithSignupHoneypot())
+
+	_, err := svc.Register(WithHoneypotValue(context.Background(), "https://spam.example"), "bot@example.com", "Bot", "password123")
+	assertSignupRejected(t, err, "automated")
+
+	expectRegistration(repo, idGen, "new@example.com")
+	_, err = svc.Register(WithHoneypotValue(context.Background(), ""), "new@example.com", "New User", "password123")
+	require.NoError(t, err)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUserService_SuspendAndReactivate(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	repo := fake.NewUserRepository()
+	auditLog := &recordingAuditLog{}
+	svc := NewUserService(repo, fake.NewIDGenerator(1), WithAuditLog(auditLog))
+
+	registered, err := svc.Register(ctx, "ada@example.com", "Ada", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+	assert.Equal(t, user.StatusActive, registered.Status)
+
+	suspended, err := svc.SuspendUser(ctx, registered.ID, "chargeback")
+	require.NoError(t, err)
+	assert.Equal(t, user.StatusSuspended, suspended.Status)
+
+	// Suspended users are refused once the password matched
+	_, err = svc.Login(ctx, "ada@example.com", "Str0ng!Passw0rd")
+	var unauthorized *errors.UnauthorizedError
+	require.ErrorAs(t, err, &unauthorized)
+	assert.Equal(t, errors.CodeForbidden, unauthorized.Code())
+	assert.Equal(t, user.StatusSuspended, unauthorized.Details()["status"])
+
+	_, err = svc.SuspendUser(ctx, registered.ID, "")
+	var stateErr *errors.InvalidStateError
+	assert.ErrorAs(t, err, &stateErr)
+
+	_, err = svc.ReactivateUser(ctx, registered.ID)
+	require.NoError(t, err)
+	_, err = svc.Login(ctx, "ada@example.com", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+
+	deactivated, err := svc.DeactivateUser(ctx, registered.ID, "closed on request")
+	require.NoError(t, err)
+	assert.Equal(t, user.StatusDeactivated, deactivated.Status)
+	_, err = svc.SuspendUser(ctx, registered.ID, "")
+	assert.ErrorAs(t, err, &stateErr, "deactivated accounts can only be reactivated")
+	_, err = svc.ReactivateUser(ctx, registered.ID)
+	require.NoError(t, err)
+
+	_, err = svc.ReactivateUser(ctx, "missing")
+	var notFound *errors.EntityNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+
+	var actions []string
+	for _, entry := range auditLog.entries {
+		actions = append(actions, entry.Action+"/"+entry.Outcome)
+	}
+	assert.Equal(t, []string{"register/", "suspend/", "login/failure", "reactivate/", "login/success", "deactivate/", "reactivate/"}, actions)
+	assert.Equal(t, audit.Change{From: user.StatusActive, To: user.StatusSuspended}, auditLog.entries[1].Changes["status"])
+}
+
+func TestUserService_ListUsersByStatus(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	repo := fake.NewUserRepository()
+	svc := NewUserService(repo, fake.NewIDGenerator(1))
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		_, err := svc.Register(ctx, email, "User", "Str0ng!Passw0rd")
+		require.NoError(t, err)
+	}
+	_, err := svc.SuspendUser(ctx, "1", "")
+	require.NoError(t, err)
+
+	result, err := svc.ListUsers(ctx, &user.ListUsersRequest{Statuses: []string{user.StatusSuspended}})
+	require.NoError(t, err)
+	require.Len(t, result.Users, 1)
+	assert.Equal(t, "1", result.Users[0].ID)
+}
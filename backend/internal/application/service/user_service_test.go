@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,6 +15,8 @@ import (
 
 	"github.com/cctw-zed/wonder/internal/domain/user"
 	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	apperrors "github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/logger"
 	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
 )
@@ -236,6 +241,11 @@ func TestNewUserService(t *testing.T) {
 }
 
 // Integration test helper
+// ptr returns a pointer to v, for optional request fields
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func createTestUser() *user.User {
 	return &user.User{
 		ID:        "test-id-123",
@@ -349,7 +359,7 @@ func TestUserService_UpdateProfile(t *testing.T) {
 			name:   "successful update name only",
 			userID: "test-id-123",
 			request: &user.UpdateProfileRequest{
-				Name: "Updated Name",
+				Name: ptr("Updated Name"),
 			},
 			mockBehavior: func() {
 				mockRepo.EXPECT().
@@ -366,7 +376,7 @@ func TestUserService_UpdateProfile(t *testing.T) {
 			name:   "successful update email only",
 			userID: "test-id-123",
 			request: &user.UpdateProfileRequest{
-				Email: "updated@example.com",
+				Email: ptr("updated@example.com"),
 			},
 			mockBehavior: func() {
 				mockRepo.EXPECT().
@@ -387,8 +397,8 @@ func TestUserService_UpdateProfile(t *testing.T) {
 			name:   "update both name and email",
 			userID: "test-id-123",
 			request: &user.UpdateProfileRequest{
-				Name:  "Updated Name",
-				Email: "updated@example.com",
+				Name:  ptr("Updated Name"),
+				Email: ptr("updated@example.com"),
 			},
 			mockBehavior: func() {
 				mockRepo.EXPECT().
@@ -409,7 +419,7 @@ func TestUserService_UpdateProfile(t *testing.T) {
 			name:   "user not found",
 			userID: "nonexistent-id",
 			request: &user.UpdateProfileRequest{
-				Name: "Updated Name",
+				Name: ptr("Updated Name"),
 			},
 			mockBehavior: func() {
 				mockRepo.EXPECT().
@@ -423,7 +433,7 @@ func TestUserService_UpdateProfile(t *testing.T) {
 			name:   "email already exists for another user",
 			userID: "test-id-123",
 			request: &user.UpdateProfileRequest{
-				Email: "existing@example.com",
+				Email: ptr("existing@example.com"),
 			},
 			mockBehavior: func() {
 				mockRepo.EXPECT().
@@ -441,7 +451,7 @@ func TestUserService_UpdateProfile(t *testing.T) {
 			name:   "empty user ID",
 			userID: "",
 			request: &user.UpdateProfileRequest{
-				Name: "Updated Name",
+				Name: ptr("Updated Name"),
 			},
 			mockBehavior: func() {
 				// No mock calls expected
@@ -477,6 +487,77 @@ func TestUserService_UpdateProfile(t *testing.T) {
 	}
 }
 
+func TestUserService_UpdateProfile_IfVersion(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	service := NewUserService(mockRepo, idMocks.NewMockGenerator(ctrl))
+
+	testUser := createTestUser()
+	current := testUser.Version()
+	mockRepo.EXPECT().GetByID(gomock.Any(), testUser.ID).Return(testUser, nil).Times(2)
+	// The write is conditional on the version that was checked
+	mockRepo.EXPECT().UpdateIfUnmodified(gomock.Any(), testUser, testUser.UpdatedAt).Return(nil).Times(1)
+
+	// A stale version is refused before anything is written
+	_, err := service.UpdateProfile(context.Background(), testUser.ID, &user.UpdateProfileRequest{Name: ptr("Updated Name"), IfVersion: []string{"stale"}})
+	var conflict *apperrors.ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, apperrors.CodeVersionMismatch, conflict.Code())
+
+	updated, err := service.UpdateProfile(context.Background(), testUser.ID, &user.UpdateProfileRequest{Name: ptr("Updated Name"), IfVersion: []string{"stale", current}})
+	require.NoError(t, err)
+	assert.NotEqual(t, current, updated.Version())
+}
+
+func TestUserService_UpdateProfile_IfVersionConcurrent(t *testing.T) {
+	logger.Initialize()
+
+	repo := fake.NewUserRepository()
+	service := NewUserService(repo, fake.NewIDGenerator(1))
+	ctx := context.Background()
+	registered, err := service.Register(ctx, "ada@example.com", "Ada", "password123")
+	require.NoError(t, err)
+	version := registered.Version()
+
+	// Every writer read the same version; only one of them may win
+	const writers = 8
+	var (
+		wg        sync.WaitGroup
+		start     = make(chan struct{})
+		succeeded atomic.Int32
+		conflicts atomic.Int32
+	)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, err := service.UpdateProfile(ctx, registered.ID, &user.UpdateProfileRequest{
+				Name:      ptr(fmt.Sprintf("Writer %d", i)),
+				IfVersion: []string{version},
+			})
+			var conflict *apperrors.ConflictError
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.As(err, &conflict) && conflict.Code() == apperrors.CodeVersionMismatch:
+				conflicts.Add(1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), succeeded.Load())
+	assert.Equal(t, int32(writers-1), conflicts.Load())
+}
+
 func TestUserService_ListUsers(t *testing.T) {
 	logger.Initialize()
 
@@ -536,6 +617,33 @@ func TestUserService_ListUsers(t *testing.T) {
 					Times(1)
 			},
 		},
+		{
+			name: "inverted date range",
+			request: &user.ListUsersRequest{
+				CreatedAfter:  time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+				CreatedBefore: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+			mockBehavior:  func() {},
+			expectedError: "created_after",
+		},
+		{
+			name: "default sort",
+			request: &user.ListUsersRequest{
+				Roles: []string{user.RoleAdmin},
+			},
+			mockBehavior: func() {
+				mockRepo.EXPECT().
+					List(gomock.Any(), &user.ListUsersRequest{
+						Page:      1,
+						PageSize:  10,
+						SortBy:    user.SortByCreatedAt,
+						SortOrder: user.SortDesc,
+						Roles:     []string{user.RoleAdmin},
+					}).
+					Return(testResponse, nil).
+					Times(1)
+			},
+		},
 		{
 			name: "repository error",
 			request: &user.ListUsersRequest{
@@ -653,3 +761,86 @@ func TestUserService_DeleteUser(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_ResetPassword(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockIDGen := idMocks.NewMockGenerator(ctrl)
+	service := NewUserService(mockRepo, mockIDGen)
+
+	tests := []struct {
+		name          string
+		userID        string
+		newPassword   string
+		mockBehavior  func()
+		expectedError string
+	}{
+		{
+			name:        "successful reset",
+			userID:      "test-id-123",
+			newPassword: "new-secret",
+			mockBehavior: func() {
+				mockRepo.EXPECT().
+					GetByID(gomock.Any(), "test-id-123").
+					Return(createTestUser(), nil).
+					Times(1)
+				mockRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, u *user.User) error {
+						assert.NoError(t, u.CheckPassword(ctx, "new-secret"))
+						return nil
+					}).
+					Times(1)
+			},
+		},
+		{
+			name:        "user not found",
+			userID:      "nonexistent-id",
+			newPassword: "new-secret",
+			mockBehavior: func() {
+				mockRepo.EXPECT().
+					GetByID(gomock.Any(), "nonexistent-id").
+					Return(nil, nil).
+					Times(1)
+			},
+			expectedError: "not found",
+		},
+		{
+			name:        "password too short",
+			userID:      "test-id-123",
+			newPassword: "abc",
+			mockBehavior: func() {
+				mockRepo.EXPECT().
+					GetByID(gomock.Any(), "test-id-123").
+					Return(createTestUser(), nil).
+					Times(1)
+			},
+			expectedError: "at least 6 characters",
+		},
+		{
+			name:          "empty password",
+			userID:        "test-id-123",
+			mockBehavior:  func() {},
+			expectedError: "new_password is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior()
+
+			err := service.ResetPassword(context.Background(), tt.userID, tt.newPassword)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+type txCtxKey struct{}
+
+// recordingUnitOfWork marks the context so tests can assert repository calls
+// happen inside the unit of work
+type recordingUnitOfWork struct {
+	calls int
+	err   error
+}
+
+func (u *recordingUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	u.calls++
+	if err := fn(context.WithValue(ctx, txCtxKey{}, true)); err != nil {
+		return err
+	}
+	return u.err
+}
+
+var inTx = gomock.Cond(func(x any) bool {
+	ctx, ok := x.(context.Context)
+	return ok && ctx.Value(txCtxKey{}) == true
+})
+
+func TestUserService_UpdateProfile_UsesUnitOfWork(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockIDGen := idMocks.NewMockGenerator(ctrl)
+	uow := &recordingUnitOfWork{}
+	svc := NewUserService(mockRepo, mockIDGen, WithUnitOfWork(uow))
+
+	existing := &user.User{ID: "user-1", Email: "old@example.com", Name: "Old Name"}
+	mockRepo.EXPECT().GetByID(inTx, "user-1").Return(existing, nil)
+	mockRepo.EXPECT().GetByEmail(inTx, "new@example.com").Return(nil, nil)
+	mockRepo.EXPECT().Update(inTx, gomock.Any()).Return(nil)
+
+	result, err := svc.UpdateProfile(context.Background(), "user-1", &user.UpdateProfileRequest{Email: ptr("new@example.com")})
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", result.Email)
+	assert.Equal(t, 1, uow.calls)
+}
+
+func TestUserService_Register_CommitFailure(t *testing.T) {
+	logger.Initialize()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	mockIDGen := idMocks.NewMockGenerator(ctrl)
+	uow := &recordingUnitOfWork{err: errors.New("commit failed")}
+	svc := NewUserService(mockRepo, mockIDGen, WithUnitOfWork(uow))
+
+	mockRepo.EXPECT().GetByEmail(inTx, "test@example.com").Return(nil, nil)
+	mockIDGen.EXPECT().Generate().Return("test-id-123")
+	mockRepo.EXPECT().Create(inTx, gomock.Any()).Return(nil)
+
+	result, err := svc.Register(context.Background(), "test@example.com", "Test User", "testpassword123")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "commit failed")
+	assert.Nil(t, result)
+}
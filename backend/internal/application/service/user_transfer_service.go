@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// exportPageSize is the number of users read per query during an export
+const exportPageSize = 100
+
+// UserTransferService exports users in bulk and imports them from files
+type UserTransferService interface {
+	// ExportUsers passes users matching the filter to emit one page at a
+	// time, newest first. Page, PageSize and Cursor of the filter are ignored.
+	ExportUsers(ctx context.Context, filter *user.ListUsersRequest, emit func(users []*user.User) error) error
+	// ImportUsers validates rows, skips emails that are already registered
+	// or repeated in the file, and creates the remaining users in batches.
+	// Batches committed before a failure are kept.
+	ImportUsers(ctx context.Context, rows []user.ImportRow) (*user.ImportReport, error)
+}
+
+// UserTransferServiceOption configures optional user transfer service
+// behavior
+type UserTransferServiceOption func(*userTransferService)
+
+// WithImportEmailRules finds repeats within an import file by rules, which
+// must be the ones the user repository uses
+func WithImportEmailRules(rules user.EmailRules) UserTransferServiceOption {
+	return func(s *userTransferService) {
+		s.emailRules = rules
+	}
+}
+
+type userTransferService struct {
+	users      user.UserRepository
+	registrar  user.UserService
+	batchSize  int
+	emailRules user.EmailRules
+	log        logger.Logger
+}
+
+// NewUserTransferService creates a new user export/import service. Imported
+// users are registered through registrar, so they are screened, evented and
+// audited like users who sign up.
+func NewUserTransferService(users user.UserRepository, registrar user.UserService, batchSize int, opts ...UserTransferServiceOption) UserTransferService {
+	return NewUserTransferServiceWithLogger(users, registrar, batchSize, logger.Get().WithLayer("application").WithComponent("user_transfer_service"), opts...)
+}
+
+func NewUserTransferServiceWithLogger(users user.UserRepository, registrar user.UserService, batchSize int, log logger.Logger, opts ...UserTransferServiceOption) UserTransferService {
+	if users == nil {
+		panic("user repository cannot be nil")
+	}
+	if registrar == nil {
+		panic("user service cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+	if batchSize <= 0 {
+		panic("import batch size must be positive")
+	}
+
+	s := &userTransferService{
+		users:     users,
+		registrar: registrar,
+		batchSize: batchSize,
+		log:       log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *userTransferService) ExportUsers(ctx context.Context, filter *user.ListUsersRequest, emit func(users []*user.User) error) error {
+	req := &user.ListUsersRequest{Page: 1, PageSize: exportPageSize}
+	if filter != nil {
+		req.Email = filter.Email
+		req.Name = filter.Name
+	}
+
+	exported := 0
+	for {
+		page, err := s.users.List(ctx, req)
+		if err != nil {
+			s.log.Error(ctx, "user export failed", "error", err, "exported", exported)
+			return err
+		}
+		if len(page.Users) > 0 {
+			if err := emit(page.Users); err != nil {
+				s.log.Warn(ctx, "user export aborted", "error", err, "exported", exported)
+				return err
+			}
+			exported += len(page.Users)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+
+	s.log.Info(ctx, "users exported", "count", exported, "email_filter", req.Email, "name_filter", req.Name)
+	return nil
+}
+
+func (s *userTransferService) ImportUsers(ctx context.Context, rows []user.ImportRow) (*user.ImportReport, error) {
+	report := &user.ImportReport{Total: len(rows), Errors: []user.ImportRowError{}}
+
+	// Validate every row and drop repeats within the file, keeping the first
+	firstRow := make(map[string]int, len(rows))
+	accepted := make([]user.ImportRow, 0, len(rows))
+	for _, row := range rows {
+		row.Email = strings.TrimSpace(row.Email)
+		row.Name = strings.TrimSpace(row.Name)
+
+		if fields := validateImportRow(row); len(fields) > 0 {
+			report.Invalid++
+			report.Errors = append(report.Errors, user.ImportRowError{Row: row.Row, Email: row.Email, Fields: fields})
+			continue
+		}
+
+		key := s.emailRules.Canonicalize(row.Email)
+		if first, ok := firstRow[key]; ok {
+			report.Duplicates++
+			report.Errors = append(report.Errors, duplicateRowError(row, fmt.Sprintf("email repeats row %d", first), map[string]interface{}{"first_row": first}))
+			continue
+		}
+		firstRow[key] = row.Row
+		accepted = append(accepted, row)
+	}
+
+	for start := 0; start < len(accepted); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(accepted) {
+			end = len(accepted)
+		}
+		if err := s.importBatch(ctx, accepted[start:end], report); err != nil {
+			s.log.Error(ctx, "user import failed", "error", err, "created", report.Created, "total", report.Total)
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+
+	s.log.Info(ctx, "users imported", "total", report.Total, "created", report.Created, "duplicates", report.Duplicates, "invalid", report.Invalid)
+	return report, nil
+}
+
+// importBatch registers the users of one batch whose emails are not yet
+// registered, in a single transaction
+func (s *userTransferService) importBatch(ctx context.Context, batch []user.ImportRow, report *user.ImportReport) error {
+	result, err := s.registrar.RegisterImported(ctx, batch)
+	if err != nil {
+		return err
+	}
+
+	report.Created += len(result.Created)
+	report.Duplicates += len(result.Duplicates)
+	report.Invalid += len(result.Rejected)
+	report.Errors = append(report.Errors, result.Duplicates...)
+	report.Errors = append(report.Errors, result.Rejected...)
+	return nil
+}
+
+// validateImportRow applies the registration rules to one row
+func validateImportRow(row user.ImportRow) []errors.FieldError {
+	var fields []errors.FieldError
+
+	switch {
+	case row.Email == "":
+		fields = append(fields, errors.FieldError{Field: "email", Code: errors.CodeRequiredField, Message: "email is required"})
+	case !(&user.User{Email: row.Email}).IsEmailValid():
+		fields = append(fields, errors.FieldError{
+			Field:      "email",
+			Code:       errors.CodeInvalidFormat,
+			Message:    "email must be a valid email address",
+			Constraint: map[string]interface{}{"format": "email"},
+		})
+	}
+
+	switch n := utf8.RuneCountInString(row.Name); {
+	case n == 0:
+		fields = append(fields, errors.FieldError{Field: "name", Code: errors.CodeRequiredField, Message: "name is required"})
+	case n < 2:
+		fields = append(fields, errors.FieldError{Field: "name", Code: errors.CodeOutOfRange, Message: "name must be at least 2 characters", Constraint: map[string]interface{}{"min": 2}})
+	case n > 50:
+		fields = append(fields, errors.FieldError{Field: "name", Code: errors.CodeOutOfRange, Message: "name must be at most 50 characters", Constraint: map[string]interface{}{"max": 50}})
+	}
+
+	switch {
+	case row.Password == "":
+		fields = append(fields, errors.FieldError{Field: "password", Code: errors.CodeRequiredField, Message: "password is required"})
+	case len(row.Password) < 6:
+		fields = append(fields, errors.FieldError{Field: "password", Code: errors.CodeOutOfRange, Message: "password must be at least 6 characters", Constraint: map[string]interface{}{"min": 6}})
+	}
+
+	return fields
+}
+
+func duplicateRowError(row user.ImportRow, message string, constraint map[string]interface{}) user.ImportRowError {
+	return user.ImportRowError{
+		Row:   row.Row,
+		Email: row.Email,
+		Fields: []errors.FieldError{{
+			Field:      "email",
+			Code:       errors.CodeDuplicateEntry,
+			Message:    message,
+			Constraint: constraint,
+		}},
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUserTransferService_ImportUsers(t *testing.T) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	registrar := mocks.NewMockUserService(ctrl)
+	svc := NewUserTransferService(repo, registrar, 2)
+
+	rows := []user.ImportRow{
+		{Row: 1, Email: "alice@example.com", Name: "Alice", Password: "secret1"},
+		{Row: 2, Email: "not-an-email", Name: "B", Password: "123"},
+		{Row: 3, Email: " ALICE@example.com ", Name: "Alice Again", Password: "secret1"},
+		{Row: 4, Email: "taken@example.com", Name: "Taken", Password: "secret1"},
+		{Row: 5, Email: "carol@example.com", Name: "Carol", Password: "password"},
+	}
+
+	registrar.EXPECT().RegisterImported(gomock.Any(), []user.ImportRow{rows[0], rows[3]}).Return(&user.ImportResult{
+		Created:    []*user.User{{ID: "user-1", Email: "alice@example.com"}},
+		Duplicates: []user.ImportRowError{duplicateRowError(rows[3], "email is already registered", nil)},
+	}, nil)
+	registrar.EXPECT().RegisterImported(gomock.Any(), []user.ImportRow{rows[4]}).Return(&user.ImportResult{
+		Rejected: []user.ImportRowError{{Row: 5, Email: "carol@example.com", Fields: []errors.FieldError{{Field: "password", Code: errors.CodeInvalidValue}}}},
+	}, nil)
+
+	report, err := svc.ImportUsers(context.Background(), rows)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 2, report.Duplicates)
+	assert.Equal(t, 2, report.Invalid)
+
+	require.Len(t, report.Errors, 4)
+	assert.Equal(t, 2, report.Errors[0].Row)
+	assert.Len(t, report.Errors[0].Fields, 3, "every invalid field is reported")
+	assert.Equal(t, 3, report.Errors[1].Row)
+	assert.Equal(t, errors.CodeDuplicateEntry, report.Errors[1].Fields[0].Code)
+	assert.Equal(t, 1, report.Errors[1].Fields[0].Constraint["first_row"])
+	assert.Equal(t, 4, report.Errors[2].Row)
+	assert.Equal(t, "email is already registered", report.Errors[2].Fields[0].Message)
+	assert.Equal(t, 5, report.Errors[3].Row)
+	assert.Equal(t, "password", report.Errors[3].Fields[0].Field)
+}
+
+func TestUserTransferService_ImportUsers_BatchFailure(t *testing.T) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	registrar := mocks.NewMockUserService(ctrl)
+	svc := NewUserTransferService(repo, registrar, 10)
+
+	dbErr := errors.NewDatabaseError("create_batch", "users", fmt.Errorf("connection reset"), true)
+	registrar.EXPECT().RegisterImported(gomock.Any(), gomock.Any()).Return(nil, dbErr)
+
+	report, err := svc.ImportUsers(context.Background(), []user.ImportRow{
+		{Row: 1, Email: "alice@example.com", Name: "Alice", Password: "secret1"},
+	})
+	assert.Nil(t, report)
+	assert.Equal(t, dbErr, err)
+}
+
+func TestUserTransferService_ExportUsers(t *testing.T) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	svc := NewUserTransferService(repo, mocks.NewMockUserService(ctrl), 10)
+
+	repo.EXPECT().List(gomock.Any(), &user.ListUsersRequest{Page: 1, PageSize: exportPageSize, Name: "ali"}).
+		Return(&user.ListUsersResponse{Users: []*user.User{{ID: "u2"}, {ID: "u1"}}, NextCursor: "next"}, nil)
+	repo.EXPECT().List(gomock.Any(), &user.ListUsersRequest{Page: 1, PageSize: exportPageSize, Name: "ali", Cursor: "next"}).
+		Return(&user.ListUsersResponse{Users: []*user.User{{ID: "u0"}}}, nil)
+
+	var ids []string
+	err := svc.ExportUsers(context.Background(), &user.ListUsersRequest{Name: "ali", Page: 7, Cursor: "ignored"}, func(users []*user.User) error {
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u1", "u0"}, ids)
+}
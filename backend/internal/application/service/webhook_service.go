@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/webhook"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// CreateWebhookRequest registers an endpoint. A secret is generated when
+// none is given.
+type CreateWebhookRequest struct {
+	URL         string
+	Events      []string
+	Secret      string
+	Description string
+}
+
+// WebhookDeliveryPage is one page of an endpoint's deliveries, newest first
+type WebhookDeliveryPage struct {
+	Deliveries []*webhook.Delivery `json:"deliveries"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// WebhookPayload is the body POSTed to endpoints
+type WebhookPayload struct {
+	// ID identifies the event; redeliveries repeat it
+	ID        string             `json:"id"`
+	Type      string             `json:"type"`
+	CreatedAt time.Time          `json:"created_at"`
+	Data      WebhookUserPayload `json:"data"`
+}
+
+// WebhookUserPayload describes the user an event is about. Change names the
+// domain event behind a user.updated event, e.g. "user.suspended".
+type WebhookUserPayload struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Handle string `json:"handle,omitempty"`
+	Change string `json:"change,omitempty"`
+}
+
+// WebhookService manages webhook endpoints and queues deliveries of user
+// events to them
+type WebhookService interface {
+	// CreateEndpoint registers an endpoint. The returned endpoint carries
+	// its secret; it is not shown again.
+	CreateEndpoint(ctx context.Context, req *CreateWebhookRequest) (*webhook.Endpoint, error)
+	GetEndpoint(ctx context.Context, id string) (*webhook.Endpoint, error)
+	ListEndpoints(ctx context.Context) ([]*webhook.Endpoint, error)
+	// DeleteEndpoint removes an endpoint and its delivery log
+	DeleteEndpoint(ctx context.Context, id string) error
+	// ListDeliveries lists an endpoint's deliveries
+	ListDeliveries(ctx context.Context, endpointID string, page, pageSize int) (*WebhookDeliveryPage, error)
+	// Redeliver queues a new delivery of the same event as deliveryID
+	Redeliver(ctx context.Context, endpointID, deliveryID string) (*webhook.Delivery, error)
+	// HandleUserEvent queues a delivery of e to each subscribed endpoint.
+	// eventID identifies the event to receivers; a new one is generated
+	// when it is empty.
+	HandleUserEvent(ctx context.Context, eventID string, e event.Event) error
+}
+
+// WebhookServiceOption configures the webhook service
+type WebhookServiceOption func(*webhookService)
+
+// WithWebhookHostCheck checks the host of each endpoint URL when it is
+// registered, e.g. netguard.CheckResolved, which refuses names resolving
+// to internal addresses. Endpoint validation only refuses address literals.
+func WithWebhookHostCheck(check func(ctx context.Context, host string) error) WebhookServiceOption {
+	return func(s *webhookService) {
+		if check != nil {
+			s.checkHost = check
+		}
+	}
+}
+
+type webhookService struct {
+	repo      webhook.Repository
+	idGen     id.Generator
+	checkHost func(ctx context.Context, host string) error
+	now       func() time.Time
+	log       logger.Logger
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo webhook.Repository, idGen id.Generator, opts ...WebhookServiceOption) WebhookService {
+	return NewWebhookServiceWithLogger(repo, idGen, logger.Get().WithLayer("application").WithComponent("webhook_service"), opts...)
+}
+
+func NewWebhookServiceWithLogger(repo webhook.Repository, idGen id.Generator, log logger.Logger, opts ...WebhookServiceOption) WebhookService {
+	if repo == nil {
+		panic("webhook repository cannot be nil")
+	}
+	if idGen == nil {
+		panic("ID generator cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	s := &webhookService{
+		repo:  repo,
+		idGen: idGen,
+		now:   time.Now,
+		log:   log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *webhookService) CreateEndpoint(ctx context.Context, req *CreateWebhookRequest) (*webhook.Endpoint, error) {
+	if req == nil {
+		return nil, errors.NewRequiredFieldError("request", "nil")
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = rand.Text()
+	}
+
+	now := s.now()
+	endpoint := &webhook.Endpoint{
+		ID:          s.idGen.Generate(),
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      req.Events,
+		Description: req.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := endpoint.Validate(); err != nil {
+		return nil, err
+	}
+	if s.checkHost != nil {
+		u, _ := url.Parse(endpoint.URL)
+		if err := s.checkHost(ctx, u.Hostname()); err != nil {
+			s.log.Warn(ctx, "webhook endpoint refused", "url", endpoint.URL, "error", err)
+			return nil, errors.NewInvalidValueError("url", endpoint.URL, "must resolve to public addresses")
+		}
+	}
+
+	if err := s.repo.CreateEndpoint(ctx, endpoint); err != nil {
+		s.log.Error(ctx, "failed to create webhook endpoint", "error", err, "url", endpoint.URL)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "webhook endpoint created", "endpoint_id", endpoint.ID, "url", endpoint.URL, "events", endpoint.Events)
+	return endpoint, nil
+}
+
+func (s *webhookService) GetEndpoint(ctx context.Context, id string) (*webhook.Endpoint, error) {
+	endpoint, err := s.repo.GetEndpoint(ctx, id)
+	if err != nil {
+		s.log.Error(ctx, "failed to get webhook endpoint", "error", err, "endpoint_id", id)
+		return nil, err
+	}
+	if endpoint == nil {
+		return nil, errors.NewEntityNotFoundError("webhook_endpoint", id)
+	}
+	return endpoint, nil
+}
+
+func (s *webhookService) ListEndpoints(ctx context.Context) ([]*webhook.Endpoint, error) {
+	endpoints, err := s.repo.ListEndpoints(ctx)
+	if err != nil {
+		s.log.Error(ctx, "failed to list webhook endpoints", "error", err)
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (s *webhookService) DeleteEndpoint(ctx context.Context, id string) error {
+	if err := s.repo.DeleteEndpoint(ctx, id); err != nil {
+		s.log.Error(ctx, "failed to delete webhook endpoint", "error", err, "endpoint_id", id)
+		return err
+	}
+	s.log.Info(ctx, "webhook endpoint deleted", "endpoint_id", id)
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, endpointID string, page, pageSize int) (*WebhookDeliveryPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if _, err := s.GetEndpoint(ctx, endpointID); err != nil {
+		return nil, err
+	}
+
+	deliveries, total, err := s.repo.ListDeliveries(ctx, endpointID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		s.log.Error(ctx, "failed to list webhook deliveries", "error", err, "endpoint_id", endpointID)
+		return nil, err
+	}
+
+	return &WebhookDeliveryPage{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, endpointID, deliveryID string) (*webhook.Delivery, error) {
+	original, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		s.log.Error(ctx, "failed to get webhook delivery", "error", err, "delivery_id", deliveryID)
+		return nil, err
+	}
+	if original == nil || original.EndpointID != endpointID {
+		return nil, errors.NewEntityNotFoundError("webhook_delivery", deliveryID)
+	}
+
+	delivery := original.Redelivery(s.idGen.Generate(), s.now())
+	if err := s.repo.CreateDeliveries(ctx, []*webhook.Delivery{delivery}); err != nil {
+		s.log.Error(ctx, "failed to queue webhook redelivery", "error", err, "delivery_id", deliveryID)
+		return nil, err
+	}
+
+	s.log.Info(ctx, "webhook redelivery queued", "endpoint_id", endpointID, "delivery_id", delivery.ID, "redelivery_of", deliveryID)
+	return delivery, nil
+}
+
+func (s *webhookService) HandleUserEvent(ctx context.Context, eventID string, e event.Event) error {
+	eventType, data, ok := webhookUserEvent(e)
+	if !ok {
+		return nil
+	}
+
+	endpoints, err := s.repo.ListEndpoints(ctx)
+	if err != nil {
+		s.log.Error(ctx, "failed to list webhook endpoints", "error", err, "event", e.EventName())
+		return err
+	}
+	var subscribed []*webhook.Endpoint
+	for _, endpoint := range endpoints {
+		if endpoint.Subscribes(eventType) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	if eventID == "" {
+		eventID = s.idGen.Generate()
+	}
+	payload, _ := json.Marshal(WebhookPayload{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: e.OccurredAt(),
+		Data:      data,
+	})
+
+	now := s.now()
+	deliveries := make([]*webhook.Delivery, 0, len(subscribed))
+	for _, endpoint := range subscribed {
+		deliveries = append(deliveries, &webhook.Delivery{
+			ID:            s.idGen.Generate(),
+			EndpointID:    endpoint.ID,
+			EventType:     eventType,
+			EventID:       eventID,
+			Payload:       payload,
+			Status:        webhook.StatusPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		})
+	}
+
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		s.log.Error(ctx, "failed to queue webhook deliveries", "error", err, "event", e.EventName())
+		return err
+	}
+
+	s.log.Debug(ctx, "webhook deliveries queued", "event_type", eventType, "event_id", eventID, "count", len(deliveries))
+	return nil
+}
+
+// webhookUserEvent maps a user domain event to the webhook event type
+// endpoints subscribe to and the data sent with it
+func webhookUserEvent(e event.Event) (string, WebhookUserPayload, bool) {
+	data := WebhookUserPayload{UserID: e.AggregateID()}
+	switch ev := e.(type) {
+	case user.UserRegistered:
+		data.Email, data.Name = ev.Email, ev.Name
+		return webhook.EventUserCreated, data, true
+	case user.UserDeleted:
+		data.Email, data.Name = ev.Email, ev.Name
+		return webhook.EventUserDeleted, data, true
+	case user.UserEmailChanged:
+		data.Email = ev.NewEmail
+	case user.UserNameChanged:
+		data.Name = ev.NewName
+	case user.UserHandleChanged:
+		data.Handle = ev.NewHandle
+	case user.UserPreferencesChanged:
+	case user.UserSuspended:
+		data.Email = ev.Email
+	case user.UserReactivated:
+		data.Email = ev.Email
+	case user.UserDeactivated:
+		data.Email = ev.Email
+	default:
+		return "", data, false
+	}
+	data.Change = e.EventName()
+	return webhook.EventUserUpdated, data, true
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/webhook"
+	webhookMocks "github.com/cctw-zed/wonder/internal/domain/webhook/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+func TestWebhookService(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (WebhookService, *webhookMocks.MockRepository, *idMocks.MockGenerator) {
+		ctrl := gomock.NewController(t)
+		repo := webhookMocks.NewMockRepository(ctrl)
+		idGen := idMocks.NewMockGenerator(ctrl)
+		return NewWebhookService(repo, idGen), repo, idGen
+	}
+
+	t.Run("create generates a secret when none is given", func(t *testing.T) {
+		svc, repo, idGen := setup(t)
+		idGen.EXPECT().Generate().Return("wh-1")
+		repo.EXPECT().CreateEndpoint(gomock.Any(), gomock.Any()).Return(nil)
+
+		endpoint, err := svc.CreateEndpoint(ctx, &CreateWebhookRequest{
+			URL:    "https://hooks.example.com/wonder",
+			Events: []string{webhook.EventUserCreated},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "wh-1", endpoint.ID)
+		assert.GreaterOrEqual(t, len(endpoint.Secret), 16)
+	})
+
+	t.Run("create rejects unknown event types", func(t *testing.T) {
+		svc, _, idGen := setup(t)
+		idGen.EXPECT().Generate().Return("wh-1")
+
+		_, err := svc.CreateEndpoint(ctx, &CreateWebhookRequest{
+			URL:    "https://hooks.example.com/wonder",
+			Events: []string{"user.exploded"},
+		})
+		var validationErr *errors.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("create refuses hosts that resolve to internal addresses", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		idGen := idMocks.NewMockGenerator(ctrl)
+		idGen.EXPECT().Generate().Return("wh-1")
+		var checked string
+		svc := NewWebhookService(webhookMocks.NewMockRepository(ctrl), idGen, WithWebhookHostCheck(func(_ context.Context, host string) error {
+			checked = host
+			return fmt.Errorf("%s resolves to 10.0.0.5", host)
+		}))
+
+		_, err := svc.CreateEndpoint(ctx, &CreateWebhookRequest{
+			URL:    "https://internal.example.com:8443/wonder",
+			Events: []string{webhook.EventUserCreated},
+		})
+		var validationErr *errors.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "internal.example.com", checked)
+	})
+
+	t.Run("user events are queued for subscribed endpoints only", func(t *testing.T) {
+		svc, repo, idGen := setup(t)
+		repo.EXPECT().ListEndpoints(gomock.Any()).Return([]*webhook.Endpoint{
+			{ID: "wh-1", Events: []string{webhook.EventUserUpdated}},
+			{ID: "wh-2", Events: []string{webhook.EventUserCreated}},
+		}, nil)
+		idGen.EXPECT().Generate().Return("d-1")
+
+		var queued []*webhook.Delivery
+		repo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, deliveries []*webhook.Delivery) error {
+			queued = deliveries
+			return nil
+		})
+
+		suspended := user.UserSuspended{Base: event.NewBase("u-1"), Email: "jane@example.com"}
+		require.NoError(t, svc.HandleUserEvent(ctx, "evt-1", suspended))
+
+		require.Len(t, queued, 1)
+		assert.Equal(t, "wh-1", queued[0].EndpointID)
+		assert.Equal(t, webhook.StatusPending, queued[0].Status)
+		assert.Equal(t, "evt-1", queued[0].EventID)
+
+		var payload WebhookPayload
+		require.NoError(t, json.Unmarshal(queued[0].Payload, &payload))
+		assert.Equal(t, webhook.EventUserUpdated, payload.Type)
+		assert.Equal(t, "evt-1", payload.ID)
+		assert.Equal(t, "u-1", payload.Data.UserID)
+		assert.Equal(t, user.EventUserSuspended, payload.Data.Change)
+	})
+
+	t.Run("profile changes are user updates", func(t *testing.T) {
+		for _, e := range []event.Event{
+			user.UserNameChanged{Base: event.NewBase("u-1"), OldName: "Jane", NewName: "Jane Doe"},
+			user.UserHandleChanged{Base: event.NewBase("u-1"), NewHandle: "jane"},
+			user.UserPreferencesChanged{Base: event.NewBase("u-1"), Field: "timezone", Value: "Europe/Paris"},
+		} {
+			eventType, data, ok := webhookUserEvent(e)
+			require.True(t, ok, e.EventName())
+			assert.Equal(t, webhook.EventUserUpdated, eventType)
+			assert.Equal(t, e.EventName(), data.Change)
+		}
+		_, data, _ := webhookUserEvent(user.UserNameChanged{Base: event.NewBase("u-1"), NewName: "Jane Doe"})
+		assert.Equal(t, "Jane Doe", data.Name)
+		_, data, _ = webhookUserEvent(user.UserHandleChanged{Base: event.NewBase("u-1"), NewHandle: "jane"})
+		assert.Equal(t, "jane", data.Handle)
+	})
+
+	t.Run("events without a webhook type are ignored", func(t *testing.T) {
+		svc, _, _ := setup(t)
+		reset := user.UserPasswordReset{Base: event.NewBase("u-1")}
+		assert.NoError(t, svc.HandleUserEvent(ctx, "", reset))
+	})
+
+	t.Run("redelivery repeats the event of the original delivery", func(t *testing.T) {
+		svc, repo, idGen := setup(t)
+		original := &webhook.Delivery{ID: "d-1", EndpointID: "wh-1", EventType: webhook.EventUserDeleted, EventID: "evt-1", Status: webhook.StatusFailed}
+		repo.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(original, nil)
+		idGen.EXPECT().Generate().Return("d-2")
+		repo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Len(1)).Return(nil)
+
+		delivery, err := svc.Redeliver(ctx, "wh-1", "d-1")
+		require.NoError(t, err)
+		assert.Equal(t, "d-2", delivery.ID)
+		assert.Equal(t, "evt-1", delivery.EventID)
+		assert.Equal(t, "d-1", delivery.RedeliveryOf)
+		assert.Equal(t, webhook.StatusPending, delivery.Status)
+	})
+
+	t.Run("redelivery of another endpoint's delivery is not found", func(t *testing.T) {
+		svc, repo, _ := setup(t)
+		repo.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(&webhook.Delivery{ID: "d-1", EndpointID: "wh-2"}, nil)
+
+		_, err := svc.Redeliver(ctx, "wh-1", "d-1")
+		var notFound *errors.EntityNotFoundError
+		assert.ErrorAs(t, err, &notFound)
+	})
+}
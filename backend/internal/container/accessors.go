@@ -0,0 +1,101 @@
+package container
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/infrastructure/eventbus"
+	"github.com/cctw-zed/wonder/internal/infrastructure/replay"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/health"
+	"github.com/cctw-zed/wonder/pkg/jwt"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+// Config returns the configuration the container was built from
+func (c *Container) Config() *config.Config {
+	return c.cfg
+}
+
+// Logger returns the container's logger
+func (c *Container) Logger() logger.Logger {
+	return c.log
+}
+
+// Database returns the primary database connection
+func (c *Container) Database() *database.Connection {
+	return c.db
+}
+
+// UserService returns the user service
+func (c *Container) UserService() user.UserService {
+	return c.userService
+}
+
+// UserTransferService returns the bulk user import and export service
+func (c *Container) UserTransferService() service.UserTransferService {
+	return c.userTransfer
+}
+
+// TenantService returns the tenant service
+func (c *Container) TenantService() tenant.Service {
+	return c.tenantService
+}
+
+// Handlers returns the HTTP handlers
+func (c *Container) Handlers() Handlers {
+	return c.handlers
+}
+
+// AuthMiddleware returns the token authentication middleware
+func (c *Container) AuthMiddleware() *middleware.AuthMiddleware {
+	return c.authMiddleware
+}
+
+// AdminOnly returns the middleware admitting admins only. It must run after
+// AuthMiddleware().RequireAuth.
+func (c *Container) AdminOnly() gin.HandlerFunc {
+	return c.adminOnly
+}
+
+// HandleCheckLimit returns the middleware throttling handle availability
+// checks, which admits every request unless security.signup enables it
+func (c *Container) HandleCheckLimit() gin.HandlerFunc {
+	if c.handleCheck == nil {
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+	return c.handleCheck
+}
+
+// ReplayStore returns the failed-request store, or nil unless failed-request
+// capture is enabled
+func (c *Container) ReplayStore() replay.Store {
+	return c.replayStore
+}
+
+// EventBus returns the domain event bus
+func (c *Container) EventBus() *eventbus.Dispatcher {
+	return c.eventBus
+}
+
+// Health returns the readiness checks; extend them with RegisterHealthCheck
+func (c *Container) Health() *health.Registry {
+	return c.health
+}
+
+// JWTKeys returns the token keys, replaced in place when the jwt section
+// reloads
+func (c *Container) JWTKeys() *jwt.KeySet {
+	return c.keySet
+}
+
+// Redis returns the shared Redis client, or nil unless external.redis is
+// enabled
+func (c *Container) Redis() *redis.Client {
+	return c.redisClient
+}
@@ -4,28 +4,114 @@ import (
 	"context"
 	"fmt"
 	"gorm.io/gorm"
+	nethttp "net/http"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/adminui"
 	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/preference"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/auditlog"
+	"github.com/cctw-zed/wonder/internal/infrastructure/broker"
 	"github.com/cctw-zed/wonder/internal/infrastructure/config"
 	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/infrastructure/eventbus"
+	"github.com/cctw-zed/wonder/internal/infrastructure/metrics"
+	"github.com/cctw-zed/wonder/internal/infrastructure/outbox"
+	"github.com/cctw-zed/wonder/internal/infrastructure/replay"
 	"github.com/cctw-zed/wonder/internal/infrastructure/repository"
+	"github.com/cctw-zed/wonder/internal/infrastructure/search"
+	"github.com/cctw-zed/wonder/internal/infrastructure/security"
+	"github.com/cctw-zed/wonder/internal/infrastructure/webhooks"
 	"github.com/cctw-zed/wonder/internal/interfaces/http"
 	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/buildinfo"
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+	"github.com/cctw-zed/wonder/pkg/cron"
+	"github.com/cctw-zed/wonder/pkg/fieldcrypt"
+	"github.com/cctw-zed/wonder/pkg/health"
+	"github.com/cctw-zed/wonder/pkg/httpclient"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+	"github.com/cctw-zed/wonder/pkg/jobs"
 	"github.com/cctw-zed/wonder/pkg/jwt"
 	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+	"github.com/cctw-zed/wonder/pkg/messaging"
+	"github.com/cctw-zed/wonder/pkg/netguard"
+	"github.com/cctw-zed/wonder/pkg/pagination"
+	"github.com/cctw-zed/wonder/pkg/redis"
+	"github.com/cctw-zed/wonder/pkg/reporting"
+	"github.com/cctw-zed/wonder/pkg/retry"
+	"github.com/cctw-zed/wonder/pkg/sentry"
 	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+	"github.com/cctw-zed/wonder/pkg/storage"
 )
 
+// Container wires the application's components. Build it with New; the
+// components are reached through its accessor methods.
 type Container struct {
-	Config         *config.Config
-	UserHandler    *http.UserHandler
-	AuthHandler    *http.AuthHandler
-	AuthMiddleware *middleware.AuthMiddleware
-	Database       *database.Connection
-	Logger         logger.Logger
-	nodeAllocator  id.NodeIDAllocator // 节点ID分配器，用于优雅关闭时释放资源
+	cfg            *config.Config
+	userService    user.UserService
+	userTransfer   service.UserTransferService
+	tenantService  tenant.Service
+	handlers       Handlers
+	authMiddleware *middleware.AuthMiddleware
+	adminOnly      gin.HandlerFunc
+	handleCheck    gin.HandlerFunc // nil unless handle availability checks are throttled
+	db             *database.Connection
+	log            logger.Logger
+	replayStore    replay.Store // nil unless failed-request capture is enabled
+	eventBus       *eventbus.Dispatcher
+	outboxRelay    *outbox.Relay              // nil unless the transactional outbox is enabled
+	searchSyncer   *search.Syncer             // nil unless Elasticsearch search is enabled
+	webhooks       *webhooks.Dispatcher       // nil unless webhooks are enabled
+	broker         messaging.Broker           // nil unless an external message broker is enabled
+	auditRecorder  *auditlog.AsyncRecorder    // nil unless audit logging is enabled
+	accountMail    service.AccountMailService // nil unless email is enabled
+	jobWorker      *jobs.Worker               // nil unless background jobs are enabled
+	scheduler      *cron.Scheduler            // nil when no task has a schedule
+	health         *health.Registry           // readiness checks; extend with RegisterHealthCheck
+	keySet         *jwt.KeySet                // token keys; replaced in place when the jwt section reloads
+	redisClient    *redis.Client              // nil unless external.redis is enabled
+	nodeAllocator  id.NodeIDAllocator         // 节点ID分配器，用于优雅关闭时释放资源
+	lifecycle      *Lifecycle                 // stops components on Shutdown
+}
+
+// Handlers are the HTTP handlers the server routes to
+type Handlers struct {
+	User         *http.UserHandler
+	UserSearch   *http.UserSearchHandler
+	Auth         *http.AuthHandler
+	MFA          *http.MFAHandler
+	Preference   *http.PreferenceHandler        // nil unless the preferences section is set
+	Notification *http.NotificationHandler      // nil unless notifications are enabled
+	Organization *http.OrganizationHandler      // nil unless organizations are enabled
+	Invitation   *http.InvitationHandler        // nil unless organizations are enabled
+	Verification *http.EmailVerificationHandler // nil unless email is enabled
+	Setup        *http.BootstrapHandler
+	Audit        *http.AuditHandler
+	Stats        *http.StatsHandler
+	Job          *http.JobHandler        // nil unless background jobs are enabled
+	DataExport   *http.DataExportHandler // nil unless background jobs are enabled
+	Tenant       *http.TenantHandler
+	ID           *http.IDHandler
+	ErrorCatalog *http.ErrorCatalogHandler
+	Transfer     *http.UserTransferHandler
+	Health       *http.HealthHandler
+	JWKS         *http.JWKSHandler
+	LogLevel     *http.LogLevelHandler
+	Version      *http.VersionHandler
+	Webhook      *http.WebhookHandler // nil unless webhooks are enabled
+	Avatar       *http.AvatarHandler  // nil unless object storage is enabled
+	Object       *http.ObjectHandler  // nil unless local object storage is enabled
+	AdminUI      *http.SPAHandler     // nil unless the admin UI is enabled
 }
 
 func NewContainer() (*Container, error) {
@@ -34,210 +120,1416 @@ func NewContainer() (*Container, error) {
 
 // NewContainerWithContext 使用上下文创建容器，支持动态nodeID分配
 func NewContainerWithContext(ctx context.Context) (*Container, error) {
-	return NewContainerWithConfig(ctx, "")
+	return New(ctx)
 }
 
 // NewContainerForEnvironment 为指定环境创建容器
 func NewContainerForEnvironment(ctx context.Context, environment string) (*Container, error) {
-	// Load environment-specific configuration from configs directory
-	cfg, err := config.LoadForEnvironment(environment, "./configs")
+	return New(ctx, WithEnvironment(environment))
+}
+
+// NewContainerWithConfig 使用配置文件路径创建容器
+func NewContainerWithConfig(ctx context.Context, configPath string) (*Container, error) {
+	return New(ctx, WithConfigPath(configPath))
+}
+
+// New wires all components. Without options it loads ./configs, connects to
+// the configured database and allocates a node ID; options replace
+// individual providers or components, e.g. in tests.
+func New(ctx context.Context, opts ...Option) (*Container, error) {
+	o := newOptions(opts)
+	cfg, err := o.config()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load config for environment %s: %w", environment, err)
+		return nil, err
 	}
 
 	// Initialize global logger with configuration
-	logger.InitializeWithConfig(logger.LogConfig{
-		Level:      cfg.Log.Level,
-		Format:     cfg.Log.Format,
-		Output:     cfg.Log.Output,
-		FilePath:   cfg.Log.FilePath,
-		EnableFile: cfg.Log.EnableFile,
-	})
-	appLogger := logger.Get().WithLayer("infrastructure").WithComponent("container")
+	logger.InitializeWithConfig(cfg.Log.LoggerConfig())
+	if cfg.Log.Buffered() {
+		metrics.EnsureLoggingMetrics()
+	}
+	baseLogger := o.logger
+	if baseLogger == nil {
+		baseLogger = logger.Get()
+	}
+	appLogger := baseLogger.WithLayer("infrastructure").WithComponent("container")
+	appLogger.Debug(ctx, "configuration loaded", "config", cfg.String())
+
+	// Circuit breakers for Redis and etcd; nil when disabled
+	redisBreaker := newBreaker(cfg, "redis", appLogger)
+	etcdBreaker := newBreaker(cfg, "etcd", appLogger)
+	redisClient := newRedisClient(cfg, redisBreaker)
 
-	// 检测ID分配策略
-	allocator := createNodeIDAllocator(ctx, cfg)
+	dbConn, err := o.database(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypt personal data columns when configured. The keyring is set
+	// either way so a previous container's keys never carry over, and
+	// before migrating since data migrations read encrypted columns.
+	var keyring *fieldcrypt.Keyring
+	if cfg.Encryption != nil && cfg.Encryption.Enabled {
+		if keyring, err = cfg.Encryption.Keyring(); err != nil {
+			return nil, fmt.Errorf("invalid encryption config: %w", err)
+		}
+	}
+	database.UseFieldEncryption(keyring)
+
+	if err := migrate(ctx, cfg, dbConn, cfg.Database.AutoMigrate && !o.checkSchemaOnly); err != nil {
+		return nil, err
+	}
 
-	// Initialize database connection using config
-	dbConn, err := database.NewConnection(cfg.Database)
+	// Emails are matched by canonical form under the configured rules, and
+	// handles are checked against the configured reserved names
+	usersCfg := cfg.Users
+	if usersCfg == nil {
+		usersCfg = config.DefaultUsersConfig()
+	}
+	emailRules := user.EmailRules{FoldGmail: usersCfg.FoldGmailAddresses}
+	handlePolicy := user.HandlePolicy{Reserved: usersCfg.ReservedHandles}
+
+	// Error responses, notifications and account emails are translated
+	// into the configured languages, or only English when i18n is
+	// disabled. The bundle is set either way so a previous container's
+	// languages never carry over.
+	var languages []string
+	if cfg.I18n != nil && cfg.I18n.Enabled {
+		languages = cfg.I18n.Languages
+	}
+	bundle, err := i18n.New(languages)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("invalid i18n config: %w", err)
 	}
+	i18n.Use(bundle)
 
-	// Run database migrations
-	migrator := database.NewMigrator(dbConn.DB())
-	if err := migrator.MigrateAll(); err != nil {
-		return nil, fmt.Errorf("failed to run database migrations: %w", err)
+	provideIDs := o.ids
+	if provideIDs == nil {
+		provideIDs = defaultIDProvider(etcdBreaker, appLogger)
+	}
+	idGen, allocator, err := provideIDs(ctx, cfg, redisClient)
+	if err != nil {
+		return nil, err
 	}
 
-	// 根据分配器类型初始化ID生成器
-	if allocator != nil {
-		// 使用动态分配器
-		serviceType := getServiceTypeFromConfig(cfg)
-		if err := id.InitDefaultWithAllocator(ctx, serviceType, allocator); err != nil {
-			return nil, fmt.Errorf("failed to init ID generator with allocator: %w", err)
+	// Audit log, written off the request path
+	auditRepo := repository.NewAuditRepository(dbConn.DB())
+	var auditRecorder *auditlog.AsyncRecorder
+	var recorder audit.Recorder
+	if cfg.Audit != nil && cfg.Audit.Enabled {
+		auditRecorder = auditlog.NewAsyncRecorder(auditRepo,
+			auditlog.WithBufferSize(cfg.Audit.BufferSize),
+			auditlog.WithBatchSize(cfg.Audit.BatchSize),
+			auditlog.WithFlushInterval(cfg.Audit.FlushInterval),
+		)
+		recorder = auditRecorder
+	}
+
+	// Outbound email for the account lifecycle
+	var accountMail service.AccountMailService
+	var smtpBreaker *circuitbreaker.Breaker
+	emailCfg := config.DefaultEmailConfig()
+	if cfg.External != nil && cfg.External.Email != nil {
+		emailCfg = cfg.External.Email
+	}
+	mail := o.mailer
+	if mail == nil && emailCfg.Enabled {
+		if emailCfg.Provider == config.EmailProviderSMTP {
+			smtpBreaker = newBreaker(cfg, "smtp", appLogger)
+		}
+		mail, err = newMailer(emailCfg, smtpBreaker, appLogger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create mailer: %w", err)
+		}
+	}
+	if mail != nil {
+		accountMail, err = newAccountMailService(mail, emailCfg.AppURL.String(), bundle, serviceLogger(baseLogger, "account_mail_service"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create mailer: %w", err)
 		}
+	}
+
+	// Background job queue; with it, event subscribers enqueue emails so a
+	// mail server outage is retried instead of losing them
+	var jobQueue jobs.Queue
+	var jobWorker *jobs.Worker
+	subscriberMail := accountMail
+	if cfg.Jobs != nil && cfg.Jobs.Enabled {
+		jobQueue = newJobQueue(cfg.Jobs, redisClient)
+		jobWorker = jobs.NewWorker(jobQueue,
+			jobs.WithConcurrency(cfg.Jobs.Concurrency),
+			jobs.WithPollInterval(cfg.Jobs.PollInterval),
+			jobs.WithLease(cfg.Jobs.Lease),
+			jobs.WithMaxAttempts(cfg.Jobs.MaxAttempts),
+			jobs.WithBackoff(cfg.Jobs.BaseBackoff, cfg.Jobs.MaxBackoff),
+		)
+		if accountMail != nil {
+			subscriberMail = service.NewQueuedAccountMailService(jobQueue)
+		}
+	}
+
+	// Domain event bus and its subscribers
+	eventBus := eventbus.NewDispatcher()
+	registerEventSubscribers(eventBus, appLogger, recorder, subscriberMail)
+
+	// External message broker for publishing domain events to other services
+	var msgBroker messaging.Broker
+	var topicPrefix string
+	if cfg.External != nil && cfg.External.Messaging != nil && cfg.External.Messaging.Enabled {
+		msgBroker, err = broker.New(cfg.External.Messaging)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to message broker: %w", err)
+		}
+		topicPrefix = cfg.External.Messaging.TopicPrefix
+	}
+
+	// Elasticsearch index users are searched in, kept in sync with their
+	// events. A cluster that is down at startup is set up by the first sync.
+	var searchIndex *search.UserIndex
+	var searchSyncer *search.Syncer
+	if cfg.Search.Elasticsearch() {
+		searchCfg := cfg.Search
+		addresses := make([]string, len(searchCfg.Addresses))
+		for i, address := range searchCfg.Addresses {
+			addresses[i] = address.String()
+		}
+		searchIndex = search.NewUserIndex(
+			search.NewClient(addresses, searchCfg.Username, searchCfg.Password, newHTTPClient(cfg, "search", searchCfg.Timeout)),
+			searchCfg.Index, dbConn.DB(), dbConn.Resolver(), searchCfg.ReindexBatchSize)
+		if err := searchIndex.Ensure(ctx); err != nil {
+			appLogger.Warn(ctx, "failed to set up user search index", "error", err)
+		}
+		searchSyncer = search.NewSyncer(searchIndex)
+	}
+
+	// Transactional outbox: events are stored with the write and relayed to
+	// the bus and, when configured, the message broker. The search index
+	// only queues users to sync, so an outage does not fail the delivery.
+	var outboxStore *outbox.Store
+	var outboxRelay *outbox.Relay
+	if cfg.Outbox != nil && cfg.Outbox.Enabled {
+		sinks := []outbox.Sink{outbox.NewBusSink(eventBus, outbox.NewRegistry(userEventTypes()...))}
+		if msgBroker != nil {
+			sinks = append(sinks, broker.NewSink(msgBroker, topicPrefix))
+		}
+		if searchSyncer != nil {
+			sinks = append(sinks, searchSyncer.Sink())
+		}
+		sink := sinks[0]
+		if len(sinks) > 1 {
+			sink = outbox.FanOut(sinks...)
+		}
+
+		outboxStore = outbox.NewStore(dbConn.DB())
+		outboxRelay = outbox.NewRelay(outboxStore, database.NewUnitOfWork(dbConn.DB()), sink,
+			outbox.WithPollInterval(cfg.Outbox.PollInterval),
+			outbox.WithBatchSize(cfg.Outbox.BatchSize),
+			outbox.WithMaxAttempts(cfg.Outbox.MaxAttempts),
+			outbox.WithBackoff(cfg.Outbox.BaseBackoff, cfg.Outbox.MaxBackoff),
+		)
 	} else {
-		// 使用配置文件中的ID配置
-		if err := id.InitDefaultForService(getServiceTypeFromConfig(cfg), cfg.ID.InstanceID); err != nil {
-			return nil, fmt.Errorf("failed to init distributed ID generator: %w", err)
+		// Without the outbox, forward events from the bus on a best-effort basis
+		if msgBroker != nil {
+			forward := broker.ForwardHandler(msgBroker, topicPrefix)
+			for _, e := range userEventTypes() {
+				eventBus.Subscribe(e.EventName(), forward)
+			}
+		}
+		if searchSyncer != nil {
+			syncUser := searchSyncer.Handler()
+			for _, e := range userEventTypes() {
+				eventBus.Subscribe(e.EventName(), syncUser)
+			}
 		}
 	}
 
-	// 后续组件可以直接使用 id.Generate()
-	userRepo := repository.NewUserRepository(dbConn.DB())
-	idGen := id.GetDefault()
-	userService := service.NewUserService(userRepo, idGen)
+	// Webhook deliveries of user events to endpoints registered by admins
+	var webhookHandler *http.WebhookHandler
+	var webhookDispatcher *webhooks.Dispatcher
+	if cfg.Webhooks != nil && cfg.Webhooks.Enabled {
+		webhookRepo := repository.NewWebhookRepository(dbConn.DB())
+		webhookService := service.NewWebhookServiceWithLogger(webhookRepo, idGen, serviceLogger(baseLogger, "webhook_service"), service.WithWebhookHostCheck(netguard.CheckResolved))
+		registerWebhookSubscribers(eventBus, webhookService)
+		webhookHandler = http.NewWebhookHandler(webhookService)
+		// Endpoint names may resolve to internal addresses after they are
+		// registered, so every dial is checked too
+		webhookClientCfg := newHTTPClientConfig(cfg, "webhooks", cfg.Webhooks.Timeout)
+		webhookClientCfg.DialControl = netguard.Control
+		webhookDispatcher = webhooks.NewDispatcher(webhookRepo, database.NewUnitOfWork(dbConn.DB()),
+			httpclient.New(webhookClientCfg),
+			webhooks.WithPollInterval(cfg.Webhooks.PollInterval),
+			webhooks.WithBatchSize(cfg.Webhooks.BatchSize),
+			webhooks.WithMaxAttempts(cfg.Webhooks.MaxAttempts),
+			webhooks.WithBackoff(cfg.Webhooks.BaseBackoff, cfg.Webhooks.MaxBackoff),
+		)
+	}
+
+	// User list cursors are signed so clients cannot forge positions;
+	// without a shared key only the issuing instance accepts them
+	cursorCodec := pagination.NewRandomCodec()
+	if usersCfg.CursorSigningKey != "" {
+		if cursorCodec, err = pagination.NewCodec([]byte(usersCfg.CursorSigningKey)); err != nil {
+			return nil, fmt.Errorf("invalid users config: %w", err)
+		}
+	} else {
+		appLogger.Info(ctx, "users.cursor_signing_key is not set; list cursors are only accepted by the instance that issued them")
+	}
+
+	userRepo := o.userRepo
+	if userRepo == nil {
+		userRepo = repository.NewUserRepository(dbConn.DB(), repository.WithReadReplicas(dbConn.Resolver()),
+			repository.WithEmailRules(emailRules), repository.WithCursorCodec(cursorCodec))
+		if policy, ok := retryPolicy(cfg); ok {
+			userRepo = repository.NewRetryingUserRepository(userRepo, policy)
+		}
+		if redisClient != nil && cfg.Users != nil && cfg.Users.CountCacheTTL > 0 {
+			userRepo = repository.NewCountCachingUserRepository(userRepo, redisClient, cfg.Users.CountCacheTTL)
+		}
+	}
+	breachChecker := newBreachChecker(cfg)
+	userOpts, err := userServiceOptions(cfg, dbConn.DB(), eventBus, outboxStore, recorder, redisClient, breachChecker)
+	if err != nil {
+		return nil, err
+	}
+	userOpts = append(userOpts, service.WithEmailRules(emailRules), service.WithHandlePolicy(handlePolicy))
+	userService := service.NewUserServiceWithLogger(userRepo, idGen, serviceLogger(baseLogger, "user_service"), userOpts...)
 	userHandler := http.NewUserHandler(userService)
+	var userSearcher user.Searcher = repository.NewUserSearcher(dbConn.DB(), dbConn.Resolver())
+	if searchIndex != nil {
+		userSearcher = searchIndex
+	}
+	userSearchHandler := http.NewUserSearchHandler(service.NewUserSearchServiceWithLogger(userSearcher, serviceLogger(baseLogger, "user_search_service")))
+
+	// Avatars in object storage. Local objects are served by the
+	// application through the URLs it presigns.
+	var avatarHandler *http.AvatarHandler
+	var objectHandler *http.ObjectHandler
+	if cfg.Storage != nil && cfg.Storage.Enabled {
+		objectStore, localStore, err := newObjectStorage(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create object storage: %w", err)
+		}
+		avatarService := service.NewAvatarServiceWithLogger(userRepo, objectStore, usersCfg.AvatarSize, cfg.Storage.URLTTL, serviceLogger(baseLogger, "avatar_service"))
+		avatarHandler = http.NewAvatarHandler(avatarService, int64(usersCfg.AvatarMaxBytes))
+		if localStore != nil {
+			objectHandler = http.NewObjectHandler(localStore)
+		}
+		// Avatars go with the account
+		eventBus.Subscribe(user.EventUserDeleted, func(ctx context.Context, e event.Event) error {
+			if deleted, ok := e.(user.UserDeleted); ok && deleted.AvatarKey != "" {
+				return objectStore.Delete(ctx, deleted.AvatarKey)
+			}
+			return nil
+		})
+	}
 
 	// Initialize JWT and Auth services
-	tokenService := jwt.NewTokenService(cfg.JWT.SigningKey, cfg.JWT.Expiry)
-	authService := service.NewAuthService(userService, tokenService)
+	activeKeyID, keys, err := jwtKeys(cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jwt keys: %w", err)
+	}
+	jwtKeySet, err := jwt.NewKeySet(activeKeyID, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jwt keys: %w", err)
+	}
+	tokenService := o.tokens
+	if tokenService == nil {
+		tokenService = jwt.NewTokenServiceWithKeys(jwtKeySet, cfg.JWT.Expiry)
+	}
+	var authOptions []service.AuthServiceOption
+	var mfaService service.MFAService
+	var mfaHandler *http.MFAHandler
+	if cfg.Auth != nil && cfg.Auth.MFA != nil {
+		mfaService = newMFAService(cfg.Auth.MFA, dbConn.DB(), userRepo, idGen, recorder, serviceLogger(baseLogger, "mfa_service"))
+		authOptions = append(authOptions, service.WithMFA(mfaService, cfg.Auth.MFA.TokenTTL))
+		mfaHandler = http.NewMFAHandler(mfaService)
+	}
+	authService := service.NewAuthServiceWithLogger(userService, tokenService, serviceLogger(baseLogger, "auth_service"), authOptions...)
 	authHandler := http.NewAuthHandler(authService)
+	jwksHandler := http.NewJWKSHandler(tokenService)
+
+	// Per-user preferences, cached in Redis when it is enabled
+	var preferenceService service.PreferenceService
+	var preferenceHandler *http.PreferenceHandler
+	if cfg.Preferences != nil {
+		var preferenceRepo preference.Repository = repository.NewPreferenceRepository(dbConn.DB())
+		if redisClient != nil && cfg.Preferences.CacheTTL > 0 {
+			preferenceRepo = repository.NewCachingPreferenceRepository(preferenceRepo, redisClient, cfg.Preferences.CacheTTL)
+		}
+		preferenceService = service.NewPreferenceServiceWithLogger(preferenceRepo, service.PreferencePolicy{
+			MaxValueBytes: int(cfg.Preferences.MaxValueBytes),
+			MaxKeys:       cfg.Preferences.MaxKeys,
+		}, serviceLogger(baseLogger, "preference_service"), service.WithPreferenceUnitOfWork(database.NewUnitOfWork(dbConn.DB())))
+		preferenceHandler = http.NewPreferenceHandler(preferenceService)
+	}
+
+	// Notifications of user events, delivered through jobs when they are
+	// enabled
+	var notificationService service.NotificationService
+	var notificationHandler *http.NotificationHandler
+	if cfg.Notifications != nil && cfg.Notifications.Enabled {
+		opts := []service.NotificationServiceOption{service.WithNotificationBundle(bundle)}
+		if jobQueue != nil {
+			opts = append(opts, service.WithNotificationQueue(jobQueue))
+		}
+		if cfg.Notifications.Email && subscriberMail != nil {
+			opts = append(opts, service.WithNotificationMail(subscriberMail))
+		}
+		if preferenceService != nil {
+			opts = append(opts, service.WithNotificationPreferences(preferenceService))
+		}
+		notificationService = service.NewNotificationServiceWithLogger(repository.NewNotificationRepository(dbConn.DB()), userRepo, idGen, serviceLogger(baseLogger, "notification_service"), opts...)
+		notificationHandler = http.NewNotificationHandler(notificationService)
+		registerNotificationSubscribers(eventBus, notificationService)
+	}
+
+	// Organizations of users, managed by their owners, who invite members
+	// by emailing them a signed link
+	var organizationHandler *http.OrganizationHandler
+	var invitationHandler *http.InvitationHandler
+	if cfg.Organizations != nil && cfg.Organizations.Enabled {
+		orgRepo := repository.NewOrganizationRepository(dbConn.DB())
+		organizationHandler = http.NewOrganizationHandler(service.NewOrganizationServiceWithLogger(
+			orgRepo, userRepo, idGen, serviceLogger(baseLogger, "organization_service"),
+			service.WithOrganizationUnitOfWork(database.NewUnitOfWork(dbConn.DB())),
+		))
+
+		inviteURL := cfg.Organizations.InviteURL.String()
+		if inviteURL == "" {
+			inviteURL = strings.TrimSuffix(emailCfg.AppURL.String(), "/") + "/invite"
+		}
+		invitationHandler = http.NewInvitationHandler(service.NewInvitationServiceWithLogger(
+			orgRepo, userRepo, userService, tokenService, idGen,
+			service.InvitationPolicy{TTL: cfg.Organizations.InviteTTL, URL: inviteURL},
+			serviceLogger(baseLogger, "invitation_service"),
+			service.WithInvitationMail(subscriberMail),
+			service.WithInvitationUnitOfWork(database.NewUnitOfWork(dbConn.DB())),
+			service.WithInvitationEmailRules(emailRules),
+		))
+	}
+
+	// Users confirm their email address through a signed link, emailed
+	// when they register and when they change it
+	var verificationHandler *http.EmailVerificationHandler
+	if subscriberMail != nil {
+		verifyURL := emailCfg.VerificationURL.String()
+		if verifyURL == "" {
+			verifyURL = strings.TrimSuffix(emailCfg.AppURL.String(), "/") + "/verify-email"
+		}
+		verificationService := service.NewEmailVerificationServiceWithLogger(userRepo, tokenService, subscriberMail,
+			service.EmailVerificationPolicy{TTL: emailCfg.VerificationTTL, URL: verifyURL}, serviceLogger(baseLogger, "email_verification_service"))
+		verificationHandler = http.NewEmailVerificationHandler(verificationService)
+		registerVerificationSubscribers(eventBus, verificationService)
+	}
+
+	// Initial admin bootstrap
+	bootstrapService := service.NewBootstrapServiceWithLogger(
+		adminBootstrapSettings(cfg),
+		userRepo,
+		repository.NewBootstrapRepository(dbConn.DB()),
+		idGen,
+		database.NewUnitOfWork(dbConn.DB()),
+		eventBus,
+		serviceLogger(baseLogger, "bootstrap_service"),
+	)
+	setupHandler := http.NewBootstrapHandler(bootstrapService)
 
 	// Initialize auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService)
+	adminOnly := middleware.RequireAdmin(userService)
 
-	appLogger.Info(ctx, "container initialized successfully", "service_name", cfg.App.Name, "version", cfg.App.Version)
+	auditHandler := http.NewAuditHandler(service.NewAuditServiceWithLogger(auditRepo, serviceLogger(baseLogger, "audit_service")))
+	reportingService := newReportingService(cfg, dbConn, redisClient, serviceLogger(baseLogger, "reporting_service"))
+	statsHandler := http.NewStatsHandler(reportingService)
 
-	return &Container{
-		Config:         cfg,
-		UserHandler:    userHandler,
-		AuthHandler:    authHandler,
-		AuthMiddleware: authMiddleware,
-		Database:       dbConn,
-		Logger:         appLogger,
+	var jobHandler *http.JobHandler
+	var exportService service.DataExportService
+	var exportHandler *http.DataExportHandler
+	if jobWorker != nil {
+		exportService = service.NewDataExportServiceWithLogger(repository.NewDataExportRepository(dbConn.DB()), userRepo, auditRepo, jobQueue, idGen,
+			cfg.Account.DataExportTTL, serviceLogger(baseLogger, "data_export_service"), service.WithExportMail(subscriberMail))
+		exportHandler = http.NewDataExportHandler(exportService)
+		var reencryptor service.PIIReencryptor
+		if cfg.Encryption != nil && cfg.Encryption.Enabled {
+			reencryptor = repository.NewPIIReencryptor(dbConn.DB(), emailRules, cfg.Encryption.ReencryptBatchSize)
+		}
+		var reindexer service.UserSearchReindexer
+		if searchIndex != nil {
+			reindexer = searchIndex
+		}
+		service.RegisterJobHandlers(jobWorker, accountMail, reportingService, exportService, reencryptor, reindexer,
+			repository.NewEmailCanonicalizer(dbConn.DB(), emailRules, usersCfg.CanonicalizeBatchSize), notificationService)
+		jobHandler = http.NewJobHandler(service.NewJobServiceWithLogger(jobQueue, jobWorker, serviceLogger(baseLogger, "job_service")))
+	}
+
+	// Tenant management and request tenant resolution
+	tenantService := service.NewTenantServiceWithLogger(repository.NewTenantRepository(dbConn.DB()), idGen, serviceLogger(baseLogger, "tenant_service"))
+	tenantHandler := http.NewTenantHandler(tenantService)
+
+	// Bulk user export and import
+	importCfg := cfg.Import
+	if importCfg == nil {
+		importCfg = config.DefaultImportConfig()
+	}
+	transferService := service.NewUserTransferServiceWithLogger(userRepo, userService, importCfg.BatchSize, serviceLogger(baseLogger, "user_transfer_service"), service.WithImportEmailRules(emailRules))
+	transferHandler := http.NewUserTransferHandler(transferService, importCfg.MaxRows, int64(importCfg.MaxBodyBytes))
+
+	// Failed-request capture for the replay tool
+	var replayStore replay.Store
+	if cfg.Replay != nil && cfg.Replay.Enabled {
+		replayStore, err = replay.NewFileStore(cfg.Replay.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize replay store: %w", err)
+		}
+	}
+
+	// Readiness checks for the dependencies wired above
+	healthRegistry := healthChecks(cfg, dbConn, allocator, idGen)
+	if searchIndex != nil {
+		// Only search depends on the cluster
+		healthRegistry.Register("elasticsearch", health.CheckerFunc(searchIndex.Ping), health.NonCritical())
+	}
+	var breakers []*circuitbreaker.Breaker
+	if redisClient != nil {
+		breakers = append(breakers, redisBreaker)
+	}
+	if _, ok := allocator.(*id.EtcdAllocator); ok {
+		breakers = append(breakers, etcdBreaker)
+	}
+	if breachChecker != nil {
+		breakers = append(breakers, breachChecker.Breaker())
+	}
+	if smtpBreaker != nil {
+		breakers = append(breakers, smtpBreaker)
+	}
+	healthHandler := http.NewHealthHandler(healthRegistry, breakers...)
+
+	// Periodic maintenance
+	var scheduler *cron.Scheduler
+	if cfg.Scheduler != nil {
+		scheduler, err = newScheduler(cfg.Scheduler, auditRepo, outboxStore, userService, exportService, mfaService, appLogger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create scheduler: %w", err)
+		}
+	}
+
+	adminUIHandler, err := newAdminUIHandler(cfg.Server.AdminUI)
+	if err != nil {
+		return nil, err
+	}
+
+	// Report 5xx errors and panics to Sentry
+	if cfg.External != nil && cfg.External.Sentry.Enabled() {
+		sentryClient, err := newSentryClient(cfg, appLogger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sentry client: %w", err)
+		}
+		reporting.Set(reporting.NewSentry(sentryClient))
+	}
+
+	// Background workers start once nothing else can fail, so an error above
+	// never leaves them running without a container to stop them
+	if outboxRelay != nil {
+		outboxRelay.Start(ctx)
+	}
+	if searchSyncer != nil {
+		searchSyncer.Start(ctx)
+	}
+	if webhookDispatcher != nil {
+		webhookDispatcher.Start(ctx)
+	}
+	if jobWorker != nil {
+		jobWorker.Start(ctx)
+	}
+	if scheduler != nil {
+		scheduler.Start(ctx)
+	}
+
+	appLogger.Info(ctx, "container initialized successfully",
+		append([]interface{}{"service_name", cfg.App.Name, "app_version", cfg.App.Version}, buildinfo.Get().LogFields()...)...)
+
+	c := &Container{
+		cfg:           cfg,
+		userService:   userService,
+		userTransfer:  transferService,
+		tenantService: tenantService,
+		handlers: Handlers{
+			User:         userHandler,
+			UserSearch:   userSearchHandler,
+			Auth:         authHandler,
+			MFA:          mfaHandler,
+			Preference:   preferenceHandler,
+			Notification: notificationHandler,
+			Organization: organizationHandler,
+			Invitation:   invitationHandler,
+			Verification: verificationHandler,
+			Setup:        setupHandler,
+			Audit:        auditHandler,
+			Stats:        statsHandler,
+			Job:          jobHandler,
+			DataExport:   exportHandler,
+			Tenant:       tenantHandler,
+			ID:           http.NewIDHandler(getServiceTypeFromConfig(cfg)),
+			ErrorCatalog: http.NewErrorCatalogHandler(),
+			Transfer:     transferHandler,
+			Health:       healthHandler,
+			JWKS:         jwksHandler,
+			LogLevel:     http.NewLogLevelHandler(),
+			Version:      http.NewVersionHandler(),
+			Webhook:      webhookHandler,
+			Avatar:       avatarHandler,
+			Object:       objectHandler,
+			AdminUI:      adminUIHandler,
+		},
+		authMiddleware: authMiddleware,
+		adminOnly:      adminOnly,
+		handleCheck:    handleCheckLimit(cfg, redisClient),
+		db:             dbConn,
+		log:            appLogger,
+		replayStore:    replayStore,
+		eventBus:       eventBus,
+		outboxRelay:    outboxRelay,
+		searchSyncer:   searchSyncer,
+		webhooks:       webhookDispatcher,
+		broker:         msgBroker,
+		auditRecorder:  auditRecorder,
+		accountMail:    accountMail,
+		jobWorker:      jobWorker,
+		scheduler:      scheduler,
+		health:         healthRegistry,
+		keySet:         jwtKeySet,
+		redisClient:    redisClient,
 		nodeAllocator:  allocator,
-	}, nil
+		lifecycle:      NewLifecycle(nil),
+	}
+	c.registerShutdownHooks()
+	return c, nil
 }
 
-// NewContainerWithConfig 使用配置文件路径创建容器
-func NewContainerWithConfig(ctx context.Context, configPath string) (*Container, error) {
-	// Load configuration
-	var cfg *config.Config
-	var err error
-	if configPath != "" {
-		cfg, err = config.Load(configPath)
-	} else {
-		// Use configs directory by default
-		cfg, err = config.Load("./configs")
+// newSentryClient creates the Sentry client. Environment falls back to the
+// app section; release falls back to the linked build version, then the app
+// section. Every event is tagged with the build.
+func newSentryClient(cfg *config.Config, appLogger logger.Logger) (*sentry.Client, error) {
+	sc := cfg.External.Sentry
+	environment, release := sc.Environment, sc.Release
+	if environment == "" {
+		environment = cfg.App.Environment
 	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %w", err)
+	if release == "" && buildinfo.Released() {
+		release = buildinfo.Version
 	}
+	if release == "" {
+		release = cfg.App.Version
+	}
+	build := buildinfo.Get()
 
-	// Initialize global logger with configuration
-	logger.InitializeWithConfig(logger.LogConfig{
-		Level:      cfg.Log.Level,
-		Format:     cfg.Log.Format,
-		Output:     cfg.Log.Output,
-		FilePath:   cfg.Log.FilePath,
-		EnableFile: cfg.Log.EnableFile,
+	return sentry.NewClient(sentry.Config{
+		DSN:         sc.DSN,
+		Environment: environment,
+		Release:     release,
+		Timeout:     sc.Timeout,
+		Tags:        map[string]string{"git_commit": build.GitCommit, "build_time": build.BuildTime, "go_version": build.GoVersion},
+		OnError: func(err error) {
+			appLogger.Warn(context.Background(), "failed to report error to sentry", "error", err)
+		},
+	}, newHTTPClient(cfg, "sentry", sc.Timeout))
+}
+
+// newMailer creates the configured mail provider. SMTP sends are retried on
+// transient failures and guarded by breaker unless it is nil.
+func newMailer(cfg *config.EmailConfig, breaker *circuitbreaker.Breaker, appLogger logger.Logger) (mailer.Mailer, error) {
+	if cfg.Provider == config.EmailProviderLog {
+		return mailer.NewLogMailer(appLogger.WithComponent("mailer")), nil
+	}
+
+	smtpMailer, err := mailer.NewSMTPMailer(mailer.SMTPConfig{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		From:     cfg.From,
+		TLS:      cfg.TLS,
+		Timeout:  cfg.Timeout,
 	})
-	appLogger := logger.Get().WithLayer("infrastructure").WithComponent("container")
+	if err != nil {
+		return nil, err
+	}
+	var m mailer.Mailer = mailer.WithRetry(smtpMailer, cfg.MaxAttempts)
+	if breaker != nil {
+		m = mailer.WithBreaker(m, breaker)
+	}
+	return m, nil
+}
+
+// newAccountMailService creates the account mail service on top of m.
+// Links in emails point to appURL and times are formatted with bundle.
+func newAccountMailService(m mailer.Mailer, appURL string, bundle *i18n.Bundle, log logger.Logger) (service.AccountMailService, error) {
+	renderer, err := mailer.NewRenderer(mailer.Templates)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewAccountMailServiceWithLogger(m, renderer, appURL, log, service.WithMailBundle(bundle)), nil
+}
 
-	// 检测ID分配策略
-	allocator := createNodeIDAllocator(ctx, cfg)
+// newJobQueue returns the configured job queue. The redis backend falls back
+// to memory when Redis is disabled, which config validation rejects.
+func newJobQueue(cfg *config.JobsConfig, redisClient *redis.Client) jobs.Queue {
+	if cfg.Backend == config.JobsBackendRedis && redisClient != nil {
+		return jobs.NewRedisQueue(redisClient, cfg.RedisKeyPrefix)
+	}
+	return jobs.NewMemoryQueue()
+}
 
-	// Initialize database connection using config
-	dbConn, err := database.NewConnection(cfg.Database)
+// newScheduler registers the maintenance tasks that have a schedule, and
+// returns nil when there are none. The account purge is registered even
+// when the scheduler is disabled: it carries out deletions users asked for
+// and removes data that has expired. outboxStore, exportService and
+// mfaService are nil unless their features are enabled.
+func newScheduler(cfg *config.SchedulerConfig, auditRepo audit.Repository, outboxStore *outbox.Store, userService user.UserService, exportService service.DataExportService, mfaService service.MFAService, log logger.Logger) (*cron.Scheduler, error) {
+	loc, err := cfg.Location()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
+	}
+	scheduler := cron.NewScheduler(cron.WithLocation(loc), cron.WithObserver(metrics.ObserveScheduledRun))
+
+	if cfg.Enabled && cfg.AuditRetentionSchedule != "" {
+		err := scheduler.Add("audit_retention", cfg.AuditRetentionSchedule, func(ctx context.Context) error {
+			deleted, err := auditRepo.DeleteBefore(ctx, time.Now().Add(-cfg.AuditRetention))
+			if err == nil {
+				log.Info(ctx, "expired audit entries deleted", "deleted", deleted, "retention", cfg.AuditRetention.String())
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Enabled && cfg.OutboxCleanupSchedule != "" && outboxStore != nil {
+		err := scheduler.Add("outbox_cleanup", cfg.OutboxCleanupSchedule, func(ctx context.Context) error {
+			deleted, err := outboxStore.DeletePublishedBefore(ctx, time.Now().Add(-cfg.OutboxRetention))
+			if err == nil {
+				log.Info(ctx, "published outbox messages deleted", "deleted", deleted, "retention", cfg.OutboxRetention.String())
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.AccountPurgeSchedule != "" {
+		err := scheduler.Add("account_purge", cfg.AccountPurgeSchedule, func(ctx context.Context) error {
+			if _, err := userService.PurgeDueDeletions(ctx); err != nil {
+				return err
+			}
+			if exportService != nil {
+				if _, err := exportService.DeleteExpired(ctx); err != nil {
+					return err
+				}
+			}
+			if mfaService != nil {
+				if _, err := mfaService.DeleteExpiredDevices(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(scheduler.Tasks()) == 0 {
+		return nil, nil
+	}
+	return scheduler, nil
+}
+
+// userServiceOptions builds optional user service collaborators from configuration
+func userServiceOptions(cfg *config.Config, db *gorm.DB, bus event.Bus, outboxStore *outbox.Store, recorder audit.Recorder, redisClient *redis.Client, breachChecker *security.HIBPBreachChecker) ([]service.UserServiceOption, error) {
+	opts := []service.UserServiceOption{
+		service.WithUnitOfWork(database.NewUnitOfWork(db)),
+		service.WithEventBus(bus),
+	}
+
+	if outboxStore != nil {
+		opts = append(opts, service.WithOutbox(outboxStore))
+	}
+
+	if recorder != nil {
+		opts = append(opts, service.WithAuditLog(recorder))
+	}
+
+	if breachChecker != nil {
+		opts = append(opts, service.WithPasswordBreachCheck(breachChecker, service.BreachPolicy(cfg.Security.PasswordBreach.Policy)))
 	}
 
-	// Run database migrations
-	migrator := database.NewMigrator(dbConn.DB())
-	if err := migrator.MigrateAll(); err != nil {
-		return nil, fmt.Errorf("failed to run database migrations: %w", err)
+	if cfg.Account != nil {
+		opts = append(opts, service.WithDeletionGracePeriod(cfg.Account.DeletionGracePeriod))
 	}
 
-	// 根据分配器类型初始化ID生成器
-	if allocator != nil {
-		// 使用动态分配器
-		serviceType := getServiceTypeFromConfig(cfg)
-		if err := id.InitDefaultWithAllocator(ctx, serviceType, allocator); err != nil {
-			return nil, fmt.Errorf("failed to init ID generator with allocator: %w", err)
+	if cfg.Organizations != nil && cfg.Organizations.Enabled {
+		opts = append(opts, service.WithOrganizations(repository.NewOrganizationRepository(db)))
+	}
+
+	if cfg.Security != nil && cfg.Security.Lockout != nil && cfg.Security.Lockout.Enabled {
+		lockoutCfg := cfg.Security.Lockout
+		var store user.LoginAttemptStore = security.NewMemoryLoginAttemptStore()
+		if lockoutCfg.Store == "redis" && redisClient != nil {
+			store = security.NewRedisLoginAttemptStore(redisClient)
 		}
+		opts = append(opts, service.WithLoginLockout(store, service.LockoutPolicy{
+			Threshold:   lockoutCfg.Threshold,
+			IPThreshold: lockoutCfg.IPThreshold,
+			Window:      lockoutCfg.Window,
+			Duration:    lockoutCfg.Duration,
+		}))
+	}
+
+	if cfg.Security != nil && cfg.Security.Captcha != nil && cfg.Security.Captcha.Enabled {
+		captchaCfg := cfg.Security.Captcha
+		verifier := security.NewSiteVerifyCaptchaVerifier(captchaCfg, newHTTPClient(cfg, "captcha", captchaCfg.Timeout))
+		opts = append(opts, service.WithCaptcha(verifier, service.CaptchaPolicy{
+			Register:       captchaCfg.Register,
+			LoginThreshold: captchaCfg.LoginThreshold,
+		}))
+	}
+
+	if cfg.Security != nil && cfg.Security.Signup != nil && cfg.Security.Signup.Enabled {
+		signupOpts, err := signupProtectionOptions(cfg.Security.Signup, redisClient)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, signupOpts...)
+	}
+
+	if cfg.Auth != nil && cfg.Auth.Provider == config.AuthProviderLDAP {
+		opts = append(opts, service.WithAuthProvider(security.NewLDAPAuthProvider(cfg.Auth.LDAP), cfg.Auth.LDAP.CreateUsers))
+	}
+
+	return opts, nil
+}
+
+// newMFAService builds the two-factor service from the auth.mfa section
+func newMFAService(cfg *config.MFAConfig, db *gorm.DB, userRepo user.UserRepository, idGen id.Generator, recorder audit.Recorder, log logger.Logger) service.MFAService {
+	var opts []service.MFAServiceOption
+	if recorder != nil {
+		opts = append(opts, service.WithMFAAuditLog(recorder))
+	}
+	return service.NewMFAServiceWithLogger(repository.NewMFARepository(db), userRepo, idGen, service.MFAPolicy{
+		Enforcement:      service.MFAEnforcement(cfg.Enforcement),
+		Issuer:           cfg.Issuer,
+		Skew:             cfg.Skew,
+		MaxAttempts:      cfg.MaxAttempts,
+		LockDuration:     cfg.LockDuration,
+		RecoveryCodes:    cfg.RecoveryCodes,
+		TrustedDeviceTTL: cfg.TrustedDeviceTTL,
+	}, log, opts...)
+}
+
+// newReportingService builds the admin statistics service. Stats are cached
+// in Redis when it is enabled and stats.cache_ttl is set.
+func newReportingService(cfg *config.Config, dbConn *database.Connection, redisClient *redis.Client, log logger.Logger) service.ReportingService {
+	var opts []service.ReportingServiceOption
+	if redisClient != nil && cfg.Stats != nil && cfg.Stats.CacheTTL > 0 {
+		opts = append(opts, service.WithStatsCache(repository.NewRedisStatsCache(redisClient), cfg.Stats.CacheTTL))
+	}
+	return service.NewReportingServiceWithLogger(repository.NewStatsRepository(dbConn.DB(), dbConn.Resolver()), cfg.JWT.Expiry, log, opts...)
+}
+
+// newSignupCounter returns the counters of the registration throttles
+func newSignupCounter(cfg *config.SignupConfig, redisClient *redis.Client) user.SignupCounter {
+	if cfg.Store == "redis" && redisClient != nil {
+		return security.NewRedisSignupCounter(redisClient)
+	}
+	return security.NewMemorySignupCounter()
+}
+
+// handleCheckLimit throttles handle availability checks per client IP, as
+// they reveal which handles are taken. It returns nil unless signup
+// protection enables it.
+func handleCheckLimit(cfg *config.Config, redisClient *redis.Client) gin.HandlerFunc {
+	if cfg.Security == nil || cfg.Security.Signup == nil || !cfg.Security.Signup.Enabled || cfg.Security.Signup.HandleCheckLimit <= 0 {
+		return nil
+	}
+	signup := cfg.Security.Signup
+	return middleware.RateLimit(newSignupCounter(signup, redisClient), "handle_check", signup.HandleCheckLimit, signup.Window)
+}
+
+// signupProtectionOptions builds the registration throttles, disposable
+// domain block list and honeypot of the security.signup section
+func signupProtectionOptions(cfg *config.SignupConfig, redisClient *redis.Client) ([]service.UserServiceOption, error) {
+	var opts []service.UserServiceOption
+	if cfg.DomainLimit > 0 || cfg.IPLimit > 0 {
+		opts = append(opts, service.WithSignupThrottle(newSignupCounter(cfg, redisClient), service.SignupPolicy{
+			DomainLimit:   cfg.DomainLimit,
+			IPLimit:       cfg.IPLimit,
+			Window:        cfg.Window,
+			ExemptDomains: cfg.ExemptDomains,
+		}))
+	}
+
+	if cfg.BlockDisposable {
+		domains := security.NewDisposableDomainList(cfg.DisposableDomains...)
+		if cfg.DisposableDomainsFile != "" {
+			listed, err := security.LoadDisposableDomainFile(cfg.DisposableDomainsFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load disposable email domains: %w", err)
+			}
+			domains.Add(listed...)
+		}
+		opts = append(opts, service.WithDisposableEmailBlock(domains))
+	}
+
+	if cfg.Honeypot {
+		opts = append(opts, service.WithSignupHoneypot())
+	}
+	return opts, nil
+}
+
+// newBreachChecker returns the breached-password checker, or nil when the
+// check is disabled
+func newBreachChecker(cfg *config.Config) *security.HIBPBreachChecker {
+	if cfg.Security == nil || cfg.Security.PasswordBreach == nil || !cfg.Security.PasswordBreach.Enabled {
+		return nil
+	}
+	var opts []security.BreachCheckerOption
+	if policy, ok := retryPolicy(cfg); ok {
+		opts = append(opts, security.WithRetryPolicy(policy))
+	}
+	// The checker retries by itself, so its circuit breaker counts one
+	// failure per call whose retries are used up
+	clientCfg := newHTTPClientConfig(cfg, "hibp", cfg.Security.PasswordBreach.Timeout)
+	clientCfg.Retry = retry.Policy{MaxAttempts: 1}
+	return security.NewHIBPBreachChecker(cfg.Security.PasswordBreach, httpclient.New(clientCfg), opts...)
+}
+
+// newBreaker builds an instrumented circuit breaker for the named
+// dependency, or returns nil when circuit breakers are disabled
+func newBreaker(cfg *config.Config, name string, log logger.Logger) *circuitbreaker.Breaker {
+	if cfg.CircuitBreaker == nil || !cfg.CircuitBreaker.Enabled {
+		return nil
+	}
+	return circuitbreaker.New(metrics.InstrumentBreaker(circuitbreaker.Settings{
+		Name:             name,
+		FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+		OpenTimeout:      cfg.CircuitBreaker.OpenTimeout,
+		HalfOpenMaxCalls: cfg.CircuitBreaker.HalfOpenMaxCalls,
+		OnStateChange: func(name string, from, to circuitbreaker.State) {
+			log.Warn(context.Background(), "circuit breaker changed state", "breaker", name, "from", from.String(), "to", to.String())
+		},
+	}))
+}
+
+// retryPolicy builds the retry policy for transient failures, or reports
+// false when retries are disabled
+func retryPolicy(cfg *config.Config) (retry.Policy, bool) {
+	if cfg.Retry == nil || !cfg.Retry.Enabled {
+		return retry.Policy{}, false
+	}
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = cfg.Retry.MaxAttempts
+	policy.BaseDelay = cfg.Retry.BaseDelay
+	policy.MaxDelay = cfg.Retry.MaxDelay
+	policy.Jitter = cfg.Retry.Jitter
+	return policy, true
+}
+
+// newAdminUIHandler serves the embedded admin UI, or the directory
+// configured in its place. It returns nil while the UI is disabled.
+func newAdminUIHandler(cfg *config.AdminUIConfig) (*http.SPAHandler, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	files := adminui.FS()
+	if cfg.Dir != "" {
+		files = os.DirFS(cfg.Dir)
+	}
+	return http.NewSPAHandler(files, http.SPAOptions{
+		CacheMaxAge:           cfg.CacheMaxAge,
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+	})
+}
+
+// newHTTPClient builds an instrumented client for calls to the external
+// service name. Idempotent calls are retried with the retry section's
+// policy; a zero timeout uses the client default.
+func newHTTPClient(cfg *config.Config, name string, timeout time.Duration) *nethttp.Client {
+	return httpclient.New(newHTTPClientConfig(cfg, name, timeout))
+}
+
+// newHTTPClientConfig returns the configuration newHTTPClient uses, for
+// clients that need further settings
+func newHTTPClientConfig(cfg *config.Config, name string, timeout time.Duration) httpclient.Config {
+	clientCfg := httpclient.DefaultConfig(name)
+	clientCfg.Retry = retry.Policy{MaxAttempts: 1}
+	if policy, ok := retryPolicy(cfg); ok {
+		clientCfg.Retry = policy
+	}
+	if timeout > 0 {
+		clientCfg.Timeout = timeout
+	}
+	return metrics.InstrumentHTTPClient(clientCfg)
+}
+
+// newObjectStorage creates the configured object storage. The local
+// backend is also returned as such, since the application serves its
+// objects.
+func newObjectStorage(cfg *config.Config) (storage.ObjectStorage, *storage.LocalStorage, error) {
+	sc := cfg.Storage
+	if sc.Backend == config.StorageBackendS3 {
+		store, err := storage.NewS3Storage(storage.S3Config{
+			Endpoint:        sc.Endpoint.String(),
+			Region:          sc.Region,
+			Bucket:          sc.Bucket,
+			AccessKeyID:     sc.AccessKeyID,
+			SecretAccessKey: sc.SecretAccessKey,
+			SessionToken:    sc.SessionToken,
+			PathStyle:       sc.PathStyle,
+		}, newHTTPClient(cfg, "storage", 0))
+		return store, nil, err
+	}
+	store, err := storage.NewLocalStorage(sc.Dir, http.ObjectPathPrefix, []byte(sc.SigningKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, store, nil
+}
+
+// newRedisClient returns a shared Redis client, or nil when Redis is disabled
+func newRedisClient(cfg *config.Config, breaker *circuitbreaker.Breaker) *redis.Client {
+	if cfg.External == nil || cfg.External.Redis == nil || !cfg.External.Redis.Enabled {
+		return nil
+	}
+	redisCfg := cfg.External.Redis
+	return redis.NewClient(redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", redisCfg.Host, redisCfg.Port),
+		Password: redisCfg.Password,
+		DB:       redisCfg.Database,
+		Breaker:  breaker,
+	})
+}
+
+// healthChecks registers readiness checks for configured dependencies. The
+// primary database is critical, unless its monitor is running and reports
+// the outage as degraded while it waits to reconnect; replica, Redis and
+// etcd outages only degrade the service. A lost node ID lease is critical because ID generation is
+// paused until a new node ID is allocated.
+func healthChecks(cfg *config.Config, dbConn *database.Connection, allocator id.NodeIDAllocator, idGen id.Generator) *health.Registry {
+	registry := health.NewRegistry()
+	driver := dbConn.Driver().Name()
+	if monitor := dbConn.Monitor(); monitor != nil {
+		registry.Register(driver, monitor)
 	} else {
-		// 使用配置文件中的ID配置
-		if err := id.InitDefaultForService(getServiceTypeFromConfig(cfg), cfg.ID.InstanceID); err != nil {
-			return nil, fmt.Errorf("failed to init distributed ID generator: %w", err)
+		registry.Register(driver, health.CheckerFunc(dbConn.Ping))
+	}
+	for _, replica := range dbConn.Resolver().Replicas() {
+		// Reads fail over to the primary, so a lost replica only degrades
+		registry.Register(driver+"_replica:"+replica.Name, health.CheckerFunc(replica.Ping), health.NonCritical())
+	}
+
+	if cfg.External != nil && cfg.External.Redis != nil && cfg.External.Redis.Enabled {
+		redisCfg := cfg.External.Redis
+		addr := fmt.Sprintf("%s:%d", redisCfg.Host, redisCfg.Port)
+		registry.Register("redis", health.RedisChecker(addr, redisCfg.Password), health.NonCritical())
+	}
+
+	if etcdAllocator, ok := allocator.(*id.EtcdAllocator); ok {
+		registry.Register("etcd", health.CheckerFunc(etcdAllocator.Ping), health.NonCritical())
+	}
+	if leaseAllocator, ok := allocator.(*id.KubernetesLeaseAllocator); ok {
+		registry.Register("kubernetes", health.CheckerFunc(leaseAllocator.Ping), health.NonCritical())
+	}
+	if _, ok := allocator.(id.LeaseWatcher); ok {
+		if gen, ok := idGen.(interface{ LeaseError() error }); ok {
+			registry.Register("node_id", health.CheckerFunc(func(context.Context) error {
+				return gen.LeaseError()
+			}))
 		}
 	}
 
-	// 后续组件可以直接使用 id.Generate()
-	userRepo := repository.NewUserRepository(dbConn.DB())
-	idGen := id.GetDefault()
-	userService := service.NewUserService(userRepo, idGen)
-	userHandler := http.NewUserHandler(userService)
+	return registry
+}
 
-	// Initialize JWT and Auth services
-	tokenService := jwt.NewTokenService(cfg.JWT.SigningKey, cfg.JWT.Expiry)
-	authService := service.NewAuthService(userService, tokenService)
-	authHandler := http.NewAuthHandler(authService)
+// RegisterHealthCheck adds a custom readiness check. Checks are critical
+// unless health.NonCritical is passed.
+func (c *Container) RegisterHealthCheck(name string, checker health.Checker, opts ...health.Option) {
+	c.health.Register(name, checker, opts...)
+}
 
-	// Initialize auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(authService)
+// adminBootstrapSettings extracts the initial admin identity from configuration
+func adminBootstrapSettings(cfg *config.Config) service.AdminBootstrapSettings {
+	if cfg.Bootstrap == nil {
+		return service.AdminBootstrapSettings{}
+	}
+	return service.AdminBootstrapSettings{
+		Email:      cfg.Bootstrap.AdminEmail,
+		Name:       cfg.Bootstrap.AdminName,
+		SetupToken: cfg.Bootstrap.SetupToken,
+	}
+}
 
-	appLogger.Info(ctx, "container initialized successfully", "service_name", cfg.App.Name, "version", cfg.App.Version)
+// userEventTypes lists the user events the outbox relay decodes back into
+// their concrete types and that are published to the message broker
+func userEventTypes() []event.Event {
+	return []event.Event{
+		user.UserRegistered{},
+		user.UserEmailChanged{},
+		user.UserNameChanged{},
+		user.UserHandleChanged{},
+		user.UserPreferencesChanged{},
+		user.UserDeleted{},
+		user.UserPasswordReset{},
+		user.UserAdminBootstrapped{},
+		user.UserSuspended{},
+		user.UserReactivated{},
+		user.UserDeactivated{},
+		user.UserDeletionScheduled{},
+		user.UserDeletionCancelled{},
+	}
+}
 
-	return &Container{
-		Config:         cfg,
-		UserHandler:    userHandler,
-		AuthHandler:    authHandler,
-		AuthMiddleware: authMiddleware,
-		Database:       dbConn,
-		Logger:         appLogger,
-		nodeAllocator:  allocator,
-	}, nil
+// registerWebhookSubscribers queues webhook deliveries of user events.
+// Relayed events keep their outbox idempotency key as the webhook event ID,
+// so receivers can discard events relayed twice.
+func registerWebhookSubscribers(bus event.Bus, webhookService service.WebhookService) {
+	queue := func(ctx context.Context, e event.Event) error {
+		eventID, _ := outbox.IdempotencyKey(ctx)
+		return webhookService.HandleUserEvent(ctx, eventID, e)
+	}
+	for _, e := range userEventTypes() {
+		bus.Subscribe(e.EventName(), queue)
+	}
 }
 
-// createNodeIDAllocator 创建节点ID分配器
-func createNodeIDAllocator(ctx context.Context, cfg *config.Config) id.NodeIDAllocator {
-	// 检查是否配置了etcd
-	etcdEndpoints := os.Getenv("ETCD_ENDPOINTS")
-	if etcdEndpoints != "" {
-		endpoints := strings.Split(etcdEndpoints, ",")
+// registerNotificationSubscribers notifies users of their account's events.
+// Relayed events keep their outbox idempotency key as the notification ID,
+// so an event relayed twice notifies once.
+func registerNotificationSubscribers(bus event.Bus, notificationService service.NotificationService) {
+	notify := func(ctx context.Context, e event.Event) error {
+		eventID, _ := outbox.IdempotencyKey(ctx)
+		return notificationService.HandleUserEvent(ctx, eventID, e)
+	}
+	for _, e := range userEventTypes() {
+		bus.Subscribe(e.EventName(), notify)
+	}
+}
+
+// serviceLogger returns the logger of an application service component,
+// derived from the container's base logger rather than the global one
+func serviceLogger(base logger.Logger, component string) logger.Logger {
+	return base.WithLayer("application").WithComponent(component)
+}
+
+// registerVerificationSubscribers emails a verification link to users who
+// register or change their email address
+func registerVerificationSubscribers(bus event.Bus, verification service.EmailVerificationService) {
+	send := func(ctx context.Context, e event.Event) error {
+		return verification.Send(ctx, e.AggregateID())
+	}
+	bus.Subscribe(user.EventUserRegistered, send)
+	bus.Subscribe(user.EventUserEmailChanged, send)
+}
+
+// registerEventSubscribers wires domain event subscribers. Features that react
+// to user lifecycle changes (welcome emails, audit trails, ...) subscribe here.
+// recorder may be nil when audit logging is disabled, and mail when email is.
+func registerEventSubscribers(bus event.Bus, log logger.Logger, recorder audit.Recorder, mail service.AccountMailService) {
+	logEvent := func(ctx context.Context, e event.Event) error {
+		log.Info(ctx, "domain event", "event", e.EventName(), "aggregate_id", e.AggregateID())
+		return nil
+	}
+
+	bus.Subscribe(user.EventUserRegistered, logEvent)
+	bus.Subscribe(user.EventUserEmailChanged, logEvent)
+	bus.Subscribe(user.EventUserNameChanged, logEvent)
+	bus.Subscribe(user.EventUserHandleChanged, logEvent)
+	bus.Subscribe(user.EventUserDeleted, logEvent)
+	bus.Subscribe(user.EventUserPasswordReset, logEvent)
+	bus.Subscribe(user.EventUserSuspended, logEvent)
+	bus.Subscribe(user.EventUserReactivated, logEvent)
+	bus.Subscribe(user.EventUserDeactivated, logEvent)
+	bus.Subscribe(user.EventUserDeletionScheduled, logEvent)
+	bus.Subscribe(user.EventUserDeletionCancelled, logEvent)
+
+	if mail != nil {
+		bus.Subscribe(user.EventUserRegistered, func(ctx context.Context, e event.Event) error {
+			if registered, ok := e.(user.UserRegistered); ok {
+				return mail.SendWelcome(ctx, registered.Email, registered.Name)
+			}
+			return nil
+		})
+		bus.Subscribe(user.EventUserPasswordReset, func(ctx context.Context, e event.Event) error {
+			if reset, ok := e.(user.UserPasswordReset); ok {
+				return mail.SendPasswordReset(ctx, reset.Email, reset.Name)
+			}
+			return nil
+		})
+		bus.Subscribe(user.EventUserDeletionScheduled, func(ctx context.Context, e event.Event) error {
+			if scheduled, ok := e.(user.UserDeletionScheduled); ok {
+				return mail.SendDeletionScheduled(ctx, scheduled.Email, scheduled.Name, scheduled.DeleteAt, scheduled.LocalePrefs)
+			}
+			return nil
+		})
+		bus.Subscribe(user.EventUserDeletionCancelled, func(ctx context.Context, e event.Event) error {
+			if cancelled, ok := e.(user.UserDeletionCancelled); ok {
+				return mail.SendDeletionCancelled(ctx, cancelled.Email, cancelled.Name)
+			}
+			return nil
+		})
+		// Only deletions the user requested are confirmed; admins removing
+		// an account notify its owner themselves
+		bus.Subscribe(user.EventUserDeleted, func(ctx context.Context, e event.Event) error {
+			if deleted, ok := e.(user.UserDeleted); ok && deleted.Scheduled {
+				return mail.SendAccountDeleted(ctx, deleted.Email, deleted.Name)
+			}
+			return nil
+		})
+	}
+
+	// Privilege grants are always kept in the log, regardless of level
+	bus.Subscribe(user.EventUserAdminBootstrapped, func(ctx context.Context, e event.Event) error {
+		email := ""
+		if bootstrapped, ok := e.(user.UserAdminBootstrapped); ok {
+			email = bootstrapped.Email
+		}
+		log.Warn(ctx, "audit: initial admin bootstrapped", "event", e.EventName(), "user_id", e.AggregateID(), "email", email, "occurred_at", e.OccurredAt())
+		if recorder != nil {
+			recorder.Record(ctx, &audit.Entry{
+				Action:     audit.ActionAdminBootstrap,
+				EntityType: "user",
+				EntityID:   e.AggregateID(),
+				Changes: audit.ChangeSet{
+					"email": {To: email},
+					"role":  {To: user.RoleAdmin},
+				},
+				CreatedAt: e.OccurredAt(),
+			})
+		}
+		return nil
+	})
+}
+
+// ApplyConfigChange applies hot-reloadable configuration to container-owned
+// components. JWT keys are swapped in place; other sections wired at
+// construction time (database, ID generation) only take effect after a restart.
+func (c *Container) ApplyConfigChange(event config.ChangeEvent) {
+	ctx := context.Background()
+
+	if event.Has(config.SectionLog) && event.Previous.Log.Level != event.Current.Log.Level {
+		if err := logger.SetLevel(event.Current.Log.Level); err != nil {
+			c.log.Warn(ctx, "failed to apply reloaded log level", "error", err)
+		} else {
+			c.log.Info(ctx, "log level changed", "old_level", event.Previous.Log.Level, "new_level", event.Current.Log.Level)
+		}
+	}
+
+	if event.Has(config.SectionJWT) {
+		c.applyJWTChange(ctx, event.Previous.JWT, event.Current.JWT)
+	}
+
+	for _, section := range []config.Section{config.SectionDatabase, config.SectionID} {
+		if event.Has(section) {
+			c.log.Warn(ctx, "config section changed but requires restart to take effect", "section", section)
+		}
+	}
+}
+
+// applyJWTChange swaps in reloaded signing keys. Tokens signed by keys that
+// remain configured keep validating, which makes rotation seamless.
+func (c *Container) applyJWTChange(ctx context.Context, previous, current *config.JWTConfig) {
+	if c.keySet != nil {
+		activeKeyID, keys, err := jwtKeys(current)
+		if err == nil {
+			err = c.keySet.Replace(activeKeyID, keys...)
+		}
+		if err != nil {
+			c.log.Warn(ctx, "failed to apply reloaded jwt keys, keeping previous", "error", err)
+		} else {
+			c.log.Info(ctx, "jwt keys reloaded", "active_key_id", activeKeyID, "keys", len(keys))
+		}
+	}
+
+	if previous.Expiry != current.Expiry {
+		c.log.Warn(ctx, "jwt expiry changed but requires restart to take effect", "old_expiry", previous.Expiry, "new_expiry", current.Expiry)
+	}
+}
+
+// jwtKeys loads the configured token keys. Without explicit keys the
+// signing_key signs HS256 tokens without a key ID, as before key rotation
+// support; with accept_legacy_tokens it stays registered to validate them.
+func jwtKeys(cfg *config.JWTConfig) (string, []*jwt.Key, error) {
+	if len(cfg.Keys) == 0 {
+		return "", []*jwt.Key{jwt.NewHMACKey("", []byte(cfg.SigningKey))}, nil
+	}
+
+	keys := make([]*jwt.Key, 0, len(cfg.Keys)+1)
+	if cfg.AcceptLegacyTokens {
+		keys = append(keys, jwt.NewHMACKey("", []byte(cfg.SigningKey)))
+	}
+	for _, kc := range cfg.Keys {
+		key, err := loadJWTKey(kc)
+		if err != nil {
+			return "", nil, err
+		}
+		if key.Algorithm != kc.Algorithm {
+			return "", nil, fmt.Errorf("key %q is a %s key but configured as %s", kc.ID, key.Algorithm, kc.Algorithm)
+		}
+		keys = append(keys, key)
+	}
+	return cfg.ActiveKeyID, keys, nil
+}
+
+// loadJWTKey builds one key from inline PEM or a PEM file. A private key
+// wins over a public key when both are given.
+func loadJWTKey(kc config.JWTKeyConfig) (*jwt.Key, error) {
+	if kc.Algorithm == jwt.AlgHS256 {
+		return jwt.NewHMACKey(kc.ID, []byte(kc.Secret)), nil
+	}
+
+	if kc.PrivateKey != "" || kc.PrivateKeyFile != "" {
+		data, err := keyMaterial(kc.PrivateKey, kc.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kc.ID, err)
+		}
+		return jwt.ParsePrivateKeyPEM(kc.ID, data)
+	}
+
+	data, err := keyMaterial(kc.PublicKey, kc.PublicKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", kc.ID, err)
+	}
+	return jwt.ParsePublicKeyPEM(kc.ID, data)
+}
+
+func keyMaterial(inline, file string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	return os.ReadFile(file)
+}
 
+// createNodeIDAllocator 根据 id.allocator 创建节点ID分配器，返回nil时使用配置中的静态ID
+func createNodeIDAllocator(ctx context.Context, cfg *config.Config, etcdBreaker *circuitbreaker.Breaker, redisClient *redis.Client, log logger.Logger) id.NodeIDAllocator {
+	kind, etcdEndpoints := cfg.ID.Allocator, cfg.ID.EtcdEndpoints
+	if kind == "" {
+		kind, etcdEndpoints = nodeIDAllocatorFromEnv(ctx, log)
+	}
+
+	switch kind {
+	case config.IDAllocatorEtcd:
 		// 创建etcd分配器
-		allocator, err := id.NewEtcdAllocator(endpoints)
+		var opts []id.EtcdOption
+		if etcdBreaker != nil {
+			opts = append(opts, id.WithCircuitBreaker(etcdBreaker))
+		}
+		if cfg.ID.LeaseTimeout > 0 && cfg.ID.RenewInterval > 0 {
+			opts = append(opts, id.WithLeaseTimeout(cfg.ID.LeaseTimeout), id.WithRenewInterval(cfg.ID.RenewInterval))
+		}
+		allocator, err := id.NewEtcdAllocator(etcdEndpoints, opts...)
 		if err != nil {
-			fmt.Printf("Failed to create etcd allocator: %v, falling back to static allocation\n", err)
+			log.Warn(ctx, "failed to create node ID allocator, falling back to static allocation", "allocator", kind, "error", err)
 			return nil
 		}
 
-		fmt.Println("Using etcd-based dynamic node ID allocation")
+		log.Info(ctx, "using etcd-based dynamic node ID allocation", "allocator", kind)
 		return allocator
-	}
+	case config.IDAllocatorRedis:
+		// 复用external.redis的连接
+		if redisClient == nil {
+			log.Warn(ctx, "redis allocator requires external.redis to be enabled, falling back to static allocation", "allocator", kind)
+			return nil
+		}
+		opts := []id.RedisOption{id.WithRedisKeyPrefix(cfg.ID.RedisKeyPrefix)}
+		if cfg.ID.LeaseTimeout > 0 && cfg.ID.RenewInterval > 0 {
+			opts = append(opts, id.WithRedisLeaseDuration(cfg.ID.LeaseTimeout, cfg.ID.RenewInterval))
+		}
+		allocator, err := id.NewRedisAllocator(redisClient, opts...)
+		if err != nil {
+			log.Warn(ctx, "failed to create node ID allocator, falling back to static allocation", "allocator", kind, "error", err)
+			return nil
+		}
 
-	// 检查是否使用机器特征分配
-	if os.Getenv("USE_MACHINE_BASED_ID") == "true" {
-		allocator, err := id.NewMachineBasedAllocator()
+		log.Info(ctx, "using redis-based dynamic node ID allocation", "allocator", kind, "key_prefix", cfg.ID.RedisKeyPrefix)
+		return allocator
+	case config.IDAllocatorKubernetesOrdinal:
+		allocator, err := id.NewKubernetesOrdinalAllocator()
 		if err != nil {
-			fmt.Printf("Failed to create machine-based allocator: %v, falling back to static allocation\n", err)
+			log.Warn(ctx, "failed to create node ID allocator, falling back to static allocation", "allocator", kind, "error", err)
 			return nil
 		}
 
-		fmt.Println("Using machine-based node ID allocation")
+		log.Info(ctx, "using kubernetes pod ordinal node ID allocation", "allocator", kind)
 		return allocator
-	}
+	case config.IDAllocatorKubernetesLease:
+		var opts []id.KubernetesOption
+		if cfg.ID.LeaseTimeout > 0 && cfg.ID.RenewInterval > 0 {
+			opts = append(opts, id.WithKubernetesLeaseDuration(cfg.ID.LeaseTimeout, cfg.ID.RenewInterval))
+		}
+		allocator, err := id.NewKubernetesLeaseAllocator(opts...)
+		if err != nil {
+			log.Warn(ctx, "failed to create node ID allocator, falling back to static allocation", "allocator", kind, "error", err)
+			return nil
+		}
 
-	// 检查是否使用后备分配器
-	if os.Getenv("USE_FALLBACK_ALLOCATOR") == "true" {
+		log.Info(ctx, "using kubernetes lease-based dynamic node ID allocation", "allocator", kind)
+		return allocator
+	case config.IDAllocatorMachine:
+		allocator, err := id.NewMachineBasedAllocator()
+		if err != nil {
+			log.Warn(ctx, "failed to create node ID allocator, falling back to static allocation", "allocator", kind, "error", err)
+			return nil
+		}
+
+		log.Info(ctx, "using machine-based node ID allocation", "allocator", kind)
+		return allocator
+	case config.IDAllocatorFallback:
 		allocator, err := id.NewFallbackAllocator()
 		if err != nil {
-			fmt.Printf("Failed to create fallback allocator: %v, falling back to static allocation\n", err)
+			log.Warn(ctx, "failed to create node ID allocator, falling back to static allocation", "allocator", kind, "error", err)
 			return nil
 		}
 
-		fmt.Println("Using fallback node ID allocation")
+		log.Info(ctx, "using fallback node ID allocation", "allocator", kind)
 		return allocator
 	}
 
-	fmt.Println("Using static node ID allocation from environment variables")
+	log.Info(ctx, "using static node ID allocation from configuration", "allocator", config.IDAllocatorStatic, "instance_id", cfg.ID.InstanceID)
 	return nil
 }
 
+// nodeIDAllocatorFromEnv 未配置 id.allocator 时按旧的环境变量选择分配器
+func nodeIDAllocatorFromEnv(ctx context.Context, log logger.Logger) (string, []string) {
+	// 检查是否配置了etcd
+	if etcdEndpoints := os.Getenv("ETCD_ENDPOINTS"); etcdEndpoints != "" {
+		return config.IDAllocatorEtcd, strings.Split(etcdEndpoints, ",")
+	}
+
+	// 检查是否使用Kubernetes分配：ordinal取StatefulSet序号，lease使用coordination API
+	switch mode := os.Getenv("K8S_NODE_ID"); mode {
+	case "":
+	case "ordinal":
+		return config.IDAllocatorKubernetesOrdinal, nil
+	case "lease":
+		return config.IDAllocatorKubernetesLease, nil
+	default:
+		log.Warn(ctx, "unknown K8S_NODE_ID, expected ordinal or lease", "K8S_NODE_ID", mode)
+	}
+
+	// 检查是否使用机器特征分配
+	if os.Getenv("USE_MACHINE_BASED_ID") == "true" {
+		return config.IDAllocatorMachine, nil
+	}
+
+	// 检查是否使用后备分配器
+	if os.Getenv("USE_FALLBACK_ALLOCATOR") == "true" {
+		return config.IDAllocatorFallback, nil
+	}
+
+	return config.IDAllocatorStatic, nil
+}
+
 // getServiceTypeFromConfig 从配置获取服务类型
 func getServiceTypeFromConfig(cfg *config.Config) id.ServiceType {
 	serviceType, err := id.ParseServiceType(cfg.ID.ServiceType)
@@ -264,16 +1556,93 @@ func getServiceTypeFromEnv() id.ServiceType {
 	return serviceType
 }
 
+// OnShutdown registers a hook that stops a component in phase, e.g. the
+// HTTP server in PhaseServer. Zero timeout uses DefaultStopTimeout.
+func (c *Container) OnShutdown(phase Phase, name string, timeout time.Duration, stop ShutdownHook) {
+	if c.lifecycle == nil {
+		c.lifecycle = NewLifecycle(c.log)
+	}
+	c.lifecycle.OnShutdown(phase, name, timeout, stop)
+}
+
+// Shutdown stops every component in dependency order: HTTP server,
+// workers, event delivery, database, node ID release and shared clients.
+// It runs once; later calls return the first result.
+func (c *Container) Shutdown(ctx context.Context) error {
+	if c.lifecycle == nil {
+		return nil
+	}
+	return c.lifecycle.Shutdown(ctx)
+}
+
 // Close 优雅关闭容器，释放资源
 func (c *Container) Close() error {
-	if c.nodeAllocator != nil {
-		// 如果是etcd分配器，需要关闭连接
-		if etcdAllocator, ok := c.nodeAllocator.(*id.EtcdAllocator); ok {
-			return etcdAllocator.Close()
-		}
-		// 其他分配器也可以添加关闭逻辑
+	return c.Shutdown(context.Background())
+}
+
+// registerShutdownHooks registers the components the container built
+func (c *Container) registerShutdownHooks() {
+	if c.scheduler != nil {
+		// Running tasks are cancelled; each deletes in one statement, so a
+		// cut-off run is simply redone next time
+		c.OnShutdown(PhaseWorkers, "scheduler", 0, c.scheduler.Stop)
+	}
+	if c.jobWorker != nil {
+		// Jobs cut off here run again once their lease runs out
+		c.OnShutdown(PhaseWorkers, "job_worker", 0, c.jobWorker.Stop)
+	}
+
+	if c.webhooks != nil {
+		// Deliveries cut off here are sent again once they are due
+		c.OnShutdown(PhaseWorkers, "webhook_dispatcher", 0, c.webhooks.Stop)
+	}
+
+	if c.outboxRelay != nil {
+		// Stop relaying before the bus stops accepting events
+		c.OnShutdown(PhaseEvents, "outbox_relay", 0, c.outboxRelay.Stop)
+	}
+	if c.eventBus != nil {
+		// Let in-flight subscribers finish before tearing down dependencies
+		c.OnShutdown(PhaseEvents, "event_bus", 0, c.eventBus.Close)
+	}
+	if c.searchSyncer != nil {
+		// After the relay and bus, so the users they queued are synced
+		c.OnShutdown(PhaseEvents, "search_syncer", 0, c.searchSyncer.Stop)
+	}
+	if c.auditRecorder != nil {
+		// Flush buffered entries after the bus so subscriber-recorded entries are kept
+		c.OnShutdown(PhaseEvents, "audit_recorder", 0, c.auditRecorder.Close)
+	}
+	if c.broker != nil {
+		c.OnShutdown(PhaseEvents, "message_broker", 0, func(context.Context) error {
+			return c.broker.Close()
+		})
+	}
+
+	if c.db != nil {
+		c.OnShutdown(PhaseStorage, "database", 0, func(context.Context) error {
+			return c.db.Close()
+		})
+	}
+
+	// etcd、Redis和Kubernetes Lease分配器需要释放节点ID，Redis分配器依赖c.redisClient，需先释放
+	if closer, ok := c.nodeAllocator.(interface{ Close() error }); ok {
+		c.OnShutdown(PhaseRelease, "node_id_allocator", 0, func(context.Context) error {
+			return closer.Close()
+		})
+	}
+
+	if c.redisClient != nil {
+		c.OnShutdown(PhaseClients, "redis", 0, func(context.Context) error {
+			return c.redisClient.Close()
+		})
+	}
+
+	if c.cfg != nil && c.cfg.Log.Buffered() {
+		// Last, so entries logged while stopping the rest are written;
+		// later entries are written synchronously
+		c.OnShutdown(PhaseClients, "logger", 0, logger.Close)
 	}
-	return nil
 }
 
 // NewContainerForService 为指定服务类型创建容器（静态分配方式）
@@ -290,7 +1659,7 @@ func NewContainerForService(db *gorm.DB, serviceType id.ServiceType, instanceID
 	userHandler := http.NewUserHandler(userService)
 
 	return &Container{
-		UserHandler:   userHandler,
+		handlers:      Handlers{User: userHandler},
 		nodeAllocator: nil, // 静态分配不需要分配器
 	}
 }
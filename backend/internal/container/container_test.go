@@ -0,0 +1,186 @@
+package container
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// testOptions builds the container on an in-memory SQLite database with a
+// fixed node ID, so no Postgres or allocator is needed
+func testOptions(t *testing.T) []Option {
+	cfg := config.DefaultConfig()
+	cfg.App.Environment = "testing"
+	cfg.Database.AutoMigrate = true
+	cfg.JWT.SigningKey = "container-test-signing-key-0123456789"
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	gen, err := id.NewSnowflakeGenerator(7)
+	require.NoError(t, err)
+
+	return []Option{WithConfig(cfg), WithDatabase(database.NewConnectionFromDB(db)), WithIDGenerator(gen)}
+}
+
+func TestNew_WithProviders(t *testing.T) {
+	ctx := context.Background()
+	c, err := New(ctx, testOptions(t)...)
+	require.NoError(t, err)
+
+	assert.Equal(t, "testing", c.Config().App.Environment)
+	assert.NotNil(t, c.Handlers().User)
+	assert.Nil(t, c.Handlers().Job)
+
+	u, err := c.UserService().Register(ctx, "ada@example.com", "Ada", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+
+	var count int64
+	require.NoError(t, c.Database().DB().Model(&user.User{}).Where("id = ?", u.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// Shutdown closes the provided database
+	require.NoError(t, c.Shutdown(ctx))
+	assert.Error(t, c.Database().Ping(ctx))
+}
+
+func TestNew_WithUserRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockUserRepository(ctrl)
+	repo.EXPECT().GetByID(gomock.Any(), "42").Return(&user.User{ID: "42", Name: "Ada"}, nil)
+
+	c, err := New(context.Background(), append(testOptions(t), WithUserRepository(repo))...)
+	require.NoError(t, err)
+	defer c.Close()
+
+	u, err := c.UserService().GetProfile(context.Background(), "42")
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", u.Name)
+}
+
+func TestNew_WithFakes(t *testing.T) {
+	ctx := context.Background()
+	repo := fake.NewUserRepository()
+	mail := fake.NewMailer()
+
+	opts := append(testOptions(t),
+		WithUserRepository(repo),
+		WithIDGenerator(fake.NewIDGenerator(1000)),
+		WithTokenService(fake.NewTokenService()),
+		WithMailer(mail),
+	)
+	c, err := New(ctx, opts...)
+	require.NoError(t, err)
+	defer c.Close()
+
+	u, err := c.UserService().Register(ctx, "ada@example.com", "Ada", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+	assert.Equal(t, "1000", u.ID)
+	assert.Equal(t, 1, repo.Len())
+
+	// The welcome and verification emails are sent by event subscribers
+	require.Eventually(t, func() bool {
+		return len(mail.SentTo("ada@example.com")) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"Welcome to Wonder, Ada", "Confirm your email address"}, subjects(mail.SentTo("ada@example.com")))
+
+	// A new address is sent a link of its own
+	_, err = c.UserService().UpdateProfile(ctx, u.ID, &user.UpdateProfileRequest{Email: ptr("lovelace@example.com")})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return slices.Contains(subjects(mail.SentTo("lovelace@example.com")), "Confirm your email address")
+	}, time.Second, 10*time.Millisecond)
+
+	// Fake tokens authenticate without logging in
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/me", c.AuthMiddleware().RequireAuth(), func(ctx *gin.Context) {
+		ctx.Status(nethttp.StatusNoContent)
+	})
+	req := httptest.NewRequest(nethttp.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+fake.Token(u.ID, ""))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, nethttp.StatusNoContent, rec.Code)
+}
+
+func subjects(messages []mailer.Message) []string {
+	var s []string
+	for _, msg := range messages {
+		s = append(s, msg.Subject)
+	}
+	return s
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestNew_WithLogger(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "wonder.log")
+	log := logger.NewLoggerWithConfig(logger.LogConfig{Level: "info", Format: "json", Output: "file", FilePath: path})
+
+	c, err := New(ctx, append(testOptions(t), WithLogger(log))...)
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.UserService().Register(ctx, "ada@example.com", "Ada", "Str0ng!Passw0rd")
+	require.NoError(t, err)
+
+	// Services log with the injected logger, not the global one
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"component":"container"`)
+	assert.Contains(t, string(data), `"component":"user_service"`)
+}
+
+func TestNew_ConfigProviderError(t *testing.T) {
+	loadErr := errors.New("no config")
+	_, err := New(context.Background(), WithConfigProvider(func() (*config.Config, error) {
+		return nil, loadErr
+	}))
+	assert.ErrorIs(t, err, loadErr)
+}
+
+func TestNewScheduler_PurgesWithoutScheduler(t *testing.T) {
+	logger.Initialize()
+	cfg := config.DefaultSchedulerConfig()
+	cfg.Enabled = false
+
+	// Expired data exports and trusted devices go with the account purge
+	scheduler, err := newScheduler(cfg, nil, nil, nil, nil, nil, logger.Get())
+	require.NoError(t, err)
+	require.NotNil(t, scheduler)
+	assert.Equal(t, []string{"account_purge"}, scheduler.Tasks())
+
+	cfg.AccountPurgeSchedule = ""
+	scheduler, err = newScheduler(cfg, nil, nil, nil, nil, nil, logger.Get())
+	require.NoError(t, err)
+	assert.Nil(t, scheduler)
+}
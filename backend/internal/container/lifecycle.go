@@ -0,0 +1,163 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// Phase orders shutdown. Components stop phase by phase, so nothing is
+// torn down while a component of an earlier phase may still use it.
+type Phase int
+
+const (
+	// PhaseServer stops accepting requests and drains in-flight ones
+	PhaseServer Phase = iota
+	// PhaseWorkers stops background work: scheduled tasks and jobs
+	PhaseWorkers
+	// PhaseEvents drains event delivery: outbox relay, event bus, search
+	// sync, audit recorder and message broker
+	PhaseEvents
+	// PhaseStorage closes the database
+	PhaseStorage
+	// PhaseRelease gives back leased resources such as the node ID
+	PhaseRelease
+	// PhaseClients closes shared clients the earlier phases use, e.g. Redis
+	PhaseClients
+)
+
+var phaseNames = map[Phase]string{
+	PhaseServer:  "server",
+	PhaseWorkers: "workers",
+	PhaseEvents:  "events",
+	PhaseStorage: "storage",
+	PhaseRelease: "release",
+	PhaseClients: "clients",
+}
+
+func (p Phase) String() string {
+	if name, ok := phaseNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("phase(%d)", int(p))
+}
+
+// DefaultStopTimeout bounds a shutdown hook registered without a timeout
+const DefaultStopTimeout = 5 * time.Second
+
+// ShutdownHook stops one component. It should return once ctx is done.
+type ShutdownHook func(ctx context.Context) error
+
+type shutdownHook struct {
+	phase   Phase
+	name    string
+	timeout time.Duration
+	stop    ShutdownHook
+}
+
+// Lifecycle stops registered components in phase order, and in
+// registration order within a phase
+type Lifecycle struct {
+	log logger.Logger
+
+	mu    sync.Mutex
+	hooks []shutdownHook
+	once  sync.Once
+	err   error
+}
+
+// NewLifecycle creates an empty lifecycle
+func NewLifecycle(log logger.Logger) *Lifecycle {
+	if log == nil {
+		log = logger.Get().WithLayer("infrastructure").WithComponent("lifecycle")
+	}
+	return &Lifecycle{log: log}
+}
+
+// OnShutdown registers stop to run in phase, given at most timeout; zero
+// uses DefaultStopTimeout. Hooks registered after Shutdown started do not
+// run.
+func (l *Lifecycle) OnShutdown(phase Phase, name string, timeout time.Duration, stop ShutdownHook) {
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, shutdownHook{phase: phase, name: name, timeout: timeout, stop: stop})
+}
+
+// Shutdown runs every hook once, even when earlier ones fail or time out,
+// and returns their errors joined. Later calls return the same result.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.once.Do(func() {
+		l.err = l.shutdown(ctx)
+	})
+	return l.err
+}
+
+func (l *Lifecycle) shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := make([]shutdownHook, len(l.hooks))
+	copy(hooks, l.hooks)
+	l.mu.Unlock()
+
+	// Stable, so registration order holds within a phase
+	ordered := make([]shutdownHook, 0, len(hooks))
+	for phase := PhaseServer; phase <= PhaseClients; phase++ {
+		for _, h := range hooks {
+			if h.phase == phase {
+				ordered = append(ordered, h)
+			}
+		}
+	}
+
+	started := time.Now()
+	l.log.Info(ctx, "shutting down", "components", len(ordered))
+
+	var errs []error
+	for _, h := range ordered {
+		if err := l.stop(ctx, h); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+
+	l.log.Info(ctx, "shutdown complete", "duration", time.Since(started).String(), "failed", len(errs))
+	return errors.Join(errs...)
+}
+
+// stop runs one hook within its timeout. A hook that ignores its context
+// is abandoned when the timeout passes so the rest still stop.
+func (l *Lifecycle) stop(ctx context.Context, h shutdownHook) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("shutdown hook panicked: %v", p)
+			}
+		}()
+		done <- h.stop(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	duration := time.Since(started).String()
+	if err != nil {
+		l.log.Warn(ctx, "component did not stop cleanly", "error", err, "component", h.name, "phase", h.phase.String(), "duration", duration)
+		return err
+	}
+	l.log.Info(ctx, "component stopped", "component", h.name, "phase", h.phase.String(), "duration", duration)
+	return nil
+}
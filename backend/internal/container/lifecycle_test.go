@@ -0,0 +1,85 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestLifecycle_StopsInPhaseOrder(t *testing.T) {
+	logger.Initialize()
+	l := NewLifecycle(nil)
+
+	var mu sync.Mutex
+	var stopped []string
+	hook := func(name string) ShutdownHook {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = append(stopped, name)
+			return nil
+		}
+	}
+
+	// Registered out of order on purpose
+	l.OnShutdown(PhaseClients, "redis", 0, hook("redis"))
+	l.OnShutdown(PhaseStorage, "database", 0, hook("database"))
+	l.OnShutdown(PhaseEvents, "outbox_relay", 0, hook("outbox_relay"))
+	l.OnShutdown(PhaseEvents, "event_bus", 0, hook("event_bus"))
+	l.OnShutdown(PhaseWorkers, "job_worker", 0, hook("job_worker"))
+	l.OnShutdown(PhaseRelease, "node_id_allocator", 0, hook("node_id_allocator"))
+	l.OnShutdown(PhaseServer, "http_server", 0, hook("http_server"))
+
+	require.NoError(t, l.Shutdown(context.Background()))
+	assert.Equal(t, []string{
+		"http_server", "job_worker", "outbox_relay", "event_bus", "database", "node_id_allocator", "redis",
+	}, stopped)
+
+	// Shutdown runs once
+	require.NoError(t, l.Shutdown(context.Background()))
+	assert.Len(t, stopped, 7)
+}
+
+func TestLifecycle_ContinuesPastFailures(t *testing.T) {
+	logger.Initialize()
+	l := NewLifecycle(nil)
+
+	var databaseClosed bool
+	l.OnShutdown(PhaseWorkers, "stuck_worker", 20*time.Millisecond, func(ctx context.Context) error {
+		// Ignores ctx; the lifecycle moves on without it
+		time.Sleep(time.Second)
+		return nil
+	})
+	l.OnShutdown(PhaseEvents, "broker", 0, func(context.Context) error {
+		return errors.New("connection reset")
+	})
+	l.OnShutdown(PhaseEvents, "panicking", 0, func(context.Context) error {
+		panic("boom")
+	})
+	l.OnShutdown(PhaseStorage, "database", 0, func(context.Context) error {
+		databaseClosed = true
+		return nil
+	})
+
+	started := time.Now()
+	err := l.Shutdown(context.Background())
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+	assert.True(t, databaseClosed)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "broker: connection reset")
+	assert.ErrorContains(t, err, "panicking: shutdown hook panicked: boom")
+}
+
+func TestContainer_ShutdownWithoutLifecycle(t *testing.T) {
+	c := &Container{}
+	assert.NoError(t, c.Close())
+}
@@ -0,0 +1,229 @@
+package container
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+	"github.com/cctw-zed/wonder/pkg/jwt"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/mailer"
+	"github.com/cctw-zed/wonder/pkg/redis"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// ConfigProvider loads the configuration the container is built from
+type ConfigProvider func() (*config.Config, error)
+
+// DBProvider opens the primary database connection. The container applies
+// or checks migrations on it and closes it on shutdown.
+type DBProvider func(ctx context.Context, cfg *config.Config) (*database.Connection, error)
+
+// IDProvider sets up ID generation. It returns the generator and, when node
+// IDs are allocated dynamically, the allocator released on shutdown.
+// redisClient is nil unless external.redis is enabled.
+type IDProvider func(ctx context.Context, cfg *config.Config, redisClient *redis.Client) (id.Generator, id.NodeIDAllocator, error)
+
+// Option overrides how New provides a component
+type Option func(*options)
+
+type options struct {
+	config   ConfigProvider
+	database DBProvider
+	ids      IDProvider
+	userRepo user.UserRepository
+	tokens   jwt.TokenService
+	mailer   mailer.Mailer
+	logger   logger.Logger
+	// checkSchemaOnly ignores database.auto_migrate
+	checkSchemaOnly bool
+}
+
+// WithConfigProvider loads the configuration with provide
+func WithConfigProvider(provide ConfigProvider) Option {
+	return func(o *options) {
+		o.config = provide
+	}
+}
+
+// WithConfig builds the container from an already loaded configuration
+func WithConfig(cfg *config.Config) Option {
+	return WithConfigProvider(func() (*config.Config, error) {
+		return cfg, nil
+	})
+}
+
+// WithConfigPath loads the configuration from path; empty means ./configs
+func WithConfigPath(path string) Option {
+	return WithConfigProvider(func() (*config.Config, error) {
+		if path == "" {
+			path = "./configs"
+		}
+		cfg, err := config.Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		return cfg, nil
+	})
+}
+
+// WithEnvironment loads the configuration for environment from ./configs
+func WithEnvironment(environment string) Option {
+	return WithConfigProvider(func() (*config.Config, error) {
+		cfg, err := config.LoadForEnvironment(environment, "./configs")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config for environment %s: %w", environment, err)
+		}
+		return cfg, nil
+	})
+}
+
+// WithLoadOptions loads the configuration from the sources selected by opts
+func WithLoadOptions(opts config.LoadOptions) Option {
+	return WithConfigProvider(func() (*config.Config, error) {
+		cfg, err := config.LoadWithOptions(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		return cfg, nil
+	})
+}
+
+// WithDBProvider opens the database with provide
+func WithDBProvider(provide DBProvider) Option {
+	return func(o *options) {
+		o.database = provide
+	}
+}
+
+// WithDatabase uses conn instead of connecting to the configured database.
+// The container takes ownership and closes it on shutdown.
+func WithDatabase(conn *database.Connection) Option {
+	return WithDBProvider(func(context.Context, *config.Config) (*database.Connection, error) {
+		return conn, nil
+	})
+}
+
+// WithIDProvider sets up ID generation with provide
+func WithIDProvider(provide IDProvider) Option {
+	return func(o *options) {
+		o.ids = provide
+	}
+}
+
+// WithIDGenerator uses gen instead of allocating a node ID and initializing
+// the default generator
+func WithIDGenerator(gen id.Generator) Option {
+	return WithIDProvider(func(context.Context, *config.Config, *redis.Client) (id.Generator, id.NodeIDAllocator, error) {
+		return gen, nil, nil
+	})
+}
+
+// WithUserRepository replaces the database-backed user repository. Read
+// replicas and retries do not apply to it.
+func WithUserRepository(repo user.UserRepository) Option {
+	return func(o *options) {
+		o.userRepo = repo
+	}
+}
+
+// WithTokenService replaces the JWT token service built from the jwt section
+func WithTokenService(tokens jwt.TokenService) Option {
+	return func(o *options) {
+		o.tokens = tokens
+	}
+}
+
+// WithMailer sends account emails through m, even when external.email is
+// disabled
+func WithMailer(m mailer.Mailer) Option {
+	return func(o *options) {
+		o.mailer = m
+	}
+}
+
+// WithLogger is the logger the container and the application services it
+// builds log with, each under its own layer and component. Without it they
+// use the global logger once it is configured from the log section.
+func WithLogger(l logger.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithoutAutoMigrate only checks that the schema is current, even when
+// database.auto_migrate is set, for tools that migrate in a command of
+// their own
+func WithoutAutoMigrate() Option {
+	return func(o *options) {
+		o.checkSchemaOnly = true
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		config:   defaultConfigProvider,
+		database: defaultDBProvider,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// defaultConfigProvider loads ./configs
+func defaultConfigProvider() (*config.Config, error) {
+	cfg, err := config.Load("./configs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
+// defaultDBProvider connects to the configured database and its replicas
+func defaultDBProvider(_ context.Context, cfg *config.Config) (*database.Connection, error) {
+	conn, err := database.NewConnection(cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return conn, nil
+}
+
+// defaultIDProvider allocates a node ID as id.allocator selects, falling
+// back to the static id.instance_id, and initializes the default generator.
+// etcdBreaker guards the etcd allocator unless it is nil; log reports the
+// allocator chosen.
+func defaultIDProvider(etcdBreaker *circuitbreaker.Breaker, log logger.Logger) IDProvider {
+	return func(ctx context.Context, cfg *config.Config, redisClient *redis.Client) (id.Generator, id.NodeIDAllocator, error) {
+		allocator := createNodeIDAllocator(ctx, cfg, etcdBreaker, redisClient, log)
+		serviceType := getServiceTypeFromConfig(cfg)
+
+		if allocator != nil {
+			// 使用动态分配器
+			if err := id.InitDefaultWithAllocator(ctx, serviceType, allocator); err != nil {
+				return nil, nil, fmt.Errorf("failed to init ID generator with allocator: %w", err)
+			}
+		} else if err := id.InitDefaultForService(serviceType, cfg.ID.InstanceID); err != nil {
+			// 使用配置文件中的ID配置
+			return nil, nil, fmt.Errorf("failed to init distributed ID generator: %w", err)
+		}
+		return id.GetDefault(), allocator, nil
+	}
+}
+
+// migrate applies schema migrations, or refuses to start on an outdated
+// schema when deployments run them explicitly
+func migrate(ctx context.Context, cfg *config.Config, dbConn *database.Connection, autoMigrate bool) error {
+	migrator := database.NewMigrator(dbConn.DB())
+	if autoMigrate {
+		if err := migrator.Up(ctx); err != nil {
+			return fmt.Errorf("failed to run database migrations: %w", err)
+		}
+	} else if err := migrator.Check(ctx); err != nil {
+		return fmt.Errorf("database schema check failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Action names recorded in the audit log
+const (
+	ActionRegister       = "register"
+	ActionUpdate         = "update"
+	ActionDelete         = "delete"
+	ActionLogin          = "login"
+	ActionChangePassword = "change_password"
+	ActionResetPassword  = "reset_password"
+	ActionAdminBootstrap = "admin_bootstrap"
+	ActionLockout        = "lockout"
+	ActionSuspend        = "suspend"
+	ActionDeactivate     = "deactivate"
+	ActionReactivate     = "reactivate"
+	ActionScheduleDelete = "schedule_delete"
+	ActionCancelDelete   = "cancel_delete"
+	ActionEnableMFA      = "enable_mfa"
+	ActionDisableMFA     = "disable_mfa"
+)
+
+// Outcomes of an audited operation
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Entry records who did what to which entity
+type Entry struct {
+	ID         string    `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	ActorID    string    `gorm:"type:varchar(64);index" json:"actor_id,omitempty"`
+	Action     string    `gorm:"type:varchar(50);not null;index" json:"action"`
+	EntityType string    `gorm:"type:varchar(50);not null;index:idx_audit_entity,priority:1" json:"entity_type"`
+	EntityID   string    `gorm:"type:varchar(64);index:idx_audit_entity,priority:2" json:"entity_id,omitempty"`
+	Outcome    string    `gorm:"type:varchar(20);not null" json:"outcome"`
+	Changes    ChangeSet `gorm:"type:text;serializer:pii_json" json:"changes,omitempty"`
+	TraceID    string    `gorm:"type:varchar(64)" json:"trace_id,omitempty"`
+	IPAddress  string    `gorm:"type:varchar(64)" json:"ip_address,omitempty"`
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// TableName pins the audit table name
+func (Entry) TableName() string {
+	return "audit_logs"
+}
+
+// Change is the before and after value of one field
+type Change struct {
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// ChangeSet maps field names to their changes. It is stored as JSON and,
+// since it holds the emails and names of users, encrypted like other
+// personal data.
+type ChangeSet map[string]Change
+
+// Diff returns the fields whose values differ between two snapshots. A nil
+// before describes a creation, a nil after a deletion.
+func Diff(before, after map[string]interface{}) ChangeSet {
+	keys := make(map[string]struct{}, len(before)+len(after))
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	changes := ChangeSet{}
+	for _, k := range names {
+		from, to := before[k], after[k]
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+		changes[k] = Change{From: from, To: to}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// Recorder accepts audit entries. Implementations may persist asynchronously
+// and must not block the audited operation on storage.
+type Recorder interface {
+	Record(ctx context.Context, entry *Entry)
+}
+
+// Repository persists and queries audit entries
+type Repository interface {
+	Save(ctx context.Context, entries ...*Entry) error
+	List(ctx context.Context, req *ListRequest) (*ListResponse, error)
+	// DeleteBefore removes entries created before cutoff and returns how
+	// many were removed
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// ListRequest filters the audit log
+type ListRequest struct {
+	Page       int       `json:"page"`
+	PageSize   int       `json:"page_size"`
+	ActorID    string    `json:"actor_id,omitempty"`
+	Action     string    `json:"action,omitempty"`
+	EntityType string    `json:"entity_type,omitempty"`
+	EntityID   string    `json:"entity_id,omitempty"`
+	From       time.Time `json:"from,omitempty"`
+	To         time.Time `json:"to,omitempty"`
+}
+
+// ListResponse is one page of audit entries, newest first
+type ListResponse struct {
+	Entries    []*Entry `json:"entries"`
+	Total      int64    `json:"total"`
+	Page       int      `json:"page"`
+	PageSize   int      `json:"page_size"`
+	TotalPages int      `json:"total_pages"`
+}
@@ -0,0 +1,26 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	t.Run("reports changed fields only", func(t *testing.T) {
+		changes := Diff(
+			map[string]interface{}{"name": "Old", "email": "a@example.com"},
+			map[string]interface{}{"name": "New", "email": "a@example.com"},
+		)
+		assert.Equal(t, ChangeSet{"name": {From: "Old", To: "New"}}, changes)
+	})
+
+	t.Run("creation and deletion", func(t *testing.T) {
+		assert.Equal(t, ChangeSet{"name": {To: "New"}}, Diff(nil, map[string]interface{}{"name": "New"}))
+		assert.Equal(t, ChangeSet{"name": {From: "Old"}}, Diff(map[string]interface{}{"name": "Old"}, nil))
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		assert.Nil(t, Diff(map[string]interface{}{"name": "Same"}, map[string]interface{}{"name": "Same"}))
+	})
+}
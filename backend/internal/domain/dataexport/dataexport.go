@@ -0,0 +1,77 @@
+// Package dataexport defines the archives of personal data users request
+// about themselves, which are generated in the background and kept for a
+// limited time.
+package dataexport
+
+import (
+	"context"
+	"time"
+)
+
+// Export statuses. Pending exports are waiting for or being generated by a
+// background job; ready exports can be downloaded until they expire.
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+)
+
+// Export is one request for a user's data and, once ready, the archive
+type Export struct {
+	ID       string `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	TenantID string `gorm:"type:varchar(64);not null;default:default;index:idx_data_exports_tenant_user,priority:1" json:"-"`
+	UserID   string `gorm:"type:varchar(64);not null;index:idx_data_exports_tenant_user,priority:2" json:"user_id"`
+	Status   string `gorm:"type:varchar(20);not null" json:"status"`
+	// Content is the ZIP archive; it is only loaded by Repository.Content
+	Content []byte `gorm:"serializer:pii" json:"-"`
+	Size    int64  `gorm:"not null;default:0" json:"size"`
+	// Error explains why generation failed
+	Error       string     `gorm:"type:varchar(255)" json:"error,omitempty"`
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// ExpiresAt is when a ready export is deleted
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
+}
+
+// TableName pins the data export table name
+func (Export) TableName() string {
+	return "data_exports"
+}
+
+// Expired reports whether the export is past its expiry at now
+func (e *Export) Expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+}
+
+// MarkReady stores the archive and keeps it until expiresAt
+func (e *Export) MarkReady(content []byte, now, expiresAt time.Time) {
+	e.Status = StatusReady
+	e.Content = content
+	e.Size = int64(len(content))
+	e.Error = ""
+	e.CompletedAt = &now
+	e.ExpiresAt = &expiresAt
+}
+
+// MarkFailed records why the archive could not be generated
+func (e *Export) MarkFailed(reason string, now time.Time) {
+	e.Status = StatusFailed
+	e.Error = reason
+	e.CompletedAt = &now
+}
+
+// Repository persists exports within the tenant of ctx. Get methods return
+// nil, nil when no export matches.
+type Repository interface {
+	Create(ctx context.Context, e *Export) error
+	// Update saves the status and, when set, the archive of e
+	Update(ctx context.Context, e *Export) error
+	GetByID(ctx context.Context, id string) (*Export, error)
+	// Latest returns the user's most recently requested export
+	Latest(ctx context.Context, userID string) (*Export, error)
+	// Content loads the archive of a ready export
+	Content(ctx context.Context, id string) ([]byte, error)
+	// DeleteExpired removes exports of every tenant that expired by now and
+	// returns how many were removed
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,66 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// Event is something that happened in the domain
+type Event interface {
+	EventName() string
+	AggregateID() string
+	OccurredAt() time.Time
+}
+
+// Handler reacts to a published event
+type Handler func(ctx context.Context, e Event) error
+
+// Bus delivers events to subscribers registered by event name
+type Bus interface {
+	Publish(ctx context.Context, events ...Event) error
+	Subscribe(eventName string, handler Handler)
+}
+
+// Base carries the fields shared by all events
+type Base struct {
+	Aggregate string    `json:"aggregate_id"`
+	Occurred  time.Time `json:"occurred_at"`
+}
+
+// NewBase creates event metadata for the given aggregate
+func NewBase(aggregateID string) Base {
+	return Base{Aggregate: aggregateID, Occurred: time.Now().UTC()}
+}
+
+// AggregateID returns the ID of the aggregate that raised the event
+func (b Base) AggregateID() string {
+	return b.Aggregate
+}
+
+// OccurredAt returns when the event was raised
+func (b Base) OccurredAt() time.Time {
+	return b.Occurred
+}
+
+// Recorder collects events raised by an aggregate until they are pulled
+// for publication. Embed it in aggregate roots.
+type Recorder struct {
+	events []Event
+}
+
+// Record appends an event
+func (r *Recorder) Record(e Event) {
+	r.events = append(r.events, e)
+}
+
+// PullEvents returns recorded events and clears the recorder
+func (r *Recorder) PullEvents() []Event {
+	events := r.events
+	r.events = nil
+	return events
+}
+
+// PendingEvents returns recorded events without clearing them
+func (r *Recorder) PendingEvents() []Event {
+	return r.events
+}
@@ -0,0 +1,41 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// Outbox message states
+const (
+	OutboxPending   = "pending"
+	OutboxPublished = "published"
+	OutboxFailed    = "failed"
+)
+
+// OutboxMessage is a serialized event waiting to be relayed. It is written
+// in the same transaction as the aggregate change that raised the event.
+type OutboxMessage struct {
+	ID             string     `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	IdempotencyKey string     `gorm:"uniqueIndex:idx_outbox_idempotency_key;type:varchar(64);not null" json:"idempotency_key"`
+	EventName      string     `gorm:"type:varchar(100);not null" json:"event_name"`
+	AggregateID    string     `gorm:"type:varchar(64);not null" json:"aggregate_id"`
+	Payload        string     `gorm:"type:text;not null;serializer:pii" json:"payload"`
+	OccurredAt     time.Time  `gorm:"not null" json:"occurred_at"`
+	Status         string     `gorm:"type:varchar(20);not null;index:idx_outbox_status_next_attempt,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_outbox_status_next_attempt,priority:2" json:"next_attempt_at"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	PublishedAt    *time.Time `json:"published_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"not null" json:"created_at"`
+}
+
+// TableName pins the outbox table name
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// Outbox stores events for later relay. Append must join the caller's
+// transaction so events are only persisted when the aggregate change commits.
+type Outbox interface {
+	Append(ctx context.Context, events ...Event) error
+}
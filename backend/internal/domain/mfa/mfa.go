@@ -0,0 +1,147 @@
+// Package mfa defines the second login factor: an authenticator app
+// enrolled with a TOTP secret, one-time recovery codes that stand in for a
+// lost device, and trusted devices that may skip the second step for a
+// while.
+package mfa
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Enrollment is a user's authenticator. It only protects logins once
+// confirmed with a first valid code.
+type Enrollment struct {
+	UserID   string `gorm:"primaryKey;type:varchar(64)" json:"-"`
+	TenantID string `gorm:"type:varchar(64);not null;default:default" json:"-"`
+	// Secret is the base32 TOTP secret, encrypted like personal data
+	Secret      string     `gorm:"type:text;not null;serializer:pii" json:"-"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// LastUsedStep is the time step of the last accepted code, so a code
+	// cannot be replayed while it is still valid
+	LastUsedStep int64 `gorm:"not null;default:0" json:"-"`
+	// RecoveryCodes holds hashes of the recovery codes not used yet
+	RecoveryCodes []string `gorm:"serializer:json;type:text;not null" json:"-"`
+	// FailedAttempts counts wrong codes since the last accepted one
+	FailedAttempts int        `gorm:"not null;default:0" json:"-"`
+	LockedUntil    *time.Time `json:"-"`
+	// Version counts writes made by code checks, so a check is only
+	// written back if no other request changed the enrollment since it was
+	// read
+	Version   int64     `gorm:"not null;default:0" json:"-"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// TableName pins the MFA enrollment table name
+func (Enrollment) TableName() string {
+	return "mfa_enrollments"
+}
+
+// Confirmed reports whether the enrollment protects logins
+func (e *Enrollment) Confirmed() bool {
+	return e.ConfirmedAt != nil
+}
+
+// Locked reports whether too many wrong codes were entered to accept
+// another at now
+func (e *Enrollment) Locked(now time.Time) bool {
+	return e.LockedUntil != nil && now.Before(*e.LockedUntil)
+}
+
+// SetRecoveryCodes replaces the recovery codes with codes, keeping only
+// their hashes
+func (e *Enrollment) SetRecoveryCodes(codes []string) {
+	e.RecoveryCodes = make([]string, 0, len(codes))
+	for _, code := range codes {
+		e.RecoveryCodes = append(e.RecoveryCodes, HashRecoveryCode(code))
+	}
+}
+
+// UseRecoveryCode removes code from the unused recovery codes and reports
+// whether it was one of them
+func (e *Enrollment) UseRecoveryCode(code string) bool {
+	hash := []byte(HashRecoveryCode(code))
+	for i, stored := range e.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), hash) == 1 {
+			e.RecoveryCodes = slices.Delete(e.RecoveryCodes, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// HashRecoveryCode hashes a recovery code for storage. Case, spaces and
+// dashes are ignored so codes can be typed as they are displayed.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// TrustedDevice is a browser or app that completed the second step and
+// asked to be remembered. It presents its token at login to skip the step
+// until ExpiresAt.
+type TrustedDevice struct {
+	ID       string `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	TenantID string `gorm:"type:varchar(64);not null;default:default;index:idx_trusted_devices_tenant_user,priority:1" json:"-"`
+	UserID   string `gorm:"type:varchar(64);not null;index:idx_trusted_devices_tenant_user,priority:2" json:"-"`
+	// TokenHash identifies the device token; the token itself is only
+	// given to the device
+	TokenHash  string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Name       string    `gorm:"type:varchar(255)" json:"name,omitempty"`
+	UserAgent  string    `gorm:"type:varchar(512)" json:"user_agent,omitempty"`
+	IPAddress  string    `gorm:"type:varchar(64)" json:"ip_address,omitempty"`
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+	LastUsedAt time.Time `gorm:"not null" json:"last_used_at"`
+	ExpiresAt  time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// TableName pins the trusted device table name
+func (TrustedDevice) TableName() string {
+	return "trusted_devices"
+}
+
+// Expired reports whether the device is no longer trusted at now
+func (d *TrustedDevice) Expired(now time.Time) bool {
+	return !now.Before(d.ExpiresAt)
+}
+
+// HashDeviceToken hashes a device token for storage and lookup
+func HashDeviceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Repository persists enrollments and trusted devices within the tenant of
+// ctx. Get methods return nil, nil when nothing matches.
+type Repository interface {
+	Get(ctx context.Context, userID string) (*Enrollment, error)
+	// Save creates or replaces the user's enrollment
+	Save(ctx context.Context, e *Enrollment) error
+	// Update writes back an enrollment read with Get and bumps its
+	// Version. It fails with a version mismatch when the enrollment was
+	// written since it was read.
+	Update(ctx context.Context, e *Enrollment) error
+	Delete(ctx context.Context, userID string) error
+
+	CreateDevice(ctx context.Context, d *TrustedDevice) error
+	GetDeviceByTokenHash(ctx context.Context, tokenHash string) (*TrustedDevice, error)
+	// TouchDevice records that the device was used at
+	TouchDevice(ctx context.Context, id string, at time.Time) error
+	// ListDevices lists the user's devices not expired by now, most
+	// recently used first
+	ListDevices(ctx context.Context, userID string, now time.Time) ([]*TrustedDevice, error)
+	DeleteDevice(ctx context.Context, userID, id string) error
+	// DeleteDevices removes all of the user's devices and returns how many
+	// were removed
+	DeleteDevices(ctx context.Context, userID string) (int64, error)
+	// DeleteExpiredDevices removes devices of every tenant that expired by
+	// now and returns how many were removed
+	DeleteExpiredDevices(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,40 @@
+package mfa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrollment_RecoveryCodes(t *testing.T) {
+	e := &Enrollment{}
+	e.SetRecoveryCodes([]string{"ABCDE-FGHIJ", "KLMNO-PQRST"})
+
+	assert.NotContains(t, e.RecoveryCodes, "ABCDE-FGHIJ", "only hashes are kept")
+	assert.True(t, e.UseRecoveryCode("abcde fghij"), "case, spaces and dashes are ignored")
+	assert.False(t, e.UseRecoveryCode("ABCDE-FGHIJ"), "codes work once")
+	assert.Len(t, e.RecoveryCodes, 1)
+	assert.False(t, e.UseRecoveryCode("UNKNOWN"))
+}
+
+func TestEnrollment_Locked(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Minute)
+	e := &Enrollment{}
+
+	assert.False(t, e.Locked(now))
+	e.LockedUntil = &until
+	assert.True(t, e.Locked(now))
+	assert.False(t, e.Locked(until))
+}
+
+func TestTrustedDevice_Expired(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	d := &TrustedDevice{ExpiresAt: now.Add(time.Hour)}
+
+	assert.False(t, d.Expired(now))
+	assert.True(t, d.Expired(now.Add(time.Hour)))
+	assert.Len(t, HashDeviceToken("token"), 64)
+	assert.NotEqual(t, HashDeviceToken("token"), HashDeviceToken("other"))
+}
@@ -0,0 +1,202 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/mfa/mfa.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/domain/mfa/mfa.go -destination=internal/domain/mfa/mocks/mock_mfa.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	mfa "github.com/cctw-zed/wonder/internal/domain/mfa"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateDevice mocks base method.
+func (m *MockRepository) CreateDevice(ctx context.Context, d *mfa.TrustedDevice) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDevice", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDevice indicates an expected call of CreateDevice.
+func (mr *MockRepositoryMockRecorder) CreateDevice(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDevice", reflect.TypeOf((*MockRepository)(nil).CreateDevice), ctx, d)
+}
+
+// Delete mocks base method.
+func (m *MockRepository) Delete(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRepositoryMockRecorder) Delete(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), ctx, userID)
+}
+
+// DeleteDevice mocks base method.
+func (m *MockRepository) DeleteDevice(ctx context.Context, userID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDevice", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDevice indicates an expected call of DeleteDevice.
+func (mr *MockRepositoryMockRecorder) DeleteDevice(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDevice", reflect.TypeOf((*MockRepository)(nil).DeleteDevice), ctx, userID, id)
+}
+
+// DeleteDevices mocks base method.
+func (m *MockRepository) DeleteDevices(ctx context.Context, userID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDevices", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDevices indicates an expected call of DeleteDevices.
+func (mr *MockRepositoryMockRecorder) DeleteDevices(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDevices", reflect.TypeOf((*MockRepository)(nil).DeleteDevices), ctx, userID)
+}
+
+// DeleteExpiredDevices mocks base method.
+func (m *MockRepository) DeleteExpiredDevices(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredDevices", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredDevices indicates an expected call of DeleteExpiredDevices.
+func (mr *MockRepositoryMockRecorder) DeleteExpiredDevices(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredDevices", reflect.TypeOf((*MockRepository)(nil).DeleteExpiredDevices), ctx, now)
+}
+
+// Get mocks base method.
+func (m *MockRepository) Get(ctx context.Context, userID string) (*mfa.Enrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID)
+	ret0, _ := ret[0].(*mfa.Enrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRepositoryMockRecorder) Get(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepository)(nil).Get), ctx, userID)
+}
+
+// GetDeviceByTokenHash mocks base method.
+func (m *MockRepository) GetDeviceByTokenHash(ctx context.Context, tokenHash string) (*mfa.TrustedDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceByTokenHash", ctx, tokenHash)
+	ret0, _ := ret[0].(*mfa.TrustedDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceByTokenHash indicates an expected call of GetDeviceByTokenHash.
+func (mr *MockRepositoryMockRecorder) GetDeviceByTokenHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceByTokenHash", reflect.TypeOf((*MockRepository)(nil).GetDeviceByTokenHash), ctx, tokenHash)
+}
+
+// ListDevices mocks base method.
+func (m *MockRepository) ListDevices(ctx context.Context, userID string, now time.Time) ([]*mfa.TrustedDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDevices", ctx, userID, now)
+	ret0, _ := ret[0].([]*mfa.TrustedDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDevices indicates an expected call of ListDevices.
+func (mr *MockRepositoryMockRecorder) ListDevices(ctx, userID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDevices", reflect.TypeOf((*MockRepository)(nil).ListDevices), ctx, userID, now)
+}
+
+// Save mocks base method.
+func (m *MockRepository) Save(ctx context.Context, e *mfa.Enrollment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockRepositoryMockRecorder) Save(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, e)
+}
+
+// TouchDevice mocks base method.
+func (m *MockRepository) TouchDevice(ctx context.Context, id string, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchDevice", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchDevice indicates an expected call of TouchDevice.
+func (mr *MockRepositoryMockRecorder) TouchDevice(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchDevice", reflect.TypeOf((*MockRepository)(nil).TouchDevice), ctx, id, at)
+}
+
+// Update mocks base method.
+func (m *MockRepository) Update(ctx context.Context, e *mfa.Enrollment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockRepositoryMockRecorder) Update(ctx, e any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRepository)(nil).Update), ctx, e)
+}
@@ -0,0 +1,148 @@
+// Package notification defines messages telling users about changes to
+// their account, delivered on one or more channels: stored for the app to
+// show, or emailed. Each type has default channels that users may
+// override in their preferences.
+package notification
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Channel is a way of delivering a notification
+type Channel string
+
+const (
+	// ChannelInApp stores the notification for the app to list
+	ChannelInApp Channel = "in_app"
+	// ChannelEmail emails the notification
+	ChannelEmail Channel = "email"
+)
+
+// Channels lists every channel in delivery order
+var Channels = []Channel{ChannelInApp, ChannelEmail}
+
+// Notification types
+const (
+	TypeWelcome           = "account.welcome"
+	TypeDeletionScheduled = "account.deletion_scheduled"
+	TypeDeletionCancelled = "account.deletion_cancelled"
+	TypeReactivated       = "account.reactivated"
+	TypeEmailChanged      = "security.email_changed"
+	TypePasswordReset     = "security.password_reset"
+	TypeHandleChanged     = "profile.handle_changed"
+)
+
+// defaultChannels are the channels of each type unless the user chose
+// otherwise. Types that already have an account email only default to
+// the app.
+var defaultChannels = map[string][]Channel{
+	TypeWelcome:           {ChannelInApp},
+	TypeDeletionScheduled: {ChannelInApp},
+	TypeDeletionCancelled: {ChannelInApp},
+	TypeReactivated:       {ChannelInApp, ChannelEmail},
+	TypeEmailChanged:      {ChannelInApp, ChannelEmail},
+	TypePasswordReset:     {ChannelInApp},
+	TypeHandleChanged:     {ChannelInApp},
+}
+
+// Types lists the notification types in order
+func Types() []string {
+	types := make([]string, 0, len(defaultChannels))
+	for t := range defaultChannels {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// KnownType reports whether t is a notification type
+func KnownType(t string) bool {
+	_, ok := defaultChannels[t]
+	return ok
+}
+
+// Security reports whether notifications of type t warn about changes to
+// how the account is accessed. Users cannot turn off their default
+// channels.
+func Security(t string) bool {
+	return strings.HasPrefix(t, "security.")
+}
+
+// PreferenceKey is the user preference that overrides the channels of
+// notifications of type t. Its value maps channels to whether they are
+// used, such as {"email": false}; channels it leaves out keep their
+// default.
+func PreferenceKey(t string) string {
+	return "notifications." + t
+}
+
+// ResolveChannels returns the channels notifications of type t are
+// delivered on, given the user's overrides. Overrides can only add
+// channels to security notifications.
+func ResolveChannels(t string, overrides map[Channel]bool) []Channel {
+	defaults := defaultChannels[t]
+	var channels []Channel
+	for _, ch := range Channels {
+		enabled, ok := overrides[ch]
+		if !ok || Security(t) && !enabled {
+			enabled = slices.Contains(defaults, ch)
+		}
+		if enabled {
+			channels = append(channels, ch)
+		}
+	}
+	return channels
+}
+
+// Notification is an in-app notification of a user
+type Notification struct {
+	ID       string `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	TenantID string `gorm:"type:varchar(64);not null;default:default;index:idx_notifications_tenant_user,priority:1" json:"-"`
+	UserID   string `gorm:"type:varchar(64);not null;index:idx_notifications_tenant_user,priority:2" json:"-"`
+	Type     string `gorm:"type:varchar(64);not null" json:"type"`
+	// Title and Body are rendered in the user's locale when the
+	// notification is delivered
+	Title string `gorm:"type:varchar(255);not null" json:"title"`
+	Body  string `gorm:"type:text;not null" json:"body"`
+	// Data holds the details the text was rendered from, for clients that
+	// render their own
+	Data      map[string]string `gorm:"serializer:json;type:text" json:"data,omitempty"`
+	ReadAt    *time.Time        `json:"read_at,omitempty"`
+	CreatedAt time.Time         `gorm:"not null;index" json:"created_at"`
+}
+
+// TableName pins the notification table name
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// Read reports whether the user has read the notification
+func (n *Notification) Read() bool {
+	return n.ReadAt != nil
+}
+
+// ListFilter selects a page of a user's notifications, newest first
+type ListFilter struct {
+	UnreadOnly bool
+	Offset     int
+	Limit      int
+}
+
+// Repository persists in-app notifications within the tenant of ctx. Get
+// returns nil, nil when nothing matches.
+type Repository interface {
+	// Create stores n unless a notification with its ID exists, so a
+	// delivery can be retried
+	Create(ctx context.Context, n *Notification) error
+	Get(ctx context.Context, userID, id string) (*Notification, error)
+	// List returns a page of the user's notifications and how many match
+	// the filter in total
+	List(ctx context.Context, userID string, filter ListFilter) ([]*Notification, int64, error)
+	CountUnread(ctx context.Context, userID string) (int64, error)
+	// MarkRead marks the user's unread notification id, or all of them
+	// when id is empty, as read at and returns how many were marked
+	MarkRead(ctx context.Context, userID, id string, at time.Time) (int64, error)
+}
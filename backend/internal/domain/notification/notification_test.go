@@ -0,0 +1,30 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cctw-zed/wonder/internal/domain/preference"
+)
+
+func TestResolveChannels(t *testing.T) {
+	assert.Equal(t, []Channel{ChannelInApp, ChannelEmail}, ResolveChannels(TypeEmailChanged, nil))
+	assert.Equal(t, []Channel{ChannelInApp, ChannelEmail}, ResolveChannels(TypeEmailChanged, map[Channel]bool{ChannelEmail: false}),
+		"security notifications cannot be turned off")
+	assert.Equal(t, []Channel{ChannelInApp, ChannelEmail}, ResolveChannels(TypePasswordReset, map[Channel]bool{ChannelInApp: false, ChannelEmail: true}))
+	assert.Equal(t, []Channel{ChannelInApp}, ResolveChannels(TypeHandleChanged, nil))
+	assert.Equal(t, []Channel{ChannelInApp, ChannelEmail}, ResolveChannels(TypeWelcome, map[Channel]bool{ChannelEmail: true}))
+	assert.Empty(t, ResolveChannels(TypeWelcome, map[Channel]bool{ChannelInApp: false}))
+	assert.Empty(t, ResolveChannels("unknown.type", nil))
+}
+
+func TestTypes(t *testing.T) {
+	types := Types()
+	assert.IsIncreasing(t, types)
+	for _, typ := range types {
+		assert.True(t, KnownType(typ))
+		assert.NoError(t, preference.ValidateKey(PreferenceKey(typ)), "preference keys of %s are valid", typ)
+	}
+	assert.False(t, KnownType("unknown.type"))
+}
@@ -0,0 +1,252 @@
+// Package organization defines organizations: teams of users within a
+// tenant, each owned by one member, and the invitations that let someone
+// join by proving they own the invited email address.
+package organization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// MaxNameLength is the longest organization name, in characters
+const MaxNameLength = 100
+
+// Role is what a member may do in an organization
+type Role string
+
+const (
+	// RoleOwner manages the organization: invites members and transfers
+	// ownership. Every organization has exactly one owner.
+	RoleOwner Role = "owner"
+	// RoleMember can see the organization and its members
+	RoleMember Role = "member"
+)
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	return r == RoleOwner || r == RoleMember
+}
+
+// Organization is a team of users within a tenant
+type Organization struct {
+	ID        string    `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	TenantID  string    `gorm:"type:varchar(64);not null;default:default;index" json:"-"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// TableName pins the organization table name
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// Rename validates and sets the organization's name, trimmed of spaces
+func (o *Organization) Rename(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.NewRequiredFieldError("name", name)
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return errors.NewOutOfRangeError("name", name, 1, MaxNameLength)
+	}
+	o.Name = name
+	return nil
+}
+
+// Member is a user's membership of an organization
+type Member struct {
+	OrgID    string `gorm:"primaryKey;type:varchar(64)" json:"org_id"`
+	UserID   string `gorm:"primaryKey;type:varchar(64);index" json:"user_id"`
+	TenantID string `gorm:"type:varchar(64);not null;default:default" json:"-"`
+	Role     Role   `gorm:"type:varchar(16);not null" json:"role"`
+	// Name and Email are the user's, filled in by the service
+	Name      string    `gorm:"-" json:"name,omitempty"`
+	Email     string    `gorm:"-" json:"email,omitempty"`
+	CreatedAt time.Time `gorm:"not null" json:"joined_at"`
+}
+
+// TableName pins the organization member table name
+func (Member) TableName() string {
+	return "organization_members"
+}
+
+// Owner reports whether the member owns the organization
+func (m *Member) Owner() bool {
+	return m.Role == RoleOwner
+}
+
+// InvitationStatus is where an invitation is in its life
+type InvitationStatus string
+
+const (
+	// InvitationPending can still be accepted
+	InvitationPending InvitationStatus = "pending"
+	// InvitationAccepted was used to join
+	InvitationAccepted InvitationStatus = "accepted"
+	// InvitationRevoked was withdrawn by the owner
+	InvitationRevoked InvitationStatus = "revoked"
+	// InvitationExpired ran out before anyone accepted it
+	InvitationExpired InvitationStatus = "expired"
+)
+
+// Invitation asks whoever owns Email to join an organization with Role.
+// Its token is given to the invitee; only its hash is stored, so issuing a
+// new token invalidates the previous one.
+type Invitation struct {
+	ID       string `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	TenantID string `gorm:"type:varchar(64);not null;default:default" json:"-"`
+	OrgID    string `gorm:"type:varchar(64);not null;index" json:"org_id"`
+	// Email is the invited address in canonical form
+	Email      string     `gorm:"type:varchar(255);not null" json:"email"`
+	Role       Role       `gorm:"type:varchar(16);not null" json:"role"`
+	TokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	InvitedBy  string     `gorm:"type:varchar(64);not null" json:"invited_by"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy string     `gorm:"type:varchar(64)" json:"accepted_by,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null" json:"created_at"`
+	// Status is filled in by the service
+	Status InvitationStatus `gorm:"-" json:"status,omitempty"`
+}
+
+// TableName pins the organization invitation table name
+func (Invitation) TableName() string {
+	return "organization_invitations"
+}
+
+// Expired reports whether the invitation can no longer be accepted at now
+func (i *Invitation) Expired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
+
+// Accepted reports whether someone joined through the invitation
+func (i *Invitation) Accepted() bool {
+	return i.AcceptedAt != nil
+}
+
+// Revoked reports whether the owner withdrew the invitation
+func (i *Invitation) Revoked() bool {
+	return i.RevokedAt != nil
+}
+
+// StatusAt returns the status of the invitation at now
+func (i *Invitation) StatusAt(now time.Time) InvitationStatus {
+	switch {
+	case i.Accepted():
+		return InvitationAccepted
+	case i.Revoked():
+		return InvitationRevoked
+	case i.Expired(now):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+// Acceptable fails when the invitation cannot be accepted at now because
+// it was used, revoked or expired
+func (i *Invitation) Acceptable(now time.Time) error {
+	if err := i.Pending(); err != nil {
+		return err
+	}
+	if i.Expired(now) {
+		return errors.NewBusinessRuleError("invitation_expired", "invitation has expired")
+	}
+	return nil
+}
+
+// Accept records that userID joined through the invitation at now. It
+// fails unless the invitation is Acceptable.
+func (i *Invitation) Accept(userID string, now time.Time) error {
+	if err := i.Acceptable(now); err != nil {
+		return err
+	}
+	i.AcceptedAt = &now
+	i.AcceptedBy = userID
+	return nil
+}
+
+// Renew replaces the invitation's token with the one hashed to tokenHash,
+// valid until expiresAt. Expired invitations may be renewed; used or
+// revoked ones may not.
+func (i *Invitation) Renew(tokenHash string, expiresAt time.Time) error {
+	if err := i.Pending(); err != nil {
+		return err
+	}
+	i.TokenHash = tokenHash
+	i.ExpiresAt = expiresAt
+	return nil
+}
+
+// Revoke withdraws the invitation at now. Revoking twice does nothing;
+// accepted invitations cannot be revoked.
+func (i *Invitation) Revoke(now time.Time) error {
+	if i.Accepted() {
+		return errors.NewBusinessRuleError("invitation_used", "invitation was already accepted")
+	}
+	if !i.Revoked() {
+		i.RevokedAt = &now
+	}
+	return nil
+}
+
+// Pending fails when the invitation was used or revoked. Expired
+// invitations are still pending; they can be renewed.
+func (i *Invitation) Pending() error {
+	if i.Accepted() {
+		return errors.NewBusinessRuleError("invitation_used", "invitation was already accepted")
+	}
+	if i.Revoked() {
+		return errors.NewBusinessRuleError("invitation_revoked", "invitation was revoked")
+	}
+	return nil
+}
+
+// HashInviteToken hashes an invitation token for storage and lookup
+func HashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Repository persists organizations, their members and invitations within
+// the tenant of ctx. Get methods return nil, nil when nothing matches.
+type Repository interface {
+	Create(ctx context.Context, o *Organization) error
+	Get(ctx context.Context, id string) (*Organization, error)
+	// ListForUser lists the organizations the user is a member of, by name
+	ListForUser(ctx context.Context, userID string) ([]*Organization, error)
+	// ListOwnedBy lists the organizations the user owns, by name
+	ListOwnedBy(ctx context.Context, userID string) ([]*Organization, error)
+	// Delete removes an organization with its members and invitations
+	Delete(ctx context.Context, id string) error
+
+	// AddMember adds a membership; it fails with a duplicate entry error
+	// when the user is already a member
+	AddMember(ctx context.Context, m *Member) error
+	GetMember(ctx context.Context, orgID, userID string) (*Member, error)
+	// GetMemberForUpdate retrieves a membership like GetMember and, inside
+	// a transaction, locks it until the transaction ends
+	GetMemberForUpdate(ctx context.Context, orgID, userID string) (*Member, error)
+	// ListMembers lists an organization's members, owner first, then by
+	// when they joined
+	ListMembers(ctx context.Context, orgID string) ([]*Member, error)
+	UpdateMemberRole(ctx context.Context, orgID, userID string, role Role) error
+
+	CreateInvitation(ctx context.Context, i *Invitation) error
+	GetInvitation(ctx context.Context, id string) (*Invitation, error)
+	GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*Invitation, error)
+	// ListInvitations lists an organization's invitations, newest first
+	ListInvitations(ctx context.Context, orgID string) ([]*Invitation, error)
+	// UpdateInvitation saves an invitation's token, expiry, acceptance and
+	// revocation. It fails with a version mismatch unless the invitation is
+	// still pending, i.e. neither accepted nor revoked, when it is written.
+	UpdateInvitation(ctx context.Context, i *Invitation) error
+}
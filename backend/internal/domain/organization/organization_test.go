@@ -0,0 +1,64 @@
+package organization
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganization_Rename(t *testing.T) {
+	o := &Organization{}
+	require.NoError(t, o.Rename("  Research  "))
+	assert.Equal(t, "Research", o.Name)
+
+	assert.Error(t, o.Rename("   "))
+	assert.Error(t, o.Rename(strings.Repeat("é", MaxNameLength+1)))
+	require.NoError(t, o.Rename(strings.Repeat("é", MaxNameLength)))
+}
+
+func TestInvitation_Accept(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	inv := &Invitation{ExpiresAt: now.Add(time.Hour)}
+
+	require.NoError(t, inv.Accept("u-2", now))
+	assert.True(t, inv.Accepted())
+	assert.Equal(t, "u-2", inv.AcceptedBy)
+	assert.Error(t, inv.Accept("u-3", now), "invitations are used once")
+
+	expired := &Invitation{ExpiresAt: now}
+	assert.True(t, expired.Expired(now))
+	assert.Error(t, expired.Accept("u-2", now))
+	assert.False(t, expired.Accepted())
+}
+
+func TestInvitation_RevokeAndRenew(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	expired := &Invitation{TokenHash: "old", ExpiresAt: now}
+	assert.Equal(t, InvitationExpired, expired.StatusAt(now))
+	require.NoError(t, expired.Renew("new", now.Add(time.Hour)))
+	assert.Equal(t, "new", expired.TokenHash)
+	assert.Equal(t, InvitationPending, expired.StatusAt(now))
+
+	inv := &Invitation{ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, inv.Revoke(now))
+	require.NoError(t, inv.Revoke(now.Add(time.Minute)), "revoking twice does nothing")
+	assert.Equal(t, now, *inv.RevokedAt)
+	assert.Equal(t, InvitationRevoked, inv.StatusAt(now))
+	assert.Error(t, inv.Accept("u-2", now))
+	assert.Error(t, inv.Renew("new", now.Add(time.Hour)))
+
+	accepted := &Invitation{ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, accepted.Accept("u-2", now))
+	assert.Equal(t, InvitationAccepted, accepted.StatusAt(now))
+	assert.Error(t, accepted.Revoke(now))
+}
+
+func TestHashInviteToken(t *testing.T) {
+	assert.Len(t, HashInviteToken("token"), 64)
+	assert.Equal(t, HashInviteToken("token"), HashInviteToken("token"))
+	assert.NotEqual(t, HashInviteToken("token"), HashInviteToken("other"))
+}
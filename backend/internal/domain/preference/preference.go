@@ -0,0 +1,105 @@
+// Package preference defines per-user settings stored as JSON values under
+// namespaced keys, so features can keep their own settings without schema
+// changes.
+package preference
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// MaxKeyLength is the longest key a preference may have
+const MaxKeyLength = 128
+
+// Keys are a namespace and a name separated by dots, such as
+// "editor.theme" or "notifications.email.digest"
+var (
+	keyPattern       = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_-]*)+$`)
+	namespacePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// Preference is one setting of a user
+type Preference struct {
+	TenantID string `gorm:"type:varchar(64);not null;default:default;index" json:"-"`
+	UserID   string `gorm:"primaryKey;type:varchar(64)" json:"-"`
+	Key      string `gorm:"primaryKey;column:preference_key;type:varchar(128)" json:"key"`
+	// Value is compact JSON
+	Value     json.RawMessage `gorm:"type:text;not null" json:"value"`
+	UpdatedAt time.Time       `gorm:"not null" json:"updated_at"`
+}
+
+// TableName pins the preference table name
+func (Preference) TableName() string {
+	return "user_preferences"
+}
+
+// Namespace returns the part of the key before the first dot
+func (p *Preference) Namespace() string {
+	return Namespace(p.Key)
+}
+
+// Namespace returns the part of key before the first dot
+func Namespace(key string) string {
+	namespace, _, _ := strings.Cut(key, ".")
+	return namespace
+}
+
+// ValidateKey checks that key is a lowercase namespaced key
+func ValidateKey(key string) error {
+	if key == "" {
+		return errors.NewRequiredFieldError("key", key)
+	}
+	if len(key) > MaxKeyLength || !keyPattern.MatchString(key) {
+		return errors.NewInvalidFormatError("key", key, "namespace.name")
+	}
+	return nil
+}
+
+// ValidateNamespace checks a namespace to filter preferences by
+func ValidateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return errors.NewInvalidFormatError("namespace", namespace, "namespace")
+	}
+	return nil
+}
+
+// NormalizeValue checks that value is JSON of at most maxBytes once
+// compacted, and returns it compacted. Zero maxBytes means no limit.
+func NormalizeValue(value []byte, maxBytes int) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(value)) == 0 {
+		return nil, errors.NewRequiredFieldError("value", nil)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, value); err != nil {
+		return nil, errors.NewInvalidFormatError("value", nil, "json")
+	}
+	if maxBytes > 0 && buf.Len() > maxBytes {
+		return nil, errors.NewOutOfRangeError("value", buf.Len(), 1, maxBytes)
+	}
+	return buf.Bytes(), nil
+}
+
+// Repository persists preferences within the tenant of ctx. Get returns
+// nil, nil when the user has no preference under the key.
+type Repository interface {
+	Get(ctx context.Context, userID, key string) (*Preference, error)
+	// List returns the user's preferences ordered by key, only those in
+	// namespace unless it is empty
+	List(ctx context.Context, userID, namespace string) ([]*Preference, error)
+	// Set creates the preference or replaces its value
+	Set(ctx context.Context, p *Preference) error
+	// Delete removes the preference and reports whether it existed
+	Delete(ctx context.Context, userID, key string) (bool, error)
+	// Count returns how many preferences the user has
+	Count(ctx context.Context, userID string) (int64, error)
+	// CountForUpdate counts like Count and, inside a transaction, holds
+	// off other transactions doing the same for the user until it ends, so
+	// that a limit checked against the count still holds at commit
+	CountForUpdate(ctx context.Context, userID string) (int64, error)
+}
@@ -0,0 +1,40 @@
+package preference
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"editor.theme", "notifications.email.digest", "beta_features.new-nav"} {
+		assert.NoError(t, ValidateKey(key), key)
+	}
+	for _, key := range []string{"", "theme", "Editor.theme", ".theme", "editor.", "editor..theme", "1editor.theme", "editor.theme!", "editor." + strings.Repeat("a", MaxKeyLength)} {
+		assert.Error(t, ValidateKey(key), key)
+	}
+
+	assert.Equal(t, "notifications", Namespace("notifications.email.digest"))
+	assert.NoError(t, ValidateNamespace("editor"))
+	assert.Error(t, ValidateNamespace("editor.theme"))
+	assert.Error(t, ValidateNamespace(""))
+}
+
+func TestNormalizeValue(t *testing.T) {
+	value, err := NormalizeValue([]byte(`{ "size": 14,  "font": "mono" }`), 64)
+	require.NoError(t, err)
+	assert.Equal(t, `{"size":14,"font":"mono"}`, string(value))
+
+	_, err = NormalizeValue([]byte(`{"size":`), 64)
+	assert.Error(t, err, "invalid JSON")
+	_, err = NormalizeValue([]byte("  "), 64)
+	assert.Error(t, err, "empty")
+	_, err = NormalizeValue([]byte(`"`+strings.Repeat("a", 64)+`"`), 64)
+	assert.Error(t, err, "too large once compacted")
+
+	value, err = NormalizeValue([]byte(`null`), 0)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(value))
+}
@@ -0,0 +1,157 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/stats/stats.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/domain/stats/stats.go -destination=internal/domain/stats/mocks/mock_stats.go -package=mocks Repository,Cache
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	stats "github.com/cctw-zed/wonder/internal/domain/stats"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountLogins mocks base method.
+func (m *MockRepository) CountLogins(ctx context.Context, from time.Time) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountLogins", ctx, from)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CountLogins indicates an expected call of CountLogins.
+func (mr *MockRepositoryMockRecorder) CountLogins(ctx, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountLogins", reflect.TypeOf((*MockRepository)(nil).CountLogins), ctx, from)
+}
+
+// CountUsersByRole mocks base method.
+func (m *MockRepository) CountUsersByRole(ctx context.Context) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsersByRole", ctx)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUsersByRole indicates an expected call of CountUsersByRole.
+func (mr *MockRepositoryMockRecorder) CountUsersByRole(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsersByRole", reflect.TypeOf((*MockRepository)(nil).CountUsersByRole), ctx)
+}
+
+// DeletionsPerDay mocks base method.
+func (m *MockRepository) DeletionsPerDay(ctx context.Context, from time.Time) ([]stats.DailyCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletionsPerDay", ctx, from)
+	ret0, _ := ret[0].([]stats.DailyCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletionsPerDay indicates an expected call of DeletionsPerDay.
+func (mr *MockRepositoryMockRecorder) DeletionsPerDay(ctx, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletionsPerDay", reflect.TypeOf((*MockRepository)(nil).DeletionsPerDay), ctx, from)
+}
+
+// SignupsPerDay mocks base method.
+func (m *MockRepository) SignupsPerDay(ctx context.Context, from time.Time) ([]stats.DailyCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignupsPerDay", ctx, from)
+	ret0, _ := ret[0].([]stats.DailyCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignupsPerDay indicates an expected call of SignupsPerDay.
+func (mr *MockRepositoryMockRecorder) SignupsPerDay(ctx, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignupsPerDay", reflect.TypeOf((*MockRepository)(nil).SignupsPerDay), ctx, from)
+}
+
+// MockCache is a mock of Cache interface.
+type MockCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheMockRecorder
+	isgomock struct{}
+}
+
+// MockCacheMockRecorder is the mock recorder for MockCache.
+type MockCacheMockRecorder struct {
+	mock *MockCache
+}
+
+// NewMockCache creates a new mock instance.
+func NewMockCache(ctrl *gomock.Controller) *MockCache {
+	mock := &MockCache{ctrl: ctrl}
+	mock.recorder = &MockCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCache) EXPECT() *MockCacheMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockCache) Get(ctx context.Context, key string) (*stats.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(*stats.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCacheMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCache)(nil).Get), ctx, key)
+}
+
+// Set mocks base method.
+func (m *MockCache) Set(ctx context.Context, key string, s *stats.Stats, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, key, s, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockCacheMockRecorder) Set(ctx, key, s, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCache)(nil).Set), ctx, key, s, ttl)
+}
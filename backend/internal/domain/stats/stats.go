@@ -0,0 +1,76 @@
+// Package stats defines the aggregate user statistics shown to
+// administrators and the queries that compute them.
+package stats
+
+import (
+	"context"
+	"time"
+)
+
+// DateLayout formats the dates of daily and weekly counts
+const DateLayout = "2006-01-02"
+
+// Window bounds for a stats request, in days
+const (
+	DefaultDays = 30
+	MaxDays     = 365
+)
+
+// DailyCount is the number of events on one UTC day
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+// WeeklyCount is the number of events in the ISO week starting on Monday
+// WeekStart
+type WeeklyCount struct {
+	WeekStart string `json:"week_start"`
+	Count     int64  `json:"count"`
+}
+
+// Stats summarizes users and their activity over the last Days days.
+// Daily and weekly series are oldest first and include days without events.
+type Stats struct {
+	Days        int       `json:"days"`
+	From        time.Time `json:"from"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	TotalUsers  int64            `json:"total_users"`
+	UsersByRole map[string]int64 `json:"users_by_role"`
+
+	Signups        int64         `json:"signups"`
+	SignupsPerDay  []DailyCount  `json:"signups_per_day"`
+	SignupsPerWeek []WeeklyCount `json:"signups_per_week"`
+
+	// ActiveSessions counts successful logins whose access token has not
+	// expired yet; ActiveUsers counts the distinct users behind them
+	ActiveSessions int64 `json:"active_sessions"`
+	ActiveUsers    int64 `json:"active_users"`
+
+	Deletions       int64        `json:"deletions"`
+	DeletionsPerDay []DailyCount `json:"deletions_per_day"`
+}
+
+// Repository runs the aggregate queries behind Stats. User counts are
+// scoped to the tenant of ctx; activity comes from the audit log.
+type Repository interface {
+	// CountUsersByRole returns the number of users per role
+	CountUsersByRole(ctx context.Context) (map[string]int64, error)
+	// SignupsPerDay counts users created since from, by UTC day. Days
+	// without signups are omitted.
+	SignupsPerDay(ctx context.Context, from time.Time) ([]DailyCount, error)
+	// DeletionsPerDay counts successful user deletions since from, by UTC
+	// day. Days without deletions are omitted.
+	DeletionsPerDay(ctx context.Context, from time.Time) ([]DailyCount, error)
+	// CountLogins returns the successful logins since from and the distinct
+	// users who made them
+	CountLogins(ctx context.Context, from time.Time) (logins int64, users int64, err error)
+}
+
+// Cache keeps computed stats for a while so dashboards polling the
+// endpoint do not rerun the aggregates. Get returns nil, nil on a miss.
+type Cache interface {
+	Get(ctx context.Context, key string) (*Stats, error)
+	Set(ctx context.Context, key string, s *Stats, ttl time.Duration) error
+}
@@ -0,0 +1,87 @@
+// Package tenant defines tenants, the isolation boundary for users, and how
+// the current tenant travels through a request context.
+package tenant
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// DefaultID is the tenant that owns every user created before multi-tenancy
+// was enabled, and the tenant used when a request names none
+const DefaultID = "default"
+
+// slugPattern is a single DNS label so slugs can be used as subdomains
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Tenant is an organization whose users are isolated from other tenants
+type Tenant struct {
+	ID        string    `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	Slug      string    `gorm:"uniqueIndex:idx_tenants_slug_unique;type:varchar(63);not null" json:"slug"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// Repository persists tenants. Get methods return nil, nil when no tenant
+// matches.
+type Repository interface {
+	Create(ctx context.Context, t *Tenant) error
+	GetByID(ctx context.Context, id string) (*Tenant, error)
+	GetBySlug(ctx context.Context, slug string) (*Tenant, error)
+	List(ctx context.Context) ([]*Tenant, error)
+	Delete(ctx context.Context, id string) error
+	// HasUsers reports whether any user belongs to the tenant
+	HasUsers(ctx context.Context, id string) (bool, error)
+}
+
+// Service manages tenants and resolves them for incoming requests
+type Service interface {
+	CreateTenant(ctx context.Context, slug, name string) (*Tenant, error)
+	GetTenant(ctx context.Context, id string) (*Tenant, error)
+	ListTenants(ctx context.Context) ([]*Tenant, error)
+	// DeleteTenant removes a tenant that has no users left
+	DeleteTenant(ctx context.Context, id string) error
+	// Resolve finds a tenant by ID or, failing that, by slug
+	Resolve(ctx context.Context, ref string) (*Tenant, error)
+}
+
+// Validate validates the tenant entity
+func (t *Tenant) Validate() error {
+	if t.ID == "" {
+		return errors.NewRequiredFieldError("id", t.ID)
+	}
+	if t.Slug == "" {
+		return errors.NewRequiredFieldError("slug", t.Slug)
+	}
+	if !IsValidSlug(t.Slug) {
+		return errors.NewInvalidFormatError("slug", t.Slug, "lowercase letters, digits and hyphens, at most 63 characters")
+	}
+	if t.Name == "" {
+		return errors.NewRequiredFieldError("name", t.Name)
+	}
+	return nil
+}
+
+// IsValidSlug reports whether slug can name a tenant and its subdomain
+func IsValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+type tenantKey struct{}
+
+// WithID returns a context scoped to the tenant with the given ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// IDFromContext returns the tenant ctx is scoped to, or DefaultID
+func IDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey{}).(string); ok && id != "" {
+		return id
+	}
+	return DefaultID
+}
@@ -0,0 +1,25 @@
+package transaction
+
+import "context"
+
+// UnitOfWork runs a function atomically. Repositories called with the
+// context passed to fn participate in the same transaction; returning an
+// error (or panicking) rolls everything back.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type consistentReadsKey struct{}
+
+// WithConsistentReads marks ctx so that reads observe every committed
+// write, bypassing read replicas that may lag behind. Read-modify-write
+// sequences outside a UnitOfWork should use it.
+func WithConsistentReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, consistentReadsKey{}, true)
+}
+
+// ConsistentReads reports whether ctx was marked by WithConsistentReads
+func ConsistentReads(ctx context.Context) bool {
+	required, _ := ctx.Value(consistentReadsKey{}).(bool)
+	return required
+}
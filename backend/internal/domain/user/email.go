@@ -0,0 +1,66 @@
+package user
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// EmailRules decide which email addresses reach the same mailbox and so
+// belong to the same account. The zero value only applies
+// CanonicalizeEmail.
+type EmailRules struct {
+	// FoldGmail ignores dots and "+tag" suffixes in the local part of Gmail
+	// addresses, which Gmail delivers to the same mailbox, and treats
+	// googlemail.com as gmail.com
+	FoldGmail bool
+}
+
+// gmailDomains are folded to gmail.com when EmailRules.FoldGmail is set
+var gmailDomains = []string{"gmail.com", "googlemail.com"}
+
+// Canonicalize returns the form of email that addresses equivalent under
+// the rules share
+func (r EmailRules) Canonicalize(email string) string {
+	email = CanonicalizeEmail(email)
+	if !r.FoldGmail {
+		return email
+	}
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+	if !slices.Contains(gmailDomains, domain) {
+		return email
+	}
+	if plus := strings.IndexByte(local, '+'); plus >= 0 {
+		local = local[:plus]
+	}
+	return strings.ReplaceAll(local, ".", "") + "@gmail.com"
+}
+
+// CanonicalizeEmail returns the form of email that equivalent addresses
+// share under any rules: trimmed, lower-cased and in Unicode normalization
+// form C, so Foo@Example.com and foo@example.com are the same
+func CanonicalizeEmail(email string) string {
+	email = norm.NFC.String(strings.ToLower(strings.TrimSpace(email)))
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return email
+	}
+	return email[:at] + "@" + strings.TrimSuffix(email[at+1:], ".")
+}
+
+// CanonicalEmailCandidates returns the canonical forms of email under every
+// rule set, without repeats. A user is stored under the rules in force when
+// they were last saved, so looking up all of them finds the user by their
+// own address while the rules change.
+func CanonicalEmailCandidates(email string) []string {
+	candidates := []string{CanonicalizeEmail(email)}
+	if folded := (EmailRules{FoldGmail: true}).Canonicalize(email); folded != candidates[0] {
+		candidates = append(candidates, folded)
+	}
+	return candidates
+}
@@ -0,0 +1,29 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalizeEmail(t *testing.T) {
+	assert.Equal(t, "foo@example.com", CanonicalizeEmail(" Foo@Example.COM "))
+	assert.Equal(t, "foo@example.com", CanonicalizeEmail("foo@example.com."), "trailing dot of the domain")
+	// é written as e and a combining accent matches the precomposed letter
+	assert.Equal(t, "jos\u00e9@example.com", CanonicalizeEmail("Jose\u0301@example.com"))
+	assert.Equal(t, "f.o.o+news@gmail.com", CanonicalizeEmail("F.O.O+news@gmail.com"), "gmail is not folded")
+	assert.Equal(t, "not-an-email", CanonicalizeEmail("Not-An-Email"))
+	assert.Equal(t, CanonicalizeEmail(" Foo@Example.COM "), EmailRules{}.Canonicalize(" Foo@Example.COM "))
+}
+
+func TestEmailRules_FoldGmail(t *testing.T) {
+	rules := EmailRules{FoldGmail: true}
+	assert.Equal(t, "foo@gmail.com", rules.Canonicalize("F.O.O+news@gmail.com"))
+	assert.Equal(t, "foo@gmail.com", rules.Canonicalize("foo@GoogleMail.com."))
+	assert.Equal(t, "f.o.o+news@example.com", rules.Canonicalize("f.o.o+news@example.com"), "only gmail is folded")
+}
+
+func TestCanonicalEmailCandidates(t *testing.T) {
+	assert.Equal(t, []string{"f.oo+news@gmail.com", "foo@gmail.com"}, CanonicalEmailCandidates("F.oo+news@gmail.com"))
+	assert.Equal(t, []string{"foo@example.com"}, CanonicalEmailCandidates("Foo@example.com"))
+}
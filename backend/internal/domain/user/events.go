@@ -0,0 +1,173 @@
+package user
+
+import (
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+)
+
+// User event names
+const (
+	EventUserRegistered    = "user.registered"
+	EventUserEmailChanged  = "user.email_changed"
+	EventUserNameChanged   = "user.name_changed"
+	EventUserHandleChanged = "user.handle_changed"
+	// EventUserPreferencesChanged covers settings without events of their
+	// own: the time zone and locale
+	EventUserPreferencesChanged = "user.preferences_changed"
+	EventUserDeleted            = "user.deleted"
+
+	EventUserPasswordReset = "user.password_reset"
+
+	EventUserAdminBootstrapped = "user.admin_bootstrapped"
+
+	EventUserSuspended   = "user.suspended"
+	EventUserReactivated = "user.reactivated"
+	EventUserDeactivated = "user.deactivated"
+
+	EventUserDeletionScheduled = "user.deletion_scheduled"
+	EventUserDeletionCancelled = "user.deletion_cancelled"
+)
+
+// UserRegistered is raised when a new user account is created
+type UserRegistered struct {
+	event.Base
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// EventName implements event.Event
+func (UserRegistered) EventName() string { return EventUserRegistered }
+
+// UserEmailChanged is raised when a user changes their email address
+type UserEmailChanged struct {
+	event.Base
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
+}
+
+// EventName implements event.Event
+func (UserEmailChanged) EventName() string { return EventUserEmailChanged }
+
+// UserNameChanged is raised when a user's name changes
+type UserNameChanged struct {
+	event.Base
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+}
+
+// EventName implements event.Event
+func (UserNameChanged) EventName() string { return EventUserNameChanged }
+
+// UserHandleChanged is raised when a user sets, changes or removes their
+// handle. An empty handle means there was or is none.
+type UserHandleChanged struct {
+	event.Base
+	OldHandle string `json:"old_handle,omitempty"`
+	NewHandle string `json:"new_handle,omitempty"`
+}
+
+// EventName implements event.Event
+func (UserHandleChanged) EventName() string { return EventUserHandleChanged }
+
+// UserPreferencesChanged is raised when a user changes their time zone or
+// locale. Field is "timezone" or "locale"; an empty Value means the
+// preference was removed.
+type UserPreferencesChanged struct {
+	event.Base
+	Field string `json:"field"`
+	Value string `json:"value,omitempty"`
+}
+
+// EventName implements event.Event
+func (UserPreferencesChanged) EventName() string { return EventUserPreferencesChanged }
+
+// UserDeleted is raised when a user account is removed. Scheduled is set
+// when the deletion was one the user requested and its grace period ended.
+// AvatarKey names the avatar object left to delete.
+type UserDeleted struct {
+	event.Base
+	Email     string `json:"email"`
+	Name      string `json:"name,omitempty"`
+	Scheduled bool   `json:"scheduled,omitempty"`
+	AvatarKey string `json:"avatar_key,omitempty"`
+}
+
+// EventName implements event.Event
+func (UserDeleted) EventName() string { return EventUserDeleted }
+
+// UserPasswordReset is raised when an administrator sets a new password
+// for a user
+type UserPasswordReset struct {
+	event.Base
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// EventName implements event.Event
+func (UserPasswordReset) EventName() string { return EventUserPasswordReset }
+
+// UserAdminBootstrapped is raised when the initial admin account is created
+type UserAdminBootstrapped struct {
+	event.Base
+	Email string `json:"email"`
+}
+
+// EventName implements event.Event
+func (UserAdminBootstrapped) EventName() string { return EventUserAdminBootstrapped }
+
+// UserSuspended is raised when an administrator suspends a user
+type UserSuspended struct {
+	event.Base
+	Email  string `json:"email"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// EventName implements event.Event
+func (UserSuspended) EventName() string { return EventUserSuspended }
+
+// UserReactivated is raised when a suspended or deactivated user is made
+// active again
+type UserReactivated struct {
+	event.Base
+	Email string `json:"email"`
+}
+
+// EventName implements event.Event
+func (UserReactivated) EventName() string { return EventUserReactivated }
+
+// UserDeactivated is raised when a user account is closed without being
+// deleted
+type UserDeactivated struct {
+	event.Base
+	Email  string `json:"email"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// EventName implements event.Event
+func (UserDeactivated) EventName() string { return EventUserDeactivated }
+
+// UserDeletionScheduled is raised when a user asks for their account to be
+// deleted once the grace period ends
+type UserDeletionScheduled struct {
+	event.Base
+	Email    string    `json:"email"`
+	Name     string    `json:"name"`
+	DeleteAt time.Time `json:"delete_at"`
+	// LocalePrefs are the user's, for formatting DeleteAt in the email
+	LocalePrefs
+}
+
+// EventName implements event.Event
+func (UserDeletionScheduled) EventName() string { return EventUserDeletionScheduled }
+
+// UserDeletionCancelled is raised when a user keeps an account whose
+// deletion was pending
+type UserDeletionCancelled struct {
+	event.Base
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// EventName implements event.Event
+func (UserDeletionCancelled) EventName() string { return EventUserDeletionCancelled }
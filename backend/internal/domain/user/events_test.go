@@ -0,0 +1,59 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUser_DomainEvents(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	u := &User{ID: "user-1", Email: "old@example.com", Name: "Test"}
+
+	u.MarkRegistered()
+	require.NoError(t, u.UpdateEmail(ctx, "new@example.com"))
+	require.NoError(t, u.UpdateEmail(ctx, "new@example.com")) // unchanged: no event
+	require.NoError(t, u.UpdateName(ctx, "Renamed"))
+	require.NoError(t, u.UpdateName(ctx, "Renamed")) // unchanged: no event
+	u.MarkPasswordReset()
+	u.MarkDeleted(false)
+
+	events := u.PullEvents()
+	require.Len(t, events, 5)
+
+	registered, ok := events[0].(UserRegistered)
+	require.True(t, ok)
+	assert.Equal(t, EventUserRegistered, registered.EventName())
+	assert.Equal(t, "user-1", registered.AggregateID())
+	assert.Equal(t, "old@example.com", registered.Email)
+	assert.False(t, registered.OccurredAt().IsZero())
+
+	changed, ok := events[1].(UserEmailChanged)
+	require.True(t, ok)
+	assert.Equal(t, "old@example.com", changed.OldEmail)
+	assert.Equal(t, "new@example.com", changed.NewEmail)
+
+	renamed, ok := events[2].(UserNameChanged)
+	require.True(t, ok)
+	assert.Equal(t, EventUserNameChanged, renamed.EventName())
+	assert.Equal(t, "Test", renamed.OldName)
+	assert.Equal(t, "Renamed", renamed.NewName)
+
+	reset, ok := events[3].(UserPasswordReset)
+	require.True(t, ok)
+	assert.Equal(t, EventUserPasswordReset, reset.EventName())
+	assert.Equal(t, "new@example.com", reset.Email)
+	assert.Equal(t, "Renamed", reset.Name)
+
+	deleted, ok := events[4].(UserDeleted)
+	require.True(t, ok)
+	assert.Equal(t, EventUserDeleted, deleted.EventName())
+
+	assert.Empty(t, u.PullEvents(), "pulling clears recorded events")
+}
@@ -0,0 +1,127 @@
+package user
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// Handle length limits
+const (
+	HandleMinLength = 3
+	HandleMaxLength = 30
+)
+
+// Reasons a handle is unavailable
+const (
+	HandleInvalid  = "invalid"
+	HandleReserved = "reserved"
+	HandleTaken    = "taken"
+)
+
+// handleFormat describes valid handles in validation errors
+const handleFormat = "3 to 30 letters, digits or underscores, starting with a letter"
+
+var handlePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedHandles name parts of the application or could pass for its
+// staff, so no user may claim them
+var reservedHandles = []string{
+	"admin", "administrator", "api", "app", "auth", "help", "login", "logout",
+	"me", "moderator", "null", "official", "register", "root", "security",
+	"settings", "signup", "staff", "support", "system", "undefined", "users",
+	"wonder", "www",
+}
+
+// HandlePolicy decides which handles users may claim. The zero value
+// reserves only the built-in names. Users who already hold a newly
+// reserved handle keep it.
+type HandlePolicy struct {
+	// Reserved handles are refused in addition to the built-in ones
+	Reserved []string
+}
+
+// reservedKey ignores underscores, so adding them to a reserved name does
+// not get around it
+func reservedKey(handle string) string {
+	return strings.ReplaceAll(handle, "_", "")
+}
+
+// NormalizeHandle returns handle as it is stored and compared: trimmed,
+// without a leading @ and lower-cased
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// IsReserved reports whether the normalized handle is reserved
+func (p HandlePolicy) IsReserved(handle string) bool {
+	key := reservedKey(handle)
+	for _, names := range [][]string{reservedHandles, p.Reserved} {
+		for _, name := range names {
+			if reservedKey(NormalizeHandle(name)) == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Validate checks a normalized handle's length, characters and that it is
+// not reserved
+func (p HandlePolicy) Validate(handle string) error {
+	if len(handle) < HandleMinLength || len(handle) > HandleMaxLength || !handlePattern.MatchString(handle) {
+		return errors.NewInvalidFormatError("handle", handle, handleFormat)
+	}
+	if p.IsReserved(handle) {
+		return errors.NewInvalidValueError("handle", handle, "handle is reserved")
+	}
+	return nil
+}
+
+// HandleAvailability tells whether a handle can be claimed
+type HandleAvailability struct {
+	// Handle is the requested handle, normalized
+	Handle    string `json:"handle"`
+	Available bool   `json:"available"`
+	// Reason is HandleInvalid, HandleReserved or HandleTaken when the
+	// handle is unavailable
+	Reason string `json:"reason,omitempty"`
+}
+
+// CurrentHandle returns the user's handle, or empty when they have none
+func (u *User) CurrentHandle() string {
+	if u.Handle == nil {
+		return ""
+	}
+	return *u.Handle
+}
+
+// ChangeHandle sets the user's handle, or removes it when handle is empty.
+// The handle must be valid under policy; uniqueness is left to the caller.
+func (u *User) ChangeHandle(ctx context.Context, handle string, policy HandlePolicy) error {
+	log := logger.Get().WithLayer("domain").WithComponent("user")
+
+	handle = NormalizeHandle(handle)
+	if handle != "" {
+		if err := policy.Validate(handle); err != nil {
+			return err
+		}
+	}
+
+	oldHandle := u.CurrentHandle()
+	if oldHandle == handle {
+		return nil
+	}
+	u.Handle = nil
+	if handle != "" {
+		u.Handle = &handle
+	}
+	u.Record(UserHandleChanged{Base: event.NewBase(u.ID), OldHandle: oldHandle, NewHandle: handle})
+
+	log.Info(ctx, "user handle updated", "user_id", u.ID, "old_handle", oldHandle, "new_handle", handle)
+	return nil
+}
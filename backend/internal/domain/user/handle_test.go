@@ -0,0 +1,64 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestHandlePolicy_Validate(t *testing.T) {
+	policy := HandlePolicy{Reserved: []string{"Billing"}}
+
+	assert.Equal(t, "ada_l", NormalizeHandle(" @Ada_L "))
+
+	for _, handle := range []string{"ada", "ada_lovelace", "a1b2c3", "abcdefghijklmnopqrstuvwxyz1234"} {
+		assert.NoError(t, policy.Validate(handle), handle)
+	}
+
+	var invalid *errors.ValidationError
+	for _, handle := range []string{"ab", "abcdefghijklmnopqrstuvwxyz12345", "1ada", "_ada", "ada-l", "ada.l", "adà"} {
+		require.ErrorAs(t, policy.Validate(handle), &invalid, handle)
+		assert.Equal(t, errors.CodeInvalidFormat, invalid.Code(), handle)
+	}
+
+	// Built-in and configured names are reserved, with or without underscores
+	for _, handle := range []string{"admin", "api", "ad_min", "api_", "billing"} {
+		require.ErrorAs(t, policy.Validate(handle), &invalid, handle)
+		assert.Equal(t, errors.CodeInvalidValue, invalid.Code(), handle)
+		assert.True(t, policy.IsReserved(handle), handle)
+	}
+	assert.False(t, policy.IsReserved("administrators"))
+
+	// The zero policy only reserves the built-in names
+	assert.True(t, HandlePolicy{}.IsReserved("admin"))
+	assert.NoError(t, HandlePolicy{}.Validate("billing"))
+}
+
+func TestUser_ChangeHandle(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	u := &User{ID: "user-1"}
+
+	require.NoError(t, u.ChangeHandle(ctx, "@Ada", HandlePolicy{}))
+	assert.Equal(t, "ada", u.CurrentHandle())
+
+	// Setting the same handle again records nothing
+	require.NoError(t, u.ChangeHandle(ctx, "ADA", HandlePolicy{}))
+
+	assert.Error(t, u.ChangeHandle(ctx, "root", HandlePolicy{}))
+	assert.Equal(t, "ada", u.CurrentHandle(), "a refused handle leaves the old one")
+
+	require.NoError(t, u.ChangeHandle(ctx, "", HandlePolicy{}))
+	assert.Nil(t, u.Handle)
+
+	events := u.PullEvents()
+	require.Len(t, events, 2)
+	assert.Equal(t, UserHandleChanged{Base: events[0].(UserHandleChanged).Base, NewHandle: "ada"}, events[0])
+	assert.Equal(t, "ada", events[1].(UserHandleChanged).OldHandle)
+	assert.Empty(t, events[1].(UserHandleChanged).NewHandle)
+}
@@ -0,0 +1,100 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// LocalePrefs is how times are shown to a user: in their IANA time zone
+// and the conventions of their BCP 47 locale. Empty fields mean UTC and
+// the default language.
+type LocalePrefs struct {
+	Timezone string `json:"timezone,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+// Location returns the time zone, or UTC when none is set or it is no
+// longer known
+func (p LocalePrefs) Location() *time.Location {
+	if p.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// NormalizeTimezone returns the canonical name of an IANA time zone, or
+// empty for an empty one
+func NormalizeTimezone(tz string) (string, error) {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		return "", nil
+	}
+	// Local is the server's zone, not one the user can mean
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return "", errors.NewInvalidFormatError("timezone", tz, "IANA time zone such as Europe/Berlin")
+	}
+	return loc.String(), nil
+}
+
+// NormalizeLocale returns the canonical form of a BCP 47 language tag, or
+// empty for an empty one
+func NormalizeLocale(locale string) (string, error) {
+	locale = strings.TrimSpace(locale)
+	if locale == "" {
+		return "", nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil || tag == language.Und {
+		return "", errors.NewInvalidFormatError("locale", locale, "BCP 47 language tag such as en-US")
+	}
+	return tag.String(), nil
+}
+
+// LocalePrefs returns the user's time zone and locale
+func (u *User) LocalePrefs() LocalePrefs {
+	return LocalePrefs{Timezone: u.Timezone, Locale: u.Locale}
+}
+
+// ChangeTimezone sets the user's time zone, or removes it when tz is empty
+func (u *User) ChangeTimezone(ctx context.Context, tz string) error {
+	log := logger.Get().WithLayer("domain").WithComponent("user")
+
+	tz, err := NormalizeTimezone(tz)
+	if err != nil {
+		return err
+	}
+	if tz != u.Timezone {
+		log.Info(ctx, "user timezone updated", "user_id", u.ID, "old_timezone", u.Timezone, "new_timezone", tz)
+		u.Timezone = tz
+		u.Record(UserPreferencesChanged{Base: event.NewBase(u.ID), Field: "timezone", Value: tz})
+	}
+	return nil
+}
+
+// ChangeLocale sets the user's locale, or removes it when locale is empty
+func (u *User) ChangeLocale(ctx context.Context, locale string) error {
+	log := logger.Get().WithLayer("domain").WithComponent("user")
+
+	locale, err := NormalizeLocale(locale)
+	if err != nil {
+		return err
+	}
+	if locale != u.Locale {
+		log.Info(ctx, "user locale updated", "user_id", u.ID, "old_locale", u.Locale, "new_locale", locale)
+		u.Locale = locale
+		u.Record(UserPreferencesChanged{Base: event.NewBase(u.ID), Field: "locale", Value: locale})
+	}
+	return nil
+}
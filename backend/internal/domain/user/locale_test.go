@@ -0,0 +1,76 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestNormalizeTimezone(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                 "",
+		" Europe/Berlin ":  "Europe/Berlin",
+		"UTC":              "UTC",
+		"America/New_York": "America/New_York",
+	} {
+		got, err := NormalizeTimezone(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"Local", "Mars/Olympus", "+02:00", "../etc/passwd"} {
+		_, err := NormalizeTimezone(in)
+		var invalid *errors.ValidationError
+		assert.ErrorAs(t, err, &invalid, in)
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	for in, want := range map[string]string{
+		"":           "",
+		"en-us":      "en-US",
+		"zh_Hans_CN": "zh-Hans-CN",
+		"de":         "de",
+	} {
+		got, err := NormalizeLocale(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"und", "english", "xx-YY", "en--US"} {
+		_, err := NormalizeLocale(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestUser_ChangeLocalePrefs(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	u := &User{ID: "u-1"}
+	assert.Equal(t, time.UTC, u.LocalePrefs().Location())
+
+	require.NoError(t, u.ChangeTimezone(ctx, "Asia/Tokyo"))
+	require.NoError(t, u.ChangeLocale(ctx, "ja-jp"))
+	assert.Equal(t, LocalePrefs{Timezone: "Asia/Tokyo", Locale: "ja-JP"}, u.LocalePrefs())
+	assert.Equal(t, "Asia/Tokyo", u.LocalePrefs().Location().String())
+	events := u.PullEvents()
+	require.Len(t, events, 2)
+	assert.Equal(t, UserPreferencesChanged{Base: events[0].(UserPreferencesChanged).Base, Field: "timezone", Value: "Asia/Tokyo"}, events[0])
+	assert.Equal(t, "locale", events[1].(UserPreferencesChanged).Field)
+
+	require.NoError(t, u.ChangeTimezone(ctx, "Asia/Tokyo"))
+	assert.Empty(t, u.PullEvents(), "an unchanged preference records nothing")
+
+	assert.Error(t, u.ChangeTimezone(ctx, "Nowhere/Special"))
+	assert.Equal(t, "Asia/Tokyo", u.Timezone, "invalid values leave the preference unchanged")
+
+	require.NoError(t, u.ChangeTimezone(ctx, ""))
+	require.NoError(t, u.ChangeLocale(ctx, ""))
+	assert.Equal(t, LocalePrefs{}, u.LocalePrefs())
+}
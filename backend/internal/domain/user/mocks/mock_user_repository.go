@@ -3,7 +3,7 @@
 //
 // Generated by this command:
 //
-//	mockgen -source=internal/domain/user/user.go -destination=internal/domain/user/mocks/mock_user_repository.go -package=mocks UserRepository,UserService
+//	mockgen -source=internal/domain/user/user.go -destination=internal/domain/user/mocks/mock_user_repository.go -package=mocks
 //
 
 // Package mocks is a generated GoMock package.
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	user "github.com/cctw-zed/wonder/internal/domain/user"
 	gomock "go.uber.org/mock/gomock"
@@ -55,6 +56,20 @@ func (mr *MockUserRepositoryMockRecorder) Create(ctx, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, arg1)
 }
 
+// CreateBatch mocks base method.
+func (m *MockUserRepository) CreateBatch(ctx context.Context, users []*user.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, users)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockUserRepositoryMockRecorder) CreateBatch(ctx, users any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockUserRepository)(nil).CreateBatch), ctx, users)
+}
+
 // Delete mocks base method.
 func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -69,6 +84,21 @@ func (mr *MockUserRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserRepository)(nil).Delete), ctx, id)
 }
 
+// ExistingEmails mocks base method.
+func (m *MockUserRepository) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistingEmails", ctx, emails)
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistingEmails indicates an expected call of ExistingEmails.
+func (mr *MockUserRepositoryMockRecorder) ExistingEmails(ctx, emails any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistingEmails", reflect.TypeOf((*MockUserRepository)(nil).ExistingEmails), ctx, emails)
+}
+
 // GetByEmail mocks base method.
 func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -84,6 +114,21 @@ func (mr *MockUserRepositoryMockRecorder) GetByEmail(ctx, email any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetByEmail), ctx, email)
 }
 
+// GetByHandle mocks base method.
+func (m *MockUserRepository) GetByHandle(ctx context.Context, handle string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHandle", ctx, handle)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHandle indicates an expected call of GetByHandle.
+func (mr *MockUserRepositoryMockRecorder) GetByHandle(ctx, handle any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHandle", reflect.TypeOf((*MockUserRepository)(nil).GetByHandle), ctx, handle)
+}
+
 // GetByID mocks base method.
 func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -99,6 +144,21 @@ func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
 }
 
+// GetByIDs mocks base method.
+func (m *MockUserRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].(map[string]*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockUserRepositoryMockRecorder) GetByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockUserRepository)(nil).GetByIDs), ctx, ids)
+}
+
 // List mocks base method.
 func (m *MockUserRepository) List(ctx context.Context, req *user.ListUsersRequest) (*user.ListUsersResponse, error) {
 	m.ctrl.T.Helper()
@@ -114,6 +174,21 @@ func (mr *MockUserRepositoryMockRecorder) List(ctx, req any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserRepository)(nil).List), ctx, req)
 }
 
+// ListDueForDeletion mocks base method.
+func (m *MockUserRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueForDeletion", ctx, now, limit)
+	ret0, _ := ret[0].([]*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueForDeletion indicates an expected call of ListDueForDeletion.
+func (mr *MockUserRepositoryMockRecorder) ListDueForDeletion(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueForDeletion", reflect.TypeOf((*MockUserRepository)(nil).ListDueForDeletion), ctx, now, limit)
+}
+
 // Update mocks base method.
 func (m *MockUserRepository) Update(ctx context.Context, arg1 *user.User) error {
 	m.ctrl.T.Helper()
@@ -128,6 +203,34 @@ func (mr *MockUserRepositoryMockRecorder) Update(ctx, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), ctx, arg1)
 }
 
+// UpdateAvatar mocks base method.
+func (m *MockUserRepository) UpdateAvatar(ctx context.Context, arg1 *user.User, previous string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAvatar", ctx, arg1, previous)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAvatar indicates an expected call of UpdateAvatar.
+func (mr *MockUserRepositoryMockRecorder) UpdateAvatar(ctx, arg1, previous any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAvatar", reflect.TypeOf((*MockUserRepository)(nil).UpdateAvatar), ctx, arg1, previous)
+}
+
+// UpdateIfUnmodified mocks base method.
+func (m *MockUserRepository) UpdateIfUnmodified(ctx context.Context, arg1 *user.User, unmodifiedSince time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIfUnmodified", ctx, arg1, unmodifiedSince)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIfUnmodified indicates an expected call of UpdateIfUnmodified.
+func (mr *MockUserRepositoryMockRecorder) UpdateIfUnmodified(ctx, arg1, unmodifiedSince any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIfUnmodified", reflect.TypeOf((*MockUserRepository)(nil).UpdateIfUnmodified), ctx, arg1, unmodifiedSince)
+}
+
 // MockUserService is a mock of UserService interface.
 type MockUserService struct {
 	ctrl     *gomock.Controller
@@ -152,6 +255,21 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// CancelDeletion mocks base method.
+func (m *MockUserService) CancelDeletion(ctx context.Context, id string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDeletion", ctx, id)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDeletion indicates an expected call of CancelDeletion.
+func (mr *MockUserServiceMockRecorder) CancelDeletion(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDeletion", reflect.TypeOf((*MockUserService)(nil).CancelDeletion), ctx, id)
+}
+
 // ChangePassword mocks base method.
 func (m *MockUserService) ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error {
 	m.ctrl.T.Helper()
@@ -166,6 +284,36 @@ func (mr *MockUserServiceMockRecorder) ChangePassword(ctx, id, oldPassword, newP
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserService)(nil).ChangePassword), ctx, id, oldPassword, newPassword)
 }
 
+// CheckHandleAvailability mocks base method.
+func (m *MockUserService) CheckHandleAvailability(ctx context.Context, handle string) (*user.HandleAvailability, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckHandleAvailability", ctx, handle)
+	ret0, _ := ret[0].(*user.HandleAvailability)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckHandleAvailability indicates an expected call of CheckHandleAvailability.
+func (mr *MockUserServiceMockRecorder) CheckHandleAvailability(ctx, handle any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckHandleAvailability", reflect.TypeOf((*MockUserService)(nil).CheckHandleAvailability), ctx, handle)
+}
+
+// DeactivateUser mocks base method.
+func (m *MockUserService) DeactivateUser(ctx context.Context, id, reason string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateUser", ctx, id, reason)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeactivateUser indicates an expected call of DeactivateUser.
+func (mr *MockUserServiceMockRecorder) DeactivateUser(ctx, id, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateUser", reflect.TypeOf((*MockUserService)(nil).DeactivateUser), ctx, id, reason)
+}
+
 // DeleteUser mocks base method.
 func (m *MockUserService) DeleteUser(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -180,6 +328,21 @@ func (mr *MockUserServiceMockRecorder) DeleteUser(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserService)(nil).DeleteUser), ctx, id)
 }
 
+// GetByHandle mocks base method.
+func (m *MockUserService) GetByHandle(ctx context.Context, handle string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHandle", ctx, handle)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHandle indicates an expected call of GetByHandle.
+func (mr *MockUserServiceMockRecorder) GetByHandle(ctx, handle any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHandle", reflect.TypeOf((*MockUserService)(nil).GetByHandle), ctx, handle)
+}
+
 // GetProfile mocks base method.
 func (m *MockUserService) GetProfile(ctx context.Context, id string) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -225,6 +388,36 @@ func (mr *MockUserServiceMockRecorder) Login(ctx, email, password any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUserService)(nil).Login), ctx, email, password)
 }
 
+// PurgeDueDeletions mocks base method.
+func (m *MockUserService) PurgeDueDeletions(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDueDeletions", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDueDeletions indicates an expected call of PurgeDueDeletions.
+func (mr *MockUserServiceMockRecorder) PurgeDueDeletions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDueDeletions", reflect.TypeOf((*MockUserService)(nil).PurgeDueDeletions), ctx)
+}
+
+// ReactivateUser mocks base method.
+func (m *MockUserService) ReactivateUser(ctx context.Context, id string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReactivateUser", ctx, id)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReactivateUser indicates an expected call of ReactivateUser.
+func (mr *MockUserServiceMockRecorder) ReactivateUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReactivateUser", reflect.TypeOf((*MockUserService)(nil).ReactivateUser), ctx, id)
+}
+
 // Register mocks base method.
 func (m *MockUserService) Register(ctx context.Context, email, name, password string) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -240,6 +433,65 @@ func (mr *MockUserServiceMockRecorder) Register(ctx, email, name, password any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUserService)(nil).Register), ctx, email, name, password)
 }
 
+// RegisterImported mocks base method.
+func (m *MockUserService) RegisterImported(ctx context.Context, rows []user.ImportRow) (*user.ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterImported", ctx, rows)
+	ret0, _ := ret[0].(*user.ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterImported indicates an expected call of RegisterImported.
+func (mr *MockUserServiceMockRecorder) RegisterImported(ctx, rows any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterImported", reflect.TypeOf((*MockUserService)(nil).RegisterImported), ctx, rows)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUserService) ResetPassword(ctx context.Context, id, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, id, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserServiceMockRecorder) ResetPassword(ctx, id, newPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserService)(nil).ResetPassword), ctx, id, newPassword)
+}
+
+// ScheduleDeletion mocks base method.
+func (m *MockUserService) ScheduleDeletion(ctx context.Context, id string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleDeletion", ctx, id)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleDeletion indicates an expected call of ScheduleDeletion.
+func (mr *MockUserServiceMockRecorder) ScheduleDeletion(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleDeletion", reflect.TypeOf((*MockUserService)(nil).ScheduleDeletion), ctx, id)
+}
+
+// SuspendUser mocks base method.
+func (m *MockUserService) SuspendUser(ctx context.Context, id, reason string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", ctx, id, reason)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockUserServiceMockRecorder) SuspendUser(ctx, id, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockUserService)(nil).SuspendUser), ctx, id, reason)
+}
+
 // UpdateProfile mocks base method.
 func (m *MockUserService) UpdateProfile(ctx context.Context, id string, req *user.UpdateProfileRequest) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -254,3 +506,377 @@ func (mr *MockUserServiceMockRecorder) UpdateProfile(ctx, id, req any) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserService)(nil).UpdateProfile), ctx, id, req)
 }
+
+// MockPasswordBreachChecker is a mock of PasswordBreachChecker interface.
+type MockPasswordBreachChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordBreachCheckerMockRecorder
+	isgomock struct{}
+}
+
+// MockPasswordBreachCheckerMockRecorder is the mock recorder for MockPasswordBreachChecker.
+type MockPasswordBreachCheckerMockRecorder struct {
+	mock *MockPasswordBreachChecker
+}
+
+// NewMockPasswordBreachChecker creates a new mock instance.
+func NewMockPasswordBreachChecker(ctrl *gomock.Controller) *MockPasswordBreachChecker {
+	mock := &MockPasswordBreachChecker{ctrl: ctrl}
+	mock.recorder = &MockPasswordBreachCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordBreachChecker) EXPECT() *MockPasswordBreachCheckerMockRecorder {
+	return m.recorder
+}
+
+// BreachCount mocks base method.
+func (m *MockPasswordBreachChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BreachCount", ctx, password)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BreachCount indicates an expected call of BreachCount.
+func (mr *MockPasswordBreachCheckerMockRecorder) BreachCount(ctx, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BreachCount", reflect.TypeOf((*MockPasswordBreachChecker)(nil).BreachCount), ctx, password)
+}
+
+// MockLoginAttemptStore is a mock of LoginAttemptStore interface.
+type MockLoginAttemptStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockLoginAttemptStoreMockRecorder is the mock recorder for MockLoginAttemptStore.
+type MockLoginAttemptStoreMockRecorder struct {
+	mock *MockLoginAttemptStore
+}
+
+// NewMockLoginAttemptStore creates a new mock instance.
+func NewMockLoginAttemptStore(ctrl *gomock.Controller) *MockLoginAttemptStore {
+	mock := &MockLoginAttemptStore{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttemptStore) EXPECT() *MockLoginAttemptStoreMockRecorder {
+	return m.recorder
+}
+
+// Failures mocks base method.
+func (m *MockLoginAttemptStore) Failures(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Failures", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Failures indicates an expected call of Failures.
+func (mr *MockLoginAttemptStoreMockRecorder) Failures(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Failures", reflect.TypeOf((*MockLoginAttemptStore)(nil).Failures), ctx, key)
+}
+
+// Lock mocks base method.
+func (m *MockLoginAttemptStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockLoginAttemptStoreMockRecorder) Lock(ctx, key, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockLoginAttemptStore)(nil).Lock), ctx, key, d)
+}
+
+// LockedFor mocks base method.
+func (m *MockLoginAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockedFor", ctx, key)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockedFor indicates an expected call of LockedFor.
+func (mr *MockLoginAttemptStoreMockRecorder) LockedFor(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockedFor", reflect.TypeOf((*MockLoginAttemptStore)(nil).LockedFor), ctx, key)
+}
+
+// RecordFailure mocks base method.
+func (m *MockLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", ctx, key, window)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockLoginAttemptStoreMockRecorder) RecordFailure(ctx, key, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockLoginAttemptStore)(nil).RecordFailure), ctx, key, window)
+}
+
+// Reset mocks base method.
+func (m *MockLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLoginAttemptStoreMockRecorder) Reset(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLoginAttemptStore)(nil).Reset), ctx, key)
+}
+
+// MockCaptchaVerifier is a mock of CaptchaVerifier interface.
+type MockCaptchaVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockCaptchaVerifierMockRecorder
+	isgomock struct{}
+}
+
+// MockCaptchaVerifierMockRecorder is the mock recorder for MockCaptchaVerifier.
+type MockCaptchaVerifierMockRecorder struct {
+	mock *MockCaptchaVerifier
+}
+
+// NewMockCaptchaVerifier creates a new mock instance.
+func NewMockCaptchaVerifier(ctrl *gomock.Controller) *MockCaptchaVerifier {
+	mock := &MockCaptchaVerifier{ctrl: ctrl}
+	mock.recorder = &MockCaptchaVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCaptchaVerifier) EXPECT() *MockCaptchaVerifierMockRecorder {
+	return m.recorder
+}
+
+// Verify mocks base method.
+func (m *MockCaptchaVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, token, remoteIP)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockCaptchaVerifierMockRecorder) Verify(ctx, token, remoteIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockCaptchaVerifier)(nil).Verify), ctx, token, remoteIP)
+}
+
+// MockSignupCounter is a mock of SignupCounter interface.
+type MockSignupCounter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSignupCounterMockRecorder
+	isgomock struct{}
+}
+
+// MockSignupCounterMockRecorder is the mock recorder for MockSignupCounter.
+type MockSignupCounterMockRecorder struct {
+	mock *MockSignupCounter
+}
+
+// NewMockSignupCounter creates a new mock instance.
+func NewMockSignupCounter(ctrl *gomock.Controller) *MockSignupCounter {
+	mock := &MockSignupCounter{ctrl: ctrl}
+	mock.recorder = &MockSignupCounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSignupCounter) EXPECT() *MockSignupCounterMockRecorder {
+	return m.recorder
+}
+
+// Increment mocks base method.
+func (m *MockSignupCounter) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Increment", ctx, key, window)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(time.Duration)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Increment indicates an expected call of Increment.
+func (mr *MockSignupCounterMockRecorder) Increment(ctx, key, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Increment", reflect.TypeOf((*MockSignupCounter)(nil).Increment), ctx, key, window)
+}
+
+// Release mocks base method.
+func (m *MockSignupCounter) Release(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockSignupCounterMockRecorder) Release(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockSignupCounter)(nil).Release), ctx, key)
+}
+
+// MockDisposableDomainProvider is a mock of DisposableDomainProvider interface.
+type MockDisposableDomainProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockDisposableDomainProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockDisposableDomainProviderMockRecorder is the mock recorder for MockDisposableDomainProvider.
+type MockDisposableDomainProviderMockRecorder struct {
+	mock *MockDisposableDomainProvider
+}
+
+// NewMockDisposableDomainProvider creates a new mock instance.
+func NewMockDisposableDomainProvider(ctrl *gomock.Controller) *MockDisposableDomainProvider {
+	mock := &MockDisposableDomainProvider{ctrl: ctrl}
+	mock.recorder = &MockDisposableDomainProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDisposableDomainProvider) EXPECT() *MockDisposableDomainProviderMockRecorder {
+	return m.recorder
+}
+
+// IsDisposable mocks base method.
+func (m *MockDisposableDomainProvider) IsDisposable(ctx context.Context, domain string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDisposable", ctx, domain)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsDisposable indicates an expected call of IsDisposable.
+func (mr *MockDisposableDomainProviderMockRecorder) IsDisposable(ctx, domain any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDisposable", reflect.TypeOf((*MockDisposableDomainProvider)(nil).IsDisposable), ctx, domain)
+}
+
+// MockAuthProvider is a mock of AuthProvider interface.
+type MockAuthProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthProviderMockRecorder is the mock recorder for MockAuthProvider.
+type MockAuthProviderMockRecorder struct {
+	mock *MockAuthProvider
+}
+
+// NewMockAuthProvider creates a new mock instance.
+func NewMockAuthProvider(ctrl *gomock.Controller) *MockAuthProvider {
+	mock := &MockAuthProvider{ctrl: ctrl}
+	mock.recorder = &MockAuthProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthProvider) EXPECT() *MockAuthProviderMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAuthProvider) Authenticate(ctx context.Context, login, password string) (*user.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, login, password)
+	ret0, _ := ret[0].(*user.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAuthProviderMockRecorder) Authenticate(ctx, login, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAuthProvider)(nil).Authenticate), ctx, login, password)
+}
+
+// Name mocks base method.
+func (m *MockAuthProvider) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockAuthProviderMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockAuthProvider)(nil).Name))
+}
+
+// MockBootstrapRepository is a mock of BootstrapRepository interface.
+type MockBootstrapRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBootstrapRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockBootstrapRepositoryMockRecorder is the mock recorder for MockBootstrapRepository.
+type MockBootstrapRepositoryMockRecorder struct {
+	mock *MockBootstrapRepository
+}
+
+// NewMockBootstrapRepository creates a new mock instance.
+func NewMockBootstrapRepository(ctrl *gomock.Controller) *MockBootstrapRepository {
+	mock := &MockBootstrapRepository{ctrl: ctrl}
+	mock.recorder = &MockBootstrapRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBootstrapRepository) EXPECT() *MockBootstrapRepositoryMockRecorder {
+	return m.recorder
+}
+
+// IsCompleted mocks base method.
+func (m *MockBootstrapRepository) IsCompleted(ctx context.Context, step string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsCompleted", ctx, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsCompleted indicates an expected call of IsCompleted.
+func (mr *MockBootstrapRepositoryMockRecorder) IsCompleted(ctx, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsCompleted", reflect.TypeOf((*MockBootstrapRepository)(nil).IsCompleted), ctx, step)
+}
+
+// MarkCompleted mocks base method.
+func (m *MockBootstrapRepository) MarkCompleted(ctx context.Context, step, subjectID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkCompleted", ctx, step, subjectID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkCompleted indicates an expected call of MarkCompleted.
+func (mr *MockBootstrapRepositoryMockRecorder) MarkCompleted(ctx, step, subjectID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkCompleted", reflect.TypeOf((*MockBootstrapRepository)(nil).MarkCompleted), ctx, step, subjectID)
+}
@@ -0,0 +1,112 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// MaxSearchTerms caps the words of a search query that are matched
+const MaxSearchTerms = 8
+
+// SearchRequest is a free-text search over user names and emails
+type SearchRequest struct {
+	Query    string `json:"q" form:"q" binding:"required,min=2,max=100"`
+	Page     int    `json:"page" form:"page" binding:"min=1"`
+	PageSize int    `json:"page_size" form:"page_size" binding:"min=1,max=100"`
+}
+
+// SearchHit is a matching user with its relevance and the matched parts of
+// its fields
+type SearchHit struct {
+	User *User   `json:"user"`
+	Rank float64 `json:"rank"`
+	// Highlights splits name and email into matched and unmatched
+	// fragments; fields without a match are omitted
+	Highlights map[string][]Fragment `json:"highlights,omitempty"`
+}
+
+// Fragment is a piece of a highlighted field
+type Fragment struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match,omitempty"`
+}
+
+// SearchResponse is one page of search hits, best match first
+type SearchResponse struct {
+	Hits       []*SearchHit `json:"hits"`
+	Total      int64        `json:"total"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	TotalPages int          `json:"total_pages"`
+}
+
+// Searcher finds users of the tenant of ctx by free text. Implementations
+// rank hits but leave highlighting to the caller.
+type Searcher interface {
+	Search(ctx context.Context, terms []string, page, pageSize int) (*SearchResponse, error)
+}
+
+// SearchTerms splits a query into lowercase words of letters and digits,
+// dropping repeats. Punctuation separates words, so an email address
+// yields its parts.
+func SearchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, w)
+		if len(terms) == MaxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// Highlight splits text into fragments, marking case-insensitive
+// occurrences of terms. It returns nil when no term occurs.
+func Highlight(text string, terms []string) []Fragment {
+	lower := []rune(strings.ToLower(text))
+	runes := []rune(text)
+	if len(lower) != len(runes) {
+		// Lowercasing changed the length; match on the text as is
+		lower = runes
+	}
+
+	matched := make([]bool, len(runes))
+	found := false
+	for _, term := range terms {
+		t := []rune(term)
+		if len(t) == 0 {
+			continue
+		}
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) == term {
+				for j := i; j < i+len(t); j++ {
+					matched[j] = true
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	var fragments []Fragment
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || matched[i] != matched[start] {
+			fragments = append(fragments, Fragment{Text: string(runes[start:i]), Match: matched[start]})
+			start = i
+		}
+	}
+	return fragments
+}
@@ -0,0 +1,34 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"alice", "smith"}, SearchTerms("  Alice SMITH alice "))
+	assert.Equal(t, []string{"bob", "example", "com"}, SearchTerms("bob@example.com"))
+	assert.Empty(t, SearchTerms("@@ -- .."))
+
+	many := SearchTerms("a b c d e f g h i j")
+	assert.Len(t, many, MaxSearchTerms)
+	assert.Equal(t, "a", many[0])
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, []Fragment{
+		{Text: "Al", Match: true},
+		{Text: "ice "},
+		{Text: "Smi", Match: true},
+		{Text: "th"},
+	}, Highlight("Alice Smith", []string{"al", "smi"}))
+
+	// Overlapping terms merge into one fragment
+	assert.Equal(t, []Fragment{
+		{Text: "alice", Match: true},
+		{Text: "@example.com"},
+	}, Highlight("alice@example.com", []string{"ali", "ice"}))
+
+	assert.Nil(t, Highlight("Bob", []string{"al"}))
+}
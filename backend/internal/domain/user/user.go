@@ -2,32 +2,120 @@ package user
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"regexp"
+	"slices"
+	"strconv"
 	"time"
 
+	"github.com/cctw-zed/wonder/internal/domain/event"
 	"github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User statuses. Active users can sign in; suspended users are blocked
+// until an admin reactivates them; deactivated accounts are closed.
+const (
+	StatusActive      = "active"
+	StatusSuspended   = "suspended"
+	StatusDeactivated = "deactivated"
+)
+
+// statusTransitions lists the statuses each status may move to
+var statusTransitions = map[string][]string{
+	StatusActive:      {StatusSuspended, StatusDeactivated},
+	StatusSuspended:   {StatusActive, StatusDeactivated},
+	StatusDeactivated: {StatusActive},
+}
+
 // User 用户聚合根
 type User struct {
 	ID           string    `gorm:"primaryKey;type:varchar(64)" json:"id"`
-	Email        string    `gorm:"uniqueIndex:idx_users_email_unique;type:varchar(255);not null" json:"email"`
-	Name         string    `gorm:"type:varchar(100);not null" json:"name"`
+	TenantID     string    `gorm:"uniqueIndex:idx_users_tenant_email_unique,priority:1;uniqueIndex:idx_users_tenant_email_index_unique,priority:1;uniqueIndex:idx_users_tenant_canonical_email_unique,priority:1;uniqueIndex:idx_users_tenant_handle_unique,priority:1;type:varchar(64);not null;default:default" json:"tenant_id"`
+	Email        string    `gorm:"uniqueIndex:idx_users_tenant_email_unique,priority:2;type:text;not null;serializer:pii" json:"email"`
+	Name         string    `gorm:"type:text;not null;serializer:pii" json:"name"`
 	PasswordHash string    `gorm:"type:varchar(255);not null" json:"-"`
+	Role         string    `gorm:"type:varchar(20);not null;default:user" json:"role"`
+	Status       string    `gorm:"type:varchar(20);not null;default:active" json:"status"`
 	CreatedAt    time.Time `gorm:"not null" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"not null" json:"updated_at"`
+
+	// DeletionScheduledAt is when a deletion the user requested takes
+	// effect; nil when none is pending
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletion_scheduled_at,omitempty"`
+
+	// EmailVerifiedAt is when the user confirmed they own Email; nil until
+	// they do, and again after it changes
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+
+	// EmailIndex is the blind index of Email the repository looks users up
+	// by while personal data is encrypted at rest; nil otherwise
+	EmailIndex *string `gorm:"uniqueIndex:idx_users_tenant_email_index_unique,priority:2;type:varchar(64)" json:"-"`
+
+	// CanonicalEmailKey is the stored form of the canonical email, under
+	// the repository's EmailRules, that duplicates are detected by: the
+	// canonical email, or its blind index while personal data is encrypted.
+	// The repository keeps it in step with Email.
+	CanonicalEmailKey *string `gorm:"column:canonical_email;uniqueIndex:idx_users_tenant_canonical_email_unique,priority:2;type:varchar(255)" json:"-"`
+
+	// Handle is the user's unique public name, normalized and validated by
+	// ChangeHandle; nil when they have none
+	Handle *string `gorm:"uniqueIndex:idx_users_tenant_handle_unique,priority:2;type:varchar(30)" json:"handle,omitempty"`
+
+	// Timezone and Locale are the IANA time zone and BCP 47 locale times
+	// are shown to the user in, set through ChangeTimezone and
+	// ChangeLocale; empty when they have not chosen one
+	Timezone string `gorm:"type:varchar(64);not null;default:''" json:"timezone,omitempty"`
+	Locale   string `gorm:"type:varchar(35);not null;default:''" json:"locale,omitempty"`
+
+	// AvatarKey is the object storage key of the user's avatar; empty when
+	// they have none
+	AvatarKey string `gorm:"type:varchar(255);not null;default:''" json:"-"`
+
+	// ExternalAuth names the AuthProvider, e.g. "ldap", that checks the
+	// user's password; empty for users with a local password. Externally
+	// authenticated users have no password hash and cannot set one.
+	ExternalAuth string `gorm:"type:varchar(32);not null;default:''" json:"external_auth,omitempty"`
+
+	event.Recorder `gorm:"-" json:"-"`
 }
 
 // UserRepository 用户仓储接口
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id string) (*User, error)
+	// GetByIDs returns the users of ids that exist, keyed by ID
+	GetByIDs(ctx context.Context, ids []string) (map[string]*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	// GetByHandle finds a user by normalized handle
+	GetByHandle(ctx context.Context, handle string) (*User, error)
 	Update(ctx context.Context, user *User) error
+	// UpdateIfUnmodified updates user like Update, but only while its
+	// stored UpdatedAt is still unmodifiedSince; otherwise nothing is
+	// written and it fails with a version mismatch
+	UpdateIfUnmodified(ctx context.Context, user *User, unmodifiedSince time.Time) error
+	// UpdateAvatar writes only user's avatar key and update time, and only
+	// while the stored avatar key is still previous; otherwise nothing is
+	// written and it fails with a version mismatch
+	UpdateAvatar(ctx context.Context, user *User, previous string) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, req *ListUsersRequest) (*ListUsersResponse, error)
+	// ExistingEmails returns which of emails are already registered, keyed
+	// by canonical email
+	ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error)
+	// CreateBatch inserts users in a single statement
+	CreateBatch(ctx context.Context, users []*User) error
+	// ListDueForDeletion returns up to limit users of every tenant whose
+	// scheduled deletion is at or before now, oldest first
+	ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*User, error)
 }
 
 // UserService 用户领域服务接口
@@ -35,24 +123,216 @@ type UserService interface {
 	Register(ctx context.Context, email, name, password string) (*User, error)
 	Login(ctx context.Context, email, password string) (*User, error)
 	GetProfile(ctx context.Context, id string) (*User, error)
+	// GetByHandle returns the profile of the user holding handle
+	GetByHandle(ctx context.Context, handle string) (*User, error)
+	// CheckHandleAvailability tells whether handle can be claimed
+	CheckHandleAvailability(ctx context.Context, handle string) (*HandleAvailability, error)
 	UpdateProfile(ctx context.Context, id string, req *UpdateProfileRequest) (*User, error)
 	ChangePassword(ctx context.Context, id string, oldPassword, newPassword string) error
+	// ResetPassword sets a new password without verifying the old one; it
+	// is reserved for administrators
+	ResetPassword(ctx context.Context, id string, newPassword string) error
 	ListUsers(ctx context.Context, req *ListUsersRequest) (*ListUsersResponse, error)
 	DeleteUser(ctx context.Context, id string) error
+	// SuspendUser blocks a user from signing in until reactivated; it is
+	// reserved for administrators
+	SuspendUser(ctx context.Context, id, reason string) (*User, error)
+	// DeactivateUser closes an account without deleting it, until it is
+	// reactivated; it is reserved for administrators
+	DeactivateUser(ctx context.Context, id, reason string) (*User, error)
+	// ReactivateUser lets a suspended or deactivated user sign in again; it
+	// is reserved for administrators
+	ReactivateUser(ctx context.Context, id string) (*User, error)
+	// ScheduleDeletion deletes the user's own account once the configured
+	// grace period has passed, or at once when there is none. The returned
+	// user is nil when the account was deleted immediately.
+	ScheduleDeletion(ctx context.Context, id string) (*User, error)
+	// CancelDeletion keeps an account whose deletion is still pending
+	CancelDeletion(ctx context.Context, id string) (*User, error)
+	// PurgeDueDeletions deletes accounts of every tenant whose grace period
+	// has passed and returns how many were deleted
+	PurgeDueDeletions(ctx context.Context) (int, error)
+	// RegisterImported registers validated import rows in one transaction
+	// as Register would, skipping emails that are already registered
+	RegisterImported(ctx context.Context, rows []ImportRow) (*ImportResult, error)
+}
+
+// PasswordBreachChecker reports how often a password appears in known data breaches
+type PasswordBreachChecker interface {
+	BreachCount(ctx context.Context, password string) (int, error)
+}
+
+// LoginAttemptStore counts failed logins and holds lockouts. Keys identify
+// what is being throttled, e.g. an account or a client IP.
+type LoginAttemptStore interface {
+	// RecordFailure increments the failure count for key and returns it.
+	// The count expires window after the first failure.
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Lock blocks key for d and clears its failure count
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor returns how long key remains locked, or zero
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Reset clears the failure count for key
+	Reset(ctx context.Context, key string) error
+	// Failures returns the current failure count for key
+	Failures(ctx context.Context, key string) (int64, error)
+}
+
+// CaptchaVerifier checks the response token a client got by solving a
+// CAPTCHA challenge
+type CaptchaVerifier interface {
+	// Verify reports whether token is a valid, unused solution. remoteIP
+	// is the solver's address, or empty when unknown.
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
 }
 
-// UpdateProfileRequest represents the request to update user profile
+// SignupCounter counts registrations in fixed windows. Keys identify what
+// is being throttled, e.g. an email domain or a client IP.
+type SignupCounter interface {
+	// Increment counts a registration for key and returns the count and
+	// how long remains until it expires, window after the first one
+	Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+	// Release takes back one Increment of key, e.g. for a registration that
+	// failed after it was counted. The expiry is left as it is.
+	Release(ctx context.Context, key string) error
+}
+
+// DisposableDomainProvider knows the domains of throwaway email services
+type DisposableDomainProvider interface {
+	// IsDisposable reports whether domain, or a domain it belongs to, is
+	// disposable. domain is lower case.
+	IsDisposable(ctx context.Context, domain string) (bool, error)
+}
+
+// Identity is a user as an external directory describes them
+type Identity struct {
+	// Subject identifies the user in the directory, e.g. an LDAP DN
+	Subject string
+	Email   string
+	Name    string
+}
+
+// AuthProvider checks passwords against a directory other than the users
+// table, such as LDAP. Rejected credentials are reported as an
+// UnauthorizedError; any other error means the directory could not be
+// asked.
+type AuthProvider interface {
+	// Name identifies the provider in logs and audit entries, e.g. "ldap"
+	Name() string
+	Authenticate(ctx context.Context, login, password string) (*Identity, error)
+}
+
+// BootstrapMarker records a completed one-time setup step
+type BootstrapMarker struct {
+	Step        string    `gorm:"primaryKey;type:varchar(64)" json:"step"`
+	SubjectID   string    `gorm:"type:varchar(64);not null" json:"subject_id"`
+	CompletedAt time.Time `gorm:"not null" json:"completed_at"`
+}
+
+// BootstrapRepository records one-time setup steps so they cannot run twice
+type BootstrapRepository interface {
+	IsCompleted(ctx context.Context, step string) (bool, error)
+	MarkCompleted(ctx context.Context, step, subjectID string) error
+}
+
+// UpdateProfileRequest represents the request to update user profile. Nil
+// fields are left unchanged; a set field is applied even when empty, so
+// clearing a required field fails validation instead of being ignored.
 type UpdateProfileRequest struct {
-	Email string `json:"email,omitempty"`
-	Name  string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+	Name  *string `json:"name,omitempty"`
+	// Handle set to empty removes the user's handle
+	Handle *string `json:"handle,omitempty"`
+	// Timezone and Locale set to empty remove the preference
+	Timezone *string `json:"timezone,omitempty"`
+	Locale   *string `json:"locale,omitempty"`
+	// IfVersion, when set, applies the update only if the user's current
+	// Version is one of the listed versions
+	IfVersion []string `json:"-"`
 }
 
+// Columns users can be sorted by
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByName      = "name"
+	SortByEmail     = "email"
+)
+
+// Sort directions
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// How ListUsersResponse.Total is obtained
+const (
+	// TotalExact counts the matching users
+	TotalExact = "exact"
+	// TotalEstimate takes the database's row estimate where it has one
+	TotalEstimate = "estimate"
+	// TotalNone skips counting
+	TotalNone = "none"
+)
+
 // ListUsersRequest represents the request to list users with pagination
 type ListUsersRequest struct {
-	Page     int    `json:"page" binding:"min=1"`
-	PageSize int    `json:"page_size" binding:"min=1,max=100"`
-	Email    string `json:"email,omitempty"`
-	Name     string `json:"name,omitempty"`
+	Page     int    `json:"page" form:"page" binding:"min=1"`
+	PageSize int    `json:"page_size" form:"page_size" binding:"min=1,max=100"`
+	Email    string `json:"email,omitempty" form:"email" binding:"max=255"`
+	Name     string `json:"name,omitempty" form:"name" binding:"max=100"`
+	// Cursor continues after a previous page's NextCursor and takes
+	// precedence over Page. A cursor is only valid with the SortBy it was
+	// issued for.
+	Cursor string `json:"cursor,omitempty" form:"cursor" binding:"max=512"`
+	// SortBy defaults to created_at and SortOrder to desc; ID breaks ties
+	SortBy    string `json:"sort_by,omitempty" form:"sort_by" binding:"omitempty,oneof=created_at updated_at name email"`
+	SortOrder string `json:"sort_order,omitempty" form:"sort_order" binding:"omitempty,oneof=asc desc"`
+	// CreatedAfter and CreatedBefore bound the creation time, exclusive;
+	// zero values leave the bound open
+	CreatedAfter  time.Time `json:"created_after,omitempty" form:"created_after"`
+	CreatedBefore time.Time `json:"created_before,omitempty" form:"created_before"`
+	// Roles keeps users having any of the roles
+	Roles []string `json:"role,omitempty" form:"role" binding:"max=2,dive,oneof=user admin"`
+	// Statuses keeps users having any of the statuses
+	Statuses []string `json:"status,omitempty" form:"status" binding:"max=3,dive,oneof=active suspended deactivated"`
+	// IncludeTotal selects how Total is obtained and defaults to exact
+	IncludeTotal string `json:"include_total,omitempty" form:"include_total" binding:"omitempty,oneof=exact estimate none"`
+}
+
+// ImportRow is one user record read from an import file
+type ImportRow struct {
+	// Row is the 1-based position of the record in the file, not counting a header
+	Row      int    `json:"row"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// ImportRowError explains why one row was not imported
+type ImportRowError struct {
+	Row    int                 `json:"row"`
+	Email  string              `json:"email,omitempty"`
+	Fields []errors.FieldError `json:"fields"`
+}
+
+// ImportReport summarizes a user import. Every row that was not created
+// has an entry in Errors.
+type ImportReport struct {
+	Total      int              `json:"total"`
+	Created    int              `json:"created"`
+	Duplicates int              `json:"duplicates"`
+	Invalid    int              `json:"invalid"`
+	Errors     []ImportRowError `json:"errors"`
+}
+
+// ImportResult is what RegisterImported did with a batch of rows
+type ImportResult struct {
+	Created []*User
+	// Duplicates are the rows whose email is already registered
+	Duplicates []ImportRowError
+	// Rejected are the rows whose password was refused
+	Rejected []ImportRowError
 }
 
 // ListUsersResponse represents the response for list users
@@ -62,6 +342,13 @@ type ListUsersResponse struct {
 	Page       int     `json:"page"`
 	PageSize   int     `json:"page_size"`
 	TotalPages int     `json:"total_pages"`
+	// TotalKind is how Total was obtained: TotalExact, TotalEstimate, or
+	// TotalNone when Total and TotalPages are zero because nothing was
+	// counted. Estimates fall back to exact counts where the database has
+	// none.
+	TotalKind string `json:"total_kind"`
+	// NextCursor is set when more users follow this page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // Validate validates the user entity
@@ -101,6 +388,160 @@ func (u *User) IsEmailValid() bool {
 	return emailRegex.MatchString(u.Email)
 }
 
+// IsAdmin reports whether the user holds the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// Version identifies the stored state of the user and changes with every
+// update. Microsecond precision matches what databases keep of UpdatedAt.
+func (u *User) Version() string {
+	// Built on the stack rather than with fmt: the version is computed for
+	// every profile read to set its ETag
+	buf := make([]byte, 0, 64)
+	buf = append(buf, u.ID...)
+	buf = append(buf, ':')
+	buf = strconv.AppendInt(buf, u.UpdatedAt.UnixMicro(), 10)
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:8])
+}
+
+// PromoteToAdmin grants the admin role as part of the initial admin bootstrap
+func (u *User) PromoteToAdmin() {
+	u.Role = RoleAdmin
+	u.Record(UserAdminBootstrapped{Base: event.NewBase(u.ID), Email: u.Email})
+}
+
+// CurrentStatus returns the user's status; users stored before statuses
+// existed are active
+func (u *User) CurrentStatus() string {
+	if u.Status == "" {
+		return StatusActive
+	}
+	return u.Status
+}
+
+// IsActive reports whether the user may sign in
+func (u *User) IsActive() bool {
+	return u.CurrentStatus() == StatusActive
+}
+
+// Suspend blocks the user from signing in until reactivated
+func (u *User) Suspend(reason string) error {
+	if err := u.transitionTo(StatusSuspended, reason); err != nil {
+		return err
+	}
+	u.Record(UserSuspended{Base: event.NewBase(u.ID), Email: u.Email, Reason: reason})
+	return nil
+}
+
+// Reactivate lets a suspended or deactivated user sign in again
+func (u *User) Reactivate() error {
+	if err := u.transitionTo(StatusActive, ""); err != nil {
+		return err
+	}
+	u.Record(UserReactivated{Base: event.NewBase(u.ID), Email: u.Email})
+	return nil
+}
+
+// Deactivate closes the account without deleting it
+func (u *User) Deactivate(reason string) error {
+	if err := u.transitionTo(StatusDeactivated, reason); err != nil {
+		return err
+	}
+	u.Record(UserDeactivated{Base: event.NewBase(u.ID), Email: u.Email, Reason: reason})
+	return nil
+}
+
+// DeletionPending reports whether the user has asked for their account to
+// be deleted
+func (u *User) DeletionPending() bool {
+	return u.DeletionScheduledAt != nil
+}
+
+// DeletionDue reports whether a pending deletion takes effect by now
+func (u *User) DeletionDue(now time.Time) bool {
+	return u.DeletionPending() && !u.DeletionScheduledAt.After(now)
+}
+
+// ScheduleDeletion marks the account for deletion at deleteAt
+func (u *User) ScheduleDeletion(deleteAt time.Time) error {
+	if u.DeletionPending() {
+		return errors.NewInvalidStateError(errors.CodeInvalidState, "user", u.ID, "deletion is already scheduled")
+	}
+	deleteAt = deleteAt.UTC()
+	u.DeletionScheduledAt = &deleteAt
+	u.Record(UserDeletionScheduled{Base: event.NewBase(u.ID), Email: u.Email, Name: u.Name, DeleteAt: deleteAt, LocalePrefs: u.LocalePrefs()})
+	return nil
+}
+
+// CancelDeletion keeps an account whose deletion is pending
+func (u *User) CancelDeletion() error {
+	if !u.DeletionPending() {
+		return errors.NewInvalidStateError(errors.CodeInvalidState, "user", u.ID, "no deletion is scheduled")
+	}
+	u.DeletionScheduledAt = nil
+	u.Record(UserDeletionCancelled{Base: event.NewBase(u.ID), Email: u.Email, Name: u.Name})
+	return nil
+}
+
+// EmailVerified reports whether the user confirmed their current email
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// VerifyEmail records that the user confirmed they own email at the given
+// time. It fails when email is no longer the user's address, so a link
+// sent to a previous address cannot verify the current one.
+func (u *User) VerifyEmail(email string, at time.Time) error {
+	if email != u.Email {
+		return errors.NewInvalidStateError(errors.CodeInvalidState, "user", u.ID, "email address changed since the verification link was sent")
+	}
+	if u.EmailVerified() {
+		return nil
+	}
+	u.EmailVerifiedAt = &at
+	return nil
+}
+
+// transitionTo moves the user to status if the current status allows it
+func (u *User) transitionTo(status, reason string) error {
+	from := u.CurrentStatus()
+	if !slices.Contains(statusTransitions[from], status) {
+		if from == status {
+			return errors.NewStateTransitionError("user", from, status, "user is already "+status)
+		}
+		return errors.NewStateTransitionError("user", from, status, "transition not allowed")
+	}
+	u.Status = status
+	return nil
+}
+
+// MarkRegistered records that the user account has been created
+func (u *User) MarkRegistered() {
+	u.Record(UserRegistered{Base: event.NewBase(u.ID), Email: u.Email, Name: u.Name})
+}
+
+// MarkPasswordReset records that an administrator has set a new password
+func (u *User) MarkPasswordReset() {
+	u.Record(UserPasswordReset{Base: event.NewBase(u.ID), Email: u.Email, Name: u.Name})
+}
+
+// MarkDeleted records that the user account has been removed. scheduled
+// is set when the purge removed it after the grace period of a deletion
+// the user requested; an admin deleting an account whose deletion is still
+// pending is not one.
+func (u *User) MarkDeleted(scheduled bool) {
+	u.Record(UserDeleted{Base: event.NewBase(u.ID), Email: u.Email, Name: u.Name, Scheduled: scheduled, AvatarKey: u.AvatarKey})
+}
+
+// ChangeAvatar sets the avatar to the object under key, or removes it when
+// key is empty, and returns the key of the avatar it replaced
+func (u *User) ChangeAvatar(key string) (previous string) {
+	previous, u.AvatarKey = u.AvatarKey, key
+	return previous
+}
+
 // UpdateName updates the user's name
 func (u *User) UpdateName(ctx context.Context, name string) error {
 	log := logger.Get().WithLayer("domain").WithComponent("user")
@@ -116,6 +557,10 @@ func (u *User) UpdateName(ctx context.Context, name string) error {
 	oldName := u.Name
 	u.Name = name
 
+	if oldName != name {
+		u.Record(UserNameChanged{Base: event.NewBase(u.ID), OldName: oldName, NewName: name})
+	}
+
 	log.Info(ctx, "user name updated", "user_id", u.ID, "old_name", oldName, "new_name", name)
 	return nil
 }
@@ -141,6 +586,11 @@ func (u *User) UpdateEmail(ctx context.Context, email string) error {
 	oldEmail := u.Email
 	u.Email = email
 
+	if oldEmail != email {
+		u.EmailVerifiedAt = nil
+		u.Record(UserEmailChanged{Base: event.NewBase(u.ID), OldEmail: oldEmail, NewEmail: email})
+	}
+
 	log.Info(ctx, "user email updated", "user_id", u.ID, "old_email", oldEmail, "new_email", email)
 	return nil
 }
@@ -157,6 +607,10 @@ func (u *User) SetPassword(ctx context.Context, password string) error {
 		return errors.NewRequiredFieldError("password", password)
 	}
 
+	if u.ExternalAuth != "" {
+		return errors.NewInvalidStateError(errors.CodeBusinessLogicError, "user", u.ID, "password is managed by "+u.ExternalAuth)
+	}
+
 	if len(password) < 6 {
 		return errors.NewInvalidFormatError("password", password, "at least 6 characters long")
 	}
@@ -184,6 +638,11 @@ func (u *User) CheckPassword(ctx context.Context, password string) error {
 		return errors.NewRequiredFieldError("password", password)
 	}
 
+	if u.ExternalAuth != "" {
+		log.Warn(ctx, "local password check for externally authenticated user", "user_id", u.ID, "provider", u.ExternalAuth)
+		return errors.NewUnauthorizedError("password_verification", u.ID, "user signs in through "+u.ExternalAuth)
+	}
+
 	if u.PasswordHash == "" {
 		log.Warn(ctx, "user has no password set", "user_id", u.ID)
 		return errors.NewInvalidStateError(errors.CodeBusinessLogicError, "user", u.ID, "password not set")
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/logger"
 )
 
@@ -420,3 +421,118 @@ func TestUser_UpdateNameAdvanced(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_Version(t *testing.T) {
+	updated := time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	u := &User{ID: "user-1", UpdatedAt: updated}
+
+	version := u.Version()
+	// ETags clients hold stay valid: the version is a fixed function of
+	// the ID and the update time
+	assert.Equal(t, "95d3ebd0e976fabc", version)
+
+	// Nanoseconds the database does not keep do not change the version
+	u.UpdatedAt = updated.Add(789 * time.Nanosecond)
+	assert.Equal(t, version, u.Version())
+
+	u.UpdatedAt = updated.Add(time.Microsecond)
+	assert.NotEqual(t, version, u.Version())
+	assert.NotEqual(t, version, (&User{ID: "user-2", UpdatedAt: updated}).Version())
+}
+
+func TestUser_StatusTransitions(t *testing.T) {
+	u := &User{ID: "user-1", Email: "ada@example.com"}
+	assert.Equal(t, StatusActive, u.CurrentStatus(), "users stored without a status are active")
+	assert.True(t, u.IsActive())
+
+	require.NoError(t, u.Suspend("spam"))
+	assert.Equal(t, StatusSuspended, u.Status)
+	assert.False(t, u.IsActive())
+
+	err := u.Suspend("again")
+	var stateErr *errors.InvalidStateError
+	require.ErrorAs(t, err, &stateErr)
+	assert.Equal(t, errors.CodeStateTransition, stateErr.Code())
+	assert.Equal(t, StatusSuspended, u.Status)
+
+	require.NoError(t, u.Reactivate())
+	require.NoError(t, u.Deactivate("closed by user"))
+	assert.ErrorAs(t, u.Suspend(""), &stateErr, "deactivated accounts cannot be suspended")
+	require.NoError(t, u.Reactivate())
+	assert.ErrorAs(t, u.Reactivate(), &stateErr)
+
+	events := u.PullEvents()
+	require.Len(t, events, 4)
+	suspended, ok := events[0].(UserSuspended)
+	require.True(t, ok)
+	assert.Equal(t, "spam", suspended.Reason)
+	assert.Equal(t, EventUserReactivated, events[1].EventName())
+	assert.Equal(t, EventUserDeactivated, events[2].EventName())
+	assert.Equal(t, EventUserReactivated, events[3].EventName())
+}
+
+func TestUser_ExternalAuthHasNoLocalPassword(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	u := &User{ID: "user-1", Email: "ada@example.com", ExternalAuth: "ldap"}
+
+	var unauthorized *errors.UnauthorizedError
+	assert.ErrorAs(t, u.CheckPassword(ctx, "anything"), &unauthorized)
+
+	var stateErr *errors.InvalidStateError
+	assert.ErrorAs(t, u.SetPassword(ctx, "password123"), &stateErr)
+	assert.Empty(t, u.PasswordHash)
+
+	// Even a hash left from before the switch is not accepted
+	local := &User{ID: "user-2"}
+	require.NoError(t, local.SetPassword(ctx, "password123"))
+	local.ExternalAuth = "ldap"
+	assert.ErrorAs(t, local.CheckPassword(ctx, "password123"), &unauthorized)
+}
+
+func TestUser_ScheduleDeletion(t *testing.T) {
+	u := &User{ID: "user-1", Email: "ada@example.com", Name: "Ada"}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	assert.False(t, u.DeletionPending())
+	assert.False(t, u.DeletionDue(now))
+
+	var stateErr *errors.InvalidStateError
+	assert.ErrorAs(t, u.CancelDeletion(), &stateErr, "nothing to cancel")
+
+	deleteAt := now.Add(30 * 24 * time.Hour)
+	require.NoError(t, u.ScheduleDeletion(deleteAt))
+	assert.True(t, u.DeletionPending())
+	assert.False(t, u.DeletionDue(now))
+	assert.True(t, u.DeletionDue(deleteAt))
+	assert.ErrorAs(t, u.ScheduleDeletion(now), &stateErr, "already scheduled")
+
+	require.NoError(t, u.CancelDeletion())
+	assert.False(t, u.DeletionPending())
+
+	events := u.PullEvents()
+	require.Len(t, events, 2)
+	scheduled, ok := events[0].(UserDeletionScheduled)
+	require.True(t, ok)
+	assert.Equal(t, deleteAt, scheduled.DeleteAt)
+	assert.Equal(t, "Ada", scheduled.Name)
+	assert.Equal(t, EventUserDeletionCancelled, events[1].EventName())
+}
+
+func TestUser_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	u := &User{ID: "user-1", Email: "ada@example.com", Name: "Ada"}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	assert.False(t, u.EmailVerified())
+
+	var stateErr *errors.InvalidStateError
+	assert.ErrorAs(t, u.VerifyEmail("old@example.com", now), &stateErr, "link sent to another address")
+	require.NoError(t, u.VerifyEmail("ada@example.com", now))
+	assert.True(t, u.EmailVerified())
+	require.NoError(t, u.VerifyEmail("ada@example.com", now.Add(time.Hour)))
+	assert.Equal(t, now, *u.EmailVerifiedAt, "verifying twice keeps the first time")
+
+	require.NoError(t, u.UpdateEmail(ctx, "ada@example.com"))
+	assert.True(t, u.EmailVerified(), "unchanged address stays verified")
+	require.NoError(t, u.UpdateEmail(ctx, "lovelace@example.com"))
+	assert.False(t, u.EmailVerified())
+}
@@ -0,0 +1,207 @@
+// Package webhook defines the endpoints administrators register to be told
+// about user lifecycle changes, and the signed deliveries sent to them.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/netguard"
+)
+
+// Event types endpoints subscribe to. They are coarser than the domain
+// events: suspensions, reactivations and email changes are all updates.
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// EventTypes lists every event type an endpoint can subscribe to
+var EventTypes = []string{EventUserCreated, EventUserUpdated, EventUserDeleted}
+
+// Delivery statuses. Pending deliveries are waiting for their next attempt;
+// failed deliveries used up their attempts and can only be redelivered.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Headers of a delivery request
+const (
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Endpoint is a URL that receives the events it subscribes to
+type Endpoint struct {
+	ID  string `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	URL string `gorm:"type:varchar(2048);not null" json:"url"`
+	// Secret signs deliveries; it is only shown when the endpoint is created
+	Secret      string    `gorm:"type:varchar(255);not null" json:"-"`
+	Events      []string  `gorm:"serializer:json;type:text;not null" json:"events"`
+	Description string    `gorm:"type:varchar(255)" json:"description,omitempty"`
+	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// TableName pins the webhook endpoint table name
+func (Endpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// Validate validates the endpoint entity
+func (e *Endpoint) Validate() error {
+	if e.ID == "" {
+		return errors.NewRequiredFieldError("id", e.ID)
+	}
+	if e.URL == "" {
+		return errors.NewRequiredFieldError("url", e.URL)
+	}
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.NewInvalidFormatError("url", e.URL, "absolute http or https URL")
+	}
+	// Names are resolved when the service registers the endpoint and the
+	// dispatcher checks every address it dials; literals are refused here
+	if err := netguard.CheckHost(u.Hostname()); err != nil {
+		return errors.NewInvalidValueError("url", e.URL, "must not point to a loopback, private, link-local or metadata address")
+	}
+	if len(e.Secret) < 16 {
+		return errors.NewInvalidValueError("secret", "[REDACTED]", "must be at least 16 characters")
+	}
+	if len(e.Events) == 0 {
+		return errors.NewRequiredFieldError("events", e.Events)
+	}
+	for _, eventType := range e.Events {
+		if !slices.Contains(EventTypes, eventType) {
+			return errors.NewInvalidValueError("events", eventType, fmt.Sprintf("must be one of %v", EventTypes))
+		}
+	}
+	return nil
+}
+
+// Subscribes reports whether the endpoint receives eventType
+func (e *Endpoint) Subscribes(eventType string) bool {
+	return slices.Contains(e.Events, eventType)
+}
+
+// Delivery is one event sent, or to be sent, to an endpoint, with the
+// outcome of its latest attempt
+type Delivery struct {
+	ID         string `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	EndpointID string `gorm:"type:varchar(64);not null;index:idx_webhook_deliveries_endpoint,priority:1" json:"endpoint_id"`
+	EventType  string `gorm:"type:varchar(64);not null" json:"event_type"`
+	// EventID identifies the event; redeliveries share it so receivers can
+	// deduplicate
+	EventID string          `gorm:"type:varchar(64);not null" json:"event_id"`
+	Payload json.RawMessage `gorm:"serializer:json;type:text;not null" json:"payload"`
+	Status  string          `gorm:"type:varchar(20);not null;index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	// Attempts counts the requests sent so far
+	Attempts int `gorm:"not null;default:0" json:"attempts"`
+	// ResponseStatus is the HTTP status of the latest attempt, zero when no
+	// response arrived
+	ResponseStatus int        `gorm:"not null;default:0" json:"response_status,omitempty"`
+	LastError      string     `gorm:"type:varchar(500)" json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	// RedeliveryOf is the delivery this one repeats
+	RedeliveryOf string    `gorm:"type:varchar(64)" json:"redelivery_of,omitempty"`
+	CreatedAt    time.Time `gorm:"not null;index:idx_webhook_deliveries_endpoint,priority:2" json:"created_at"`
+}
+
+// TableName pins the webhook delivery table name
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// MarkSucceeded records an attempt answered with a 2xx status
+func (d *Delivery) MarkSucceeded(status int, now time.Time) {
+	d.Attempts++
+	d.Status = StatusSucceeded
+	d.ResponseStatus = status
+	d.LastError = ""
+	d.LastAttemptAt = &now
+	d.DeliveredAt = &now
+	d.NextAttemptAt = nil
+}
+
+// MarkAttemptFailed records a failed attempt. The delivery is tried again
+// at next, or fails for good when next is nil.
+func (d *Delivery) MarkAttemptFailed(status int, reason string, now time.Time, next *time.Time) {
+	d.Attempts++
+	d.ResponseStatus = status
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+	d.LastError = reason
+	d.LastAttemptAt = &now
+	d.NextAttemptAt = next
+	d.Status = StatusPending
+	if next == nil {
+		d.Status = StatusFailed
+	}
+}
+
+// Redelivery returns a new pending delivery of the same event, due at now
+func (d *Delivery) Redelivery(id string, now time.Time) *Delivery {
+	return &Delivery{
+		ID:            id,
+		EndpointID:    d.EndpointID,
+		EventType:     d.EventType,
+		EventID:       d.EventID,
+		Payload:       d.Payload,
+		Status:        StatusPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  d.ID,
+		CreatedAt:     now,
+	}
+}
+
+// Sign returns the X-Webhook-Signature value of body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Receivers
+// recompute it with the shared secret and reject stale timestamps to stop
+// replays.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Repository persists endpoints and their deliveries. Get methods return
+// nil, nil when nothing matches.
+type Repository interface {
+	CreateEndpoint(ctx context.Context, e *Endpoint) error
+	GetEndpoint(ctx context.Context, id string) (*Endpoint, error)
+	ListEndpoints(ctx context.Context) ([]*Endpoint, error)
+	// DeleteEndpoint removes an endpoint and its deliveries
+	DeleteEndpoint(ctx context.Context, id string) error
+
+	CreateDeliveries(ctx context.Context, deliveries []*Delivery) error
+	GetDelivery(ctx context.Context, id string) (*Delivery, error)
+	// UpdateDelivery saves the outcome of an attempt
+	UpdateDelivery(ctx context.Context, d *Delivery) error
+	// ListDeliveries returns a page of an endpoint's deliveries, newest
+	// first, with their total count
+	ListDeliveries(ctx context.Context, endpointID string, offset, limit int) ([]*Delivery, int64, error)
+	// ClaimDueDeliveries leases up to limit pending deliveries due by now
+	// by moving their next attempt to leaseUntil, so concurrent dispatchers
+	// never send the same delivery. A delivery whose attempt is not
+	// recorded by then, e.g. because its dispatcher crashed, is due again.
+	ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*Delivery, error)
+}
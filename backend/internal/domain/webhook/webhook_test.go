@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpoint_Validate(t *testing.T) {
+	valid := func() *Endpoint {
+		return &Endpoint{ID: "wh-1", URL: "https://hooks.example.com/wonder", Secret: "0123456789abcdef", Events: []string{EventUserCreated}}
+	}
+
+	assert.NoError(t, valid().Validate())
+
+	e := valid()
+	e.URL = "ftp://hooks.example.com"
+	assert.Error(t, e.Validate())
+
+	for _, internal := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://169.254.169.254/latest/meta-data", "https://[::1]/hook", "http://10.0.0.5/hook"} {
+		e = valid()
+		e.URL = internal
+		assert.Error(t, e.Validate(), internal)
+	}
+
+	e = valid()
+	e.Secret = "short"
+	assert.Error(t, e.Validate())
+
+	e = valid()
+	e.Events = []string{"user.registered"}
+	assert.Error(t, e.Validate(), "domain event names are not webhook event types")
+}
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	// echo -n '1700000000.{"id":"evt-1"}' | openssl dgst -sha256 -hmac 0123456789abcdef
+	assert.Equal(t, "t=1700000000,v1=8359dd8d8b212c2512dcb7ab4f83cfb02ea22036533574ee7f4e02b4e73f8280",
+		Sign("0123456789abcdef", at, []byte(`{"id":"evt-1"}`)))
+	assert.NotEqual(t, Sign("0123456789abcdef", at, []byte("a")), Sign("0123456789abcdef", at, []byte("b")))
+	assert.NotEqual(t, Sign("0123456789abcdef", at, []byte("a")), Sign("0123456789abcdeX", at, []byte("a")))
+}
+
+func TestDelivery_Attempts(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	next := now.Add(time.Minute)
+	d := &Delivery{ID: "d-1", EventID: "evt-1", Status: StatusPending}
+
+	d.MarkAttemptFailed(500, "endpoint responded 500", now, &next)
+	assert.Equal(t, StatusPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, &next, d.NextAttemptAt)
+
+	d.MarkAttemptFailed(0, "connection refused", now, nil)
+	assert.Equal(t, StatusFailed, d.Status)
+	assert.Nil(t, d.NextAttemptAt)
+
+	again := d.Redelivery("d-2", now)
+	assert.Equal(t, StatusPending, again.Status)
+	assert.Equal(t, "evt-1", again.EventID)
+	assert.Equal(t, "d-1", again.RedeliveryOf)
+	assert.Zero(t, again.Attempts)
+}
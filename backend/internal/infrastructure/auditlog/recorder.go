@@ -0,0 +1,210 @@
+// Package auditlog persists audit entries off the request path
+package auditlog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// Context keys set by the HTTP middleware. They are plain strings, matching
+// how the logger reads the trace ID.
+const (
+	userIDKey   = "user_id"
+	traceIDKey  = "trace_id"
+	clientIPKey = "client_ip"
+)
+
+const (
+	defaultBufferSize    = 1024
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	saveTimeout          = 5 * time.Second
+)
+
+// Option configures an AsyncRecorder
+type Option func(*AsyncRecorder)
+
+// WithBufferSize sets how many entries may wait for persistence. Entries
+// recorded while the buffer is full are dropped and logged.
+func WithBufferSize(n int) Option {
+	return func(r *AsyncRecorder) {
+		if n > 0 {
+			r.bufferSize = n
+		}
+	}
+}
+
+// WithBatchSize sets the maximum number of entries written per insert
+func WithBatchSize(n int) Option {
+	return func(r *AsyncRecorder) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithFlushInterval sets how long a partial batch may wait before it is written
+func WithFlushInterval(d time.Duration) Option {
+	return func(r *AsyncRecorder) {
+		if d > 0 {
+			r.flushInterval = d
+		}
+	}
+}
+
+// AsyncRecorder is an audit.Recorder that batches entries and writes them
+// from a background goroutine, so audited operations never wait on storage
+type AsyncRecorder struct {
+	repo audit.Repository
+	log  logger.Logger
+
+	bufferSize    int
+	batchSize     int
+	flushInterval time.Duration
+
+	entries chan *audit.Entry
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAsyncRecorder creates a recorder and starts its writer
+func NewAsyncRecorder(repo audit.Repository, opts ...Option) *AsyncRecorder {
+	if repo == nil {
+		panic("audit repository cannot be nil")
+	}
+
+	r := &AsyncRecorder{
+		repo:          repo,
+		log:           logger.Get().WithLayer("infrastructure").WithComponent("audit_recorder"),
+		bufferSize:    defaultBufferSize,
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.entries = make(chan *audit.Entry, r.bufferSize)
+
+	go r.run()
+	return r
+}
+
+// Record implements audit.Recorder. Missing IDs, timestamps, actor and trace
+// IDs and the client IP are filled in from ctx before the entry is queued.
+func (r *AsyncRecorder) Record(ctx context.Context, entry *audit.Entry) {
+	if entry == nil {
+		return
+	}
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	if entry.Outcome == "" {
+		entry.Outcome = audit.OutcomeSuccess
+	}
+	if entry.ActorID == "" {
+		entry.ActorID = stringFromContext(ctx, userIDKey)
+	}
+	if entry.TraceID == "" {
+		entry.TraceID = stringFromContext(ctx, traceIDKey)
+	}
+	if entry.IPAddress == "" {
+		entry.IPAddress = stringFromContext(ctx, clientIPKey)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		r.log.Warn(ctx, "audit recorder closed, entry dropped", "action", entry.Action, "entity_id", entry.EntityID)
+		return
+	}
+
+	select {
+	case r.entries <- entry:
+	default:
+		r.log.Error(ctx, "audit buffer full, entry dropped", "action", entry.Action, "entity_id", entry.EntityID)
+	}
+}
+
+// Close stops accepting entries and waits until queued entries are written
+// or ctx expires
+func (r *AsyncRecorder) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.entries)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("audit recorder did not drain before deadline: %w", ctx.Err())
+	}
+}
+
+func (r *AsyncRecorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*audit.Entry, 0, r.batchSize)
+	for {
+		select {
+		case entry, ok := <-r.entries:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= r.batchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (r *AsyncRecorder) flush(batch []*audit.Entry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+
+	entries := make([]*audit.Entry, len(batch))
+	copy(entries, batch)
+	if err := r.repo.Save(ctx, entries...); err != nil {
+		r.log.Error(ctx, "failed to persist audit entries", "error", err, "count", len(entries))
+	}
+}
+
+func stringFromContext(ctx context.Context, key string) string {
+	if ctx == nil {
+		return ""
+	}
+	if v, ok := ctx.Value(key).(string); ok {
+		return v
+	}
+	return ""
+}
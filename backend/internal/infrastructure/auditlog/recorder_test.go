@@ -0,0 +1,90 @@
+package auditlog
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// memoryRepository stores saved entries and counts inserts
+type memoryRepository struct {
+	mu      sync.Mutex
+	entries []*audit.Entry
+	batches int
+}
+
+func (r *memoryRepository) Save(ctx context.Context, entries ...*audit.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entries...)
+	r.batches++
+	return nil
+}
+
+func (r *memoryRepository) List(ctx context.Context, req *audit.ListRequest) (*audit.ListResponse, error) {
+	return &audit.ListResponse{}, nil
+}
+
+func (r *memoryRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryRepository) saved() []*audit.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*audit.Entry(nil), r.entries...)
+}
+
+func TestAsyncRecorder_FillsEntryFromContext(t *testing.T) {
+	logger.Initialize()
+	repo := &memoryRepository{}
+	recorder := NewAsyncRecorder(repo)
+
+	ctx := context.WithValue(context.Background(), userIDKey, "admin-1")
+	ctx = context.WithValue(ctx, traceIDKey, "trace-1")
+	ctx = context.WithValue(ctx, clientIPKey, "192.0.2.1")
+	recorder.Record(ctx, &audit.Entry{Action: audit.ActionDelete, EntityType: "user", EntityID: "user-1"})
+
+	require.NoError(t, recorder.Close(context.Background()))
+
+	saved := repo.saved()
+	require.Len(t, saved, 1)
+	assert.NotEmpty(t, saved[0].ID)
+	assert.False(t, saved[0].CreatedAt.IsZero())
+	assert.Equal(t, "admin-1", saved[0].ActorID)
+	assert.Equal(t, "trace-1", saved[0].TraceID)
+	assert.Equal(t, "192.0.2.1", saved[0].IPAddress)
+	assert.Equal(t, audit.OutcomeSuccess, saved[0].Outcome)
+}
+
+func TestAsyncRecorder_BatchesAndFlushesOnInterval(t *testing.T) {
+	logger.Initialize()
+	repo := &memoryRepository{}
+	recorder := NewAsyncRecorder(repo, WithBatchSize(2), WithFlushInterval(10*time.Millisecond))
+	defer recorder.Close(context.Background())
+
+	for i := 0; i < 3; i++ {
+		recorder.Record(context.Background(), &audit.Entry{Action: audit.ActionLogin, EntityType: "user"})
+	}
+
+	assert.Eventually(t, func() bool { return len(repo.saved()) == 3 }, time.Second, 5*time.Millisecond)
+}
+
+func TestAsyncRecorder_DropsAfterClose(t *testing.T) {
+	logger.Initialize()
+	repo := &memoryRepository{}
+	recorder := NewAsyncRecorder(repo)
+
+	require.NoError(t, recorder.Close(context.Background()))
+	require.NoError(t, recorder.Close(context.Background()))
+	recorder.Record(context.Background(), &audit.Entry{Action: audit.ActionLogin, EntityType: "user"})
+
+	assert.Empty(t, repo.saved())
+}
@@ -0,0 +1,123 @@
+// Package broker bridges domain events to an external message broker
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/outbox"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/messaging"
+)
+
+// New creates the broker selected by configuration
+func New(cfg *config.MessagingConfig) (messaging.Broker, error) {
+	switch cfg.Provider {
+	case "nats":
+		return messaging.NewNATSBroker(messaging.NATSConfig{
+			URL:            cfg.URL.String(),
+			ClientName:     cfg.ClientName,
+			ConnectTimeout: cfg.ConnectTimeout,
+		})
+	case "memory":
+		return messaging.NewMemoryBroker(), nil
+	default:
+		return nil, fmt.Errorf("unsupported messaging provider %q", cfg.Provider)
+	}
+}
+
+// Topic returns the topic an event is published to, e.g. "wonder.user.registered"
+func Topic(prefix, eventName string) string {
+	if prefix == "" {
+		return eventName
+	}
+	return prefix + "." + eventName
+}
+
+// FromOutbox converts a stored outbox message into a broker message. The
+// outbox idempotency key becomes the message ID.
+func FromOutbox(prefix string, msg event.OutboxMessage) *messaging.Message {
+	return &messaging.Message{
+		ID:        msg.IdempotencyKey,
+		Topic:     Topic(prefix, msg.EventName),
+		Key:       msg.AggregateID,
+		Payload:   []byte(msg.Payload),
+		Timestamp: msg.OccurredAt,
+		Headers: map[string]string{
+			messaging.HeaderEventName:   msg.EventName,
+			messaging.HeaderAggregateID: msg.AggregateID,
+			messaging.HeaderOccurredAt:  msg.OccurredAt.UTC().Format(time.RFC3339Nano),
+			messaging.HeaderContentType: "application/json",
+		},
+	}
+}
+
+// FromEvent serializes a domain event into a broker message
+func FromEvent(prefix string, e event.Event) (*messaging.Message, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", e.EventName(), err)
+	}
+
+	return FromOutbox(prefix, event.OutboxMessage{
+		IdempotencyKey: outbox.IdempotencyKeyFor(e.EventName(), e.AggregateID(), e.OccurredAt(), payload),
+		EventName:      e.EventName(),
+		AggregateID:    e.AggregateID(),
+		Payload:        string(payload),
+		OccurredAt:     e.OccurredAt(),
+	}), nil
+}
+
+// NewSink publishes relayed outbox messages to the broker
+func NewSink(pub messaging.Publisher, prefix string) outbox.Sink {
+	if pub == nil {
+		panic("message publisher cannot be nil")
+	}
+
+	return outbox.SinkFunc(func(ctx context.Context, msg event.OutboxMessage) error {
+		return pub.Publish(ctx, FromOutbox(prefix, msg))
+	})
+}
+
+// ForwardHandler returns an event bus handler that republishes events to the
+// broker. It is used when the transactional outbox is disabled; delivery is
+// then best effort.
+func ForwardHandler(pub messaging.Publisher, prefix string) event.Handler {
+	if pub == nil {
+		panic("message publisher cannot be nil")
+	}
+	log := logger.Get().WithLayer("infrastructure").WithComponent("event_forwarder")
+
+	return func(ctx context.Context, e event.Event) error {
+		msg, err := FromEvent(prefix, e)
+		if err != nil {
+			return err
+		}
+		if err := pub.Publish(ctx, msg); err != nil {
+			log.Warn(ctx, "failed to forward event to broker", "event", e.EventName(), "topic", msg.Topic, "error", err)
+			return err
+		}
+		return nil
+	}
+}
+
+// Decode turns a received broker message back into a domain event. Event
+// names missing from registry decode to outbox.RawEvent.
+func Decode(msg *messaging.Message, registry *outbox.Registry) (event.Event, error) {
+	occurred, _ := time.Parse(time.RFC3339Nano, msg.Header(messaging.HeaderOccurredAt))
+	if registry == nil {
+		registry = outbox.NewRegistry()
+	}
+
+	return registry.Decode(event.OutboxMessage{
+		IdempotencyKey: msg.ID,
+		EventName:      msg.Header(messaging.HeaderEventName),
+		AggregateID:    msg.Header(messaging.HeaderAggregateID),
+		Payload:        string(msg.Payload),
+		OccurredAt:     occurred,
+	})
+}
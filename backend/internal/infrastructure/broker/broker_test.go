@@ -0,0 +1,95 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/outbox"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/messaging"
+)
+
+func TestForwardHandler_PublishesDecodableMessages(t *testing.T) {
+	logger.Initialize()
+	b := messaging.NewMemoryBroker()
+	ctx := context.Background()
+
+	var received []event.Event
+	_, err := b.Subscribe(ctx, "wonder.user.registered", "audit", func(ctx context.Context, msg *messaging.Message) error {
+		assert.Len(t, msg.ID, 64)
+		assert.Equal(t, "user-1", msg.Key)
+		e, err := Decode(msg, outbox.NewRegistry(user.UserRegistered{}))
+		if err != nil {
+			return err
+		}
+		received = append(received, e)
+		return nil
+	})
+	require.NoError(t, err)
+
+	registered := user.UserRegistered{Base: event.NewBase("user-1"), Email: "a@example.com", Name: "A"}
+	require.NoError(t, ForwardHandler(b, "wonder")(ctx, registered))
+
+	require.Len(t, received, 1)
+	decoded, ok := received[0].(user.UserRegistered)
+	require.True(t, ok)
+	assert.Equal(t, "a@example.com", decoded.Email)
+	assert.Equal(t, "user-1", decoded.AggregateID())
+	assert.True(t, registered.OccurredAt().Equal(decoded.OccurredAt()))
+}
+
+func TestNewSink_UsesOutboxIdempotencyKey(t *testing.T) {
+	b := messaging.NewMemoryBroker()
+	ctx := context.Background()
+
+	var got *messaging.Message
+	_, err := b.Subscribe(ctx, "svc.user.deleted", "", func(ctx context.Context, msg *messaging.Message) error {
+		got = msg
+		return nil
+	})
+	require.NoError(t, err)
+
+	sink := NewSink(b, "svc")
+	require.NoError(t, sink.Deliver(ctx, event.OutboxMessage{
+		IdempotencyKey: "idem-1",
+		EventName:      user.EventUserDeleted,
+		AggregateID:    "user-9",
+		Payload:        `{"email":"x@example.com"}`,
+	}))
+
+	require.NotNil(t, got)
+	assert.Equal(t, "idem-1", got.ID)
+	assert.Equal(t, user.EventUserDeleted, got.Header(messaging.HeaderEventName))
+}
+
+func TestNewSink_PropagatesPublishErrors(t *testing.T) {
+	b := messaging.NewMemoryBroker()
+	_, err := b.Subscribe(context.Background(), "user.deleted", "", func(context.Context, *messaging.Message) error {
+		return errors.New("consumer down")
+	})
+	require.NoError(t, err)
+
+	err = NewSink(b, "").Deliver(context.Background(), event.OutboxMessage{EventName: user.EventUserDeleted})
+	assert.Error(t, err)
+}
+
+func TestNew_SelectsProvider(t *testing.T) {
+	b, err := New(&config.MessagingConfig{Provider: "memory"})
+	require.NoError(t, err)
+	assert.IsType(t, &messaging.MemoryBroker{}, b)
+
+	_, err = New(&config.MessagingConfig{Provider: "carrier-pigeon"})
+	assert.Error(t, err)
+}
+
+func TestTopic(t *testing.T) {
+	assert.Equal(t, "wonder.user.registered", Topic("wonder", "user.registered"))
+	assert.Equal(t, "user.registered", Topic("", "user.registered"))
+}
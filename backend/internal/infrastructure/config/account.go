@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// AccountConfig represents self-service account management
+type AccountConfig struct {
+	// DeletionGracePeriod is how long users can cancel a deletion they
+	// requested before the account is removed for good; zero deletes
+	// immediately
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" mapstructure:"deletion_grace_period" env:"ACCOUNT_DELETION_GRACE_PERIOD"`
+	// DataExportTTL is how long a generated data export can be downloaded
+	DataExportTTL time.Duration `yaml:"data_export_ttl" mapstructure:"data_export_ttl" env:"ACCOUNT_DATA_EXPORT_TTL"`
+}
+
+// DefaultAccountConfig returns default account configuration
+func DefaultAccountConfig() *AccountConfig {
+	return &AccountConfig{
+		DeletionGracePeriod: 30 * 24 * time.Hour,
+		DataExportTTL:       7 * 24 * time.Hour,
+	}
+}
+
+// Validate validates account configuration
+func (c *AccountConfig) Validate() error {
+	if c.DeletionGracePeriod < 0 {
+		return fmt.Errorf("account deletion_grace_period must not be negative")
+	}
+	if c.DataExportTTL <= 0 {
+		return fmt.Errorf("account data_export_ttl must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultAdminUICSP allows the UI's own scripts, styles and API calls and
+// nothing from other origins
+const DefaultAdminUICSP = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; font-src 'self'; connect-src 'self'; object-src 'none'; " +
+	"frame-ancestors 'none'; base-uri 'self'; form-action 'self'"
+
+// AdminUIConfig represents the bundled management UI served by the backend.
+// The UI is embedded in the binary at build time; Dir serves a directory on
+// disk instead, e.g. while developing the frontend.
+type AdminUIConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"SERVER_ADMIN_UI_ENABLED"`
+	// Path is where the UI is mounted; routes below it that are not files
+	// answer with index.html so the UI can route with the history API
+	Path string `yaml:"path" mapstructure:"path" env:"SERVER_ADMIN_UI_PATH"`
+	Dir  string `yaml:"dir" mapstructure:"dir" env:"SERVER_ADMIN_UI_DIR"`
+	// CacheMaxAge is how long browsers may cache assets; fingerprinted
+	// assets are cached for a year and HTML is always revalidated
+	CacheMaxAge           time.Duration `yaml:"cache_max_age" mapstructure:"cache_max_age" env:"SERVER_ADMIN_UI_CACHE_MAX_AGE"`
+	ContentSecurityPolicy string        `yaml:"content_security_policy" mapstructure:"content_security_policy" env:"SERVER_ADMIN_UI_CSP"`
+}
+
+// DefaultAdminUIConfig returns default admin UI configuration
+func DefaultAdminUIConfig() *AdminUIConfig {
+	return &AdminUIConfig{
+		Enabled:               false,
+		Path:                  "/admin",
+		CacheMaxAge:           time.Hour,
+		ContentSecurityPolicy: DefaultAdminUICSP,
+	}
+}
+
+// Validate validates admin UI configuration
+func (c *AdminUIConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	path := strings.TrimSuffix(c.Path, "/")
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("admin_ui path %q must start with / and not be the root", c.Path)
+	}
+	if strings.ContainsAny(path, ":*") {
+		return fmt.Errorf("admin_ui path %q cannot contain route parameters", c.Path)
+	}
+	for _, reserved := range []string{"/api", "/metrics", "/health", "/healthz", "/readyz", "/.well-known"} {
+		if underPath(path, reserved) {
+			return fmt.Errorf("admin_ui path %q overlaps %s", c.Path, reserved)
+		}
+	}
+	if c.CacheMaxAge < 0 {
+		return fmt.Errorf("admin_ui cache_max_age must not be negative")
+	}
+	return nil
+}
+
+// MountPath returns Path without a trailing slash
+func (c *AdminUIConfig) MountPath() string {
+	return strings.TrimSuffix(c.Path, "/")
+}
+
+// underPath reports whether path is prefix or lies below it
+func underPath(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
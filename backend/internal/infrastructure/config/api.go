@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// APIConfig represents the versions of the HTTP API that are served and
+// the retirement schedule of v1. v1 keeps its payloads unchanged while v2
+// evolves; clients move over between the deprecation and the sunset.
+type APIConfig struct {
+	// V2 serves /api/v2 alongside /api/v1
+	V2 bool `yaml:"v2" mapstructure:"v2" env:"API_V2"`
+	// V1DeprecatedAt is when v1 was, or will be, deprecated, as an RFC 3339
+	// time announced in the Deprecation header of v1 responses; empty
+	// sends no header
+	V1DeprecatedAt string `yaml:"v1_deprecated_at" mapstructure:"v1_deprecated_at" env:"API_V1_DEPRECATED_AT"`
+	// V1SunsetAt is when v1 stops being served, as an RFC 3339 time
+	// announced in the Sunset header of v1 responses; empty sends no header
+	V1SunsetAt string `yaml:"v1_sunset_at" mapstructure:"v1_sunset_at" env:"API_V1_SUNSET_AT"`
+	// V1DeprecationLink documents the move to v2, linked from v1 responses
+	// with rel="deprecation"
+	V1DeprecationLink url.URL `yaml:"v1_deprecation_link" mapstructure:"v1_deprecation_link" env:"API_V1_DEPRECATION_LINK"`
+}
+
+// DefaultAPIConfig returns default API configuration
+func DefaultAPIConfig() *APIConfig {
+	return &APIConfig{
+		V2: true,
+	}
+}
+
+// V1Deprecation returns when v1 is deprecated; zero when it is not
+func (c *APIConfig) V1Deprecation() time.Time {
+	t, _ := time.Parse(time.RFC3339, c.V1DeprecatedAt)
+	return t
+}
+
+// V1Sunset returns when v1 stops being served; zero when it is not
+// scheduled to
+func (c *APIConfig) V1Sunset() time.Time {
+	t, _ := time.Parse(time.RFC3339, c.V1SunsetAt)
+	return t
+}
+
+// Validate validates API configuration
+func (c *APIConfig) Validate() error {
+	for _, field := range []struct{ key, value string }{
+		{"v1_deprecated_at", c.V1DeprecatedAt},
+		{"v1_sunset_at", c.V1SunsetAt},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, field.value); err != nil {
+			return fmt.Errorf("api %s must be an RFC 3339 time such as 2027-01-01T00:00:00Z", field.key)
+		}
+	}
+	if c.V1DeprecatedAt != "" || c.V1SunsetAt != "" {
+		if !c.V2 {
+			return fmt.Errorf("api v2 must be served while v1 is deprecated")
+		}
+	}
+	if c.V1DeprecatedAt != "" && c.V1SunsetAt != "" && c.V1Sunset().Before(c.V1Deprecation()) {
+		return fmt.Errorf("api v1_sunset_at must not be before v1_deprecated_at")
+	}
+	if c.V1DeprecationLink != (url.URL{}) && !isHTTPURL(c.V1DeprecationLink) {
+		return fmt.Errorf("api v1_deprecation_link %q must be an http or https URL", c.V1DeprecationLink.String())
+	}
+	return nil
+}
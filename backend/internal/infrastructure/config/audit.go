@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// AuditConfig represents the audit log and its asynchronous writer
+type AuditConfig struct {
+	Enabled       bool          `yaml:"enabled" mapstructure:"enabled" env:"AUDIT_ENABLED"`
+	BufferSize    int           `yaml:"buffer_size" mapstructure:"buffer_size" env:"AUDIT_BUFFER_SIZE"`
+	BatchSize     int           `yaml:"batch_size" mapstructure:"batch_size" env:"AUDIT_BATCH_SIZE"`
+	FlushInterval time.Duration `yaml:"flush_interval" mapstructure:"flush_interval" env:"AUDIT_FLUSH_INTERVAL"`
+}
+
+// DefaultAuditConfig returns default audit configuration
+func DefaultAuditConfig() *AuditConfig {
+	return &AuditConfig{
+		Enabled:       true,
+		BufferSize:    1024,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+	}
+}
+
+// Validate validates audit configuration
+func (c *AuditConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.BufferSize <= 0 {
+		return fmt.Errorf("audit buffer_size must be positive")
+	}
+	if c.BatchSize <= 0 || c.BatchSize > c.BufferSize {
+		return fmt.Errorf("audit batch_size must satisfy 0 < batch_size <= buffer_size")
+	}
+	if c.FlushInterval <= 0 {
+		return fmt.Errorf("audit flush_interval must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Authentication providers
+const (
+	AuthProviderLocal = "local"
+	AuthProviderLDAP  = "ldap"
+)
+
+// Two-factor enforcement policies
+const (
+	MFAEnforcementOptional = "optional"
+	MFAEnforcementAdmins   = "admins"
+	MFAEnforcementAll      = "all"
+)
+
+// AuthConfig selects where login passwords are checked
+type AuthConfig struct {
+	// Provider is "local" (password hashes in the users table) or "ldap"
+	Provider string      `yaml:"provider" mapstructure:"provider" env:"AUTH_PROVIDER"`
+	LDAP     *LDAPConfig `yaml:"ldap" mapstructure:"ldap"`
+	MFA      *MFAConfig  `yaml:"mfa" mapstructure:"mfa"`
+}
+
+// MFAConfig represents two-factor authentication with authenticator apps.
+// Users may always enroll; Enforcement decides who must enroll before a
+// login completes.
+type MFAConfig struct {
+	// Enforcement is "optional", "admins" or "all"
+	Enforcement string `yaml:"enforcement" mapstructure:"enforcement" env:"MFA_ENFORCEMENT"`
+	// Issuer names the service in authenticator apps
+	Issuer string `yaml:"issuer" mapstructure:"issuer" env:"MFA_ISSUER"`
+	// TokenTTL is how long the token issued after the password step can
+	// be exchanged for an access token
+	TokenTTL time.Duration `yaml:"token_ttl" mapstructure:"token_ttl" env:"MFA_TOKEN_TTL"`
+	// Skew is how many 30 second steps of clock drift codes may have
+	Skew int `yaml:"skew" mapstructure:"skew" env:"MFA_SKEW"`
+	// MaxAttempts wrong codes in a row lock the second step for
+	// LockDuration
+	MaxAttempts   int           `yaml:"max_attempts" mapstructure:"max_attempts" env:"MFA_MAX_ATTEMPTS"`
+	LockDuration  time.Duration `yaml:"lock_duration" mapstructure:"lock_duration" env:"MFA_LOCK_DURATION"`
+	RecoveryCodes int           `yaml:"recovery_codes" mapstructure:"recovery_codes" env:"MFA_RECOVERY_CODES"`
+	// TrustedDeviceTTL is how long a device remembered after the second
+	// step may skip it; 0 disables remembering devices
+	TrustedDeviceTTL time.Duration `yaml:"trusted_device_ttl" mapstructure:"trusted_device_ttl" env:"MFA_TRUSTED_DEVICE_TTL"`
+}
+
+// LDAPConfig represents an LDAP or Active Directory server users sign in
+// against. The service account finds the user's entry with UserFilter, in
+// which {login} is replaced by the escaped login; the user's password is
+// then checked by binding as that entry.
+type LDAPConfig struct {
+	// URL is ldap://host[:389] or ldaps://host[:636]
+	URL                url.URL       `yaml:"url" mapstructure:"url" env:"LDAP_URL"`
+	StartTLS           bool          `yaml:"start_tls" mapstructure:"start_tls" env:"LDAP_START_TLS"`
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify" mapstructure:"insecure_skip_verify" env:"LDAP_INSECURE_SKIP_VERIFY"`
+	BindDN             string        `yaml:"bind_dn" mapstructure:"bind_dn" env:"LDAP_BIND_DN"`
+	BindPassword       string        `yaml:"bind_password" mapstructure:"bind_password" env:"LDAP_BIND_PASSWORD" redact:"true"`
+	BaseDN             string        `yaml:"base_dn" mapstructure:"base_dn" env:"LDAP_BASE_DN"`
+	UserFilter         string        `yaml:"user_filter" mapstructure:"user_filter" env:"LDAP_USER_FILTER"`
+	EmailAttribute     string        `yaml:"email_attribute" mapstructure:"email_attribute" env:"LDAP_EMAIL_ATTRIBUTE"`
+	NameAttribute      string        `yaml:"name_attribute" mapstructure:"name_attribute" env:"LDAP_NAME_ATTRIBUTE"`
+	Timeout            time.Duration `yaml:"timeout" mapstructure:"timeout" env:"LDAP_TIMEOUT"`
+	// CreateUsers creates the local account of a directory user on their
+	// first login; without it only existing accounts can sign in
+	CreateUsers bool `yaml:"create_users" mapstructure:"create_users" env:"LDAP_CREATE_USERS"`
+}
+
+// DefaultAuthConfig returns default authentication configuration
+func DefaultAuthConfig() *AuthConfig {
+	return &AuthConfig{
+		Provider: AuthProviderLocal,
+		LDAP: &LDAPConfig{
+			UserFilter:     "(&(objectClass=person)(mail={login}))",
+			EmailAttribute: "mail",
+			NameAttribute:  "cn",
+			Timeout:        5 * time.Second,
+			CreateUsers:    true,
+		},
+		MFA: &MFAConfig{
+			Enforcement:      MFAEnforcementOptional,
+			Issuer:           "Wonder",
+			TokenTTL:         5 * time.Minute,
+			Skew:             1,
+			MaxAttempts:      5,
+			LockDuration:     15 * time.Minute,
+			RecoveryCodes:    10,
+			TrustedDeviceTTL: 30 * 24 * time.Hour,
+		},
+	}
+}
+
+// Validate validates authentication configuration
+func (c *AuthConfig) Validate() error {
+	if c.MFA != nil {
+		if err := c.MFA.Validate(); err != nil {
+			return err
+		}
+	}
+
+	switch c.Provider {
+	case "", AuthProviderLocal:
+		return nil
+	case AuthProviderLDAP:
+		if c.LDAP == nil {
+			return fmt.Errorf("auth ldap section is required when provider is ldap")
+		}
+		return c.LDAP.Validate()
+	default:
+		return fmt.Errorf("auth provider must be local or ldap, got %q", c.Provider)
+	}
+}
+
+// Validate validates LDAP configuration
+func (c *LDAPConfig) Validate() error {
+	if c.URL.Scheme != "ldap" && c.URL.Scheme != "ldaps" {
+		return fmt.Errorf("ldap url must be ldap://host or ldaps://host")
+	}
+	if c.StartTLS && c.URL.Scheme == "ldaps" {
+		return fmt.Errorf("ldap start_tls cannot be combined with an ldaps url")
+	}
+	if c.BaseDN == "" {
+		return fmt.Errorf("ldap base_dn is required")
+	}
+	if (c.BindDN == "") != (c.BindPassword == "") {
+		return fmt.Errorf("ldap bind_dn and bind_password must be set together")
+	}
+	if !strings.Contains(c.UserFilter, "{login}") {
+		return fmt.Errorf("ldap user_filter must contain {login}")
+	}
+	if c.EmailAttribute == "" {
+		return fmt.Errorf("ldap email_attribute is required")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("ldap timeout must be positive")
+	}
+	return nil
+}
+
+// Validate validates two-factor configuration
+func (c *MFAConfig) Validate() error {
+	switch c.Enforcement {
+	case MFAEnforcementOptional, MFAEnforcementAdmins, MFAEnforcementAll:
+	default:
+		return fmt.Errorf("mfa enforcement must be optional, admins or all, got %q", c.Enforcement)
+	}
+	if c.Issuer == "" {
+		return fmt.Errorf("mfa issuer is required")
+	}
+	if c.TokenTTL <= 0 {
+		return fmt.Errorf("mfa token_ttl must be positive")
+	}
+	if c.Skew < 0 || c.Skew > 10 {
+		return fmt.Errorf("mfa skew must be between 0 and 10")
+	}
+	if c.MaxAttempts <= 0 {
+		return fmt.Errorf("mfa max_attempts must be positive")
+	}
+	if c.LockDuration <= 0 {
+		return fmt.Errorf("mfa lock_duration must be positive")
+	}
+	if c.RecoveryCodes <= 0 {
+		return fmt.Errorf("mfa recovery_codes must be positive")
+	}
+	if c.TrustedDeviceTTL < 0 {
+		return fmt.Errorf("mfa trusted_device_ttl cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import "fmt"
+
+// minSetupTokenLength is the shortest setup token accepted for admin bootstrap
+const minSetupTokenLength = 16
+
+// BootstrapConfig represents the one-time initial admin bootstrap. The
+// bootstrap is enabled when an admin email is configured; the setup token
+// must be presented to claim the account.
+type BootstrapConfig struct {
+	AdminEmail string `yaml:"admin_email" mapstructure:"admin_email" env:"BOOTSTRAP_ADMIN_EMAIL"`
+	AdminName  string `yaml:"admin_name" mapstructure:"admin_name" env:"BOOTSTRAP_ADMIN_NAME"`
+	SetupToken string `yaml:"setup_token" mapstructure:"setup_token" env:"BOOTSTRAP_SETUP_TOKEN" redact:"true"`
+}
+
+// DefaultBootstrapConfig returns default bootstrap configuration
+func DefaultBootstrapConfig() *BootstrapConfig {
+	return &BootstrapConfig{
+		AdminName: "Administrator",
+	}
+}
+
+// Enabled reports whether an initial admin is configured
+func (c *BootstrapConfig) Enabled() bool {
+	return c.AdminEmail != ""
+}
+
+// Validate validates bootstrap configuration
+func (c *BootstrapConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if len(c.SetupToken) < minSetupTokenLength {
+		return fmt.Errorf("bootstrap setup_token must be at least %d characters when admin_email is set", minSetupTokenLength)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes. Configuration files and environment
+// variables may write it as a plain number of bytes or with a unit, such as
+// 512KB, 10MB or 1GiB. Units are powers of 1024; KB and KiB are the same.
+type ByteSize int64
+
+// Byte size units
+const (
+	Byte     ByteSize = 1
+	Kilobyte          = 1024 * Byte
+	Megabyte          = 1024 * Kilobyte
+	Gigabyte          = 1024 * Megabyte
+	Terabyte          = 1024 * Gigabyte
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":  Byte,
+	"b": Byte,
+	"k": Kilobyte, "kb": Kilobyte, "kib": Kilobyte,
+	"m": Megabyte, "mb": Megabyte, "mib": Megabyte,
+	"g": Gigabyte, "gb": Gigabyte, "gib": Gigabyte,
+	"t": Terabyte, "tb": Terabyte, "tib": Terabyte,
+}
+
+// ParseByteSize parses a size such as 1048576, 512KB, 1.5MB or 2 GiB
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	number := strings.TrimRightFunc(s, func(r rune) bool {
+		return r == ' ' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+	})
+	unit, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(s[len(number):]))]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid size %q: use a number of bytes or a unit such as 512KB, 10MB or 1GB", s)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q: use a number of bytes or a unit such as 512KB, 10MB or 1GB", s)
+	}
+	bytes := value * float64(unit)
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	if bytes != math.Trunc(bytes) {
+		return 0, fmt.Errorf("size %q is not a whole number of bytes", s)
+	}
+	return ByteSize(bytes), nil
+}
+
+// String formats the size with the largest unit that divides it evenly
+func (b ByteSize) String() string {
+	for _, u := range []struct {
+		size ByteSize
+		name string
+	}{{Terabyte, "TB"}, {Gigabyte, "GB"}, {Megabyte, "MB"}, {Kilobyte, "KB"}} {
+		if b != 0 && b%u.size == 0 {
+			return fmt.Sprintf("%d%s", b/u.size, u.name)
+		}
+	}
+	return fmt.Sprintf("%dB", int64(b))
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// CircuitBreakerConfig represents the circuit breakers guarding Redis and
+// etcd. The breach checker keeps its own threshold under
+// security.password_breach.
+type CircuitBreakerConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"CIRCUIT_BREAKER_ENABLED"`
+	// FailureThreshold is the number of consecutive failures that opens a breaker
+	FailureThreshold int `yaml:"failure_threshold" mapstructure:"failure_threshold" env:"CIRCUIT_BREAKER_FAILURE_THRESHOLD"`
+	// OpenTimeout is how long a breaker rejects calls before trying again
+	OpenTimeout time.Duration `yaml:"open_timeout" mapstructure:"open_timeout" env:"CIRCUIT_BREAKER_OPEN_TIMEOUT"`
+	// HalfOpenMaxCalls caps concurrent trial calls after the open timeout
+	HalfOpenMaxCalls int `yaml:"half_open_max_calls" mapstructure:"half_open_max_calls" env:"CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS"`
+}
+
+// DefaultCircuitBreakerConfig returns default circuit breaker configuration
+func DefaultCircuitBreakerConfig() *CircuitBreakerConfig {
+	return &CircuitBreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenMaxCalls: 1,
+	}
+}
+
+// Validate validates circuit breaker configuration
+func (c *CircuitBreakerConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.FailureThreshold < 1 {
+		return fmt.Errorf("circuit breaker failure_threshold must be at least 1")
+	}
+	if c.OpenTimeout <= 0 {
+		return fmt.Errorf("circuit breaker open_timeout must be positive")
+	}
+	if c.HalfOpenMaxCalls < 1 {
+		return fmt.Errorf("circuit breaker half_open_max_calls must be at least 1")
+	}
+	return nil
+}
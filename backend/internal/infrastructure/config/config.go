@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
 )
 
 // Config represents the application configuration structure organized by DDD layers
@@ -19,8 +23,82 @@ type Config struct {
 	// Domain layer configurations
 	ID *IDConfig `yaml:"id" mapstructure:"id"`
 
+	// Security policy configurations
+	Security *SecurityConfig `yaml:"security" mapstructure:"security"`
+
+	// Login password provider configuration
+	Auth *AuthConfig `yaml:"auth" mapstructure:"auth"`
+
+	// Initial admin bootstrap configuration
+	Bootstrap *BootstrapConfig `yaml:"bootstrap" mapstructure:"bootstrap"`
+
+	// Self-service account management configuration
+	Account *AccountConfig `yaml:"account" mapstructure:"account"`
+
+	// Encryption of personal data at rest
+	Encryption *EncryptionConfig `yaml:"encryption" mapstructure:"encryption"`
+
+	// Reliable event delivery configurations
+	Outbox *OutboxConfig `yaml:"outbox" mapstructure:"outbox"`
+
+	// Background job queue configuration
+	Jobs *JobsConfig `yaml:"jobs" mapstructure:"jobs"`
+
+	// Periodic maintenance task configuration
+	Scheduler *SchedulerConfig `yaml:"scheduler" mapstructure:"scheduler"`
+
+	// Audit log configuration
+	Audit *AuditConfig `yaml:"audit" mapstructure:"audit"`
+
+	// Bulk user import configuration
+	Import *ImportConfig `yaml:"import" mapstructure:"import"`
+
+	// Admin statistics configuration
+	Stats *StatsConfig `yaml:"stats" mapstructure:"stats"`
+
+	// User list configuration
+	Users *UsersConfig `yaml:"users" mapstructure:"users"`
+
+	// Per-user preference configuration
+	Preferences *PreferencesConfig `yaml:"preferences" mapstructure:"preferences"`
+
+	// Notification configuration
+	Notifications *NotificationsConfig `yaml:"notifications" mapstructure:"notifications"`
+
+	// Organization configuration
+	Organizations *OrganizationsConfig `yaml:"organizations" mapstructure:"organizations"`
+
+	// HTTP API version configuration
+	API *APIConfig `yaml:"api" mapstructure:"api"`
+
+	// User search backend configuration
+	Search *SearchConfig `yaml:"search" mapstructure:"search"`
+
+	// Object storage configuration
+	Storage *StorageConfig `yaml:"storage" mapstructure:"storage"`
+
+	// Webhook delivery configuration
+	Webhooks *WebhooksConfig `yaml:"webhooks" mapstructure:"webhooks"`
+
+	// Retry policy for transient infrastructure failures
+	Retry *RetryConfig `yaml:"retry" mapstructure:"retry"`
+	// Circuit breakers for Redis and etcd
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuit_breaker" mapstructure:"circuit_breaker"`
+
+	// Multi-tenancy configuration
+	Tenancy *TenancyConfig `yaml:"tenancy" mapstructure:"tenancy"`
+
+	// Response localization configuration
+	I18n *I18nConfig `yaml:"i18n" mapstructure:"i18n"`
+
+	// Debugging tools configurations
+	Replay *ReplayConfig `yaml:"replay" mapstructure:"replay"`
+
 	// External services configurations
 	External *ExternalConfig `yaml:"external" mapstructure:"external"`
+
+	// Secret store configurations for vault:/aws-sm: value references
+	Secrets *SecretsConfig `yaml:"secrets" mapstructure:"secrets"`
 }
 
 // AppConfig represents application-level configuration
@@ -31,6 +109,9 @@ type AppConfig struct {
 	Debug       bool   `yaml:"debug" mapstructure:"debug" env:"APP_DEBUG"`
 }
 
+// DefaultShutdownTimeout bounds the HTTP server drain unless configured
+const DefaultShutdownTimeout = 30 * time.Second
+
 // ServerConfig represents HTTP server configuration
 type ServerConfig struct {
 	Host         string        `yaml:"host" mapstructure:"host" env:"SERVER_HOST"`
@@ -39,6 +120,38 @@ type ServerConfig struct {
 	WriteTimeout time.Duration `yaml:"write_timeout" mapstructure:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
 	IdleTimeout  time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
 	EnableCORS   bool          `yaml:"enable_cors" mapstructure:"enable_cors" env:"SERVER_ENABLE_CORS"`
+	// CORS is the policy applied while EnableCORS is set
+	CORS *CORSConfig `yaml:"cors" mapstructure:"cors"`
+	// TLS serves HTTPS on Port instead of plain HTTP
+	TLS *TLSConfig `yaml:"tls" mapstructure:"tls"`
+
+	// MaxBodyBytes caps request bodies (413 beyond it) and HandlerTimeout
+	// cancels a handler's context (504 after it); zero disables either.
+	// Routes overrides both for single routes.
+	MaxBodyBytes   ByteSize      `yaml:"max_body_bytes" mapstructure:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES"`
+	HandlerTimeout time.Duration `yaml:"handler_timeout" mapstructure:"handler_timeout" env:"SERVER_HANDLER_TIMEOUT"`
+	Routes         []RouteConfig `yaml:"routes,omitempty" mapstructure:"routes"`
+
+	// ShutdownTimeout is how long in-flight requests may finish on
+	// shutdown; zero uses DefaultShutdownTimeout
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" mapstructure:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+
+	// TrustedProxies lists the addresses or CIDR ranges of reverse proxies
+	// whose X-Forwarded-For and X-Real-IP headers name the client. When
+	// empty, the client IP is always the peer address.
+	TrustedProxies []string `yaml:"trusted_proxies" mapstructure:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
+
+	// AdminUI serves the bundled management UI
+	AdminUI *AdminUIConfig `yaml:"admin_ui" mapstructure:"admin_ui"`
+}
+
+// RouteConfig sets the limits of one route, named by method and path
+// pattern as registered, e.g. "POST /api/v1/admin/users/import". Both
+// limits replace the server defaults; zero disables a limit.
+type RouteConfig struct {
+	Route          string        `yaml:"route" mapstructure:"route"`
+	MaxBodyBytes   ByteSize      `yaml:"max_body_bytes" mapstructure:"max_body_bytes"`
+	HandlerTimeout time.Duration `yaml:"handler_timeout" mapstructure:"handler_timeout"`
 }
 
 // LogConfig represents logging configuration
@@ -54,6 +167,14 @@ type LogConfig struct {
 	MaxBackups    int    `yaml:"max_backups" mapstructure:"max_backups" env:"LOG_MAX_BACKUPS"`
 	MaxAge        int    `yaml:"max_age" mapstructure:"max_age" env:"LOG_MAX_AGE"`
 	Compress      bool   `yaml:"compress" mapstructure:"compress" env:"LOG_COMPRESS"`
+
+	Sampling LogSamplingConfig `yaml:"sampling" mapstructure:"sampling"`
+	Async    LogAsyncConfig    `yaml:"async" mapstructure:"async"`
+	Ship     LogShipConfig     `yaml:"ship" mapstructure:"ship"`
+
+	// SignalLevelTTL is how long a level changed with SIGUSR1 or SIGUSR2
+	// lasts; 0 keeps it until the next change or restart
+	SignalLevelTTL time.Duration `yaml:"signal_level_ttl" mapstructure:"signal_level_ttl" env:"LOG_SIGNAL_LEVEL_TTL"`
 }
 
 // IDConfig represents ID generation configuration
@@ -61,35 +182,77 @@ type IDConfig struct {
 	ServiceType string `yaml:"service_type" mapstructure:"service_type" env:"ID_SERVICE_TYPE"`
 	InstanceID  int64  `yaml:"instance_id" mapstructure:"instance_id" env:"ID_INSTANCE_ID"`
 	NodeID      int64  `yaml:"node_id" mapstructure:"node_id" env:"ID_NODE_ID"`
+
+	// Allocator selects how the snowflake node ID is assigned: static,
+	// machine, fallback, etcd, redis, kubernetes_ordinal or kubernetes_lease.
+	// Empty keeps the environment variable detection (ETCD_ENDPOINTS,
+	// K8S_NODE_ID, USE_MACHINE_BASED_ID, USE_FALLBACK_ALLOCATOR).
+	Allocator string `yaml:"allocator" mapstructure:"allocator" env:"ID_ALLOCATOR"`
+	// EtcdEndpoints are used by the etcd allocator
+	EtcdEndpoints []string `yaml:"etcd_endpoints" mapstructure:"etcd_endpoints" env:"ID_ETCD_ENDPOINTS"`
+	// RedisKeyPrefix namespaces the redis allocator's keys in external.redis
+	RedisKeyPrefix string `yaml:"redis_key_prefix" mapstructure:"redis_key_prefix" env:"ID_REDIS_KEY_PREFIX"`
+	// LeaseTimeout and RenewInterval apply to the etcd, redis and
+	// kubernetes_lease allocators
+	LeaseTimeout  time.Duration `yaml:"lease_timeout" mapstructure:"lease_timeout" env:"ID_LEASE_TIMEOUT"`
+	RenewInterval time.Duration `yaml:"renew_interval" mapstructure:"renew_interval" env:"ID_RENEW_INTERVAL"`
 }
 
+// Node ID allocators selectable with IDConfig.Allocator
+const (
+	IDAllocatorStatic            = "static"
+	IDAllocatorMachine           = "machine"
+	IDAllocatorFallback          = "fallback"
+	IDAllocatorEtcd              = "etcd"
+	IDAllocatorRedis             = "redis"
+	IDAllocatorKubernetesOrdinal = "kubernetes_ordinal"
+	IDAllocatorKubernetesLease   = "kubernetes_lease"
+)
+
 // ExternalConfig represents external services configuration
 type ExternalConfig struct {
 	Redis *RedisConfig `yaml:"redis" mapstructure:"redis"`
 	Email *EmailConfig `yaml:"email" mapstructure:"email"`
+
+	Messaging *MessagingConfig `yaml:"messaging" mapstructure:"messaging"`
+	Sentry    *SentryConfig    `yaml:"sentry" mapstructure:"sentry"`
 }
 
-// RedisConfig represents Redis configuration (future use)
+// RedisConfig represents Redis configuration. Readiness probes ping Redis
+// only when it is enabled.
 type RedisConfig struct {
+	Enabled  bool   `yaml:"enabled" mapstructure:"enabled" env:"REDIS_ENABLED"`
 	Host     string `yaml:"host" mapstructure:"host" env:"REDIS_HOST"`
 	Port     int    `yaml:"port" mapstructure:"port" env:"REDIS_PORT"`
-	Password string `yaml:"password" mapstructure:"password" env:"REDIS_PASSWORD"`
+	Password string `yaml:"password" mapstructure:"password" env:"REDIS_PASSWORD" redact:"true"`
 	Database int    `yaml:"database" mapstructure:"database" env:"REDIS_DATABASE"`
 }
 
-// EmailConfig represents email service configuration (future use)
-type EmailConfig struct {
-	Provider string `yaml:"provider" mapstructure:"provider" env:"EMAIL_PROVIDER"`
-	Host     string `yaml:"host" mapstructure:"host" env:"EMAIL_HOST"`
-	Port     int    `yaml:"port" mapstructure:"port" env:"EMAIL_PORT"`
-	Username string `yaml:"username" mapstructure:"username" env:"EMAIL_USERNAME"`
-	Password string `yaml:"password" mapstructure:"password" env:"EMAIL_PASSWORD"`
-}
-
 // JWTConfig represents JWT configuration
 type JWTConfig struct {
-	SigningKey string        `yaml:"signing_key" mapstructure:"signing_key" env:"JWT_SIGNING_KEY"`
+	SigningKey string        `yaml:"signing_key" mapstructure:"signing_key" env:"JWT_SIGNING_KEY" redact:"true"`
 	Expiry     time.Duration `yaml:"expiry" mapstructure:"expiry" env:"JWT_EXPIRY"`
+
+	// ActiveKeyID selects the entry of Keys that signs new tokens. When Keys
+	// is empty, SigningKey signs HS256 tokens without a key ID.
+	ActiveKeyID string         `yaml:"active_key_id" mapstructure:"active_key_id" env:"JWT_ACTIVE_KEY_ID"`
+	Keys        []JWTKeyConfig `yaml:"keys" mapstructure:"keys"`
+	// AcceptLegacyTokens keeps validating tokens without a key ID against
+	// SigningKey while migrating to Keys
+	AcceptLegacyTokens bool `yaml:"accept_legacy_tokens" mapstructure:"accept_legacy_tokens" env:"JWT_ACCEPT_LEGACY_TOKENS"`
+}
+
+// JWTKeyConfig is one signing or verification key. HS256 keys use Secret;
+// RS256 and ES256 keys use a PEM private key, or only a public key when the
+// key is retired and kept to validate tokens it already signed.
+type JWTKeyConfig struct {
+	ID             string `yaml:"id" mapstructure:"id"`
+	Algorithm      string `yaml:"algorithm" mapstructure:"algorithm"`
+	Secret         string `yaml:"secret,omitempty" mapstructure:"secret" redact:"true"`
+	PrivateKey     string `yaml:"private_key,omitempty" mapstructure:"private_key" redact:"true"`
+	PrivateKeyFile string `yaml:"private_key_file,omitempty" mapstructure:"private_key_file"`
+	PublicKey      string `yaml:"public_key,omitempty" mapstructure:"public_key" redact:"false"`
+	PublicKeyFile  string `yaml:"public_key_file,omitempty" mapstructure:"public_key_file"`
 }
 
 // DefaultConfig returns the default configuration
@@ -108,6 +271,14 @@ func DefaultConfig() *Config {
 			WriteTimeout: 30 * time.Second,
 			IdleTimeout:  60 * time.Second,
 			EnableCORS:   true,
+			CORS:         DefaultCORSConfig(),
+			TLS:          DefaultTLSConfig(),
+			AdminUI:      DefaultAdminUIConfig(),
+
+			MaxBodyBytes:   1 << 20,
+			HandlerTimeout: 15 * time.Second,
+
+			ShutdownTimeout: DefaultShutdownTimeout,
 		},
 		Database: DefaultDatabaseConfig(),
 		Log: &LogConfig{
@@ -122,16 +293,47 @@ func DefaultConfig() *Config {
 			MaxBackups:    3,
 			MaxAge:        28, // days
 			Compress:      true,
+			Async:         LogAsyncConfig{Capacity: logger.DefaultAsyncCapacity},
+			Ship:          DefaultLogShipConfig(),
+
+			SignalLevelTTL: 10 * time.Minute,
 		},
 		JWT: &JWTConfig{
 			SigningKey: "your-secret-signing-key-change-this-in-production",
 			Expiry:     24 * time.Hour,
 		},
 		ID: &IDConfig{
-			ServiceType: "user",
-			InstanceID:  0,
-			NodeID:      1,
+			ServiceType:    "user",
+			InstanceID:     0,
+			NodeID:         1,
+			RedisKeyPrefix: "snowflake",
+			LeaseTimeout:   30 * time.Second,
+			RenewInterval:  10 * time.Second,
 		},
+		Security:       DefaultSecurityConfig(),
+		Auth:           DefaultAuthConfig(),
+		Bootstrap:      DefaultBootstrapConfig(),
+		Account:        DefaultAccountConfig(),
+		Encryption:     DefaultEncryptionConfig(),
+		Outbox:         DefaultOutboxConfig(),
+		Jobs:           DefaultJobsConfig(),
+		Scheduler:      DefaultSchedulerConfig(),
+		Audit:          DefaultAuditConfig(),
+		Import:         DefaultImportConfig(),
+		Stats:          DefaultStatsConfig(),
+		Users:          DefaultUsersConfig(),
+		Preferences:    DefaultPreferencesConfig(),
+		Notifications:  DefaultNotificationsConfig(),
+		Organizations:  DefaultOrganizationsConfig(),
+		API:            DefaultAPIConfig(),
+		Search:         DefaultSearchConfig(),
+		Storage:        DefaultStorageConfig(),
+		Webhooks:       DefaultWebhooksConfig(),
+		Retry:          DefaultRetryConfig(),
+		CircuitBreaker: DefaultCircuitBreakerConfig(),
+		Tenancy:        DefaultTenancyConfig(),
+		I18n:           DefaultI18nConfig(),
+		Replay:         DefaultReplayConfig(),
 		External: &ExternalConfig{
 			Redis: &RedisConfig{
 				Host:     "localhost",
@@ -139,44 +341,245 @@ func DefaultConfig() *Config {
 				Password: "",
 				Database: 0,
 			},
-			Email: &EmailConfig{
-				Provider: "smtp",
-				Host:     "smtp.gmail.com",
-				Port:     587,
-				Username: "",
-				Password: "",
-			},
+			Email:     DefaultEmailConfig(),
+			Messaging: DefaultMessagingConfig(),
+			Sentry:    DefaultSentryConfig(),
 		},
+		Secrets: DefaultSecretsConfig(),
 	}
 }
 
-// Validate validates the entire configuration
+// Validate validates the entire configuration. Every failed section is
+// reported, not just the first.
 func (c *Config) Validate() error {
+	return errors.Join(c.ValidationErrors()...)
+}
+
+// ValidationErrors returns the validation error of every failed section, in
+// section order
+func (c *Config) ValidationErrors() []error {
+	var errs []error
+
 	if err := c.App.Validate(); err != nil {
-		return fmt.Errorf("app config validation failed: %w", err)
+		errs = append(errs, fmt.Errorf("app config validation failed: %w", err))
 	}
 
 	if err := c.Server.Validate(); err != nil {
-		return fmt.Errorf("server config validation failed: %w", err)
+		errs = append(errs, fmt.Errorf("server config validation failed: %w", err))
 	}
 
 	if err := c.Database.Validate(); err != nil {
-		return fmt.Errorf("database config validation failed: %w", err)
+		errs = append(errs, fmt.Errorf("database config validation failed: %w", err))
 	}
 
 	if err := c.Log.Validate(); err != nil {
-		return fmt.Errorf("log config validation failed: %w", err)
+		errs = append(errs, fmt.Errorf("log config validation failed: %w", err))
 	}
 
 	if err := c.ID.Validate(); err != nil {
-		return fmt.Errorf("id config validation failed: %w", err)
+		errs = append(errs, fmt.Errorf("id config validation failed: %w", err))
+	}
+	if c.ID.Allocator == IDAllocatorRedis && (c.External == nil || c.External.Redis == nil || !c.External.Redis.Enabled) {
+		errs = append(errs, fmt.Errorf("id config validation failed: allocator redis requires external.redis to be enabled"))
 	}
 
 	if err := c.JWT.Validate(); err != nil {
-		return fmt.Errorf("jwt config validation failed: %w", err)
+		errs = append(errs, fmt.Errorf("jwt config validation failed: %w", err))
 	}
 
-	return nil
+	if c.Security != nil {
+		if err := c.Security.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("security config validation failed: %w", err))
+		}
+		if l := c.Security.Lockout; l != nil && l.Enabled && l.Store == "redis" &&
+			(c.External == nil || c.External.Redis == nil || !c.External.Redis.Enabled) {
+			errs = append(errs, fmt.Errorf("security config validation failed: lockout store redis requires external.redis to be enabled"))
+		}
+		if s := c.Security.Signup; s != nil && s.Enabled && s.Store == "redis" &&
+			(c.External == nil || c.External.Redis == nil || !c.External.Redis.Enabled) {
+			errs = append(errs, fmt.Errorf("security config validation failed: signup store redis requires external.redis to be enabled"))
+		}
+	}
+
+	if c.Auth != nil {
+		if err := c.Auth.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("auth config validation failed: %w", err))
+		}
+	}
+
+	if c.Bootstrap != nil {
+		if err := c.Bootstrap.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("bootstrap config validation failed: %w", err))
+		}
+	}
+
+	if c.Outbox != nil {
+		if err := c.Outbox.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("outbox config validation failed: %w", err))
+		}
+	}
+
+	if c.Jobs != nil {
+		if err := c.Jobs.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("jobs config validation failed: %w", err))
+		}
+		if c.Jobs.Enabled && c.Jobs.Backend == JobsBackendRedis &&
+			(c.External == nil || c.External.Redis == nil || !c.External.Redis.Enabled) {
+			errs = append(errs, fmt.Errorf("jobs config validation failed: backend redis requires external.redis to be enabled"))
+		}
+	}
+
+	if c.Scheduler != nil {
+		if err := c.Scheduler.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("scheduler config validation failed: %w", err))
+		}
+	}
+
+	if c.Audit != nil {
+		if err := c.Audit.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("audit config validation failed: %w", err))
+		}
+	}
+
+	if c.Account != nil {
+		if err := c.Account.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("account config validation failed: %w", err))
+		}
+		// Accounts scheduled for deletion are only removed by the purge
+		if c.Account.DeletionGracePeriod > 0 && (c.Scheduler == nil || c.Scheduler.AccountPurgeSchedule == "") {
+			errs = append(errs, fmt.Errorf("account config validation failed: deletion_grace_period needs scheduler.account_purge_schedule, or set it to 0"))
+		}
+	}
+
+	if c.Encryption != nil {
+		if err := c.Encryption.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("encryption config validation failed: %w", err))
+		}
+	}
+
+	if c.Import != nil {
+		if err := c.Import.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("import config validation failed: %w", err))
+		}
+	}
+
+	if c.Stats != nil {
+		if err := c.Stats.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("stats config validation failed: %w", err))
+		}
+	}
+
+	if c.Users != nil {
+		if err := c.Users.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("users config validation failed: %w", err))
+		}
+	}
+
+	if c.Preferences != nil {
+		if err := c.Preferences.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("preferences config validation failed: %w", err))
+		}
+	}
+
+	if c.Notifications != nil {
+		if err := c.Notifications.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("notifications config validation failed: %w", err))
+		}
+	}
+
+	if c.Organizations != nil {
+		if err := c.Organizations.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("organizations config validation failed: %w", err))
+		}
+	}
+
+	if c.API != nil {
+		if err := c.API.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("api config validation failed: %w", err))
+		}
+	}
+
+	if c.Search != nil {
+		if err := c.Search.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("search config validation failed: %w", err))
+		}
+		// The index would hold the plaintext encryption protects
+		if c.Search.Elasticsearch() && c.Encryption != nil && c.Encryption.Enabled {
+			errs = append(errs, fmt.Errorf("search config validation failed: the elasticsearch backend cannot be used while encryption is enabled"))
+		}
+	}
+
+	if c.Storage != nil {
+		if err := c.Storage.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("storage config validation failed: %w", err))
+		}
+	}
+
+	if c.Webhooks != nil {
+		if err := c.Webhooks.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("webhooks config validation failed: %w", err))
+		}
+	}
+
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("retry config validation failed: %w", err))
+		}
+	}
+
+	if c.CircuitBreaker != nil {
+		if err := c.CircuitBreaker.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("circuit breaker config validation failed: %w", err))
+		}
+	}
+
+	if c.Tenancy != nil {
+		if err := c.Tenancy.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("tenancy config validation failed: %w", err))
+		}
+	}
+
+	if c.I18n != nil {
+		if err := c.I18n.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("i18n config validation failed: %w", err))
+		}
+	}
+
+	if c.External != nil && c.External.Email != nil {
+		if err := c.External.Email.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("email config validation failed: %w", err))
+		}
+	}
+
+	if c.External != nil && c.External.Messaging != nil {
+		if err := c.External.Messaging.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("messaging config validation failed: %w", err))
+		}
+	}
+
+	if c.External != nil && c.External.Sentry != nil {
+		if err := c.External.Sentry.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("sentry config validation failed: %w", err))
+		}
+	}
+
+	if c.Replay != nil {
+		if err := c.Replay.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("replay config validation failed: %w", err))
+		}
+	}
+
+	if c.Secrets != nil {
+		if err := c.Secrets.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("secrets config validation failed: %w", err))
+		}
+	}
+
+	if report := c.CheckHardening(); report.HasIssues() {
+		errs = append(errs, report)
+	}
+
+	return errs
 }
 
 // Validate validates app configuration
@@ -213,6 +616,50 @@ func (c *ServerConfig) Validate() error {
 	if c.IdleTimeout <= 0 {
 		return fmt.Errorf("server idle_timeout must be positive")
 	}
+	if c.EnableCORS && c.CORS != nil {
+		if err := c.CORS.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return err
+		}
+		if c.TLS.ListensHTTP() && c.TLS.HTTPPort == c.Port {
+			return fmt.Errorf("server tls http_port must differ from port")
+		}
+	}
+	if c.MaxBodyBytes < 0 {
+		return fmt.Errorf("server max_body_bytes must not be negative")
+	}
+	if c.HandlerTimeout < 0 {
+		return fmt.Errorf("server handler_timeout must not be negative")
+	}
+	if c.ShutdownTimeout < 0 {
+		return fmt.Errorf("server shutdown_timeout must not be negative")
+	}
+	if err := validateIPRanges("server trusted_proxies", c.TrustedProxies); err != nil {
+		return err
+	}
+	if c.AdminUI != nil {
+		if err := c.AdminUI.Validate(); err != nil {
+			return err
+		}
+	}
+	seen := make(map[string]bool, len(c.Routes))
+	for _, r := range c.Routes {
+		method, path, ok := strings.Cut(r.Route, " ")
+		if !ok || method != strings.ToUpper(method) || !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("server route %q must be a method and path such as \"POST /api/v1/users/register\"", r.Route)
+		}
+		if seen[r.Route] {
+			return fmt.Errorf("server route %q is listed twice", r.Route)
+		}
+		seen[r.Route] = true
+		if r.MaxBodyBytes < 0 || r.HandlerTimeout < 0 {
+			return fmt.Errorf("server route %q limits must not be negative", r.Route)
+		}
+	}
 	return nil
 }
 
@@ -242,7 +689,7 @@ func (c *LogConfig) Validate() error {
 		return fmt.Errorf("log format must be one of: %v", validFormats)
 	}
 
-	validOutputs := []string{"stdout", "stderr", "file", "both"}
+	validOutputs := []string{"stdout", "stderr", "file", "both", logger.OutputLoki, logger.OutputElasticsearch}
 	valid = false
 	for _, output := range validOutputs {
 		if c.Output == output {
@@ -274,6 +721,27 @@ func (c *LogConfig) Validate() error {
 		return fmt.Errorf("log max_age must be non-negative")
 	}
 
+	if err := c.Sampling.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Async.Validate(); err != nil {
+		return err
+	}
+
+	if c.SignalLevelTTL < 0 {
+		return fmt.Errorf("log signal_level_ttl must be non-negative")
+	}
+
+	if c.Output == logger.OutputLoki || c.Output == logger.OutputElasticsearch {
+		if c.Format != "json" {
+			return fmt.Errorf("log output %s needs the json format", c.Output)
+		}
+		if err := c.Ship.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -299,23 +767,98 @@ func (c *IDConfig) Validate() error {
 		return fmt.Errorf("id node_id must be non-negative")
 	}
 
+	switch c.Allocator {
+	case "", IDAllocatorStatic, IDAllocatorMachine, IDAllocatorFallback, IDAllocatorKubernetesOrdinal:
+	case IDAllocatorEtcd, IDAllocatorRedis, IDAllocatorKubernetesLease:
+		if c.Allocator == IDAllocatorEtcd && len(c.EtcdEndpoints) == 0 {
+			return fmt.Errorf("id etcd_endpoints are required by the etcd allocator")
+		}
+		if c.Allocator == IDAllocatorRedis && c.RedisKeyPrefix == "" {
+			return fmt.Errorf("id redis_key_prefix cannot be empty")
+		}
+		if c.RenewInterval <= 0 || c.RenewInterval >= c.LeaseTimeout {
+			return fmt.Errorf("id renew_interval must be positive and shorter than lease_timeout")
+		}
+		// client-go leader election renews with jitter of up to 1.2 intervals
+		// and gives up one interval before the lease expires
+		if c.Allocator == IDAllocatorKubernetesLease && c.LeaseTimeout*5 <= c.RenewInterval*11 {
+			return fmt.Errorf("id lease_timeout must be more than 2.2 times renew_interval for the kubernetes_lease allocator")
+		}
+	default:
+		return fmt.Errorf("id allocator must be one of: %v", []string{
+			IDAllocatorStatic, IDAllocatorMachine, IDAllocatorFallback, IDAllocatorEtcd,
+			IDAllocatorRedis, IDAllocatorKubernetesOrdinal, IDAllocatorKubernetesLease,
+		})
+	}
+
 	return nil
 }
 
 // Validate validates JWT configuration
 func (c *JWTConfig) Validate() error {
-	if c.SigningKey == "" {
-		return fmt.Errorf("jwt signing_key is required")
-	}
-	if len(c.SigningKey) < 32 {
-		return fmt.Errorf("jwt signing_key must be at least 32 characters long")
+	if c.UsesSigningKey() {
+		if c.SigningKey == "" {
+			return fmt.Errorf("jwt signing_key is required")
+		}
+		if len(c.SigningKey) < 32 {
+			return fmt.Errorf("jwt signing_key must be at least 32 characters long")
+		}
 	}
 	if c.Expiry <= 0 {
 		return fmt.Errorf("jwt expiry must be positive")
 	}
+	if len(c.Keys) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(c.Keys))
+	activeCanSign := false
+	for i, k := range c.Keys {
+		if k.ID == "" {
+			return fmt.Errorf("jwt keys[%d] id is required", i)
+		}
+		if seen[k.ID] {
+			return fmt.Errorf("jwt key id %q is duplicated", k.ID)
+		}
+		seen[k.ID] = true
+
+		hasPrivate := k.PrivateKey != "" || k.PrivateKeyFile != ""
+		hasPublic := k.PublicKey != "" || k.PublicKeyFile != ""
+		switch k.Algorithm {
+		case "HS256":
+			if len(k.Secret) < 32 {
+				return fmt.Errorf("jwt key %q secret must be at least 32 characters long", k.ID)
+			}
+			hasPrivate = true
+		case "RS256", "ES256":
+			if !hasPrivate && !hasPublic {
+				return fmt.Errorf("jwt key %q needs a private_key or public_key", k.ID)
+			}
+		default:
+			return fmt.Errorf("jwt key %q algorithm must be one of: HS256, RS256, ES256", k.ID)
+		}
+		if k.ID == c.ActiveKeyID {
+			activeCanSign = hasPrivate
+		}
+	}
+
+	if c.ActiveKeyID == "" {
+		return fmt.Errorf("jwt active_key_id is required when keys are configured")
+	}
+	if !seen[c.ActiveKeyID] {
+		return fmt.Errorf("jwt active_key_id %q does not match any key", c.ActiveKeyID)
+	}
+	if !activeCanSign {
+		return fmt.Errorf("jwt active key %q has no private key", c.ActiveKeyID)
+	}
 	return nil
 }
 
+// UsesSigningKey reports whether SigningKey signs or validates tokens
+func (c *JWTConfig) UsesSigningKey() bool {
+	return len(c.Keys) == 0 || c.AcceptLegacyTokens
+}
+
 // GetEnvironment returns the current environment
 func (c *Config) GetEnvironment() string {
 	return c.App.Environment
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CORSConfig represents the cross-origin policy applied while
+// server.enable_cors is set
+type CORSConfig struct {
+	// AllowedOrigins lists exact origins such as https://app.example.com.
+	// "*" allows any origin and https://*.example.com any subdomain.
+	AllowedOrigins []string `yaml:"allowed_origins" mapstructure:"allowed_origins" env:"SERVER_CORS_ALLOWED_ORIGINS"`
+	AllowedMethods []string `yaml:"allowed_methods" mapstructure:"allowed_methods" env:"SERVER_CORS_ALLOWED_METHODS"`
+	// AllowedHeaders lists request headers; "*" allows any
+	AllowedHeaders []string `yaml:"allowed_headers" mapstructure:"allowed_headers" env:"SERVER_CORS_ALLOWED_HEADERS"`
+	// ExposedHeaders lists response headers readable by scripts
+	ExposedHeaders   []string `yaml:"exposed_headers" mapstructure:"exposed_headers" env:"SERVER_CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool     `yaml:"allow_credentials" mapstructure:"allow_credentials" env:"SERVER_CORS_ALLOW_CREDENTIALS"`
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration `yaml:"max_age" mapstructure:"max_age" env:"SERVER_CORS_MAX_AGE"`
+}
+
+// DefaultCORSConfig returns default CORS configuration
+func DefaultCORSConfig() *CORSConfig {
+	return &CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "Accept", "Origin", "Cache-Control", "X-Requested-With", "X-Trace-ID", "X-Request-ID", "X-Correlation-ID", "X-Tenant-ID", "If-Match", "If-None-Match"},
+		ExposedHeaders: []string{"X-Trace-ID", "X-Request-ID", "X-Correlation-ID", "ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// AllowsAnyOrigin reports whether the policy accepts every origin
+func (c *CORSConfig) AllowsAnyOrigin() bool {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate validates CORS configuration
+func (c *CORSConfig) Validate() error {
+	if len(c.AllowedOrigins) == 0 {
+		return fmt.Errorf("cors allowed_origins cannot be empty")
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin != "*" && !strings.Contains(origin, "://") {
+			return fmt.Errorf("cors allowed origin %q must include the scheme, e.g. https://app.example.com", origin)
+		}
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("cors allowed origin %q may contain at most one wildcard", origin)
+		}
+	}
+	if c.AllowCredentials && c.AllowsAnyOrigin() {
+		return fmt.Errorf("cors allow_credentials cannot be combined with allowed origin \"*\"")
+	}
+	for _, method := range c.AllowedMethods {
+		switch strings.ToUpper(method) {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("cors allowed method %q is not supported", method)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("cors max_age must not be negative")
+	}
+	return nil
+}
@@ -2,15 +2,47 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
 	"time"
 )
 
+// Database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+)
+
+// DatabaseDrivers lists the supported values of database.driver
+var DatabaseDrivers = []string{DriverPostgres, DriverMySQL, DriverSQLite}
+
+// mysqlTLS maps the PostgreSQL ssl modes used by ssl_mode to the MySQL
+// driver's tls parameter
+var mysqlTLS = map[string]string{
+	"disable":     "false",
+	"allow":       "preferred",
+	"prefer":      "preferred",
+	"require":     "skip-verify",
+	"verify-ca":   "true",
+	"verify-full": "true",
+}
+
+// SQLiteMemory is the database name of an in-memory SQLite database
+const SQLiteMemory = ":memory:"
+
 // DatabaseConfig represents database configuration
 type DatabaseConfig struct {
+	// Driver selects the database; with sqlite, Database is the file path
+	// and the server settings are ignored. SSLMode takes PostgreSQL's modes
+	// with every driver.
+	Driver          string        `yaml:"driver" mapstructure:"driver" env:"DB_DRIVER"`
 	Host            string        `yaml:"host" mapstructure:"host" env:"DB_HOST"`
 	Port            int           `yaml:"port" mapstructure:"port" env:"DB_PORT"`
 	Username        string        `yaml:"username" mapstructure:"username" env:"DB_USERNAME"`
-	Password        string        `yaml:"password" mapstructure:"password" env:"DB_PASSWORD"`
+	Password        string        `yaml:"password" mapstructure:"password" env:"DB_PASSWORD" redact:"true"`
 	Database        string        `yaml:"database" mapstructure:"database" env:"DB_DATABASE"`
 	SSLMode         string        `yaml:"ssl_mode" mapstructure:"ssl_mode" env:"DB_SSL_MODE"`
 	Timezone        string        `yaml:"timezone" mapstructure:"timezone" env:"DB_TIMEZONE"`
@@ -19,11 +51,39 @@ type DatabaseConfig struct {
 	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" mapstructure:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
 	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" mapstructure:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
 	LogLevel        string        `yaml:"log_level" mapstructure:"log_level" env:"DB_LOG_LEVEL"`
+	// SlowQueryThreshold logs statements that take at least this long with
+	// their trace ID; zero disables slow query logging
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" mapstructure:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD"`
+	// AutoMigrate applies pending schema migrations at startup. When false,
+	// startup fails unless migrations were run with `migrate up`.
+	AutoMigrate bool `yaml:"auto_migrate" mapstructure:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+	// ReplicaHosts lists read replicas as "host" or "host:port". Replicas
+	// share the primary's credentials, database name and pool settings.
+	ReplicaHosts []string `yaml:"replica_hosts" mapstructure:"replica_hosts" env:"DB_REPLICA_HOSTS"`
+	// ReplicaCheckInterval is how often replicas are pinged; failing
+	// replicas receive no reads until they recover
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" mapstructure:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL"`
+	// PrepareStmt caches prepared statements per connection. Disable it
+	// behind poolers that do not keep a client on one server connection,
+	// such as PgBouncer in transaction mode.
+	PrepareStmt bool `yaml:"prepare_stmt" mapstructure:"prepare_stmt" env:"DB_PREPARE_STMT"`
+	// StatementTimeout makes the server abort statements running longer,
+	// as statement_timeout on PostgreSQL and max_execution_time (SELECT
+	// only) on MySQL; zero leaves the server's setting
+	StatementTimeout time.Duration `yaml:"statement_timeout" mapstructure:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	// QueryTimeout bounds each statement's context, on top of any deadline
+	// the caller set; zero relies on the caller's context alone
+	QueryTimeout time.Duration `yaml:"query_timeout" mapstructure:"query_timeout" env:"DB_QUERY_TIMEOUT"`
+	// MonitorInterval is how often the primary is pinged in the background;
+	// readiness then reports the last ping. Zero pings on every probe
+	// instead and disables reconnecting.
+	MonitorInterval time.Duration `yaml:"monitor_interval" mapstructure:"monitor_interval" env:"DB_MONITOR_INTERVAL"`
 }
 
 // DefaultDatabaseConfig returns default database configuration
 func DefaultDatabaseConfig() *DatabaseConfig {
 	return &DatabaseConfig{
+		Driver:          DriverPostgres,
 		Host:            "localhost",
 		Port:            5432,
 		Username:        "dev",
@@ -36,17 +96,95 @@ func DefaultDatabaseConfig() *DatabaseConfig {
 		ConnMaxLifetime: time.Hour,
 		ConnMaxIdleTime: time.Minute * 30,
 		LogLevel:        "info",
+		AutoMigrate:     true,
+
+		SlowQueryThreshold:   200 * time.Millisecond,
+		ReplicaCheckInterval: 10 * time.Second,
+		PrepareStmt:          true,
+		QueryTimeout:         30 * time.Second,
+		MonitorInterval:      5 * time.Second,
 	}
 }
 
-// DSN builds PostgreSQL connection string
+// DSN builds the connection string for the configured driver
 func (c *DatabaseConfig) DSN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s timezone=%s",
+	switch c.Driver {
+	case DriverSQLite:
+		return c.sqliteDSN()
+	case DriverMySQL:
+		return c.mysqlDSN()
+	}
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s timezone=%s",
 		c.Host, c.Port, c.Username, c.Password, c.Database, c.SSLMode, c.Timezone)
+	if c.StatementTimeout > 0 {
+		// Sent as a run-time parameter when the session starts
+		dsn += fmt.Sprintf(" statement_timeout=%d", c.StatementTimeout.Milliseconds())
+	}
+	return dsn
+}
+
+// mysqlDSN uses utf8mb4 and reads DATETIME columns as time.Time in
+// Timezone
+func (c *DatabaseConfig) mysqlDSN() string {
+	params := url.Values{}
+	params.Set("charset", "utf8mb4")
+	params.Set("parseTime", "true")
+	params.Set("loc", c.Timezone)
+	params.Set("tls", mysqlTLS[c.SSLMode])
+	if c.StatementTimeout > 0 {
+		// Unknown parameters are set as session variables
+		params.Set("max_execution_time", strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10))
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?%s", c.Username, c.Password,
+		net.JoinHostPort(c.Host, strconv.Itoa(c.Port)), c.Database, params.Encode())
+}
+
+// sqliteDSN waits for locks instead of failing and enforces foreign keys.
+// Transactions take the write lock when they begin, as one that read first
+// fails at once on its first write if another connection wrote meanwhile.
+// An in-memory database is shared by the pool's connections.
+func (c *DatabaseConfig) sqliteDSN() string {
+	params := url.Values{}
+	params.Set("_busy_timeout", "5000")
+	params.Set("_foreign_keys", "on")
+	params.Set("_txlock", "immediate")
+	if c.Database == SQLiteMemory {
+		params.Set("cache", "shared")
+		params.Set("mode", "memory")
+		return "file:wonder?" + params.Encode()
+	}
+	return "file:" + c.Database + "?" + params.Encode()
+}
+
+// ReplicaConfig returns the connection settings for one of ReplicaHosts
+func (c *DatabaseConfig) ReplicaConfig(hostPort string) (*DatabaseConfig, error) {
+	host, port := hostPort, c.Port
+	if h, p, err := net.SplitHostPort(hostPort); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > 65535 {
+			return nil, fmt.Errorf("invalid port in replica host %q", hostPort)
+		}
+		host, port = h, n
+	}
+	if host == "" {
+		return nil, fmt.Errorf("replica host %q has no host name", hostPort)
+	}
+
+	replica := *c
+	replica.Host = host
+	replica.Port = port
+	replica.ReplicaHosts = nil
+	return &replica, nil
 }
 
 // Validate validates database configuration
 func (c *DatabaseConfig) Validate() error {
+	if !slices.Contains(DatabaseDrivers, c.Driver) {
+		return fmt.Errorf("database driver must be one of: %v", DatabaseDrivers)
+	}
+	if c.Driver == DriverSQLite {
+		return c.validateSQLite()
+	}
 	if c.Host == "" {
 		return fmt.Errorf("database host is required")
 	}
@@ -59,6 +197,9 @@ func (c *DatabaseConfig) Validate() error {
 	if c.Database == "" {
 		return fmt.Errorf("database name is required")
 	}
+	if _, ok := mysqlTLS[c.SSLMode]; c.Driver == DriverMySQL && !ok {
+		return fmt.Errorf("ssl_mode %q is not supported with the mysql driver", c.SSLMode)
+	}
 	if c.MaxOpenConns <= 0 {
 		return fmt.Errorf("max_open_conns must be positive")
 	}
@@ -68,5 +209,54 @@ func (c *DatabaseConfig) Validate() error {
 	if c.MaxIdleConns > c.MaxOpenConns {
 		return fmt.Errorf("max_idle_conns cannot be greater than max_open_conns")
 	}
+	if c.SlowQueryThreshold < 0 {
+		return fmt.Errorf("slow_query_threshold cannot be negative")
+	}
+	if err := c.validateTimeouts(); err != nil {
+		return err
+	}
+	for _, hostPort := range c.ReplicaHosts {
+		if _, err := c.ReplicaConfig(hostPort); err != nil {
+			return err
+		}
+	}
+	if len(c.ReplicaHosts) > 0 && c.ReplicaCheckInterval <= 0 {
+		return fmt.Errorf("replica_check_interval must be positive when replicas are configured")
+	}
+	return nil
+}
+
+func (c *DatabaseConfig) validateSQLite() error {
+	if c.Database == "" {
+		return fmt.Errorf("database name is required: a file path or %s", SQLiteMemory)
+	}
+	if len(c.ReplicaHosts) > 0 {
+		return fmt.Errorf("read replicas are not supported with the sqlite driver")
+	}
+	if c.MaxOpenConns <= 0 {
+		return fmt.Errorf("max_open_conns must be positive")
+	}
+	if c.MaxIdleConns > c.MaxOpenConns {
+		return fmt.Errorf("max_idle_conns cannot be greater than max_open_conns")
+	}
+	if c.StatementTimeout != 0 {
+		return fmt.Errorf("statement_timeout is not supported with the sqlite driver, use query_timeout")
+	}
+	return c.validateTimeouts()
+}
+
+func (c *DatabaseConfig) validateTimeouts() error {
+	if c.StatementTimeout < 0 {
+		return fmt.Errorf("statement_timeout cannot be negative")
+	}
+	if c.StatementTimeout > 0 && c.StatementTimeout < time.Millisecond {
+		return fmt.Errorf("statement_timeout must be at least 1ms")
+	}
+	if c.QueryTimeout < 0 {
+		return fmt.Errorf("query_timeout cannot be negative")
+	}
+	if c.MonitorInterval < 0 {
+		return fmt.Errorf("monitor_interval cannot be negative")
+	}
 	return nil
 }
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+	urlType      = reflect.TypeOf(url.URL{})
+	urlPtrType   = reflect.TypeOf(&url.URL{})
+)
+
+// decodeHooks converts raw values to durations, byte sizes, URLs and
+// comma-separated lists. They replace viper's defaults so that a bad value
+// is reported with what it should look like.
+func decodeHooks() viper.DecoderConfigOption {
+	return viper.DecodeHook(decodeHook())
+}
+
+func decodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		decodeDuration,
+		decodeByteSize,
+		decodeURL,
+		decodeList(","),
+	)
+}
+
+// decodeDuration accepts Go durations such as 30s or 1h30m. Numbers other
+// than zero are rejected: YAML has no duration type, and a bare 30 would
+// otherwise mean 30 nanoseconds.
+func decodeDuration(_ reflect.Type, to reflect.Type, data any) (any, error) {
+	if to != durationType {
+		return data, nil
+	}
+	switch v := data.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return time.Duration(0), nil
+		}
+		d, err := time.ParseDuration(s)
+		if err == nil {
+			return d, nil
+		}
+		if _, numErr := strconv.ParseFloat(s, 64); numErr == nil {
+			return nil, fmt.Errorf("duration %q has no unit, write e.g. %ss", v, s)
+		}
+		return nil, fmt.Errorf("invalid duration %q: use a number with a unit such as 30s, 5m or 1h30m", v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		if reflect.ValueOf(v).IsZero() {
+			return time.Duration(0), nil
+		}
+		return nil, fmt.Errorf("duration %v has no unit, write e.g. %vs", v, v)
+	}
+	return data, nil
+}
+
+// decodeByteSize accepts sizes such as 10MB as well as plain byte counts
+func decodeByteSize(_ reflect.Type, to reflect.Type, data any) (any, error) {
+	if to != byteSizeType {
+		return data, nil
+	}
+	switch v := data.(type) {
+	case ByteSize:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return ByteSize(0), nil
+		}
+		return ParseByteSize(v)
+	case float32, float64:
+		f := reflect.ValueOf(v).Float()
+		if f != float64(int64(f)) {
+			return nil, fmt.Errorf("size %v is not a whole number of bytes", v)
+		}
+		return ByteSize(f), nil
+	}
+	return data, nil
+}
+
+// decodeURL parses url.URL and *url.URL fields and requires absolute URLs
+func decodeURL(_ reflect.Type, to reflect.Type, data any) (any, error) {
+	if to != urlType && to != urlPtrType {
+		return data, nil
+	}
+	s, ok := data.(string)
+	if !ok {
+		return data, nil
+	}
+	if strings.TrimSpace(s) == "" {
+		if to == urlPtrType {
+			return (*url.URL)(nil), nil
+		}
+		return url.URL{}, nil
+	}
+	u, err := parseURL(s)
+	if err != nil {
+		return nil, err
+	}
+	if to == urlPtrType {
+		return u, nil
+	}
+	return *u, nil
+}
+
+// parseURL parses an absolute URL, the form every URL setting takes
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: use an absolute URL such as https://example.com/path", s)
+	}
+	return u, nil
+}
+
+// mustParseURL parses the URL of a default value
+func mustParseURL(s string) url.URL {
+	u, err := parseURL(s)
+	if err != nil {
+		panic(err)
+	}
+	return *u
+}
+
+// isHTTPURL reports whether u is an http or https URL
+func isHTTPURL(u url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// isURLSetting reports whether t holds URLs. Though structs, they are
+// single values written as strings, not sections.
+func isURLSetting(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t == urlType || t == urlPtrType
+}
+
+// settingValue returns the value of v as configuration files write it,
+// with URLs as strings
+func settingValue(v reflect.Value) any {
+	switch {
+	case v.Type() == urlType:
+		u := v.Interface().(url.URL)
+		return u.String()
+	case v.Type() == urlPtrType:
+		if v.IsNil() {
+			return ""
+		}
+		return v.Interface().(*url.URL).String()
+	case v.Kind() == reflect.Slice && isURLSetting(v.Type()):
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = settingValue(v.Index(i)).(string)
+		}
+		return values
+	}
+	return v.Interface()
+}
+
+// decodeList splits strings, e.g. from environment variables, into lists
+func decodeList(sep string) mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Slice {
+			return data, nil
+		}
+		raw := data.(string)
+		if raw == "" {
+			return []string{}, nil
+		}
+		return strings.Split(raw, sep), nil
+	}
+}
+
+// unmarshal decodes the merged configuration into cfg
+func (l *Loader) unmarshal(cfg *Config) error {
+	if err := l.viper.Unmarshal(cfg, decodeHooks()); err != nil {
+		return describeDecodeError(err)
+	}
+	return nil
+}
+
+// describeDecodeError rewrites decoding errors as one line per key, each
+// naming the key, the value and what was expected
+func describeDecodeError(err error) error {
+	var problems []string
+	var collect func(err error)
+	collect = func(err error) {
+		decodeErr, ok := err.(*mapstructure.DecodeError)
+		if !ok {
+			switch wrapped := err.(type) {
+			case interface{ Unwrap() []error }:
+				for _, e := range wrapped.Unwrap() {
+					collect(e)
+				}
+			case interface{ Unwrap() error }:
+				collect(wrapped.Unwrap())
+			default:
+				problems = append(problems, err.Error())
+			}
+			return
+		}
+		var parseErr *mapstructure.ParseError
+		var typeErr *mapstructure.UnconvertibleTypeError
+		switch {
+		case errors.As(decodeErr, &parseErr):
+			problems = append(problems, fmt.Sprintf("%s: cannot parse %q as %s", decodeErr.Name(), fmt.Sprint(parseErr.Value), parseErr.Expected.Type()))
+		case errors.As(decodeErr, &typeErr):
+			problems = append(problems, fmt.Sprintf("%s: expected %s, got %v", decodeErr.Name(), typeErr.Expected.Type(), typeErr.Value))
+		default:
+			problems = append(problems, fmt.Sprintf("%s: %v", decodeErr.Name(), decodeErr.Unwrap()))
+		}
+	}
+	collect(err)
+	return fmt.Errorf("invalid config values:\n  %s", strings.Join(problems, "\n  "))
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	valid := map[string]ByteSize{
+		"0":       0,
+		"1048576": Megabyte,
+		"512KB":   512 * Kilobyte,
+		"10MB":    10 * Megabyte,
+		"10mb":    10 * Megabyte,
+		"1.5 MiB": Megabyte + 512*Kilobyte,
+		"2G":      2 * Gigabyte,
+		"100B":    100,
+	}
+	for input, want := range valid {
+		got, err := ParseByteSize(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "MB", "10 XB", "-1MB", "ten", "1.5B", "9999999TB"} {
+		_, err := ParseByteSize(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	assert.Equal(t, "0B", ByteSize(0).String())
+	assert.Equal(t, "100B", ByteSize(100).String())
+	assert.Equal(t, "64KB", (64 * Kilobyte).String())
+	assert.Equal(t, "10MB", (10 * Megabyte).String())
+	assert.Equal(t, "1536KB", (Megabyte + 512*Kilobyte).String())
+}
+
+func TestLoader_TypedValues(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+server:
+  read_timeout: "45s"
+  max_body_bytes: "2MB"
+import:
+  max_body_bytes: 4096
+external:
+  email:
+    app_url: "https://wonder.example.com"
+`), 0600))
+	t.Setenv("REPLAY_MAX_BODY_BYTES", "128KB")
+	t.Setenv("SEARCH_ADDRESSES", "http://es-1:9200,http://es-2:9200")
+
+	cfg, err := NewLoader().Load(LoadOptions{ConfigPath: configFile})
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 2*Megabyte, cfg.Server.MaxBodyBytes)
+	assert.Equal(t, ByteSize(4096), cfg.Import.MaxBodyBytes)
+	assert.Equal(t, 128*Kilobyte, cfg.Replay.MaxBodyBytes)
+	assert.Equal(t, "wonder.example.com", cfg.External.Email.AppURL.Host)
+	require.Len(t, cfg.Search.Addresses, 2)
+	assert.Equal(t, "es-2:9200", cfg.Search.Addresses[1].Host)
+	assert.Equal(t, "https://api.pwnedpasswords.com", cfg.Security.PasswordBreach.APIURL.String(), "URL defaults apply")
+}
+
+func TestLoader_MalformedValues(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{
+			name: "duration without a unit",
+			yaml: "server:\n  read_timeout: 30\n",
+			want: []string{`server.read_timeout: duration 30 has no unit, write e.g. 30s`},
+		},
+		{
+			name: "duration string without a unit",
+			yaml: "server:\n  write_timeout: \"15\"\n",
+			want: []string{`server.write_timeout: duration "15" has no unit, write e.g. 15s`},
+		},
+		{
+			name: "unparseable duration",
+			yaml: "server:\n  read_timeout: \"5 seconds\"\n",
+			want: []string{`server.read_timeout: invalid duration "5 seconds"`},
+		},
+		{
+			name: "unparseable size",
+			yaml: "server:\n  max_body_bytes: \"ten megs\"\n",
+			want: []string{`server.max_body_bytes: invalid size "ten megs"`},
+		},
+		{
+			name: "relative URL",
+			yaml: "external:\n  email:\n    app_url: \"wonder.example.com\"\n",
+			want: []string{`external.email.app_url: invalid URL "wonder.example.com"`},
+		},
+		{
+			name: "unparseable number",
+			yaml: "server:\n  port: \"http\"\n",
+			want: []string{`server.port: cannot parse "http" as int`},
+		},
+		{
+			name: "every bad value is reported",
+			yaml: "server:\n  read_timeout: \"soon\"\n  max_body_bytes: \"lots\"\nimport:\n  max_body_bytes: \"1ZB\"\n",
+			want: []string{
+				`server.read_timeout: invalid duration "soon"`,
+				`server.max_body_bytes: invalid size "lots"`,
+				`import.max_body_bytes: invalid size "1ZB"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(tt.yaml), 0600))
+
+			_, err := NewLoader().Load(LoadOptions{ConfigPath: configFile})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid config values")
+			for _, want := range tt.want {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestDecodeURL(t *testing.T) {
+	type target struct {
+		Endpoint url.URL  `mapstructure:"endpoint"`
+		Callback *url.URL `mapstructure:"callback"`
+	}
+	decode := func(input map[string]any) (*target, error) {
+		var out target
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{DecodeHook: decodeURL, Result: &out})
+		require.NoError(t, err)
+		return &out, decoder.Decode(input)
+	}
+
+	out, err := decode(map[string]any{"endpoint": "https://api.example.com/v1", "callback": "http://localhost:8080/cb"})
+	require.NoError(t, err)
+	assert.Equal(t, "api.example.com", out.Endpoint.Host)
+	assert.Equal(t, "/cb", out.Callback.Path)
+
+	out, err = decode(map[string]any{"callback": ""})
+	require.NoError(t, err)
+	assert.Nil(t, out.Callback)
+
+	_, err = decode(map[string]any{"endpoint": "api.example.com/v1"})
+	assert.ErrorContains(t, err, `invalid URL "api.example.com/v1"`)
+}
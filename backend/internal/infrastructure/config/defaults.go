@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// setDefaults registers every value of DefaultConfig as a viper default.
+// Keys come from the mapstructure tags, so a field added to a section and
+// to its Default function needs nothing else here. Lists of sections, such
+// as server.routes, are registered whole; URLs as strings.
+func (l *Loader) setDefaults() {
+	setDefaultValues(l.viper, reflect.ValueOf(DefaultConfig()), "")
+}
+
+func setDefaultValues(v *viper.Viper, value reflect.Value, prefix string) {
+	if isURLSetting(value.Type()) {
+		v.SetDefault(prefix, settingValue(value))
+		return
+	}
+	switch value.Kind() {
+	case reflect.Ptr:
+		if !value.IsNil() {
+			setDefaultValues(v, value.Elem(), prefix)
+		}
+	case reflect.Struct:
+		t := value.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() {
+				setDefaultValues(v, value.Field(i), fieldKey(f, prefix))
+			}
+		}
+	default:
+		v.SetDefault(prefix, value.Interface())
+	}
+}
+
+// fieldKey returns the configuration key of f below prefix
+func fieldKey(f reflect.StructField, prefix string) string {
+	name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
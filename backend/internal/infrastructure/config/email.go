@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"time"
+)
+
+// Email providers
+const (
+	EmailProviderSMTP = "smtp"
+	// EmailProviderLog writes messages to the log instead of sending them,
+	// for development
+	EmailProviderLog = "log"
+)
+
+// EmailConfig represents outbound email. Welcome and password reset emails
+// are sent only when Enabled is set.
+type EmailConfig struct {
+	Enabled  bool   `yaml:"enabled" mapstructure:"enabled" env:"EMAIL_ENABLED"`
+	Provider string `yaml:"provider" mapstructure:"provider" env:"EMAIL_PROVIDER"`
+	Host     string `yaml:"host" mapstructure:"host" env:"EMAIL_HOST"`
+	Port     int    `yaml:"port" mapstructure:"port" env:"EMAIL_PORT"`
+	Username string `yaml:"username" mapstructure:"username" env:"EMAIL_USERNAME"`
+	Password string `yaml:"password" mapstructure:"password" env:"EMAIL_PASSWORD" redact:"true"`
+	// From is the sender address, e.g. "Wonder <no-reply@example.com>"
+	From string `yaml:"from" mapstructure:"from" env:"EMAIL_FROM"`
+	// TLS is starttls, implicit (usually port 465) or none
+	TLS     string        `yaml:"tls" mapstructure:"tls" env:"EMAIL_TLS"`
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout" env:"EMAIL_TIMEOUT"`
+	// MaxAttempts bounds the attempts at sending a message that fails with
+	// a transient error
+	MaxAttempts int `yaml:"max_attempts" mapstructure:"max_attempts" env:"EMAIL_MAX_ATTEMPTS"`
+	// AppURL is the public URL of the application linked from emails
+	AppURL url.URL `yaml:"app_url" mapstructure:"app_url" env:"EMAIL_APP_URL"`
+	// VerificationTTL is how long an email verification link can be opened
+	VerificationTTL time.Duration `yaml:"verification_ttl" mapstructure:"verification_ttl" env:"EMAIL_VERIFICATION_TTL"`
+	// VerificationURL is the page verification links open, given the token
+	// as a query parameter; empty means /verify-email under app_url
+	VerificationURL url.URL `yaml:"verification_url" mapstructure:"verification_url" env:"EMAIL_VERIFICATION_URL"`
+}
+
+// DefaultEmailConfig returns default email configuration
+func DefaultEmailConfig() *EmailConfig {
+	return &EmailConfig{
+		Enabled:     false,
+		Provider:    EmailProviderSMTP,
+		Host:        "smtp.gmail.com",
+		Port:        587,
+		TLS:         "starttls",
+		Timeout:     10 * time.Second,
+		MaxAttempts: 3,
+
+		VerificationTTL: 48 * time.Hour,
+	}
+}
+
+// Validate validates email configuration
+func (c *EmailConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("email from must be a valid address: %w", err)
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("email max_attempts must be at least 1")
+	}
+	if c.VerificationTTL <= 0 {
+		return fmt.Errorf("email verification_ttl must be positive")
+	}
+	if c.AppURL != (url.URL{}) && !isHTTPURL(c.AppURL) {
+		return fmt.Errorf("email app_url %q must be an http or https URL", c.AppURL.String())
+	}
+	if c.VerificationURL != (url.URL{}) && !isHTTPURL(c.VerificationURL) {
+		return fmt.Errorf("email verification_url %q must be an http or https URL", c.VerificationURL.String())
+	}
+
+	switch c.Provider {
+	case EmailProviderSMTP:
+	case EmailProviderLog:
+		return nil
+	default:
+		return fmt.Errorf("email provider must be one of: smtp, log")
+	}
+	if c.Host == "" {
+		return fmt.Errorf("email host is required for provider smtp")
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("email port must be between 1 and 65535")
+	}
+	switch c.TLS {
+	case "starttls", "implicit", "none":
+	default:
+		return fmt.Errorf("email tls must be one of: starttls, implicit, none")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("email timeout must be positive")
+	}
+	return nil
+}
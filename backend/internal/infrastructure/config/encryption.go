@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/cctw-zed/wonder/pkg/fieldcrypt"
+)
+
+// EncryptionConfig configures encryption of personal data at rest. Keys are
+// base64 encoded 32-byte values and, like any other value, may reference a
+// secret store, e.g. "vault:secret/data/wonder#pii_key_2026_01".
+type EncryptionConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"ENCRYPTION_ENABLED"`
+	// ActiveKeyID selects the entry of Keys new values are encrypted with.
+	// Retired keys stay listed until the reencrypt-pii job has run.
+	ActiveKeyID string                `yaml:"active_key_id" mapstructure:"active_key_id" env:"ENCRYPTION_ACTIVE_KEY_ID"`
+	Keys        []EncryptionKeyConfig `yaml:"keys" mapstructure:"keys"`
+	// IndexKey keys the blind index email lookups use. Changing it needs
+	// the reencrypt-pii job to rebuild the index.
+	IndexKey string `yaml:"index_key" mapstructure:"index_key" env:"ENCRYPTION_INDEX_KEY" redact:"true"`
+	// ReencryptBatchSize is how many users the reencrypt-pii job reads at a time
+	ReencryptBatchSize int `yaml:"reencrypt_batch_size" mapstructure:"reencrypt_batch_size" env:"ENCRYPTION_REENCRYPT_BATCH_SIZE"`
+}
+
+// EncryptionKeyConfig is one key-encryption key
+type EncryptionKeyConfig struct {
+	ID  string `yaml:"id" mapstructure:"id"`
+	Key string `yaml:"key" mapstructure:"key" redact:"true"`
+}
+
+// DefaultEncryptionConfig returns default encryption configuration
+func DefaultEncryptionConfig() *EncryptionConfig {
+	return &EncryptionConfig{
+		Enabled:            false,
+		ReencryptBatchSize: 500,
+	}
+}
+
+// Validate validates encryption configuration
+func (c *EncryptionConfig) Validate() error {
+	if c.ReencryptBatchSize <= 0 {
+		return fmt.Errorf("encryption reencrypt_batch_size must be positive")
+	}
+	if !c.Enabled {
+		return nil
+	}
+	if _, err := c.Keyring(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Keyring decodes the configured keys
+func (c *EncryptionConfig) Keyring() (*fieldcrypt.Keyring, error) {
+	if len(c.Keys) == 0 {
+		return nil, fmt.Errorf("encryption keys are required when enabled")
+	}
+	if c.ActiveKeyID == "" {
+		return nil, fmt.Errorf("encryption active_key_id is required when enabled")
+	}
+
+	keys := make(map[string][]byte, len(c.Keys))
+	for i, k := range c.Keys {
+		if k.ID == "" {
+			return nil, fmt.Errorf("encryption keys[%d] id is required", i)
+		}
+		if _, ok := keys[k.ID]; ok {
+			return nil, fmt.Errorf("encryption key id %q is duplicated", k.ID)
+		}
+		raw, err := base64.StdEncoding.DecodeString(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q is not valid base64", k.ID)
+		}
+		keys[k.ID] = raw
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(c.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("encryption index_key is not valid base64")
+	}
+
+	keyring, err := fieldcrypt.NewKeyring(c.ActiveKeyID, keys, indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+	return keyring, nil
+}
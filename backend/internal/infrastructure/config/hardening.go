@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HardeningIssue is a single failed production hardening check
+type HardeningIssue struct {
+	Check       string
+	Problem     string
+	Remediation string
+}
+
+// HardeningReport collects every failed hardening check so operators can fix
+// them all at once instead of one restart per problem
+type HardeningReport struct {
+	Environment string
+	Issues      []HardeningIssue
+}
+
+// HasIssues reports whether any hardening check failed
+func (r *HardeningReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+// Error renders the report with remediation hints
+func (r *HardeningReport) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d production hardening check(s) failed for environment %q:", len(r.Issues), r.Environment)
+	for _, issue := range r.Issues {
+		fmt.Fprintf(&b, "\n  - [%s] %s\n    fix: %s", issue.Check, issue.Problem, issue.Remediation)
+	}
+	return b.String()
+}
+
+func (r *HardeningReport) add(check, problem, remediation string) {
+	r.Issues = append(r.Issues, HardeningIssue{Check: check, Problem: problem, Remediation: remediation})
+}
+
+// knownWeakSigningKeys are signing keys shipped in defaults and sample configs
+var knownWeakSigningKeys = map[string]bool{
+	"your-secret-signing-key-change-this-in-production":                 true,
+	"wonder-dev-signing-key-change-in-production-please-make-it-longer": true,
+	"wonder-test-signing-key-for-testing-environment-only-32-chars-min": true,
+}
+
+// secureSSLModes are the PostgreSQL ssl modes that encrypt the connection
+var secureSSLModes = map[string]bool{
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// CheckHardening runs environment-aware hardening checks. Only production is
+// checked; other environments always return an empty report.
+func (c *Config) CheckHardening() *HardeningReport {
+	report := &HardeningReport{Environment: c.App.Environment}
+	if !c.IsProduction() {
+		return report
+	}
+
+	if c.JWT != nil && c.JWT.UsesSigningKey() {
+		key := c.JWT.SigningKey
+		switch {
+		case knownWeakSigningKeys[key]:
+			report.add("jwt.signing_key", "signing key is a published default",
+				"set JWT_SIGNING_KEY to a random value, e.g. `openssl rand -base64 48`")
+		case strings.Contains(strings.ToLower(key), "change"):
+			report.add("jwt.signing_key", "signing key looks like a placeholder",
+				"set JWT_SIGNING_KEY to a random value, e.g. `openssl rand -base64 48`")
+		case distinctChars(key) < 10:
+			report.add("jwt.signing_key", "signing key has too little variety to be random",
+				"set JWT_SIGNING_KEY to a random value, e.g. `openssl rand -base64 48`")
+		}
+	}
+
+	if c.Database != nil && c.Database.Driver == DriverSQLite {
+		report.add("database.driver", "SQLite is meant for local development and CI",
+			"set database.driver (DB_DRIVER) to postgres")
+	} else if c.Database != nil {
+		if !secureSSLModes[c.Database.SSLMode] {
+			report.add("database.ssl_mode", fmt.Sprintf("database connections are not encrypted (ssl_mode=%q)", c.Database.SSLMode),
+				"set database.ssl_mode (DB_SSL_MODE) to require, verify-ca or verify-full")
+		}
+		if c.Database.Password == "" || c.Database.Password == DefaultDatabaseConfig().Password {
+			report.add("database.password", "database password is empty or the development default",
+				"set DB_PASSWORD from your secret store")
+		}
+	}
+
+	if c.Log != nil && c.Log.Level == "debug" {
+		report.add("log.level", "debug logging may leak request data and degrades performance",
+			"set log.level (LOG_LEVEL) to info or higher")
+	}
+
+	if c.App != nil && c.App.Debug {
+		report.add("app.debug", "debug mode is enabled",
+			"set app.debug (APP_DEBUG) to false")
+	}
+
+	if c.Server != nil && c.Server.EnableCORS && (c.Server.CORS == nil || c.Server.CORS.AllowsAnyOrigin()) {
+		report.add("server.enable_cors", "CORS allows any origin",
+			"set server.enable_cors (SERVER_ENABLE_CORS) to false or list origins in server.cors.allowed_origins (SERVER_CORS_ALLOWED_ORIGINS)")
+	}
+
+	if c.Server != nil && trustsEveryProxy(c.Server.TrustedProxies) {
+		report.add("server.trusted_proxies", "any client can choose its IP address with X-Forwarded-For",
+			"list only your load balancers in server.trusted_proxies (SERVER_TRUSTED_PROXIES)")
+	}
+
+	if c.Security != nil && c.Security.Captcha != nil && c.Security.Captcha.BypassToken != "" {
+		report.add("security.captcha.bypass_token", "a fixed token passes every CAPTCHA",
+			"unset security.captcha.bypass_token (CAPTCHA_BYPASS_TOKEN); it is meant for tests")
+	}
+
+	if c.Auth != nil && c.Auth.Provider == AuthProviderLDAP && c.Auth.LDAP != nil {
+		ldap := c.Auth.LDAP
+		if ldap.URL.Scheme == "ldap" && !ldap.StartTLS {
+			report.add("auth.ldap.url", "LDAP binds send user passwords unencrypted",
+				"use an ldaps:// URL or set auth.ldap.start_tls (LDAP_START_TLS)")
+		}
+		if ldap.InsecureSkipVerify {
+			report.add("auth.ldap.insecure_skip_verify", "the LDAP server certificate is not verified",
+				"set auth.ldap.insecure_skip_verify (LDAP_INSECURE_SKIP_VERIFY) to false")
+		}
+	}
+
+	return report
+}
+
+func distinctChars(s string) int {
+	seen := make(map[rune]struct{})
+	for _, r := range s {
+		seen[r] = struct{}{}
+	}
+	return len(seen)
+}
+
+// trustsEveryProxy reports whether a trusted proxy range covers every
+// IPv4 or every IPv6 address
+func trustsEveryProxy(proxies []string) bool {
+	ranges, err := ParseIPRanges(proxies)
+	if err != nil {
+		return false
+	}
+	for _, r := range ranges {
+		if r.Bits() == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hardenedProductionConfig() *Config {
+	cfg := DefaultConfig()
+	cfg.App.Environment = "production"
+	cfg.App.Debug = false
+	cfg.Server.EnableCORS = false
+	cfg.Database.SSLMode = "verify-full"
+	cfg.Database.Password = "s3cure-prod-password"
+	cfg.JWT.SigningKey = "Zq8v1Lr3Nw5Kt7Hy9Bx2Mc4Pd6Fg0Js-prod"
+	return cfg
+}
+
+func TestConfig_CheckHardening(t *testing.T) {
+	t.Run("hardened production config passes", func(t *testing.T) {
+		cfg := hardenedProductionConfig()
+		assert.False(t, cfg.CheckHardening().HasIssues())
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("CORS restricted to listed origins passes", func(t *testing.T) {
+		cfg := hardenedProductionConfig()
+		cfg.Server.EnableCORS = true
+		cfg.Server.CORS.AllowedOrigins = []string{"https://app.example.com"}
+		assert.False(t, cfg.CheckHardening().HasIssues())
+	})
+
+	t.Run("non-production environments are not checked", func(t *testing.T) {
+		cfg := DefaultConfig()
+		assert.False(t, cfg.CheckHardening().HasIssues())
+	})
+
+	t.Run("all problems are reported together", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.App.Environment = "production"
+		cfg.Log.Level = "debug"
+
+		report := cfg.CheckHardening()
+		checks := make([]string, 0, len(report.Issues))
+		for _, issue := range report.Issues {
+			checks = append(checks, issue.Check)
+			assert.NotEmpty(t, issue.Remediation)
+		}
+		assert.ElementsMatch(t, []string{
+			"jwt.signing_key",
+			"database.ssl_mode",
+			"database.password",
+			"log.level",
+			"app.debug",
+			"server.enable_cors",
+		}, checks)
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		var hardening *HardeningReport
+		require.True(t, errors.As(err, &hardening))
+		assert.Contains(t, err.Error(), "6 production hardening check(s) failed")
+		assert.Contains(t, err.Error(), "fix: set JWT_SIGNING_KEY")
+	})
+
+	t.Run("LDAP without transport security", func(t *testing.T) {
+		cfg := hardenedProductionConfig()
+		cfg.Auth.Provider = AuthProviderLDAP
+		cfg.Auth.LDAP.URL = mustParseURL("ldap://ldap.example.com")
+		cfg.Auth.LDAP.BaseDN = "dc=example,dc=com"
+
+		report := cfg.CheckHardening()
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, "auth.ldap.url", report.Issues[0].Check)
+
+		cfg.Auth.LDAP.StartTLS = true
+		assert.False(t, cfg.CheckHardening().HasIssues())
+	})
+
+	t.Run("CAPTCHA bypass token", func(t *testing.T) {
+		cfg := hardenedProductionConfig()
+		cfg.Security.Captcha.Enabled = true
+		cfg.Security.Captcha.BypassToken = "test-pass"
+
+		report := cfg.CheckHardening()
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, "security.captcha.bypass_token", report.Issues[0].Check)
+	})
+
+	t.Run("every proxy trusted", func(t *testing.T) {
+		cfg := hardenedProductionConfig()
+		cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "0.0.0.0/0"}
+
+		report := cfg.CheckHardening()
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, "server.trusted_proxies", report.Issues[0].Check)
+	})
+
+	t.Run("SQLite database", func(t *testing.T) {
+		cfg := hardenedProductionConfig()
+		cfg.Database.Driver = DriverSQLite
+		cfg.Database.Database = "/var/lib/wonder/wonder.db"
+		cfg.Database.Password = ""
+
+		report := cfg.CheckHardening()
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, "database.driver", report.Issues[0].Check)
+	})
+
+	t.Run("low-variety signing key", func(t *testing.T) {
+		cfg := hardenedProductionConfig()
+		cfg.JWT.SigningKey = "abababababababababababababababab"
+
+		report := cfg.CheckHardening()
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, "jwt.signing_key", report.Issues[0].Check)
+	})
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/cctw-zed/wonder/pkg/i18n"
+)
+
+// I18nConfig represents response localization configuration. Error and
+// validation messages are translated into the language negotiated from
+// Accept-Language; English is always served and is the fallback.
+type I18nConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"I18N_ENABLED"`
+	// Languages served besides English; each needs a message catalog
+	Languages []string `yaml:"languages" mapstructure:"languages" env:"I18N_LANGUAGES"`
+}
+
+// DefaultI18nConfig returns default localization configuration
+func DefaultI18nConfig() *I18nConfig {
+	return &I18nConfig{
+		Enabled:   true,
+		Languages: []string{"en", "zh"},
+	}
+}
+
+// Validate validates localization configuration
+func (c *I18nConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if _, err := i18n.New(c.Languages); err != nil {
+		return fmt.Errorf("i18n languages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import "fmt"
+
+// ImportConfig represents limits for bulk user imports
+type ImportConfig struct {
+	BatchSize    int      `yaml:"batch_size" mapstructure:"batch_size" env:"IMPORT_BATCH_SIZE"`
+	MaxRows      int      `yaml:"max_rows" mapstructure:"max_rows" env:"IMPORT_MAX_ROWS"`
+	MaxBodyBytes ByteSize `yaml:"max_body_bytes" mapstructure:"max_body_bytes" env:"IMPORT_MAX_BODY_BYTES"`
+}
+
+// DefaultImportConfig returns default user import configuration
+func DefaultImportConfig() *ImportConfig {
+	return &ImportConfig{
+		BatchSize:    100,
+		MaxRows:      10000,
+		MaxBodyBytes: 10 * 1024 * 1024,
+	}
+}
+
+// Validate validates user import configuration
+func (c *ImportConfig) Validate() error {
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("import batch_size must be positive")
+	}
+	if c.MaxRows <= 0 {
+		return fmt.Errorf("import max_rows must be positive")
+	}
+	if c.MaxBodyBytes <= 0 {
+		return fmt.Errorf("import max_body_bytes must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// IPFilterConfig represents client IP allow and deny lists. Allow and Deny
+// apply to every request; each group applies its own lists to the requests
+// under its path prefix as well. Client IPs are taken from X-Forwarded-For
+// or X-Real-IP only when the peer is one of server.trusted_proxies.
+type IPFilterConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"IP_FILTER_ENABLED"`
+	// Allow lists addresses or CIDR ranges; when set, only they are
+	// admitted. Deny wins over Allow.
+	Allow  []string              `yaml:"allow" mapstructure:"allow" env:"IP_FILTER_ALLOW"`
+	Deny   []string              `yaml:"deny" mapstructure:"deny" env:"IP_FILTER_DENY"`
+	Groups []IPFilterGroupConfig `yaml:"groups,omitempty" mapstructure:"groups"`
+}
+
+// IPFilterGroupConfig restricts the routes under PathPrefix, such as
+// /api/v1/admin
+type IPFilterGroupConfig struct {
+	PathPrefix string   `yaml:"path_prefix" mapstructure:"path_prefix"`
+	Allow      []string `yaml:"allow" mapstructure:"allow"`
+	Deny       []string `yaml:"deny" mapstructure:"deny"`
+}
+
+// DefaultIPFilterConfig returns default IP filter configuration
+func DefaultIPFilterConfig() *IPFilterConfig {
+	return &IPFilterConfig{Enabled: false}
+}
+
+// Validate validates IP filter configuration
+func (c *IPFilterConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := validateIPRanges("ip_filter allow", c.Allow); err != nil {
+		return err
+	}
+	if err := validateIPRanges("ip_filter deny", c.Deny); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(c.Groups))
+	for _, g := range c.Groups {
+		if !strings.HasPrefix(g.PathPrefix, "/") {
+			return fmt.Errorf("ip_filter group path_prefix %q must start with /", g.PathPrefix)
+		}
+		if seen[g.PathPrefix] {
+			return fmt.Errorf("ip_filter group %q is listed twice", g.PathPrefix)
+		}
+		seen[g.PathPrefix] = true
+		if len(g.Allow) == 0 && len(g.Deny) == 0 {
+			return fmt.Errorf("ip_filter group %q must allow or deny something", g.PathPrefix)
+		}
+		if err := validateIPRanges("ip_filter group "+g.PathPrefix+" allow", g.Allow); err != nil {
+			return err
+		}
+		if err := validateIPRanges("ip_filter group "+g.PathPrefix+" deny", g.Deny); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseIPRanges parses addresses and CIDR ranges; a bare address is a
+// range of one
+func ParseIPRanges(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func validateIPRanges(field string, entries []string) error {
+	if _, err := ParseIPRanges(entries); err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	return nil
+}
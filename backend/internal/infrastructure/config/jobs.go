@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Job queue backends
+const (
+	JobsBackendMemory = "memory"
+	JobsBackendRedis  = "redis"
+)
+
+// JobsConfig represents the background job queue and its worker pool
+type JobsConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"JOBS_ENABLED"`
+	// Backend is memory (per instance, lost on restart) or redis
+	Backend      string        `yaml:"backend" mapstructure:"backend" env:"JOBS_BACKEND"`
+	Concurrency  int           `yaml:"concurrency" mapstructure:"concurrency" env:"JOBS_CONCURRENCY"`
+	PollInterval time.Duration `yaml:"poll_interval" mapstructure:"poll_interval" env:"JOBS_POLL_INTERVAL"`
+	// Lease bounds a job's run time; a job still leased after it is run
+	// again by another worker
+	Lease       time.Duration `yaml:"lease" mapstructure:"lease" env:"JOBS_LEASE"`
+	MaxAttempts int           `yaml:"max_attempts" mapstructure:"max_attempts" env:"JOBS_MAX_ATTEMPTS"`
+	BaseBackoff time.Duration `yaml:"base_backoff" mapstructure:"base_backoff" env:"JOBS_BASE_BACKOFF"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" mapstructure:"max_backoff" env:"JOBS_MAX_BACKOFF"`
+	// RedisKeyPrefix namespaces the redis backend's keys in external.redis
+	RedisKeyPrefix string `yaml:"redis_key_prefix" mapstructure:"redis_key_prefix" env:"JOBS_REDIS_KEY_PREFIX"`
+}
+
+// DefaultJobsConfig returns default job queue configuration
+func DefaultJobsConfig() *JobsConfig {
+	return &JobsConfig{
+		Enabled:        false,
+		Backend:        JobsBackendMemory,
+		Concurrency:    4,
+		PollInterval:   time.Second,
+		Lease:          5 * time.Minute,
+		MaxAttempts:    5,
+		BaseBackoff:    5 * time.Second,
+		MaxBackoff:     10 * time.Minute,
+		RedisKeyPrefix: "wonder:{jobs}",
+	}
+}
+
+// Validate validates job queue configuration
+func (c *JobsConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Backend != JobsBackendMemory && c.Backend != JobsBackendRedis {
+		return fmt.Errorf("jobs backend must be one of: memory, redis")
+	}
+	if c.Concurrency <= 0 {
+		return fmt.Errorf("jobs concurrency must be positive")
+	}
+	if c.PollInterval <= 0 || c.Lease <= 0 {
+		return fmt.Errorf("jobs poll_interval and lease must be positive")
+	}
+	if c.MaxAttempts <= 0 {
+		return fmt.Errorf("jobs max_attempts must be positive")
+	}
+	if c.BaseBackoff <= 0 || c.MaxBackoff < c.BaseBackoff {
+		return fmt.Errorf("jobs backoff must satisfy 0 < base_backoff <= max_backoff")
+	}
+	if c.Backend == JobsBackendRedis && c.RedisKeyPrefix == "" {
+		return fmt.Errorf("jobs redis_key_prefix cannot be empty")
+	}
+	return nil
+}
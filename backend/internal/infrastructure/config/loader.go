@@ -1,10 +1,14 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -12,6 +16,17 @@ import (
 // Loader handles configuration loading from multiple sources
 type Loader struct {
 	viper *viper.Viper
+
+	// State retained for hot-reload (see watcher.go)
+	mu          sync.RWMutex
+	environment string
+	current     *Config
+	handlers    []ChangeHandler
+	watchOnce   sync.Once
+	secretKeys  []string
+
+	// reloadMu serializes reloads triggered by file changes and secret refreshes
+	reloadMu sync.Mutex
 }
 
 // NewLoader creates a new configuration loader
@@ -24,57 +39,43 @@ func NewLoader() *Loader {
 func (l *Loader) LoadConfig(configPaths ...string) (*Config, error) {
 	// Set default configuration paths if none provided
 	if len(configPaths) == 0 {
-		configPaths = []string{
-			".",
-			"./configs",
-			"./config",
-			"/etc/wonder",
-		}
+		configPaths = defaultConfigPaths
 	}
 
 	// Configure viper
 	l.setupViper(configPaths)
 
-	// Apply environment variable overrides BEFORE loading config
-	l.bindEnvironmentVariables()
-
-	// Attempt to read configuration file
-	if err := l.viper.ReadInConfig(); err != nil {
-		// If config file is not found, that's OK - we'll use defaults
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("failed to read config file: %w", err)
-		}
-	}
-
-	// Start with default configuration and unmarshal from viper to override values
-	config := DefaultConfig()
-	if err := l.viper.Unmarshal(config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
-	}
-
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+	config, err := l.readConfig("")
+	if err != nil {
+		return nil, err
 	}
 
-	return config, nil
+	return l.complete("", config)
 }
 
 // LoadConfigForEnvironment loads configuration for specific environment
 func (l *Loader) LoadConfigForEnvironment(env string, configPaths ...string) (*Config, error) {
 	// Set default configuration paths if none provided
 	if len(configPaths) == 0 {
-		configPaths = []string{
-			".",
-			"./configs",
-			"./config",
-			"/etc/wonder",
-		}
+		configPaths = defaultConfigPaths
 	}
 
 	// Configure viper
 	l.setupViperForEnvironment(env, configPaths)
 
+	config, err := l.readConfig(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.complete(env, config)
+}
+
+// readConfig reads the configuration file viper was set up to find and
+// merges it over the defaults and environment variables. A non-empty env
+// overrides app.environment. Secrets are not resolved and nothing is
+// validated.
+func (l *Loader) readConfig(env string) (*Config, error) {
 	// Apply environment variable overrides BEFORE loading config
 	l.bindEnvironmentVariables()
 
@@ -88,20 +89,14 @@ func (l *Loader) LoadConfigForEnvironment(env string, configPaths ...string) (*C
 
 	// Start with default configuration and unmarshal from viper to override values
 	config := DefaultConfig()
-	if err := l.viper.Unmarshal(config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	if err := l.unmarshal(config); err != nil {
+		return nil, err
 	}
 
 	// Override environment if specified
 	if env != "" {
 		config.App.Environment = env
 	}
-
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
-	}
-
 	return config, nil
 }
 
@@ -149,67 +144,6 @@ func (l *Loader) setupViperForEnvironment(env string, configPaths []string) {
 	l.setDefaults()
 }
 
-// setDefaults sets default values in viper
-func (l *Loader) setDefaults() {
-	defaults := DefaultConfig()
-
-	// App defaults
-	l.viper.SetDefault("app.name", defaults.App.Name)
-	l.viper.SetDefault("app.version", defaults.App.Version)
-	l.viper.SetDefault("app.environment", defaults.App.Environment)
-	l.viper.SetDefault("app.debug", defaults.App.Debug)
-
-	// Server defaults
-	l.viper.SetDefault("server.host", defaults.Server.Host)
-	l.viper.SetDefault("server.port", defaults.Server.Port)
-	l.viper.SetDefault("server.read_timeout", defaults.Server.ReadTimeout)
-	l.viper.SetDefault("server.write_timeout", defaults.Server.WriteTimeout)
-	l.viper.SetDefault("server.idle_timeout", defaults.Server.IdleTimeout)
-	l.viper.SetDefault("server.enable_cors", defaults.Server.EnableCORS)
-
-	// Database defaults
-	l.viper.SetDefault("database.host", defaults.Database.Host)
-	l.viper.SetDefault("database.port", defaults.Database.Port)
-	l.viper.SetDefault("database.username", defaults.Database.Username)
-	l.viper.SetDefault("database.password", defaults.Database.Password)
-	l.viper.SetDefault("database.database", defaults.Database.Database)
-	l.viper.SetDefault("database.ssl_mode", defaults.Database.SSLMode)
-	l.viper.SetDefault("database.timezone", defaults.Database.Timezone)
-	l.viper.SetDefault("database.max_open_conns", defaults.Database.MaxOpenConns)
-	l.viper.SetDefault("database.max_idle_conns", defaults.Database.MaxIdleConns)
-	l.viper.SetDefault("database.conn_max_lifetime", defaults.Database.ConnMaxLifetime)
-	l.viper.SetDefault("database.conn_max_idle_time", defaults.Database.ConnMaxIdleTime)
-	l.viper.SetDefault("database.log_level", defaults.Database.LogLevel)
-
-	// Log defaults
-	l.viper.SetDefault("log.level", defaults.Log.Level)
-	l.viper.SetDefault("log.format", defaults.Log.Format)
-	l.viper.SetDefault("log.output", defaults.Log.Output)
-	l.viper.SetDefault("log.enable_file", defaults.Log.EnableFile)
-	l.viper.SetDefault("log.file_path", defaults.Log.FilePath)
-
-	// ID defaults
-	l.viper.SetDefault("id.service_type", defaults.ID.ServiceType)
-	l.viper.SetDefault("id.instance_id", defaults.ID.InstanceID)
-	l.viper.SetDefault("id.node_id", defaults.ID.NodeID)
-
-	// External defaults
-	if defaults.External.Redis != nil {
-		l.viper.SetDefault("external.redis.host", defaults.External.Redis.Host)
-		l.viper.SetDefault("external.redis.port", defaults.External.Redis.Port)
-		l.viper.SetDefault("external.redis.password", defaults.External.Redis.Password)
-		l.viper.SetDefault("external.redis.database", defaults.External.Redis.Database)
-	}
-
-	if defaults.External.Email != nil {
-		l.viper.SetDefault("external.email.provider", defaults.External.Email.Provider)
-		l.viper.SetDefault("external.email.host", defaults.External.Email.Host)
-		l.viper.SetDefault("external.email.port", defaults.External.Email.Port)
-		l.viper.SetDefault("external.email.username", defaults.External.Email.Username)
-		l.viper.SetDefault("external.email.password", defaults.External.Email.Password)
-	}
-}
-
 // bindEnvironmentVariables binds environment variables to configuration keys
 func (l *Loader) bindEnvironmentVariables() {
 	// App configuration
@@ -225,8 +159,34 @@ func (l *Loader) bindEnvironmentVariables() {
 	l.viper.BindEnv("server.write_timeout", "SERVER_WRITE_TIMEOUT")
 	l.viper.BindEnv("server.idle_timeout", "SERVER_IDLE_TIMEOUT")
 	l.viper.BindEnv("server.enable_cors", "SERVER_ENABLE_CORS")
+	l.viper.BindEnv("server.cors.allowed_origins", "SERVER_CORS_ALLOWED_ORIGINS")
+	l.viper.BindEnv("server.cors.allowed_methods", "SERVER_CORS_ALLOWED_METHODS")
+	l.viper.BindEnv("server.cors.allowed_headers", "SERVER_CORS_ALLOWED_HEADERS")
+	l.viper.BindEnv("server.cors.exposed_headers", "SERVER_CORS_EXPOSED_HEADERS")
+	l.viper.BindEnv("server.cors.allow_credentials", "SERVER_CORS_ALLOW_CREDENTIALS")
+	l.viper.BindEnv("server.cors.max_age", "SERVER_CORS_MAX_AGE")
+	l.viper.BindEnv("server.tls.enabled", "SERVER_TLS_ENABLED")
+	l.viper.BindEnv("server.tls.cert_file", "SERVER_TLS_CERT_FILE")
+	l.viper.BindEnv("server.tls.key_file", "SERVER_TLS_KEY_FILE")
+	l.viper.BindEnv("server.tls.autocert", "SERVER_TLS_AUTOCERT")
+	l.viper.BindEnv("server.tls.autocert_domains", "SERVER_TLS_AUTOCERT_DOMAINS")
+	l.viper.BindEnv("server.tls.autocert_email", "SERVER_TLS_AUTOCERT_EMAIL")
+	l.viper.BindEnv("server.tls.autocert_cache_dir", "SERVER_TLS_AUTOCERT_CACHE_DIR")
+	l.viper.BindEnv("server.tls.redirect_http", "SERVER_TLS_REDIRECT_HTTP")
+	l.viper.BindEnv("server.tls.http_port", "SERVER_TLS_HTTP_PORT")
+	l.viper.BindEnv("server.tls.http2", "SERVER_TLS_HTTP2")
+	l.viper.BindEnv("server.max_body_bytes", "SERVER_MAX_BODY_BYTES")
+	l.viper.BindEnv("server.handler_timeout", "SERVER_HANDLER_TIMEOUT")
+	l.viper.BindEnv("server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT")
+	l.viper.BindEnv("server.trusted_proxies", "SERVER_TRUSTED_PROXIES")
+	l.viper.BindEnv("server.admin_ui.enabled", "SERVER_ADMIN_UI_ENABLED")
+	l.viper.BindEnv("server.admin_ui.path", "SERVER_ADMIN_UI_PATH")
+	l.viper.BindEnv("server.admin_ui.dir", "SERVER_ADMIN_UI_DIR")
+	l.viper.BindEnv("server.admin_ui.cache_max_age", "SERVER_ADMIN_UI_CACHE_MAX_AGE")
+	l.viper.BindEnv("server.admin_ui.content_security_policy", "SERVER_ADMIN_UI_CSP")
 
 	// Database configuration
+	l.viper.BindEnv("database.driver", "DB_DRIVER")
 	l.viper.BindEnv("database.host", "DB_HOST")
 	l.viper.BindEnv("database.port", "DB_PORT")
 	l.viper.BindEnv("database.username", "DB_USERNAME")
@@ -239,6 +199,14 @@ func (l *Loader) bindEnvironmentVariables() {
 	l.viper.BindEnv("database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME")
 	l.viper.BindEnv("database.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME")
 	l.viper.BindEnv("database.log_level", "DB_LOG_LEVEL")
+	l.viper.BindEnv("database.auto_migrate", "DB_AUTO_MIGRATE")
+	l.viper.BindEnv("database.slow_query_threshold", "DB_SLOW_QUERY_THRESHOLD")
+	l.viper.BindEnv("database.prepare_stmt", "DB_PREPARE_STMT")
+	l.viper.BindEnv("database.statement_timeout", "DB_STATEMENT_TIMEOUT")
+	l.viper.BindEnv("database.query_timeout", "DB_QUERY_TIMEOUT")
+	l.viper.BindEnv("database.monitor_interval", "DB_MONITOR_INTERVAL")
+	l.viper.BindEnv("database.replica_hosts", "DB_REPLICA_HOSTS")
+	l.viper.BindEnv("database.replica_check_interval", "DB_REPLICA_CHECK_INTERVAL")
 
 	// Log configuration
 	l.viper.BindEnv("log.level", "LOG_LEVEL")
@@ -246,24 +214,293 @@ func (l *Loader) bindEnvironmentVariables() {
 	l.viper.BindEnv("log.output", "LOG_OUTPUT")
 	l.viper.BindEnv("log.enable_file", "LOG_ENABLE_FILE")
 	l.viper.BindEnv("log.file_path", "LOG_FILE_PATH")
+	l.viper.BindEnv("log.sampling.message_rate", "LOG_SAMPLING_MESSAGE_RATE")
+	l.viper.BindEnv("log.sampling.burst", "LOG_SAMPLING_BURST")
+	l.viper.BindEnv("log.sampling.debug_percent", "LOG_SAMPLING_DEBUG_PERCENT")
+	l.viper.BindEnv("log.async.enabled", "LOG_ASYNC_ENABLED")
+	l.viper.BindEnv("log.async.capacity", "LOG_ASYNC_CAPACITY")
+	l.viper.BindEnv("log.ship.url", "LOG_SHIP_URL")
+	l.viper.BindEnv("log.ship.index", "LOG_SHIP_INDEX")
+	l.viper.BindEnv("log.ship.username", "LOG_SHIP_USERNAME")
+	l.viper.BindEnv("log.ship.password", "LOG_SHIP_PASSWORD")
+	l.viper.BindEnv("log.ship.batch_size", "LOG_SHIP_BATCH_SIZE")
+	l.viper.BindEnv("log.ship.flush_interval", "LOG_SHIP_FLUSH_INTERVAL")
+	l.viper.BindEnv("log.ship.queue_size", "LOG_SHIP_QUEUE_SIZE")
+	l.viper.BindEnv("log.ship.max_retries", "LOG_SHIP_MAX_RETRIES")
+	l.viper.BindEnv("log.ship.timeout", "LOG_SHIP_TIMEOUT")
+	l.viper.BindEnv("log.signal_level_ttl", "LOG_SIGNAL_LEVEL_TTL")
+
+	// JWT configuration
+	l.viper.BindEnv("jwt.signing_key", "JWT_SIGNING_KEY")
+	l.viper.BindEnv("jwt.expiry", "JWT_EXPIRY")
+	l.viper.BindEnv("jwt.active_key_id", "JWT_ACTIVE_KEY_ID")
+	l.viper.BindEnv("jwt.accept_legacy_tokens", "JWT_ACCEPT_LEGACY_TOKENS")
 
 	// ID configuration
 	l.viper.BindEnv("id.service_type", "ID_SERVICE_TYPE", "SERVICE_TYPE")
 	l.viper.BindEnv("id.instance_id", "ID_INSTANCE_ID", "INSTANCE_ID")
 	l.viper.BindEnv("id.node_id", "ID_NODE_ID", "NODE_ID")
+	l.viper.BindEnv("id.allocator", "ID_ALLOCATOR")
+	l.viper.BindEnv("id.etcd_endpoints", "ID_ETCD_ENDPOINTS")
+	l.viper.BindEnv("id.redis_key_prefix", "ID_REDIS_KEY_PREFIX")
+	l.viper.BindEnv("id.lease_timeout", "ID_LEASE_TIMEOUT")
+	l.viper.BindEnv("id.renew_interval", "ID_RENEW_INTERVAL")
+
+	// Security configuration
+	l.viper.BindEnv("security.password_breach.enabled", "PASSWORD_BREACH_ENABLED")
+	l.viper.BindEnv("security.password_breach.policy", "PASSWORD_BREACH_POLICY")
+	l.viper.BindEnv("security.password_breach.api_url", "PASSWORD_BREACH_API_URL")
+	l.viper.BindEnv("security.password_breach.timeout", "PASSWORD_BREACH_TIMEOUT")
+	l.viper.BindEnv("security.password_breach.cache_ttl", "PASSWORD_BREACH_CACHE_TTL")
+	l.viper.BindEnv("security.password_breach.failure_threshold", "PASSWORD_BREACH_FAILURE_THRESHOLD")
+	l.viper.BindEnv("security.password_breach.open_timeout", "PASSWORD_BREACH_OPEN_TIMEOUT")
+	l.viper.BindEnv("security.lockout.enabled", "LOCKOUT_ENABLED")
+	l.viper.BindEnv("security.lockout.store", "LOCKOUT_STORE")
+	l.viper.BindEnv("security.lockout.threshold", "LOCKOUT_THRESHOLD")
+	l.viper.BindEnv("security.lockout.ip_threshold", "LOCKOUT_IP_THRESHOLD")
+	l.viper.BindEnv("security.lockout.window", "LOCKOUT_WINDOW")
+	l.viper.BindEnv("security.lockout.duration", "LOCKOUT_DURATION")
+	l.viper.BindEnv("security.captcha.enabled", "CAPTCHA_ENABLED")
+	l.viper.BindEnv("security.captcha.provider", "CAPTCHA_PROVIDER")
+	l.viper.BindEnv("security.captcha.secret_key", "CAPTCHA_SECRET_KEY")
+	l.viper.BindEnv("security.captcha.verify_url", "CAPTCHA_VERIFY_URL")
+	l.viper.BindEnv("security.captcha.timeout", "CAPTCHA_TIMEOUT")
+	l.viper.BindEnv("security.captcha.min_score", "CAPTCHA_MIN_SCORE")
+	l.viper.BindEnv("security.captcha.register", "CAPTCHA_REGISTER")
+	l.viper.BindEnv("security.captcha.login_threshold", "CAPTCHA_LOGIN_THRESHOLD")
+	l.viper.BindEnv("security.captcha.bypass_token", "CAPTCHA_BYPASS_TOKEN")
+	l.viper.BindEnv("security.ip_filter.enabled", "IP_FILTER_ENABLED")
+	l.viper.BindEnv("security.ip_filter.allow", "IP_FILTER_ALLOW")
+	l.viper.BindEnv("security.ip_filter.deny", "IP_FILTER_DENY")
+	l.viper.BindEnv("security.signup.enabled", "SIGNUP_PROTECTION_ENABLED")
+	l.viper.BindEnv("security.signup.store", "SIGNUP_STORE")
+	l.viper.BindEnv("security.signup.window", "SIGNUP_WINDOW")
+	l.viper.BindEnv("security.signup.domain_limit", "SIGNUP_DOMAIN_LIMIT")
+	l.viper.BindEnv("security.signup.ip_limit", "SIGNUP_IP_LIMIT")
+	l.viper.BindEnv("security.signup.exempt_domains", "SIGNUP_EXEMPT_DOMAINS")
+	l.viper.BindEnv("security.signup.block_disposable", "SIGNUP_BLOCK_DISPOSABLE")
+	l.viper.BindEnv("security.signup.disposable_domains", "SIGNUP_DISPOSABLE_DOMAINS")
+	l.viper.BindEnv("security.signup.disposable_domains_file", "SIGNUP_DISPOSABLE_DOMAINS_FILE")
+	l.viper.BindEnv("security.signup.honeypot", "SIGNUP_HONEYPOT")
+	l.viper.BindEnv("security.signup.handle_check_limit", "SIGNUP_HANDLE_CHECK_LIMIT")
+
+	// Auth configuration
+	l.viper.BindEnv("auth.provider", "AUTH_PROVIDER")
+	l.viper.BindEnv("auth.ldap.url", "LDAP_URL")
+	l.viper.BindEnv("auth.ldap.start_tls", "LDAP_START_TLS")
+	l.viper.BindEnv("auth.ldap.insecure_skip_verify", "LDAP_INSECURE_SKIP_VERIFY")
+	l.viper.BindEnv("auth.ldap.bind_dn", "LDAP_BIND_DN")
+	l.viper.BindEnv("auth.ldap.bind_password", "LDAP_BIND_PASSWORD")
+	l.viper.BindEnv("auth.ldap.base_dn", "LDAP_BASE_DN")
+	l.viper.BindEnv("auth.ldap.user_filter", "LDAP_USER_FILTER")
+	l.viper.BindEnv("auth.ldap.email_attribute", "LDAP_EMAIL_ATTRIBUTE")
+	l.viper.BindEnv("auth.ldap.name_attribute", "LDAP_NAME_ATTRIBUTE")
+	l.viper.BindEnv("auth.ldap.timeout", "LDAP_TIMEOUT")
+	l.viper.BindEnv("auth.ldap.create_users", "LDAP_CREATE_USERS")
+	l.viper.BindEnv("auth.mfa.enforcement", "MFA_ENFORCEMENT")
+	l.viper.BindEnv("auth.mfa.issuer", "MFA_ISSUER")
+	l.viper.BindEnv("auth.mfa.token_ttl", "MFA_TOKEN_TTL")
+	l.viper.BindEnv("auth.mfa.skew", "MFA_SKEW")
+	l.viper.BindEnv("auth.mfa.max_attempts", "MFA_MAX_ATTEMPTS")
+	l.viper.BindEnv("auth.mfa.lock_duration", "MFA_LOCK_DURATION")
+	l.viper.BindEnv("auth.mfa.recovery_codes", "MFA_RECOVERY_CODES")
+	l.viper.BindEnv("auth.mfa.trusted_device_ttl", "MFA_TRUSTED_DEVICE_TTL")
+
+	// Bootstrap configuration
+	l.viper.BindEnv("bootstrap.admin_email", "BOOTSTRAP_ADMIN_EMAIL")
+	l.viper.BindEnv("bootstrap.admin_name", "BOOTSTRAP_ADMIN_NAME")
+	l.viper.BindEnv("bootstrap.setup_token", "BOOTSTRAP_SETUP_TOKEN")
+
+	// Outbox configuration
+	l.viper.BindEnv("outbox.enabled", "OUTBOX_ENABLED")
+	l.viper.BindEnv("outbox.poll_interval", "OUTBOX_POLL_INTERVAL")
+	l.viper.BindEnv("outbox.batch_size", "OUTBOX_BATCH_SIZE")
+	l.viper.BindEnv("outbox.max_attempts", "OUTBOX_MAX_ATTEMPTS")
+	l.viper.BindEnv("outbox.base_backoff", "OUTBOX_BASE_BACKOFF")
+	l.viper.BindEnv("outbox.max_backoff", "OUTBOX_MAX_BACKOFF")
+
+	// Jobs configuration
+	l.viper.BindEnv("jobs.enabled", "JOBS_ENABLED")
+	l.viper.BindEnv("jobs.backend", "JOBS_BACKEND")
+	l.viper.BindEnv("jobs.concurrency", "JOBS_CONCURRENCY")
+	l.viper.BindEnv("jobs.poll_interval", "JOBS_POLL_INTERVAL")
+	l.viper.BindEnv("jobs.lease", "JOBS_LEASE")
+	l.viper.BindEnv("jobs.max_attempts", "JOBS_MAX_ATTEMPTS")
+	l.viper.BindEnv("jobs.base_backoff", "JOBS_BASE_BACKOFF")
+	l.viper.BindEnv("jobs.max_backoff", "JOBS_MAX_BACKOFF")
+	l.viper.BindEnv("jobs.redis_key_prefix", "JOBS_REDIS_KEY_PREFIX")
+
+	// Scheduler configuration
+	l.viper.BindEnv("scheduler.enabled", "SCHEDULER_ENABLED")
+	l.viper.BindEnv("scheduler.timezone", "SCHEDULER_TIMEZONE")
+	l.viper.BindEnv("scheduler.audit_retention_schedule", "SCHEDULER_AUDIT_RETENTION_SCHEDULE")
+	l.viper.BindEnv("scheduler.audit_retention", "SCHEDULER_AUDIT_RETENTION")
+	l.viper.BindEnv("scheduler.outbox_cleanup_schedule", "SCHEDULER_OUTBOX_CLEANUP_SCHEDULE")
+	l.viper.BindEnv("scheduler.outbox_retention", "SCHEDULER_OUTBOX_RETENTION")
+	l.viper.BindEnv("scheduler.account_purge_schedule", "SCHEDULER_ACCOUNT_PURGE_SCHEDULE")
+
+	// Audit configuration
+	l.viper.BindEnv("audit.enabled", "AUDIT_ENABLED")
+	l.viper.BindEnv("audit.buffer_size", "AUDIT_BUFFER_SIZE")
+	l.viper.BindEnv("audit.batch_size", "AUDIT_BATCH_SIZE")
+	l.viper.BindEnv("audit.flush_interval", "AUDIT_FLUSH_INTERVAL")
+
+	// Account configuration
+	l.viper.BindEnv("account.deletion_grace_period", "ACCOUNT_DELETION_GRACE_PERIOD")
+	l.viper.BindEnv("account.data_export_ttl", "ACCOUNT_DATA_EXPORT_TTL")
+
+	// Encryption configuration
+	l.viper.BindEnv("encryption.enabled", "ENCRYPTION_ENABLED")
+	l.viper.BindEnv("encryption.active_key_id", "ENCRYPTION_ACTIVE_KEY_ID")
+	l.viper.BindEnv("encryption.index_key", "ENCRYPTION_INDEX_KEY")
+	l.viper.BindEnv("encryption.reencrypt_batch_size", "ENCRYPTION_REENCRYPT_BATCH_SIZE")
+
+	// Import configuration
+	l.viper.BindEnv("import.batch_size", "IMPORT_BATCH_SIZE")
+	l.viper.BindEnv("import.max_rows", "IMPORT_MAX_ROWS")
+	l.viper.BindEnv("import.max_body_bytes", "IMPORT_MAX_BODY_BYTES")
+
+	// Stats configuration
+	l.viper.BindEnv("stats.cache_ttl", "STATS_CACHE_TTL")
+
+	// Users configuration
+	l.viper.BindEnv("users.count_cache_ttl", "USERS_COUNT_CACHE_TTL")
+	l.viper.BindEnv("users.avatar_max_bytes", "USERS_AVATAR_MAX_BYTES")
+	l.viper.BindEnv("users.avatar_size", "USERS_AVATAR_SIZE")
+	l.viper.BindEnv("users.fold_gmail_addresses", "USERS_FOLD_GMAIL_ADDRESSES")
+	l.viper.BindEnv("users.canonicalize_batch_size", "USERS_CANONICALIZE_BATCH_SIZE")
+	l.viper.BindEnv("users.reserved_handles", "USERS_RESERVED_HANDLES")
+	l.viper.BindEnv("users.cursor_signing_key", "USERS_CURSOR_SIGNING_KEY")
+
+	// Preferences configuration
+	l.viper.BindEnv("preferences.max_value_bytes", "PREFERENCES_MAX_VALUE_BYTES")
+	l.viper.BindEnv("preferences.max_keys", "PREFERENCES_MAX_KEYS")
+	l.viper.BindEnv("preferences.cache_ttl", "PREFERENCES_CACHE_TTL")
+
+	// Notifications configuration
+	l.viper.BindEnv("notifications.enabled", "NOTIFICATIONS_ENABLED")
+	l.viper.BindEnv("notifications.email", "NOTIFICATIONS_EMAIL")
+
+	// Organizations configuration
+	l.viper.BindEnv("organizations.enabled", "ORGANIZATIONS_ENABLED")
+	l.viper.BindEnv("organizations.invite_ttl", "ORGANIZATIONS_INVITE_TTL")
+	l.viper.BindEnv("organizations.invite_url", "ORGANIZATIONS_INVITE_URL")
+
+	// API version configuration
+	l.viper.BindEnv("api.v2", "API_V2")
+	l.viper.BindEnv("api.v1_deprecated_at", "API_V1_DEPRECATED_AT")
+	l.viper.BindEnv("api.v1_sunset_at", "API_V1_SUNSET_AT")
+	l.viper.BindEnv("api.v1_deprecation_link", "API_V1_DEPRECATION_LINK")
+
+	// Search configuration
+	l.viper.BindEnv("search.backend", "SEARCH_BACKEND")
+	l.viper.BindEnv("search.addresses", "SEARCH_ADDRESSES")
+	l.viper.BindEnv("search.username", "SEARCH_USERNAME")
+	l.viper.BindEnv("search.password", "SEARCH_PASSWORD")
+	l.viper.BindEnv("search.index", "SEARCH_INDEX")
+	l.viper.BindEnv("search.timeout", "SEARCH_TIMEOUT")
+	l.viper.BindEnv("search.reindex_batch_size", "SEARCH_REINDEX_BATCH_SIZE")
+
+	// Storage configuration
+	l.viper.BindEnv("storage.enabled", "STORAGE_ENABLED")
+	l.viper.BindEnv("storage.backend", "STORAGE_BACKEND")
+	l.viper.BindEnv("storage.dir", "STORAGE_DIR")
+	l.viper.BindEnv("storage.signing_key", "STORAGE_SIGNING_KEY")
+	l.viper.BindEnv("storage.endpoint", "STORAGE_ENDPOINT")
+	l.viper.BindEnv("storage.region", "STORAGE_REGION")
+	l.viper.BindEnv("storage.bucket", "STORAGE_BUCKET")
+	l.viper.BindEnv("storage.access_key_id", "STORAGE_ACCESS_KEY_ID")
+	l.viper.BindEnv("storage.secret_access_key", "STORAGE_SECRET_ACCESS_KEY")
+	l.viper.BindEnv("storage.session_token", "STORAGE_SESSION_TOKEN")
+	l.viper.BindEnv("storage.path_style", "STORAGE_PATH_STYLE")
+	l.viper.BindEnv("storage.url_ttl", "STORAGE_URL_TTL")
+
+	// Webhooks configuration
+	l.viper.BindEnv("webhooks.enabled", "WEBHOOKS_ENABLED")
+	l.viper.BindEnv("webhooks.poll_interval", "WEBHOOKS_POLL_INTERVAL")
+	l.viper.BindEnv("webhooks.batch_size", "WEBHOOKS_BATCH_SIZE")
+	l.viper.BindEnv("webhooks.max_attempts", "WEBHOOKS_MAX_ATTEMPTS")
+	l.viper.BindEnv("webhooks.base_backoff", "WEBHOOKS_BASE_BACKOFF")
+	l.viper.BindEnv("webhooks.max_backoff", "WEBHOOKS_MAX_BACKOFF")
+	l.viper.BindEnv("webhooks.timeout", "WEBHOOKS_TIMEOUT")
+
+	// Retry configuration
+	l.viper.BindEnv("retry.enabled", "RETRY_ENABLED")
+	l.viper.BindEnv("retry.max_attempts", "RETRY_MAX_ATTEMPTS")
+	l.viper.BindEnv("retry.base_delay", "RETRY_BASE_DELAY")
+	l.viper.BindEnv("retry.max_delay", "RETRY_MAX_DELAY")
+	l.viper.BindEnv("retry.jitter", "RETRY_JITTER")
+
+	// Circuit breaker configuration
+	l.viper.BindEnv("circuit_breaker.enabled", "CIRCUIT_BREAKER_ENABLED")
+	l.viper.BindEnv("circuit_breaker.failure_threshold", "CIRCUIT_BREAKER_FAILURE_THRESHOLD")
+	l.viper.BindEnv("circuit_breaker.open_timeout", "CIRCUIT_BREAKER_OPEN_TIMEOUT")
+	l.viper.BindEnv("circuit_breaker.half_open_max_calls", "CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS")
+
+	// Tenancy
+	l.viper.BindEnv("tenancy.enabled", "TENANCY_ENABLED")
+	l.viper.BindEnv("tenancy.header", "TENANCY_HEADER")
+	l.viper.BindEnv("tenancy.base_domain", "TENANCY_BASE_DOMAIN")
+
+	// Localization
+	l.viper.BindEnv("i18n.enabled", "I18N_ENABLED")
+	l.viper.BindEnv("i18n.languages", "I18N_LANGUAGES")
+
+	// Replay configuration
+	l.viper.BindEnv("replay.enabled", "REPLAY_ENABLED")
+	l.viper.BindEnv("replay.dir", "REPLAY_DIR")
+	l.viper.BindEnv("replay.min_status", "REPLAY_MIN_STATUS")
+	l.viper.BindEnv("replay.max_body_bytes", "REPLAY_MAX_BODY_BYTES")
 
 	// Redis configuration
+	l.viper.BindEnv("external.redis.enabled", "REDIS_ENABLED")
 	l.viper.BindEnv("external.redis.host", "REDIS_HOST")
 	l.viper.BindEnv("external.redis.port", "REDIS_PORT")
 	l.viper.BindEnv("external.redis.password", "REDIS_PASSWORD")
 	l.viper.BindEnv("external.redis.database", "REDIS_DATABASE")
 
 	// Email configuration
+	l.viper.BindEnv("external.email.enabled", "EMAIL_ENABLED")
 	l.viper.BindEnv("external.email.provider", "EMAIL_PROVIDER")
 	l.viper.BindEnv("external.email.host", "EMAIL_HOST")
 	l.viper.BindEnv("external.email.port", "EMAIL_PORT")
 	l.viper.BindEnv("external.email.username", "EMAIL_USERNAME")
 	l.viper.BindEnv("external.email.password", "EMAIL_PASSWORD")
+	l.viper.BindEnv("external.email.from", "EMAIL_FROM")
+	l.viper.BindEnv("external.email.tls", "EMAIL_TLS")
+	l.viper.BindEnv("external.email.timeout", "EMAIL_TIMEOUT")
+	l.viper.BindEnv("external.email.max_attempts", "EMAIL_MAX_ATTEMPTS")
+	l.viper.BindEnv("external.email.app_url", "EMAIL_APP_URL")
+	l.viper.BindEnv("external.email.verification_ttl", "EMAIL_VERIFICATION_TTL")
+	l.viper.BindEnv("external.email.verification_url", "EMAIL_VERIFICATION_URL")
+
+	// Messaging configuration
+	l.viper.BindEnv("external.messaging.enabled", "MESSAGING_ENABLED")
+	l.viper.BindEnv("external.messaging.provider", "MESSAGING_PROVIDER")
+	l.viper.BindEnv("external.messaging.url", "MESSAGING_URL")
+	l.viper.BindEnv("external.messaging.topic_prefix", "MESSAGING_TOPIC_PREFIX")
+	l.viper.BindEnv("external.messaging.client_name", "MESSAGING_CLIENT_NAME")
+	l.viper.BindEnv("external.messaging.connect_timeout", "MESSAGING_CONNECT_TIMEOUT")
+
+	// Sentry configuration
+	l.viper.BindEnv("external.sentry.dsn", "SENTRY_DSN")
+	l.viper.BindEnv("external.sentry.environment", "SENTRY_ENVIRONMENT")
+	l.viper.BindEnv("external.sentry.release", "SENTRY_RELEASE")
+	l.viper.BindEnv("external.sentry.timeout", "SENTRY_TIMEOUT")
+
+	// Secrets configuration; the Vault and AWS SDK variable names are honored too
+	l.viper.BindEnv("secrets.refresh_interval", "SECRETS_REFRESH_INTERVAL")
+	l.viper.BindEnv("secrets.timeout", "SECRETS_TIMEOUT")
+	l.viper.BindEnv("secrets.vault.enabled", "SECRETS_VAULT_ENABLED")
+	l.viper.BindEnv("secrets.vault.address", "SECRETS_VAULT_ADDRESS", "VAULT_ADDR")
+	l.viper.BindEnv("secrets.vault.token", "SECRETS_VAULT_TOKEN", "VAULT_TOKEN")
+	l.viper.BindEnv("secrets.vault.namespace", "SECRETS_VAULT_NAMESPACE", "VAULT_NAMESPACE")
+	l.viper.BindEnv("secrets.aws.enabled", "SECRETS_AWS_ENABLED")
+	l.viper.BindEnv("secrets.aws.region", "SECRETS_AWS_REGION", "AWS_REGION")
+	l.viper.BindEnv("secrets.aws.access_key_id", "SECRETS_AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID")
+	l.viper.BindEnv("secrets.aws.secret_access_key", "SECRETS_AWS_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY")
+	l.viper.BindEnv("secrets.aws.session_token", "SECRETS_AWS_SESSION_TOKEN", "AWS_SESSION_TOKEN")
+	l.viper.BindEnv("secrets.aws.endpoint", "SECRETS_AWS_ENDPOINT")
 }
 
 // GetConfigFilePath returns the path of the loaded configuration file
@@ -310,8 +547,43 @@ func (l *Loader) setConfigValues(v *viper.Viper, config *Config) {
 	v.Set("server.write_timeout", config.Server.WriteTimeout)
 	v.Set("server.idle_timeout", config.Server.IdleTimeout)
 	v.Set("server.enable_cors", config.Server.EnableCORS)
+	if config.Server.CORS != nil {
+		v.Set("server.cors.allowed_origins", config.Server.CORS.AllowedOrigins)
+		v.Set("server.cors.allowed_methods", config.Server.CORS.AllowedMethods)
+		v.Set("server.cors.allowed_headers", config.Server.CORS.AllowedHeaders)
+		v.Set("server.cors.exposed_headers", config.Server.CORS.ExposedHeaders)
+		v.Set("server.cors.allow_credentials", config.Server.CORS.AllowCredentials)
+		v.Set("server.cors.max_age", config.Server.CORS.MaxAge)
+	}
+	if config.Server.TLS != nil {
+		v.Set("server.tls.enabled", config.Server.TLS.Enabled)
+		v.Set("server.tls.cert_file", config.Server.TLS.CertFile)
+		v.Set("server.tls.key_file", config.Server.TLS.KeyFile)
+		v.Set("server.tls.autocert", config.Server.TLS.Autocert)
+		v.Set("server.tls.autocert_domains", config.Server.TLS.AutocertDomains)
+		v.Set("server.tls.autocert_email", config.Server.TLS.AutocertEmail)
+		v.Set("server.tls.autocert_cache_dir", config.Server.TLS.AutocertCacheDir)
+		v.Set("server.tls.redirect_http", config.Server.TLS.RedirectHTTP)
+		v.Set("server.tls.http_port", config.Server.TLS.HTTPPort)
+		v.Set("server.tls.http2", config.Server.TLS.HTTP2)
+	}
+	v.Set("server.max_body_bytes", config.Server.MaxBodyBytes)
+	v.Set("server.handler_timeout", config.Server.HandlerTimeout)
+	v.Set("server.shutdown_timeout", config.Server.ShutdownTimeout)
+	v.Set("server.trusted_proxies", config.Server.TrustedProxies)
+	if config.Server.AdminUI != nil {
+		v.Set("server.admin_ui.enabled", config.Server.AdminUI.Enabled)
+		v.Set("server.admin_ui.path", config.Server.AdminUI.Path)
+		v.Set("server.admin_ui.dir", config.Server.AdminUI.Dir)
+		v.Set("server.admin_ui.cache_max_age", config.Server.AdminUI.CacheMaxAge)
+		v.Set("server.admin_ui.content_security_policy", config.Server.AdminUI.ContentSecurityPolicy)
+	}
+	if len(config.Server.Routes) > 0 {
+		v.Set("server.routes", config.Server.Routes)
+	}
 
 	// Database configuration
+	v.Set("database.driver", config.Database.Driver)
 	v.Set("database.host", config.Database.Host)
 	v.Set("database.port", config.Database.Port)
 	v.Set("database.username", config.Database.Username)
@@ -324,6 +596,14 @@ func (l *Loader) setConfigValues(v *viper.Viper, config *Config) {
 	v.Set("database.conn_max_lifetime", config.Database.ConnMaxLifetime)
 	v.Set("database.conn_max_idle_time", config.Database.ConnMaxIdleTime)
 	v.Set("database.log_level", config.Database.LogLevel)
+	v.Set("database.auto_migrate", config.Database.AutoMigrate)
+	v.Set("database.slow_query_threshold", config.Database.SlowQueryThreshold)
+	v.Set("database.prepare_stmt", config.Database.PrepareStmt)
+	v.Set("database.statement_timeout", config.Database.StatementTimeout)
+	v.Set("database.query_timeout", config.Database.QueryTimeout)
+	v.Set("database.monitor_interval", config.Database.MonitorInterval)
+	v.Set("database.replica_hosts", config.Database.ReplicaHosts)
+	v.Set("database.replica_check_interval", config.Database.ReplicaCheckInterval)
 
 	// Log configuration
 	v.Set("log.level", config.Log.Level)
@@ -331,14 +611,314 @@ func (l *Loader) setConfigValues(v *viper.Viper, config *Config) {
 	v.Set("log.output", config.Log.Output)
 	v.Set("log.enable_file", config.Log.EnableFile)
 	v.Set("log.file_path", config.Log.FilePath)
+	v.Set("log.sampling.message_rate", config.Log.Sampling.MessageRate)
+	v.Set("log.sampling.burst", config.Log.Sampling.Burst)
+	v.Set("log.sampling.debug_percent", config.Log.Sampling.DebugPercent)
+	if len(config.Log.Sampling.Messages) > 0 {
+		v.Set("log.sampling.messages", config.Log.Sampling.Messages)
+	}
+	v.Set("log.async.enabled", config.Log.Async.Enabled)
+	v.Set("log.async.capacity", config.Log.Async.Capacity)
+	v.Set("log.ship.url", config.Log.Ship.URL.String())
+	v.Set("log.ship.labels", config.Log.Ship.Labels)
+	v.Set("log.ship.index", config.Log.Ship.Index)
+	v.Set("log.ship.username", config.Log.Ship.Username)
+	v.Set("log.ship.password", config.Log.Ship.Password)
+	v.Set("log.ship.batch_size", config.Log.Ship.BatchSize)
+	v.Set("log.ship.flush_interval", config.Log.Ship.FlushInterval)
+	v.Set("log.ship.queue_size", config.Log.Ship.QueueSize)
+	v.Set("log.ship.max_retries", config.Log.Ship.MaxRetries)
+	v.Set("log.ship.timeout", config.Log.Ship.Timeout)
+	v.Set("log.signal_level_ttl", config.Log.SignalLevelTTL)
+
+	// JWT configuration
+	v.Set("jwt.signing_key", config.JWT.SigningKey)
+	v.Set("jwt.expiry", config.JWT.Expiry)
+	v.Set("jwt.active_key_id", config.JWT.ActiveKeyID)
+	v.Set("jwt.accept_legacy_tokens", config.JWT.AcceptLegacyTokens)
+	if len(config.JWT.Keys) > 0 {
+		v.Set("jwt.keys", config.JWT.Keys)
+	}
 
 	// ID configuration
 	v.Set("id.service_type", config.ID.ServiceType)
 	v.Set("id.instance_id", config.ID.InstanceID)
 	v.Set("id.node_id", config.ID.NodeID)
+	v.Set("id.allocator", config.ID.Allocator)
+	v.Set("id.etcd_endpoints", config.ID.EtcdEndpoints)
+	v.Set("id.redis_key_prefix", config.ID.RedisKeyPrefix)
+	v.Set("id.lease_timeout", config.ID.LeaseTimeout)
+	v.Set("id.renew_interval", config.ID.RenewInterval)
+
+	// Security configuration
+	if config.Security != nil && config.Security.PasswordBreach != nil {
+		v.Set("security.password_breach.enabled", config.Security.PasswordBreach.Enabled)
+		v.Set("security.password_breach.policy", config.Security.PasswordBreach.Policy)
+		v.Set("security.password_breach.api_url", config.Security.PasswordBreach.APIURL.String())
+		v.Set("security.password_breach.timeout", config.Security.PasswordBreach.Timeout)
+		v.Set("security.password_breach.cache_ttl", config.Security.PasswordBreach.CacheTTL)
+		v.Set("security.password_breach.failure_threshold", config.Security.PasswordBreach.FailureThreshold)
+		v.Set("security.password_breach.open_timeout", config.Security.PasswordBreach.OpenTimeout)
+	}
+	if config.Security != nil && config.Security.Lockout != nil {
+		v.Set("security.lockout.enabled", config.Security.Lockout.Enabled)
+		v.Set("security.lockout.store", config.Security.Lockout.Store)
+		v.Set("security.lockout.threshold", config.Security.Lockout.Threshold)
+		v.Set("security.lockout.ip_threshold", config.Security.Lockout.IPThreshold)
+		v.Set("security.lockout.window", config.Security.Lockout.Window)
+		v.Set("security.lockout.duration", config.Security.Lockout.Duration)
+	}
+	if config.Security != nil && config.Security.Captcha != nil {
+		v.Set("security.captcha.enabled", config.Security.Captcha.Enabled)
+		v.Set("security.captcha.provider", config.Security.Captcha.Provider)
+		v.Set("security.captcha.secret_key", config.Security.Captcha.SecretKey)
+		v.Set("security.captcha.verify_url", config.Security.Captcha.VerifyURL.String())
+		v.Set("security.captcha.timeout", config.Security.Captcha.Timeout)
+		v.Set("security.captcha.min_score", config.Security.Captcha.MinScore)
+		v.Set("security.captcha.register", config.Security.Captcha.Register)
+		v.Set("security.captcha.login_threshold", config.Security.Captcha.LoginThreshold)
+		v.Set("security.captcha.bypass_token", config.Security.Captcha.BypassToken)
+	}
+	if config.Security != nil && config.Security.IPFilter != nil {
+		v.Set("security.ip_filter.enabled", config.Security.IPFilter.Enabled)
+		v.Set("security.ip_filter.allow", config.Security.IPFilter.Allow)
+		v.Set("security.ip_filter.deny", config.Security.IPFilter.Deny)
+		v.Set("security.ip_filter.groups", config.Security.IPFilter.Groups)
+	}
+	if config.Security != nil && config.Security.Signup != nil {
+		v.Set("security.signup.enabled", config.Security.Signup.Enabled)
+		v.Set("security.signup.store", config.Security.Signup.Store)
+		v.Set("security.signup.window", config.Security.Signup.Window)
+		v.Set("security.signup.domain_limit", config.Security.Signup.DomainLimit)
+		v.Set("security.signup.ip_limit", config.Security.Signup.IPLimit)
+		v.Set("security.signup.exempt_domains", config.Security.Signup.ExemptDomains)
+		v.Set("security.signup.block_disposable", config.Security.Signup.BlockDisposable)
+		v.Set("security.signup.disposable_domains", config.Security.Signup.DisposableDomains)
+		v.Set("security.signup.disposable_domains_file", config.Security.Signup.DisposableDomainsFile)
+		v.Set("security.signup.honeypot", config.Security.Signup.Honeypot)
+		v.Set("security.signup.handle_check_limit", config.Security.Signup.HandleCheckLimit)
+	}
+
+	// Auth configuration
+	if config.Auth != nil {
+		v.Set("auth.provider", config.Auth.Provider)
+	}
+	if config.Auth != nil && config.Auth.LDAP != nil {
+		v.Set("auth.ldap.url", config.Auth.LDAP.URL.String())
+		v.Set("auth.ldap.start_tls", config.Auth.LDAP.StartTLS)
+		v.Set("auth.ldap.insecure_skip_verify", config.Auth.LDAP.InsecureSkipVerify)
+		v.Set("auth.ldap.bind_dn", config.Auth.LDAP.BindDN)
+		v.Set("auth.ldap.bind_password", config.Auth.LDAP.BindPassword)
+		v.Set("auth.ldap.base_dn", config.Auth.LDAP.BaseDN)
+		v.Set("auth.ldap.user_filter", config.Auth.LDAP.UserFilter)
+		v.Set("auth.ldap.email_attribute", config.Auth.LDAP.EmailAttribute)
+		v.Set("auth.ldap.name_attribute", config.Auth.LDAP.NameAttribute)
+		v.Set("auth.ldap.timeout", config.Auth.LDAP.Timeout)
+		v.Set("auth.ldap.create_users", config.Auth.LDAP.CreateUsers)
+	}
+	if config.Auth != nil && config.Auth.MFA != nil {
+		v.Set("auth.mfa.enforcement", config.Auth.MFA.Enforcement)
+		v.Set("auth.mfa.issuer", config.Auth.MFA.Issuer)
+		v.Set("auth.mfa.token_ttl", config.Auth.MFA.TokenTTL)
+		v.Set("auth.mfa.skew", config.Auth.MFA.Skew)
+		v.Set("auth.mfa.max_attempts", config.Auth.MFA.MaxAttempts)
+		v.Set("auth.mfa.lock_duration", config.Auth.MFA.LockDuration)
+		v.Set("auth.mfa.recovery_codes", config.Auth.MFA.RecoveryCodes)
+		v.Set("auth.mfa.trusted_device_ttl", config.Auth.MFA.TrustedDeviceTTL)
+	}
+
+	// Bootstrap configuration
+	if config.Bootstrap != nil {
+		v.Set("bootstrap.admin_email", config.Bootstrap.AdminEmail)
+		v.Set("bootstrap.admin_name", config.Bootstrap.AdminName)
+		v.Set("bootstrap.setup_token", config.Bootstrap.SetupToken)
+	}
+
+	// Outbox configuration
+	if config.Outbox != nil {
+		v.Set("outbox.enabled", config.Outbox.Enabled)
+		v.Set("outbox.poll_interval", config.Outbox.PollInterval)
+		v.Set("outbox.batch_size", config.Outbox.BatchSize)
+		v.Set("outbox.max_attempts", config.Outbox.MaxAttempts)
+		v.Set("outbox.base_backoff", config.Outbox.BaseBackoff)
+		v.Set("outbox.max_backoff", config.Outbox.MaxBackoff)
+	}
+
+	// Jobs configuration
+	if config.Jobs != nil {
+		v.Set("jobs.enabled", config.Jobs.Enabled)
+		v.Set("jobs.backend", config.Jobs.Backend)
+		v.Set("jobs.concurrency", config.Jobs.Concurrency)
+		v.Set("jobs.poll_interval", config.Jobs.PollInterval)
+		v.Set("jobs.lease", config.Jobs.Lease)
+		v.Set("jobs.max_attempts", config.Jobs.MaxAttempts)
+		v.Set("jobs.base_backoff", config.Jobs.BaseBackoff)
+		v.Set("jobs.max_backoff", config.Jobs.MaxBackoff)
+		v.Set("jobs.redis_key_prefix", config.Jobs.RedisKeyPrefix)
+	}
+
+	// Scheduler configuration
+	if config.Scheduler != nil {
+		v.Set("scheduler.enabled", config.Scheduler.Enabled)
+		v.Set("scheduler.timezone", config.Scheduler.Timezone)
+		v.Set("scheduler.audit_retention_schedule", config.Scheduler.AuditRetentionSchedule)
+		v.Set("scheduler.audit_retention", config.Scheduler.AuditRetention)
+		v.Set("scheduler.outbox_cleanup_schedule", config.Scheduler.OutboxCleanupSchedule)
+		v.Set("scheduler.outbox_retention", config.Scheduler.OutboxRetention)
+		v.Set("scheduler.account_purge_schedule", config.Scheduler.AccountPurgeSchedule)
+	}
+
+	// Audit configuration
+	if config.Audit != nil {
+		v.Set("audit.enabled", config.Audit.Enabled)
+		v.Set("audit.buffer_size", config.Audit.BufferSize)
+		v.Set("audit.batch_size", config.Audit.BatchSize)
+		v.Set("audit.flush_interval", config.Audit.FlushInterval)
+	}
+
+	// Account configuration
+	if config.Account != nil {
+		v.Set("account.deletion_grace_period", config.Account.DeletionGracePeriod)
+		v.Set("account.data_export_ttl", config.Account.DataExportTTL)
+	}
+
+	// Encryption configuration
+	if config.Encryption != nil {
+		v.Set("encryption.enabled", config.Encryption.Enabled)
+		v.Set("encryption.active_key_id", config.Encryption.ActiveKeyID)
+		v.Set("encryption.index_key", config.Encryption.IndexKey)
+		v.Set("encryption.reencrypt_batch_size", config.Encryption.ReencryptBatchSize)
+		if len(config.Encryption.Keys) > 0 {
+			v.Set("encryption.keys", config.Encryption.Keys)
+		}
+	}
+
+	// Import configuration
+	if config.Import != nil {
+		v.Set("import.batch_size", config.Import.BatchSize)
+		v.Set("import.max_rows", config.Import.MaxRows)
+		v.Set("import.max_body_bytes", config.Import.MaxBodyBytes)
+	}
+
+	// Stats configuration
+	if config.Stats != nil {
+		v.Set("stats.cache_ttl", config.Stats.CacheTTL)
+	}
+
+	// Users configuration
+	if config.Users != nil {
+		v.Set("users.count_cache_ttl", config.Users.CountCacheTTL)
+		v.Set("users.avatar_max_bytes", config.Users.AvatarMaxBytes)
+		v.Set("users.avatar_size", config.Users.AvatarSize)
+		v.Set("users.fold_gmail_addresses", config.Users.FoldGmailAddresses)
+		v.Set("users.canonicalize_batch_size", config.Users.CanonicalizeBatchSize)
+		v.Set("users.reserved_handles", config.Users.ReservedHandles)
+		v.Set("users.cursor_signing_key", config.Users.CursorSigningKey)
+	}
+
+	// Preferences configuration
+	if config.Preferences != nil {
+		v.Set("preferences.max_value_bytes", config.Preferences.MaxValueBytes)
+		v.Set("preferences.max_keys", config.Preferences.MaxKeys)
+		v.Set("preferences.cache_ttl", config.Preferences.CacheTTL)
+	}
+
+	// Notifications configuration
+	if config.Notifications != nil {
+		v.Set("notifications.enabled", config.Notifications.Enabled)
+		v.Set("notifications.email", config.Notifications.Email)
+	}
+
+	// Organizations configuration
+	if config.Organizations != nil {
+		v.Set("organizations.enabled", config.Organizations.Enabled)
+		v.Set("organizations.invite_ttl", config.Organizations.InviteTTL)
+		v.Set("organizations.invite_url", config.Organizations.InviteURL.String())
+	}
+
+	// API version configuration
+	if config.API != nil {
+		v.Set("api.v2", config.API.V2)
+		v.Set("api.v1_deprecated_at", config.API.V1DeprecatedAt)
+		v.Set("api.v1_sunset_at", config.API.V1SunsetAt)
+		v.Set("api.v1_deprecation_link", config.API.V1DeprecationLink.String())
+	}
+
+	// Search configuration
+	if config.Search != nil {
+		v.Set("search.backend", config.Search.Backend)
+		v.Set("search.addresses", settingValue(reflect.ValueOf(config.Search.Addresses)))
+		v.Set("search.username", config.Search.Username)
+		v.Set("search.password", config.Search.Password)
+		v.Set("search.index", config.Search.Index)
+		v.Set("search.timeout", config.Search.Timeout)
+		v.Set("search.reindex_batch_size", config.Search.ReindexBatchSize)
+	}
+
+	// Storage configuration
+	if config.Storage != nil {
+		v.Set("storage.enabled", config.Storage.Enabled)
+		v.Set("storage.backend", config.Storage.Backend)
+		v.Set("storage.dir", config.Storage.Dir)
+		v.Set("storage.signing_key", config.Storage.SigningKey)
+		v.Set("storage.endpoint", config.Storage.Endpoint.String())
+		v.Set("storage.region", config.Storage.Region)
+		v.Set("storage.bucket", config.Storage.Bucket)
+		v.Set("storage.access_key_id", config.Storage.AccessKeyID)
+		v.Set("storage.secret_access_key", config.Storage.SecretAccessKey)
+		v.Set("storage.session_token", config.Storage.SessionToken)
+		v.Set("storage.path_style", config.Storage.PathStyle)
+		v.Set("storage.url_ttl", config.Storage.URLTTL)
+	}
+
+	// Webhooks configuration
+	if config.Webhooks != nil {
+		v.Set("webhooks.enabled", config.Webhooks.Enabled)
+		v.Set("webhooks.poll_interval", config.Webhooks.PollInterval)
+		v.Set("webhooks.batch_size", config.Webhooks.BatchSize)
+		v.Set("webhooks.max_attempts", config.Webhooks.MaxAttempts)
+		v.Set("webhooks.base_backoff", config.Webhooks.BaseBackoff)
+		v.Set("webhooks.max_backoff", config.Webhooks.MaxBackoff)
+		v.Set("webhooks.timeout", config.Webhooks.Timeout)
+	}
+
+	// Retry configuration
+	if config.Retry != nil {
+		v.Set("retry.enabled", config.Retry.Enabled)
+		v.Set("retry.max_attempts", config.Retry.MaxAttempts)
+		v.Set("retry.base_delay", config.Retry.BaseDelay)
+		v.Set("retry.max_delay", config.Retry.MaxDelay)
+		v.Set("retry.jitter", config.Retry.Jitter)
+	}
+
+	// Circuit breaker configuration
+	if config.CircuitBreaker != nil {
+		v.Set("circuit_breaker.enabled", config.CircuitBreaker.Enabled)
+		v.Set("circuit_breaker.failure_threshold", config.CircuitBreaker.FailureThreshold)
+		v.Set("circuit_breaker.open_timeout", config.CircuitBreaker.OpenTimeout)
+		v.Set("circuit_breaker.half_open_max_calls", config.CircuitBreaker.HalfOpenMaxCalls)
+	}
+	if config.Tenancy != nil {
+		v.Set("tenancy.enabled", config.Tenancy.Enabled)
+		v.Set("tenancy.header", config.Tenancy.Header)
+		v.Set("tenancy.base_domain", config.Tenancy.BaseDomain)
+	}
+	if config.I18n != nil {
+		v.Set("i18n.enabled", config.I18n.Enabled)
+		v.Set("i18n.languages", config.I18n.Languages)
+	}
+
+	// Replay configuration
+	if config.Replay != nil {
+		v.Set("replay.enabled", config.Replay.Enabled)
+		v.Set("replay.dir", config.Replay.Dir)
+		v.Set("replay.min_status", config.Replay.MinStatus)
+		v.Set("replay.max_body_bytes", config.Replay.MaxBodyBytes)
+	}
 
 	// External services configuration
 	if config.External.Redis != nil {
+		v.Set("external.redis.enabled", config.External.Redis.Enabled)
 		v.Set("external.redis.host", config.External.Redis.Host)
 		v.Set("external.redis.port", config.External.Redis.Port)
 		v.Set("external.redis.password", config.External.Redis.Password)
@@ -346,11 +926,55 @@ func (l *Loader) setConfigValues(v *viper.Viper, config *Config) {
 	}
 
 	if config.External.Email != nil {
+		v.Set("external.email.enabled", config.External.Email.Enabled)
 		v.Set("external.email.provider", config.External.Email.Provider)
 		v.Set("external.email.host", config.External.Email.Host)
 		v.Set("external.email.port", config.External.Email.Port)
 		v.Set("external.email.username", config.External.Email.Username)
 		v.Set("external.email.password", config.External.Email.Password)
+		v.Set("external.email.from", config.External.Email.From)
+		v.Set("external.email.tls", config.External.Email.TLS)
+		v.Set("external.email.timeout", config.External.Email.Timeout)
+		v.Set("external.email.max_attempts", config.External.Email.MaxAttempts)
+		v.Set("external.email.app_url", config.External.Email.AppURL.String())
+		v.Set("external.email.verification_ttl", config.External.Email.VerificationTTL)
+		v.Set("external.email.verification_url", config.External.Email.VerificationURL.String())
+	}
+
+	if config.External.Messaging != nil {
+		v.Set("external.messaging.enabled", config.External.Messaging.Enabled)
+		v.Set("external.messaging.provider", config.External.Messaging.Provider)
+		v.Set("external.messaging.url", config.External.Messaging.URL.String())
+		v.Set("external.messaging.topic_prefix", config.External.Messaging.TopicPrefix)
+		v.Set("external.messaging.client_name", config.External.Messaging.ClientName)
+		v.Set("external.messaging.connect_timeout", config.External.Messaging.ConnectTimeout)
+	}
+
+	if config.External.Sentry != nil {
+		v.Set("external.sentry.dsn", config.External.Sentry.DSN)
+		v.Set("external.sentry.environment", config.External.Sentry.Environment)
+		v.Set("external.sentry.release", config.External.Sentry.Release)
+		v.Set("external.sentry.timeout", config.External.Sentry.Timeout)
+	}
+
+	// Secrets configuration
+	if config.Secrets != nil {
+		v.Set("secrets.refresh_interval", config.Secrets.RefreshInterval)
+		v.Set("secrets.timeout", config.Secrets.Timeout)
+		if config.Secrets.Vault != nil {
+			v.Set("secrets.vault.enabled", config.Secrets.Vault.Enabled)
+			v.Set("secrets.vault.address", config.Secrets.Vault.Address)
+			v.Set("secrets.vault.token", config.Secrets.Vault.Token)
+			v.Set("secrets.vault.namespace", config.Secrets.Vault.Namespace)
+		}
+		if config.Secrets.AWS != nil {
+			v.Set("secrets.aws.enabled", config.Secrets.AWS.Enabled)
+			v.Set("secrets.aws.region", config.Secrets.AWS.Region)
+			v.Set("secrets.aws.access_key_id", config.Secrets.AWS.AccessKeyID)
+			v.Set("secrets.aws.secret_access_key", config.Secrets.AWS.SecretAccessKey)
+			v.Set("secrets.aws.session_token", config.Secrets.AWS.SessionToken)
+			v.Set("secrets.aws.endpoint", config.Secrets.AWS.Endpoint)
+		}
 	}
 }
 
@@ -373,6 +997,31 @@ func LoadForEnvironment(env string, configPaths ...string) (*Config, error) {
 	return globalLoader.LoadConfigForEnvironment(env, configPaths...)
 }
 
+// Subscribe registers a change handler on the global loader
+func Subscribe(handler ChangeHandler) error {
+	if globalLoader == nil {
+		return fmt.Errorf("configuration has not been loaded")
+	}
+	return globalLoader.Subscribe(handler)
+}
+
+// WatchSecrets periodically re-fetches secret references using the global loader
+func WatchSecrets(ctx context.Context, interval time.Duration) {
+	if globalLoader == nil {
+		return
+	}
+	globalLoader.WatchSecrets(ctx, interval)
+}
+
+// Watch subscribes to configuration changes using the global loader.
+// The global loader must already have loaded a configuration file.
+func Watch(handler ChangeHandler) error {
+	if globalLoader == nil {
+		return fmt.Errorf("configuration has not been loaded")
+	}
+	return globalLoader.Watch(handler)
+}
+
 // WriteConfig writes configuration to file using global loader
 func WriteConfig(config *Config, filePath string) error {
 	if globalLoader == nil {
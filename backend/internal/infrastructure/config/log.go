@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// LoggerConfig returns the configuration of pkg/logger, the single place
+// log settings are translated
+func (c *LogConfig) LoggerConfig() logger.LogConfig {
+	return logger.LogConfig{
+		Level:      c.Level,
+		Format:     c.Format,
+		Output:     c.Output,
+		FilePath:   c.FilePath,
+		EnableFile: c.EnableFile,
+		Sampling:   c.Sampling.LoggerConfig(),
+		Async: logger.AsyncConfig{
+			Enabled:  c.Async.Enabled,
+			Capacity: c.Async.Capacity,
+		},
+		Ship: logger.ShipConfig{
+			URL:           c.Ship.URL.String(),
+			Labels:        c.Ship.Labels,
+			Index:         c.Ship.Index,
+			Username:      c.Ship.Username,
+			Password:      c.Ship.Password,
+			BatchSize:     c.Ship.BatchSize,
+			FlushInterval: c.Ship.FlushInterval,
+			QueueSize:     c.Ship.QueueSize,
+			MaxRetries:    c.Ship.MaxRetries,
+			Timeout:       c.Ship.Timeout,
+		},
+	}
+}
+
+// Buffered reports whether entries are queued before they are written, so
+// the logger must be closed on shutdown
+func (c *LogConfig) Buffered() bool {
+	return c.Async.Enabled || c.Output == logger.OutputLoki || c.Output == logger.OutputElasticsearch
+}
+
+// LogShipConfig configures the loki and elasticsearch outputs, which send
+// entries to the log store in batches
+type LogShipConfig struct {
+	// URL is Loki's push endpoint or the Elasticsearch base URL
+	URL url.URL `yaml:"url" mapstructure:"url" env:"LOG_SHIP_URL"`
+	// Labels are the Loki stream labels
+	Labels map[string]string `yaml:"labels" mapstructure:"labels"`
+	// Index is the Elasticsearch index; "{date}" becomes the UTC date
+	Index    string `yaml:"index" mapstructure:"index" env:"LOG_SHIP_INDEX"`
+	Username string `yaml:"username" mapstructure:"username" env:"LOG_SHIP_USERNAME"`
+	Password string `yaml:"password" mapstructure:"password" env:"LOG_SHIP_PASSWORD" redact:"true"`
+
+	BatchSize     int           `yaml:"batch_size" mapstructure:"batch_size" env:"LOG_SHIP_BATCH_SIZE"`
+	FlushInterval time.Duration `yaml:"flush_interval" mapstructure:"flush_interval" env:"LOG_SHIP_FLUSH_INTERVAL"`
+	// QueueSize entries wait to be sent; when the queue is full new entries
+	// are dropped
+	QueueSize  int           `yaml:"queue_size" mapstructure:"queue_size" env:"LOG_SHIP_QUEUE_SIZE"`
+	MaxRetries int           `yaml:"max_retries" mapstructure:"max_retries" env:"LOG_SHIP_MAX_RETRIES"`
+	Timeout    time.Duration `yaml:"timeout" mapstructure:"timeout" env:"LOG_SHIP_TIMEOUT"`
+}
+
+// DefaultLogShipConfig returns default log shipping configuration
+func DefaultLogShipConfig() LogShipConfig {
+	defaults := logger.DefaultShipConfig()
+	return LogShipConfig{
+		Labels:        defaults.Labels,
+		Index:         defaults.Index,
+		BatchSize:     defaults.BatchSize,
+		FlushInterval: defaults.FlushInterval,
+		QueueSize:     defaults.QueueSize,
+		MaxRetries:    defaults.MaxRetries,
+		Timeout:       defaults.Timeout,
+	}
+}
+
+// Validate validates log shipping configuration
+func (c *LogShipConfig) Validate() error {
+	if c.URL == (url.URL{}) {
+		return fmt.Errorf("log ship url is required for network outputs")
+	}
+	if !isHTTPURL(c.URL) {
+		return fmt.Errorf("log ship url %q must be an http or https URL", c.URL.String())
+	}
+	if c.BatchSize <= 0 || c.QueueSize <= 0 {
+		return fmt.Errorf("log ship batch_size and queue_size must be positive")
+	}
+	if c.FlushInterval <= 0 || c.Timeout <= 0 {
+		return fmt.Errorf("log ship flush_interval and timeout must be positive")
+	}
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("log ship max_retries must be non-negative")
+	}
+	return nil
+}
+
+// LogAsyncConfig moves writing log entries off the request path. Entries
+// are dropped rather than waited for when the buffer is full.
+type LogAsyncConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"LOG_ASYNC_ENABLED"`
+	// Capacity is how many entries the buffer holds
+	Capacity int `yaml:"capacity" mapstructure:"capacity" env:"LOG_ASYNC_CAPACITY"`
+}
+
+// Validate validates async logging configuration
+func (c *LogAsyncConfig) Validate() error {
+	if c.Enabled && c.Capacity <= 0 {
+		return fmt.Errorf("log async capacity must be positive")
+	}
+	return nil
+}
+
+// LogSamplingConfig limits how often noisy info and debug messages are
+// logged; warnings and errors are always logged. The zero value logs
+// everything.
+type LogSamplingConfig struct {
+	// MessageRate is how many entries per second each message may log;
+	// 0 disables rate limiting
+	MessageRate int `yaml:"message_rate" mapstructure:"message_rate" env:"LOG_SAMPLING_MESSAGE_RATE"`
+	// Burst is how many entries of a message may be logged at once before
+	// the rate applies; 0 means message_rate
+	Burst int `yaml:"burst" mapstructure:"burst" env:"LOG_SAMPLING_BURST"`
+	// DebugPercent is the share of debug entries kept; 0 or 100 keeps all
+	DebugPercent int `yaml:"debug_percent" mapstructure:"debug_percent" env:"LOG_SAMPLING_DEBUG_PERCENT"`
+	// Messages override the rate of individual messages
+	Messages []LogMessageRateConfig `yaml:"messages" mapstructure:"messages"`
+}
+
+// LogMessageRateConfig sets the rate of one message; 0 leaves it unlimited
+type LogMessageRateConfig struct {
+	Message string `yaml:"message" mapstructure:"message"`
+	Rate    int    `yaml:"rate" mapstructure:"rate"`
+}
+
+// Validate validates log sampling configuration
+func (c *LogSamplingConfig) Validate() error {
+	if c.MessageRate < 0 {
+		return fmt.Errorf("log sampling message_rate must be non-negative")
+	}
+	if c.Burst < 0 {
+		return fmt.Errorf("log sampling burst must be non-negative")
+	}
+	if c.DebugPercent < 0 || c.DebugPercent > 100 {
+		return fmt.Errorf("log sampling debug_percent must be between 0 and 100")
+	}
+	for i, m := range c.Messages {
+		if m.Message == "" {
+			return fmt.Errorf("log sampling messages[%d] message is required", i)
+		}
+		if m.Rate < 0 {
+			return fmt.Errorf("log sampling messages[%d] rate must be non-negative", i)
+		}
+	}
+	return nil
+}
+
+// LoggerConfig returns the sampling configuration of pkg/logger
+func (c *LogSamplingConfig) LoggerConfig() logger.SamplingConfig {
+	sampling := logger.SamplingConfig{
+		MessageRate:  c.MessageRate,
+		Burst:        c.Burst,
+		DebugPercent: c.DebugPercent,
+	}
+	if len(c.Messages) > 0 {
+		sampling.Messages = make(map[string]int, len(c.Messages))
+		for _, m := range c.Messages {
+			sampling.Messages[m.Message] = m.Rate
+		}
+	}
+	return sampling
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// MessagingConfig represents the external message broker that domain events
+// are published to
+type MessagingConfig struct {
+	Enabled        bool          `yaml:"enabled" mapstructure:"enabled" env:"MESSAGING_ENABLED"`
+	Provider       string        `yaml:"provider" mapstructure:"provider" env:"MESSAGING_PROVIDER"`
+	URL            url.URL       `yaml:"url" mapstructure:"url" env:"MESSAGING_URL"`
+	TopicPrefix    string        `yaml:"topic_prefix" mapstructure:"topic_prefix" env:"MESSAGING_TOPIC_PREFIX"`
+	ClientName     string        `yaml:"client_name" mapstructure:"client_name" env:"MESSAGING_CLIENT_NAME"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout" mapstructure:"connect_timeout" env:"MESSAGING_CONNECT_TIMEOUT"`
+}
+
+// DefaultMessagingConfig returns default messaging configuration
+func DefaultMessagingConfig() *MessagingConfig {
+	return &MessagingConfig{
+		Enabled:        false,
+		Provider:       "nats",
+		URL:            mustParseURL("nats://localhost:4222"),
+		TopicPrefix:    "wonder",
+		ClientName:     "wonder",
+		ConnectTimeout: 5 * time.Second,
+	}
+}
+
+// Validate validates messaging configuration
+func (c *MessagingConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Provider {
+	case "nats":
+		if c.URL == (url.URL{}) {
+			return fmt.Errorf("messaging url is required for provider nats")
+		}
+	case "memory":
+	default:
+		return fmt.Errorf("messaging provider must be one of: nats, memory")
+	}
+	if c.ConnectTimeout < 0 {
+		return fmt.Errorf("messaging connect_timeout must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package config
+
+// NotificationsConfig represents notifications raised by user events.
+// Users choose their channels in their preferences; email is only sent
+// when email is configured.
+type NotificationsConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"NOTIFICATIONS_ENABLED"`
+	// Email allows the email channel; when false notifications are only
+	// stored for the app whatever users choose
+	Email bool `yaml:"email" mapstructure:"email" env:"NOTIFICATIONS_EMAIL"`
+}
+
+// DefaultNotificationsConfig returns default notification configuration
+func DefaultNotificationsConfig() *NotificationsConfig {
+	return &NotificationsConfig{
+		Enabled: true,
+		Email:   true,
+	}
+}
+
+// Validate validates notification configuration
+func (c *NotificationsConfig) Validate() error {
+	return nil
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/subosito/gotenv"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultEnvFile is the .env file read from the working directory when it
+// exists and no other file is named
+const DefaultEnvFile = ".env"
+
+// DefaultEnvFiles returns DefaultEnvFile when it exists, so callers can
+// pass it as LoadOptions.EnvFiles without failing when it does not
+func DefaultEnvFiles() []string {
+	if _, err := os.Stat(DefaultEnvFile); err != nil {
+		return nil
+	}
+	return []string{DefaultEnvFile}
+}
+
+// defaultConfigPaths are searched for the config file when no path is given
+var defaultConfigPaths = []string{".", "./configs", "./config", "/etc/wonder"}
+
+// LoadOptions selects where configuration is read from. Sources are merged
+// in this order, each overriding the ones before it:
+//
+//  1. defaults (DefaultConfig)
+//  2. the document at Remote
+//  3. the config file: config.yaml, or config.<Environment>.yaml
+//  4. variables from EnvFiles that are not set in the environment
+//  5. environment variables
+//  6. flags in Flags that were set on the command line
+type LoadOptions struct {
+	// Environment selects config.<Environment>.yaml and overrides
+	// app.environment
+	Environment string
+	// ConfigPath is a config file, or a directory searched for one; empty
+	// searches ., ./configs, ./config and /etc/wonder
+	ConfigPath string
+	// EnvFiles are .env files, read in order; each must exist
+	EnvFiles []string
+	// Flags holds flags registered with RegisterFlags
+	Flags *pflag.FlagSet
+	// Remote is a YAML document in a key/value store
+	Remote *RemoteSource
+}
+
+// Load loads, resolves and validates the configuration selected by opts
+func (l *Loader) Load(opts LoadOptions) (*Config, error) {
+	config, err := l.read(opts)
+	if err != nil {
+		return nil, err
+	}
+	return l.complete(opts.Environment, config)
+}
+
+// read merges the sources selected by opts without resolving secrets or
+// validating
+func (l *Loader) read(opts LoadOptions) (*Config, error) {
+	configPaths, configFile := defaultConfigPaths, ""
+	if opts.ConfigPath != "" {
+		// Unlike LoadConfig, a path that does not exist is a mistake here
+		info, err := os.Stat(opts.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config path: %w", err)
+		}
+		if info.IsDir() {
+			configPaths = []string{opts.ConfigPath}
+		} else {
+			configFile = opts.ConfigPath
+		}
+	}
+
+	if opts.Environment != "" {
+		l.setupViperForEnvironment(opts.Environment, configPaths)
+	} else {
+		l.setupViper(configPaths)
+	}
+	if configFile != "" {
+		l.viper.SetConfigFile(configFile)
+	}
+
+	if len(opts.EnvFiles) > 0 {
+		// Variables already in the environment win over the files
+		if err := gotenv.Load(opts.EnvFiles...); err != nil {
+			return nil, fmt.Errorf("failed to read env file: %w", err)
+		}
+	}
+	if opts.Remote != nil {
+		if err := l.applyRemote(opts.Remote); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Flags != nil {
+		if err := l.bindFlags(opts.Flags); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.readConfig(opts.Environment)
+}
+
+// complete resolves secret references in config, validates it and makes it
+// the current configuration
+func (l *Loader) complete(env string, config *Config) (*Config, error) {
+	if err := l.resolveSecrets(config); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	l.setCurrent(env, config)
+	return config, nil
+}
+
+// configKeyAnnotation marks flags registered by RegisterFlags with the
+// configuration key they set
+const configKeyAnnotation = "config_key"
+
+// RegisterFlags adds a flag named after each configuration key, such as
+// --server.port or --log.level, to flags. Only flags set on the command
+// line override other sources. Lists take comma-separated values; list
+// sections such as server.routes have no flags.
+func RegisterFlags(flags *pflag.FlagSet) {
+	walkValues(reflect.ValueOf(DefaultConfig()).Elem(), "", func(key string, v reflect.Value) {
+		if strings.Contains(key, "[") || flags.Lookup(key) != nil {
+			return
+		}
+		usage := "Overrides " + key
+		switch {
+		case v.Type() == durationType:
+			flags.Duration(key, time.Duration(v.Int()), usage)
+		case v.Type() == byteSizeType:
+			flags.String(key, v.Interface().(ByteSize).String(), usage)
+		case v.Kind() == reflect.String:
+			flags.String(key, v.String(), usage)
+		case v.Kind() == reflect.Bool:
+			flags.Bool(key, v.Bool(), usage)
+		case v.CanInt():
+			flags.Int64(key, v.Int(), usage)
+		case v.CanUint():
+			flags.Uint64(key, v.Uint(), usage)
+		case v.CanFloat():
+			flags.Float64(key, v.Float(), usage)
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+			flags.StringSlice(key, v.Interface().([]string), usage)
+		default:
+			return
+		}
+		_ = flags.SetAnnotation(key, configKeyAnnotation, []string{key})
+	})
+}
+
+// bindFlags binds the flags registered by RegisterFlags to their keys
+func (l *Loader) bindFlags(flags *pflag.FlagSet) error {
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		keys := f.Annotations[configKeyAnnotation]
+		if len(keys) == 0 || err != nil {
+			return
+		}
+		err = l.viper.BindPFlag(keys[0], f)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to bind flags: %w", err)
+	}
+	return nil
+}
+
+// Remote configuration providers
+const (
+	RemoteProviderEtcd   = "etcd"
+	RemoteProviderConsul = "consul"
+)
+
+// defaultRemoteTimeout bounds fetching the remote document
+const defaultRemoteTimeout = 5 * time.Second
+
+// RemoteSource is a YAML configuration document stored under Key in etcd
+// or in Consul's key/value store
+type RemoteSource struct {
+	Provider  string
+	Endpoints []string
+	Key       string
+	Timeout   time.Duration
+
+	// read replaces the store lookup in tests
+	read func(ctx context.Context) ([]byte, error)
+}
+
+// ParseRemoteSource parses a source written as
+// etcd://host1:2379,host2:2379/wonder/config or
+// consul://localhost:8500/wonder/config. Consul takes one agent address;
+// its ACL token comes from CONSUL_HTTP_TOKEN.
+func ParseRemoteSource(raw string) (*RemoteSource, error) {
+	u, err := parseURL(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote config: %w", err)
+	}
+	if u.Scheme != RemoteProviderEtcd && u.Scheme != RemoteProviderConsul {
+		return nil, fmt.Errorf("remote config provider %q is not supported, use etcd:// or consul://", u.Scheme)
+	}
+	if u.Path == "" || u.Path == "/" {
+		return nil, fmt.Errorf("remote config %q must name endpoints and a key, e.g. %s://localhost:2379/wonder/config", raw, u.Scheme)
+	}
+
+	source := &RemoteSource{Provider: u.Scheme, Key: u.Path, Timeout: defaultRemoteTimeout}
+	hosts := strings.Split(u.Host, ",")
+	if u.Scheme == RemoteProviderConsul {
+		if len(hosts) > 1 {
+			return nil, fmt.Errorf("remote config %q must name one Consul agent", raw)
+		}
+		// Consul keys have no leading slash
+		source.Key = strings.TrimPrefix(u.Path, "/")
+	}
+	for _, host := range hosts {
+		source.Endpoints = append(source.Endpoints, "http://"+host)
+	}
+	return source, nil
+}
+
+// fetch returns the document stored under the source's key
+func (r *RemoteSource) fetch(ctx context.Context) ([]byte, error) {
+	if r.read != nil {
+		return r.read(ctx)
+	}
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultRemoteTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if r.Provider == RemoteProviderConsul {
+		return r.fetchConsul(ctx)
+	}
+	return r.fetchEtcd(ctx, timeout)
+}
+
+func (r *RemoteSource) fetchEtcd(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	client, err := clientv3.New(clientv3.Config{Endpoints: r.Endpoints, DialTimeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	resp, err := client.Get(ctx, r.Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("key %s not found", r.Key)
+	}
+	return resp.Kvs[0].Value, nil
+}
+
+func (r *RemoteSource) fetchConsul(ctx context.Context) ([]byte, error) {
+	// DefaultConfig reads CONSUL_HTTP_TOKEN and the other CONSUL_* variables
+	cfg := consulapi.DefaultConfig()
+	if len(r.Endpoints) > 0 {
+		cfg.Address = r.Endpoints[0]
+	}
+	client, err := consulapi.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pair, _, err := client.KV().Get(r.Key, (&consulapi.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, fmt.Errorf("key %s not found", r.Key)
+	}
+	return pair.Value, nil
+}
+
+// applyRemote reads the remote document and applies its values as
+// defaults. That keeps them below the config file, also when the file is
+// re-read on change.
+func (l *Loader) applyRemote(source *RemoteSource) error {
+	doc, err := source.fetch(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to read remote config %s: %w", source.Key, err)
+	}
+	remote := viper.New()
+	remote.SetConfigType("yaml")
+	if err := remote.ReadConfig(bytes.NewReader(doc)); err != nil {
+		return fmt.Errorf("failed to parse remote config: %w", err)
+	}
+	for _, key := range remote.AllKeys() {
+		l.viper.SetDefault(key, remote.Get(key))
+	}
+	return nil
+}
+
+// LoadWithOptions loads configuration with the global loader
+func LoadWithOptions(opts LoadOptions) (*Config, error) {
+	if globalLoader == nil {
+		globalLoader = NewLoader()
+	}
+	return globalLoader.Load(opts)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// OrganizationsConfig represents organizations: teams of users that an
+// owner invites members to
+type OrganizationsConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"ORGANIZATIONS_ENABLED"`
+	// InviteTTL is how long an invitation link can be accepted
+	InviteTTL time.Duration `yaml:"invite_ttl" mapstructure:"invite_ttl" env:"ORGANIZATIONS_INVITE_TTL"`
+	// InviteURL is the page invitation links open, given the token as a
+	// query parameter; empty means /invite under the email app_url
+	InviteURL url.URL `yaml:"invite_url" mapstructure:"invite_url" env:"ORGANIZATIONS_INVITE_URL"`
+}
+
+// DefaultOrganizationsConfig returns default organization configuration
+func DefaultOrganizationsConfig() *OrganizationsConfig {
+	return &OrganizationsConfig{
+		Enabled:   true,
+		InviteTTL: 7 * 24 * time.Hour,
+	}
+}
+
+// Validate validates organization configuration
+func (c *OrganizationsConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.InviteTTL <= 0 {
+		return fmt.Errorf("organizations invite_ttl must be positive")
+	}
+	if c.InviteURL != (url.URL{}) && !isHTTPURL(c.InviteURL) {
+		return fmt.Errorf("organizations invite_url %q must be an http or https URL", c.InviteURL.String())
+	}
+	return nil
+}
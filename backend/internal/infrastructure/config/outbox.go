@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// OutboxConfig represents the transactional outbox and its relay worker
+type OutboxConfig struct {
+	Enabled      bool          `yaml:"enabled" mapstructure:"enabled" env:"OUTBOX_ENABLED"`
+	PollInterval time.Duration `yaml:"poll_interval" mapstructure:"poll_interval" env:"OUTBOX_POLL_INTERVAL"`
+	BatchSize    int           `yaml:"batch_size" mapstructure:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+	MaxAttempts  int           `yaml:"max_attempts" mapstructure:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" mapstructure:"base_backoff" env:"OUTBOX_BASE_BACKOFF"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" mapstructure:"max_backoff" env:"OUTBOX_MAX_BACKOFF"`
+}
+
+// DefaultOutboxConfig returns default outbox configuration
+func DefaultOutboxConfig() *OutboxConfig {
+	return &OutboxConfig{
+		Enabled:      false,
+		PollInterval: time.Second,
+		BatchSize:    100,
+		MaxAttempts:  10,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   5 * time.Minute,
+	}
+}
+
+// Validate validates outbox configuration
+func (c *OutboxConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("outbox poll_interval must be positive")
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("outbox batch_size must be positive")
+	}
+	if c.MaxAttempts <= 0 {
+		return fmt.Errorf("outbox max_attempts must be positive")
+	}
+	if c.BaseBackoff <= 0 || c.MaxBackoff < c.BaseBackoff {
+		return fmt.Errorf("outbox backoff must satisfy 0 < base_backoff <= max_backoff")
+	}
+	return nil
+}
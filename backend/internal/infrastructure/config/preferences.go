@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// PreferencesConfig represents per-user preference limits and caching
+type PreferencesConfig struct {
+	// MaxValueBytes limits the size of one preference value as compact
+	// JSON
+	MaxValueBytes ByteSize `yaml:"max_value_bytes" mapstructure:"max_value_bytes" env:"PREFERENCES_MAX_VALUE_BYTES"`
+	// MaxKeys is how many preferences a user may have
+	MaxKeys int `yaml:"max_keys" mapstructure:"max_keys" env:"PREFERENCES_MAX_KEYS"`
+	// CacheTTL keeps each user's preferences in Redis for this long when
+	// external.redis is enabled; writes invalidate them sooner. Zero
+	// disables caching.
+	CacheTTL time.Duration `yaml:"cache_ttl" mapstructure:"cache_ttl" env:"PREFERENCES_CACHE_TTL"`
+}
+
+// DefaultPreferencesConfig returns default preference configuration
+func DefaultPreferencesConfig() *PreferencesConfig {
+	return &PreferencesConfig{
+		MaxValueBytes: 4 * Kilobyte,
+		MaxKeys:       100,
+		CacheTTL:      10 * time.Minute,
+	}
+}
+
+// Validate validates preference configuration
+func (c *PreferencesConfig) Validate() error {
+	if c.MaxValueBytes <= 0 {
+		return fmt.Errorf("preferences max_value_bytes must be positive")
+	}
+	// Values are stored in a TEXT column
+	if c.MaxValueBytes > 64*Kilobyte-1 {
+		return fmt.Errorf("preferences max_value_bytes must be below 64KiB")
+	}
+	if c.MaxKeys <= 0 {
+		return fmt.Errorf("preferences max_keys must be positive")
+	}
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("preferences cache_ttl must not be negative")
+	}
+	return nil
+}
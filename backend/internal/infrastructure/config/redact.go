@@ -0,0 +1,111 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// redactTag marks fields whose values are secret, such as passwords,
+// signing keys, tokens and DSNs: `redact:"true"`
+const redactTag = "redact"
+
+// Redacted returns a copy of c that is safe to print or log: values of
+// fields tagged redact:"true" are replaced with ******, and passwords
+// embedded in URLs are masked
+func (c *Config) Redacted() *Config {
+	out := reflect.New(reflect.TypeOf(*c))
+	deepCopy(out.Elem(), reflect.ValueOf(c).Elem())
+	redactValue(out.Elem(), false)
+	return out.Interface().(*Config)
+}
+
+// String formats the redacted configuration as key=value pairs sorted by
+// key, so printing a Config never reveals secrets
+func (c *Config) String() string {
+	settings := maskedSettings(c, nil)
+	pairs := make([]string, len(settings))
+	for i, s := range settings {
+		value := s.Value
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		pairs[i] = s.Key + "=" + value
+	}
+	return strings.Join(pairs, " ")
+}
+
+// redactValue masks the strings reachable from v in place: all of them
+// when secret, otherwise those under tagged fields. URLs keep all but their
+// password.
+func redactValue(v reflect.Value, secret bool) {
+	if v.Type() == urlType {
+		u := v.Addr().Interface().(*url.URL)
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), maskedValue)
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			redactValue(v.Elem(), secret)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() {
+				redactValue(v.Field(i), secret || f.Tag.Get(redactTag) == "true")
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			redactValue(v.Index(i), secret)
+		}
+	case reflect.String:
+		if secret && v.String() != "" {
+			v.SetString(maskedValue)
+		}
+	}
+}
+
+// deepCopy copies src to dst without sharing pointers, slices or maps
+func deepCopy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		deepCopy(dst.Elem(), src.Elem())
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).IsExported() {
+				deepCopy(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			value := reflect.New(iter.Value().Type()).Elem()
+			deepCopy(value, iter.Value())
+			dst.SetMapIndex(iter.Key(), value)
+		}
+	default:
+		dst.Set(src)
+	}
+}
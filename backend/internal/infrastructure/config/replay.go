@@ -0,0 +1,39 @@
+package config
+
+import "fmt"
+
+// ReplayConfig represents failed-request capture configuration used by the
+// replay tooling
+type ReplayConfig struct {
+	Enabled      bool     `yaml:"enabled" mapstructure:"enabled" env:"REPLAY_ENABLED"`
+	Dir          string   `yaml:"dir" mapstructure:"dir" env:"REPLAY_DIR"`
+	MinStatus    int      `yaml:"min_status" mapstructure:"min_status" env:"REPLAY_MIN_STATUS"`
+	MaxBodyBytes ByteSize `yaml:"max_body_bytes" mapstructure:"max_body_bytes" env:"REPLAY_MAX_BODY_BYTES"`
+}
+
+// DefaultReplayConfig returns default replay capture configuration
+func DefaultReplayConfig() *ReplayConfig {
+	return &ReplayConfig{
+		Enabled:      false,
+		Dir:          "replay",
+		MinStatus:    500,
+		MaxBodyBytes: 64 * 1024,
+	}
+}
+
+// Validate validates replay capture configuration
+func (c *ReplayConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Dir == "" {
+		return fmt.Errorf("replay dir is required when enabled")
+	}
+	if c.MinStatus < 400 || c.MinStatus > 599 {
+		return fmt.Errorf("replay min_status must be between 400 and 599")
+	}
+	if c.MaxBodyBytes <= 0 {
+		return fmt.Errorf("replay max_body_bytes must be positive")
+	}
+	return nil
+}
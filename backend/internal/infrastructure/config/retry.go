@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetryConfig represents the retry policy for transient database and
+// external service failures
+type RetryConfig struct {
+	Enabled     bool          `yaml:"enabled" mapstructure:"enabled" env:"RETRY_ENABLED"`
+	MaxAttempts int           `yaml:"max_attempts" mapstructure:"max_attempts" env:"RETRY_MAX_ATTEMPTS"`
+	BaseDelay   time.Duration `yaml:"base_delay" mapstructure:"base_delay" env:"RETRY_BASE_DELAY"`
+	MaxDelay    time.Duration `yaml:"max_delay" mapstructure:"max_delay" env:"RETRY_MAX_DELAY"`
+	// Jitter randomizes each delay by up to this fraction (0 to 1)
+	Jitter float64 `yaml:"jitter" mapstructure:"jitter" env:"RETRY_JITTER"`
+}
+
+// DefaultRetryConfig returns default retry configuration
+func DefaultRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		Enabled:     true,
+		MaxAttempts: 3,
+		BaseDelay:   50 * time.Millisecond,
+		MaxDelay:    time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// Validate validates retry configuration
+func (c *RetryConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("retry max_attempts must be at least 1")
+	}
+	if c.BaseDelay <= 0 || c.MaxDelay < c.BaseDelay {
+		return fmt.Errorf("retry delays must satisfy 0 < base_delay <= max_delay")
+	}
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return fmt.Errorf("retry jitter must be between 0 and 1")
+	}
+	return nil
+}
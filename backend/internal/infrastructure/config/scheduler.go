@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/cron"
+)
+
+// SchedulerConfig represents the periodic maintenance tasks. An empty
+// schedule disables its task.
+type SchedulerConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"SCHEDULER_ENABLED"`
+	// Timezone is the IANA zone schedules are evaluated in
+	Timezone string `yaml:"timezone" mapstructure:"timezone" env:"SCHEDULER_TIMEZONE"`
+	// AuditRetentionSchedule deletes audit entries older than AuditRetention
+	AuditRetentionSchedule string        `yaml:"audit_retention_schedule" mapstructure:"audit_retention_schedule" env:"SCHEDULER_AUDIT_RETENTION_SCHEDULE"`
+	AuditRetention         time.Duration `yaml:"audit_retention" mapstructure:"audit_retention" env:"SCHEDULER_AUDIT_RETENTION"`
+	// OutboxCleanupSchedule deletes outbox messages published longer than
+	// OutboxRetention ago
+	OutboxCleanupSchedule string        `yaml:"outbox_cleanup_schedule" mapstructure:"outbox_cleanup_schedule" env:"SCHEDULER_OUTBOX_CLEANUP_SCHEDULE"`
+	OutboxRetention       time.Duration `yaml:"outbox_retention" mapstructure:"outbox_retention" env:"SCHEDULER_OUTBOX_RETENTION"`
+	// AccountPurgeSchedule deletes accounts whose deletion grace period
+	// (account.deletion_grace_period) has passed, expired data exports and
+	// trusted devices. It runs even when the scheduler is disabled, since
+	// users were promised these deletions.
+	AccountPurgeSchedule string `yaml:"account_purge_schedule" mapstructure:"account_purge_schedule" env:"SCHEDULER_ACCOUNT_PURGE_SCHEDULE"`
+}
+
+// DefaultSchedulerConfig returns default scheduler configuration
+func DefaultSchedulerConfig() *SchedulerConfig {
+	return &SchedulerConfig{
+		Enabled:                false,
+		Timezone:               "UTC",
+		AuditRetentionSchedule: "0 3 * * *",
+		AuditRetention:         90 * 24 * time.Hour,
+		OutboxCleanupSchedule:  "30 3 * * *",
+		OutboxRetention:        7 * 24 * time.Hour,
+		AccountPurgeSchedule:   "0 4 * * *",
+	}
+}
+
+// Validate validates scheduler configuration. The account purge runs even
+// when the scheduler is disabled, so its schedule is always checked.
+func (c *SchedulerConfig) Validate() error {
+	if _, err := c.Location(); err != nil {
+		return fmt.Errorf("scheduler timezone %q is invalid: %w", c.Timezone, err)
+	}
+	if c.AccountPurgeSchedule != "" {
+		if _, err := cron.Parse(c.AccountPurgeSchedule); err != nil {
+			return fmt.Errorf("scheduler account_purge_schedule is invalid: %w", err)
+		}
+	}
+	if !c.Enabled {
+		return nil
+	}
+
+	for _, task := range []struct {
+		name      string
+		schedule  string
+		retention time.Duration
+	}{
+		{"audit_retention", c.AuditRetentionSchedule, c.AuditRetention},
+		{"outbox_cleanup", c.OutboxCleanupSchedule, c.OutboxRetention},
+	} {
+		if task.schedule == "" {
+			continue
+		}
+		if _, err := cron.Parse(task.schedule); err != nil {
+			return fmt.Errorf("scheduler %s_schedule is invalid: %w", task.name, err)
+		}
+		if task.retention <= 0 {
+			return fmt.Errorf("scheduler %s needs a positive retention", task.name)
+		}
+	}
+	return nil
+}
+
+// Location returns the time zone schedules are evaluated in
+func (c *SchedulerConfig) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(c.Timezone)
+}
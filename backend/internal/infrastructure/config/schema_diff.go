@@ -0,0 +1,230 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// DefaultEnvironments are the environments with a config file in ./configs
+var DefaultEnvironments = []string{"development", "testing", "production"}
+
+// Kinds of SchemaIssue
+const (
+	// IssueUnknownKey is a key that no Config field reads, usually a typo
+	// or a setting that was removed
+	IssueUnknownKey = "unknown_key"
+	// IssueTypeMismatch is a value that cannot be decoded into its field
+	IssueTypeMismatch = "type_mismatch"
+	// IssueMissingOverride is a key that some environments set and others
+	// leave at the default
+	IssueMissingOverride = "missing_override"
+)
+
+// placeholderPattern matches values filled in from the environment at
+// deploy time, such as "${DB_PORT}", whose type cannot be checked
+var placeholderPattern = regexp.MustCompile(`^\$\{[A-Za-z_][A-Za-z0-9_]*\}$`)
+
+// SchemaIssue is one difference between an environment's config file and
+// the Config struct or the other environments
+type SchemaIssue struct {
+	Kind        string
+	Environment string
+	Key         string
+	Detail      string
+}
+
+// SchemaReport compares the config files of several environments
+type SchemaReport struct {
+	// Files maps each environment to the file that was read
+	Files  map[string]string
+	Issues []SchemaIssue
+}
+
+// Drifted reports whether any file has unknown keys or values of the wrong
+// type. With strict, keys missing from some environments count as well.
+func (r *SchemaReport) Drifted(strict bool) bool {
+	for _, issue := range r.Issues {
+		if strict || issue.Kind != IssueMissingOverride {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the issues grouped by kind
+func (r *SchemaReport) Print(w io.Writer) {
+	envs := make([]string, 0, len(r.Files))
+	for env := range r.Files {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	for _, env := range envs {
+		fmt.Fprintf(w, "%-12s %s\n", env, r.Files[env])
+	}
+	if len(r.Issues) == 0 {
+		fmt.Fprintln(w, "\nno differences from the Config schema")
+		return
+	}
+
+	for _, kind := range []string{IssueUnknownKey, IssueTypeMismatch, IssueMissingOverride} {
+		var lines []string
+		for _, issue := range r.Issues {
+			if issue.Kind != kind {
+				continue
+			}
+			line := issue.Key
+			if issue.Environment != "" {
+				line = issue.Environment + ": " + line
+			}
+			if issue.Detail != "" {
+				line += ": " + issue.Detail
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s (%d):\n", strings.ReplaceAll(kind, "_", " "), len(lines))
+		for _, line := range lines {
+			fmt.Fprintf(w, "  - %s\n", line)
+		}
+	}
+}
+
+// DiffEnvironments reads config.<env>.yaml from dir for each environment
+// and checks every key against the Config struct: keys no field reads,
+// values that do not decode into their field's type, and keys set in some
+// environments but not in others. Values written as ${VAR} are filled in
+// at deploy time and are not type checked.
+func DiffEnvironments(dir string, envs []string) (*SchemaReport, error) {
+	if len(envs) == 0 {
+		envs = DefaultEnvironments
+	}
+	schema := schemaTypes()
+	report := &SchemaReport{Files: make(map[string]string, len(envs))}
+	setIn := make(map[string][]string)
+
+	for _, env := range envs {
+		file := filepath.Join(dir, fmt.Sprintf("config.%s.yaml", env))
+		v := viper.New()
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		report.Files[env] = file
+
+		seen := make(map[string]bool)
+		for _, key := range v.AllKeys() {
+			field, typ, ok := lookupSchema(schema, key)
+			if !ok {
+				report.Issues = append(report.Issues, SchemaIssue{Kind: IssueUnknownKey, Environment: env, Key: key})
+				continue
+			}
+			if !seen[field] {
+				seen[field] = true
+				setIn[field] = append(setIn[field], env)
+			}
+			if field != key {
+				// An entry of a map; the map is checked as a whole
+				continue
+			}
+			if err := checkType(v.Get(key), typ); err != nil {
+				report.Issues = append(report.Issues, SchemaIssue{Kind: IssueTypeMismatch, Environment: env, Key: key, Detail: err.Error()})
+			}
+		}
+	}
+
+	for key, set := range setIn {
+		if len(set) == len(envs) {
+			continue
+		}
+		var missing []string
+		for _, env := range envs {
+			if !slices.Contains(set, env) {
+				missing = append(missing, env)
+			}
+		}
+		report.Issues = append(report.Issues, SchemaIssue{
+			Kind:   IssueMissingOverride,
+			Key:    key,
+			Detail: fmt.Sprintf("set in %s, not in %s", strings.Join(set, ", "), strings.Join(missing, ", ")),
+		})
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Environment < b.Environment
+	})
+	return report, nil
+}
+
+// schemaTypes maps every configuration key to its field type. Lists of
+// sections and maps are single keys.
+func schemaTypes() map[string]reflect.Type {
+	schema := make(map[string]reflect.Type)
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || isURLSetting(t) {
+			schema[prefix] = t
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() {
+				walk(f.Type, fieldKey(f, prefix))
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return schema
+}
+
+// lookupSchema finds the field that reads key. Keys below a map field
+// resolve to the map.
+func lookupSchema(schema map[string]reflect.Type, key string) (string, reflect.Type, bool) {
+	if t, ok := schema[key]; ok {
+		return key, t, true
+	}
+	for prefix := key; strings.Contains(prefix, "."); {
+		prefix = prefix[:strings.LastIndex(prefix, ".")]
+		if t, ok := schema[prefix]; ok && t.Kind() == reflect.Map {
+			return prefix, t, true
+		}
+	}
+	return "", nil, false
+}
+
+// checkType decodes value into a new value of typ the way the loader does.
+// Fields of list entries that typ does not have are reported too.
+func checkType(value any, typ reflect.Type) error {
+	if s, ok := value.(string); ok && placeholderPattern.MatchString(s) {
+		return nil
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       decodeHook(),
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+		Result:           reflect.New(typ).Interface(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("%s", strings.TrimPrefix(describeDecodeError(err).Error(), "invalid config values:\n  "))
+	}
+	return nil
+}
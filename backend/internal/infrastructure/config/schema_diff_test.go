@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffEnvironments(t *testing.T) {
+	dir := t.TempDir()
+	write := func(env, body string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config."+env+".yaml"), []byte(body), 0600))
+	}
+	write("development", `
+server:
+  port: 8080
+  read_timout: 10s
+  max_body_bytes: "2MB"
+log:
+  level: debug
+  ship:
+    labels:
+      team: platform
+`)
+	write("testing", `
+server:
+  port: "eighty"
+  max_body_bytes: "lots"
+  routes:
+    - route: "POST /upload"
+      max_body_byte: 10
+log:
+  level: info
+`)
+	write("production", `
+server:
+  port: "${SERVER_PORT}"
+  read_timeout: 30
+log:
+  level: info
+  ship:
+    labels:
+      team: platform
+`)
+
+	report, err := DiffEnvironments(dir, nil)
+	require.NoError(t, err)
+
+	issues := make(map[string]SchemaIssue)
+	for _, issue := range report.Issues {
+		issues[issue.Kind+" "+issue.Environment+" "+issue.Key] = issue
+	}
+	has := func(kind, env, key string) SchemaIssue {
+		t.Helper()
+		issue, ok := issues[kind+" "+env+" "+key]
+		assert.True(t, ok, "expected %s for %s in %s", kind, key, env)
+		return issue
+	}
+
+	has(IssueUnknownKey, "development", "server.read_timout")
+	assert.Contains(t, has(IssueTypeMismatch, "testing", "server.port").Detail, `cannot parse "eighty" as int`)
+	assert.Contains(t, has(IssueTypeMismatch, "testing", "server.max_body_bytes").Detail, `invalid size "lots"`)
+	assert.Contains(t, has(IssueTypeMismatch, "testing", "server.routes").Detail, "max_body_byte")
+	assert.Contains(t, has(IssueTypeMismatch, "production", "server.read_timeout").Detail, "has no unit")
+	assert.Contains(t, has(IssueMissingOverride, "", "log.ship.labels").Detail, "not in testing")
+	assert.Contains(t, has(IssueMissingOverride, "", "server.max_body_bytes").Detail, "not in production")
+
+	_, placeholderChecked := issues[IssueTypeMismatch+" production server.port"]
+	assert.False(t, placeholderChecked, "${VAR} placeholders are not type checked")
+	_, levelMissing := issues[IssueMissingOverride+" log.level"]
+	assert.False(t, levelMissing, "keys set everywhere are not reported")
+
+	assert.True(t, report.Drifted(false))
+	var out bytes.Buffer
+	report.Print(&out)
+	assert.Contains(t, out.String(), "unknown key (1):")
+	assert.Contains(t, out.String(), "development: server.read_timout")
+}
+
+func TestDiffEnvironments_Clean(t *testing.T) {
+	dir := t.TempDir()
+	for _, env := range []string{"staging", "production"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config."+env+".yaml"), []byte("log:\n  level: info\n"), 0600))
+	}
+
+	report, err := DiffEnvironments(dir, []string{"staging", "production"})
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.False(t, report.Drifted(true))
+
+	_, err = DiffEnvironments(dir, []string{"qa"})
+	assert.ErrorContains(t, err, "config.qa.yaml")
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Search backends
+const (
+	SearchBackendDatabase      = "database"
+	SearchBackendElasticsearch = "elasticsearch"
+)
+
+// searchIndexPattern accepts names Elasticsearch and OpenSearch both allow
+var searchIndexPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,199}$`)
+
+// SearchConfig represents the user search backend
+type SearchConfig struct {
+	// Backend is database, searching the users table, or elasticsearch,
+	// searching an index kept in sync through domain events. OpenSearch is
+	// supported as elasticsearch.
+	Backend string `yaml:"backend" mapstructure:"backend" env:"SEARCH_BACKEND"`
+	// Addresses are the cluster's node URLs, tried in order
+	Addresses []url.URL `yaml:"addresses" mapstructure:"addresses" env:"SEARCH_ADDRESSES"`
+	Username  string    `yaml:"username" mapstructure:"username" env:"SEARCH_USERNAME"`
+	Password  string    `yaml:"password" mapstructure:"password" env:"SEARCH_PASSWORD" redact:"true"`
+	// Index is the alias searches and updates go through; the documents
+	// live in an index named after it with a timestamp suffix
+	Index string `yaml:"index" mapstructure:"index" env:"SEARCH_INDEX"`
+	// Timeout bounds each request to the cluster
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout" env:"SEARCH_TIMEOUT"`
+	// ReindexBatchSize is how many users a reindex reads and sends at once
+	ReindexBatchSize int `yaml:"reindex_batch_size" mapstructure:"reindex_batch_size" env:"SEARCH_REINDEX_BATCH_SIZE"`
+}
+
+// DefaultSearchConfig returns default search configuration
+func DefaultSearchConfig() *SearchConfig {
+	return &SearchConfig{
+		Backend:          SearchBackendDatabase,
+		Index:            "wonder-users",
+		Timeout:          5 * time.Second,
+		ReindexBatchSize: 500,
+	}
+}
+
+// Elasticsearch reports whether users are searched in Elasticsearch
+func (c *SearchConfig) Elasticsearch() bool {
+	return c != nil && c.Backend == SearchBackendElasticsearch
+}
+
+// Validate validates search configuration
+func (c *SearchConfig) Validate() error {
+	switch c.Backend {
+	case SearchBackendDatabase:
+		return nil
+	case SearchBackendElasticsearch:
+	default:
+		return fmt.Errorf("search backend must be one of: %s, %s", SearchBackendDatabase, SearchBackendElasticsearch)
+	}
+
+	if len(c.Addresses) == 0 {
+		return fmt.Errorf("search addresses are required by the elasticsearch backend")
+	}
+	for _, address := range c.Addresses {
+		if !isHTTPURL(address) {
+			return fmt.Errorf("search address %q must be an http or https URL", address.String())
+		}
+	}
+	if !searchIndexPattern.MatchString(c.Index) {
+		return fmt.Errorf("search index %q must be lowercase letters, digits, '-' and '_', not starting with '-' or '_'", c.Index)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("search timeout must be positive")
+	}
+	if c.ReindexBatchSize <= 0 {
+		return fmt.Errorf("search reindex_batch_size must be positive")
+	}
+	return nil
+}
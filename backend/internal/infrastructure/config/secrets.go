@@ -0,0 +1,221 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/secrets"
+)
+
+// SecretsConfig configures the secret stores that configuration values may
+// reference, e.g. database.password: "vault:secret/data/wonder#db_password"
+type SecretsConfig struct {
+	// RefreshInterval re-fetches referenced secrets to pick up rotations; 0 disables
+	RefreshInterval time.Duration       `yaml:"refresh_interval" mapstructure:"refresh_interval" env:"SECRETS_REFRESH_INTERVAL"`
+	Timeout         time.Duration       `yaml:"timeout" mapstructure:"timeout" env:"SECRETS_TIMEOUT"`
+	Vault           *VaultSecretsConfig `yaml:"vault" mapstructure:"vault"`
+	AWS             *AWSSecretsConfig   `yaml:"aws" mapstructure:"aws"`
+}
+
+// VaultSecretsConfig configures HashiCorp Vault token access
+type VaultSecretsConfig struct {
+	Enabled   bool   `yaml:"enabled" mapstructure:"enabled" env:"SECRETS_VAULT_ENABLED"`
+	Address   string `yaml:"address" mapstructure:"address" env:"SECRETS_VAULT_ADDRESS"`
+	Token     string `yaml:"token" mapstructure:"token" env:"SECRETS_VAULT_TOKEN" redact:"true"`
+	Namespace string `yaml:"namespace" mapstructure:"namespace" env:"SECRETS_VAULT_NAMESPACE"`
+}
+
+// AWSSecretsConfig configures AWS Secrets Manager access with static credentials
+type AWSSecretsConfig struct {
+	Enabled         bool   `yaml:"enabled" mapstructure:"enabled" env:"SECRETS_AWS_ENABLED"`
+	Region          string `yaml:"region" mapstructure:"region" env:"SECRETS_AWS_REGION"`
+	AccessKeyID     string `yaml:"access_key_id" mapstructure:"access_key_id" env:"SECRETS_AWS_ACCESS_KEY_ID" redact:"true"`
+	SecretAccessKey string `yaml:"secret_access_key" mapstructure:"secret_access_key" env:"SECRETS_AWS_SECRET_ACCESS_KEY" redact:"true"`
+	SessionToken    string `yaml:"session_token" mapstructure:"session_token" env:"SECRETS_AWS_SESSION_TOKEN" redact:"true"`
+	Endpoint        string `yaml:"endpoint" mapstructure:"endpoint" env:"SECRETS_AWS_ENDPOINT"`
+}
+
+// DefaultSecretsConfig returns default secrets configuration
+func DefaultSecretsConfig() *SecretsConfig {
+	return &SecretsConfig{
+		RefreshInterval: 0,
+		Timeout:         5 * time.Second,
+		Vault: &VaultSecretsConfig{
+			Enabled: false,
+			Address: "http://127.0.0.1:8200",
+		},
+		AWS: &AWSSecretsConfig{
+			Enabled: false,
+		},
+	}
+}
+
+// Validate validates secrets configuration
+func (c *SecretsConfig) Validate() error {
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("secrets refresh_interval must be non-negative")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("secrets timeout must be positive")
+	}
+	if c.Vault != nil && c.Vault.Enabled {
+		if c.Vault.Address == "" {
+			return fmt.Errorf("secrets vault address is required when enabled")
+		}
+		if c.Vault.Token == "" {
+			return fmt.Errorf("secrets vault token is required when enabled")
+		}
+	}
+	if c.AWS != nil && c.AWS.Enabled {
+		if c.AWS.Region == "" {
+			return fmt.Errorf("secrets aws region is required when enabled")
+		}
+		if c.AWS.AccessKeyID == "" || c.AWS.SecretAccessKey == "" {
+			return fmt.Errorf("secrets aws access_key_id and secret_access_key are required when enabled")
+		}
+	}
+	return nil
+}
+
+// newSecretResolver builds a resolver for the configured stores. Schemes of
+// disabled stores are still recognized so a reference to them fails loudly
+// instead of being used as a literal value.
+func newSecretResolver(c *SecretsConfig) (*secrets.Resolver, error) {
+	if c == nil {
+		c = DefaultSecretsConfig()
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	resolver := secrets.NewResolver()
+	resolver.Register(secrets.SchemeVault, disabledProvider("secrets.vault"))
+	resolver.Register(secrets.SchemeAWSSecretsManager, disabledProvider("secrets.aws"))
+
+	if c.Vault != nil && c.Vault.Enabled {
+		provider, err := secrets.NewVaultProvider(secrets.VaultConfig{
+			Address:   c.Vault.Address,
+			Token:     c.Vault.Token,
+			Namespace: c.Vault.Namespace,
+			Timeout:   c.Timeout,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		resolver.Register(secrets.SchemeVault, provider)
+	}
+
+	if c.AWS != nil && c.AWS.Enabled {
+		provider, err := secrets.NewAWSProvider(secrets.AWSConfig{
+			Region:          c.AWS.Region,
+			AccessKeyID:     c.AWS.AccessKeyID,
+			SecretAccessKey: c.AWS.SecretAccessKey,
+			SessionToken:    c.AWS.SessionToken,
+			Endpoint:        c.AWS.Endpoint,
+			Timeout:         c.Timeout,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		resolver.Register(secrets.SchemeAWSSecretsManager, provider)
+	}
+
+	return resolver, nil
+}
+
+func disabledProvider(section string) secrets.Provider {
+	return secrets.ProviderFunc(func(ctx context.Context, path string) (map[string]string, error) {
+		return nil, fmt.Errorf("%s is not enabled", section)
+	})
+}
+
+// resolveSecrets replaces secret references in cfg and records the resolved
+// keys so WatchSecrets knows whether there is anything to refresh
+func (l *Loader) resolveSecrets(cfg *Config) error {
+	resolver, err := newSecretResolver(cfg.Secrets)
+	if err != nil {
+		return err
+	}
+
+	keys, err := resolveSecretRefs(context.Background(), cfg, resolver)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.secretKeys = keys
+	l.mu.Unlock()
+	return nil
+}
+
+// resolveSecretRefs replaces every string value in cfg that references a
+// secret store with the fetched secret. It returns the config keys that were
+// resolved. The secrets section itself is never resolved.
+func resolveSecretRefs(ctx context.Context, cfg *Config, resolver *secrets.Resolver) ([]string, error) {
+	var resolved []string
+	err := walkStrings(reflect.ValueOf(cfg).Elem(), "", func(key string, field reflect.Value) error {
+		if key == "secrets" || strings.HasPrefix(key, "secrets.") {
+			return nil
+		}
+		ref, ok := resolver.Parse(field.String())
+		if !ok {
+			return nil
+		}
+		value, err := resolver.ResolveReference(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		field.SetString(value)
+		resolved = append(resolved, key)
+		return nil
+	})
+	return resolved, err
+}
+
+// walkStrings calls fn for every settable string field reachable from v,
+// keyed by its mapstructure path
+func walkStrings(v reflect.Value, prefix string, fn func(key string, field reflect.Value) error) error {
+	if isURLSetting(v.Type()) {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return walkStrings(v.Elem(), prefix, fn)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			key := name
+			if prefix != "" {
+				key = prefix + "." + name
+			}
+			if err := walkStrings(v.Field(i), key, fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", prefix, i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if v.CanSet() {
+			return fn(prefix, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secretsTestConfig = `
+app:
+  environment: "testing"
+database:
+  password: "vault:secret/data/wonder#db_password"
+jwt:
+  signing_key: "vault:secret/data/wonder#jwt_key"
+secrets:
+  vault:
+    enabled: %t
+    address: "%s"
+    token: "test-token"
+`
+
+// fakeVault serves a KV v2 secret whose db_password can be rotated
+func fakeVault(t *testing.T, password *atomic.Value) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/wonder" || r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"data":{"db_password":%q,"jwt_key":"vault-signing-key-0123456789abcdef0123"},"metadata":{"version":1}}}`,
+			password.Load().(string))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeSecretsConfig(t *testing.T, vaultEnabled bool, address string) string {
+	t.Helper()
+	dir := t.TempDir()
+	content := fmt.Sprintf(secretsTestConfig, vaultEnabled, address)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0644))
+	return dir
+}
+
+func TestLoader_ResolvesSecretReferences(t *testing.T) {
+	var password atomic.Value
+	password.Store("initial-password")
+	vault := fakeVault(t, &password)
+
+	loader := NewLoader()
+	cfg, err := loader.LoadConfig(writeSecretsConfig(t, true, vault.URL))
+	require.NoError(t, err)
+
+	assert.Equal(t, "initial-password", cfg.Database.Password)
+	assert.Equal(t, "vault-signing-key-0123456789abcdef0123", cfg.JWT.SigningKey)
+	assert.Equal(t, "test-token", cfg.Secrets.Vault.Token)
+}
+
+func TestLoader_RejectsReferencesToDisabledStores(t *testing.T) {
+	loader := NewLoader()
+	_, err := loader.LoadConfig(writeSecretsConfig(t, false, "http://127.0.0.1:1"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database.password")
+	assert.Contains(t, err.Error(), "secrets.vault is not enabled")
+}
+
+func TestLoader_WatchSecrets_PublishesRotation(t *testing.T) {
+	var password atomic.Value
+	password.Store("initial-password")
+	vault := fakeVault(t, &password)
+
+	loader := NewLoader()
+	_, err := loader.LoadConfig(writeSecretsConfig(t, true, vault.URL))
+	require.NoError(t, err)
+
+	events := make(chan ChangeEvent, 4)
+	require.NoError(t, loader.Subscribe(func(event ChangeEvent) {
+		events <- event
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	loader.WatchSecrets(ctx, 20*time.Millisecond)
+
+	password.Store("rotated-password")
+
+	select {
+	case event := <-events:
+		assert.Equal(t, []Section{SectionDatabase}, event.Changed)
+		assert.Equal(t, "initial-password", event.Previous.Database.Password)
+		assert.Equal(t, "rotated-password", event.Current.Database.Password)
+		assert.Equal(t, "rotated-password", loader.Current().Database.Password)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for secret rotation event")
+	}
+}
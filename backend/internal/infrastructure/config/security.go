@@ -0,0 +1,289 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// SecurityConfig represents security policy configuration
+type SecurityConfig struct {
+	PasswordBreach *PasswordBreachConfig `yaml:"password_breach" mapstructure:"password_breach"`
+	Lockout        *LockoutConfig        `yaml:"lockout" mapstructure:"lockout"`
+	Captcha        *CaptchaConfig        `yaml:"captcha" mapstructure:"captcha"`
+	IPFilter       *IPFilterConfig       `yaml:"ip_filter" mapstructure:"ip_filter"`
+	Signup         *SignupConfig         `yaml:"signup" mapstructure:"signup"`
+}
+
+// PasswordBreachConfig represents breached-password screening configuration
+// backed by the HaveIBeenPwned range API (k-anonymity model)
+type PasswordBreachConfig struct {
+	Enabled          bool          `yaml:"enabled" mapstructure:"enabled" env:"PASSWORD_BREACH_ENABLED"`
+	Policy           string        `yaml:"policy" mapstructure:"policy" env:"PASSWORD_BREACH_POLICY"`
+	APIURL           url.URL       `yaml:"api_url" mapstructure:"api_url" env:"PASSWORD_BREACH_API_URL"`
+	Timeout          time.Duration `yaml:"timeout" mapstructure:"timeout" env:"PASSWORD_BREACH_TIMEOUT"`
+	CacheTTL         time.Duration `yaml:"cache_ttl" mapstructure:"cache_ttl" env:"PASSWORD_BREACH_CACHE_TTL"`
+	FailureThreshold int           `yaml:"failure_threshold" mapstructure:"failure_threshold" env:"PASSWORD_BREACH_FAILURE_THRESHOLD"`
+	OpenTimeout      time.Duration `yaml:"open_timeout" mapstructure:"open_timeout" env:"PASSWORD_BREACH_OPEN_TIMEOUT"`
+}
+
+// LockoutConfig represents brute-force protection on login. Failed attempts
+// are counted per account and per client IP within a sliding window; reaching
+// a threshold locks that key for Duration.
+type LockoutConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"LOCKOUT_ENABLED"`
+	// Store keeps the counters: "memory" (single instance) or "redis"
+	Store       string        `yaml:"store" mapstructure:"store" env:"LOCKOUT_STORE"`
+	Threshold   int           `yaml:"threshold" mapstructure:"threshold" env:"LOCKOUT_THRESHOLD"`
+	IPThreshold int           `yaml:"ip_threshold" mapstructure:"ip_threshold" env:"LOCKOUT_IP_THRESHOLD"`
+	Window      time.Duration `yaml:"window" mapstructure:"window" env:"LOCKOUT_WINDOW"`
+	Duration    time.Duration `yaml:"duration" mapstructure:"duration" env:"LOCKOUT_DURATION"`
+}
+
+// CAPTCHA providers
+const (
+	CaptchaProviderHCaptcha  = "hcaptcha"
+	CaptchaProviderReCaptcha = "recaptcha"
+)
+
+// captchaVerifyURLs are the siteverify endpoints of the providers
+var captchaVerifyURLs = map[string]string{
+	CaptchaProviderHCaptcha:  "https://api.hcaptcha.com/siteverify",
+	CaptchaProviderReCaptcha: "https://www.google.com/recaptcha/api/siteverify",
+}
+
+// CaptchaConfig represents CAPTCHA challenges on registration and on
+// logins after repeated failures
+type CaptchaConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"CAPTCHA_ENABLED"`
+	// Provider is "hcaptcha" or "recaptcha"
+	Provider  string `yaml:"provider" mapstructure:"provider" env:"CAPTCHA_PROVIDER"`
+	SecretKey string `yaml:"secret_key" mapstructure:"secret_key" env:"CAPTCHA_SECRET_KEY" redact:"true"`
+	// VerifyURL overrides the provider's siteverify endpoint
+	VerifyURL url.URL       `yaml:"verify_url" mapstructure:"verify_url" env:"CAPTCHA_VERIFY_URL"`
+	Timeout   time.Duration `yaml:"timeout" mapstructure:"timeout" env:"CAPTCHA_TIMEOUT"`
+	// MinScore rejects solutions the provider scores lower, for score
+	// based challenges such as reCAPTCHA v3; 0 accepts any score
+	MinScore float64 `yaml:"min_score" mapstructure:"min_score" env:"CAPTCHA_MIN_SCORE"`
+	// Register requires a CAPTCHA to register
+	Register bool `yaml:"register" mapstructure:"register" env:"CAPTCHA_REGISTER"`
+	// LoginThreshold failed logins of an account or client IP require a
+	// CAPTCHA to log in; 0 never requires one. Failures are counted by the
+	// lockout, which must be enabled.
+	LoginThreshold int `yaml:"login_threshold" mapstructure:"login_threshold" env:"CAPTCHA_LOGIN_THRESHOLD"`
+	// BypassToken is accepted as a solved CAPTCHA without asking the
+	// provider, for automated tests. Never set it in production.
+	BypassToken string `yaml:"bypass_token" mapstructure:"bypass_token" env:"CAPTCHA_BYPASS_TOKEN" redact:"true"`
+}
+
+// SignupConfig represents protection of registration against automated
+// sign-ups: throttles per email domain and per client IP, a block list of
+// disposable email domains, a honeypot form field and a throttle of handle
+// availability checks
+type SignupConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"SIGNUP_PROTECTION_ENABLED"`
+	// Store keeps the counters: "memory" (single instance) or "redis"
+	Store string `yaml:"store" mapstructure:"store" env:"SIGNUP_STORE"`
+	// Window is how long a registration counts towards the limits
+	Window time.Duration `yaml:"window" mapstructure:"window" env:"SIGNUP_WINDOW"`
+	// DomainLimit registrations per email domain within Window; 0 disables it
+	DomainLimit int `yaml:"domain_limit" mapstructure:"domain_limit" env:"SIGNUP_DOMAIN_LIMIT"`
+	// IPLimit registrations per client IP within Window; 0 disables it
+	IPLimit int `yaml:"ip_limit" mapstructure:"ip_limit" env:"SIGNUP_IP_LIMIT"`
+	// ExemptDomains are not throttled per domain
+	ExemptDomains []string `yaml:"exempt_domains" mapstructure:"exempt_domains" env:"SIGNUP_EXEMPT_DOMAINS"`
+	// BlockDisposable refuses email addresses of disposable domains
+	BlockDisposable bool `yaml:"block_disposable" mapstructure:"block_disposable" env:"SIGNUP_BLOCK_DISPOSABLE"`
+	// DisposableDomains are blocked in addition to those in
+	// DisposableDomainsFile
+	DisposableDomains []string `yaml:"disposable_domains" mapstructure:"disposable_domains" env:"SIGNUP_DISPOSABLE_DOMAINS"`
+	// DisposableDomainsFile lists one disposable domain per line
+	DisposableDomainsFile string `yaml:"disposable_domains_file" mapstructure:"disposable_domains_file" env:"SIGNUP_DISPOSABLE_DOMAINS_FILE"`
+	// Honeypot refuses registrations that fill in the hidden website field
+	Honeypot bool `yaml:"honeypot" mapstructure:"honeypot" env:"SIGNUP_HONEYPOT"`
+	// HandleCheckLimit handle availability checks per client IP within
+	// Window; 0 disables it
+	HandleCheckLimit int `yaml:"handle_check_limit" mapstructure:"handle_check_limit" env:"SIGNUP_HANDLE_CHECK_LIMIT"`
+}
+
+// SiteVerifyURL returns the verification endpoint to call
+func (c *CaptchaConfig) SiteVerifyURL() string {
+	if c.VerifyURL != (url.URL{}) {
+		return c.VerifyURL.String()
+	}
+	return captchaVerifyURLs[c.Provider]
+}
+
+// DefaultSecurityConfig returns default security configuration
+func DefaultSecurityConfig() *SecurityConfig {
+	return &SecurityConfig{
+		PasswordBreach: &PasswordBreachConfig{
+			Enabled:          false,
+			Policy:           "reject",
+			APIURL:           mustParseURL("https://api.pwnedpasswords.com"),
+			Timeout:          3 * time.Second,
+			CacheTTL:         time.Hour,
+			FailureThreshold: 5,
+			OpenTimeout:      30 * time.Second,
+		},
+		Lockout: &LockoutConfig{
+			Enabled:     true,
+			Store:       "memory",
+			Threshold:   5,
+			IPThreshold: 20,
+			Window:      15 * time.Minute,
+			Duration:    15 * time.Minute,
+		},
+		Captcha: &CaptchaConfig{
+			Enabled:        false,
+			Provider:       CaptchaProviderHCaptcha,
+			Timeout:        5 * time.Second,
+			Register:       true,
+			LoginThreshold: 3,
+		},
+		IPFilter: DefaultIPFilterConfig(),
+		Signup: &SignupConfig{
+			Enabled:         false,
+			Store:           "memory",
+			Window:          time.Hour,
+			DomainLimit:     20,
+			IPLimit:         5,
+			ExemptDomains:   []string{"gmail.com", "googlemail.com", "outlook.com", "hotmail.com", "yahoo.com", "icloud.com", "proton.me"},
+			BlockDisposable: true,
+			DisposableDomains: []string{
+				"mailinator.com", "guerrillamail.com", "10minutemail.com", "yopmail.com",
+				"trashmail.com", "temp-mail.org", "sharklasers.com", "dispostable.com",
+			},
+			Honeypot:         true,
+			HandleCheckLimit: 300,
+		},
+	}
+}
+
+// Validate validates security configuration
+func (c *SecurityConfig) Validate() error {
+	if c.PasswordBreach != nil {
+		if err := c.PasswordBreach.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Lockout != nil {
+		if err := c.Lockout.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Captcha != nil {
+		if err := c.Captcha.Validate(); err != nil {
+			return err
+		}
+		if c.Captcha.Enabled && c.Captcha.LoginThreshold > 0 && (c.Lockout == nil || !c.Lockout.Enabled) {
+			return fmt.Errorf("captcha login_threshold needs the lockout enabled to count failed logins")
+		}
+	}
+	if c.IPFilter != nil {
+		if err := c.IPFilter.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Signup != nil {
+		if err := c.Signup.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates password breach configuration
+func (c *PasswordBreachConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Policy != "reject" && c.Policy != "warn" {
+		return fmt.Errorf("password_breach policy must be one of: reject, warn")
+	}
+	if c.APIURL == (url.URL{}) {
+		return fmt.Errorf("password_breach api_url is required when enabled")
+	}
+	if !isHTTPURL(c.APIURL) {
+		return fmt.Errorf("password_breach api_url %q must be an http or https URL", c.APIURL.String())
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("password_breach timeout must be positive")
+	}
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("password_breach cache_ttl must be non-negative")
+	}
+	if c.FailureThreshold <= 0 {
+		return fmt.Errorf("password_breach failure_threshold must be positive")
+	}
+	if c.OpenTimeout <= 0 {
+		return fmt.Errorf("password_breach open_timeout must be positive")
+	}
+	return nil
+}
+
+// Validate validates lockout configuration
+func (c *LockoutConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Store != "memory" && c.Store != "redis" {
+		return fmt.Errorf("lockout store must be one of: memory, redis")
+	}
+	if c.Threshold <= 0 {
+		return fmt.Errorf("lockout threshold must be positive")
+	}
+	if c.IPThreshold < 0 {
+		return fmt.Errorf("lockout ip_threshold must be non-negative")
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("lockout window must be positive")
+	}
+	if c.Duration <= 0 {
+		return fmt.Errorf("lockout duration must be positive")
+	}
+	return nil
+}
+
+// Validate validates CAPTCHA configuration
+func (c *CaptchaConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if _, ok := captchaVerifyURLs[c.Provider]; !ok {
+		return fmt.Errorf("captcha provider must be one of: hcaptcha, recaptcha")
+	}
+	if c.SecretKey == "" && c.BypassToken == "" {
+		return fmt.Errorf("captcha secret_key is required when enabled")
+	}
+	if c.VerifyURL != (url.URL{}) && !isHTTPURL(c.VerifyURL) {
+		return fmt.Errorf("captcha verify_url %q must be an http or https URL", c.VerifyURL.String())
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("captcha timeout must be positive")
+	}
+	if c.MinScore < 0 || c.MinScore > 1 {
+		return fmt.Errorf("captcha min_score must be between 0 and 1")
+	}
+	if c.LoginThreshold < 0 {
+		return fmt.Errorf("captcha login_threshold must be non-negative")
+	}
+	return nil
+}
+
+// Validate validates signup protection configuration
+func (c *SignupConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Store != "memory" && c.Store != "redis" {
+		return fmt.Errorf("signup store must be one of: memory, redis")
+	}
+	if c.DomainLimit < 0 || c.IPLimit < 0 || c.HandleCheckLimit < 0 {
+		return fmt.Errorf("signup domain_limit, ip_limit and handle_check_limit must be non-negative")
+	}
+	if (c.DomainLimit > 0 || c.IPLimit > 0 || c.HandleCheckLimit > 0) && c.Window <= 0 {
+		return fmt.Errorf("signup window must be positive")
+	}
+	return nil
+}
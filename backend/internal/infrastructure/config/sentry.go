@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/sentry"
+)
+
+// SentryConfig represents error reporting to Sentry. Recovered panics are
+// reported when DSN is set; Environment and Release default to
+// app.environment and app.version.
+type SentryConfig struct {
+	DSN         string        `yaml:"dsn" mapstructure:"dsn" env:"SENTRY_DSN" redact:"true"`
+	Environment string        `yaml:"environment" mapstructure:"environment" env:"SENTRY_ENVIRONMENT"`
+	Release     string        `yaml:"release" mapstructure:"release" env:"SENTRY_RELEASE"`
+	Timeout     time.Duration `yaml:"timeout" mapstructure:"timeout" env:"SENTRY_TIMEOUT"`
+}
+
+// DefaultSentryConfig returns default Sentry configuration
+func DefaultSentryConfig() *SentryConfig {
+	return &SentryConfig{
+		Timeout: 5 * time.Second,
+	}
+}
+
+// Enabled reports whether events are sent to Sentry
+func (c *SentryConfig) Enabled() bool {
+	return c != nil && c.DSN != ""
+}
+
+// Validate validates Sentry configuration
+func (c *SentryConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if _, err := sentry.ParseDSN(c.DSN); err != nil {
+		return err
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("sentry timeout must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// StatsConfig represents the admin statistics endpoint
+type StatsConfig struct {
+	// CacheTTL keeps computed stats in Redis for this long when
+	// external.redis is enabled; zero disables caching
+	CacheTTL time.Duration `yaml:"cache_ttl" mapstructure:"cache_ttl" env:"STATS_CACHE_TTL"`
+}
+
+// DefaultStatsConfig returns default statistics configuration
+func DefaultStatsConfig() *StatsConfig {
+	return &StatsConfig{
+		CacheTTL: 5 * time.Minute,
+	}
+}
+
+// Validate validates statistics configuration
+func (c *StatsConfig) Validate() error {
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("stats cache_ttl must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Object storage backends
+const (
+	StorageBackendLocal = "local"
+	StorageBackendS3    = "s3"
+)
+
+// StorageConfig represents the object storage that keeps user avatars
+type StorageConfig struct {
+	// Enabled turns on object storage and the avatar endpoints
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"STORAGE_ENABLED"`
+	// Backend is local, keeping objects under Dir and serving them from the
+	// application, or s3, keeping them in an S3 or MinIO bucket
+	Backend string `yaml:"backend" mapstructure:"backend" env:"STORAGE_BACKEND"`
+	// Dir is the local backend's directory
+	Dir string `yaml:"dir" mapstructure:"dir" env:"STORAGE_DIR"`
+	// SigningKey signs the local backend's object URLs
+	SigningKey string `yaml:"signing_key" mapstructure:"signing_key" env:"STORAGE_SIGNING_KEY" redact:"true"`
+	// Endpoint is the s3 backend's base URL, e.g. https://s3.eu-west-1.amazonaws.com
+	Endpoint        url.URL `yaml:"endpoint" mapstructure:"endpoint" env:"STORAGE_ENDPOINT"`
+	Region          string  `yaml:"region" mapstructure:"region" env:"STORAGE_REGION"`
+	Bucket          string  `yaml:"bucket" mapstructure:"bucket" env:"STORAGE_BUCKET"`
+	AccessKeyID     string  `yaml:"access_key_id" mapstructure:"access_key_id" env:"STORAGE_ACCESS_KEY_ID" redact:"true"`
+	SecretAccessKey string  `yaml:"secret_access_key" mapstructure:"secret_access_key" env:"STORAGE_SECRET_ACCESS_KEY" redact:"true"`
+	// SessionToken accompanies temporary credentials
+	SessionToken string `yaml:"session_token" mapstructure:"session_token" env:"STORAGE_SESSION_TOKEN" redact:"true"`
+	// PathStyle addresses the bucket in the URL path rather than the host
+	// name, as MinIO expects
+	PathStyle bool `yaml:"path_style" mapstructure:"path_style" env:"STORAGE_PATH_STYLE"`
+	// URLTTL is how long the URLs handed out to read objects stay valid
+	URLTTL time.Duration `yaml:"url_ttl" mapstructure:"url_ttl" env:"STORAGE_URL_TTL"`
+}
+
+// DefaultStorageConfig returns default object storage configuration
+func DefaultStorageConfig() *StorageConfig {
+	return &StorageConfig{
+		Enabled: false,
+		Backend: StorageBackendLocal,
+		Dir:     "data/objects",
+		Region:  "us-east-1",
+		URLTTL:  15 * time.Minute,
+	}
+}
+
+// Validate validates object storage configuration
+func (c *StorageConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.URLTTL < time.Second || c.URLTTL > 7*24*time.Hour {
+		return fmt.Errorf("storage url_ttl must be between 1s and 168h")
+	}
+
+	switch c.Backend {
+	case StorageBackendLocal:
+		if c.Dir == "" {
+			return fmt.Errorf("storage dir is required by the local backend")
+		}
+		if len(c.SigningKey) < 32 {
+			return fmt.Errorf("storage signing_key must be at least 32 characters for the local backend")
+		}
+	case StorageBackendS3:
+		if !isHTTPURL(c.Endpoint) {
+			return fmt.Errorf("storage endpoint %q must be an http or https URL", c.Endpoint.String())
+		}
+		if c.Region == "" || c.Bucket == "" {
+			return fmt.Errorf("storage region and bucket are required by the s3 backend")
+		}
+		if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+			return fmt.Errorf("storage access_key_id and secret_access_key are required by the s3 backend")
+		}
+	default:
+		return fmt.Errorf("storage backend must be one of: %s, %s", StorageBackendLocal, StorageBackendS3)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TenancyConfig represents multi-tenancy configuration. Disabled, every
+// request belongs to the default tenant.
+type TenancyConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled" env:"TENANCY_ENABLED"`
+	// Header names the tenant of a request by ID or slug
+	Header string `yaml:"header" mapstructure:"header" env:"TENANCY_HEADER"`
+	// BaseDomain, if set, resolves <slug>.<base_domain> hosts to a tenant
+	// when the header is absent
+	BaseDomain string `yaml:"base_domain" mapstructure:"base_domain" env:"TENANCY_BASE_DOMAIN"`
+}
+
+// DefaultTenancyConfig returns default tenancy configuration
+func DefaultTenancyConfig() *TenancyConfig {
+	return &TenancyConfig{
+		Enabled: false,
+		Header:  "X-Tenant-ID",
+	}
+}
+
+// Validate validates tenancy configuration
+func (c *TenancyConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if strings.TrimSpace(c.Header) == "" {
+		return fmt.Errorf("tenancy header cannot be empty")
+	}
+	if strings.ContainsAny(c.BaseDomain, ":/ ") {
+		return fmt.Errorf("tenancy base_domain must be a bare host name")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+)
+
+// TLSConfig represents HTTPS termination. Certificates come either from
+// CertFile/KeyFile, re-read on SIGHUP, or from Let's Encrypt when Autocert
+// is set.
+type TLSConfig struct {
+	Enabled  bool   `yaml:"enabled" mapstructure:"enabled" env:"SERVER_TLS_ENABLED"`
+	CertFile string `yaml:"cert_file" mapstructure:"cert_file" env:"SERVER_TLS_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" mapstructure:"key_file" env:"SERVER_TLS_KEY_FILE"`
+
+	// Autocert obtains and renews certificates for AutocertDomains through
+	// ACME. Issued certificates are kept in AutocertCacheDir.
+	Autocert         bool     `yaml:"autocert" mapstructure:"autocert" env:"SERVER_TLS_AUTOCERT"`
+	AutocertDomains  []string `yaml:"autocert_domains" mapstructure:"autocert_domains" env:"SERVER_TLS_AUTOCERT_DOMAINS"`
+	AutocertEmail    string   `yaml:"autocert_email" mapstructure:"autocert_email" env:"SERVER_TLS_AUTOCERT_EMAIL"`
+	AutocertCacheDir string   `yaml:"autocert_cache_dir" mapstructure:"autocert_cache_dir" env:"SERVER_TLS_AUTOCERT_CACHE_DIR"`
+
+	// RedirectHTTP serves plain HTTP on HTTPPort and redirects it to HTTPS.
+	// Autocert always listens there to answer ACME http-01 challenges.
+	RedirectHTTP bool `yaml:"redirect_http" mapstructure:"redirect_http" env:"SERVER_TLS_REDIRECT_HTTP"`
+	HTTPPort     int  `yaml:"http_port" mapstructure:"http_port" env:"SERVER_TLS_HTTP_PORT"`
+
+	// HTTP2 offers h2 through ALPN next to HTTP/1.1
+	HTTP2 bool `yaml:"http2" mapstructure:"http2" env:"SERVER_TLS_HTTP2"`
+}
+
+// DefaultTLSConfig returns default TLS configuration
+func DefaultTLSConfig() *TLSConfig {
+	return &TLSConfig{
+		Enabled:          false,
+		AutocertCacheDir: "./certs",
+		HTTPPort:         80,
+		HTTP2:            true,
+	}
+}
+
+// ListensHTTP reports whether a plain HTTP listener runs next to HTTPS
+func (c *TLSConfig) ListensHTTP() bool {
+	return c.Enabled && (c.RedirectHTTP || c.Autocert)
+}
+
+// Validate validates TLS configuration
+func (c *TLSConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Autocert {
+		if c.CertFile != "" || c.KeyFile != "" {
+			return fmt.Errorf("tls cert_file and key_file cannot be combined with autocert")
+		}
+		if len(c.AutocertDomains) == 0 {
+			return fmt.Errorf("tls autocert_domains is required when autocert is enabled")
+		}
+		if c.AutocertCacheDir == "" {
+			return fmt.Errorf("tls autocert_cache_dir is required when autocert is enabled")
+		}
+	} else if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("tls cert_file and key_file are required unless autocert is enabled")
+	}
+	if c.ListensHTTP() && (c.HTTPPort <= 0 || c.HTTPPort > 65535) {
+		return fmt.Errorf("tls http_port must be between 1 and 65535")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UsersConfig represents user list, avatar, email matching and handle
+// settings
+type UsersConfig struct {
+	// CountCacheTTL keeps exact user list totals in Redis for this long
+	// when external.redis is enabled; writes invalidate them sooner. Zero
+	// disables caching.
+	CountCacheTTL time.Duration `yaml:"count_cache_ttl" mapstructure:"count_cache_ttl" env:"USERS_COUNT_CACHE_TTL"`
+	// AvatarMaxBytes limits the size of uploaded avatar images
+	AvatarMaxBytes ByteSize `yaml:"avatar_max_bytes" mapstructure:"avatar_max_bytes" env:"USERS_AVATAR_MAX_BYTES"`
+	// AvatarSize is the side in pixels avatars are scaled down to
+	AvatarSize int `yaml:"avatar_size" mapstructure:"avatar_size" env:"USERS_AVATAR_SIZE"`
+	// FoldGmailAddresses treats Gmail addresses that differ only in dots or
+	// a "+tag" as the same account. Users keep signing in with their own
+	// address after it changes; run the canonicalize-emails job so other
+	// spellings of it match too.
+	FoldGmailAddresses bool `yaml:"fold_gmail_addresses" mapstructure:"fold_gmail_addresses" env:"USERS_FOLD_GMAIL_ADDRESSES"`
+	// CanonicalizeBatchSize is how many users the canonicalize-emails job
+	// reads at a time
+	CanonicalizeBatchSize int `yaml:"canonicalize_batch_size" mapstructure:"canonicalize_batch_size" env:"USERS_CANONICALIZE_BATCH_SIZE"`
+	// ReservedHandles cannot be claimed as handles, in addition to the
+	// built-in names such as admin and api
+	ReservedHandles []string `yaml:"reserved_handles" mapstructure:"reserved_handles" env:"USERS_RESERVED_HANDLES"`
+	// CursorSigningKey signs user list cursors, so every instance sharing
+	// it accepts cursors the others issued. Empty uses a random key per
+	// process.
+	CursorSigningKey string `yaml:"cursor_signing_key" mapstructure:"cursor_signing_key" env:"USERS_CURSOR_SIGNING_KEY" redact:"true"`
+}
+
+// DefaultUsersConfig returns default user list, avatar, email matching and
+// handle configuration
+func DefaultUsersConfig() *UsersConfig {
+	return &UsersConfig{
+		CountCacheTTL:         time.Minute,
+		AvatarMaxBytes:        5 * Megabyte,
+		AvatarSize:            256,
+		CanonicalizeBatchSize: 500,
+	}
+}
+
+// Validate validates user list, avatar, email matching and handle
+// configuration
+func (c *UsersConfig) Validate() error {
+	if c.CountCacheTTL < 0 {
+		return fmt.Errorf("users count_cache_ttl must not be negative")
+	}
+	if c.AvatarMaxBytes <= 0 {
+		return fmt.Errorf("users avatar_max_bytes must be positive")
+	}
+	if c.AvatarSize < 16 || c.AvatarSize > 1024 {
+		return fmt.Errorf("users avatar_size must be between 16 and 1024")
+	}
+	if c.CanonicalizeBatchSize <= 0 {
+		return fmt.Errorf("users canonicalize_batch_size must be positive")
+	}
+	if c.CursorSigningKey != "" && len(c.CursorSigningKey) < 32 {
+		return fmt.Errorf("users cursor_signing_key must be at least 32 characters")
+	}
+	for _, handle := range c.ReservedHandles {
+		if strings.TrimSpace(handle) == "" {
+			return fmt.Errorf("users reserved_handles must not contain empty names")
+		}
+	}
+	return nil
+}
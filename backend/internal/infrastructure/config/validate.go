@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maskedValue replaces secrets in printed configuration
+const maskedValue = "******"
+
+// Setting is one effective configuration value
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// ValidationReport is the outcome of loading a configuration without
+// starting the service
+type ValidationReport struct {
+	// Source is the configuration file read; empty when only defaults and
+	// environment variables were used
+	Source string
+	// Settings lists every effective value by key, with secrets masked
+	Settings []Setting
+	// Errors holds every validation failure, not just the first
+	Errors []error
+}
+
+// Valid reports whether the configuration passed validation
+func (r *ValidationReport) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Print writes the effective values followed by the validation outcome
+func (r *ValidationReport) Print(w io.Writer) {
+	source := r.Source
+	if source == "" {
+		source = "defaults and environment"
+	}
+	fmt.Fprintf(w, "configuration loaded from %s\n\n", source)
+
+	width := 0
+	for _, s := range r.Settings {
+		width = max(width, len(s.Key))
+	}
+	for _, s := range r.Settings {
+		fmt.Fprintf(w, "%-*s  %s\n", width, s.Key, s.Value)
+	}
+
+	if r.Valid() {
+		fmt.Fprintln(w, "\nconfiguration is valid")
+		return
+	}
+	fmt.Fprintf(w, "\nconfiguration is invalid (%d error(s)):\n", len(r.Errors))
+	for _, err := range r.Errors {
+		fmt.Fprintf(w, "  - %s\n", strings.ReplaceAll(err.Error(), "\n", "\n    "))
+	}
+}
+
+// ValidateFile loads the merged configuration the way the server does and
+// validates it without connecting to anything but the secret stores.
+// configPath is an existing config file or directory to search; empty
+// searches the default locations. A non-empty env selects config.<env>.yaml and
+// overrides app.environment. The error is only for configuration that
+// cannot be read at all; validation failures are collected in the report.
+func (l *Loader) ValidateFile(env, configPath string) (*ValidationReport, error) {
+	return l.ValidateOptions(LoadOptions{Environment: env, ConfigPath: configPath})
+}
+
+// ValidateOptions is ValidateFile for configuration from all the sources
+// opts selects
+func (l *Loader) ValidateOptions(opts LoadOptions) (*ValidationReport, error) {
+	cfg, err := l.read(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ValidationReport{Source: l.GetConfigFilePath()}
+	if err := l.resolveSecrets(cfg); err != nil {
+		report.Errors = append(report.Errors, fmt.Errorf("failed to resolve secrets: %w", err))
+	}
+	report.Errors = append(report.Errors, cfg.ValidationErrors()...)
+
+	l.mu.RLock()
+	resolved := make(map[string]bool, len(l.secretKeys))
+	for _, key := range l.secretKeys {
+		resolved[key] = true
+	}
+	l.mu.RUnlock()
+	report.Settings = maskedSettings(cfg, resolved)
+
+	return report, nil
+}
+
+// ValidateFile validates configuration with a fresh loader. It does not
+// replace the global configuration.
+func ValidateFile(env, configPath string) (*ValidationReport, error) {
+	return NewLoader().ValidateFile(env, configPath)
+}
+
+// ValidateOptions validates configuration from the sources opts selects
+// with a fresh loader
+func ValidateOptions(opts LoadOptions) (*ValidationReport, error) {
+	return NewLoader().ValidateOptions(opts)
+}
+
+// maskedSettings flattens cfg into sorted settings, masking sensitive keys,
+// values resolved from a secret store and passwords embedded in URLs
+func maskedSettings(cfg *Config, resolved map[string]bool) []Setting {
+	var settings []Setting
+	walkValues(reflect.ValueOf(cfg.Redacted()).Elem(), "", func(key string, v reflect.Value) {
+		value := fmt.Sprint(settingValue(v))
+		if value != "" && resolved[key] {
+			value = maskedValue
+		}
+		settings = append(settings, Setting{Key: key, Value: value})
+	})
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// walkValues calls fn for every leaf value reachable from v, keyed by its
+// mapstructure path. Nil sections are skipped; URLs are leaves.
+func walkValues(v reflect.Value, prefix string, fn func(key string, v reflect.Value)) {
+	if isURLSetting(v.Type()) {
+		fn(prefix, v)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			walkValues(v.Elem(), prefix, fn)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			walkValues(v.Field(i), fieldKey(f, prefix), fn)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Struct && v.Type().Elem().Kind() != reflect.Ptr {
+			fn(prefix, v)
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			walkValues(v.Index(i), fmt.Sprintf("%s[%d]", prefix, i), fn)
+		}
+	default:
+		fn(prefix, v)
+	}
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// Section identifies a top-level configuration section by its key
+type Section string
+
+const (
+	SectionApp           Section = "app"
+	SectionServer        Section = "server"
+	SectionDatabase      Section = "database"
+	SectionLog           Section = "log"
+	SectionJWT           Section = "jwt"
+	SectionID            Section = "id"
+	SectionSecurity      Section = "security"
+	SectionAuth          Section = "auth"
+	SectionBootstrap     Section = "bootstrap"
+	SectionAccount       Section = "account"
+	SectionEncryption    Section = "encryption"
+	SectionOutbox        Section = "outbox"
+	SectionJobs          Section = "jobs"
+	SectionScheduler     Section = "scheduler"
+	SectionAudit         Section = "audit"
+	SectionImport        Section = "import"
+	SectionStats         Section = "stats"
+	SectionUsers         Section = "users"
+	SectionPreferences   Section = "preferences"
+	SectionNotifications Section = "notifications"
+	SectionOrganizations Section = "organizations"
+	SectionAPI           Section = "api"
+	SectionSearch        Section = "search"
+	SectionStorage       Section = "storage"
+	SectionWebhooks      Section = "webhooks"
+	SectionRetry         Section = "retry"
+	SectionBreaker       Section = "circuit_breaker"
+	SectionTenancy       Section = "tenancy"
+	SectionI18n          Section = "i18n"
+	SectionReplay        Section = "replay"
+	SectionExternal      Section = "external"
+	SectionSecrets       Section = "secrets"
+)
+
+// sections lists every field of Config in order, named by its
+// mapstructure tag, so new sections take part in reloads without being
+// listed here
+var sections = configSections()
+
+func configSections() []Section {
+	t := reflect.TypeOf(Config{})
+	out := make([]Section, t.NumField())
+	for i := range out {
+		out[i] = Section(t.Field(i).Tag.Get("mapstructure"))
+	}
+	return out
+}
+
+// ChangeEvent describes a validated configuration reload
+type ChangeEvent struct {
+	Previous *Config
+	Current  *Config
+	Changed  []Section
+}
+
+// Has reports whether the given section changed in this event
+func (e ChangeEvent) Has(section Section) bool {
+	for _, s := range e.Changed {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeHandler is invoked after a configuration file change or secret
+// rotation has been loaded and validated. Handlers run sequentially on the
+// watcher goroutine and should return quickly.
+type ChangeHandler func(event ChangeEvent)
+
+// Watch starts watching the loaded configuration file and registers handler
+// to receive change events. It may be called multiple times to register
+// several handlers; the underlying file watcher is only started once.
+//
+// Invalid configurations are rejected: the previous configuration stays in
+// effect and no event is published.
+func (l *Loader) Watch(handler ChangeHandler) error {
+	if handler == nil {
+		return fmt.Errorf("change handler cannot be nil")
+	}
+	if l.viper.ConfigFileUsed() == "" {
+		return fmt.Errorf("no configuration file loaded, nothing to watch")
+	}
+
+	if err := l.Subscribe(handler); err != nil {
+		return err
+	}
+
+	l.watchOnce.Do(func() {
+		l.viper.OnConfigChange(func(e fsnotify.Event) {
+			l.reload(e.Name)
+		})
+		l.viper.WatchConfig()
+	})
+
+	return nil
+}
+
+// Subscribe registers handler to receive change events without starting the
+// file watcher, e.g. for changes caused only by secret rotation
+func (l *Loader) Subscribe(handler ChangeHandler) error {
+	if handler == nil {
+		return fmt.Errorf("change handler cannot be nil")
+	}
+
+	l.mu.Lock()
+	l.handlers = append(l.handlers, handler)
+	l.mu.Unlock()
+	return nil
+}
+
+// WatchSecrets re-fetches secret references every interval until ctx is
+// done. Rotated values are published to subscribers like a file change. It
+// does nothing when interval is not positive or no value references a secret.
+func (l *Loader) WatchSecrets(ctx context.Context, interval time.Duration) {
+	l.mu.RLock()
+	refs := len(l.secretKeys)
+	l.mu.RUnlock()
+	if interval <= 0 || refs == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.reload("secrets")
+			}
+		}
+	}()
+}
+
+// Current returns the most recently loaded valid configuration
+func (l *Loader) Current() *Config {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.current
+}
+
+// setCurrent records the active configuration and its environment override
+func (l *Loader) setCurrent(env string, cfg *Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.environment = env
+	l.current = cfg
+}
+
+// reload re-reads configuration after a file change or secret refresh and
+// notifies handlers. source names the trigger in logs.
+func (l *Loader) reload(source string) {
+	ctx := context.Background()
+	log := logger.Get().WithLayer("infrastructure").WithComponent("config_watcher")
+
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	next := DefaultConfig()
+	if err := l.unmarshal(next); err != nil {
+		log.Error(ctx, "failed to unmarshal reloaded config, keeping previous", "source", source, "error", err)
+		return
+	}
+
+	l.mu.RLock()
+	env := l.environment
+	previous := l.current
+	l.mu.RUnlock()
+
+	if env != "" {
+		next.App.Environment = env
+	}
+
+	if err := l.resolveSecrets(next); err != nil {
+		log.Warn(ctx, "failed to resolve secrets for reloaded config, keeping previous", "source", source, "error", err)
+		return
+	}
+
+	if err := next.Validate(); err != nil {
+		log.Warn(ctx, "reloaded config is invalid, keeping previous", "source", source, "error", err)
+		return
+	}
+
+	changed := diffSections(previous, next)
+	if len(changed) == 0 {
+		if log.DebugEnabled() {
+			log.Debug(ctx, "config reloaded without effective differences", "source", source)
+		}
+		return
+	}
+
+	l.mu.Lock()
+	l.current = next
+	handlers := make([]ChangeHandler, len(l.handlers))
+	copy(handlers, l.handlers)
+	l.mu.Unlock()
+
+	log.Info(ctx, "configuration reloaded", "source", source, "changed_sections", changed)
+
+	event := ChangeEvent{Previous: previous, Current: next, Changed: changed}
+	for _, h := range handlers {
+		h(event)
+	}
+}
+
+// diffSections returns the top-level sections that differ between two configs
+func diffSections(previous, next *Config) []Section {
+	if previous == nil {
+		return append([]Section(nil), sections...)
+	}
+
+	var changed []Section
+	a, b := reflect.ValueOf(previous).Elem(), reflect.ValueOf(next).Elem()
+	for i, section := range sections {
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			changed = append(changed, section)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const watchTestConfig = `
+app:
+  name: "watch-app"
+  environment: "testing"
+log:
+  level: "%s"
+server:
+  enable_cors: %t
+`
+
+// writeWatchConfig replaces the file atomically so the watcher never
+// observes a partially written config
+func writeWatchConfig(t *testing.T, path, level string, cors bool) {
+	t.Helper()
+	content := []byte(fmt.Sprintf(watchTestConfig, level, cors))
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, content, 0644))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func TestLoader_Watch_PublishesChangeEvent(t *testing.T) {
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, "config.yaml")
+	writeWatchConfig(t, configFile, "info", true)
+
+	loader := NewLoader()
+	cfg, err := loader.LoadConfig(tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, "info", cfg.Log.Level)
+
+	events := make(chan ChangeEvent, 4)
+	require.NoError(t, loader.Watch(func(event ChangeEvent) {
+		events <- event
+	}))
+
+	writeWatchConfig(t, configFile, "debug", false)
+
+	select {
+	case event := <-events:
+		assert.True(t, event.Has(SectionLog))
+		assert.True(t, event.Has(SectionServer))
+		assert.False(t, event.Has(SectionDatabase))
+		assert.Equal(t, "info", event.Previous.Log.Level)
+		assert.Equal(t, "debug", event.Current.Log.Level)
+		assert.False(t, event.Current.Server.EnableCORS)
+		assert.Equal(t, "debug", loader.Current().Log.Level)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change event")
+	}
+}
+
+func TestLoader_Watch_RejectsInvalidConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, "config.yaml")
+	writeWatchConfig(t, configFile, "info", true)
+
+	loader := NewLoader()
+	_, err := loader.LoadConfig(tempDir)
+	require.NoError(t, err)
+
+	events := make(chan ChangeEvent, 4)
+	require.NoError(t, loader.Watch(func(event ChangeEvent) {
+		events <- event
+	}))
+
+	writeWatchConfig(t, configFile, "verbose", true)
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected change event for invalid config: %v", event.Changed)
+	case <-time.After(500 * time.Millisecond):
+	}
+	assert.Equal(t, "info", loader.Current().Log.Level)
+}
+
+func TestLoader_Watch_RequiresConfigFile(t *testing.T) {
+	loader := NewLoader()
+	_, err := loader.LoadConfig("/nonexistent/path")
+	require.NoError(t, err)
+
+	err = loader.Watch(func(ChangeEvent) {})
+	assert.Error(t, err)
+}
+
+func TestDiffSections(t *testing.T) {
+	a := DefaultConfig()
+	b := DefaultConfig()
+	assert.Empty(t, diffSections(a, b))
+
+	b.Log.Level = "debug"
+	b.JWT.Expiry = time.Hour
+	assert.Equal(t, []Section{SectionLog, SectionJWT}, diffSections(a, b))
+
+	assert.Len(t, diffSections(nil, b), reflect.TypeOf(Config{}).NumField())
+
+	b = DefaultConfig()
+	b.Users.FoldGmailAddresses = !a.Users.FoldGmailAddresses
+	assert.Equal(t, []Section{SectionUsers}, diffSections(a, b))
+}
+
+// Every Config field must be a declared Section, or reloads that only touch
+// it would go unnoticed
+func TestSections_CoverConfig(t *testing.T) {
+	declared := []Section{
+		SectionApp, SectionServer, SectionDatabase, SectionLog, SectionJWT, SectionID,
+		SectionSecurity, SectionAuth, SectionBootstrap, SectionAccount, SectionEncryption,
+		SectionOutbox, SectionJobs, SectionScheduler, SectionAudit, SectionImport,
+		SectionStats, SectionUsers, SectionPreferences, SectionNotifications,
+		SectionOrganizations, SectionAPI, SectionSearch, SectionStorage, SectionWebhooks,
+		SectionRetry, SectionBreaker, SectionTenancy, SectionI18n, SectionReplay,
+		SectionExternal, SectionSecrets,
+	}
+
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		assert.Contains(t, declared, Section(field.Tag.Get("mapstructure")), "Config.%s has no Section", field.Name)
+	}
+	assert.ElementsMatch(t, declared, sections)
+}
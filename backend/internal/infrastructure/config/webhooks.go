@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// WebhooksConfig represents webhook deliveries of user events and their
+// dispatcher worker
+type WebhooksConfig struct {
+	Enabled      bool          `yaml:"enabled" mapstructure:"enabled" env:"WEBHOOKS_ENABLED"`
+	PollInterval time.Duration `yaml:"poll_interval" mapstructure:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL"`
+	BatchSize    int           `yaml:"batch_size" mapstructure:"batch_size" env:"WEBHOOKS_BATCH_SIZE"`
+	MaxAttempts  int           `yaml:"max_attempts" mapstructure:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" mapstructure:"base_backoff" env:"WEBHOOKS_BASE_BACKOFF"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" mapstructure:"max_backoff" env:"WEBHOOKS_MAX_BACKOFF"`
+	// Timeout bounds each request to an endpoint
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout" env:"WEBHOOKS_TIMEOUT"`
+}
+
+// DefaultWebhooksConfig returns default webhook configuration
+func DefaultWebhooksConfig() *WebhooksConfig {
+	return &WebhooksConfig{
+		Enabled:      false,
+		PollInterval: time.Second,
+		BatchSize:    50,
+		MaxAttempts:  8,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   6 * time.Hour,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// Validate validates webhook configuration
+func (c *WebhooksConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("webhooks poll_interval must be positive")
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("webhooks batch_size must be positive")
+	}
+	if c.MaxAttempts <= 0 {
+		return fmt.Errorf("webhooks max_attempts must be positive")
+	}
+	if c.BaseBackoff <= 0 || c.MaxBackoff < c.BaseBackoff {
+		return fmt.Errorf("webhooks backoff must satisfy 0 < base_backoff <= max_backoff")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("webhooks timeout must be positive")
+	}
+	return nil
+}
@@ -1,67 +1,176 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"time"
 
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
 	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/metrics"
 )
 
 // Connection manages database connection
 type Connection struct {
-	db     *gorm.DB
-	config *config.DatabaseConfig
+	db       *gorm.DB
+	config   *config.DatabaseConfig
+	resolver *Resolver
+	monitor  *Monitor
+	stop     context.CancelFunc
 }
 
-// NewConnection creates a new database connection
+// NewConnection creates a new database connection, plus one per configured
+// read replica
 func NewConnection(cfg *config.DatabaseConfig) (*Connection, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid database config: %w", err)
 	}
 
+	db, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	registerPoolMetrics(primaryPoolName, db)
+
+	replicas := make([]*Replica, 0, len(cfg.ReplicaHosts))
+	for _, hostPort := range cfg.ReplicaHosts {
+		replicaCfg, err := cfg.ReplicaConfig(hostPort)
+		if err != nil {
+			closeAll(db, replicas)
+			return nil, err
+		}
+		replicaDB, err := open(replicaCfg)
+		if err != nil {
+			closeAll(db, replicas)
+			return nil, fmt.Errorf("replica %s: %w", hostPort, err)
+		}
+		replicas = append(replicas, &Replica{Name: hostPort, DB: replicaDB})
+		registerPoolMetrics(hostPort, replicaDB)
+	}
+
+	resolver := NewResolver(db, replicas...)
+	ctx, stop := context.WithCancel(context.Background())
+	go resolver.Watch(ctx, cfg.ReplicaCheckInterval)
+
+	var monitor *Monitor
+	if cfg.MonitorInterval > 0 {
+		_, maxIdle := poolSize(cfg)
+		monitor = NewMonitor(primaryPoolName, db, maxIdle)
+		go monitor.Run(ctx, cfg.MonitorInterval)
+	}
+
+	return &Connection{
+		db:       db,
+		config:   cfg,
+		resolver: resolver,
+		monitor:  monitor,
+		stop:     stop,
+	}, nil
+}
+
+// NewConnectionFromDB wraps an already opened database without read
+// replicas, e.g. an in-memory SQLite database in tests. Close closes db.
+func NewConnectionFromDB(db *gorm.DB) *Connection {
+	return &Connection{
+		db:       db,
+		resolver: NewResolver(db),
+	}
+}
+
+// open connects to the database described by cfg and configures its pool
+func open(cfg *config.DatabaseConfig) (*gorm.DB, error) {
+	driver, err := LookupDriver(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+
 	// Configure GORM logger
 	gormLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags),
 		logger.Config{
-			SlowThreshold:             time.Second,
+			SlowThreshold:             0, // reported by Instrumentation without bound values
 			LogLevel:                  parseLogLevel(cfg.LogLevel),
 			IgnoreRecordNotFoundError: true,
+			ParameterizedQueries:      true, // bound values are never logged
 			Colorful:                  true,
 		},
 	)
 
 	// Open database connection
-	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+	db, err := gorm.Open(driver.Dialector(cfg.DSN()), &gorm.Config{
 		Logger:                                   gormLogger,
-		PrepareStmt:                              true,
+		PrepareStmt:                              cfg.PrepareStmt,
 		DisableForeignKeyConstraintWhenMigrating: false,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := db.Use(NewInstrumentation(cfg.SlowQueryThreshold)); err != nil {
+		return nil, fmt.Errorf("failed to register query instrumentation: %w", err)
+	}
+	if cfg.QueryTimeout > 0 {
+		if err := db.Use(NewQueryTimeout(cfg.QueryTimeout)); err != nil {
+			return nil, fmt.Errorf("failed to register query timeout: %w", err)
+		}
+	}
+
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
-	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	maxOpen, maxIdle := poolSize(cfg)
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetMaxIdleConns(maxIdle)
 	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
-	return &Connection{
-		db:     db,
-		config: cfg,
-	}, nil
+	return db, nil
+}
+
+// poolSize returns the connection limits of the pool described by cfg
+func poolSize(cfg *config.DatabaseConfig) (maxOpen, maxIdle int) {
+	if cfg.Driver == config.DriverSQLite && cfg.Database == config.SQLiteMemory {
+		// Connections to a shared in-memory database lock each other's
+		// tables, so they take turns on a single one
+		return 1, 1
+	}
+	return cfg.MaxOpenConns, cfg.MaxIdleConns
+}
+
+// primaryPoolName labels the primary's pool in metrics; replicas use their host
+const primaryPoolName = "primary"
+
+// registerPoolMetrics exports the pool statistics of db under name
+func registerPoolMetrics(name string, db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDBPool(name, sqlDB.Stats)
+	}
+}
+
+// closeAll releases connections opened before a later one failed
+func closeAll(primary *gorm.DB, replicas []*Replica) {
+	metrics.UnregisterDBPool(primaryPoolName)
+	for _, replica := range replicas {
+		metrics.UnregisterDBPool(replica.Name)
+	}
+
+	dbs := []*gorm.DB{primary}
+	for _, replica := range replicas {
+		dbs = append(dbs, replica.DB)
+	}
+	for _, db := range dbs {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
 }
 
 // DB returns the GORM database instance
@@ -69,6 +178,22 @@ func (c *Connection) DB() *gorm.DB {
 	return c.db
 }
 
+// Resolver returns the router between the primary and read replicas
+func (c *Connection) Resolver() *Resolver {
+	return c.resolver
+}
+
+// Monitor returns the background monitor of the primary, or nil when
+// database.monitor_interval is zero
+func (c *Connection) Monitor() *Monitor {
+	return c.monitor
+}
+
+// Driver returns the driver of the primary database
+func (c *Connection) Driver() Driver {
+	return DriverFor(c.db)
+}
+
 // Health checks database connectivity
 func (c *Connection) Health() error {
 	sqlDB, err := c.db.DB()
@@ -79,8 +204,31 @@ func (c *Connection) Health() error {
 	return sqlDB.Ping()
 }
 
-// Close closes the database connection
+// Ping checks database connectivity within ctx
+func (c *Connection) Ping(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+// Close stops replica health checks and closes all connections
 func (c *Connection) Close() error {
+	if c.stop != nil {
+		c.stop()
+	}
+	metrics.UnregisterDBPool(primaryPoolName)
+	if c.resolver != nil {
+		for _, replica := range c.resolver.Replicas() {
+			metrics.UnregisterDBPool(replica.Name)
+			if sqlDB, err := replica.DB.DB(); err == nil {
+				sqlDB.Close()
+			}
+		}
+	}
+
 	sqlDB, err := c.db.DB()
 	if err != nil {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
@@ -129,6 +277,7 @@ func parseLogLevel(level string) logger.LogLevel {
 // NewConnectionFromEnv creates database connection from environment variables
 func NewConnectionFromEnv() (*Connection, error) {
 	cfg := &config.DatabaseConfig{
+		Driver:          getEnvOrDefault("DB_DRIVER", config.DriverPostgres),
 		Host:            getEnvOrDefault("DB_HOST", "localhost"),
 		Port:            getEnvIntOrDefault("DB_PORT", 5432),
 		Username:        getEnvOrDefault("DB_USERNAME", "postgres"),
@@ -141,6 +290,8 @@ func NewConnectionFromEnv() (*Connection, error) {
 		ConnMaxLifetime: getEnvDurationOrDefault("DB_CONN_MAX_LIFETIME", time.Hour),
 		ConnMaxIdleTime: getEnvDurationOrDefault("DB_CONN_MAX_IDLE_TIME", time.Minute*30),
 		LogLevel:        getEnvOrDefault("DB_LOG_LEVEL", "info"),
+		PrepareStmt:     getEnvOrDefault("DB_PREPARE_STMT", "true") == "true",
+		QueryTimeout:    getEnvDurationOrDefault("DB_QUERY_TIMEOUT", 30*time.Second),
 	}
 
 	return NewConnection(cfg)
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/fieldcrypt"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// dataMigration rewrites rows in Go after the statements of a migration's
+// up file ran, in the same transaction, for changes SQL cannot express
+type dataMigration func(ctx context.Context, tx *sql.Tx, dialect string, log logger.Logger) error
+
+// dataMigrations are the data migrations of the embedded migrations, by
+// version
+var dataMigrations = map[uint]dataMigration{
+	26: backfillCanonicalEmails,
+}
+
+// duplicateEmailKeyPrefix starts the canonical email key of a user whose
+// address was already taken in its tenant when canonical emails were
+// backfilled. The key is followed by the user's ID.
+const duplicateEmailKeyPrefix = "duplicate:"
+
+// backfillCanonicalEmails sets the canonical email of users saved before
+// the column existed, under the default email rules. A canonical email
+// stays with the user already keyed by it, or else goes to the oldest user
+// sharing it; the others get a duplicateEmailKeyPrefix key so the unique
+// index holds. Their data is kept, but they cannot be found by email until they
+// are merged or their address is changed.
+func backfillCanonicalEmails(ctx context.Context, tx *sql.Tx, dialect string, log logger.Logger) error {
+	taken := make(map[string]bool)
+	keyed, err := tx.QueryContext(ctx, "SELECT tenant_id, canonical_email FROM users WHERE canonical_email IS NOT NULL")
+	if err != nil {
+		return err
+	}
+	for keyed.Next() {
+		var tenantID, key string
+		if err := keyed.Scan(&tenantID, &key); err != nil {
+			keyed.Close()
+			return err
+		}
+		taken[tenantID+"\x00"+key] = true
+	}
+	keyed.Close()
+	if err := keyed.Err(); err != nil {
+		return err
+	}
+
+	type pending struct{ id, tenantID, email string }
+	var users []pending
+	rows, err := tx.QueryContext(ctx, "SELECT id, tenant_id, email FROM users WHERE canonical_email IS NULL ORDER BY created_at, id")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var u pending
+		if err := rows.Scan(&u.id, &u.tenantID, &u.email); err != nil {
+			rows.Close()
+			return err
+		}
+		users = append(users, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	update := "UPDATE users SET canonical_email = ? WHERE id = ?"
+	if dialect == "postgres" {
+		update = "UPDATE users SET canonical_email = $1 WHERE id = $2"
+	}
+	keyring := FieldEncryption()
+	duplicates := 0
+	for _, u := range users {
+		email := u.email
+		if fieldcrypt.IsEncrypted(email) {
+			if keyring == nil {
+				return fmt.Errorf("user %s has an encrypted email but encryption is not configured", u.id)
+			}
+			if email, err = keyring.Decrypt(email); err != nil {
+				return fmt.Errorf("failed to decrypt email of user %s: %w", u.id, err)
+			}
+		}
+
+		key := CanonicalEmailKey(user.CanonicalizeEmail(email))
+		if taken[u.tenantID+"\x00"+key] {
+			duplicates++
+			log.Warn(ctx, "user shares its canonical email with an older user", "user_id", u.id)
+			key = duplicateEmailKeyPrefix + u.id
+		}
+		taken[u.tenantID+"\x00"+key] = true
+		if _, err := tx.ExecContext(ctx, update, key, u.id); err != nil {
+			return err
+		}
+	}
+
+	log.Info(ctx, "canonical emails backfilled", "users", len(users), "duplicates", duplicates)
+	return nil
+}
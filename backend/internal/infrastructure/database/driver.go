@@ -0,0 +1,158 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+)
+
+// Driver adapts the repository layer to a database engine: it opens
+// connections and supplies the SQL that differs between engines
+type Driver interface {
+	// Name is the config value selecting the driver and the name of its
+	// GORM dialector
+	Name() string
+	// Dialector opens the database at dsn
+	Dialector(dsn string) gorm.Dialector
+	// ContainsFold returns a condition matching rows whose column contains
+	// the single LIKE pattern argument, ignoring case. A backslash escapes
+	// the next character of the pattern, as in ContainsPattern.
+	ContainsFold(column string) string
+	// SkipLocked reports whether SELECT ... FOR UPDATE SKIP LOCKED is
+	// available
+	SkipLocked() bool
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// ContainsPattern returns the ContainsFold argument matching values that
+// contain s, with the wildcards in s matched literally
+func ContainsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+func init() {
+	RegisterDriver(postgresDriver{})
+	RegisterDriver(mysqlDriver{})
+	RegisterDriver(sqliteDriver{})
+}
+
+// RegisterDriver makes d available under d.Name(), replacing a driver
+// registered under the same name
+func RegisterDriver(d Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[d.Name()] = d
+}
+
+// LookupDriver returns the driver registered under name
+func LookupDriver(name string) (Driver, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	d, ok := drivers[name]
+	if !ok {
+		names := make([]string, 0, len(drivers))
+		for registered := range drivers {
+			names = append(names, registered)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown database driver %q, registered: %s", name, strings.Join(names, ", "))
+	}
+	return d, nil
+}
+
+// DriverFor returns the driver of an open database. Databases opened with
+// an unregistered dialector are treated as PostgreSQL.
+func DriverFor(db *gorm.DB) Driver {
+	if db != nil && db.Dialector != nil {
+		if d, err := LookupDriver(db.Dialector.Name()); err == nil {
+			return d
+		}
+	}
+	return postgresDriver{}
+}
+
+// IsDuplicateKey reports whether err is a unique constraint violation from
+// any registered driver
+func IsDuplicateKey(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	for _, d := range drivers {
+		if translator, ok := d.Dialector("").(gorm.ErrorTranslator); ok {
+			if errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+				return true
+			}
+		}
+	}
+
+	// Errors that lost their driver type on the way, e.g. through a retry
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "duplicate key value violates unique constraint") ||
+		strings.Contains(message, "unique constraint failed") ||
+		strings.Contains(message, "23505") ||
+		strings.Contains(message, "error 1062")
+}
+
+type postgresDriver struct{}
+
+func (postgresDriver) Name() string { return config.DriverPostgres }
+
+func (postgresDriver) Dialector(dsn string) gorm.Dialector { return postgres.Open(dsn) }
+
+func (postgresDriver) ContainsFold(column string) string { return column + " ILIKE ?" }
+
+func (postgresDriver) SkipLocked() bool { return true }
+
+type mysqlDriver struct{}
+
+func (mysqlDriver) Name() string { return config.DriverMySQL }
+
+func (mysqlDriver) Dialector(dsn string) gorm.Dialector { return mysql.Open(dsn) }
+
+// ContainsFold lowers both sides, as LIKE follows the column's collation,
+// which need not ignore case
+func (mysqlDriver) ContainsFold(column string) string {
+	return "LOWER(" + column + ") LIKE LOWER(?)"
+}
+
+// SkipLocked needs MySQL 8.0
+func (mysqlDriver) SkipLocked() bool { return true }
+
+// sqliteDriver uses github.com/mattn/go-sqlite3, which needs cgo
+type sqliteDriver struct{}
+
+func (sqliteDriver) Name() string { return config.DriverSQLite }
+
+func (sqliteDriver) Dialector(dsn string) gorm.Dialector { return sqlite.Open(dsn) }
+
+// ContainsFold lowers both sides so the condition does not depend on
+// PRAGMA case_sensitive_like. Only ASCII letters are folded. SQLite has no
+// default escape character, unlike PostgreSQL and MySQL.
+func (sqliteDriver) ContainsFold(column string) string {
+	return "LOWER(" + column + ") LIKE LOWER(?) ESCAPE '\\'"
+}
+
+// SkipLocked is false, as SQLite locks the whole database for writes
+func (sqliteDriver) SkipLocked() bool { return false }
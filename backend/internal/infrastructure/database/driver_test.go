@@ -0,0 +1,92 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+)
+
+func TestLookupDriver(t *testing.T) {
+	for _, name := range config.DatabaseDrivers {
+		d, err := LookupDriver(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, d.Name())
+	}
+
+	_, err := LookupDriver("oracle")
+	assert.ErrorContains(t, err, `unknown database driver "oracle"`)
+}
+
+func TestDriver_ContainsFold(t *testing.T) {
+	db := setupMigrationDB(t)
+	require.Equal(t, config.DriverSQLite, DriverFor(db).Name())
+
+	type person struct {
+		ID    uint
+		Email string
+	}
+	require.NoError(t, db.AutoMigrate(&person{}))
+	require.NoError(t, db.Create(&[]person{{Email: "Alice@Example.com"}, {Email: "bob@example.org"}, {Email: "50%_off@example.com"}}).Error)
+
+	var found []person
+	require.NoError(t, db.Where(DriverFor(db).ContainsFold("email"), ContainsPattern("alice@EXAMPLE")).Find(&found).Error)
+	require.Len(t, found, 1)
+	assert.Equal(t, "Alice@Example.com", found[0].Email)
+
+	// Wildcards in the search are matched literally
+	for _, search := range []string{"%", "_", "%_"} {
+		found = nil
+		require.NoError(t, db.Where(DriverFor(db).ContainsFold("email"), ContainsPattern(search)).Find(&found).Error)
+		require.Len(t, found, 1, search)
+		assert.Equal(t, "50%_off@example.com", found[0].Email)
+	}
+	found = nil
+	require.NoError(t, db.Where(DriverFor(db).ContainsFold("email"), ContainsPattern(`\`)).Find(&found).Error)
+	assert.Empty(t, found)
+	assert.Equal(t, `%a\%b\_c\\%`, ContainsPattern(`a%b_c\`))
+
+	assert.Equal(t, "email ILIKE ?", postgresDriver{}.ContainsFold("email"))
+}
+
+func TestIsDuplicateKey(t *testing.T) {
+	db := setupMigrationDB(t)
+	type account struct {
+		ID    uint
+		Email string `gorm:"uniqueIndex"`
+	}
+	require.NoError(t, db.AutoMigrate(&account{}))
+	require.NoError(t, db.Create(&account{Email: "a@example.com"}).Error)
+	err := db.Create(&account{Email: "a@example.com"}).Error
+	require.Error(t, err)
+
+	assert.True(t, IsDuplicateKey(err))
+	assert.True(t, IsDuplicateKey(&pgconn.PgError{Code: "23505"}))
+	assert.True(t, IsDuplicateKey(&gomysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.com' for key 'idx_users_tenant_email_unique'"}))
+	assert.False(t, IsDuplicateKey(&gomysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"}))
+	assert.True(t, IsDuplicateKey(fmt.Errorf("create: %w", err)))
+	assert.False(t, IsDuplicateKey(&pgconn.PgError{Code: "23503"}))
+	assert.False(t, IsDuplicateKey(errors.New("connection refused")))
+	assert.False(t, IsDuplicateKey(nil))
+}
+
+func TestNewConnection_SQLite(t *testing.T) {
+	cfg := config.DefaultDatabaseConfig()
+	cfg.Driver = config.DriverSQLite
+	cfg.Database = config.SQLiteMemory
+	cfg.LogLevel = "silent"
+
+	conn, err := NewConnection(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	assert.Equal(t, config.DriverSQLite, conn.Driver().Name())
+	require.NoError(t, conn.Health())
+	assert.Equal(t, 1, conn.Stats().(map[string]interface{})["max_open_connections"])
+}
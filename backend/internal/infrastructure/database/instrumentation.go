@@ -0,0 +1,137 @@
+package database
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/metrics"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const (
+	instrumentationName = "wonder:instrumentation"
+	startTimeKey        = "wonder:instrumentation:start"
+	// maxLoggedSQLLength keeps huge batch inserts out of the logs
+	maxLoggedSQLLength = 1000
+)
+
+// quotedValue matches the single-quoted literals drivers put in error
+// messages, such as MySQL's "Duplicate entry 'ada@example.com' for key"
+var quotedValue = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// Instrumentation is a GORM plugin that times every statement, exports the
+// durations as metrics and logs statements slower than a threshold. Logged
+// SQL carries placeholders, not the bound values, and values quoted in
+// database errors are masked.
+type Instrumentation struct {
+	slowThreshold time.Duration
+	log           logger.Logger
+}
+
+// NewInstrumentation creates the plugin. A zero slowThreshold disables slow
+// query logging.
+func NewInstrumentation(slowThreshold time.Duration) *Instrumentation {
+	return NewInstrumentationWithLogger(slowThreshold, logger.Get().WithLayer("infrastructure").WithComponent("database"))
+}
+
+// NewInstrumentationWithLogger creates the plugin with an explicit logger
+func NewInstrumentationWithLogger(slowThreshold time.Duration, log logger.Logger) *Instrumentation {
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+	return &Instrumentation{slowThreshold: slowThreshold, log: log}
+}
+
+// Name implements gorm.Plugin
+func (p *Instrumentation) Name() string {
+	return instrumentationName
+}
+
+// Initialize implements gorm.Plugin by wrapping every callback processor
+func (p *Instrumentation) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register(instrumentationName+":before_create", start),
+		cb.Create().After("gorm:create").Register(instrumentationName+":after_create", p.finish("create")),
+		cb.Query().Before("gorm:query").Register(instrumentationName+":before_query", start),
+		cb.Query().After("gorm:query").Register(instrumentationName+":after_query", p.finish("query")),
+		cb.Update().Before("gorm:update").Register(instrumentationName+":before_update", start),
+		cb.Update().After("gorm:update").Register(instrumentationName+":after_update", p.finish("update")),
+		cb.Delete().Before("gorm:delete").Register(instrumentationName+":before_delete", start),
+		cb.Delete().After("gorm:delete").Register(instrumentationName+":after_delete", p.finish("delete")),
+		cb.Row().Before("gorm:row").Register(instrumentationName+":before_row", start),
+		cb.Row().After("gorm:row").Register(instrumentationName+":after_row", p.finish("row")),
+		cb.Raw().Before("gorm:raw").Register(instrumentationName+":before_raw", start),
+		cb.Raw().After("gorm:raw").Register(instrumentationName+":after_raw", p.finish("raw")),
+	)
+}
+
+func start(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func (p *Instrumentation) finish(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		started, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		duration := time.Since(started)
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		err := db.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		slow := p.slowThreshold > 0 && duration >= p.slowThreshold
+
+		metrics.ObserveDBQuery(operation, table, err != nil, slow, duration.Seconds())
+
+		if !slow && !p.log.DebugEnabled() {
+			return
+		}
+
+		ctx := db.Statement.Context
+		keyvals := []interface{}{
+			"operation", operation,
+			"table", table,
+			"duration_ms", duration.Milliseconds(),
+			"rows_affected", db.Statement.RowsAffected,
+			"sql", sanitizeSQL(db.Statement.SQL.String()),
+		}
+		if err != nil {
+			keyvals = append(keyvals, "error", sanitizeError(err))
+		}
+
+		if slow {
+			p.log.Warn(ctx, "slow database query", append(keyvals, "threshold_ms", p.slowThreshold.Milliseconds())...)
+			return
+		}
+		p.log.Debug(ctx, "database operation", keyvals...)
+	}
+}
+
+// sanitizeSQL collapses whitespace and truncates the statement
+func sanitizeSQL(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > maxLoggedSQLLength {
+		sql = sql[:maxLoggedSQLLength] + "..."
+	}
+	return sql
+}
+
+// sanitizeError masks the values a driver quoted in the error message
+func sanitizeError(err error) string {
+	return quotedValue.ReplaceAllString(err.Error(), "'?'")
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wonderLogger "github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestInstrumentation_LogsSlowQueries(t *testing.T) {
+	db := setupMigrationDB(t)
+	logPath := filepath.Join(t.TempDir(), "db.log")
+	log := wonderLogger.NewLoggerWithConfig(wonderLogger.LogConfig{
+		Level:      "warn",
+		Format:     "json",
+		Output:     "file",
+		FilePath:   logPath,
+		EnableFile: true,
+	})
+	require.NoError(t, db.Use(NewInstrumentationWithLogger(time.Nanosecond, log)))
+	require.NoError(t, db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, secret TEXT)").Error)
+
+	ctx := context.WithValue(context.Background(), "trace_id", "trace-123")
+	require.NoError(t, db.WithContext(ctx).Exec("INSERT INTO items (id, secret) VALUES (?, ?)", 1, "hunter2").Error)
+
+	var count int64
+	require.NoError(t, db.WithContext(ctx).Table("items").Where("secret = ?", "hunter2").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	logs := string(data)
+	assert.Contains(t, logs, "slow database query")
+	assert.Contains(t, logs, `"trace_id":"trace-123"`)
+	assert.Contains(t, logs, `"table":"items"`)
+	assert.Contains(t, logs, `"rows_affected":1`)
+	assert.NotContains(t, logs, "hunter2", "bound values are never logged")
+}
+
+func TestInstrumentation_BelowThreshold(t *testing.T) {
+	db := setupMigrationDB(t)
+	logPath := filepath.Join(t.TempDir(), "db.log")
+	log := wonderLogger.NewLoggerWithConfig(wonderLogger.LogConfig{
+		Level:      "info",
+		Format:     "json",
+		Output:     "file",
+		FilePath:   logPath,
+		EnableFile: true,
+	})
+	require.NoError(t, db.Use(NewInstrumentationWithLogger(time.Hour, log)))
+	require.NoError(t, db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)").Error)
+
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(string(data)))
+}
+
+func TestSanitizeError(t *testing.T) {
+	assert.Equal(t, "Error 1062 (23000): Duplicate entry '?' for key '?'",
+		sanitizeError(errors.New("Error 1062 (23000): Duplicate entry 'ada@example.com' for key 'users.idx_users_email_unique'")))
+	assert.Equal(t, "Incorrect integer value: '?' for column '?'",
+		sanitizeError(errors.New("Incorrect integer value: 'it''s' for column 'id'")))
+	assert.Equal(t, "UNIQUE constraint failed: users.email", sanitizeError(errors.New("UNIQUE constraint failed: users.email")))
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	assert.Equal(t, "SELECT * FROM users WHERE id = $1", sanitizeSQL("SELECT *\n  FROM users\n\tWHERE id = $1"))
+	assert.Len(t, sanitizeSQL(strings.Repeat("x ", 2000)), maxLoggedSQLLength+3)
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// MigrateUsage describes the migrate subcommand
+const MigrateUsage = `Usage: server migrate [-config path | -env name] <command>
+
+Commands:
+  up             apply all pending migrations
+  down [N]       roll back the last N migrations (default 1)
+  version        print the applied version and dirty state
+  status         list migrations and whether each is applied
+  force VERSION  record VERSION as applied and clear the dirty flag
+`
+
+// RunCommand runs one command of the server's migrate subcommand against
+// m, writing results to out. See MigrateUsage for the commands.
+func (m *Migrator) RunCommand(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n\n%s", MigrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := m.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("down takes a positive number of steps, got %q", args[1])
+			}
+			steps = n
+		}
+		if err := m.Down(ctx, steps); err != nil && !errors.Is(err, ErrNoChange) {
+			return err
+		}
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("force requires a version")
+		}
+		v, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := m.Force(ctx, uint(v)); err != nil {
+			return err
+		}
+	case "status":
+		version, dirty, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.Migrations() {
+			state := "pending"
+			switch {
+			case mig.Version == version && dirty:
+				state = "dirty"
+			case mig.Version <= version:
+				state = "applied"
+			}
+			fmt.Fprintf(out, "%04d_%s\t%s\n", mig.Version, mig.Name, state)
+		}
+		return nil
+	case "version":
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], MigrateUsage)
+	}
+
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "version %d (latest %d)", version, m.Latest())
+	if dirty {
+		fmt.Fprint(out, ", dirty")
+	}
+	fmt.Fprintln(out)
+	return nil
+}
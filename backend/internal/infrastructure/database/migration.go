@@ -1,124 +1,514 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
 
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
 	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database/migrations"
+	"github.com/cctw-zed/wonder/pkg/logger"
 )
 
-// Migrator handles database migrations
+// SchemaVersionTable records the applied migration version and whether the
+// last migration was interrupted
+const SchemaVersionTable = "schema_version"
+
+// migrationLockKey is the Postgres advisory lock, and names the MySQL named
+// lock, held while migrating, so replicas starting together do not race
+const migrationLockKey = 727_100_281
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// dialectDirective restricts the statement it precedes to a comma-separated
+// list of databases, e.g. "-- dialect: postgres" for extensions and index
+// types SQLite lacks
+var dialectDirective = regexp.MustCompile(`(?m)^\s*--\s*dialect:\s*(\w+(?:\s*,\s*\w+)*)\s*$`)
+
+// dialectRewrites adapt the Postgres statements to other databases. The
+// SQLite driver only returns time.Time for columns declared DATETIME, DATE or
+// TIMESTAMP; MySQL has no IF NOT EXISTS for indexes and keeps microseconds
+// only when asked to.
+var dialectRewrites = map[string]*strings.Replacer{
+	"sqlite": strings.NewReplacer("TIMESTAMPTZ", "DATETIME"),
+	"mysql":  strings.NewReplacer("TIMESTAMPTZ", "DATETIME(6)", "BYTEA", "LONGBLOB", "INDEX IF NOT EXISTS", "INDEX"),
+}
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// DirtyError reports a migration that started but did not finish. The
+// schema must be repaired by hand and the version forced before migrating
+// again.
+type DirtyError struct {
+	Version uint
+}
+
+func (e *DirtyError) Error() string {
+	return fmt.Sprintf("database schema is dirty at version %d: repair it manually, then run `migrate force <version>`", e.Version)
+}
+
+// ErrNoChange is returned by Down when no migration is applied
+var ErrNoChange = errors.New("no migration to roll back")
+
+// Migrator applies versioned SQL migrations and tracks the schema version
 type Migrator struct {
-	db *gorm.DB
+	db         *gorm.DB
+	migrations []Migration
+	data       map[uint]dataMigration
+	log        logger.Logger
 }
 
-// NewMigrator creates a new database migrator
+// NewMigrator creates a migrator for the embedded migrations and their data
+// migrations
 func NewMigrator(db *gorm.DB) *Migrator {
-	return &Migrator{db: db}
+	m, err := NewMigratorWithSource(db, migrations.FS)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded migrations: %v", err))
+	}
+	m.data = dataMigrations
+	return m
 }
 
-// MigrateAll runs all database migrations
-func (m *Migrator) MigrateAll() error {
-	if err := m.migrateUserTable(); err != nil {
-		return fmt.Errorf("failed to migrate user table: %w", err)
+// NewMigratorWithSource creates a migrator for the migration files in source
+func NewMigratorWithSource(db *gorm.DB, source fs.FS) (*Migrator, error) {
+	if db == nil {
+		panic("database connection cannot be nil")
 	}
 
-	return nil
+	list, err := loadMigrations(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: list,
+		log:        logger.Get().WithLayer("infrastructure").WithComponent("migrator"),
+	}, nil
 }
 
-// migrateUserTable creates or updates the users table
-func (m *Migrator) migrateUserTable() error {
-	// Check if the table exists
-	hasTable := m.db.Migrator().HasTable(&user.User{})
+// loadMigrations reads and orders the migration files in source. Every
+// version needs both an up and a down file.
+func loadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s must be named NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration file %s has an invalid version", entry.Name())
+		}
 
-	if hasTable {
-		// Check if password_hash column exists
-		hasPasswordHash := m.db.Migrator().HasColumn(&user.User{}, "password_hash")
+		body, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
 
-		if !hasPasswordHash {
-			// First, add the column as nullable
-			if err := m.db.Exec("ALTER TABLE users ADD COLUMN password_hash VARCHAR(255) NULL").Error; err != nil {
-				return fmt.Errorf("failed to add password_hash column: %w", err)
-			}
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs non-empty up and down files", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Migrations returns all known migrations in version order
+func (m *Migrator) Migrations() []Migration {
+	return append([]Migration(nil), m.migrations...)
+}
+
+// Latest returns the highest known migration version
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the applied schema version and whether the last migration
+// was interrupted. Zero means no migration has been applied.
+func (m *Migrator) Version(ctx context.Context) (version uint, dirty bool, err error) {
+	err = m.withConn(ctx, false, func(conn *sql.Conn) error {
+		version, dirty, err = readVersion(ctx, conn)
+		return err
+	})
+	return version, dirty, err
+}
+
+// Pending returns the migrations not yet applied
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, &DirtyError{Version: version}
+	}
+	return m.after(version), nil
+}
+
+// Check returns an error unless the schema is clean and fully migrated
+func (m *Migrator) Check(ctx context.Context) error {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is behind: %d migration(s) pending up to version %d, run `migrate up`", len(pending), m.Latest())
+	}
+	return nil
+}
+
+// Up applies every pending migration in order
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withConn(ctx, true, func(conn *sql.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return &DirtyError{Version: version}
+		}
+		if _, err := m.index(version); err != nil {
+			return err
+		}
 
-			// Set a default password hash for existing users (they'll need to reset their password)
-			defaultPasswordHash := "$2a$10$CwTycUXWue0Thq9StjUM0uNzZCbF8FFdQLJkN8XWNq3xKWbkjT2Ba" // "changeme"
-			if err := m.db.Exec("UPDATE users SET password_hash = ? WHERE password_hash IS NULL", defaultPasswordHash).Error; err != nil {
-				return fmt.Errorf("failed to set default password hash: %w", err)
+		for _, mig := range m.after(version) {
+			m.log.Info(ctx, "applying migration", "version", mig.Version, "name", mig.Name)
+			if err := m.apply(ctx, conn, mig.Version, mig.Version, mig.Up, m.data[mig.Version]); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", mig.Version, mig.Name, err)
 			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the latest steps applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive")
+	}
+
+	return m.withConn(ctx, true, func(conn *sql.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return &DirtyError{Version: version}
+		}
+		if version == 0 {
+			return ErrNoChange
+		}
 
-			// Now make it NOT NULL
-			if err := m.db.Exec("ALTER TABLE users ALTER COLUMN password_hash SET NOT NULL").Error; err != nil {
-				return fmt.Errorf("failed to make password_hash NOT NULL: %w", err)
+		i, err := m.index(version)
+		if err != nil {
+			return err
+		}
+		for ; steps > 0 && i >= 0; steps, i = steps-1, i-1 {
+			mig := m.migrations[i]
+			var previous uint
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			m.log.Info(ctx, "rolling back migration", "version", mig.Version, "name", mig.Name)
+			if err := m.apply(ctx, conn, mig.Version, previous, mig.Down, nil); err != nil {
+				return fmt.Errorf("rollback of %04d_%s failed: %w", mig.Version, mig.Name, err)
 			}
 		}
+		return nil
+	})
+}
+
+// Force records version as applied and clears the dirty flag without
+// running any migration. Use it after repairing a failed migration by hand.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if _, err := m.index(version); err != nil {
+		return err
+	}
+	return m.withConn(ctx, true, func(conn *sql.Conn) error {
+		m.log.Warn(ctx, "forcing schema version", "version", version)
+		return writeVersion(ctx, conn, version, false)
+	})
+}
+
+// DropAll rolls back every applied migration (use with caution!)
+func (m *Migrator) DropAll(ctx context.Context) error {
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	i, err := m.index(version)
+	if err != nil || i < 0 {
+		return err
+	}
+	return m.Down(ctx, i+1)
+}
+
+// apply marks the schema dirty at from, runs the statements and then data,
+// if any, in a transaction and records to as the clean version. A failure
+// leaves the dirty flag set.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, from, to uint, script string, data dataMigration) error {
+	if err := writeVersion(ctx, conn, from, true); err != nil {
+		return err
 	}
 
-	// Run the normal auto-migration
-	if err := m.db.AutoMigrate(&user.User{}); err != nil {
-		return fmt.Errorf("failed to auto-migrate User model: %w", err)
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(script) {
+		if !runsOn(stmt, m.db.Dialector.Name()) {
+			continue
+		}
+		if rewrite, ok := dialectRewrites[m.db.Dialector.Name()]; ok {
+			stmt = rewrite.Replace(stmt)
+		}
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if data != nil {
+		if err := data(ctx, tx, m.db.Dialector.Name(), m.log); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return writeVersion(ctx, conn, to, false)
+}
 
+// after returns the migrations newer than version
+func (m *Migrator) after(version uint) []Migration {
+	for i, mig := range m.migrations {
+		if mig.Version > version {
+			return m.migrations[i:]
+		}
+	}
 	return nil
 }
 
-// DropAll drops all tables (use with caution!)
-func (m *Migrator) DropAll() error {
-	if err := m.db.Migrator().DropTable(&user.User{}); err != nil {
-		return fmt.Errorf("failed to drop user table: %w", err)
+// index returns the position of version in the migration list, or -1 for
+// version zero
+func (m *Migrator) index(version uint) (int, error) {
+	if version == 0 {
+		return -1, nil
+	}
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i, nil
+		}
 	}
+	return 0, fmt.Errorf("schema version %d is unknown to this build (latest is %d)", version, m.Latest())
+}
 
-	return nil
+// migrationLock returns the statements taking and releasing the lock that
+// serializes migrators, or "" when dialect has none
+func migrationLock(dialect string) (acquire, release string) {
+	switch dialect {
+	case "postgres":
+		return fmt.Sprintf("SELECT pg_advisory_lock(%d)", migrationLockKey),
+			fmt.Sprintf("SELECT pg_advisory_unlock(%d)", migrationLockKey)
+	case "mysql":
+		return fmt.Sprintf("SELECT GET_LOCK('wonder_migrate_%d', -1)", migrationLockKey),
+			fmt.Sprintf("SELECT RELEASE_LOCK('wonder_migrate_%d')", migrationLockKey)
+	}
+	return "", ""
 }
 
-// CreateIndexes creates additional database indexes
-func (m *Migrator) CreateIndexes() error {
-	// Email index is handled by uniqueIndex tag in the model, skip manual creation
+// withConn runs fn on a dedicated connection after creating the version
+// table. With lock set on Postgres and MySQL, a database lock serializes
+// migrators.
+func (m *Migrator) withConn(ctx context.Context, lock bool, fn func(conn *sql.Conn) error) error {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	// Migrations may outlast statement_timeout; RESET restores the value
+	// the session started with before the connection returns to the pool
+	if m.db.Dialector.Name() == "postgres" {
+		if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+			return fmt.Errorf("failed to lift statement timeout: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "RESET statement_timeout")
+	}
 
-	// Create created_at index for time-based queries
-	if !m.db.Migrator().HasIndex(&user.User{}, "created_at") {
-		if err := m.db.Migrator().CreateIndex(&user.User{}, "created_at"); err != nil {
-			return fmt.Errorf("failed to create created_at index: %w", err)
+	if lock {
+		acquire, release := migrationLock(m.db.Dialector.Name())
+		if acquire != "" {
+			if _, err := conn.ExecContext(ctx, acquire); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			defer conn.ExecContext(context.Background(), release)
 		}
 	}
 
-	return nil
+	create := "CREATE TABLE IF NOT EXISTS " + SchemaVersionTable + " (version BIGINT NOT NULL, dirty BOOLEAN NOT NULL)"
+	if _, err := conn.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", SchemaVersionTable, err)
+	}
+	return fn(conn)
 }
 
-// CheckTables verifies that all required tables exist
-func (m *Migrator) CheckTables() error {
-	if !m.db.Migrator().HasTable(&user.User{}) {
-		return fmt.Errorf("users table does not exist")
+func readVersion(ctx context.Context, conn *sql.Conn) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM "+SchemaVersionTable+" LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
 	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
 
-	return nil
+// writeVersion replaces the single version row. Values are formatted into
+// the statement because placeholder syntax differs between drivers.
+func writeVersion(ctx context.Context, conn *sql.Conn, version uint, dirty bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM "+SchemaVersionTable); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to write schema version: %w", err)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES (%d, %t)", SchemaVersionTable, version, dirty)
+	if _, err := tx.ExecContext(ctx, insert); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to write schema version: %w", err)
+	}
+	return tx.Commit()
+}
+
+// splitStatements splits a script on semicolons that end a line and drops
+// comment-only chunks
+func splitStatements(script string) []string {
+	var stmts []string
+	var current strings.Builder
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		current.Reset()
+		if stmt == "" {
+			return
+		}
+		for _, line := range strings.Split(stmt, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+				stmts = append(stmts, stmt)
+				return
+			}
+		}
+	}
+
+	for _, line := range strings.SplitAfter(script, "\n") {
+		current.WriteString(line)
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			flush()
+		}
+	}
+	flush()
+	return stmts
+}
+
+// statementDialect returns the databases a statement is restricted to by a
+// dialect directive, or "" when it runs everywhere
+func statementDialect(stmt string) string {
+	if match := dialectDirective.FindStringSubmatch(stmt); match != nil {
+		return match[1]
+	}
+	return ""
 }
 
-// GetTableStatus returns information about table structure
-func (m *Migrator) GetTableStatus() (map[string]interface{}, error) {
-	status := make(map[string]interface{})
+// runsOn reports whether stmt runs on dialect
+func runsOn(stmt, dialect string) bool {
+	restricted := statementDialect(stmt)
+	if restricted == "" {
+		return true
+	}
+	for _, name := range strings.Split(restricted, ",") {
+		if strings.TrimSpace(name) == dialect {
+			return true
+		}
+	}
+	return false
+}
 
-	// Check if users table exists
-	status["users_table_exists"] = m.db.Migrator().HasTable(&user.User{})
+// CheckTables verifies that all required tables exist
+func (m *Migrator) CheckTables() error {
+	if !m.db.Migrator().HasTable(&user.User{}) {
+		return fmt.Errorf("users table does not exist")
+	}
 
-	// Check columns
-	userColumns := []string{"id", "email", "name", "created_at", "updated_at"}
-	existingColumns := make(map[string]bool)
+	if !m.db.Migrator().HasTable(&user.BootstrapMarker{}) {
+		return fmt.Errorf("bootstrap_markers table does not exist")
+	}
 
-	for _, column := range userColumns {
-		existingColumns[column] = m.db.Migrator().HasColumn(&user.User{}, column)
+	if !m.db.Migrator().HasTable(&event.OutboxMessage{}) {
+		return fmt.Errorf("outbox_messages table does not exist")
 	}
-	status["users_columns"] = existingColumns
 
-	// Check indexes
-	userIndexes := []string{"email", "created_at"}
-	existingIndexes := make(map[string]bool)
+	if !m.db.Migrator().HasTable(&audit.Entry{}) {
+		return fmt.Errorf("audit_logs table does not exist")
+	}
 
-	for _, index := range userIndexes {
-		existingIndexes[index] = m.db.Migrator().HasIndex(&user.User{}, index)
+	if !m.db.Migrator().HasTable(&tenant.Tenant{}) {
+		return fmt.Errorf("tenants table does not exist")
 	}
-	status["users_indexes"] = existingIndexes
 
-	return status, nil
+	return nil
 }
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	wonderLogger "github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func setupMigrationDB(t *testing.T) *gorm.DB {
+	wonderLogger.Initialize()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestMigrator_EmbeddedUpAndDown(t *testing.T) {
+	db := setupMigrationDB(t)
+	ctx := context.Background()
+	m := NewMigrator(db)
+
+	pending, err := m.Pending(ctx)
+	require.NoError(t, err)
+	assert.Len(t, pending, len(m.Migrations()))
+	assert.Error(t, m.Check(ctx))
+
+	require.NoError(t, m.Up(ctx))
+	require.NoError(t, m.CheckTables())
+	require.NoError(t, m.Check(ctx))
+
+	version, dirty, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, m.Latest(), version)
+	assert.False(t, dirty)
+
+	// Running again is a no-op
+	require.NoError(t, m.Up(ctx))
+
+	require.NoError(t, m.DropAll(ctx))
+	assert.False(t, db.Migrator().HasTable("users"))
+	version, _, err = m.Version(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, version)
+	assert.ErrorIs(t, m.Down(ctx, 1), ErrNoChange)
+}
+
+func TestMigrator_TenantsDownRestoresEmailIndex(t *testing.T) {
+	db := setupMigrationDB(t)
+	ctx := context.Background()
+	m := NewMigrator(db)
+	require.NoError(t, m.Up(ctx))
+	require.NoError(t, m.Down(ctx, int(m.Latest())-5))
+	assert.False(t, db.Migrator().HasIndex("users", "idx_users_email_unique"))
+
+	// Users of different tenants sharing an email cannot be rolled back
+	insert := "INSERT INTO users (id, tenant_id, email, name, password_hash, created_at, updated_at) VALUES (?, ?, 'ada@example.com', 'Ada', 'hash', ?, ?)"
+	now := time.Now()
+	require.NoError(t, db.Exec(insert, "u-1", "default", now, now).Error)
+	require.NoError(t, db.Exec(insert, "u-2", "acme", now, now).Error)
+	assert.Error(t, m.Down(ctx, 1))
+	assert.True(t, db.Migrator().HasIndex("users", "idx_users_tenant_email_unique"), "a failed rollback keeps the schema")
+
+	require.NoError(t, db.Exec("DELETE FROM users WHERE id = 'u-2'").Error)
+	require.NoError(t, m.Force(ctx, 5))
+	require.NoError(t, m.Down(ctx, 1))
+	assert.True(t, db.Migrator().HasIndex("users", "idx_users_email_unique"))
+	assert.False(t, db.Migrator().HasIndex("users", "idx_users_tenant_email_unique"))
+	assert.False(t, db.Migrator().HasColumn("users", "tenant_id"))
+	err := db.Exec("INSERT INTO users (id, email, name, password_hash, created_at, updated_at) VALUES ('u-3', 'ada@example.com', 'Ada', 'hash', ?, ?)", now, now).Error
+	assert.Error(t, err, "emails are unique again")
+}
+
+func TestMigrator_BackfillsCanonicalEmails(t *testing.T) {
+	db := setupMigrationDB(t)
+	ctx := context.Background()
+	m := NewMigrator(db)
+	require.NoError(t, m.Up(ctx))
+	require.NoError(t, m.Down(ctx, 2))
+
+	// Users saved before canonical emails existed, newest first
+	insert := "INSERT INTO users (id, tenant_id, email, name, password_hash, created_at, updated_at) VALUES (?, ?, ?, 'User', 'hash', ?, ?)"
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for i, u := range [][3]string{
+		{"u-4", "default", "grace@example.com"},
+		{"u-3", "acme", "ada@example.com"},
+		{"u-2", "default", "ada@example.com"},
+		{"u-1", "default", " Ada@Example.com"},
+	} {
+		at := start.Add(-time.Duration(i) * time.Minute)
+		require.NoError(t, db.Exec(insert, u[0], u[1], u[2], at, at).Error)
+	}
+	require.NoError(t, db.Exec("UPDATE users SET canonical_email = 'grace@example.com' WHERE id = 'u-4'").Error)
+
+	require.NoError(t, m.Up(ctx))
+
+	keys := map[string]string{}
+	rows, err := db.Raw("SELECT id, canonical_email FROM users").Rows()
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id, key string
+		require.NoError(t, rows.Scan(&id, &key))
+		keys[id] = key
+	}
+	assert.Equal(t, map[string]string{
+		"u-1": "ada@example.com",
+		"u-2": "duplicate:u-2",
+		"u-3": "ada@example.com",
+		"u-4": "grace@example.com",
+	}, keys, "the oldest user keeps a shared canonical email")
+}
+
+func TestMigrator_DirtyState(t *testing.T) {
+	db := setupMigrationDB(t)
+	ctx := context.Background()
+
+	source := fstest.MapFS{
+		"0001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);\n")},
+		"0001_create_items.down.sql": {Data: []byte("DROP TABLE items;\n")},
+		"0002_broken.up.sql":         {Data: []byte("-- adds a column\nALTER TABLE items ADD COLUMN name TEXT;\nALTER TABLE missing ADD COLUMN x TEXT;\n")},
+		"0002_broken.down.sql":       {Data: []byte("ALTER TABLE items DROP COLUMN name;\n")},
+	}
+	m, err := NewMigratorWithSource(db, source)
+	require.NoError(t, err)
+
+	err = m.Up(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 0002_broken failed")
+	assert.False(t, db.Migrator().HasColumn("items", "name"), "the failed migration is rolled back")
+
+	version, dirty, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), version)
+	assert.True(t, dirty)
+
+	var dirtyErr *DirtyError
+	assert.ErrorAs(t, m.Up(ctx), &dirtyErr)
+	assert.ErrorAs(t, m.Check(ctx), &dirtyErr)
+
+	require.NoError(t, m.Force(ctx, 1))
+	version, dirty, err = m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), version)
+	assert.False(t, dirty)
+	assert.Error(t, m.Force(ctx, 7), "unknown versions cannot be forced")
+}
+
+func TestMigrator_RunCommand(t *testing.T) {
+	db := setupMigrationDB(t)
+	ctx := context.Background()
+	m := NewMigrator(db)
+
+	var out bytes.Buffer
+	require.NoError(t, m.RunCommand(ctx, []string{"up"}, &out))
+	assert.Equal(t, "version 27 (latest 27)\n", out.String())
+
+	out.Reset()
+	require.NoError(t, m.RunCommand(ctx, []string{"down", "2"}, &out))
+	assert.Equal(t, "version 25 (latest 27)\n", out.String())
+
+	out.Reset()
+	require.NoError(t, m.RunCommand(ctx, []string{"status"}, &out))
+	assert.Equal(t, "0001_create_users\tapplied\n"+
+		"0002_create_bootstrap_markers\tapplied\n"+
+		"0003_create_outbox_messages\tapplied\n"+
+		"0004_create_audit_logs\tapplied\n"+
+		"0005_add_tenants\tapplied\n"+
+		"0006_add_user_search\tapplied\n"+
+		"0007_add_user_list_indexes\tapplied\n"+
+		"0008_add_user_status\tapplied\n"+
+		"0009_add_user_deletion_schedule\tapplied\n"+
+		"0010_create_data_exports\tapplied\n"+
+		"0011_add_user_pii_encryption\tapplied\n"+
+		"0012_create_webhooks\tapplied\n"+
+		"0013_create_mfa_enrollments\tapplied\n"+
+		"0014_create_trusted_devices\tapplied\n"+
+		"0015_add_audit_ip_address\tapplied\n"+
+		"0016_add_user_avatar\tapplied\n"+
+		"0017_add_user_canonical_email\tapplied\n"+
+		"0018_add_user_handle\tapplied\n"+
+		"0019_add_user_locale\tapplied\n"+
+		"0020_create_user_preferences\tapplied\n"+
+		"0021_create_notifications\tapplied\n"+
+		"0022_create_organizations\tapplied\n"+
+		"0023_add_invitation_revoked_at\tapplied\n"+
+		"0024_add_mfa_enrollment_version\tapplied\n"+
+		"0025_add_user_external_auth\tapplied\n"+
+		"0026_backfill_canonical_emails\tpending\n"+
+		"0027_add_user_email_verified_at\tpending\n", out.String())
+
+	assert.Error(t, m.RunCommand(ctx, []string{"down", "zero"}, &out))
+	assert.Error(t, m.RunCommand(ctx, []string{"sideways"}, &out))
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	_, err := loadMigrations(fstest.MapFS{"create_users.sql": {Data: []byte("CREATE TABLE users (id INT);")}})
+	assert.ErrorContains(t, err, "must be named")
+
+	_, err = loadMigrations(fstest.MapFS{"0001_users.up.sql": {Data: []byte("CREATE TABLE users (id INT);")}})
+	assert.ErrorContains(t, err, "needs non-empty up and down files")
+}
+
+func TestMigrator_DialectDirective(t *testing.T) {
+	db := setupMigrationDB(t)
+	ctx := context.Background()
+
+	source := fstest.MapFS{
+		"0001_create_items.up.sql": {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);\n" +
+			"-- dialect: postgres\nCREATE INDEX idx_items_name ON items USING gin (name gin_trgm_ops);\n" +
+			"-- dialect: sqlite\nCREATE INDEX idx_items_id_name ON items (id, name);\n")},
+		"0001_create_items.down.sql": {Data: []byte("DROP TABLE items;\n")},
+	}
+	m, err := NewMigratorWithSource(db, source)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Up(ctx))
+	assert.True(t, db.Migrator().HasIndex("items", "idx_items_id_name"))
+	assert.False(t, db.Migrator().HasIndex("items", "idx_items_name"))
+
+	assert.Equal(t, "postgres", statementDialect("-- trigram index\n-- dialect: postgres\nCREATE INDEX x ON t (c);"))
+	assert.Empty(t, statementDialect("CREATE INDEX x ON t (c);"))
+
+	dropIndex := "-- dialect: postgres, sqlite\nDROP INDEX IF EXISTS x;"
+	assert.True(t, runsOn(dropIndex, "sqlite"))
+	assert.False(t, runsOn(dropIndex, "mysql"))
+	assert.True(t, runsOn("CREATE INDEX x ON t (c);", "mysql"))
+}
+
+func TestDialectRewrites_MySQL(t *testing.T) {
+	assert.Equal(t, "CREATE UNIQUE INDEX x ON t (c, at DATETIME(6), data LONGBLOB);",
+		dialectRewrites["mysql"].Replace("CREATE UNIQUE INDEX IF NOT EXISTS x ON t (c, at TIMESTAMPTZ, data BYTEA);"))
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := "-- header\nCREATE TABLE a (\n  id INT\n);\n\nINSERT INTO a VALUES (1); \n-- trailing comment\n"
+	assert.Equal(t, []string{
+		"-- header\nCREATE TABLE a (\n  id INT\n);",
+		"INSERT INTO a VALUES (1);",
+	}, splitStatements(script))
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS bootstrap_markers;
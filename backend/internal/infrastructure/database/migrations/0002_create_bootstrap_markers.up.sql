@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS bootstrap_markers (
+    step VARCHAR(64) PRIMARY KEY,
+    subject_id VARCHAR(64) NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id VARCHAR(64) PRIMARY KEY,
+    idempotency_key VARCHAR(64) NOT NULL,
+    event_name VARCHAR(100) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error TEXT,
+    published_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_idempotency_key ON outbox_messages (idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_outbox_status_next_attempt ON outbox_messages (status, next_attempt_at);
@@ -0,0 +1,12 @@
+-- Restore the email index the up migration dropped before anything else,
+-- so that rolling back while users of different tenants share an email
+-- fails without changing the schema, on MySQL too.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_unique ON users (email);
+
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_tenant_email_unique;
+-- dialect: mysql
+DROP INDEX idx_users_tenant_email_unique ON users;
+
+ALTER TABLE users DROP COLUMN tenant_id;
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(64) PRIMARY KEY,
+    slug VARCHAR(63) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenants_slug_unique ON tenants (slug);
+
+INSERT INTO tenants (id, slug, name, created_at, updated_at)
+VALUES ('default', 'default', 'Default', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+ALTER TABLE users ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_email_unique;
+-- dialect: mysql
+DROP INDEX idx_users_email_unique ON users;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email_unique ON users (tenant_id, email);
@@ -0,0 +1,8 @@
+-- dialect: postgres
+DROP INDEX IF EXISTS idx_users_search;
+
+-- dialect: postgres
+DROP INDEX IF EXISTS idx_users_email_trgm;
+
+-- dialect: postgres
+DROP INDEX IF EXISTS idx_users_name_trgm;
@@ -0,0 +1,16 @@
+-- Trigram matching for fuzzy search on name and email
+-- dialect: postgres
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- dialect: postgres
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING gin (name gin_trgm_ops);
+
+-- dialect: postgres
+CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING gin (email gin_trgm_ops);
+
+-- Full-text search over name and the parts of the email address. The
+-- expression must match the one used by the user search query.
+-- dialect: postgres
+CREATE INDEX IF NOT EXISTS idx_users_search ON users USING gin (
+    to_tsvector('simple', name || ' ' || translate(email, '@.+_-', '     '))
+);
@@ -0,0 +1,19 @@
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_tenant_role;
+-- dialect: mysql
+DROP INDEX idx_users_tenant_role ON users;
+
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_tenant_name;
+-- dialect: mysql
+DROP INDEX idx_users_tenant_name ON users;
+
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_tenant_updated_at;
+-- dialect: mysql
+DROP INDEX idx_users_tenant_updated_at ON users;
+
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_tenant_created_at;
+-- dialect: mysql
+DROP INDEX idx_users_tenant_created_at ON users;
@@ -0,0 +1,6 @@
+-- Sorting and keyset pagination of user lists within a tenant. Sorting by
+-- email uses idx_users_tenant_email_unique.
+CREATE INDEX IF NOT EXISTS idx_users_tenant_created_at ON users (tenant_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_updated_at ON users (tenant_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_name ON users (tenant_id, name, id);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_role ON users (tenant_id, role);
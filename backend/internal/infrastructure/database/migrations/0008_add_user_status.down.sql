@@ -0,0 +1,6 @@
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_tenant_status;
+-- dialect: mysql
+DROP INDEX idx_users_tenant_status ON users;
+
+ALTER TABLE users DROP COLUMN status;
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+-- Filtering user lists by status within a tenant
+CREATE INDEX IF NOT EXISTS idx_users_tenant_status ON users (tenant_id, status);
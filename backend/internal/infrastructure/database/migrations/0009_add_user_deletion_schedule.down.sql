@@ -0,0 +1,6 @@
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_deletion_scheduled_at;
+-- dialect: mysql
+DROP INDEX idx_users_deletion_scheduled_at ON users;
+
+ALTER TABLE users DROP COLUMN deletion_scheduled_at;
@@ -0,0 +1,21 @@
+-- Archives of personal data users request about themselves. They go with
+-- the account when it is deleted.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id VARCHAR(64) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    user_id VARCHAR(64) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    content BYTEA,
+    size BIGINT NOT NULL DEFAULT 0,
+    error VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_tenant_user ON data_exports (tenant_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_data_exports_expires_at ON data_exports (expires_at);
+
+-- MySQL before 9.0 ignores REFERENCES in column definitions
+-- dialect: mysql
+ALTER TABLE data_exports ADD CONSTRAINT fk_data_exports_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
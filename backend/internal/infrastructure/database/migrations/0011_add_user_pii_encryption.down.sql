@@ -0,0 +1,12 @@
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_tenant_email_index_unique;
+-- dialect: mysql
+DROP INDEX idx_users_tenant_email_index_unique ON users;
+
+ALTER TABLE users DROP COLUMN email_index;
+
+-- dialect: postgres
+ALTER TABLE users ALTER COLUMN email TYPE VARCHAR(255), ALTER COLUMN name TYPE VARCHAR(100);
+
+-- dialect: mysql
+ALTER TABLE users MODIFY email VARCHAR(255) NOT NULL, MODIFY name VARCHAR(100) NOT NULL;
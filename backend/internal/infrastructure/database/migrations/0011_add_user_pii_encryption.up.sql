@@ -0,0 +1,14 @@
+-- Encrypted email addresses and names outgrow their plaintext limits
+-- dialect: postgres
+ALTER TABLE users ALTER COLUMN email TYPE TEXT, ALTER COLUMN name TYPE TEXT;
+
+-- MySQL indexes TEXT only by prefix, so the columns stay VARCHAR within the
+-- index size limit
+-- dialect: mysql
+ALTER TABLE users MODIFY email VARCHAR(700) NOT NULL, MODIFY name VARCHAR(700) NOT NULL;
+
+-- Blind index of the email address, set while encryption is enabled.
+-- Encrypted addresses are randomized, so uniqueness is enforced here.
+ALTER TABLE users ADD COLUMN email_index VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email_index_unique ON users (tenant_id, email_index);
@@ -0,0 +1,35 @@
+-- Endpoints administrators register to receive user events, and every
+-- delivery sent to them. Deliveries go with their endpoint.
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id VARCHAR(64) PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT NOT NULL,
+    description VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id VARCHAR(64) PRIMARY KEY,
+    endpoint_id VARCHAR(64) NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+    event_type VARCHAR(64) NOT NULL,
+    event_id VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    response_status BIGINT NOT NULL DEFAULT 0,
+    last_error VARCHAR(500),
+    next_attempt_at TIMESTAMPTZ,
+    last_attempt_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ,
+    redelivery_of VARCHAR(64),
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries (endpoint_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+
+-- MySQL before 9.0 ignores REFERENCES in column definitions
+-- dialect: mysql
+ALTER TABLE webhook_deliveries ADD CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints (id) ON DELETE CASCADE;
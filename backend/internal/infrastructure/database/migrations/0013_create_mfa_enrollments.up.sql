@@ -0,0 +1,19 @@
+-- Authenticator apps users enroll as a second login factor, with the hashes
+-- of their unused recovery codes. They go with the account when it is
+-- deleted.
+CREATE TABLE IF NOT EXISTS mfa_enrollments (
+    user_id VARCHAR(64) PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    recovery_codes TEXT NOT NULL,
+    failed_attempts BIGINT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- MySQL before 9.0 ignores REFERENCES in column definitions
+-- dialect: mysql
+ALTER TABLE mfa_enrollments ADD CONSTRAINT fk_mfa_enrollments_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
@@ -0,0 +1,22 @@
+-- Devices that completed the second login step and may skip it until they
+-- expire. Only a hash of each device's token is kept.
+CREATE TABLE IF NOT EXISTS trusted_devices (
+    id VARCHAR(64) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    user_id VARCHAR(64) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    name VARCHAR(255),
+    user_agent VARCHAR(512),
+    ip_address VARCHAR(64),
+    created_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trusted_devices_token_hash ON trusted_devices (token_hash);
+CREATE INDEX IF NOT EXISTS idx_trusted_devices_tenant_user ON trusted_devices (tenant_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_trusted_devices_expires_at ON trusted_devices (expires_at);
+
+-- MySQL before 9.0 ignores REFERENCES in column definitions
+-- dialect: mysql
+ALTER TABLE trusted_devices ADD CONSTRAINT fk_trusted_devices_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
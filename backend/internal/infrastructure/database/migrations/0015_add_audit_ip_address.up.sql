@@ -0,0 +1 @@
+ALTER TABLE audit_logs ADD COLUMN ip_address VARCHAR(64);
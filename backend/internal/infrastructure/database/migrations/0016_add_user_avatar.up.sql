@@ -0,0 +1,2 @@
+-- Object storage key of the user's avatar; empty when they have none
+ALTER TABLE users ADD COLUMN avatar_key VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+-- dialect: postgres, sqlite
+DROP INDEX IF EXISTS idx_users_tenant_canonical_email_unique;
+-- dialect: mysql
+DROP INDEX idx_users_tenant_canonical_email_unique ON users;
+
+ALTER TABLE users DROP COLUMN canonical_email;
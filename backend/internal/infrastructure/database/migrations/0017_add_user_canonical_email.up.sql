@@ -0,0 +1,7 @@
+-- Canonical form of the email address that duplicates are detected by, or
+-- its blind index while personal data is encrypted. Existing users are
+-- filled in by the canonicalize-emails job; until then they are looked up
+-- by the email column as before.
+ALTER TABLE users ADD COLUMN canonical_email VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_canonical_email_unique ON users (tenant_id, canonical_email);
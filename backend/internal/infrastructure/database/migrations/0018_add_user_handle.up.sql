@@ -0,0 +1,5 @@
+-- Optional public name of the user, unique per tenant. NULLs do not
+-- collide, so users without a handle are not constrained.
+ALTER TABLE users ADD COLUMN handle VARCHAR(30);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_handle_unique ON users (tenant_id, handle);
@@ -0,0 +1,4 @@
+-- IANA time zone and BCP 47 locale the user reads times in; empty when
+-- they have not chosen one
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT '';
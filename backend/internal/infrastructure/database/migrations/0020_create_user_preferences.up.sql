@@ -0,0 +1,16 @@
+-- Per-user settings stored as compact JSON under namespaced keys, so
+-- features can keep their own settings without schema changes.
+CREATE TABLE IF NOT EXISTS user_preferences (
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    user_id VARCHAR(64) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    preference_key VARCHAR(128) NOT NULL,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, preference_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_preferences_tenant_id ON user_preferences (tenant_id);
+
+-- MySQL before 9.0 ignores REFERENCES in column definitions
+-- dialect: mysql
+ALTER TABLE user_preferences ADD CONSTRAINT fk_user_preferences_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
@@ -0,0 +1,48 @@
+-- Organizations are teams of users within a tenant. Each has one owner
+-- among its members; invitations carry the hash of the token given to the
+-- invitee.
+CREATE TABLE IF NOT EXISTS organizations (
+    id VARCHAR(64) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_organizations_tenant_id ON organizations (tenant_id);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    org_id VARCHAR(64) NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id VARCHAR(64) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    role VARCHAR(16) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON organization_members (user_id);
+
+CREATE TABLE IF NOT EXISTS organization_invitations (
+    id VARCHAR(64) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    org_id VARCHAR(64) NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    invited_by VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    accepted_by VARCHAR(64),
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_invitations_token_hash ON organization_invitations (token_hash);
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_org_id ON organization_invitations (org_id);
+
+-- MySQL before 9.0 ignores REFERENCES in column definitions
+-- dialect: mysql
+ALTER TABLE organization_members ADD CONSTRAINT fk_organization_members_org FOREIGN KEY (org_id) REFERENCES organizations (id) ON DELETE CASCADE;
+-- dialect: mysql
+ALTER TABLE organization_members ADD CONSTRAINT fk_organization_members_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+-- dialect: mysql
+ALTER TABLE organization_invitations ADD CONSTRAINT fk_organization_invitations_org FOREIGN KEY (org_id) REFERENCES organizations (id) ON DELETE CASCADE;
@@ -0,0 +1 @@
+ALTER TABLE organization_invitations DROP COLUMN revoked_at;
@@ -0,0 +1 @@
+ALTER TABLE organization_invitations ADD COLUMN revoked_at TIMESTAMPTZ;
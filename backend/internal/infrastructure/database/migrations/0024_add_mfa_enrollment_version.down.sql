@@ -0,0 +1 @@
+ALTER TABLE mfa_enrollments DROP COLUMN version;
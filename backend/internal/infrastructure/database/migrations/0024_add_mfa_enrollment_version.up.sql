@@ -0,0 +1,3 @@
+-- Code checks write an enrollment back only if its version is still the
+-- one they read, so parallel requests cannot use one code twice.
+ALTER TABLE mfa_enrollments ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Users provisioned by an external directory such as LDAP are marked with
+-- its name; they have no local password and cannot set one.
+ALTER TABLE users ADD COLUMN external_auth VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- The backfilled canonical emails are kept: 0025 looks users up by them
+-- as well.
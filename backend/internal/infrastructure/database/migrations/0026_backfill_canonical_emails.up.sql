@@ -0,0 +1,5 @@
+-- Canonical emails of users saved before 0017 are filled in by the
+-- backfillCanonicalEmails data migration, which runs after this file in
+-- the same transaction. Duplicates among them are resolved there, so that
+-- idx_users_tenant_canonical_email_unique holds for every user and
+-- lookups by email no longer fall back to the email column.
@@ -0,0 +1,2 @@
+-- When a user confirmed they own their email address
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
@@ -0,0 +1,15 @@
+// Package migrations holds the versioned SQL schema migrations applied by
+// database.Migrator. Each version is a pair of files named
+// NNNN_description.up.sql and NNNN_description.down.sql. Statements are
+// separated by a semicolon at the end of a line. A statement preceded by a
+// "-- dialect: postgres" comment only runs on that database, which keeps
+// Postgres-only indexes out of SQLite test databases. Applied migrations
+// must never be edited; add a new version instead.
+package migrations
+
+import "embed"
+
+// FS contains the migration files
+//
+//go:embed *.sql
+var FS embed.FS
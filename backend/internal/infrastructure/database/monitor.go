@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/metrics"
+	"github.com/cctw-zed/wonder/pkg/health"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// monitorPingTimeout bounds a single monitor ping
+const monitorPingTimeout = 2 * time.Second
+
+// Monitor pings a database in the background and exports the latency and
+// outcome as metrics. When the database answers again after failing, the
+// pool's idle connections, which may have been cut by the outage, are
+// closed and a fresh one is opened, so requests do not pay for the
+// reconnect.
+type Monitor struct {
+	name    string
+	db      *gorm.DB
+	maxIdle int
+	log     logger.Logger
+
+	mu        sync.RWMutex
+	lastErr   error
+	downSince time.Time
+}
+
+// NewMonitor creates a monitor of db, whose pool keeps up to maxIdle idle
+// connections. name labels the pool in metrics and logs.
+func NewMonitor(name string, db *gorm.DB, maxIdle int) *Monitor {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	return &Monitor{
+		name:    name,
+		db:      db,
+		maxIdle: maxIdle,
+		log:     logger.Get().WithLayer("infrastructure").WithComponent("db_monitor"),
+	}
+}
+
+// Run pings the database every interval until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.CheckNow(ctx)
+		}
+	}
+}
+
+// CheckNow pings the database once, updates the state reported by Check
+// and re-establishes the pool if the database just recovered
+func (m *Monitor) CheckNow(ctx context.Context) error {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, monitorPingTimeout)
+	start := time.Now()
+	err = sqlDB.PingContext(pingCtx)
+	cancel()
+	metrics.ObserveDBPing(m.name, err != nil, time.Since(start).Seconds())
+
+	m.mu.Lock()
+	wasDown, since := !m.downSince.IsZero(), m.downSince
+	m.lastErr = err
+	switch {
+	case err != nil && !wasDown:
+		m.downSince = time.Now()
+	case err == nil:
+		m.downSince = time.Time{}
+	}
+	m.mu.Unlock()
+
+	switch {
+	case err != nil && !wasDown:
+		m.log.Error(ctx, "database unreachable", "pool", m.name, "error", err)
+	case err == nil && wasDown:
+		m.reconnect(ctx, sqlDB)
+		m.log.Info(ctx, "database reachable again, connection pool re-established",
+			"pool", m.name, "downtime_ms", time.Since(since).Milliseconds())
+	}
+	return err
+}
+
+// reconnect drops the idle connections opened before the outage and warms
+// the pool with a new one
+func (m *Monitor) reconnect(ctx context.Context, sqlDB *sql.DB) {
+	sqlDB.SetMaxIdleConns(0)
+	sqlDB.SetMaxIdleConns(m.maxIdle)
+	metrics.ObserveDBReconnect(m.name)
+
+	ctx, cancel := context.WithTimeout(ctx, monitorPingTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		m.log.Warn(ctx, "failed to warm connection pool", "pool", m.name, "error", err)
+	}
+}
+
+// Check implements health.Checker with the outcome of the last ping. While
+// the database is unreachable the check is degraded rather than down: every
+// instance shares the database, so taking them out of rotation would not
+// route requests anywhere better.
+func (m *Monitor) Check(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.lastErr == nil {
+		return nil
+	}
+	return health.Degraded(fmt.Errorf("unreachable since %s: %w", m.downSince.UTC().Format(time.RFC3339), m.lastErr))
+}
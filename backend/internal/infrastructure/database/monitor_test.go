@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/pkg/health"
+	wonderLogger "github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// flakyDriver opens connections that fail while down is set
+type flakyDriver struct {
+	down  atomic.Bool
+	opens atomic.Int32
+}
+
+func (d *flakyDriver) Open(string) (driver.Conn, error) {
+	if d.down.Load() {
+		return nil, errors.New("connection refused")
+	}
+	d.opens.Add(1)
+	return &flakyConn{driver: d}, nil
+}
+
+type flakyConn struct {
+	driver *flakyDriver
+}
+
+func (c *flakyConn) Ping(context.Context) error {
+	if c.driver.down.Load() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *flakyConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *flakyConn) Close() error                        { return nil }
+func (c *flakyConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func TestMonitor(t *testing.T) {
+	wonderLogger.Initialize()
+	fake := &flakyDriver{}
+	sqlDB := sql.OpenDB(connector{fake})
+	t.Cleanup(func() { sqlDB.Close() })
+	sqlDB.SetMaxIdleConns(2)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+
+	monitor := NewMonitor("test", db, 2)
+	ctx := context.Background()
+	registry := health.NewRegistry()
+	registry.Register("postgres", monitor)
+
+	require.NoError(t, monitor.CheckNow(ctx))
+	assert.Equal(t, health.StatusUp, registry.Check(ctx).Status)
+	assert.Equal(t, int32(1), fake.opens.Load())
+
+	fake.down.Store(true)
+	require.Error(t, monitor.CheckNow(ctx))
+	report := registry.Check(ctx)
+	assert.Equal(t, health.StatusDegraded, report.Status)
+	assert.Equal(t, health.StatusDegraded, report.Checks["postgres"].Status)
+	assert.Contains(t, report.Checks["postgres"].Error, "unreachable since")
+
+	fake.down.Store(false)
+	require.NoError(t, monitor.CheckNow(ctx))
+	assert.Equal(t, health.StatusUp, registry.Check(ctx).Status)
+	// The connection of the successful ping was dropped with the other idle
+	// ones and the pool warmed with a fresh one
+	assert.Equal(t, int32(3), fake.opens.Load())
+	assert.Equal(t, 1, sqlDB.Stats().Idle)
+}
+
+type connector struct {
+	driver *flakyDriver
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.driver }
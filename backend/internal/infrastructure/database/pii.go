@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+
+	"github.com/cctw-zed/wonder/pkg/fieldcrypt"
+)
+
+// PIISerializer is the GORM serializer name of string and []byte columns
+// holding personal data, e.g. `gorm:"serializer:pii"`. Values are
+// encrypted with the keyring set by UseFieldEncryption and stored as
+// plaintext while none is set.
+const PIISerializer = "pii"
+
+// PIIJSONSerializer stores a value as JSON encrypted like PIISerializer,
+// for structured columns such as audit change sets. Empty maps and slices
+// are stored as NULL.
+const PIIJSONSerializer = "pii_json"
+
+// fieldKeyring is process-wide because GORM serializers are registered
+// globally
+var fieldKeyring atomic.Pointer[fieldcrypt.Keyring]
+
+func init() {
+	schema.RegisterSerializer(PIISerializer, piiSerializer{})
+	schema.RegisterSerializer(PIIJSONSerializer, piiJSONSerializer{})
+}
+
+// UseFieldEncryption encrypts personal data columns with keyring from now
+// on; nil stores new values as plaintext
+func UseFieldEncryption(keyring *fieldcrypt.Keyring) {
+	fieldKeyring.Store(keyring)
+}
+
+// FieldEncryption returns the keyring personal data columns are encrypted
+// with, or nil when encryption is disabled
+func FieldEncryption() *fieldcrypt.Keyring {
+	return fieldKeyring.Load()
+}
+
+// EmailIndex returns the blind index of an email address, compared
+// case-insensitively, or "" when encryption is disabled
+func EmailIndex(email string) string {
+	keyring := FieldEncryption()
+	if keyring == nil {
+		return ""
+	}
+	return keyring.BlindIndex(strings.ToLower(strings.TrimSpace(email)))
+}
+
+// CanonicalEmailKey returns the stored form of a canonical email: the
+// address itself, or its blind index while encryption is enabled so the
+// column holds no personal data
+func CanonicalEmailKey(canonical string) string {
+	if keyring := FieldEncryption(); keyring != nil {
+		return keyring.BlindIndex(canonical)
+	}
+	return canonical
+}
+
+type piiSerializer struct{}
+
+// Scan implements schema.SerializerInterface
+func (piiSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	plaintext, err := decryptColumn(field, dbValue)
+	if err != nil {
+		return err
+	}
+	target := field.ReflectValueOf(ctx, dst)
+	if target.Kind() == reflect.Slice {
+		if dbValue == nil {
+			target.SetBytes(nil)
+			return nil
+		}
+		target.SetBytes([]byte(plaintext))
+		return nil
+	}
+	target.SetString(plaintext)
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface
+func (piiSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	switch plaintext := fieldValue.(type) {
+	case string:
+		return encryptColumn(plaintext)
+	case []byte:
+		if plaintext == nil {
+			return nil, nil
+		}
+		encrypted, err := encryptColumn(string(plaintext))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(encrypted), nil
+	default:
+		return nil, fmt.Errorf("unsupported field type %T for column %s", fieldValue, field.DBName)
+	}
+}
+
+type piiJSONSerializer struct{}
+
+// Scan implements schema.SerializerInterface
+func (piiJSONSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	target := field.ReflectValueOf(ctx, dst)
+	if dbValue == nil {
+		target.Set(reflect.Zero(field.FieldType))
+		return nil
+	}
+	plaintext, err := decryptColumn(field, dbValue)
+	if err != nil {
+		return err
+	}
+	if plaintext == "" {
+		target.Set(reflect.Zero(field.FieldType))
+		return nil
+	}
+	value := reflect.New(field.FieldType)
+	if err := json.Unmarshal([]byte(plaintext), value.Interface()); err != nil {
+		return fmt.Errorf("column %s: %w", field.DBName, err)
+	}
+	target.Set(value.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface
+func (piiJSONSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	v := reflect.ValueOf(fieldValue)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Map, reflect.Slice:
+		if v.Len() == 0 {
+			return nil, nil
+		}
+	}
+	data, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %w", field.DBName, err)
+	}
+	return encryptColumn(string(data))
+}
+
+// decryptColumn returns the plaintext of a stored column value
+func decryptColumn(field *schema.Field, dbValue interface{}) (string, error) {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return "", fmt.Errorf("unsupported value type %T for column %s", dbValue, field.DBName)
+	}
+
+	if !fieldcrypt.IsEncrypted(stored) {
+		return stored, nil
+	}
+	keyring := FieldEncryption()
+	if keyring == nil {
+		return "", fmt.Errorf("column %s is encrypted but field encryption is not configured", field.DBName)
+	}
+	plaintext, err := keyring.Decrypt(stored)
+	if err != nil {
+		return "", fmt.Errorf("column %s: %w", field.DBName, err)
+	}
+	return plaintext, nil
+}
+
+// encryptColumn returns the stored form of plaintext
+func encryptColumn(plaintext string) (string, error) {
+	keyring := FieldEncryption()
+	if keyring == nil || plaintext == "" {
+		return plaintext, nil
+	}
+	return keyring.Encrypt(plaintext)
+}
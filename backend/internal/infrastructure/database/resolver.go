@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/transaction"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// replicaPingTimeout bounds a single replica health check
+const replicaPingTimeout = 2 * time.Second
+
+// UsePrimary routes reads made with the returned context to the primary,
+// e.g. to read back a write before replicas have caught up
+func UsePrimary(ctx context.Context) context.Context {
+	return transaction.WithConsistentReads(ctx)
+}
+
+// Replica is a read-only connection tracked by a Resolver
+type Replica struct {
+	Name    string
+	DB      *gorm.DB
+	healthy atomic.Bool
+}
+
+// Healthy reports whether the replica passed its last health check
+func (r *Replica) Healthy() bool {
+	return r.healthy.Load()
+}
+
+// Ping checks replica connectivity within ctx
+func (r *Replica) Ping(ctx context.Context) error {
+	sqlDB, err := r.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Resolver routes reads to healthy replicas in turn and everything else to
+// the primary. Without healthy replicas reads fall back to the primary.
+type Resolver struct {
+	primary  *gorm.DB
+	replicas []*Replica
+	next     atomic.Uint64
+	log      logger.Logger
+}
+
+// NewResolver creates a resolver over primary and replicas. Replicas start
+// out healthy.
+func NewResolver(primary *gorm.DB, replicas ...*Replica) *Resolver {
+	if primary == nil {
+		panic("database connection cannot be nil")
+	}
+	for _, replica := range replicas {
+		replica.healthy.Store(true)
+	}
+
+	return &Resolver{
+		primary:  primary,
+		replicas: replicas,
+		log:      logger.Get().WithLayer("infrastructure").WithComponent("db_resolver"),
+	}
+}
+
+// Primary returns the primary connection
+func (r *Resolver) Primary() *gorm.DB {
+	return r.primary
+}
+
+// Replicas returns the configured replicas
+func (r *Resolver) Replicas() []*Replica {
+	return r.replicas
+}
+
+// Reader returns a connection for a read-only query scoped to ctx. An
+// active transaction and UsePrimary both pin the query to the primary.
+func (r *Resolver) Reader(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	if transaction.ConsistentReads(ctx) || len(r.replicas) == 0 {
+		return r.primary.WithContext(ctx)
+	}
+
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		replica := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if replica.Healthy() {
+			return replica.DB.WithContext(ctx)
+		}
+	}
+	return r.primary.WithContext(ctx)
+}
+
+// CheckReplicas pings every replica and updates its health
+func (r *Resolver) CheckReplicas(ctx context.Context) {
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := replica.Ping(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) != healthy {
+			if healthy {
+				r.log.Info(ctx, "read replica recovered", "replica", replica.Name)
+			} else {
+				r.log.Warn(ctx, "read replica failing, routing reads elsewhere", "replica", replica.Name, "error", err)
+			}
+		}
+	}
+}
+
+// Watch runs CheckReplicas every interval until ctx is cancelled
+func (r *Resolver) Watch(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.CheckReplicas(ctx)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// label reports which database a connection points at
+func label(t *testing.T, db *gorm.DB) string {
+	var name string
+	require.NoError(t, db.Raw("SELECT name FROM label").Scan(&name).Error)
+	return name
+}
+
+func labelledDB(t *testing.T, name string) *gorm.DB {
+	db := setupMigrationDB(t)
+	require.NoError(t, db.Exec("CREATE TABLE label (name TEXT)").Error)
+	require.NoError(t, db.Exec("INSERT INTO label VALUES (?)", name).Error)
+	return db
+}
+
+func TestResolver_Reader(t *testing.T) {
+	ctx := context.Background()
+	primary := labelledDB(t, "primary")
+	replicaA := &Replica{Name: "a", DB: labelledDB(t, "a")}
+	replicaB := &Replica{Name: "b", DB: labelledDB(t, "b")}
+	resolver := NewResolver(primary, replicaA, replicaB)
+
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		seen[label(t, resolver.Reader(ctx))]++
+	}
+	assert.Equal(t, map[string]int{"a": 2, "b": 2}, seen, "reads are spread across replicas")
+
+	assert.Equal(t, "primary", label(t, resolver.Reader(UsePrimary(ctx))))
+
+	err := NewUnitOfWork(primary).Do(ctx, func(ctx context.Context) error {
+		assert.Equal(t, "primary", label(t, resolver.Reader(ctx)), "transactions read their own writes")
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestResolver_Failover(t *testing.T) {
+	ctx := context.Background()
+	primary := labelledDB(t, "primary")
+	replica := &Replica{Name: "a", DB: labelledDB(t, "a")}
+	resolver := NewResolver(primary, replica)
+
+	resolver.CheckReplicas(ctx)
+	assert.True(t, replica.Healthy())
+
+	sqlDB, err := replica.DB.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	resolver.CheckReplicas(ctx)
+	assert.False(t, replica.Healthy())
+	assert.Equal(t, "primary", label(t, resolver.Reader(ctx)), "reads fall back to the primary")
+}
+
+func TestResolver_NoReplicas(t *testing.T) {
+	resolver := NewResolver(labelledDB(t, "primary"))
+	assert.Equal(t, "primary", label(t, resolver.Reader(context.Background())))
+}
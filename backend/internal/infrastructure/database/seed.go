@@ -0,0 +1,210 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// CommonSeedDir holds seed files applied to every environment, before the
+// environment's own directory
+const CommonSeedDir = "common"
+
+// UserImporter creates users from seed rows, skipping emails that are
+// already registered
+type UserImporter interface {
+	ImportUsers(ctx context.Context, rows []user.ImportRow) (*user.ImportReport, error)
+}
+
+// SeedFile is the YAML seed format. Users are created through the import
+// path, so passwords are hashed and existing emails are skipped.
+type SeedFile struct {
+	Users    []SeedUser    `yaml:"users"`
+	Generate *SeedGenerate `yaml:"generate"`
+}
+
+// SeedUser is one user of a seed file. Role, user or admin, is set on
+// every run, also on an account an earlier run created; users without one
+// are created as user.
+type SeedUser struct {
+	Email    string `yaml:"email"`
+	Name     string `yaml:"name"`
+	Password string `yaml:"password"`
+	Role     string `yaml:"role"`
+}
+
+// SeedGenerate asks for generated users in a seed file
+type SeedGenerate struct {
+	Users      int    `yaml:"users"`
+	RandomSeed int64  `yaml:"random_seed"`
+	Password   string `yaml:"password"`
+}
+
+// SeedReport summarizes a seed run
+type SeedReport struct {
+	Files      []string
+	Statements int
+	Users      user.ImportReport
+}
+
+// Seeder loads seed data. Seed files live in one directory per
+// environment; files in common/ apply to every environment. Files run in
+// name order: .sql files execute their statements in a transaction, and
+// .yaml files create users. Seeds run on every invocation, so SQL seeds
+// must be idempotent, e.g. with ON CONFLICT DO NOTHING.
+type Seeder struct {
+	db    *gorm.DB
+	users UserImporter
+	log   logger.Logger
+}
+
+// NewSeeder creates a seeder that writes SQL seeds to db and creates users
+// through users
+func NewSeeder(db *gorm.DB, users UserImporter) *Seeder {
+	return &Seeder{
+		db:    db,
+		users: users,
+		log:   logger.Get().WithLayer("infrastructure").WithComponent("seeder"),
+	}
+}
+
+// Run applies the seed files in source for env
+func (s *Seeder) Run(ctx context.Context, source fs.FS, env string) (*SeedReport, error) {
+	files, err := seedFiles(source, env)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no seed files in %s/ or %s/", CommonSeedDir, env)
+	}
+
+	report := &SeedReport{Users: user.ImportReport{Errors: []user.ImportRowError{}}}
+	for _, name := range files {
+		data, err := fs.ReadFile(source, name)
+		if err != nil {
+			return report, err
+		}
+		switch path.Ext(name) {
+		case ".sql":
+			err = s.runSQL(ctx, string(data), report)
+		default:
+			err = s.runYAML(ctx, data, report)
+		}
+		if err != nil {
+			return report, fmt.Errorf("seed %s: %w", name, err)
+		}
+		report.Files = append(report.Files, name)
+		s.log.Info(ctx, "seed file applied", "file", name, "environment", env)
+	}
+	return report, nil
+}
+
+// SeedUsers creates rows as users
+func (s *Seeder) SeedUsers(ctx context.Context, rows []user.ImportRow, report *SeedReport) error {
+	result, err := s.users.ImportUsers(ctx, rows)
+	if err != nil {
+		return err
+	}
+	report.Users.Total += result.Total
+	report.Users.Created += result.Created
+	report.Users.Duplicates += result.Duplicates
+	report.Users.Invalid += result.Invalid
+	report.Users.Errors = append(report.Users.Errors, result.Errors...)
+	return nil
+}
+
+func (s *Seeder) runSQL(ctx context.Context, script string, report *SeedReport) error {
+	dialect := s.db.Dialector.Name()
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(script) {
+			if !runsOn(stmt, dialect) {
+				continue
+			}
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+			report.Statements++
+		}
+		return nil
+	})
+}
+
+func (s *Seeder) runYAML(ctx context.Context, data []byte, report *SeedReport) error {
+	var file SeedFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return fmt.Errorf("invalid seed file: %w", err)
+	}
+
+	rows := make([]user.ImportRow, 0, len(file.Users))
+	for i, u := range file.Users {
+		if u.Role != "" && u.Role != user.RoleUser && u.Role != user.RoleAdmin {
+			return fmt.Errorf("user %d: role must be %s or %s, got %q", i+1, user.RoleUser, user.RoleAdmin, u.Role)
+		}
+		rows = append(rows, user.ImportRow{Row: i + 1, Email: u.Email, Name: u.Name, Password: u.Password})
+	}
+	if g := file.Generate; g != nil && g.Users > 0 {
+		rows = append(rows, GenerateUsers(g.Users, g.RandomSeed, g.Password)...)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := s.SeedUsers(ctx, rows, report); err != nil {
+		return err
+	}
+	return s.applyRoles(ctx, file.Users)
+}
+
+// applyRoles sets the roles seed users ask for; imports always create
+// plain users
+func (s *Seeder) applyRoles(ctx context.Context, users []SeedUser) error {
+	for _, u := range users {
+		if u.Role == "" {
+			continue
+		}
+		err := s.db.WithContext(ctx).Table("users").
+			Where("tenant_id = ? AND email = ?", tenant.IDFromContext(ctx), u.Email).
+			Update("role", u.Role).Error
+		if err != nil {
+			return fmt.Errorf("set role of %s: %w", u.Email, err)
+		}
+	}
+	return nil
+}
+
+// seedFiles lists the seed files for env, common ones first
+func seedFiles(source fs.FS, env string) ([]string, error) {
+	var files []string
+	for _, dir := range []string{CommonSeedDir, env} {
+		entries, err := fs.ReadDir(source, dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		var names []string
+		for _, entry := range entries {
+			switch ext := path.Ext(entry.Name()); {
+			case entry.IsDir():
+			case ext == ".sql" || ext == ".yaml" || ext == ".yml":
+				names = append(names, path.Join(dir, entry.Name()))
+			}
+		}
+		sort.Strings(names)
+		files = append(files, names...)
+	}
+	return files, nil
+}
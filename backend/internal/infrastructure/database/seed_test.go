@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+)
+
+// recordingImporter creates every row whose email it has not seen, and
+// stores it in db when set
+type recordingImporter struct {
+	emails map[string]bool
+	db     *gorm.DB
+}
+
+func (r *recordingImporter) ImportUsers(_ context.Context, rows []user.ImportRow) (*user.ImportReport, error) {
+	report := &user.ImportReport{Total: len(rows)}
+	for _, row := range rows {
+		if r.emails[row.Email] {
+			report.Duplicates++
+			continue
+		}
+		r.emails[row.Email] = true
+		report.Created++
+		if r.db != nil {
+			if err := r.db.Exec("INSERT INTO users (tenant_id, email, role) VALUES ('default', ?, 'user')", row.Email).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	return report, nil
+}
+
+func TestSeeder_Run(t *testing.T) {
+	db := setupMigrationDB(t)
+	ctx := context.Background()
+	require.NoError(t, db.Exec("CREATE TABLE users (tenant_id TEXT, email TEXT, role TEXT)").Error)
+	importer := &recordingImporter{emails: map[string]bool{}, db: db}
+	seeder := NewSeeder(db, importer)
+
+	source := fstest.MapFS{
+		"common/001_settings.sql": {Data: []byte(`
+CREATE TABLE IF NOT EXISTS settings (name TEXT PRIMARY KEY, value TEXT);
+INSERT INTO settings (name, value) VALUES ('theme', 'light') ON CONFLICT DO NOTHING;
+-- dialect: postgres
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+`)},
+		"development/010_users.yaml": {Data: []byte(`
+users:
+  - email: admin@example.com
+    name: Admin
+    password: Admin-Passw0rd!
+    role: admin
+  - email: user@example.com
+    name: User
+    password: User-Passw0rd!
+generate:
+  users: 25
+  random_seed: 7
+`)},
+		"development/README.md":   {Data: []byte("ignored")},
+		"production/001_noop.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	report, err := seeder.Run(ctx, source, "development")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"common/001_settings.sql", "development/010_users.yaml"}, report.Files)
+	assert.Equal(t, 2, report.Statements, "statements for other databases are skipped")
+	assert.Equal(t, 27, report.Users.Created)
+
+	var value string
+	require.NoError(t, db.Raw("SELECT value FROM settings WHERE name = 'theme'").Scan(&value).Error)
+	assert.Equal(t, "light", value)
+
+	var admins []string
+	require.NoError(t, db.Raw("SELECT email FROM users WHERE role = ?", user.RoleAdmin).Scan(&admins).Error)
+	assert.Equal(t, []string{"admin@example.com"}, admins)
+
+	// Seeds are re-runnable
+	report, err = seeder.Run(ctx, source, "development")
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Users.Created)
+	assert.Equal(t, 27, report.Users.Duplicates)
+}
+
+func TestSeeder_Run_Errors(t *testing.T) {
+	db := setupMigrationDB(t)
+	seeder := NewSeeder(db, &recordingImporter{emails: map[string]bool{}})
+
+	_, err := seeder.Run(context.Background(), fstest.MapFS{}, "staging")
+	assert.ErrorContains(t, err, "no seed files")
+
+	_, err = seeder.Run(context.Background(), fstest.MapFS{
+		"staging/users.yaml": {Data: []byte("user:\n  - email: a@example.com\n")},
+	}, "staging")
+	assert.ErrorContains(t, err, "seed staging/users.yaml")
+
+	_, err = seeder.Run(context.Background(), fstest.MapFS{
+		"staging/users.yaml": {Data: []byte("users:\n  - email: a@example.com\n    role: owner\n")},
+	}, "staging")
+	assert.ErrorContains(t, err, "role must be user or admin")
+
+	_, err = seeder.Run(context.Background(), fstest.MapFS{
+		"staging/broken.sql": {Data: []byte("CREATE TABLE t (id INTEGER);\nINSERT INTO missing VALUES (1);\n")},
+	}, "staging")
+	require.Error(t, err)
+	assert.False(t, db.Migrator().HasTable("t"), "a failed SQL seed is rolled back")
+}
+
+func TestGenerateUsers(t *testing.T) {
+	rows := GenerateUsers(500, 42, "")
+	require.Len(t, rows, 500)
+	assert.Equal(t, rows, GenerateUsers(500, 42, ""), "the same seed gives the same users")
+	assert.NotEqual(t, rows[0], GenerateUsers(1, 43, "")[0])
+
+	emails := make(map[string]bool, len(rows))
+	for i, row := range rows {
+		assert.Equal(t, i+1, row.Row)
+		assert.False(t, emails[row.Email], "duplicate email %s", row.Email)
+		emails[row.Email] = true
+		assert.True(t, (&user.User{Email: row.Email}).IsEmailValid(), row.Email)
+		assert.Equal(t, DefaultSeedPassword, row.Password)
+		assert.GreaterOrEqual(t, len([]rune(row.Name)), 2)
+	}
+
+	assert.Equal(t, "secret123", GenerateUsers(1, 1, "secret123")[0].Password)
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v6"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+)
+
+// DefaultSeedPassword is the password of generated users unless another is
+// given
+const DefaultSeedPassword = "Seed-Passw0rd!"
+
+// seedDomains are reserved for documentation, so no mail reaches anyone
+var seedDomains = []string{"example.com", "example.org", "example.net", "mail.example.com"}
+
+// GenerateUsers returns n users with realistic names and unique emails on
+// example domains, for load and pagination testing. The same randomSeed
+// gives the same users, except 0, which picks a random one; an empty
+// password uses DefaultSeedPassword.
+func GenerateUsers(n int, randomSeed int64, password string) []user.ImportRow {
+	if password == "" {
+		password = DefaultSeedPassword
+	}
+	faker := gofakeit.New(randomSeed)
+	rows := make([]user.ImportRow, n)
+	for i := range rows {
+		first, last := faker.FirstName(), faker.LastName()
+		domain := seedDomains[faker.Number(0, len(seedDomains)-1)]
+		rows[i] = user.ImportRow{
+			Row: i + 1,
+			// The index keeps emails unique however the names repeat
+			Email:    fmt.Sprintf("%s.%s.%d@%s", emailLocal(first), emailLocal(last), i+1, domain),
+			Name:     first + " " + last,
+			Password: password,
+		}
+	}
+	return rows
+}
+
+// emailLocal keeps the ASCII letters and digits of a name, lowercased, for
+// an email address
+func emailLocal(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, name)
+}
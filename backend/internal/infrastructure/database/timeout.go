@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	queryTimeoutName = "wonder:query_timeout"
+	queryTimeoutKey  = "wonder:query_timeout:restore"
+)
+
+// QueryTimeout is a GORM plugin that bounds each statement's context by a
+// timeout. Callers' shorter deadlines and cancellation still apply, so a
+// statement stops when the request that issued it ends. Row and Rows are
+// not bounded, as their results are read after the statement returns.
+type QueryTimeout struct {
+	timeout time.Duration
+}
+
+// NewQueryTimeout creates the plugin
+func NewQueryTimeout(timeout time.Duration) *QueryTimeout {
+	if timeout <= 0 {
+		panic("query timeout must be positive")
+	}
+	return &QueryTimeout{timeout: timeout}
+}
+
+// Name implements gorm.Plugin
+func (p *QueryTimeout) Name() string {
+	return queryTimeoutName
+}
+
+// Initialize implements gorm.Plugin. Writes are bounded after their
+// implicit transaction begins, so that ending the statement's context does
+// not roll the transaction back before it commits.
+func (p *QueryTimeout) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().After("gorm:begin_transaction").Before("gorm:create").Register(queryTimeoutName+":before_create", p.bound),
+		cb.Create().After("gorm:create").Register(queryTimeoutName+":after_create", release),
+		cb.Query().Before("gorm:query").Register(queryTimeoutName+":before_query", p.bound),
+		cb.Query().After("gorm:query").Register(queryTimeoutName+":after_query", release),
+		cb.Update().After("gorm:begin_transaction").Before("gorm:update").Register(queryTimeoutName+":before_update", p.bound),
+		cb.Update().After("gorm:update").Register(queryTimeoutName+":after_update", release),
+		cb.Delete().After("gorm:begin_transaction").Before("gorm:delete").Register(queryTimeoutName+":before_delete", p.bound),
+		cb.Delete().After("gorm:delete").Register(queryTimeoutName+":after_delete", release),
+		cb.Raw().Before("gorm:raw").Register(queryTimeoutName+":before_raw", p.bound),
+		cb.Raw().After("gorm:raw").Register(queryTimeoutName+":after_raw", release),
+	)
+}
+
+// boundContext is the statement's own context and how to undo it
+type boundContext struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+func (p *QueryTimeout) bound(db *gorm.DB) {
+	parent := db.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, p.timeout)
+	db.InstanceSet(queryTimeoutKey, boundContext{parent: parent, cancel: cancel})
+	db.Statement.Context = ctx
+}
+
+// release ends the statement's context and restores the caller's, which
+// later statements chained on the same *gorm.DB go on to use
+func release(db *gorm.DB) {
+	value, ok := db.InstanceGet(queryTimeoutKey)
+	if !ok {
+		return
+	}
+	bound, ok := value.(boundContext)
+	if !ok {
+		return
+	}
+	bound.cancel()
+	db.Statement.Context = bound.parent
+}
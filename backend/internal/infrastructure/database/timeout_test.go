@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endless never finishes on its own
+const endless = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+
+func TestQueryTimeout(t *testing.T) {
+	db := setupMigrationDB(t)
+	require.NoError(t, db.Use(NewQueryTimeout(50*time.Millisecond)))
+
+	type item struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&item{}))
+	require.NoError(t, db.Create(&[]item{{Name: "a"}, {Name: "b"}}).Error)
+
+	t.Run("long statements are cancelled", func(t *testing.T) {
+		started := time.Now()
+		err := db.Exec(endless).Error
+		require.Error(t, err)
+		assert.Less(t, time.Since(started), 5*time.Second)
+	})
+
+	t.Run("the caller's cancellation still applies", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var items []item
+		assert.ErrorIs(t, db.WithContext(ctx).Find(&items).Error, context.Canceled)
+	})
+
+	t.Run("chained statements get a fresh timeout", func(t *testing.T) {
+		ctx := context.Background()
+		query := db.WithContext(ctx).Model(&item{}).Where("name <> ?", "")
+		var total int64
+		require.NoError(t, query.Count(&total).Error)
+		time.Sleep(60 * time.Millisecond)
+
+		var items []item
+		require.NoError(t, query.Find(&items).Error)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, items, 2)
+		assert.Equal(t, ctx, query.Statement.Context)
+	})
+
+	t.Run("writes commit", func(t *testing.T) {
+		require.NoError(t, db.Create(&item{Name: "c"}).Error)
+		require.NoError(t, db.Model(&item{}).Where("name = ?", "c").Update("name", "d").Error)
+		require.NoError(t, db.Where("name = ?", "d").Delete(&item{}).Error)
+		var total int64
+		require.NoError(t, db.Model(&item{}).Count(&total).Error)
+		assert.Equal(t, int64(2), total)
+	})
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/transaction"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type txKey struct{}
+
+type commitHooksKey struct{}
+
+// commitHooks are the functions to run once a transaction commits
+type commitHooks struct {
+	mu  sync.Mutex
+	fns []func(ctx context.Context)
+}
+
+// gormUnitOfWork implements transaction.UnitOfWork on top of GORM
+type gormUnitOfWork struct {
+	db *gorm.DB
+}
+
+// NewUnitOfWork creates a GORM-backed UnitOfWork
+func NewUnitOfWork(db *gorm.DB) transaction.UnitOfWork {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+
+	return &gormUnitOfWork{db: db}
+}
+
+// Do runs fn inside a transaction. Nested calls join the outer transaction
+// instead of opening a new one.
+func (u *gormUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	var fnErr error
+	hooks := &commitHooks{}
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fnErr = fn(context.WithValue(context.WithValue(ctx, txKey{}, tx), commitHooksKey{}, hooks))
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		// Failure in begin/commit rather than in the unit of work itself
+		return wonderErrors.NewDatabaseError("transaction", "", err, true)
+	}
+	for _, hook := range hooks.fns {
+		hook(ctx)
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction bound to ctx commits, or right
+// away when ctx carries none; fn is dropped when the transaction rolls
+// back. Effects outside the database, such as invalidating a cache, belong
+// here: run inside the transaction, another request could act on them
+// before the writes they describe are visible.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	hooks, ok := ctx.Value(commitHooksKey{}).(*commitHooks)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.fns = append(hooks.fns, fn)
+}
+
+// FromContext returns the transaction bound to ctx, or db when none is
+// active. The result is already scoped to ctx.
+func FromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InTransaction reports whether ctx carries an active transaction
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type uowRecord struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func setupUnitOfWorkDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// A single connection keeps the in-memory database shared across calls
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&uowRecord{}))
+	return db
+}
+
+func countRecords(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	require.NoError(t, db.Model(&uowRecord{}).Count(&count).Error)
+	return count
+}
+
+func TestUnitOfWork_Commit(t *testing.T) {
+	db := setupUnitOfWorkDB(t)
+	uow := NewUnitOfWork(db)
+
+	err := uow.Do(context.Background(), func(ctx context.Context) error {
+		if err := FromContext(ctx, db).Create(&uowRecord{Name: "a"}).Error; err != nil {
+			return err
+		}
+		return FromContext(ctx, db).Create(&uowRecord{Name: "b"}).Error
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), countRecords(t, db))
+}
+
+func TestUnitOfWork_RollbackOnError(t *testing.T) {
+	db := setupUnitOfWorkDB(t)
+	uow := NewUnitOfWork(db)
+	wantErr := errors.New("business rule violated")
+
+	err := uow.Do(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, FromContext(ctx, db).Create(&uowRecord{Name: "a"}).Error)
+		return wantErr
+	})
+	assert.Same(t, wantErr, err)
+	assert.Equal(t, int64(0), countRecords(t, db))
+}
+
+func TestUnitOfWork_RollbackOnPanic(t *testing.T) {
+	db := setupUnitOfWorkDB(t)
+	uow := NewUnitOfWork(db)
+
+	assert.Panics(t, func() {
+		_ = uow.Do(context.Background(), func(ctx context.Context) error {
+			require.NoError(t, FromContext(ctx, db).Create(&uowRecord{Name: "a"}).Error)
+			panic("boom")
+		})
+	})
+	assert.Equal(t, int64(0), countRecords(t, db))
+}
+
+func TestUnitOfWork_NestedJoinsOuterTransaction(t *testing.T) {
+	db := setupUnitOfWorkDB(t)
+	uow := NewUnitOfWork(db)
+
+	err := uow.Do(context.Background(), func(ctx context.Context) error {
+		inner := uow.Do(ctx, func(ctx context.Context) error {
+			return FromContext(ctx, db).Create(&uowRecord{Name: "inner"}).Error
+		})
+		require.NoError(t, inner)
+		return errors.New("outer failure")
+	})
+	require.Error(t, err)
+	assert.Equal(t, int64(0), countRecords(t, db), "inner writes roll back with the outer transaction")
+}
+
+func TestAfterCommit(t *testing.T) {
+	db := setupUnitOfWorkDB(t)
+	uow := NewUnitOfWork(db)
+
+	t.Run("runs once the transaction commits", func(t *testing.T) {
+		var committed []int64
+		err := uow.Do(context.Background(), func(ctx context.Context) error {
+			require.NoError(t, FromContext(ctx, db).Create(&uowRecord{Name: "a"}).Error)
+			return uow.Do(ctx, func(ctx context.Context) error {
+				AfterCommit(ctx, func(ctx context.Context) {
+					assert.False(t, InTransaction(ctx))
+					committed = append(committed, countRecords(t, db))
+				})
+				assert.Empty(t, committed, "a nested unit of work waits for the outer commit")
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1}, committed)
+	})
+
+	t.Run("is dropped on rollback", func(t *testing.T) {
+		err := uow.Do(context.Background(), func(ctx context.Context) error {
+			AfterCommit(ctx, func(context.Context) { t.Error("ran after a rollback") })
+			return errors.New("business rule violated")
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("runs right away outside a transaction", func(t *testing.T) {
+		ran := false
+		AfterCommit(context.Background(), func(context.Context) { ran = true })
+		assert.True(t, ran)
+	})
+}
+
+func TestFromContext_WithoutTransaction(t *testing.T) {
+	db := setupUnitOfWorkDB(t)
+
+	require.NoError(t, FromContext(context.Background(), db).Create(&uowRecord{Name: "a"}).Error)
+	assert.Equal(t, int64(1), countRecords(t, db))
+}
+
+func TestNewUnitOfWork_NilDB(t *testing.T) {
+	assert.Panics(t, func() {
+		NewUnitOfWork(nil)
+	})
+}
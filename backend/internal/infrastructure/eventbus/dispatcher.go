@@ -0,0 +1,196 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const (
+	defaultWorkers    = 4
+	defaultBufferSize = 256
+)
+
+type delivery struct {
+	ctx     context.Context
+	event   event.Event
+	handler event.Handler
+}
+
+// Dispatcher is an in-process event.Bus. Handlers run asynchronously on a
+// fixed worker pool so publishers never wait on subscribers; handler errors
+// and panics are logged and do not affect other subscribers. Dispatch runs
+// them in the caller instead, for callers that must know they succeeded.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string][]event.Handler
+	queue    chan delivery
+	wg       sync.WaitGroup
+	closed   bool
+	log      logger.Logger
+}
+
+// DispatcherOption configures a Dispatcher
+type DispatcherOption func(*dispatcherOptions)
+
+type dispatcherOptions struct {
+	workers    int
+	bufferSize int
+}
+
+// WithWorkers sets the number of delivery goroutines
+func WithWorkers(n int) DispatcherOption {
+	return func(o *dispatcherOptions) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// WithBufferSize sets the delivery queue capacity
+func WithBufferSize(n int) DispatcherOption {
+	return func(o *dispatcherOptions) {
+		if n > 0 {
+			o.bufferSize = n
+		}
+	}
+}
+
+// NewDispatcher creates and starts an in-process event dispatcher
+func NewDispatcher(opts ...DispatcherOption) *Dispatcher {
+	options := dispatcherOptions{workers: defaultWorkers, bufferSize: defaultBufferSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	d := &Dispatcher{
+		handlers: make(map[string][]event.Handler),
+		queue:    make(chan delivery, options.bufferSize),
+		log:      logger.Get().WithLayer("infrastructure").WithComponent("event_dispatcher"),
+	}
+
+	d.wg.Add(options.workers)
+	for i := 0; i < options.workers; i++ {
+		go d.worker()
+	}
+
+	return d
+}
+
+// Subscribe registers handler for events with the given name
+func (d *Dispatcher) Subscribe(eventName string, handler event.Handler) {
+	if handler == nil {
+		panic("event handler cannot be nil")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[eventName] = append(d.handlers[eventName], handler)
+}
+
+// Publish queues events for delivery to their subscribers. It blocks only
+// when the queue is full and returns early if ctx is cancelled.
+func (d *Dispatcher) Publish(ctx context.Context, events ...event.Event) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return fmt.Errorf("event dispatcher is closed")
+	}
+
+	// Detach from request cancellation; delivery outlives the publisher
+	deliveryCtx := context.WithoutCancel(ctx)
+
+	for _, e := range events {
+		for _, h := range d.handlers[e.EventName()] {
+			select {
+			case d.queue <- delivery{ctx: deliveryCtx, event: e, handler: h}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// Dispatch runs the subscribers of events in the calling goroutine and
+// returns their errors, with panics reported as errors. Every subscriber
+// runs even when an earlier one fails. Unlike Publish it suits callers that
+// must know delivery succeeded, such as the outbox relay, which retries a
+// failed message with all its subscribers again.
+func (d *Dispatcher) Dispatch(ctx context.Context, events ...event.Event) error {
+	d.mu.RLock()
+	if d.closed {
+		d.mu.RUnlock()
+		return fmt.Errorf("event dispatcher is closed")
+	}
+	jobs := make([]delivery, 0, len(events))
+	for _, e := range events {
+		for _, h := range d.handlers[e.EventName()] {
+			jobs = append(jobs, delivery{ctx: ctx, event: e, handler: h})
+		}
+	}
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, job := range jobs {
+		if err := d.run(job); err != nil {
+			errs = append(errs, fmt.Errorf("%s handler: %w", job.event.EventName(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops accepting events and waits for queued deliveries to finish
+// or for ctx to expire
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.queue)
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event dispatcher did not drain before deadline: %w", ctx.Err())
+	}
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for job := range d.queue {
+		d.deliver(job)
+	}
+}
+
+func (d *Dispatcher) deliver(job delivery) {
+	if err := d.run(job); err != nil {
+		d.log.Error(job.ctx, "event handler failed", "event", job.event.EventName(),
+			"aggregate_id", job.event.AggregateID(), "error", err)
+	}
+}
+
+// run calls the handler of job, turning a panic into an error
+func (d *Dispatcher) run(job delivery) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler panicked: %v", r)
+		}
+	}()
+	return job.handler(job.ctx, job.event)
+}
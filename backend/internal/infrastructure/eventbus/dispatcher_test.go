@@ -0,0 +1,133 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+type testEvent struct {
+	event.Base
+	name string
+}
+
+func (e testEvent) EventName() string { return e.name }
+
+func TestDispatcher_DeliversToSubscribers(t *testing.T) {
+	logger.Initialize()
+
+	d := NewDispatcher(WithWorkers(2), WithBufferSize(8))
+
+	var mu sync.Mutex
+	received := map[string][]string{}
+	record := func(subscriber string) event.Handler {
+		return func(ctx context.Context, e event.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received[subscriber] = append(received[subscriber], e.AggregateID())
+			return nil
+		}
+	}
+
+	d.Subscribe("user.registered", record("email"))
+	d.Subscribe("user.registered", record("audit"))
+	d.Subscribe("user.deleted", record("audit"))
+
+	err := d.Publish(context.Background(),
+		testEvent{Base: event.NewBase("u1"), name: "user.registered"},
+		testEvent{Base: event.NewBase("u2"), name: "user.unhandled"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, d.Close(context.Background()))
+
+	assert.Equal(t, []string{"u1"}, received["email"])
+	assert.Equal(t, []string{"u1"}, received["audit"])
+}
+
+func TestDispatcher_IsolatesFailingHandlers(t *testing.T) {
+	logger.Initialize()
+
+	d := NewDispatcher(WithWorkers(1))
+
+	var delivered int32
+	d.Subscribe("evt", func(ctx context.Context, e event.Event) error { panic("boom") })
+	d.Subscribe("evt", func(ctx context.Context, e event.Event) error { return errors.New("failed") })
+	d.Subscribe("evt", func(ctx context.Context, e event.Event) error {
+		atomic.AddInt32(&delivered, 1)
+		return nil
+	})
+
+	require.NoError(t, d.Publish(context.Background(), testEvent{Base: event.NewBase("a"), name: "evt"}))
+	require.NoError(t, d.Close(context.Background()))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&delivered))
+}
+
+func TestDispatcher_OutlivesPublisherContext(t *testing.T) {
+	logger.Initialize()
+
+	d := NewDispatcher(WithWorkers(1))
+
+	done := make(chan error, 1)
+	d.Subscribe("evt", func(ctx context.Context, e event.Event) error {
+		done <- ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, d.Publish(ctx, testEvent{Base: event.NewBase("a"), name: "evt"}))
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "handler context must not inherit publisher cancellation")
+	case <-time.After(time.Second):
+		t.Fatal("event was not delivered")
+	}
+	require.NoError(t, d.Close(context.Background()))
+}
+
+func TestDispatcher_PublishAfterClose(t *testing.T) {
+	logger.Initialize()
+
+	d := NewDispatcher()
+	require.NoError(t, d.Close(context.Background()))
+	require.NoError(t, d.Close(context.Background()), "close is idempotent")
+
+	err := d.Publish(context.Background(), testEvent{Base: event.NewBase("a"), name: "evt"})
+	assert.Error(t, err)
+}
+
+func TestDispatcher_DispatchReportsFailures(t *testing.T) {
+	logger.Initialize()
+
+	d := NewDispatcher(WithWorkers(1))
+
+	var delivered int32
+	d.Subscribe("evt", func(ctx context.Context, e event.Event) error { panic("boom") })
+	d.Subscribe("evt", func(ctx context.Context, e event.Event) error { return errors.New("failed") })
+	d.Subscribe("evt", func(ctx context.Context, e event.Event) error {
+		atomic.AddInt32(&delivered, 1)
+		return nil
+	})
+
+	err := d.Dispatch(context.Background(), testEvent{Base: event.NewBase("a"), name: "evt"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "panicked: boom")
+	assert.Contains(t, err.Error(), "failed")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&delivered), "handlers ran before Dispatch returned")
+
+	assert.NoError(t, d.Dispatch(context.Background(), testEvent{Base: event.NewBase("a"), name: "other"}))
+
+	require.NoError(t, d.Close(context.Background()))
+	assert.Error(t, d.Dispatch(context.Background(), testEvent{Base: event.NewBase("a"), name: "evt"}))
+}
@@ -0,0 +1,177 @@
+// Package loadtest drives a running server at a fixed request rate and
+// measures the latency of its hot endpoints, so that releases can be
+// compared with a stored baseline.
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// Password is the password of every user the load test registers
+const Password = "L0adTest!Passw0rd"
+
+// Endpoint is one call under load. Prepare runs once before the load
+// starts, for example to create the user a login needs; Request builds
+// the i-th request.
+type Endpoint struct {
+	Name    string
+	Prepare func(t *Target, ctx context.Context) error
+	Request func(t *Target, ctx context.Context, i int64) (*http.Request, error)
+}
+
+// Target is the server under load
+type Target struct {
+	baseURL    string
+	httpClient *http.Client
+	// run makes the emails of this run unique, so it can be repeated
+	// against the same database
+	run string
+
+	email string
+	token string
+}
+
+// NewTarget creates a target for the given base URL
+func NewTarget(baseURL string, httpClient *http.Client) *Target {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Target{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+		run:        fmt.Sprintf("%x", time.Now().UnixNano()),
+	}
+}
+
+// Endpoints are the endpoints the load test knows, by name
+var Endpoints = map[string]Endpoint{
+	"register": {
+		Name: "register",
+		Request: func(t *Target, ctx context.Context, i int64) (*http.Request, error) {
+			return t.jsonRequest(ctx, http.MethodPost, "/api/v1/users/register", map[string]string{
+				"email":    t.emailFor(i),
+				"name":     "Load Test",
+				"password": Password,
+			})
+		},
+	},
+	"login": {
+		Name:    "login",
+		Prepare: (*Target).ensureUser,
+		Request: func(t *Target, ctx context.Context, _ int64) (*http.Request, error) {
+			return t.jsonRequest(ctx, http.MethodPost, "/api/v1/auth/login", map[string]string{
+				"email":    t.email,
+				"password": Password,
+			})
+		},
+	},
+	"list": {
+		Name:    "list",
+		Prepare: (*Target).ensureToken,
+		Request: func(t *Target, ctx context.Context, _ int64) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+"/api/v1/users?page=1&page_size=20", nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "Bearer "+t.token)
+			return req, nil
+		},
+	},
+}
+
+func (t *Target) emailFor(i int64) string {
+	return fmt.Sprintf("loadtest-%s-%d@example.com", t.run, i)
+}
+
+// ensureUser registers the user logins are made with
+func (t *Target) ensureUser(ctx context.Context) error {
+	if t.email != "" {
+		return nil
+	}
+	email := fmt.Sprintf("loadtest-%s-prepared@example.com", t.run)
+	req, err := t.jsonRequest(ctx, http.MethodPost, "/api/v1/users/register", map[string]string{
+		"email":    email,
+		"name":     "Load Test",
+		"password": Password,
+	})
+	if err != nil {
+		return err
+	}
+	if err := t.call(req, "register", http.StatusCreated, nil); err != nil {
+		return err
+	}
+	t.email = email
+	return nil
+}
+
+// ensureToken logs the prepared user in for authenticated endpoints
+func (t *Target) ensureToken(ctx context.Context) error {
+	if t.token != "" {
+		return nil
+	}
+	if err := t.ensureUser(ctx); err != nil {
+		return err
+	}
+	req, err := t.jsonRequest(ctx, http.MethodPost, "/api/v1/auth/login", map[string]string{
+		"email":    t.email,
+		"password": Password,
+	})
+	if err != nil {
+		return err
+	}
+	var body struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+		} `json:"data"`
+	}
+	if err := t.call(req, "login", http.StatusOK, &body); err != nil {
+		return err
+	}
+	if body.Data.AccessToken == "" {
+		return fmt.Errorf("login response did not contain an access token")
+	}
+	t.token = body.Data.AccessToken
+	return nil
+}
+
+func (t *Target) jsonRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// call sends a preparation request, which must answer with status, and
+// decodes its body into out when given
+func (t *Target) call(req *http.Request, operation string, status int, out interface{}) error {
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return wonderErrors.NewExternalServiceError("loadtest_target", operation, 0, "", err, true)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != status {
+		return wonderErrors.NewExternalServiceError("loadtest_target", operation, resp.StatusCode, "",
+			fmt.Errorf("%s failed with status %d", operation, resp.StatusCode), false)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", operation, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Result is what one endpoint measured under load
+type Result struct {
+	Endpoint string `json:"endpoint"`
+	// Requests counts the requests sent, including failed ones
+	Requests int64 `json:"requests"`
+	// Errors counts requests that failed or answered with a 4xx or 5xx
+	Errors int64 `json:"errors"`
+	// Dropped counts requests not sent because every worker was busy
+	Dropped int64 `json:"dropped"`
+	// Throughput is the successful requests per second
+	Throughput float64     `json:"throughput"`
+	Statuses   map[int]int `json:"statuses"`
+	Latency    Percentiles `json:"latency"`
+}
+
+// Percentiles of the latency of successful requests, in nanoseconds when
+// encoded
+type Percentiles struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+func newResult(endpoint string, elapsed time.Duration, latencies []time.Duration, statuses map[int]int, sent, errs, dropped int64) *Result {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	r := &Result{
+		Endpoint: endpoint,
+		Requests: sent,
+		Errors:   errs,
+		Dropped:  dropped,
+		Statuses: statuses,
+		Latency: Percentiles{
+			P50: percentile(latencies, 0.50),
+			P90: percentile(latencies, 0.90),
+			P95: percentile(latencies, 0.95),
+			P99: percentile(latencies, 0.99),
+		},
+	}
+	if len(latencies) > 0 {
+		r.Latency.Max = latencies[len(latencies)-1]
+	}
+	if elapsed > 0 {
+		r.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	return r
+}
+
+// percentile returns the nearest-rank q-th percentile of sorted latencies
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// Report is the outcome of a load test run, and the format baselines are
+// stored in
+type Report struct {
+	Rate      int           `json:"rate"`
+	Duration  time.Duration `json:"duration"`
+	Workers   int           `json:"workers"`
+	Results   []*Result     `json:"results"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Result returns the result of endpoint, or nil when it was not run
+func (r *Report) Result(endpoint string) *Result {
+	for _, res := range r.Results {
+		if res.Endpoint == endpoint {
+			return res
+		}
+	}
+	return nil
+}
+
+// ReadReport reads a report written by WriteReport
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// WriteReport writes r as indented JSON
+func WriteReport(path string, r *Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Gate decides whether a run regressed from its baseline
+type Gate struct {
+	// MaxRegression is the p99 increase tolerated, as a fraction of the
+	// baseline p99: 0.2 allows 20% slower
+	MaxRegression float64
+	// Slack is an absolute p99 increase always tolerated, so that noise on
+	// endpoints answering in a millisecond or two does not fail the gate
+	Slack time.Duration
+	// MaxErrorRate is the fraction of requests allowed to fail
+	MaxErrorRate float64
+	// MaxDropRate is the fraction of scheduled requests allowed to be
+	// dropped. Latency is measured from when a request is sent, so a server
+	// slow enough to keep every worker busy would otherwise pass with the
+	// p99 of the few requests that got through.
+	MaxDropRate float64
+}
+
+// Regression is an endpoint that failed the gate
+type Regression struct {
+	Endpoint string
+	Reason   string
+}
+
+func (r Regression) String() string {
+	return r.Endpoint + ": " + r.Reason
+}
+
+// Check compares the p99 of each endpoint of run with baseline, and its
+// error and drop rates with the limits. Endpoints missing from the
+// baseline are only checked for errors and drops.
+func (g Gate) Check(baseline, run *Report) []Regression {
+	var regressions []Regression
+	for _, res := range run.Results {
+		if res.Requests > 0 {
+			if rate := float64(res.Errors) / float64(res.Requests); rate > g.MaxErrorRate {
+				regressions = append(regressions, Regression{res.Endpoint,
+					fmt.Sprintf("%.1f%% of requests failed, above %.1f%%", rate*100, g.MaxErrorRate*100)})
+			}
+		}
+		if scheduled := res.Requests + res.Dropped; scheduled > 0 {
+			if rate := float64(res.Dropped) / float64(scheduled); rate > g.MaxDropRate {
+				regressions = append(regressions, Regression{res.Endpoint,
+					fmt.Sprintf("%.1f%% of requests were dropped, above %.1f%%", rate*100, g.MaxDropRate*100)})
+			}
+		}
+
+		base := baseline.Result(res.Endpoint)
+		if base == nil {
+			continue
+		}
+		limit := time.Duration(float64(base.Latency.P99)*(1+g.MaxRegression)) + g.Slack
+		if res.Latency.P99 > limit {
+			regressions = append(regressions, Regression{res.Endpoint,
+				fmt.Sprintf("p99 %s exceeds %s (baseline %s)", res.Latency.P99, limit, base.Latency.P99)})
+		}
+	}
+	return regressions
+}
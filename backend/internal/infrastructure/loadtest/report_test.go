@@ -0,0 +1,81 @@
+package loadtest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 0.50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 0.99))
+	assert.Equal(t, 7*time.Millisecond, percentile([]time.Duration{7 * time.Millisecond}, 0.99))
+	assert.Zero(t, percentile(nil, 0.99))
+}
+
+func TestNewResult(t *testing.T) {
+	latencies := []time.Duration{3 * time.Millisecond, time.Millisecond, 2 * time.Millisecond}
+	r := newResult("login", time.Second, latencies, map[int]int{200: 3, 500: 1}, 4, 1, 2)
+
+	assert.Equal(t, int64(4), r.Requests)
+	assert.Equal(t, int64(1), r.Errors)
+	assert.Equal(t, int64(2), r.Dropped)
+	assert.Equal(t, 2*time.Millisecond, r.Latency.P50)
+	assert.Equal(t, 3*time.Millisecond, r.Latency.P99)
+	assert.Equal(t, 3*time.Millisecond, r.Latency.Max)
+	assert.InDelta(t, 3.0, r.Throughput, 0.001)
+}
+
+func TestGate_Check(t *testing.T) {
+	baseline := &Report{Results: []*Result{
+		{Endpoint: "login", Requests: 100, Latency: Percentiles{P99: 100 * time.Millisecond}},
+		{Endpoint: "list", Requests: 100, Latency: Percentiles{P99: time.Millisecond}},
+	}}
+	gate := Gate{MaxRegression: 0.2, Slack: 5 * time.Millisecond, MaxErrorRate: 0.01, MaxDropRate: 0.01}
+
+	tests := []struct {
+		name   string
+		result *Result
+		failed bool
+	}{
+		{"within threshold", &Result{Endpoint: "login", Requests: 100, Latency: Percentiles{P99: 124 * time.Millisecond}}, false},
+		{"beyond threshold", &Result{Endpoint: "login", Requests: 100, Latency: Percentiles{P99: 126 * time.Millisecond}}, true},
+		{"noise within slack", &Result{Endpoint: "list", Requests: 100, Latency: Percentiles{P99: 4 * time.Millisecond}}, false},
+		{"too many errors", &Result{Endpoint: "list", Requests: 100, Errors: 2, Latency: Percentiles{P99: time.Millisecond}}, true},
+		{"too many dropped", &Result{Endpoint: "login", Requests: 90, Dropped: 10, Latency: Percentiles{P99: 50 * time.Millisecond}}, true},
+		{"few dropped", &Result{Endpoint: "login", Requests: 999, Dropped: 1, Latency: Percentiles{P99: 100 * time.Millisecond}}, false},
+		{"not in baseline", &Result{Endpoint: "register", Requests: 100, Latency: Percentiles{P99: time.Second}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regressions := gate.Check(baseline, &Report{Results: []*Result{tt.result}})
+			if tt.failed {
+				require.Len(t, regressions, 1)
+				assert.Equal(t, tt.result.Endpoint, regressions[0].Endpoint)
+			} else {
+				assert.Empty(t, regressions)
+			}
+		})
+	}
+}
+
+func TestReport_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	report := &Report{Rate: 50, Duration: 30 * time.Second, Workers: 50, Results: []*Result{
+		{Endpoint: "login", Requests: 1500, Statuses: map[int]int{200: 1500}, Latency: Percentiles{P99: 42 * time.Millisecond}},
+	}}
+	require.NoError(t, WriteReport(path, report))
+
+	read, err := ReadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, 42*time.Millisecond, read.Result("login").Latency.P99)
+	assert.Equal(t, 1500, read.Result("login").Statuses[200])
+	assert.Nil(t, read.Result("list"))
+}
@@ -0,0 +1,106 @@
+package loadtest
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options shape the load put on each endpoint
+type Options struct {
+	// Rate is the number of requests started per second
+	Rate int
+	// Duration is how long each endpoint is loaded
+	Duration time.Duration
+	// Workers bounds the requests in flight. A request whose turn comes
+	// while every worker is busy is dropped and counted, rather than sent
+	// late and measured as if it had been on time.
+	Workers int
+}
+
+// Run loads endpoint at a fixed rate for the duration and returns its
+// measurements. It fails only when the endpoint cannot be prepared; requests
+// that fail or answer with an error status are counted in the result.
+func Run(ctx context.Context, t *Target, endpoint Endpoint, opts Options) (*Result, error) {
+	if endpoint.Prepare != nil {
+		if err := endpoint.Prepare(t, ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		mu        sync.Mutex
+		latencies = make([]time.Duration, 0, opts.Rate*int(opts.Duration/time.Second+1))
+		statuses  = make(map[int]int)
+		sent      atomic.Int64
+		errs      atomic.Int64
+		dropped   int64
+		seq       atomic.Int64
+	)
+
+	// Unbuffered: a tick is only taken by a worker that is idle
+	work := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range work {
+				sent.Add(1)
+				req, err := endpoint.Request(t, ctx, seq.Add(1))
+				if err != nil {
+					errs.Add(1)
+					continue
+				}
+				start := time.Now()
+				resp, err := t.httpClient.Do(req)
+				if err != nil {
+					errs.Add(1)
+					continue
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				elapsed := time.Since(start)
+
+				// Only successes are timed, so that fast rejections such as
+				// rate limiting do not flatter the percentiles
+				mu.Lock()
+				statuses[resp.StatusCode]++
+				if resp.StatusCode < 400 {
+					latencies = append(latencies, elapsed)
+				} else {
+					errs.Add(1)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	interval := time.Second / time.Duration(opts.Rate)
+	ticker := time.NewTicker(interval)
+	deadline := time.NewTimer(opts.Duration)
+	started := time.Now()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-ticker.C:
+			select {
+			case work <- struct{}{}:
+			default:
+				dropped++
+			}
+		}
+	}
+	ticker.Stop()
+	deadline.Stop()
+	close(work)
+	wg.Wait()
+
+	return newResult(endpoint.Name, time.Since(started), latencies, statuses, sent.Load(), errs.Load(), dropped), nil
+}
@@ -0,0 +1,93 @@
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers the endpoints the load test calls and records the
+// emails registered
+func fakeServer(t *testing.T) (*httptest.Server, *sync.Map) {
+	registered := &sync.Map{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/users/register":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if _, dup := registered.LoadOrStore(body["email"], true); dup {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		case "/api/v1/auth/login":
+			w.Write([]byte(`{"data":{"access_token":"token"}}`))
+		case "/api/v1/users":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"data":{"users":[]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, registered
+}
+
+func TestRun(t *testing.T) {
+	server, registered := fakeServer(t)
+	target := NewTarget(server.URL, server.Client())
+	opts := Options{Rate: 200, Duration: 250 * time.Millisecond, Workers: 4}
+
+	for _, name := range []string{"register", "login", "list"} {
+		result, err := Run(context.Background(), target, Endpoints[name], opts)
+		require.NoError(t, err, name)
+
+		assert.Equal(t, name, result.Endpoint)
+		assert.Greater(t, result.Requests, int64(10), name)
+		assert.Zero(t, result.Errors, name)
+		assert.Equal(t, int(result.Requests), result.Statuses[http.StatusCreated]+result.Statuses[http.StatusOK], name)
+		assert.Positive(t, result.Latency.P99, name)
+		assert.LessOrEqual(t, result.Latency.P50, result.Latency.P99, name)
+		assert.LessOrEqual(t, result.Latency.P99, result.Latency.Max, name)
+	}
+
+	// Every registration used a new email, plus the prepared user
+	count := 0
+	registered.Range(func(_, _ interface{}) bool { count++; return true })
+	assert.Greater(t, count, 10)
+}
+
+func TestRun_CountsErrorStatuses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	result, err := Run(context.Background(), NewTarget(server.URL, server.Client()), Endpoints["register"],
+		Options{Rate: 100, Duration: 100 * time.Millisecond, Workers: 2})
+	require.NoError(t, err)
+	assert.Equal(t, result.Requests, result.Errors)
+	assert.Zero(t, result.Latency.P99, "rejections are not timed")
+}
+
+func TestRun_PrepareFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := Run(context.Background(), NewTarget(server.URL, server.Client()), Endpoints["login"],
+		Options{Rate: 10, Duration: 50 * time.Millisecond, Workers: 1})
+	assert.Error(t, err)
+}
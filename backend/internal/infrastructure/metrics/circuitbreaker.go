@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+)
+
+var (
+	breakerRegisterOnce sync.Once
+	breakerState        *prometheus.GaugeVec
+	breakerTransitions  *prometheus.CounterVec
+	breakerRejected     *prometheus.CounterVec
+)
+
+func initBreaker() {
+	breakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "wonder",
+		Subsystem: "circuit_breaker",
+		Name:      "state",
+		Help:      "Current circuit breaker state: 0 closed, 1 half-open, 2 open.",
+	}, []string{"name"})
+
+	breakerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "circuit_breaker",
+		Name:      "transitions_total",
+		Help:      "Total number of circuit breaker state changes, labeled by the new state.",
+	}, []string{"name", "state"})
+
+	breakerRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "circuit_breaker",
+		Name:      "rejected_total",
+		Help:      "Total number of calls rejected without running because the breaker was open.",
+	}, []string{"name"})
+
+	prometheus.MustRegister(breakerState, breakerTransitions, breakerRejected)
+}
+
+// EnsureBreakerMetrics registers the circuit breaker metrics once per process.
+func EnsureBreakerMetrics() {
+	breakerRegisterOnce.Do(initBreaker)
+}
+
+// ObserveBreakerState records the current state of the named breaker.
+func ObserveBreakerState(name string, state circuitbreaker.State) {
+	EnsureBreakerMetrics()
+	breakerState.WithLabelValues(name).Set(float64(state))
+}
+
+// InstrumentBreaker returns s with hooks exporting state changes and
+// rejected calls. Existing hooks still run.
+func InstrumentBreaker(s circuitbreaker.Settings) circuitbreaker.Settings {
+	ObserveBreakerState(s.Name, circuitbreaker.StateClosed)
+
+	onStateChange, onReject := s.OnStateChange, s.OnReject
+	s.OnStateChange = func(name string, from, to circuitbreaker.State) {
+		ObserveBreakerState(name, to)
+		breakerTransitions.WithLabelValues(name, to.String()).Inc()
+		if onStateChange != nil {
+			onStateChange(name, from, to)
+		}
+	}
+	s.OnReject = func(name string) {
+		breakerRejected.WithLabelValues(name).Inc()
+		if onReject != nil {
+			onReject(name)
+		}
+	}
+	return s
+}
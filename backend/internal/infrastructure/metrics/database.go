@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	dbRegisterOnce  sync.Once
+	dbQueryDuration *prometheus.HistogramVec
+	dbSlowQueries   *prometheus.CounterVec
+	dbPingDuration  *prometheus.HistogramVec
+	dbUp            *prometheus.GaugeVec
+	dbReconnects    *prometheus.CounterVec
+	dbPools         = &poolCollector{pools: map[string]func() sql.DBStats{}}
+)
+
+func initDatabase() {
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "wonder",
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Histogram of database query latencies in seconds, labeled by operation, table and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "table", "outcome"})
+
+	dbSlowQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "db",
+		Name:      "slow_queries_total",
+		Help:      "Total number of database queries exceeding the slow query threshold.",
+	}, []string{"operation", "table"})
+
+	dbPingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "wonder",
+		Subsystem: "db",
+		Name:      "ping_duration_seconds",
+		Help:      "Histogram of database health check ping latencies in seconds, labeled by pool and outcome.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"pool", "outcome"})
+
+	dbUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "wonder",
+		Subsystem: "db",
+		Name:      "up",
+		Help:      "Whether the last health check ping of the pool succeeded (1) or failed (0).",
+	}, []string{"pool"})
+
+	dbReconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "db",
+		Name:      "reconnects_total",
+		Help:      "Total number of times the pool was re-established after the database became reachable again.",
+	}, []string{"pool"})
+
+	prometheus.MustRegister(dbQueryDuration, dbSlowQueries, dbPingDuration, dbUp, dbReconnects, dbPools)
+}
+
+// EnsureDatabaseMetrics registers the database metrics once per process.
+func EnsureDatabaseMetrics() {
+	dbRegisterOnce.Do(initDatabase)
+}
+
+// ObserveDBQuery records metrics for a single database statement.
+func ObserveDBQuery(operation, table string, failed, slow bool, durationSeconds float64) {
+	EnsureDatabaseMetrics()
+	outcome := "success"
+	if failed {
+		outcome = "error"
+	}
+	dbQueryDuration.WithLabelValues(operation, table, outcome).Observe(durationSeconds)
+	if slow {
+		dbSlowQueries.WithLabelValues(operation, table).Inc()
+	}
+}
+
+// ObserveDBPing records a health check ping of the named pool.
+func ObserveDBPing(pool string, failed bool, durationSeconds float64) {
+	EnsureDatabaseMetrics()
+	outcome, up := "success", 1.0
+	if failed {
+		outcome, up = "error", 0
+	}
+	dbPingDuration.WithLabelValues(pool, outcome).Observe(durationSeconds)
+	dbUp.WithLabelValues(pool).Set(up)
+}
+
+// ObserveDBReconnect counts a re-established pool.
+func ObserveDBReconnect(pool string) {
+	EnsureDatabaseMetrics()
+	dbReconnects.WithLabelValues(pool).Inc()
+}
+
+// RegisterDBPool exports connection pool statistics for the named pool.
+// stats is read on every scrape; registering a name again replaces it.
+func RegisterDBPool(name string, stats func() sql.DBStats) {
+	EnsureDatabaseMetrics()
+	dbPools.mu.Lock()
+	defer dbPools.mu.Unlock()
+	dbPools.pools[name] = stats
+}
+
+// UnregisterDBPool stops exporting statistics for the named pool.
+func UnregisterDBPool(name string) {
+	dbPools.mu.Lock()
+	defer dbPools.mu.Unlock()
+	delete(dbPools.pools, name)
+}
+
+var (
+	poolOpenDesc = prometheus.NewDesc("wonder_db_pool_open_connections",
+		"Number of established connections, both in use and idle.", []string{"pool"}, nil)
+	poolInUseDesc = prometheus.NewDesc("wonder_db_pool_in_use_connections",
+		"Number of connections currently in use.", []string{"pool"}, nil)
+	poolIdleDesc = prometheus.NewDesc("wonder_db_pool_idle_connections",
+		"Number of idle connections.", []string{"pool"}, nil)
+	poolMaxOpenDesc = prometheus.NewDesc("wonder_db_pool_max_open_connections",
+		"Maximum number of open connections allowed.", []string{"pool"}, nil)
+	poolWaitCountDesc = prometheus.NewDesc("wonder_db_pool_wait_count_total",
+		"Total number of connections waited for.", []string{"pool"}, nil)
+	poolWaitSecondsDesc = prometheus.NewDesc("wonder_db_pool_wait_seconds_total",
+		"Total time blocked waiting for a new connection.", []string{"pool"}, nil)
+)
+
+// poolCollector reads sql.DBStats at scrape time
+type poolCollector struct {
+	mu    sync.Mutex
+	pools map[string]func() sql.DBStats
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolOpenDesc
+	ch <- poolInUseDesc
+	ch <- poolIdleDesc
+	ch <- poolMaxOpenDesc
+	ch <- poolWaitCountDesc
+	ch <- poolWaitSecondsDesc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.pools))
+	for name := range c.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stats := make([]sql.DBStats, len(names))
+	for i, name := range names {
+		stats[i] = c.pools[name]()
+	}
+	c.mu.Unlock()
+
+	for i, name := range names {
+		s := stats[i]
+		ch <- prometheus.MustNewConstMetric(poolOpenDesc, prometheus.GaugeValue, float64(s.OpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(poolInUseDesc, prometheus.GaugeValue, float64(s.InUse), name)
+		ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(s.Idle), name)
+		ch <- prometheus.MustNewConstMetric(poolMaxOpenDesc, prometheus.GaugeValue, float64(s.MaxOpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(poolWaitCountDesc, prometheus.CounterValue, float64(s.WaitCount), name)
+		ch <- prometheus.MustNewConstMetric(poolWaitSecondsDesc, prometheus.CounterValue, s.WaitDuration.Seconds(), name)
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cctw-zed/wonder/pkg/httpclient"
+)
+
+var (
+	httpClientRegisterOnce    sync.Once
+	httpClientRequestsTotal   *prometheus.CounterVec
+	httpClientRequestDuration *prometheus.HistogramVec
+)
+
+func initHTTPClient() {
+	httpClientRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "http_client",
+		Name:      "requests_total",
+		Help:      "Total number of outbound HTTP request attempts, labeled by client, method, host, and status code or \"error\".",
+	}, []string{"client", "method", "host", "status"})
+
+	httpClientRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "wonder",
+		Subsystem: "http_client",
+		Name:      "request_duration_seconds",
+		Help:      "Histogram of latencies for outbound HTTP request attempts in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"client", "method", "host"})
+
+	prometheus.MustRegister(httpClientRequestsTotal, httpClientRequestDuration)
+}
+
+// EnsureHTTPClientMetrics registers the outbound HTTP metrics once per
+// process.
+func EnsureHTTPClientMetrics() {
+	httpClientRegisterOnce.Do(initHTTPClient)
+}
+
+// ObserveHTTPClientRequest records one outbound request attempt.
+func ObserveHTTPClientRequest(o httpclient.Observation) {
+	EnsureHTTPClientMetrics()
+	status := "error"
+	if o.Err == nil {
+		status = strconv.Itoa(o.Status)
+	}
+	httpClientRequestsTotal.WithLabelValues(o.Client, o.Method, o.Host, status).Inc()
+	httpClientRequestDuration.WithLabelValues(o.Client, o.Method, o.Host).Observe(o.Duration.Seconds())
+}
+
+// InstrumentHTTPClient returns cfg with its attempts and retries counted.
+// An existing observer still runs.
+func InstrumentHTTPClient(cfg httpclient.Config) httpclient.Config {
+	observer := cfg.Observer
+	cfg.Observer = func(o httpclient.Observation) {
+		ObserveHTTPClientRequest(o)
+		if observer != nil {
+			observer(o)
+		}
+	}
+	cfg.Retry = InstrumentRetry(cfg.Retry, "http_client."+cfg.Name)
+	return cfg
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+var loggingRegisterOnce sync.Once
+
+func initLogging() {
+	prometheus.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "log",
+		Name:      "entries_dropped_total",
+		Help:      "Total number of log entries dropped because a log buffer was full or shipping them failed.",
+	}, func() float64 {
+		return float64(logger.DroppedEntries())
+	}))
+}
+
+// EnsureLoggingMetrics registers the logging metrics once per process.
+func EnsureLoggingMetrics() {
+	loggingRegisterOnce.Do(initLogging)
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"sync"
+	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -10,8 +11,17 @@ var (
 	registerOnce        sync.Once
 	httpRequestsTotal   *prometheus.CounterVec
 	httpRequestDuration *prometheus.HistogramVec
+	httpRequestSize     *prometheus.HistogramVec
+	httpResponseSize    *prometheus.HistogramVec
 )
 
+// sizeBuckets span 100B to 100MB
+var sizeBuckets = prometheus.ExponentialBuckets(100, 10, 7)
+
+// maxExemplarTraceID bounds trace IDs attached as exemplars; exemplar
+// labels are limited to 128 runes in total
+const maxExemplarTraceID = 64
+
 func initDefault() {
 	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "wonder",
@@ -28,7 +38,23 @@ func initDefault() {
 		Buckets:   prometheus.DefBuckets,
 	}, []string{"method", "route"})
 
-	prometheus.MustRegister(httpRequestsTotal, httpRequestDuration)
+	httpRequestSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "wonder",
+		Subsystem: "http",
+		Name:      "request_size_bytes",
+		Help:      "Histogram of HTTP request body sizes in bytes.",
+		Buckets:   sizeBuckets,
+	}, []string{"method", "route"})
+
+	httpResponseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "wonder",
+		Subsystem: "http",
+		Name:      "response_size_bytes",
+		Help:      "Histogram of HTTP response body sizes in bytes.",
+		Buckets:   sizeBuckets,
+	}, []string{"method", "route"})
+
+	prometheus.MustRegister(httpRequestsTotal, httpRequestDuration, httpRequestSize, httpResponseSize)
 }
 
 // EnsureHTTPMetrics registers the default HTTP metrics once per process.
@@ -36,9 +62,32 @@ func EnsureHTTPMetrics() {
 	registerOnce.Do(initDefault)
 }
 
+// HTTPRequest describes a handled HTTP request. Route is the route
+// template, e.g. /api/v1/users/:id, never the raw path, so the number of
+// series stays bounded.
+type HTTPRequest struct {
+	Method          string
+	Route           string
+	Status          string
+	DurationSeconds float64
+	RequestBytes    int64
+	ResponseBytes   int64
+	// TraceID links the latency observation to its trace as an exemplar
+	TraceID string
+}
+
 // ObserveHTTPRequest records metrics for a single HTTP request.
-func ObserveHTTPRequest(method, route, status string, durationSeconds float64) {
+func ObserveHTTPRequest(r HTTPRequest) {
 	EnsureHTTPMetrics()
-	httpRequestsTotal.WithLabelValues(method, route, status).Inc()
-	httpRequestDuration.WithLabelValues(method, route).Observe(durationSeconds)
+	httpRequestsTotal.WithLabelValues(r.Method, r.Route, r.Status).Inc()
+
+	duration := httpRequestDuration.WithLabelValues(r.Method, r.Route)
+	if r.TraceID != "" && len(r.TraceID) <= maxExemplarTraceID && utf8.ValidString(r.TraceID) {
+		duration.(prometheus.ExemplarObserver).ObserveWithExemplar(r.DurationSeconds, prometheus.Labels{"trace_id": r.TraceID})
+	} else {
+		duration.Observe(r.DurationSeconds)
+	}
+
+	httpRequestSize.WithLabelValues(r.Method, r.Route).Observe(float64(max(r.RequestBytes, 0)))
+	httpResponseSize.WithLabelValues(r.Method, r.Route).Observe(float64(max(r.ResponseBytes, 0)))
 }
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	panicRegisterOnce sync.Once
+	httpPanicsTotal   *prometheus.CounterVec
+)
+
+func initPanic() {
+	httpPanicsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "http",
+		Name:      "panics_total",
+		Help:      "Total number of panics recovered from HTTP handlers, labeled by method and route.",
+	}, []string{"method", "route"})
+
+	prometheus.MustRegister(httpPanicsTotal)
+}
+
+// EnsurePanicMetrics registers the panic metrics once per process.
+func EnsurePanicMetrics() {
+	panicRegisterOnce.Do(initPanic)
+}
+
+// ObservePanic records a panic recovered from a handler of route.
+func ObservePanic(method, route string) {
+	EnsurePanicMetrics()
+	httpPanicsTotal.WithLabelValues(method, route).Inc()
+}
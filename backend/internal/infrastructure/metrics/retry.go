@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cctw-zed/wonder/pkg/retry"
+)
+
+var (
+	retryRegisterOnce sync.Once
+	retriesTotal      *prometheus.CounterVec
+	retryExhausted    *prometheus.CounterVec
+)
+
+func initRetry() {
+	retriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "retry",
+		Name:      "attempts_total",
+		Help:      "Total number of retries after transient failures, labeled by operation.",
+	}, []string{"operation"})
+
+	retryExhausted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "retry",
+		Name:      "exhausted_total",
+		Help:      "Total number of operations that still failed with a transient error after all retries.",
+	}, []string{"operation"})
+
+	prometheus.MustRegister(retriesTotal, retryExhausted)
+}
+
+// EnsureRetryMetrics registers the retry metrics once per process.
+func EnsureRetryMetrics() {
+	retryRegisterOnce.Do(initRetry)
+}
+
+// ObserveRetry records one retry of operation.
+func ObserveRetry(operation string) {
+	EnsureRetryMetrics()
+	retriesTotal.WithLabelValues(operation).Inc()
+}
+
+// ObserveRetryExhausted records an operation that ran out of retries.
+func ObserveRetryExhausted(operation string) {
+	EnsureRetryMetrics()
+	retryExhausted.WithLabelValues(operation).Inc()
+}
+
+// InstrumentRetry returns p with hooks counting retries and exhausted
+// retries of operation. Existing hooks still run.
+func InstrumentRetry(p retry.Policy, operation string) retry.Policy {
+	onRetry, onGiveUp := p.OnRetry, p.OnGiveUp
+	p.OnRetry = func(attempt int, err error, delay time.Duration) {
+		ObserveRetry(operation)
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+	}
+	p.OnGiveUp = func(attempts int, err error) {
+		ObserveRetryExhausted(operation)
+		if onGiveUp != nil {
+			onGiveUp(attempts, err)
+		}
+	}
+	return p
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cctw-zed/wonder/pkg/cron"
+)
+
+var (
+	schedulerRegisterOnce sync.Once
+	scheduledRunsTotal    *prometheus.CounterVec
+	scheduledRunDuration  *prometheus.HistogramVec
+	scheduledLastSuccess  *prometheus.GaugeVec
+)
+
+func initScheduler() {
+	scheduledRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wonder",
+		Subsystem: "scheduler",
+		Name:      "runs_total",
+		Help:      "Total number of scheduled task activations, labeled by result: success, failure or skipped.",
+	}, []string{"task", "result"})
+
+	scheduledRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "wonder",
+		Subsystem: "scheduler",
+		Name:      "run_duration_seconds",
+		Help:      "Scheduled task run duration in seconds.",
+		Buckets:   []float64{.01, .1, .5, 1, 5, 15, 60, 300, 900},
+	}, []string{"task"})
+
+	scheduledLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "wonder",
+		Subsystem: "scheduler",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time the task last completed without error.",
+	}, []string{"task"})
+
+	prometheus.MustRegister(scheduledRunsTotal, scheduledRunDuration, scheduledLastSuccess)
+}
+
+// EnsureSchedulerMetrics registers the scheduler metrics once per process.
+func EnsureSchedulerMetrics() {
+	schedulerRegisterOnce.Do(initScheduler)
+}
+
+// ObserveScheduledRun records one activation of a scheduled task. Use it
+// as the scheduler's observer.
+func ObserveScheduledRun(run cron.Run) {
+	EnsureSchedulerMetrics()
+	switch {
+	case run.Skipped:
+		scheduledRunsTotal.WithLabelValues(run.Task, "skipped").Inc()
+		return
+	case run.Err != nil:
+		scheduledRunsTotal.WithLabelValues(run.Task, "failure").Inc()
+	default:
+		scheduledRunsTotal.WithLabelValues(run.Task, "success").Inc()
+		scheduledLastSuccess.WithLabelValues(run.Task).Set(float64(run.Started.Add(run.Duration).Unix()))
+	}
+	scheduledRunDuration.WithLabelValues(run.Task).Observe(run.Duration.Seconds())
+}
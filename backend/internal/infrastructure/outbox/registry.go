@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+)
+
+// Registry maps event names to their concrete types so relayed messages can
+// be decoded back into the values subscribers expect
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+// NewRegistry creates a registry with the given event prototypes
+func NewRegistry(prototypes ...event.Event) *Registry {
+	r := &Registry{types: make(map[string]reflect.Type)}
+	r.Register(prototypes...)
+	return r
+}
+
+// Register adds event types using zero-value prototypes, e.g. user.UserRegistered{}
+func (r *Registry) Register(prototypes ...event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range prototypes {
+		r.types[p.EventName()] = reflect.TypeOf(p)
+	}
+}
+
+// Decode rebuilds the event stored in msg. Unregistered names decode to a
+// RawEvent carrying the original payload.
+func (r *Registry) Decode(msg event.OutboxMessage) (event.Event, error) {
+	r.mu.RLock()
+	typ, ok := r.types[msg.EventName]
+	r.mu.RUnlock()
+
+	if !ok {
+		return RawEvent{
+			Name:      msg.EventName,
+			Aggregate: msg.AggregateID,
+			Occurred:  msg.OccurredAt,
+			Payload:   json.RawMessage(msg.Payload),
+		}, nil
+	}
+
+	ptr := reflect.New(typ)
+	if err := json.Unmarshal([]byte(msg.Payload), ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("decode %s payload: %w", msg.EventName, err)
+	}
+	e, ok := ptr.Elem().Interface().(event.Event)
+	if !ok {
+		return nil, fmt.Errorf("registered type for %s does not implement event.Event", msg.EventName)
+	}
+	return e, nil
+}
+
+// RawEvent is an outbox message whose concrete type is not registered
+type RawEvent struct {
+	Name      string
+	Aggregate string
+	Occurred  time.Time
+	Payload   json.RawMessage
+}
+
+// EventName implements event.Event
+func (e RawEvent) EventName() string { return e.Name }
+
+// AggregateID implements event.Event
+func (e RawEvent) AggregateID() string { return e.Aggregate }
+
+// OccurredAt implements event.Event
+func (e RawEvent) OccurredAt() time.Time { return e.Occurred }
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey attaches the outbox idempotency key to ctx
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// IdempotencyKey returns the key of the relayed message being handled.
+// Delivery is at-least-once; handlers with side effects should dedupe on it.
+func IdempotencyKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyCtx{}).(string)
+	return key, ok
+}
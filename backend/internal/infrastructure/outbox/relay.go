@@ -0,0 +1,296 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/transaction"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultMaxAttempts  = 10
+	defaultBaseBackoff  = time.Second
+	defaultMaxBackoff   = 5 * time.Minute
+)
+
+// Sink delivers relayed messages to their destination
+type Sink interface {
+	Deliver(ctx context.Context, msg event.OutboxMessage) error
+}
+
+// SinkFunc adapts a function to Sink
+type SinkFunc func(ctx context.Context, msg event.OutboxMessage) error
+
+// Deliver implements Sink
+func (f SinkFunc) Deliver(ctx context.Context, msg event.OutboxMessage) error {
+	return f(ctx, msg)
+}
+
+// FanOut delivers each message to every sink in order and stops at the first
+// failure. A retried message is delivered to all sinks again, so downstream
+// consumers must tolerate duplicates.
+func FanOut(sinks ...Sink) Sink {
+	return SinkFunc(func(ctx context.Context, msg event.OutboxMessage) error {
+		for _, sink := range sinks {
+			if err := sink.Deliver(ctx, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Dispatcher runs the subscribers of events before returning and reports
+// their failures, as eventbus.Dispatcher does
+type Dispatcher interface {
+	Dispatch(ctx context.Context, events ...event.Event) error
+}
+
+// NewBusSink republishes messages on an in-process event bus. Subscribers
+// run before Deliver returns, so a message is only marked published once
+// they all succeeded; when one fails the message is retried with every
+// subscriber, so they must tolerate duplicates. The message's idempotency
+// key is available to handlers via IdempotencyKey(ctx).
+func NewBusSink(bus Dispatcher, registry *Registry) Sink {
+	if bus == nil {
+		panic("event bus cannot be nil")
+	}
+	if registry == nil {
+		registry = NewRegistry()
+	}
+
+	return SinkFunc(func(ctx context.Context, msg event.OutboxMessage) error {
+		e, err := registry.Decode(msg)
+		if err != nil {
+			return err
+		}
+		return bus.Dispatch(WithIdempotencyKey(ctx, msg.IdempotencyKey), e)
+	})
+}
+
+// RelayOption configures a Relay
+type RelayOption func(*Relay)
+
+// WithPollInterval sets how often the relay looks for due messages
+func WithPollInterval(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		if d > 0 {
+			r.pollInterval = d
+		}
+	}
+}
+
+// WithBatchSize sets the maximum number of messages handled per poll
+func WithBatchSize(n int) RelayOption {
+	return func(r *Relay) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithMaxAttempts sets how many deliveries are tried before a message is parked as failed
+func WithMaxAttempts(n int) RelayOption {
+	return func(r *Relay) {
+		if n > 0 {
+			r.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets the exponential retry delay bounds
+func WithBackoff(base, max time.Duration) RelayOption {
+	return func(r *Relay) {
+		if base > 0 {
+			r.baseBackoff = base
+		}
+		if max >= r.baseBackoff {
+			r.maxBackoff = max
+		}
+	}
+}
+
+// Relay polls the outbox and hands due messages to a sink, retrying failed
+// deliveries with exponential backoff
+type Relay struct {
+	store *Store
+	uow   transaction.UnitOfWork
+	sink  Sink
+	log   logger.Logger
+
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+
+	mu      sync.Mutex
+	running bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewRelay creates an outbox relay. uow wraps each batch so row locks taken
+// while fetching are held until delivery results are recorded.
+func NewRelay(store *Store, uow transaction.UnitOfWork, sink Sink, opts ...RelayOption) *Relay {
+	if store == nil {
+		panic("outbox store cannot be nil")
+	}
+	if uow == nil {
+		panic("unit of work cannot be nil")
+	}
+	if sink == nil {
+		panic("outbox sink cannot be nil")
+	}
+
+	r := &Relay{
+		store:        store,
+		uow:          uow,
+		sink:         sink,
+		log:          logger.Get().WithLayer("infrastructure").WithComponent("outbox_relay"),
+		pollInterval: defaultPollInterval,
+		batchSize:    defaultBatchSize,
+		maxAttempts:  defaultMaxAttempts,
+		baseBackoff:  defaultBaseBackoff,
+		maxBackoff:   defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start launches the polling loop. Calling Start on a running relay is a no-op.
+func (r *Relay) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running {
+		return
+	}
+	r.running = true
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go r.loop(context.WithoutCancel(ctx), r.stop, r.done)
+	r.log.Info(ctx, "outbox relay started", "poll_interval", r.pollInterval, "batch_size", r.batchSize)
+}
+
+// Stop ends the polling loop after the current batch, or when ctx expires
+func (r *Relay) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return nil
+	}
+	r.running = false
+	close(r.stop)
+	done := r.done
+	r.mu.Unlock()
+
+	select {
+	case <-done:
+		r.log.Info(ctx, "outbox relay stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("outbox relay did not stop before deadline: %w", ctx.Err())
+	}
+}
+
+func (r *Relay) loop(ctx context.Context, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain backlogs without waiting for the next tick
+		for {
+			n, err := r.RunOnce(ctx)
+			if err != nil {
+				r.log.Error(ctx, "outbox relay batch failed", "error", err)
+			}
+			if err != nil || n < r.batchSize {
+				break
+			}
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce delivers one batch of due messages and returns how many it handled
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	handled := 0
+	err := r.uow.Do(ctx, func(ctx context.Context) error {
+		messages, err := r.store.FetchDue(ctx, r.batchSize, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			if err := r.deliver(ctx, msg); err != nil {
+				return err
+			}
+			handled++
+		}
+		return nil
+	})
+	return handled, err
+}
+
+// deliver sends one message and records the outcome. Only bookkeeping
+// failures are returned; delivery failures are scheduled for retry.
+func (r *Relay) deliver(ctx context.Context, msg event.OutboxMessage) error {
+	deliveryErr := r.safeDeliver(ctx, msg)
+	if deliveryErr == nil {
+		return r.store.MarkPublished(ctx, msg.ID, time.Now().UTC())
+	}
+
+	attempts := msg.Attempts + 1
+	if attempts >= r.maxAttempts {
+		r.log.Error(ctx, "outbox message exhausted delivery attempts", "message_id", msg.ID,
+			"event", msg.EventName, "attempts", attempts, "error", deliveryErr)
+		return r.store.MarkFailed(ctx, msg.ID, attempts, deliveryErr.Error())
+	}
+
+	next := time.Now().UTC().Add(r.backoff(attempts))
+	r.log.Warn(ctx, "outbox delivery failed, will retry", "message_id", msg.ID,
+		"event", msg.EventName, "attempts", attempts, "next_attempt_at", next, "error", deliveryErr)
+	return r.store.MarkRetry(ctx, msg.ID, attempts, next, deliveryErr.Error())
+}
+
+func (r *Relay) safeDeliver(ctx context.Context, msg event.OutboxMessage) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("outbox sink panicked: %v", p)
+		}
+	}()
+	return r.sink.Deliver(ctx, msg)
+}
+
+// backoff returns the delay before the given attempt number
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= r.maxBackoff {
+			return r.maxBackoff
+		}
+	}
+	return d
+}
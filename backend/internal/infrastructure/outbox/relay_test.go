@@ -0,0 +1,189 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+)
+
+// syncBus delivers events synchronously and records idempotency keys.
+// Deliveries fail with err when it is set.
+type syncBus struct {
+	mu     sync.Mutex
+	events []event.Event
+	keys   []string
+	err    error
+}
+
+func (b *syncBus) Dispatch(ctx context.Context, events ...event.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key, _ := IdempotencyKey(ctx)
+	for _, e := range events {
+		b.events = append(b.events, e)
+		b.keys = append(b.keys, key)
+	}
+	return b.err
+}
+
+func (b *syncBus) received() []event.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]event.Event(nil), b.events...)
+}
+
+func TestRelay_DeliversToBusWithConcreteTypes(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	bus := &syncBus{}
+	relay := NewRelay(store, database.NewUnitOfWork(db), NewBusSink(bus, NewRegistry(testEvent{})))
+
+	require.NoError(t, store.Append(context.Background(), newTestEvent("agg-1", "a")))
+
+	n, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	received := bus.received()
+	require.Len(t, received, 1)
+	decoded, ok := received[0].(testEvent)
+	require.True(t, ok, "expected concrete event type, got %T", received[0])
+	assert.Equal(t, "a", decoded.Value)
+	assert.Equal(t, "agg-1", decoded.AggregateID())
+
+	messages := loadMessages(t, db)
+	assert.Equal(t, event.OutboxPublished, messages[0].Status)
+	assert.NotNil(t, messages[0].PublishedAt)
+	assert.Equal(t, messages[0].IdempotencyKey, bus.keys[0])
+
+	// Published messages are not delivered again
+	n, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestRelay_FailedHandlersAreRetried(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	bus := &syncBus{err: errors.New("search index unavailable")}
+	relay := NewRelay(store, database.NewUnitOfWork(db), NewBusSink(bus, nil), WithBackoff(time.Millisecond, time.Millisecond))
+
+	require.NoError(t, store.Append(context.Background(), newTestEvent("agg-1", "a")))
+	_, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	msg := loadMessages(t, db)[0]
+	assert.Equal(t, event.OutboxPending, msg.Status, "not published while a handler fails")
+	assert.Equal(t, "search index unavailable", msg.LastError)
+
+	bus.mu.Lock()
+	bus.err = nil
+	bus.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	_, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, event.OutboxPublished, loadMessages(t, db)[0].Status)
+	assert.Len(t, bus.received(), 2)
+}
+
+func TestRelay_UnregisteredEventsDecodeAsRaw(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	bus := &syncBus{}
+	relay := NewRelay(store, database.NewUnitOfWork(db), NewBusSink(bus, nil))
+
+	require.NoError(t, store.Append(context.Background(), newTestEvent("agg-1", "a")))
+	_, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	raw, ok := bus.received()[0].(RawEvent)
+	require.True(t, ok)
+	assert.Equal(t, testEventName, raw.EventName())
+	assert.Contains(t, string(raw.Payload), `"value":"a"`)
+}
+
+func TestRelay_RetriesWithBackoffThenFails(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	sink := SinkFunc(func(context.Context, event.OutboxMessage) error {
+		return errors.New("broker unavailable")
+	})
+	relay := NewRelay(store, database.NewUnitOfWork(db), sink,
+		WithMaxAttempts(2), WithBackoff(time.Hour, 2*time.Hour))
+
+	require.NoError(t, store.Append(context.Background(), newTestEvent("agg-1", "a")))
+
+	_, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	msg := loadMessages(t, db)[0]
+	assert.Equal(t, event.OutboxPending, msg.Status)
+	assert.Equal(t, 1, msg.Attempts)
+	assert.Equal(t, "broker unavailable", msg.LastError)
+	assert.True(t, msg.NextAttemptAt.After(time.Now().Add(50*time.Minute)))
+
+	// Not due yet
+	n, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// Make it due and exhaust the last attempt
+	require.NoError(t, db.Model(&event.OutboxMessage{}).Where("id = ?", msg.ID).
+		Update("next_attempt_at", time.Now().UTC().Add(-time.Second)).Error)
+	_, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	msg = loadMessages(t, db)[0]
+	assert.Equal(t, event.OutboxFailed, msg.Status)
+	assert.Equal(t, 2, msg.Attempts)
+}
+
+func TestRelay_RecoversSinkPanics(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	sink := SinkFunc(func(context.Context, event.OutboxMessage) error {
+		panic("boom")
+	})
+	relay := NewRelay(store, database.NewUnitOfWork(db), sink)
+
+	require.NoError(t, store.Append(context.Background(), newTestEvent("agg-1", "a")))
+	_, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, loadMessages(t, db)[0].LastError, "panicked")
+}
+
+func TestRelay_StartStop(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	bus := &syncBus{}
+	relay := NewRelay(store, database.NewUnitOfWork(db), NewBusSink(bus, nil), WithPollInterval(10*time.Millisecond))
+
+	relay.Start(context.Background())
+	relay.Start(context.Background()) // second start is a no-op
+
+	require.NoError(t, store.Append(context.Background(), newTestEvent("agg-1", "a")))
+	assert.Eventually(t, func() bool { return len(bus.received()) == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, relay.Stop(ctx))
+	require.NoError(t, relay.Stop(ctx))
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	relay := &Relay{baseBackoff: time.Second, maxBackoff: 5 * time.Second}
+
+	assert.Equal(t, time.Second, relay.backoff(1))
+	assert.Equal(t, 2*time.Second, relay.backoff(2))
+	assert.Equal(t, 4*time.Second, relay.backoff(3))
+	assert.Equal(t, 5*time.Second, relay.backoff(4))
+}
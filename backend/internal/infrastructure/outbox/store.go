@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const outboxTable = "outbox_messages"
+
+// Store persists outbox messages. Every method joins the transaction carried
+// by ctx, so Append commits or rolls back with the aggregate change.
+type Store struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewStore creates an outbox store on db
+func NewStore(db *gorm.DB) *Store {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+
+	return &Store{
+		db:  db,
+		log: logger.Get().WithLayer("infrastructure").WithComponent("outbox_store"),
+	}
+}
+
+// Append serializes events into pending outbox messages
+func (s *Store) Append(ctx context.Context, events ...event.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	messages := make([]event.OutboxMessage, 0, len(events))
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return wonderErrors.NewDatabaseError("append", outboxTable, err, false, map[string]interface{}{
+				"event": e.EventName(),
+			})
+		}
+
+		messages = append(messages, event.OutboxMessage{
+			ID:             uuid.NewString(),
+			IdempotencyKey: IdempotencyKeyFor(e.EventName(), e.AggregateID(), e.OccurredAt(), payload),
+			EventName:      e.EventName(),
+			AggregateID:    e.AggregateID(),
+			Payload:        string(payload),
+			OccurredAt:     e.OccurredAt(),
+			Status:         event.OutboxPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+
+	// Re-appending the same event is a no-op thanks to the idempotency key
+	err := database.FromContext(ctx, s.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "idempotency_key"}}, DoNothing: true}).
+		Create(&messages).Error
+	if err != nil {
+		s.log.Error(ctx, "failed to append outbox messages", "error", err, "count", len(messages))
+		return wonderErrors.NewDatabaseError("append", outboxTable, err, true)
+	}
+
+	return nil
+}
+
+// FetchDue returns up to limit pending messages whose next attempt is due.
+// On PostgreSQL and MySQL the rows are locked with SKIP LOCKED so
+// concurrent relays never deliver the same message at the same time.
+func (s *Store) FetchDue(ctx context.Context, limit int, now time.Time) ([]event.OutboxMessage, error) {
+	query := database.FromContext(ctx, s.db).
+		Where("status = ? AND next_attempt_at <= ?", event.OutboxPending, now).
+		Order("created_at").
+		Limit(limit)
+	if database.DriverFor(s.db).SkipLocked() {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	}
+
+	var messages []event.OutboxMessage
+	if err := query.Find(&messages).Error; err != nil {
+		return nil, wonderErrors.NewDatabaseError("fetch_due", outboxTable, err, true)
+	}
+	return messages, nil
+}
+
+// MarkPublished records a successful delivery
+func (s *Store) MarkPublished(ctx context.Context, id string, at time.Time) error {
+	return s.update(ctx, id, map[string]interface{}{
+		"status":       event.OutboxPublished,
+		"published_at": at,
+		"last_error":   "",
+	})
+}
+
+// MarkRetry records a failed delivery that will be attempted again
+func (s *Store) MarkRetry(ctx context.Context, id string, attempts int, next time.Time, lastErr string) error {
+	return s.update(ctx, id, map[string]interface{}{
+		"attempts":        attempts,
+		"next_attempt_at": next,
+		"last_error":      lastErr,
+	})
+}
+
+// MarkFailed parks a message that exhausted its delivery attempts
+func (s *Store) MarkFailed(ctx context.Context, id string, attempts int, lastErr string) error {
+	return s.update(ctx, id, map[string]interface{}{
+		"status":     event.OutboxFailed,
+		"attempts":   attempts,
+		"last_error": lastErr,
+	})
+}
+
+// DeletePublishedBefore removes messages published before cutoff and
+// returns how many were removed. Pending and failed messages are kept.
+func (s *Store) DeletePublishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := database.FromContext(ctx, s.db).
+		Where("status = ? AND published_at < ?", event.OutboxPublished, cutoff).
+		Delete(&event.OutboxMessage{})
+	if result.Error != nil {
+		return 0, wonderErrors.NewDatabaseError("delete", outboxTable, result.Error, true)
+	}
+	return result.RowsAffected, nil
+}
+
+func (s *Store) update(ctx context.Context, id string, values map[string]interface{}) error {
+	err := database.FromContext(ctx, s.db).Model(&event.OutboxMessage{}).Where("id = ?", id).Updates(values).Error
+	if err != nil {
+		return wonderErrors.NewDatabaseError("update", outboxTable, err, true, map[string]interface{}{
+			"message_id": id,
+		})
+	}
+	return nil
+}
+
+// IdempotencyKeyFor derives a stable key for an event so consumers can
+// discard redeliveries
+func IdempotencyKeyFor(name, aggregateID string, occurredAt time.Time, payload []byte) string {
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(aggregateID))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatInt(occurredAt.UnixNano(), 10)))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
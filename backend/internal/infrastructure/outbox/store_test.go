@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+)
+
+const testEventName = "test.happened"
+
+type testEvent struct {
+	event.Base
+	Value string `json:"value"`
+}
+
+func (testEvent) EventName() string { return testEventName }
+
+func newTestEvent(aggregateID, value string) testEvent {
+	return testEvent{Base: event.NewBase(aggregateID), Value: value}
+}
+
+func loadMessages(t *testing.T, db *gorm.DB) []event.OutboxMessage {
+	var messages []event.OutboxMessage
+	require.NoError(t, db.Order("created_at").Find(&messages).Error)
+	return messages
+}
+
+func TestStore_AppendIsIdempotent(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	ctx := context.Background()
+
+	e := newTestEvent("agg-1", "a")
+	require.NoError(t, store.Append(ctx, e))
+	require.NoError(t, store.Append(ctx, e))
+
+	messages := loadMessages(t, db)
+	require.Len(t, messages, 1)
+	assert.Equal(t, testEventName, messages[0].EventName)
+	assert.Equal(t, "agg-1", messages[0].AggregateID)
+	assert.Equal(t, event.OutboxPending, messages[0].Status)
+	assert.Len(t, messages[0].IdempotencyKey, 64)
+	assert.Contains(t, messages[0].Payload, `"value":"a"`)
+}
+
+func TestStore_AppendJoinsTransaction(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	uow := database.NewUnitOfWork(db)
+
+	err := uow.Do(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, store.Append(ctx, newTestEvent("agg-1", "a")))
+		return errors.New("aggregate write failed")
+	})
+	require.Error(t, err)
+	assert.Empty(t, loadMessages(t, db))
+}
+
+func TestStore_FetchDueSkipsFutureAndFinishedMessages(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	ctx := context.Background()
+
+	require.NoError(t, store.Append(ctx, newTestEvent("due", "a"), newTestEvent("later", "b"), newTestEvent("done", "c")))
+	messages := loadMessages(t, db)
+	byAggregate := map[string]event.OutboxMessage{}
+	for _, m := range messages {
+		byAggregate[m.AggregateID] = m
+	}
+
+	now := time.Now().UTC()
+	require.NoError(t, store.MarkRetry(ctx, byAggregate["later"].ID, 1, now.Add(time.Hour), "boom"))
+	require.NoError(t, store.MarkPublished(ctx, byAggregate["done"].ID, now))
+
+	due, err := store.FetchDue(ctx, 10, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "due", due[0].AggregateID)
+}
+
+func TestStore_DeletePublishedBefore(t *testing.T) {
+	db := dbtest.Open(t)
+	store := NewStore(db)
+	ctx := context.Background()
+
+	require.NoError(t, store.Append(ctx, newTestEvent("old", "a"), newTestEvent("recent", "b"), newTestEvent("pending", "c")))
+	byAggregate := map[string]event.OutboxMessage{}
+	for _, m := range loadMessages(t, db) {
+		byAggregate[m.AggregateID] = m
+	}
+
+	now := time.Now().UTC()
+	require.NoError(t, store.MarkPublished(ctx, byAggregate["old"].ID, now.Add(-48*time.Hour)))
+	require.NoError(t, store.MarkPublished(ctx, byAggregate["recent"].ID, now))
+
+	deleted, err := store.DeletePublishedBefore(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	remaining := loadMessages(t, db)
+	require.Len(t, remaining, 2)
+	for _, m := range remaining {
+		assert.NotEqual(t, "old", m.AggregateID)
+	}
+}
+
+func TestIdempotencyKeyFor_IsStable(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	a := IdempotencyKeyFor("x", "1", at, []byte(`{}`))
+	b := IdempotencyKeyFor("x", "1", at, []byte(`{}`))
+	c := IdempotencyKeyFor("x", "2", at, []byte(`{}`))
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
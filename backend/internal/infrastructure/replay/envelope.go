@@ -0,0 +1,131 @@
+package replay
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Envelope is a sanitized record of a failed request. It keeps enough shape
+// to reproduce a request without retaining credentials or user data.
+type Envelope struct {
+	ID         string            `json:"id"`
+	CapturedAt time.Time         `json:"captured_at"`
+	TraceID    string            `json:"trace_id,omitempty"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Route      string            `json:"route,omitempty"`
+	QueryKeys  []string          `json:"query_keys,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	BodySchema interface{}       `json:"body_schema,omitempty"`
+	Status     int               `json:"status"`
+}
+
+// allowedHeaders is the subset of request headers kept in envelopes.
+// Authorization, cookies and forwarding headers are never captured.
+var allowedHeaders = []string{
+	"Accept",
+	"Accept-Language",
+	"Content-Type",
+	"User-Agent",
+}
+
+// SanitizeHeaders keeps only allow-listed headers
+func SanitizeHeaders(h http.Header) map[string]string {
+	out := make(map[string]string)
+	for _, name := range allowedHeaders {
+		if v := h.Get(name); v != "" {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+// QueryKeys returns the sorted query parameter names without their values
+func QueryKeys(query map[string][]string) []string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// BodySchema reduces a JSON body to its structure: objects keep their keys,
+// arrays keep their first element's shape and every scalar is replaced by
+// its type name. Non-JSON bodies return nil.
+func BodySchema(body []byte) interface{} {
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil
+	}
+	return schemaOf(v)
+}
+
+func schemaOf(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			out[k] = schemaOf(child)
+		}
+		return out
+	case []interface{}:
+		if len(val) == 0 {
+			return []interface{}{}
+		}
+		return []interface{}{schemaOf(val[0])}
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// SampleBody builds a placeholder JSON value from a body schema, optionally
+// overlaying concrete values supplied by the operator
+func SampleBody(schema interface{}, overrides map[string]interface{}) interface{} {
+	sample := sampleOf(schema)
+	if obj, ok := sample.(map[string]interface{}); ok {
+		for k, v := range overrides {
+			obj[k] = v
+		}
+	}
+	return sample
+}
+
+func sampleOf(schema interface{}) interface{} {
+	switch val := schema.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			out[k] = sampleOf(child)
+		}
+		return out
+	case []interface{}:
+		if len(val) == 0 {
+			return []interface{}{}
+		}
+		return []interface{}{sampleOf(val[0])}
+	case string:
+		switch val {
+		case "string":
+			return "replay"
+		case "number":
+			return 0
+		case "boolean":
+			return false
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package replay
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodySchema(t *testing.T) {
+	schema := BodySchema([]byte(`{"name":"bob","profile":{"age":7,"admin":true},"ids":[1,2],"note":null}`))
+	assert.Equal(t, map[string]interface{}{
+		"name":    "string",
+		"profile": map[string]interface{}{"age": "number", "admin": "boolean"},
+		"ids":     []interface{}{"number"},
+		"note":    "null",
+	}, schema)
+
+	assert.Nil(t, BodySchema([]byte("not json")))
+	assert.Nil(t, BodySchema(nil))
+}
+
+func TestSampleBody(t *testing.T) {
+	schema := map[string]interface{}{"email": "string", "age": "number", "tags": []interface{}{"string"}}
+	sample := SampleBody(schema, map[string]interface{}{"email": "qa@example.com"})
+
+	assert.Equal(t, map[string]interface{}{
+		"email": "qa@example.com",
+		"age":   0,
+		"tags":  []interface{}{"replay"},
+	}, sample)
+}
+
+func TestSanitizeHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("Authorization", "Bearer token")
+	h.Set("Cookie", "a=b")
+	h.Set("X-Forwarded-For", "10.0.0.1")
+
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, SanitizeHeaders(h))
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	older := &Envelope{ID: "b", CapturedAt: time.Now().Add(-time.Minute), Method: "GET", Path: "/x", Status: 500}
+	newer := &Envelope{ID: "a", CapturedAt: time.Now(), Method: "POST", Path: "/y", Status: 502}
+	require.NoError(t, store.Save(ctx, newer))
+	require.NoError(t, store.Save(ctx, older))
+
+	got, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "/y", got.Path)
+
+	list, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "b", list[0].ID)
+
+	_, err = store.Get(ctx, "missing")
+	assert.Error(t, err)
+	_, err = store.Get(ctx, "../etc")
+	assert.Error(t, err)
+}
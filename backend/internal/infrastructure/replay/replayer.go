@@ -0,0 +1,138 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// Result is the outcome of replaying one envelope
+type Result struct {
+	EnvelopeID     string
+	Method         string
+	Path           string
+	OriginalStatus int
+	Status         int
+	Duration       time.Duration
+	Body           string
+}
+
+// Replayer re-issues captured envelopes against a target environment
+type Replayer struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+}
+
+// NewReplayer creates a replayer for the given base URL
+func NewReplayer(baseURL string, httpClient *http.Client) *Replayer {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Replayer{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Login obtains a fresh access token from the target environment; captured
+// envelopes never contain credentials
+func (r *Replayer) Login(ctx context.Context, email, password string) error {
+	payload, err := json.Marshal(map[string]string{"email": email, "password": password})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+"/api/v1/auth/login", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return wonderErrors.NewExternalServiceError("replay_target", "login", 0, "", err, true)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return wonderErrors.NewExternalServiceError("replay_target", "login", resp.StatusCode, "",
+			fmt.Errorf("login failed with status %d", resp.StatusCode), false)
+	}
+
+	var body struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode login response: %w", err)
+	}
+	if body.Data.AccessToken == "" {
+		return fmt.Errorf("login response did not contain an access token")
+	}
+
+	r.token = body.Data.AccessToken
+	return nil
+}
+
+// Replay sends a request reconstructed from env. Query parameters are sent
+// with placeholder values and the body is synthesized from its schema with
+// optional overrides.
+func (r *Replayer) Replay(ctx context.Context, env *Envelope, overrides map[string]interface{}) (*Result, error) {
+	var body io.Reader
+	if env.BodySchema != nil {
+		payload, err := json.Marshal(SampleBody(env.BodySchema, overrides))
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, env.Method, r.baseURL+env.Path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	for _, k := range env.QueryKeys {
+		q.Set(k, "")
+	}
+	req.URL.RawQuery = q.Encode()
+
+	for k, v := range env.Headers {
+		req.Header.Set(k, v)
+	}
+	if env.TraceID != "" {
+		req.Header.Set("X-Trace-ID", "replay-"+env.TraceID)
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	start := time.Now()
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, wonderErrors.NewExternalServiceError("replay_target", "replay", 0, "", err, true)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	return &Result{
+		EnvelopeID:     env.ID,
+		Method:         env.Method,
+		Path:           env.Path,
+		OriginalStatus: env.Status,
+		Status:         resp.StatusCode,
+		Duration:       time.Since(start),
+		Body:           string(respBody),
+	}, nil
+}
@@ -0,0 +1,62 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayer_LoginAndReplay(t *testing.T) {
+	var replayed *http.Request
+	var replayedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/auth/login" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"data":{"access_token":"fresh-token"}}`))
+			return
+		}
+		replayed = r
+		json.NewDecoder(r.Body).Decode(&replayedBody)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	replayer := NewReplayer(server.URL, server.Client())
+	require.NoError(t, replayer.Login(context.Background(), "qa@example.com", "secret"))
+
+	env := &Envelope{
+		ID:         "env-1",
+		TraceID:    "abc",
+		Method:     http.MethodPut,
+		Path:       "/api/v1/users/42",
+		QueryKeys:  []string{"verbose"},
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		BodySchema: map[string]interface{}{"name": "string"},
+		Status:     http.StatusInternalServerError,
+	}
+
+	result, err := replayer.Replay(context.Background(), env, map[string]interface{}{"name": "Replay User"})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, result.Status)
+	assert.Equal(t, "Bearer fresh-token", replayed.Header.Get("Authorization"))
+	assert.Equal(t, "replay-abc", replayed.Header.Get("X-Trace-ID"))
+	assert.True(t, replayed.URL.Query().Has("verbose"))
+	assert.Equal(t, map[string]interface{}{"name": "Replay User"}, replayedBody)
+}
+
+func TestReplayer_LoginFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := NewReplayer(server.URL, server.Client()).Login(context.Background(), "qa@example.com", "bad")
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// Store persists captured envelopes
+type Store interface {
+	Save(ctx context.Context, env *Envelope) error
+	Get(ctx context.Context, id string) (*Envelope, error)
+	List(ctx context.Context) ([]*Envelope, error)
+}
+
+// fileStore keeps one JSON file per envelope in a directory
+type fileStore struct {
+	dir string
+}
+
+// NewFileStore creates a directory-backed envelope store
+func NewFileStore(dir string) (Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("replay store directory cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create replay store directory: %w", err)
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+// Save writes the envelope atomically
+func (s *fileStore) Save(ctx context.Context, env *Envelope) error {
+	if env == nil || env.ID == "" {
+		return wonderErrors.NewRequiredFieldError("id", "")
+	}
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode envelope: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".envelope-*")
+	if err != nil {
+		return fmt.Errorf("failed to create envelope file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write envelope: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write envelope: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), s.path(env.ID))
+}
+
+// Get loads a single envelope by ID
+func (s *fileStore) Get(ctx context.Context, id string) (*Envelope, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, wonderErrors.NewInvalidValueError("id", id, "invalid envelope id")
+	}
+
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, wonderErrors.NewEntityNotFoundError("replay_envelope", id)
+		}
+		return nil, fmt.Errorf("failed to read envelope: %w", err)
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to decode envelope %s: %w", id, err)
+	}
+	return &env, nil
+}
+
+// List returns all envelopes ordered by capture time
+func (s *fileStore) List(ctx context.Context) ([]*Envelope, error) {
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	envelopes := make([]*Envelope, 0, len(matches))
+	for _, m := range matches {
+		env, err := s.Get(ctx, strings.TrimSuffix(filepath.Base(m), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, env)
+	}
+
+	sort.Slice(envelopes, func(i, j int) bool {
+		return envelopes[i].CapturedAt.Before(envelopes[j].CapturedAt)
+	})
+	return envelopes, nil
+}
+
+func (s *fileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+type auditRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewAuditRepository creates a new audit.Repository implementation
+func NewAuditRepository(db *gorm.DB) audit.Repository {
+	return NewAuditRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("audit_repository"))
+}
+
+// NewAuditRepositoryWithLogger creates a new audit.Repository implementation with explicit logger
+func NewAuditRepositoryWithLogger(db *gorm.DB, log logger.Logger) audit.Repository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &auditRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Save inserts audit entries in a single statement
+func (r *auditRepository) Save(ctx context.Context, entries ...*audit.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := database.FromContext(ctx, r.db).Create(entries).Error; err != nil {
+		r.log.Error(ctx, "failed to save audit entries", "error", err, "count", len(entries))
+		return wonderErrors.NewDatabaseError("create", "audit_logs", err, isRetryableError(err), map[string]interface{}{
+			"count": len(entries),
+		})
+	}
+
+	return nil
+}
+
+// DeleteBefore removes audit entries older than cutoff
+func (r *auditRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := database.FromContext(ctx, r.db).Where("created_at < ?", cutoff).Delete(&audit.Entry{})
+	if result.Error != nil {
+		r.log.Error(ctx, "failed to delete audit entries", "error", result.Error, "cutoff", cutoff)
+		return 0, wonderErrors.NewDatabaseError("delete", "audit_logs", result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"cutoff": cutoff,
+		})
+	}
+	return result.RowsAffected, nil
+}
+
+// List retrieves audit entries with pagination and filtering, newest first
+func (r *auditRepository) List(ctx context.Context, req *audit.ListRequest) (*audit.ListResponse, error) {
+	if req == nil {
+		return nil, wonderErrors.NewRequiredFieldError("request", "nil")
+	}
+
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	offset := (page - 1) * pageSize
+
+	query := database.FromContext(ctx, r.db).Model(&audit.Entry{})
+	if req.ActorID != "" {
+		query = query.Where("actor_id = ?", req.ActorID)
+	}
+	if req.Action != "" {
+		query = query.Where("action = ?", req.Action)
+	}
+	if req.EntityType != "" {
+		query = query.Where("entity_type = ?", req.EntityType)
+	}
+	if req.EntityID != "" {
+		query = query.Where("entity_id = ?", req.EntityID)
+	}
+	if !req.From.IsZero() {
+		query = query.Where("created_at >= ?", req.From)
+	}
+	if !req.To.IsZero() {
+		query = query.Where("created_at < ?", req.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.log.Error(ctx, "failed to count audit entries", "error", err)
+		return nil, wonderErrors.NewDatabaseError("count", "audit_logs", err, isRetryableError(err), map[string]interface{}{
+			"page":      page,
+			"page_size": pageSize,
+		})
+	}
+
+	var entries []*audit.Entry
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&entries).Error; err != nil {
+		r.log.Error(ctx, "failed to list audit entries", "error", err)
+		return nil, wonderErrors.NewDatabaseError("list", "audit_logs", err, isRetryableError(err), map[string]interface{}{
+			"page":      page,
+			"page_size": pageSize,
+		})
+	}
+
+	return &audit.ListResponse{
+		Entries:    entries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+)
+
+func TestAuditRepository_SaveAndList(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewAuditRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var entries []*audit.Entry
+	for i := 0; i < 5; i++ {
+		action := audit.ActionUpdate
+		if i%2 == 0 {
+			action = audit.ActionLogin
+		}
+		entries = append(entries, &audit.Entry{
+			ID:         fmt.Sprintf("entry-%d", i),
+			ActorID:    "user-1",
+			Action:     action,
+			EntityType: "user",
+			EntityID:   "user-1",
+			Outcome:    audit.OutcomeSuccess,
+			Changes:    audit.ChangeSet{"name": {From: "a", To: "b"}},
+			CreatedAt:  base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	require.NoError(t, repo.Save(ctx, entries...))
+
+	page, err := repo.List(ctx, &audit.ListRequest{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), page.Total)
+	assert.Equal(t, 3, page.TotalPages)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, "entry-4", page.Entries[0].ID, "newest entries come first")
+	assert.Equal(t, audit.Change{From: "a", To: "b"}, page.Entries[0].Changes["name"])
+
+	logins, err := repo.List(ctx, &audit.ListRequest{Action: audit.ActionLogin})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), logins.Total)
+
+	window, err := repo.List(ctx, &audit.ListRequest{From: base.Add(time.Hour), To: base.Add(3 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), window.Total)
+
+	none, err := repo.List(ctx, &audit.ListRequest{ActorID: "someone-else"})
+	require.NoError(t, err)
+	assert.Empty(t, none.Entries)
+
+	deleted, err := repo.DeleteBefore(ctx, base.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	rest, err := repo.List(ctx, &audit.ListRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), rest.Total)
+}
+
+func TestAuditRepository_ListRequiresRequest(t *testing.T) {
+	repo := NewAuditRepository(dbtest.Open(t))
+
+	_, err := repo.List(context.Background(), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+type bootstrapRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewBootstrapRepository creates a new BootstrapRepository implementation
+func NewBootstrapRepository(db *gorm.DB) user.BootstrapRepository {
+	return NewBootstrapRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("bootstrap_repository"))
+}
+
+// NewBootstrapRepositoryWithLogger creates a new BootstrapRepository implementation with explicit logger
+func NewBootstrapRepositoryWithLogger(db *gorm.DB, log logger.Logger) user.BootstrapRepository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &bootstrapRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// IsCompleted reports whether the setup step has already been recorded
+func (r *bootstrapRepository) IsCompleted(ctx context.Context, step string) (bool, error) {
+	if step == "" {
+		return false, wonderErrors.NewRequiredFieldError("step", step)
+	}
+
+	var count int64
+	err := database.FromContext(ctx, r.db).Model(&user.BootstrapMarker{}).Where("step = ?", step).Count(&count).Error
+	if err != nil {
+		r.log.Error(ctx, "bootstrap marker query failed", "error", err, "step", step)
+		return false, wonderErrors.NewDatabaseError("is_completed", "bootstrap_markers", err, isRetryableError(err), map[string]interface{}{
+			"step": step,
+		})
+	}
+
+	return count > 0, nil
+}
+
+// MarkCompleted records the setup step. The primary key on step guarantees
+// that concurrent bootstraps cannot both succeed.
+func (r *bootstrapRepository) MarkCompleted(ctx context.Context, step, subjectID string) error {
+	if step == "" {
+		return wonderErrors.NewRequiredFieldError("step", step)
+	}
+
+	marker := &user.BootstrapMarker{
+		Step:        step,
+		SubjectID:   subjectID,
+		CompletedAt: time.Now(),
+	}
+
+	if err := database.FromContext(ctx, r.db).Create(marker).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			r.log.Warn(ctx, "bootstrap step already completed", "step", step)
+			return wonderErrors.NewConflictError("bootstrap_marker", "step already completed", step)
+		}
+		r.log.Error(ctx, "bootstrap marker create failed", "error", err, "step", step)
+		return wonderErrors.NewDatabaseError("mark_completed", "bootstrap_markers", err, isRetryableError(err), map[string]interface{}{
+			"step": step,
+		})
+	}
+
+	r.log.Info(ctx, "bootstrap step completed", "step", step, "subject_id", subjectID)
+	return nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func TestBootstrapRepository_MarkCompletedOnce(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewBootstrapRepository(db)
+	ctx := context.Background()
+
+	done, err := repo.IsCompleted(ctx, "admin")
+	require.NoError(t, err)
+	assert.False(t, done)
+
+	require.NoError(t, repo.MarkCompleted(ctx, "admin", "user-1"))
+
+	done, err = repo.IsCompleted(ctx, "admin")
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	err = repo.MarkCompleted(ctx, "admin", "user-2")
+	require.Error(t, err)
+	var conflict *wonderErrors.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+}
+
+func TestBootstrapRepository_RequiresStep(t *testing.T) {
+	repo := NewBootstrapRepository(dbtest.Open(t))
+
+	_, err := repo.IsCompleted(context.Background(), "")
+	assert.Error(t, err)
+	assert.Error(t, repo.MarkCompleted(context.Background(), "", "user-1"))
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/preference"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+const preferenceKeyPrefix = "wonder:prefs:"
+
+// cachingPreferenceRepository keeps each user's preferences in Redis as one
+// entry, since users have few and features read them often. Entries are
+// stored under a per-user version, which writes through the repository
+// bump once they commit. A reader that loaded the preferences before a
+// write stores them under the old version, where nobody reads them, so a
+// deleted preference cannot come back from the cache. Writes that bypass
+// the repository, such as rows removed with their user, show up once the
+// entry expires after ttl. Redis failures fall back to the database.
+type cachingPreferenceRepository struct {
+	preference.Repository
+	client *redis.Client
+	ttl    time.Duration
+	log    logger.Logger
+}
+
+// NewCachingPreferenceRepository wraps next so that preferences are read
+// from Redis for up to ttl
+func NewCachingPreferenceRepository(next preference.Repository, client *redis.Client, ttl time.Duration) preference.Repository {
+	if next == nil {
+		panic("preference repository cannot be nil")
+	}
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	return &cachingPreferenceRepository{
+		Repository: next,
+		client:     client,
+		ttl:        ttl,
+		log:        logger.Get().WithLayer("infrastructure").WithComponent("preference_cache"),
+	}
+}
+
+func (r *cachingPreferenceRepository) Get(ctx context.Context, userID, key string) (*preference.Preference, error) {
+	prefs, ok := r.cached(ctx, userID)
+	if !ok {
+		return r.Repository.Get(ctx, userID, key)
+	}
+	for _, p := range prefs {
+		if p.Key == key {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *cachingPreferenceRepository) List(ctx context.Context, userID, namespace string) ([]*preference.Preference, error) {
+	prefs, ok := r.cached(ctx, userID)
+	if !ok {
+		return r.Repository.List(ctx, userID, namespace)
+	}
+	if namespace == "" {
+		return prefs, nil
+	}
+	matching := make([]*preference.Preference, 0, len(prefs))
+	for _, p := range prefs {
+		if strings.HasPrefix(p.Key, namespace+".") {
+			matching = append(matching, p)
+		}
+	}
+	return matching, nil
+}
+
+func (r *cachingPreferenceRepository) Count(ctx context.Context, userID string) (int64, error) {
+	prefs, ok := r.cached(ctx, userID)
+	if !ok {
+		return r.Repository.Count(ctx, userID)
+	}
+	return int64(len(prefs)), nil
+}
+
+func (r *cachingPreferenceRepository) Set(ctx context.Context, p *preference.Preference) error {
+	if err := r.Repository.Set(ctx, p); err != nil {
+		return err
+	}
+	r.invalidate(ctx, p.UserID)
+	return nil
+}
+
+func (r *cachingPreferenceRepository) Delete(ctx context.Context, userID, key string) (bool, error) {
+	deleted, err := r.Repository.Delete(ctx, userID, key)
+	if err != nil {
+		return false, err
+	}
+	if deleted {
+		r.invalidate(ctx, userID)
+	}
+	return deleted, nil
+}
+
+// cached returns all of the user's preferences, loading them into the
+// cache on a miss. It reports false when they should be read from the
+// database instead: inside a transaction, which may see its own
+// uncommitted writes, or when Redis fails.
+func (r *cachingPreferenceRepository) cached(ctx context.Context, userID string) ([]*preference.Preference, bool) {
+	if database.InTransaction(ctx) {
+		return nil, false
+	}
+	// The version is read before the database, so that preferences loaded
+	// while a write commits are stored under the version it replaces
+	version, err := r.client.String(ctx, "GET", r.versionKey(ctx, userID))
+	if errors.Is(err, redis.ErrNil) {
+		version = "0"
+	} else if err != nil {
+		r.log.Warn(ctx, "failed to read cached preferences version", "error", err)
+		return nil, false
+	}
+	key := r.key(ctx, userID, version)
+
+	cached, err := r.client.String(ctx, "GET", key)
+	if err == nil {
+		var prefs []*preference.Preference
+		if err := json.Unmarshal([]byte(cached), &prefs); err == nil {
+			// Owners are not part of the JSON form
+			for _, p := range prefs {
+				p.TenantID, p.UserID = tenant.IDFromContext(ctx), userID
+			}
+			return prefs, true
+		}
+		r.log.Warn(ctx, "ignoring malformed cached preferences", "user_id", userID)
+	} else if !errors.Is(err, redis.ErrNil) {
+		r.log.Warn(ctx, "failed to read cached preferences", "error", err)
+		return nil, false
+	}
+
+	prefs, err := r.Repository.List(ctx, userID, "")
+	if err != nil {
+		return nil, false
+	}
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return prefs, true
+	}
+	ms := max(r.ttl.Milliseconds(), 1)
+	if _, err := r.client.Do(ctx, "SET", key, string(data), "PX", strconv.FormatInt(ms, 10)); err != nil {
+		r.log.Warn(ctx, "failed to cache preferences", "error", err)
+	}
+	return prefs, true
+}
+
+// key names the cached preferences of the user in the tenant of ctx at
+// version
+func (r *cachingPreferenceRepository) key(ctx context.Context, userID, version string) string {
+	return preferenceKeyPrefix + tenant.IDFromContext(ctx) + ":" + userID + ":" + version
+}
+
+// versionKey names the version of the user's cached preferences
+func (r *cachingPreferenceRepository) versionKey(ctx context.Context, userID string) string {
+	return preferenceKeyPrefix + "ver:" + tenant.IDFromContext(ctx) + ":" + userID
+}
+
+// invalidate bumps the version of the user's cached preferences once the
+// write commits. The version outlives the entries cached under earlier
+// ones, so a version that expired starts over with none left.
+func (r *cachingPreferenceRepository) invalidate(ctx context.Context, userID string) {
+	key := r.versionKey(ctx, userID)
+	database.AfterCommit(ctx, func(ctx context.Context) {
+		if _, err := r.client.Int(ctx, "INCR", key); err != nil {
+			r.log.Warn(ctx, "failed to invalidate cached preferences", "user_id", userID, "error", err)
+			return
+		}
+		ms := max(2*r.ttl.Milliseconds(), 1)
+		if _, err := r.client.Do(ctx, "PEXPIRE", key, strconv.FormatInt(ms, 10)); err != nil {
+			r.log.Warn(ctx, "failed to expire cached preferences version", "user_id", userID, "error", err)
+		}
+	})
+}
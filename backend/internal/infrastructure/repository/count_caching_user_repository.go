@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+const userCountKeyPrefix = "wonder:user_count:"
+
+// countCachingUserRepository keeps the exact totals of user lists in Redis.
+// Every write through it moves its tenant to a new generation once the
+// write is committed, which orphans the cached counts; they expire after
+// ttl. Writes that bypass the repository, such as the initial admin
+// bootstrap, show up once the counts expire. Redis failures fall back to
+// counting.
+type countCachingUserRepository struct {
+	user.UserRepository
+	client *redis.Client
+	ttl    time.Duration
+	log    logger.Logger
+}
+
+// NewCountCachingUserRepository wraps next so that exact list totals are
+// cached in Redis for up to ttl
+func NewCountCachingUserRepository(next user.UserRepository, client *redis.Client, ttl time.Duration) user.UserRepository {
+	if next == nil {
+		panic("user repository cannot be nil")
+	}
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	return &countCachingUserRepository{
+		UserRepository: next,
+		client:         client,
+		ttl:            ttl,
+		log:            logger.Get().WithLayer("infrastructure").WithComponent("user_count_cache"),
+	}
+}
+
+func (r *countCachingUserRepository) Create(ctx context.Context, u *user.User) error {
+	if err := r.UserRepository.Create(ctx, u); err != nil {
+		return err
+	}
+	r.invalidate(ctx, u.TenantID)
+	return nil
+}
+
+func (r *countCachingUserRepository) CreateBatch(ctx context.Context, users []*user.User) error {
+	if err := r.UserRepository.CreateBatch(ctx, users); err != nil {
+		return err
+	}
+	r.invalidate(ctx, tenant.IDFromContext(ctx))
+	return nil
+}
+
+func (r *countCachingUserRepository) Update(ctx context.Context, u *user.User) error {
+	if err := r.UserRepository.Update(ctx, u); err != nil {
+		return err
+	}
+	r.invalidate(ctx, u.TenantID)
+	return nil
+}
+
+func (r *countCachingUserRepository) Delete(ctx context.Context, id string) error {
+	if err := r.UserRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, tenant.IDFromContext(ctx))
+	return nil
+}
+
+// List serves exact totals from the cache. Lists inside a transaction are
+// counted, as the transaction may see its own uncommitted writes.
+func (r *countCachingUserRepository) List(ctx context.Context, req *user.ListUsersRequest) (*user.ListUsersResponse, error) {
+	if (req.IncludeTotal != "" && req.IncludeTotal != user.TotalExact) || database.InTransaction(ctx) {
+		return r.UserRepository.List(ctx, req)
+	}
+
+	key, err := r.key(ctx, req)
+	if err != nil {
+		r.log.Warn(ctx, "failed to read user count generation", "error", err)
+		return r.UserRepository.List(ctx, req)
+	}
+
+	cached, err := r.client.String(ctx, "GET", key)
+	if err == nil {
+		total, _ := strconv.ParseInt(cached, 10, 64)
+		uncounted := *req
+		uncounted.IncludeTotal = user.TotalNone
+		resp, err := r.UserRepository.List(ctx, &uncounted)
+		if err != nil {
+			return nil, err
+		}
+		resp.Total = total
+		resp.TotalKind = user.TotalExact
+		resp.TotalPages = int((total + int64(resp.PageSize) - 1) / int64(resp.PageSize))
+		return resp, nil
+	}
+	if !errors.Is(err, redis.ErrNil) {
+		r.log.Warn(ctx, "failed to read cached user count", "error", err)
+	}
+
+	resp, err := r.UserRepository.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	ms := max(r.ttl.Milliseconds(), 1)
+	if _, err := r.client.Do(ctx, "SET", key, strconv.FormatInt(resp.Total, 10), "PX", strconv.FormatInt(ms, 10)); err != nil {
+		r.log.Warn(ctx, "failed to cache user count", "error", err)
+	}
+	return resp, nil
+}
+
+// key names the cached count of the users req filters for, in the current
+// generation of the tenant. Paging and sorting do not change the count.
+func (r *countCachingUserRepository) key(ctx context.Context, req *user.ListUsersRequest) (string, error) {
+	tenantID := tenant.IDFromContext(ctx)
+	generation, err := r.client.String(ctx, "GET", userCountKeyPrefix+"gen:"+tenantID)
+	if errors.Is(err, redis.ErrNil) {
+		generation = "0"
+	} else if err != nil {
+		return "", err
+	}
+
+	filters, err := json.Marshal(struct {
+		Email, Name                 string
+		CreatedAfter, CreatedBefore time.Time
+		Roles, Statuses             []string
+	}{req.Email, req.Name, req.CreatedAfter, req.CreatedBefore, req.Roles, req.Statuses})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(filters)
+	return userCountKeyPrefix + tenantID + ":" + generation + ":" + hex.EncodeToString(sum[:12]), nil
+}
+
+// invalidate starts a new generation of the tenant's cached counts once
+// the write commits. Invalidated before, a request counting in between
+// would cache the old total in the new generation.
+func (r *countCachingUserRepository) invalidate(ctx context.Context, tenantID string) {
+	if tenantID == "" {
+		tenantID = tenant.IDFromContext(ctx)
+	}
+	database.AfterCommit(ctx, func(ctx context.Context) {
+		if _, err := r.client.Int(ctx, "INCR", userCountKeyPrefix+"gen:"+tenantID); err != nil {
+			r.log.Warn(ctx, "failed to invalidate cached user counts", "tenant_id", tenantID, "error", err)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+func TestCountCachingUserRepository(t *testing.T) {
+	db := dbtest.Open(t)
+	inner := NewUserRepository(db)
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	repo := NewCountCachingUserRepository(inner, client, time.Minute)
+	ctx := context.Background()
+
+	create := func(id, email string) {
+		require.NoError(t, repo.Create(ctx, &user.User{ID: id, Email: email, Name: "User", PasswordHash: "hash", Role: user.RoleUser}))
+	}
+	create("user-a", "a@example.com")
+	create("user-b", "b@example.com")
+
+	resp, err := repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Total)
+
+	// A row written behind the repository's back is not counted until the
+	// cached count is invalidated
+	require.NoError(t, db.Create(&user.User{ID: "user-c", Email: "c@example.com", Name: "User", PasswordHash: "hash", Role: user.RoleUser}).Error)
+	resp, err = repo.List(ctx, &user.ListUsersRequest{Page: 2, PageSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Total)
+	assert.Equal(t, user.TotalExact, resp.TotalKind)
+	assert.Equal(t, 2, resp.TotalPages)
+	assert.Len(t, resp.Users, 1)
+
+	t.Run("filters are counted separately", func(t *testing.T) {
+		resp, err := repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 1, Email: "b@"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.Total)
+	})
+
+	t.Run("other totals bypass the cache", func(t *testing.T) {
+		resp, err := repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 1, IncludeTotal: user.TotalEstimate})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), resp.Total)
+	})
+
+	t.Run("writes invalidate", func(t *testing.T) {
+		create("user-d", "d@example.com")
+		resp, err := repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), resp.Total)
+
+		require.NoError(t, repo.Delete(ctx, "user-d"))
+		resp, err = repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), resp.Total)
+	})
+
+	t.Run("transactional writes invalidate once committed", func(t *testing.T) {
+		generation := func() string {
+			gen, err := client.String(ctx, "GET", userCountKeyPrefix+"gen:"+tenant.IDFromContext(ctx))
+			require.NoError(t, err)
+			return gen
+		}
+		before := generation()
+		uow := database.NewUnitOfWork(db)
+
+		err := uow.Do(ctx, func(txCtx context.Context) error {
+			require.NoError(t, repo.Create(txCtx, &user.User{ID: "user-e", Email: "e@example.com", Name: "User", PasswordHash: "hash", Role: user.RoleUser}))
+			// A request counting now would cache the old total again
+			assert.Equal(t, before, generation())
+			return errors.New("rolled back")
+		})
+		require.Error(t, err)
+		assert.Equal(t, before, generation(), "a rolled back write keeps the counts")
+
+		require.NoError(t, uow.Do(ctx, func(txCtx context.Context) error {
+			return repo.Create(txCtx, &user.User{ID: "user-e", Email: "e@example.com", Name: "User", PasswordHash: "hash", Role: user.RoleUser})
+		}))
+		assert.NotEqual(t, before, generation())
+		resp, err := repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), resp.Total)
+		require.NoError(t, repo.Delete(ctx, "user-e"))
+	})
+
+	t.Run("falls back to counting without redis", func(t *testing.T) {
+		down := redis.NewClient(redis.Options{Addr: "127.0.0.1:1", Timeout: 50 * time.Millisecond})
+		defer down.Close()
+		resp, err := NewCountCachingUserRepository(inner, down, time.Minute).List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), resp.Total)
+	})
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/dataexport"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+type dataExportRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewDataExportRepository creates a new dataexport.Repository implementation
+func NewDataExportRepository(db *gorm.DB) dataexport.Repository {
+	return NewDataExportRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("data_export_repository"))
+}
+
+// NewDataExportRepositoryWithLogger creates a new dataexport.Repository implementation with explicit logger
+func NewDataExportRepositoryWithLogger(db *gorm.DB, log logger.Logger) dataexport.Repository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &dataExportRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// scoped returns the connection for ctx restricted to its tenant
+func (r *dataExportRepository) scoped(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db).Where("tenant_id = ?", tenant.IDFromContext(ctx))
+}
+
+// Create inserts an export into the tenant of ctx
+func (r *dataExportRepository) Create(ctx context.Context, e *dataexport.Export) error {
+	if e == nil {
+		return wonderErrors.NewRequiredFieldError("export", "nil")
+	}
+	e.TenantID = tenant.IDFromContext(ctx)
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	if err := database.FromContext(ctx, r.db).Create(e).Error; err != nil {
+		r.log.Error(ctx, "data export create failed", "error", err, "export_id", e.ID)
+		return wonderErrors.NewDatabaseError("create", "data_exports", err, isRetryableError(err), map[string]interface{}{
+			"export_id": e.ID,
+		})
+	}
+	return nil
+}
+
+// Update saves e. The archive is left as stored when e carries none.
+func (r *dataExportRepository) Update(ctx context.Context, e *dataexport.Export) error {
+	if e == nil {
+		return wonderErrors.NewRequiredFieldError("export", "nil")
+	}
+
+	query := r.scoped(ctx).Model(e).Select("*").Omit("id", "tenant_id", "user_id", "created_at")
+	if e.Content == nil {
+		query = query.Omit("content")
+	}
+	result := query.Updates(e)
+	if result.Error != nil {
+		r.log.Error(ctx, "data export update failed", "error", result.Error, "export_id", e.ID)
+		return wonderErrors.NewDatabaseError("update", "data_exports", result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"export_id": e.ID,
+		})
+	}
+	if result.RowsAffected == 0 {
+		return wonderErrors.NewEntityNotFoundError("data_export", e.ID)
+	}
+	return nil
+}
+
+// GetByID retrieves an export without its archive
+func (r *dataExportRepository) GetByID(ctx context.Context, id string) (*dataexport.Export, error) {
+	return r.first(ctx, "get", r.scoped(ctx).Where("id = ?", id))
+}
+
+// Latest retrieves the user's most recent export without its archive
+func (r *dataExportRepository) Latest(ctx context.Context, userID string) (*dataexport.Export, error) {
+	return r.first(ctx, "latest", r.scoped(ctx).Where("user_id = ?", userID).Order("created_at DESC").Order("id DESC"))
+}
+
+func (r *dataExportRepository) first(ctx context.Context, operation string, query *gorm.DB) (*dataexport.Export, error) {
+	var e dataexport.Export
+	err := query.Omit("content").First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "data export lookup failed", "error", err, "operation", operation)
+		return nil, wonderErrors.NewDatabaseError(operation, "data_exports", err, isRetryableError(err))
+	}
+	return &e, nil
+}
+
+// Content loads the archive of an export
+func (r *dataExportRepository) Content(ctx context.Context, id string) ([]byte, error) {
+	var e dataexport.Export
+	err := r.scoped(ctx).Select("id", "content").Where("id = ?", id).First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, wonderErrors.NewEntityNotFoundError("data_export", id)
+	}
+	if err != nil {
+		r.log.Error(ctx, "data export content lookup failed", "error", err, "export_id", id)
+		return nil, wonderErrors.NewDatabaseError("content", "data_exports", err, isRetryableError(err), map[string]interface{}{
+			"export_id": id,
+		})
+	}
+	return e.Content, nil
+}
+
+// DeleteExpired removes expired exports of every tenant
+func (r *dataExportRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := database.FromContext(ctx, r.db).Where("expires_at <= ?", now).Delete(&dataexport.Export{})
+	if result.Error != nil {
+		r.log.Error(ctx, "failed to delete expired data exports", "error", result.Error)
+		return 0, wonderErrors.NewDatabaseError("delete", "data_exports", result.Error, isRetryableError(result.Error))
+	}
+	return result.RowsAffected, nil
+}
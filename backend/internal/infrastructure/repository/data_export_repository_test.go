@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/dataexport"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func TestDataExportRepository(t *testing.T) {
+	repo := NewDataExportRepository(dbtest.Open(t))
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	older := &dataexport.Export{ID: "exp-1", UserID: "u-1", Status: dataexport.StatusPending, CreatedAt: now.Add(-time.Hour)}
+	latest := &dataexport.Export{ID: "exp-2", UserID: "u-1", Status: dataexport.StatusPending, CreatedAt: now}
+	require.NoError(t, repo.Create(acme, older))
+	require.NoError(t, repo.Create(acme, latest))
+	assert.Equal(t, "acme", latest.TenantID)
+
+	found, err := repo.Latest(acme, "u-1")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "exp-2", found.ID)
+
+	// Another tenant's exports are invisible
+	found, err = repo.Latest(globex, "u-1")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	latest.MarkReady([]byte("zip"), now, now.Add(24*time.Hour))
+	require.NoError(t, repo.Update(acme, latest))
+
+	// Lookups leave the archive out, and updating without one keeps it
+	found, err = repo.GetByID(acme, "exp-2")
+	require.NoError(t, err)
+	assert.Equal(t, dataexport.StatusReady, found.Status)
+	assert.Equal(t, int64(3), found.Size)
+	assert.Nil(t, found.Content)
+	require.NoError(t, repo.Update(acme, found))
+
+	content, err := repo.Content(acme, "exp-2")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("zip"), content)
+
+	_, err = repo.Content(globex, "exp-2")
+	var notFound *wonderErrors.EntityNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+	assert.ErrorAs(t, repo.Update(globex, found), &notFound)
+
+	// Only expired exports are deleted; pending ones have no expiry
+	deleted, err := repo.DeleteExpired(context.Background(), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	deleted, err = repo.DeleteExpired(context.Background(), now.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	found, err = repo.Latest(acme, "u-1")
+	require.NoError(t, err)
+	assert.Equal(t, "exp-1", found.ID)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// EmailCanonicalizer recomputes the canonical email of every user after the
+// email rules change. Canonical emails of users saved before they existed
+// are filled in by migration 0026.
+type EmailCanonicalizer struct {
+	db        *gorm.DB
+	rules     user.EmailRules
+	batchSize int
+	log       logger.Logger
+}
+
+// NewEmailCanonicalizer creates a canonicalizer applying rules and reading
+// batchSize users at a time
+func NewEmailCanonicalizer(db *gorm.DB, rules user.EmailRules, batchSize int) *EmailCanonicalizer {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if batchSize <= 0 {
+		panic("batch size must be positive")
+	}
+	return &EmailCanonicalizer{
+		db:        db,
+		rules:     rules,
+		batchSize: batchSize,
+		log:       logger.Get().WithLayer("infrastructure").WithComponent("email_canonicalizer"),
+	}
+}
+
+// Canonicalize walks the users of every tenant and returns how many it
+// updated. A user whose canonical email under the new rules is already
+// taken by another user of the tenant is a duplicate account; it is logged
+// and keeps its current canonical email, so it can still sign in with its
+// own address until it is merged or removed.
+func (c *EmailCanonicalizer) Canonicalize(ctx context.Context) (int, error) {
+	keyring := database.FieldEncryption()
+
+	updated, duplicates, after := 0, 0, ""
+	for {
+		var rows []piiRow
+		err := database.FromContext(ctx, c.db).Where("id > ?", after).Order("id").Limit(c.batchSize).Find(&rows).Error
+		if err != nil {
+			c.log.Error(ctx, "failed to read users for canonicalization", "error", err, "updated", updated)
+			return updated, wonderErrors.NewDatabaseError("canonicalize_emails", "users", err, isRetryableError(err))
+		}
+		if len(rows) == 0 {
+			c.log.Info(ctx, "emails canonicalized", "updated", updated, "duplicates", duplicates)
+			return updated, nil
+		}
+
+		for _, row := range rows {
+			email := row.Email
+			if keyring != nil {
+				if email, err = keyring.Decrypt(row.Email); err != nil {
+					c.log.Error(ctx, "failed to decrypt user email", "error", err, "user_id", row.ID)
+					return updated, wonderErrors.NewDatabaseError("canonicalize_emails", "users", err, false, map[string]interface{}{
+						"user_id": row.ID,
+					})
+				}
+			}
+			key := canonicalEmailKey(c.rules, email)
+			if row.CanonicalEmail != nil && *row.CanonicalEmail == key {
+				continue
+			}
+
+			// Users whose email changed since they were read were already
+			// saved with its canonical form
+			err = database.FromContext(ctx, c.db).Model(&piiRow{}).
+				Where("id = ? AND email = ?", row.ID, row.Email).
+				Update("canonical_email", key).Error
+			if isDuplicateKeyError(err) {
+				duplicates++
+				c.log.Warn(ctx, "user shares its canonical email with another user", "user_id", row.ID)
+				continue
+			}
+			if err != nil {
+				c.log.Error(ctx, "failed to canonicalize user email", "error", err, "user_id", row.ID)
+				return updated, wonderErrors.NewDatabaseError("canonicalize_emails", "users", err, isRetryableError(err), map[string]interface{}{
+					"user_id": row.ID,
+				})
+			}
+			updated++
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
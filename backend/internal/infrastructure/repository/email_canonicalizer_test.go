@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+)
+
+func TestUserRepository_CanonicalEmail(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, newPIIUser("u-1", "Ada@Example.com", "Ada")))
+	assert.Equal(t, "ada@example.com", *storedRow(t, db, "u-1").CanonicalEmail)
+
+	// Addresses differing only in case belong to the same account
+	assert.Error(t, repo.Create(ctx, newPIIUser("u-2", "ada@example.COM", "Ada Again")))
+
+	found, err := repo.GetByEmail(ctx, "ADA@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "u-1", found.ID)
+
+	existing, err := repo.ExistingEmails(ctx, []string{"aDa@example.com", "grace@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"ada@example.com": true}, existing)
+}
+
+func TestEmailCanonicalizer(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	// Saved while gmail addresses were not folded
+	for _, u := range []*user.User{
+		newPIIUser("u-1", "Ada@example.com", "Ada"),
+		newPIIUser("u-2", "ada.lovelace@googlemail.com", "Ada Lovelace"),
+		newPIIUser("u-3", "grace.hopper@gmail.com", "Grace"),
+		newPIIUser("u-4", "gracehopper@gmail.com", "Grace Again"),
+	} {
+		require.NoError(t, repo.Create(ctx, u))
+	}
+
+	// Folding is turned on. Users still sign in with their own address
+	// before the job runs; other spellings of it need the job.
+	rules := user.EmailRules{FoldGmail: true}
+	folded := NewUserRepository(db, WithEmailRules(rules))
+	found, err := folded.GetByEmail(ctx, "Ada.Lovelace@googlemail.com")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "u-2", found.ID)
+	found, err = folded.GetByEmail(ctx, "adalovelace+news@gmail.com")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	canonicalizer := NewEmailCanonicalizer(db, rules, 2)
+	updated, err := canonicalizer.Canonicalize(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, "adalovelace@gmail.com", *storedRow(t, db, "u-2").CanonicalEmail)
+	assert.Equal(t, "grace.hopper@gmail.com", *storedRow(t, db, "u-3").CanonicalEmail, "duplicates keep their key")
+
+	found, err = folded.GetByEmail(ctx, "adalovelace+news@gmail.com")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "u-2", found.ID)
+
+	// Current rows are left alone
+	updated, err = canonicalizer.Canonicalize(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cctw-zed/wonder/internal/domain/mfa"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const (
+	mfaEnrollmentsTable = "mfa_enrollments"
+	trustedDevicesTable = "trusted_devices"
+)
+
+type mfaRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewMFARepository creates a new mfa.Repository implementation
+func NewMFARepository(db *gorm.DB) mfa.Repository {
+	return NewMFARepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("mfa_repository"))
+}
+
+// NewMFARepositoryWithLogger creates a new mfa.Repository implementation with explicit logger
+func NewMFARepositoryWithLogger(db *gorm.DB, log logger.Logger) mfa.Repository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &mfaRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// scoped returns the connection for ctx restricted to its tenant
+func (r *mfaRepository) scoped(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db).Where("tenant_id = ?", tenant.IDFromContext(ctx))
+}
+
+// Get retrieves the user's enrollment
+func (r *mfaRepository) Get(ctx context.Context, userID string) (*mfa.Enrollment, error) {
+	var e mfa.Enrollment
+	err := r.scoped(ctx).Where("user_id = ?", userID).First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "mfa enrollment lookup failed", "error", err, "user_id", userID)
+		return nil, wonderErrors.NewDatabaseError("get", mfaEnrollmentsTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return &e, nil
+}
+
+// Save inserts the enrollment or replaces the user's existing one
+func (r *mfaRepository) Save(ctx context.Context, e *mfa.Enrollment) error {
+	if e == nil {
+		return wonderErrors.NewRequiredFieldError("enrollment", "nil")
+	}
+	e.TenantID = tenant.IDFromContext(ctx)
+	now := time.Now()
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = now
+	}
+	e.UpdatedAt = now
+	if e.RecoveryCodes == nil {
+		e.RecoveryCodes = []string{}
+	}
+
+	err := database.FromContext(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		UpdateAll: true,
+	}).Create(e).Error
+	if err != nil {
+		r.log.Error(ctx, "mfa enrollment save failed", "error", err, "user_id", e.UserID)
+		return wonderErrors.NewDatabaseError("save", mfaEnrollmentsTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": e.UserID,
+		})
+	}
+	return nil
+}
+
+// Update writes back the state code checks change, on the condition that
+// the stored version is still the one e was read with
+func (r *mfaRepository) Update(ctx context.Context, e *mfa.Enrollment) error {
+	if e == nil {
+		return wonderErrors.NewRequiredFieldError("enrollment", "nil")
+	}
+	next := *e
+	next.Version++
+	next.UpdatedAt = time.Now()
+	if next.RecoveryCodes == nil {
+		next.RecoveryCodes = []string{}
+	}
+
+	result := r.scoped(ctx).Model(&next).
+		Where("version = ?", e.Version).
+		Select("confirmed_at", "last_used_step", "recovery_codes", "failed_attempts", "locked_until", "version", "updated_at").
+		Updates(&next)
+	if result.Error != nil {
+		r.log.Error(ctx, "mfa enrollment update failed", "error", result.Error, "user_id", e.UserID)
+		return wonderErrors.NewDatabaseError("update", mfaEnrollmentsTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"user_id": e.UserID,
+		})
+	}
+	if result.RowsAffected == 0 {
+		r.log.Warn(ctx, "mfa enrollment changed since it was read", "user_id", e.UserID, "version", e.Version)
+		return wonderErrors.NewVersionMismatchError("mfa_enrollment", e.UserID, "")
+	}
+
+	*e = next
+	return nil
+}
+
+// Delete removes the user's enrollment
+func (r *mfaRepository) Delete(ctx context.Context, userID string) error {
+	result := r.scoped(ctx).Where("user_id = ?", userID).Delete(&mfa.Enrollment{})
+	if result.Error != nil {
+		r.log.Error(ctx, "mfa enrollment delete failed", "error", result.Error, "user_id", userID)
+		return wonderErrors.NewDatabaseError("delete", mfaEnrollmentsTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	if result.RowsAffected == 0 {
+		return wonderErrors.NewEntityNotFoundError("mfa_enrollment", userID)
+	}
+	return nil
+}
+
+// CreateDevice inserts a trusted device into the tenant of ctx
+func (r *mfaRepository) CreateDevice(ctx context.Context, d *mfa.TrustedDevice) error {
+	if d == nil {
+		return wonderErrors.NewRequiredFieldError("device", "nil")
+	}
+	d.TenantID = tenant.IDFromContext(ctx)
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+	if d.LastUsedAt.IsZero() {
+		d.LastUsedAt = d.CreatedAt
+	}
+
+	if err := database.FromContext(ctx, r.db).Create(d).Error; err != nil {
+		r.log.Error(ctx, "trusted device create failed", "error", err, "device_id", d.ID)
+		return wonderErrors.NewDatabaseError("create", trustedDevicesTable, err, isRetryableError(err), map[string]interface{}{
+			"device_id": d.ID,
+		})
+	}
+	return nil
+}
+
+// GetDeviceByTokenHash retrieves the device a token belongs to
+func (r *mfaRepository) GetDeviceByTokenHash(ctx context.Context, tokenHash string) (*mfa.TrustedDevice, error) {
+	var d mfa.TrustedDevice
+	err := r.scoped(ctx).Where("token_hash = ?", tokenHash).First(&d).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "trusted device lookup failed", "error", err)
+		return nil, wonderErrors.NewDatabaseError("get", trustedDevicesTable, err, isRetryableError(err))
+	}
+	return &d, nil
+}
+
+// TouchDevice records when the device was last used
+func (r *mfaRepository) TouchDevice(ctx context.Context, id string, at time.Time) error {
+	err := r.scoped(ctx).Model(&mfa.TrustedDevice{}).Where("id = ?", id).Update("last_used_at", at).Error
+	if err != nil {
+		r.log.Error(ctx, "trusted device touch failed", "error", err, "device_id", id)
+		return wonderErrors.NewDatabaseError("update", trustedDevicesTable, err, isRetryableError(err), map[string]interface{}{
+			"device_id": id,
+		})
+	}
+	return nil
+}
+
+// ListDevices lists the user's unexpired devices, most recently used first
+func (r *mfaRepository) ListDevices(ctx context.Context, userID string, now time.Time) ([]*mfa.TrustedDevice, error) {
+	var devices []*mfa.TrustedDevice
+	err := r.scoped(ctx).Where("user_id = ? AND expires_at > ?", userID, now).
+		Order("last_used_at DESC").Order("id DESC").Find(&devices).Error
+	if err != nil {
+		r.log.Error(ctx, "trusted device list failed", "error", err, "user_id", userID)
+		return nil, wonderErrors.NewDatabaseError("list", trustedDevicesTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return devices, nil
+}
+
+// DeleteDevice removes one of the user's devices
+func (r *mfaRepository) DeleteDevice(ctx context.Context, userID, id string) error {
+	result := r.scoped(ctx).Where("user_id = ? AND id = ?", userID, id).Delete(&mfa.TrustedDevice{})
+	if result.Error != nil {
+		r.log.Error(ctx, "trusted device delete failed", "error", result.Error, "device_id", id)
+		return wonderErrors.NewDatabaseError("delete", trustedDevicesTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"device_id": id,
+		})
+	}
+	if result.RowsAffected == 0 {
+		return wonderErrors.NewEntityNotFoundError("trusted_device", id)
+	}
+	return nil
+}
+
+// DeleteDevices removes all of the user's devices
+func (r *mfaRepository) DeleteDevices(ctx context.Context, userID string) (int64, error) {
+	result := r.scoped(ctx).Where("user_id = ?", userID).Delete(&mfa.TrustedDevice{})
+	if result.Error != nil {
+		r.log.Error(ctx, "trusted devices delete failed", "error", result.Error, "user_id", userID)
+		return 0, wonderErrors.NewDatabaseError("delete", trustedDevicesTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return result.RowsAffected, nil
+}
+
+// DeleteExpiredDevices removes expired devices of every tenant
+func (r *mfaRepository) DeleteExpiredDevices(ctx context.Context, now time.Time) (int64, error) {
+	result := database.FromContext(ctx, r.db).Where("expires_at <= ?", now).Delete(&mfa.TrustedDevice{})
+	if result.Error != nil {
+		r.log.Error(ctx, "failed to delete expired trusted devices", "error", result.Error)
+		return 0, wonderErrors.NewDatabaseError("delete", trustedDevicesTable, result.Error, isRetryableError(result.Error))
+	}
+	return result.RowsAffected, nil
+}
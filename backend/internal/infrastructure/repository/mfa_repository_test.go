@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/mfa"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func TestMFARepository(t *testing.T) {
+	repo := NewMFARepository(dbtest.Open(t))
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+
+	found, err := repo.Get(acme, "u-1")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	e := &mfa.Enrollment{UserID: "u-1", Secret: "JBSWY3DPEHPK3PXP"}
+	require.NoError(t, repo.Save(acme, e))
+	assert.Equal(t, "acme", e.TenantID)
+
+	// Saving again replaces the enrollment
+	confirmedAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	e.ConfirmedAt = &confirmedAt
+	e.LastUsedStep = 42
+	e.SetRecoveryCodes([]string{"AAAAA-BBBBB"})
+	require.NoError(t, repo.Save(acme, e))
+
+	found, err = repo.Get(acme, "u-1")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.True(t, found.Confirmed())
+	assert.Equal(t, int64(42), found.LastUsedStep)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", found.Secret)
+	assert.True(t, found.UseRecoveryCode("AAAAA-BBBBB"))
+
+	// Another tenant's enrollments are invisible
+	found, err = repo.Get(globex, "u-1")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+	var notFound *wonderErrors.EntityNotFoundError
+	assert.ErrorAs(t, repo.Delete(globex, "u-1"), &notFound)
+
+	require.NoError(t, repo.Delete(acme, "u-1"))
+	found, err = repo.Get(acme, "u-1")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestMFARepository_Update(t *testing.T) {
+	repo := NewMFARepository(dbtest.Open(t))
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	e := &mfa.Enrollment{UserID: "u-1", Secret: "JBSWY3DPEHPK3PXP"}
+	e.SetRecoveryCodes([]string{"AAAAA-BBBBB"})
+	require.NoError(t, repo.Save(ctx, e))
+
+	// Parallel requests read the enrollment, then each spends the same
+	// recovery code
+	const requests = 8
+	read := make([]*mfa.Enrollment, requests)
+	for i := range read {
+		found, err := repo.Get(ctx, "u-1")
+		require.NoError(t, err)
+		read[i] = found
+	}
+	var wg sync.WaitGroup
+	var written atomic.Int32
+	for _, found := range read {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !found.UseRecoveryCode("AAAAA-BBBBB") {
+				return
+			}
+			err := repo.Update(ctx, found)
+			if err == nil {
+				written.Add(1)
+				return
+			}
+			var conflict *wonderErrors.ConflictError
+			if assert.ErrorAs(t, err, &conflict) {
+				assert.Equal(t, wonderErrors.CodeVersionMismatch, conflict.Code())
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), written.Load(), "only one write of the same version succeeds")
+
+	found, err := repo.Get(ctx, "u-1")
+	require.NoError(t, err)
+	assert.Empty(t, found.RecoveryCodes)
+	assert.Equal(t, int64(1), found.Version)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", found.Secret, "the secret is left as it was")
+
+	// Another tenant cannot write the enrollment
+	found.FailedAttempts = 2
+	assert.Error(t, repo.Update(tenant.WithID(context.Background(), "globex"), found))
+}
+
+func TestMFARepository_TrustedDevices(t *testing.T) {
+	repo := NewMFARepository(dbtest.Open(t))
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	laptop := &mfa.TrustedDevice{ID: "dev-1", UserID: "u-1", TokenHash: mfa.HashDeviceToken("laptop"), Name: "Laptop", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(24 * time.Hour)}
+	phone := &mfa.TrustedDevice{ID: "dev-2", UserID: "u-1", TokenHash: mfa.HashDeviceToken("phone"), Name: "Phone", CreatedAt: now, ExpiresAt: now.Add(24 * time.Hour)}
+	stale := &mfa.TrustedDevice{ID: "dev-3", UserID: "u-1", TokenHash: mfa.HashDeviceToken("stale"), CreatedAt: now.Add(-48 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	for _, d := range []*mfa.TrustedDevice{laptop, phone, stale} {
+		require.NoError(t, repo.CreateDevice(acme, d))
+	}
+
+	found, err := repo.GetDeviceByTokenHash(acme, mfa.HashDeviceToken("laptop"))
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "dev-1", found.ID)
+	found, err = repo.GetDeviceByTokenHash(globex, mfa.HashDeviceToken("laptop"))
+	require.NoError(t, err)
+	assert.Nil(t, found, "another tenant's devices are invisible")
+
+	// Listing leaves expired devices out, most recently used first
+	require.NoError(t, repo.TouchDevice(acme, "dev-1", now.Add(time.Minute)))
+	devices, err := repo.ListDevices(acme, "u-1", now)
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	assert.Equal(t, "dev-1", devices[0].ID)
+
+	deleted, err := repo.DeleteExpiredDevices(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	var notFound *wonderErrors.EntityNotFoundError
+	assert.ErrorAs(t, repo.DeleteDevice(acme, "u-2", "dev-1"), &notFound, "devices are only revoked by their owner")
+	require.NoError(t, repo.DeleteDevice(acme, "u-1", "dev-1"))
+
+	deleted, err = repo.DeleteDevices(acme, "u-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
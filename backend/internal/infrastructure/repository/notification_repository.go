@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cctw-zed/wonder/internal/domain/notification"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const notificationsTable = "notifications"
+
+type notificationRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewNotificationRepository creates a new notification.Repository implementation
+func NewNotificationRepository(db *gorm.DB) notification.Repository {
+	return NewNotificationRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("notification_repository"))
+}
+
+// NewNotificationRepositoryWithLogger creates a new notification.Repository implementation with explicit logger
+func NewNotificationRepositoryWithLogger(db *gorm.DB, log logger.Logger) notification.Repository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &notificationRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// scoped returns the connection for ctx restricted to its tenant
+func (r *notificationRepository) scoped(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db).Where("tenant_id = ?", tenant.IDFromContext(ctx))
+}
+
+// Create inserts a notification into the tenant of ctx, leaving an
+// existing one with the same ID as it is
+func (r *notificationRepository) Create(ctx context.Context, n *notification.Notification) error {
+	if n == nil {
+		return wonderErrors.NewRequiredFieldError("notification", "nil")
+	}
+	n.TenantID = tenant.IDFromContext(ctx)
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+
+	err := database.FromContext(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(n).Error
+	if err != nil {
+		r.log.Error(ctx, "notification create failed", "error", err, "notification_id", n.ID, "user_id", n.UserID)
+		return wonderErrors.NewDatabaseError("create", notificationsTable, err, isRetryableError(err), map[string]interface{}{
+			"notification_id": n.ID,
+			"user_id":         n.UserID,
+		})
+	}
+	return nil
+}
+
+// Get retrieves one of the user's notifications
+func (r *notificationRepository) Get(ctx context.Context, userID, id string) (*notification.Notification, error) {
+	var n notification.Notification
+	err := r.scoped(ctx).Where("user_id = ? AND id = ?", userID, id).First(&n).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "notification lookup failed", "error", err, "notification_id", id)
+		return nil, wonderErrors.NewDatabaseError("get", notificationsTable, err, isRetryableError(err), map[string]interface{}{
+			"notification_id": id,
+			"user_id":         userID,
+		})
+	}
+	return &n, nil
+}
+
+// List returns a page of the user's notifications, newest first
+func (r *notificationRepository) List(ctx context.Context, userID string, filter notification.ListFilter) ([]*notification.Notification, int64, error) {
+	query := r.scoped(ctx).Model(&notification.Notification{}).Where("user_id = ?", userID)
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.log.Error(ctx, "notification count failed", "error", err, "user_id", userID)
+		return nil, 0, wonderErrors.NewDatabaseError("count", notificationsTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+
+	var notifications []*notification.Notification
+	err := query.Order("created_at DESC").Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&notifications).Error
+	if err != nil {
+		r.log.Error(ctx, "notification list failed", "error", err, "user_id", userID)
+		return nil, 0, wonderErrors.NewDatabaseError("list", notificationsTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return notifications, total, nil
+}
+
+// CountUnread returns how many of the user's notifications are unread
+func (r *notificationRepository) CountUnread(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.scoped(ctx).Model(&notification.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	if err != nil {
+		r.log.Error(ctx, "unread notification count failed", "error", err, "user_id", userID)
+		return 0, wonderErrors.NewDatabaseError("count", notificationsTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return count, nil
+}
+
+// MarkRead sets the read time of one or all of the user's unread
+// notifications
+func (r *notificationRepository) MarkRead(ctx context.Context, userID, id string, at time.Time) (int64, error) {
+	query := r.scoped(ctx).Model(&notification.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+
+	result := query.Update("read_at", at)
+	if result.Error != nil {
+		r.log.Error(ctx, "notification mark read failed", "error", result.Error, "user_id", userID, "notification_id", id)
+		return 0, wonderErrors.NewDatabaseError("mark_read", notificationsTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"notification_id": id,
+			"user_id":         userID,
+		})
+	}
+	return result.RowsAffected, nil
+}
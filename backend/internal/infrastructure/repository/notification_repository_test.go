@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/notification"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+)
+
+func TestNotificationRepository(t *testing.T) {
+	repo := NewNotificationRepository(dbtest.Open(t))
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"n-1", "n-2", "n-3"} {
+		require.NoError(t, repo.Create(acme, &notification.Notification{
+			ID: id, UserID: "u-1", Type: notification.TypeWelcome, Title: "Welcome",
+			Data: map[string]string{"name": "Ada"}, CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+	require.NoError(t, repo.Create(acme, &notification.Notification{ID: "n-4", UserID: "u-2", Type: notification.TypeWelcome, Title: "Welcome"}))
+
+	// Creating again is a no-op, so deliveries can be retried
+	require.NoError(t, repo.Create(acme, &notification.Notification{ID: "n-1", UserID: "u-1", Type: notification.TypeWelcome, Title: "Changed"}))
+	found, err := repo.Get(acme, "u-1", "n-1")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "Welcome", found.Title)
+	assert.Equal(t, "Ada", found.Data["name"])
+
+	page, total, err := repo.List(acme, "u-1", notification.ListFilter{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, page, 2)
+	assert.Equal(t, "n-3", page[0].ID, "newest first")
+
+	marked, err := repo.MarkRead(acme, "u-1", "n-3", start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), marked)
+	marked, err = repo.MarkRead(acme, "u-1", "n-3", start.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), marked, "read notifications keep their read time")
+
+	unread, total, err := repo.List(acme, "u-1", notification.ListFilter{UnreadOnly: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, unread, 2)
+
+	t.Run("other users and tenants are not touched", func(t *testing.T) {
+		found, err := repo.Get(acme, "u-2", "n-1")
+		require.NoError(t, err)
+		assert.Nil(t, found)
+		found, err = repo.Get(globex, "u-1", "n-1")
+		require.NoError(t, err)
+		assert.Nil(t, found)
+		marked, err := repo.MarkRead(globex, "u-1", "", start)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), marked)
+	})
+
+	marked, err = repo.MarkRead(acme, "u-1", "", start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), marked)
+	count, err := repo.CountUnread(acme, "u-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+	count, err = repo.CountUnread(acme, "u-2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
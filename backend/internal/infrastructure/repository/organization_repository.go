@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const (
+	organizationsTable           = "organizations"
+	organizationMembersTable     = "organization_members"
+	organizationInvitationsTable = "organization_invitations"
+)
+
+type organizationRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewOrganizationRepository creates a new organization.Repository implementation
+func NewOrganizationRepository(db *gorm.DB) organization.Repository {
+	return NewOrganizationRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("organization_repository"))
+}
+
+// NewOrganizationRepositoryWithLogger creates a new organization.Repository implementation with explicit logger
+func NewOrganizationRepositoryWithLogger(db *gorm.DB, log logger.Logger) organization.Repository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &organizationRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// scoped returns the connection for ctx restricted to its tenant
+func (r *organizationRepository) scoped(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db).Where("tenant_id = ?", tenant.IDFromContext(ctx))
+}
+
+// Create inserts an organization into the tenant of ctx
+func (r *organizationRepository) Create(ctx context.Context, o *organization.Organization) error {
+	if o == nil {
+		return wonderErrors.NewRequiredFieldError("organization", "nil")
+	}
+	o.TenantID = tenant.IDFromContext(ctx)
+
+	if err := database.FromContext(ctx, r.db).Create(o).Error; err != nil {
+		r.log.Error(ctx, "organization create failed", "error", err, "org_id", o.ID)
+		return wonderErrors.NewDatabaseError("create", organizationsTable, err, isRetryableError(err), map[string]interface{}{
+			"org_id": o.ID,
+		})
+	}
+	return nil
+}
+
+// Get retrieves an organization by ID
+func (r *organizationRepository) Get(ctx context.Context, id string) (*organization.Organization, error) {
+	var o organization.Organization
+	err := r.scoped(ctx).Where("id = ?", id).First(&o).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "organization lookup failed", "error", err, "org_id", id)
+		return nil, wonderErrors.NewDatabaseError("get", organizationsTable, err, isRetryableError(err), map[string]interface{}{
+			"org_id": id,
+		})
+	}
+	return &o, nil
+}
+
+// ListForUser lists the organizations the user is a member of, by name
+func (r *organizationRepository) ListForUser(ctx context.Context, userID string) ([]*organization.Organization, error) {
+	members := r.scoped(ctx).Model(&organization.Member{}).Select("org_id").Where("user_id = ?", userID)
+
+	var orgs []*organization.Organization
+	err := r.scoped(ctx).Where("id IN (?)", members).Order("name").Order("id").Find(&orgs).Error
+	if err != nil {
+		r.log.Error(ctx, "organization list failed", "error", err, "user_id", userID)
+		return nil, wonderErrors.NewDatabaseError("list", organizationsTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return orgs, nil
+}
+
+// ListOwnedBy lists the organizations the user owns, by name
+func (r *organizationRepository) ListOwnedBy(ctx context.Context, userID string) ([]*organization.Organization, error) {
+	owned := r.scoped(ctx).Model(&organization.Member{}).Select("org_id").
+		Where("user_id = ? AND role = ?", userID, organization.RoleOwner)
+
+	var orgs []*organization.Organization
+	err := r.scoped(ctx).Where("id IN (?)", owned).Order("name").Order("id").Find(&orgs).Error
+	if err != nil {
+		r.log.Error(ctx, "owned organization list failed", "error", err, "user_id", userID)
+		return nil, wonderErrors.NewDatabaseError("list_owned", organizationsTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return orgs, nil
+}
+
+// Delete removes an organization with its members and invitations. Call it
+// in a transaction so nothing is left behind when a step fails.
+func (r *organizationRepository) Delete(ctx context.Context, id string) error {
+	for _, step := range []struct {
+		table string
+		model interface{}
+		where string
+	}{
+		{organizationInvitationsTable, &organization.Invitation{}, "org_id = ?"},
+		{organizationMembersTable, &organization.Member{}, "org_id = ?"},
+		{organizationsTable, &organization.Organization{}, "id = ?"},
+	} {
+		if err := r.scoped(ctx).Where(step.where, id).Delete(step.model).Error; err != nil {
+			r.log.Error(ctx, "organization delete failed", "error", err, "org_id", id, "table", step.table)
+			return wonderErrors.NewDatabaseError("delete", step.table, err, isRetryableError(err), map[string]interface{}{
+				"org_id": id,
+			})
+		}
+	}
+	return nil
+}
+
+// AddMember adds a user to an organization of the tenant of ctx
+func (r *organizationRepository) AddMember(ctx context.Context, m *organization.Member) error {
+	if m == nil {
+		return wonderErrors.NewRequiredFieldError("member", "nil")
+	}
+	m.TenantID = tenant.IDFromContext(ctx)
+
+	if err := database.FromContext(ctx, r.db).Create(m).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			return wonderErrors.NewDuplicateEntryError("member", "user_id", m.UserID, m.UserID)
+		}
+		r.log.Error(ctx, "organization member create failed", "error", err, "org_id", m.OrgID, "user_id", m.UserID)
+		return wonderErrors.NewDatabaseError("create", organizationMembersTable, err, isRetryableError(err), map[string]interface{}{
+			"org_id":  m.OrgID,
+			"user_id": m.UserID,
+		})
+	}
+	return nil
+}
+
+// GetMember retrieves a user's membership of an organization
+func (r *organizationRepository) GetMember(ctx context.Context, orgID, userID string) (*organization.Member, error) {
+	return r.getMember(ctx, r.scoped(ctx), orgID, userID)
+}
+
+// GetMemberForUpdate retrieves a membership with SELECT ... FOR UPDATE on
+// PostgreSQL and MySQL. SQLite has no row locks; it lets one transaction
+// write at a time instead.
+func (r *organizationRepository) GetMemberForUpdate(ctx context.Context, orgID, userID string) (*organization.Member, error) {
+	query := r.scoped(ctx)
+	if database.DriverFor(r.db).SkipLocked() {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	return r.getMember(ctx, query, orgID, userID)
+}
+
+// getMember retrieves a membership with query
+func (r *organizationRepository) getMember(ctx context.Context, query *gorm.DB, orgID, userID string) (*organization.Member, error) {
+	var m organization.Member
+	err := query.Where("org_id = ? AND user_id = ?", orgID, userID).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "organization member lookup failed", "error", err, "org_id", orgID, "user_id", userID)
+		return nil, wonderErrors.NewDatabaseError("get", organizationMembersTable, err, isRetryableError(err), map[string]interface{}{
+			"org_id":  orgID,
+			"user_id": userID,
+		})
+	}
+	return &m, nil
+}
+
+// ListMembers lists an organization's members, owner first, then by when
+// they joined
+func (r *organizationRepository) ListMembers(ctx context.Context, orgID string) ([]*organization.Member, error) {
+	var members []*organization.Member
+	err := r.scoped(ctx).Where("org_id = ?", orgID).
+		Order("CASE WHEN role = '" + string(organization.RoleOwner) + "' THEN 0 ELSE 1 END").
+		Order("created_at").Order("user_id").
+		Find(&members).Error
+	if err != nil {
+		r.log.Error(ctx, "organization member list failed", "error", err, "org_id", orgID)
+		return nil, wonderErrors.NewDatabaseError("list", organizationMembersTable, err, isRetryableError(err), map[string]interface{}{
+			"org_id": orgID,
+		})
+	}
+	return members, nil
+}
+
+// UpdateMemberRole changes the role of a member
+func (r *organizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID string, role organization.Role) error {
+	result := r.scoped(ctx).Model(&organization.Member{}).Where("org_id = ? AND user_id = ?", orgID, userID).Update("role", role)
+	if result.Error != nil {
+		r.log.Error(ctx, "organization member update failed", "error", result.Error, "org_id", orgID, "user_id", userID)
+		return wonderErrors.NewDatabaseError("update", organizationMembersTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"org_id":  orgID,
+			"user_id": userID,
+		})
+	}
+	if result.RowsAffected == 0 {
+		return wonderErrors.NewEntityNotFoundError("member", userID)
+	}
+	return nil
+}
+
+// CreateInvitation inserts an invitation into the tenant of ctx
+func (r *organizationRepository) CreateInvitation(ctx context.Context, i *organization.Invitation) error {
+	if i == nil {
+		return wonderErrors.NewRequiredFieldError("invitation", "nil")
+	}
+	i.TenantID = tenant.IDFromContext(ctx)
+
+	if err := database.FromContext(ctx, r.db).Create(i).Error; err != nil {
+		r.log.Error(ctx, "organization invitation create failed", "error", err, "invitation_id", i.ID, "org_id", i.OrgID)
+		return wonderErrors.NewDatabaseError("create", organizationInvitationsTable, err, isRetryableError(err), map[string]interface{}{
+			"invitation_id": i.ID,
+			"org_id":        i.OrgID,
+		})
+	}
+	return nil
+}
+
+// GetInvitation retrieves an invitation by ID
+func (r *organizationRepository) GetInvitation(ctx context.Context, id string) (*organization.Invitation, error) {
+	var i organization.Invitation
+	err := r.scoped(ctx).Where("id = ?", id).First(&i).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "organization invitation lookup failed", "error", err, "invitation_id", id)
+		return nil, wonderErrors.NewDatabaseError("get", organizationInvitationsTable, err, isRetryableError(err), map[string]interface{}{
+			"invitation_id": id,
+		})
+	}
+	return &i, nil
+}
+
+// GetInvitationByTokenHash finds the invitation a token was issued for
+func (r *organizationRepository) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*organization.Invitation, error) {
+	var i organization.Invitation
+	err := r.scoped(ctx).Where("token_hash = ?", tokenHash).First(&i).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "organization invitation lookup failed", "error", err)
+		return nil, wonderErrors.NewDatabaseError("get", organizationInvitationsTable, err, isRetryableError(err))
+	}
+	return &i, nil
+}
+
+// ListInvitations lists an organization's invitations, newest first
+func (r *organizationRepository) ListInvitations(ctx context.Context, orgID string) ([]*organization.Invitation, error) {
+	var invitations []*organization.Invitation
+	err := r.scoped(ctx).Where("org_id = ?", orgID).Order("created_at DESC").Order("id DESC").Find(&invitations).Error
+	if err != nil {
+		r.log.Error(ctx, "organization invitation list failed", "error", err, "org_id", orgID)
+		return nil, wonderErrors.NewDatabaseError("list", organizationInvitationsTable, err, isRetryableError(err), map[string]interface{}{
+			"org_id": orgID,
+		})
+	}
+	return invitations, nil
+}
+
+// UpdateInvitation saves an invitation's token, expiry, acceptance and
+// revocation. Only pending invitations are written; one accepted or
+// revoked since it was read fails with a version mismatch, so a revoke and
+// an accept racing each other cannot both succeed.
+func (r *organizationRepository) UpdateInvitation(ctx context.Context, i *organization.Invitation) error {
+	result := r.scoped(ctx).Model(&organization.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", i.ID).
+		Updates(map[string]interface{}{
+			"token_hash":  i.TokenHash,
+			"expires_at":  i.ExpiresAt,
+			"accepted_at": i.AcceptedAt,
+			"accepted_by": i.AcceptedBy,
+			"revoked_at":  i.RevokedAt,
+		})
+	if err := result.Error; err != nil {
+		r.log.Error(ctx, "organization invitation update failed", "error", err, "invitation_id", i.ID)
+		return wonderErrors.NewDatabaseError("update", organizationInvitationsTable, err, isRetryableError(err), map[string]interface{}{
+			"invitation_id": i.ID,
+		})
+	}
+	if result.RowsAffected == 0 {
+		return wonderErrors.NewVersionMismatchError("invitation", i.ID, "")
+	}
+	return nil
+}
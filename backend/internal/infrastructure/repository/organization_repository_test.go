@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func TestOrganizationRepository(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewOrganizationRepository(db)
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	for _, o := range []*organization.Organization{
+		{ID: "o-1", Name: "Research", CreatedAt: start, UpdatedAt: start},
+		{ID: "o-2", Name: "Design", CreatedAt: start, UpdatedAt: start},
+		{ID: "o-3", Name: "Sales", CreatedAt: start, UpdatedAt: start},
+	} {
+		require.NoError(t, repo.Create(acme, o))
+	}
+	require.NoError(t, repo.AddMember(acme, &organization.Member{OrgID: "o-1", UserID: "u-2", Role: organization.RoleMember, CreatedAt: start}))
+	require.NoError(t, repo.AddMember(acme, &organization.Member{OrgID: "o-1", UserID: "u-1", Role: organization.RoleOwner, CreatedAt: start.Add(time.Minute)}))
+	require.NoError(t, repo.AddMember(acme, &organization.Member{OrgID: "o-2", UserID: "u-1", Role: organization.RoleOwner, CreatedAt: start}))
+
+	err := repo.AddMember(acme, &organization.Member{OrgID: "o-1", UserID: "u-2", Role: organization.RoleMember, CreatedAt: start})
+	var conflict *wonderErrors.ConflictError
+	assert.ErrorAs(t, err, &conflict, "users join once")
+
+	orgs, err := repo.ListForUser(acme, "u-1")
+	require.NoError(t, err)
+	require.Len(t, orgs, 2)
+	assert.Equal(t, []string{"Design", "Research"}, []string{orgs[0].Name, orgs[1].Name})
+
+	members, err := repo.ListMembers(acme, "o-1")
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, "u-1", members[0].UserID, "owner first")
+
+	t.Run("ownership moves in a transaction", func(t *testing.T) {
+		require.NoError(t, database.NewUnitOfWork(db).Do(acme, func(ctx context.Context) error {
+			if err := repo.UpdateMemberRole(ctx, "o-1", "u-2", organization.RoleOwner); err != nil {
+				return err
+			}
+			return repo.UpdateMemberRole(ctx, "o-1", "u-1", organization.RoleMember)
+		}))
+		m, err := repo.GetMember(acme, "o-1", "u-2")
+		require.NoError(t, err)
+		require.NotNil(t, m)
+		assert.True(t, m.Owner())
+
+		var notFound *wonderErrors.EntityNotFoundError
+		assert.ErrorAs(t, repo.UpdateMemberRole(acme, "o-1", "u-9", organization.RoleOwner), &notFound)
+	})
+
+	t.Run("invitations are found by token hash", func(t *testing.T) {
+		require.NoError(t, repo.CreateInvitation(acme, &organization.Invitation{
+			ID: "i-1", OrgID: "o-1", Email: "grace@example.com", Role: organization.RoleMember,
+			TokenHash: organization.HashInviteToken("token"), InvitedBy: "u-1", ExpiresAt: start.Add(time.Hour), CreatedAt: start,
+		}))
+		inv, err := repo.GetInvitationByTokenHash(acme, organization.HashInviteToken("token"))
+		require.NoError(t, err)
+		require.NotNil(t, inv)
+		require.NoError(t, inv.Accept("u-3", start))
+		require.NoError(t, repo.UpdateInvitation(acme, inv))
+
+		inv, err = repo.GetInvitationByTokenHash(acme, organization.HashInviteToken("token"))
+		require.NoError(t, err)
+		assert.True(t, inv.Accepted())
+		assert.Equal(t, "u-3", inv.AcceptedBy)
+	})
+
+	t.Run("renewed and revoked invitations are saved", func(t *testing.T) {
+		require.NoError(t, repo.CreateInvitation(acme, &organization.Invitation{
+			ID: "i-2", OrgID: "o-1", Email: "alan@example.com", Role: organization.RoleMember,
+			TokenHash: organization.HashInviteToken("first"), InvitedBy: "u-1", ExpiresAt: start.Add(time.Hour), CreatedAt: start.Add(time.Minute),
+		}))
+		inv, err := repo.GetInvitation(acme, "i-2")
+		require.NoError(t, err)
+		require.NoError(t, inv.Renew(organization.HashInviteToken("second"), start.Add(2*time.Hour)))
+		require.NoError(t, repo.UpdateInvitation(acme, inv))
+
+		old, err := repo.GetInvitationByTokenHash(acme, organization.HashInviteToken("first"))
+		require.NoError(t, err)
+		assert.Nil(t, old, "renewing replaces the token")
+
+		// An accept read before the revoke lands is refused
+		stale, err := repo.GetInvitation(acme, "i-2")
+		require.NoError(t, err)
+		require.NoError(t, inv.Revoke(start))
+		require.NoError(t, repo.UpdateInvitation(acme, inv))
+		require.NoError(t, stale.Accept("u-3", start))
+		err = repo.UpdateInvitation(acme, stale)
+		var conflict *wonderErrors.ConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, wonderErrors.CodeVersionMismatch, conflict.Code())
+
+		inv, err = repo.GetInvitationByTokenHash(acme, organization.HashInviteToken("second"))
+		require.NoError(t, err)
+		assert.True(t, inv.Revoked())
+		assert.False(t, inv.Accepted())
+		assert.True(t, start.Add(2*time.Hour).Equal(inv.ExpiresAt))
+
+		invitations, err := repo.ListInvitations(acme, "o-1")
+		require.NoError(t, err)
+		require.Len(t, invitations, 2)
+		assert.Equal(t, "i-2", invitations[0].ID, "newest first")
+	})
+
+	t.Run("members are locked for update", func(t *testing.T) {
+		require.NoError(t, database.NewUnitOfWork(db).Do(acme, func(ctx context.Context) error {
+			m, err := repo.GetMemberForUpdate(ctx, "o-1", "u-2")
+			require.NoError(t, err)
+			require.NotNil(t, m)
+			m, err = repo.GetMemberForUpdate(ctx, "o-1", "u-9")
+			require.NoError(t, err)
+			assert.Nil(t, m)
+			return nil
+		}))
+	})
+
+	t.Run("owned organizations are listed and deleted", func(t *testing.T) {
+		owned, err := repo.ListOwnedBy(acme, "u-1")
+		require.NoError(t, err)
+		require.Len(t, owned, 1, "u-1 handed o-1 over")
+		assert.Equal(t, "o-2", owned[0].ID)
+
+		require.NoError(t, repo.Delete(acme, "o-1"))
+		o, err := repo.Get(acme, "o-1")
+		require.NoError(t, err)
+		assert.Nil(t, o)
+		members, err := repo.ListMembers(acme, "o-1")
+		require.NoError(t, err)
+		assert.Empty(t, members)
+		invitations, err := repo.ListInvitations(acme, "o-1")
+		require.NoError(t, err)
+		assert.Empty(t, invitations)
+
+		o, err = repo.Get(acme, "o-2")
+		require.NoError(t, err)
+		assert.NotNil(t, o, "other organizations are kept")
+	})
+
+	t.Run("other tenants are not touched", func(t *testing.T) {
+		o, err := repo.Get(globex, "o-1")
+		require.NoError(t, err)
+		assert.Nil(t, o)
+		orgs, err := repo.ListForUser(globex, "u-1")
+		require.NoError(t, err)
+		assert.Empty(t, orgs)
+		m, err := repo.GetMember(globex, "o-1", "u-1")
+		require.NoError(t, err)
+		assert.Nil(t, m)
+		inv, err := repo.GetInvitationByTokenHash(globex, organization.HashInviteToken("token"))
+		require.NoError(t, err)
+		assert.Nil(t, inv)
+		inv, err = repo.GetInvitation(globex, "i-1")
+		require.NoError(t, err)
+		assert.Nil(t, inv)
+	})
+}
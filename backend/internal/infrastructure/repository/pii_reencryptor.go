@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/fieldcrypt"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// piiRow is the stored form of a user's personal data columns. It has no
+// pii serializer, so values are read and written as they are stored.
+type piiRow struct {
+	ID             string
+	Email          string
+	Name           string
+	EmailIndex     *string
+	CanonicalEmail *string
+}
+
+// TableName pins the users table
+func (piiRow) TableName() string {
+	return "users"
+}
+
+// PIIReencryptor rewrites the personal data of users so every value is
+// encrypted with the active key and the email blind index and canonical
+// email key are current.
+// Plaintext written before encryption was enabled is encrypted too.
+type PIIReencryptor struct {
+	db        *gorm.DB
+	rules     user.EmailRules
+	batchSize int
+	log       logger.Logger
+}
+
+// NewPIIReencryptor creates a re-encryptor indexing emails under rules and
+// reading batchSize users at a time
+func NewPIIReencryptor(db *gorm.DB, rules user.EmailRules, batchSize int) *PIIReencryptor {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if batchSize <= 0 {
+		panic("batch size must be positive")
+	}
+	return &PIIReencryptor{
+		db:        db,
+		rules:     rules,
+		batchSize: batchSize,
+		log:       logger.Get().WithLayer("infrastructure").WithComponent("pii_reencryptor"),
+	}
+}
+
+// Reencrypt walks the users of every tenant and returns how many it
+// rewrote. Running it again after an interruption picks up where the
+// previous run left off, since current rows are skipped.
+func (r *PIIReencryptor) Reencrypt(ctx context.Context) (int, error) {
+	keyring := database.FieldEncryption()
+	if keyring == nil {
+		return 0, wonderErrors.NewBusinessRuleError("encryption_disabled", "personal data encryption is not enabled")
+	}
+
+	rewritten, after := 0, ""
+	for {
+		var rows []piiRow
+		err := database.FromContext(ctx, r.db).Where("id > ?", after).Order("id").Limit(r.batchSize).Find(&rows).Error
+		if err != nil {
+			r.log.Error(ctx, "failed to read users for re-encryption", "error", err, "rewritten", rewritten)
+			return rewritten, wonderErrors.NewDatabaseError("reencrypt", "users", err, isRetryableError(err))
+		}
+		if len(rows) == 0 {
+			r.log.Info(ctx, "personal data re-encrypted", "rewritten", rewritten, "active_key_id", keyring.ActiveKeyID())
+			return rewritten, nil
+		}
+
+		for _, row := range rows {
+			updates, err := reencryptRow(keyring, r.rules, row)
+			if err != nil {
+				return rewritten, fmt.Errorf("user %s: %w", row.ID, err)
+			}
+			if updates == nil {
+				continue
+			}
+
+			// Users changed since they were read were already written with
+			// the active key, so the update only applies to unchanged rows
+			err = database.FromContext(ctx, r.db).Model(&piiRow{}).
+				Where("id = ? AND email = ? AND name = ?", row.ID, row.Email, row.Name).
+				Updates(updates).Error
+			if err != nil {
+				r.log.Error(ctx, "failed to re-encrypt user", "error", err, "user_id", row.ID)
+				return rewritten, wonderErrors.NewDatabaseError("reencrypt", "users", err, isRetryableError(err), map[string]interface{}{
+					"user_id": row.ID,
+				})
+			}
+			rewritten++
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
+
+// reencryptRow returns the columns of row to rewrite, or nil when it is
+// current
+func reencryptRow(keyring *fieldcrypt.Keyring, rules user.EmailRules, row piiRow) (map[string]interface{}, error) {
+	email, err := keyring.Decrypt(row.Email)
+	if err != nil {
+		return nil, err
+	}
+	index := database.EmailIndex(email)
+	// Canonical keys not backfilled yet are left to the EmailCanonicalizer,
+	// which resolves duplicates among them
+	var canonical *string
+	if row.CanonicalEmail != nil {
+		key := canonicalEmailKey(rules, email)
+		canonical = &key
+	}
+	if keyring.Current(row.Email) && keyring.Current(row.Name) && row.EmailIndex != nil && *row.EmailIndex == index &&
+		(canonical == nil || *row.CanonicalEmail == *canonical) {
+		return nil, nil
+	}
+
+	name, err := keyring.Decrypt(row.Name)
+	if err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{"email_index": index}
+	if canonical != nil {
+		updates["canonical_email"] = *canonical
+	}
+	if updates["email"], err = keyring.Encrypt(email); err != nil {
+		return nil, err
+	}
+	if updates["name"], err = keyring.Encrypt(name); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cctw-zed/wonder/internal/domain/preference"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const userPreferencesTable = "user_preferences"
+
+type preferenceRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewPreferenceRepository creates a new preference.Repository implementation
+func NewPreferenceRepository(db *gorm.DB) preference.Repository {
+	return NewPreferenceRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("preference_repository"))
+}
+
+// NewPreferenceRepositoryWithLogger creates a new preference.Repository implementation with explicit logger
+func NewPreferenceRepositoryWithLogger(db *gorm.DB, log logger.Logger) preference.Repository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &preferenceRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// scoped returns the connection for ctx restricted to its tenant
+func (r *preferenceRepository) scoped(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db).Where("tenant_id = ?", tenant.IDFromContext(ctx))
+}
+
+// Get retrieves one of the user's preferences
+func (r *preferenceRepository) Get(ctx context.Context, userID, key string) (*preference.Preference, error) {
+	var p preference.Preference
+	err := r.scoped(ctx).Where("user_id = ? AND preference_key = ?", userID, key).First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "preference lookup failed", "error", err, "user_id", userID, "key", key)
+		return nil, wonderErrors.NewDatabaseError("get", userPreferencesTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": userID,
+			"key":     key,
+		})
+	}
+	return &p, nil
+}
+
+// List retrieves the user's preferences, optionally in one namespace
+func (r *preferenceRepository) List(ctx context.Context, userID, namespace string) ([]*preference.Preference, error) {
+	query := r.scoped(ctx).Where("user_id = ?", userID)
+	if namespace != "" {
+		// Keys in the namespace sort between "namespace." and "namespace/"
+		query = query.Where("preference_key > ? AND preference_key < ?", namespace+".", namespace+"/")
+	}
+
+	var prefs []*preference.Preference
+	if err := query.Order("preference_key").Find(&prefs).Error; err != nil {
+		r.log.Error(ctx, "preference list failed", "error", err, "user_id", userID)
+		return nil, wonderErrors.NewDatabaseError("list", userPreferencesTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id":   userID,
+			"namespace": namespace,
+		})
+	}
+	return prefs, nil
+}
+
+// Set inserts the preference or replaces the value of the existing one
+func (r *preferenceRepository) Set(ctx context.Context, p *preference.Preference) error {
+	if p == nil {
+		return wonderErrors.NewRequiredFieldError("preference", "nil")
+	}
+	p.TenantID = tenant.IDFromContext(ctx)
+	p.UpdatedAt = time.Now()
+
+	err := database.FromContext(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "preference_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(p).Error
+	if err != nil {
+		r.log.Error(ctx, "preference save failed", "error", err, "user_id", p.UserID, "key", p.Key)
+		return wonderErrors.NewDatabaseError("set", userPreferencesTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": p.UserID,
+			"key":     p.Key,
+		})
+	}
+	return nil
+}
+
+// Delete removes one of the user's preferences
+func (r *preferenceRepository) Delete(ctx context.Context, userID, key string) (bool, error) {
+	result := r.scoped(ctx).Where("user_id = ? AND preference_key = ?", userID, key).Delete(&preference.Preference{})
+	if result.Error != nil {
+		r.log.Error(ctx, "preference delete failed", "error", result.Error, "user_id", userID, "key", key)
+		return false, wonderErrors.NewDatabaseError("delete", userPreferencesTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"user_id": userID,
+			"key":     key,
+		})
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountForUpdate locks the user's row with SELECT ... FOR UPDATE on
+// PostgreSQL and MySQL before counting. SQLite has no row locks; it lets
+// one transaction write at a time instead.
+func (r *preferenceRepository) CountForUpdate(ctx context.Context, userID string) (int64, error) {
+	if database.DriverFor(r.db).SkipLocked() {
+		var ids []string
+		err := database.FromContext(ctx, r.db).Table("users").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", userID).Pluck("id", &ids).Error
+		if err != nil {
+			r.log.Error(ctx, "preference owner lock failed", "error", err, "user_id", userID)
+			return 0, wonderErrors.NewDatabaseError("lock", "users", err, isRetryableError(err), map[string]interface{}{
+				"user_id": userID,
+			})
+		}
+	}
+	return r.Count(ctx, userID)
+}
+
+// Count returns how many preferences the user has
+func (r *preferenceRepository) Count(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := r.scoped(ctx).Model(&preference.Preference{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		r.log.Error(ctx, "preference count failed", "error", err, "user_id", userID)
+		return 0, wonderErrors.NewDatabaseError("count", userPreferencesTable, err, isRetryableError(err), map[string]interface{}{
+			"user_id": userID,
+		})
+	}
+	return count, nil
+}
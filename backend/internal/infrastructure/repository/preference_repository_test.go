@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/preference"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+func setPreference(t *testing.T, ctx context.Context, repo preference.Repository, key, value string) {
+	t.Helper()
+	require.NoError(t, repo.Set(ctx, &preference.Preference{UserID: "u-1", Key: key, Value: json.RawMessage(value)}))
+}
+
+func preferenceKeys(prefs []*preference.Preference) []string {
+	keys := make([]string, 0, len(prefs))
+	for _, p := range prefs {
+		keys = append(keys, p.Key)
+	}
+	return keys
+}
+
+func TestPreferenceRepository(t *testing.T) {
+	repo := NewPreferenceRepository(dbtest.Open(t))
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+
+	found, err := repo.Get(acme, "u-1", "editor.theme")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	setPreference(t, acme, repo, "editor.theme", `"dark"`)
+	setPreference(t, acme, repo, "editor.font_size", `14`)
+	setPreference(t, acme, repo, "editors.layout", `"grid"`)
+	setPreference(t, acme, repo, "editor.theme", `"light"`)
+
+	found, err = repo.Get(acme, "u-1", "editor.theme")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.JSONEq(t, `"light"`, string(found.Value), "setting again replaces the value")
+	assert.Equal(t, "acme", found.TenantID)
+
+	all, err := repo.List(acme, "u-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor.font_size", "editor.theme", "editors.layout"}, preferenceKeys(all))
+
+	inNamespace, err := repo.List(acme, "u-1", "editor")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor.font_size", "editor.theme"}, preferenceKeys(inNamespace))
+
+	count, err := repo.Count(acme, "u-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	count, err = repo.CountForUpdate(acme, "u-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	t.Run("other tenants do not see them", func(t *testing.T) {
+		found, err := repo.Get(globex, "u-1", "editor.theme")
+		require.NoError(t, err)
+		assert.Nil(t, found)
+		deleted, err := repo.Delete(globex, "u-1", "editor.theme")
+		require.NoError(t, err)
+		assert.False(t, deleted)
+	})
+
+	deleted, err := repo.Delete(acme, "u-1", "editor.theme")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = repo.Delete(acme, "u-1", "editor.theme")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestCachingPreferenceRepository(t *testing.T) {
+	db := dbtest.Open(t)
+	inner := NewPreferenceRepository(db)
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	repo := NewCachingPreferenceRepository(inner, client, time.Minute)
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	setPreference(t, ctx, repo, "editor.theme", `"dark"`)
+	setPreference(t, ctx, repo, "editor.font_size", `14`)
+
+	found, err := repo.Get(ctx, "u-1", "editor.theme")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.JSONEq(t, `"dark"`, string(found.Value))
+	assert.Equal(t, "u-1", found.UserID)
+
+	// A row removed behind the repository's back is still served until the
+	// entry is invalidated
+	require.NoError(t, db.Where("preference_key = ?", "editor.font_size").Delete(&preference.Preference{}).Error)
+	count, err := repo.Count(ctx, "u-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	listed, err := repo.List(ctx, "u-1", "editor")
+	require.NoError(t, err)
+	assert.Len(t, listed, 2)
+
+	t.Run("writes invalidate", func(t *testing.T) {
+		setPreference(t, ctx, repo, "editor.theme", `"light"`)
+		found, err := repo.Get(ctx, "u-1", "editor.theme")
+		require.NoError(t, err)
+		assert.JSONEq(t, `"light"`, string(found.Value))
+		count, err := repo.Count(ctx, "u-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		deleted, err := repo.Delete(ctx, "u-1", "editor.theme")
+		require.NoError(t, err)
+		assert.True(t, deleted)
+		found, err = repo.Get(ctx, "u-1", "editor.theme")
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("a load racing a delete does not bring it back", func(t *testing.T) {
+		setPreference(t, ctx, repo, "editor.theme", `"dark"`)
+		caching := repo.(*cachingPreferenceRepository)
+		version, err := client.String(ctx, "GET", caching.versionKey(ctx, "u-1"))
+		require.NoError(t, err)
+		loaded, err := inner.List(ctx, "u-1", "")
+		require.NoError(t, err)
+		require.Len(t, loaded, 1)
+
+		// The reader loaded the row before the delete and caches it after
+		deleted, err := repo.Delete(ctx, "u-1", "editor.theme")
+		require.NoError(t, err)
+		require.True(t, deleted)
+		data, err := json.Marshal(loaded)
+		require.NoError(t, err)
+		_, err = client.Do(ctx, "SET", caching.key(ctx, "u-1", version), string(data))
+		require.NoError(t, err)
+
+		found, err := repo.Get(ctx, "u-1", "editor.theme")
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("writes in a transaction invalidate once committed", func(t *testing.T) {
+		setPreference(t, ctx, repo, "editor.theme", `"dark"`)
+		_, err := repo.Get(ctx, "u-1", "editor.theme")
+		require.NoError(t, err)
+
+		err = database.NewUnitOfWork(db).Do(ctx, func(txCtx context.Context) error {
+			setPreference(t, txCtx, repo, "editor.theme", `"light"`)
+			return errors.New("rolled back")
+		})
+		require.Error(t, err)
+		found, err := repo.Get(ctx, "u-1", "editor.theme")
+		require.NoError(t, err)
+		assert.JSONEq(t, `"dark"`, string(found.Value))
+
+		require.NoError(t, database.NewUnitOfWork(db).Do(ctx, func(txCtx context.Context) error {
+			setPreference(t, txCtx, repo, "editor.theme", `"light"`)
+			return nil
+		}))
+		found, err = repo.Get(ctx, "u-1", "editor.theme")
+		require.NoError(t, err)
+		assert.JSONEq(t, `"light"`, string(found.Value))
+	})
+
+	t.Run("falls back to the database without redis", func(t *testing.T) {
+		down := redis.NewClient(redis.Options{Addr: "127.0.0.1:1", Timeout: 50 * time.Millisecond})
+		defer down.Close()
+		fallback := NewCachingPreferenceRepository(inner, down, time.Minute)
+		setPreference(t, ctx, fallback, "editor.theme", `"dark"`)
+		found, err := fallback.Get(ctx, "u-1", "editor.theme")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.JSONEq(t, `"dark"`, string(found.Value))
+	})
+}
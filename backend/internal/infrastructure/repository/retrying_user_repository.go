@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/infrastructure/metrics"
+	"github.com/cctw-zed/wonder/pkg/retry"
+)
+
+// retryingUserRepository retries transient database failures of another
+// UserRepository. Calls inside a transaction are not retried: PostgreSQL
+// aborts the transaction on the first error, so only the unit of work as a
+// whole could be retried. Inserts and deletes are not retried either, as a
+// lost commit acknowledgement would turn into a conflict or not-found error.
+type retryingUserRepository struct {
+	next   user.UserRepository
+	policy retry.Policy
+}
+
+// NewRetryingUserRepository wraps next so that reads and updates are
+// retried according to policy
+func NewRetryingUserRepository(next user.UserRepository, policy retry.Policy) user.UserRepository {
+	if next == nil {
+		panic("user repository cannot be nil")
+	}
+	return &retryingUserRepository{next: next, policy: policy}
+}
+
+// retryUserOp runs fn with retries unless ctx carries a transaction
+func retryUserOp[T any](ctx context.Context, r *retryingUserRepository, operation string, fn func(ctx context.Context) (T, error)) (T, error) {
+	if database.InTransaction(ctx) {
+		return fn(ctx)
+	}
+	return retry.DoValue(ctx, metrics.InstrumentRetry(r.policy, "user_repository."+operation), fn)
+}
+
+func (r *retryingUserRepository) Create(ctx context.Context, u *user.User) error {
+	return r.next.Create(ctx, u)
+}
+
+func (r *retryingUserRepository) CreateBatch(ctx context.Context, users []*user.User) error {
+	return r.next.CreateBatch(ctx, users)
+}
+
+func (r *retryingUserRepository) GetByID(ctx context.Context, id string) (*user.User, error) {
+	return retryUserOp(ctx, r, "get_by_id", func(ctx context.Context) (*user.User, error) {
+		return r.next.GetByID(ctx, id)
+	})
+}
+
+func (r *retryingUserRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*user.User, error) {
+	return retryUserOp(ctx, r, "get_by_ids", func(ctx context.Context) (map[string]*user.User, error) {
+		return r.next.GetByIDs(ctx, ids)
+	})
+}
+
+func (r *retryingUserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	return retryUserOp(ctx, r, "get_by_email", func(ctx context.Context) (*user.User, error) {
+		return r.next.GetByEmail(ctx, email)
+	})
+}
+
+func (r *retryingUserRepository) GetByHandle(ctx context.Context, handle string) (*user.User, error) {
+	return retryUserOp(ctx, r, "get_by_handle", func(ctx context.Context) (*user.User, error) {
+		return r.next.GetByHandle(ctx, handle)
+	})
+}
+
+func (r *retryingUserRepository) Update(ctx context.Context, u *user.User) error {
+	_, err := retryUserOp(ctx, r, "update", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.Update(ctx, u)
+	})
+	return err
+}
+
+func (r *retryingUserRepository) UpdateIfUnmodified(ctx context.Context, u *user.User, unmodifiedSince time.Time) error {
+	_, err := retryUserOp(ctx, r, "update", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.UpdateIfUnmodified(ctx, u, unmodifiedSince)
+	})
+	return err
+}
+
+func (r *retryingUserRepository) UpdateAvatar(ctx context.Context, u *user.User, previous string) error {
+	_, err := retryUserOp(ctx, r, "update_avatar", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.next.UpdateAvatar(ctx, u, previous)
+	})
+	return err
+}
+
+func (r *retryingUserRepository) Delete(ctx context.Context, id string) error {
+	return r.next.Delete(ctx, id)
+}
+
+func (r *retryingUserRepository) List(ctx context.Context, req *user.ListUsersRequest) (*user.ListUsersResponse, error) {
+	return retryUserOp(ctx, r, "list", func(ctx context.Context) (*user.ListUsersResponse, error) {
+		return r.next.List(ctx, req)
+	})
+}
+
+func (r *retryingUserRepository) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	return retryUserOp(ctx, r, "existing_emails", func(ctx context.Context) (map[string]bool, error) {
+		return r.next.ExistingEmails(ctx, emails)
+	})
+}
+
+func (r *retryingUserRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*user.User, error) {
+	return retryUserOp(ctx, r, "list_due_for_deletion", func(ctx context.Context) ([]*user.User, error) {
+		return r.next.ListDueForDeletion(ctx, now, limit)
+	})
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/retry"
+)
+
+func TestRetryingUserRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	inner := mocks.NewMockUserRepository(ctrl)
+	policy := retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	repo := NewRetryingUserRepository(inner, policy)
+	ctx := context.Background()
+
+	transient := wonderErrors.NewDatabaseError("get_by_id", "users", fmt.Errorf("connection reset"), true)
+	found := &user.User{ID: "u-1"}
+
+	t.Run("reads retry transient failures", func(t *testing.T) {
+		gomock.InOrder(
+			inner.EXPECT().GetByID(gomock.Any(), "u-1").Return(nil, transient),
+			inner.EXPECT().GetByID(gomock.Any(), "u-1").Return(found, nil),
+		)
+		got, err := repo.GetByID(ctx, "u-1")
+		require.NoError(t, err)
+		assert.Equal(t, found, got)
+	})
+
+	t.Run("permanent failures are returned at once", func(t *testing.T) {
+		inner.EXPECT().GetByEmail(gomock.Any(), "a@example.com").
+			Return(nil, wonderErrors.NewDatabaseError("get_by_email", "users", fmt.Errorf("syntax error"), false)).Times(1)
+		_, err := repo.GetByEmail(ctx, "a@example.com")
+		assert.Error(t, err)
+	})
+
+	t.Run("inserts are not retried", func(t *testing.T) {
+		inner.EXPECT().Create(gomock.Any(), found).Return(transient).Times(1)
+		assert.Error(t, repo.Create(ctx, found))
+	})
+
+	t.Run("calls inside a transaction are not retried", func(t *testing.T) {
+		db := dbtest.Open(t)
+		inner.EXPECT().Update(gomock.Any(), found).Return(transient).Times(1)
+		err := database.NewUnitOfWork(db).Do(ctx, func(ctx context.Context) error {
+			return repo.Update(ctx, found)
+		})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/stats"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+const statsCacheKeyPrefix = "wonder:stats:"
+
+// RedisStatsCache keeps computed stats in Redis as JSON so every instance
+// serves the same snapshot until it expires
+type RedisStatsCache struct {
+	client *redis.Client
+}
+
+var _ stats.Cache = (*RedisStatsCache)(nil)
+
+func NewRedisStatsCache(client *redis.Client) *RedisStatsCache {
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	return &RedisStatsCache{client: client}
+}
+
+// Get implements stats.Cache
+func (c *RedisStatsCache) Get(ctx context.Context, key string) (*stats.Stats, error) {
+	raw, err := c.client.String(ctx, "GET", statsCacheKeyPrefix+key)
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s stats.Stats
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Set implements stats.Cache
+func (c *RedisStatsCache) Set(ctx context.Context, key string, s *stats.Stats, ttl time.Duration) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	ms := ttl.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	_, err = c.client.String(ctx, "SET", statsCacheKeyPrefix+key, string(data), "PX", strconv.FormatInt(ms, 10))
+	return err
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/stats"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+type statsRepository struct {
+	db       *gorm.DB
+	log      logger.Logger
+	resolver *database.Resolver
+}
+
+// NewStatsRepository creates a new stats.Repository implementation. The
+// aggregates only read, so they run on the resolver's replicas when one
+// is given.
+func NewStatsRepository(db *gorm.DB, resolver *database.Resolver) stats.Repository {
+	return NewStatsRepositoryWithLogger(db, resolver, logger.Get().WithLayer("infrastructure").WithComponent("stats_repository"))
+}
+
+// NewStatsRepositoryWithLogger creates a new stats.Repository implementation with explicit logger
+func NewStatsRepositoryWithLogger(db *gorm.DB, resolver *database.Resolver, log logger.Logger) stats.Repository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &statsRepository{
+		db:       db,
+		log:      log,
+		resolver: resolver,
+	}
+}
+
+func (r *statsRepository) reader(ctx context.Context) *gorm.DB {
+	if r.resolver == nil {
+		return database.FromContext(ctx, r.db)
+	}
+	return r.resolver.Reader(ctx)
+}
+
+// CountUsersByRole implements stats.Repository
+func (r *statsRepository) CountUsersByRole(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Role  string
+		Count int64
+	}
+	err := r.reader(ctx).Model(&user.User{}).Scopes(tenantScope(ctx)).
+		Select("role, COUNT(*) AS count").Group("role").Scan(&rows).Error
+	if err != nil {
+		r.log.Error(ctx, "failed to count users by role", "error", err)
+		return nil, wonderErrors.NewDatabaseError("count", "users", err, isRetryableError(err), nil)
+	}
+
+	byRole := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		byRole[row.Role] = row.Count
+	}
+	return byRole, nil
+}
+
+// SignupsPerDay implements stats.Repository
+func (r *statsRepository) SignupsPerDay(ctx context.Context, from time.Time) ([]stats.DailyCount, error) {
+	query := r.reader(ctx).Model(&user.User{}).Scopes(tenantScope(ctx)).Where("created_at >= ?", from)
+	counts, err := countPerDay(query)
+	if err != nil {
+		r.log.Error(ctx, "failed to count signups per day", "error", err)
+		return nil, wonderErrors.NewDatabaseError("count", "users", err, isRetryableError(err), nil)
+	}
+	return counts, nil
+}
+
+// DeletionsPerDay implements stats.Repository
+func (r *statsRepository) DeletionsPerDay(ctx context.Context, from time.Time) ([]stats.DailyCount, error) {
+	query := r.reader(ctx).Model(&audit.Entry{}).
+		Where("action = ? AND entity_type = ? AND outcome = ?", audit.ActionDelete, "user", audit.OutcomeSuccess).
+		Where("created_at >= ?", from)
+	counts, err := countPerDay(query)
+	if err != nil {
+		r.log.Error(ctx, "failed to count deletions per day", "error", err)
+		return nil, wonderErrors.NewDatabaseError("count", "audit_logs", err, isRetryableError(err), nil)
+	}
+	return counts, nil
+}
+
+// CountLogins implements stats.Repository
+func (r *statsRepository) CountLogins(ctx context.Context, from time.Time) (int64, int64, error) {
+	var row struct {
+		Logins int64
+		Users  int64
+	}
+	err := r.reader(ctx).Model(&audit.Entry{}).
+		Select("COUNT(*) AS logins, COUNT(DISTINCT actor_id) AS users").
+		Where("action = ? AND outcome = ?", audit.ActionLogin, audit.OutcomeSuccess).
+		Where("created_at >= ?", from).
+		Scan(&row).Error
+	if err != nil {
+		r.log.Error(ctx, "failed to count logins", "error", err)
+		return 0, 0, wonderErrors.NewDatabaseError("count", "audit_logs", err, isRetryableError(err), nil)
+	}
+	return row.Logins, row.Users, nil
+}
+
+// countPerDay groups the rows of query by the UTC date of created_at.
+// DATE() works on both Postgres and SQLite; the driver returns the day as a
+// time or a string depending on the database.
+func countPerDay(query *gorm.DB) ([]stats.DailyCount, error) {
+	rows, err := query.Select("DATE(created_at) AS day, COUNT(*) AS count").
+		Group("DATE(created_at)").Order("day").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []stats.DailyCount
+	for rows.Next() {
+		var day sql.NullString
+		var count int64
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, err
+		}
+		if len(day.String) < len(stats.DateLayout) {
+			return nil, fmt.Errorf("unexpected day %q", day.String)
+		}
+		counts = append(counts, stats.DailyCount{Date: day.String[:len(stats.DateLayout)], Count: count})
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/stats"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+func TestStatsRepository(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewStatsRepository(db, nil)
+	ctx := context.Background()
+
+	day := func(d, hour int) time.Time {
+		return time.Date(2026, 3, d, hour, 0, 0, 0, time.UTC)
+	}
+	users := []*user.User{
+		{ID: "u-1", TenantID: tenant.DefaultID, Email: "a@example.com", Role: user.RoleAdmin, CreatedAt: day(1, 9)},
+		{ID: "u-2", TenantID: tenant.DefaultID, Email: "b@example.com", Role: user.RoleUser, CreatedAt: day(3, 1)},
+		{ID: "u-3", TenantID: tenant.DefaultID, Email: "c@example.com", Role: user.RoleUser, CreatedAt: day(3, 23)},
+		{ID: "u-4", TenantID: "acme", Email: "d@example.com", Role: user.RoleUser, CreatedAt: day(3, 12)},
+	}
+	for _, u := range users {
+		u.Name, u.PasswordHash, u.UpdatedAt = "User", "hash", u.CreatedAt
+	}
+	require.NoError(t, db.Create(users).Error)
+
+	entries := []*audit.Entry{
+		{Action: audit.ActionDelete, Outcome: audit.OutcomeSuccess, CreatedAt: day(2, 10)},
+		{Action: audit.ActionDelete, Outcome: audit.OutcomeSuccess, CreatedAt: day(2, 11)},
+		{Action: audit.ActionDelete, Outcome: audit.OutcomeFailure, CreatedAt: day(2, 12)},
+		{Action: audit.ActionLogin, Outcome: audit.OutcomeSuccess, ActorID: "u-1", CreatedAt: day(3, 8)},
+		{Action: audit.ActionLogin, Outcome: audit.OutcomeSuccess, ActorID: "u-1", CreatedAt: day(3, 9)},
+		{Action: audit.ActionLogin, Outcome: audit.OutcomeSuccess, ActorID: "u-2", CreatedAt: day(3, 10)},
+		{Action: audit.ActionLogin, Outcome: audit.OutcomeFailure, ActorID: "u-3", CreatedAt: day(3, 10)},
+		{Action: audit.ActionLogin, Outcome: audit.OutcomeSuccess, ActorID: "u-3", CreatedAt: day(1, 10)},
+	}
+	for i, e := range entries {
+		e.ID, e.EntityType = fmt.Sprintf("a-%d", i), "user"
+	}
+	require.NoError(t, db.Create(entries).Error)
+
+	byRole, err := repo.CountUsersByRole(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{user.RoleAdmin: 1, user.RoleUser: 2}, byRole)
+
+	signups, err := repo.SignupsPerDay(ctx, day(2, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []stats.DailyCount{{Date: "2026-03-03", Count: 2}}, signups)
+
+	// Counts follow the tenant of the context
+	signups, err = repo.SignupsPerDay(tenant.WithID(ctx, "acme"), day(1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []stats.DailyCount{{Date: "2026-03-03", Count: 1}}, signups)
+
+	deletions, err := repo.DeletionsPerDay(ctx, day(1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []stats.DailyCount{{Date: "2026-03-02", Count: 2}}, deletions)
+
+	logins, loginUsers, err := repo.CountLogins(ctx, day(3, 0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), logins)
+	assert.Equal(t, int64(2), loginUsers)
+}
+
+func TestRedisStatsCache(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	cache := NewRedisStatsCache(client)
+	ctx := context.Background()
+
+	got, err := cache.Get(ctx, "default:30")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	want := &stats.Stats{Days: 30, TotalUsers: 3, UsersByRole: map[string]int64{"user": 3}}
+	require.NoError(t, cache.Set(ctx, "default:30", want, time.Minute))
+
+	got, err = cache.Get(ctx, "default:30")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
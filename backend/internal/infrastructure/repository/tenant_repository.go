@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+type tenantRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewTenantRepository creates a new tenant.Repository implementation
+func NewTenantRepository(db *gorm.DB) tenant.Repository {
+	return NewTenantRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("tenant_repository"))
+}
+
+// NewTenantRepositoryWithLogger creates a new tenant.Repository implementation with explicit logger
+func NewTenantRepositoryWithLogger(db *gorm.DB, log logger.Logger) tenant.Repository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &tenantRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *tenantRepository) conn(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db)
+}
+
+// Create inserts a tenant. A taken slug is reported as a conflict.
+func (r *tenantRepository) Create(ctx context.Context, t *tenant.Tenant) error {
+	if t == nil {
+		return wonderErrors.NewRequiredFieldError("tenant", "nil")
+	}
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+	if t.UpdatedAt.IsZero() {
+		t.UpdatedAt = now
+	}
+
+	if err := r.conn(ctx).Create(t).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			return wonderErrors.NewConflictError("tenant", "slug already exists", "", map[string]interface{}{
+				"slug": t.Slug,
+			})
+		}
+		r.log.Error(ctx, "tenant create failed", "error", err, "slug", t.Slug)
+		return wonderErrors.NewDatabaseError("create", "tenants", err, isRetryableError(err), map[string]interface{}{
+			"tenant_id": t.ID,
+		})
+	}
+
+	r.log.Info(ctx, "tenant created", "tenant_id", t.ID, "slug", t.Slug)
+	return nil
+}
+
+// GetByID retrieves a tenant by ID
+func (r *tenantRepository) GetByID(ctx context.Context, id string) (*tenant.Tenant, error) {
+	if id == "" {
+		return nil, wonderErrors.NewRequiredFieldError("id", id)
+	}
+	return r.first(ctx, "get_by_id", "id = ?", id)
+}
+
+// GetBySlug retrieves a tenant by slug
+func (r *tenantRepository) GetBySlug(ctx context.Context, slug string) (*tenant.Tenant, error) {
+	if slug == "" {
+		return nil, wonderErrors.NewRequiredFieldError("slug", slug)
+	}
+	return r.first(ctx, "get_by_slug", "slug = ?", slug)
+}
+
+func (r *tenantRepository) first(ctx context.Context, operation, query string, arg string) (*tenant.Tenant, error) {
+	var t tenant.Tenant
+	err := r.conn(ctx).Where(query, arg).First(&t).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, wonderErrors.NewDatabaseError(operation, "tenants", err, isRetryableError(err))
+	}
+	return &t, nil
+}
+
+// List returns all tenants ordered by slug
+func (r *tenantRepository) List(ctx context.Context) ([]*tenant.Tenant, error) {
+	var tenants []*tenant.Tenant
+	if err := r.conn(ctx).Order("slug").Find(&tenants).Error; err != nil {
+		r.log.Error(ctx, "failed to list tenants", "error", err)
+		return nil, wonderErrors.NewDatabaseError("list", "tenants", err, isRetryableError(err))
+	}
+	return tenants, nil
+}
+
+// Delete removes a tenant by ID
+func (r *tenantRepository) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return wonderErrors.NewRequiredFieldError("id", id)
+	}
+
+	result := r.conn(ctx).Delete(&tenant.Tenant{}, "id = ?", id)
+	if result.Error != nil {
+		return wonderErrors.NewDatabaseError("delete", "tenants", result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"tenant_id": id,
+		})
+	}
+	if result.RowsAffected == 0 {
+		return wonderErrors.NewEntityNotFoundError("tenant", id)
+	}
+
+	r.log.Info(ctx, "tenant deleted", "tenant_id", id)
+	return nil
+}
+
+// HasUsers reports whether any user belongs to the tenant
+func (r *tenantRepository) HasUsers(ctx context.Context, id string) (bool, error) {
+	var count int64
+	err := r.conn(ctx).Model(&user.User{}).Where("tenant_id = ?", id).Count(&count).Error
+	if err != nil {
+		return false, wonderErrors.NewDatabaseError("has_users", "users", err, isRetryableError(err), map[string]interface{}{
+			"tenant_id": id,
+		})
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUserRepository_TenantScoping(t *testing.T) {
+	logger.Initialize()
+	repo := NewUserRepository(dbtest.Open(t))
+
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+
+	newUser := func(id string) *user.User {
+		return &user.User{ID: id, Email: "same@example.com", Name: "Same Email", PasswordHash: "hash", Role: user.RoleUser}
+	}
+
+	// Emails are unique per tenant, not globally
+	acmeUser := newUser("acme-1")
+	require.NoError(t, repo.Create(acme, acmeUser))
+	assert.Equal(t, "acme", acmeUser.TenantID)
+	require.NoError(t, repo.Create(globex, newUser("globex-1")))
+	assert.Error(t, repo.Create(acme, newUser("acme-2")))
+
+	found, err := repo.GetByEmail(globex, "same@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "globex-1", found.ID)
+
+	// Another tenant's users are invisible
+	found, err = repo.GetByID(globex, "acme-1")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	list, err := repo.List(acme, &user.ListUsersRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), list.Total)
+
+	existing, err := repo.ExistingEmails(context.Background(), []string{"same@example.com"})
+	require.NoError(t, err)
+	assert.Empty(t, existing, "the default tenant has no users")
+
+	// ... and cannot be changed or removed
+	acmeUser.Name = "Hijacked"
+	assert.Error(t, repo.Update(globex, acmeUser))
+	assert.Error(t, repo.Delete(globex, "acme-1"))
+
+	found, err = repo.GetByID(acme, "acme-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Same Email", found.Name)
+}
+
+func TestTenantRepository(t *testing.T) {
+	logger.Initialize()
+	db := dbtest.Open(t)
+	repo := NewTenantRepository(db)
+	ctx := context.Background()
+
+	acme := &tenant.Tenant{ID: "t-1", Slug: "acme", Name: "Acme"}
+	require.NoError(t, repo.Create(ctx, acme))
+
+	err := repo.Create(ctx, &tenant.Tenant{ID: "t-2", Slug: "acme", Name: "Other Acme"})
+	var conflict *wonderErrors.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+
+	found, err := repo.GetBySlug(ctx, "acme")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "t-1", found.ID)
+
+	found, err = repo.GetByID(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	hasUsers, err := repo.HasUsers(ctx, "t-1")
+	require.NoError(t, err)
+	assert.False(t, hasUsers)
+	require.NoError(t, NewUserRepository(db).Create(tenant.WithID(ctx, "t-1"),
+		&user.User{ID: "u-1", Email: "a@example.com", Name: "Member", PasswordHash: "hash"}))
+	hasUsers, err = repo.HasUsers(ctx, "t-1")
+	require.NoError(t, err)
+	assert.True(t, hasUsers)
+
+	tenants, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, tenants, 2, "the migrations create the default tenant")
+	slugs := []string{tenants[0].Slug, tenants[1].Slug}
+	assert.Contains(t, slugs, "acme")
+
+	require.NoError(t, repo.Delete(ctx, "t-1"))
+	assert.Error(t, repo.Delete(ctx, "t-1"))
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -9,23 +10,59 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
 	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
 	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/pagination"
 )
 
 type userRepository struct {
-	db  *gorm.DB
-	log logger.Logger
+	db         *gorm.DB
+	log        logger.Logger
+	resolver   *database.Resolver
+	emailRules user.EmailRules
+	cursors    *pagination.Codec
+}
+
+// UserRepositoryOption configures optional user repository behaviour
+type UserRepositoryOption func(*userRepository)
+
+// WithReadReplicas sends GetByID, GetByEmail, GetByHandle and List to the
+// resolver's read replicas; writes always go to db
+func WithReadReplicas(resolver *database.Resolver) UserRepositoryOption {
+	return func(r *userRepository) {
+		r.resolver = resolver
+	}
+}
+
+// WithEmailRules matches and stores emails in their canonical form under
+// rules instead of the zero EmailRules
+func WithEmailRules(rules user.EmailRules) UserRepositoryOption {
+	return func(r *userRepository) {
+		r.emailRules = rules
+	}
+}
+
+// WithCursorCodec signs list cursors with codec instead of a random key,
+// so every server sharing its key accepts them
+func WithCursorCodec(codec *pagination.Codec) UserRepositoryOption {
+	return func(r *userRepository) {
+		if codec != nil {
+			r.cursors = codec
+		}
+	}
 }
 
 // NewUserRepository creates a new UserRepository implementation
-func NewUserRepository(db *gorm.DB) user.UserRepository {
-	return NewUserRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("user_repository"))
+func NewUserRepository(db *gorm.DB, opts ...UserRepositoryOption) user.UserRepository {
+	return NewUserRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("user_repository"), opts...)
 }
 
 // NewUserRepositoryWithLogger creates a new UserRepository implementation with explicit logger
-func NewUserRepositoryWithLogger(db *gorm.DB, log logger.Logger) user.UserRepository {
+func NewUserRepositoryWithLogger(db *gorm.DB, log logger.Logger, opts ...UserRepositoryOption) user.UserRepository {
 	if db == nil {
 		panic("database connection cannot be nil")
 	}
@@ -33,9 +70,35 @@ func NewUserRepositoryWithLogger(db *gorm.DB, log logger.Logger) user.UserReposi
 		panic("logger cannot be nil")
 	}
 
-	return &userRepository{
-		db:  db,
-		log: log,
+	r := &userRepository{
+		db:      db,
+		log:     log,
+		cursors: pagination.NewRandomCodec(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// conn returns the active transaction for ctx, or the base connection
+func (r *userRepository) conn(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db)
+}
+
+// reader returns the connection for a read-only query, which may be a replica
+func (r *userRepository) reader(ctx context.Context) *gorm.DB {
+	if r.resolver == nil {
+		return r.conn(ctx)
+	}
+	return r.resolver.Reader(ctx)
+}
+
+// tenantScope restricts a query to the users of the tenant ctx is scoped to
+func tenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("tenant_id = ?", tenant.IDFromContext(ctx))
 	}
 }
 
@@ -57,6 +120,10 @@ func (r *userRepository) Create(ctx context.Context, u *user.User) error {
 		return err
 	}
 
+	if u.TenantID == "" {
+		u.TenantID = tenant.IDFromContext(ctx)
+	}
+
 	// Set timestamps if not already set
 	now := time.Now()
 	if u.CreatedAt.IsZero() {
@@ -65,9 +132,14 @@ func (r *userRepository) Create(ctx context.Context, u *user.User) error {
 	if u.UpdatedAt.IsZero() {
 		u.UpdatedAt = now
 	}
+	// Keep only the precision the database stores, so the user's Version
+	// is the same before and after a round trip
+	u.CreatedAt = u.CreatedAt.Truncate(time.Microsecond)
+	u.UpdatedAt = u.UpdatedAt.Truncate(time.Microsecond)
+	r.indexEmail(u)
 
 	// Create user in database
-	if err := r.db.WithContext(ctx).Create(u).Error; err != nil {
+	if err := r.conn(ctx).Create(u).Error; err != nil {
 		if isDuplicateKeyError(err) {
 			r.log.Warn(ctx, "duplicate email", "email", u.Email)
 			return wonderErrors.NewConflictError("user", "email already exists", "", map[string]interface{}{
@@ -92,7 +164,7 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*user.User, er
 	}
 
 	var u user.User
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&u).Error
+	err := r.reader(ctx).Scopes(tenantScope(ctx)).Where("id = ?", id).First(&u).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // Return nil for not found (application layer will handle)
@@ -105,6 +177,25 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*user.User, er
 	return &u, nil
 }
 
+// GetByIDs retrieves the users of ids in one query
+func (r *userRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*user.User, error) {
+	found := make(map[string]*user.User, len(ids))
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	var users []*user.User
+	if err := r.reader(ctx).Scopes(tenantScope(ctx)).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, wonderErrors.NewDatabaseError("get_by_ids", "users", err, isRetryableError(err), map[string]interface{}{
+			"count": len(ids),
+		})
+	}
+	for _, u := range users {
+		found[u.ID] = u
+	}
+	return found, nil
+}
+
 // GetByEmail retrieves a user by email
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
 	if email == "" {
@@ -115,23 +206,92 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*user.Us
 		r.log.Debug(ctx, "querying user by email", "email", email)
 	}
 
-	var u user.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&u).Error
+	query := r.reader(ctx).Scopes(tenantScope(ctx)).Where(emailMatch(r.reader(ctx), []string{email}))
+
+	// Candidates are read back and compared under the current rules, since
+	// users saved under other rules may be stored by another form
+	var candidates []*user.User
+	err := query.Order("created_at").Order("id").Find(&candidates).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil // Return nil for not found (application layer will handle)
-		}
 		r.log.Error(ctx, "email query failed", "error", err, "email", email)
 		return nil, wonderErrors.NewDatabaseError("get_by_email", "users", err, isRetryableError(err), map[string]interface{}{
 			"email": email,
 		})
 	}
 
+	canonical := r.emailRules.Canonicalize(email)
+	for _, u := range candidates {
+		if r.emailRules.Canonicalize(u.Email) == canonical {
+			return u, nil
+		}
+	}
+	return nil, nil // Return nil for not found (application layer will handle)
+}
+
+// GetByHandle retrieves a user by normalized handle
+func (r *userRepository) GetByHandle(ctx context.Context, handle string) (*user.User, error) {
+	if handle == "" {
+		return nil, wonderErrors.NewRequiredFieldError("handle", handle)
+	}
+
+	if r.log.DebugEnabled() {
+		r.log.Debug(ctx, "querying user by handle", "handle", handle)
+	}
+
+	var u user.User
+	err := r.reader(ctx).Scopes(tenantScope(ctx)).Where("handle = ?", handle).First(&u).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		r.log.Error(ctx, "handle query failed", "error", err, "handle", handle)
+		return nil, wonderErrors.NewDatabaseError("get_by_handle", "users", err, isRetryableError(err), map[string]interface{}{
+			"handle": handle,
+		})
+	}
+
 	return &u, nil
 }
 
 // Update updates an existing user
 func (r *userRepository) Update(ctx context.Context, u *user.User) error {
+	return r.update(ctx, u, nil)
+}
+
+// UpdateIfUnmodified updates u in one conditional statement, so that of two
+// writers that read the same version only the first succeeds
+func (r *userRepository) UpdateIfUnmodified(ctx context.Context, u *user.User, unmodifiedSince time.Time) error {
+	return r.update(ctx, u, &unmodifiedSince)
+}
+
+// UpdateAvatar writes the avatar key without touching the rest of the row,
+// so it cannot overwrite a concurrent profile update
+func (r *userRepository) UpdateAvatar(ctx context.Context, u *user.User, previous string) error {
+	if u == nil || u.ID == "" {
+		return fmt.Errorf("user ID cannot be empty")
+	}
+
+	updatedAt := time.Now().Truncate(time.Microsecond)
+	result := r.conn(ctx).Model(&user.User{}).Scopes(tenantScope(ctx)).
+		Where("id = ? AND avatar_key = ?", u.ID, previous).
+		Updates(map[string]interface{}{"avatar_key": u.AvatarKey, "updated_at": updatedAt})
+	if result.Error != nil {
+		r.log.Error(ctx, "failed to update user avatar", "error", result.Error, "user_id", u.ID)
+		return wonderErrors.NewDatabaseError("update_avatar", "users", result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"user_id": u.ID,
+		})
+	}
+	if result.RowsAffected == 0 {
+		r.log.Warn(ctx, "user avatar changed since it was read", "user_id", u.ID)
+		return wonderErrors.NewVersionMismatchError("user", u.ID, "")
+	}
+	u.UpdatedAt = updatedAt
+	return nil
+}
+
+// update saves u, only while its stored updated_at is unmodifiedSince when
+// that is set
+func (r *userRepository) update(ctx context.Context, u *user.User, unmodifiedSince *time.Time) error {
 	if u == nil {
 		return fmt.Errorf("user cannot be nil")
 	}
@@ -145,21 +305,47 @@ func (r *userRepository) Update(ctx context.Context, u *user.User) error {
 		return fmt.Errorf("user validation failed: %w", err)
 	}
 
-	// Update timestamp
-	u.UpdatedAt = time.Now()
+	// Users never move between tenants
+	if u.TenantID == "" {
+		u.TenantID = tenant.IDFromContext(ctx)
+	}
+	if u.TenantID != tenant.IDFromContext(ctx) {
+		return fmt.Errorf("user with ID %s not found", u.ID)
+	}
+
+	// Update timestamp, at the precision the database stores
+	previous := u.UpdatedAt
+	u.UpdatedAt = time.Now().Truncate(time.Microsecond)
+	r.indexEmail(u)
 
-	// Update user in database
-	result := r.db.WithContext(ctx).Save(u)
+	// Update user in database. Selecting all columns explicitly keeps Save
+	// from inserting when no row of this tenant matches. The avatar is
+	// only written by UpdateAvatar, so a stale copy cannot bring back a
+	// replaced one.
+	query := r.conn(ctx).Scopes(tenantScope(ctx))
+	if unmodifiedSince != nil {
+		query = query.Where("updated_at = ?", *unmodifiedSince)
+	}
+	result := query.Select("*").Omit("avatar_key").Save(u)
 	if result.Error != nil {
+		u.UpdatedAt = previous
 		// Check for unique constraint violation
 		if isDuplicateKeyError(result.Error) {
+			if u.Handle != nil {
+				return fmt.Errorf("user with email %s or handle %s already exists", u.Email, *u.Handle)
+			}
 			return fmt.Errorf("user with email %s already exists", u.Email)
 		}
 		return fmt.Errorf("failed to update user: %w", result.Error)
 	}
 
-	// Check if user exists
+	// Check if user exists, or whether it was changed since it was read
 	if result.RowsAffected == 0 {
+		u.UpdatedAt = previous
+		if unmodifiedSince != nil {
+			r.log.Warn(ctx, "user changed since it was read", "user_id", u.ID)
+			return wonderErrors.NewVersionMismatchError("user", u.ID, "")
+		}
 		return fmt.Errorf("user with ID %s not found", u.ID)
 	}
 
@@ -172,7 +358,7 @@ func (r *userRepository) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("user ID cannot be empty")
 	}
 
-	result := r.db.WithContext(ctx).Delete(&user.User{}, "id = ?", id)
+	result := r.conn(ctx).Scopes(tenantScope(ctx)).Delete(&user.User{}, "id = ?", id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete user: %w", result.Error)
 	}
@@ -185,6 +371,51 @@ func (r *userRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// listSortColumns maps ListUsersRequest.SortBy values to columns; empty
+// selects the default order
+var listSortColumns = map[string]string{
+	"":                   "created_at",
+	user.SortByCreatedAt: "created_at",
+	user.SortByUpdatedAt: "updated_at",
+	user.SortByName:      "name",
+	user.SortByEmail:     "email",
+}
+
+// cursorSort is the Sort recorded in cursors for column
+func cursorSort(column string) string {
+	if column == "created_at" {
+		return ""
+	}
+	return column
+}
+
+// cursorKey returns the value of column for u as cursors carry it. Names
+// and email addresses are personal data, which cursors encrypt.
+func cursorKey(column string, u *user.User) (key string, private bool) {
+	switch column {
+	case "updated_at":
+		return u.UpdatedAt.UTC().Format(time.RFC3339Nano), false
+	case "name":
+		return u.Name, true
+	case "email":
+		return u.Email, true
+	}
+	return "", false
+}
+
+// cursorPosition returns the value of column the page after c starts
+// after; false if c does not carry it
+func cursorPosition(column string, c *pagination.Cursor) (interface{}, bool) {
+	switch column {
+	case "created_at":
+		return c.CreatedAt, true
+	case "updated_at":
+		at, err := time.Parse(time.RFC3339Nano, c.Key)
+		return at, err == nil
+	}
+	return c.Key, c.Key != ""
+}
+
 // List retrieves users with pagination and filtering
 func (r *userRepository) List(ctx context.Context, req *user.ListUsersRequest) (*user.ListUsersResponse, error) {
 	if req == nil {
@@ -206,35 +437,125 @@ func (r *userRepository) List(ctx context.Context, req *user.ListUsersRequest) (
 
 	offset := (page - 1) * pageSize
 
+	// Sort columns are interpolated into SQL, so only known ones are accepted
+	column, ok := listSortColumns[req.SortBy]
+	if !ok {
+		return nil, wonderErrors.NewInvalidValueError("sort_by", req.SortBy, "unsupported sort column")
+	}
+	var ascending bool
+	switch req.SortOrder {
+	case user.SortAsc:
+		ascending = true
+	case "", user.SortDesc:
+	default:
+		return nil, wonderErrors.NewInvalidValueError("sort_order", req.SortOrder, "must be asc or desc")
+	}
+
+	// Encrypted columns can neither be searched for substrings nor sorted
+	encrypted := database.FieldEncryption() != nil
+	if encrypted && (column == "name" || column == "email") {
+		return nil, wonderErrors.NewInvalidValueError("sort_by", req.SortBy, "sorting by name or email is unavailable while personal data is encrypted")
+	}
+	if encrypted && req.Name != "" {
+		return nil, wonderErrors.NewInvalidValueError("name", req.Name, "name filters are unavailable while personal data is encrypted")
+	}
+
+	order := user.SortDesc
+	if ascending {
+		order = user.SortAsc
+	}
+	var cursor *pagination.Cursor
+	if req.Cursor != "" {
+		c, err := r.cursors.Decode(req.Cursor)
+		if err != nil {
+			return nil, wonderErrors.NewInvalidFormatError("cursor", req.Cursor, "next_cursor from a previous page")
+		}
+		if c.Sort != cursorSort(column) || c.Order != order {
+			return nil, wonderErrors.NewInvalidValueError("cursor", req.Cursor, "cursor belongs to a different sort_by or sort_order")
+		}
+		cursor = &c
+	}
+
 	if r.log.DebugEnabled() {
-		r.log.Debug(ctx, "listing users", "page", page, "page_size", pageSize, "email_filter", req.Email, "name_filter", req.Name)
+		r.log.Debug(ctx, "listing users", "page", page, "page_size", pageSize, "cursor", req.Cursor != "", "email_filter", req.Email, "name_filter", req.Name, "sort", column, "ascending", ascending)
 	}
 
 	// Build query with filters
-	query := r.db.WithContext(ctx).Model(&user.User{})
+	query := r.reader(ctx).Model(&user.User{}).Scopes(tenantScope(ctx))
 
-	if req.Email != "" {
-		query = query.Where("email ILIKE ?", "%"+req.Email+"%")
+	if req.Email != "" && encrypted {
+		// Only the whole address can be matched
+		query = query.Where(r.reader(ctx).Where("email_index = ?", database.EmailIndex(req.Email)).
+			Or("email_index IS NULL AND LOWER(email) = ?", strings.ToLower(req.Email)))
+	} else if req.Email != "" {
+		query = query.Where(database.DriverFor(query).ContainsFold("email"), database.ContainsPattern(req.Email))
 	}
 
 	if req.Name != "" {
-		query = query.Where("name ILIKE ?", "%"+req.Name+"%")
+		query = query.Where(database.DriverFor(query).ContainsFold("name"), database.ContainsPattern(req.Name))
+	}
+
+	if !req.CreatedAfter.IsZero() {
+		query = query.Where("created_at > ?", req.CreatedAfter)
+	}
+
+	if !req.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", req.CreatedBefore)
+	}
+
+	if len(req.Roles) > 0 {
+		query = query.Where("role IN ?", req.Roles)
+	}
+
+	if len(req.Statuses) > 0 {
+		query = query.Where("status IN ?", req.Statuses)
 	}
 
-	// Get total count
 	var total int64
-	countQuery := query
-	if err := countQuery.Count(&total).Error; err != nil {
-		r.log.Error(ctx, "failed to count users", "error", err)
-		return nil, wonderErrors.NewDatabaseError("count", "users", err, isRetryableError(err), map[string]interface{}{
-			"page":      page,
-			"page_size": pageSize,
-		})
+	totalKind := req.IncludeTotal
+	switch totalKind {
+	case user.TotalNone:
+	case user.TotalEstimate:
+		estimate, ok := r.estimateCount(ctx, query)
+		if ok {
+			total = estimate
+			break
+		}
+		fallthrough
+	default:
+		totalKind = user.TotalExact
+		if err := query.Count(&total).Error; err != nil {
+			r.log.Error(ctx, "failed to count users", "error", err)
+			return nil, wonderErrors.NewDatabaseError("count", "users", err, isRetryableError(err), map[string]interface{}{
+				"page":      page,
+				"page_size": pageSize,
+			})
+		}
+	}
+
+	// Get users with pagination. One extra row is fetched to tell whether
+	// another page follows; ID breaks ties between equal sort values.
+	direction, after := "DESC", "<"
+	if ascending {
+		direction, after = "ASC", ">"
+	}
+	pageQuery := query.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).Limit(pageSize + 1)
+	if cursor != nil {
+		position, ok := cursorPosition(column, cursor)
+		if !ok {
+			return nil, wonderErrors.NewInvalidValueError("cursor", req.Cursor, "cursor has no position; start from the first page")
+		}
+
+		// A row value comparison lets the (tenant_id, column, id) indexes
+		// seek straight to the position
+		page = 0
+		pageQuery = pageQuery.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, after), position, cursor.ID)
+	} else {
+		pageQuery = pageQuery.Offset(offset)
 	}
 
-	// Get users with pagination
 	var users []*user.User
-	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
+	if err := pageQuery.Find(&users).Error; err != nil {
 		r.log.Error(ctx, "failed to list users", "error", err)
 		return nil, wonderErrors.NewDatabaseError("list", "users", err, isRetryableError(err), map[string]interface{}{
 			"page":      page,
@@ -242,6 +563,28 @@ func (r *userRepository) List(ctx context.Context, req *user.ListUsersRequest) (
 		})
 	}
 
+	var nextCursor string
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		last := users[len(users)-1]
+		key, private := cursorKey(column, last)
+		nextCursor = r.cursors.Encode(pagination.Cursor{
+			Sort: cursorSort(column), Order: order, CreatedAt: last.CreatedAt, Key: key, Private: private, ID: last.ID,
+		})
+	}
+
+	if totalKind == user.TotalEstimate {
+		// Statistics can lag behind; the users seen so far are a floor
+		seen := int64(offset + len(users))
+		if cursor != nil {
+			seen = int64(len(users))
+		}
+		if nextCursor != "" {
+			seen++
+		}
+		total = max(total, seen)
+	}
+
 	// Calculate total pages
 	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
 
@@ -253,20 +596,172 @@ func (r *userRepository) List(ctx context.Context, req *user.ListUsersRequest) (
 		Page:       page,
 		PageSize:   pageSize,
 		TotalPages: totalPages,
+		TotalKind:  totalKind,
+		NextCursor: nextCursor,
 	}, nil
 }
 
-// isDuplicateKeyError checks if the error is a duplicate key constraint violation
-func isDuplicateKeyError(err error) bool {
-	if err == nil {
-		return false
+// estimateCount returns the PostgreSQL planner's estimate of the rows query
+// matches. The planner works from the table statistics kept in pg_class and
+// pg_statistic, so the estimate accounts for the filters without reading
+// the rows. ok is false on other databases and when the plan cannot be read.
+func (r *userRepository) estimateCount(ctx context.Context, query *gorm.DB) (estimate int64, ok bool) {
+	if database.DriverFor(query).Name() != config.DriverPostgres {
+		return 0, false
 	}
+	stmt := query.Session(&gorm.Session{DryRun: true}).Select("id").Find(&[]*user.User{}).Statement
 
-	// PostgreSQL duplicate key error codes
-	errorStr := strings.ToLower(err.Error())
-	return strings.Contains(errorStr, "duplicate key value violates unique constraint") ||
-		strings.Contains(errorStr, "unique constraint failed") ||
-		strings.Contains(errorStr, "23505") // PostgreSQL unique violation error code
+	var plan string
+	if err := query.Session(&gorm.Session{NewDB: true}).Raw("EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).Row().Scan(&plan); err != nil {
+		r.log.Warn(ctx, "failed to estimate user count", "error", err)
+		return 0, false
+	}
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &plans); err != nil || len(plans) == 0 {
+		r.log.Warn(ctx, "failed to read user count estimate", "error", err)
+		return 0, false
+	}
+	return int64(plans[0].Plan.Rows), true
+}
+
+// ExistingEmails returns which of emails are already registered in the
+// tenant, keyed by canonical email under the repository's rules
+func (r *userRepository) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(emails) == 0 {
+		return existing, nil
+	}
+
+	// Matching addresses are read back, decrypted, and canonicalized again
+	var found []*user.User
+	err := r.conn(ctx).Select("email").Scopes(tenantScope(ctx)).
+		Where(emailMatch(r.conn(ctx), emails)).
+		Find(&found).Error
+	if err != nil {
+		r.log.Error(ctx, "failed to look up existing emails", "error", err, "count", len(emails))
+		return nil, wonderErrors.NewDatabaseError("existing_emails", "users", err, isRetryableError(err), map[string]interface{}{
+			"count": len(emails),
+		})
+	}
+
+	for _, u := range found {
+		existing[r.emailRules.Canonicalize(u.Email)] = true
+	}
+	return existing, nil
+}
+
+// ListDueForDeletion returns users whose scheduled deletion is due. It is
+// not tenant-scoped: the purge job runs once for every tenant.
+func (r *userRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*user.User, error) {
+	var users []*user.User
+	err := r.conn(ctx).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", now).
+		Order("deletion_scheduled_at ASC").Order("id ASC").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		r.log.Error(ctx, "failed to list users due for deletion", "error", err)
+		return nil, wonderErrors.NewDatabaseError("list_due_for_deletion", "users", err, isRetryableError(err))
+	}
+	return users, nil
+}
+
+// CreateBatch inserts users in one statement. Each user is validated first.
+func (r *userRepository) CreateBatch(ctx context.Context, users []*user.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	tenantID := tenant.IDFromContext(ctx)
+	for _, u := range users {
+		if err := u.Validate(ctx); err != nil {
+			return err
+		}
+		if u.TenantID == "" {
+			u.TenantID = tenantID
+		}
+		if u.CreatedAt.IsZero() {
+			u.CreatedAt = now
+		}
+		if u.UpdatedAt.IsZero() {
+			u.UpdatedAt = now
+		}
+		u.CreatedAt = u.CreatedAt.Truncate(time.Microsecond)
+		u.UpdatedAt = u.UpdatedAt.Truncate(time.Microsecond)
+		r.indexEmail(u)
+	}
+
+	if err := r.conn(ctx).Create(&users).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			r.log.Warn(ctx, "duplicate email in batch", "count", len(users))
+			return wonderErrors.NewConflictError("user", "email already exists", "", map[string]interface{}{
+				"count": len(users),
+			})
+		}
+		r.log.Error(ctx, "database batch create failed", "error", err, "count", len(users), "retryable", isRetryableError(err))
+		return wonderErrors.NewDatabaseError("create_batch", "users", err, isRetryableError(err), map[string]interface{}{
+			"count": len(users),
+		})
+	}
+
+	r.log.Info(ctx, "users created", "count", len(users))
+	return nil
+}
+
+// indexEmail keeps the blind index and canonical key of u's email in step
+// with the address. The blind index is cleared while encryption is
+// disabled.
+func (r *userRepository) indexEmail(u *user.User) {
+	u.EmailIndex = nil
+	if index := database.EmailIndex(u.Email); index != "" {
+		u.EmailIndex = &index
+	}
+	key := canonicalEmailKey(r.emailRules, u.Email)
+	u.CanonicalEmailKey = &key
+}
+
+// canonicalEmailKey returns the stored canonical key of email under rules
+func canonicalEmailKey(rules user.EmailRules, email string) string {
+	return database.CanonicalEmailKey(rules.Canonicalize(email))
+}
+
+// emailMatch returns the condition matching the users that may be
+// registered with any of emails: those stored by any of their canonical
+// forms, whichever rules were in force when they were saved. Callers
+// compare the matches under the current rules. While encryption is
+// enabled, rows written before it was keep plaintext columns and no blind
+// index until the re-encryptor rewrites them; they are matched as stored,
+// so enabling encryption locks no one out.
+func emailMatch(db *gorm.DB, emails []string) *gorm.DB {
+	var canonical, keys []string
+	for _, e := range emails {
+		for _, c := range user.CanonicalEmailCandidates(e) {
+			canonical = append(canonical, c)
+			keys = append(keys, database.CanonicalEmailKey(c))
+		}
+	}
+
+	match := db.Where("canonical_email IN ?", keys)
+	if database.FieldEncryption() == nil {
+		return match
+	}
+
+	indexes := make([]string, len(emails))
+	for i, e := range emails {
+		indexes[i] = database.EmailIndex(e)
+	}
+	return match.Or("email_index IN ?", indexes).
+		Or("email_index IS NULL AND canonical_email IN ?", canonical)
+}
+
+// isDuplicateKeyError checks if the error is a duplicate key constraint violation
+func isDuplicateKeyError(err error) bool {
+	return database.IsDuplicateKey(err)
 }
 
 // isRetryableError determines if a database error is retryable
@@ -275,7 +770,14 @@ func isRetryableError(err error) bool {
 		return false
 	}
 
+	// A statement that ran out of time would only run out of time again
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
 	errorStr := strings.ToLower(err.Error())
+	if strings.Contains(errorStr, "statement timeout") || strings.Contains(errorStr, "maximum statement execution time exceeded") {
+		return false
+	}
 
 	// Network or connection errors (retryable)
 	retryablePatterns := []string{
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func TestUserRepository_CreateBatchAndExistingEmails(t *testing.T) {
+	repo := setupListDB(t, 2)
+	ctx := context.Background()
+
+	existing, err := repo.ExistingEmails(ctx, []string{"USER00@example.com", "new@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"user00@example.com": true}, existing)
+
+	batch := []*user.User{
+		{ID: "batch-1", Email: "new@example.com", Name: "New", PasswordHash: "hash", Role: user.RoleUser},
+		{ID: "batch-2", Email: "other@example.com", Name: "Other", PasswordHash: "hash", Role: user.RoleUser},
+	}
+	require.NoError(t, repo.CreateBatch(ctx, batch))
+	assert.False(t, batch[0].CreatedAt.IsZero())
+
+	got, err := repo.GetByEmail(ctx, "other@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "batch-2", got.ID)
+
+	err = repo.CreateBatch(ctx, []*user.User{
+		{ID: "batch-3", Email: "new@example.com", Name: "Again", PasswordHash: "hash", Role: user.RoleUser},
+	})
+	var conflict *errors.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+}
+
+func TestUserRepository_GetByHandle(t *testing.T) {
+	repo := setupListDB(t, 2)
+	ctx := context.Background()
+
+	missing, err := repo.GetByHandle(ctx, "ada")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	u, err := repo.GetByEmail(ctx, "user00@example.com")
+	require.NoError(t, err)
+	require.NoError(t, u.ChangeHandle(ctx, "ada", user.HandlePolicy{}))
+	require.NoError(t, repo.Update(ctx, u))
+
+	found, err := repo.GetByHandle(ctx, "ada")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, u.ID, found.ID)
+
+	// Handles are unique per tenant
+	other, err := repo.GetByEmail(ctx, "user01@example.com")
+	require.NoError(t, err)
+	require.NoError(t, other.ChangeHandle(ctx, "ada", user.HandlePolicy{}))
+	assert.ErrorContains(t, repo.Update(ctx, other), "handle ada already exists")
+}
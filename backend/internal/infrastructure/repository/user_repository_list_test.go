@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+)
+
+func setupListDB(t *testing.T, count int) user.UserRepository {
+	db := dbtest.Open(t)
+
+	// Two users share each timestamp to exercise the ID tie-breaker
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		created := base.Add(time.Duration(i/2) * time.Minute)
+		email := fmt.Sprintf("user%02d@example.com", i)
+		require.NoError(t, db.Create(&user.User{
+			ID:                fmt.Sprintf("user-%02d", i),
+			Email:             email,
+			CanonicalEmailKey: &email,
+			Name:              "User",
+			PasswordHash:      "hash",
+			Role:              user.RoleUser,
+			CreatedAt:         created,
+			UpdatedAt:         created,
+		}).Error)
+	}
+	return NewUserRepository(db)
+}
+
+func TestUserRepository_ListWithCursor(t *testing.T) {
+	repo := setupListDB(t, 7)
+	ctx := context.Background()
+
+	var seen []string
+	req := &user.ListUsersRequest{Page: 1, PageSize: 3}
+	for i := 0; i < 5; i++ {
+		resp, err := repo.List(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), resp.Total)
+		for _, u := range resp.Users {
+			seen = append(seen, u.ID)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		req = &user.ListUsersRequest{PageSize: 3, Cursor: resp.NextCursor}
+	}
+
+	assert.Equal(t, []string{"user-06", "user-05", "user-04", "user-03", "user-02", "user-01", "user-00"}, seen)
+}
+
+func TestUserRepository_ListOffsetSetsNextCursor(t *testing.T) {
+	repo := setupListDB(t, 4)
+	ctx := context.Background()
+
+	first, err := repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	assert.Len(t, first.Users, 2)
+	assert.NotEmpty(t, first.NextCursor)
+
+	last, err := repo.List(ctx, &user.ListUsersRequest{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	assert.Len(t, last.Users, 2)
+	assert.Empty(t, last.NextCursor)
+}
+
+func TestUserRepository_ListRejectsInvalidCursor(t *testing.T) {
+	repo := setupListDB(t, 1)
+
+	_, err := repo.List(context.Background(), &user.ListUsersRequest{PageSize: 2, Cursor: "garbage"})
+	assert.ErrorContains(t, err, "cursor")
+}
+
+func TestUserRepository_ListSortAndFilter(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	users := []*user.User{
+		{ID: "u-1", Name: "Carol", Email: "carol@example.com", Role: user.RoleUser, CreatedAt: day(1), UpdatedAt: day(9)},
+		{ID: "u-2", Name: "alice", Email: "alice@example.com", Role: user.RoleAdmin, CreatedAt: day(2), UpdatedAt: day(2)},
+		{ID: "u-3", Name: "Bob", Email: "bob@example.com", Role: user.RoleUser, Status: user.StatusSuspended, CreatedAt: day(3), UpdatedAt: day(5)},
+		{ID: "u-4", Name: "Dave", Email: "dave@example.com", Role: user.RoleUser, CreatedAt: day(4), UpdatedAt: day(4)},
+	}
+	for _, u := range users {
+		u.TenantID, u.PasswordHash = "default", "hash"
+	}
+	require.NoError(t, db.Create(users).Error)
+
+	ids := func(req *user.ListUsersRequest) []string {
+		t.Helper()
+		resp, err := repo.List(ctx, req)
+		require.NoError(t, err)
+		var got []string
+		for _, u := range resp.Users {
+			got = append(got, u.ID)
+		}
+		return got
+	}
+
+	assert.Equal(t, []string{"u-2", "u-3", "u-1", "u-4"}, ids(&user.ListUsersRequest{SortBy: user.SortByEmail, SortOrder: user.SortAsc}))
+	assert.Equal(t, []string{"u-1", "u-3", "u-4", "u-2"}, ids(&user.ListUsersRequest{SortBy: user.SortByUpdatedAt}))
+	assert.Equal(t, []string{"u-1", "u-2", "u-3", "u-4"}, ids(&user.ListUsersRequest{SortOrder: user.SortAsc}))
+
+	// Date bounds are exclusive
+	assert.Equal(t, []string{"u-3", "u-2"}, ids(&user.ListUsersRequest{CreatedAfter: day(1), CreatedBefore: day(4)}))
+	assert.Equal(t, []string{"u-4", "u-3", "u-1"}, ids(&user.ListUsersRequest{Roles: []string{user.RoleUser}}))
+	assert.Equal(t, []string{"u-4", "u-3", "u-2", "u-1"}, ids(&user.ListUsersRequest{Roles: []string{user.RoleUser, user.RoleAdmin}}))
+	assert.Equal(t, []string{"u-3"}, ids(&user.ListUsersRequest{Statuses: []string{user.StatusSuspended}}))
+	assert.Equal(t, []string{"u-4", "u-1"}, ids(&user.ListUsersRequest{Roles: []string{user.RoleUser}, Statuses: []string{user.StatusActive}}), "users stored without a status are active")
+
+	// Ascending cursor pages continue forward
+	first, err := repo.List(ctx, &user.ListUsersRequest{PageSize: 3, SortOrder: user.SortAsc})
+	require.NoError(t, err)
+	require.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, []string{"u-4"}, ids(&user.ListUsersRequest{PageSize: 3, SortOrder: user.SortAsc, Cursor: first.NextCursor}))
+
+	// Every sort column pages by cursor
+	byName, err := repo.List(ctx, &user.ListUsersRequest{PageSize: 3, SortBy: user.SortByName})
+	require.NoError(t, err)
+	require.NotEmpty(t, byName.NextCursor)
+	assert.Equal(t, []string{"u-3"}, ids(&user.ListUsersRequest{PageSize: 3, SortBy: user.SortByName, Cursor: byName.NextCursor}))
+
+	byUpdate, err := repo.List(ctx, &user.ListUsersRequest{PageSize: 2, SortBy: user.SortByUpdatedAt})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u-4", "u-2"}, ids(&user.ListUsersRequest{PageSize: 2, SortBy: user.SortByUpdatedAt, Cursor: byUpdate.NextCursor}))
+
+	// Cursors carry no personal data
+	payload, _, _ := strings.Cut(byName.NextCursor, ".")
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	require.NoError(t, err)
+	assert.NotContains(t, string(decoded), "Carol")
+
+	byEmail, err := repo.List(ctx, &user.ListUsersRequest{PageSize: 1, SortBy: user.SortByEmail, SortOrder: user.SortAsc})
+	require.NoError(t, err)
+	payload, _, _ = strings.Cut(byEmail.NextCursor, ".")
+	decoded, err = base64.RawURLEncoding.DecodeString(payload)
+	require.NoError(t, err)
+	assert.NotContains(t, string(decoded), "alice")
+	assert.Equal(t, []string{"u-3"}, ids(&user.ListUsersRequest{PageSize: 1, SortBy: user.SortByEmail, SortOrder: user.SortAsc, Cursor: byEmail.NextCursor}))
+
+	// A cursor only continues the order it was issued for
+	_, err = repo.List(ctx, &user.ListUsersRequest{SortBy: user.SortByName, Cursor: first.NextCursor})
+	assert.ErrorContains(t, err, "cursor")
+	_, err = repo.List(ctx, &user.ListUsersRequest{Cursor: first.NextCursor})
+	assert.ErrorContains(t, err, "sort_order")
+
+	// Cursors hold the sort key, so they outlive the user the page ended with
+	require.NoError(t, db.Delete(&user.User{}, "id = ?", "u-1").Error)
+	assert.Equal(t, []string{"u-3"}, ids(&user.ListUsersRequest{PageSize: 3, SortBy: user.SortByName, Cursor: byName.NextCursor}))
+
+	// Wildcards in filters match themselves
+	require.NoError(t, db.Create(&user.User{
+		ID: "u-5", TenantID: "default", Name: "50%_off", Email: "50%_off@example.com", PasswordHash: "hash", Role: user.RoleUser, CreatedAt: day(5), UpdatedAt: day(5),
+	}).Error)
+	assert.Equal(t, []string{"u-5"}, ids(&user.ListUsersRequest{Name: "%"}))
+	assert.Equal(t, []string{"u-5"}, ids(&user.ListUsersRequest{Email: "_off"}))
+
+	_, err = repo.List(ctx, &user.ListUsersRequest{SortBy: "password_hash"})
+	assert.ErrorContains(t, err, "sort_by")
+}
+
+func TestUserRepository_ListDueForDeletion(t *testing.T) {
+	repo := NewUserRepository(dbtest.Open(t))
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	create := func(tenantID, id string, deleteAt *time.Time) {
+		require.NoError(t, repo.Create(tenant.WithID(context.Background(), tenantID), &user.User{
+			ID: id, Email: id + "@example.com", Name: "User", PasswordHash: "hash", Role: user.RoleUser,
+			DeletionScheduledAt: deleteAt,
+		}))
+	}
+	at := func(d time.Duration) *time.Time {
+		ts := now.Add(d)
+		return &ts
+	}
+	create("acme", "due-late", at(-time.Minute))
+	create("globex", "due-early", at(-time.Hour))
+	create("acme", "due-now", at(0))
+	create("acme", "pending", at(time.Hour))
+	create("acme", "kept", nil)
+
+	// Due users of every tenant, oldest first
+	due, err := repo.ListDueForDeletion(context.Background(), now, 10)
+	require.NoError(t, err)
+	var ids []string
+	for _, u := range due {
+		ids = append(ids, u.ID)
+	}
+	assert.Equal(t, []string{"due-early", "due-late", "due-now"}, ids)
+
+	due, err = repo.ListDueForDeletion(context.Background(), now, 1)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "globex", due[0].TenantID)
+}
+
+func TestUserRepository_ListIncludeTotal(t *testing.T) {
+	repo := setupListDB(t, 5)
+	ctx := context.Background()
+
+	resp, err := repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 2, IncludeTotal: user.TotalNone})
+	require.NoError(t, err)
+	assert.Equal(t, user.TotalNone, resp.TotalKind)
+	assert.Zero(t, resp.Total)
+	assert.Zero(t, resp.TotalPages)
+	assert.Len(t, resp.Users, 2)
+	assert.NotEmpty(t, resp.NextCursor)
+
+	// SQLite has no planner estimate, so the count is exact
+	resp, err = repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 2, IncludeTotal: user.TotalEstimate})
+	require.NoError(t, err)
+	assert.Equal(t, user.TotalExact, resp.TotalKind)
+	assert.Equal(t, int64(5), resp.Total)
+	assert.Equal(t, 3, resp.TotalPages)
+}
+
+func TestUserRepository_GetByIDs(t *testing.T) {
+	repo := setupListDB(t, 3)
+
+	found, err := repo.GetByIDs(context.Background(), []string{"user-00", "user-02", "user-99"})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "user00@example.com", found["user-00"].Email)
+	assert.Equal(t, "user02@example.com", found["user-02"].Email)
+
+	found, err = repo.GetByIDs(tenant.WithID(context.Background(), "acme"), []string{"user-00"})
+	require.NoError(t, err)
+	assert.Empty(t, found, "users of other tenants are not found")
+
+	found, err = repo.GetByIDs(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
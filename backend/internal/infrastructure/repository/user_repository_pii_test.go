@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/dataexport"
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/fieldcrypt"
+)
+
+// useTestKeyring encrypts personal data with activeID until the test ends
+func useTestKeyring(t *testing.T, activeID string) *fieldcrypt.Keyring {
+	keyring, err := fieldcrypt.NewKeyring(activeID, map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, fieldcrypt.KeySize),
+		"k2": bytes.Repeat([]byte{2}, fieldcrypt.KeySize),
+	}, bytes.Repeat([]byte{9}, fieldcrypt.KeySize))
+	require.NoError(t, err)
+	database.UseFieldEncryption(keyring)
+	t.Cleanup(func() { database.UseFieldEncryption(nil) })
+	return keyring
+}
+
+// storedRow reads a user's personal data columns as stored
+func storedRow(t *testing.T, db *gorm.DB, id string) piiRow {
+	var row piiRow
+	require.NoError(t, db.Where("id = ?", id).First(&row).Error)
+	return row
+}
+
+func newPIIUser(id, email, name string) *user.User {
+	return &user.User{ID: id, Email: email, Name: name, PasswordHash: "hash", Role: user.RoleUser, Status: user.StatusActive}
+}
+
+func TestUserRepository_EncryptedPII(t *testing.T) {
+	db := dbtest.Open(t)
+	keyring := useTestKeyring(t, "k1")
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, newPIIUser("u-1", "ada@example.com", "Ada Lovelace")))
+	require.NoError(t, repo.CreateBatch(ctx, []*user.User{newPIIUser("u-2", "grace@example.com", "Grace Hopper")}))
+
+	// Neither column holds plaintext
+	row := storedRow(t, db, "u-1")
+	assert.True(t, fieldcrypt.IsEncrypted(row.Email))
+	assert.True(t, fieldcrypt.IsEncrypted(row.Name))
+	assert.NotContains(t, row.Email+row.Name, "ada")
+	require.NotNil(t, row.EmailIndex)
+	assert.Equal(t, keyring.BlindIndex("ada@example.com"), *row.EmailIndex)
+
+	// Reads decrypt, and email lookups go through the blind index
+	found, err := repo.GetByEmail(ctx, "Ada@Example.com")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "ada@example.com", found.Email)
+	assert.Equal(t, "Ada Lovelace", found.Name)
+
+	found.Email = "ada@lovelace.dev"
+	require.NoError(t, repo.Update(ctx, found))
+	found, err = repo.GetByEmail(ctx, "ada@lovelace.dev")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	found, err = repo.GetByEmail(ctx, "ada@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	// Uniqueness is enforced on the blind index
+	var conflict *wonderErrors.ConflictError
+	assert.ErrorAs(t, repo.Create(ctx, newPIIUser("u-3", "GRACE@example.com", "Imposter")), &conflict)
+
+	existing, err := repo.ExistingEmails(ctx, []string{"Grace@example.com", "linus@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"grace@example.com": true}, existing)
+
+	// Emails match whole addresses only; names cannot be filtered or sorted
+	listed, err := repo.List(ctx, &user.ListUsersRequest{Email: "grace@example.com"})
+	require.NoError(t, err)
+	require.Len(t, listed.Users, 1)
+	assert.Equal(t, "Grace Hopper", listed.Users[0].Name)
+
+	listed, err = repo.List(ctx, &user.ListUsersRequest{Email: "grace"})
+	require.NoError(t, err)
+	assert.Empty(t, listed.Users)
+
+	var invalid *wonderErrors.ValidationError
+	_, err = repo.List(ctx, &user.ListUsersRequest{Name: "Grace"})
+	assert.ErrorAs(t, err, &invalid)
+	_, err = repo.List(ctx, &user.ListUsersRequest{SortBy: user.SortByEmail})
+	assert.ErrorAs(t, err, &invalid)
+
+	_, err = NewUserSearcher(db, nil).Search(ctx, []string{"grace"}, 1, 10)
+	var unavailable *wonderErrors.DomainRuleError
+	assert.ErrorAs(t, err, &unavailable)
+}
+
+func TestPIIReencryptor(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	// Written before encryption was enabled
+	require.NoError(t, repo.Create(ctx, newPIIUser("u-1", "ada@example.com", "Ada")))
+	require.NoError(t, repo.Create(ctx, newPIIUser("u-2", "grace@example.com", "Grace")))
+
+	reencryptor := NewPIIReencryptor(db, user.EmailRules{}, 1)
+	_, err := reencryptor.Reencrypt(ctx)
+	assert.Error(t, err, "encryption must be enabled")
+
+	useTestKeyring(t, "k1")
+
+	// Rows not re-encrypted yet are still found by email, so users can sign
+	// in before the job has run
+	found, err := repo.GetByEmail(ctx, "Ada@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "u-1", found.ID)
+	existing, err := repo.ExistingEmails(ctx, []string{"grace@example.com"})
+	require.NoError(t, err)
+	assert.True(t, existing["grace@example.com"])
+	listed, err := repo.List(ctx, &user.ListUsersRequest{Email: "grace@example.com"})
+	require.NoError(t, err)
+	assert.Len(t, listed.Users, 1)
+
+	rewritten, err := reencryptor.Reencrypt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rewritten)
+	assert.Equal(t, "k1", fieldcrypt.KeyID(storedRow(t, db, "u-1").Email))
+
+	found, err = repo.GetByEmail(ctx, "ada@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "Ada", found.Name)
+
+	// Current rows are left alone
+	rewritten, err = reencryptor.Reencrypt(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, rewritten)
+
+	// After rotating, rows move to the new key
+	useTestKeyring(t, "k2")
+	rewritten, err = reencryptor.Reencrypt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rewritten)
+	row := storedRow(t, db, "u-2")
+	assert.Equal(t, "k2", fieldcrypt.KeyID(row.Email))
+	assert.Equal(t, "k2", fieldcrypt.KeyID(row.Name))
+
+	found, err = repo.GetByID(ctx, "u-2")
+	require.NoError(t, err)
+	assert.Equal(t, "grace@example.com", found.Email)
+}
+
+func TestEncryptedRecordsOfUsers(t *testing.T) {
+	db := dbtest.Open(t)
+	useTestKeyring(t, "k1")
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	changes := audit.ChangeSet{"email": {From: "ada@example.com", To: "ada@lovelace.dev"}}
+	require.NoError(t, NewAuditRepository(db).Save(ctx, &audit.Entry{
+		ID: "a-1", Action: audit.ActionUpdate, EntityType: "user", EntityID: "u-1",
+		Outcome: audit.OutcomeSuccess, Changes: changes, CreatedAt: now,
+	}))
+	payload := `{"email":"ada@example.com","name":"Ada Lovelace"}`
+	require.NoError(t, db.Create(&event.OutboxMessage{
+		ID: "o-1", IdempotencyKey: "o-1", EventName: user.EventUserRegistered, AggregateID: "u-1",
+		Payload: payload, OccurredAt: now, Status: "pending", NextAttemptAt: now, CreatedAt: now,
+	}).Error)
+	exports := NewDataExportRepository(db)
+	archive := []byte("PK\x03\x04ada@example.com")
+	require.NoError(t, exports.Create(ctx, &dataexport.Export{
+		ID: "exp-1", UserID: "u-1", Status: dataexport.StatusReady, Content: archive, CreatedAt: now,
+	}))
+
+	// None of them is stored as plaintext
+	for _, column := range []struct{ table, name, id string }{
+		{"audit_logs", "changes", "a-1"},
+		{"outbox_messages", "payload", "o-1"},
+		{"data_exports", "content", "exp-1"},
+	} {
+		var stored string
+		require.NoError(t, db.Table(column.table).Select(column.name).Where("id = ?", column.id).Scan(&stored).Error)
+		assert.True(t, fieldcrypt.IsEncrypted(stored), column.table)
+		assert.NotContains(t, stored, "ada", column.table)
+	}
+
+	// And all of them read back decrypted
+	listed, err := NewAuditRepository(db).List(ctx, &audit.ListRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, listed.Entries, 1)
+	assert.Equal(t, changes, listed.Entries[0].Changes)
+
+	var message event.OutboxMessage
+	require.NoError(t, db.First(&message, "id = ?", "o-1").Error)
+	assert.Equal(t, payload, message.Payload)
+
+	content, err := exports.Content(ctx, "exp-1")
+	require.NoError(t, err)
+	assert.Equal(t, archive, content)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUserRepository_ReadReplicas(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	primary := dbtest.Open(t)
+	replica := &database.Replica{Name: "replica", DB: dbtest.Open(t)}
+	repo := NewUserRepository(primary, WithReadReplicas(database.NewResolver(primary, replica)))
+
+	u := &user.User{ID: "replica-1", Email: "lag@example.com", Name: "Lagging", PasswordHash: "hash", Role: user.RoleUser}
+	require.NoError(t, repo.Create(ctx, u))
+
+	// The replica has not caught up, so plain reads miss the new user
+	got, err := repo.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	got, err = repo.GetByEmail(ctx, u.Email)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	got, err = repo.GetByID(database.UsePrimary(ctx), u.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, u.Email, got.Email)
+
+	err = database.NewUnitOfWork(primary).Do(ctx, func(ctx context.Context) error {
+		got, err := repo.GetByEmail(ctx, u.Email)
+		require.NoError(t, err)
+		assert.NotNil(t, got, "reads inside a transaction use the primary")
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Once the replica receives the row, reads are served from it
+	require.NoError(t, replica.DB.Create(u).Error)
+	got, err = repo.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, got)
+}
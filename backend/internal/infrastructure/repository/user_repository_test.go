@@ -469,7 +469,8 @@ func TestUserRepository_TimestampHandling(t *testing.T) {
 	user.CreatedAt = time.Time{}
 	user.UpdatedAt = time.Time{}
 
-	before := time.Now()
+	// Stored timestamps are truncated to microseconds
+	before := time.Now().Truncate(time.Microsecond)
 	err := repo.Create(ctx, user)
 	require.NoError(t, err)
 	after := time.Now()
@@ -489,4 +490,9 @@ func TestUserRepository_TimestampHandling(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.True(t, user.UpdatedAt.After(originalUpdated))
+
+	// The version is unchanged by the round trip through the database
+	stored, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Version(), stored.Version())
 }
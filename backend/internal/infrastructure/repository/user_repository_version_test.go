@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func TestUserRepository_UpdateIfUnmodified(t *testing.T) {
+	repo := NewUserRepository(dbtest.Open(t))
+	ctx := context.Background()
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.Create(ctx, &user.User{
+		ID: "user-1", Email: "ada@example.com", Name: "Ada", PasswordHash: "hash",
+		CreatedAt: created, UpdatedAt: created,
+	}))
+
+	// Two writers read the same version
+	first, err := repo.GetByID(ctx, "user-1")
+	require.NoError(t, err)
+	second, err := repo.GetByID(ctx, "user-1")
+	require.NoError(t, err)
+	version := first.UpdatedAt
+
+	first.Name = "Ada Lovelace"
+	require.NoError(t, repo.UpdateIfUnmodified(ctx, first, version))
+
+	second.Name = "Ada Byron"
+	err = repo.UpdateIfUnmodified(ctx, second, version)
+	var conflict *wonderErrors.ConflictError
+	require.True(t, errors.As(err, &conflict), "got %v", err)
+	assert.Equal(t, wonderErrors.CodeVersionMismatch, conflict.Code())
+	assert.Equal(t, version, second.UpdatedAt, "a refused write leaves the user as it was read")
+
+	stored, err := repo.GetByID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", stored.Name)
+	assert.Equal(t, first.Version(), stored.Version())
+
+	// The version the first writer got back is current
+	stored.Name = "Countess of Lovelace"
+	require.NoError(t, repo.UpdateIfUnmodified(ctx, stored, first.UpdatedAt))
+}
+
+func TestUserRepository_UpdateAvatar(t *testing.T) {
+	repo := NewUserRepository(dbtest.Open(t))
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, &user.User{ID: "user-1", Email: "ada@example.com", Name: "Ada", PasswordHash: "hash"}))
+	uploader, err := repo.GetByID(ctx, "user-1")
+	require.NoError(t, err)
+
+	// A profile update lands between reading the user and setting the avatar
+	editor, err := repo.GetByID(ctx, "user-1")
+	require.NoError(t, err)
+	editor.Name = "Ada Lovelace"
+	require.NoError(t, repo.Update(ctx, editor))
+
+	previous := uploader.ChangeAvatar("avatars/a.png")
+	require.NoError(t, repo.UpdateAvatar(ctx, uploader, previous))
+
+	stored, err := repo.GetByID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "avatars/a.png", stored.AvatarKey)
+	assert.Equal(t, "Ada Lovelace", stored.Name, "only the avatar is written")
+	assert.Equal(t, uploader.Version(), stored.Version())
+
+	// Profile updates from a copy read before the avatar changed keep it
+	editor.Name = "Countess of Lovelace"
+	require.NoError(t, repo.Update(ctx, editor))
+	stored, err = repo.GetByID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "avatars/a.png", stored.AvatarKey)
+	assert.Equal(t, "Countess of Lovelace", stored.Name)
+
+	// A writer that read the avatar before it changed is refused
+	previous = editor.ChangeAvatar("avatars/b.png")
+	err = repo.UpdateAvatar(ctx, editor, previous)
+	var conflict *wonderErrors.ConflictError
+	require.True(t, errors.As(err, &conflict), "got %v", err)
+	assert.Equal(t, wonderErrors.CodeVersionMismatch, conflict.Code())
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// searchDocument is the text indexed by idx_users_search; queries must use
+// the same expression for the index to apply
+const searchDocument = `to_tsvector('simple', name || ' ' || translate(email, '@.+_-', '     '))`
+
+// NewUserSearcher returns a user.Searcher for db: full-text and trigram
+// search on Postgres, or a naive substring search elsewhere, e.g. on the
+// SQLite databases used in tests. Searches run on the resolver's replicas
+// when one is given.
+func NewUserSearcher(db *gorm.DB, resolver *database.Resolver) user.Searcher {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	base := userSearcher{
+		db:       db,
+		resolver: resolver,
+		log:      logger.Get().WithLayer("infrastructure").WithComponent("user_searcher"),
+	}
+	if db.Dialector.Name() == "postgres" {
+		return &postgresUserSearcher{base}
+	}
+	return &naiveUserSearcher{base}
+}
+
+type userSearcher struct {
+	db       *gorm.DB
+	resolver *database.Resolver
+	log      logger.Logger
+}
+
+func (s *userSearcher) reader(ctx context.Context) *gorm.DB {
+	if s.resolver == nil {
+		return database.FromContext(ctx, s.db)
+	}
+	return s.resolver.Reader(ctx)
+}
+
+// available rejects searches while names and emails are encrypted, since
+// neither the indexes nor substring matching see their plaintext
+func (s *userSearcher) available() error {
+	if database.FieldEncryption() != nil {
+		return wonderErrors.NewBusinessRuleError("search_unavailable", "user search is unavailable while personal data is encrypted")
+	}
+	return nil
+}
+
+func (s *userSearcher) fail(ctx context.Context, operation string, err error) error {
+	s.log.Error(ctx, "failed to search users", "error", err, "operation", operation)
+	return wonderErrors.NewDatabaseError(operation, "users", err, isRetryableError(err), nil)
+}
+
+// postgresUserSearcher matches whole words and word prefixes through the
+// full-text index and misspellings through trigram similarity
+type postgresUserSearcher struct {
+	userSearcher
+}
+
+// Search implements user.Searcher
+func (s *postgresUserSearcher) Search(ctx context.Context, terms []string, page, pageSize int) (*user.SearchResponse, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+
+	// Terms are letters and digits only, so they are safe in tsquery syntax
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+	tsquery := strings.Join(prefixes, " & ")
+	text := strings.Join(terms, " ")
+
+	query := s.reader(ctx).Model(&user.User{}).Scopes(tenantScope(ctx)).
+		Where(searchDocument+" @@ to_tsquery('simple', ?) OR ? <% name OR ? <% email", tsquery, text, text)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, s.fail(ctx, "count", err)
+	}
+
+	var rows []struct {
+		user.User `gorm:"embedded"`
+		Rank      float64
+	}
+	err := query.
+		Select("users.*, ts_rank("+searchDocument+", to_tsquery('simple', ?)) + GREATEST(word_similarity(?, name), word_similarity(?, email)) AS rank", tsquery, text, text).
+		Order("rank DESC, id").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, s.fail(ctx, "search", err)
+	}
+
+	hits := make([]*user.SearchHit, len(rows))
+	for i := range rows {
+		u := rows[i].User
+		hits[i] = &user.SearchHit{User: &u, Rank: rows[i].Rank}
+	}
+	return searchResponse(hits, total, page, pageSize), nil
+}
+
+// naiveUserSearcher requires every term to occur in the name or email and
+// ranks in memory. It loads all matches, so it only suits small databases.
+type naiveUserSearcher struct {
+	userSearcher
+}
+
+// Search implements user.Searcher
+func (s *naiveUserSearcher) Search(ctx context.Context, terms []string, page, pageSize int) (*user.SearchResponse, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+
+	query := s.reader(ctx).Model(&user.User{}).Scopes(tenantScope(ctx))
+	for _, term := range terms {
+		pattern := "%" + term + "%"
+		query = query.Where("(LOWER(name) LIKE ? OR LOWER(email) LIKE ?)", pattern, pattern)
+	}
+
+	var users []*user.User
+	if err := query.Find(&users).Error; err != nil {
+		return nil, s.fail(ctx, "search", err)
+	}
+
+	hits := make([]*user.SearchHit, len(users))
+	for i, u := range users {
+		hits[i] = &user.SearchHit{User: u, Rank: naiveRank(u, terms)}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].User.ID < hits[j].User.ID
+	})
+
+	total := int64(len(hits))
+	start := (page - 1) * pageSize
+	if start > len(hits) {
+		start = len(hits)
+	}
+	end := start + pageSize
+	if end > len(hits) {
+		end = len(hits)
+	}
+	return searchResponse(hits[start:end], total, page, pageSize), nil
+}
+
+// naiveRank averages how well each term matches a word of the name or
+// email: 1 for the whole word, 0.75 for a prefix, 0.5 anywhere inside
+func naiveRank(u *user.User, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	words := append(user.SearchTerms(u.Name), user.SearchTerms(u.Email)...)
+
+	var sum float64
+	for _, term := range terms {
+		best := 0.0
+		for _, word := range words {
+			switch {
+			case word == term:
+				best = 1
+			case strings.HasPrefix(word, term) && best < 0.75:
+				best = 0.75
+			case strings.Contains(word, term) && best < 0.5:
+				best = 0.5
+			}
+		}
+		sum += best
+	}
+	return sum / float64(len(terms))
+}
+
+func searchResponse(hits []*user.SearchHit, total int64, page, pageSize int) *user.SearchResponse {
+	return &user.SearchResponse{
+		Hits:       hits,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}
+}
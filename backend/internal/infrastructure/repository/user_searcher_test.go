@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+)
+
+func TestUserSearcher_Naive(t *testing.T) {
+	db := dbtest.Open(t)
+	searcher := NewUserSearcher(db, nil)
+	require.IsType(t, &naiveUserSearcher{}, searcher)
+	ctx := context.Background()
+
+	now := time.Now()
+	users := []*user.User{
+		{ID: "u-1", TenantID: tenant.DefaultID, Name: "Alice Smith", Email: "alice@example.com"},
+		{ID: "u-2", TenantID: tenant.DefaultID, Name: "Malice Jones", Email: "mj@example.com"},
+		{ID: "u-3", TenantID: tenant.DefaultID, Name: "Alicia Keys", Email: "keys@example.com"},
+		{ID: "u-4", TenantID: tenant.DefaultID, Name: "Bob Brown", Email: "bob@example.com"},
+		{ID: "u-5", TenantID: "acme", Name: "Alice Other", Email: "alice@acme.com"},
+	}
+	for _, u := range users {
+		u.PasswordHash, u.Role, u.CreatedAt, u.UpdatedAt = "hash", user.RoleUser, now, now
+	}
+	require.NoError(t, db.Create(users).Error)
+
+	// Whole words rank above prefixes, prefixes above substrings
+	result, err := searcher.Search(ctx, []string{"alice"}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	require.Len(t, result.Hits, 2)
+	assert.Equal(t, "u-1", result.Hits[0].User.ID)
+	assert.Equal(t, 1.0, result.Hits[0].Rank)
+	assert.Equal(t, "u-2", result.Hits[1].User.ID)
+	assert.Equal(t, 0.5, result.Hits[1].Rank)
+
+	result, err = searcher.Search(ctx, []string{"ali"}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 3)
+	assert.Equal(t, "u-1", result.Hits[0].User.ID)
+	assert.Equal(t, "u-3", result.Hits[1].User.ID)
+	assert.Equal(t, "u-2", result.Hits[2].User.ID)
+
+	// Every term must match
+	result, err = searcher.Search(ctx, []string{"ali", "keys"}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "u-3", result.Hits[0].User.ID)
+
+	// Pages are cut after ranking
+	result, err = searcher.Search(ctx, []string{"ali"}, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.Equal(t, 2, result.TotalPages)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "u-2", result.Hits[0].User.ID)
+
+	// Search is limited to the tenant of the context
+	result, err = searcher.Search(tenant.WithID(ctx, "acme"), []string{"alice"}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "u-5", result.Hits[0].User.ID)
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cctw-zed/wonder/internal/domain/webhook"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const (
+	webhookEndpointsTable  = "webhook_endpoints"
+	webhookDeliveriesTable = "webhook_deliveries"
+)
+
+type webhookRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+// NewWebhookRepository creates a new webhook.Repository implementation
+func NewWebhookRepository(db *gorm.DB) webhook.Repository {
+	return NewWebhookRepositoryWithLogger(db, logger.Get().WithLayer("infrastructure").WithComponent("webhook_repository"))
+}
+
+// NewWebhookRepositoryWithLogger creates a new webhook.Repository implementation with explicit logger
+func NewWebhookRepositoryWithLogger(db *gorm.DB, log logger.Logger) webhook.Repository {
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if log == nil {
+		panic("logger cannot be nil")
+	}
+
+	return &webhookRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *webhookRepository) conn(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db)
+}
+
+// CreateEndpoint inserts a new endpoint
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, e *webhook.Endpoint) error {
+	if e == nil {
+		return wonderErrors.NewRequiredFieldError("endpoint", "nil")
+	}
+	now := time.Now()
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = now
+	}
+	if e.UpdatedAt.IsZero() {
+		e.UpdatedAt = e.CreatedAt
+	}
+
+	if err := r.conn(ctx).Create(e).Error; err != nil {
+		r.log.Error(ctx, "webhook endpoint create failed", "error", err, "endpoint_id", e.ID)
+		return wonderErrors.NewDatabaseError("create", webhookEndpointsTable, err, isRetryableError(err), map[string]interface{}{
+			"endpoint_id": e.ID,
+		})
+	}
+	return nil
+}
+
+// GetEndpoint retrieves an endpoint by ID
+func (r *webhookRepository) GetEndpoint(ctx context.Context, id string) (*webhook.Endpoint, error) {
+	var e webhook.Endpoint
+	err := r.conn(ctx).Where("id = ?", id).First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "webhook endpoint lookup failed", "error", err, "endpoint_id", id)
+		return nil, wonderErrors.NewDatabaseError("get", webhookEndpointsTable, err, isRetryableError(err), map[string]interface{}{
+			"endpoint_id": id,
+		})
+	}
+	return &e, nil
+}
+
+// ListEndpoints returns every endpoint, oldest first
+func (r *webhookRepository) ListEndpoints(ctx context.Context) ([]*webhook.Endpoint, error) {
+	var endpoints []*webhook.Endpoint
+	if err := r.conn(ctx).Order("created_at").Order("id").Find(&endpoints).Error; err != nil {
+		r.log.Error(ctx, "webhook endpoint list failed", "error", err)
+		return nil, wonderErrors.NewDatabaseError("list", webhookEndpointsTable, err, isRetryableError(err))
+	}
+	return endpoints, nil
+}
+
+// DeleteEndpoint removes an endpoint and its deliveries
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, id string) error {
+	return database.NewUnitOfWork(r.db).Do(ctx, func(ctx context.Context) error {
+		db := r.conn(ctx)
+		if err := db.Where("endpoint_id = ?", id).Delete(&webhook.Delivery{}).Error; err != nil {
+			r.log.Error(ctx, "webhook delivery delete failed", "error", err, "endpoint_id", id)
+			return wonderErrors.NewDatabaseError("delete", webhookDeliveriesTable, err, isRetryableError(err), map[string]interface{}{
+				"endpoint_id": id,
+			})
+		}
+
+		result := db.Where("id = ?", id).Delete(&webhook.Endpoint{})
+		if result.Error != nil {
+			r.log.Error(ctx, "webhook endpoint delete failed", "error", result.Error, "endpoint_id", id)
+			return wonderErrors.NewDatabaseError("delete", webhookEndpointsTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+				"endpoint_id": id,
+			})
+		}
+		if result.RowsAffected == 0 {
+			return wonderErrors.NewEntityNotFoundError("webhook_endpoint", id)
+		}
+		return nil
+	})
+}
+
+// CreateDeliveries inserts deliveries in one statement
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []*webhook.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := r.conn(ctx).Create(deliveries).Error; err != nil {
+		r.log.Error(ctx, "webhook delivery create failed", "error", err, "count", len(deliveries))
+		return wonderErrors.NewDatabaseError("create", webhookDeliveriesTable, err, isRetryableError(err))
+	}
+	return nil
+}
+
+// GetDelivery retrieves a delivery by ID
+func (r *webhookRepository) GetDelivery(ctx context.Context, id string) (*webhook.Delivery, error) {
+	var d webhook.Delivery
+	err := r.conn(ctx).Where("id = ?", id).First(&d).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Error(ctx, "webhook delivery lookup failed", "error", err, "delivery_id", id)
+		return nil, wonderErrors.NewDatabaseError("get", webhookDeliveriesTable, err, isRetryableError(err), map[string]interface{}{
+			"delivery_id": id,
+		})
+	}
+	return &d, nil
+}
+
+// UpdateDelivery saves the outcome of an attempt
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, d *webhook.Delivery) error {
+	if d == nil {
+		return wonderErrors.NewRequiredFieldError("delivery", "nil")
+	}
+
+	result := r.conn(ctx).Model(d).
+		Select("status", "attempts", "response_status", "last_error", "next_attempt_at", "last_attempt_at", "delivered_at").
+		Updates(d)
+	if result.Error != nil {
+		r.log.Error(ctx, "webhook delivery update failed", "error", result.Error, "delivery_id", d.ID)
+		return wonderErrors.NewDatabaseError("update", webhookDeliveriesTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+			"delivery_id": d.ID,
+		})
+	}
+	if result.RowsAffected == 0 {
+		return wonderErrors.NewEntityNotFoundError("webhook_delivery", d.ID)
+	}
+	return nil
+}
+
+// ListDeliveries returns a page of an endpoint's deliveries, newest first
+func (r *webhookRepository) ListDeliveries(ctx context.Context, endpointID string, offset, limit int) ([]*webhook.Delivery, int64, error) {
+	query := r.conn(ctx).Model(&webhook.Delivery{}).Where("endpoint_id = ?", endpointID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		r.log.Error(ctx, "webhook delivery count failed", "error", err, "endpoint_id", endpointID)
+		return nil, 0, wonderErrors.NewDatabaseError("count", webhookDeliveriesTable, err, isRetryableError(err), map[string]interface{}{
+			"endpoint_id": endpointID,
+		})
+	}
+
+	var deliveries []*webhook.Delivery
+	err := query.Order("created_at DESC").Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		r.log.Error(ctx, "webhook delivery list failed", "error", err, "endpoint_id", endpointID)
+		return nil, 0, wonderErrors.NewDatabaseError("list", webhookDeliveriesTable, err, isRetryableError(err), map[string]interface{}{
+			"endpoint_id": endpointID,
+		})
+	}
+	return deliveries, total, nil
+}
+
+// ClaimDueDeliveries leases up to limit pending deliveries due by now
+// until leaseUntil. Rows are selected with SKIP LOCKED on PostgreSQL and
+// MySQL, and each is moved forward only if it is still due, so two
+// dispatchers never claim the same delivery even where rows cannot be
+// locked.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*webhook.Delivery, error) {
+	query := r.conn(ctx).
+		Where("status = ? AND next_attempt_at <= ?", webhook.StatusPending, now).
+		Order("next_attempt_at").
+		Limit(limit)
+	if database.DriverFor(r.db).SkipLocked() {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	}
+
+	var due []*webhook.Delivery
+	if err := query.Find(&due).Error; err != nil {
+		r.log.Error(ctx, "webhook due delivery lookup failed", "error", err)
+		return nil, wonderErrors.NewDatabaseError("claim", webhookDeliveriesTable, err, isRetryableError(err))
+	}
+
+	claimed := make([]*webhook.Delivery, 0, len(due))
+	for _, d := range due {
+		result := r.conn(ctx).Model(&webhook.Delivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", d.ID, webhook.StatusPending, now).
+			Update("next_attempt_at", leaseUntil)
+		if result.Error != nil {
+			r.log.Error(ctx, "webhook delivery claim failed", "error", result.Error, "delivery_id", d.ID)
+			return nil, wonderErrors.NewDatabaseError("claim", webhookDeliveriesTable, result.Error, isRetryableError(result.Error), map[string]interface{}{
+				"delivery_id": d.ID,
+			})
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		lease := leaseUntil
+		d.NextAttemptAt = &lease
+		claimed = append(claimed, d)
+	}
+	return claimed, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/webhook"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	repo := NewWebhookRepository(dbtest.Open(t))
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	endpoint := &webhook.Endpoint{
+		ID:     "wh-1",
+		URL:    "https://hooks.example.com/wonder",
+		Secret: "0123456789abcdef",
+		Events: []string{webhook.EventUserCreated, webhook.EventUserDeleted},
+	}
+	require.NoError(t, repo.CreateEndpoint(ctx, endpoint))
+
+	found, err := repo.GetEndpoint(ctx, "wh-1")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, endpoint.Events, found.Events)
+	assert.Equal(t, endpoint.Secret, found.Secret)
+
+	missing, err := repo.GetEndpoint(ctx, "wh-missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	endpoints, err := repo.ListEndpoints(ctx)
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 1)
+
+	payload := json.RawMessage(`{"type":"user.created"}`)
+	due := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+	require.NoError(t, repo.CreateDeliveries(ctx, []*webhook.Delivery{
+		{ID: "d-1", EndpointID: "wh-1", EventType: webhook.EventUserCreated, EventID: "e-1", Payload: payload,
+			Status: webhook.StatusPending, NextAttemptAt: &due, CreatedAt: now.Add(-time.Hour)},
+		{ID: "d-2", EndpointID: "wh-1", EventType: webhook.EventUserCreated, EventID: "e-2", Payload: payload,
+			Status: webhook.StatusPending, NextAttemptAt: &later, CreatedAt: now},
+	}))
+
+	// Only deliveries whose next attempt is due are claimed, and a claimed
+	// delivery is not due again until its lease ends
+	lease := now.Add(10 * time.Minute)
+	dueDeliveries, err := repo.ClaimDueDeliveries(ctx, now, lease, 10)
+	require.NoError(t, err)
+	require.Len(t, dueDeliveries, 1)
+	assert.Equal(t, "d-1", dueDeliveries[0].ID)
+	assert.JSONEq(t, string(payload), string(dueDeliveries[0].Payload))
+
+	again, err := repo.ClaimDueDeliveries(ctx, now, lease, 10)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+	expired, err := repo.ClaimDueDeliveries(ctx, lease, lease.Add(10*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 1, "a delivery whose lease ran out is claimed again")
+	assert.Equal(t, "d-1", expired[0].ID)
+
+	delivery := dueDeliveries[0]
+	delivery.MarkSucceeded(204, now)
+	require.NoError(t, repo.UpdateDelivery(ctx, delivery))
+
+	stored, err := repo.GetDelivery(ctx, "d-1")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, webhook.StatusSucceeded, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Equal(t, 204, stored.ResponseStatus)
+	assert.Nil(t, stored.NextAttemptAt)
+
+	dueDeliveries, err = repo.ClaimDueDeliveries(ctx, lease, lease, 10)
+	require.NoError(t, err)
+	assert.Empty(t, dueDeliveries)
+
+	page, total, err := repo.ListDeliveries(ctx, "wh-1", 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, page, 1)
+	assert.Equal(t, "d-2", page[0].ID, "newest deliveries come first")
+
+	// Deleting an endpoint takes its deliveries with it
+	require.NoError(t, repo.DeleteEndpoint(ctx, "wh-1"))
+	_, total, err = repo.ListDeliveries(ctx, "wh-1", 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	var notFound *wonderErrors.EntityNotFoundError
+	assert.ErrorAs(t, repo.DeleteEndpoint(ctx, "wh-1"), &notFound)
+}
@@ -0,0 +1,147 @@
+// Package search keeps users in an Elasticsearch or OpenSearch index and
+// searches them there. Only the REST APIs the two have in common are used.
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ResponseError is an error reply from the cluster
+type ResponseError struct {
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *ResponseError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("elasticsearch: status %d", e.Status)
+	}
+	return fmt.Sprintf("elasticsearch: status %d: %s: %s", e.Status, e.Type, e.Reason)
+}
+
+// IsNotFound reports whether err is a 404 reply, e.g. for a missing index
+// or document
+func IsNotFound(err error) bool {
+	var resp *ResponseError
+	return errors.As(err, &resp) && resp.Status == http.StatusNotFound
+}
+
+// ndjson is a request body of newline-delimited JSON, as the bulk API takes
+type ndjson []byte
+
+// Client sends requests to the nodes of a cluster
+type Client struct {
+	addresses []string
+	username  string
+	password  string
+	http      *http.Client
+}
+
+// NewClient creates a client of the nodes at addresses, tried in order.
+// Requests use basic authentication when username is set.
+func NewClient(addresses []string, username, password string, httpClient *http.Client) *Client {
+	if len(addresses) == 0 {
+		panic("search addresses cannot be empty")
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	trimmed := make([]string, len(addresses))
+	for i, address := range addresses {
+		trimmed[i] = strings.TrimRight(address, "/")
+	}
+	return &Client{addresses: trimmed, username: username, password: password, http: httpClient}
+}
+
+// Ping checks that a node answers
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/", nil, nil)
+}
+
+// do sends body as JSON and decodes the reply into out. A node that cannot
+// be reached, or answers that it is unavailable, is skipped for the next.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var payload []byte
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case ndjson:
+		payload, contentType = b, "application/x-ndjson"
+	default:
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("encode %s %s: %w", method, path, err)
+		}
+	}
+
+	var lastErr error
+	for _, address := range c.addresses {
+		err := c.send(ctx, address, method, path, payload, contentType, out)
+		var resp *ResponseError
+		if err == nil || ctx.Err() != nil || (errors.As(err, &resp) && resp.Status != http.StatusServiceUnavailable) {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+func (c *Client) send(ctx context.Context, address, method, path string, payload []byte, contentType string, out any) error {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, address+path, reader)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var reply struct {
+			Error json.RawMessage `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&reply)
+		respErr := &ResponseError{Status: resp.StatusCode}
+		var cause struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		}
+		if json.Unmarshal(reply.Error, &cause) == nil {
+			respErr.Type, respErr.Reason = cause.Type, cause.Reason
+		} else {
+			// Some APIs, e.g. _alias, reply with the error as a string
+			_ = json.Unmarshal(reply.Error, &respErr.Reason)
+		}
+		return respErr
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Failover(t *testing.T) {
+	var unavailable, healthy atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		unavailable.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthy.Add(1)
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "elastic" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/missing/_doc/1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"type":"index_not_found_exception","reason":"no such index [missing]"}}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(up.Close)
+
+	// Unreachable and unavailable nodes are skipped
+	client := NewClient([]string{"http://127.0.0.1:1", down.URL + "/", up.URL}, "elastic", "secret", nil)
+	require.NoError(t, client.Ping(context.Background()))
+	assert.Equal(t, int32(1), unavailable.Load())
+	assert.Equal(t, int32(1), healthy.Load())
+
+	// Other error replies are returned as they are
+	client = NewClient([]string{up.URL, down.URL}, "elastic", "secret", nil)
+	err := client.do(context.Background(), http.MethodGet, "/missing/_doc/1", nil, nil)
+	assert.True(t, IsNotFound(err))
+	assert.EqualError(t, err, "elasticsearch: status 404: index_not_found_exception: no such index [missing]")
+	assert.Equal(t, int32(1), unavailable.Load())
+}
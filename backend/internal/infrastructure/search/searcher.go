@@ -0,0 +1,121 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// maxResultWindow is how deep into the hits the cluster pages by default
+const maxResultWindow = 10000
+
+// Search implements user.Searcher. Every term must match a word of the
+// name or email, either as a prefix or within one or two typos. Hits are
+// loaded from the database, so users deleted since they were indexed are
+// left out.
+func (x *UserIndex) Search(ctx context.Context, terms []string, page, pageSize int) (*user.SearchResponse, error) {
+	if page*pageSize > maxResultWindow {
+		return nil, wonderErrors.NewOutOfRangeError("page", page, 1, maxResultWindow/pageSize)
+	}
+
+	tenantID := tenant.IDFromContext(ctx)
+	must := make([]any, len(terms))
+	for i, term := range terms {
+		must[i] = map[string]any{"bool": map[string]any{
+			"should": []any{
+				map[string]any{"multi_match": map[string]any{
+					"query":  term,
+					"type":   "bool_prefix",
+					"fields": []string{"name", "name._2gram", "name._3gram", "email", "email._2gram", "email._3gram"},
+				}},
+				map[string]any{"multi_match": map[string]any{
+					"query":     term,
+					"fields":    []string{"name", "email"},
+					"fuzziness": "AUTO",
+				}},
+			},
+		}}
+	}
+	query := map[string]any{
+		"from":             (page - 1) * pageSize,
+		"size":             pageSize,
+		"track_total_hits": true,
+		"_source":          false,
+		"query": map[string]any{"bool": map[string]any{
+			"filter": []any{map[string]any{"term": map[string]any{"tenant_id": tenantID}}},
+			"must":   must,
+		}},
+		"sort": []any{
+			map[string]any{"_score": "desc"},
+			map[string]any{"created_at": "desc"},
+		},
+	}
+
+	var reply struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID    string  `json:"_id"`
+				Score float64 `json:"_score"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := x.client.do(ctx, http.MethodPost, "/"+url.PathEscape(x.alias)+"/_search", query, &reply); err != nil {
+		x.log.Error(ctx, "failed to search users", "error", err, "operation", "search")
+		status := 0
+		var resp *ResponseError
+		if errors.As(err, &resp) {
+			status = resp.Status
+		}
+		return nil, wonderErrors.NewExternalServiceError("elasticsearch", "search", status, "", err, status == 0 || status >= 500)
+	}
+
+	ids := make([]string, len(reply.Hits.Hits))
+	for i, hit := range reply.Hits.Hits {
+		ids[i] = hit.ID
+	}
+	byID := make(map[string]*user.User, len(ids))
+	if len(ids) > 0 {
+		var users []*user.User
+		if err := x.reader(ctx).Where("tenant_id = ? AND id IN ?", tenantID, ids).Find(&users).Error; err != nil {
+			x.log.Error(ctx, "failed to search users", "error", err, "operation", "load")
+			return nil, wonderErrors.NewDatabaseError("load", "users", err, true)
+		}
+		for _, u := range users {
+			byID[u.ID] = u
+		}
+	}
+
+	hits := make([]*user.SearchHit, 0, len(ids))
+	for _, hit := range reply.Hits.Hits {
+		if u, ok := byID[hit.ID]; ok {
+			hits = append(hits, &user.SearchHit{User: u, Rank: hit.Score})
+		}
+	}
+
+	total := reply.Hits.Total.Value
+	return &user.SearchResponse{
+		Hits:       hits,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+func (x *UserIndex) reader(ctx context.Context) *gorm.DB {
+	if x.resolver == nil {
+		return database.FromContext(ctx, x.db)
+	}
+	return x.resolver.Reader(ctx)
+}
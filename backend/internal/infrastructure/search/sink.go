@@ -0,0 +1,269 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/infrastructure/outbox"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const (
+	defaultSyncInterval    = time.Second
+	defaultSyncMaxPending  = 10000
+	defaultSyncBaseBackoff = time.Second
+	defaultSyncMaxBackoff  = 5 * time.Minute
+)
+
+// isUserEvent reports whether the event named name changes a user
+func isUserEvent(name string) bool {
+	return strings.HasPrefix(name, "user.")
+}
+
+// SyncerOption configures a Syncer
+type SyncerOption func(*Syncer)
+
+// WithSyncInterval sets how often queued users are synced
+func WithSyncInterval(d time.Duration) SyncerOption {
+	return func(s *Syncer) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+// WithSyncMaxPending bounds how many users wait to be synced
+func WithSyncMaxPending(n int) SyncerOption {
+	return func(s *Syncer) {
+		if n > 0 {
+			s.maxPending = n
+		}
+	}
+}
+
+// WithSyncBackoff sets the exponential retry delay bounds
+func WithSyncBackoff(base, max time.Duration) SyncerOption {
+	return func(s *Syncer) {
+		if base > 0 {
+			s.baseBackoff = base
+		}
+		if max >= s.baseBackoff {
+			s.maxBackoff = max
+		}
+	}
+}
+
+// pendingSync is a user waiting to be synced
+type pendingSync struct {
+	attempts int
+	next     time.Time
+}
+
+// Syncer syncs the users of user events to the index in the background.
+// Events only queue their user, so a cluster outage neither holds up the
+// outbox relay nor makes it deliver the event to the bus and broker again.
+// Failed syncs are retried with backoff. The queue is kept in memory and
+// bounded; users dropped from it, or still queued at shutdown, are
+// restored by the next reindex.
+type Syncer struct {
+	index *UserIndex
+	log   logger.Logger
+	now   func() time.Time
+
+	interval    time.Duration
+	maxPending  int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingSync
+	dropped int64
+	running bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewSyncer creates a syncer for index
+func NewSyncer(index *UserIndex, opts ...SyncerOption) *Syncer {
+	if index == nil {
+		panic("user search index cannot be nil")
+	}
+
+	s := &Syncer{
+		index:       index,
+		log:         logger.Get().WithLayer("infrastructure").WithComponent("user_search_sync"),
+		now:         time.Now,
+		interval:    defaultSyncInterval,
+		maxPending:  defaultSyncMaxPending,
+		baseBackoff: defaultSyncBaseBackoff,
+		maxBackoff:  defaultSyncMaxBackoff,
+		pending:     map[string]*pendingSync{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Sink returns an outbox sink that queues the users of relayed user
+// events. It never fails a delivery.
+func (s *Syncer) Sink() outbox.Sink {
+	return outbox.SinkFunc(func(ctx context.Context, msg event.OutboxMessage) error {
+		if isUserEvent(msg.EventName) {
+			s.Queue(ctx, msg.AggregateID)
+		}
+		return nil
+	})
+}
+
+// Handler returns an event bus handler that queues the user of each
+// event. It is used when the transactional outbox is disabled.
+func (s *Syncer) Handler() event.Handler {
+	return func(ctx context.Context, e event.Event) error {
+		s.Queue(ctx, e.AggregateID())
+		return nil
+	}
+}
+
+// Queue schedules the user with id to be synced. A user already queued,
+// even for a retry, is synced once with its state at that time.
+func (s *Syncer) Queue(ctx context.Context, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, queued := s.pending[id]; queued {
+		return
+	}
+	if len(s.pending) >= s.maxPending {
+		s.dropped++
+		s.log.Error(ctx, "search sync queue full, user left for the next reindex", "user_id", id, "dropped", s.dropped)
+		return
+	}
+	s.pending[id] = &pendingSync{next: s.now()}
+}
+
+// retry schedules another sync of the user after attempts failures
+func (s *Syncer) retry(id string, attempts int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A newer event queued the user meanwhile; keep its earlier turn
+	if p, queued := s.pending[id]; queued {
+		p.attempts = attempts
+		return
+	}
+	s.pending[id] = &pendingSync{attempts: attempts, next: s.now().Add(s.backoff(attempts))}
+}
+
+// Pending returns how many users wait to be synced
+func (s *Syncer) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Dropped returns how many users were left for a reindex because the
+// queue was full
+func (s *Syncer) Dropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// SyncDue syncs the queued users that are due and returns how many of them
+// succeeded
+func (s *Syncer) SyncDue(ctx context.Context) int {
+	now := s.now()
+	s.mu.Lock()
+	due := make(map[string]int)
+	for id, p := range s.pending {
+		if !p.next.After(now) {
+			due[id] = p.attempts
+			delete(s.pending, id)
+		}
+	}
+	s.mu.Unlock()
+
+	synced := 0
+	for id, attempts := range due {
+		if err := s.index.Sync(ctx, id); err != nil {
+			s.log.Warn(ctx, "failed to sync user to search index, will retry", "user_id", id, "attempts", attempts+1, "error", err)
+			s.retry(id, attempts+1)
+			continue
+		}
+		synced++
+	}
+	return synced
+}
+
+// Start launches the sync loop. Calling Start on a running syncer is a
+// no-op.
+func (s *Syncer) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go s.loop(context.WithoutCancel(ctx), s.stop, s.done)
+}
+
+// Stop ends the sync loop after syncing the users that are due, or gives
+// up when ctx expires. Users still queued are logged so a reindex can be
+// scheduled.
+func (s *Syncer) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	close(s.stop)
+	done := s.done
+	s.mu.Unlock()
+
+	select {
+	case <-done:
+		if pending := s.Pending(); pending > 0 {
+			s.log.Warn(ctx, "search sync stopped with users still queued, reindex to restore them", "pending", pending)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("search syncer did not stop before deadline: %w", ctx.Err())
+	}
+}
+
+func (s *Syncer) loop(ctx context.Context, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			s.SyncDue(ctx)
+			return
+		case <-ticker.C:
+			s.SyncDue(ctx)
+		}
+	}
+}
+
+// backoff returns the delay before the retry following the given number
+// of failures
+func (s *Syncer) backoff(attempts int) time.Duration {
+	d := s.baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= s.maxBackoff {
+			return s.maxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,347 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// mappingVersion is stored in the index mapping's _meta. Raise it when
+// userMapping changes; indexes with an older version keep working but
+// need a reindex to pick the change up.
+const mappingVersion = 1
+
+// userMapping analyzes names with the standard analyzer and emails split
+// at anything but letters and digits, as user.SearchTerms splits queries.
+// Both are search_as_you_type fields, so the last term of a query matches
+// word prefixes.
+var userMapping = map[string]any{
+	"settings": map[string]any{
+		"analysis": map[string]any{
+			"tokenizer": map[string]any{
+				"email_parts": map[string]any{"type": "pattern", "pattern": `[^\p{L}\p{N}]+`},
+			},
+			"analyzer": map[string]any{
+				"email": map[string]any{"type": "custom", "tokenizer": "email_parts", "filter": []string{"lowercase"}},
+			},
+		},
+	},
+	"mappings": map[string]any{
+		"dynamic": "strict",
+		"_meta":   map[string]any{"version": mappingVersion},
+		"properties": map[string]any{
+			"tenant_id":  map[string]any{"type": "keyword"},
+			"name":       map[string]any{"type": "search_as_you_type"},
+			"email":      map[string]any{"type": "search_as_you_type", "analyzer": "email"},
+			"created_at": map[string]any{"type": "date"},
+		},
+	},
+}
+
+// userDocument is the indexed form of a user
+type userDocument struct {
+	TenantID  string    `json:"tenant_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func documentOf(u *user.User) userDocument {
+	return userDocument{TenantID: u.TenantID, Name: u.Name, Email: u.Email, CreatedAt: u.CreatedAt}
+}
+
+// documentVersion orders the writes of a user's document: a write carrying
+// an older version than the stored one is ignored, so a stale copy cannot
+// replace a newer one however the writes interleave
+func documentVersion(u *user.User) int64 {
+	return u.UpdatedAt.UnixMicro()
+}
+
+// UserIndex keeps users in an index behind an alias. Documents are written
+// from the users table rather than from event payloads, so a write is
+// always of the user's current state and applying one twice is harmless.
+type UserIndex struct {
+	client    *Client
+	alias     string
+	db        *gorm.DB
+	resolver  *database.Resolver
+	batchSize int
+	log       logger.Logger
+
+	ensured atomic.Bool
+}
+
+// NewUserIndex creates the index of db's users behind alias. Searches load
+// the matched users through resolver's replicas when one is given; batchSize
+// users at a time are read while reindexing.
+func NewUserIndex(client *Client, alias string, db *gorm.DB, resolver *database.Resolver, batchSize int) *UserIndex {
+	if client == nil {
+		panic("search client cannot be nil")
+	}
+	if db == nil {
+		panic("database connection cannot be nil")
+	}
+	if batchSize <= 0 {
+		panic("batch size must be positive")
+	}
+	return &UserIndex{
+		client:    client,
+		alias:     alias,
+		db:        db,
+		resolver:  resolver,
+		batchSize: batchSize,
+		log:       logger.Get().WithLayer("infrastructure").WithComponent("user_search_index"),
+	}
+}
+
+// Ping checks that the cluster answers
+func (x *UserIndex) Ping(ctx context.Context) error {
+	return x.client.Ping(ctx)
+}
+
+// Ensure creates the index and its alias unless the alias exists. An
+// index with an outdated mapping is kept, with a warning to reindex.
+func (x *UserIndex) Ensure(ctx context.Context) error {
+	var mappings map[string]struct {
+		Mappings struct {
+			Meta struct {
+				Version int `json:"version"`
+			} `json:"_meta"`
+		} `json:"mappings"`
+	}
+	err := x.client.do(ctx, http.MethodGet, "/"+url.PathEscape(x.alias)+"/_mapping", nil, &mappings)
+	if IsNotFound(err) {
+		if err := x.create(ctx, x.newIndexName(), true); err != nil {
+			return err
+		}
+		x.ensured.Store(true)
+		x.log.Info(ctx, "user search index created", "alias", x.alias)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read mapping of %s: %w", x.alias, err)
+	}
+
+	for index, m := range mappings {
+		if m.Mappings.Meta.Version < mappingVersion {
+			x.log.Warn(ctx, "user search index mapping is outdated, run the reindex-user-search job",
+				"index", index, "version", m.Mappings.Meta.Version, "current_version", mappingVersion)
+		}
+	}
+	x.ensured.Store(true)
+	return nil
+}
+
+// ensure runs Ensure until it first succeeds, so an index that could not
+// be set up at startup is set up by the first write
+func (x *UserIndex) ensure(ctx context.Context) error {
+	if x.ensured.Load() {
+		return nil
+	}
+	return x.Ensure(ctx)
+}
+
+// newIndexName names a concrete index behind the alias
+func (x *UserIndex) newIndexName() string {
+	return x.alias + "_" + strconv.FormatInt(time.Now().UnixMilli(), 10)
+}
+
+// create creates index with the user mapping, behind the alias if aliased
+func (x *UserIndex) create(ctx context.Context, index string, aliased bool) error {
+	body := map[string]any{"settings": userMapping["settings"], "mappings": userMapping["mappings"]}
+	if aliased {
+		body["aliases"] = map[string]any{x.alias: map[string]any{}}
+	}
+	if err := x.client.do(ctx, http.MethodPut, "/"+url.PathEscape(index), body, nil); err != nil {
+		return fmt.Errorf("create index %s: %w", index, err)
+	}
+	return nil
+}
+
+// Sync writes the current state of the user with id to the index, or
+// removes the user's document once the user is gone. The user is read
+// unscoped from the primary, since relayed events carry no tenant.
+func (x *UserIndex) Sync(ctx context.Context, id string) error {
+	if err := x.ensure(ctx); err != nil {
+		return err
+	}
+
+	var u user.User
+	err := database.FromContext(ctx, x.db).Where("id = ?", id).Take(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err := x.client.do(ctx, http.MethodDelete, x.docPath(id), nil, nil)
+		if err != nil && !IsNotFound(err) {
+			return fmt.Errorf("delete user %s from search index: %w", id, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return wonderErrors.NewDatabaseError("sync_search_index", "users", err, true, map[string]interface{}{"user_id": id})
+	}
+
+	path := x.docPath(id) + "?version_type=external_gte&version=" + strconv.FormatInt(documentVersion(&u), 10)
+	err = x.client.do(ctx, http.MethodPut, path, documentOf(&u), nil)
+	if err != nil && !isVersionConflict(err) {
+		return fmt.Errorf("index user %s: %w", id, err)
+	}
+	return nil
+}
+
+func (x *UserIndex) docPath(id string) string {
+	return "/" + url.PathEscape(x.alias) + "/_doc/" + url.PathEscape(id)
+}
+
+// isVersionConflict reports whether a write was skipped because a newer
+// version of the document is stored
+func isVersionConflict(err error) bool {
+	var resp *ResponseError
+	return errors.As(err, &resp) && resp.Status == http.StatusConflict
+}
+
+// Reindex builds a new index of the users of every tenant, points the
+// alias at it and deletes the indexes it replaced, returning how many users
+// it indexed. Users written while it runs are indexed again once the alias
+// is switched; a user deleted while it runs may linger in the index, but
+// searches skip documents whose user is gone.
+func (x *UserIndex) Reindex(ctx context.Context) (int, error) {
+	started := time.Now()
+	index := x.newIndexName()
+	if err := x.create(ctx, index, false); err != nil {
+		return 0, err
+	}
+
+	indexed, err := x.copyUsers(ctx, index, nil)
+	if err == nil {
+		err = x.switchAlias(ctx, index)
+	}
+	if err != nil {
+		x.log.Error(ctx, "user search reindex failed", "error", err, "index", index, "indexed", indexed)
+		if dropErr := x.client.do(ctx, http.MethodDelete, "/"+url.PathEscape(index), nil, nil); dropErr != nil {
+			x.log.Warn(ctx, "failed to delete abandoned search index", "index", index, "error", dropErr)
+		}
+		return indexed, err
+	}
+	x.ensured.Store(true)
+
+	// Writes that went to the replaced index while the copy ran; the
+	// margin covers clock skew between the database and this host
+	if _, err := x.copyUsers(ctx, x.alias, func(db *gorm.DB) *gorm.DB {
+		return db.Where("updated_at >= ?", started.Add(-time.Minute))
+	}); err != nil {
+		return indexed, err
+	}
+
+	x.log.Info(ctx, "users reindexed", "index", index, "alias", x.alias, "indexed", indexed,
+		"duration_ms", time.Since(started).Milliseconds())
+	return indexed, nil
+}
+
+// copyUsers bulk-writes the users filter selects, all when nil, to index
+func (x *UserIndex) copyUsers(ctx context.Context, index string, filter func(*gorm.DB) *gorm.DB) (int, error) {
+	copied, after := 0, ""
+	for {
+		query := database.FromContext(ctx, x.db).Where("id > ?", after)
+		if filter != nil {
+			query = filter(query)
+		}
+		var users []*user.User
+		if err := query.Order("id").Limit(x.batchSize).Find(&users).Error; err != nil {
+			return copied, wonderErrors.NewDatabaseError("reindex", "users", err, true)
+		}
+		if len(users) == 0 {
+			return copied, nil
+		}
+		if err := x.bulkIndex(ctx, index, users); err != nil {
+			return copied, err
+		}
+		copied += len(users)
+		after = users[len(users)-1].ID
+	}
+}
+
+// bulkIndex writes users to index in one bulk request
+func (x *UserIndex) bulkIndex(ctx context.Context, index string, users []*user.User) error {
+	var body strings.Builder
+	enc := json.NewEncoder(&body)
+	for _, u := range users {
+		action := map[string]any{"index": map[string]any{
+			"_index":       index,
+			"_id":          u.ID,
+			"version":      documentVersion(u),
+			"version_type": "external_gte",
+		}}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+		if err := enc.Encode(documentOf(u)); err != nil {
+			return err
+		}
+	}
+
+	var reply struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := x.client.do(ctx, http.MethodPost, "/_bulk", ndjson(body.String()), &reply); err != nil {
+		return fmt.Errorf("bulk index users: %w", err)
+	}
+	if !reply.Errors {
+		return nil
+	}
+	for _, item := range reply.Items {
+		for _, result := range item {
+			if result.Status >= 300 && result.Status != http.StatusConflict {
+				return fmt.Errorf("bulk index user %s: %w", result.ID,
+					&ResponseError{Status: result.Status, Type: result.Error.Type, Reason: result.Error.Reason})
+			}
+		}
+	}
+	return nil
+}
+
+// switchAlias points the alias at index alone, in one atomic update, and
+// then deletes the indexes it pointed at before
+func (x *UserIndex) switchAlias(ctx context.Context, index string) error {
+	var current map[string]json.RawMessage
+	err := x.client.do(ctx, http.MethodGet, "/_alias/"+url.PathEscape(x.alias), nil, &current)
+	if err != nil && !IsNotFound(err) {
+		return fmt.Errorf("read alias %s: %w", x.alias, err)
+	}
+
+	actions := make([]any, 0, len(current)+1)
+	for old := range current {
+		actions = append(actions, map[string]any{"remove": map[string]any{"index": old, "alias": x.alias}})
+	}
+	actions = append(actions, map[string]any{"add": map[string]any{"index": index, "alias": x.alias}})
+	if err := x.client.do(ctx, http.MethodPost, "/_aliases", map[string]any{"actions": actions}, nil); err != nil {
+		return fmt.Errorf("switch alias %s to %s: %w", x.alias, index, err)
+	}
+
+	for old := range current {
+		if err := x.client.do(ctx, http.MethodDelete, "/"+url.PathEscape(old), nil, nil); err != nil {
+			x.log.Warn(ctx, "failed to delete replaced search index", "index", old, "error", err)
+		}
+	}
+	return nil
+}
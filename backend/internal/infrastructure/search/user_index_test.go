@@ -0,0 +1,465 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+)
+
+// fakeCluster is an in-memory stand-in for the parts of the Elasticsearch
+// API the index uses. Searches match terms as word prefixes and score
+// whole-word matches higher.
+type fakeCluster struct {
+	mu       sync.Mutex
+	indices  map[string]*fakeIndex
+	aliases  map[string]string
+	searches []map[string]any
+	// down makes every request fail as during an outage
+	down bool
+}
+
+type fakeIndex struct {
+	version int
+	docs    map[string]fakeDoc
+}
+
+type fakeDoc struct {
+	version int64
+	source  userDocument
+}
+
+func newFakeCluster(t *testing.T) (*fakeCluster, *Client) {
+	cluster := &fakeCluster{indices: map[string]*fakeIndex{}, aliases: map[string]string{}}
+	server := httptest.NewServer(cluster)
+	t.Cleanup(server.Close)
+	return cluster, NewClient([]string{server.URL}, "", "", server.Client())
+}
+
+func (c *fakeCluster) resolve(name string) (string, *fakeIndex) {
+	if index, ok := c.aliases[name]; ok {
+		name = index
+	}
+	return name, c.indices[name]
+}
+
+func (c *fakeCluster) put(index *fakeIndex, id string, version int64, doc userDocument) int {
+	if stored, ok := index.docs[id]; ok && stored.version > version {
+		return http.StatusConflict
+	}
+	index.docs[id] = fakeDoc{version: version, source: doc}
+	return http.StatusOK
+}
+
+func (c *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reply := func(status int, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+	if c.down {
+		reply(http.StatusServiceUnavailable, map[string]any{"error": map[string]any{"type": "cluster_block_exception", "reason": "unavailable"}})
+		return
+	}
+	notFound := func() {
+		reply(http.StatusNotFound, map[string]any{"error": map[string]any{"type": "index_not_found_exception", "reason": "no such index"}})
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/":
+		reply(http.StatusOK, map[string]any{"version": map[string]any{"number": "8.13.0"}})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/_bulk":
+		var items []any
+		failed := false
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action struct {
+				Index struct {
+					Index   string `json:"_index"`
+					ID      string `json:"_id"`
+					Version int64  `json:"version"`
+				} `json:"index"`
+			}
+			_ = json.Unmarshal(scanner.Bytes(), &action)
+			scanner.Scan()
+			var doc userDocument
+			_ = json.Unmarshal(scanner.Bytes(), &doc)
+			status := http.StatusNotFound
+			if _, index := c.resolve(action.Index.Index); index != nil {
+				status = c.put(index, action.Index.ID, action.Index.Version, doc)
+			}
+			failed = failed || status != http.StatusOK
+			items = append(items, map[string]any{"index": map[string]any{"_id": action.Index.ID, "status": status}})
+		}
+		reply(http.StatusOK, map[string]any{"errors": failed, "items": items})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/_aliases":
+		var body struct {
+			Actions []map[string]struct {
+				Index string `json:"index"`
+				Alias string `json:"alias"`
+			} `json:"actions"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for _, action := range body.Actions {
+			for kind, target := range action {
+				if kind == "add" {
+					c.aliases[target.Alias] = target.Index
+				} else if c.aliases[target.Alias] == target.Index {
+					delete(c.aliases, target.Alias)
+				}
+			}
+		}
+		reply(http.StatusOK, map[string]any{"acknowledged": true})
+
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "_alias":
+		index, ok := c.aliases[parts[1]]
+		if !ok {
+			reply(http.StatusNotFound, map[string]any{"error": "alias [" + parts[1] + "] missing", "status": 404})
+			return
+		}
+		reply(http.StatusOK, map[string]any{index: map[string]any{"aliases": map[string]any{parts[1]: map[string]any{}}}})
+
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[1] == "_mapping":
+		name, index := c.resolve(parts[0])
+		if index == nil {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, map[string]any{name: map[string]any{"mappings": map[string]any{"_meta": map[string]any{"version": index.version}}}})
+
+	case r.Method == http.MethodPut && len(parts) == 1:
+		var body struct {
+			Mappings struct {
+				Meta struct {
+					Version int `json:"version"`
+				} `json:"_meta"`
+			} `json:"mappings"`
+			Aliases map[string]any `json:"aliases"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		c.indices[parts[0]] = &fakeIndex{version: body.Mappings.Meta.Version, docs: map[string]fakeDoc{}}
+		for alias := range body.Aliases {
+			c.aliases[alias] = parts[0]
+		}
+		reply(http.StatusOK, map[string]any{"acknowledged": true})
+
+	case r.Method == http.MethodDelete && len(parts) == 1:
+		if c.indices[parts[0]] == nil {
+			notFound()
+			return
+		}
+		delete(c.indices, parts[0])
+		reply(http.StatusOK, map[string]any{"acknowledged": true})
+
+	case len(parts) == 3 && parts[1] == "_doc":
+		_, index := c.resolve(parts[0])
+		if index == nil {
+			notFound()
+			return
+		}
+		if r.Method == http.MethodDelete {
+			if _, ok := index.docs[parts[2]]; !ok {
+				reply(http.StatusNotFound, map[string]any{"result": "not_found"})
+				return
+			}
+			delete(index.docs, parts[2])
+			reply(http.StatusOK, map[string]any{"result": "deleted"})
+			return
+		}
+		var doc userDocument
+		_ = json.NewDecoder(r.Body).Decode(&doc)
+		version, _ := strconv.ParseInt(r.URL.Query().Get("version"), 10, 64)
+		if status := c.put(index, parts[2], version, doc); status != http.StatusOK {
+			reply(status, map[string]any{"error": map[string]any{"type": "version_conflict_engine_exception", "reason": "version conflict"}})
+			return
+		}
+		reply(http.StatusOK, map[string]any{"result": "created"})
+
+	case r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "_search":
+		_, index := c.resolve(parts[0])
+		if index == nil {
+			notFound()
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		c.searches = append(c.searches, body)
+		reply(http.StatusOK, c.search(index, body))
+
+	default:
+		reply(http.StatusBadRequest, map[string]any{"error": map[string]any{"type": "illegal_argument_exception", "reason": r.Method + " " + r.URL.Path}})
+	}
+}
+
+// search runs the query Search builds
+func (c *fakeCluster) search(index *fakeIndex, body map[string]any) map[string]any {
+	boolQuery := body["query"].(map[string]any)["bool"].(map[string]any)
+	tenantID := boolQuery["filter"].([]any)[0].(map[string]any)["term"].(map[string]any)["tenant_id"]
+	var terms []string
+	for _, clause := range boolQuery["must"].([]any) {
+		should := clause.(map[string]any)["bool"].(map[string]any)["should"].([]any)
+		terms = append(terms, should[0].(map[string]any)["multi_match"].(map[string]any)["query"].(string))
+	}
+
+	type hit struct {
+		id    string
+		score float64
+	}
+	var hits []hit
+	for id, doc := range index.docs {
+		if doc.source.TenantID != tenantID {
+			continue
+		}
+		words := append(user.SearchTerms(doc.source.Name), user.SearchTerms(doc.source.Email)...)
+		score := 0.0
+		for _, term := range terms {
+			best := 0.0
+			for _, word := range words {
+				if word == term {
+					best = 2
+				} else if strings.HasPrefix(word, term) && best < 1 {
+					best = 1
+				}
+			}
+			if best == 0 {
+				score = 0
+				break
+			}
+			score += best
+		}
+		if score > 0 {
+			hits = append(hits, hit{id, score})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].id < hits[j].id
+	})
+
+	from, size := int(body["from"].(float64)), int(body["size"].(float64))
+	page := []any{}
+	for i := from; i < len(hits) && i < from+size; i++ {
+		page = append(page, map[string]any{"_id": hits[i].id, "_score": hits[i].score})
+	}
+	return map[string]any{"hits": map[string]any{"total": map[string]any{"value": len(hits)}, "hits": page}}
+}
+
+func (c *fakeCluster) docs(alias string) map[string]fakeDoc {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, index := c.resolve(alias)
+	if index == nil {
+		return nil
+	}
+	return index.docs
+}
+
+func setupUserIndex(t *testing.T) (*UserIndex, *fakeCluster, *gorm.DB) {
+	db := dbtest.Open(t)
+
+	cluster, client := newFakeCluster(t)
+	return NewUserIndex(client, "users", db, nil, 2), cluster, db
+}
+
+func createUsers(t *testing.T, db *gorm.DB, users ...*user.User) {
+	now := time.Now()
+	for _, u := range users {
+		u.PasswordHash, u.Role, u.Status, u.CreatedAt, u.UpdatedAt = "hash", user.RoleUser, user.StatusActive, now, now
+	}
+	require.NoError(t, db.Create(users).Error)
+}
+
+func TestUserIndex_Ensure(t *testing.T) {
+	index, cluster, _ := setupUserIndex(t)
+	ctx := context.Background()
+
+	require.NoError(t, index.Ensure(ctx))
+	require.Len(t, cluster.indices, 1)
+	concrete := cluster.aliases["users"]
+	assert.True(t, strings.HasPrefix(concrete, "users_"))
+	assert.Equal(t, mappingVersion, cluster.indices[concrete].version)
+
+	// An existing alias is kept
+	require.NoError(t, index.Ensure(ctx))
+	assert.Len(t, cluster.indices, 1)
+	assert.Equal(t, concrete, cluster.aliases["users"])
+}
+
+func TestUserIndex_Sync(t *testing.T) {
+	index, cluster, db := setupUserIndex(t)
+	ctx := context.Background()
+
+	alice := &user.User{ID: "u-1", TenantID: "acme", Name: "Alice Smith", Email: "alice@example.com"}
+	createUsers(t, db, alice)
+
+	// The index is created by the first sync
+	require.NoError(t, index.Sync(ctx, "u-1"))
+	doc := cluster.docs("users")["u-1"]
+	assert.Equal(t, userDocument{TenantID: "acme", Name: "Alice Smith", Email: "alice@example.com", CreatedAt: alice.CreatedAt.UTC()}, userDocument{
+		TenantID: doc.source.TenantID, Name: doc.source.Name, Email: doc.source.Email, CreatedAt: doc.source.CreatedAt.UTC(),
+	})
+
+	// A stale write is skipped rather than failing
+	require.NoError(t, db.Model(alice).Updates(map[string]any{"name": "Alice Jones", "updated_at": alice.UpdatedAt.Add(-time.Hour)}).Error)
+	require.NoError(t, index.Sync(ctx, "u-1"))
+	assert.Equal(t, "Alice Smith", cluster.docs("users")["u-1"].source.Name)
+
+	require.NoError(t, db.Model(alice).Updates(map[string]any{"name": "Alice Jones", "updated_at": alice.UpdatedAt.Add(time.Hour)}).Error)
+	require.NoError(t, index.Sync(ctx, "u-1"))
+	assert.Equal(t, "Alice Jones", cluster.docs("users")["u-1"].source.Name)
+
+	// Deleted users are removed, and removing them again is harmless
+	require.NoError(t, db.Delete(&user.User{}, "id = ?", "u-1").Error)
+	require.NoError(t, index.Sync(ctx, "u-1"))
+	assert.Empty(t, cluster.docs("users"))
+	require.NoError(t, index.Sync(ctx, "u-1"))
+}
+
+func TestUserIndex_Reindex(t *testing.T) {
+	index, cluster, db := setupUserIndex(t)
+	ctx := context.Background()
+
+	require.NoError(t, index.Ensure(ctx))
+	old := cluster.aliases["users"]
+	cluster.indices[old].docs["gone"] = fakeDoc{source: userDocument{TenantID: tenant.DefaultID, Name: "Gone"}}
+	cluster.indices[old].version = 0
+
+	createUsers(t, db,
+		&user.User{ID: "u-1", TenantID: tenant.DefaultID, Name: "Alice", Email: "alice@example.com"},
+		&user.User{ID: "u-2", TenantID: "acme", Name: "Bob", Email: "bob@example.com"},
+		&user.User{ID: "u-3", TenantID: "acme", Name: "Carol", Email: "carol@example.com"},
+	)
+
+	time.Sleep(2 * time.Millisecond) // a distinct index name
+	indexed, err := index.Reindex(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, indexed)
+
+	current := cluster.aliases["users"]
+	assert.NotEqual(t, old, current)
+	assert.NotContains(t, cluster.indices, old, "the replaced index is deleted")
+	assert.Equal(t, mappingVersion, cluster.indices[current].version)
+	docs := cluster.docs("users")
+	assert.Len(t, docs, 3)
+	assert.Equal(t, "acme", docs["u-3"].source.TenantID)
+}
+
+func TestUserIndex_Search(t *testing.T) {
+	index, cluster, db := setupUserIndex(t)
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	createUsers(t, db,
+		&user.User{ID: "u-1", TenantID: "acme", Name: "Alice Smith", Email: "alice@example.com"},
+		&user.User{ID: "u-2", TenantID: "acme", Name: "Alicia Keys", Email: "keys@example.com"},
+		&user.User{ID: "u-3", TenantID: "acme", Name: "Bob Brown", Email: "bob@example.com"},
+		&user.User{ID: "u-4", TenantID: tenant.DefaultID, Name: "Alice Other", Email: "alice@other.com"},
+	)
+	_, err := index.Reindex(ctx)
+	require.NoError(t, err)
+
+	result, err := index.Search(ctx, []string{"ali"}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	require.Len(t, result.Hits, 2)
+	assert.Equal(t, "u-1", result.Hits[0].User.ID)
+	assert.Equal(t, "Alice Smith", result.Hits[0].User.Name, "users are loaded from the database")
+	assert.Equal(t, "u-2", result.Hits[1].User.ID)
+
+	result, err = index.Search(ctx, []string{"alice"}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, 2.0, result.Hits[0].Rank)
+
+	query := cluster.searches[len(cluster.searches)-1]
+	assert.Equal(t, true, query["track_total_hits"])
+	assert.Equal(t, float64(0), query["from"])
+
+	// Users deleted since they were indexed are left out
+	require.NoError(t, db.Delete(&user.User{}, "id = ?", "u-1").Error)
+	result, err = index.Search(ctx, []string{"ali"}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "u-2", result.Hits[0].User.ID)
+
+	_, err = index.Search(ctx, []string{"ali"}, 101, 100)
+	assert.ErrorContains(t, err, "page")
+}
+
+func TestSyncer(t *testing.T) {
+	index, cluster, db := setupUserIndex(t)
+	ctx := context.Background()
+	createUsers(t, db, &user.User{ID: "u-1", TenantID: "acme", Name: "Alice", Email: "alice@example.com"})
+
+	syncer := NewSyncer(index)
+	sink := syncer.Sink()
+	require.NoError(t, sink.Deliver(ctx, event.OutboxMessage{EventName: "webhook.disabled", AggregateID: "u-1"}))
+	assert.Zero(t, syncer.Pending(), "other events are ignored")
+
+	require.NoError(t, sink.Deliver(ctx, event.OutboxMessage{EventName: user.EventUserRegistered, AggregateID: "u-1"}))
+	require.NoError(t, sink.Deliver(ctx, event.OutboxMessage{EventName: user.EventUserNameChanged, AggregateID: "u-1"}))
+	assert.Equal(t, 1, syncer.Pending(), "a user is queued once")
+	assert.Equal(t, 1, syncer.SyncDue(ctx))
+	assert.Contains(t, cluster.docs("users"), "u-1")
+
+	require.NoError(t, db.Delete(&user.User{}, "id = ?", "u-1").Error)
+	require.NoError(t, syncer.Handler()(ctx, user.UserDeleted{Base: event.NewBase("u-1")}))
+	assert.Equal(t, 1, syncer.SyncDue(ctx))
+	assert.Empty(t, cluster.docs("users"))
+}
+
+func TestSyncer_Outage(t *testing.T) {
+	index, cluster, db := setupUserIndex(t)
+	ctx := context.Background()
+	createUsers(t, db, &user.User{ID: "u-1", TenantID: "acme", Name: "Alice", Email: "alice@example.com"},
+		&user.User{ID: "u-2", TenantID: "acme", Name: "Bob", Email: "bob@example.com"})
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	syncer := NewSyncer(index, WithSyncBackoff(time.Second, time.Minute), WithSyncMaxPending(1))
+	syncer.now = func() time.Time { return now }
+
+	// The relay's delivery succeeds while the cluster is down, so the
+	// message is not delivered to the other sinks again
+	cluster.mu.Lock()
+	cluster.down = true
+	cluster.mu.Unlock()
+	require.NoError(t, syncer.Sink().Deliver(ctx, event.OutboxMessage{EventName: user.EventUserRegistered, AggregateID: "u-1"}))
+	assert.Zero(t, syncer.SyncDue(ctx))
+	assert.Equal(t, 1, syncer.Pending(), "the failed sync is queued for a retry")
+
+	// A full queue drops users rather than growing
+	syncer.Queue(ctx, "u-2")
+	assert.Equal(t, int64(1), syncer.Dropped())
+
+	cluster.mu.Lock()
+	cluster.down = false
+	cluster.mu.Unlock()
+	assert.Zero(t, syncer.SyncDue(ctx), "the retry waits for its backoff")
+	now = now.Add(time.Second)
+	assert.Equal(t, 1, syncer.SyncDue(ctx))
+	assert.Zero(t, syncer.Pending())
+	assert.Contains(t, cluster.docs("users"), "u-1")
+}
@@ -0,0 +1,215 @@
+package security
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/metrics"
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/retry"
+)
+
+const (
+	hibpServiceName = "hibp"
+	hashPrefixLen   = 5
+	maxCacheEntries = 4096
+)
+
+// HIBPBreachChecker queries the HaveIBeenPwned range API using the
+// k-anonymity model: only the first five hex characters of the SHA-1 hash
+// leave the process, and matching happens locally against the returned
+// suffix list.
+type HIBPBreachChecker struct {
+	httpClient *http.Client
+	apiURL     string
+	cacheTTL   time.Duration
+	retry      retry.Policy
+	log        logger.Logger
+
+	breaker *circuitbreaker.Breaker
+
+	mu    sync.Mutex
+	cache map[string]rangeEntry
+}
+
+type rangeEntry struct {
+	suffixes  map[string]int
+	expiresAt time.Time
+}
+
+// BreachCheckerOption configures optional HIBPBreachChecker behaviour
+type BreachCheckerOption func(*HIBPBreachChecker)
+
+// WithRetryPolicy retries transient range query failures. The circuit
+// breaker counts a call as one failure only once its retries are used up.
+func WithRetryPolicy(policy retry.Policy) BreachCheckerOption {
+	return func(c *HIBPBreachChecker) {
+		c.retry = metrics.InstrumentRetry(policy, "hibp.range_query")
+	}
+}
+
+// NewHIBPBreachChecker creates a breach checker from configuration that
+// calls the API through httpClient, normally the application's outbound
+// client. A nil httpClient uses a bare client with the configured timeout.
+func NewHIBPBreachChecker(cfg *config.PasswordBreachConfig, httpClient *http.Client, opts ...BreachCheckerOption) *HIBPBreachChecker {
+	if cfg == nil {
+		panic("password breach config cannot be nil")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	log := logger.Get().WithLayer("infrastructure").WithComponent("breach_checker")
+	c := &HIBPBreachChecker{
+		httpClient: httpClient,
+		apiURL:     strings.TrimRight(cfg.APIURL.String(), "/"),
+		cacheTTL:   cfg.CacheTTL,
+		log:        log,
+		cache:      make(map[string]rangeEntry),
+		breaker: circuitbreaker.New(metrics.InstrumentBreaker(circuitbreaker.Settings{
+			Name:             hibpServiceName,
+			FailureThreshold: cfg.FailureThreshold,
+			OpenTimeout:      cfg.OpenTimeout,
+			OnStateChange: func(name string, from, to circuitbreaker.State) {
+				log.Warn(context.Background(), "breach check circuit changed state", "from", from.String(), "to", to.String())
+			},
+		})),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// BreachCount returns how many times the password appears in known breaches
+func (c *HIBPBreachChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:hashPrefixLen], hash[hashPrefixLen:]
+
+	if suffixes, ok := c.cached(prefix); ok {
+		return suffixes[suffix], nil
+	}
+
+	var suffixes map[string]int
+	err := c.breaker.Execute(func() error {
+		var err error
+		suffixes, err = retry.DoValue(ctx, c.retry, func(ctx context.Context) (map[string]int, error) {
+			return c.fetchRange(ctx, prefix)
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.store(prefix, suffixes)
+	return suffixes[suffix], nil
+}
+
+// Breaker returns the circuit breaker guarding the range API
+func (c *HIBPBreachChecker) Breaker() *circuitbreaker.Breaker {
+	return c.breaker
+}
+
+// fetchRange retrieves all hash suffixes sharing the given prefix
+func (c *HIBPBreachChecker) fetchRange(ctx context.Context, prefix string) (map[string]int, error) {
+	endpoint := fmt.Sprintf("%s/range/%s", c.apiURL, prefix)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, wonderErrors.NewExternalServiceError(hibpServiceName, "range_query", 0, "", err, false)
+	}
+	// Padding hides the real response size from network observers
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "wonder-password-check")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, wonderErrors.NewExternalServiceError(hibpServiceName, "range_query", 0, "", err, true)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return nil, wonderErrors.NewExternalServiceError(hibpServiceName, "range_query", resp.StatusCode, "",
+			fmt.Errorf("unexpected status %d", resp.StatusCode), retryable)
+	}
+
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		count, err := strconv.Atoi(parts[1])
+		if err != nil || count == 0 {
+			continue // padding entries carry a zero count
+		}
+		suffixes[strings.ToUpper(parts[0])] = count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, wonderErrors.NewExternalServiceError(hibpServiceName, "range_query", resp.StatusCode, "", err, true)
+	}
+
+	return suffixes, nil
+}
+
+func (c *HIBPBreachChecker) cached(prefix string) (map[string]int, bool) {
+	if c.cacheTTL <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.cache[prefix]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.cache, prefix)
+		return nil, false
+	}
+	return entry.suffixes, true
+}
+
+func (c *HIBPBreachChecker) store(prefix string, suffixes map[string]int) {
+	if c.cacheTTL <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.cache) >= maxCacheEntries {
+		now := time.Now()
+		for k, v := range c.cache {
+			if now.After(v.expiresAt) {
+				delete(c.cache, k)
+			}
+		}
+		// Still full: drop an arbitrary entry to bound memory
+		for k := range c.cache {
+			if len(c.cache) < maxCacheEntries {
+				break
+			}
+			delete(c.cache, k)
+		}
+	}
+
+	c.cache[prefix] = rangeEntry{suffixes: suffixes, expiresAt: time.Now().Add(c.cacheTTL)}
+}
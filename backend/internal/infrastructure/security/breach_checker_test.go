@@ -0,0 +1,131 @@
+package security
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/retry"
+)
+
+func hashParts(password string) (string, string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return hash[:5], hash[5:]
+}
+
+func testBreachConfig(serverURL string) *config.PasswordBreachConfig {
+	apiURL, err := url.Parse(serverURL)
+	if err != nil {
+		panic(err)
+	}
+	cfg := config.DefaultSecurityConfig().PasswordBreach
+	cfg.Enabled = true
+	cfg.APIURL = *apiURL
+	cfg.FailureThreshold = 2
+	cfg.OpenTimeout = time.Minute
+	return cfg
+}
+
+func TestHIBPBreachChecker_BreachCount(t *testing.T) {
+	logger.Initialize()
+
+	prefix, suffix := hashParts("password123")
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// Only the 5-character prefix may leave the process
+		assert.Equal(t, "/range/"+prefix, r.URL.Path)
+		assert.Equal(t, "true", r.Header.Get("Add-Padding"))
+		fmt.Fprintf(w, "0000000000000000000000000000000000A:0\r\n%s:1234\r\n", suffix)
+	}))
+	defer server.Close()
+
+	checker := NewHIBPBreachChecker(testBreachConfig(server.URL), nil)
+
+	count, err := checker.BreachCount(context.Background(), "password123")
+	require.NoError(t, err)
+	assert.Equal(t, 1234, count)
+
+	// Second lookup for the same prefix is served from cache
+	count, err = checker.BreachCount(context.Background(), "password123")
+	require.NoError(t, err)
+	assert.Equal(t, 1234, count)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestHIBPBreachChecker_NotFound(t *testing.T) {
+	logger.Initialize()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "0000000000000000000000000000000000A:3\r\n")
+	}))
+	defer server.Close()
+
+	checker := NewHIBPBreachChecker(testBreachConfig(server.URL), nil)
+
+	count, err := checker.BreachCount(context.Background(), "a-unique-passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestHIBPBreachChecker_CircuitBreaker(t *testing.T) {
+	logger.Initialize()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	checker := NewHIBPBreachChecker(testBreachConfig(server.URL), nil)
+
+	for i := 0; i < 2; i++ {
+		_, err := checker.BreachCount(context.Background(), fmt.Sprintf("pw-%d", i))
+		require.Error(t, err)
+	}
+
+	// Breaker is open: no further upstream calls
+	_, err := checker.BreachCount(context.Background(), "pw-3")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "circuit open")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestHIBPBreachChecker_Retry(t *testing.T) {
+	logger.Initialize()
+
+	_, suffix := hashParts("password123")
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "%s:7\r\n", suffix)
+	}))
+	defer server.Close()
+
+	policy := retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	checker := NewHIBPBreachChecker(testBreachConfig(server.URL), nil, WithRetryPolicy(policy))
+
+	count, err := checker.BreachCount(context.Background(), "password123")
+	require.NoError(t, err)
+	assert.Equal(t, 7, count)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,98 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func testCaptchaConfig(serverURL string) *config.CaptchaConfig {
+	verifyURL, err := url.Parse(serverURL)
+	if err != nil {
+		panic(err)
+	}
+	cfg := config.DefaultSecurityConfig().Captcha
+	cfg.Enabled = true
+	cfg.SecretKey = "secret"
+	cfg.VerifyURL = *verifyURL
+	return cfg
+}
+
+func TestSiteVerifyCaptchaVerifier_Verify(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "192.0.2.1", r.PostForm.Get("remoteip"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.PostForm.Get("response") {
+		case "solved":
+			w.Write([]byte(`{"success":true,"hostname":"example.com"}`))
+		case "bot":
+			w.Write([]byte(`{"success":true,"score":0.1}`))
+		default:
+			w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := testCaptchaConfig(server.URL)
+	cfg.MinScore = 0.5
+	verifier := NewSiteVerifyCaptchaVerifier(cfg, nil)
+
+	ok, err := verifier.Verify(ctx, "solved", "192.0.2.1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = verifier.Verify(ctx, "bot", "192.0.2.1")
+	require.NoError(t, err)
+	assert.False(t, ok, "scores below min_score are rejected")
+
+	ok, err = verifier.Verify(ctx, "forged", "192.0.2.1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestSiteVerifyCaptchaVerifier_BypassToken(t *testing.T) {
+	logger.Initialize()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"success":false}`))
+	}))
+	defer server.Close()
+
+	cfg := testCaptchaConfig(server.URL)
+	cfg.BypassToken = "test-pass"
+	verifier := NewSiteVerifyCaptchaVerifier(cfg, nil)
+
+	ok, err := verifier.Verify(context.Background(), "test-pass", "")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Zero(t, calls.Load(), "the bypass token is not sent to the provider")
+}
+
+func TestSiteVerifyCaptchaVerifier_Unavailable(t *testing.T) {
+	logger.Initialize()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := NewSiteVerifyCaptchaVerifier(testCaptchaConfig(server.URL), nil).Verify(context.Background(), "solved", "")
+	var external *wonderErrors.ExternalServiceError
+	require.ErrorAs(t, err, &external)
+	assert.True(t, external.IsRetryable)
+}
@@ -0,0 +1,89 @@
+package security
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+)
+
+// DisposableDomainList is a fixed set of disposable email domains.
+// Subdomains of a listed domain are disposable too.
+type DisposableDomainList struct {
+	domains map[string]struct{}
+}
+
+var _ user.DisposableDomainProvider = (*DisposableDomainList)(nil)
+
+// NewDisposableDomainList creates a list from domains; case and a leading
+// "@" or "." are ignored
+func NewDisposableDomainList(domains ...string) *DisposableDomainList {
+	l := &DisposableDomainList{domains: make(map[string]struct{}, len(domains))}
+	l.Add(domains...)
+	return l
+}
+
+// Add adds domains to the list
+func (l *DisposableDomainList) Add(domains ...string) {
+	for _, d := range domains {
+		d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), "@.")
+		if d != "" {
+			l.domains[d] = struct{}{}
+		}
+	}
+}
+
+// Len returns how many domains are listed
+func (l *DisposableDomainList) Len() int {
+	return len(l.domains)
+}
+
+// IsDisposable implements user.DisposableDomainProvider
+func (l *DisposableDomainList) IsDisposable(_ context.Context, domain string) (bool, error) {
+	for d := domain; d != ""; {
+		if _, ok := l.domains[d]; ok {
+			return true, nil
+		}
+		i := strings.IndexByte(d, '.')
+		if i < 0 {
+			break
+		}
+		d = d[i+1:]
+	}
+	return false, nil
+}
+
+// ReadDisposableDomains reads one domain per line. Blank lines and lines
+// starting with "#" are skipped, the format of the widely shared
+// disposable-email-domains blocklists.
+func ReadDisposableDomains(r io.Reader) ([]string, error) {
+	var domains []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	return domains, scanner.Err()
+}
+
+// LoadDisposableDomainFile reads the domains listed in the file at path
+func LoadDisposableDomainFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open disposable domain list: %w", err)
+	}
+	defer f.Close()
+
+	domains, err := ReadDisposableDomains(f)
+	if err != nil {
+		return nil, fmt.Errorf("read disposable domain list %s: %w", path, err)
+	}
+	return domains, nil
+}
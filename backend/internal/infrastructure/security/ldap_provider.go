@@ -0,0 +1,143 @@
+package security
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const ldapServiceName = "ldap"
+
+// LDAPAuthProvider checks passwords against an LDAP or Active Directory
+// server. It searches for the user's entry as the service account, then
+// binds as that entry with the given password; a fresh connection is used
+// per login so binds never leak between users.
+type LDAPAuthProvider struct {
+	cfg       *config.LDAPConfig
+	tlsConfig *tls.Config
+	log       logger.Logger
+}
+
+// NewLDAPAuthProvider creates an LDAP provider from configuration
+func NewLDAPAuthProvider(cfg *config.LDAPConfig) *LDAPAuthProvider {
+	if cfg == nil {
+		panic("ldap config cannot be nil")
+	}
+
+	return &LDAPAuthProvider{
+		cfg: cfg,
+		tlsConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			// StartTLS verifies the certificate against ServerName, which
+			// ldaps would otherwise take from the address it dials
+			ServerName:         cfg.URL.Hostname(),
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+		},
+		log: logger.Get().WithLayer("infrastructure").WithComponent("ldap_auth_provider"),
+	}
+}
+
+// Name implements user.AuthProvider
+func (p *LDAPAuthProvider) Name() string {
+	return ldapServiceName
+}
+
+// Authenticate implements user.AuthProvider
+func (p *LDAPAuthProvider) Authenticate(ctx context.Context, login, password string) (*user.Identity, error) {
+	// An empty password would be an unauthenticated bind, which servers
+	// accept for any DN
+	if password == "" {
+		return nil, wonderErrors.NewUnauthorizedError("ldap_login", "", "invalid credentials")
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		return nil, p.unavailable("dial", err)
+	}
+	defer conn.Close()
+	// go-ldap has no context support; closing the connection ends any
+	// request still waiting when ctx does
+	stop := context.AfterFunc(ctx, conn.Close)
+	defer stop()
+
+	if p.cfg.StartTLS {
+		if err := conn.StartTLS(p.tlsConfig); err != nil {
+			return nil, p.unavailable("start_tls", err)
+		}
+	}
+
+	if p.cfg.BindDN != "" {
+		if err := conn.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+			return nil, p.unavailable("service_bind", err)
+		}
+	}
+
+	attributes := []string{p.cfg.EmailAttribute}
+	if p.cfg.NameAttribute != "" {
+		attributes = append(attributes, p.cfg.NameAttribute)
+	}
+	result, err := conn.Search(ldap.NewSearchRequest(
+		p.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(p.cfg.Timeout.Seconds()), false,
+		strings.ReplaceAll(p.cfg.UserFilter, "{login}", ldap.EscapeFilter(login)),
+		attributes, nil,
+	))
+	// Hitting the size limit of 2 already means the match is ambiguous
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, p.unavailable("search", err)
+	}
+	if result == nil || len(result.Entries) != 1 {
+		// Zero or ambiguous matches are both a failed login; a filter that
+		// matches several entries needs fixing, so say so in the logs
+		if result != nil && len(result.Entries) > 1 {
+			p.log.Warn(ctx, "ldap user filter matched several entries", "login", login)
+		}
+		return nil, wonderErrors.NewUnauthorizedError("ldap_login", "", "invalid credentials")
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, wonderErrors.NewUnauthorizedError("ldap_login", "", "invalid credentials")
+		}
+		return nil, p.unavailable("user_bind", err)
+	}
+
+	email := strings.ToLower(strings.TrimSpace(entry.GetEqualFoldAttributeValue(p.cfg.EmailAttribute)))
+	if email == "" {
+		p.log.Warn(ctx, "ldap entry has no email attribute", "dn", entry.DN, "attribute", p.cfg.EmailAttribute)
+		return nil, wonderErrors.NewUnauthorizedError("ldap_login", "", "directory entry has no email address")
+	}
+
+	identity := &user.Identity{Subject: entry.DN, Email: email}
+	if p.cfg.NameAttribute != "" {
+		identity.Name = strings.TrimSpace(entry.GetEqualFoldAttributeValue(p.cfg.NameAttribute))
+	}
+	return identity, nil
+}
+
+// dial connects to the directory. Dialing and every request are bounded by
+// the configured timeout.
+func (p *LDAPAuthProvider) dial() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(p.cfg.URL.String(),
+		ldap.DialWithDialer(&net.Dialer{Timeout: p.cfg.Timeout}),
+		ldap.DialWithTLSConfig(p.tlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(p.cfg.Timeout)
+	return conn, nil
+}
+
+func (p *LDAPAuthProvider) unavailable(operation string, err error) error {
+	return wonderErrors.NewExternalServiceError(ldapServiceName, operation, 0, "", err, true)
+}
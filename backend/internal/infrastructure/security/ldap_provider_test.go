@@ -0,0 +1,190 @@
+package security
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+type fakeEntry struct {
+	dn    string
+	attrs map[string]string
+}
+
+// fakeDirectory is an in-process LDAP server. Searches are answered by
+// their exact filter, and binds succeed for the DNs in passwords.
+type fakeDirectory struct {
+	passwords map[string]string
+	searches  map[string][]fakeEntry
+}
+
+func (d *fakeDirectory) serve(t *testing.T) url.URL {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go d.handle(conn)
+		}
+	}()
+	return url.URL{Scheme: "ldap", Host: ln.Addr().String()}
+}
+
+func (d *fakeDirectory) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil || len(request.Children) < 2 {
+			return
+		}
+		id := request.Children[0].Value.(int64)
+		op := request.Children[1]
+		reply := func(resp *ber.Packet) {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+			envelope.AppendChild(resp)
+			_, _ = conn.Write(envelope.Bytes())
+		}
+		result := func(tag ber.Tag, code uint16) *ber.Packet {
+			resp := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+			resp.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+			resp.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+			resp.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+			return resp
+		}
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if want, ok := d.passwords[dn]; ok && want == password {
+				code = ldap.LDAPResultSuccess
+			}
+			reply(result(ldap.ApplicationBindResponse, code))
+		case ldap.ApplicationSearchRequest:
+			filter, err := ldap.DecompileFilter(op.Children[6])
+			if err != nil {
+				reply(result(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError))
+				continue
+			}
+			sizeLimit := int(op.Children[3].Value.(int64))
+			entries := d.searches[filter]
+			code := uint16(ldap.LDAPResultSuccess)
+			if sizeLimit > 0 && len(entries) > sizeLimit {
+				entries, code = entries[:sizeLimit], ldap.LDAPResultSizeLimitExceeded
+			}
+			for _, entry := range entries {
+				resp := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+				resp.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, ""))
+				attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				for name, value := range entry.attrs {
+					attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+					attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+					values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+					values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+					attr.AppendChild(values)
+					attrs.AppendChild(attr)
+				}
+				resp.AppendChild(attrs)
+				reply(resp)
+			}
+			reply(result(ldap.ApplicationSearchResultDone, code))
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func TestLDAPAuthProvider_Authenticate(t *testing.T) {
+	logger.Initialize()
+	jane := fakeEntry{dn: "uid=jane,ou=people,dc=example,dc=com", attrs: map[string]string{"mail": "Jane@Example.com", "cn": "Jane Doe"}}
+	dir := &fakeDirectory{
+		passwords: map[string]string{
+			"cn=reader,dc=example,dc=com": "reader-secret",
+			jane.dn:                       "jane-secret",
+		},
+		searches: map[string][]fakeEntry{
+			"(&(objectClass=person)(mail=jane@example.com))": {jane},
+			"(&(objectClass=person)(mail=team@example.com))": {jane, jane, jane},
+		},
+	}
+
+	cfg := config.DefaultAuthConfig().LDAP
+	cfg.URL = dir.serve(t)
+	cfg.BindDN = "cn=reader,dc=example,dc=com"
+	cfg.BindPassword = "reader-secret"
+	cfg.BaseDN = "dc=example,dc=com"
+	cfg.UserFilter = "(&(objectClass=person)(mail={login}))"
+	cfg.EmailAttribute = "mail"
+	cfg.NameAttribute = "CN"
+	cfg.Timeout = time.Second
+	provider := NewLDAPAuthProvider(cfg)
+	ctx := context.Background()
+
+	identity, err := provider.Authenticate(ctx, "jane@example.com", "jane-secret")
+	require.NoError(t, err)
+	assert.Equal(t, jane.dn, identity.Subject)
+	assert.Equal(t, "jane@example.com", identity.Email)
+	assert.Equal(t, "Jane Doe", identity.Name)
+
+	// Wrong passwords, unknown or ambiguous logins, filter metacharacters
+	// and empty passwords are all rejected credentials
+	for _, attempt := range []struct{ login, password string }{
+		{"jane@example.com", "wrong"},
+		{"nobody@example.com", "jane-secret"},
+		{"team@example.com", "jane-secret"},
+		{"*", "jane-secret"},
+		{"jane@example.com", ""},
+	} {
+		_, err := provider.Authenticate(ctx, attempt.login, attempt.password)
+		var unauthorized *wonderErrors.UnauthorizedError
+		assert.ErrorAs(t, err, &unauthorized, attempt.login)
+	}
+
+	// A service account the directory refuses is an outage, not a bad login
+	cfg.BindPassword = "stale"
+	_, err = NewLDAPAuthProvider(cfg).Authenticate(ctx, "jane@example.com", "jane-secret")
+	var external *wonderErrors.ExternalServiceError
+	assert.ErrorAs(t, err, &external)
+}
+
+func TestLDAPAuthProvider_DirectoryUnavailable(t *testing.T) {
+	logger.Initialize()
+
+	// Reserve a port and release it so nothing is listening there
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	cfg := config.DefaultAuthConfig().LDAP
+	cfg.URL = url.URL{Scheme: "ldap", Host: addr}
+	cfg.BaseDN = "dc=example,dc=com"
+	cfg.Timeout = time.Second
+
+	provider := NewLDAPAuthProvider(cfg)
+	assert.Equal(t, "ldap", provider.Name())
+
+	_, err = provider.Authenticate(context.Background(), "alice@example.com", "secret")
+	var external *wonderErrors.ExternalServiceError
+	require.ErrorAs(t, err, &external)
+	assert.True(t, external.IsRetryable)
+}
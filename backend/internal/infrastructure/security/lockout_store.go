@@ -0,0 +1,200 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+const (
+	failureKeyPrefix = "wonder:login:failures:"
+	lockKeyPrefix    = "wonder:login:lock:"
+)
+
+// MemoryLoginAttemptStore keeps login failure counters in process. Counts
+// are not shared between instances; use the Redis store when running more
+// than one.
+type MemoryLoginAttemptStore struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	failures map[string]counter
+	locks    map[string]time.Time
+}
+
+type counter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+var _ user.LoginAttemptStore = (*MemoryLoginAttemptStore)(nil)
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		now:      time.Now,
+		failures: make(map[string]counter),
+		locks:    make(map[string]time.Time),
+	}
+}
+
+// RecordFailure implements user.LoginAttemptStore
+func (s *MemoryLoginAttemptStore) RecordFailure(_ context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+
+	c := s.failures[key]
+	if c.count == 0 {
+		c.expiresAt = now.Add(window)
+	}
+	c.count++
+	s.failures[key] = c
+	return c.count, nil
+}
+
+// Lock implements user.LoginAttemptStore
+func (s *MemoryLoginAttemptStore) Lock(_ context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locks[key] = s.now().Add(d)
+	delete(s.failures, key)
+	return nil
+}
+
+// LockedFor implements user.LoginAttemptStore
+func (s *MemoryLoginAttemptStore) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.locks[key]
+	if !ok {
+		return 0, nil
+	}
+	remaining := until.Sub(s.now())
+	if remaining <= 0 {
+		delete(s.locks, key)
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+// Reset implements user.LoginAttemptStore
+func (s *MemoryLoginAttemptStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+// Failures implements user.LoginAttemptStore
+func (s *MemoryLoginAttemptStore) Failures(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.failures[key]
+	if !ok || !s.now().Before(c.expiresAt) {
+		return 0, nil
+	}
+	return c.count, nil
+}
+
+// prune drops expired entries so abandoned keys do not accumulate
+func (s *MemoryLoginAttemptStore) prune(now time.Time) {
+	for k, c := range s.failures {
+		if !now.Before(c.expiresAt) {
+			delete(s.failures, k)
+		}
+	}
+	for k, until := range s.locks {
+		if !now.Before(until) {
+			delete(s.locks, k)
+		}
+	}
+}
+
+// RedisLoginAttemptStore keeps login failure counters in Redis so every
+// instance sees the same counts. Counters expire one window after the first
+// failure, which lets old failures decay without a cleanup job.
+type RedisLoginAttemptStore struct {
+	client *redis.Client
+}
+
+var _ user.LoginAttemptStore = (*RedisLoginAttemptStore)(nil)
+
+func NewRedisLoginAttemptStore(client *redis.Client) *RedisLoginAttemptStore {
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	return &RedisLoginAttemptStore{client: client}
+}
+
+// RecordFailure implements user.LoginAttemptStore
+func (s *RedisLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	count, err := s.client.Int(ctx, "INCR", failureKeyPrefix+key)
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if _, err := s.client.Int(ctx, "PEXPIRE", failureKeyPrefix+key, millis(window)); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// Lock implements user.LoginAttemptStore
+func (s *RedisLoginAttemptStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	if _, err := s.client.String(ctx, "SET", lockKeyPrefix+key, "1", "PX", millis(d)); err != nil {
+		return err
+	}
+	_, err := s.client.Int(ctx, "DEL", failureKeyPrefix+key)
+	return err
+}
+
+// LockedFor implements user.LoginAttemptStore
+func (s *RedisLoginAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.Int(ctx, "PTTL", lockKeyPrefix+key)
+	if err != nil {
+		return 0, err
+	}
+	// -2 means no such key, -1 a key without expiry; neither is a lockout
+	if ttl <= 0 {
+		return 0, nil
+	}
+	return time.Duration(ttl) * time.Millisecond, nil
+}
+
+// Reset implements user.LoginAttemptStore
+func (s *RedisLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	_, err := s.client.Int(ctx, "DEL", failureKeyPrefix+key)
+	return err
+}
+
+// Failures implements user.LoginAttemptStore
+func (s *RedisLoginAttemptStore) Failures(ctx context.Context, key string) (int64, error) {
+	reply, err := s.client.String(ctx, "GET", failureKeyPrefix+key)
+	if errors.Is(err, redis.ErrNil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(reply, 10, 64)
+}
+
+func millis(d time.Duration) string {
+	ms := d.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return strconv.FormatInt(ms, 10)
+}
@@ -0,0 +1,85 @@
+package security
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+func TestLoginAttemptStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) user.LoginAttemptStore{
+		"memory": func(t *testing.T) user.LoginAttemptStore {
+			return NewMemoryLoginAttemptStore()
+		},
+		"redis": func(t *testing.T) user.LoginAttemptStore {
+			client := redis.NewClient(redis.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { client.Close() })
+			return NewRedisLoginAttemptStore(client)
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			ctx := context.Background()
+
+			for want := int64(1); want <= 3; want++ {
+				n, err := store.RecordFailure(ctx, "account:a@example.com", time.Minute)
+				require.NoError(t, err)
+				assert.Equal(t, want, n)
+			}
+
+			failures, err := store.Failures(ctx, "account:a@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), failures)
+			failures, err = store.Failures(ctx, "account:nobody@example.com")
+			require.NoError(t, err)
+			assert.Zero(t, failures)
+
+			require.NoError(t, store.Reset(ctx, "account:a@example.com"))
+			n, err := store.RecordFailure(ctx, "account:a@example.com", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n, "reset clears the count")
+
+			locked, err := store.LockedFor(ctx, "account:a@example.com")
+			require.NoError(t, err)
+			assert.Zero(t, locked)
+
+			require.NoError(t, store.Lock(ctx, "account:a@example.com", time.Minute))
+			locked, err = store.LockedFor(ctx, "account:a@example.com")
+			require.NoError(t, err)
+			assert.InDelta(t, time.Minute, locked, float64(time.Second))
+
+			n, err = store.RecordFailure(ctx, "account:a@example.com", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n, "locking clears the count")
+		})
+	}
+}
+
+func TestMemoryLoginAttemptStore_Decay(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryLoginAttemptStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	store.RecordFailure(ctx, "ip:10.0.0.1", time.Minute)
+	store.RecordFailure(ctx, "ip:10.0.0.1", time.Minute)
+	require.NoError(t, store.Lock(ctx, "account:b@example.com", 30*time.Second))
+
+	now = now.Add(time.Minute)
+	n, err := store.RecordFailure(ctx, "ip:10.0.0.1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "failures expire after the window")
+
+	locked, err := store.LockedFor(ctx, "account:b@example.com")
+	require.NoError(t, err)
+	assert.Zero(t, locked, "locks expire after their duration")
+}
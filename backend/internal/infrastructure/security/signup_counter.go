@@ -0,0 +1,131 @@
+package security
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+const signupKeyPrefix = "wonder:signup:"
+
+// MemorySignupCounter counts registrations in process. Counts are not
+// shared between instances; use the Redis counter when running more than
+// one.
+type MemorySignupCounter struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	counts map[string]counter
+}
+
+var _ user.SignupCounter = (*MemorySignupCounter)(nil)
+
+func NewMemorySignupCounter() *MemorySignupCounter {
+	return &MemorySignupCounter{
+		now:    time.Now,
+		counts: make(map[string]counter),
+	}
+}
+
+// Increment implements user.SignupCounter
+func (s *MemorySignupCounter) Increment(_ context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, c := range s.counts {
+		if !now.Before(c.expiresAt) {
+			delete(s.counts, k)
+		}
+	}
+
+	c := s.counts[key]
+	if c.count == 0 {
+		c.expiresAt = now.Add(window)
+	}
+	c.count++
+	s.counts[key] = c
+	return c.count, c.expiresAt.Sub(now), nil
+}
+
+// Release implements user.SignupCounter
+func (s *MemorySignupCounter) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counts[key]
+	if !ok {
+		return nil
+	}
+	c.count--
+	if c.count <= 0 {
+		delete(s.counts, key)
+		return nil
+	}
+	s.counts[key] = c
+	return nil
+}
+
+// RedisSignupCounter counts registrations in Redis so every instance sees
+// the same counts. Counters expire one window after the first registration.
+type RedisSignupCounter struct {
+	client *redis.Client
+}
+
+var _ user.SignupCounter = (*RedisSignupCounter)(nil)
+
+func NewRedisSignupCounter(client *redis.Client) *RedisSignupCounter {
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	return &RedisSignupCounter{client: client}
+}
+
+// Increment implements user.SignupCounter
+func (s *RedisSignupCounter) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	count, err := s.client.Int(ctx, "INCR", signupKeyPrefix+key)
+	if err != nil {
+		return 0, 0, err
+	}
+	if count == 1 {
+		if _, err := s.client.Int(ctx, "PEXPIRE", signupKeyPrefix+key, millis(window)); err != nil {
+			return count, window, err
+		}
+		return count, window, nil
+	}
+
+	ttl, err := s.client.Int(ctx, "PTTL", signupKeyPrefix+key)
+	if err != nil {
+		return count, 0, err
+	}
+	// A counter left without expiry, e.g. when PEXPIRE failed, would throttle
+	// forever; give it a fresh window
+	if ttl == -1 {
+		if _, err := s.client.Int(ctx, "PEXPIRE", signupKeyPrefix+key, millis(window)); err != nil {
+			return count, window, err
+		}
+		return count, window, nil
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	return count, time.Duration(ttl) * time.Millisecond, nil
+}
+
+// signupReleaseScript decrements a counter only while it is positive, so
+// a release racing the expiry cannot leave a negative count without a TTL
+var signupReleaseScript = redis.NewScript(`
+local count = tonumber(redis.call('GET', KEYS[1]) or '0')
+if count > 0 then
+	return redis.call('DECR', KEYS[1])
+end
+return 0`)
+
+// Release implements user.SignupCounter
+func (s *RedisSignupCounter) Release(ctx context.Context, key string) error {
+	_, err := signupReleaseScript.Run(ctx, s.client, []string{signupKeyPrefix + key})
+	return err
+}
@@ -0,0 +1,110 @@
+package security
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+func TestSignupCounters(t *testing.T) {
+	counters := map[string]func(t *testing.T) user.SignupCounter{
+		"memory": func(t *testing.T) user.SignupCounter {
+			return NewMemorySignupCounter()
+		},
+		"redis": func(t *testing.T) user.SignupCounter {
+			client := redis.NewClient(redis.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { client.Close() })
+			return NewRedisSignupCounter(client)
+		},
+	}
+
+	for name, newCounter := range counters {
+		t.Run(name, func(t *testing.T) {
+			counter := newCounter(t)
+			ctx := context.Background()
+
+			for want := int64(1); want <= 3; want++ {
+				n, ttl, err := counter.Increment(ctx, "ip:10.0.0.1", time.Minute)
+				require.NoError(t, err)
+				assert.Equal(t, want, n)
+				assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+			}
+
+			n, _, err := counter.Increment(ctx, "ip:10.0.0.2", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n, "keys are counted apart")
+
+			// Released slots are counted again; releasing never goes below zero
+			require.NoError(t, counter.Release(ctx, "ip:10.0.0.1"))
+			n, _, err = counter.Increment(ctx, "ip:10.0.0.1", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), n)
+			require.NoError(t, counter.Release(ctx, "ip:10.0.0.2"))
+			require.NoError(t, counter.Release(ctx, "ip:10.0.0.2"))
+			require.NoError(t, counter.Release(ctx, "ip:10.0.0.3"))
+			n, _, err = counter.Increment(ctx, "ip:10.0.0.2", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+		})
+	}
+
+	t.Run("memory counts expire", func(t *testing.T) {
+		counter := NewMemorySignupCounter()
+		now := time.Now()
+		counter.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		_, _, err := counter.Increment(ctx, "domain:example.com", time.Minute)
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+		n, ttl, err := counter.Increment(ctx, "domain:example.com", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		assert.Equal(t, time.Minute, ttl)
+	})
+}
+
+func TestDisposableDomainList(t *testing.T) {
+	ctx := context.Background()
+	list := NewDisposableDomainList("Mailinator.com", "@yopmail.com", " ")
+	assert.Equal(t, 2, list.Len())
+
+	for domain, want := range map[string]bool{
+		"mailinator.com":         true,
+		"mx.mailinator.com":      true,
+		"yopmail.com":            true,
+		"example.com":            false,
+		"notmailinator.com":      false,
+		"mailinator.com.evil.io": false,
+	} {
+		got, err := list.IsDisposable(ctx, domain)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, domain)
+	}
+}
+
+func TestLoadDisposableDomainFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# throwaway\n\nguerrillamail.com\n  trashmail.com \n"), 0o600))
+
+	domains, err := LoadDisposableDomainFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"guerrillamail.com", "trashmail.com"}, domains)
+
+	_, err = LoadDisposableDomainFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+
+	domains, err = ReadDisposableDomains(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, domains)
+}
@@ -0,0 +1,307 @@
+// Package webhooks sends queued webhook deliveries to their endpoints
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/transaction"
+	"github.com/cctw-zed/wonder/internal/domain/webhook"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 50
+	defaultMaxAttempts  = 8
+	defaultBaseBackoff  = 30 * time.Second
+	defaultMaxBackoff   = 6 * time.Hour
+	// defaultLease applies when the client has no timeout
+	defaultLease = 10 * time.Minute
+)
+
+// Option configures a Dispatcher
+type Option func(*Dispatcher)
+
+// WithPollInterval sets how often the dispatcher looks for due deliveries
+func WithPollInterval(d time.Duration) Option {
+	return func(p *Dispatcher) {
+		if d > 0 {
+			p.pollInterval = d
+		}
+	}
+}
+
+// WithBatchSize sets the maximum number of deliveries sent per poll
+func WithBatchSize(n int) Option {
+	return func(p *Dispatcher) {
+		if n > 0 {
+			p.batchSize = n
+		}
+	}
+}
+
+// WithMaxAttempts sets how many requests are sent before a delivery fails
+func WithMaxAttempts(n int) Option {
+	return func(p *Dispatcher) {
+		if n > 0 {
+			p.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets the exponential retry delay bounds
+func WithBackoff(base, max time.Duration) Option {
+	return func(p *Dispatcher) {
+		if base > 0 {
+			p.baseBackoff = base
+		}
+		if max >= p.baseBackoff {
+			p.maxBackoff = max
+		}
+	}
+}
+
+// WithLease sets how long claimed deliveries are reserved for the
+// dispatcher that claimed them. It must outlast sending a whole batch; a
+// delivery not recorded by then is sent again.
+func WithLease(d time.Duration) Option {
+	return func(p *Dispatcher) {
+		if d > 0 {
+			p.lease = d
+		}
+	}
+}
+
+// Dispatcher polls for due deliveries and POSTs them to their endpoints,
+// retrying failures with exponential backoff
+type Dispatcher struct {
+	repo   webhook.Repository
+	uow    transaction.UnitOfWork
+	client *http.Client
+	log    logger.Logger
+
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	lease        time.Duration
+
+	mu      sync.Mutex
+	running bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewDispatcher creates a webhook dispatcher. uow wraps each claim of a
+// batch; requests are sent after it commits, so no transaction or row lock
+// is held while endpoints respond. Without WithLease, claims last long
+// enough for every delivery of a batch to time out, plus a minute.
+func NewDispatcher(repo webhook.Repository, uow transaction.UnitOfWork, client *http.Client, opts ...Option) *Dispatcher {
+	if repo == nil {
+		panic("webhook repository cannot be nil")
+	}
+	if uow == nil {
+		panic("unit of work cannot be nil")
+	}
+	if client == nil {
+		panic("HTTP client cannot be nil")
+	}
+
+	p := &Dispatcher{
+		repo:         repo,
+		uow:          uow,
+		client:       client,
+		log:          logger.Get().WithLayer("infrastructure").WithComponent("webhook_dispatcher"),
+		pollInterval: defaultPollInterval,
+		batchSize:    defaultBatchSize,
+		maxAttempts:  defaultMaxAttempts,
+		baseBackoff:  defaultBaseBackoff,
+		maxBackoff:   defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.lease == 0 {
+		p.lease = defaultLease
+		if client.Timeout > 0 {
+			p.lease = time.Duration(p.batchSize)*client.Timeout + time.Minute
+		}
+	}
+	return p
+}
+
+// Start launches the polling loop. Calling Start on a running dispatcher is a no-op.
+func (p *Dispatcher) Start(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running {
+		return
+	}
+	p.running = true
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+
+	go p.loop(context.WithoutCancel(ctx), p.stop, p.done)
+	p.log.Info(ctx, "webhook dispatcher started", "poll_interval", p.pollInterval, "batch_size", p.batchSize)
+}
+
+// Stop ends the polling loop after the current batch, or when ctx expires
+func (p *Dispatcher) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.running {
+		p.mu.Unlock()
+		return nil
+	}
+	p.running = false
+	close(p.stop)
+	done := p.done
+	p.mu.Unlock()
+
+	select {
+	case <-done:
+		p.log.Info(ctx, "webhook dispatcher stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook dispatcher did not stop before deadline: %w", ctx.Err())
+	}
+}
+
+func (p *Dispatcher) loop(ctx context.Context, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain backlogs without waiting for the next tick
+		for {
+			n, err := p.RunOnce(ctx)
+			if err != nil {
+				p.log.Error(ctx, "webhook dispatch batch failed", "error", err)
+			}
+			if err != nil || n < p.batchSize {
+				break
+			}
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends one batch of due deliveries and returns how many it handled
+func (p *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	var deliveries []*webhook.Delivery
+	err := p.uow.Do(ctx, func(ctx context.Context) error {
+		now := time.Now().UTC()
+		var err error
+		deliveries, err = p.repo.ClaimDueDeliveries(ctx, now, now.Add(p.lease), p.batchSize)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	endpoints := make(map[string]*webhook.Endpoint)
+	for i, d := range deliveries {
+		endpoint, ok := endpoints[d.EndpointID]
+		if !ok {
+			if endpoint, err = p.repo.GetEndpoint(ctx, d.EndpointID); err != nil {
+				return i, err
+			}
+			endpoints[d.EndpointID] = endpoint
+		}
+		if err := p.attempt(ctx, endpoint, d); err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// attempt sends one delivery and records the outcome. Only bookkeeping
+// failures are returned; failed requests are scheduled for retry, and a
+// delivery whose outcome cannot be recorded is sent again when its lease
+// ends.
+func (p *Dispatcher) attempt(ctx context.Context, endpoint *webhook.Endpoint, d *webhook.Delivery) error {
+	now := time.Now().UTC()
+	if endpoint == nil {
+		d.MarkAttemptFailed(0, "endpoint no longer exists", now, nil)
+		return p.repo.UpdateDelivery(ctx, d)
+	}
+
+	status, sendErr := p.send(ctx, endpoint, d)
+	if sendErr == nil {
+		d.MarkSucceeded(status, time.Now().UTC())
+		p.log.Debug(ctx, "webhook delivered", "delivery_id", d.ID, "endpoint_id", endpoint.ID, "status", status)
+		return p.repo.UpdateDelivery(ctx, d)
+	}
+
+	var next *time.Time
+	if d.Attempts+1 < p.maxAttempts {
+		at := now.Add(p.backoff(d.Attempts + 1))
+		next = &at
+	}
+	d.MarkAttemptFailed(status, sendErr.Error(), now, next)
+	if next == nil {
+		p.log.Error(ctx, "webhook delivery exhausted attempts", "delivery_id", d.ID, "endpoint_id", endpoint.ID,
+			"event_type", d.EventType, "attempts", d.Attempts, "error", sendErr)
+	} else {
+		p.log.Warn(ctx, "webhook delivery failed, will retry", "delivery_id", d.ID, "endpoint_id", endpoint.ID,
+			"event_type", d.EventType, "attempts", d.Attempts, "next_attempt_at", *next, "error", sendErr)
+	}
+	return p.repo.UpdateDelivery(ctx, d)
+}
+
+// send POSTs the signed payload and returns the response status. Anything
+// but a 2xx response is an error.
+func (p *Dispatcher) send(ctx context.Context, endpoint *webhook.Endpoint, d *webhook.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wonder-webhooks")
+	req.Header.Set(webhook.HeaderDelivery, d.ID)
+	req.Header.Set(webhook.HeaderEvent, d.EventType)
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(endpoint.Secret, time.Now(), d.Payload))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the given attempt number
+func (p *Dispatcher) backoff(attempts int) time.Duration {
+	d := p.baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= p.maxBackoff {
+			return p.maxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,163 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/webhook"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/infrastructure/repository"
+	"github.com/cctw-zed/wonder/internal/testutil/dbtest"
+)
+
+func setupDispatcher(t *testing.T, handler http.HandlerFunc, opts ...Option) (*Dispatcher, webhook.Repository) {
+	db := dbtest.Open(t)
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	repo := repository.NewWebhookRepository(db)
+	ctx := context.Background()
+	require.NoError(t, repo.CreateEndpoint(ctx, &webhook.Endpoint{
+		ID:     "wh-1",
+		URL:    server.URL,
+		Secret: "0123456789abcdef",
+		Events: []string{webhook.EventUserCreated},
+	}))
+	due := time.Now().UTC().Add(-time.Second)
+	require.NoError(t, repo.CreateDeliveries(ctx, []*webhook.Delivery{{
+		ID:            "d-1",
+		EndpointID:    "wh-1",
+		EventType:     webhook.EventUserCreated,
+		EventID:       "evt-1",
+		Payload:       json.RawMessage(`{"id":"evt-1"}`),
+		Status:        webhook.StatusPending,
+		NextAttemptAt: &due,
+		CreatedAt:     due,
+	}}))
+
+	return NewDispatcher(repo, database.NewUnitOfWork(db), server.Client(), opts...), repo
+}
+
+func TestDispatcher_SendsSignedDeliveries(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	dispatcher, repo := setupDispatcher(t, func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	n, err := dispatcher.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.NotNil(t, received)
+	assert.Equal(t, "d-1", received.Header.Get(webhook.HeaderDelivery))
+	assert.Equal(t, webhook.EventUserCreated, received.Header.Get(webhook.HeaderEvent))
+	assert.JSONEq(t, `{"id":"evt-1"}`, string(body))
+
+	// The signature verifies against the timestamp it carries
+	signature := received.Header.Get(webhook.HeaderSignature)
+	var ts int64
+	_, err = fmt.Sscanf(signature, "t=%d,", &ts)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.Sign("0123456789abcdef", time.Unix(ts, 0), body), signature)
+
+	delivery, err := repo.GetDelivery(context.Background(), "d-1")
+	require.NoError(t, err)
+	assert.Equal(t, webhook.StatusSucceeded, delivery.Status)
+	assert.Equal(t, http.StatusNoContent, delivery.ResponseStatus)
+	assert.NotNil(t, delivery.DeliveredAt)
+}
+
+func TestDispatcher_RetriesWithBackoffThenFails(t *testing.T) {
+	var calls atomic.Int32
+	dispatcher, repo := setupDispatcher(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}, WithMaxAttempts(2), WithBackoff(time.Minute, time.Hour))
+	ctx := context.Background()
+
+	_, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+
+	delivery, err := repo.GetDelivery(ctx, "d-1")
+	require.NoError(t, err)
+	assert.Equal(t, webhook.StatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *delivery.NextAttemptAt, 5*time.Second)
+
+	// Not due yet, so nothing is sent
+	n, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	past := time.Now().UTC().Add(-time.Second)
+	delivery.NextAttemptAt = &past
+	require.NoError(t, repo.UpdateDelivery(ctx, delivery))
+
+	_, err = dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+
+	delivery, err = repo.GetDelivery(ctx, "d-1")
+	require.NoError(t, err)
+	assert.Equal(t, webhook.StatusFailed, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Contains(t, delivery.LastError, "500")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestDispatcher_SendsOutsideTheClaimTransaction(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	dispatcher, repo := setupDispatcher(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctx := context.Background()
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := dispatcher.RunOnce(ctx)
+		first <- err
+	}()
+	<-started
+
+	// The test database has a single connection, so a second poll only
+	// gets through if the first is not holding a transaction while it
+	// waits for the endpoint. The leased delivery is not claimed again.
+	n, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	close(release)
+	require.NoError(t, <-first)
+
+	delivery, err := repo.GetDelivery(ctx, "d-1")
+	require.NoError(t, err)
+	assert.Equal(t, webhook.StatusSucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	p := &Dispatcher{baseBackoff: time.Second, maxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, p.backoff(1))
+	assert.Equal(t, 2*time.Second, p.backoff(2))
+	assert.Equal(t, 4*time.Second, p.backoff(3))
+	assert.Equal(t, 5*time.Second, p.backoff(4))
+}
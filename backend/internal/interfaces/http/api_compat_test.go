@@ -0,0 +1,199 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	apperrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// The compatibility suite serves the calls returning users, the only
+// resource v2 changes, through every API version and compares the bodies
+// with testdata/api/<version>/<case>.json. v1 is frozen: its golden files
+// are never rewritten, so any change to a v1 payload fails here. v2 golden
+// files are rewritten with
+//
+//	go test ./internal/interfaces/http -run TestAPICompatibility -update-v2
+//
+// Every other v1 route is frozen by the contract suite in test/contract,
+// which records each one under /api/v1 and refuses to rewrite them.
+var updateV2 = flag.Bool("update-v2", false, "rewrite the API v2 golden files")
+
+// compatUsers returns a user with every optional field set, and one
+// with none
+func compatUsers() (full, bare *user.User) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	scheduled := created.Add(30 * 24 * time.Hour)
+	full = &user.User{
+		ID:                  "u-1",
+		Email:               "ada@example.com",
+		Name:                "Ada Lovelace",
+		Handle:              ptr("ada"),
+		Role:                user.RoleAdmin,
+		Status:              user.StatusActive,
+		AvatarKey:           "avatars/u-1/3f2a.png",
+		Timezone:            "Europe/London",
+		Locale:              "en-GB",
+		DeletionScheduledAt: &scheduled,
+		CreatedAt:           created,
+		UpdatedAt:           created.Add(time.Hour),
+	}
+	bare = &user.User{
+		ID:        "u-2",
+		Email:     "grace@example.com",
+		Name:      "Grace",
+		Role:      user.RoleUser,
+		Status:    user.StatusActive,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	return full, bare
+}
+
+func TestAPICompatibility(t *testing.T) {
+	full, bare := compatUsers()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		expect func(svc *mocks.MockUserService)
+	}{
+		{
+			name:   "get_profile",
+			method: http.MethodGet,
+			path:   "/users/u-1",
+			expect: func(svc *mocks.MockUserService) {
+				svc.EXPECT().GetProfile(gomock.Any(), "u-1").Return(full, nil)
+			},
+		},
+		{
+			name:   "get_profile_bare",
+			method: http.MethodGet,
+			path:   "/users/u-2",
+			expect: func(svc *mocks.MockUserService) {
+				svc.EXPECT().GetProfile(gomock.Any(), "u-2").Return(bare, nil)
+			},
+		},
+		{
+			name:   "get_profile_not_found",
+			method: http.MethodGet,
+			path:   "/users/u-3",
+			expect: func(svc *mocks.MockUserService) {
+				svc.EXPECT().GetProfile(gomock.Any(), "u-3").Return(nil, apperrors.NewEntityNotFoundError("user", "u-3"))
+			},
+		},
+		{
+			name:   "list_users",
+			method: http.MethodGet,
+			path:   "/users?page=1&page_size=2",
+			expect: func(svc *mocks.MockUserService) {
+				svc.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Return(&user.ListUsersResponse{
+					Users:      []*user.User{full, bare},
+					Total:      3,
+					Page:       1,
+					PageSize:   2,
+					TotalPages: 2,
+					TotalKind:  user.TotalExact,
+				}, nil)
+			},
+		},
+		{
+			name:   "register",
+			method: http.MethodPost,
+			path:   "/users/register",
+			body:   RegisterRequest{Email: "grace@example.com", Name: "Grace", Password: "password123"},
+			expect: func(svc *mocks.MockUserService) {
+				svc.EXPECT().Register(gomock.Any(), "grace@example.com", "Grace", "password123").Return(bare, nil)
+			},
+		},
+	}
+
+	for _, v := range APIVersions {
+		for _, tt := range tests {
+			t.Run(v.String()+"/"+tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				svc := mocks.NewMockUserService(ctrl)
+				tt.expect(svc)
+				handler := NewUserHandler(svc)
+
+				router := setupGinTest()
+				api := router.Group(v.Prefix(), UseAPIVersion(v))
+				api.POST("/users/register", handler.Register)
+				api.GET("/users", handler.ListUsers)
+				api.GET("/users/:id", handler.GetProfile)
+
+				var body bytes.Buffer
+				if tt.body != nil {
+					require.NoError(t, json.NewEncoder(&body).Encode(tt.body))
+				}
+				req := httptest.NewRequest(tt.method, v.Prefix()+tt.path, &body)
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				assertGolden(t, v, tt.name, w)
+			})
+		}
+	}
+}
+
+// assertGolden compares the status and body of w with the golden file of
+// the case, rewriting it first for v2 when -update-v2 is set
+func assertGolden(t *testing.T, v APIVersion, name string, w *httptest.ResponseRecorder) {
+	t.Helper()
+	var indented bytes.Buffer
+	require.NoError(t, json.Indent(&indented, w.Body.Bytes(), "", "  "))
+	got, err := json.MarshalIndent(struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body"`
+	}{w.Code, indented.Bytes()}, "", "  ")
+	require.NoError(t, err)
+
+	golden := filepath.Join("testdata", "api", v.String(), name+".json")
+	if *updateV2 && v != APIv1 {
+		require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+		require.NoError(t, os.WriteFile(golden, append(got, '\n'), 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err, "missing golden file; v1 files are written by hand, v2 files with -update-v2")
+	assert.JSONEq(t, string(want), string(got), "%s payload changed", v)
+}
+
+// v1 responses must not depend on any field added for v2
+func TestAPICompatibility_V1IgnoresV2Fields(t *testing.T) {
+	full, _ := compatUsers()
+	v1, err := json.Marshal(NewUserView(APIv1, full))
+	require.NoError(t, err)
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(v1, &fields))
+	for _, key := range []string{"preferences", "deletion"} {
+		assert.NotContains(t, fields, key)
+	}
+}
+
+// Routes outside the versioned groups answer as v1
+func TestAPIVersionOf_Default(t *testing.T) {
+	router := setupGinTest()
+	var got APIVersion
+	router.GET("/ping", func(c *gin.Context) { got = APIVersionOf(c) })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	assert.Equal(t, APIv1, got)
+	assert.Equal(t, "/api/v2", APIv2.Prefix())
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+)
+
+// APIVersion is a major version of the HTTP API, served under /api/v<N>.
+// Every version is served by the same handlers; what differs is how they
+// map results to payloads, chosen per request with APIVersionOf. v2 only
+// versions users: every other resource has the same payload in v1 and v2.
+type APIVersion int
+
+const (
+	// APIv1 is frozen: its payloads do not change
+	APIv1 APIVersion = 1
+	// APIv2 changes how users are represented; see UserResponseV2
+	APIv2 APIVersion = 2
+)
+
+// APIVersions lists the versions of the API, oldest first
+var APIVersions = []APIVersion{APIv1, APIv2}
+
+// String returns the version as it appears in paths, such as "v1"
+func (v APIVersion) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+// Prefix returns the path prefix of the version's routes
+func (v APIVersion) Prefix() string {
+	return "/api/" + v.String()
+}
+
+// apiVersionKey is the gin context key of the request's API version
+const apiVersionKey = "api_version"
+
+// UseAPIVersion returns middleware marking the requests of a route group
+// as served by version v
+func UseAPIVersion(v APIVersion) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, v)
+		c.Next()
+	}
+}
+
+// APIVersionOf returns the API version serving the request; v1 outside
+// the versioned route groups
+func APIVersionOf(c *gin.Context) APIVersion {
+	if v, ok := c.Get(apiVersionKey); ok {
+		if version, ok := v.(APIVersion); ok {
+			return version
+		}
+	}
+	return APIv1
+}
+
+// userView maps u to its representation in the request's API version
+func userView(c *gin.Context, u *user.User) any {
+	return NewUserView(APIVersionOf(c), u)
+}
+
+// userViews maps users to their representation in the request's API
+// version
+func userViews(c *gin.Context, users []*user.User) []any {
+	return NewUserViews(APIVersionOf(c), users)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+	errorMapper  *errors.ErrorMapper
+	errorLogger  errors.ErrorLogger
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		errorMapper:  errors.NewErrorMapper(),
+		errorLogger:  errors.NewDefaultErrorLogger("audit-service"),
+	}
+}
+
+// ListAuditLogs retrieves audit entries with pagination and filtering.
+// from and to are RFC 3339 timestamps; to is exclusive.
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	req := &audit.ListRequest{
+		Page:       page,
+		PageSize:   pageSize,
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+	}
+
+	for field, dst := range map[string]*time.Time{"from": &req.From, "to": &req.To} {
+		raw := c.Query(field)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			httpErr := errors.NewHTTPError(
+				http.StatusBadRequest,
+				errors.CodeInvalidFormat,
+				"Invalid time filter",
+				map[string]interface{}{"field": field, "expected_format": "RFC3339"},
+				traceID,
+			)
+			response.Error(c, httpErr)
+			return
+		}
+		*dst = t
+	}
+
+	result, err := h.auditService.ListAuditLogs(c.Request.Context(), req)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "list_audit_logs",
+			"request":   req,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.Page(c, result.Entries, &response.Meta{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages,
+		HasMore:    result.Page < result.TotalPages,
+	})
+}
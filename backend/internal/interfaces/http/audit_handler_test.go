@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+)
+
+// stubAuditService captures the list request for handler tests
+type stubAuditService struct {
+	req  *audit.ListRequest
+	resp *audit.ListResponse
+	err  error
+}
+
+func (s *stubAuditService) ListAuditLogs(ctx context.Context, req *audit.ListRequest) (*audit.ListResponse, error) {
+	s.req = req
+	return s.resp, s.err
+}
+
+func getAuditLogs(handler *AuditHandler, query string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.GET("/admin/audit-logs", handler.ListAuditLogs)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/audit-logs"+query, nil))
+	return w
+}
+
+func TestAuditHandler_ListAuditLogs_Filters(t *testing.T) {
+	svc := &stubAuditService{resp: &audit.ListResponse{
+		Entries:  []*audit.Entry{{ID: "a-1", Action: audit.ActionDelete, EntityType: "user", EntityID: "u-1"}},
+		Total:    1,
+		Page:     2,
+		PageSize: 5,
+	}}
+	handler := NewAuditHandler(svc)
+
+	w := getAuditLogs(handler, "?page=2&page_size=5&actor_id=admin-1&action=delete&entity_type=user&entity_id=u-1&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, svc.req)
+	assert.Equal(t, 2, svc.req.Page)
+	assert.Equal(t, 5, svc.req.PageSize)
+	assert.Equal(t, "admin-1", svc.req.ActorID)
+	assert.Equal(t, "delete", svc.req.Action)
+	assert.Equal(t, "user", svc.req.EntityType)
+	assert.Equal(t, "u-1", svc.req.EntityID)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), svc.req.From.UTC())
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), svc.req.To.UTC())
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body["data"], 1)
+	meta := body["meta"].(map[string]interface{})
+	assert.Equal(t, float64(1), meta["total"])
+	assert.Equal(t, float64(2), meta["page"])
+}
+
+func TestAuditHandler_ListAuditLogs_InvalidTime(t *testing.T) {
+	svc := &stubAuditService{}
+	handler := NewAuditHandler(svc)
+
+	w := getAuditLogs(handler, "?from=yesterday")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Nil(t, svc.req)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
 	"github.com/cctw-zed/wonder/internal/middleware"
 	"github.com/cctw-zed/wonder/pkg/errors"
 )
@@ -25,11 +27,6 @@ func NewAuthHandler(authService service.AuthService) *AuthHandler {
 	}
 }
 
-type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-}
-
 // Login authenticates user and returns JWT token
 func (h *AuthHandler) Login(c *gin.Context) {
 	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
@@ -43,12 +40,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			map[string]interface{}{"validation_error": err.Error()},
 			traceID,
 		)
-		c.JSON(httpErr.StatusCode, httpErr)
+		response.Error(c, httpErr)
 		return
 	}
 
 	// Authenticate user
-	response, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	ctx := service.WithCaptchaToken(service.WithDeviceToken(c.Request.Context(), req.DeviceToken), req.CaptchaToken)
+	result, err := h.authService.Login(ctx, req.Email, req.Password)
 	if err != nil {
 		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
 			"operation": "user_login",
@@ -56,15 +54,71 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		})
 
 		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
-		c.JSON(httpErr.StatusCode, httpErr)
+		setRetryAfter(c, httpErr)
+		response.Error(c, httpErr)
 		return
 	}
 
 	// Success response
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"data":     response,
-		"trace_id": traceID,
-	})
+	response.OK(c, NewLoginResponse(APIVersionOf(c), result))
+}
+
+// VerifyMFA completes a login with a two-factor or recovery code
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req MFAVerifyRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	result, err := h.authService.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, rememberDevice(c, &req))
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "verify_mfa"})
+		return
+	}
+
+	response.OK(c, NewLoginResponse(APIVersionOf(c), result))
+}
+
+// BeginMFAEnrollment starts enrolling the authenticator of a login that
+// requires one
+func (h *AuthHandler) BeginMFAEnrollment(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req MFATokenRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	setup, err := h.authService.BeginMFAEnrollment(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "begin_login_mfa_enrollment"})
+		return
+	}
+
+	response.Created(c, setup)
+}
+
+// CompleteMFAEnrollment confirms the authenticator and completes the login
+func (h *AuthHandler) CompleteMFAEnrollment(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req MFAVerifyRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	result, err := h.authService.CompleteMFAEnrollment(c.Request.Context(), req.MFAToken, req.Code, rememberDevice(c, &req))
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "complete_login_mfa_enrollment"})
+		return
+	}
+
+	response.OK(c, NewLoginResponse(APIVersionOf(c), result))
 }
 
 // Logout invalidates the current user's token
@@ -84,28 +138,32 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		})
 
 		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
-		c.JSON(httpErr.StatusCode, httpErr)
+		response.Error(c, httpErr)
 		return
 	}
 
 	// Success response
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"message":  "Logout successful",
-		"trace_id": traceID,
-	})
+	response.Message(c, "Logout successful")
 }
 
 // GetMe returns current user information based on JWT token
 // Note: This endpoint is protected by auth middleware, so user ID is already available in context
 func (h *AuthHandler) GetMe(c *gin.Context) {
-	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
-
 	// Get user ID from context (injected by auth middleware)
-	userID := middleware.GetUserIDFromGinContext(c)
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
 
 	// Return user ID from middleware context
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"user_id":  userID,
-		"trace_id": traceID,
+	response.OK(c, map[string]interface{}{
+		"user_id": userID,
 	})
 }
+
+func (h *AuthHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+	setRetryAfter(c, httpErr)
+	response.Error(c, httpErr)
+}
@@ -2,12 +2,19 @@ package http
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
 	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/jwt"
 )
 
@@ -22,11 +29,102 @@ func TestNewAuthHandler(t *testing.T) {
 	require.NotNil(t, handler)
 }
 
-// Simple mock implementation for testing constructor only
-type mockAuthService struct{}
+func TestAuthHandler_Login_Locked(t *testing.T) {
+	handler := NewAuthHandler(&mockAuthService{loginErr: errors.NewAccountLockedError(2 * time.Minute)})
+
+	router := setupGinTest()
+	router.POST("/auth/login", handler.Login)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"a@example.com","password":"wrong-password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusLocked, w.Code)
+	assert.Equal(t, "120", w.Header().Get("Retry-After"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	errBody, ok := body["error"].(map[string]interface{})
+	require.True(t, ok, "Expected error envelope in response: %v", body)
+	assert.Equal(t, string(errors.CodeResourceLocked), errBody["code"])
+}
+
+func TestAuthHandler_Login_MFARequired(t *testing.T) {
+	handler := NewAuthHandler(&mockAuthService{loginResp: &service.LoginResponse{MFARequired: true, MFAToken: "mfa-token", ExpiresIn: 300}})
+
+	router := setupGinTest()
+	router.POST("/auth/login", handler.Login)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"a@example.com","password":"password123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, true, body.Data["mfa_required"])
+	assert.Equal(t, "mfa-token", body.Data["mfa_token"])
+	assert.NotContains(t, body.Data, "access_token")
+	assert.NotContains(t, body.Data, "user")
+}
+
+func TestAuthHandler_VerifyMFA(t *testing.T) {
+	serve := func(handler *AuthHandler, body string) *httptest.ResponseRecorder {
+		router := setupGinTest()
+		router.POST("/auth/mfa/verify", handler.VerifyMFA)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/auth/mfa/verify", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("valid code returns the access token", func(t *testing.T) {
+		handler := NewAuthHandler(&mockAuthService{verifyResp: &service.LoginResponse{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 86400}})
+		w := serve(handler, `{"mfa_token":"mfa-token","code":"123456"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"access_token":"access"`)
+	})
+
+	t.Run("code is required", func(t *testing.T) {
+		w := serve(NewAuthHandler(&mockAuthService{}), `{"mfa_token":"mfa-token"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("locked second step sets Retry-After", func(t *testing.T) {
+		handler := NewAuthHandler(&mockAuthService{verifyErr: errors.NewAccountLockedError(time.Minute)})
+		w := serve(handler, `{"mfa_token":"mfa-token","code":"123456"}`)
+		assert.Equal(t, http.StatusLocked, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	})
+}
+
+// Simple mock implementation returning canned login results
+type mockAuthService struct {
+	loginResp  *service.LoginResponse
+	loginErr   error
+	verifyResp *service.LoginResponse
+	verifyErr  error
+}
 
 func (m *mockAuthService) Login(ctx context.Context, email, password string) (*service.LoginResponse, error) {
-	return nil, nil
+	return m.loginResp, m.loginErr
+}
+
+func (m *mockAuthService) VerifyMFA(ctx context.Context, mfaToken, code string, remember *service.DeviceInfo) (*service.LoginResponse, error) {
+	return m.verifyResp, m.verifyErr
+}
+
+func (m *mockAuthService) BeginMFAEnrollment(ctx context.Context, mfaToken string) (*service.MFASetup, error) {
+	return nil, m.verifyErr
+}
+
+func (m *mockAuthService) CompleteMFAEnrollment(ctx context.Context, mfaToken, code string, remember *service.DeviceInfo) (*service.LoginResponse, error) {
+	return m.verifyResp, m.verifyErr
 }
 
 func (m *mockAuthService) Logout(ctx context.Context, token string) error {
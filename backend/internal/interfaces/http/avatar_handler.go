@@ -0,0 +1,210 @@
+package http
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/storage"
+)
+
+// avatarField is the multipart form field an avatar is uploaded in
+const avatarField = "avatar"
+
+// ObjectPathPrefix is where the API serves the objects of local object
+// storage, followed by their key
+const ObjectPathPrefix = "/api/v1/objects/"
+
+// AvatarPath is where the API serves the avatar stored as file
+func AvatarPath(file string) string {
+	return "/api/v1/avatars/" + file
+}
+
+type AvatarHandler struct {
+	avatarService service.AvatarService
+	maxBytes      int64
+	errorMapper   *errors.ErrorMapper
+	errorLogger   errors.ErrorLogger
+}
+
+// NewAvatarHandler creates a handler for avatar uploads. Images larger
+// than maxBytes are rejected.
+func NewAvatarHandler(avatarService service.AvatarService, maxBytes int64) *AvatarHandler {
+	return &AvatarHandler{
+		avatarService: avatarService,
+		maxBytes:      maxBytes,
+		errorMapper:   errors.NewErrorMapper(),
+		errorLogger:   errors.NewDefaultErrorLogger("avatar"),
+	}
+}
+
+// UploadAvatar replaces the authenticated user's avatar with the image in
+// the avatar field of a multipart/form-data body
+func (h *AvatarHandler) UploadAvatar(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	data, err := h.readAvatar(c.Request)
+	if err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	u, err := h.avatarService.UploadAvatar(c.Request.Context(), userID, data)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "upload_avatar", "user_id": userID})
+		return
+	}
+	response.OK(c, userView(c, u))
+}
+
+// DeleteAvatar removes the authenticated user's avatar
+func (h *AvatarHandler) DeleteAvatar(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	u, err := h.avatarService.DeleteAvatar(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "delete_avatar", "user_id": userID})
+		return
+	}
+	response.OK(c, userView(c, u))
+}
+
+// GetAvatar redirects to a short-lived URL of the avatar. Avatar file
+// names are random, so the route needs no authentication and works in
+// img tags. The redirect is not cached because the URL expires.
+func (h *AvatarHandler) GetAvatar(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	url, err := h.avatarService.AvatarURL(c.Request.Context(), c.Param("file"))
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "get_avatar", "file": c.Param("file")})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, url)
+}
+
+// readAvatar returns the content of the avatar part, reading the body as a
+// stream so nothing is buffered on disk
+func (h *AvatarHandler) readAvatar(r *http.Request) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return nil, errors.NewHTTPError(http.StatusUnsupportedMediaType, errors.CodeUnsupportedMedia,
+			"Avatars must be uploaded as multipart/form-data",
+			map[string]interface{}{"supported": []string{"multipart/form-data"}}, "")
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, h.invalidBody(err)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, errors.NewRequiredFieldError(avatarField, "")
+		}
+		if err != nil {
+			return nil, h.invalidBody(err)
+		}
+		if part.FormName() != avatarField {
+			part.Close()
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, h.maxBytes+1))
+		part.Close()
+		if err != nil {
+			return nil, h.invalidBody(err)
+		}
+		if int64(len(data)) > h.maxBytes {
+			return nil, h.tooLarge()
+		}
+		return data, nil
+	}
+}
+
+func (h *AvatarHandler) invalidBody(err error) error {
+	var sizeErr *http.MaxBytesError
+	if stderrors.As(err, &sizeErr) {
+		return h.tooLarge()
+	}
+	return errors.NewFieldValidationError(errors.FieldError{
+		Field:      "body",
+		Code:       errors.CodeInvalidFormat,
+		Message:    "body must be a valid multipart/form-data message",
+		Constraint: map[string]interface{}{"format": "multipart/form-data"},
+	})
+}
+
+func (h *AvatarHandler) tooLarge() error {
+	return errors.NewHTTPError(http.StatusRequestEntityTooLarge, errors.CodePayloadTooLarge,
+		fmt.Sprintf("Avatar must be at most %d bytes", h.maxBytes),
+		map[string]interface{}{"max_bytes": h.maxBytes}, "")
+}
+
+func (h *AvatarHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
+
+// ObjectHandler serves the objects of local object storage through the
+// URLs it presigns
+type ObjectHandler struct {
+	store *storage.LocalStorage
+}
+
+// NewObjectHandler creates a handler serving the objects of store
+func NewObjectHandler(store *storage.LocalStorage) *ObjectHandler {
+	return &ObjectHandler{store: store}
+}
+
+// GetObject serves the object under the key in the path if the expires and
+// signature query parameters are those of a presigned URL
+func (h *ObjectHandler) GetObject(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	f, err := h.store.Open(key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		if stderrors.Is(err, storage.ErrInvalidSignature) {
+			response.Error(c, errors.NewHTTPError(http.StatusForbidden, errors.CodeForbidden,
+				"Object URL is invalid or has expired", nil, traceID))
+			return
+		}
+		response.Error(c, errors.NewHTTPError(http.StatusNotFound, errors.CodeEntityNotFound,
+			"Object not found", nil, traceID))
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		response.Error(c, errors.NewHTTPError(http.StatusNotFound, errors.CodeEntityNotFound,
+			"Object not found", nil, traceID))
+		return
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(key)); contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime(), f)
+}
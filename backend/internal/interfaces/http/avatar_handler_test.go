@@ -0,0 +1,146 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/storage"
+)
+
+// stubAvatarService records uploads for handler tests
+type stubAvatarService struct {
+	uploaded []byte
+}
+
+func (s *stubAvatarService) UploadAvatar(ctx context.Context, userID string, data []byte) (*user.User, error) {
+	s.uploaded = data
+	return &user.User{ID: userID, AvatarKey: "avatars/0f3a.jpg"}, nil
+}
+
+func (s *stubAvatarService) DeleteAvatar(ctx context.Context, userID string) (*user.User, error) {
+	return &user.User{ID: userID}, nil
+}
+
+func (s *stubAvatarService) AvatarURL(ctx context.Context, file string) (string, error) {
+	if file != "0f3a.jpg" {
+		return "", errors.NewEntityNotFoundError("avatar", file)
+	}
+	return "https://objects.example.com/avatars/0f3a.jpg?sig=1", nil
+}
+
+func multipartBody(t *testing.T, field string, data []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	require.NoError(t, w.WriteField("note", "ignored"))
+	part, err := w.CreateFormFile(field, "me.png")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return &body, w.FormDataContentType()
+}
+
+func TestAvatarHandler_UploadAvatar(t *testing.T) {
+	serve := func(svc *stubAvatarService, userID string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+		router := setupGinTest()
+		if userID != "" {
+			router.Use(withUserID(userID))
+		}
+		router.POST("/users/me/avatar", NewAvatarHandler(svc, 8).UploadAvatar)
+		req := httptest.NewRequest(http.MethodPost, "/users/me/avatar", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("uploads the avatar part", func(t *testing.T) {
+		svc := &stubAvatarService{}
+		body, contentType := multipartBody(t, "avatar", []byte("png-data"))
+		w := serve(svc, "u-1", body, contentType)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "png-data", string(svc.uploaded))
+		var resp struct {
+			Data UserResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "/api/v1/avatars/0f3a.jpg", resp.Data.AvatarURL)
+	})
+
+	t.Run("rejects oversized images", func(t *testing.T) {
+		body, contentType := multipartBody(t, "avatar", []byte("too-large"))
+		w := serve(&stubAvatarService{}, "u-1", body, contentType)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), string(errors.CodePayloadTooLarge))
+	})
+
+	t.Run("requires the avatar field", func(t *testing.T) {
+		body, contentType := multipartBody(t, "picture", []byte("png"))
+		w := serve(&stubAvatarService{}, "u-1", body, contentType)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("requires multipart bodies", func(t *testing.T) {
+		w := serve(&stubAvatarService{}, "u-1", strings.NewReader("png"), "image/png")
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("requires authentication", func(t *testing.T) {
+		body, contentType := multipartBody(t, "avatar", []byte("png"))
+		w := serve(&stubAvatarService{}, "", body, contentType)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAvatarHandler_GetAvatar(t *testing.T) {
+	router := setupGinTest()
+	router.GET("/avatars/:file", NewAvatarHandler(&stubAvatarService{}, 8).GetAvatar)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/avatars/0f3a.jpg", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://objects.example.com/avatars/0f3a.jpg?sig=1", w.Header().Get("Location"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/avatars/other.jpg", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestObjectHandler_GetObject(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir(), "/objects/", []byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	require.NoError(t, store.Put(context.Background(), "avatars/a.png", strings.NewReader("png"), 3, "image/png"))
+	signed, err := store.PresignGet(context.Background(), "avatars/a.png", time.Minute)
+	require.NoError(t, err)
+
+	router := setupGinTest()
+	router.GET("/objects/*key", NewObjectHandler(store).GetObject)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, signed, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "png", w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+
+	u, _ := url.Parse(signed)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u.Path+"?expires="+u.Query().Get("expires")+"&signature=forged", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
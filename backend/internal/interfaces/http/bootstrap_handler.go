@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type BootstrapHandler struct {
+	bootstrapService service.BootstrapService
+	errorMapper      *errors.ErrorMapper
+	errorLogger      errors.ErrorLogger
+}
+
+func NewBootstrapHandler(bootstrapService service.BootstrapService) *BootstrapHandler {
+	return &BootstrapHandler{
+		bootstrapService: bootstrapService,
+		errorMapper:      errors.NewErrorMapper(),
+		errorLogger:      errors.NewDefaultErrorLogger("bootstrap-service"),
+	}
+}
+
+// Status reports whether the initial admin can still be bootstrapped
+func (h *BootstrapHandler) Status(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	available, err := h.bootstrapService.Available(c.Request.Context())
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "admin_bootstrap_status",
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.OK(c, map[string]interface{}{
+		"admin_bootstrap_available": available,
+	})
+}
+
+// BootstrapAdmin creates the configured initial admin account
+func (h *BootstrapHandler) BootstrapAdmin(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req BootstrapAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpErr := errors.NewHTTPError(
+			http.StatusBadRequest,
+			errors.CodeValidationError,
+			"Invalid request data",
+			map[string]interface{}{"validation_error": err.Error()},
+			traceID,
+		)
+		response.Error(c, httpErr)
+		return
+	}
+
+	admin, err := h.bootstrapService.BootstrapAdmin(c.Request.Context(), req.SetupToken, req.Password)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "admin_bootstrap",
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.Created(c, userView(c, admin))
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	apperrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// stubBootstrapService returns canned results for handler tests
+type stubBootstrapService struct {
+	available bool
+	admin     *user.User
+	err       error
+	token     string
+}
+
+func (s *stubBootstrapService) Available(ctx context.Context) (bool, error) {
+	return s.available, s.err
+}
+
+func (s *stubBootstrapService) BootstrapAdmin(ctx context.Context, setupToken, password string) (*user.User, error) {
+	s.token = setupToken
+	return s.admin, s.err
+}
+
+func postBootstrapAdmin(t *testing.T, handler *BootstrapHandler, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	jsonBody, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	router := setupGinTest()
+	router.POST("/setup/admin", handler.BootstrapAdmin)
+
+	req := httptest.NewRequest(http.MethodPost, "/setup/admin", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestBootstrapHandler_BootstrapAdmin_Success(t *testing.T) {
+	svc := &stubBootstrapService{admin: &user.User{ID: "admin-1", Email: "admin@example.com", Role: user.RoleAdmin}}
+	handler := NewBootstrapHandler(svc)
+
+	w := postBootstrapAdmin(t, handler, BootstrapAdminRequest{SetupToken: "0123456789abcdef", Password: "S3cure-admin-pass"})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "0123456789abcdef", svc.token)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	userData := response["data"].(map[string]interface{})
+	assert.Equal(t, "admin", userData["role"])
+	assert.NotContains(t, userData, "password_hash")
+}
+
+func TestBootstrapHandler_BootstrapAdmin_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"invalid token", apperrors.NewUnauthorizedError("admin_bootstrap", "", "invalid setup token"), http.StatusUnauthorized},
+		{"already completed", apperrors.NewInvalidStateError(apperrors.CodeInvalidState, "admin_bootstrap", "completed", "done"), http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBootstrapHandler(&stubBootstrapService{err: tt.err})
+			w := postBootstrapAdmin(t, handler, BootstrapAdminRequest{SetupToken: "token", Password: "S3cure-admin-pass"})
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestBootstrapHandler_BootstrapAdmin_InvalidRequest(t *testing.T) {
+	handler := NewBootstrapHandler(&stubBootstrapService{})
+
+	w := postBootstrapAdmin(t, handler, map[string]string{"password": "S3cure-admin-pass"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBootstrapHandler_Status(t *testing.T) {
+	handler := NewBootstrapHandler(&stubBootstrapService{available: true})
+
+	router := setupGinTest()
+	router.GET("/setup/status", handler.Status)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/setup/status", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, true, response["data"].(map[string]interface{})["admin_bootstrap_available"])
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/dataexport"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type DataExportHandler struct {
+	exportService service.DataExportService
+	errorMapper   *errors.ErrorMapper
+	errorLogger   errors.ErrorLogger
+}
+
+func NewDataExportHandler(exportService service.DataExportService) *DataExportHandler {
+	return &DataExportHandler{
+		exportService: exportService,
+		errorMapper:   errors.NewErrorMapper(),
+		errorLogger:   errors.NewDefaultErrorLogger("data-export"),
+	}
+}
+
+// RequestExport queues an export of everything the service holds about the
+// authenticated user. The user is emailed when it is ready.
+func (h *DataExportHandler) RequestExport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	e, err := h.exportService.RequestExport(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "request_export", "user_id": userID})
+		return
+	}
+
+	response.JSON(c, http.StatusAccepted, NewDataExportResponse(e), nil)
+}
+
+// GetExport downloads the authenticated user's latest export as a ZIP
+// archive once it is ready, and otherwise reports its status
+func (h *DataExportHandler) GetExport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	e, err := h.exportService.LatestExport(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "get_export", "user_id": userID})
+		return
+	}
+
+	switch e.Status {
+	case dataexport.StatusPending:
+		response.JSON(c, http.StatusAccepted, NewDataExportResponse(e), nil)
+		return
+	case dataexport.StatusFailed:
+		response.OK(c, NewDataExportResponse(e))
+		return
+	}
+
+	content, err := h.exportService.ExportContent(c.Request.Context(), e.ID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "download_export", "user_id": userID, "export_id": e.ID})
+		return
+	}
+
+	filename := fmt.Sprintf("wonder-export-%s.zip", e.CompletedAt.UTC().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", content)
+}
+
+func (h *DataExportHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
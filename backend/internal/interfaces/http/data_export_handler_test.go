@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/dataexport"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// stubExportService serves a fixed latest export for handler tests
+type stubExportService struct {
+	latest    *dataexport.Export
+	content   []byte
+	requested string
+}
+
+func (s *stubExportService) RequestExport(ctx context.Context, userID string) (*dataexport.Export, error) {
+	s.requested = userID
+	return &dataexport.Export{ID: "exp-1", UserID: userID, Status: dataexport.StatusPending}, nil
+}
+
+func (s *stubExportService) LatestExport(ctx context.Context, userID string) (*dataexport.Export, error) {
+	if s.latest == nil {
+		return nil, errors.NewEntityNotFoundError("data_export", userID)
+	}
+	return s.latest, nil
+}
+
+func (s *stubExportService) ExportContent(ctx context.Context, exportID string) ([]byte, error) {
+	return s.content, nil
+}
+
+func (s *stubExportService) GenerateExport(ctx context.Context, exportID string) error {
+	return nil
+}
+
+func (s *stubExportService) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func serveExport(handler *DataExportHandler, method, userID string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	if userID != "" {
+		router.Use(withUserID(userID))
+	}
+	router.POST("/users/me/export", handler.RequestExport)
+	router.GET("/users/me/export", handler.GetExport)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, "/users/me/export", nil))
+	return w
+}
+
+func TestDataExportHandler_RequestExport(t *testing.T) {
+	svc := &stubExportService{}
+	handler := NewDataExportHandler(svc)
+
+	w := serveExport(handler, http.MethodPost, "u-1")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "u-1", svc.requested)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "pending", body["data"].(map[string]interface{})["status"])
+
+	w = serveExport(handler, http.MethodPost, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestDataExportHandler_GetExport(t *testing.T) {
+	svc := &stubExportService{}
+	handler := NewDataExportHandler(svc)
+
+	w := serveExport(handler, http.MethodGet, "u-1")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	svc.latest = &dataexport.Export{ID: "exp-1", Status: dataexport.StatusPending}
+	w = serveExport(handler, http.MethodGet, "u-1")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	completed := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	svc.latest.MarkReady([]byte("PK"), completed, completed.Add(time.Hour))
+	svc.content = []byte("PK")
+	w = serveExport(handler, http.MethodGet, "u-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="wonder-export-20261016.zip"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "PK", w.Body.String())
+}
@@ -0,0 +1,278 @@
+package http
+
+import (
+	"path"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/dataexport"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+)
+
+// Request and response bodies of the HTTP API. Handlers bind requests to
+// these types and map domain results to them before responding, so fields
+// added to domain entities never reach a payload unless mapped here.
+
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Name     string `json:"name" binding:"required,min=2,max=50"`
+	Password string `json:"password" binding:"required,min=6"`
+	// CaptchaToken is the solved CAPTCHA when registration requires one
+	CaptchaToken string `json:"captcha_token,omitempty" binding:"max=4096"`
+	// Website is a honeypot: sign-up forms hide it, so only bots fill it in
+	Website string `json:"website,omitempty" binding:"max=2048"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// UpdateProfileRequest replaces the profile fields that are not empty. Use
+// PatchUserRequest to set fields explicitly.
+type UpdateProfileRequest struct {
+	Email  string `json:"email,omitempty" binding:"omitempty,email"`
+	Name   string `json:"name,omitempty" binding:"omitempty,min=2,max=50"`
+	Handle string `json:"handle,omitempty" binding:"omitempty,max=31"`
+	// Timezone is an IANA time zone and Locale a BCP 47 language tag
+	Timezone string `json:"timezone,omitempty" binding:"omitempty,max=64"`
+	Locale   string `json:"locale,omitempty" binding:"omitempty,max=35"`
+}
+
+// toDomain maps the request to the user service's update
+func (r *UpdateProfileRequest) toDomain() *user.UpdateProfileRequest {
+	return &user.UpdateProfileRequest{
+		Email:    nonEmpty(r.Email),
+		Name:     nonEmpty(r.Name),
+		Handle:   nonEmpty(r.Handle),
+		Timezone: nonEmpty(r.Timezone),
+		Locale:   nonEmpty(r.Locale),
+	}
+}
+
+// PatchUserRequest is a decoded profile patch. Nil fields are left
+// unchanged; set fields are validated and applied even when empty.
+type PatchUserRequest struct {
+	Email *string `json:"email" binding:"omitempty,email"`
+	Name  *string `json:"name" binding:"omitempty,min=2,max=50"`
+	// Handle set to null or "" removes the handle
+	Handle *string `json:"handle" binding:"omitempty,max=31"`
+	// Timezone and Locale set to null or "" remove the preference
+	Timezone *string `json:"timezone" binding:"omitempty,max=64"`
+	Locale   *string `json:"locale" binding:"omitempty,max=35"`
+}
+
+// toDomain maps the patch to the user service's update
+func (r *PatchUserRequest) toDomain() *user.UpdateProfileRequest {
+	return &user.UpdateProfileRequest{Email: r.Email, Name: r.Name, Handle: r.Handle, Timezone: r.Timezone, Locale: r.Locale}
+}
+
+// HandleAvailabilityQuery names the handle to check
+type HandleAvailabilityQuery struct {
+	Handle string `form:"handle" binding:"required,max=31"`
+}
+
+// SuspendUserRequest optionally explains a suspension
+type SuspendUserRequest struct {
+	Reason string `json:"reason,omitempty" binding:"max=500"`
+}
+
+// DeactivateUserRequest optionally explains a deactivation
+type DeactivateUserRequest struct {
+	Reason string `json:"reason,omitempty" binding:"max=500"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	// DeviceToken is the token of a trusted device; it skips the
+	// two-factor step
+	DeviceToken string `json:"device_token,omitempty" binding:"max=128"`
+	// CaptchaToken is the solved CAPTCHA once failed logins require one
+	CaptchaToken string `json:"captcha_token,omitempty" binding:"max=4096"`
+}
+
+type BootstrapAdminRequest struct {
+	SetupToken string `json:"setup_token" binding:"required"`
+	Password   string `json:"password" binding:"required,min=6"`
+}
+
+// UserResponse is a user as API v1 returns it
+type UserResponse struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Handle    string    `json:"handle,omitempty"`
+	Timezone  string    `json:"timezone,omitempty"`
+	Locale    string    `json:"locale,omitempty"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletionScheduledAt is set while a deletion the user asked for is pending
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	// AvatarURL is where the user's avatar is served; empty without one
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// NewUserResponse maps u to its API representation; nil maps to nil
+func NewUserResponse(u *user.User) *UserResponse {
+	if u == nil {
+		return nil
+	}
+	return &UserResponse{
+		ID:                  u.ID,
+		Email:               u.Email,
+		Name:                u.Name,
+		Handle:              u.CurrentHandle(),
+		Timezone:            u.Timezone,
+		Locale:              u.Locale,
+		Role:                u.Role,
+		Status:              u.CurrentStatus(),
+		CreatedAt:           u.CreatedAt,
+		UpdatedAt:           u.UpdatedAt,
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		AvatarURL:           avatarURL(u.AvatarKey),
+	}
+}
+
+// NewUserExportResponse maps u to its record in user exports: the API
+// representation with timestamps in loc
+func NewUserExportResponse(u *user.User, loc *time.Location) *UserResponse {
+	resp := NewUserResponse(u)
+	if resp == nil {
+		return nil
+	}
+	resp.CreatedAt, resp.UpdatedAt = resp.CreatedAt.In(loc), resp.UpdatedAt.In(loc)
+	if resp.DeletionScheduledAt != nil {
+		scheduled := resp.DeletionScheduledAt.In(loc)
+		resp.DeletionScheduledAt = &scheduled
+	}
+	return resp
+}
+
+// avatarURL is the API path of the avatar stored under key
+func avatarURL(key string) string {
+	if key == "" {
+		return ""
+	}
+	return AvatarPath(path.Base(key))
+}
+
+// NewUserView maps u to its representation in API version v; nil maps to
+// nil
+func NewUserView(v APIVersion, u *user.User) any {
+	if u == nil {
+		return nil
+	}
+	if v >= APIv2 {
+		return NewUserResponseV2(u)
+	}
+	return NewUserResponse(u)
+}
+
+// NewUserViews maps users in order to their representation in API version
+// v. The result is never nil, so an empty page encodes as [].
+func NewUserViews(v APIVersion, users []*user.User) []any {
+	out := make([]any, 0, len(users))
+	for _, u := range users {
+		out = append(out, NewUserView(v, u))
+	}
+	return out
+}
+
+// NewUserResponses maps users in order. The result is never nil, so an
+// empty page encodes as [].
+func NewUserResponses(users []*user.User) []*UserResponse {
+	out := make([]*UserResponse, 0, len(users))
+	for _, u := range users {
+		out = append(out, NewUserResponse(u))
+	}
+	return out
+}
+
+// DataExportResponse is the state of a data export. The archive itself is
+// downloaded once the status is ready.
+type DataExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	Size        int64      `json:"size,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// NewDataExportResponse maps e to its API representation
+func NewDataExportResponse(e *dataexport.Export) *DataExportResponse {
+	return &DataExportResponse{
+		ID:          e.ID,
+		Status:      e.Status,
+		Size:        e.Size,
+		Error:       e.Error,
+		CreatedAt:   e.CreatedAt,
+		CompletedAt: e.CompletedAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+}
+
+// LoginResponse carries the issued access token and the signed-in user.
+// A login waiting for its second factor has neither, only the MFA token
+// and what the client has to do with it; expires_in is then the MFA
+// token's lifetime.
+type LoginResponse struct {
+	User                  any      `json:"user,omitempty"`
+	AccessToken           string   `json:"access_token,omitempty"`
+	TokenType             string   `json:"token_type,omitempty"`
+	ExpiresIn             int64    `json:"expires_in"`
+	MFARequired           bool     `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool     `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string   `json:"mfa_token,omitempty"`
+	RecoveryCodes         []string `json:"recovery_codes,omitempty"`
+	DeviceToken           string   `json:"device_token,omitempty"`
+}
+
+// NewLoginResponse maps the auth service's login result for API version v
+func NewLoginResponse(v APIVersion, r *service.LoginResponse) *LoginResponse {
+	return &LoginResponse{
+		User:                  NewUserView(v, r.User),
+		AccessToken:           r.AccessToken,
+		TokenType:             r.TokenType,
+		ExpiresIn:             r.ExpiresIn,
+		MFARequired:           r.MFARequired,
+		MFAEnrollmentRequired: r.MFAEnrollmentRequired,
+		MFAToken:              r.MFAToken,
+		RecoveryCodes:         r.RecoveryCodes,
+		DeviceToken:           r.DeviceToken,
+	}
+}
+
+// SearchHitResponse is a user matching a search, with its rank and the
+// matched parts of name and email
+type SearchHitResponse struct {
+	User       any                        `json:"user"`
+	Rank       float64                    `json:"rank"`
+	Highlights map[string][]user.Fragment `json:"highlights,omitempty"`
+}
+
+// NewSearchHitResponses maps hits in order for API version v; the result
+// is never nil
+func NewSearchHitResponses(v APIVersion, hits []*user.SearchHit) []*SearchHitResponse {
+	out := make([]*SearchHitResponse, 0, len(hits))
+	for _, hit := range hits {
+		out = append(out, &SearchHitResponse{
+			User:       NewUserView(v, hit.User),
+			Rank:       hit.Rank,
+			Highlights: hit.Highlights,
+		})
+	}
+	return out
+}
+
+// nonEmpty returns a pointer to s, or nil when s is empty
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
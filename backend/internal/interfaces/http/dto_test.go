@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+)
+
+// jsonKeys returns the sorted top-level keys v encodes to
+func jsonKeys(t *testing.T, v interface{}) []string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func testUser() *user.User {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &user.User{
+		ID:           "u-1",
+		TenantID:     "acme",
+		Email:        "ada@example.com",
+		Name:         "Ada",
+		PasswordHash: "$2a$10$secret",
+		Role:         user.RoleAdmin,
+		CreatedAt:    created,
+		UpdatedAt:    created.Add(time.Hour),
+	}
+}
+
+func TestUserResponse_Contract(t *testing.T) {
+	u := testUser()
+	resp := NewUserResponse(u)
+
+	assert.Equal(t, &UserResponse{
+		ID:        "u-1",
+		Email:     "ada@example.com",
+		Name:      "Ada",
+		Role:      user.RoleAdmin,
+		Status:    user.StatusActive,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}, resp)
+	// Internal fields such as the password hash and tenant are never encoded
+	assert.Equal(t, []string{"created_at", "email", "id", "name", "role", "status", "updated_at"}, jsonKeys(t, resp))
+
+	assert.Nil(t, NewUserResponse(nil))
+}
+
+func TestUserExportResponse_Contract(t *testing.T) {
+	u := testUser()
+	u.ExternalAuth = "ldap"
+	scheduled := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	u.DeletionScheduledAt = &scheduled
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(NewUserExportResponse(u, berlin))
+	require.NoError(t, err)
+	// Export records are the API representation, without tenant or
+	// sign-in internals, in the requested time zone
+	assert.JSONEq(t, `{
+		"id": "u-1",
+		"email": "ada@example.com",
+		"name": "Ada",
+		"role": "admin",
+		"status": "active",
+		"created_at": "2024-03-01T13:00:00+01:00",
+		"updated_at": "2024-03-01T14:00:00+01:00",
+		"deletion_scheduled_at": "2024-04-01T02:00:00+02:00"
+	}`, string(data))
+	assert.Equal(t, scheduled, *u.DeletionScheduledAt, "the user is left unchanged")
+
+	assert.Nil(t, NewUserExportResponse(nil, berlin))
+}
+
+func TestNewUserResponses(t *testing.T) {
+	data, err := json.Marshal(NewUserResponses(nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(data))
+
+	other := testUser()
+	other.ID = "u-2"
+	resps := NewUserResponses([]*user.User{testUser(), other})
+	require.Len(t, resps, 2)
+	assert.Equal(t, "u-1", resps[0].ID)
+	assert.Equal(t, "u-2", resps[1].ID)
+}
+
+func TestUserResponseV2_Contract(t *testing.T) {
+	u := testUser()
+	data, err := json.Marshal(NewUserResponseV2(u))
+	require.NoError(t, err)
+	// Optional fields are present and null rather than omitted
+	assert.JSONEq(t, `{
+		"id": "u-1",
+		"email": "ada@example.com",
+		"name": "Ada",
+		"handle": null,
+		"role": "admin",
+		"status": "active",
+		"avatar_url": null,
+		"preferences": {"timezone": null, "locale": null},
+		"deletion": null,
+		"email_verified_at": null,
+		"created_at": "2024-03-01T12:00:00Z",
+		"updated_at": "2024-03-01T13:00:00Z"
+	}`, string(data))
+
+	scheduled := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	u.Timezone = "Europe/London"
+	u.Locale = "en-GB"
+	u.DeletionScheduledAt = &scheduled
+	resp := NewUserResponseV2(u)
+	assert.Equal(t, "Europe/London", *resp.Preferences.Timezone)
+	assert.Equal(t, "en-GB", *resp.Preferences.Locale)
+	assert.Equal(t, &UserDeletionV2{ScheduledAt: scheduled}, resp.Deletion)
+
+	assert.Nil(t, NewUserResponseV2(nil))
+}
+
+func TestNewUserView(t *testing.T) {
+	assert.IsType(t, &UserResponse{}, NewUserView(APIv1, testUser()))
+	assert.IsType(t, &UserResponseV2{}, NewUserView(APIv2, testUser()))
+	// A missing user is untyped nil so that it encodes as null in any version
+	assert.Nil(t, NewUserView(APIv2, nil))
+	assert.Len(t, NewUserViews(APIv2, []*user.User{testUser(), testUser()}), 2)
+}
+
+func TestLoginResponse_Contract(t *testing.T) {
+	resp := NewLoginResponse(APIv1, &service.LoginResponse{
+		User:        testUser(),
+		AccessToken: "token",
+		TokenType:   "Bearer",
+		ExpiresIn:   3600,
+	})
+
+	assert.Equal(t, []string{"access_token", "expires_in", "token_type", "user"}, jsonKeys(t, resp))
+	assert.Equal(t, []string{"created_at", "email", "id", "name", "role", "status", "updated_at"}, jsonKeys(t, resp.User))
+	assert.Equal(t, "token", resp.AccessToken)
+	assert.Equal(t, int64(3600), resp.ExpiresIn)
+}
+
+func TestSearchHitResponse_Contract(t *testing.T) {
+	hits := NewSearchHitResponses(APIv1, []*user.SearchHit{
+		{User: testUser(), Rank: 0.5, Highlights: map[string][]user.Fragment{"name": {{Text: "Ada", Match: true}}}},
+		{User: testUser(), Rank: 0.1},
+	})
+
+	require.Len(t, hits, 2)
+	assert.Equal(t, []string{"highlights", "rank", "user"}, jsonKeys(t, hits[0]))
+	assert.Equal(t, []string{"rank", "user"}, jsonKeys(t, hits[1]))
+	assert.Equal(t, []string{"created_at", "email", "id", "name", "role", "status", "updated_at"}, jsonKeys(t, hits[0].User))
+}
+
+func TestUpdateProfileRequest_ToDomain(t *testing.T) {
+	req := (&UpdateProfileRequest{Email: "new@example.com", Name: "Ada L"}).toDomain()
+	assert.Equal(t, &user.UpdateProfileRequest{Email: ptr("new@example.com"), Name: ptr("Ada L")}, req)
+
+	// PUT leaves empty fields unchanged
+	req = (&UpdateProfileRequest{Name: "Ada L"}).toDomain()
+	assert.Nil(t, req.Email)
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+)
+
+// Response bodies that changed in API v2. Payloads not listed here are the
+// same in every version; v1 payloads stay as they are in dto.go.
+
+// UserResponseV2 is a user as API v2 returns it. Unlike v1, locale
+// settings are grouped under preferences, a scheduled deletion is an
+// object, and optional fields are always present, null when unset.
+type UserResponseV2 struct {
+	ID          string            `json:"id"`
+	Email       string            `json:"email"`
+	Name        string            `json:"name"`
+	Handle      *string           `json:"handle"`
+	Role        string            `json:"role"`
+	Status      string            `json:"status"`
+	AvatarURL   *string           `json:"avatar_url"`
+	Preferences UserPreferencesV2 `json:"preferences"`
+	Deletion    *UserDeletionV2   `json:"deletion"`
+	// EmailVerifiedAt is when the user confirmed their current email
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// UserPreferencesV2 are the settings a user's times and messages are
+// shown with
+type UserPreferencesV2 struct {
+	Timezone *string `json:"timezone"`
+	Locale   *string `json:"locale"`
+}
+
+// UserDeletionV2 is the deletion a user scheduled for their account
+type UserDeletionV2 struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+// NewUserResponseV2 maps u to its v2 representation; nil maps to nil
+func NewUserResponseV2(u *user.User) *UserResponseV2 {
+	if u == nil {
+		return nil
+	}
+	resp := &UserResponseV2{
+		ID:        u.ID,
+		Email:     u.Email,
+		Name:      u.Name,
+		Handle:    nonEmpty(u.CurrentHandle()),
+		Role:      u.Role,
+		Status:    u.CurrentStatus(),
+		AvatarURL: nonEmpty(avatarURL(u.AvatarKey)),
+		Preferences: UserPreferencesV2{
+			Timezone: nonEmpty(u.Timezone),
+			Locale:   nonEmpty(u.Locale),
+		},
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+	}
+	if u.DeletionScheduledAt != nil {
+		resp.Deletion = &UserDeletionV2{ScheduledAt: *u.DeletionScheduledAt}
+	}
+	return resp
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required,max=2048"`
+}
+
+// EmailVerificationHandler confirms email addresses through the links
+// emailed on registration and email change, and sends them again
+type EmailVerificationHandler struct {
+	verificationService service.EmailVerificationService
+	errorMapper         *errors.ErrorMapper
+	errorLogger         errors.ErrorLogger
+}
+
+func NewEmailVerificationHandler(verificationService service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		verificationService: verificationService,
+		errorMapper:         errors.NewErrorMapper(),
+		errorLogger:         errors.NewDefaultErrorLogger("email-verification-service"),
+	}
+}
+
+// VerifyEmail confirms the address a verification link was sent to
+func (h *EmailVerificationHandler) VerifyEmail(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req VerifyEmailRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	u, err := h.verificationService.Verify(c.Request.Context(), req.Token)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "verify_email"})
+		return
+	}
+
+	response.OK(c, userView(c, u))
+}
+
+// ResendVerification emails the current user a new verification link
+func (h *EmailVerificationHandler) ResendVerification(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.verificationService.Send(c.Request.Context(), userID); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "resend_verification", "user_id": userID})
+		return
+	}
+
+	response.Message(c, "Verification email sent")
+}
+
+func (h *EmailVerificationHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+)
+
+// ErrorCatalogHandler publishes the error codes API consumers can program
+// against
+type ErrorCatalogHandler struct {
+	errorMapper *errors.ErrorMapper
+}
+
+func NewErrorCatalogHandler() *ErrorCatalogHandler {
+	return &ErrorCatalogHandler{errorMapper: errors.NewErrorMapper()}
+}
+
+// ListErrors returns every public error code with its HTTP status. Titles
+// are in the request's language; descriptions are only kept in English.
+func (h *ErrorCatalogHandler) ListErrors(c *gin.Context) {
+	entries := errors.Catalog()
+	for i := range entries {
+		entries[i] = localizeEntry(c, entries[i])
+	}
+	response.OK(c, entries)
+}
+
+// GetError returns one error code; the docs_url of error bodies points here
+func (h *ErrorCatalogHandler) GetError(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	entry, ok := errors.LookupCode(errors.ErrorCode(c.Param("code")))
+	if !ok {
+		response.Error(c, h.errorMapper.MapToHTTPError(errors.NewEntityNotFoundError("error_code", c.Param("code")), traceID))
+		return
+	}
+
+	response.OK(c, localizeEntry(c, entry))
+}
+
+// localizeEntry translates the entry's title, which is the message error
+// bodies carry in the request's language
+func localizeEntry(c *gin.Context, entry errors.CatalogEntry) errors.CatalogEntry {
+	lang := i18n.LanguageFromContext(c.Request.Context())
+	if title, ok := i18n.Get().Lookup(lang, "error."+string(entry.Code)); ok && lang != i18n.DefaultLanguage {
+		entry.Title = title
+	}
+	return entry
+}
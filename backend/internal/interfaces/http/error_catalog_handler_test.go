@@ -0,0 +1,60 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+)
+
+func TestErrorCatalogHandler(t *testing.T) {
+	router := setupGinTest()
+	handler := NewErrorCatalogHandler()
+	router.GET("/errors", handler.ListErrors)
+	router.GET("/errors/:code", handler.GetError)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/errors", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Data []errors.CatalogEntry `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Data, len(errors.Catalog()))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/errors/ENTITY_NOT_FOUND", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var one struct {
+		Data errors.CatalogEntry `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &one))
+	assert.Equal(t, errors.CodeEntityNotFound, one.Data.Code)
+	assert.Equal(t, http.StatusNotFound, one.Data.Status)
+	assert.Equal(t, "/api/v1/errors/ENTITY_NOT_FOUND", one.Data.DocsURL)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/errors/NO_SUCH_CODE", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Titles follow Accept-Language
+	localized := setupGinTest()
+	localized.Use(middleware.LocaleMiddleware(i18n.Get()))
+	localized.GET("/errors/:code", handler.GetError)
+	req := httptest.NewRequest(http.MethodGet, "/errors/ENTITY_NOT_FOUND", nil)
+	req.Header.Set("Accept-Language", "zh-CN")
+	w = httptest.NewRecorder()
+	localized.ServeHTTP(w, req)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &one))
+	assert.Equal(t, errors.CodeEntityNotFound, one.Data.Code)
+	assert.Equal(t, "资源不存在", one.Data.Title)
+}
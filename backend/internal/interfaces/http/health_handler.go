@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+	"github.com/cctw-zed/wonder/pkg/health"
+)
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	registry  *health.Registry
+	breakers  []*circuitbreaker.Breaker
+	startedAt time.Time
+}
+
+// NewHealthHandler creates the probe handler. The states of breakers are
+// reported by the liveness probe; nil breakers are skipped.
+func NewHealthHandler(registry *health.Registry, breakers ...*circuitbreaker.Breaker) *HealthHandler {
+	if registry == nil {
+		panic("health registry cannot be nil")
+	}
+	h := &HealthHandler{
+		registry:  registry,
+		startedAt: time.Now(),
+	}
+	for _, b := range breakers {
+		if b != nil {
+			h.breakers = append(h.breakers, b)
+		}
+	}
+	return h
+}
+
+// Liveness reports that the process is running. It never touches
+// dependencies, so an outage elsewhere does not get the pod restarted.
+// Circuit breaker states are informational and never change the status.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	body := map[string]interface{}{
+		"status":         health.StatusUp,
+		"uptime_seconds": int64(time.Since(h.startedAt).Seconds()),
+		"log_level":      CurrentLogLevel(),
+	}
+	if len(h.breakers) > 0 {
+		states := make(map[string]string, len(h.breakers))
+		for _, b := range h.breakers {
+			states[b.Name()] = b.State().String()
+		}
+		body["circuit_breakers"] = states
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// Readiness runs all registered checks. Degraded services stay in rotation;
+// a failing critical dependency returns 503.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.registry.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+	"github.com/cctw-zed/wonder/pkg/health"
+)
+
+func probe(handler *HealthHandler, path string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.GET("/healthz", handler.Liveness)
+	router.GET("/readyz", handler.Readiness)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	up := health.CheckerFunc(func(ctx context.Context) error { return nil })
+	down := health.CheckerFunc(func(ctx context.Context) error { return errors.New("unreachable") })
+
+	tests := []struct {
+		name       string
+		register   func(r *health.Registry)
+		wantStatus int
+		wantHealth health.Status
+	}{
+		{
+			name:       "up",
+			register:   func(r *health.Registry) { r.Register("postgres", up) },
+			wantStatus: http.StatusOK,
+			wantHealth: health.StatusUp,
+		},
+		{
+			name: "degraded stays ready",
+			register: func(r *health.Registry) {
+				r.Register("postgres", up)
+				r.Register("redis", down, health.NonCritical())
+			},
+			wantStatus: http.StatusOK,
+			wantHealth: health.StatusDegraded,
+		},
+		{
+			name:       "critical dependency down",
+			register:   func(r *health.Registry) { r.Register("postgres", down) },
+			wantStatus: http.StatusServiceUnavailable,
+			wantHealth: health.StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := health.NewRegistry()
+			tt.register(registry)
+
+			w := probe(NewHealthHandler(registry), "/readyz")
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			var report health.Report
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Equal(t, tt.wantHealth, report.Status)
+			assert.Contains(t, report.Checks, "postgres")
+		})
+	}
+}
+
+func TestHealthHandler_LivenessIgnoresDependencies(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("postgres", health.CheckerFunc(func(ctx context.Context) error { return errors.New("down") }))
+
+	w := probe(NewHealthHandler(registry), "/healthz")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"up"`)
+}
+
+func TestHealthHandler_LivenessReportsCircuitBreakers(t *testing.T) {
+	breaker := circuitbreaker.New(circuitbreaker.Settings{Name: "redis", FailureThreshold: 1, OpenTimeout: time.Hour})
+	_ = breaker.Execute(func() error { return errors.New("connection refused") })
+
+	w := probe(NewHealthHandler(health.NewRegistry(), breaker, nil), "/healthz")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Status          string            `json:"status"`
+		CircuitBreakers map[string]string `json:"circuit_breakers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "up", body.Status)
+	assert.Equal(t, map[string]string{"redis": "open"}, body.CircuitBreakers)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// IDHandler exposes snowflake ID introspection for tracing which service
+// instance issued an ID
+type IDHandler struct {
+	serviceType id.ServiceType
+	errorMapper *errors.ErrorMapper
+}
+
+// NewIDHandler creates the handler. IDs are decoded as issued by
+// serviceType, this deployment's, unless the request names another.
+func NewIDHandler(serviceType id.ServiceType) *IDHandler {
+	return &IDHandler{serviceType: serviceType, errorMapper: errors.NewErrorMapper()}
+}
+
+// DecodeID splits an ID into its timestamp, node ID, service type and
+// sequence. The ID itself does not record its service, so it is taken from
+// the service_type query parameter, or this deployment's when absent.
+func (h *IDHandler) DecodeID(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	serviceType := h.serviceType
+	if name := c.Query("service_type"); name != "" {
+		parsed, err := id.ParseServiceType(name)
+		if err != nil {
+			response.Error(c, h.errorMapper.MapToHTTPError(errors.NewInvalidFormatError("service_type", name, "user, order, payment, auth or gateway"), traceID))
+			return
+		}
+		serviceType = parsed
+	}
+
+	decoded, err := id.Decode(c.Param("id"), serviceType)
+	if err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(errors.NewInvalidFormatError("id", c.Param("id"), "snowflake ID"), traceID))
+		return
+	}
+
+	response.OK(c, decoded)
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+func TestIDHandler_DecodeID(t *testing.T) {
+	router := setupGinTest()
+	router.GET("/admin/ids/:id/decode", NewIDHandler(id.ServiceTypeUser).DecodeID)
+
+	gen, err := id.NewSnowflakeGeneratorForService(id.ServiceTypeUser, 7)
+	require.NoError(t, err)
+	issued := gen.Generate()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/ids/"+issued+"/decode", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Data id.DecodedID `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, issued, body.Data.ID)
+	assert.Equal(t, int64(7), body.Data.NodeID)
+	assert.Equal(t, "user", body.Data.ServiceType)
+
+	// The caller can name the service that issued the ID
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/ids/"+issued+"/decode?service_type=order", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "order", body.Data.ServiceType)
+
+	for _, path := range []string{"/admin/ids/not-an-id/decode", "/admin/ids/" + issued + "/decode?service_type=billing"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
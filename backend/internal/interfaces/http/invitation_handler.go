@@ -0,0 +1,218 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type CreateInvitationRequest struct {
+	OrgID string `json:"org_id" binding:"required,max=64"`
+	Email string `json:"email" binding:"required,email,max=255"`
+}
+
+type ListInvitationsRequest struct {
+	OrgID string `form:"org_id" binding:"required,max=64"`
+}
+
+type InvitationTokenRequest struct {
+	Token string `form:"token" json:"token" binding:"required,max=2048"`
+}
+
+// RegisterWithInvitationRequest registers the invited email; unlike
+// RegisterRequest it has no email, which comes from the invitation
+type RegisterWithInvitationRequest struct {
+	Token    string `json:"token" binding:"required,max=2048"`
+	Name     string `json:"name" binding:"required,min=2,max=50"`
+	Password string `json:"password" binding:"required,min=6"`
+	// CaptchaToken is the solved CAPTCHA when registration requires one
+	CaptchaToken string `json:"captcha_token,omitempty" binding:"max=4096"`
+	// Website is a honeypot: sign-up forms hide it, so only bots fill it in
+	Website string `json:"website,omitempty" binding:"max=2048"`
+}
+
+// InvitationRegistrationResponse is a user who registered through an
+// invitation and their new membership
+type InvitationRegistrationResponse struct {
+	User       any                  `json:"user"`
+	Membership *organization.Member `json:"membership"`
+}
+
+// InvitationHandler lets organization owners invite people by email, and
+// invitees preview and accept their invitation links
+type InvitationHandler struct {
+	invitationService service.InvitationService
+	errorMapper       *errors.ErrorMapper
+	errorLogger       errors.ErrorLogger
+}
+
+func NewInvitationHandler(invitationService service.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		errorMapper:       errors.NewErrorMapper(),
+		errorLogger:       errors.NewDefaultErrorLogger("invitation-service"),
+	}
+}
+
+// CreateInvitation emails an invitation link to join an organization the
+// current user owns. The link is in the response only when it could not
+// be emailed.
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	inv, err := h.invitationService.Invite(c.Request.Context(), userID, req.OrgID, req.Email)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "create_invitation", "user_id": userID, "org_id": req.OrgID})
+		return
+	}
+
+	response.Created(c, inv)
+}
+
+// ListInvitations lists the invitations of an organization the current
+// user owns
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ListInvitationsRequest
+	if err := validation.BindQuery(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	invitations, err := h.invitationService.List(c.Request.Context(), userID, req.OrgID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "list_invitations", "user_id": userID, "org_id": req.OrgID})
+		return
+	}
+
+	response.OK(c, invitations)
+}
+
+// ResendInvitation emails an invitation a new link; earlier links stop
+// working
+func (h *InvitationHandler) ResendInvitation(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	invitationID := c.Param("id")
+
+	inv, err := h.invitationService.Resend(c.Request.Context(), userID, invitationID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "resend_invitation", "user_id": userID, "invitation_id": invitationID})
+		return
+	}
+
+	response.OK(c, inv)
+}
+
+// RevokeInvitation withdraws an invitation
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	invitationID := c.Param("id")
+
+	if err := h.invitationService.Revoke(c.Request.Context(), userID, invitationID); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "revoke_invitation", "user_id": userID, "invitation_id": invitationID})
+		return
+	}
+
+	response.Message(c, "Invitation revoked")
+}
+
+// PreviewInvitation describes the invitation of a link, for the sign-up
+// form to pre-fill
+func (h *InvitationHandler) PreviewInvitation(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req InvitationTokenRequest
+	if err := validation.BindQuery(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	preview, err := h.invitationService.Preview(c.Request.Context(), req.Token)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "preview_invitation"})
+		return
+	}
+
+	response.OK(c, preview)
+}
+
+// AcceptInvitation makes the current user a member through an invitation
+// to their email address
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req InvitationTokenRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	m, err := h.invitationService.Accept(c.Request.Context(), userID, req.Token)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "accept_invitation", "user_id": userID})
+		return
+	}
+
+	response.OK(c, m)
+}
+
+// RegisterWithInvitation registers the invited email and makes the new
+// user a member
+func (h *InvitationHandler) RegisterWithInvitation(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req RegisterWithInvitationRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	ctx := service.WithHoneypotValue(service.WithCaptchaToken(c.Request.Context(), req.CaptchaToken), req.Website)
+	u, m, err := h.invitationService.Register(ctx, req.Token, req.Name, req.Password)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{"operation": "register_with_invitation", "name": req.Name})
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		setRetryAfter(c, httpErr)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.Created(c, InvitationRegistrationResponse{User: userView(c, u), Membership: m})
+}
+
+func (h *InvitationHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	orgMocks "github.com/cctw-zed/wonder/internal/domain/organization/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func serveInvitations(handler *InvitationHandler, userID, method, path, body string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.Use(withUserID(userID))
+	router.POST("/invitations", handler.CreateInvitation)
+	router.GET("/invitations", handler.ListInvitations)
+	router.GET("/invitations/preview", handler.PreviewInvitation)
+	router.POST("/invitations/accept", handler.AcceptInvitation)
+	router.POST("/invitations/register", handler.RegisterWithInvitation)
+	router.POST("/invitations/:id/resend", handler.ResendInvitation)
+	router.DELETE("/invitations/:id", handler.RevokeInvitation)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func newTestInvitationHandler(t *testing.T) (*InvitationHandler, *orgMocks.MockRepository) {
+	logger.Initialize()
+	repo := orgMocks.NewMockRepository(gomock.NewController(t))
+	users := fake.NewUserRepository()
+	require.NoError(t, users.Create(context.Background(), &user.User{ID: "user-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser}))
+	svc := service.NewInvitationService(repo, users, service.NewUserService(users, fake.NewIDGenerator(100)), fake.NewTokenService(), fake.NewIDGenerator(1),
+		service.InvitationPolicy{TTL: time.Hour, URL: "https://wonder.example.com/invite"})
+	return NewInvitationHandler(svc), repo
+}
+
+func TestInvitationHandler_InviteAndRegister(t *testing.T) {
+	handler, repo := newTestInvitationHandler(t)
+
+	var stored *organization.Invitation
+	repo.EXPECT().GetMember(gomock.Any(), "o-1", "user-1").Return(&organization.Member{OrgID: "o-1", UserID: "user-1", Role: organization.RoleOwner}, nil)
+	repo.EXPECT().CreateInvitation(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, i *organization.Invitation) error {
+		stored = i
+		return nil
+	})
+	w := serveInvitations(handler, "user-1", http.MethodPost, "/invitations", `{"org_id":"o-1","email":"grace@example.com"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Data struct {
+			Status  string `json:"status"`
+			Emailed bool   `json:"emailed"`
+			Link    string `json:"link"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "pending", created.Data.Status)
+	assert.False(t, created.Data.Emailed)
+	assert.NotContains(t, w.Body.String(), stored.TokenHash)
+	link, err := url.Parse(created.Data.Link)
+	require.NoError(t, err)
+	token := link.Query().Get("token")
+	require.NotEmpty(t, token)
+
+	repo.EXPECT().GetInvitationByTokenHash(gomock.Any(), stored.TokenHash).Return(stored, nil).Times(2)
+	repo.EXPECT().Get(gomock.Any(), "o-1").Return(&organization.Organization{ID: "o-1", Name: "Research"}, nil)
+	w = serveInvitations(handler, "", http.MethodGet, "/invitations/preview?token="+url.QueryEscape(token), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"email":"grace@example.com"`)
+	assert.Contains(t, w.Body.String(), `"registered":false`)
+
+	repo.EXPECT().AddMember(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().UpdateInvitation(gomock.Any(), stored).Return(nil)
+	w = serveInvitations(handler, "", http.MethodPost, "/invitations/register", `{"token":"`+token+`","name":"Grace","password":"password123"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"email":"grace@example.com"`)
+	assert.Contains(t, w.Body.String(), `"role":"member"`)
+}
+
+func TestInvitationHandler_Errors(t *testing.T) {
+	handler, repo := newTestInvitationHandler(t)
+
+	w := serveInvitations(handler, "user-1", http.MethodGet, "/invitations", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "org_id is required")
+
+	w = serveInvitations(handler, "", http.MethodGet, "/invitations/preview?token=forged", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	repo.EXPECT().GetInvitation(gomock.Any(), "i-9").Return(nil, nil)
+	w = serveInvitations(handler, "user-1", http.MethodDelete, "/invitations/i-9", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
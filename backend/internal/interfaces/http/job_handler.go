@@ -0,0 +1,135 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type EnqueueJobRequest struct {
+	Type    string          `json:"type" binding:"required,max=100"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// deadJobsQuery pages through the dead-letter set
+type deadJobsQuery struct {
+	Page     int `form:"page" binding:"min=1"`
+	PageSize int `form:"page_size" binding:"min=1,max=100"`
+}
+
+type JobHandler struct {
+	jobService  service.JobService
+	errorMapper *errors.ErrorMapper
+	errorLogger errors.ErrorLogger
+}
+
+func NewJobHandler(jobService service.JobService) *JobHandler {
+	return &JobHandler{
+		jobService:  jobService,
+		errorMapper: errors.NewErrorMapper(),
+		errorLogger: errors.NewDefaultErrorLogger("job-service"),
+	}
+}
+
+// GetStats counts pending, scheduled, active and dead jobs
+func (h *JobHandler) GetStats(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	stats, err := h.jobService.Stats(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "job_stats"})
+		return
+	}
+
+	response.OK(c, stats)
+}
+
+// ListDeadJobs lists jobs that used up their attempts, most recently
+// failed first
+func (h *JobHandler) ListDeadJobs(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	query := &deadJobsQuery{Page: 1, PageSize: 20}
+	if err := validation.BindQuery(c, query); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	result, err := h.jobService.ListDead(c.Request.Context(), query.Page, query.PageSize)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "list_dead_jobs"})
+		return
+	}
+
+	response.Page(c, result.Jobs, &response.Meta{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages,
+		HasMore:    result.Page < result.TotalPages,
+	})
+}
+
+// EnqueueJob adds a job of a registered type, e.g. rebuild-stats
+func (h *JobHandler) EnqueueJob(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req EnqueueJobRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	job, err := h.jobService.Enqueue(c.Request.Context(), req.Type, req.Payload)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation": "enqueue_job",
+			"type":      req.Type,
+		})
+		return
+	}
+
+	response.JSON(c, http.StatusAccepted, job, nil)
+}
+
+// RetryDeadJob makes a dead job due now with its attempts reset
+func (h *JobHandler) RetryDeadJob(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	if err := h.jobService.RetryDead(c.Request.Context(), c.Param("id")); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation": "retry_dead_job",
+			"job_id":    c.Param("id"),
+		})
+		return
+	}
+
+	response.Message(c, "Job requeued successfully")
+}
+
+// DeleteDeadJob discards a dead job
+func (h *JobHandler) DeleteDeadJob(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	if err := h.jobService.DeleteDead(c.Request.Context(), c.Param("id")); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation": "delete_dead_job",
+			"job_id":    c.Param("id"),
+		})
+		return
+	}
+
+	response.Message(c, "Job deleted successfully")
+}
+
+func (h *JobHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/pkg/jobs"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func serveJobs(handler *JobHandler, method, path, body string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.GET("/admin/jobs", handler.GetStats)
+	router.POST("/admin/jobs", handler.EnqueueJob)
+	router.GET("/admin/jobs/dead", handler.ListDeadJobs)
+	router.POST("/admin/jobs/dead/:id/retry", handler.RetryDeadJob)
+	router.DELETE("/admin/jobs/dead/:id", handler.DeleteDeadJob)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func newTestJobHandler(t *testing.T) (*JobHandler, *jobs.MemoryQueue, *jobs.Worker) {
+	logger.Initialize()
+	queue := jobs.NewMemoryQueue()
+	worker := jobs.NewWorker(queue)
+	service.RegisterJobHandlers(worker, nil, &stubReportingService{}, nil, nil, nil, nil, nil)
+	return NewJobHandler(service.NewJobService(queue, worker)), queue, worker
+}
+
+func TestJobHandler_EnqueueJob(t *testing.T) {
+	handler, _, _ := newTestJobHandler(t)
+
+	w := serveJobs(handler, http.MethodPost, "/admin/jobs", `{"type":"rebuild-stats","payload":{"days":7}}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var body struct {
+		Data jobs.Job `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.NotEmpty(t, body.Data.ID)
+	assert.Equal(t, service.JobRebuildStats, body.Data.Type)
+
+	w = serveJobs(handler, http.MethodGet, "/admin/jobs", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"pending":1`)
+
+	w = serveJobs(handler, http.MethodPost, "/admin/jobs", `{"type":"send-email"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestJobHandler_DeadJobs(t *testing.T) {
+	handler, queue, worker := newTestJobHandler(t)
+	ctx := context.Background()
+
+	require.NoError(t, queue.Enqueue(ctx, &jobs.Job{ID: "j-1", Type: "retired-type"}))
+	_, err := worker.RunOnce(ctx)
+	require.NoError(t, err)
+
+	w := serveJobs(handler, http.MethodGet, "/admin/jobs/dead?page_size=10", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data []jobs.Job `json:"data"`
+		Meta struct {
+			Total int64 `json:"total"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(1), body.Meta.Total)
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, "j-1", body.Data[0].ID)
+
+	w = serveJobs(handler, http.MethodGet, "/admin/jobs/dead?page_size=500", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serveJobs(handler, http.MethodPost, "/admin/jobs/dead/j-1/retry", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveJobs(handler, http.MethodDelete, "/admin/jobs/dead/j-1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	apperrors "github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// Both JSON engines are exercised directly, whichever the build selects, so
+// that a payload json-iterator writes differently fails here rather than in
+// a client.
+var jsoniterAPI = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// jsonPayloads are response bodies as the handlers write them
+func jsonPayloads() map[string]interface{} {
+	full, bare := compatUsers()
+	hits := []*user.SearchHit{{
+		User: full,
+		Rank: 0.0759909,
+		Highlights: map[string][]user.Fragment{
+			"name":  {{Text: "Ada", Match: true}, {Text: " Lovelace"}},
+			"email": {{Text: "ada", Match: true}, {Text: "@example.com"}},
+		},
+	}}
+	validation := apperrors.NewHTTPError(400, apperrors.CodeValidationError, "validation failed <email>", map[string]interface{}{
+		"fields": []apperrors.FieldError{{
+			Field:      "name",
+			Code:       apperrors.CodeOutOfRange,
+			Message:    "name must be at least 2 characters & at most 50",
+			Constraint: map[string]interface{}{"min": 2, "max": 50},
+		}},
+	}, "trace-1")
+
+	payloads := map[string]interface{}{
+		"message":    response.Envelope{Data: response.MessageData{Message: "Password changed"}, TraceID: "trace-1"},
+		"error":      response.Envelope{Error: validation, TraceID: "trace-1", RequestID: "req-1"},
+		"export":     response.Envelope{Data: &DataExportResponse{ID: "x-1", Status: "ready", Size: 2048, CreatedAt: full.CreatedAt, CompletedAt: full.DeletionScheduledAt}, TraceID: "trace-1"},
+		"mfa_login":  response.Envelope{Data: &LoginResponse{ExpiresIn: 300, MFARequired: true, MFAToken: "mfa.token"}, TraceID: "trace-1"},
+		"unicode":    response.Envelope{Data: response.MessageData{Message: "héllo \u2028 wörld \U0001F600 \x7f"}, TraceID: "trace-1"},
+		"empty_page": response.Envelope{Data: NewUserViews(APIv2, nil), Meta: &response.Meta{PageSize: 20, TotalKind: "none"}, TraceID: "trace-1"},
+	}
+	for _, v := range APIVersions {
+		prefix := v.String() + "_"
+		payloads[prefix+"user"] = response.Envelope{Data: NewUserView(v, full), TraceID: "trace-1"}
+		payloads[prefix+"bare_user"] = response.Envelope{Data: NewUserView(v, bare), TraceID: "trace-1"}
+		payloads[prefix+"page"] = response.Envelope{
+			Data:    NewUserViews(v, []*user.User{full, bare}),
+			Meta:    &response.Meta{Page: 2, PageSize: 2, Total: 7, TotalPages: 4, NextCursor: "eyJpZCI6InUtMiJ9", HasMore: true},
+			TraceID: "trace-1",
+		}
+		payloads[prefix+"login"] = response.Envelope{
+			Data: NewLoginResponse(v, &service.LoginResponse{
+				User:          full,
+				AccessToken:   "header.payload.signature",
+				TokenType:     "Bearer",
+				ExpiresIn:     900,
+				RecoveryCodes: []string{"abcd-efgh", "ijkl-mnop"},
+			}),
+			TraceID: "trace-1",
+		}
+		payloads[prefix+"search"] = response.Envelope{Data: NewSearchHitResponses(v, hits), TraceID: "trace-1"}
+	}
+	return payloads
+}
+
+func TestJSONEngines_SameOutput(t *testing.T) {
+	for name, payload := range jsonPayloads() {
+		t.Run(name, func(t *testing.T) {
+			want, err := json.Marshal(payload)
+			require.NoError(t, err)
+			got, err := jsoniterAPI.Marshal(payload)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+// Invalid UTF-8 is the one known difference: encoding/json writes the
+// replacement character as is, json-iterator as \ufffd. Clients read the
+// same string either way.
+func TestJSONEngines_InvalidUTF8(t *testing.T) {
+	payload := response.Envelope{Data: response.MessageData{Message: "caf\xe9"}, TraceID: "trace-1"}
+	want, err := json.Marshal(payload)
+	require.NoError(t, err)
+	got, err := jsoniterAPI.Marshal(payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+}
+
+func TestJSONEngines_SameDecoding(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		value func() interface{}
+	}{
+		{"register", `{"email":"ada@example.com","name":"Ada","password":"secret1","website":""}`, func() interface{} { return &RegisterRequest{} }},
+		{"register_unknown_field", `{"email":"ada@example.com","extra":{"a":[1,2]}}`, func() interface{} { return &RegisterRequest{} }},
+		{"register_escapes", `{"name":"Adá \"L\"","email":"ada@example.com"}`, func() interface{} { return &RegisterRequest{} }},
+		{"register_case_insensitive", `{"EMAIL":"ada@example.com","Name":"Ada"}`, func() interface{} { return &RegisterRequest{} }},
+		{"patch_nulls", `{"handle":null,"timezone":"","locale":"en-GB"}`, func() interface{} { return &PatchUserRequest{} }},
+		{"patch_wrong_type", `{"name":42}`, func() interface{} { return &PatchUserRequest{} }},
+		{"login_truncated", `{"email":"ada@example.com","password":`, func() interface{} { return &LoginRequest{} }},
+		{"login_not_object", `["ada@example.com"]`, func() interface{} { return &LoginRequest{} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, got := tt.value(), tt.value()
+			wantErr := json.Unmarshal([]byte(tt.body), want)
+			gotErr := jsoniterAPI.Unmarshal([]byte(tt.body), got)
+			assert.Equal(t, wantErr == nil, gotErr == nil, "std: %v, jsoniter: %v", wantErr, gotErr)
+			if wantErr == nil {
+				assert.Equal(t, want, got)
+			}
+		})
+	}
+}
+
+func BenchmarkJSON_Encode(b *testing.B) {
+	full, bare := compatUsers()
+	page := response.Envelope{
+		Data:    NewUserViews(APIv2, []*user.User{full, bare, full, bare, full, bare, full, bare, full, bare}),
+		Meta:    &response.Meta{Page: 1, PageSize: 10, Total: 42, TotalPages: 5, HasMore: true},
+		TraceID: "trace-1",
+	}
+	engines := map[string]func(v interface{}) ([]byte, error){
+		"std":      json.Marshal,
+		"jsoniter": jsoniterAPI.Marshal,
+	}
+	for name, marshal := range engines {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := marshal(page); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkJSON_Decode(b *testing.B) {
+	body := []byte(`{"email":"ada@example.com","name":"Ada Lovelace","password":"correct horse battery","captcha_token":"0.aBcDeFgHiJkLmNoPqRsTuVwXyZ"}`)
+	engines := map[string]func(data []byte, v interface{}) error{
+		"std":      json.Unmarshal,
+		"jsoniter": jsoniterAPI.Unmarshal,
+	}
+	for name, unmarshal := range engines {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var req RegisterRequest
+				if err := unmarshal(body, &req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
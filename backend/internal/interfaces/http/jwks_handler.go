@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/pkg/jwt"
+)
+
+// KeySource provides the public token verification keys
+type KeySource interface {
+	JWKS() jwt.JWKS
+}
+
+// JWKSHandler publishes the token verification keys so other services can
+// validate access tokens without sharing a secret
+type JWKSHandler struct {
+	keys KeySource
+}
+
+func NewJWKSHandler(keys KeySource) *JWKSHandler {
+	if keys == nil {
+		panic("key source cannot be nil")
+	}
+	return &JWKSHandler{keys: keys}
+}
+
+// JWKS serves the key set in the standard RFC 7517 layout rather than the API
+// envelope, since JWT libraries fetch it directly. Short caching lets
+// clients pick up rotated keys within minutes.
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/jwt"
+)
+
+type staticKeySource jwt.JWKS
+
+func (s staticKeySource) JWKS() jwt.JWKS { return jwt.JWKS(s) }
+
+func TestJWKSHandler_JWKS(t *testing.T) {
+	keys := staticKeySource{Keys: []jwt.JWK{{KeyType: "RSA", Use: "sig", Algorithm: jwt.AlgRS256, KeyID: "2025-01", N: "abc", E: "AQAB"}}}
+
+	router := setupGinTest()
+	router.GET("/.well-known/jwks.json", NewJWKSHandler(keys).JWKS)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+
+	var body jwt.JWKS
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, jwt.JWKS(keys), body)
+}
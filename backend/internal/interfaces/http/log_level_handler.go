@@ -0,0 +1,100 @@
+package http
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// SetLogLevelRequest overrides the log level, for Duration when given, e.g.
+// {"level": "debug", "duration": "10m"}
+type SetLogLevelRequest struct {
+	Level    string `json:"level" binding:"required,oneof=debug info warn error"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// LogLevelResponse is the level the process logs at
+type LogLevelResponse struct {
+	Level           string     `json:"level"`
+	ConfiguredLevel string     `json:"configured_level"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+}
+
+// LogLevelHandler changes the level of this process's logger at runtime.
+// Overrides live in memory: they apply to the instance serving the request
+// and end on restart.
+type LogLevelHandler struct {
+	errorMapper *errors.ErrorMapper
+	log         logger.Logger
+}
+
+func NewLogLevelHandler() *LogLevelHandler {
+	return &LogLevelHandler{
+		errorMapper: errors.NewErrorMapper(),
+		log:         logger.Get().WithLayer("interfaces").WithComponent("log_level_handler"),
+	}
+}
+
+// GetLogLevel returns the current level and the override in effect
+func (h *LogLevelHandler) GetLogLevel(c *gin.Context) {
+	response.OK(c, CurrentLogLevel())
+}
+
+// SetLogLevel overrides the level until the duration passes or the
+// override is cleared
+func (h *LogLevelHandler) SetLogLevel(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req SetLogLevelRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	var ttl time.Duration
+	if req.Duration != "" {
+		parsed, err := time.ParseDuration(req.Duration)
+		if err != nil || parsed <= 0 {
+			response.Error(c, h.errorMapper.MapToHTTPError(errors.NewInvalidFormatError("duration", req.Duration, "positive duration, e.g. 10m"), traceID))
+			return
+		}
+		ttl = parsed
+	}
+
+	o, err := logger.OverrideLevel(req.Level, ttl)
+	if err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+	h.log.Warn(c.Request.Context(), "log level overridden", "level", o.Level, "configured_level", o.ConfiguredLevel, "duration", req.Duration)
+
+	response.OK(c, CurrentLogLevel())
+}
+
+// ClearLogLevel ends the override and restores the configured level
+func (h *LogLevelHandler) ClearLogLevel(c *gin.Context) {
+	if logger.ClearLevelOverride() {
+		h.log.Warn(c.Request.Context(), "log level override cleared", "level", logger.GetLevel())
+	}
+	response.OK(c, CurrentLogLevel())
+}
+
+// CurrentLogLevel describes the level the global logger logs at
+func CurrentLogLevel() *LogLevelResponse {
+	level := logger.GetLevel()
+	resp := &LogLevelResponse{Level: level, ConfiguredLevel: level}
+	if o, ok := logger.ActiveLevelOverride(); ok {
+		resp.ConfiguredLevel = o.ConfiguredLevel
+		if !o.ExpiresAt.IsZero() {
+			expiresAt := o.ExpiresAt.UTC()
+			resp.ExpiresAt = &expiresAt
+		}
+	}
+	return resp
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	require.NoError(t, logger.SetLevel("info"))
+	t.Cleanup(func() { logger.ClearLevelOverride() })
+
+	handler := NewLogLevelHandler()
+	router := setupGinTest()
+	router.GET("/admin/log-level", handler.GetLogLevel)
+	router.PUT("/admin/log-level", handler.SetLogLevel)
+	router.DELETE("/admin/log-level", handler.ClearLogLevel)
+
+	send := func(method, body string) (*httptest.ResponseRecorder, LogLevelResponse) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/admin/log-level", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		var resp struct {
+			Data LogLevelResponse `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp.Data
+	}
+
+	w, level := send(http.MethodPut, `{"level":"debug","duration":"10m"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "debug", level.Level)
+	assert.Equal(t, "info", level.ConfiguredLevel)
+	require.NotNil(t, level.ExpiresAt)
+
+	_, level = send(http.MethodGet, "")
+	assert.Equal(t, "debug", level.Level)
+
+	w, _ = send(http.MethodPut, `{"level":"loud"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = send(http.MethodPut, `{"level":"debug","duration":"soon"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	_, level = send(http.MethodDelete, "")
+	assert.Equal(t, "info", level.Level)
+	assert.Nil(t, level.ExpiresAt)
+}
@@ -0,0 +1,219 @@
+package http
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// MFACodeRequest carries a code from the authenticator app or, where
+// accepted, a recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// MFATokenRequest carries the MFA token of a login waiting for its second
+// factor
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFAVerifyRequest completes a login waiting for its second factor.
+// RememberDevice asks for a device token that skips the second factor on
+// later logins from this device.
+type MFAVerifyRequest struct {
+	MFAToken       string `json:"mfa_token" binding:"required"`
+	Code           string `json:"code" binding:"required,max=32"`
+	RememberDevice bool   `json:"remember_device"`
+	DeviceName     string `json:"device_name,omitempty" binding:"max=255"`
+}
+
+// RecoveryCodesResponse lists recovery codes; they are not shown again
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAHandler lets signed-in users manage their own authenticator
+type MFAHandler struct {
+	mfaService  service.MFAService
+	errorMapper *errors.ErrorMapper
+	errorLogger errors.ErrorLogger
+}
+
+func NewMFAHandler(mfaService service.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService:  mfaService,
+		errorMapper: errors.NewErrorMapper(),
+		errorLogger: errors.NewDefaultErrorLogger("mfa-service"),
+	}
+}
+
+// GetStatus reports whether the current user has two-factor enabled
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.mfaService.Status(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "get_mfa_status", "user_id": userID})
+		return
+	}
+
+	response.OK(c, status)
+}
+
+// Enroll generates a new authenticator secret for the current user
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	setup, err := h.mfaService.BeginEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "begin_mfa_enrollment", "user_id": userID})
+		return
+	}
+
+	response.Created(c, setup)
+}
+
+// Confirm enables two-factor with a first code and returns the recovery codes
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmEnrollment(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "confirm_mfa_enrollment", "user_id": userID})
+		return
+	}
+
+	response.OK(c, &RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "regenerate_recovery_codes", "user_id": userID})
+		return
+	}
+
+	response.OK(c, &RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable turns two-factor off for the current user
+func (h *MFAHandler) Disable(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	if err := h.mfaService.Disable(c.Request.Context(), userID, req.Code); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "disable_mfa", "user_id": userID})
+		return
+	}
+
+	response.Message(c, "Two-factor authentication disabled")
+}
+
+// ListDevices lists the current user's trusted devices
+func (h *MFAHandler) ListDevices(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.mfaService.ListTrustedDevices(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "list_trusted_devices", "user_id": userID})
+		return
+	}
+
+	response.OK(c, devices)
+}
+
+// RevokeDevice stops one of the current user's devices skipping the second
+// factor
+func (h *MFAHandler) RevokeDevice(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	deviceID := c.Param("id")
+
+	if err := h.mfaService.RevokeTrustedDevice(c.Request.Context(), userID, deviceID); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "revoke_trusted_device", "user_id": userID, "device_id": deviceID})
+		return
+	}
+
+	response.Message(c, "Trusted device revoked")
+}
+
+// rememberDevice describes the requesting device when the client asked to
+// remember it
+func rememberDevice(c *gin.Context, req *MFAVerifyRequest) *service.DeviceInfo {
+	if !req.RememberDevice {
+		return nil
+	}
+	return &service.DeviceInfo{
+		Name:      req.DeviceName,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: middleware.GetClientIPFromContext(c.Request.Context()),
+	}
+}
+
+func (h *MFAHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+	setRetryAfter(c, httpErr)
+	response.Error(c, httpErr)
+}
+
+// setRetryAfter tells clients when a locked resource can be retried
+func setRetryAfter(c *gin.Context, httpErr *errors.HTTPError) {
+	if retryAfter, ok := httpErr.ErrorDetails["retry_after_seconds"].(int); ok {
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+	}
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/mfa"
+	mfaMocks "github.com/cctw-zed/wonder/internal/domain/mfa/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	userMocks "github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/totp"
+)
+
+var testMFAPolicy = service.MFAPolicy{
+	Enforcement:   service.MFAEnforcementOptional,
+	Issuer:        "Wonder",
+	Skew:          1,
+	MaxAttempts:   5,
+	LockDuration:  15 * time.Minute,
+	RecoveryCodes: 4,
+}
+
+func serveMFA(handler *MFAHandler, method, path, body string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.Use(withUserID("user-1"))
+	router.GET("/users/me/mfa", handler.GetStatus)
+	router.POST("/users/me/mfa", handler.Enroll)
+	router.POST("/users/me/mfa/confirm", handler.Confirm)
+	router.POST("/users/me/mfa/recovery-codes", handler.RegenerateRecoveryCodes)
+	router.DELETE("/users/me/mfa", handler.Disable)
+	router.GET("/users/me/mfa/devices", handler.ListDevices)
+	router.DELETE("/users/me/mfa/devices/:id", handler.RevokeDevice)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func newTestMFAHandler(t *testing.T) (*MFAHandler, *mfaMocks.MockRepository, *userMocks.MockUserRepository) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+	repo := mfaMocks.NewMockRepository(ctrl)
+	users := userMocks.NewMockUserRepository(ctrl)
+	return NewMFAHandler(service.NewMFAService(repo, users, fake.NewIDGenerator(1), testMFAPolicy)), repo, users
+}
+
+func TestMFAHandler_Enrollment(t *testing.T) {
+	handler, repo, users := newTestMFAHandler(t)
+	users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Email: "ada@example.com"}, nil)
+	repo.EXPECT().Get(gomock.Any(), "user-1").Return(nil, nil)
+	var saved *mfa.Enrollment
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *mfa.Enrollment) error {
+		saved = e
+		return nil
+	})
+
+	w := serveMFA(handler, http.MethodPost, "/users/me/mfa", "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var setup struct {
+		Data service.MFASetup `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &setup))
+	assert.Equal(t, saved.Secret, setup.Data.Secret)
+	assert.Contains(t, setup.Data.ProvisioningURI, "otpauth://totp/Wonder:ada@example.com")
+
+	// Confirming with a current code returns the recovery codes
+	code, err := totp.Code(saved.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	repo.EXPECT().Get(gomock.Any(), "user-1").Return(saved, nil)
+	repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+	w = serveMFA(handler, http.MethodPost, "/users/me/mfa/confirm", `{"code":"`+code+`"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var codes struct {
+		Data RecoveryCodesResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &codes))
+	assert.Len(t, codes.Data.RecoveryCodes, 4)
+	assert.True(t, saved.Confirmed())
+}
+
+func TestMFAHandler_Confirm_InvalidCode(t *testing.T) {
+	handler, repo, _ := newTestMFAHandler(t)
+	repo.EXPECT().Get(gomock.Any(), "user-1").Return(&mfa.Enrollment{UserID: "user-1", Secret: "JBSWY3DPEHPK3PXP"}, nil)
+	repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+	w := serveMFA(handler, http.MethodPost, "/users/me/mfa/confirm", `{"code":"000000"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serveMFA(handler, http.MethodPost, "/users/me/mfa/confirm", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMFAHandler_GetStatus(t *testing.T) {
+	handler, repo, users := newTestMFAHandler(t)
+	confirmedAt := time.Now()
+	users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Role: user.RoleUser}, nil)
+	repo.EXPECT().Get(gomock.Any(), "user-1").Return(&mfa.Enrollment{
+		UserID: "user-1", ConfirmedAt: &confirmedAt, RecoveryCodes: []string{"a", "b"},
+	}, nil)
+
+	w := serveMFA(handler, http.MethodGet, "/users/me/mfa", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"enabled":true`)
+	assert.Contains(t, w.Body.String(), `"recovery_codes_remaining":2`)
+}
+
+func TestMFAHandler_TrustedDevices(t *testing.T) {
+	handler, repo, _ := newTestMFAHandler(t)
+	repo.EXPECT().ListDevices(gomock.Any(), "user-1", gomock.Any()).Return([]*mfa.TrustedDevice{
+		{ID: "device-1", UserID: "user-1", TokenHash: mfa.HashDeviceToken("secret"), Name: "Laptop"},
+	}, nil)
+
+	w := serveMFA(handler, http.MethodGet, "/users/me/mfa/devices", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Laptop"`)
+	assert.NotContains(t, w.Body.String(), mfa.HashDeviceToken("secret"))
+
+	repo.EXPECT().DeleteDevice(gomock.Any(), "user-1", "device-1").Return(nil)
+	w = serveMFA(handler, http.MethodDelete, "/users/me/mfa/devices/device-1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
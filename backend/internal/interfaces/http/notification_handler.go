@@ -0,0 +1,131 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// ListNotificationsQuery pages through the current user's notifications,
+// newest first
+type ListNotificationsQuery struct {
+	Unread   bool `form:"unread"`
+	Page     int  `form:"page" binding:"min=1"`
+	PageSize int  `form:"page_size" binding:"min=1,max=100"`
+}
+
+// UnreadCountResponse is how many notifications the user has not read
+type UnreadCountResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+// MarkAllReadResponse is how many notifications were marked read
+type MarkAllReadResponse struct {
+	Marked int64 `json:"marked"`
+}
+
+// NotificationHandler lets signed-in users read their in-app notifications
+type NotificationHandler struct {
+	notificationService service.NotificationService
+	errorMapper         *errors.ErrorMapper
+	errorLogger         errors.ErrorLogger
+}
+
+func NewNotificationHandler(notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		errorMapper:         errors.NewErrorMapper(),
+		errorLogger:         errors.NewDefaultErrorLogger("notification-service"),
+	}
+}
+
+// ListNotifications lists a page of the current user's notifications
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	query := &ListNotificationsQuery{Page: 1, PageSize: 20}
+	if err := validation.BindQuery(c, query); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	result, err := h.notificationService.List(c.Request.Context(), userID, query.Unread, query.Page, query.PageSize)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "list_notifications", "user_id": userID})
+		return
+	}
+
+	response.Page(c, result.Notifications, &response.Meta{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages,
+		HasMore:    result.Page < result.TotalPages,
+	})
+}
+
+// UnreadCount returns how many of the current user's notifications are
+// unread, for a badge
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.notificationService.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "count_unread_notifications", "user_id": userID})
+		return
+	}
+
+	response.OK(c, &UnreadCountResponse{Unread: count})
+}
+
+// MarkRead marks one of the current user's notifications read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+
+	n, err := h.notificationService.MarkRead(c.Request.Context(), userID, id)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "mark_notification_read", "user_id": userID, "notification_id": id})
+		return
+	}
+
+	response.OK(c, n)
+}
+
+// MarkAllRead marks all of the current user's notifications read
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	marked, err := h.notificationService.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "mark_all_notifications_read", "user_id": userID})
+		return
+	}
+
+	response.OK(c, &MarkAllReadResponse{Marked: marked})
+}
+
+func (h *NotificationHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/notification"
+	notificationMocks "github.com/cctw-zed/wonder/internal/domain/notification/mocks"
+	userMocks "github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+func serveNotifications(handler *NotificationHandler, method, path string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.Use(withUserID("user-1"))
+	router.GET("/users/me/notifications", handler.ListNotifications)
+	router.GET("/users/me/notifications/unread-count", handler.UnreadCount)
+	router.POST("/users/me/notifications/read", handler.MarkAllRead)
+	router.POST("/users/me/notifications/:id/read", handler.MarkRead)
+
+	req := httptest.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func newTestNotificationHandler(t *testing.T) (*NotificationHandler, *notificationMocks.MockRepository) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+	repo := notificationMocks.NewMockRepository(ctrl)
+	svc := service.NewNotificationService(repo, userMocks.NewMockUserRepository(ctrl), idMocks.NewMockGenerator(ctrl))
+	return NewNotificationHandler(svc), repo
+}
+
+func TestNotificationHandler_List(t *testing.T) {
+	handler, repo := newTestNotificationHandler(t)
+	repo.EXPECT().List(gomock.Any(), "user-1", notification.ListFilter{UnreadOnly: true, Offset: 2, Limit: 2}).
+		Return([]*notification.Notification{{ID: "n-1", UserID: "user-1", Type: notification.TypeWelcome, Title: "Welcome"}}, int64(3), nil)
+
+	w := serveNotifications(handler, http.MethodGet, "/users/me/notifications?unread=true&page=2&page_size=2")
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"data"`
+		Meta struct {
+			Total   int64 `json:"total"`
+			HasMore bool  `json:"has_more"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, "n-1", body.Data[0].ID)
+	assert.Equal(t, int64(3), body.Meta.Total)
+	assert.False(t, body.Meta.HasMore)
+	assert.NotContains(t, w.Body.String(), "user-1")
+
+	w = serveNotifications(handler, http.MethodGet, "/users/me/notifications?page_size=500")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestNotificationHandler_Read(t *testing.T) {
+	handler, repo := newTestNotificationHandler(t)
+
+	repo.EXPECT().CountUnread(gomock.Any(), "user-1").Return(int64(2), nil)
+	w := serveNotifications(handler, http.MethodGet, "/users/me/notifications/unread-count")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"unread":2}`, responseData(t, w))
+
+	repo.EXPECT().Get(gomock.Any(), "user-1", "n-1").Return(&notification.Notification{ID: "n-1", UserID: "user-1", Title: "Welcome", CreatedAt: time.Now()}, nil)
+	repo.EXPECT().MarkRead(gomock.Any(), "user-1", "n-1", gomock.Any()).Return(int64(1), nil)
+	w = serveNotifications(handler, http.MethodPost, "/users/me/notifications/n-1/read")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"read_at"`)
+
+	repo.EXPECT().Get(gomock.Any(), "user-1", "missing").Return(nil, nil)
+	w = serveNotifications(handler, http.MethodPost, "/users/me/notifications/missing/read")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	repo.EXPECT().MarkRead(gomock.Any(), "user-1", "", gomock.Any()).Return(int64(1), nil)
+	w = serveNotifications(handler, http.MethodPost, "/users/me/notifications/read")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"marked":1}`, responseData(t, w))
+}
+
+func responseData(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return string(body.Data)
+}
+
+func TestNotificationHandler_Unauthenticated(t *testing.T) {
+	handler, _ := newTestNotificationHandler(t)
+
+	router := setupGinTest()
+	router.GET("/users/me/notifications", handler.ListNotifications)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/me/notifications", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
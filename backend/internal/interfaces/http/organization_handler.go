@@ -0,0 +1,139 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type TransferOwnershipRequest struct {
+	UserID string `json:"user_id" binding:"required,max=64"`
+}
+
+// OrganizationHandler lets signed-in users run organizations they belong
+// to. Only members see an organization; only its owner manages it.
+type OrganizationHandler struct {
+	organizationService service.OrganizationService
+	errorMapper         *errors.ErrorMapper
+	errorLogger         errors.ErrorLogger
+}
+
+func NewOrganizationHandler(organizationService service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+		errorMapper:         errors.NewErrorMapper(),
+		errorLogger:         errors.NewDefaultErrorLogger("organization-service"),
+	}
+}
+
+// CreateOrganization creates an organization owned by the current user
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateOrganizationRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	o, err := h.organizationService.CreateOrganization(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "create_organization", "user_id": userID})
+		return
+	}
+
+	response.Created(c, o)
+}
+
+// ListOrganizations lists the organizations the current user belongs to
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	orgs, err := h.organizationService.ListOrganizations(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "list_organizations", "user_id": userID})
+		return
+	}
+
+	response.OK(c, orgs)
+}
+
+// GetOrganization returns an organization the current user belongs to
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	orgID := c.Param("id")
+
+	o, err := h.organizationService.GetOrganization(c.Request.Context(), userID, orgID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "get_organization", "user_id": userID, "org_id": orgID})
+		return
+	}
+
+	response.OK(c, o)
+}
+
+// ListMembers lists the members of an organization, owner first
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	orgID := c.Param("id")
+
+	members, err := h.organizationService.ListMembers(c.Request.Context(), userID, orgID)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "list_organization_members", "user_id": userID, "org_id": orgID})
+		return
+	}
+
+	response.OK(c, members)
+}
+
+// TransferOwnership makes another member the owner of an organization
+func (h *OrganizationHandler) TransferOwnership(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	orgID := c.Param("id")
+
+	var req TransferOwnershipRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	if err := h.organizationService.TransferOwnership(c.Request.Context(), userID, orgID, req.UserID); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "transfer_organization_ownership", "user_id": userID, "org_id": orgID})
+		return
+	}
+
+	response.Message(c, "Ownership transferred")
+}
+
+func (h *OrganizationHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
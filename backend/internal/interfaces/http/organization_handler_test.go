@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/organization"
+	orgMocks "github.com/cctw-zed/wonder/internal/domain/organization/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func serveOrganizations(handler *OrganizationHandler, userID, method, path, body string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.Use(withUserID(userID))
+	router.POST("/organizations", handler.CreateOrganization)
+	router.GET("/organizations", handler.ListOrganizations)
+	router.GET("/organizations/:id", handler.GetOrganization)
+	router.GET("/organizations/:id/members", handler.ListMembers)
+	router.POST("/organizations/:id/transfer-ownership", handler.TransferOwnership)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func newTestOrganizationHandler(t *testing.T) (*OrganizationHandler, *orgMocks.MockRepository) {
+	logger.Initialize()
+	repo := orgMocks.NewMockRepository(gomock.NewController(t))
+	users := fake.NewUserRepository()
+	require.NoError(t, users.Create(context.Background(), &user.User{ID: "user-2", Email: "grace@example.com", Name: "Grace", Role: user.RoleUser}))
+	return NewOrganizationHandler(service.NewOrganizationService(repo, users, fake.NewIDGenerator(1))), repo
+}
+
+func TestOrganizationHandler_Create(t *testing.T) {
+	handler, repo := newTestOrganizationHandler(t)
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().AddMember(gomock.Any(), gomock.Any()).Return(nil)
+
+	w := serveOrganizations(handler, "user-1", http.MethodPost, "/organizations", `{"name":"Research"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Research"`)
+
+	w = serveOrganizations(handler, "user-1", http.MethodPost, "/organizations", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestOrganizationHandler_Authorization(t *testing.T) {
+	handler, repo := newTestOrganizationHandler(t)
+
+	repo.EXPECT().GetMember(gomock.Any(), "o-1", "user-9").Return(nil, nil)
+	w := serveOrganizations(handler, "user-9", http.MethodGet, "/organizations/o-1/members", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, "non-members do not learn the organization exists")
+
+	repo.EXPECT().GetMemberForUpdate(gomock.Any(), "o-1", "user-2").Return(&organization.Member{OrgID: "o-1", UserID: "user-2", Role: organization.RoleMember}, nil)
+	w = serveOrganizations(handler, "user-2", http.MethodPost, "/organizations/o-1/transfer-ownership", `{"user_id":"user-2"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,194 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// Media types PATCH endpoints accept
+const (
+	// MediaTypeMergePatch is an RFC 7386 JSON Merge Patch: an object whose
+	// members replace the target's, with null removing a member
+	MediaTypeMergePatch = "application/merge-patch+json"
+	// MediaTypeJSONPatch is an RFC 6902 JSON Patch: an array of operations
+	MediaTypeJSONPatch = "application/json-patch+json"
+)
+
+// acceptPatch is advertised in the Accept-Patch header
+var acceptPatch = []string{MediaTypeMergePatch, MediaTypeJSONPatch}
+
+// patchOperation is one RFC 6902 operation
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// decodeUserPatch decodes body as a JSON Patch when contentType says so and
+// as a merge patch otherwise, including plain application/json
+func decodeUserPatch(contentType string, body []byte) (*PatchUserRequest, error) {
+	mediaType := MediaTypeMergePatch
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			parsed = contentType
+		}
+		mediaType = parsed
+	}
+
+	var (
+		members map[string]json.RawMessage
+		err     error
+	)
+	switch mediaType {
+	case MediaTypeMergePatch, "application/json":
+		if err := json.Unmarshal(body, &members); err != nil || members == nil {
+			return nil, errors.NewFieldValidationError(errors.FieldError{
+				Field:      "body",
+				Code:       errors.CodeInvalidFormat,
+				Message:    "merge patch must be a JSON object",
+				Constraint: map[string]interface{}{"format": "json"},
+			})
+		}
+	case MediaTypeJSONPatch:
+		if members, err = jsonPatchMembers(body); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.NewHTTPError(http.StatusUnsupportedMediaType, errors.CodeUnsupportedMedia,
+			fmt.Sprintf("Content-Type %s is not supported", mediaType),
+			map[string]interface{}{"supported": acceptPatch}, "")
+	}
+	return userPatchFromMembers(members)
+}
+
+// jsonPatchMembers turns add, replace and remove operations on top-level
+// members into the merge patch with the same effect. Later operations on a
+// member win, as they would when applied in order.
+func jsonPatchMembers(body []byte) (map[string]json.RawMessage, error) {
+	var ops []patchOperation
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, errors.NewFieldValidationError(errors.FieldError{
+			Field:      "body",
+			Code:       errors.CodeInvalidFormat,
+			Message:    "JSON Patch must be an array of operations",
+			Constraint: map[string]interface{}{"format": "json"},
+		})
+	}
+
+	members := make(map[string]json.RawMessage, len(ops))
+	var fields []errors.FieldError
+	for i, op := range ops {
+		field := fmt.Sprintf("body[%d]", i)
+		if len(op.Path) < 2 || op.Path[0] != '/' {
+			fields = append(fields, errors.FieldError{
+				Field:   field + ".path",
+				Code:    errors.CodeInvalidFormat,
+				Message: "path must point to a member, e.g. /name",
+			})
+			continue
+		}
+		member := op.Path[1:]
+
+		switch op.Op {
+		case "add", "replace":
+			if len(op.Value) == 0 {
+				fields = append(fields, errors.FieldError{
+					Field:   field + ".value",
+					Code:    errors.CodeRequiredField,
+					Message: fmt.Sprintf("%s requires a value", op.Op),
+				})
+				continue
+			}
+			members[member] = op.Value
+		case "remove":
+			members[member] = json.RawMessage("null")
+		default:
+			fields = append(fields, errors.FieldError{
+				Field:      field + ".op",
+				Code:       errors.CodeInvalidValue,
+				Message:    "op must be one of: add, replace, remove",
+				Constraint: map[string]interface{}{"oneof": []string{"add", "replace", "remove"}},
+			})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, errors.NewFieldValidationError(fields...)
+	}
+	return members, nil
+}
+
+// userPatchFromMembers maps merge patch members to profile fields. Members
+// that are not profile fields are rejected rather than ignored, so a
+// client patching role or id learns it has no effect.
+func userPatchFromMembers(members map[string]json.RawMessage) (*PatchUserRequest, error) {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	req := &PatchUserRequest{}
+	var fields []errors.FieldError
+	for _, name := range names {
+		var target **string
+		switch name {
+		case "name":
+			target = &req.Name
+		case "email":
+			target = &req.Email
+		case "handle":
+			target = &req.Handle
+		case "timezone":
+			target = &req.Timezone
+		case "locale":
+			target = &req.Locale
+		default:
+			fields = append(fields, errors.FieldError{
+				Field:   name,
+				Code:    errors.CodeInvalidValue,
+				Message: fmt.Sprintf("%s cannot be changed", name),
+			})
+			continue
+		}
+
+		// null removes a member; only the handle and preferences are optional
+		raw := members[name]
+		if string(raw) == "null" && name != "name" && name != "email" {
+			*target = new(string)
+			continue
+		}
+		if string(raw) == "null" {
+			fields = append(fields, errors.FieldError{
+				Field:   name,
+				Code:    errors.CodeRequiredField,
+				Message: fmt.Sprintf("%s is required and cannot be removed", name),
+			})
+			continue
+		}
+		value := new(string)
+		if err := json.Unmarshal(raw, value); err != nil {
+			fields = append(fields, errors.FieldError{
+				Field:      name,
+				Code:       errors.CodeInvalidFormat,
+				Message:    fmt.Sprintf("%s must be of type string", name),
+				Constraint: map[string]interface{}{"type": "string"},
+			})
+			continue
+		}
+		*target = value
+	}
+	if len(fields) > 0 {
+		return nil, errors.NewFieldValidationError(fields...)
+	}
+	if err := validation.Validate(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
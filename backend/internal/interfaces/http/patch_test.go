@@ -0,0 +1,85 @@
+package http
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// patchFields returns the invalid fields err reports, keyed by field
+func patchFields(t *testing.T, err error) map[string]errors.FieldError {
+	t.Helper()
+	var fve *errors.FieldValidationError
+	require.True(t, stderrors.As(err, &fve), "got %v", err)
+	fields := make(map[string]errors.FieldError, len(fve.Fields))
+	for _, f := range fve.Fields {
+		fields[f.Field] = f
+	}
+	return fields
+}
+
+func TestDecodeUserPatch_MergePatch(t *testing.T) {
+	patch, err := decodeUserPatch("application/merge-patch+json; charset=utf-8", []byte(`{"email":"ada@example.com"}`))
+	require.NoError(t, err)
+	assert.Equal(t, &PatchUserRequest{Email: ptr("ada@example.com")}, patch)
+
+	patch, err = decodeUserPatch("", []byte(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, &PatchUserRequest{}, patch, "an empty patch changes nothing")
+
+	_, err = decodeUserPatch(MediaTypeMergePatch, []byte(`["name"]`))
+	assert.Equal(t, errors.CodeInvalidFormat, patchFields(t, err)["body"].Code)
+
+	_, err = decodeUserPatch(MediaTypeMergePatch, []byte(`{"name":42,"email":null,"id":"1"}`))
+	fields := patchFields(t, err)
+	require.Len(t, fields, 3)
+	assert.Equal(t, errors.CodeInvalidFormat, fields["name"].Code)
+	assert.Equal(t, errors.CodeRequiredField, fields["email"].Code)
+	assert.Equal(t, errors.CodeInvalidValue, fields["id"].Code)
+
+	// Preferences are optional, so null removes them
+	patch, err = decodeUserPatch(MediaTypeMergePatch, []byte(`{"timezone":null,"locale":"en-GB"}`))
+	require.NoError(t, err)
+	assert.Equal(t, &PatchUserRequest{Timezone: ptr(""), Locale: ptr("en-GB")}, patch)
+}
+
+func TestDecodeUserPatch_JSONPatch(t *testing.T) {
+	patch, err := decodeUserPatch(MediaTypeJSONPatch, []byte(`[
+		{"op":"replace","path":"/name","value":"Ada"},
+		{"op":"add","path":"/name","value":"Ada Lovelace"},
+		{"op":"replace","path":"/email","value":"ada@example.com"}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, &PatchUserRequest{Name: ptr("Ada Lovelace"), Email: ptr("ada@example.com")}, patch, "later operations win")
+
+	_, err = decodeUserPatch(MediaTypeJSONPatch, []byte(`[
+		{"op":"move","from":"/name","path":"/email"},
+		{"op":"replace","path":"name","value":"Ada"},
+		{"op":"add","path":"/name"}
+	]`))
+	fields := patchFields(t, err)
+	require.Len(t, fields, 3)
+	assert.Equal(t, errors.CodeInvalidValue, fields["body[0].op"].Code)
+	assert.Equal(t, errors.CodeInvalidFormat, fields["body[1].path"].Code)
+	assert.Equal(t, errors.CodeRequiredField, fields["body[2].value"].Code)
+
+	_, err = decodeUserPatch(MediaTypeJSONPatch, []byte(`[{"op":"remove","path":"/name"}]`))
+	assert.Equal(t, errors.CodeRequiredField, patchFields(t, err)["name"].Code)
+
+	// The handle is optional, so removing it clears it
+	patch, err = decodeUserPatch(MediaTypeJSONPatch, []byte(`[{"op":"remove","path":"/handle"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, &PatchUserRequest{Handle: ptr("")}, patch)
+}
+
+func TestDecodeUserPatch_UnsupportedMediaType(t *testing.T) {
+	_, err := decodeUserPatch("application/xml", []byte(`<user/>`))
+	var httpErr *errors.HTTPError
+	require.True(t, stderrors.As(err, &httpErr))
+	assert.Equal(t, errors.CodeUnsupportedMedia, httpErr.ErrorCode)
+	assert.Equal(t, acceptPatch, httpErr.ErrorDetails["supported"])
+}
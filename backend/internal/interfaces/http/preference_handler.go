@@ -0,0 +1,126 @@
+package http
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// ListPreferencesQuery filters preferences by the namespace before the
+// first dot of their keys
+type ListPreferencesQuery struct {
+	Namespace string `form:"namespace" binding:"max=64"`
+}
+
+// SetPreferenceRequest carries any JSON value to store
+type SetPreferenceRequest struct {
+	Value json.RawMessage `json:"value" binding:"required"`
+}
+
+// PreferenceHandler lets signed-in users store their own settings
+type PreferenceHandler struct {
+	preferenceService service.PreferenceService
+	errorMapper       *errors.ErrorMapper
+	errorLogger       errors.ErrorLogger
+}
+
+func NewPreferenceHandler(preferenceService service.PreferenceService) *PreferenceHandler {
+	return &PreferenceHandler{
+		preferenceService: preferenceService,
+		errorMapper:       errors.NewErrorMapper(),
+		errorLogger:       errors.NewDefaultErrorLogger("preference-service"),
+	}
+}
+
+// ListPreferences lists the current user's preferences ordered by key
+func (h *PreferenceHandler) ListPreferences(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var query ListPreferencesQuery
+	if err := validation.BindQuery(c, &query); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	prefs, err := h.preferenceService.List(c.Request.Context(), userID, query.Namespace)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "list_preferences", "user_id": userID})
+		return
+	}
+
+	response.OK(c, prefs)
+}
+
+// GetPreference returns one of the current user's preferences
+func (h *PreferenceHandler) GetPreference(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	key := c.Param("key")
+
+	p, err := h.preferenceService.Get(c.Request.Context(), userID, key)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "get_preference", "user_id": userID, "key": key})
+		return
+	}
+
+	response.OK(c, p)
+}
+
+// SetPreference stores a value under a key, replacing any previous one
+func (h *PreferenceHandler) SetPreference(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	key := c.Param("key")
+
+	var req SetPreferenceRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	p, err := h.preferenceService.Set(c.Request.Context(), userID, key, req.Value)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "set_preference", "user_id": userID, "key": key})
+		return
+	}
+
+	response.OK(c, p)
+}
+
+// DeletePreference removes one of the current user's preferences
+func (h *PreferenceHandler) DeletePreference(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	key := c.Param("key")
+
+	if err := h.preferenceService.Delete(c.Request.Context(), userID, key); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "delete_preference", "user_id": userID, "key": key})
+		return
+	}
+
+	response.Message(c, "Preference deleted")
+}
+
+func (h *PreferenceHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/preference"
+	prefMocks "github.com/cctw-zed/wonder/internal/domain/preference/mocks"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func servePreferences(handler *PreferenceHandler, method, path, body string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.Use(withUserID("user-1"))
+	router.GET("/users/me/preferences", handler.ListPreferences)
+	router.GET("/users/me/preferences/:key", handler.GetPreference)
+	router.PUT("/users/me/preferences/:key", handler.SetPreference)
+	router.DELETE("/users/me/preferences/:key", handler.DeletePreference)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func newTestPreferenceHandler(t *testing.T) (*PreferenceHandler, *prefMocks.MockRepository) {
+	logger.Initialize()
+	repo := prefMocks.NewMockRepository(gomock.NewController(t))
+	return NewPreferenceHandler(service.NewPreferenceService(repo, service.PreferencePolicy{MaxValueBytes: 64, MaxKeys: 10})), repo
+}
+
+func TestPreferenceHandler_Set(t *testing.T) {
+	handler, repo := newTestPreferenceHandler(t)
+	repo.EXPECT().CountForUpdate(gomock.Any(), "user-1").Return(int64(0), nil)
+	repo.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *preference.Preference) error {
+		assert.Equal(t, "user-1", p.UserID)
+		return nil
+	})
+
+	w := servePreferences(handler, http.MethodPut, "/users/me/preferences/editor.theme", `{"value": {"mode": "dark"}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data struct {
+			Key   string          `json:"key"`
+			Value json.RawMessage `json:"value"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "editor.theme", body.Data.Key)
+	assert.JSONEq(t, `{"mode":"dark"}`, string(body.Data.Value))
+	assert.NotContains(t, w.Body.String(), "user-1")
+
+	t.Run("rejects bad keys and values", func(t *testing.T) {
+		w := servePreferences(handler, http.MethodPut, "/users/me/preferences/theme", `{"value":"dark"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = servePreferences(handler, http.MethodPut, "/users/me/preferences/editor.theme", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = servePreferences(handler, http.MethodPut, "/users/me/preferences/editor.theme", `{"value":"`+strings.Repeat("a", 64)+`"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestPreferenceHandler_ListGetDelete(t *testing.T) {
+	handler, repo := newTestPreferenceHandler(t)
+
+	repo.EXPECT().List(gomock.Any(), "user-1", "editor").Return([]*preference.Preference{
+		{UserID: "user-1", Key: "editor.theme", Value: json.RawMessage(`"dark"`)},
+	}, nil)
+	w := servePreferences(handler, http.MethodGet, "/users/me/preferences?namespace=editor", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"key":"editor.theme","value":"dark"`)
+
+	repo.EXPECT().Get(gomock.Any(), "user-1", "editor.font").Return(nil, nil)
+	w = servePreferences(handler, http.MethodGet, "/users/me/preferences/editor.font", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	repo.EXPECT().Delete(gomock.Any(), "user-1", "editor.theme").Return(true, nil)
+	w = servePreferences(handler, http.MethodDelete, "/users/me/preferences/editor.theme", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = servePreferences(handler, http.MethodGet, "/users/me/preferences?namespace=Editor!", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
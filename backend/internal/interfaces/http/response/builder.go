@@ -0,0 +1,61 @@
+package response
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/pkg/jsoncodec"
+)
+
+// contentTypeJSON is the content type gin sets for JSON responses
+const contentTypeJSON = "application/json; charset=utf-8"
+
+// maxPooledBuffer bounds the buffers kept for reuse, so that one large
+// response does not stay allocated for the life of the process
+const maxPooledBuffer = 64 << 10
+
+// builder encodes a response body into a buffer reused across requests,
+// with the engine jsoncodec selects. gin's c.JSON marshals every body into
+// a new slice; envelopes are written on every request, so they are encoded
+// here instead.
+type builder struct {
+	buf bytes.Buffer
+	enc jsoncodec.Encoder
+}
+
+var builders = sync.Pool{
+	New: func() interface{} {
+		b := &builder{}
+		b.enc = jsoncodec.NewEncoder(&b.buf)
+		return b
+	},
+}
+
+// write encodes body as JSON and writes it with status. Like c.JSON, it
+// panics when body cannot be encoded, which only a programming error
+// causes; the recovery middleware answers 500.
+func write(c *gin.Context, status int, body interface{}) {
+	b := builders.Get().(*builder)
+	if err := b.enc.Encode(body); err != nil {
+		// The builder is dropped rather than released. json-iterator's
+		// encoder keeps the error and stays failed, and the buffer may hold
+		// part of this document. Pooled again, it would break or corrupt
+		// every later response encoded with it. The pool makes a new one.
+		panic(err)
+	}
+	// Encode ends the document with a newline that c.JSON does not write
+	c.Data(status, contentTypeJSON, bytes.TrimSuffix(b.buf.Bytes(), []byte{'\n'}))
+	release(b)
+}
+
+// release returns b to the pool, unless its buffer grew past
+// maxPooledBuffer
+func release(b *builder) {
+	if b.buf.Cap() > maxPooledBuffer {
+		return
+	}
+	b.buf.Reset()
+	builders.Put(b)
+}
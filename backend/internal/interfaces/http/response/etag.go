@@ -0,0 +1,46 @@
+package response
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag quotes an opaque version into a strong entity tag
+func ETag(version string) string {
+	return `"` + version + `"`
+}
+
+// EntityTags parses an If-Match or If-None-Match header into the opaque
+// versions it lists. Weak tags compare like strong ones. It returns nil for
+// a missing header or "*", which matches any version.
+func EntityTags(header string) []string {
+	var versions []string
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil
+		}
+		tag = strings.TrimPrefix(tag, "W/")
+		if len(tag) >= 2 && tag[0] == '"' && tag[len(tag)-1] == '"' {
+			versions = append(versions, tag[1:len(tag)-1])
+		}
+	}
+	return versions
+}
+
+// NotModified sets the ETag header for version and, when the request's
+// If-None-Match lists it, answers 304 Not Modified. Callers write the body
+// only when it returns false.
+func NotModified(c *gin.Context, version string) bool {
+	c.Header("ETag", ETag(version))
+
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" || (header != "*" && !slices.Contains(EntityTags(header), version)) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
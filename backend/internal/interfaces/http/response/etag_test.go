@@ -0,0 +1,39 @@
+package response
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityTags(t *testing.T) {
+	assert.Equal(t, []string{"abc", "def"}, EntityTags(`"abc", W/"def"`))
+	assert.Nil(t, EntityTags("*"))
+	assert.Nil(t, EntityTags(""))
+	assert.Nil(t, EntityTags("abc"), "unquoted tags are ignored")
+}
+
+func TestNotModified(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":               false,
+		`"v1"`:           true,
+		`W/"v1"`:         true,
+		`"v0", "v1"`:     true,
+		`"v2"`:           false,
+		"*":              true,
+		"not-a-quoted-v": false,
+	} {
+		c, w := newTestContext("")
+		if header != "" {
+			c.Request.Header.Set("If-None-Match", header)
+		}
+
+		assert.Equal(t, want, NotModified(c, "v1"), header)
+		assert.Equal(t, `"v1"`, w.Header().Get("ETag"), header)
+		if want {
+			c.Writer.WriteHeaderNow()
+			assert.Equal(t, http.StatusNotModified, w.Code, header)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package response
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+)
+
+// localize translates the message of an error envelope and of each invalid
+// field into lang. Codes, details and constraints are left as they are so
+// clients can keep matching on them; messages without a translation stay
+// in English. The catalog message for a code is more generic than the one
+// the handler wrote, so the latter is kept in details.detail when it is
+// replaced. English errors are returned unchanged.
+func localize(err *errors.HTTPError, lang string) {
+	if lang == "" || lang == i18n.DefaultLanguage {
+		return
+	}
+	bundle := i18n.Get()
+
+	// The details may be shared with the original error
+	details := make(map[string]interface{}, len(err.ErrorDetails)+1)
+	for k, v := range err.ErrorDetails {
+		details[k] = v
+	}
+	changed := false
+
+	if msg, ok := bundle.Lookup(lang, "error."+string(err.ErrorCode)); ok && msg != err.Message {
+		if _, set := details["detail"]; !set && err.Message != "" {
+			details["detail"] = err.Message
+			changed = true
+		}
+		err.Message = msg
+	}
+
+	if fields, ok := err.ErrorDetails["fields"].([]errors.FieldError); ok && len(fields) > 0 {
+		localized := make([]errors.FieldError, len(fields))
+		for i, field := range fields {
+			localized[i] = field
+			if key, args := fieldMessageKey(bundle, field); key != "" {
+				if msg, ok := bundle.Lookup(lang, key); ok {
+					localized[i].Message = i18n.Format(msg, args)
+				}
+			}
+		}
+		details["fields"] = localized
+		changed = true
+	}
+
+	if changed {
+		err.ErrorDetails = details
+	}
+}
+
+// fieldMessageKey picks the catalog message describing an invalid field
+// from its code and constraint
+func fieldMessageKey(bundle *i18n.Bundle, field errors.FieldError) (string, map[string]interface{}) {
+	args := map[string]interface{}{"field": field.Field}
+	for k, v := range field.Constraint {
+		if values, ok := v.([]string); ok {
+			v = strings.Join(values, ", ")
+		}
+		args[k] = v
+	}
+
+	switch {
+	case field.Code == errors.CodeRequiredField:
+		return "field.required", args
+	case field.Constraint["format"] != nil:
+		format := fmt.Sprint(field.Constraint["format"])
+		if key := "field.format." + format; bundle.Has(key) {
+			return key, args
+		}
+		return "field.format", args
+	case field.Constraint["type"] != nil:
+		return "field.type", args
+	case field.Constraint["oneof"] != nil:
+		return "field.oneof", args
+	case field.Constraint["min"] != nil:
+		return "field.min", args
+	case field.Constraint["max"] != nil:
+		return "field.max", args
+	case field.Constraint["len"] != nil:
+		return "field.len", args
+	case field.Code == errors.CodeInvalidValue || field.Code == errors.CodeInvalidFormat:
+		return "field.invalid", args
+	}
+	return "", nil
+}
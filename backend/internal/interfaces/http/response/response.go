@@ -0,0 +1,194 @@
+// Package response writes the JSON envelope shared by all API endpoints:
+//
+//	{"data": ..., "meta": {...}, "trace_id": "..."}
+//	{"error": {"status_code": 404, "code": "...", "message": "...", "details": {...}}, "trace_id": "...", "request_id": "..."}
+package response
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+	"github.com/cctw-zed/wonder/pkg/reporting"
+)
+
+// Request context keys set by the trace, client IP and auth middleware. They are read
+// directly because the middleware package itself writes envelopes.
+const (
+	traceIDKey   = "trace_id"
+	requestIDKey = "request_id"
+	userIDKey    = "user_id"
+	clientIPKey  = "client_ip"
+)
+
+// Envelope is the body of every API response. Exactly one of Data and Error
+// is set.
+type Envelope struct {
+	Data    interface{}       `json:"data,omitempty"`
+	Meta    *Meta             `json:"meta,omitempty"`
+	Error   *errors.HTTPError `json:"error,omitempty"`
+	TraceID string            `json:"trace_id"`
+	// RequestID identifies this hop of the request; set on errors so a
+	// failure can be found in the logs of the instance that served it
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Meta describes a page of a collection. Offset pages fill Page and
+// TotalPages; NextCursor is set whenever more results follow and can be
+// passed back as ?cursor= to fetch them. TotalKind is set when Total is
+// not an exact count: "estimate", or "none" when nothing was counted.
+type Meta struct {
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size"`
+	Total      int64  `json:"total"`
+	TotalKind  string `json:"total_kind,omitempty"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// MessageData is the payload of responses that only confirm an action
+type MessageData struct {
+	Message string `json:"message"`
+}
+
+// OK writes a 200 response with data
+func OK(c *gin.Context, data interface{}) {
+	JSON(c, http.StatusOK, data, nil)
+}
+
+// Created writes a 201 response with data
+func Created(c *gin.Context, data interface{}) {
+	JSON(c, http.StatusCreated, data, nil)
+}
+
+// Message writes a 200 response confirming an action
+func Message(c *gin.Context, message string) {
+	JSON(c, http.StatusOK, MessageData{Message: message}, nil)
+}
+
+// Page writes a 200 response with one page of a collection
+func Page(c *gin.Context, items interface{}, meta *Meta) {
+	JSON(c, http.StatusOK, items, meta)
+}
+
+// JSON writes a success envelope with an explicit status
+func JSON(c *gin.Context, status int, data interface{}, meta *Meta) {
+	write(c, status, Envelope{
+		Data:    data,
+		Meta:    meta,
+		TraceID: traceID(c),
+	})
+}
+
+// Error writes an error envelope using the error's status code. 5xx errors
+// are passed to the error reporter.
+func Error(c *gin.Context, err *errors.HTTPError) {
+	status, body := errorEnvelope(c, err)
+	write(c, status, body)
+	report(c, err, body.TraceID)
+}
+
+// Abort writes an error envelope and stops the handler chain
+func Abort(c *gin.Context, err *errors.HTTPError) {
+	status, body := errorEnvelope(c, err)
+	c.Abort()
+	write(c, status, body)
+	report(c, err, body.TraceID)
+}
+
+// report sends server errors to the error reporter with the original error
+// when the envelope was produced by the error mapper
+func report(c *gin.Context, err *errors.HTTPError, traceID string) {
+	if err.StatusCode < http.StatusInternalServerError {
+		return
+	}
+	var cause error = err
+	if err.Cause() != nil {
+		cause = err.Cause()
+	}
+
+	req := RequestInfo(c)
+	req.TraceID = traceID
+	req.Status = err.StatusCode
+	reporting.Get().CaptureError(cause, req)
+}
+
+// RequestInfo describes the request for error reporting
+func RequestInfo(c *gin.Context) reporting.Request {
+	if c.Request == nil {
+		return reporting.Request{}
+	}
+	userID, _ := c.Request.Context().Value(userIDKey).(string)
+	return reporting.Request{
+		TraceID:   traceID(c),
+		RequestID: RequestID(c),
+		UserID:    userID,
+		Method:    c.Request.Method,
+		Route:     c.FullPath(),
+		Path:      c.Request.URL.Path,
+		ClientIP:  clientIP(c),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+func errorEnvelope(c *gin.Context, err *errors.HTTPError) (int, Envelope) {
+	// The trace ID moves to the envelope so it appears once per body
+	body := *err
+	tid := body.TraceID
+	if tid == "" {
+		tid = traceID(c)
+	}
+	body.TraceID = ""
+	if c.Request != nil {
+		localize(&body, i18n.LanguageFromContext(c.Request.Context()))
+	}
+
+	return err.StatusCode, Envelope{
+		Error:     &body,
+		TraceID:   tid,
+		RequestID: RequestID(c),
+	}
+}
+
+// clientIP returns the client IP resolved by the client IP middleware,
+// resolving it when the middleware did not run
+func clientIP(c *gin.Context) string {
+	if v, ok := c.Request.Context().Value(clientIPKey).(string); ok && v != "" {
+		return v
+	}
+	return c.ClientIP()
+}
+
+func traceID(c *gin.Context) string {
+	if c.Request == nil {
+		return ""
+	}
+	if v, ok := c.Request.Context().Value(traceIDKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// RequestID returns the request ID set by the trace middleware
+func RequestID(c *gin.Context) string {
+	if c.Request == nil {
+		return ""
+	}
+	v, _ := c.Request.Context().Value(requestIDKey).(string)
+	return v
+}
+
+// NoRoute writes the error envelope for paths without an endpoint
+func NoRoute(c *gin.Context) {
+	Error(c, errors.NewHTTPError(http.StatusNotFound, errors.CodeRouteNotFound,
+		"Route not found", map[string]interface{}{"path": c.Request.URL.Path}, ""))
+}
+
+// NoMethod writes the error envelope for methods an endpoint does not support
+func NoMethod(c *gin.Context) {
+	Error(c, errors.NewHTTPError(http.StatusMethodNotAllowed, errors.CodeMethodNotAllowed,
+		"Method not allowed", map[string]interface{}{"method": c.Request.Method}, ""))
+}
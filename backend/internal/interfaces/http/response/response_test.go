@@ -0,0 +1,319 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+	"github.com/cctw-zed/wonder/pkg/reporting"
+)
+
+func newTestContext(traceID string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if traceID != "" {
+		req = req.WithContext(context.WithValue(req.Context(), traceIDKey, traceID))
+	}
+	c.Request = req
+	return c, w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body
+}
+
+func TestSuccessEnvelope(t *testing.T) {
+	t.Run("should wrap data with the request trace ID", func(t *testing.T) {
+		c, w := newTestContext("trace-1")
+
+		Created(c, map[string]string{"id": "42"})
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, map[string]interface{}{"id": "42"}, body["data"])
+		assert.Equal(t, "trace-1", body["trace_id"])
+		assert.NotContains(t, body, "error")
+		assert.NotContains(t, body, "meta")
+		assert.NotContains(t, body, "request_id")
+	})
+
+	t.Run("should include page metadata", func(t *testing.T) {
+		c, w := newTestContext("")
+
+		Page(c, []string{"a", "b"}, &Meta{PageSize: 2, Total: 5, NextCursor: "abc", HasMore: true})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := decode(t, w)
+		assert.Len(t, body["data"], 2)
+		meta := body["meta"].(map[string]interface{})
+		assert.Equal(t, "abc", meta["next_cursor"])
+		assert.Equal(t, true, meta["has_more"])
+		assert.NotContains(t, meta, "page")
+		assert.Contains(t, body, "trace_id")
+	})
+
+	t.Run("should wrap confirmation messages", func(t *testing.T) {
+		c, w := newTestContext("")
+
+		Message(c, "done")
+
+		body := decode(t, w)
+		assert.Equal(t, "done", body["data"].(map[string]interface{})["message"])
+	})
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	t.Run("should move the error trace ID to the envelope", func(t *testing.T) {
+		c, w := newTestContext("ctx-trace")
+		httpErr := &errors.HTTPError{
+			StatusCode: http.StatusNotFound,
+			ErrorCode:  errors.CodeEntityNotFound,
+			Message:    "user not found",
+			TraceID:    "err-trace",
+		}
+
+		Error(c, httpErr)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, "err-trace", body["trace_id"])
+		errBody := body["error"].(map[string]interface{})
+		assert.Equal(t, string(errors.CodeEntityNotFound), errBody["code"])
+		assert.Equal(t, "user not found", errBody["message"])
+		assert.NotContains(t, errBody, "trace_id")
+		assert.NotContains(t, body, "data")
+		assert.Equal(t, "err-trace", httpErr.TraceID, "caller's error must not be modified")
+	})
+
+	t.Run("should fall back to the context trace ID and abort", func(t *testing.T) {
+		c, w := newTestContext("ctx-trace")
+
+		Abort(c, &errors.HTTPError{StatusCode: http.StatusForbidden, ErrorCode: errors.CodeInsufficientRole})
+
+		assert.True(t, c.IsAborted())
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "ctx-trace", decode(t, w)["trace_id"])
+	})
+
+	t.Run("should include the request ID", func(t *testing.T) {
+		c, w := newTestContext("ctx-trace")
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey, "req-1"))
+
+		Error(c, &errors.HTTPError{StatusCode: http.StatusConflict, ErrorCode: errors.CodeEntityNotFound})
+
+		body := decode(t, w)
+		assert.Equal(t, "ctx-trace", body["trace_id"])
+		assert.Equal(t, "req-1", body["request_id"])
+	})
+
+	t.Run("should write envelopes for unknown routes and methods", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.HandleMethodNotAllowed = true
+		router.NoRoute(NoRoute)
+		router.NoMethod(NoMethod)
+		router.GET("/users", func(*gin.Context) {})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, string(errors.CodeRouteNotFound), decode(t, w)["error"].(map[string]interface{})["code"])
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, string(errors.CodeMethodNotAllowed), decode(t, w)["error"].(map[string]interface{})["code"])
+	})
+	t.Run("should report server errors with the original error", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		reporting.Set(reporter)
+		defer reporting.Set(nil)
+
+		c, _ := newTestContext("ctx-trace")
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), userIDKey, "user-7"))
+		cause := errors.NewDatabaseError("select", "users", stderrors.New("connection reset"), true)
+
+		Error(c, errors.NewErrorMapper().MapToHTTPError(cause, "err-trace"))
+		Error(c, errors.NewErrorMapper().MapToHTTPError(errors.NewEntityNotFoundError("user", "1"), ""))
+
+		require.Len(t, reporter.errs, 1, "4xx errors are not reported")
+		assert.Same(t, cause, reporter.errs[0])
+		assert.Equal(t, "err-trace", reporter.reqs[0].TraceID)
+		assert.Equal(t, "user-7", reporter.reqs[0].UserID)
+		assert.Equal(t, http.StatusServiceUnavailable, reporter.reqs[0].Status)
+		assert.Equal(t, http.MethodGet, reporter.reqs[0].Method)
+	})
+}
+
+func TestLocalizedErrorEnvelope(t *testing.T) {
+	fields := []errors.FieldError{
+		{Field: "email", Code: errors.CodeInvalidFormat, Message: "email must be a valid email address", Constraint: map[string]interface{}{"format": "email"}},
+		{Field: "name", Code: errors.CodeOutOfRange, Message: "name must be at least 2 characters", Constraint: map[string]interface{}{"min": 2}},
+		{Field: "role", Code: errors.CodeInvalidValue, Message: "role must be one of: user, admin", Constraint: map[string]interface{}{"oneof": []string{"user", "admin"}}},
+		{Field: "csv", Code: errors.CodeBusinessRuleError, Message: "row 3 is a duplicate"},
+	}
+	httpErr := &errors.HTTPError{
+		StatusCode:   http.StatusBadRequest,
+		ErrorCode:    errors.CodeValidationError,
+		Message:      "request validation failed",
+		ErrorDetails: map[string]interface{}{"fields": fields, "resource": "user"},
+	}
+
+	t.Run("should translate messages and keep codes", func(t *testing.T) {
+		c, w := newTestContext("")
+		c.Request = c.Request.WithContext(i18n.WithLanguage(c.Request.Context(), "zh"))
+
+		Error(c, httpErr)
+
+		errBody := decode(t, w)["error"].(map[string]interface{})
+		assert.Equal(t, string(errors.CodeValidationError), errBody["code"])
+		assert.Equal(t, "验证失败", errBody["message"])
+		details := errBody["details"].(map[string]interface{})
+		assert.Equal(t, "user", details["resource"])
+		assert.Equal(t, "request validation failed", details["detail"], "the specific message is kept")
+		got := details["fields"].([]interface{})
+		require.Len(t, got, 4)
+		assert.Equal(t, "email 必须是有效的电子邮件地址", got[0].(map[string]interface{})["message"])
+		assert.Equal(t, "name 不能小于 2", got[1].(map[string]interface{})["message"])
+		assert.Equal(t, "role 必须是以下值之一：user, admin", got[2].(map[string]interface{})["message"])
+		assert.Equal(t, string(errors.CodeInvalidValue), got[2].(map[string]interface{})["code"])
+		assert.Equal(t, "row 3 is a duplicate", got[3].(map[string]interface{})["message"], "untranslated messages stay in English")
+
+		assert.Equal(t, "request validation failed", httpErr.Message, "caller's error must not be modified")
+		assert.Equal(t, "email must be a valid email address", fields[0].Message)
+		assert.NotContains(t, httpErr.ErrorDetails, "detail")
+	})
+
+	t.Run("should keep the specific message of errors without details", func(t *testing.T) {
+		c, w := newTestContext("")
+		c.Request = c.Request.WithContext(i18n.WithLanguage(c.Request.Context(), "zh"))
+
+		Error(c, errors.NewHTTPError(http.StatusConflict, errors.CodeBusinessRuleError,
+			"a user can have at most 100 preferences", nil, ""))
+
+		errBody := decode(t, w)["error"].(map[string]interface{})
+		assert.NotEqual(t, "a user can have at most 100 preferences", errBody["message"])
+		assert.Equal(t, "a user can have at most 100 preferences", errBody["details"].(map[string]interface{})["detail"])
+	})
+
+	t.Run("should keep English messages as they are", func(t *testing.T) {
+		c, w := newTestContext("")
+		c.Request = c.Request.WithContext(i18n.WithLanguage(c.Request.Context(), "en"))
+
+		Error(c, httpErr)
+
+		errBody := decode(t, w)["error"].(map[string]interface{})
+		assert.Equal(t, "request validation failed", errBody["message"])
+		assert.NotContains(t, errBody["details"], "detail")
+		got := errBody["details"].(map[string]interface{})["fields"].([]interface{})
+		assert.Equal(t, "name must be at least 2 characters", got[1].(map[string]interface{})["message"])
+	})
+}
+
+type recordingReporter struct {
+	errs []error
+	reqs []reporting.Request
+}
+
+func (r *recordingReporter) CaptureError(err error, req reporting.Request) {
+	r.errs = append(r.errs, err)
+	r.reqs = append(r.reqs, req)
+}
+
+func (r *recordingReporter) CapturePanic(interface{}, reporting.Request) {}
+
+func TestWrite(t *testing.T) {
+	t.Run("should write what c.JSON writes", func(t *testing.T) {
+		body := Envelope{Data: map[string]string{"name": "<Ada & Grace>"}, TraceID: "trace-1"}
+		c, w := newTestContext("")
+		gc, gw := newTestContext("")
+
+		write(c, http.StatusOK, body)
+		gc.JSON(http.StatusOK, body)
+
+		assert.Equal(t, gw.Body.String(), w.Body.String())
+		assert.Equal(t, gw.Header().Get("Content-Type"), w.Header().Get("Content-Type"))
+	})
+
+	t.Run("should panic on a body that cannot be encoded", func(t *testing.T) {
+		c, _ := newTestContext("")
+		assert.Panics(t, func() { write(c, http.StatusOK, make(chan int)) })
+	})
+
+	t.Run("should not pool a buffer grown past the limit", func(t *testing.T) {
+		c, w := newTestContext("")
+
+		write(c, http.StatusOK, strings.Repeat("x", 2*maxPooledBuffer))
+
+		assert.Greater(t, w.Body.Len(), 2*maxPooledBuffer)
+		for i := 0; i < 4; i++ {
+			b := builders.Get().(*builder)
+			assert.LessOrEqual(t, b.buf.Cap(), maxPooledBuffer)
+			defer release(b)
+		}
+	})
+}
+
+// registerResponse has the shape of the body of a registration response
+type registerResponse struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BenchmarkCreated_Register compares writing a registration response with
+// the pooled builder to gin's c.JSON, which it replaced
+func BenchmarkCreated_Register(b *testing.B) {
+	now := time.Now()
+	body := Envelope{
+		Data: registerResponse{ID: "1754385312345678901", Email: "bench@example.com", Name: "Bench User",
+			Role: "user", Status: "active", CreatedAt: now, UpdatedAt: now},
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+	}
+	c, w := newTestContext("")
+
+	b.Run("c.JSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w.Body.Reset()
+			c.JSON(http.StatusCreated, body)
+		}
+	})
+	b.Run("builder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w.Body.Reset()
+			write(c, http.StatusCreated, body)
+		}
+	})
+}
+
+func TestAbort(t *testing.T) {
+	c, w := newTestContext("trace-1")
+
+	Abort(c, errors.NewHTTPError(http.StatusForbidden, errors.CodeForbidden, "Forbidden", nil, ""))
+
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "trace-1", decode(t, w)["trace_id"])
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+)
+
+// immutableAssetDirs hold fingerprinted build output whose names change
+// with their content (Next.js and Vite layouts)
+var immutableAssetDirs = []string{"_next/static/", "assets/"}
+
+// SPAOptions configures an SPAHandler
+type SPAOptions struct {
+	// CacheMaxAge is how long browsers may cache assets outside the
+	// fingerprinted directories; zero disables caching
+	CacheMaxAge time.Duration
+	// ContentSecurityPolicy is sent with every response when set
+	ContentSecurityPolicy string
+}
+
+// SPAHandler serves a single-page application from a file system. Paths
+// that are not files answer with index.html, so the application can route
+// with the history API; paths with a file extension that do not exist
+// answer 404 instead.
+type SPAHandler struct {
+	files fs.FS
+	index []byte
+	opts  SPAOptions
+	// modTime is the start time, as embedded files carry none
+	modTime time.Time
+}
+
+// NewSPAHandler creates a handler for the application in files. It fails
+// when files has no index.html.
+func NewSPAHandler(files fs.FS, opts SPAOptions) (*SPAHandler, error) {
+	index, err := fs.ReadFile(files, "index.html")
+	if err != nil {
+		return nil, fmt.Errorf("application has no index.html: %w", err)
+	}
+	return &SPAHandler{files: files, index: index, opts: opts, modTime: time.Now()}, nil
+}
+
+// Serve serves the file named by the "filepath" route parameter
+func (h *SPAHandler) Serve(c *gin.Context) {
+	h.securityHeaders(c)
+
+	name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+	if name == "" || name == "index.html" {
+		h.serveIndex(c)
+		return
+	}
+
+	if info, err := fs.Stat(h.files, name); err == nil {
+		if info.IsDir() {
+			name = path.Join(name, "index.html")
+			if _, err := fs.Stat(h.files, name); err != nil {
+				h.serveIndex(c)
+				return
+			}
+		}
+		h.serveFile(c, name)
+		return
+	}
+
+	if path.Ext(name) != "" {
+		response.NoRoute(c)
+		return
+	}
+	h.serveIndex(c)
+}
+
+func (h *SPAHandler) serveIndex(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache")
+	http.ServeContent(c.Writer, c.Request, "index.html", h.modTime, bytes.NewReader(h.index))
+}
+
+func (h *SPAHandler) serveFile(c *gin.Context, name string) {
+	switch {
+	case strings.HasSuffix(name, ".html"):
+		c.Header("Cache-Control", "no-cache")
+	case fingerprinted(name):
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	case h.opts.CacheMaxAge > 0:
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.opts.CacheMaxAge.Seconds())))
+	default:
+		c.Header("Cache-Control", "no-cache")
+	}
+	http.ServeFileFS(c.Writer, c.Request, h.files, name)
+}
+
+func (h *SPAHandler) securityHeaders(c *gin.Context) {
+	if h.opts.ContentSecurityPolicy != "" {
+		c.Header("Content-Security-Policy", h.opts.ContentSecurityPolicy)
+	}
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Referrer-Policy", "same-origin")
+}
+
+func fingerprinted(name string) bool {
+	for _, dir := range immutableAssetDirs {
+		if strings.HasPrefix(name, dir) || strings.Contains(name, "/"+dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSPAHandler_Serve(t *testing.T) {
+	files := fstest.MapFS{
+		"index.html":                 {Data: []byte("<html>app</html>")},
+		"favicon.ico":                {Data: []byte("icon")},
+		"_next/static/chunks/a1.js":  {Data: []byte("console.log(1)")},
+		"settings/index.html":        {Data: []byte("<html>settings</html>")},
+		"assets/logo-3f2a.svg":       {Data: []byte("<svg/>")},
+		"users/placeholder/.gitkeep": {Data: nil},
+	}
+	handler, err := NewSPAHandler(files, SPAOptions{CacheMaxAge: time.Hour, ContentSecurityPolicy: "default-src 'self'"})
+	require.NoError(t, err)
+
+	router := setupGinTest()
+	router.GET("/admin/*filepath", handler.Serve)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("index is never cached", func(t *testing.T) {
+		w := get("/admin/")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<html>app</html>", w.Body.String())
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "default-src 'self'", w.Header().Get("Content-Security-Policy"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	})
+
+	t.Run("client-side routes fall back to the index", func(t *testing.T) {
+		w := get("/admin/users/42")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<html>app</html>", w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	})
+
+	t.Run("directories serve their own index", func(t *testing.T) {
+		w := get("/admin/settings")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<html>settings</html>", w.Body.String())
+	})
+
+	t.Run("cache headers by asset kind", func(t *testing.T) {
+		w := get("/admin/_next/static/chunks/a1.js")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+
+		w = get("/admin/assets/logo-3f2a.svg")
+		assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+
+		w = get("/admin/favicon.ico")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("missing assets are not found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/admin/_next/static/chunks/missing.js").Code)
+	})
+
+	t.Run("paths cannot leave the root", func(t *testing.T) {
+		w := get("/admin/../../etc/passwd")
+		assert.NotContains(t, w.Body.String(), "root:")
+	})
+}
+
+func TestNewSPAHandler_RequiresIndex(t *testing.T) {
+	_, err := NewSPAHandler(fstest.MapFS{"app.js": {Data: []byte("x")}}, SPAOptions{})
+	assert.ErrorContains(t, err, "no index.html")
+}
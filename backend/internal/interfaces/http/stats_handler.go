@@ -0,0 +1,57 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/stats"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type StatsHandler struct {
+	reportingService service.ReportingService
+	errorMapper      *errors.ErrorMapper
+	errorLogger      errors.ErrorLogger
+}
+
+func NewStatsHandler(reportingService service.ReportingService) *StatsHandler {
+	return &StatsHandler{
+		reportingService: reportingService,
+		errorMapper:      errors.NewErrorMapper(),
+		errorLogger:      errors.NewDefaultErrorLogger("reporting-service"),
+	}
+}
+
+// statsQuery selects the reporting window
+type statsQuery struct {
+	Days int `form:"days" binding:"min=1,max=365"`
+}
+
+// GetStats returns user counts, signups, active sessions and deletions
+// over the last days days (default 30)
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	query := &statsQuery{Days: stats.DefaultDays}
+	if err := validation.BindQuery(c, query); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	result, err := h.reportingService.GetStats(c.Request.Context(), query.Days)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "get_stats",
+			"days":      query.Days,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.OK(c, result)
+}
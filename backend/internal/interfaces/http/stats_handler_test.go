@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/stats"
+)
+
+// stubReportingService captures the requested window for handler tests
+type stubReportingService struct {
+	days  int
+	stats *stats.Stats
+	err   error
+}
+
+func (s *stubReportingService) GetStats(ctx context.Context, days int) (*stats.Stats, error) {
+	s.days = days
+	return s.stats, s.err
+}
+
+func (s *stubReportingService) RebuildStats(ctx context.Context, days int) error {
+	s.days = days
+	return s.err
+}
+
+func getStats(handler *StatsHandler, query string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.GET("/admin/stats", handler.GetStats)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/stats"+query, nil))
+	return w
+}
+
+func TestStatsHandler_GetStats(t *testing.T) {
+	svc := &stubReportingService{stats: &stats.Stats{
+		Days:          7,
+		TotalUsers:    12,
+		UsersByRole:   map[string]int64{"user": 11, "admin": 1},
+		SignupsPerDay: []stats.DailyCount{{Date: "2026-03-11", Count: 2}},
+	}}
+
+	w := getStats(NewStatsHandler(svc), "?days=7")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 7, svc.days)
+
+	var body struct {
+		Data stats.Stats `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(12), body.Data.TotalUsers)
+	assert.Equal(t, int64(1), body.Data.UsersByRole["admin"])
+	assert.Len(t, body.Data.SignupsPerDay, 1)
+}
+
+func TestStatsHandler_GetStats_DefaultWindow(t *testing.T) {
+	svc := &stubReportingService{stats: &stats.Stats{}}
+
+	w := getStats(NewStatsHandler(svc), "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, stats.DefaultDays, svc.days)
+}
+
+func TestStatsHandler_GetStats_InvalidWindow(t *testing.T) {
+	svc := &stubReportingService{}
+
+	w := getStats(NewStatsHandler(svc), "?days=400")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Zero(t, svc.days)
+}
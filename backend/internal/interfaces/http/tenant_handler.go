@@ -0,0 +1,101 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type CreateTenantRequest struct {
+	Slug string `json:"slug" binding:"required,max=63"`
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type TenantHandler struct {
+	tenantService tenant.Service
+	errorMapper   *errors.ErrorMapper
+	errorLogger   errors.ErrorLogger
+}
+
+func NewTenantHandler(tenantService tenant.Service) *TenantHandler {
+	return &TenantHandler{
+		tenantService: tenantService,
+		errorMapper:   errors.NewErrorMapper(),
+		errorLogger:   errors.NewDefaultErrorLogger("tenant-service"),
+	}
+}
+
+// CreateTenant creates a tenant reachable by its slug
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req CreateTenantRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	t, err := h.tenantService.CreateTenant(c.Request.Context(), req.Slug, req.Name)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation": "create_tenant",
+			"slug":      req.Slug,
+		})
+		return
+	}
+
+	response.Created(c, t)
+}
+
+// ListTenants lists every tenant
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	tenants, err := h.tenantService.ListTenants(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "list_tenants"})
+		return
+	}
+
+	response.OK(c, tenants)
+}
+
+// GetTenant retrieves a tenant by ID
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	t, err := h.tenantService.GetTenant(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation": "get_tenant",
+			"tenant_id": c.Param("id"),
+		})
+		return
+	}
+
+	response.OK(c, t)
+}
+
+// DeleteTenant deletes a tenant that has no users left
+func (h *TenantHandler) DeleteTenant(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	if err := h.tenantService.DeleteTenant(c.Request.Context(), c.Param("id")); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation": "delete_tenant",
+			"tenant_id": c.Param("id"),
+		})
+		return
+	}
+
+	response.Message(c, "Tenant deleted successfully")
+}
+
+func (h *TenantHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
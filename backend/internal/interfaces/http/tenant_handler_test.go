@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	tenantMocks "github.com/cctw-zed/wonder/internal/domain/tenant/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func serveTenants(handler *TenantHandler, method, path, body string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.POST("/admin/tenants", handler.CreateTenant)
+	router.GET("/admin/tenants", handler.ListTenants)
+	router.GET("/admin/tenants/:id", handler.GetTenant)
+	router.DELETE("/admin/tenants/:id", handler.DeleteTenant)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTenantHandler_CreateTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := tenantMocks.NewMockService(ctrl)
+	handler := NewTenantHandler(svc)
+
+	svc.EXPECT().CreateTenant(gomock.Any(), "acme", "Acme Corp").
+		Return(&tenant.Tenant{ID: "t-1", Slug: "acme", Name: "Acme Corp"}, nil)
+
+	w := serveTenants(handler, http.MethodPost, "/admin/tenants", `{"slug":"acme","name":"Acme Corp"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var body struct {
+		Data tenant.Tenant `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "t-1", body.Data.ID)
+	assert.Equal(t, "acme", body.Data.Slug)
+
+	// Missing fields never reach the service
+	w = serveTenants(handler, http.MethodPost, "/admin/tenants", `{"slug":"acme"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTenantHandler_DeleteTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := tenantMocks.NewMockService(ctrl)
+	handler := NewTenantHandler(svc)
+
+	svc.EXPECT().DeleteTenant(gomock.Any(), "t-1").Return(nil)
+	svc.EXPECT().DeleteTenant(gomock.Any(), "t-2").Return(errors.NewConflictError("tenant", "tenant still has users", "t-2"))
+
+	assert.Equal(t, http.StatusOK, serveTenants(handler, http.MethodDelete, "/admin/tenants/t-1", "").Code)
+	assert.Equal(t, http.StatusConflict, serveTenants(handler, http.MethodDelete, "/admin/tenants/t-2", "").Code)
+}
+
+func TestTenantHandler_GetTenant_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := tenantMocks.NewMockService(ctrl)
+	handler := NewTenantHandler(svc)
+
+	svc.EXPECT().GetTenant(gomock.Any(), "missing").Return(nil, errors.NewEntityNotFoundError("tenant", "missing"))
+
+	assert.Equal(t, http.StatusNotFound, serveTenants(handler, http.MethodGet, "/admin/tenants/missing", "").Code)
+}
@@ -1,14 +1,20 @@
 package http
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/cctw-zed/wonder/internal/application/service"
 	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
 	"github.com/cctw-zed/wonder/internal/middleware"
 	"github.com/cctw-zed/wonder/pkg/errors"
-	"strconv"
 )
 
 type UserHandler struct {
@@ -25,37 +31,20 @@ func NewUserHandler(userService user.UserService) *UserHandler {
 	}
 }
 
-type RegisterRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Name     string `json:"name" binding:"required,min=2,max=50"`
-	Password string `json:"password" binding:"required,min=6"`
-}
-
-type ChangePasswordRequest struct {
-	OldPassword string `json:"old_password" binding:"required,min=6"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
-}
-
 func (h *UserHandler) Register(c *gin.Context) {
 	// Get trace ID from context (injected by middleware)
 	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
 
 	var req RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// Handle validation errors from Gin binding
-		httpErr := errors.NewHTTPError(
-			http.StatusBadRequest,
-			errors.CodeValidationError,
-			"Invalid request data",
-			map[string]interface{}{"validation_error": err.Error()},
-			traceID,
-		)
-		c.JSON(httpErr.StatusCode, httpErr)
+	if err := validation.BindJSON(c, &req); err != nil {
+		// Every invalid field is reported with its code and constraint
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
 		return
 	}
 
 	// Call application service
-	user, err := h.userService.Register(c.Request.Context(), req.Email, req.Name, req.Password)
+	ctx := service.WithHoneypotValue(service.WithCaptchaToken(c.Request.Context(), req.CaptchaToken), req.Website)
+	user, err := h.userService.Register(ctx, req.Email, req.Name, req.Password)
 	if err != nil {
 		// Log the error with structured logging
 		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
@@ -66,33 +55,31 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 		// Map service layer error to HTTP error
 		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
-		c.JSON(httpErr.StatusCode, httpErr)
+		setRetryAfter(c, httpErr)
+		response.Error(c, httpErr)
 		return
 	}
 
 	// Success response
-	c.JSON(http.StatusCreated, map[string]interface{}{
-		"user":     user,
-		"trace_id": traceID,
-	})
+	response.Created(c, userView(c, user))
 }
 
 // GetProfile retrieves user profile by ID
 func (h *UserHandler) GetProfile(c *gin.Context) {
-	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
-	userID := c.Param("id")
+	if userID, ok := h.pathUserID(c); ok {
+		h.getProfile(c, userID)
+	}
+}
 
-	if userID == "" {
-		httpErr := errors.NewHTTPError(
-			http.StatusBadRequest,
-			errors.CodeValidationError,
-			"User ID is required",
-			map[string]interface{}{"field": "id"},
-			traceID,
-		)
-		c.JSON(httpErr.StatusCode, httpErr)
-		return
+// GetMe retrieves the authenticated user's profile
+func (h *UserHandler) GetMe(c *gin.Context) {
+	if userID, ok := currentUserID(c); ok {
+		h.getProfile(c, userID)
 	}
+}
+
+func (h *UserHandler) getProfile(c *gin.Context, userID string) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
 
 	user, err := h.userService.GetProfile(c.Request.Context(), userID)
 	if err != nil {
@@ -102,47 +89,143 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		})
 
 		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
-		c.JSON(httpErr.StatusCode, httpErr)
+		response.Error(c, httpErr)
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"user":     user,
-		"trace_id": traceID,
-	})
+	if response.NotModified(c, user.Version()) {
+		return
+	}
+	response.OK(c, userView(c, user))
+}
+
+// GetByHandle retrieves the profile of the user holding the handle in the path
+func (h *UserHandler) GetByHandle(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	handle := c.Param("handle")
+	u, err := h.userService.GetByHandle(c.Request.Context(), handle)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "get_user_by_handle",
+			"handle":    handle,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	if response.NotModified(c, u.Version()) {
+		return
+	}
+	response.OK(c, userView(c, u))
+}
+
+// CheckHandleAvailability tells whether the handle query parameter can be
+// claimed, and why not when it cannot
+func (h *UserHandler) CheckHandleAvailability(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var query HandleAvailabilityQuery
+	if err := validation.BindQuery(c, &query); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	availability, err := h.userService.CheckHandleAvailability(c.Request.Context(), query.Handle)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "check_handle_availability",
+			"handle":    query.Handle,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.OK(c, availability)
 }
 
 // UpdateProfile updates user profile
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	if userID, ok := h.ownedUserID(c); ok {
+		h.updateProfile(c, userID)
+	}
+}
+
+// UpdateMe updates the authenticated user's profile
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	if userID, ok := currentUserID(c); ok {
+		h.updateProfile(c, userID)
+	}
+}
+
+func (h *UserHandler) updateProfile(c *gin.Context, userID string) {
 	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
-	userID := c.Param("id")
 
-	if userID == "" {
-		httpErr := errors.NewHTTPError(
-			http.StatusBadRequest,
-			errors.CodeValidationError,
-			"User ID is required",
-			map[string]interface{}{"field": "id"},
-			traceID,
-		)
-		c.JSON(httpErr.StatusCode, httpErr)
+	var body UpdateProfileRequest
+	if err := validation.BindJSON(c, &body); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
 		return
 	}
+	h.saveProfile(c, userID, body.toDomain())
+}
 
-	var req user.UpdateProfileRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		httpErr := errors.NewHTTPError(
-			http.StatusBadRequest,
-			errors.CodeValidationError,
-			"Invalid request data",
-			map[string]interface{}{"validation_error": err.Error()},
-			traceID,
-		)
-		c.JSON(httpErr.StatusCode, httpErr)
+// PatchProfile applies a JSON Merge Patch (RFC 7386) or, with
+// Content-Type application/json-patch+json, a JSON Patch (RFC 6902) to a
+// user's profile. Unlike PUT, a field set to null or "" is applied, not
+// ignored.
+func (h *UserHandler) PatchProfile(c *gin.Context) {
+	if userID, ok := h.ownedUserID(c); ok {
+		h.patchProfile(c, userID)
+	}
+}
+
+// PatchMe patches the authenticated user's profile like PatchProfile
+func (h *UserHandler) PatchMe(c *gin.Context) {
+	if userID, ok := currentUserID(c); ok {
+		h.patchProfile(c, userID)
+	}
+}
+
+func (h *UserHandler) patchProfile(c *gin.Context, userID string) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	body, err := validation.ReadBody(c)
+	if err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
 		return
 	}
+	patch, err := decodeUserPatch(c.ContentType(), body)
+	if err != nil {
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		if httpErr.StatusCode == http.StatusUnsupportedMediaType {
+			c.Header("Accept-Patch", strings.Join(acceptPatch, ", "))
+		}
+		response.Error(c, httpErr)
+		return
+	}
+	h.saveProfile(c, userID, patch.toDomain())
+}
 
-	updatedUser, err := h.userService.UpdateProfile(c.Request.Context(), userID, &req)
+// saveProfile applies req to the user, conditionally on If-Match, and
+// responds with the updated profile
+func (h *UserHandler) saveProfile(c *gin.Context, userID string, req *user.UpdateProfileRequest) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	// If-Match makes the update conditional on the version the client read
+	if header := c.GetHeader("If-Match"); header != "" {
+		req.IfVersion = response.EntityTags(header)
+		if req.IfVersion == nil && strings.TrimSpace(header) != "*" {
+			err := errors.NewInvalidFormatError("If-Match", header, "quoted entity tag")
+			response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+			return
+		}
+	}
+
+	updatedUser, err := h.userService.UpdateProfile(c.Request.Context(), userID, req)
 	if err != nil {
 		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
 			"operation": "update_user_profile",
@@ -151,43 +234,35 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		})
 
 		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
-		c.JSON(httpErr.StatusCode, httpErr)
+		response.Error(c, httpErr)
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"user":     updatedUser,
-		"trace_id": traceID,
-	})
+	c.Header("ETag", response.ETag(updatedUser.Version()))
+	response.OK(c, userView(c, updatedUser))
 }
 
 // ChangePassword updates the user's password
 func (h *UserHandler) ChangePassword(c *gin.Context) {
-	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
-	userID := c.Param("id")
+	if userID, ok := h.ownedUserID(c); ok {
+		h.changePassword(c, userID)
+	}
+}
 
-	if userID == "" {
-		httpErr := errors.NewHTTPError(
-			http.StatusBadRequest,
-			errors.CodeValidationError,
-			"User ID is required",
-			map[string]interface{}{"field": "id"},
-			traceID,
-		)
-		c.JSON(httpErr.StatusCode, httpErr)
-		return
+// ChangeMyPassword updates the authenticated user's password. The current
+// password must be supplied as old_password.
+func (h *UserHandler) ChangeMyPassword(c *gin.Context) {
+	if userID, ok := currentUserID(c); ok {
+		h.changePassword(c, userID)
 	}
+}
+
+func (h *UserHandler) changePassword(c *gin.Context, userID string) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
 
 	var req ChangePasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		httpErr := errors.NewHTTPError(
-			http.StatusBadRequest,
-			errors.CodeValidationError,
-			"Invalid request data",
-			map[string]interface{}{"validation_error": err.Error()},
-			traceID,
-		)
-		c.JSON(httpErr.StatusCode, httpErr)
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
 		return
 	}
 
@@ -198,34 +273,26 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		})
 
 		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
-		c.JSON(httpErr.StatusCode, httpErr)
+		response.Error(c, httpErr)
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"message":  "Password updated successfully",
-		"trace_id": traceID,
-	})
+	response.Message(c, "Password updated successfully")
 }
 
-// ListUsers retrieves users with pagination and filtering
+// ListUsers retrieves users with pagination and filtering. Clients page
+// with page/page_size or by passing meta.next_cursor back as cursor.
 func (h *UserHandler) ListUsers(c *gin.Context) {
 	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
 
-	// Parse query parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	email := c.Query("email")
-	name := c.Query("name")
-
-	req := &user.ListUsersRequest{
-		Page:     page,
-		PageSize: pageSize,
-		Email:    email,
-		Name:     name,
+	// Parse query parameters over the defaults
+	req := &user.ListUsersRequest{Page: 1, PageSize: 10}
+	if err := validation.BindQuery(c, req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
 	}
 
-	response, err := h.userService.ListUsers(c.Request.Context(), req)
+	result, err := h.userService.ListUsers(c.Request.Context(), req)
 	if err != nil {
 		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
 			"operation": "list_users",
@@ -233,33 +300,105 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		})
 
 		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
-		c.JSON(httpErr.StatusCode, httpErr)
+		response.Error(c, httpErr)
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"data":     response,
-		"trace_id": traceID,
+	if response.NotModified(c, listVersion(result)) {
+		return
+	}
+	response.Page(c, userViews(c, result.Users), &response.Meta{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalKind:  listTotalKind(result.TotalKind),
+		TotalPages: result.TotalPages,
+		NextCursor: result.NextCursor,
+		HasMore:    result.NextCursor != "" || (result.Page > 0 && result.Page < result.TotalPages),
 	})
 }
 
-// DeleteUser deletes a user by ID
+// listTotalKind reports only totals that are not exact counts, so that
+// responses to clients that never ask for anything else are unchanged
+func listTotalKind(kind string) string {
+	if kind == user.TotalExact {
+		return ""
+	}
+	return kind
+}
+
+// DeleteUser deletes a user by ID. Users deleting their own account get the
+// grace period DeleteMe gives; only admins delete other users at once.
 func (h *UserHandler) DeleteUser(c *gin.Context) {
+	userID, ok := h.ownedUserID(c)
+	if !ok {
+		return
+	}
+	if userID == middleware.GetUserIDFromGinContext(c) {
+		h.scheduleDeletion(c, userID)
+		return
+	}
+	h.deleteUser(c, userID)
+}
+
+// DeleteMe schedules deletion of the authenticated user's account. It
+// responds 202 with the user while the grace period runs, and as DeleteUser
+// does when there is no grace period.
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	if userID, ok := currentUserID(c); ok {
+		h.scheduleDeletion(c, userID)
+	}
+}
+
+func (h *UserHandler) scheduleDeletion(c *gin.Context, userID string) {
 	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
-	userID := c.Param("id")
 
-	if userID == "" {
-		httpErr := errors.NewHTTPError(
-			http.StatusBadRequest,
-			errors.CodeValidationError,
-			"User ID is required",
-			map[string]interface{}{"field": "id"},
-			traceID,
-		)
-		c.JSON(httpErr.StatusCode, httpErr)
+	u, err := h.userService.ScheduleDeletion(c.Request.Context(), userID)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "schedule_deletion",
+			"user_id":   userID,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
 		return
 	}
 
+	if u == nil {
+		response.Message(c, "User deleted successfully")
+		return
+	}
+	response.JSON(c, http.StatusAccepted, userView(c, u), nil)
+}
+
+// CancelMyDeletion keeps the authenticated user's account when its
+// deletion is still pending
+func (h *UserHandler) CancelMyDeletion(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	u, err := h.userService.CancelDeletion(c.Request.Context(), userID)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "cancel_deletion",
+			"user_id":   userID,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.OK(c, userView(c, u))
+}
+
+func (h *UserHandler) deleteUser(c *gin.Context, userID string) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
 	err := h.userService.DeleteUser(c.Request.Context(), userID)
 	if err != nil {
 		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
@@ -268,12 +407,170 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		})
 
 		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
-		c.JSON(httpErr.StatusCode, httpErr)
+		response.Error(c, httpErr)
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"message":  "User deleted successfully",
-		"trace_id": traceID,
-	})
+	response.Message(c, "User deleted successfully")
+}
+
+// SuspendUser blocks a user from signing in until reactivated. The body,
+// with an optional reason, may be omitted.
+func (h *UserHandler) SuspendUser(c *gin.Context) {
+	userID, ok := h.pathUserID(c)
+	if !ok {
+		return
+	}
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req SuspendUserRequest
+	if c.Request.ContentLength != 0 {
+		if err := validation.BindJSON(c, &req); err != nil {
+			response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+			return
+		}
+	}
+
+	u, err := h.userService.SuspendUser(c.Request.Context(), userID, req.Reason)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "suspend_user",
+			"user_id":   userID,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.OK(c, userView(c, u))
+}
+
+// DeactivateUser closes an account without deleting it. The body, with an
+// optional reason, may be omitted.
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	userID, ok := h.pathUserID(c)
+	if !ok {
+		return
+	}
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req DeactivateUserRequest
+	if c.Request.ContentLength != 0 {
+		if err := validation.BindJSON(c, &req); err != nil {
+			response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+			return
+		}
+	}
+
+	u, err := h.userService.DeactivateUser(c.Request.Context(), userID, req.Reason)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "deactivate_user",
+			"user_id":   userID,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.OK(c, userView(c, u))
+}
+
+// ReactivateUser lets a suspended or deactivated user sign in again
+func (h *UserHandler) ReactivateUser(c *gin.Context) {
+	userID, ok := h.pathUserID(c)
+	if !ok {
+		return
+	}
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	u, err := h.userService.ReactivateUser(c.Request.Context(), userID)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "reactivate_user",
+			"user_id":   userID,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.OK(c, userView(c, u))
+}
+
+// pathUserID returns the :id path parameter, responding 400 when it is empty
+func (h *UserHandler) pathUserID(c *gin.Context) (string, bool) {
+	userID := c.Param("id")
+	if userID == "" {
+		httpErr := errors.NewHTTPError(
+			http.StatusBadRequest,
+			errors.CodeValidationError,
+			"User ID is required",
+			map[string]interface{}{"field": "id"},
+			middleware.GetTraceIDFromContext(c.Request.Context()),
+		)
+		response.Error(c, httpErr)
+		return "", false
+	}
+	return userID, true
+}
+
+// ownedUserID returns the :id path parameter of a route changing a user,
+// responding 403 unless it is the authenticated user or they are an admin
+func (h *UserHandler) ownedUserID(c *gin.Context) (string, bool) {
+	userID, ok := h.pathUserID(c)
+	if !ok {
+		return "", false
+	}
+	callerID, ok := currentUserID(c)
+	if !ok || callerID == userID {
+		return userID, ok
+	}
+
+	ctx := c.Request.Context()
+	traceID := middleware.GetTraceIDFromContext(ctx)
+	caller, err := h.userService.GetProfile(ctx, callerID)
+	if err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return "", false
+	}
+	if !caller.IsAdmin() {
+		err := errors.NewInsufficientRoleError("change_user", callerID, user.RoleAdmin)
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return "", false
+	}
+	return userID, true
+}
+
+// currentUserID returns the user ID injected by the auth middleware,
+// responding 401 when the request is not authenticated. Handlers acting on
+// the current user call it instead of reading the context themselves.
+func currentUserID(c *gin.Context) (string, bool) {
+	userID := middleware.GetUserIDFromGinContext(c)
+	if userID == "" {
+		httpErr := errors.NewHTTPError(
+			http.StatusUnauthorized,
+			errors.CodeUnauthorized,
+			"Authentication required",
+			nil,
+			middleware.GetTraceIDFromContext(c.Request.Context()),
+		)
+		response.Error(c, httpErr)
+		return "", false
+	}
+	return userID, true
+}
+
+// listVersion identifies a page of users by the versions of its users and
+// its position in the collection
+func listVersion(result *user.ListUsersResponse) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:%d:%d:%s", result.Total, result.Page, result.PageSize, result.NextCursor)
+	for _, u := range result.Users {
+		fmt.Fprintf(h, ":%s", u.Version())
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/cctw-zed/wonder/internal/domain/user"
 	"github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/internal/middleware"
 	"github.com/cctw-zed/wonder/internal/testutil/builder"
 	apperrors "github.com/cctw-zed/wonder/pkg/errors"
 )
@@ -25,6 +27,11 @@ func setupGinTest() *gin.Engine {
 	return gin.New()
 }
 
+// ptr returns a pointer to v, for optional request fields
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func TestUserHandler_Register_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -68,7 +75,7 @@ func TestUserHandler_Register_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 
-	responseUser := response["user"].(map[string]interface{})
+	responseUser := response["data"].(map[string]interface{})
 	assert.Equal(t, expectedUser.ID, responseUser["id"])
 	assert.Equal(t, expectedUser.Email, responseUser["email"])
 	assert.Equal(t, expectedUser.Name, responseUser["name"])
@@ -85,7 +92,7 @@ func TestUserHandler_Register_ValidationErrors(t *testing.T) {
 		name           string
 		requestBody    interface{}
 		expectedStatus int
-		errorContains  string
+		invalidField   string
 	}{
 		{
 			name: "missing email",
@@ -93,7 +100,7 @@ func TestUserHandler_Register_ValidationErrors(t *testing.T) {
 				"name": "Test User",
 			},
 			expectedStatus: http.StatusBadRequest,
-			errorContains:  "Invalid request data",
+			invalidField:   "email",
 		},
 		{
 			name: "missing name",
@@ -101,7 +108,7 @@ func TestUserHandler_Register_ValidationErrors(t *testing.T) {
 				"email": "test@example.com",
 			},
 			expectedStatus: http.StatusBadRequest,
-			errorContains:  "Invalid request data",
+			invalidField:   "name",
 		},
 		{
 			name: "invalid email format",
@@ -111,7 +118,7 @@ func TestUserHandler_Register_ValidationErrors(t *testing.T) {
 				Password: "password123",
 			},
 			expectedStatus: http.StatusBadRequest,
-			errorContains:  "Invalid request data",
+			invalidField:   "email",
 		},
 		{
 			name: "name too short",
@@ -121,7 +128,7 @@ func TestUserHandler_Register_ValidationErrors(t *testing.T) {
 				Password: "password123",
 			},
 			expectedStatus: http.StatusBadRequest,
-			errorContains:  "Invalid request data",
+			invalidField:   "name",
 		},
 		{
 			name: "name too long",
@@ -131,7 +138,7 @@ func TestUserHandler_Register_ValidationErrors(t *testing.T) {
 				Password: "password123",
 			},
 			expectedStatus: http.StatusBadRequest,
-			errorContains:  "Invalid request data",
+			invalidField:   "name",
 		},
 		{
 			name:           "invalid JSON",
@@ -165,30 +172,24 @@ func TestUserHandler_Register_ValidationErrors(t *testing.T) {
 			// Assert response
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
-			if tt.errorContains != "" {
+			if tt.invalidField != "" {
 				var response map[string]interface{}
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				require.NoError(t, err)
 
-				// Check various possible error fields
-				var errorMsg string
-				var exists bool
-				if response["error"] != nil {
-					errorMsg = response["error"].(string)
-					exists = true
-				} else if response["message"] != nil {
-					errorMsg = response["message"].(string)
-					exists = true
-				} else if response["details"] != nil {
-					if details, ok := response["details"].(map[string]interface{}); ok {
-						if validation_error, ok := details["validation_error"].(string); ok {
-							errorMsg = validation_error
-							exists = true
-						}
-					}
+				errBody, ok := response["error"].(map[string]interface{})
+				require.True(t, ok, "Expected error envelope in response: %v", response)
+				assert.Equal(t, string(apperrors.CodeValidationError), errBody["code"])
+				details, ok := errBody["details"].(map[string]interface{})
+				require.True(t, ok, "Expected error details in response: %v", errBody)
+				fields, ok := details["fields"].([]interface{})
+				require.True(t, ok, "Expected field errors in details: %v", details)
+
+				var names []string
+				for _, f := range fields {
+					names = append(names, f.(map[string]interface{})["field"].(string))
 				}
-				require.True(t, exists, "Expected error message in response: %v", response)
-				assert.Contains(t, errorMsg, tt.errorContains)
+				assert.Contains(t, names, tt.invalidField)
 			}
 		})
 	}
@@ -263,28 +264,40 @@ func TestUserHandler_Register_ServiceErrors(t *testing.T) {
 			require.NoError(t, err)
 
 			// Check various possible error fields
-			var errorMsg string
-			var exists bool
-			if response["error"] != nil {
-				errorMsg = response["error"].(string)
-				exists = true
-			} else if response["message"] != nil {
-				errorMsg = response["message"].(string)
-				exists = true
-			} else if response["details"] != nil {
-				if details, ok := response["details"].(map[string]interface{}); ok {
-					if validation_error, ok := details["validation_error"].(string); ok {
-						errorMsg = validation_error
-						exists = true
-					}
+			errBody, ok := response["error"].(map[string]interface{})
+			require.True(t, ok, "Expected error envelope in response: %v", response)
+			errorMsg, _ := errBody["message"].(string)
+			if details, ok := errBody["details"].(map[string]interface{}); ok {
+				if validationError, ok := details["validation_error"].(string); ok {
+					errorMsg += " " + validationError
 				}
 			}
-			require.True(t, exists, "Expected error message in response: %v", response)
 			assert.Contains(t, errorMsg, tt.errorContains)
 		})
 	}
 }
 
+func TestUserHandler_Register_Throttled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	mockUserService.EXPECT().
+		Register(gomock.Any(), "test@example.com", "Test User", "password123").
+		Return(nil, apperrors.NewSignupThrottledError("ip", 2*time.Minute))
+
+	router := setupGinTest()
+	router.POST("/users/register", handler.Register)
+	jsonBody, _ := json.Marshal(RegisterRequest{Email: "test@example.com", Name: "Test User", Password: "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/users/register", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "120", w.Header().Get("Retry-After"))
+}
+
 func TestUserHandler_Register_ContextPropagation(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -365,10 +378,11 @@ func BenchmarkUserHandler_Register(b *testing.B) {
 	}
 	jsonBody, _ := json.Marshal(requestBody)
 
-	// Setup Gin router
-	router := setupGinTest()
+	// Setup Gin router with the request-scoped middleware of the hot path
+	router := setupBenchRouter()
 	router.POST("/users/register", handler.Register)
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		req := httptest.NewRequest(http.MethodPost, "/users/register", bytes.NewBuffer(jsonBody))
@@ -383,6 +397,45 @@ func BenchmarkUserHandler_Register(b *testing.B) {
 	}
 }
 
+func BenchmarkUserHandler_GetProfile(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	expectedUser := builder.NewUserBuilderForTesting().
+		ValidUserWithEmail("bench@example.com")
+	mockUserService.EXPECT().
+		GetProfile(gomock.Any(), "bench-user-id").
+		Return(expectedUser, nil).
+		AnyTimes()
+
+	router := setupBenchRouter()
+	router.GET("/users/:id", handler.GetProfile)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/users/bench-user-id", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			b.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	}
+}
+
+// setupBenchRouter returns a router with the middleware every API request
+// passes through, which binds the request's logger
+func setupBenchRouter() *gin.Engine {
+	router := setupGinTest()
+	router.Use(middleware.TraceIDMiddleware(), middleware.RequestContextMiddleware())
+	return router
+}
+
 func TestUserHandler_GetProfile_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -417,10 +470,10 @@ func TestUserHandler_GetProfile_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 
-	assert.Contains(t, response, "user")
+	assert.Contains(t, response, "data")
 	assert.Contains(t, response, "trace_id")
 
-	userData := response["user"].(map[string]interface{})
+	userData := response["data"].(map[string]interface{})
 	assert.Equal(t, expectedUser.ID, userData["id"])
 	assert.Equal(t, expectedUser.Email, userData["email"])
 }
@@ -448,6 +501,158 @@ func TestUserHandler_GetProfile_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestUserHandler_GetProfile_ETag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	expectedUser := builder.NewUserBuilderForTesting().
+		ValidUserWithEmail("test@example.com")
+	etag := `"` + expectedUser.Version() + `"`
+
+	mockUserService.EXPECT().
+		GetProfile(gomock.Any(), "test-user-id").
+		Return(expectedUser, nil).
+		Times(2)
+
+	router := setupGinTest()
+	router.GET("/users/:id", handler.GetProfile)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/test-user-id", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/test-user-id", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestUserHandler_ListUsers_ETag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	u := builder.NewUserBuilderForTesting().ValidUserWithEmail("test@example.com")
+	result := &user.ListUsersResponse{Users: []*user.User{u}, Total: 1, Page: 1, PageSize: 10, TotalPages: 1}
+	mockUserService.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Return(result, nil).Times(3)
+
+	router := setupGinTest()
+	router.GET("/users", handler.ListUsers)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// Any change to a listed user changes the tag
+	u.UpdatedAt = u.UpdatedAt.Add(time.Second)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestUserHandler_UpdateProfile_IfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	updatedUser := builder.NewUserBuilderForTesting().ValidUserWithEmail("updated@example.com")
+	gomock.InOrder(
+		mockUserService.EXPECT().
+			UpdateProfile(gomock.Any(), "test-user-id", &user.UpdateProfileRequest{Name: ptr("Updated Name"), IfVersion: []string{"v1"}}).
+			Return(updatedUser, nil),
+		mockUserService.EXPECT().
+			UpdateProfile(gomock.Any(), "test-user-id", gomock.Any()).
+			Return(nil, apperrors.NewVersionMismatchError("user", "test-user-id", "v2")),
+	)
+
+	router := setupGinTest()
+	router.PUT("/users/:id", withUserID("test-user-id"), handler.UpdateProfile)
+	put := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/users/test-user-id", bytes.NewBufferString(`{"name":"Updated Name"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := put(`"v1"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"`+updatedUser.Version()+`"`, w.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusPreconditionFailed, put(`"v1"`).Code)
+	assert.Equal(t, http.StatusBadRequest, put("v1").Code, "unquoted tags are rejected")
+}
+
+func TestUserHandler_PatchProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+	updatedUser := builder.NewUserBuilderForTesting().ValidUserWithEmail("updated@example.com")
+
+	gomock.InOrder(
+		// A merge patch changes only the members it lists
+		mockUserService.EXPECT().
+			UpdateProfile(gomock.Any(), "test-user-id", &user.UpdateProfileRequest{Name: ptr("Updated Name")}).
+			Return(updatedUser, nil),
+		mockUserService.EXPECT().
+			UpdateProfile(gomock.Any(), "test-user-id", &user.UpdateProfileRequest{Email: ptr("updated@example.com"), IfVersion: []string{"v1"}}).
+			Return(updatedUser, nil),
+	)
+
+	router := setupGinTest()
+	router.PATCH("/users/:id", withUserID("test-user-id"), handler.PatchProfile)
+	patch := func(contentType, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/users/test-user-id", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch(MediaTypeMergePatch, `{"name":"Updated Name"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"`+updatedUser.Version()+`"`, w.Header().Get("ETag"))
+
+	w = patch(MediaTypeJSONPatch, `[{"op":"replace","path":"/email","value":"updated@example.com"}]`, "If-Match", `"v1"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Rejected before reaching the service; unlike PUT, null and "" are
+	// not ignored
+	assert.Equal(t, http.StatusBadRequest, patch(MediaTypeMergePatch, `{"name":null}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch(MediaTypeMergePatch, `{"name":""}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch(MediaTypeMergePatch, `{"role":"admin"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch(MediaTypeMergePatch, `{"name":"A"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch(MediaTypeMergePatch, ``).Code)
+
+	w = patch("text/plain", `name=Updated`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Equal(t, MediaTypeMergePatch+", "+MediaTypeJSONPatch, w.Header().Get("Accept-Patch"))
+}
+
 func TestUserHandler_UpdateProfile_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -464,13 +669,13 @@ func TestUserHandler_UpdateProfile_Success(t *testing.T) {
 		Times(1)
 
 	requestBody := user.UpdateProfileRequest{
-		Email: "updated@example.com",
-		Name:  "Updated Name",
+		Email: ptr("updated@example.com"),
+		Name:  ptr("Updated Name"),
 	}
 	jsonBody, _ := json.Marshal(requestBody)
 
 	router := setupGinTest()
-	router.PUT("/users/:id", handler.UpdateProfile)
+	router.PUT("/users/:id", withUserID("test-user-id"), handler.UpdateProfile)
 
 	req := httptest.NewRequest(http.MethodPut, "/users/test-user-id", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -484,7 +689,7 @@ func TestUserHandler_UpdateProfile_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 
-	assert.Contains(t, response, "user")
+	assert.Contains(t, response, "data")
 	assert.Contains(t, response, "trace_id")
 }
 
@@ -496,7 +701,7 @@ func TestUserHandler_UpdateProfile_InvalidJSON(t *testing.T) {
 	handler := NewUserHandler(mockUserService)
 
 	router := setupGinTest()
-	router.PUT("/users/:id", handler.UpdateProfile)
+	router.PUT("/users/:id", withUserID("test-user-id"), handler.UpdateProfile)
 
 	req := httptest.NewRequest(http.MethodPut, "/users/test-user-id", bytes.NewBuffer([]byte("invalid-json")))
 	req.Header.Set("Content-Type", "application/json")
@@ -528,7 +733,7 @@ func TestUserHandler_ChangePassword_Success(t *testing.T) {
 	require.NoError(t, err)
 
 	router := setupGinTest()
-	router.PUT("/users/:id/password", handler.ChangePassword)
+	router.PUT("/users/:id/password", withUserID("test-user-id"), handler.ChangePassword)
 
 	req := httptest.NewRequest(http.MethodPut, "/users/test-user-id/password", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -540,7 +745,7 @@ func TestUserHandler_ChangePassword_Success(t *testing.T) {
 
 	var response map[string]interface{}
 	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
-	assert.Equal(t, "Password updated successfully", response["message"])
+	assert.Equal(t, "Password updated successfully", response["data"].(map[string]interface{})["message"])
 }
 
 func TestUserHandler_ChangePassword_InvalidJSON(t *testing.T) {
@@ -551,7 +756,7 @@ func TestUserHandler_ChangePassword_InvalidJSON(t *testing.T) {
 	handler := NewUserHandler(mockUserService)
 
 	router := setupGinTest()
-	router.PUT("/users/:id/password", handler.ChangePassword)
+	router.PUT("/users/:id/password", withUserID("test-user-id"), handler.ChangePassword)
 
 	req := httptest.NewRequest(http.MethodPut, "/users/test-user-id/password", bytes.NewBuffer([]byte("invalid")))
 	req.Header.Set("Content-Type", "application/json")
@@ -583,7 +788,7 @@ func TestUserHandler_ChangePassword_ServiceError(t *testing.T) {
 	require.NoError(t, err)
 
 	router := setupGinTest()
-	router.PUT("/users/:id/password", handler.ChangePassword)
+	router.PUT("/users/:id/password", withUserID("test-user-id"), handler.ChangePassword)
 
 	req := httptest.NewRequest(http.MethodPut, "/users/test-user-id/password", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -636,9 +841,11 @@ func TestUserHandler_ListUsers_Success(t *testing.T) {
 	assert.Contains(t, response, "data")
 	assert.Contains(t, response, "trace_id")
 
-	data := response["data"].(map[string]interface{})
-	assert.Equal(t, float64(2), data["total"])
-	assert.Equal(t, float64(1), data["page"])
+	assert.Len(t, response["data"], 2)
+	meta := response["meta"].(map[string]interface{})
+	assert.Equal(t, float64(2), meta["total"])
+	assert.Equal(t, float64(1), meta["page"])
+	assert.Equal(t, false, meta["has_more"])
 }
 
 func TestUserHandler_ListUsers_WithFilters(t *testing.T) {
@@ -672,6 +879,90 @@ func TestUserHandler_ListUsers_WithFilters(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestUserHandler_ListUsers_SortAndFilters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	mockUserService.EXPECT().
+		ListUsers(gomock.Any(), &user.ListUsersRequest{
+			Page:          2,
+			PageSize:      10,
+			SortBy:        user.SortByName,
+			SortOrder:     user.SortAsc,
+			CreatedAfter:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			CreatedBefore: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			Roles:         []string{user.RoleAdmin, user.RoleUser},
+		}).
+		Return(&user.ListUsersResponse{Users: []*user.User{}, Total: 25, Page: 2, PageSize: 10, TotalPages: 3}, nil).
+		Times(1)
+
+	router := setupGinTest()
+	router.GET("/users", handler.ListUsers)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?page=2&sort_by=name&sort_order=asc"+
+		"&created_after=2026-01-01T00:00:00Z&created_before=2026-02-01T00:00:00Z&role=admin,user", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Meta struct {
+			HasMore bool `json:"has_more"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.True(t, body.Meta.HasMore, "offset pages without a cursor report has_more")
+}
+
+func TestUserHandler_ListUsers_IncludeTotal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	mockUserService.EXPECT().
+		ListUsers(gomock.Any(), &user.ListUsersRequest{Page: 1, PageSize: 10, IncludeTotal: user.TotalNone}).
+		Return(&user.ListUsersResponse{Users: []*user.User{}, Page: 1, PageSize: 10, TotalKind: user.TotalNone, NextCursor: "next"}, nil).
+		Times(1)
+
+	router := setupGinTest()
+	router.GET("/users", handler.ListUsers)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?page=1&page_size=10&include_total=none", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "none", body.Meta["total_kind"])
+	assert.Equal(t, true, body.Meta["has_more"])
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?include_total=all", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserHandler_ListUsers_InvalidSort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewUserHandler(mocks.NewMockUserService(ctrl))
+	router := setupGinTest()
+	router.GET("/users", handler.ListUsers)
+
+	for _, query := range []string{"?sort_by=password_hash", "?sort_order=up", "?created_after=yesterday", "?role=root"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
 func TestUserHandler_DeleteUser_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -679,13 +970,16 @@ func TestUserHandler_DeleteUser_Success(t *testing.T) {
 	mockUserService := mocks.NewMockUserService(ctrl)
 	handler := NewUserHandler(mockUserService)
 
+	admin := builder.NewUserBuilderForTesting().ValidUserWithID("admin-id")
+	admin.Role = user.RoleAdmin
+	mockUserService.EXPECT().GetProfile(gomock.Any(), "admin-id").Return(admin, nil)
 	mockUserService.EXPECT().
 		DeleteUser(gomock.Any(), "test-user-id").
 		Return(nil).
 		Times(1)
 
 	router := setupGinTest()
-	router.DELETE("/users/:id", handler.DeleteUser)
+	router.DELETE("/users/:id", withUserID("admin-id"), handler.DeleteUser)
 
 	req := httptest.NewRequest(http.MethodDelete, "/users/test-user-id", nil)
 	w := httptest.NewRecorder()
@@ -698,9 +992,29 @@ func TestUserHandler_DeleteUser_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 
-	assert.Contains(t, response, "message")
+	assert.Contains(t, response, "data")
 	assert.Contains(t, response, "trace_id")
-	assert.Equal(t, "User deleted successfully", response["message"])
+	assert.Equal(t, "User deleted successfully", response["data"].(map[string]interface{})["message"])
+}
+
+func TestUserHandler_DeleteUser_Self(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	// Deleting one's own account by ID waits out the grace period too, so a
+	// stolen token cannot destroy the account at once
+	me := builder.NewUserBuilderForTesting().ValidUserWithID("test-user-id")
+	mockUserService.EXPECT().ScheduleDeletion(gomock.Any(), "test-user-id").Return(me, nil)
+
+	router := setupGinTest()
+	router.DELETE("/users/:id", withUserID("test-user-id"), handler.DeleteUser)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/test-user-id", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
 }
 
 func TestUserHandler_DeleteUser_NotFound(t *testing.T) {
@@ -710,13 +1024,16 @@ func TestUserHandler_DeleteUser_NotFound(t *testing.T) {
 	mockUserService := mocks.NewMockUserService(ctrl)
 	handler := NewUserHandler(mockUserService)
 
+	admin := builder.NewUserBuilderForTesting().ValidUserWithID("admin-id")
+	admin.Role = user.RoleAdmin
+	mockUserService.EXPECT().GetProfile(gomock.Any(), "admin-id").Return(admin, nil)
 	mockUserService.EXPECT().
 		DeleteUser(gomock.Any(), "nonexistent-id").
 		Return(apperrors.NewEntityNotFoundError("user", "nonexistent-id")).
 		Times(1)
 
 	router := setupGinTest()
-	router.DELETE("/users/:id", handler.DeleteUser)
+	router.DELETE("/users/:id", withUserID("admin-id"), handler.DeleteUser)
 
 	req := httptest.NewRequest(http.MethodDelete, "/users/nonexistent-id", nil)
 	w := httptest.NewRecorder()
@@ -726,6 +1043,51 @@ func TestUserHandler_DeleteUser_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestUserHandler_ChangeOtherUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	userA := builder.NewUserBuilderForTesting().ValidUserWithID("user-a")
+	admin := builder.NewUserBuilderForTesting().ValidUserWithID("admin-id")
+	admin.Role = user.RoleAdmin
+	mockUserService.EXPECT().GetProfile(gomock.Any(), "user-a").Return(userA, nil).Times(4)
+	mockUserService.EXPECT().GetProfile(gomock.Any(), "admin-id").Return(admin, nil)
+	mockUserService.EXPECT().
+		UpdateProfile(gomock.Any(), "user-b", &user.UpdateProfileRequest{Email: ptr("attacker@example.com")}).
+		Return(builder.NewUserBuilderForTesting().ValidUserWithID("user-b"), nil)
+
+	router := setupGinTest()
+	asUserA := router.Group("/a", withUserID("user-a"))
+	asUserA.PUT("/users/:id", handler.UpdateProfile)
+	asUserA.PATCH("/users/:id", handler.PatchProfile)
+	asUserA.PUT("/users/:id/password", handler.ChangePassword)
+	asUserA.DELETE("/users/:id", handler.DeleteUser)
+	router.PATCH("/admin/users/:id", withUserID("admin-id"), handler.PatchProfile)
+
+	send := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// User A cannot change user B, least of all their email
+	w := send(http.MethodPatch, "/a/users/user-b", MediaTypeMergePatch, `{"email":"attacker@example.com"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPut, "/a/users/user-b", "application/json", `{"name":"Taken Over"}`).Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPut, "/a/users/user-b/password", "application/json",
+		`{"old_password":"password123","new_password":"newpassword456"}`).Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, "/a/users/user-b", "", "").Code)
+
+	// Admins may
+	w = send(http.MethodPatch, "/admin/users/user-b", MediaTypeMergePatch, `{"email":"attacker@example.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestUserHandler_GetProfile_EmptyUserID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -744,3 +1106,211 @@ func TestUserHandler_GetProfile_EmptyUserID(t *testing.T) {
 	// The request should result in a 404 because the route doesn't match
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+// withUserID stands in for the auth middleware
+func withUserID(userID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.UserIDKey, userID))
+		c.Next()
+	}
+}
+
+func TestUserHandler_Me(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+	me := builder.NewUserBuilderForTesting().ValidUserWithEmail("me@example.com")
+
+	router := setupGinTest()
+	users := router.Group("/users", withUserID(me.ID))
+	users.GET("/me", handler.GetMe)
+	users.PUT("/me", handler.UpdateMe)
+	users.DELETE("/me", handler.DeleteMe)
+	users.PATCH("/me/password", handler.ChangeMyPassword)
+	users.GET("/:id", handler.GetProfile)
+
+	mockUserService.EXPECT().GetProfile(gomock.Any(), me.ID).Return(me, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/me", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockUserService.EXPECT().
+		UpdateProfile(gomock.Any(), me.ID, &user.UpdateProfileRequest{Name: ptr("Renamed")}).
+		Return(me, nil)
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/users/me", bytes.NewBufferString(`{"name":"Renamed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockUserService.EXPECT().ChangePassword(gomock.Any(), me.ID, "current123", "replacement456").Return(nil)
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPatch, "/users/me/password",
+		bytes.NewBufferString(`{"old_password":"current123","new_password":"replacement456"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Without a grace period the account is deleted at once
+	mockUserService.EXPECT().ScheduleDeletion(gomock.Any(), me.ID).Return(nil, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/me", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Other IDs still route to the by-ID handler
+	mockUserService.EXPECT().GetProfile(gomock.Any(), "other-id").Return(me, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/other-id", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUserHandler_Me_Unauthenticated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewUserHandler(mocks.NewMockUserService(ctrl))
+
+	router := setupGinTest()
+	router.GET("/users/me", handler.GetMe)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/me", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUserHandler_SuspendAndReactivate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	suspended := &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser, Status: user.StatusSuspended}
+	gomock.InOrder(
+		mockUserService.EXPECT().SuspendUser(gomock.Any(), "u-1", "chargeback").Return(suspended, nil),
+		mockUserService.EXPECT().SuspendUser(gomock.Any(), "u-1", "").
+			Return(nil, apperrors.NewStateTransitionError("user", user.StatusSuspended, user.StatusSuspended, "user is already suspended")),
+		mockUserService.EXPECT().ReactivateUser(gomock.Any(), "u-1").Return(&user.User{ID: "u-1", Status: user.StatusActive}, nil),
+		mockUserService.EXPECT().DeactivateUser(gomock.Any(), "u-1", "closed on request").Return(&user.User{ID: "u-1", Status: user.StatusDeactivated}, nil),
+		mockUserService.EXPECT().DeactivateUser(gomock.Any(), "u-1", "").
+			Return(nil, apperrors.NewStateTransitionError("user", user.StatusDeactivated, user.StatusDeactivated, "user is already deactivated")),
+	)
+
+	router := setupGinTest()
+	router.POST("/admin/users/:id/suspend", handler.SuspendUser)
+	router.POST("/admin/users/:id/reactivate", handler.ReactivateUser)
+	router.POST("/admin/users/:id/deactivate", handler.DeactivateUser)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/admin/users/u-1/suspend", `{"reason":"chargeback"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, user.StatusSuspended, body.Data.Status)
+
+	// The body is optional; suspending twice is an invalid transition
+	assert.Equal(t, http.StatusConflict, post("/admin/users/u-1/suspend", "").Code)
+
+	w = post("/admin/users/u-1/reactivate", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, user.StatusActive, body.Data.Status)
+
+	w = post("/admin/users/u-1/deactivate", `{"reason":"closed on request"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, user.StatusDeactivated, body.Data.Status)
+	assert.Equal(t, http.StatusConflict, post("/admin/users/u-1/deactivate", "").Code)
+}
+
+func TestUserHandler_ScheduleAndCancelDeletion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	deleteAt := time.Date(2026, 11, 15, 4, 0, 0, 0, time.UTC)
+	pending := &user.User{ID: "u-1", Email: "ada@example.com", Name: "Ada", Role: user.RoleUser, DeletionScheduledAt: &deleteAt}
+	gomock.InOrder(
+		mockUserService.EXPECT().ScheduleDeletion(gomock.Any(), "u-1").Return(pending, nil),
+		mockUserService.EXPECT().CancelDeletion(gomock.Any(), "u-1").Return(&user.User{ID: "u-1", Role: user.RoleUser}, nil),
+		mockUserService.EXPECT().CancelDeletion(gomock.Any(), "u-1").
+			Return(nil, apperrors.NewInvalidStateError(apperrors.CodeInvalidState, "user", "u-1", "no deletion is scheduled")),
+	)
+
+	router := setupGinTest()
+	users := router.Group("/users", withUserID("u-1"))
+	users.DELETE("/me", handler.DeleteMe)
+	users.POST("/me/deletion/cancel", handler.CancelMyDeletion)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/me", nil))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var body struct {
+		Data UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NotNil(t, body.Data.DeletionScheduledAt)
+	assert.True(t, deleteAt.Equal(*body.Data.DeletionScheduledAt))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/me/deletion/cancel", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body.Data = UserResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Nil(t, body.Data.DeletionScheduledAt)
+
+	// Nothing left to cancel
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/me/deletion/cancel", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestUserHandler_Handles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUserService := mocks.NewMockUserService(ctrl)
+	handler := NewUserHandler(mockUserService)
+
+	router := setupGinTest()
+	router.GET("/users/handle-availability", handler.CheckHandleAvailability)
+	router.GET("/users/by-handle/:handle", handler.GetByHandle)
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	mockUserService.EXPECT().CheckHandleAvailability(gomock.Any(), "Admin").
+		Return(&user.HandleAvailability{Handle: "admin", Reason: user.HandleReserved}, nil)
+	w := get("/users/handle-availability?handle=Admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"handle":"admin","available":false,"reason":"reserved"},"trace_id":""}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, get("/users/handle-availability").Code)
+
+	ada := builder.NewUserBuilderForTesting().ValidUserWithEmail("ada@example.com")
+	ada.Handle = ptr("ada")
+	mockUserService.EXPECT().GetByHandle(gomock.Any(), "ada").Return(ada, nil)
+	w = get("/users/by-handle/ada")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct{ Data UserResponse }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "ada", body.Data.Handle)
+
+	mockUserService.EXPECT().GetByHandle(gomock.Any(), "nobody").Return(nil, apperrors.NewEntityNotFoundError("user", "nobody"))
+	assert.Equal(t, http.StatusNotFound, get("/users/by-handle/nobody").Code)
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type UserSearchHandler struct {
+	searchService service.UserSearchService
+	errorMapper   *errors.ErrorMapper
+	errorLogger   errors.ErrorLogger
+}
+
+func NewUserSearchHandler(searchService service.UserSearchService) *UserSearchHandler {
+	return &UserSearchHandler{
+		searchService: searchService,
+		errorMapper:   errors.NewErrorMapper(),
+		errorLogger:   errors.NewDefaultErrorLogger("user-search-service"),
+	}
+}
+
+// SearchUsers returns users whose name or email matches q, best match
+// first, with the matched parts highlighted
+func (h *UserSearchHandler) SearchUsers(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	req := &user.SearchRequest{Page: 1, PageSize: 10}
+	if err := validation.BindQuery(c, req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	result, err := h.searchService.SearchUsers(c.Request.Context(), req)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "search_users",
+			"page":      req.Page,
+		})
+
+		httpErr := h.errorMapper.MapToHTTPError(err, traceID)
+		response.Error(c, httpErr)
+		return
+	}
+
+	response.Page(c, NewSearchHitResponses(APIVersionOf(c), result.Hits), &response.Meta{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages,
+		HasMore:    result.Page < result.TotalPages,
+	})
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+)
+
+// stubUserSearchService captures the request for handler tests
+type stubUserSearchService struct {
+	req    *user.SearchRequest
+	result *user.SearchResponse
+	err    error
+}
+
+func (s *stubUserSearchService) SearchUsers(ctx context.Context, req *user.SearchRequest) (*user.SearchResponse, error) {
+	s.req = req
+	return s.result, s.err
+}
+
+func searchUsers(handler *UserSearchHandler, query string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.GET("/users/search", handler.SearchUsers)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/search"+query, nil))
+	return w
+}
+
+func TestUserSearchHandler_SearchUsers(t *testing.T) {
+	svc := &stubUserSearchService{result: &user.SearchResponse{
+		Hits: []*user.SearchHit{{
+			User:       &user.User{ID: "u-1", Name: "Alice"},
+			Rank:       0.9,
+			Highlights: map[string][]user.Fragment{"name": {{Text: "Ali", Match: true}, {Text: "ce"}}},
+		}},
+		Total: 11, Page: 1, PageSize: 10, TotalPages: 2,
+	}}
+
+	w := searchUsers(NewUserSearchHandler(svc), "?q=ali")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, &user.SearchRequest{Query: "ali", Page: 1, PageSize: 10}, svc.req)
+
+	var body struct {
+		Data []user.SearchHit `json:"data"`
+		Meta struct {
+			Total   int64 `json:"total"`
+			HasMore bool  `json:"has_more"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, "u-1", body.Data[0].User.ID)
+	assert.Equal(t, "Ali", body.Data[0].Highlights["name"][0].Text)
+	assert.Equal(t, int64(11), body.Meta.Total)
+	assert.True(t, body.Meta.HasMore)
+}
+
+func TestUserSearchHandler_SearchUsers_InvalidQuery(t *testing.T) {
+	for _, query := range []string{"", "?q=a", "?q=alice&page_size=500"} {
+		svc := &stubUserSearchService{}
+		w := searchUsers(NewUserSearchHandler(svc), query)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Nil(t, svc.req, query)
+	}
+}
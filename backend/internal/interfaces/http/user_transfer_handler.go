@@ -0,0 +1,374 @@
+package http
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// Supported export and import formats
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// exportColumns is the CSV header of a user export
+var exportColumns = []string{"id", "email", "name", "role", "created_at", "updated_at"}
+
+// importColumns are the CSV columns an import must provide
+var importColumns = []string{"email", "name", "password"}
+
+// maxNDJSONLine bounds a single NDJSON import record
+const maxNDJSONLine = 64 * 1024
+
+// ExportUsersQuery holds the export query parameters
+type ExportUsersQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	Email  string `form:"email" binding:"max=255"`
+	Name   string `form:"name" binding:"max=100"`
+	// Timezone is the IANA time zone timestamps are written in; UTC when empty
+	Timezone string `form:"timezone" binding:"max=64"`
+}
+
+type UserTransferHandler struct {
+	transferService service.UserTransferService
+	maxRows         int
+	maxBodyBytes    int64
+	errorMapper     *errors.ErrorMapper
+	errorLogger     errors.ErrorLogger
+}
+
+// NewUserTransferHandler creates a handler for bulk user export and import.
+// Imports larger than maxRows records or maxBodyBytes bytes are rejected.
+func NewUserTransferHandler(transferService service.UserTransferService, maxRows int, maxBodyBytes int64) *UserTransferHandler {
+	return &UserTransferHandler{
+		transferService: transferService,
+		maxRows:         maxRows,
+		maxBodyBytes:    maxBodyBytes,
+		errorMapper:     errors.NewErrorMapper(),
+		errorLogger:     errors.NewDefaultErrorLogger("user-transfer"),
+	}
+}
+
+// ExportUsers streams users matching the email and name filters as CSV
+// (default) or newline-delimited JSON. Password hashes are never exported.
+func (h *UserTransferHandler) ExportUsers(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	query := &ExportUsersQuery{Format: formatCSV}
+	if err := validation.BindQuery(c, query); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+	timezone, err := user.NormalizeTimezone(query.Timezone)
+	if err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+	loc := user.LocalePrefs{Timezone: timezone}.Location()
+
+	var (
+		started bool
+		csvw    *csv.Writer
+		jsonw   *json.Encoder
+	)
+	// Headers are written with the first page so a failed first query can
+	// still be reported as an error envelope
+	start := func() {
+		started = true
+		filename := fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102T150405Z"), query.Format)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		if query.Format == formatNDJSON {
+			c.Header("Content-Type", "application/x-ndjson")
+			jsonw = json.NewEncoder(c.Writer)
+		} else {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			csvw = csv.NewWriter(c.Writer)
+			csvw.Write(exportColumns)
+		}
+		c.Status(http.StatusOK)
+	}
+
+	filter := &user.ListUsersRequest{Email: query.Email, Name: query.Name}
+	err = h.transferService.ExportUsers(c.Request.Context(), filter, func(users []*user.User) error {
+		if !started {
+			start()
+		}
+		for _, u := range users {
+			if jsonw != nil {
+				if err := jsonw.Encode(NewUserExportResponse(u, loc)); err != nil {
+					return err
+				}
+				continue
+			}
+			csvw.Write([]string{
+				u.ID,
+				csvCell(u.Email),
+				csvCell(u.Name),
+				u.Role,
+				u.CreatedAt.In(loc).Format(time.RFC3339),
+				u.UpdatedAt.In(loc).Format(time.RFC3339),
+			})
+		}
+		if csvw != nil {
+			csvw.Flush()
+			if err := csvw.Error(); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "export_users",
+			"format":    query.Format,
+			"streaming": started,
+		})
+		if started {
+			// The status line is already sent; a truncated body is all we can signal
+			c.Abort()
+			return
+		}
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	if !started {
+		start()
+	}
+	if csvw != nil {
+		csvw.Flush()
+	}
+}
+
+// csvCell keeps spreadsheets from evaluating a user-supplied value as a
+// formula by prefixing values that start like one with a quote
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// ImportUsers creates users from a CSV (header row with email, name and
+// password columns) or NDJSON request body. The format comes from ?format=
+// or the Content-Type. The response reports every row that was not created.
+func (h *UserTransferHandler) ImportUsers(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	format := c.Query("format")
+	if format == "" {
+		format = formatCSV
+		if ct := c.ContentType(); ct == "application/x-ndjson" || ct == "application/ndjson" {
+			format = formatNDJSON
+		}
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBodyBytes)
+	var rows []user.ImportRow
+	var err error
+	switch format {
+	case formatCSV:
+		rows, err = h.readCSV(body)
+	case formatNDJSON:
+		rows, err = h.readNDJSON(body)
+	default:
+		err = errors.NewFieldValidationError(errors.FieldError{
+			Field:      "format",
+			Code:       errors.CodeInvalidValue,
+			Message:    "format must be one of: csv, ndjson",
+			Constraint: map[string]interface{}{"oneof": []string{formatCSV, formatNDJSON}},
+		})
+	}
+	var tooLarge *http.MaxBytesError
+	if stderrors.As(err, &tooLarge) {
+		err = errors.NewFieldValidationError(errors.FieldError{
+			Field:      "body",
+			Code:       errors.CodeOutOfRange,
+			Message:    fmt.Sprintf("body must be at most %d bytes", h.maxBodyBytes),
+			Constraint: map[string]interface{}{"max_bytes": h.maxBodyBytes},
+		})
+	}
+	if err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	report, err := h.transferService.ImportUsers(c.Request.Context(), rows)
+	if err != nil {
+		h.errorLogger.LogError(c.Request.Context(), err, traceID, map[string]interface{}{
+			"operation": "import_users",
+			"format":    format,
+			"rows":      len(rows),
+		})
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	response.OK(c, report)
+}
+
+// readCSV parses an import file whose first record names the columns.
+// Column order is free and unknown columns are ignored.
+func (h *UserTransferHandler) readCSV(body io.Reader) ([]user.ImportRow, error) {
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, emptyBodyError()
+	}
+	if err != nil {
+		return nil, csvFormatError(err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var missing []errors.FieldError
+	for _, col := range importColumns {
+		if _, ok := index[col]; !ok {
+			missing = append(missing, errors.FieldError{
+				Field:      "header",
+				Code:       errors.CodeRequiredField,
+				Message:    fmt.Sprintf("header must include a %s column", col),
+				Constraint: map[string]interface{}{"column": col},
+			})
+		}
+	}
+	if len(missing) > 0 {
+		return nil, errors.NewFieldValidationError(missing...)
+	}
+
+	column := func(record []string, name string) string {
+		if i := index[name]; i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rows []user.ImportRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, csvFormatError(err)
+		}
+		if len(rows) == h.maxRows {
+			return nil, h.tooManyRowsError()
+		}
+		rows = append(rows, user.ImportRow{
+			Row:      len(rows) + 1,
+			Email:    column(record, "email"),
+			Name:     column(record, "name"),
+			Password: column(record, "password"),
+		})
+	}
+	return rows, nil
+}
+
+// readNDJSON parses one JSON object per line. Blank lines are skipped but
+// still counted, so row numbers match line numbers.
+func (h *UserTransferHandler) readNDJSON(body io.Reader) ([]user.ImportRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxNDJSONLine)
+
+	var rows []user.ImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if len(rows) == h.maxRows {
+			return nil, h.tooManyRowsError()
+		}
+
+		var row user.ImportRow
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			return nil, errors.NewFieldValidationError(errors.FieldError{
+				Field:      "body",
+				Code:       errors.CodeInvalidFormat,
+				Message:    fmt.Sprintf("line %d is not a valid JSON object", line),
+				Constraint: map[string]interface{}{"format": formatNDJSON, "line": line},
+			})
+		}
+		row.Row = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		if stderrors.Is(err, bufio.ErrTooLong) {
+			return nil, errors.NewFieldValidationError(errors.FieldError{
+				Field:      "body",
+				Code:       errors.CodeOutOfRange,
+				Message:    fmt.Sprintf("line %d exceeds %d bytes", line+1, maxNDJSONLine),
+				Constraint: map[string]interface{}{"max_line_bytes": maxNDJSONLine},
+			})
+		}
+		return nil, err
+	}
+	if line == 0 {
+		return nil, emptyBodyError()
+	}
+	return rows, nil
+}
+
+func (h *UserTransferHandler) tooManyRowsError() error {
+	return errors.NewFieldValidationError(errors.FieldError{
+		Field:      "body",
+		Code:       errors.CodeOutOfRange,
+		Message:    fmt.Sprintf("import must contain at most %d rows", h.maxRows),
+		Constraint: map[string]interface{}{"max_rows": h.maxRows},
+	})
+}
+
+func emptyBodyError() error {
+	return errors.NewFieldValidationError(errors.FieldError{
+		Field:   "body",
+		Code:    errors.CodeRequiredField,
+		Message: "request body is required",
+	})
+}
+
+// csvFormatError reports malformed CSV; a body over the size limit is
+// passed through for the caller to report
+func csvFormatError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if stderrors.As(err, &tooLarge) {
+		return err
+	}
+	constraint := map[string]interface{}{"format": formatCSV}
+	var parseErr *csv.ParseError
+	if stderrors.As(err, &parseErr) {
+		constraint["line"] = parseErr.Line
+	}
+	return errors.NewFieldValidationError(errors.FieldError{
+		Field:      "body",
+		Code:       errors.CodeInvalidFormat,
+		Message:    "request body must be valid CSV",
+		Constraint: constraint,
+	})
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// stubTransferService captures export filters and import rows for handler tests
+type stubTransferService struct {
+	pages  [][]*user.User
+	filter *user.ListUsersRequest
+	rows   []user.ImportRow
+	report *user.ImportReport
+	err    error
+}
+
+func (s *stubTransferService) ExportUsers(ctx context.Context, filter *user.ListUsersRequest, emit func([]*user.User) error) error {
+	s.filter = filter
+	if s.err != nil {
+		return s.err
+	}
+	for _, page := range s.pages {
+		if err := emit(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stubTransferService) ImportUsers(ctx context.Context, rows []user.ImportRow) (*user.ImportReport, error) {
+	s.rows = rows
+	return s.report, s.err
+}
+
+func serveTransfer(handler *UserTransferHandler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.GET("/admin/users/export", handler.ExportUsers)
+	router.POST("/admin/users/import", handler.ImportUsers)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserTransferHandler_ExportUsers(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	svc := &stubTransferService{pages: [][]*user.User{
+		{{ID: "u-2", TenantID: "acme", Email: "bob@example.com", Name: "Bob, Jr.", Role: user.RoleUser, PasswordHash: "secret-hash", CreatedAt: created, UpdatedAt: created}},
+		{{ID: "u-1", Email: "ann@example.com", Name: "Ann", Role: user.RoleAdmin, PasswordHash: "secret-hash", CreatedAt: created, UpdatedAt: created}},
+	}}
+	handler := NewUserTransferHandler(svc, 100, 1024)
+
+	w := serveTransfer(handler, http.MethodGet, "/admin/users/export?name=b", "", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, "b", svc.filter.Name)
+	assert.Equal(t, "id,email,name,role,created_at,updated_at\n"+
+		"u-2,bob@example.com,\"Bob, Jr.\",user,2026-01-02T03:04:05Z,2026-01-02T03:04:05Z\n"+
+		"u-1,ann@example.com,Ann,admin,2026-01-02T03:04:05Z,2026-01-02T03:04:05Z\n", w.Body.String())
+
+	w = serveTransfer(handler, http.MethodGet, "/admin/users/export?format=ndjson", "", "")
+
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.NotContains(t, w.Body.String(), "secret-hash")
+	assert.NotContains(t, w.Body.String(), "tenant_id")
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "bob@example.com", first["email"])
+
+	w = serveTransfer(handler, http.MethodGet, "/admin/users/export?timezone=Europe/Berlin", "", "")
+	assert.Contains(t, w.Body.String(), "u-1,ann@example.com,Ann,admin,2026-01-02T04:04:05+01:00,2026-01-02T04:04:05+01:00\n")
+}
+
+func TestUserTransferHandler_ExportUsers_Formulas(t *testing.T) {
+	svc := &stubTransferService{pages: [][]*user.User{{
+		{ID: "u-1", Email: "ann@example.com", Name: `=HYPERLINK("https://evil.example","Ann")`, Role: user.RoleUser},
+		{ID: "u-2", Email: "+1@example.com", Name: "-Bob", Role: user.RoleUser},
+		{ID: "u-3", Email: "@cy@example.com", Name: "\tCy", Role: user.RoleUser},
+	}}}
+	handler := NewUserTransferHandler(svc, 100, 1024)
+
+	w := serveTransfer(handler, http.MethodGet, "/admin/users/export", "", "")
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, `'=HYPERLINK("https://evil.example","Ann")`, rows[1][2])
+	assert.Equal(t, []string{"'+1@example.com", "'-Bob"}, rows[2][1:3])
+	assert.Equal(t, []string{"'@cy@example.com", "'\tCy"}, rows[3][1:3])
+}
+
+func TestUserTransferHandler_ExportUsers_Errors(t *testing.T) {
+	handler := NewUserTransferHandler(&stubTransferService{}, 100, 1024)
+	w := serveTransfer(handler, http.MethodGet, "/admin/users/export?format=xml", "", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serveTransfer(handler, http.MethodGet, "/admin/users/export?timezone=Mars/Olympus", "", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	svc := &stubTransferService{err: errors.NewDatabaseError("list", "users", assert.AnError, true)}
+	handler = NewUserTransferHandler(svc, 100, 1024)
+	w = serveTransfer(handler, http.MethodGet, "/admin/users/export", "", "")
+	assert.GreaterOrEqual(t, w.Code, http.StatusInternalServerError, "a failure before streaming starts is reported as an envelope")
+}
+
+func TestUserTransferHandler_ImportUsers(t *testing.T) {
+	svc := &stubTransferService{report: &user.ImportReport{Total: 2, Created: 2, Errors: []user.ImportRowError{}}}
+	handler := NewUserTransferHandler(svc, 100, 1024)
+
+	csvBody := "\ufeffName,Email,Password,Notes\nAlice,alice@example.com,secret1,x\nBob,bob@example.com, spaced \n"
+	w := serveTransfer(handler, http.MethodPost, "/admin/users/import", "text/csv", csvBody)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []user.ImportRow{
+		{Row: 1, Email: "alice@example.com", Name: "Alice", Password: "secret1"},
+		{Row: 2, Email: "bob@example.com", Name: "Bob", Password: " spaced "},
+	}, svc.rows)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, float64(2), body["data"].(map[string]interface{})["created"])
+
+	ndjson := `{"email":"alice@example.com","name":"Alice","password":"secret1"}` + "\n\n" +
+		`{"email":"bob@example.com","name":"Bob","password":"secret2"}` + "\n"
+	w = serveTransfer(handler, http.MethodPost, "/admin/users/import", "application/x-ndjson", ndjson)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, svc.rows, 2)
+	assert.Equal(t, 3, svc.rows[1].Row, "row numbers follow line numbers")
+}
+
+func TestUserTransferHandler_ImportUsers_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		field       string
+	}{
+		{"missing columns", "/admin/users/import", "text/csv", "email,name\na@example.com,A\n", "header"},
+		{"empty body", "/admin/users/import", "text/csv", "", "body"},
+		{"malformed csv", "/admin/users/import", "text/csv", "email,name,password\n\"a@example.com,A,x\n", "body"},
+		{"malformed ndjson", "/admin/users/import?format=ndjson", "", "{\"email\":\n", "body"},
+		{"too many rows", "/admin/users/import", "text/csv", "email,name,password\na,b,c\nd,e,f\ng,h,i\n", "body"},
+		{"body too large", "/admin/users/import", "text/csv", "email,name,password\n" + strings.Repeat("x", 2048), "body"},
+		{"unknown format", "/admin/users/import?format=xml", "", "<users/>", "format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stubTransferService{}
+			handler := NewUserTransferHandler(svc, 2, 1024)
+
+			w := serveTransfer(handler, http.MethodPost, tt.target, tt.contentType, tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Nil(t, svc.rows, "nothing is imported")
+			var body struct {
+				Error struct {
+					Code    string `json:"code"`
+					Details struct {
+						Fields []errors.FieldError `json:"fields"`
+					} `json:"details"`
+				} `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, string(errors.CodeValidationError), body.Error.Code)
+			require.NotEmpty(t, body.Error.Details.Fields)
+			assert.Equal(t, tt.field, body.Error.Details.Fields[0].Field)
+		})
+	}
+}
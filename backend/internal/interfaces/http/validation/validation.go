@@ -0,0 +1,334 @@
+// Package validation binds request bodies and query strings and reports
+// every invalid field at once as an errors.FieldValidationError, so clients
+// get one structured 400 listing what to fix instead of Gin's raw binding
+// message.
+package validation
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/jsoncodec"
+)
+
+// BindJSON decodes the JSON body into obj, a pointer to a struct, and
+// validates its binding tags. Field names in the result follow json tags.
+// The body is decoded with the engine jsoncodec selects.
+func BindJSON(c *gin.Context, obj interface{}) error {
+	err := decodeJSON(c.Request, obj)
+	if err == nil && binding.Validator != nil {
+		err = binding.Validator.ValidateStruct(obj)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var sizeErr *http.MaxBytesError
+	switch {
+	case stderrors.As(err, &sizeErr):
+		return errors.NewHTTPError(http.StatusRequestEntityTooLarge, errors.CodePayloadTooLarge,
+			fmt.Sprintf("Request body must be at most %d bytes", sizeErr.Limit),
+			map[string]interface{}{"max_bytes": sizeErr.Limit}, "")
+	case stderrors.Is(err, io.EOF):
+		return errors.NewFieldValidationError(errors.FieldError{
+			Field:   "body",
+			Code:    errors.CodeRequiredField,
+			Message: "request body is required",
+		})
+	case stderrors.As(err, &syntaxErr), stderrors.Is(err, io.ErrUnexpectedEOF):
+		return errors.NewFieldValidationError(errors.FieldError{
+			Field:      "body",
+			Code:       errors.CodeInvalidFormat,
+			Message:    "request body must be valid JSON",
+			Constraint: map[string]interface{}{"format": "json"},
+		})
+	case stderrors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		kind := typeName(typeErr.Type)
+		return errors.NewFieldValidationError(errors.FieldError{
+			Field:      field,
+			Code:       errors.CodeInvalidFormat,
+			Message:    fmt.Sprintf("%s must be of type %s", field, kind),
+			Constraint: map[string]interface{}{"type": kind},
+		})
+	}
+	return translate(obj, err, nil, nil)
+}
+
+// decodeJSON decodes the request body into obj as binding.JSON does
+func decodeJSON(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return fmt.Errorf("invalid request")
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return jsoncodec.Decode(data, obj)
+}
+
+// BindQuery fills obj, a pointer to a struct, from query parameters named
+// by form tags and validates its binding tags. Fields missing from the
+// query keep their current value, so callers can preset defaults.
+// Supported field kinds are string, bool, integers, time.Time (RFC 3339)
+// and []string, which collects repeated parameters and splits each on
+// commas.
+func BindQuery(c *gin.Context, obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("validation: BindQuery needs a pointer to a struct, got %T", obj)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	var fields []errors.FieldError
+	failed := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		name := tagName(t.Field(i), "form")
+		if name == "" || name == "-" {
+			continue
+		}
+		values, ok := c.GetQueryArray(name)
+		if !ok {
+			continue
+		}
+		raw := values[len(values)-1]
+
+		f := v.Field(i)
+		if f.Type() == timeType {
+			ts, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				fields = append(fields, errors.FieldError{
+					Field:      name,
+					Code:       errors.CodeInvalidFormat,
+					Message:    fmt.Sprintf("%s must be an RFC 3339 timestamp", name),
+					Constraint: map[string]interface{}{"format": "rfc3339"},
+				})
+				failed[name] = true
+				continue
+			}
+			f.Set(reflect.ValueOf(ts))
+			continue
+		}
+
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(raw)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(raw, 10, f.Type().Bits())
+			if err != nil {
+				fields = append(fields, errors.FieldError{
+					Field:      name,
+					Code:       errors.CodeInvalidFormat,
+					Message:    fmt.Sprintf("%s must be an integer", name),
+					Constraint: map[string]interface{}{"type": "integer"},
+				})
+				failed[name] = true
+				continue
+			}
+			f.SetInt(n)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				fields = append(fields, errors.FieldError{
+					Field:      name,
+					Code:       errors.CodeInvalidFormat,
+					Message:    fmt.Sprintf("%s must be true or false", name),
+					Constraint: map[string]interface{}{"type": "boolean"},
+				})
+				failed[name] = true
+				continue
+			}
+			f.SetBool(b)
+		case reflect.Slice:
+			if f.Type().Elem().Kind() != reflect.String {
+				return fmt.Errorf("validation: unsupported query field %s of type %s", t.Field(i).Name, f.Type())
+			}
+			var items []string
+			for _, value := range values {
+				for _, item := range strings.Split(value, ",") {
+					if item = strings.TrimSpace(item); item != "" {
+						items = append(items, item)
+					}
+				}
+			}
+			f.Set(reflect.ValueOf(items).Convert(f.Type()))
+		default:
+			return fmt.Errorf("validation: unsupported query field %s of kind %s", t.Field(i).Name, f.Kind())
+		}
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return translate(obj, err, fields, failed)
+	}
+	if len(fields) > 0 {
+		return errors.NewFieldValidationError(fields...)
+	}
+	return nil
+}
+
+// ReadBody reads the raw request body for handlers that decode it
+// themselves. A missing body or one over the size limit is reported like
+// BindJSON reports it.
+func ReadBody(c *gin.Context) ([]byte, error) {
+	body, err := c.GetRawData()
+	var sizeErr *http.MaxBytesError
+	switch {
+	case stderrors.As(err, &sizeErr):
+		return nil, errors.NewHTTPError(http.StatusRequestEntityTooLarge, errors.CodePayloadTooLarge,
+			fmt.Sprintf("Request body must be at most %d bytes", sizeErr.Limit),
+			map[string]interface{}{"max_bytes": sizeErr.Limit}, "")
+	case err != nil:
+		return nil, err
+	case len(body) == 0:
+		return nil, errors.NewFieldValidationError(errors.FieldError{
+			Field:   "body",
+			Code:    errors.CodeRequiredField,
+			Message: "request body is required",
+		})
+	}
+	return body, nil
+}
+
+// Validate checks the binding tags of obj, a struct a handler filled
+// itself, and reports every invalid field like BindJSON
+func Validate(obj interface{}) error {
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return translate(obj, err, nil, nil)
+	}
+	return nil
+}
+
+// translate converts validator errors into field errors, appending them to
+// fields. Fields that already failed to parse are skipped.
+func translate(obj interface{}, err error, fields []errors.FieldError, skip map[string]bool) error {
+	var verrs validator.ValidationErrors
+	if !stderrors.As(err, &verrs) {
+		return err
+	}
+
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, fe := range verrs {
+		name := fe.Field()
+		// Elements of a slice are reported as Field[i]
+		structField, index, _ := strings.Cut(fe.StructField(), "[")
+		if sf, ok := t.FieldByName(structField); ok {
+			if tagged := tagName(sf, "json"); tagged != "" && tagged != "-" {
+				name = tagged
+			} else if tagged := tagName(sf, "form"); tagged != "" && tagged != "-" {
+				name = tagged
+			}
+			if index != "" {
+				name += "[" + index
+			}
+		}
+		if skip[name] {
+			continue
+		}
+		fields = append(fields, fieldError(name, fe))
+	}
+	return errors.NewFieldValidationError(fields...)
+}
+
+// fieldError describes one failed validator tag
+func fieldError(name string, fe validator.FieldError) errors.FieldError {
+	param := fe.Param()
+	var bound interface{} = param
+	if n, err := strconv.Atoi(param); err == nil {
+		bound = n
+	}
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return errors.FieldError{Field: name, Code: errors.CodeRequiredField, Message: fmt.Sprintf("%s is required", name)}
+	case "email":
+		return errors.FieldError{
+			Field:      name,
+			Code:       errors.CodeInvalidFormat,
+			Message:    fmt.Sprintf("%s must be a valid email address", name),
+			Constraint: map[string]interface{}{"format": "email"},
+		}
+	case "min", "gte":
+		msg := fmt.Sprintf("%s must be at least %s", name, param)
+		if isString {
+			msg += " characters"
+		}
+		return errors.FieldError{Field: name, Code: errors.CodeOutOfRange, Message: msg, Constraint: map[string]interface{}{"min": bound}}
+	case "max", "lte":
+		msg := fmt.Sprintf("%s must be at most %s", name, param)
+		if isString {
+			msg += " characters"
+		}
+		return errors.FieldError{Field: name, Code: errors.CodeOutOfRange, Message: msg, Constraint: map[string]interface{}{"max": bound}}
+	case "len":
+		msg := fmt.Sprintf("%s must be exactly %s", name, param)
+		if isString {
+			msg += " characters"
+		}
+		return errors.FieldError{Field: name, Code: errors.CodeOutOfRange, Message: msg, Constraint: map[string]interface{}{"len": bound}}
+	case "oneof":
+		return errors.FieldError{
+			Field:      name,
+			Code:       errors.CodeInvalidValue,
+			Message:    fmt.Sprintf("%s must be one of: %s", name, strings.Join(strings.Fields(param), ", ")),
+			Constraint: map[string]interface{}{"oneof": strings.Fields(param)},
+		}
+	default:
+		constraint := map[string]interface{}{"rule": fe.Tag()}
+		if param != "" {
+			constraint[fe.Tag()] = bound
+		}
+		return errors.FieldError{
+			Field:      name,
+			Code:       errors.CodeInvalidValue,
+			Message:    fmt.Sprintf("%s failed the %s check", name, fe.Tag()),
+			Constraint: constraint,
+		}
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func tagName(f reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+	return name
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
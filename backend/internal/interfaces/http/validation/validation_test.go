@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type signupRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Name     string `json:"name" binding:"required,min=2,max=50"`
+	Password string `json:"password" binding:"required,min=6"`
+	Plan     string `json:"plan" binding:"omitempty,oneof=free pro"`
+}
+
+type pageQuery struct {
+	Page     int    `form:"page" binding:"min=1"`
+	PageSize int    `form:"page_size" binding:"min=1,max=100"`
+	Name     string `form:"name" binding:"max=10"`
+}
+
+func testContext(method, target, body string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return c
+}
+
+func fieldsOf(t *testing.T, err error) map[string]errors.FieldError {
+	t.Helper()
+	var verr *errors.FieldValidationError
+	require.ErrorAs(t, err, &verr)
+	byName := make(map[string]errors.FieldError, len(verr.Fields))
+	for _, f := range verr.Fields {
+		byName[f.Field] = f
+	}
+	return byName
+}
+
+func TestBindJSON_ReportsEveryField(t *testing.T) {
+	c := testContext(http.MethodPost, "/", `{"email":"not-an-email","name":"A","plan":"gold"}`)
+
+	var req signupRequest
+	fields := fieldsOf(t, BindJSON(c, &req))
+
+	require.Len(t, fields, 4)
+	assert.Equal(t, errors.CodeInvalidFormat, fields["email"].Code)
+	assert.Equal(t, map[string]interface{}{"format": "email"}, fields["email"].Constraint)
+	assert.Equal(t, errors.CodeOutOfRange, fields["name"].Code)
+	assert.Equal(t, "name must be at least 2 characters", fields["name"].Message)
+	assert.Equal(t, map[string]interface{}{"min": 2}, fields["name"].Constraint)
+	assert.Equal(t, errors.CodeRequiredField, fields["password"].Code)
+	assert.Equal(t, errors.CodeInvalidValue, fields["plan"].Code)
+	assert.Equal(t, []string{"free", "pro"}, fields["plan"].Constraint["oneof"])
+}
+
+func TestBindJSON_DecodeErrors(t *testing.T) {
+	var req signupRequest
+
+	fields := fieldsOf(t, BindJSON(testContext(http.MethodPost, "/", `{"email":`), &req))
+	assert.Equal(t, errors.CodeInvalidFormat, fields["body"].Code)
+
+	fields = fieldsOf(t, BindJSON(testContext(http.MethodPost, "/", `{"name":42}`), &req))
+	assert.Equal(t, "name must be of type string", fields["name"].Message)
+
+	fields = fieldsOf(t, BindJSON(testContext(http.MethodPost, "/", ``), &req))
+	assert.Equal(t, errors.CodeRequiredField, fields["body"].Code)
+
+	c := testContext(http.MethodPost, "/", `{"email":"a@example.com","name":"Alice"}`)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 8)
+	var httpErr *errors.HTTPError
+	require.ErrorAs(t, BindJSON(c, &req), &httpErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.StatusCode)
+	assert.Equal(t, errors.CodePayloadTooLarge, httpErr.ErrorCode)
+}
+
+func TestBindJSON_Valid(t *testing.T) {
+	c := testContext(http.MethodPost, "/", `{"email":"a@example.com","name":"Alice","password":"secret123"}`)
+
+	var req signupRequest
+	require.NoError(t, BindJSON(c, &req))
+	assert.Equal(t, "Alice", req.Name)
+}
+
+func TestBindQuery(t *testing.T) {
+	q := pageQuery{Page: 1, PageSize: 10}
+	require.NoError(t, BindQuery(testContext(http.MethodGet, "/?name=bob", ""), &q))
+	assert.Equal(t, pageQuery{Page: 1, PageSize: 10, Name: "bob"}, q, "missing parameters keep their defaults")
+
+	q = pageQuery{Page: 1, PageSize: 10}
+	fields := fieldsOf(t, BindQuery(testContext(http.MethodGet, "/?page=abc&page_size=500&name=averyverylongname", ""), &q))
+
+	require.Len(t, fields, 3, "a parse failure is reported once, not again as a range error")
+	assert.Equal(t, errors.CodeInvalidFormat, fields["page"].Code)
+	assert.Equal(t, map[string]interface{}{"max": 100}, fields["page_size"].Constraint)
+	assert.Equal(t, "name must be at most 10 characters", fields["name"].Message)
+}
+
+type filterQuery struct {
+	Since time.Time `form:"since"`
+	Tags  []string  `form:"tag" binding:"max=3,dive,oneof=a b c"`
+}
+
+func TestBindQuery_TimesAndLists(t *testing.T) {
+	var q filterQuery
+	require.NoError(t, BindQuery(testContext(http.MethodGet, "/?since=2026-03-01T10:00:00Z&tag=a,b&tag=c", ""), &q))
+	assert.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), q.Since)
+	assert.Equal(t, []string{"a", "b", "c"}, q.Tags, "repeated and comma-separated values are combined")
+
+	q = filterQuery{}
+	fields := fieldsOf(t, BindQuery(testContext(http.MethodGet, "/?since=yesterday&tag=a,x", ""), &q))
+
+	require.Len(t, fields, 2)
+	assert.Equal(t, map[string]interface{}{"format": "rfc3339"}, fields["since"].Constraint)
+	assert.Equal(t, errors.CodeInvalidValue, fields["tag[1]"].Code)
+}
+
+type patchBody struct {
+	Name  *string `json:"name" binding:"omitempty,min=2"`
+	Email *string `json:"email" binding:"omitempty,email"`
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(&patchBody{}), "nil pointers are not validated")
+
+	short, email := "a", "not-an-email"
+	fields := fieldsOf(t, Validate(&patchBody{Name: &short, Email: &email}))
+	require.Len(t, fields, 2)
+	assert.Equal(t, errors.CodeOutOfRange, fields["name"].Code)
+	assert.Equal(t, errors.CodeInvalidFormat, fields["email"].Code)
+}
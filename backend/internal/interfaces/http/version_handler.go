@@ -0,0 +1,22 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/pkg/buildinfo"
+)
+
+// VersionHandler reports the build of the running binary
+type VersionHandler struct {
+	info buildinfo.Info
+}
+
+func NewVersionHandler() *VersionHandler {
+	return &VersionHandler{info: buildinfo.Get()}
+}
+
+// GetVersion returns the version, commit, build time and Go version
+func (h *VersionHandler) GetVersion(c *gin.Context) {
+	response.OK(c, h.info)
+}
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/buildinfo"
+)
+
+func TestVersionHandler_GetVersion(t *testing.T) {
+	router := setupGinTest()
+	router.GET("/api/v1/version", NewVersionHandler().GetVersion)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data buildinfo.Info `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, buildinfo.Version, resp.Data.Version)
+	assert.Equal(t, runtime.Version(), resp.Data.GoVersion)
+	assert.NotEmpty(t, resp.Data.GitCommit)
+}
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/webhook"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/validation"
+	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=2048"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=user.created user.updated user.deleted"`
+	Secret      string   `json:"secret,omitempty" binding:"omitempty,min=16,max=255"`
+	Description string   `json:"description,omitempty" binding:"max=255"`
+}
+
+// CreatedWebhookResponse is a new endpoint with its signing secret, which
+// is not shown again
+type CreatedWebhookResponse struct {
+	*webhook.Endpoint
+	Secret string `json:"secret"`
+}
+
+// webhookDeliveriesQuery pages through an endpoint's delivery log
+type webhookDeliveriesQuery struct {
+	Page     int `form:"page" binding:"min=1"`
+	PageSize int `form:"page_size" binding:"min=1,max=100"`
+}
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+	errorMapper    *errors.ErrorMapper
+	errorLogger    errors.ErrorLogger
+}
+
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		errorMapper:    errors.NewErrorMapper(),
+		errorLogger:    errors.NewDefaultErrorLogger("webhook-service"),
+	}
+}
+
+// CreateWebhook registers an endpoint for user events
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	var req CreateWebhookRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(c.Request.Context(), &service.CreateWebhookRequest{
+		URL:         req.URL,
+		Events:      req.Events,
+		Secret:      req.Secret,
+		Description: req.Description,
+	})
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation": "create_webhook",
+			"url":       req.URL,
+		})
+		return
+	}
+
+	response.Created(c, &CreatedWebhookResponse{Endpoint: endpoint, Secret: endpoint.Secret})
+}
+
+// ListWebhooks lists every endpoint
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	endpoints, err := h.webhookService.ListEndpoints(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{"operation": "list_webhooks"})
+		return
+	}
+
+	response.OK(c, endpoints)
+}
+
+// GetWebhook returns an endpoint without its secret
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	endpoint, err := h.webhookService.GetEndpoint(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation":   "get_webhook",
+			"endpoint_id": c.Param("id"),
+		})
+		return
+	}
+
+	response.OK(c, endpoint)
+}
+
+// DeleteWebhook removes an endpoint and its delivery log
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	if err := h.webhookService.DeleteEndpoint(c.Request.Context(), c.Param("id")); err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation":   "delete_webhook",
+			"endpoint_id": c.Param("id"),
+		})
+		return
+	}
+
+	response.Message(c, "Webhook deleted successfully")
+}
+
+// ListDeliveries lists an endpoint's deliveries, newest first
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	query := &webhookDeliveriesQuery{Page: 1, PageSize: 20}
+	if err := validation.BindQuery(c, query); err != nil {
+		response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+		return
+	}
+
+	result, err := h.webhookService.ListDeliveries(c.Request.Context(), c.Param("id"), query.Page, query.PageSize)
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation":   "list_webhook_deliveries",
+			"endpoint_id": c.Param("id"),
+		})
+		return
+	}
+
+	response.Page(c, result.Deliveries, &response.Meta{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		Total:      result.Total,
+		TotalPages: result.TotalPages,
+		HasMore:    result.Page < result.TotalPages,
+	})
+}
+
+// Redeliver queues the event of a delivery to be sent again
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	traceID := middleware.GetTraceIDFromContext(c.Request.Context())
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		h.fail(c, err, traceID, map[string]interface{}{
+			"operation":   "redeliver_webhook",
+			"endpoint_id": c.Param("id"),
+			"delivery_id": c.Param("delivery_id"),
+		})
+		return
+	}
+
+	response.JSON(c, http.StatusAccepted, delivery, nil)
+}
+
+func (h *WebhookHandler) fail(c *gin.Context, err error, traceID string, fields map[string]interface{}) {
+	h.errorLogger.LogError(c.Request.Context(), err, traceID, fields)
+	response.Error(c, h.errorMapper.MapToHTTPError(err, traceID))
+}
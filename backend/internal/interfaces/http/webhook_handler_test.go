@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/webhook"
+	webhookMocks "github.com/cctw-zed/wonder/internal/domain/webhook/mocks"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
+)
+
+func serveWebhooks(handler *WebhookHandler, method, path, body string) *httptest.ResponseRecorder {
+	router := setupGinTest()
+	router.POST("/admin/webhooks", handler.CreateWebhook)
+	router.GET("/admin/webhooks", handler.ListWebhooks)
+	router.GET("/admin/webhooks/:id", handler.GetWebhook)
+	router.DELETE("/admin/webhooks/:id", handler.DeleteWebhook)
+	router.GET("/admin/webhooks/:id/deliveries", handler.ListDeliveries)
+	router.POST("/admin/webhooks/:id/deliveries/:delivery_id/redeliver", handler.Redeliver)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func newTestWebhookHandler(t *testing.T) (*WebhookHandler, *webhookMocks.MockRepository, *idMocks.MockGenerator) {
+	logger.Initialize()
+	ctrl := gomock.NewController(t)
+	repo := webhookMocks.NewMockRepository(ctrl)
+	idGen := idMocks.NewMockGenerator(ctrl)
+	return NewWebhookHandler(service.NewWebhookService(repo, idGen)), repo, idGen
+}
+
+func TestWebhookHandler_CreateWebhook(t *testing.T) {
+	handler, repo, idGen := newTestWebhookHandler(t)
+	idGen.EXPECT().Generate().Return("wh-1")
+	repo.EXPECT().CreateEndpoint(gomock.Any(), gomock.Any()).Return(nil)
+
+	w := serveWebhooks(handler, http.MethodPost, "/admin/webhooks",
+		`{"url":"https://hooks.example.com/wonder","events":["user.created"]}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var body struct {
+		Data struct {
+			ID     string   `json:"id"`
+			Events []string `json:"events"`
+			Secret string   `json:"secret"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "wh-1", body.Data.ID)
+	assert.Equal(t, []string{webhook.EventUserCreated}, body.Data.Events)
+	assert.NotEmpty(t, body.Data.Secret, "the secret is returned once on creation")
+
+	w = serveWebhooks(handler, http.MethodPost, "/admin/webhooks",
+		`{"url":"https://hooks.example.com/wonder","events":["user.exploded"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWebhookHandler_GetWebhookHidesSecret(t *testing.T) {
+	handler, repo, _ := newTestWebhookHandler(t)
+	repo.EXPECT().GetEndpoint(gomock.Any(), "wh-1").Return(&webhook.Endpoint{ID: "wh-1", Secret: "0123456789abcdef"}, nil)
+	repo.EXPECT().GetEndpoint(gomock.Any(), "wh-2").Return(nil, nil)
+
+	w := serveWebhooks(handler, http.MethodGet, "/admin/webhooks/wh-1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "0123456789abcdef")
+
+	w = serveWebhooks(handler, http.MethodGet, "/admin/webhooks/wh-2", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestWebhookHandler_Deliveries(t *testing.T) {
+	handler, repo, idGen := newTestWebhookHandler(t)
+	delivery := &webhook.Delivery{ID: "d-1", EndpointID: "wh-1", EventType: webhook.EventUserCreated, EventID: "evt-1", Status: webhook.StatusFailed}
+	repo.EXPECT().GetEndpoint(gomock.Any(), "wh-1").Return(&webhook.Endpoint{ID: "wh-1"}, nil)
+	repo.EXPECT().ListDeliveries(gomock.Any(), "wh-1", 0, 10).Return([]*webhook.Delivery{delivery}, int64(1), nil)
+
+	w := serveWebhooks(handler, http.MethodGet, "/admin/webhooks/wh-1/deliveries?page_size=10", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data []webhook.Delivery `json:"data"`
+		Meta struct {
+			Total int64 `json:"total"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(1), body.Meta.Total)
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, "d-1", body.Data[0].ID)
+
+	repo.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(delivery, nil)
+	idGen.EXPECT().Generate().Return("d-2")
+	repo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Len(1)).Return(nil)
+
+	w = serveWebhooks(handler, http.MethodPost, "/admin/webhooks/wh-1/deliveries/d-1/redeliver", "")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"redelivery_of":"d-1"`)
+}
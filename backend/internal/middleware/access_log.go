@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// AccessLogMiddleware logs one entry per request with the route template
+// rather than the raw path, using the request-scoped logger so the entry
+// carries the trace, tenant and user of the request
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		ctx := c.Request.Context()
+		status := c.Writer.Status()
+		fields := []interface{}{
+			logger.String("method", c.Request.Method),
+			logger.String("route", routeTemplate(c)),
+			logger.Int("status", status),
+			logger.Dur("duration", time.Since(start)),
+			logger.Int64("request_bytes", max(c.Request.ContentLength, 0)),
+			logger.Int("response_bytes", max(c.Writer.Size(), 0)),
+		}
+
+		log := logger.FromContext(ctx)
+		if status >= 500 {
+			log.Warn(ctx, "request completed", fields...)
+			return
+		}
+		log.Info(ctx, "request completed", fields...)
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	log := logger.NewLoggerWithConfig(logger.LogConfig{Level: "info", Format: "json", Output: "file", FilePath: path})
+
+	router := gin.New()
+	router.Use(TraceIDMiddleware(), func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), log))
+		c.Next()
+	}, AccessLogMiddleware())
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/12345", nil)
+	req.Header.Set(TraceIDHeader, "trace-123")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &entry))
+
+	assert.Equal(t, "request completed", entry["message"])
+	assert.Equal(t, "/users/:id", entry["route"])
+	assert.NotContains(t, string(data), "12345")
+	assert.EqualValues(t, http.StatusOK, entry["status"])
+	assert.EqualValues(t, 2, entry["response_bytes"])
+	assert.Equal(t, "trace-123", entry["trace_id"])
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/cctw-zed/wonder/internal/application/service"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
 	"github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/jwt"
 )
@@ -105,6 +107,19 @@ func (m *AuthMiddleware) validateTokenFromRequest(c *gin.Context) (*jwt.Claims,
 		return nil, err // Auth service already returns proper error types
 	}
 
+	// A token is only good for the tenant it was issued in
+	tokenTenant := claims.TenantID
+	if tokenTenant == "" {
+		tokenTenant = tenant.DefaultID
+	}
+	if tokenTenant != tenant.IDFromContext(c.Request.Context()) {
+		return nil, errors.NewUnauthorizedError(
+			"auth_middleware",
+			"tenant_mismatch",
+			"token was not issued for this tenant",
+		)
+	}
+
 	return claims, nil
 }
 
@@ -112,6 +127,7 @@ func (m *AuthMiddleware) validateTokenFromRequest(c *gin.Context) (*jwt.Claims,
 func (m *AuthMiddleware) injectUserContext(c *gin.Context, userID string) {
 	// Inject user ID into request context
 	ctx := context.WithValue(c.Request.Context(), UserIDKey, userID)
+	ctx = updateRequestContext(ctx, func(rc *RequestContext) { rc.UserID = userID })
 	c.Request = c.Request.WithContext(ctx)
 
 	// Inject user ID into request headers for easy access in handlers
@@ -126,7 +142,7 @@ func (m *AuthMiddleware) handleAuthError(c *gin.Context, err error) {
 	errorMapper := errors.NewErrorMapper()
 	httpErr := errorMapper.MapToHTTPError(err, traceID)
 
-	c.JSON(httpErr.StatusCode, httpErr)
+	response.Error(c, httpErr)
 }
 
 // GetUserIDFromContext extracts user ID from context
@@ -13,6 +13,7 @@ import (
 	"go.uber.org/mock/gomock"
 
 	serviceMocks "github.com/cctw-zed/wonder/internal/application/service/mocks"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
 	"github.com/cctw-zed/wonder/pkg/errors"
 	"github.com/cctw-zed/wonder/pkg/jwt"
 )
@@ -42,6 +43,18 @@ func createTestRouter(middleware gin.HandlerFunc) *gin.Engine {
 	return router
 }
 
+// decodeErrorEnvelope extracts the error from a response envelope
+func decodeErrorEnvelope(t *testing.T, w *httptest.ResponseRecorder) errors.HTTPError {
+	t.Helper()
+	var body struct {
+		Error   *errors.HTTPError `json:"error"`
+		TraceID string            `json:"trace_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NotNil(t, body.Error, "expected error envelope: %s", w.Body.String())
+	return *body.Error
+}
+
 func TestNewAuthMiddleware(t *testing.T) {
 	t.Run("should create middleware with valid auth service", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -115,9 +128,7 @@ func TestRequireAuth_MissingToken(t *testing.T) {
 	// Assertions
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
-	var response errors.HTTPError
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	require.NoError(t, err)
+	response := decodeErrorEnvelope(t, w)
 
 	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
 	assert.Equal(t, "UNAUTHORIZED", string(response.Code()))
@@ -166,9 +177,7 @@ func TestRequireAuth_InvalidTokenFormat(t *testing.T) {
 			// Assertions
 			assert.Equal(t, http.StatusUnauthorized, w.Code)
 
-			var response errors.HTTPError
-			err := json.Unmarshal(w.Body.Bytes(), &response)
-			require.NoError(t, err)
+			response := decodeErrorEnvelope(t, w)
 
 			assert.Equal(t, "UNAUTHORIZED", string(response.Code()))
 			assert.Equal(t, "Unauthorized access", response.Message)
@@ -200,14 +209,40 @@ func TestRequireAuth_TokenValidationFailure(t *testing.T) {
 	// Assertions
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
-	var response errors.HTTPError
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	require.NoError(t, err)
+	response := decodeErrorEnvelope(t, w)
 
 	assert.Equal(t, "UNAUTHORIZED", string(response.Code()))
 	assert.Equal(t, "Unauthorized access", response.Message)
 }
 
+func TestRequireAuth_TenantMismatch(t *testing.T) {
+	middleware, mockAuthService, ctrl := setupAuthMiddlewareTest(t)
+	defer ctrl.Finish()
+
+	mockAuthService.EXPECT().
+		ValidateToken(gomock.Any(), "acme-token").
+		Return(&jwt.Claims{UserID: "user123", TenantID: "acme"}, nil).
+		Times(2)
+
+	// Without tenant middleware every request belongs to the default tenant
+	router := createTestRouter(middleware.RequireAuth())
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer acme-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Scoped to the token's tenant the request goes through
+	router = gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), "acme"))
+	}, middleware.RequireAuth())
+	router.GET("/protected", func(c *gin.Context) { c.Status(http.StatusOK) })
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestOptionalAuth_WithValidToken(t *testing.T) {
 	middleware, mockAuthService, ctrl := setupAuthMiddlewareTest(t)
 	defer ctrl.Finish()
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// RequireAdmin creates middleware that only lets administrators through.
+// It must run after RequireAuth. The role is read from the user record on
+// every request so demotions take effect immediately.
+// Returns 403 Forbidden if the authenticated user is not an admin
+func RequireAdmin(users user.UserService) gin.HandlerFunc {
+	if users == nil {
+		panic("user service cannot be nil")
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		traceID := GetTraceIDFromContext(ctx)
+		errorMapper := errors.NewErrorMapper()
+
+		userID := GetUserIDFromContext(ctx)
+		if userID == "" {
+			httpErr := errorMapper.MapToHTTPError(errors.NewUnauthorizedError("admin_middleware", "", "authentication required"), traceID)
+			response.Abort(c, httpErr)
+			return
+		}
+
+		u, err := users.GetProfile(ctx, userID)
+		if err != nil {
+			httpErr := errorMapper.MapToHTTPError(err, traceID)
+			response.Abort(c, httpErr)
+			return
+		}
+		if !u.IsAdmin() {
+			httpErr := errorMapper.MapToHTTPError(errors.NewInsufficientRoleError("admin_middleware", userID, user.RoleAdmin), traceID)
+			response.Abort(c, httpErr)
+			return
+		}
+
+		c.Next()
+	}
+}
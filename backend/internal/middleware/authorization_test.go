@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	userMocks "github.com/cctw-zed/wonder/internal/domain/user/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func createAdminRouter(users user.UserService, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TraceIDMiddleware())
+	router.Use(func(c *gin.Context) {
+		if userID != "" {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), UserIDKey, userID))
+		}
+		c.Next()
+	})
+	router.GET("/admin", RequireAdmin(users), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+	return router
+}
+
+func TestRequireAdmin(t *testing.T) {
+	t.Run("should allow admins", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := userMocks.NewMockUserService(ctrl)
+		users.EXPECT().GetProfile(gomock.Any(), "admin-1").Return(&user.User{ID: "admin-1", Role: user.RoleAdmin}, nil)
+
+		w := httptest.NewRecorder()
+		createAdminRouter(users, "admin-1").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should reject regular users with 403", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := userMocks.NewMockUserService(ctrl)
+		users.EXPECT().GetProfile(gomock.Any(), "user-1").Return(&user.User{ID: "user-1", Role: user.RoleUser}, nil)
+
+		w := httptest.NewRecorder()
+		createAdminRouter(users, "user-1").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, errors.CodeInsufficientRole, decodeErrorEnvelope(t, w).ErrorCode)
+	})
+
+	t.Run("should reject unauthenticated requests with 401", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		users := userMocks.NewMockUserService(ctrl)
+
+		w := httptest.NewRecorder()
+		createAdminRouter(users, "").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should panic with nil user service", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RequireAdmin(nil)
+		})
+	})
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIPMiddleware resolves the client IP once and stores it in the
+// request context under ClientIPKey, where rate limiting, audit entries
+// and session records read it. X-Forwarded-For and X-Real-IP name the
+// client only when the peer is one of the engine's trusted proxies (see
+// gin.Engine.SetTrustedProxies); otherwise the peer address is the client.
+func ClientIPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		c.Set(ClientIPKey, ip)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ClientIPKey, ip))
+		c.Next()
+	}
+}
+
+// GetClientIPFromContext extracts the client IP resolved by
+// ClientIPMiddleware
+func GetClientIPFromContext(ctx context.Context) string {
+	return stringFromContext(ctx, ClientIPKey)
+}
+
+// clientIP returns the client IP resolved for the request, resolving it
+// when ClientIPMiddleware did not run
+func clientIP(c *gin.Context) string {
+	if ip := GetClientIPFromContext(c.Request.Context()); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIPMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies([]string{"10.0.0.0/8"}))
+	router.Use(ClientIPMiddleware())
+
+	var fromContext, fromGin string
+	router.GET("/test", func(c *gin.Context) {
+		fromContext = GetClientIPFromContext(c.Request.Context())
+		fromGin = c.GetString(ClientIPKey)
+	})
+
+	request := func(remoteAddr string, headers map[string]string) string {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = remoteAddr
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, fromContext, fromGin)
+		return fromContext
+	}
+
+	t.Run("peer address without a proxy", func(t *testing.T) {
+		assert.Equal(t, "192.0.2.10", request("192.0.2.10:51234", nil))
+	})
+
+	t.Run("forwarded address from a trusted proxy", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.7", request("10.1.1.1:4000", map[string]string{"X-Forwarded-For": "203.0.113.7"}))
+		assert.Equal(t, "203.0.113.8", request("10.1.1.1:4000", map[string]string{"X-Real-IP": "203.0.113.8"}))
+	})
+
+	t.Run("proxies in the chain are skipped up to the first untrusted hop", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.7", request("10.1.1.1:4000", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.2.2.2"}))
+	})
+
+	t.Run("forwarding headers from untrusted peers are ignored", func(t *testing.T) {
+		assert.Equal(t, "192.0.2.10", request("192.0.2.10:51234", map[string]string{"X-Forwarded-For": "203.0.113.7"}))
+	})
+}
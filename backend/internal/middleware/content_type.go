@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// sniffLen is how many leading bytes content sniffing looks at
+const sniffLen = 512
+
+// ContentPolicy restricts the request bodies of a route
+type ContentPolicy struct {
+	// MediaTypes are the Content-Type media types accepted; empty accepts
+	// any
+	MediaTypes []string
+	// Multipart limits multipart/form-data bodies; nil leaves them unchecked
+	Multipart *MultipartPolicy
+}
+
+// MultipartPolicy limits the parts of multipart/form-data bodies
+type MultipartPolicy struct {
+	// MaxParts caps the number of parts; zero disables the cap
+	MaxParts int
+	// MaxPartBytes caps the size of each part; zero disables the cap
+	MaxPartBytes int64
+	// FileTypes are the media types file parts may have, detected from
+	// their first bytes rather than trusted from the client; empty accepts
+	// any
+	FileTypes []string
+}
+
+// ContentPolicies holds a default content policy and per-route overrides
+// keyed by "METHOD /path/pattern" as registered with gin
+type ContentPolicies struct {
+	defaults ContentPolicy
+	routes   map[string]ContentPolicy
+}
+
+// NewContentPolicies creates content policies. Routes replace the default
+// for the requests they match.
+func NewContentPolicies(defaults ContentPolicy, routes map[string]ContentPolicy) *ContentPolicies {
+	return &ContentPolicies{defaults: defaults, routes: routes}
+}
+
+// For returns the policy of a route; unmatched routes use the default
+func (p *ContentPolicies) For(method, route string) ContentPolicy {
+	if policy, ok := p.routes[method+" "+route]; ok {
+		return policy
+	}
+	return p.defaults
+}
+
+// Handler checks request bodies against the route's policy before the
+// handler reads them. Bodies must declare an accepted Content-Type, a UTF-8
+// or no charset and no Content-Encoding, since none is decoded; violations
+// answer 415 UNSUPPORTED_MEDIA_TYPE. Multipart bodies with too many or too
+// large parts answer 413 PAYLOAD_TOO_LARGE, and files whose content is not
+// of an allowed type 415. Requests without a body or a matching route pass
+// through. Multipart bodies are buffered to be checked, so BodyLimit must
+// run first.
+func (p *ContentPolicies) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.FullPath() == "" || !hasBody(c.Request) {
+			c.Next()
+			return
+		}
+		policy := p.For(c.Request.Method, c.FullPath())
+
+		mediaType, err := checkContentType(c.Request, policy)
+		if err == nil && mediaType == "multipart/form-data" && policy.Multipart != nil {
+			err = checkMultipart(c.Request, policy.Multipart)
+		}
+		if err != nil {
+			response.Abort(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasBody reports whether r carries a body, declared by its length or by
+// chunked transfer
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && (r.ContentLength > 0 || r.ContentLength == -1)
+}
+
+// checkContentType returns the media type of r if its headers satisfy policy
+func checkContentType(r *http.Request, policy ContentPolicy) (string, *errors.HTTPError) {
+	if encoding := r.Header.Get("Content-Encoding"); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		return "", unsupportedMediaError(fmt.Sprintf("Content-Encoding %s is not supported", encoding),
+			map[string]interface{}{"content_encoding": encoding})
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return "", unsupportedMediaError("Content-Type is required",
+			map[string]interface{}{"supported": policy.MediaTypes})
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", unsupportedMediaError(fmt.Sprintf("Content-Type %q is malformed", contentType),
+			map[string]interface{}{"supported": policy.MediaTypes})
+	}
+	if len(policy.MediaTypes) > 0 && !slices.Contains(policy.MediaTypes, mediaType) {
+		return "", unsupportedMediaError(fmt.Sprintf("Content-Type %s is not supported", mediaType),
+			map[string]interface{}{"supported": policy.MediaTypes})
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+		return "", unsupportedMediaError(fmt.Sprintf("charset %s is not supported", charset),
+			map[string]interface{}{"supported_charsets": []string{"utf-8"}})
+	}
+	return mediaType, nil
+}
+
+// checkMultipart reads the multipart body of r part by part against policy
+// and restores it for the handler
+func checkMultipart(r *http.Request, policy *MultipartPolicy) *errors.HTTPError {
+	_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var buf bytes.Buffer
+	reader := multipart.NewReader(io.TeeReader(r.Body, &buf), params["boundary"])
+
+	err := func() *errors.HTTPError {
+		for parts := 1; ; parts++ {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return malformedMultipartError(err)
+			}
+			if policy.MaxParts > 0 && parts > policy.MaxParts {
+				return errors.NewHTTPError(http.StatusRequestEntityTooLarge, errors.CodePayloadTooLarge,
+					fmt.Sprintf("Multipart body must have at most %d parts", policy.MaxParts),
+					map[string]interface{}{"max_parts": policy.MaxParts}, "")
+			}
+			if encoding := part.Header.Get("Content-Transfer-Encoding"); encoding != "" && !slices.Contains([]string{"7bit", "8bit", "binary"}, strings.ToLower(encoding)) {
+				return unsupportedMediaError(fmt.Sprintf("Content-Transfer-Encoding %s is not supported", encoding),
+					map[string]interface{}{"part": part.FormName()})
+			}
+
+			head := make([]byte, sniffLen)
+			n, err := io.ReadFull(part, head)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return malformedMultipartError(err)
+			}
+			size := int64(n)
+			if n == sniffLen {
+				rest, err := io.Copy(io.Discard, part)
+				if err != nil {
+					return malformedMultipartError(err)
+				}
+				size += rest
+			}
+			if policy.MaxPartBytes > 0 && size > policy.MaxPartBytes {
+				return errors.NewHTTPError(http.StatusRequestEntityTooLarge, errors.CodePayloadTooLarge,
+					fmt.Sprintf("Multipart part %s must be at most %d bytes", part.FormName(), policy.MaxPartBytes),
+					map[string]interface{}{"part": part.FormName(), "max_bytes": policy.MaxPartBytes}, "")
+			}
+			if part.FileName() != "" && len(policy.FileTypes) > 0 {
+				detected, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+				if !slices.Contains(policy.FileTypes, detected) {
+					return unsupportedMediaError(fmt.Sprintf("File %s of type %s is not supported", part.FormName(), detected),
+						map[string]interface{}{"part": part.FormName(), "supported": policy.FileTypes})
+				}
+			}
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	// Anything after the closing boundary is kept, so the handler reads
+	// the body exactly as sent
+	r.Body = io.NopCloser(io.MultiReader(&buf, r.Body))
+	return nil
+}
+
+func unsupportedMediaError(message string, details map[string]interface{}) *errors.HTTPError {
+	return errors.NewHTTPError(http.StatusUnsupportedMediaType, errors.CodeUnsupportedMedia, message, details, "")
+}
+
+// malformedMultipartError maps a failed multipart read: 413 when the body
+// limit cut it off, 400 otherwise
+func malformedMultipartError(err error) *errors.HTTPError {
+	var sizeErr *http.MaxBytesError
+	if stderrors.As(err, &sizeErr) {
+		return PayloadTooLargeError(sizeErr.Limit)
+	}
+	return errors.NewHTTPError(http.StatusBadRequest, errors.CodeInvalidFormat,
+		"Request body is not a valid multipart/form-data message", nil, "")
+}
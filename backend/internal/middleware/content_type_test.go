@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newContentRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewRequestLimits(Limits{MaxBodyBytes: 4096}, nil).BodyLimit())
+	router.Use(NewContentPolicies(ContentPolicy{MediaTypes: []string{"application/json"}}, map[string]ContentPolicy{
+		"POST /import": {MediaTypes: []string{"text/csv"}},
+		"POST /upload": {
+			MediaTypes: []string{"multipart/form-data"},
+			Multipart:  &MultipartPolicy{MaxParts: 2, MaxPartBytes: 1024, FileTypes: []string{"image/png"}},
+		},
+	}).Handler())
+
+	echo := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	}
+	router.POST("/json", echo)
+	router.DELETE("/json", echo)
+	router.POST("/import", echo)
+	router.POST("/upload", echo)
+	return router
+}
+
+func sendContent(router *gin.Engine, method, path, contentType string, body io.Reader, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+type filePart struct {
+	field, filename string
+	data            []byte
+	encoding        string
+}
+
+func multipartRequest(t *testing.T, parts ...filePart) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		h := textproto.MIMEHeader{}
+		disposition := `form-data; name="` + p.field + `"`
+		if p.filename != "" {
+			disposition += `; filename="` + p.filename + `"`
+		}
+		h.Set("Content-Disposition", disposition)
+		if p.encoding != "" {
+			h.Set("Content-Transfer-Encoding", p.encoding)
+		}
+		part, err := w.CreatePart(h)
+		require.NoError(t, err)
+		_, err = part.Write(p.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return &body, w.FormDataContentType()
+}
+
+func TestContentPolicies_MediaTypes(t *testing.T) {
+	router := newContentRouter()
+
+	w := sendContent(router, http.MethodPost, "/json", "application/json; charset=UTF-8", strings.NewReader(`{}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = sendContent(router, http.MethodPost, "/import", "text/csv", strings.NewReader("email\n"))
+	assert.Equal(t, http.StatusOK, w.Code, "route override")
+
+	// Requests without a body are not checked
+	w = sendContent(router, http.MethodDelete, "/json", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for name, tc := range map[string]struct {
+		path, contentType string
+		headers           []string
+	}{
+		"missing content type": {path: "/json"},
+		"other media type":     {path: "/json", contentType: "application/x-www-form-urlencoded"},
+		"not the route's type": {path: "/import", contentType: "application/json"},
+		"malformed":            {path: "/json", contentType: "application/json; charset"},
+		"other charset":        {path: "/json", contentType: "application/json; charset=iso-8859-1"},
+		"us-ascii charset":     {path: "/json", contentType: "application/json; charset=us-ascii"},
+		"compressed":           {path: "/json", contentType: "application/json", headers: []string{"Content-Encoding", "gzip"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := sendContent(router, http.MethodPost, tc.path, tc.contentType, strings.NewReader(`{}`), tc.headers...)
+			assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+			assert.Equal(t, errors.CodeUnsupportedMedia, errorCode(t, w))
+		})
+	}
+}
+
+func TestContentPolicies_Multipart(t *testing.T) {
+	router := newContentRouter()
+
+	t.Run("passes valid uploads through unchanged", func(t *testing.T) {
+		body, contentType := multipartRequest(t,
+			filePart{field: "note", data: []byte("hello")},
+			filePart{field: "avatar", filename: "me.png", data: pngHeader})
+		size := body.Len()
+		w := sendContent(router, http.MethodPost, "/upload", contentType, body)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, strconv.Itoa(size), w.Body.String())
+	})
+
+	t.Run("sniffs file content", func(t *testing.T) {
+		body, contentType := multipartRequest(t, filePart{field: "avatar", filename: "me.png", data: []byte("<html><script>")})
+		w := sendContent(router, http.MethodPost, "/upload", contentType, body)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Contains(t, w.Body.String(), "text/html")
+	})
+
+	t.Run("limits parts", func(t *testing.T) {
+		body, contentType := multipartRequest(t,
+			filePart{field: "a", data: []byte("1")}, filePart{field: "b", data: []byte("2")}, filePart{field: "c", data: []byte("3")})
+		w := sendContent(router, http.MethodPost, "/upload", contentType, body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, errors.CodePayloadTooLarge, errorCode(t, w))
+	})
+
+	t.Run("limits part size", func(t *testing.T) {
+		body, contentType := multipartRequest(t, filePart{field: "avatar", filename: "me.png", data: append(pngHeader, make([]byte, 1024)...)})
+		w := sendContent(router, http.MethodPost, "/upload", contentType, body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("rejects transfer encodings", func(t *testing.T) {
+		body, contentType := multipartRequest(t, filePart{field: "avatar", filename: "me.png", data: []byte("iVBORw0K"), encoding: "base64"})
+		w := sendContent(router, http.MethodPost, "/upload", contentType, body)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("reports the body limit", func(t *testing.T) {
+		body, contentType := multipartRequest(t, filePart{field: "a", data: make([]byte, 5000)})
+		// Hide the length so the limit cuts the read off
+		w := sendContent(router, http.MethodPost, "/upload", contentType, io.MultiReader(body))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("rejects malformed bodies", func(t *testing.T) {
+		w := sendContent(router, http.MethodPost, "/upload", "multipart/form-data; boundary=xyz", strings.NewReader("not multipart"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
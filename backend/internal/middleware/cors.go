@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// CORSPolicy is the cross-origin policy applied by CORS. Origins are exact,
+// "*" for any origin, or contain one wildcard such as https://*.example.com.
+// AllowedHeaders may be "*" to accept any requested header.
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// CORS answers preflight requests and adds CORS headers to responses. The
+// policy can be replaced at runtime, e.g. on config reload.
+type CORS struct {
+	policy atomic.Pointer[corsPolicy]
+}
+
+// corsPolicy is a CORSPolicy prepared for matching
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	patterns         [][2]string // prefix and suffix around the wildcard
+	methods          map[string]bool
+	anyHeader        bool
+	headers          map[string]bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// NewCORS creates the middleware. A nil policy disables it.
+func NewCORS(policy *CORSPolicy) *CORS {
+	m := &CORS{}
+	m.SetPolicy(policy)
+	return m
+}
+
+// SetPolicy replaces the policy; nil disables CORS handling
+func (m *CORS) SetPolicy(policy *CORSPolicy) {
+	if policy == nil {
+		m.policy.Store(nil)
+		return
+	}
+
+	p := &corsPolicy{
+		origins:          make(map[string]bool),
+		methods:          make(map[string]bool),
+		headers:          make(map[string]bool),
+		allowCredentials: policy.AllowCredentials,
+		exposeHeaders:    strings.Join(policy.ExposedHeaders, ", "),
+	}
+	for _, origin := range policy.AllowedOrigins {
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(strings.ToLower(origin), "*")
+			p.patterns = append(p.patterns, [2]string{prefix, suffix})
+		default:
+			p.origins[strings.ToLower(origin)] = true
+		}
+	}
+	methods := make([]string, 0, len(policy.AllowedMethods))
+	for _, method := range policy.AllowedMethods {
+		method = strings.ToUpper(method)
+		p.methods[method] = true
+		methods = append(methods, method)
+	}
+	p.allowMethods = strings.Join(methods, ", ")
+	for _, header := range policy.AllowedHeaders {
+		if header == "*" {
+			p.anyHeader = true
+			continue
+		}
+		p.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	p.allowHeaders = strings.Join(policy.AllowedHeaders, ", ")
+	if policy.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(policy.MaxAge / time.Second))
+	}
+	m.policy.Store(p)
+}
+
+// Handler returns the gin middleware
+func (m *CORS) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := m.policy.Load()
+		origin := c.GetHeader("Origin")
+		if p == nil || origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		c.Writer.Header().Add("Vary", "Origin")
+		if !p.allowsOrigin(origin) {
+			if preflight {
+				response.Abort(c, errors.NewHTTPError(http.StatusForbidden, errors.CodeForbidden,
+					"Origin not allowed", map[string]interface{}{"origin": origin}, ""))
+				return
+			}
+			// Answer without CORS headers; the browser withholds the response
+			c.Next()
+			return
+		}
+
+		if p.anyOrigin && !p.allowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if p.allowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if p.exposeHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", p.exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		method := strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))
+		requested := c.GetHeader("Access-Control-Request-Headers")
+		if !p.methods[method] || !p.allowsHeaders(requested) {
+			response.Abort(c, errors.NewHTTPError(http.StatusForbidden, errors.CodeForbidden,
+				"CORS request not allowed", map[string]interface{}{"method": method, "headers": requested}, ""))
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", p.allowMethods)
+		if p.anyHeader {
+			c.Header("Access-Control-Allow-Headers", requested)
+		} else if p.allowHeaders != "" {
+			c.Header("Access-Control-Allow-Headers", p.allowHeaders)
+		}
+		if p.maxAge != "" {
+			c.Header("Access-Control-Max-Age", p.maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func (p *corsPolicy) allowsOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if len(origin) > len(pattern[0])+len(pattern[1]) &&
+			strings.HasPrefix(origin, pattern[0]) && strings.HasSuffix(origin, pattern[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *corsPolicy) allowsHeaders(requested string) bool {
+	if p.anyHeader || requested == "" {
+		return true
+	}
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSRouter(cors *CORS) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.Use(cors.Handler())
+	router.GET("/users", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func corsRequest(router *gin.Engine, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/users", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS(t *testing.T) {
+	cors := NewCORS(&CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.preview.example.com"},
+		AllowedMethods:   []string{"GET", "post"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Trace-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	router := newCORSRouter(cors)
+
+	t.Run("simple request from an allowed origin", func(t *testing.T) {
+		w := corsRequest(router, http.MethodGet, "https://app.example.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "X-Trace-ID", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("wildcard subdomain", func(t *testing.T) {
+		w := corsRequest(router, http.MethodGet, "https://pr-42.preview.example.com", nil)
+		assert.Equal(t, "https://pr-42.preview.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = corsRequest(router, http.MethodGet, "https://preview.example.com", nil)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("disallowed origin gets no CORS headers", func(t *testing.T) {
+		w := corsRequest(router, http.MethodGet, "https://evil.example.org", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("same-origin request is untouched", func(t *testing.T) {
+		w := corsRequest(router, http.MethodGet, "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Values("Vary"))
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		w := corsRequest(router, http.MethodOptions, "https://app.example.com", map[string]string{
+			"Access-Control-Request-Method":  "POST",
+			"Access-Control-Request-Headers": "content-type, authorization",
+		})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("preflight rejections", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			origin  string
+			headers map[string]string
+		}{
+			"origin": {"https://evil.example.org", map[string]string{"Access-Control-Request-Method": "GET"}},
+			"method": {"https://app.example.com", map[string]string{"Access-Control-Request-Method": "DELETE"}},
+			"header": {"https://app.example.com", map[string]string{
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "X-Debug",
+			}},
+		} {
+			w := corsRequest(router, http.MethodOptions, tc.origin, tc.headers)
+			assert.Equal(t, http.StatusForbidden, w.Code, name)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"), name)
+		}
+	})
+
+	t.Run("policy can be replaced and disabled", func(t *testing.T) {
+		cors.SetPolicy(&CORSPolicy{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}, AllowedHeaders: []string{"*"}})
+		w := corsRequest(router, http.MethodOptions, "https://other.example.net", map[string]string{
+			"Access-Control-Request-Method":  "GET",
+			"Access-Control-Request-Headers": "X-Anything",
+		})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "X-Anything", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+
+		cors.SetPolicy(nil)
+		w = corsRequest(router, http.MethodGet, "https://app.example.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation describes the retirement of an API version to its clients
+type Deprecation struct {
+	// At is when the version was, or will be, deprecated; zero sends no
+	// Deprecation header
+	At time.Time
+	// Sunset is when the version stops being served; zero sends no Sunset
+	// header
+	Sunset time.Time
+	// Link documents the move to the successor
+	Link string
+	// Prefix and Successor are the path prefixes of the version and of the
+	// one replacing it, such as /api/v1 and /api/v2
+	Prefix    string
+	Successor string
+}
+
+// DeprecationMiddleware announces d on every response of the routes it is
+// mounted on, errors included: the Deprecation header (RFC 9745), the
+// Sunset header (RFC 8594), and Link headers to the documentation and to
+// the same path under the successor version.
+func DeprecationMiddleware(d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		if !d.At.IsZero() {
+			header.Set("Deprecation", "@"+strconv.FormatInt(d.At.Unix(), 10))
+		}
+		if !d.Sunset.IsZero() {
+			header.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Link != "" {
+			header.Add("Link", "<"+d.Link+`>; rel="deprecation"; type="text/html"`)
+		}
+		if d.Successor != "" {
+			if rest, ok := strings.CutPrefix(c.Request.URL.Path, d.Prefix); ok {
+				header.Add("Link", "<"+d.Successor+rest+`>; rel="successor-version"`)
+			}
+		}
+		c.Next()
+	}
+}
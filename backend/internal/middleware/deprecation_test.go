@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	v1 := router.Group("/api/v1", DeprecationMiddleware(Deprecation{
+		At:        time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2027, 7, 1, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		Link:      "https://docs.example.com/migrate-to-v2",
+		Prefix:    "/api/v1",
+		Successor: "/api/v2",
+	}))
+	v1.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/u-1?fields=name", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "@1798761600", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Wed, 30 Jun 2027 22:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, []string{
+		`<https://docs.example.com/migrate-to-v2>; rel="deprecation"; type="text/html"`,
+		`</api/v2/users/u-1>; rel="successor-version"`,
+	}, w.Header().Values("Link"))
+}
+
+func TestDeprecationMiddleware_Unscheduled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/version", DeprecationMiddleware(Deprecation{Prefix: "/api/v1"}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+	assert.Empty(t, w.Header().Values("Link"))
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func prefixes(entries ...string) []netip.Prefix {
+	out := make([]netip.Prefix, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, netip.MustParsePrefix(e))
+	}
+	return out
+}
+
+func TestIPRules_Admits(t *testing.T) {
+	rules := IPRules{
+		Allow: prefixes("10.0.0.0/8", "2001:db8::/32"),
+		Deny:  prefixes("10.0.0.13/32"),
+	}
+
+	assert.True(t, rules.Admits(netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, rules.Admits(netip.MustParseAddr("2001:db8::1")))
+	assert.True(t, rules.Admits(netip.MustParseAddr("::ffff:10.1.2.3")), "IPv4-mapped addresses match IPv4 ranges")
+	assert.False(t, rules.Admits(netip.MustParseAddr("10.0.0.13")), "deny wins over allow")
+	assert.False(t, rules.Admits(netip.MustParseAddr("192.0.2.1")), "addresses outside the allowlist are refused")
+
+	assert.True(t, IPRules{}.Admits(netip.MustParseAddr("192.0.2.1")), "no rules admit everyone")
+}
+
+func TestIPFilter_Handler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	filter := NewIPFilter(
+		IPRules{Deny: prefixes("198.51.100.0/24")},
+		map[string]IPRules{"/api/v1/admin": {Allow: prefixes("10.0.0.0/8")}},
+	)
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies([]string{"127.0.0.1"}))
+	router.Use(filter.Handler())
+	router.RemoveExtraSlash = true
+	for _, path := range []string{"/api/v1/users", "/api/v1/admin/stats", "/api/v1/admin/users/:id", "/api/v1/administrators"} {
+		router.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	request := func(path, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("global deny list", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/api/v1/users", "192.0.2.1:4000", "").Code)
+
+		w := request("/api/v1/users", "198.51.100.7:4000", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, errors.CodeIPBlocked, errorCode(t, w))
+	})
+
+	t.Run("group allowlist covers the paths below its prefix", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/api/v1/admin/stats", "10.2.3.4:4000", "").Code)
+		assert.Equal(t, http.StatusForbidden, request("/api/v1/admin/stats", "192.0.2.1:4000", "").Code)
+		assert.Equal(t, http.StatusOK, request("/api/v1/administrators", "192.0.2.1:4000", "").Code,
+			"prefixes match whole path segments")
+	})
+
+	t.Run("paths are matched as routed, not as spelled", func(t *testing.T) {
+		for _, path := range []string{"/api//v1/admin/stats", "/api/v1/admin/users/42", "/api/v1/users/../admin/nowhere"} {
+			assert.Equal(t, http.StatusForbidden, request(path, "192.0.2.1:4000", "").Code, path)
+		}
+		assert.Equal(t, http.StatusOK, request("/api//v1/admin/stats", "10.2.3.4:4000", "").Code)
+	})
+
+	t.Run("forwarded addresses count only from trusted proxies", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/api/v1/admin/stats", "127.0.0.1:4000", "10.2.3.4").Code)
+		assert.Equal(t, http.StatusForbidden, request("/api/v1/users", "127.0.0.1:4000", "198.51.100.7").Code)
+		assert.Equal(t, http.StatusForbidden, request("/api/v1/admin/stats", "192.0.2.1:4000", "10.2.3.4").Code,
+			"an untrusted peer cannot claim an allowed address")
+	})
+}
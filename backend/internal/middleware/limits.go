@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// Limits caps one request; zero disables a limit
+type Limits struct {
+	MaxBodyBytes int64
+	Timeout      time.Duration
+}
+
+// RequestLimits holds default limits and per-route overrides keyed by
+// "METHOD /path/pattern" as registered with gin
+type RequestLimits struct {
+	defaults Limits
+	routes   map[string]Limits
+}
+
+// NewRequestLimits creates request limits. Routes replace the defaults for
+// the requests they match.
+func NewRequestLimits(defaults Limits, routes map[string]Limits) *RequestLimits {
+	return &RequestLimits{defaults: defaults, routes: routes}
+}
+
+// For returns the limits of a route; unmatched requests use the defaults
+func (l *RequestLimits) For(method, route string) Limits {
+	if limits, ok := l.routes[method+" "+route]; ok {
+		return limits
+	}
+	return l.defaults
+}
+
+// BodyLimit rejects bodies larger than the route's limit with 413
+// PAYLOAD_TOO_LARGE. Bodies without a declared length are cut off at the
+// limit and the read fails with *http.MaxBytesError.
+func (l *RequestLimits) BodyLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		max := l.For(c.Request.Method, c.FullPath()).MaxBodyBytes
+		if max <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > max {
+			response.Abort(c, PayloadTooLargeError(max))
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max)
+		c.Next()
+	}
+}
+
+// PayloadTooLargeError is the 413 error for bodies above max bytes
+func PayloadTooLargeError(max int64) *errors.HTTPError {
+	return errors.NewHTTPError(http.StatusRequestEntityTooLarge, errors.CodePayloadTooLarge,
+		fmt.Sprintf("Request body must be at most %d bytes", max),
+		map[string]interface{}{"max_bytes": max}, "")
+}
+
+// Timeout cancels the request context after the route's timeout and answers
+// 504 REQUEST_TIMEOUT. Handlers keep running until they notice the
+// cancellation, but anything they write after the deadline is discarded.
+func (l *RequestLimits) Timeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := l.For(c.Request.Method, c.FullPath()).Timeout
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := c.Writer
+		c.Writer = &deadlineWriter{ResponseWriter: writer, ctx: ctx}
+		c.Next()
+		c.Writer = writer
+
+		if !stderrors.Is(ctx.Err(), context.DeadlineExceeded) || writer.Written() {
+			return
+		}
+		response.Abort(c, errors.NewHTTPError(http.StatusGatewayTimeout, errors.CodeRequestTimeout,
+			"Request timed out", map[string]interface{}{"timeout": timeout.String()}, ""))
+	}
+}
+
+// deadlineWriter drops writes once the request deadline has passed so the
+// timeout response is not mixed with a late handler response
+type deadlineWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+}
+
+func (w *deadlineWriter) expired() bool {
+	return w.ctx.Err() != nil && !w.ResponseWriter.Written()
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *deadlineWriter) WriteHeaderNow() {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *deadlineWriter) Write(data []byte) (int, error) {
+	if w.expired() {
+		return 0, w.ctx.Err()
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *deadlineWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return 0, w.ctx.Err()
+	}
+	return w.ResponseWriter.WriteString(s)
+}
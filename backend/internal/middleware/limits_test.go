@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func newLimitsRouter(limits *RequestLimits) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(limits.BodyLimit(), limits.Timeout())
+
+	echo := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	}
+	router.POST("/echo", echo)
+	router.POST("/upload", echo)
+	router.GET("/slow", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			// Written after the deadline, so it must be discarded
+			c.String(http.StatusOK, "late")
+		case <-time.After(time.Second):
+			c.String(http.StatusOK, "done")
+		}
+	})
+	return router
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) errors.ErrorCode {
+	var body struct {
+		Error struct {
+			Code errors.ErrorCode `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.Error.Code
+}
+
+func TestRequestLimits_BodyLimit(t *testing.T) {
+	router := newLimitsRouter(NewRequestLimits(Limits{MaxBodyBytes: 8}, map[string]Limits{
+		"POST /upload": {MaxBodyBytes: 64},
+	}))
+
+	send := func(path string, body io.Reader) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, body))
+		return w
+	}
+
+	w := send("/echo", strings.NewReader("small"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "5", w.Body.String())
+
+	w = send("/echo", strings.NewReader(strings.Repeat("x", 9)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, errors.CodePayloadTooLarge, errorCode(t, w))
+
+	// Without a declared length the read is cut off at the limit
+	w = send("/echo", io.MultiReader(strings.NewReader(strings.Repeat("x", 9))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The route override replaces the default
+	w = send("/upload", strings.NewReader(strings.Repeat("x", 32)))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequestLimits_Timeout(t *testing.T) {
+	router := newLimitsRouter(NewRequestLimits(Limits{Timeout: 20 * time.Millisecond}, nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, errors.CodeRequestTimeout, errorCode(t, w))
+	assert.NotContains(t, w.Body.String(), "late")
+
+	// Fast handlers are unaffected
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("ok")))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/pkg/i18n"
+)
+
+// LocaleMiddleware negotiates the response language from the
+// Accept-Language header and stores it in the request context, where the
+// response package reads it to translate error messages. The language is
+// echoed in Content-Language.
+func LocaleMiddleware(bundle *i18n.Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := bundle.Negotiate(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(i18n.WithLanguage(c.Request.Context(), lang))
+		c.Header("Content-Language", lang)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
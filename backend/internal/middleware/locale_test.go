@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/i18n"
+)
+
+func TestLocaleMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bundle, err := i18n.New([]string{"zh"})
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(LocaleMiddleware(bundle))
+	var lang string
+	router.GET("/test", func(c *gin.Context) {
+		lang = i18n.LanguageFromContext(c.Request.Context())
+		response.Error(c, errors.NewHTTPError(http.StatusNotFound, errors.CodeEntityNotFound, "user not found", nil, ""))
+	})
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"zh-CN,zh;q=0.9,en;q=0.8", "zh"},
+		{"fr-FR, en;q=0.5", "en"},
+		{"de", "en"},
+		{"not a language", "en"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Accept-Language", tt.header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.want, lang, tt.header)
+		assert.Equal(t, tt.want, w.Header().Get("Content-Language"), tt.header)
+		assert.Contains(t, w.Header().Values("Vary"), "Accept-Language")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Accept-Language", "zh-TW")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), `"code":"ENTITY_NOT_FOUND"`)
+	assert.Contains(t, w.Body.String(), `"message":"资源不存在"`)
+}
@@ -17,17 +17,24 @@ func MetricsMiddleware() gin.HandlerFunc {
 		start := time.Now()
 		c.Next()
 
-		duration := time.Since(start).Seconds()
-		route := c.FullPath()
-		if route == "" {
-			route = "unknown"
-		}
+		inframetrics.ObserveHTTPRequest(inframetrics.HTTPRequest{
+			Method:          c.Request.Method,
+			Route:           routeTemplate(c),
+			Status:          strconv.Itoa(c.Writer.Status()),
+			DurationSeconds: time.Since(start).Seconds(),
+			RequestBytes:    c.Request.ContentLength,
+			ResponseBytes:   int64(c.Writer.Size()),
+			TraceID:         GetTraceIDFromContext(c.Request.Context()),
+		})
+	}
+}
 
-		inframetrics.ObserveHTTPRequest(
-			c.Request.Method,
-			route,
-			strconv.Itoa(c.Writer.Status()),
-			duration,
-		)
+// routeTemplate returns the template of the matched route, e.g.
+// /api/v1/users/:id, so metrics and logs do not get a series or value per
+// path. Unmatched requests share "unknown".
+func routeTemplate(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
 	}
+	return "unknown"
 }
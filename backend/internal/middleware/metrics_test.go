@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddleware_RouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(TraceIDMiddleware(), MetricsMiddleware())
+	router.POST("/metrics-test/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for _, id := range []string{"1", "2", "3"} {
+		req := httptest.NewRequest(http.MethodPost, "/metrics-test/users/"+id, strings.NewReader(`{"name":"x"}`))
+		req.Header.Set(TraceIDHeader, "trace-"+id)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	duration := findHistogram(t, families, "wonder_http_request_duration_seconds", "/metrics-test/users/:id")
+	assert.EqualValues(t, 3, duration.GetSampleCount(), "all paths share the route template")
+	var exemplar *dto.Exemplar
+	for _, b := range duration.GetBucket() {
+		if b.GetExemplar() != nil {
+			exemplar = b.GetExemplar()
+		}
+	}
+	require.NotNil(t, exemplar)
+	assert.Equal(t, "trace_id", exemplar.GetLabel()[0].GetName())
+	assert.True(t, strings.HasPrefix(exemplar.GetLabel()[0].GetValue(), "trace-"))
+
+	requestSize := findHistogram(t, families, "wonder_http_request_size_bytes", "/metrics-test/users/:id")
+	assert.EqualValues(t, 3*len(`{"name":"x"}`), requestSize.GetSampleSum())
+	responseSize := findHistogram(t, families, "wonder_http_response_size_bytes", "/metrics-test/users/:id")
+	assert.EqualValues(t, 3*len("ok"), responseSize.GetSampleSum())
+}
+
+func findHistogram(t *testing.T, families []*dto.MetricFamily, name, route string) *dto.Histogram {
+	t.Helper()
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "route" && label.GetValue() == route {
+					return m.GetHistogram()
+				}
+			}
+		}
+	}
+	t.Fatalf("no %s series for route %s", name, route)
+	return nil
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// RateLimit admits at most limit requests per client IP in each window to
+// the routes it guards; the rest get 429 RATE_LIMIT_EXCEEDED with
+// Retry-After. name keeps the counts of different limits apart. It counts
+// with the registration throttle's counters, which instances share when
+// they are kept in Redis. Requests are admitted when counting fails.
+func RateLimit(counter user.SignupCounter, name string, limit int, window time.Duration) gin.HandlerFunc {
+	if counter == nil {
+		panic("rate limit counter cannot be nil")
+	}
+	log := logger.Get().WithLayer("middleware").WithComponent("rate_limit")
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		count, retryAfter, err := counter.Increment(ctx, name+":ip:"+clientIP(c), window)
+		if err != nil {
+			log.Warn(ctx, "failed to count request for rate limit", "limit", name, "error", err)
+			c.Next()
+			return
+		}
+		if count > int64(limit) {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			response.Abort(c, RateLimitedError(seconds))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitedError is the error answered to a client over a rate limit
+func RateLimitedError(retryAfterSeconds int) *errors.HTTPError {
+	return errors.NewHTTPError(http.StatusTooManyRequests, errors.CodeRateLimitExceeded,
+		"Too many requests, try again later", map[string]interface{}{"retry_after_seconds": retryAfterSeconds}, "")
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/security"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// failingCounter cannot count
+type failingCounter struct{}
+
+func (failingCounter) Increment(context.Context, string, time.Duration) (int64, time.Duration, error) {
+	return 0, 0, assert.AnError
+}
+
+func (failingCounter) Release(context.Context, string) error { return nil }
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/limited", RateLimit(security.NewMemorySignupCounter(), "test", 2, time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/unavailable", RateLimit(failingCounter{}, "test", 1, time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("/limited", "192.0.2.1:4000").Code)
+	assert.Equal(t, http.StatusOK, request("/limited", "192.0.2.1:4000").Code)
+	w := request("/limited", "192.0.2.1:4000")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, errors.CodeRateLimitExceeded, errorCode(t, w))
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, request("/limited", "192.0.2.2:4000").Code, "other clients are counted apart")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, request("/unavailable", "192.0.2.1:4000").Code, "counter failures admit requests")
+	}
+}
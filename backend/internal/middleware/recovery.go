@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+
+	inframetrics "github.com/cctw-zed/wonder/internal/infrastructure/metrics"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/reporting"
+)
+
+// RecoveryMiddleware turns handler panics into a 500 INTERNAL_SERVER_ERROR
+// envelope carrying the trace ID. The panic value and stack are logged,
+// counted and passed to the error reporter but never sent to the client.
+func RecoveryMiddleware() gin.HandlerFunc {
+	inframetrics.EnsurePanicMetrics()
+	log := logger.Get().WithLayer("middleware").WithComponent("recovery")
+
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// The client is gone; there is nobody to answer
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			ctx := c.Request.Context()
+			traceID := GetTraceIDFromContext(ctx)
+			route := routeTemplate(c)
+
+			inframetrics.ObservePanic(c.Request.Method, route)
+			log.Error(ctx, "panic recovered",
+				"panic", recovered,
+				"method", c.Request.Method,
+				"route", route,
+				"path", c.Request.URL.Path,
+				"stack", string(debug.Stack()))
+			req := response.RequestInfo(c)
+			req.Status = http.StatusInternalServerError
+			reporting.Get().CapturePanic(recovered, req)
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			// Written directly: the panic is already reported
+			c.AbortWithStatusJSON(http.StatusInternalServerError, response.Envelope{
+				Error: errors.NewHTTPError(http.StatusInternalServerError, errors.CodeInternalError,
+					"Internal server error", nil, ""),
+				TraceID:   traceID,
+				RequestID: GetRequestIDFromContext(ctx),
+			})
+		}()
+
+		c.Next()
+	}
+}
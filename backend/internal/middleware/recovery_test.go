@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/reporting"
+)
+
+type recordingReporter struct {
+	errs      []error
+	recovered interface{}
+	req       reporting.Request
+}
+
+func (r *recordingReporter) CaptureError(err error, req reporting.Request) {
+	r.errs = append(r.errs, err)
+}
+
+func (r *recordingReporter) CapturePanic(recovered interface{}, req reporting.Request) {
+	r.recovered = recovered
+	r.req = req
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	logger.Initialize()
+	gin.SetMode(gin.TestMode)
+
+	reporter := &recordingReporter{}
+	reporting.Set(reporter)
+	defer reporting.Set(nil)
+
+	router := gin.New()
+	router.Use(TraceIDMiddleware())
+	router.Use(RecoveryMiddleware())
+	router.GET("/users/:id", func(c *gin.Context) {
+		panic("secret internal state")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(TraceIDHeader, "trace-panic")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret internal state")
+
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+		TraceID string `json:"trace_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, string(errors.CodeInternalError), body.Error.Code)
+	assert.Equal(t, "trace-panic", body.TraceID)
+
+	assert.Equal(t, "secret internal state", reporter.recovered)
+	assert.Equal(t, "trace-panic", reporter.req.TraceID)
+	assert.Equal(t, "/users/:id", reporter.req.Route)
+	assert.Equal(t, "/users/42", reporter.req.Path)
+	assert.Equal(t, http.StatusInternalServerError, reporter.req.Status)
+	assert.Empty(t, reporter.errs, "a panic is reported once")
+}
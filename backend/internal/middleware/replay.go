@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/replay"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// ReplayCaptureMiddleware records a sanitized envelope for every request
+// whose response status is at least minStatus. Bodies larger than
+// maxBodyBytes are not inspected.
+func ReplayCaptureMiddleware(store replay.Store, minStatus int, maxBodyBytes int64) gin.HandlerFunc {
+	if store == nil {
+		panic("replay store cannot be nil")
+	}
+	log := logger.Get().WithLayer("middleware").WithComponent("replay_capture")
+
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil && c.Request.ContentLength <= maxBodyBytes {
+			limited, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBodyBytes+1))
+			if err == nil {
+				body = limited
+			}
+			// Restore the body for downstream handlers
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(limited), c.Request.Body))
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < minStatus {
+			return
+		}
+
+		env := &replay.Envelope{
+			ID:         uuid.New().String(),
+			CapturedAt: time.Now().UTC(),
+			TraceID:    GetTraceIDFromContext(c.Request.Context()),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Route:      c.FullPath(),
+			QueryKeys:  replay.QueryKeys(c.Request.URL.Query()),
+			Headers:    replay.SanitizeHeaders(c.Request.Header),
+			Status:     status,
+		}
+		if int64(len(body)) <= maxBodyBytes {
+			env.BodySchema = replay.BodySchema(body)
+		}
+
+		if err := store.Save(c.Request.Context(), env); err != nil {
+			log.Warn(c.Request.Context(), "failed to capture replay envelope", "error", err, "path", env.Path)
+		}
+	}
+}
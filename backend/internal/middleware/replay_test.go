@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/replay"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestReplayCaptureMiddleware(t *testing.T) {
+	logger.Initialize()
+	gin.SetMode(gin.TestMode)
+
+	store, err := replay.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(TraceIDMiddleware())
+	router.Use(ReplayCaptureMiddleware(store, http.StatusInternalServerError, 1024))
+
+	var seenBody string
+	router.POST("/users/:id", func(c *gin.Context) {
+		data, _ := io.ReadAll(c.Request.Body)
+		seenBody = string(data)
+		c.Status(http.StatusInternalServerError)
+	})
+	router.GET("/ok", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	body := `{"email":"alice@example.com","password":"hunter2","tags":["a","b"],"age":30}`
+	req := httptest.NewRequest(http.MethodPost, "/users/42?verbose=1&token=secret", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Cookie", "session=abc")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+	assert.Equal(t, body, seenBody, "handler must still see the full body")
+
+	envelopes, err := store.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+
+	env := envelopes[0]
+	assert.Equal(t, http.MethodPost, env.Method)
+	assert.Equal(t, "/users/:id", env.Route)
+	assert.Equal(t, http.StatusInternalServerError, env.Status)
+	assert.NotEmpty(t, env.TraceID)
+	assert.Equal(t, []string{"token", "verbose"}, env.QueryKeys)
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, env.Headers)
+	assert.Equal(t, map[string]interface{}{
+		"email":    "string",
+		"password": "string",
+		"tags":     []interface{}{"string"},
+		"age":      "number",
+	}, env.BodySchema)
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// RequestContext is the metadata known about a request. Fields are filled
+// in as middleware resolves them: the tenant once TenantMiddleware has run
+// and the user once the request is authenticated.
+type RequestContext struct {
+	TraceID  string
+	UserID   string
+	TenantID string
+	ClientIP string
+
+	RequestID     string
+	CorrelationID string
+	// Logger is bound to the fields above
+	Logger logger.Logger
+}
+
+type requestContextKey struct{}
+
+// RequestContextMiddleware creates a middleware that binds a RequestContext
+// and a logger carrying its fields to the request context. It must run
+// after TraceIDMiddleware. Logs written with the request context, by any
+// logger, carry the request's metadata.
+func RequestContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		rc := &RequestContext{
+			TraceID:  GetTraceIDFromContext(ctx),
+			UserID:   GetUserIDFromContext(ctx),
+			TenantID: tenant.IDFromContext(ctx),
+			ClientIP: clientIP(c),
+
+			RequestID:     GetRequestIDFromContext(ctx),
+			CorrelationID: GetCorrelationIDFromContext(ctx),
+		}
+		c.Request = c.Request.WithContext(rc.bind(ctx))
+		c.Next()
+	}
+}
+
+// GetRequestContext returns the RequestContext bound to ctx, or nil outside
+// of requests
+func GetRequestContext(ctx context.Context) *RequestContext {
+	if ctx == nil {
+		return nil
+	}
+	rc, _ := ctx.Value(requestContextKey{}).(*RequestContext)
+	return rc
+}
+
+// updateRequestContext returns ctx with a copy of its RequestContext changed
+// by update, leaving ctx as is outside of requests
+func updateRequestContext(ctx context.Context, update func(*RequestContext)) context.Context {
+	current := GetRequestContext(ctx)
+	if current == nil {
+		return ctx
+	}
+	next := *current
+	update(&next)
+	return next.bind(ctx)
+}
+
+// requestContextFields is the number of fields a request's logger carries
+const requestContextFields = 6
+
+// bind binds rc and a logger carrying its fields to ctx
+func (rc *RequestContext) bind(ctx context.Context) context.Context {
+	// Room for every field, including the user's, so that appending it
+	// does not copy the slice
+	keyvals := make([]interface{}, 0, requestContextFields*2)
+	keyvals = append(keyvals,
+		TraceIDKey, rc.TraceID, RequestIDKey, rc.RequestID, CorrelationIDKey, rc.CorrelationID,
+		"tenant_id", rc.TenantID, ClientIPKey, rc.ClientIP,
+	)
+	if rc.UserID != "" {
+		keyvals = append(keyvals, UserIDKey, rc.UserID)
+	}
+	rc.Logger = logger.Get().With(keyvals...)
+
+	ctx = context.WithValue(ctx, requestContextKey{}, rc)
+	return logger.NewContext(ctx, rc.Logger)
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestRequestContextMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(TraceIDMiddleware(), RequestContextMiddleware())
+	router.Use(func(c *gin.Context) {
+		// Stands in for the tenant and auth middleware resolving the request
+		ctx := updateRequestContext(c.Request.Context(), func(rc *RequestContext) {
+			rc.TenantID = "acme"
+			rc.UserID = "user-1"
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+
+	var captured *RequestContext
+	var bound logger.Logger
+	router.GET("/test", func(c *gin.Context) {
+		captured = GetRequestContext(c.Request.Context())
+		bound = logger.FromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(TraceIDHeader, "trace-123")
+	req.RemoteAddr = "203.0.113.7:4321"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, captured)
+	assert.Equal(t, "trace-123", captured.TraceID)
+	assert.NotEmpty(t, captured.RequestID)
+	assert.Equal(t, "trace-123", captured.CorrelationID)
+	assert.Equal(t, "acme", captured.TenantID)
+	assert.Equal(t, "user-1", captured.UserID)
+	assert.Equal(t, "203.0.113.7", captured.ClientIP)
+	assert.Same(t, captured.Logger, bound)
+}
+
+func TestRequestContext_OutsideRequests(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, GetRequestContext(ctx))
+	assert.Equal(t, ctx, updateRequestContext(ctx, func(rc *RequestContext) { rc.UserID = "user-1" }))
+	assert.Equal(t, logger.Get(), logger.FromContext(ctx))
+}
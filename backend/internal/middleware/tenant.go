@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// TenantIDHeader is the default HTTP header naming the tenant of a request
+const TenantIDHeader = "X-Tenant-ID"
+
+// TenantMiddleware scopes each request to a tenant. The tenant is taken from
+// the header, then from a single-label subdomain of baseDomain, and is the
+// default tenant when neither names one. Either the tenant ID or its slug
+// may be given. Returns 404 Not Found for unknown tenants.
+func TenantMiddleware(tenants tenant.Service, header, baseDomain string) gin.HandlerFunc {
+	if tenants == nil {
+		panic("tenant service cannot be nil")
+	}
+	if header == "" {
+		header = TenantIDHeader
+	}
+	baseDomain = strings.ToLower(strings.Trim(baseDomain, "."))
+
+	return func(c *gin.Context) {
+		ref := strings.TrimSpace(c.GetHeader(header))
+		if ref == "" {
+			ref = subdomain(c.Request.Host, baseDomain)
+		}
+		if ref == "" {
+			ref = tenant.DefaultID
+		}
+
+		ctx := c.Request.Context()
+		t, err := tenants.Resolve(ctx, ref)
+		if err != nil {
+			errorMapper := errors.NewErrorMapper()
+			response.Abort(c, errorMapper.MapToHTTPError(err, GetTraceIDFromContext(ctx)))
+			return
+		}
+
+		ctx = updateRequestContext(tenant.WithID(ctx, t.ID), func(rc *RequestContext) { rc.TenantID = t.ID })
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireTenant creates middleware that only lets requests scoped to the
+// given tenant through. It must run after TenantMiddleware.
+// Returns 403 Forbidden for any other tenant
+func RequireTenant(tenantID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if tenant.IDFromContext(ctx) != tenantID {
+			errorMapper := errors.NewErrorMapper()
+			err := errors.NewUnauthorizedError("tenant_middleware", GetUserIDFromContext(ctx), "not available to this tenant")
+			err.ErrorCode = errors.CodeForbidden
+			response.Abort(c, errorMapper.MapToHTTPError(err, GetTraceIDFromContext(ctx)))
+			return
+		}
+		c.Next()
+	}
+}
+
+// subdomain returns the label in front of baseDomain in host, or "" when
+// host is not exactly one level below baseDomain
+func subdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	label, ok := strings.CutSuffix(host, "."+baseDomain)
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	tenantMocks "github.com/cctw-zed/wonder/internal/domain/tenant/mocks"
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func createTenantRouter(handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TraceIDMiddleware())
+	router.GET("/tenant", append(handlers, func(c *gin.Context) {
+		c.String(http.StatusOK, tenant.IDFromContext(c.Request.Context()))
+	})...)
+	return router
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		host       string
+		header     string
+		resolve    string
+		wantStatus int
+		wantTenant string
+	}{
+		{name: "header wins over subdomain", host: "beta.example.com", header: "acme", resolve: "acme", wantStatus: http.StatusOK, wantTenant: "t-acme"},
+		{name: "subdomain", host: "acme.example.com:8080", resolve: "acme", wantStatus: http.StatusOK, wantTenant: "t-acme"},
+		{name: "nested subdomain is ignored", host: "a.acme.example.com", resolve: tenant.DefaultID, wantStatus: http.StatusOK, wantTenant: tenant.DefaultID},
+		{name: "other domain falls back to default", host: "acme.other.com", resolve: tenant.DefaultID, wantStatus: http.StatusOK, wantTenant: tenant.DefaultID},
+		{name: "unknown tenant", host: "example.com", header: "ghost", resolve: "ghost", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			tenants := tenantMocks.NewMockService(ctrl)
+			switch tt.wantStatus {
+			case http.StatusOK:
+				tenants.EXPECT().Resolve(gomock.Any(), tt.resolve).Return(&tenant.Tenant{ID: tt.wantTenant}, nil)
+			default:
+				tenants.EXPECT().Resolve(gomock.Any(), tt.resolve).Return(nil, errors.NewEntityNotFoundError("tenant", tt.resolve))
+			}
+
+			router := createTenantRouter(TenantMiddleware(tenants, "", "example.com"))
+			req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(TenantIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantTenant, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tenants := tenantMocks.NewMockService(ctrl)
+	tenants.EXPECT().Resolve(gomock.Any(), "acme").Return(&tenant.Tenant{ID: "t-acme"}, nil)
+	tenants.EXPECT().Resolve(gomock.Any(), tenant.DefaultID).Return(&tenant.Tenant{ID: tenant.DefaultID}, nil)
+
+	router := createTenantRouter(TenantMiddleware(tenants, "", ""), RequireTenant(tenant.DefaultID))
+
+	req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+	req.Header.Set(TenantIDHeader, "acme")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	httpErr := decodeErrorEnvelope(t, w)
+	assert.Equal(t, "FORBIDDEN", string(httpErr.Code()))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tenant", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
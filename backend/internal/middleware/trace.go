@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -12,27 +13,76 @@ const (
 	TraceIDKey = "trace_id"
 	// TraceIDHeader is the HTTP header name for trace ID
 	TraceIDHeader = "X-Trace-ID"
+	// RequestIDKey is the context key for storing the request ID
+	RequestIDKey = "request_id"
+	// RequestIDHeader is the HTTP header name for the request ID
+	RequestIDHeader = "X-Request-ID"
+	// CorrelationIDKey is the context key for storing the correlation ID
+	CorrelationIDKey = "correlation_id"
+	// CorrelationIDHeader is the HTTP header name for the correlation ID
+	CorrelationIDHeader = "X-Correlation-ID"
+	// ClientIPKey is the context key for storing the caller's IP address
+	ClientIPKey = "client_ip"
+)
+
+// maxIDLength bounds IDs accepted from request headers
+const maxIDLength = 128
+
+// The ID headers in canonical form. The header maps are read and written
+// with them directly, since gin canonicalizes the names above, which are
+// not, into a new string on every call.
+var (
+	traceIDHeaderKey       = http.CanonicalHeaderKey(TraceIDHeader)
+	requestIDHeaderKey     = http.CanonicalHeaderKey(RequestIDHeader)
+	correlationIDHeaderKey = http.CanonicalHeaderKey(CorrelationIDHeader)
 )
 
 // TraceIDMiddleware creates a middleware that automatically generates and injects
-// a TraceID into the request context for distributed tracing and logging
+// a TraceID into the request context for distributed tracing and logging.
+//
+// Three IDs are tracked per request:
+//   - the trace ID follows a request through every service it reaches
+//   - the request ID identifies one hop; it is taken from X-Request-ID as set
+//     by the proxy or caller in front of us, otherwise generated
+//   - the correlation ID groups the requests of one business operation, e.g.
+//     a checkout spanning several API calls; it defaults to the trace ID
+//
+// All three are echoed in the response headers.
 func TraceIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var traceID string
 
 		// First, check if trace ID is provided in the request header
-		if headerTraceID := c.GetHeader(TraceIDHeader); headerTraceID != "" {
+		if headerTraceID := firstValue(c.Request.Header, traceIDHeaderKey); validID(headerTraceID) {
 			traceID = headerTraceID
 		} else {
 			// Generate a new UUID for trace ID if not provided
 			traceID = uuid.New().String()
 		}
 
-		// Set trace ID in response header for client visibility
-		c.Header(TraceIDHeader, traceID)
+		requestID := firstValue(c.Request.Header, requestIDHeaderKey)
+		if !validID(requestID) {
+			requestID = uuid.New().String()
+		}
+		correlationID := firstValue(c.Request.Header, correlationIDHeaderKey)
+		if !validID(correlationID) {
+			correlationID = traceID
+		}
+
+		// Set the IDs in response headers for client visibility
+		// The three values share one backing array; each header gets a
+		// full slice of one element, so appending to one cannot overwrite
+		// the next
+		ids := []string{traceID, requestID, correlationID}
+		header := c.Writer.Header()
+		header[traceIDHeaderKey] = ids[0:1:1]
+		header[requestIDHeaderKey] = ids[1:2:2]
+		header[correlationIDHeaderKey] = ids[2:3:3]
 
-		// Inject trace ID into the request context
+		// Inject the IDs into the request context
 		ctx := context.WithValue(c.Request.Context(), TraceIDKey, traceID)
+		ctx = context.WithValue(ctx, RequestIDKey, requestID)
+		ctx = context.WithValue(ctx, CorrelationIDKey, correlationID)
 		c.Request = c.Request.WithContext(ctx)
 
 		// Continue with the next handler
@@ -43,15 +93,54 @@ func TraceIDMiddleware() gin.HandlerFunc {
 // GetTraceIDFromContext extracts trace ID from context
 // This is a convenience function for manual trace ID extraction if needed
 func GetTraceIDFromContext(ctx context.Context) string {
+	return stringFromContext(ctx, TraceIDKey)
+}
+
+// GetRequestIDFromContext extracts the request ID from context
+func GetRequestIDFromContext(ctx context.Context) string {
+	return stringFromContext(ctx, RequestIDKey)
+}
+
+// GetCorrelationIDFromContext extracts the correlation ID from context
+func GetCorrelationIDFromContext(ctx context.Context) string {
+	return stringFromContext(ctx, CorrelationIDKey)
+}
+
+// stringFromContext returns the string stored under key. key is an
+// interface so that the constant keys callers pass are not boxed on every
+// call.
+func stringFromContext(ctx context.Context, key interface{}) string {
 	if ctx == nil {
 		return ""
 	}
 
-	if traceID := ctx.Value(TraceIDKey); traceID != nil {
-		if str, ok := traceID.(string); ok {
+	if v := ctx.Value(key); v != nil {
+		if str, ok := v.(string); ok {
 			return str
 		}
 	}
 
 	return ""
 }
+
+// firstValue returns the first value of the canonical header key
+func firstValue(header http.Header, key string) string {
+	if values := header[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// validID reports whether an ID from a request header is safe to echo and
+// log: non-empty, bounded and printable ASCII
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
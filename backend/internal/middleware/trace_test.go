@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -79,6 +80,65 @@ func TestTraceIDMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.NotEmpty(t, w.Header().Get(TraceIDHeader))
 	})
+
+	t.Run("separates request and correlation IDs from the trace ID", func(t *testing.T) {
+		router := gin.New()
+		router.Use(TraceIDMiddleware())
+
+		var requestID, correlationID string
+		router.GET("/test", func(c *gin.Context) {
+			requestID = GetRequestIDFromContext(c.Request.Context())
+			correlationID = GetCorrelationIDFromContext(c.Request.Context())
+		})
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set(TraceIDHeader, "trace-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.NotEmpty(t, requestID)
+		assert.NotEqual(t, "trace-1", requestID)
+		assert.Equal(t, "trace-1", correlationID, "correlation ID defaults to the trace ID")
+		assert.Equal(t, requestID, w.Header().Get(RequestIDHeader))
+		assert.Equal(t, "trace-1", w.Header().Get(CorrelationIDHeader))
+	})
+
+	t.Run("accepts request and correlation IDs from headers", func(t *testing.T) {
+		router := gin.New()
+		router.Use(TraceIDMiddleware())
+
+		var requestID, correlationID string
+		router.GET("/test", func(c *gin.Context) {
+			requestID = GetRequestIDFromContext(c.Request.Context())
+			correlationID = GetCorrelationIDFromContext(c.Request.Context())
+		})
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set(RequestIDHeader, "lb-req-7")
+		req.Header.Set(CorrelationIDHeader, "checkout-42")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "lb-req-7", requestID)
+		assert.Equal(t, "checkout-42", correlationID)
+		assert.Equal(t, "lb-req-7", w.Header().Get(RequestIDHeader))
+		assert.Equal(t, "checkout-42", w.Header().Get(CorrelationIDHeader))
+	})
+
+	t.Run("replaces malformed IDs", func(t *testing.T) {
+		router := gin.New()
+		router.Use(TraceIDMiddleware())
+		router.GET("/test", func(c *gin.Context) {})
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set(TraceIDHeader, "bad id with spaces")
+		req.Header.Set(RequestIDHeader, strings.Repeat("x", maxIDLength+1))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.NotEqual(t, "bad id with spaces", w.Header().Get(TraceIDHeader))
+		assert.Len(t, w.Header().Get(RequestIDHeader), 36)
+	})
 }
 
 func TestGetTraceIDFromContext(t *testing.T) {
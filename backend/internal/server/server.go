@@ -3,25 +3,43 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/cctw-zed/wonder/internal/container"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	apihttp "github.com/cctw-zed/wonder/internal/interfaces/http"
+	"github.com/cctw-zed/wonder/internal/interfaces/http/response"
 	"github.com/cctw-zed/wonder/internal/middleware"
+	"github.com/cctw-zed/wonder/pkg/i18n"
 )
 
 // Server represents the HTTP server
 type Server struct {
 	httpServer *http.Server
 	container  *container.Container
+	cors       *middleware.CORS
+
+	// Set while TLS is enabled. redirectServer listens for plain HTTP;
+	// certs is nil when autocert manages certificates.
+	redirectServer *http.Server
+	certs          *certReloader
 }
 
-// New creates a new server instance
-func New(c *container.Container) *Server {
+// New creates a new server instance. It fails when TLS is enabled and the
+// certificate cannot be loaded, or when trusted proxies or IP filter
+// ranges do not parse.
+func New(c *container.Container) (*Server, error) {
+	cfg := c.Config()
+
 	// Set Gin mode based on environment
-	switch c.Config.App.Environment {
+	switch cfg.App.Environment {
 	case "production":
 		gin.SetMode(gin.ReleaseMode)
 	case "testing":
@@ -30,31 +48,126 @@ func New(c *container.Container) *Server {
 		gin.SetMode(gin.DebugMode)
 	}
 
+	// The CORS policy can be changed at runtime through config hot-reload
+	cors := middleware.NewCORS(corsPolicy(cfg.Server))
+
 	// Setup HTTP router
-	router := setupRouter(c)
+	router, err := setupRouter(c, cors)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create HTTP server
 	httpServer := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", c.Config.Server.Host, c.Config.Server.Port),
+		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
 		Handler:      router,
-		ReadTimeout:  c.Config.Server.ReadTimeout,
-		WriteTimeout: c.Config.Server.WriteTimeout,
-		IdleTimeout:  c.Config.Server.IdleTimeout,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	return &Server{
+	s := &Server{
 		httpServer: httpServer,
 		container:  c,
+		cors:       cors,
+	}
+	if tlsCfg := cfg.Server.TLS; tlsCfg != nil && tlsCfg.Enabled {
+		if err := s.setupTLS(tlsCfg); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// setupTLS switches the server to HTTPS and adds the plain HTTP listener
+// for redirects and ACME challenges
+func (s *Server) setupTLS(cfg *config.TLSConfig) error {
+	manager := newAutocertManager(cfg)
+	tlsConfig, certs, err := newTLSConfig(cfg, manager)
+	if err != nil {
+		return err
+	}
+	s.httpServer.TLSConfig = tlsConfig
+	s.httpServer.Protocols = protocols(cfg)
+	s.certs = certs
+
+	if !cfg.ListensHTTP() {
+		return nil
+	}
+	handler := http.NotFoundHandler()
+	if cfg.RedirectHTTP {
+		handler = redirectHandler(s.container.Config().Server.Port)
+	}
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+	s.redirectServer = &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", s.container.Config().Server.Host, cfg.HTTPPort),
+		Handler:           handler,
+		ReadHeaderTimeout: s.httpServer.ReadTimeout,
+		IdleTimeout:       s.httpServer.IdleTimeout,
+	}
+	return nil
+}
+
+// ApplyConfigChange re-configures the runtime-tunable parts of the server.
+// Listener address, timeouts and TLS are fixed at startup and require a
+// restart; certificates are reloaded on SIGHUP.
+func (s *Server) ApplyConfigChange(event config.ChangeEvent) {
+	if !event.Has(config.SectionServer) {
+		return
 	}
+	s.cors.SetPolicy(corsPolicy(event.Current.Server))
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server, or the HTTPS server and its plain HTTP
+// listener. It returns once either of them stops.
 func (s *Server) Start() error {
-	return s.httpServer.ListenAndServe()
+	if s.httpServer.TLSConfig == nil {
+		return s.httpServer.ListenAndServe()
+	}
+	if s.redirectServer == nil {
+		return s.httpServer.ListenAndServeTLS("", "")
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- s.redirectServer.ListenAndServe() }()
+	go func() { errs <- s.httpServer.ListenAndServeTLS("", "") }()
+	return <-errs
+}
+
+// Serve serves on ln, which lets tests listen on an ephemeral port. It
+// serves HTTPS when TLS is enabled but does not start the plain HTTP
+// listener.
+func (s *Server) Serve(ln net.Listener) error {
+	if s.httpServer.TLSConfig == nil {
+		return s.httpServer.Serve(ln)
+	}
+	return s.httpServer.ServeTLS(ln, "", "")
+}
+
+// TLSEnabled reports whether the server serves HTTPS
+func (s *Server) TLSEnabled() bool {
+	return s.httpServer.TLSConfig != nil
+}
+
+// ReloadCertificates re-reads the TLS certificate and key from disk. New
+// handshakes use the new certificate; open connections are kept. It does
+// nothing without TLS or when autocert renews certificates itself.
+func (s *Server) ReloadCertificates() error {
+	if s.certs == nil {
+		return nil
+	}
+	return s.certs.Reload()
 }
 
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.redirectServer != nil {
+		if err := s.redirectServer.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
 	return s.httpServer.Shutdown(ctx)
 }
 
@@ -64,29 +177,82 @@ func (s *Server) GetAddr() string {
 }
 
 // setupRouter configures the HTTP routes
-func setupRouter(c *container.Container) *gin.Engine {
+func setupRouter(c *container.Container, cors *middleware.CORS) (*gin.Engine, error) {
+	cfg, h := c.Config(), c.Handlers()
 	router := gin.New()
 
-	// Add TraceID middleware first to ensure all requests have trace IDs
+	// Only the configured proxies may name the client in X-Forwarded-For
+	// or X-Real-IP; without any the client IP is the peer address
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("server trusted_proxies: %w", err)
+	}
+
+	// Resolve the client IP once; everything after reads it from the context
+	router.Use(middleware.ClientIPMiddleware())
+
+	// Add TraceID middleware to ensure all requests have trace IDs
 	router.Use(middleware.TraceIDMiddleware())
 
-	// Use default Gin middleware for now
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-	router.Use(middleware.MetricsMiddleware())
+	// Bind request metadata and a logger carrying it to every request
+	router.Use(middleware.RequestContextMiddleware())
+
+	// Negotiate the language error messages are written in
+	if cfg.I18n != nil && cfg.I18n.Enabled {
+		router.Use(middleware.LocaleMiddleware(i18n.Get()))
+	}
+
+	// Scope every request to a tenant; without it all requests belong to
+	// the default tenant
+	if cfg.Tenancy != nil && cfg.Tenancy.Enabled {
+		router.Use(middleware.TenantMiddleware(c.TenantService(), cfg.Tenancy.Header, cfg.Tenancy.BaseDomain))
+	}
+
+	// Capture sanitized envelopes of failed requests for later replay
+	if c.ReplayStore() != nil {
+		router.Use(middleware.ReplayCaptureMiddleware(c.ReplayStore(), cfg.Replay.MinStatus, int64(cfg.Replay.MaxBodyBytes)))
+	}
 
-	// Expose Prometheus metrics endpoint
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	// Access log and metrics name routes by template, never by raw path
+	router.Use(middleware.AccessLogMiddleware())
+	router.Use(middleware.RecoveryMiddleware())
+	router.Use(middleware.MetricsMiddleware())
 
-	// Add CORS middleware if enabled
-	if c.Config.Server.EnableCORS {
-		router.Use(corsMiddleware())
+	// Refuse blocked client IPs before any route, /metrics included
+	if cfg.Security != nil && cfg.Security.IPFilter != nil && cfg.Security.IPFilter.Enabled {
+		filter, err := ipFilter(cfg.Security.IPFilter)
+		if err != nil {
+			return nil, err
+		}
+		router.Use(filter.Handler())
 	}
 
+	// Expose Prometheus metrics endpoint; OpenMetrics carries the trace
+	// exemplars of the latency histograms
+	router.GET("/metrics", gin.WrapH(promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)))
+
+	// Add CORS middleware (policy may change on config reload)
+	router.Use(cors.Handler())
+
+	// Cap request bodies and handler run time
+	limits := requestLimits(cfg)
+	router.Use(limits.BodyLimit())
+	router.Use(limits.Timeout())
+
+	// Accept only the body formats each route reads
+	router.Use(contentPolicies(cfg).Handler())
+
+	// Unknown routes and methods answer with the error envelope too
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(response.NoRoute)
+	router.NoMethod(response.NoMethod)
+
 	// Health check endpoint
 	router.GET("/health", func(ctx *gin.Context) {
 		// Check database health
-		if err := c.Database.Health(); err != nil {
+		if err := c.Database().Health(); err != nil {
 			ctx.JSON(http.StatusServiceUnavailable, gin.H{
 				"status": "unhealthy",
 				"error":  err.Error(),
@@ -96,51 +262,395 @@ func setupRouter(c *container.Container) *gin.Engine {
 
 		ctx.JSON(http.StatusOK, gin.H{
 			"status":      "healthy",
-			"app":         c.Config.App.Name,
-			"version":     c.Config.App.Version,
-			"environment": c.Config.App.Environment,
+			"app":         cfg.App.Name,
+			"version":     cfg.App.Version,
+			"environment": cfg.App.Environment,
 		})
 	})
 
-	// API version 1
-	v1 := router.Group("/api/v1")
+	// Kubernetes probes: liveness never touches dependencies, readiness pings them
+	router.GET("/healthz", h.Health.Liveness)
+	router.GET("/readyz", h.Health.Readiness)
+
+	// Token verification keys for services validating our access tokens
+	router.GET("/.well-known/jwks.json", h.JWKS.JWKS)
+
+	// Bundled management UI; it calls the API below with the user's token
+	if h.AdminUI != nil {
+		ui := cfg.Server.AdminUI.MountPath()
+		router.GET(ui+"/*filepath", h.AdminUI.Serve)
+		router.HEAD(ui+"/*filepath", h.AdminUI.Serve)
+	}
+
+	// Every API version serves the same routes; v1 is frozen and may be
+	// scheduled for retirement, v2 is where payloads evolve
+	for _, v := range apihttp.APIVersions {
+		if v == apihttp.APIv2 && cfg.API != nil && !cfg.API.V2 {
+			continue
+		}
+		handlers := []gin.HandlerFunc{apihttp.UseAPIVersion(v)}
+		if d, ok := v1Deprecation(cfg.API); ok && v == apihttp.APIv1 {
+			handlers = append(handlers, middleware.DeprecationMiddleware(d))
+		}
+		apiRoutes(router.Group(v.Prefix(), handlers...), c)
+	}
+
+	return router, nil
+}
+
+// apiRoutes registers the routes of one API version on its group.
+// Handlers map results to the payloads of the version serving the request.
+func apiRoutes(api *gin.RouterGroup, c *container.Container) {
+	h := c.Handlers()
+
+	// Authentication routes (public endpoints)
+	auth := api.Group("/auth")
+	{
+		auth.POST("/login", h.Auth.Login)                                     // Public: login
+		auth.POST("/logout", c.AuthMiddleware().RequireAuth(), h.Auth.Logout) // Protected: logout requires valid token
+		auth.GET("/me", c.AuthMiddleware().RequireAuth(), h.Auth.GetMe)       // Protected: get current user
+	}
+
+	// Build of the running binary (public)
+	api.GET("/version", h.Version.GetVersion)
+
+	// Error code catalog (public)
+	api.GET("/errors", h.ErrorCatalog.ListErrors)
+	api.GET("/errors/:code", h.ErrorCatalog.GetError)
+
+	// Initial admin bootstrap (public, guarded by the one-time setup token)
+	setup := api.Group("/setup")
 	{
-		// Authentication routes (public endpoints)
-		auth := v1.Group("/auth")
+		setup.GET("/status", h.Setup.Status)
+		setup.POST("/admin", h.Setup.BootstrapAdmin)
+	}
+
+	// Administration (admin role required)
+	admin := api.Group("/admin", c.AuthMiddleware().RequireAuth(), c.AdminOnly())
+	{
+		admin.GET("/audit-logs", middleware.RequireTenant(tenant.DefaultID), h.Audit.ListAuditLogs) // Audit log is not split by tenant
+		admin.GET("/stats", middleware.RequireTenant(tenant.DefaultID), h.Stats.GetStats)           // Activity comes from the audit log
+		admin.GET("/users/export", h.Transfer.ExportUsers)
+		admin.POST("/users/import", h.Transfer.ImportUsers)
+		admin.POST("/users/:id/suspend", h.User.SuspendUser)
+		admin.POST("/users/:id/deactivate", h.User.DeactivateUser)
+		admin.POST("/users/:id/reactivate", h.User.ReactivateUser)
+		admin.GET("/ids/:id/decode", h.ID.DecodeID) // Debug ID provenance across services
+
+		// Tenants are managed by administrators of the default tenant
+		tenants := admin.Group("/tenants", middleware.RequireTenant(tenant.DefaultID))
 		{
-			auth.POST("/login", c.AuthHandler.Login)                                   // Public: login
-			auth.POST("/logout", c.AuthMiddleware.RequireAuth(), c.AuthHandler.Logout) // Protected: logout requires valid token
-			auth.GET("/me", c.AuthMiddleware.RequireAuth(), c.AuthHandler.GetMe)       // Protected: get current user
+			tenants.POST("", h.Tenant.CreateTenant)
+			tenants.GET("", h.Tenant.ListTenants)
+			tenants.GET("/:id", h.Tenant.GetTenant)
+			tenants.DELETE("/:id", h.Tenant.DeleteTenant)
 		}
 
-		// User routes
-		users := v1.Group("/users")
+		// The log level is process-wide; overrides apply to the instance
+		// serving the request
+		logLevel := admin.Group("/log-level", middleware.RequireTenant(tenant.DefaultID))
 		{
-			users.POST("/register", c.UserHandler.Register)                                          // Public: registration
-			users.GET("", c.AuthMiddleware.OptionalAuth(), c.UserHandler.ListUsers)                  // Optional auth: may filter results based on user role
-			users.GET("/:id", c.AuthMiddleware.RequireAuth(), c.UserHandler.GetProfile)              // Protected: get user profile
-			users.PUT("/:id", c.AuthMiddleware.RequireAuth(), c.UserHandler.UpdateProfile)           // Protected: update profile
-			users.PUT("/:id/password", c.AuthMiddleware.RequireAuth(), c.UserHandler.ChangePassword) // Protected: change password
-			users.DELETE("/:id", c.AuthMiddleware.RequireAuth(), c.UserHandler.DeleteUser)           // Protected: delete user
+			logLevel.GET("", h.LogLevel.GetLogLevel)
+			logLevel.PUT("", h.LogLevel.SetLogLevel)
+			logLevel.DELETE("", h.LogLevel.ClearLogLevel)
+		}
+
+		// The job queue is shared by all tenants
+		if h.Job != nil {
+			jobs := admin.Group("/jobs", middleware.RequireTenant(tenant.DefaultID))
+			{
+				jobs.GET("", h.Job.GetStats)
+				jobs.POST("", h.Job.EnqueueJob)
+				jobs.GET("/dead", h.Job.ListDeadJobs)
+				jobs.POST("/dead/:id/retry", h.Job.RetryDeadJob)
+				jobs.DELETE("/dead/:id", h.Job.DeleteDeadJob)
+			}
 		}
+
+		// Webhook endpoints receive user events of every tenant
+		if h.Webhook != nil {
+			webhooks := admin.Group("/webhooks", middleware.RequireTenant(tenant.DefaultID))
+			{
+				webhooks.POST("", h.Webhook.CreateWebhook)
+				webhooks.GET("", h.Webhook.ListWebhooks)
+				webhooks.GET("/:id", h.Webhook.GetWebhook)
+				webhooks.DELETE("/:id", h.Webhook.DeleteWebhook)
+				webhooks.GET("/:id/deliveries", h.Webhook.ListDeliveries)
+				webhooks.POST("/:id/deliveries/:delivery_id/redeliver", h.Webhook.Redeliver)
+			}
+		}
+	}
+
+	// User routes
+	users := api.Group("/users")
+	{
+		users.POST("/register", h.User.Register)                                                     // Public: registration
+		users.GET("", c.AuthMiddleware().OptionalAuth(), h.User.ListUsers)                           // Optional auth: may filter results based on user role
+		users.GET("/search", c.AuthMiddleware().RequireAuth(), h.UserSearch.SearchUsers)             // Protected: ranked search by name or email
+		users.GET("/handle-availability", c.HandleCheckLimit(), h.User.CheckHandleAvailability)      // Public: whether a handle can be claimed, throttled per client IP
+		users.GET("/by-handle/:handle", c.AuthMiddleware().RequireAuth(), h.User.GetByHandle)        // Protected: get user profile by handle
+		users.GET("/me", c.AuthMiddleware().RequireAuth(), h.User.GetMe)                             // Protected: get own profile
+		users.PUT("/me", c.AuthMiddleware().RequireAuth(), h.User.UpdateMe)                          // Protected: update own profile
+		users.PATCH("/me", c.AuthMiddleware().RequireAuth(), h.User.PatchMe)                         // Protected: patch own profile
+		users.DELETE("/me", c.AuthMiddleware().RequireAuth(), h.User.DeleteMe)                       // Protected: schedule deletion of own account
+		users.POST("/me/deletion/cancel", c.AuthMiddleware().RequireAuth(), h.User.CancelMyDeletion) // Protected: keep own account
+		users.PATCH("/me/password", c.AuthMiddleware().RequireAuth(), h.User.ChangeMyPassword)       // Protected: change own password
+		users.GET("/:id", c.AuthMiddleware().RequireAuth(), h.User.GetProfile)                       // Protected: get user profile
+		users.PUT("/:id", c.AuthMiddleware().RequireAuth(), h.User.UpdateProfile)                    // Protected: update own profile, or any as admin
+		users.PATCH("/:id", c.AuthMiddleware().RequireAuth(), h.User.PatchProfile)                   // Protected: patch own profile, or any as admin
+		users.PUT("/:id/password", c.AuthMiddleware().RequireAuth(), h.User.ChangePassword)          // Protected: change own password, or any as admin
+		users.DELETE("/:id", c.AuthMiddleware().RequireAuth(), h.User.DeleteUser)                    // Protected: delete own account, or any as admin
+	}
+
+	// Personal data exports are generated by background jobs
+	if h.DataExport != nil {
+		users.POST("/me/export", c.AuthMiddleware().RequireAuth(), h.DataExport.RequestExport) // Protected: request own data export
+		users.GET("/me/export", c.AuthMiddleware().RequireAuth(), h.DataExport.GetExport)      // Protected: download own data export
+	}
+
+	// Two-factor authentication: the second login step takes the MFA
+	// token issued by login, and users manage their own authenticator
+	if h.MFA != nil {
+		auth.POST("/mfa/verify", h.Auth.VerifyMFA)                     // Public: exchange MFA token and code for an access token
+		auth.POST("/mfa/enroll", h.Auth.BeginMFAEnrollment)            // Public: enroll an authenticator the login requires
+		auth.POST("/mfa/enroll/confirm", h.Auth.CompleteMFAEnrollment) // Public: confirm it and complete the login
+
+		users.GET("/me/mfa", c.AuthMiddleware().RequireAuth(), h.MFA.GetStatus)                               // Protected: own two-factor status
+		users.POST("/me/mfa", c.AuthMiddleware().RequireAuth(), h.MFA.Enroll)                                 // Protected: start enrolling an authenticator
+		users.POST("/me/mfa/confirm", c.AuthMiddleware().RequireAuth(), h.MFA.Confirm)                        // Protected: enable two-factor with a first code
+		users.POST("/me/mfa/recovery-codes", c.AuthMiddleware().RequireAuth(), h.MFA.RegenerateRecoveryCodes) // Protected: replace recovery codes
+		users.DELETE("/me/mfa", c.AuthMiddleware().RequireAuth(), h.MFA.Disable)                              // Protected: disable two-factor
+		users.GET("/me/mfa/devices", c.AuthMiddleware().RequireAuth(), h.MFA.ListDevices)                     // Protected: list trusted devices
+		users.DELETE("/me/mfa/devices/:id", c.AuthMiddleware().RequireAuth(), h.MFA.RevokeDevice)             // Protected: revoke a trusted device
+	}
+
+	// Per-user settings stored by product features
+	if h.Preference != nil {
+		users.GET("/me/preferences", c.AuthMiddleware().RequireAuth(), h.Preference.ListPreferences)          // Protected: list own preferences
+		users.GET("/me/preferences/:key", c.AuthMiddleware().RequireAuth(), h.Preference.GetPreference)       // Protected: get one of own preferences
+		users.PUT("/me/preferences/:key", c.AuthMiddleware().RequireAuth(), h.Preference.SetPreference)       // Protected: set one of own preferences
+		users.DELETE("/me/preferences/:key", c.AuthMiddleware().RequireAuth(), h.Preference.DeletePreference) // Protected: remove one of own preferences
 	}
 
-	return router
+	// In-app notifications of account changes
+	if h.Notification != nil {
+		users.GET("/me/notifications", c.AuthMiddleware().RequireAuth(), h.Notification.ListNotifications)        // Protected: list own notifications
+		users.GET("/me/notifications/unread-count", c.AuthMiddleware().RequireAuth(), h.Notification.UnreadCount) // Protected: count own unread notifications
+		users.POST("/me/notifications/read", c.AuthMiddleware().RequireAuth(), h.Notification.MarkAllRead)        // Protected: mark all own notifications read
+		users.POST("/me/notifications/:id/read", c.AuthMiddleware().RequireAuth(), h.Notification.MarkRead)       // Protected: mark one own notification read
+	}
+
+	// Organizations are only visible to their members, and only their
+	// owner invites members or hands over ownership
+	if h.Organization != nil {
+		orgs := api.Group("/organizations", c.AuthMiddleware().RequireAuth())
+		orgs.POST("", h.Organization.CreateOrganization)                       // Protected: create an organization owned by the caller
+		orgs.GET("", h.Organization.ListOrganizations)                         // Protected: list own organizations
+		orgs.GET("/:id", h.Organization.GetOrganization)                       // Protected, members: get an organization
+		orgs.GET("/:id/members", h.Organization.ListMembers)                   // Protected, members: list members
+		orgs.POST("/:id/transfer-ownership", h.Organization.TransferOwnership) // Protected, owner: make another member the owner
+	}
+
+	// Invitations are emailed as signed links that expire; whoever holds
+	// a link may preview it and register with the invited email
+	if h.Invitation != nil {
+		invitations := api.Group("/invitations")
+		invitations.POST("", c.AuthMiddleware().RequireAuth(), h.Invitation.CreateInvitation)            // Protected, owner: email an invitation link
+		invitations.GET("", c.AuthMiddleware().RequireAuth(), h.Invitation.ListInvitations)              // Protected, owner: list an organization's invitations
+		invitations.GET("/preview", h.Invitation.PreviewInvitation)                                      // Public: describe the invitation of a link
+		invitations.POST("/accept", c.AuthMiddleware().RequireAuth(), h.Invitation.AcceptInvitation)     // Protected: join through an invitation to own email
+		invitations.POST("/register", h.Invitation.RegisterWithInvitation)                               // Public: register the invited email and join
+		invitations.POST("/:id/resend", c.AuthMiddleware().RequireAuth(), h.Invitation.ResendInvitation) // Protected, owner: email a new link
+		invitations.DELETE("/:id", c.AuthMiddleware().RequireAuth(), h.Invitation.RevokeInvitation)      // Protected, owner: revoke an invitation
+	}
+
+	// Email verification links are signed and expire; whoever holds one
+	// may open it
+	if h.Verification != nil {
+		auth.POST("/verify-email", h.Verification.VerifyEmail)                                                    // Public: confirm the address a link was sent to
+		users.POST("/me/email/verification", c.AuthMiddleware().RequireAuth(), h.Verification.ResendVerification) // Protected: email own verification link again
+	}
+
+	// Avatars in object storage. Avatar and object URLs are public so
+	// img tags can load them; random names and signatures guard them.
+	if h.Avatar != nil {
+		users.POST("/me/avatar", c.AuthMiddleware().RequireAuth(), h.Avatar.UploadAvatar)   // Protected: upload own avatar
+		users.DELETE("/me/avatar", c.AuthMiddleware().RequireAuth(), h.Avatar.DeleteAvatar) // Protected: remove own avatar
+		api.GET("/avatars/:file", h.Avatar.GetAvatar)                                       // Public: redirect to a presigned avatar URL
+	}
+	if h.Object != nil {
+		api.GET("/objects/*key", h.Object.GetObject) // Public: presigned local object URLs
+	}
 }
 
-// corsMiddleware adds CORS headers
-func corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+// avatarMaxParts leaves room for a few form fields next to the image
+const avatarMaxParts = 4
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+// multipartOverhead is what the multipart framing of a single file upload
+// may add to the file
+const multipartOverhead = 64 * 1024
+
+// requestLimits builds the body size and handler time limits. Import takes
+// large uploads and export streams for as long as the download runs, so
+// both are exempt from the defaults unless server.routes says otherwise.
+// Avatar uploads may be as large as users.avatar_max_bytes plus room for
+// the multipart framing.
+func requestLimits(cfg *config.Config) *middleware.RequestLimits {
+	server := cfg.Server
+	importBytes := server.MaxBodyBytes
+	if cfg.Import != nil {
+		importBytes = cfg.Import.MaxBodyBytes
+	}
+	routes := map[string]middleware.Limits{
+		"POST /api/v1/admin/users/import": {MaxBodyBytes: int64(importBytes)},
+		"GET /api/v1/admin/users/export":  {MaxBodyBytes: int64(server.MaxBodyBytes)},
+	}
+	if cfg.Users != nil {
+		routes["POST /api/v1/users/me/avatar"] = middleware.Limits{MaxBodyBytes: int64(cfg.Users.AvatarMaxBytes) + multipartOverhead, Timeout: server.HandlerTimeout}
+	}
+	for _, r := range server.Routes {
+		routes[r.Route] = middleware.Limits{MaxBodyBytes: int64(r.MaxBodyBytes), Timeout: r.HandlerTimeout}
+	}
+	everyAPIVersion(routes)
+	return middleware.NewRequestLimits(middleware.Limits{
+		MaxBodyBytes: int64(server.MaxBodyBytes),
+		Timeout:      server.HandlerTimeout,
+	}, routes)
+}
+
+// contentPolicies lists the body formats of the routes that read anything
+// but JSON. PATCH handlers negotiate the patch format themselves, answering
+// with Accept-Patch. Avatars must be images by content, whatever the client
+// claims.
+func contentPolicies(cfg *config.Config) *middleware.ContentPolicies {
+	routes := map[string]middleware.ContentPolicy{
+		"PATCH /api/v1/users/me":  {},
+		"PATCH /api/v1/users/:id": {},
+		"POST /api/v1/admin/users/import": {
+			MediaTypes: []string{"text/csv", "text/plain", "application/x-ndjson", "application/ndjson"},
+		},
+	}
+	if cfg.Users != nil {
+		routes["POST /api/v1/users/me/avatar"] = middleware.ContentPolicy{
+			MediaTypes: []string{"multipart/form-data"},
+			Multipart: &middleware.MultipartPolicy{
+				MaxParts:     avatarMaxParts,
+				MaxPartBytes: int64(cfg.Users.AvatarMaxBytes),
+				FileTypes:    []string{"image/jpeg", "image/png", "image/gif"},
+			},
+		}
+	}
+	everyAPIVersion(routes)
+	return middleware.NewContentPolicies(middleware.ContentPolicy{MediaTypes: []string{"application/json"}}, routes)
+}
+
+// v1Deprecation returns how v1 responses announce its retirement; false
+// while it is not scheduled
+func v1Deprecation(cfg *config.APIConfig) (middleware.Deprecation, bool) {
+	if cfg == nil || (cfg.V1DeprecatedAt == "" && cfg.V1SunsetAt == "") {
+		return middleware.Deprecation{}, false
+	}
+	return middleware.Deprecation{
+		At:        cfg.V1Deprecation(),
+		Sunset:    cfg.V1Sunset(),
+		Link:      cfg.V1DeprecationLink.String(),
+		Prefix:    apihttp.APIv1.Prefix(),
+		Successor: apihttp.APIv2.Prefix(),
+	}, true
+}
+
+// everyAPIVersion applies the entries of routes, keyed "METHOD path", that
+// name a v1 path to the same path in later versions too, unless those are
+// listed themselves
+func everyAPIVersion[T any](routes map[string]T) {
+	for route, value := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		later, ok := laterAPIVersions(path)
+		if !ok {
+			continue
+		}
+		for _, p := range later {
+			if _, listed := routes[method+" "+p]; !listed {
+				routes[method+" "+p] = value
+			}
+		}
+	}
+}
+
+// laterAPIVersions returns path under the prefixes of the versions after
+// v1; false when path is not under /api/v1
+func laterAPIVersions(path string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(path, apihttp.APIv1.Prefix())
+	if !ok || (rest != "" && rest[0] != '/') {
+		return nil, false
+	}
+	var paths []string
+	for _, v := range apihttp.APIVersions[1:] {
+		paths = append(paths, v.Prefix()+rest)
+	}
+	return paths, true
+}
+
+// ipFilter converts the IP filter section into middleware rules. Groups
+// under /api/v1 cover the same paths of later API versions.
+func ipFilter(cfg *config.IPFilterConfig) (*middleware.IPFilter, error) {
+	global, err := ipRules(cfg.Allow, cfg.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("ip_filter: %w", err)
+	}
+	groups := make(map[string]middleware.IPRules, len(cfg.Groups))
+	for _, g := range cfg.Groups {
+		rules, err := ipRules(g.Allow, g.Deny)
+		if err != nil {
+			return nil, fmt.Errorf("ip_filter group %s: %w", g.PathPrefix, err)
 		}
+		groups[g.PathPrefix] = rules
+	}
+	for prefix, rules := range groups {
+		if later, ok := laterAPIVersions(prefix); ok {
+			for _, p := range later {
+				if _, listed := groups[p]; !listed {
+					groups[p] = rules
+				}
+			}
+		}
+	}
+	return middleware.NewIPFilter(global, groups), nil
+}
 
-		c.Next()
+func ipRules(allow, deny []string) (middleware.IPRules, error) {
+	allowed, err := config.ParseIPRanges(allow)
+	if err != nil {
+		return middleware.IPRules{}, err
 	}
+	denied, err := config.ParseIPRanges(deny)
+	if err != nil {
+		return middleware.IPRules{}, err
+	}
+	return middleware.IPRules{Allow: allowed, Deny: denied}, nil
+}
+
+// corsPolicy returns the CORS policy of the server section, or nil while
+// CORS is disabled
+func corsPolicy(cfg *config.ServerConfig) *middleware.CORSPolicy {
+	if !cfg.EnableCORS {
+		return nil
+	}
+	cors := cfg.CORS
+	if cors == nil {
+		cors = config.DefaultCORSConfig()
+	}
+	return &middleware.CORSPolicy{
+		AllowedOrigins:   cors.AllowedOrigins,
+		AllowedMethods:   cors.AllowedMethods,
+		AllowedHeaders:   cors.AllowedHeaders,
+		ExposedHeaders:   cors.ExposedHeaders,
+		AllowCredentials: cors.AllowCredentials,
+		MaxAge:           cors.MaxAge,
+	}
+}
+
+// Routes lists the routes the server serves
+func (s *Server) Routes() gin.RoutesInfo {
+	return s.httpServer.Handler.(*gin.Engine).Routes()
 }
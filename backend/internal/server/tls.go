@@ -0,0 +1,106 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+)
+
+// certReloader serves a certificate loaded from disk and swaps it on Reload,
+// so renewed certificates take effect without dropping connections
+type certReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads the certificate and key. On failure the previous
+// certificate stays in use.
+func (r *certReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %w", err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// newTLSConfig builds the listener TLS configuration. It returns the
+// reloader for file certificates, or nil when autocert manages them.
+func newTLSConfig(cfg *config.TLSConfig, manager *autocert.Manager) (*tls.Config, *certReloader, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if manager != nil {
+		tlsConfig.GetCertificate = manager.GetCertificate
+		// Answer tls-alpn-01 challenges on the HTTPS port
+		tlsConfig.NextProtos = []string{"acme-tls/1"}
+		return tlsConfig, nil, nil
+	}
+
+	certs, err := newCertReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsConfig.GetCertificate = certs.GetCertificate
+	return tlsConfig, certs, nil
+}
+
+// newAutocertManager returns the ACME manager, or nil unless autocert is on
+func newAutocertManager(cfg *config.TLSConfig) *autocert.Manager {
+	if !cfg.Autocert {
+		return nil
+	}
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+		Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+		Email:      cfg.AutocertEmail,
+	}
+}
+
+// protocols returns the protocols offered through ALPN
+func protocols(cfg *config.TLSConfig) *http.Protocols {
+	var p http.Protocols
+	p.SetHTTP1(true)
+	p.SetHTTP2(cfg.HTTP2)
+	return &p
+}
+
+// redirectHandler sends plain HTTP requests to the same URL over HTTPS.
+// GET and HEAD get 301; other methods get 308 so clients resend the body.
+func redirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
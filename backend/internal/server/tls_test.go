@@ -0,0 +1,119 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+)
+
+// writeCertificate writes a self-signed certificate for name to dir
+func writeCertificate(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "old.example.com")
+
+	certs, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	commonName := func() string {
+		cert, err := certs.GetCertificate(nil)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return leaf.Subject.CommonName
+	}
+	assert.Equal(t, "old.example.com", commonName())
+
+	writeCertificate(t, dir, "new.example.com")
+	require.NoError(t, certs.Reload())
+	assert.Equal(t, "new.example.com", commonName())
+
+	// A broken file keeps the current certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	assert.Error(t, certs.Reload())
+	assert.Equal(t, "new.example.com", commonName())
+
+	_, err = newCertReloader(filepath.Join(dir, "missing.crt"), keyFile)
+	assert.Error(t, err)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, t.TempDir(), "api.example.com")
+	cfg := &config.TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, HTTP2: true}
+
+	tlsConfig, certs, err := newTLSConfig(cfg, nil)
+	require.NoError(t, err)
+	require.NotNil(t, certs)
+	assert.NotNil(t, tlsConfig.GetCertificate)
+
+	cfg.Autocert, cfg.CertFile, cfg.KeyFile = true, "", ""
+	cfg.AutocertDomains = []string{"api.example.com"}
+	cfg.AutocertCacheDir = t.TempDir()
+	tlsConfig, certs, err = newTLSConfig(cfg, newAutocertManager(cfg))
+	require.NoError(t, err)
+	assert.Nil(t, certs)
+	assert.Contains(t, tlsConfig.NextProtos, "acme-tls/1")
+
+	assert.True(t, protocols(cfg).HTTP2())
+	cfg.HTTP2 = false
+	assert.False(t, protocols(cfg).HTTP2())
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		method   string
+		target   string
+		port     int
+		status   int
+		location string
+	}{
+		{http.MethodGet, "http://api.example.com/api/v1/users?page=2", 443, http.StatusMovedPermanently, "https://api.example.com/api/v1/users?page=2"},
+		{http.MethodGet, "http://api.example.com:8080/health", 8443, http.StatusMovedPermanently, "https://api.example.com:8443/health"},
+		{http.MethodPost, "http://api.example.com/api/v1/auth/login", 443, http.StatusPermanentRedirect, "https://api.example.com/api/v1/auth/login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			redirectHandler(tt.port).ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,361 @@
+// Package contract pins the HTTP contract of the API. Scenarios drive a
+// running server through a Session, which records every request and
+// response. Recordings are sanitized of generated values such as IDs,
+// tokens and times, and compared with golden files, so any change to a
+// status code, header or JSON body fails until the golden files are
+// rewritten on purpose.
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Interaction is one recorded request and the response it got
+type Interaction struct {
+	// Name describes the call within its scenario
+	Name string `json:"name"`
+	// Route is the route template that served the request, such as
+	// "GET /api/v1/users/:id"; empty when none matched
+	Route    string   `json:"route"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a sanitized request
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Response is a sanitized response. Bodies that are not JSON are kept as
+// a JSON string.
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Result is the unsanitized response of a call, for the scenario to read
+// the values it passes on to later calls
+type Result struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Get returns the value at path in the JSON body as a string; path names
+// object keys and array indexes separated by dots, such as
+// "data.users.0.id". It is empty when the path does not exist.
+func (r *Result) Get(path string) string {
+	var v interface{}
+	if json.Unmarshal(r.Body, &v) != nil {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return ""
+			}
+			v = node[i]
+		default:
+			return ""
+		}
+	}
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
+
+// Option adjusts a call
+type Option func(*call)
+
+// call is a request being made and how its response is recorded
+type call struct {
+	req       *http.Request
+	shapeOnly bool
+}
+
+// Bearer authenticates the request with an access token
+func Bearer(token string) Option {
+	return Header("Authorization", "Bearer "+token)
+}
+
+// Header sets a request header
+func Header(key, value string) Option {
+	return func(c *call) {
+		c.req.Header.Set(key, value)
+	}
+}
+
+// ShapeOnly records only the shape of the response body: the keys of
+// objects, the type of values and the shape of the first element of
+// arrays. It is for responses whose values depend on the clock or on
+// work done in the background, such as statistics.
+func ShapeOnly() Option {
+	return func(c *call) {
+		c.shapeOnly = true
+	}
+}
+
+// Raw is a request body sent as is with its content type, for bodies
+// that are not JSON
+type Raw struct {
+	ContentType string
+	Data        []byte
+}
+
+// Session records the interactions of one scenario against a server
+type Session struct {
+	t            testing.TB
+	baseURL      string
+	client       *http.Client
+	routes       gin.RoutesInfo
+	interactions []Interaction
+	frozen       []string
+}
+
+// NewSession starts recording calls to the server at baseURL. routes are
+// the server's routes, to name the one serving each call.
+func NewSession(t testing.TB, baseURL string, client *http.Client, routes gin.RoutesInfo) *Session {
+	return &Session{t: t, baseURL: baseURL, client: client, routes: routes}
+}
+
+// Freeze keeps the recordings of routes under the path prefixes from being
+// rewritten: Verify with update fails when one of them changed or is no
+// longer made, and writes nothing. Only new interactions with those routes
+// are recorded; changing a frozen recording means editing the golden file.
+func (s *Session) Freeze(prefixes ...string) {
+	s.frozen = append(s.frozen, prefixes...)
+}
+
+// Do sends a request and records it under name. body is encoded as JSON
+// unless it is nil or a Raw body. It fails the test when the server
+// cannot be reached.
+func (s *Session) Do(name, method, path string, body interface{}, opts ...Option) *Result {
+	s.t.Helper()
+
+	var payload []byte
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case Raw:
+		payload, contentType = b.Data, b.ContentType
+	default:
+		data, err := json.Marshal(b)
+		require.NoError(s.t, err)
+		payload, contentType = data, "application/json"
+	}
+
+	req, err := http.NewRequest(method, s.baseURL+path, bytes.NewReader(payload))
+	require.NoError(s.t, err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c := &call{req: req}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	resp, err := s.client.Do(req)
+	require.NoError(s.t, err, "%s: %s %s", name, method, path)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(s.t, err)
+
+	respBody := sanitizeBody(data, resp.Header.Get("Content-Type"), redactedKeys)
+	if c.shapeOnly {
+		respBody = shapeOf(respBody)
+	}
+	s.interactions = append(s.interactions, Interaction{
+		Name:  name,
+		Route: matchRoute(s.routes, method, req.URL.Path),
+		Request: Request{
+			Method:  method,
+			Path:    sanitizeString(req.URL.RequestURI()),
+			Headers: sanitizeHeaders(req.Header, nil),
+			Body:    sanitizeBody(payload, contentType, requestRedactedKeys),
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: sanitizeHeaders(resp.Header, ignoredResponseHeaders),
+			Body:    respBody,
+		},
+	})
+	return &Result{Status: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// Interactions returns what the session recorded so far
+func (s *Session) Interactions() []Interaction {
+	return s.interactions
+}
+
+// Verify compares the recorded interactions with the golden file, or
+// rewrites it when update is set. Interactions are compared in order and
+// in full: request, status, headers and body.
+func (s *Session) Verify(golden string, update bool) {
+	s.t.Helper()
+
+	got := marshal(s.interactions, "  ")
+	require.NotNil(s.t, got)
+	if update && len(s.frozen) > 0 {
+		if data, err := os.ReadFile(golden); err == nil {
+			var want []Interaction
+			require.NoError(s.t, json.Unmarshal(data, &want))
+			if changed := frozenChanges(want, s.interactions, s.frozen); len(changed) > 0 {
+				for _, name := range changed {
+					s.t.Errorf("%s: interaction %q is frozen and changed; edit the golden file by hand if this is intended", golden, name)
+				}
+				return
+			}
+		}
+	}
+	if update {
+		require.NoError(s.t, os.MkdirAll(filepath.Dir(golden), 0o755))
+		require.NoError(s.t, os.WriteFile(golden, append(got, '\n'), 0o644))
+		return
+	}
+
+	data, err := os.ReadFile(golden)
+	require.NoError(s.t, err, "missing golden file; record it with -update")
+	var want []Interaction
+	require.NoError(s.t, json.Unmarshal(data, &want))
+
+	for i := 0; i < len(want) || i < len(s.interactions); i++ {
+		switch {
+		case i >= len(s.interactions):
+			s.t.Errorf("%s: interaction %q was not made", golden, want[i].Name)
+		case i >= len(want):
+			s.t.Errorf("%s: interaction %q is not recorded", golden, s.interactions[i].Name)
+		default:
+			expected := marshal(want[i], "")
+			actual := marshal(s.interactions[i], "")
+			assert.JSONEq(s.t, string(expected), string(actual), "%s: interaction %q changed", golden, want[i].Name)
+		}
+	}
+}
+
+// frozenChanges names the interactions of want with a route under one of
+// the prefixes that got no longer has, or has with another recording.
+// Interactions are matched by name, in order among those sharing a name.
+func frozenChanges(want, got []Interaction, prefixes []string) []string {
+	made := make(map[string][]Interaction, len(got))
+	for _, in := range got {
+		made[in.Name] = append(made[in.Name], in)
+	}
+	seen := make(map[string]int, len(want))
+	var changed []string
+	for _, in := range want {
+		n := seen[in.Name]
+		seen[in.Name]++
+		if !underPrefix(in.Route, prefixes) {
+			continue
+		}
+		if n >= len(made[in.Name]) || !sameJSON(in, made[in.Name][n]) {
+			changed = append(changed, in.Name)
+		}
+	}
+	return changed
+}
+
+// sameJSON reports whether a and b encode to equal JSON values
+func sameJSON(a, b interface{}) bool {
+	var x, y interface{}
+	if json.Unmarshal(marshal(a, ""), &x) != nil || json.Unmarshal(marshal(b, ""), &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// underPrefix reports whether the path of route, as "METHOD /path", is
+// under one of the prefixes
+func underPrefix(route string, prefixes []string) bool {
+	_, path, _ := strings.Cut(route, " ")
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Uncovered lists the routes, as "METHOD /path", that none of the
+// interactions reached
+func Uncovered(routes gin.RoutesInfo, interactions []Interaction) []string {
+	covered := make(map[string]bool, len(interactions))
+	for _, in := range interactions {
+		covered[in.Route] = true
+	}
+	var missing []string
+	for _, r := range routes {
+		if route := r.Method + " " + r.Path; !covered[route] {
+			missing = append(missing, route)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// matchRoute returns the route serving method and path, preferring static
+// segments over parameters like the router does
+func matchRoute(routes gin.RoutesInfo, method, path string) string {
+	best, bestScore := "", -1
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, r := range routes {
+		if r.Method != method {
+			continue
+		}
+		if score, ok := matchSegments(strings.Split(strings.Trim(r.Path, "/"), "/"), segments); ok && score > bestScore {
+			best, bestScore = fmt.Sprintf("%s %s", r.Method, r.Path), score
+		}
+	}
+	return best
+}
+
+// matchSegments reports whether the segments of a path match those of a
+// route template, scoring one for each static segment
+func matchSegments(template, path []string) (int, bool) {
+	score := 0
+	for i, seg := range template {
+		if strings.HasPrefix(seg, "*") {
+			return score, true
+		}
+		if i >= len(path) {
+			return 0, false
+		}
+		switch {
+		case strings.HasPrefix(seg, ":"):
+		case seg == path[i]:
+			score++
+		default:
+			return 0, false
+		}
+	}
+	return score, len(template) == len(path)
+}
@@ -0,0 +1,196 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Generated values are replaced with placeholders so that recordings are
+// the same on every run and hold no secrets
+var (
+	jwtPattern       = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	timePattern      = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+	httpDatePattern  = regexp.MustCompile(`[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} GMT`)
+	compactPattern   = regexp.MustCompile(`\b\d{8}T\d{6}Z\b`)
+	datePattern      = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`)
+	uuidPattern      = regexp.MustCompile(`\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	snowflakePattern = regexp.MustCompile(`\b\d{15,20}\b`)
+)
+
+// redactedKeys are JSON keys whose values are secret, random or measured
+// whatever their format; their values become "<key>"
+var redactedKeys = map[string]bool{
+	"password":         true,
+	"old_password":     true,
+	"new_password":     true,
+	"token":            true,
+	"access_token":     true,
+	"mfa_token":        true,
+	"device_token":     true,
+	"secret":           true,
+	"provisioning_uri": true,
+	"recovery_codes":   true,
+	"link":             true,
+	"trace_id":         true,
+	"request_id":       true,
+	"setup_token":      true,
+	"go_version":       true,
+	"latency_ms":       true,
+	"uptime_seconds":   true,
+}
+
+// requestRedactedKeys are redacted in request bodies on top of
+// redactedKeys. Responses keep "code", which names errors there.
+var requestRedactedKeys = withKeys(redactedKeys, "code")
+
+// volatileHeaders change on every run; only their presence is recorded
+var volatileHeaders = map[string]bool{
+	"Etag":          true,
+	"If-None-Match": true,
+	"If-Match":      true,
+	"X-Trace-Id":    true,
+}
+
+func withKeys(keys map[string]bool, more ...string) map[string]bool {
+	out := make(map[string]bool, len(keys)+len(more))
+	for k := range keys {
+		out[k] = true
+	}
+	for _, k := range more {
+		out[k] = true
+	}
+	return out
+}
+
+// ignoredResponseHeaders are left out of recordings: they are set by the
+// HTTP server rather than the API
+var ignoredResponseHeaders = map[string]bool{
+	"Date":           true,
+	"Content-Length": true,
+}
+
+// sanitizeString replaces generated values in s
+func sanitizeString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, "<jwt>")
+	s = timePattern.ReplaceAllString(s, "<time>")
+	s = httpDatePattern.ReplaceAllString(s, "<http-date>")
+	s = compactPattern.ReplaceAllString(s, "<time>")
+	s = datePattern.ReplaceAllString(s, "<date>")
+	s = uuidPattern.ReplaceAllString(s, "<uuid>")
+	return snowflakePattern.ReplaceAllString(s, "<id>")
+}
+
+// sanitizeHeaders returns the headers to record, sanitized and with
+// repeated values joined, leaving out ignored ones
+func sanitizeHeaders(header http.Header, ignored map[string]bool) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(header))
+	for key, values := range header {
+		key = http.CanonicalHeaderKey(key)
+		switch {
+		case ignored[key]:
+		case volatileHeaders[key]:
+			out[key] = "<" + strings.ToLower(key) + ">"
+		default:
+			out[key] = sanitizeString(strings.Join(values, ", "))
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// sanitizeBody returns a JSON body with its generated values and the
+// values of redacted keys replaced, or any other body as a JSON string
+func sanitizeBody(data []byte, contentType string, redacted map[string]bool) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	var v interface{}
+	if strings.Contains(contentType, "json") && json.Unmarshal(data, &v) == nil {
+		v = sanitizeValue(redacted, "", v)
+	} else {
+		v = sanitizeString(string(data))
+	}
+	return marshal(v, "")
+}
+
+// sanitizeValue sanitizes the JSON value found under key
+func sanitizeValue(redacted map[string]bool, key string, v interface{}) interface{} {
+	if redacted[key] && v != nil {
+		if list, ok := v.([]interface{}); ok {
+			for i := range list {
+				list[i] = "<" + key + ">"
+			}
+			return list
+		}
+		return "<" + key + ">"
+	}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = sanitizeValue(redacted, k, item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = sanitizeValue(redacted, key, item)
+		}
+		return value
+	case string:
+		return sanitizeString(value)
+	default:
+		return v
+	}
+}
+
+// shapeOf reduces a sanitized JSON body to its shape
+func shapeOf(body json.RawMessage) json.RawMessage {
+	var v interface{}
+	if json.Unmarshal(body, &v) != nil {
+		return body
+	}
+	return marshal(shape(v), "")
+}
+
+func shape(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = shape(item)
+		}
+		return value
+	case []interface{}:
+		if len(value) == 0 {
+			return value
+		}
+		return []interface{}{shape(value[0])}
+	case string:
+		return "<string>"
+	case float64:
+		return "<number>"
+	case bool:
+		return "<bool>"
+	default:
+		return v
+	}
+}
+
+// marshal encodes v as JSON indented by indent, leaving the placeholders'
+// angle brackets unescaped so that recordings read as they are
+func marshal(v interface{}, indent string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
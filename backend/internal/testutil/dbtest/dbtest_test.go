@@ -0,0 +1,29 @@
+package dbtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+)
+
+func TestOpen_Migrated(t *testing.T) {
+	db := Open(t)
+
+	m := database.NewMigrator(db)
+	require.NoError(t, m.Check(context.Background()))
+	require.NoError(t, m.CheckTables())
+	assert.True(t, db.Migrator().HasTable("users"))
+}
+
+func TestOpen_Isolated(t *testing.T) {
+	a, b := Open(t), Open(t)
+	require.NoError(t, a.Exec("INSERT INTO bootstrap_markers (step, subject_id, completed_at) VALUES ('admin', 'u-1', CURRENT_TIMESTAMP)").Error)
+
+	var count int64
+	require.NoError(t, b.Table("bootstrap_markers").Count(&count).Error)
+	assert.Zero(t, count)
+}
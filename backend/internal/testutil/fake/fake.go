@@ -0,0 +1,4 @@
+// Package fake provides in-memory stand-ins for infrastructure, so tests can
+// run the application without Postgres, an SMTP server or node ID
+// allocation. Pass them to container.New through its options.
+package fake
@@ -0,0 +1,64 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/mailer"
+)
+
+func TestTokenService(t *testing.T) {
+	tokens := NewTokenService()
+
+	token, err := tokens.GenerateTokenForTenant("42", "acme")
+	require.NoError(t, err)
+	assert.Equal(t, Token("42", "acme"), token)
+
+	claims, err := tokens.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.UserID)
+	assert.Equal(t, "acme", claims.TenantID)
+
+	tokens.Revoke(token)
+	_, err = tokens.ValidateToken(token)
+	assert.Error(t, err)
+	_, err = tokens.ValidateToken("eyJhbGciOiJIUzI1NiJ9.e30.sig")
+	assert.Error(t, err)
+
+	mfaToken, err := tokens.GeneratePurposeToken("42", "", "mfa", time.Minute)
+	require.NoError(t, err)
+	claims, err = tokens.ValidatePurposeToken(mfaToken, "mfa")
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.UserID)
+	_, err = tokens.ValidateToken(mfaToken)
+	assert.Error(t, err, "purpose tokens are not access tokens")
+}
+
+func TestMailer(t *testing.T) {
+	m := NewMailer()
+	ctx := context.Background()
+
+	require.NoError(t, m.Send(ctx, &mailer.Message{To: "ada@example.com", Subject: "Welcome"}))
+	require.NoError(t, m.Send(ctx, &mailer.Message{To: "bob@example.com", Subject: "Welcome"}))
+	assert.Len(t, m.Messages(), 2)
+	assert.Len(t, m.SentTo("ada@example.com"), 1)
+
+	m.FailWith(errors.New("mailbox full"))
+	assert.Error(t, m.Send(ctx, &mailer.Message{To: "ada@example.com"}))
+	assert.Len(t, m.SentTo("ada@example.com"), 1)
+
+	m.Reset()
+	assert.Empty(t, m.Messages())
+}
+
+func TestIDGenerator(t *testing.T) {
+	gen := NewIDGenerator(7)
+	assert.Equal(t, "7", gen.Generate())
+	assert.Equal(t, int64(8), gen.GenerateInt64())
+	assert.Equal(t, "9", gen.Generate())
+}
@@ -0,0 +1,43 @@
+package fake
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// IDGenerator is an id.Generator handing out consecutive IDs, so tests can
+// predict them
+type IDGenerator struct {
+	next atomic.Int64
+}
+
+var _ id.Generator = (*IDGenerator)(nil)
+
+// NewIDGenerator creates a generator whose first ID is first
+func NewIDGenerator(first int64) *IDGenerator {
+	g := &IDGenerator{}
+	g.next.Store(first)
+	return g
+}
+
+// Generate implements id.Generator
+func (g *IDGenerator) Generate() string {
+	return strconv.FormatInt(g.GenerateInt64(), 10)
+}
+
+// GenerateInt64 implements id.Generator
+func (g *IDGenerator) GenerateInt64() int64 {
+	return g.next.Add(1) - 1
+}
+
+// GetNodeID implements id.Generator
+func (g *IDGenerator) GetNodeID() int64 {
+	return 0
+}
+
+// GetServiceType implements id.Generator
+func (g *IDGenerator) GetServiceType() id.ServiceType {
+	return id.ServiceTypeUser
+}
@@ -0,0 +1,65 @@
+package fake
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cctw-zed/wonder/pkg/mailer"
+)
+
+// Mailer is a mailer.Mailer that keeps sent messages for inspection
+type Mailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+	err      error
+}
+
+var _ mailer.Mailer = (*Mailer)(nil)
+
+// NewMailer creates a Mailer
+func NewMailer() *Mailer {
+	return &Mailer{}
+}
+
+// Send implements mailer.Mailer
+func (m *Mailer) Send(_ context.Context, msg *mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// FailWith makes Send return err until called with nil
+func (m *Mailer) FailWith(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Messages returns the messages sent so far, oldest first
+func (m *Mailer) Messages() []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mailer.Message(nil), m.messages...)
+}
+
+// SentTo returns the messages sent to address, oldest first
+func (m *Mailer) SentTo(address string) []mailer.Message {
+	var sent []mailer.Message
+	for _, msg := range m.Messages() {
+		if msg.To == address {
+			sent = append(sent, msg)
+		}
+	}
+	return sent
+}
+
+// Reset forgets the messages sent so far
+func (m *Mailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
@@ -0,0 +1,152 @@
+package fake
+
+import (
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/jwt"
+)
+
+// tokenPrefix marks tokens issued by TokenService
+const tokenPrefix = "fake-token."
+
+// TokenService is a jwt.TokenService issuing readable, unsigned tokens of
+// the form fake-token.<user ID>[.<tenant ID>], or
+// fake-token.<purpose>:<user or invitation ID>[.<tenant ID>] for purpose
+// tokens; email verification tokens append :<base64url email> to the
+// user ID. Tokens never expire unless revoked with Revoke.
+type TokenService struct {
+	mu      sync.Mutex
+	revoked map[string]bool
+}
+
+var _ jwt.TokenService = (*TokenService)(nil)
+
+// NewTokenService creates a TokenService
+func NewTokenService() *TokenService {
+	return &TokenService{revoked: make(map[string]bool)}
+}
+
+// Token returns the token TokenService issues for userID in tenantID,
+// e.g. to authenticate test requests without logging in
+func Token(userID, tenantID string) string {
+	if tenantID == "" {
+		return tokenPrefix + userID
+	}
+	return tokenPrefix + userID + "." + tenantID
+}
+
+// GenerateToken implements jwt.TokenService
+func (s *TokenService) GenerateToken(userID string) (string, error) {
+	return s.GenerateTokenForTenant(userID, "")
+}
+
+// GenerateTokenForTenant implements jwt.TokenService
+func (s *TokenService) GenerateTokenForTenant(userID, tenantID string) (string, error) {
+	if userID == "" {
+		return "", errors.NewRequiredFieldError("user_id", userID)
+	}
+	return Token(userID, tenantID), nil
+}
+
+// GeneratePurposeToken implements jwt.TokenService
+func (s *TokenService) GeneratePurposeToken(userID, tenantID, purpose string, _ time.Duration) (string, error) {
+	if purpose == "" {
+		return "", errors.NewRequiredFieldError("purpose", purpose)
+	}
+	if purpose == jwt.PurposeInvitation {
+		return "", errors.NewBusinessLogicError("token_generation", "invitation tokens are issued by GenerateInvitationToken")
+	}
+	if purpose == jwt.PurposeEmailVerification {
+		return "", errors.NewBusinessLogicError("token_generation", "email verification tokens are issued by GenerateEmailVerificationToken")
+	}
+	return s.GenerateTokenForTenant(purpose+":"+userID, tenantID)
+}
+
+// GenerateEmailVerificationToken implements jwt.TokenService
+func (s *TokenService) GenerateEmailVerificationToken(userID, tenantID, email string, _ time.Duration) (string, error) {
+	if userID == "" {
+		return "", errors.NewRequiredFieldError("user_id", userID)
+	}
+	if email == "" {
+		return "", errors.NewRequiredFieldError("email", email)
+	}
+	return Token(jwt.PurposeEmailVerification+":"+userID+":"+base64.RawURLEncoding.EncodeToString([]byte(email)), tenantID), nil
+}
+
+// GenerateInvitationToken implements jwt.TokenService
+func (s *TokenService) GenerateInvitationToken(invitationID, tenantID string, _ time.Duration) (string, error) {
+	if invitationID == "" {
+		return "", errors.NewRequiredFieldError("invitation_id", invitationID)
+	}
+	return Token(jwt.PurposeInvitation+":"+invitationID, tenantID), nil
+}
+
+// ValidateToken implements jwt.TokenService
+func (s *TokenService) ValidateToken(tokenString string) (*jwt.Claims, error) {
+	return s.validate(tokenString, "")
+}
+
+// ValidatePurposeToken implements jwt.TokenService
+func (s *TokenService) ValidatePurposeToken(tokenString, purpose string) (*jwt.Claims, error) {
+	return s.validate(tokenString, purpose)
+}
+
+func (s *TokenService) validate(tokenString, purpose string) (*jwt.Claims, error) {
+	if tokenString == "" {
+		return nil, errors.NewRequiredFieldError("token", tokenString)
+	}
+	s.mu.Lock()
+	revoked := s.revoked[tokenString]
+	s.mu.Unlock()
+
+	rest, ok := strings.CutPrefix(tokenString, tokenPrefix)
+	if !ok || rest == "" || revoked {
+		return nil, errors.NewUnauthorizedError("token_validation", "", "invalid token")
+	}
+
+	userID, tenantID, _ := strings.Cut(rest, ".")
+	tokenPurpose, purposeUser, ok := strings.Cut(userID, ":")
+	if ok {
+		userID = purposeUser
+	} else {
+		tokenPurpose = ""
+	}
+	if tokenPurpose != purpose {
+		return nil, errors.NewUnauthorizedError("token_validation", "", "invalid token")
+	}
+	if purpose == jwt.PurposeInvitation {
+		return &jwt.Claims{TenantID: tenantID, Purpose: purpose, InvitationID: userID}, nil
+	}
+	claims := &jwt.Claims{UserID: userID, TenantID: tenantID, Purpose: purpose}
+	if purpose == jwt.PurposeEmailVerification {
+		id, encoded, _ := strings.Cut(userID, ":")
+		email, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil || len(email) == 0 {
+			return nil, errors.NewUnauthorizedError("token_validation", "", "invalid token")
+		}
+		claims.UserID, claims.Email = id, string(email)
+	}
+	claims.Subject = claims.UserID
+	return claims, nil
+}
+
+// Revoke makes ValidateToken reject token
+func (s *TokenService) Revoke(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[token] = true
+}
+
+// GetSigningKey implements jwt.TokenService
+func (s *TokenService) GetSigningKey() []byte {
+	return []byte("fake-signing-key")
+}
+
+// JWKS implements jwt.TokenService; fake tokens have no public keys
+func (s *TokenService) JWKS() jwt.JWKS {
+	return jwt.JWKS{Keys: []jwt.JWK{}}
+}
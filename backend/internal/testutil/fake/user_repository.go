@@ -0,0 +1,499 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/internal/domain/event"
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	wonderErrors "github.com/cctw-zed/wonder/pkg/errors"
+	"github.com/cctw-zed/wonder/pkg/pagination"
+)
+
+// UserRepository is an in-memory user.UserRepository. Like the Postgres
+// repository it scopes users to the tenant of the context, rejects
+// duplicate emails and handles within a tenant and returns nil, nil for missing users.
+type UserRepository struct {
+	mu      sync.RWMutex
+	users   map[string]*user.User // by ID
+	cursors *pagination.Codec
+}
+
+var _ user.UserRepository = (*UserRepository)(nil)
+
+// NewUserRepository creates an empty repository
+func NewUserRepository() *UserRepository {
+	return &UserRepository{users: make(map[string]*user.User), cursors: pagination.NewRandomCodec()}
+}
+
+// stored copies u without its pending events
+func stored(u *user.User) *user.User {
+	c := *u
+	c.Recorder = event.Recorder{}
+	return &c
+}
+
+// emailTaken reports whether another user of the tenant has the canonical
+// form of email, like the unique index on canonical_email
+func (r *UserRepository) emailTaken(tenantID, email, exceptID string) bool {
+	canonical := user.CanonicalizeEmail(email)
+	for _, u := range r.users {
+		if u.TenantID == tenantID && u.ID != exceptID && user.CanonicalizeEmail(u.Email) == canonical {
+			return true
+		}
+	}
+	return false
+}
+
+// handleTaken reports whether another user of the tenant has handle
+func (r *UserRepository) handleTaken(tenantID, handle, exceptID string) bool {
+	for _, u := range r.users {
+		if handle != "" && u.TenantID == tenantID && u.ID != exceptID && u.CurrentHandle() == handle {
+			return true
+		}
+	}
+	return false
+}
+
+// Create implements user.UserRepository
+func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
+	return r.CreateBatch(ctx, []*user.User{u})
+}
+
+// CreateBatch implements user.UserRepository. No user is stored when one
+// of them fails.
+func (r *UserRepository) CreateBatch(ctx context.Context, users []*user.User) error {
+	now := time.Now().Truncate(time.Microsecond)
+	for _, u := range users {
+		if u == nil {
+			return wonderErrors.NewRequiredFieldError("user", "nil")
+		}
+		if err := u.Validate(ctx); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[string]bool, len(users))
+	for _, u := range users {
+		if u.TenantID == "" {
+			u.TenantID = tenant.IDFromContext(ctx)
+		}
+		key := u.TenantID + "\x00" + user.CanonicalizeEmail(u.Email)
+		if _, exists := r.users[u.ID]; exists || seen[key] || r.emailTaken(u.TenantID, u.Email, "") {
+			return wonderErrors.NewConflictError("user", "email already exists", "", map[string]interface{}{
+				"email": u.Email,
+			})
+		}
+		seen[key] = true
+	}
+	for _, u := range users {
+		if u.CreatedAt.IsZero() {
+			u.CreatedAt = now
+		}
+		if u.UpdatedAt.IsZero() {
+			u.UpdatedAt = now
+		}
+		r.users[u.ID] = stored(u)
+	}
+	return nil
+}
+
+// find returns the stored user with id in the tenant of ctx
+func (r *UserRepository) find(ctx context.Context, id string) (*user.User, bool) {
+	u, ok := r.users[id]
+	if !ok || u.TenantID != tenant.IDFromContext(ctx) {
+		return nil, false
+	}
+	return u, true
+}
+
+// GetByID implements user.UserRepository
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*user.User, error) {
+	if id == "" {
+		return nil, wonderErrors.NewRequiredFieldError("id", id)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if u, ok := r.find(ctx, id); ok {
+		return stored(u), nil
+	}
+	return nil, nil
+}
+
+// GetByIDs implements user.UserRepository
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	found := make(map[string]*user.User, len(ids))
+	for _, id := range ids {
+		if u, ok := r.find(ctx, id); ok {
+			found[id] = stored(u)
+		}
+	}
+	return found, nil
+}
+
+// GetByEmail implements user.UserRepository
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	if email == "" {
+		return nil, wonderErrors.NewRequiredFieldError("email", email)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID, canonical := tenant.IDFromContext(ctx), user.CanonicalizeEmail(email)
+	for _, u := range r.users {
+		if u.TenantID == tenantID && user.CanonicalizeEmail(u.Email) == canonical {
+			return stored(u), nil
+		}
+	}
+	return nil, nil
+}
+
+// Update implements user.UserRepository
+func (r *UserRepository) Update(ctx context.Context, u *user.User) error {
+	return r.update(ctx, u, nil)
+}
+
+// UpdateIfUnmodified implements user.UserRepository
+func (r *UserRepository) UpdateIfUnmodified(ctx context.Context, u *user.User, unmodifiedSince time.Time) error {
+	return r.update(ctx, u, &unmodifiedSince)
+}
+
+// UpdateAvatar implements user.UserRepository
+func (r *UserRepository) UpdateAvatar(ctx context.Context, u *user.User, previous string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.find(ctx, u.ID)
+	if !ok {
+		return fmt.Errorf("user with ID %s not found", u.ID)
+	}
+	if current.AvatarKey != previous {
+		return wonderErrors.NewVersionMismatchError("user", u.ID, "")
+	}
+	current.AvatarKey = u.AvatarKey
+	current.UpdatedAt = time.Now().Truncate(time.Microsecond)
+	u.UpdatedAt = current.UpdatedAt
+	return nil
+}
+
+func (r *UserRepository) update(ctx context.Context, u *user.User, unmodifiedSince *time.Time) error {
+	if u == nil {
+		return fmt.Errorf("user cannot be nil")
+	}
+	if err := u.Validate(ctx); err != nil {
+		return fmt.Errorf("user validation failed: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u.TenantID == "" {
+		u.TenantID = tenant.IDFromContext(ctx)
+	}
+	current, ok := r.find(ctx, u.ID)
+	if !ok || u.TenantID != tenant.IDFromContext(ctx) {
+		return fmt.Errorf("user with ID %s not found", u.ID)
+	}
+	if unmodifiedSince != nil && !current.UpdatedAt.Equal(*unmodifiedSince) {
+		return wonderErrors.NewVersionMismatchError("user", u.ID, "")
+	}
+	if r.emailTaken(u.TenantID, u.Email, u.ID) {
+		return fmt.Errorf("user with email %s already exists", u.Email)
+	}
+	if r.handleTaken(u.TenantID, u.CurrentHandle(), u.ID) {
+		return fmt.Errorf("user with handle %s already exists", u.CurrentHandle())
+	}
+
+	u.UpdatedAt = time.Now().Truncate(time.Microsecond)
+	// Like the database, the avatar is only written by UpdateAvatar
+	next := stored(u)
+	next.AvatarKey = current.AvatarKey
+	r.users[u.ID] = next
+	return nil
+}
+
+// Delete implements user.UserRepository
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.find(ctx, id); !ok {
+		return fmt.Errorf("user with ID %s not found", id)
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// List implements user.UserRepository. Filters match case-insensitive
+// substrings, as ILIKE does in Postgres.
+func (r *UserRepository) List(ctx context.Context, req *user.ListUsersRequest) (*user.ListUsersResponse, error) {
+	if req == nil {
+		return nil, wonderErrors.NewRequiredFieldError("request", "nil")
+	}
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	less, err := listOrder(req.SortBy, req.SortOrder)
+	if err != nil {
+		return nil, err
+	}
+	order := req.SortOrder
+	if order == "" {
+		order = user.SortDesc
+	}
+	var cursor *pagination.Cursor
+	if req.Cursor != "" {
+		c, err := r.cursors.Decode(req.Cursor)
+		if err != nil {
+			return nil, wonderErrors.NewInvalidFormatError("cursor", req.Cursor, "next_cursor from a previous page")
+		}
+		if c.Sort != cursorSort(req.SortBy) || c.Order != order {
+			return nil, wonderErrors.NewInvalidValueError("cursor", req.Cursor, "cursor belongs to a different sort_by or sort_order")
+		}
+		cursor = &c
+	}
+
+	r.mu.RLock()
+	var matched []*user.User
+	for _, u := range r.users {
+		if u.TenantID == tenant.IDFromContext(ctx) && matchesFilter(u, req) {
+			matched = append(matched, stored(u))
+		}
+	}
+	var anchor *user.User
+	if cursor != nil {
+		var ok bool
+		if anchor, ok = cursorAnchor(req.SortBy, cursor); !ok {
+			r.mu.RUnlock()
+			return nil, wonderErrors.NewInvalidValueError("cursor", req.Cursor, "cursor has no position; start from the first page")
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	total := int64(len(matched))
+	var rest []*user.User
+	if cursor != nil {
+		page = 0
+		for i, u := range matched {
+			if less(anchor, u) {
+				rest = matched[i:]
+				break
+			}
+		}
+	} else if offset := (page - 1) * pageSize; offset < len(matched) {
+		rest = matched[offset:]
+	}
+
+	var nextCursor string
+	if len(rest) > pageSize {
+		rest = rest[:pageSize]
+		last := rest[len(rest)-1]
+		key, private := cursorKey(req.SortBy, last)
+		nextCursor = r.cursors.Encode(pagination.Cursor{Sort: cursorSort(req.SortBy), Order: order, CreatedAt: last.CreatedAt, Key: key, Private: private, ID: last.ID})
+	}
+
+	// Estimates are exact here, as they are on databases without a planner
+	// estimate
+	kind := user.TotalExact
+	if req.IncludeTotal == user.TotalNone {
+		kind, total = user.TotalNone, 0
+	}
+	return &user.ListUsersResponse{
+		Users:      rest,
+		Total:      total,
+		TotalKind:  kind,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// cursorSort is the Sort recorded in cursors for sortBy, as in the real
+// repository
+func cursorSort(sortBy string) string {
+	if sortBy == user.SortByCreatedAt {
+		return ""
+	}
+	return sortBy
+}
+
+// cursorKey returns the sort key cursors carry for u and whether it is
+// personal data, as in the real repository
+func cursorKey(sortBy string, u *user.User) (key string, private bool) {
+	switch sortBy {
+	case user.SortByUpdatedAt:
+		return u.UpdatedAt.UTC().Format(time.RFC3339Nano), false
+	case user.SortByName:
+		return u.Name, true
+	case user.SortByEmail:
+		return u.Email, true
+	}
+	return "", false
+}
+
+// cursorAnchor rebuilds the position of the user c was issued after from
+// the cursor alone; false if c does not carry it
+func cursorAnchor(sortBy string, c *pagination.Cursor) (*user.User, bool) {
+	anchor := &user.User{ID: c.ID, CreatedAt: c.CreatedAt}
+	switch sortBy {
+	case user.SortByUpdatedAt:
+		at, err := time.Parse(time.RFC3339Nano, c.Key)
+		anchor.UpdatedAt = at
+		return anchor, err == nil
+	case user.SortByName:
+		anchor.Name = c.Key
+	case user.SortByEmail:
+		anchor.Email = c.Key
+	default:
+		return anchor, true
+	}
+	return anchor, c.Key != ""
+}
+
+// listOrder returns the ordering List sorts by; ID breaks ties
+func listOrder(sortBy, sortOrder string) (func(a, b *user.User) bool, error) {
+	var key func(a, b *user.User) int
+	switch sortBy {
+	case "", user.SortByCreatedAt:
+		key = func(a, b *user.User) int { return a.CreatedAt.Compare(b.CreatedAt) }
+	case user.SortByUpdatedAt:
+		key = func(a, b *user.User) int { return a.UpdatedAt.Compare(b.UpdatedAt) }
+	case user.SortByName:
+		key = func(a, b *user.User) int { return strings.Compare(a.Name, b.Name) }
+	case user.SortByEmail:
+		key = func(a, b *user.User) int { return strings.Compare(a.Email, b.Email) }
+	default:
+		return nil, wonderErrors.NewInvalidValueError("sort_by", sortBy, "unsupported sort column")
+	}
+
+	var ascending bool
+	switch sortOrder {
+	case user.SortAsc:
+		ascending = true
+	case "", user.SortDesc:
+	default:
+		return nil, wonderErrors.NewInvalidValueError("sort_order", sortOrder, "must be asc or desc")
+	}
+
+	return func(a, b *user.User) bool {
+		c := key(a, b)
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if ascending {
+			return c < 0
+		}
+		return c > 0
+	}, nil
+}
+
+func matchesFilter(u *user.User, req *user.ListUsersRequest) bool {
+	if req.Email != "" && !strings.Contains(strings.ToLower(u.Email), strings.ToLower(req.Email)) {
+		return false
+	}
+	if req.Name != "" && !strings.Contains(strings.ToLower(u.Name), strings.ToLower(req.Name)) {
+		return false
+	}
+	if !req.CreatedAfter.IsZero() && !u.CreatedAt.After(req.CreatedAfter) {
+		return false
+	}
+	if !req.CreatedBefore.IsZero() && !u.CreatedAt.Before(req.CreatedBefore) {
+		return false
+	}
+	if len(req.Roles) > 0 && !slices.Contains(req.Roles, u.Role) {
+		return false
+	}
+	if len(req.Statuses) > 0 && !slices.Contains(req.Statuses, u.CurrentStatus()) {
+		return false
+	}
+	return true
+}
+
+// GetByHandle implements user.UserRepository
+func (r *UserRepository) GetByHandle(ctx context.Context, handle string) (*user.User, error) {
+	if handle == "" {
+		return nil, wonderErrors.NewRequiredFieldError("handle", handle)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+	for _, u := range r.users {
+		if u.TenantID == tenantID && u.CurrentHandle() == handle {
+			return stored(u), nil
+		}
+	}
+	return nil, nil
+}
+
+// ExistingEmails implements user.UserRepository
+func (r *UserRepository) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		wanted[user.CanonicalizeEmail(email)] = true
+	}
+	existing := make(map[string]bool)
+	for _, u := range r.users {
+		if email := user.CanonicalizeEmail(u.Email); u.TenantID == tenant.IDFromContext(ctx) && wanted[email] {
+			existing[email] = true
+		}
+	}
+	return existing, nil
+}
+
+// ListDueForDeletion implements user.UserRepository. Like the Postgres
+// repository it looks across tenants.
+func (r *UserRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*user.User, error) {
+	r.mu.RLock()
+	var due []*user.User
+	for _, u := range r.users {
+		if u.DeletionDue(now) {
+			due = append(due, stored(u))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(due, func(i, j int) bool {
+		if c := due[i].DeletionScheduledAt.Compare(*due[j].DeletionScheduledAt); c != 0 {
+			return c < 0
+		}
+		return due[i].ID < due[j].ID
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Len returns the number of stored users across all tenants
+func (r *UserRepository) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.users)
+}
@@ -0,0 +1,116 @@
+package fake
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/tenant"
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/testutil/builder"
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func TestUserRepository_CRUD(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	repo := NewUserRepository()
+
+	u := builder.NewUserBuilder().WithID("1").WithEmail("ada@example.com").Build()
+	require.NoError(t, repo.Create(ctx, u))
+	assert.Equal(t, tenant.DefaultID, u.TenantID)
+
+	dup := builder.NewUserBuilder().WithID("2").WithEmail("ada@example.com").Build()
+	assert.Error(t, repo.Create(ctx, dup))
+
+	got, err := repo.GetByEmail(ctx, "ada@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "1", got.ID)
+
+	// Returned users are copies
+	got.Name = "Changed"
+	again, err := repo.GetByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Test User", again.Name)
+
+	require.NoError(t, repo.Update(ctx, got))
+	again, err = repo.GetByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Changed", again.Name)
+
+	existing, err := repo.ExistingEmails(ctx, []string{"ADA@example.com", "bob@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"ada@example.com": true}, existing)
+
+	require.NoError(t, repo.Delete(ctx, "1"))
+	assert.Error(t, repo.Delete(ctx, "1"))
+	missing, err := repo.GetByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestUserRepository_TenantScope(t *testing.T) {
+	logger.Initialize()
+	acme := tenant.WithID(context.Background(), "acme")
+	repo := NewUserRepository()
+
+	u := builder.NewUserBuilder().WithID("1").Build()
+	require.NoError(t, repo.Create(acme, u))
+
+	// The same email may exist in another tenant
+	other := builder.NewUserBuilder().WithID("2").Build()
+	require.NoError(t, repo.Create(context.Background(), other))
+
+	got, err := repo.GetByID(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	assert.Error(t, repo.Delete(context.Background(), "1"))
+
+	got, err = repo.GetByID(acme, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", got.TenantID)
+}
+
+func TestUserRepository_List(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	repo := NewUserRepository()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"Carol", "Ada", "Bob"} {
+		u := builder.NewUserBuilder().
+			WithID(string(rune('a'+i))).
+			WithEmail(name+"@example.com").
+			WithName(name).
+			WithTimestamps(base.Add(time.Duration(i)*time.Hour), base).
+			Build()
+		require.NoError(t, repo.Create(ctx, u))
+	}
+
+	resp, err := repo.List(ctx, &user.ListUsersRequest{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), resp.Total)
+	assert.Equal(t, 2, resp.TotalPages)
+	require.Len(t, resp.Users, 2)
+	assert.Equal(t, "Bob", resp.Users[0].Name)
+	require.NotEmpty(t, resp.NextCursor)
+
+	next, err := repo.List(ctx, &user.ListUsersRequest{PageSize: 2, Cursor: resp.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, next.Users, 1)
+	assert.Equal(t, "Carol", next.Users[0].Name)
+	assert.Empty(t, next.NextCursor)
+
+	byName, err := repo.List(ctx, &user.ListUsersRequest{SortBy: user.SortByName, SortOrder: user.SortAsc, Name: "o"})
+	require.NoError(t, err)
+	require.Len(t, byName.Users, 2)
+	assert.Equal(t, "Bob", byName.Users[0].Name)
+	assert.Equal(t, "Carol", byName.Users[1].Name)
+
+	_, err = repo.List(ctx, &user.ListUsersRequest{SortBy: "password_hash"})
+	assert.Error(t, err)
+}
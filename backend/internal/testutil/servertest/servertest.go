@@ -0,0 +1,180 @@
+// Package servertest runs the whole application on an ephemeral port for
+// end-to-end tests. By default it uses a throwaway SQLite database, records
+// emails instead of sending them and needs no configuration files.
+package servertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/cctw-zed/wonder/internal/container"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
+	"github.com/cctw-zed/wonder/internal/server"
+	"github.com/cctw-zed/wonder/internal/testutil/fake"
+	"github.com/cctw-zed/wonder/pkg/snowflake/id"
+)
+
+// Server is the application listening on a random local port. It is shut
+// down when the test that started it ends.
+type Server struct {
+	// BaseURL is the server's root, e.g. http://127.0.0.1:51234
+	BaseURL string
+	// Client talks to the server with a timeout
+	Client *http.Client
+	// Container holds the running components
+	Container *container.Container
+	// DB is the database the server writes to
+	DB *gorm.DB
+	// Mailer records the emails the server sent
+	Mailer *fake.Mailer
+	// Routes are the routes the server serves
+	Routes gin.RoutesInfo
+}
+
+// Option customizes the server
+type Option func(*settings)
+
+type settings struct {
+	configure     []func(*config.Config)
+	postgres      *config.DatabaseConfig
+	containerOpts []container.Option
+}
+
+// WithConfig adjusts the configuration before the server starts. It starts
+// from config.DefaultConfig with the testing environment.
+func WithConfig(configure func(cfg *config.Config)) Option {
+	return func(s *settings) {
+		s.configure = append(s.configure, configure)
+	}
+}
+
+// WithPostgres runs against the Postgres database described by cfg, e.g.
+// one started by testcontainers or a CI service, instead of SQLite.
+// Migrations are applied; data is not cleaned up.
+func WithPostgres(cfg *config.DatabaseConfig) Option {
+	return func(s *settings) {
+		s.postgres = cfg
+	}
+}
+
+// WithContainerOptions passes opts to container.New after the harness's
+// own, e.g. to replace the user repository with a fake
+func WithContainerOptions(opts ...container.Option) Option {
+	return func(s *settings) {
+		s.containerOpts = append(s.containerOpts, opts...)
+	}
+}
+
+// New starts the server and registers its shutdown with t.Cleanup. It
+// fails t when the server cannot start.
+func New(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+
+	s := &settings{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.App.Environment = "testing"
+	cfg.Log.Level = "warn"
+	cfg.Database.AutoMigrate = true
+	cfg.JWT.SigningKey = "servertest-signing-key-not-for-production"
+	for _, configure := range s.configure {
+		configure(cfg)
+	}
+
+	conn, err := openDatabase(t, cfg, s.postgres)
+	if err != nil {
+		t.Fatalf("servertest: %v", err)
+	}
+	gen, err := id.NewSnowflakeGenerator(1)
+	if err != nil {
+		t.Fatalf("servertest: %v", err)
+	}
+	mail := fake.NewMailer()
+
+	containerOpts := append([]container.Option{
+		container.WithConfig(cfg),
+		container.WithDatabase(conn),
+		container.WithIDGenerator(gen),
+		container.WithMailer(mail),
+	}, s.containerOpts...)
+	c, err := container.New(context.Background(), containerOpts...)
+	if err != nil {
+		conn.Close()
+		t.Fatalf("servertest: failed to build container: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := c.Shutdown(ctx); err != nil {
+			t.Errorf("servertest: shutdown: %v", err)
+		}
+	})
+
+	srv, err := server.New(c)
+	if err != nil {
+		t.Fatalf("servertest: failed to create server: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("servertest: failed to listen: %v", err)
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("servertest: server stopped: %v", err)
+		}
+	}()
+	c.OnShutdown(container.PhaseServer, "http_server", 0, srv.Shutdown)
+
+	scheme := "http"
+	if srv.TLSEnabled() {
+		scheme = "https"
+	}
+	return &Server{
+		BaseURL:   fmt.Sprintf("%s://%s", scheme, ln.Addr().String()),
+		Client:    &http.Client{Timeout: 10 * time.Second},
+		Container: c,
+		DB:        conn.DB(),
+		Mailer:    mail,
+		Routes:    srv.Routes(),
+	}
+}
+
+// URL returns the absolute URL of path, e.g. "/api/v1/users"
+func (s *Server) URL(path string) string {
+	return s.BaseURL + path
+}
+
+// openDatabase connects to postgres when given, or creates a SQLite
+// database in a temporary directory removed after the test
+func openDatabase(t testing.TB, cfg *config.Config, postgres *config.DatabaseConfig) (*database.Connection, error) {
+	if postgres != nil {
+		dbCfg := *postgres
+		dbCfg.AutoMigrate = true
+		cfg.Database = &dbCfg
+		return database.NewConnection(cfg.Database)
+	}
+
+	dsn := filepath.Join(t.TempDir(), "wonder.db") + "?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	return database.NewConnectionFromDB(db), nil
+}
@@ -0,0 +1,55 @@
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+)
+
+func TestNew_ServesTheAPI(t *testing.T) {
+	s := New(t)
+
+	resp, err := s.Client.Get(s.URL("/health"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := json.Marshal(map[string]string{
+		"email":    "ada@example.com",
+		"name":     "Ada",
+		"password": "Str0ng!Passw0rd",
+	})
+	require.NoError(t, err)
+	resp, err = s.Client.Post(s.URL("/api/v1/users/register"), "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var count int64
+	require.NoError(t, s.DB.Model(&user.User{}).Where("email = ?", "ada@example.com").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// Welcome and verification emails
+	require.Eventually(t, func() bool {
+		return len(s.Mailer.SentTo("ada@example.com")) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNew_Isolated(t *testing.T) {
+	a := New(t)
+	b := New(t, WithConfig(func(cfg *config.Config) {
+		cfg.App.Name = "other"
+	}))
+
+	assert.NotEqual(t, a.BaseURL, b.BaseURL)
+	assert.Equal(t, "other", b.Container.Config().App.Name)
+	assert.NotSame(t, a.DB, b.DB)
+}
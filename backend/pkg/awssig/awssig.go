@@ -0,0 +1,152 @@
+// Package awssig signs requests to AWS APIs, and to stores that speak the
+// same protocol such as MinIO, with Signature Version 4
+package awssig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Algorithm names the signing algorithm in headers and URLs
+	Algorithm = "AWS4-HMAC-SHA256"
+	// TimeFormat is the layout of X-Amz-Date
+	TimeFormat = "20060102T150405Z"
+	// UnsignedPayload leaves the body out of the signature, so it can be
+	// streamed. Only S3 accepts it.
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// Credentials are static AWS credentials. SessionToken is set for
+// temporary credentials, e.g. those of an assumed role.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Signer signs requests to one service in one region
+type Signer struct {
+	Credentials Credentials
+	Region      string
+	Service     string
+}
+
+// PayloadHash returns the hex SHA-256 of a request body, as signed by Sign
+func PayloadHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign adds the X-Amz-Date, X-Amz-Security-Token and Authorization headers
+// to req. Host, Content-Type and every X-Amz- header already set are
+// signed. payloadHash is PayloadHash of the body or UnsignedPayload.
+func (s Signer) Sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(TimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.Credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.Credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := s.scope(amzDate)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		Algorithm, s.Credentials.AccessKeyID, scope, signedHeaders, s.signature(amzDate, scope, canonicalRequest)))
+}
+
+// Presign returns u with query parameters that authorize method on it,
+// signing only the host, until now plus expires
+func (s Signer) Presign(method string, u *url.URL, expires time.Duration, now time.Time) *url.URL {
+	amzDate := now.UTC().Format(TimeFormat)
+	scope := s.scope(amzDate)
+
+	signed := *u
+	query := signed.Query()
+	query.Set("X-Amz-Algorithm", Algorithm)
+	query.Set("X-Amz-Credential", s.Credentials.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if s.Credentials.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", s.Credentials.SessionToken)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalPath(&signed),
+		canonicalQuery(query),
+		"host:" + signed.Host + "\n",
+		"host",
+		UnsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(amzDate, scope, canonicalRequest))
+	signed.RawQuery = canonicalQuery(query)
+	return &signed
+}
+
+func (s Signer) scope(amzDate string) string {
+	return strings.Join([]string{amzDate[:8], s.Region, s.Service, "aws4_request"}, "/")
+}
+
+func (s Signer) signature(amzDate, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{Algorithm, amzDate, scope, PayloadHash([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.Credentials.SecretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func canonicalPath(u *url.URL) string {
+	if path := u.EscapedPath(); path != "" {
+		return path
+	}
+	return "/"
+}
+
+// canonicalQuery encodes query sorted by key with spaces as %20, as
+// Signature Version 4 requires
+func canonicalQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,54 @@
+package awssig
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSigner_Sign checks the signer against the "get-vanilla" case of the
+// AWS Signature Version 4 test suite
+func TestSigner_Sign(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	signer := Signer{
+		Credentials: Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		Region:      "us-east-1",
+		Service:     "service",
+	}
+	signer.Sign(req, PayloadHash(nil), time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+			"SignedHeaders=host;x-amz-date, "+
+			"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestSigner_SessionToken(t *testing.T) {
+	signer := Signer{
+		Credentials: Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token/with+chars"},
+		Region:      "eu-west-1",
+		Service:     "s3",
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	req, err := http.NewRequest(http.MethodPut, "https://bucket.s3.amazonaws.com/a.png", nil)
+	require.NoError(t, err)
+	signer.Sign(req, UnsignedPayload, now)
+	assert.Equal(t, "token/with+chars", req.Header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-amz-security-token,")
+
+	u, err := url.Parse("https://bucket.s3.amazonaws.com/a.png")
+	require.NoError(t, err)
+	presigned := signer.Presign(http.MethodGet, u, time.Minute, now)
+	assert.Equal(t, "token/with+chars", presigned.Query().Get("X-Amz-Security-Token"))
+	assert.NotEmpty(t, presigned.Query().Get("X-Amz-Signature"))
+	assert.Empty(t, u.RawQuery, "the unsigned URL is left alone")
+}
@@ -0,0 +1,83 @@
+// Package buildinfo describes the running binary. The variables are set at
+// link time:
+//
+//	go build -ldflags "-X github.com/cctw-zed/wonder/pkg/buildinfo.Version=1.2.0 \
+//		-X github.com/cctw-zed/wonder/pkg/buildinfo.GitCommit=$(git rev-parse --short HEAD) \
+//		-X github.com/cctw-zed/wonder/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Unknown is reported for values the build did not set
+const Unknown = "unknown"
+
+// Set with -ldflags -X
+var (
+	Version   = "dev"
+	GitCommit = ""
+	BuildTime = ""
+)
+
+// Info is the build of the running binary
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build info. Commit and build time not set by ldflags fall
+// back to the VCS stamp of go build, which plain builds inside a checkout
+// carry.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	if info.GitCommit == "" || info.BuildTime == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				switch {
+				case s.Key == "vcs.revision" && info.GitCommit == "":
+					info.GitCommit = shortCommit(s.Value)
+				case s.Key == "vcs.time" && info.BuildTime == "":
+					info.BuildTime = s.Value
+				}
+			}
+		}
+	}
+	if info.GitCommit == "" {
+		info.GitCommit = Unknown
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = Unknown
+	}
+	return info
+}
+
+// Released reports whether the version was set at link time
+func Released() bool {
+	return Version != "dev" && Version != ""
+}
+
+// LogFields returns the build info as logger key-value pairs
+func (i Info) LogFields() []interface{} {
+	return []interface{}{
+		"version", i.Version,
+		"git_commit", i.GitCommit,
+		"build_time", i.BuildTime,
+		"go_version", i.GoVersion,
+	}
+}
+
+func shortCommit(revision string) string {
+	if len(revision) > 12 {
+		return revision[:12]
+	}
+	return revision
+}
@@ -0,0 +1,34 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	version, commit, built := Version, GitCommit, BuildTime
+	t.Cleanup(func() { Version, GitCommit, BuildTime = version, commit, built })
+
+	Version, GitCommit, BuildTime = "1.2.0", "abc1234", "2026-10-16T08:00:00Z"
+	assert.Equal(t, Info{
+		Version:   "1.2.0",
+		GitCommit: "abc1234",
+		BuildTime: "2026-10-16T08:00:00Z",
+		GoVersion: runtime.Version(),
+	}, Get())
+	assert.True(t, Released())
+
+	// Test binaries carry no VCS stamp
+	Version, GitCommit, BuildTime = "dev", "", ""
+	info := Get()
+	assert.NotEmpty(t, info.GitCommit)
+	assert.NotEmpty(t, info.BuildTime)
+	assert.False(t, Released())
+}
+
+func TestShortCommit(t *testing.T) {
+	assert.Equal(t, "0123456789ab", shortCommit("0123456789abcdef0123456789abcdef01234567"))
+	assert.Equal(t, "abc", shortCommit("abc"))
+}
@@ -0,0 +1,214 @@
+// Package circuitbreaker stops calling a failing dependency for a while so
+// callers fail fast instead of piling up behind timeouts.
+//
+// A breaker starts closed and counts consecutive failures. At the threshold
+// it opens and rejects calls until the open timeout passes. It then goes
+// half-open and lets a few trial calls through. A successful trial closes it
+// again; a failed one re-opens it.
+package circuitbreaker
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// ErrOpen is the cause of errors returned while the breaker rejects calls
+var ErrOpen = stderrors.New("circuit open")
+
+// State is the position of a breaker
+type State int
+
+const (
+	// StateClosed lets every call through
+	StateClosed State = iota
+	// StateHalfOpen lets a limited number of trial calls through
+	StateHalfOpen
+	// StateOpen rejects every call
+	StateOpen
+)
+
+// String returns the state name used in logs, metrics and health output
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// Settings configures a breaker
+type Settings struct {
+	// Name identifies the dependency in errors, logs and metrics
+	Name string
+	// FailureThreshold is the number of consecutive failures that opens
+	// the breaker. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before going
+	// half-open. Defaults to 30s.
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls caps concurrent trial calls while half-open.
+	// Defaults to 1.
+	HalfOpenMaxCalls int
+	// IsFailure decides whether an error counts against the dependency.
+	// Nil counts every error except context cancellation.
+	IsFailure func(error) bool
+	// OnStateChange, if set, is called after every transition
+	OnStateChange func(name string, from, to State)
+	// OnReject, if set, is called for every call rejected without running
+	OnReject func(name string)
+}
+
+// Breaker is a consecutive-failure circuit breaker. It is safe for
+// concurrent use.
+type Breaker struct {
+	settings Settings
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	trials    int
+	openUntil time.Time
+	// generation changes on every transition so results of calls started
+	// in an earlier state are ignored
+	generation uint64
+}
+
+// New creates a closed breaker
+func New(s Settings) *Breaker {
+	if s.Name == "" {
+		panic("circuit breaker name cannot be empty")
+	}
+	if s.FailureThreshold <= 0 {
+		s.FailureThreshold = 5
+	}
+	if s.OpenTimeout <= 0 {
+		s.OpenTimeout = 30 * time.Second
+	}
+	if s.HalfOpenMaxCalls <= 0 {
+		s.HalfOpenMaxCalls = 1
+	}
+	if s.IsFailure == nil {
+		s.IsFailure = isFailure
+	}
+	return &Breaker{settings: s}
+}
+
+func isFailure(err error) bool {
+	return err != nil && !stderrors.Is(err, context.Canceled)
+}
+
+// Name returns the breaker name
+func (b *Breaker) Name() string {
+	return b.settings.Name
+}
+
+// State returns the current state. An open breaker whose timeout has passed
+// reports half-open.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(time.Now())
+	return b.state
+}
+
+// Execute runs fn unless the breaker is open, and records its outcome.
+// Rejected calls return an ExternalServiceError wrapping ErrOpen with code
+// SERVICE_UNAVAILABLE.
+func (b *Breaker) Execute(fn func() error) error {
+	generation, err := b.before()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	b.after(generation, err)
+	return err
+}
+
+func (b *Breaker) before() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.advance(now)
+
+	switch b.state {
+	case StateOpen:
+		return 0, b.reject(fmt.Errorf("%w until %s", ErrOpen, b.openUntil.Format(time.RFC3339)))
+	case StateHalfOpen:
+		if b.trials >= b.settings.HalfOpenMaxCalls {
+			return 0, b.reject(fmt.Errorf("%w: trial call in progress", ErrOpen))
+		}
+		b.trials++
+	}
+	return b.generation, nil
+}
+
+func (b *Breaker) after(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	if !b.settings.IsFailure(err) {
+		if b.state == StateHalfOpen {
+			b.setState(StateClosed, time.Now())
+		}
+		b.failures = 0
+		return
+	}
+
+	switch b.state {
+	case StateHalfOpen:
+		b.setState(StateOpen, time.Now())
+	case StateClosed:
+		b.failures++
+		if b.failures >= b.settings.FailureThreshold {
+			b.setState(StateOpen, time.Now())
+		}
+	}
+}
+
+// advance moves an expired open breaker to half-open
+func (b *Breaker) advance(now time.Time) {
+	if b.state == StateOpen && !now.Before(b.openUntil) {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+func (b *Breaker) setState(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.failures = 0
+	b.trials = 0
+	b.openUntil = time.Time{}
+	if to == StateOpen {
+		b.openUntil = now.Add(b.settings.OpenTimeout)
+	}
+	if b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(b.settings.Name, from, to)
+	}
+}
+
+func (b *Breaker) reject(cause error) error {
+	if b.settings.OnReject != nil {
+		b.settings.OnReject(b.settings.Name)
+	}
+	// Retrying within milliseconds cannot succeed; the breaker decides
+	// when the dependency is tried again
+	err := errors.NewExternalServiceError(b.settings.Name, "circuit_breaker", 0, "", cause, false)
+	err.ErrorCode = errors.CodeServiceUnavailable
+	return err
+}
@@ -0,0 +1,106 @@
+package circuitbreaker
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+var errBoom = stderrors.New("boom")
+
+func fail() error { return errBoom }
+
+func succeed() error { return nil }
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	var transitions []string
+	rejected := 0
+	b := New(Settings{
+		Name:             "redis",
+		FailureThreshold: 2,
+		OpenTimeout:      time.Hour,
+		OnStateChange: func(name string, from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+		OnReject: func(name string) { rejected++ },
+	})
+
+	// A success in between resets the count
+	assert.ErrorIs(t, b.Execute(fail), errBoom)
+	require.NoError(t, b.Execute(succeed))
+	assert.ErrorIs(t, b.Execute(fail), errBoom)
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.ErrorIs(t, b.Execute(fail), errBoom)
+	assert.Equal(t, StateOpen, b.State())
+
+	called := false
+	err := b.Execute(func() error { called = true; return nil })
+	assert.False(t, called)
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.False(t, errors.IsRetryable(err))
+
+	var serviceErr *errors.ExternalServiceError
+	require.ErrorAs(t, err, &serviceErr)
+	assert.Equal(t, "redis", serviceErr.Service)
+	assert.Equal(t, errors.CodeServiceUnavailable, serviceErr.ErrorCode)
+
+	assert.Equal(t, []string{"closed->open"}, transitions)
+	assert.Equal(t, 1, rejected)
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	newOpenBreaker := func() *Breaker {
+		b := New(Settings{Name: "etcd", FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond})
+		_ = b.Execute(fail)
+		require.Equal(t, StateOpen, b.State())
+		time.Sleep(15 * time.Millisecond)
+		require.Equal(t, StateHalfOpen, b.State())
+		return b
+	}
+
+	t.Run("successful trial closes", func(t *testing.T) {
+		b := newOpenBreaker()
+		require.NoError(t, b.Execute(succeed))
+		assert.Equal(t, StateClosed, b.State())
+	})
+
+	t.Run("failed trial re-opens", func(t *testing.T) {
+		b := newOpenBreaker()
+		assert.ErrorIs(t, b.Execute(fail), errBoom)
+		assert.Equal(t, StateOpen, b.State())
+	})
+
+	t.Run("concurrent trials are limited", func(t *testing.T) {
+		b := newOpenBreaker()
+		inTrial := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- b.Execute(func() error {
+				close(inTrial)
+				<-release
+				return nil
+			})
+		}()
+
+		<-inTrial
+		assert.ErrorIs(t, b.Execute(succeed), ErrOpen)
+		close(release)
+		require.NoError(t, <-done)
+		assert.Equal(t, StateClosed, b.State())
+	})
+}
+
+func TestBreaker_IgnoresCancellation(t *testing.T) {
+	b := New(Settings{Name: "hibp", FailureThreshold: 1})
+
+	assert.ErrorIs(t, b.Execute(func() error { return context.Canceled }), context.Canceled)
+	assert.Equal(t, StateClosed, b.State())
+}
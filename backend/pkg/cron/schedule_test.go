@@ -0,0 +1,78 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Next(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, 3, 11, 15, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 11, 15, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 11, 15, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 3, 12, 3, 0, 0, 0, time.UTC)},
+		{"30 15 * * *", time.Date(2026, 3, 12, 15, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 3, 11, 17, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan,jul *", time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: the 1st or any Monday
+		{"0 0 1 * mon", time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 11, 16, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(from))
+		})
+	}
+}
+
+func TestParse_Location(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	schedule, err := Parse("0 3 * * *")
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC).In(loc))
+	assert.Equal(t, time.Date(2026, 3, 11, 19, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestParse_NeverFires(t *testing.T) {
+	schedule, err := Parse("0 0 31 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every 10ms",
+		"@every soon",
+		"@fortnightly",
+	} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,224 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// Task is periodic work. Its context is cancelled when the scheduler stops.
+type Task func(ctx context.Context) error
+
+// Run describes one activation of a task
+type Run struct {
+	Task     string
+	Started  time.Time
+	Duration time.Duration
+	Err      error
+	// Skipped is set when the task was due while its previous run was
+	// still going, and did not run
+	Skipped bool
+}
+
+// Option configures a Scheduler
+type Option func(*Scheduler)
+
+// WithLocation evaluates schedules in loc instead of UTC
+func WithLocation(loc *time.Location) Option {
+	return func(s *Scheduler) {
+		if loc != nil {
+			s.loc = loc
+		}
+	}
+}
+
+// WithObserver calls fn after every run and every skipped activation,
+// e.g. to record metrics
+func WithObserver(fn func(Run)) Option {
+	return func(s *Scheduler) {
+		s.observe = fn
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(log logger.Logger) Option {
+	return func(s *Scheduler) {
+		if log != nil {
+			s.log = log
+		}
+	}
+}
+
+type entry struct {
+	name     string
+	spec     string
+	schedule Schedule
+	task     Task
+	running  atomic.Bool
+}
+
+// Scheduler runs tasks on their schedules until stopped
+type Scheduler struct {
+	loc     *time.Location
+	observe func(Run)
+	log     logger.Logger
+
+	mu      sync.Mutex
+	entries []*entry
+	running bool
+	cancel  context.CancelFunc
+	done    sync.WaitGroup
+}
+
+// NewScheduler creates a stopped scheduler; add tasks, then Start it
+func NewScheduler(opts ...Option) *Scheduler {
+	s := &Scheduler{
+		loc: time.UTC,
+		log: logger.Get().WithLayer("infrastructure").WithComponent("scheduler"),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add schedules task under name. Tasks added after Start are not run.
+func (s *Scheduler) Add(name, spec string, task Task) error {
+	if name == "" {
+		return fmt.Errorf("cron: task name is required")
+	}
+	if task == nil {
+		return fmt.Errorf("cron: task %s is nil", name)
+	}
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("task %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.name == name {
+			return fmt.Errorf("cron: task %s already added", name)
+		}
+	}
+	s.entries = append(s.entries, &entry{name: name, spec: spec, schedule: schedule, task: task})
+	return nil
+}
+
+// Tasks lists the names of the added tasks
+func (s *Scheduler) Tasks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, len(s.entries))
+	for i, e := range s.entries {
+		names[i] = e.name
+	}
+	return names
+}
+
+// Start runs every task on its schedule. ctx is the parent of every run's
+// context.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, e := range s.entries {
+		s.done.Add(1)
+		go s.loop(ctx, e)
+		s.log.Info(ctx, "task scheduled", "task", e.name, "schedule", e.spec, "next_run", e.schedule.Next(time.Now().In(s.loc)))
+	}
+}
+
+// Stop stops scheduling and cancels running tasks, then waits for them to
+// return or ctx to end
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	s.cancel()
+	s.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		s.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.done.Done()
+
+	for {
+		now := time.Now().In(s.loc)
+		next := e.schedule.Next(now)
+		if next.IsZero() {
+			s.log.Warn(ctx, "task schedule never fires", "task", e.name, "schedule", e.spec)
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !e.running.CompareAndSwap(false, true) {
+			s.log.Warn(ctx, "task skipped, previous run still in progress", "task", e.name)
+			s.report(Run{Task: e.name, Started: time.Now(), Skipped: true})
+			continue
+		}
+		s.done.Add(1)
+		go s.run(ctx, e)
+	}
+}
+
+// run executes one activation, turning panics into errors
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	defer s.done.Done()
+	defer e.running.Store(false)
+
+	started := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("task panicked: %v", p)
+			}
+		}()
+		return e.task(ctx)
+	}()
+	duration := time.Since(started)
+
+	if err != nil {
+		s.log.Error(ctx, "scheduled task failed", "error", err, "task", e.name, "duration", duration.String())
+	} else {
+		s.log.Info(ctx, "scheduled task completed", "task", e.name, "duration", duration.String())
+	}
+	s.report(Run{Task: e.name, Started: started, Duration: duration, Err: err})
+}
+
+func (s *Scheduler) report(run Run) {
+	if s.observe != nil {
+		s.observe(run)
+	}
+}
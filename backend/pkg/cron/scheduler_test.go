@@ -0,0 +1,101 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// runRecorder collects observed runs
+type runRecorder struct {
+	mu   sync.Mutex
+	runs []Run
+}
+
+func (r *runRecorder) observe(run Run) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, run)
+}
+
+func (r *runRecorder) count(match func(Run) bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, run := range r.runs {
+		if match(run) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestScheduler_Add(t *testing.T) {
+	logger.Initialize()
+	s := NewScheduler()
+	noop := func(context.Context) error { return nil }
+
+	require.NoError(t, s.Add("cleanup", "@daily", noop))
+	assert.ErrorContains(t, s.Add("cleanup", "@hourly", noop), "already added")
+	assert.Error(t, s.Add("broken", "not a schedule", noop))
+	assert.Error(t, s.Add("", "@daily", noop))
+	assert.Error(t, s.Add("nil", "@daily", nil))
+	assert.Equal(t, []string{"cleanup"}, s.Tasks())
+}
+
+func TestScheduler_RunsAndReports(t *testing.T) {
+	logger.Initialize()
+	recorder := &runRecorder{}
+	s := NewScheduler(WithObserver(recorder.observe))
+
+	require.NoError(t, s.Add("ok", "@every 1s", func(context.Context) error { return nil }))
+	require.NoError(t, s.Add("failing", "@every 1s", func(context.Context) error { return errors.New("db down") }))
+	require.NoError(t, s.Add("panicking", "@every 1s", func(context.Context) error { panic("boom") }))
+
+	s.Start(context.Background())
+	require.Eventually(t, func() bool {
+		return recorder.count(func(Run) bool { return true }) >= 3
+	}, 3*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Stop(ctx))
+
+	assert.Positive(t, recorder.count(func(r Run) bool { return r.Task == "ok" && r.Err == nil }))
+	assert.Positive(t, recorder.count(func(r Run) bool { return r.Task == "failing" && r.Err != nil }))
+	assert.Positive(t, recorder.count(func(r Run) bool {
+		return r.Task == "panicking" && r.Err != nil && r.Err.Error() == "task panicked: boom"
+	}))
+}
+
+func TestScheduler_SkipsOverlappingRuns(t *testing.T) {
+	logger.Initialize()
+	recorder := &runRecorder{}
+	s := NewScheduler(WithObserver(recorder.observe))
+
+	started := make(chan struct{}, 10)
+	require.NoError(t, s.Add("slow", "@every 1s", func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	s.Start(context.Background())
+	require.Eventually(t, func() bool {
+		return recorder.count(func(r Run) bool { return r.Skipped }) >= 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// Stop cancels the running task and waits for it
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Stop(ctx))
+	assert.Len(t, started, 1)
+	assert.Equal(t, 1, recorder.count(func(r Run) bool { return !r.Skipped && errors.Is(r.Err, context.Canceled) }))
+}
@@ -1,6 +1,10 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"time"
+)
 
 // EntityNotFoundError represents entity not found errors
 type EntityNotFoundError struct {
@@ -234,6 +238,43 @@ func NewDuplicateEntryError(entityType, field string, value interface{}, existin
 	}
 }
 
+// NewInsufficientRoleError reports an authenticated user lacking the role an operation requires
+func NewInsufficientRoleError(operation, userID, requiredRole string) *UnauthorizedError {
+	return &UnauthorizedError{
+		ErrorCode: CodeInsufficientRole,
+		Operation: operation,
+		UserID:    userID,
+		Reason:    fmt.Sprintf("requires role '%s'", requiredRole),
+		Context: map[string]interface{}{
+			"required_role": requiredRole,
+		},
+	}
+}
+
+// NewAccountInactiveError reports a login refused because the account is
+// suspended or deactivated
+func NewAccountInactiveError(userID, status string) *UnauthorizedError {
+	return &UnauthorizedError{
+		ErrorCode: CodeForbidden,
+		Operation: "login",
+		UserID:    userID,
+		Reason:    fmt.Sprintf("account is %s", status),
+		Context: map[string]interface{}{
+			"status": status,
+		},
+	}
+}
+
+// NewCaptchaRequiredError reports a request refused until the client solves
+// a CAPTCHA
+func NewCaptchaRequiredError(operation, reason string) *UnauthorizedError {
+	return &UnauthorizedError{
+		ErrorCode: CodeCaptchaRequired,
+		Operation: operation,
+		Reason:    reason,
+	}
+}
+
 func NewResourceLockedError(entityType, entityID, reason string) *ConflictError {
 	return &ConflictError{
 		ErrorCode:  CodeResourceLocked,
@@ -242,3 +283,62 @@ func NewResourceLockedError(entityType, entityID, reason string) *ConflictError
 		ExistingID: entityID,
 	}
 }
+
+// NewVersionMismatchError reports an update conditioned on a version of the
+// entity that is no longer current
+func NewVersionMismatchError(entityType, entityID, currentVersion string) *ConflictError {
+	return &ConflictError{
+		ErrorCode:  CodeVersionMismatch,
+		Resource:   entityType,
+		Reason:     "the resource was modified since it was read",
+		ExistingID: entityID,
+		Context: map[string]interface{}{
+			"current_version": currentVersion,
+		},
+	}
+}
+
+// NewAccountLockedError reports a login refused because too many attempts
+// failed. The unlock time is exposed so clients can tell users when to retry.
+func NewAccountLockedError(retryAfter time.Duration) *ConflictError {
+	return &ConflictError{
+		ErrorCode: CodeResourceLocked,
+		Resource:  "account",
+		Reason:    "too many failed login attempts",
+		Context: map[string]interface{}{
+			"locked_until":        time.Now().Add(retryAfter).UTC().Format(time.RFC3339),
+			"retry_after_seconds": int(math.Ceil(retryAfter.Seconds())),
+		},
+	}
+}
+
+// NewSignupThrottledError reports a registration refused because too many
+// accounts were registered recently from the same scope, e.g. "ip" or
+// "domain". retry_after_seconds tells clients when to try again.
+func NewSignupThrottledError(scope string, retryAfter time.Duration) *BusinessLogicError {
+	return &BusinessLogicError{
+		ErrorCode: CodeRateLimitExceeded,
+		Operation: "register",
+		Reason:    fmt.Sprintf("too many registrations from this %s", scope),
+		AdditionalDetails: map[string]interface{}{
+			"scope":               scope,
+			"retry_after_seconds": int(math.Ceil(retryAfter.Seconds())),
+		},
+	}
+}
+
+// NewSignupRejectedError reports a registration refused as likely abuse.
+// reason is machine readable, e.g. "disposable_email"; value is what
+// triggered it and may be empty.
+func NewSignupRejectedError(reason, value string) *BusinessLogicError {
+	details := map[string]interface{}{}
+	if value != "" {
+		details["value"] = value
+	}
+	return &BusinessLogicError{
+		ErrorCode:         CodeSignupRejected,
+		Operation:         "register",
+		Reason:            reason,
+		AdditionalDetails: details,
+	}
+}
@@ -2,6 +2,7 @@ package errors_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -66,6 +67,31 @@ func TestConflictError(t *testing.T) {
 		assert.Equal(t, "test@example.com", details["email"])
 		assert.Equal(t, "2023-10-01T10:00:00Z", details["attempted_at"])
 	})
+
+	t.Run("Create account locked error", func(t *testing.T) {
+		err := errors.NewAccountLockedError(90*time.Second + time.Millisecond)
+
+		assert.Equal(t, errors.CodeResourceLocked, err.Code())
+		assert.Equal(t, 423, errors.GetHTTPStatusCode(err))
+
+		details := err.Details()
+		assert.Equal(t, 91, details["retry_after_seconds"])
+		assert.NotEmpty(t, details["locked_until"])
+	})
+}
+
+func TestSignupErrors(t *testing.T) {
+	throttled := errors.NewSignupThrottledError("domain", 30*time.Second)
+	assert.Equal(t, errors.CodeRateLimitExceeded, throttled.Code())
+	assert.Equal(t, 429, errors.GetHTTPStatusCode(throttled))
+	assert.Equal(t, "domain", throttled.Details()["scope"])
+	assert.Equal(t, 30, throttled.Details()["retry_after_seconds"])
+
+	rejected := errors.NewSignupRejectedError("disposable_email", "mailinator.com")
+	assert.Equal(t, errors.CodeSignupRejected, rejected.Code())
+	assert.Equal(t, 422, errors.GetHTTPStatusCode(rejected))
+	assert.Equal(t, "disposable_email", rejected.Details()["reason"])
+	assert.Equal(t, "mailinator.com", rejected.Details()["value"])
 }
 
 func TestUnauthorizedError(t *testing.T) {
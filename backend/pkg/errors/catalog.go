@@ -0,0 +1,186 @@
+package errors
+
+import (
+	"net/http"
+	"sync"
+)
+
+// CatalogEntry documents a public error code. Codes are stable: once
+// published they are never renamed or given a different meaning, so API
+// consumers can branch on them.
+type CatalogEntry struct {
+	Code        ErrorCode `json:"code"`
+	Status      int       `json:"status"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	DocsURL     string    `json:"docs_url"`
+}
+
+// catalog lists every public error code with the HTTP status it is
+// returned with
+var catalog = []CatalogEntry{
+	// Domain
+	{Code: CodeValidationError, Status: http.StatusBadRequest, Title: "Validation failed",
+		Description: "The request is malformed or one or more fields are invalid. details lists the offending fields."},
+	{Code: CodeRequiredField, Status: http.StatusBadRequest, Title: "Required field missing",
+		Description: "A required field is empty or missing. details.field names it."},
+	{Code: CodeInvalidFormat, Status: http.StatusBadRequest, Title: "Invalid format",
+		Description: "A field does not match its expected format, such as an email address or RFC 3339 timestamp."},
+	{Code: CodeInvalidValue, Status: http.StatusBadRequest, Title: "Invalid value",
+		Description: "A field is well-formed but its value is not allowed."},
+	{Code: CodeOutOfRange, Status: http.StatusBadRequest, Title: "Value out of range",
+		Description: "A field is shorter, longer, smaller or larger than allowed. details gives the bounds."},
+	{Code: CodeDomainRuleViolation, Status: http.StatusUnprocessableEntity, Title: "Domain rule violation",
+		Description: "The request is valid but breaks a domain rule."},
+	{Code: CodeInvariantViolation, Status: http.StatusUnprocessableEntity, Title: "Invariant violation",
+		Description: "The change would leave an entity in an inconsistent state."},
+	{Code: CodeBusinessRuleError, Status: http.StatusUnprocessableEntity, Title: "Business rule violation",
+		Description: "The request is valid but not allowed by a business rule, such as reusing a recent password."},
+	{Code: CodeInvalidState, Status: http.StatusConflict, Title: "Invalid entity state",
+		Description: "The entity is not in a state that allows this operation."},
+	{Code: CodeStateTransition, Status: http.StatusConflict, Title: "Invalid state transition",
+		Description: "The entity cannot move from its current state to the requested one."},
+	{Code: CodePreconditionError, Status: http.StatusConflict, Title: "Precondition failed",
+		Description: "A precondition of the operation does not hold for the entity."},
+
+	// Application
+	{Code: CodeEntityNotFound, Status: http.StatusNotFound, Title: "Resource not found",
+		Description: "The addressed resource does not exist or is not visible to the caller."},
+	{Code: CodeResourceConflict, Status: http.StatusConflict, Title: "Resource conflict",
+		Description: "The request conflicts with the current state of the resource."},
+	{Code: CodeDuplicateEntry, Status: http.StatusConflict, Title: "Duplicate entry",
+		Description: "A resource with the same unique value, such as an email address, already exists."},
+	{Code: CodeVersionMismatch, Status: http.StatusPreconditionFailed, Title: "Version mismatch",
+		Description: "If-Match did not name the current version of the resource, which changed since it was read. Fetch it again and retry."},
+	{Code: CodeResourceLocked, Status: http.StatusLocked, Title: "Resource locked",
+		Description: "The resource is temporarily locked, for example after repeated failed logins. Retry later."},
+	{Code: CodeUnauthorized, Status: http.StatusUnauthorized, Title: "Unauthorized",
+		Description: "Authentication is missing or invalid."},
+	{Code: CodeForbidden, Status: http.StatusForbidden, Title: "Forbidden",
+		Description: "The caller is authenticated but may not perform this operation."},
+	{Code: CodeInsufficientRole, Status: http.StatusForbidden, Title: "Insufficient role",
+		Description: "The operation requires a role the caller does not have."},
+	{Code: CodeTokenExpired, Status: http.StatusUnauthorized, Title: "Token expired",
+		Description: "The access token has expired. Log in again."},
+	{Code: CodeCaptchaRequired, Status: http.StatusForbidden, Title: "CAPTCHA required",
+		Description: "The request must carry a solved CAPTCHA as captcha_token, or the one sent did not verify. Show the challenge and retry."},
+	{Code: CodeIPBlocked, Status: http.StatusForbidden, Title: "IP blocked",
+		Description: "Requests from the client's IP address are not allowed on this route."},
+	{Code: CodeBusinessLogicError, Status: http.StatusUnprocessableEntity, Title: "Business logic error",
+		Description: "The operation could not be completed for a business reason given in details."},
+	{Code: CodeOperationFailed, Status: http.StatusUnprocessableEntity, Title: "Operation failed",
+		Description: "The operation could not be completed."},
+	{Code: CodeQuotaExceeded, Status: http.StatusTooManyRequests, Title: "Quota exceeded",
+		Description: "The caller has used up its quota."},
+	{Code: CodeRateLimitExceeded, Status: http.StatusTooManyRequests, Title: "Rate limit exceeded",
+		Description: "Too many requests. Retry after a short wait."},
+	{Code: CodeSignupRejected, Status: http.StatusUnprocessableEntity, Title: "Signup rejected",
+		Description: "The registration was refused as likely abuse. details.reason tells why, e.g. disposable_email."},
+
+	// Infrastructure
+	{Code: CodeDatabaseError, Status: http.StatusServiceUnavailable, Title: "Database error",
+		Description: "The database failed to complete the request. details.retryable tells whether retrying may help."},
+	{Code: CodeDatabaseConnection, Status: http.StatusServiceUnavailable, Title: "Database unavailable",
+		Description: "The database could not be reached. Retry later."},
+	{Code: CodeDatabaseTimeout, Status: http.StatusServiceUnavailable, Title: "Database timeout",
+		Description: "The database did not respond in time. Retry later."},
+	{Code: CodeDatabaseDeadlock, Status: http.StatusServiceUnavailable, Title: "Database deadlock",
+		Description: "The request lost a deadlock with a concurrent request. Retrying is safe."},
+	{Code: CodeTransactionRollback, Status: http.StatusInternalServerError, Title: "Transaction rolled back",
+		Description: "The change was rolled back and nothing was saved."},
+	{Code: CodeNetworkError, Status: http.StatusServiceUnavailable, Title: "Network error",
+		Description: "A dependency could not be reached over the network."},
+	{Code: CodeConnectionRefused, Status: http.StatusServiceUnavailable, Title: "Connection refused",
+		Description: "A dependency refused the connection."},
+	{Code: CodeConnectionTimeout, Status: http.StatusServiceUnavailable, Title: "Connection timeout",
+		Description: "A dependency did not accept the connection in time."},
+	{Code: CodeServiceUnavailable, Status: http.StatusServiceUnavailable, Title: "Service unavailable",
+		Description: "The service or one of its dependencies is temporarily unavailable. Retry later."},
+	{Code: CodeExternalServiceError, Status: http.StatusServiceUnavailable, Title: "External service error",
+		Description: "A third-party service returned an error."},
+	{Code: CodeAPICallFailed, Status: http.StatusServiceUnavailable, Title: "External API call failed",
+		Description: "A call to a third-party API failed."},
+	{Code: CodeExternalTimeout, Status: http.StatusServiceUnavailable, Title: "External service timeout",
+		Description: "A third-party service did not respond in time."},
+	{Code: CodeInvalidResponse, Status: http.StatusInternalServerError, Title: "Invalid response",
+		Description: "A third-party service returned a response that could not be understood."},
+	{Code: CodeConfigurationError, Status: http.StatusInternalServerError, Title: "Configuration error",
+		Description: "The service is misconfigured. Contact the operator."},
+	{Code: CodeMissingConfig, Status: http.StatusInternalServerError, Title: "Missing configuration",
+		Description: "A setting the operation needs is not configured. Contact the operator."},
+	{Code: CodeInvalidConfig, Status: http.StatusInternalServerError, Title: "Invalid configuration",
+		Description: "A setting the operation needs is invalid. Contact the operator."},
+
+	// System
+	{Code: CodeInternalError, Status: http.StatusInternalServerError, Title: "Internal server error",
+		Description: "An unexpected error occurred. Quote trace_id when reporting it."},
+	{Code: CodeNotImplemented, Status: http.StatusNotImplemented, Title: "Not implemented",
+		Description: "The operation is not supported by this deployment."},
+	{Code: CodeServiceStartup, Status: http.StatusServiceUnavailable, Title: "Service starting",
+		Description: "The service is still starting. Retry shortly."},
+	{Code: CodeDependencyMissing, Status: http.StatusInternalServerError, Title: "Dependency missing",
+		Description: "A component the operation needs is not configured."},
+	{Code: CodeRouteNotFound, Status: http.StatusNotFound, Title: "Route not found",
+		Description: "No endpoint exists at this path."},
+	{Code: CodeMethodNotAllowed, Status: http.StatusMethodNotAllowed, Title: "Method not allowed",
+		Description: "The endpoint exists but does not support this HTTP method."},
+	{Code: CodePayloadTooLarge, Status: http.StatusRequestEntityTooLarge, Title: "Payload too large",
+		Description: "The request body exceeds the endpoint's size limit. details.max_bytes gives the limit."},
+	{Code: CodeRequestTimeout, Status: http.StatusGatewayTimeout, Title: "Request timeout",
+		Description: "The request took longer than the endpoint's time limit and was cancelled. Retry later."},
+	{Code: CodeUnsupportedMedia, Status: http.StatusUnsupportedMediaType, Title: "Unsupported media type",
+		Description: "The endpoint does not accept the request body's Content-Type. details.supported lists the types it accepts."},
+}
+
+var (
+	catalogIndex = func() map[ErrorCode]int {
+		index := make(map[ErrorCode]int, len(catalog))
+		for i, entry := range catalog {
+			index[entry.Code] = i
+		}
+		return index
+	}()
+
+	docsMu      sync.RWMutex
+	docsBaseURL = "/api/v1/errors/"
+)
+
+// SetDocsBaseURL sets the prefix of documentation links; the code is
+// appended to it. The default points at the catalog endpoint.
+func SetDocsBaseURL(base string) {
+	docsMu.Lock()
+	defer docsMu.Unlock()
+	docsBaseURL = base
+}
+
+// DocsURL returns the documentation link of the code, or "" for codes
+// outside the catalog
+func (c ErrorCode) DocsURL() string {
+	if _, ok := catalogIndex[c]; !ok {
+		return ""
+	}
+	docsMu.RLock()
+	defer docsMu.RUnlock()
+	return docsBaseURL + string(c)
+}
+
+// Catalog returns every public error code in a stable order
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, len(catalog))
+	for i, entry := range catalog {
+		entry.DocsURL = entry.Code.DocsURL()
+		entries[i] = entry
+	}
+	return entries
+}
+
+// LookupCode returns the catalog entry of a code
+func LookupCode(code ErrorCode) (CatalogEntry, bool) {
+	i, ok := catalogIndex[code]
+	if !ok {
+		return CatalogEntry{}, false
+	}
+	entry := catalog[i]
+	entry.DocsURL = code.DocsURL()
+	return entry, true
+}
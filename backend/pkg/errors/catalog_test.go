@@ -0,0 +1,61 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	entries := Catalog()
+	seen := make(map[ErrorCode]bool, len(entries))
+	for _, entry := range entries {
+		assert.False(t, seen[entry.Code], "duplicate code %s", entry.Code)
+		seen[entry.Code] = true
+		assert.NotEmpty(t, entry.Title, entry.Code)
+		assert.NotEmpty(t, entry.Description, entry.Code)
+		assert.Equal(t, "/api/v1/errors/"+string(entry.Code), entry.DocsURL)
+	}
+
+	entry, ok := LookupCode(CodeResourceLocked)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusLocked, entry.Status)
+	_, ok = LookupCode("NO_SUCH_CODE")
+	assert.False(t, ok)
+	assert.Empty(t, ErrorCode("NO_SUCH_CODE").DocsURL())
+}
+
+func TestCatalog_StatusMatchesMapper(t *testing.T) {
+	mapper := NewErrorMapper()
+	for _, err := range []error{
+		NewRequiredFieldError("email", ""),
+		NewBusinessRuleError("password_reuse", "reused"),
+		NewStateTransitionError("user", "active", "pending", "not allowed"),
+		NewEntityNotFoundError("user", "1"),
+		NewDuplicateEntryError("user", "email", "a@b.c", "1"),
+		NewResourceLockedError("user", "1", "locked"),
+		NewVersionMismatchError("user", "1", "abc"),
+		NewInsufficientRoleError("delete", "1", "admin"),
+		NewCaptchaRequiredError("register", "captcha response is required"),
+		NewSignupThrottledError("ip", time.Minute),
+		NewSignupRejectedError("disposable_email", "example.com"),
+		NewDatabaseError("select", "users", nil, true),
+		NewConfigurationError("jwt", "secret", "", "missing"),
+	} {
+		httpErr := mapper.MapToHTTPError(err, "")
+		entry, ok := LookupCode(httpErr.ErrorCode)
+		require.True(t, ok, httpErr.ErrorCode)
+		assert.Equal(t, entry.Status, httpErr.StatusCode, httpErr.ErrorCode)
+		assert.Equal(t, entry.DocsURL, httpErr.DocsURL)
+	}
+}
+
+func TestSetDocsBaseURL(t *testing.T) {
+	SetDocsBaseURL("https://docs.example.com/errors#")
+	defer SetDocsBaseURL("/api/v1/errors/")
+
+	assert.Equal(t, "https://docs.example.com/errors#TOKEN_EXPIRED", CodeTokenExpired.DocsURL())
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ValidationError represents validation failures in domain entities
 type ValidationError struct {
@@ -59,6 +62,60 @@ func NewValidationError(code ErrorCode, field string, value interface{}, message
 	}
 }
 
+// FieldError describes one invalid request field. Constraint holds the
+// violated rule's parameters, e.g. {"min": 2} or {"format": "email"}.
+type FieldError struct {
+	Field      string                 `json:"field"`
+	Code       ErrorCode              `json:"code"`
+	Message    string                 `json:"message"`
+	Constraint map[string]interface{} `json:"constraint,omitempty"`
+}
+
+// FieldValidationError reports every invalid field of a request at once
+type FieldValidationError struct {
+	Fields  []FieldError
+	Context map[string]interface{}
+}
+
+func (e *FieldValidationError) Error() string {
+	names := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		names[i] = f.Field
+	}
+	return fmt.Sprintf("validation failed for fields: %s", strings.Join(names, ", "))
+}
+
+func (e *FieldValidationError) Code() ErrorCode {
+	return CodeValidationError
+}
+
+func (e *FieldValidationError) Type() ErrorType {
+	return ErrorTypeDomain
+}
+
+func (e *FieldValidationError) Details() map[string]interface{} {
+	details := map[string]interface{}{
+		"fields": e.Fields,
+	}
+	for k, v := range e.Context {
+		details[k] = v
+	}
+	return details
+}
+
+func (e *FieldValidationError) WithContext(key string, value interface{}) BaseError {
+	if e.Context == nil {
+		e.Context = make(map[string]interface{})
+	}
+	e.Context[key] = value
+	return e
+}
+
+// NewFieldValidationError creates a validation error listing invalid fields
+func NewFieldValidationError(fields ...FieldError) *FieldValidationError {
+	return &FieldValidationError{Fields: fields}
+}
+
 // Convenience constructors for common validation errors
 func NewRequiredFieldError(field string, value interface{}) *ValidationError {
 	return NewValidationError(CodeRequiredField, field, value, fmt.Sprintf("%s is required", field))
@@ -31,6 +31,19 @@ func TestValidationError(t *testing.T) {
 		assert.Equal(t, 3, details["value"])
 		assert.Contains(t, details["message"].(string), "must be between 6 and 50")
 	})
+
+	t.Run("Create field validation error", func(t *testing.T) {
+		err := errors.NewFieldValidationError(
+			errors.FieldError{Field: "email", Code: errors.CodeInvalidFormat, Message: "email must be a valid email address"},
+			errors.FieldError{Field: "name", Code: errors.CodeOutOfRange, Message: "name must be at least 2 characters", Constraint: map[string]interface{}{"min": 2}},
+		)
+
+		assert.Equal(t, "validation failed for fields: email, name", err.Error())
+		assert.Equal(t, errors.CodeValidationError, err.Code())
+		assert.True(t, errors.IsDomainError(err))
+		assert.Equal(t, 400, errors.GetHTTPStatusCode(err))
+		assert.Len(t, err.Details()["fields"], 2)
+	})
 }
 
 func TestDomainRuleError(t *testing.T) {
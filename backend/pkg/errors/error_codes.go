@@ -30,18 +30,29 @@ const (
 	CodeResourceConflict ErrorCode = "RESOURCE_CONFLICT"
 	CodeDuplicateEntry   ErrorCode = "DUPLICATE_ENTRY"
 	CodeResourceLocked   ErrorCode = "RESOURCE_LOCKED"
+	// CodeVersionMismatch reports a conditional request made against an
+	// outdated version of a resource
+	CodeVersionMismatch ErrorCode = "VERSION_MISMATCH"
 
 	// Authorization errors
 	CodeUnauthorized     ErrorCode = "UNAUTHORIZED"
 	CodeForbidden        ErrorCode = "FORBIDDEN"
 	CodeInsufficientRole ErrorCode = "INSUFFICIENT_ROLE"
 	CodeTokenExpired     ErrorCode = "TOKEN_EXPIRED"
+	// CodeCaptchaRequired reports a request that must carry a solved
+	// CAPTCHA, or carried one that did not verify
+	CodeCaptchaRequired ErrorCode = "CAPTCHA_REQUIRED"
+	// CodeIPBlocked reports a request refused by the IP allow and deny lists
+	CodeIPBlocked ErrorCode = "IP_BLOCKED"
 
 	// Business logic errors
 	CodeBusinessLogicError ErrorCode = "BUSINESS_LOGIC_ERROR"
 	CodeOperationFailed    ErrorCode = "OPERATION_FAILED"
 	CodeQuotaExceeded      ErrorCode = "QUOTA_EXCEEDED"
 	CodeRateLimitExceeded  ErrorCode = "RATE_LIMIT_EXCEEDED"
+	// CodeSignupRejected reports a registration refused as likely abuse,
+	// e.g. from a disposable email domain
+	CodeSignupRejected ErrorCode = "SIGNUP_REJECTED"
 )
 
 // Infrastructure error codes
@@ -77,6 +88,11 @@ const (
 	CodeNotImplemented    ErrorCode = "NOT_IMPLEMENTED"
 	CodeServiceStartup    ErrorCode = "SERVICE_STARTUP_ERROR"
 	CodeDependencyMissing ErrorCode = "DEPENDENCY_MISSING"
+	CodeRouteNotFound     ErrorCode = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed  ErrorCode = "METHOD_NOT_ALLOWED"
+	CodePayloadTooLarge   ErrorCode = "PAYLOAD_TOO_LARGE"
+	CodeRequestTimeout    ErrorCode = "REQUEST_TIMEOUT"
+	CodeUnsupportedMedia  ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
 )
 
 // String returns the string representation of the error code
@@ -84,60 +100,9 @@ func (c ErrorCode) String() string {
 	return string(c)
 }
 
-// IsValid checks if the error code is a known valid code
+// IsValid checks if the error code is a known valid code, i.e. listed in
+// the public catalog
 func (c ErrorCode) IsValid() bool {
-	validCodes := map[ErrorCode]bool{
-		// Domain codes
-		CodeValidationError:     true,
-		CodeRequiredField:       true,
-		CodeInvalidFormat:       true,
-		CodeInvalidValue:        true,
-		CodeOutOfRange:          true,
-		CodeDomainRuleViolation: true,
-		CodeInvariantViolation:  true,
-		CodeBusinessRuleError:   true,
-		CodeInvalidState:        true,
-		CodeStateTransition:     true,
-		CodePreconditionError:   true,
-
-		// Application codes
-		CodeEntityNotFound:     true,
-		CodeResourceConflict:   true,
-		CodeDuplicateEntry:     true,
-		CodeResourceLocked:     true,
-		CodeUnauthorized:       true,
-		CodeForbidden:          true,
-		CodeInsufficientRole:   true,
-		CodeTokenExpired:       true,
-		CodeBusinessLogicError: true,
-		CodeOperationFailed:    true,
-		CodeQuotaExceeded:      true,
-		CodeRateLimitExceeded:  true,
-
-		// Infrastructure codes
-		CodeDatabaseError:        true,
-		CodeDatabaseConnection:   true,
-		CodeDatabaseTimeout:      true,
-		CodeDatabaseDeadlock:     true,
-		CodeTransactionRollback:  true,
-		CodeNetworkError:         true,
-		CodeConnectionRefused:    true,
-		CodeConnectionTimeout:    true,
-		CodeServiceUnavailable:   true,
-		CodeExternalServiceError: true,
-		CodeAPICallFailed:        true,
-		CodeExternalTimeout:      true,
-		CodeInvalidResponse:      true,
-		CodeConfigurationError:   true,
-		CodeMissingConfig:        true,
-		CodeInvalidConfig:        true,
-
-		// System codes
-		CodeInternalError:     true,
-		CodeNotImplemented:    true,
-		CodeServiceStartup:    true,
-		CodeDependencyMissing: true,
-	}
-
-	return validCodes[c]
+	_, ok := catalogIndex[c]
+	return ok
 }
@@ -1,6 +1,7 @@
 package errors_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -191,6 +192,11 @@ func TestRetryableErrors(t *testing.T) {
 			err:       errors.NewDatabaseError("create", "users", nil, false),
 			retryable: false,
 		},
+		{
+			name:      "Wrapped retryable network error",
+			err:       fmt.Errorf("fetch: %w", errors.NewNetworkError("hibp", "/range", "GET", nil, true)),
+			retryable: true,
+		},
 		{
 			name:      "Configuration error is not retryable",
 			err:       errors.NewConfigurationError("database", "host", "", "missing host"),
@@ -1,5 +1,7 @@
 package errors
 
+import stderrors "errors"
+
 // ErrorType represents the layer where the error originates
 type ErrorType string
 
@@ -26,7 +28,7 @@ type ErrorClassifier struct{}
 // ClassifyError determines the error type and provides type-safe checking
 func (c *ErrorClassifier) ClassifyError(err error) ErrorType {
 	switch err.(type) {
-	case *ValidationError, *DomainRuleError, *InvalidStateError:
+	case *ValidationError, *FieldValidationError, *DomainRuleError, *InvalidStateError:
 		return ErrorTypeDomain
 	case *EntityNotFoundError, *ConflictError, *UnauthorizedError, *BusinessLogicError:
 		return ErrorTypeApplication
@@ -54,20 +56,14 @@ func (c *ErrorClassifier) IsInfrastructureError(err error) bool {
 	return c.ClassifyError(err) == ErrorTypeInfrastructure
 }
 
-// IsRetryable determines if an error should be retried
+// IsRetryable determines if an error should be retried. Wrapped errors
+// are unwrapped until an infrastructure error is found.
 func (c *ErrorClassifier) IsRetryable(err error) bool {
-	switch e := err.(type) {
-	case *DatabaseError:
-		return e.IsRetryable
-	case *NetworkError:
-		return e.IsRetryable
-	case *ExternalServiceError:
-		return e.IsRetryable
-	case *ConfigurationError:
-		return false // Configuration errors are never retryable
-	default:
-		return false
+	var retryable interface{ Retryable() bool }
+	if stderrors.As(err, &retryable) {
+		return retryable.Retryable()
 	}
+	return false
 }
 
 // Global classifier instance
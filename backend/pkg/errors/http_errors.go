@@ -10,7 +10,10 @@ type HTTPError struct {
 	ErrorCode    ErrorCode              `json:"code"`
 	Message      string                 `json:"message"`
 	ErrorDetails map[string]interface{} `json:"details,omitempty"`
+	DocsURL      string                 `json:"docs_url,omitempty"`
 	TraceID      string                 `json:"trace_id,omitempty"`
+
+	cause error
 }
 
 func (e *HTTPError) Error() string {
@@ -29,6 +32,12 @@ func (e *HTTPError) Details() map[string]interface{} {
 	return e.ErrorDetails
 }
 
+// Cause returns the error MapToHTTPError mapped, or nil for errors created
+// with NewHTTPError
+func (e *HTTPError) Cause() error {
+	return e.cause
+}
+
 func (e *HTTPError) WithContext(key string, value interface{}) BaseError {
 	if e.ErrorDetails == nil {
 		e.ErrorDetails = make(map[string]interface{})
@@ -44,6 +53,7 @@ func NewHTTPError(statusCode int, code ErrorCode, message string, details map[st
 		ErrorCode:    code,
 		Message:      message,
 		ErrorDetails: details,
+		DocsURL:      code.DocsURL(),
 		TraceID:      traceID,
 	}
 }
@@ -56,8 +66,24 @@ func NewErrorMapper() *ErrorMapper {
 	return &ErrorMapper{}
 }
 
-// MapToHTTPError maps various error types to HTTP errors
+// MapToHTTPError maps various error types to HTTP errors. The original
+// error stays available through Cause for error reporting.
 func (m *ErrorMapper) MapToHTTPError(err error, traceID string) *HTTPError {
+	httpErr := m.mapError(err, traceID)
+	httpErr.cause = err
+	return httpErr
+}
+
+func (m *ErrorMapper) mapError(err error, traceID string) *HTTPError {
+	// Already mapped, e.g. by the HTTP layer itself
+	if httpErr, ok := err.(*HTTPError); ok {
+		mapped := *httpErr
+		if mapped.TraceID == "" {
+			mapped.TraceID = traceID
+		}
+		return &mapped
+	}
+
 	// Use the new error classification system
 	errorType := Classifier.ClassifyError(err)
 
@@ -143,6 +169,14 @@ func (m *ErrorMapper) mapApplicationError(err BaseError, traceID string) *HTTPEr
 			err.Details(),
 			traceID,
 		)
+	case CodeVersionMismatch:
+		return NewHTTPError(
+			http.StatusPreconditionFailed,
+			err.Code(),
+			"Precondition failed",
+			err.Details(),
+			traceID,
+		)
 	case CodeResourceLocked:
 		return NewHTTPError(
 			http.StatusLocked,
@@ -159,7 +193,7 @@ func (m *ErrorMapper) mapApplicationError(err BaseError, traceID string) *HTTPEr
 			err.Details(),
 			traceID,
 		)
-	case CodeForbidden, CodeInsufficientRole:
+	case CodeForbidden, CodeInsufficientRole, CodeCaptchaRequired, CodeIPBlocked:
 		return NewHTTPError(
 			http.StatusForbidden,
 			err.Code(),
@@ -175,6 +209,14 @@ func (m *ErrorMapper) mapApplicationError(err BaseError, traceID string) *HTTPEr
 			err.Details(),
 			traceID,
 		)
+	case CodeSignupRejected:
+		return NewHTTPError(
+			http.StatusUnprocessableEntity,
+			err.Code(),
+			"Signup rejected",
+			err.Details(),
+			traceID,
+		)
 	case CodeQuotaExceeded, CodeRateLimitExceeded:
 		return NewHTTPError(
 			http.StatusTooManyRequests,
@@ -97,6 +97,12 @@ func TestErrorMapper_MapApplicationErrors(t *testing.T) {
 			expectedStatus:   http.StatusUnauthorized,
 			expectedCode:     errors.CodeUnauthorized,
 		},
+		{
+			name:             "InsufficientRoleError maps to 403 Forbidden",
+			applicationError: errors.NewInsufficientRoleError("list_audit_logs", "user-123", "admin"),
+			expectedStatus:   http.StatusForbidden,
+			expectedCode:     errors.CodeInsufficientRole,
+		},
 		{
 			name:             "BusinessLogicError maps to 422 Unprocessable Entity",
 			applicationError: errors.NewBusinessLogicError("registration", "age restriction"),
@@ -2,9 +2,8 @@ package errors
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"time"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
 )
 
 // ErrorLogger provides structured error logging functionality
@@ -41,35 +40,41 @@ func (l *DefaultErrorLogger) LogError(ctx context.Context, err error, traceID st
 	l.LogErrorWithLevel(ctx, err, LogLevelError, traceID, metadata)
 }
 
-// LogErrorWithLevel logs an error with specified level
+// LogErrorWithLevel logs an error with specified level through the
+// application logger, so error entries share its output and format
 func (l *DefaultErrorLogger) LogErrorWithLevel(ctx context.Context, err error, level LogLevel, traceID string, metadata map[string]interface{}) {
-	// Create log entry with structured fields
-	logEntry := map[string]interface{}{
-		"timestamp":  time.Now().UTC().Format(time.RFC3339),
-		"service":    l.serviceName,
-		"level":      string(level),
-		"trace_id":   traceID,
-		"error":      err.Error(),
-		"error_type": l.getErrorType(err),
-		"retryable":  l.isRetryable(err),
+	fields := []interface{}{
+		logger.String("service", l.serviceName),
+		logger.Error(err),
+		logger.String("error_type", l.getErrorType(err)),
+		logger.Bool("retryable", l.isRetryable(err)),
+		// Add error-specific details using the new classification system
+		logger.String("layer", string(Classifier.ClassifyError(err))),
+	}
+	if traceID != "" {
+		fields = append(fields, logger.String("trace_id", traceID))
 	}
-
-	// Add error-specific details using the new classification system
-	errorType := Classifier.ClassifyError(err)
-	logEntry["layer"] = string(errorType)
 
 	if baseErr, ok := err.(BaseError); ok {
-		logEntry["error_code"] = baseErr.Code()
-		logEntry["error_details"] = baseErr.Details()
+		fields = append(fields, logger.String("error_code", string(baseErr.Code())), logger.Any("error_details", baseErr.Details()))
 	}
 
 	// Add custom metadata
 	for k, v := range metadata {
-		logEntry[k] = v
+		fields = append(fields, logger.Any(k, v))
 	}
 
-	// Log the structured entry (in production, this would use a proper logger like logrus or zap)
-	l.logStructured(logEntry)
+	log, msg := logger.FromContext(ctx), err.Error()
+	switch level {
+	case LogLevelDebug:
+		log.Debug(ctx, msg, fields...)
+	case LogLevelInfo:
+		log.Info(ctx, msg, fields...)
+	case LogLevelWarn:
+		log.Warn(ctx, msg, fields...)
+	default:
+		log.Error(ctx, msg, fields...)
+	}
 }
 
 // getErrorType returns the type of error for classification
@@ -96,34 +101,6 @@ func (l *DefaultErrorLogger) isRetryable(err error) bool {
 	return IsRetryable(err)
 }
 
-// logStructured outputs the structured log entry
-func (l *DefaultErrorLogger) logStructured(entry map[string]interface{}) {
-	// In production, this would use a proper structured logger
-	// For now, we'll format it as JSON-like output
-	logLine := fmt.Sprintf("[%s] %s - %s",
-		entry["level"],
-		entry["timestamp"],
-		entry["error"])
-
-	if traceID, ok := entry["trace_id"].(string); ok && traceID != "" {
-		logLine += fmt.Sprintf(" (trace_id: %s)", traceID)
-	}
-
-	if layer, ok := entry["layer"].(string); ok {
-		logLine += fmt.Sprintf(" [layer: %s]", layer)
-	}
-
-	if code, ok := entry["error_code"].(string); ok {
-		logLine += fmt.Sprintf(" [code: %s]", code)
-	}
-
-	if retryable, ok := entry["retryable"].(bool); ok && retryable {
-		logLine += " [retryable]"
-	}
-
-	log.Println(logLine)
-}
-
 // LogErrorsMiddleware creates Gin middleware for error logging
 func LogErrorsMiddleware(logger ErrorLogger) func(ctx context.Context, err error, traceID string) {
 	return func(ctx context.Context, err error, traceID string) {
@@ -0,0 +1,184 @@
+// Package fieldcrypt encrypts individual values for storage with envelope
+// encryption: every value is sealed with its own random data key, and the
+// data key is wrapped with a long-lived key-encryption key identified by its
+// ID. Rotating key-encryption keys therefore only needs the stored values to
+// be re-wrapped, which Keyring.Current tells apart.
+//
+// Encrypted values are randomized, so equal plaintexts never compare equal.
+// BlindIndex derives a deterministic keyed hash for exact-match lookups.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length in bytes of key-encryption, data and index keys
+const KeySize = 32
+
+// prefix marks an encrypted value; values without it are plaintext
+const prefix = "enc:v1:"
+
+var encoding = base64.RawURLEncoding
+
+// Keyring holds the key-encryption keys values may be wrapped with, the
+// active one new values are wrapped with, and the blind index key
+type Keyring struct {
+	active   string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// NewKeyring creates a keyring from raw keys by ID. activeID must name one
+// of keys; retired keys stay until no value is wrapped with them anymore.
+func NewKeyring(activeID string, keys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	if len(indexKey) != KeySize {
+		return nil, fmt.Errorf("index key must be %d bytes", KeySize)
+	}
+
+	k := &Keyring{active: activeID, keys: make(map[string]cipher.AEAD, len(keys)), indexKey: indexKey}
+	for id, raw := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("key ID %q must be non-empty and must not contain ':'", id)
+		}
+		if len(raw) != KeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes", id, KeySize)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[activeID]; !ok {
+		return nil, fmt.Errorf("active key %q is not in the keyring", activeID)
+	}
+	return k, nil
+}
+
+// ActiveKeyID returns the ID of the key new values are wrapped with
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// Encrypt seals plaintext under a fresh data key wrapped with the active key
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(k.keys[k.active], dataKey, []byte(k.active))
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(aead, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	return prefix + k.active + ":" + encoding.EncodeToString(wrapped) + ":" + encoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Plaintext values, e.g. stored
+// before encryption was enabled, are returned unchanged.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	id := parts[0]
+	kek, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("value is encrypted with unknown key %q", id)
+	}
+
+	wrapped, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	sealed, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	dataKey, err := open(kek, wrapped, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Current reports whether value is encrypted with the active key, i.e.
+// whether re-encryption would leave it as it is
+func (k *Keyring) Current(value string) bool {
+	return KeyID(value) == k.active
+}
+
+// BlindIndex returns a keyed hash of value for exact-match lookups.
+// Callers normalize value first when lookups should ignore e.g. case.
+func (k *Keyring) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID returns the ID of the key an encrypted value is wrapped with, or ""
+// for plaintext
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data, prefixing the random nonce
+func seal(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, additional), nil
+}
+
+func open(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additional)
+}
@@ -0,0 +1,87 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	k, err := NewKeyring("2026-01", map[string][]byte{"2026-01": testKey(1)}, testKey(9))
+	require.NoError(t, err)
+
+	first, err := k.Encrypt("ada@example.com")
+	require.NoError(t, err)
+	second, err := k.Encrypt("ada@example.com")
+	require.NoError(t, err)
+
+	assert.True(t, IsEncrypted(first))
+	assert.NotContains(t, first, "ada")
+	assert.NotEqual(t, first, second, "values are randomized")
+	assert.Equal(t, "2026-01", KeyID(first))
+	assert.True(t, k.Current(first))
+
+	plain, err := k.Decrypt(first)
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", plain)
+
+	// Values stored before encryption was enabled read as they are
+	plain, err = k.Decrypt("grace@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "grace@example.com", plain)
+	assert.False(t, k.Current("grace@example.com"))
+
+	// Tampering is detected
+	tampered := first[:len(first)-2] + "AA"
+	_, err = k.Decrypt(tampered)
+	assert.Error(t, err)
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, err := NewKeyring("2026-01", map[string][]byte{"2026-01": testKey(1)}, testKey(9))
+	require.NoError(t, err)
+	value, err := old.Encrypt("Ada")
+	require.NoError(t, err)
+
+	rotated, err := NewKeyring("2026-07", map[string][]byte{"2026-01": testKey(1), "2026-07": testKey(2)}, testKey(9))
+	require.NoError(t, err)
+	assert.False(t, rotated.Current(value))
+
+	plain, err := rotated.Decrypt(value)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", plain)
+
+	reencrypted, err := rotated.Encrypt(plain)
+	require.NoError(t, err)
+	assert.True(t, rotated.Current(reencrypted))
+
+	// Dropping the retired key too early leaves its values unreadable
+	retired, err := NewKeyring("2026-07", map[string][]byte{"2026-07": testKey(2)}, testKey(9))
+	require.NoError(t, err)
+	_, err = retired.Decrypt(value)
+	assert.ErrorContains(t, err, "unknown key")
+
+	// The blind index does not depend on the encryption keys
+	assert.Equal(t, old.BlindIndex("ada@example.com"), retired.BlindIndex("ada@example.com"))
+	assert.NotEqual(t, old.BlindIndex("ada@example.com"), old.BlindIndex("grace@example.com"))
+}
+
+func TestNewKeyring_Invalid(t *testing.T) {
+	_, err := NewKeyring("a", map[string][]byte{"a": testKey(1)}, testKey(9)[:16])
+	assert.ErrorContains(t, err, "index key")
+
+	_, err = NewKeyring("a", map[string][]byte{"a": testKey(1)[:16]}, testKey(9))
+	assert.ErrorContains(t, err, "32 bytes")
+
+	_, err = NewKeyring("a:b", map[string][]byte{"a:b": testKey(1)}, testKey(9))
+	assert.ErrorContains(t, err, "':'")
+
+	_, err = NewKeyring("missing", map[string][]byte{"a": testKey(1)}, testKey(9))
+	assert.ErrorContains(t, err, "not in the keyring")
+}
@@ -0,0 +1,224 @@
+// Package health runs dependency checks for liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the health of a single check or of the whole service
+type Status string
+
+const (
+	// StatusUp means the dependency responded in time
+	StatusUp Status = "up"
+	// StatusDegraded means a non-critical dependency is failing; the service
+	// still serves traffic with reduced functionality
+	StatusDegraded Status = "degraded"
+	// StatusDown means a critical dependency is failing
+	StatusDown Status = "down"
+)
+
+const defaultTimeout = 2 * time.Second
+
+// Checker probes one dependency. A nil error means healthy.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// degradedError marks a failure that degrades the service even when the
+// check is critical
+type degradedError struct {
+	err error
+}
+
+func (e degradedError) Error() string { return e.err.Error() }
+
+func (e degradedError) Unwrap() error { return e.err }
+
+// Degraded wraps err so that the failing check reports StatusDegraded
+// instead of StatusDown, e.g. while a dependency is being reconnected
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return degradedError{err: err}
+}
+
+// Option configures a registered check
+type Option func(*registration)
+
+// NonCritical marks a check whose failure degrades the service instead of
+// taking it out of rotation
+func NonCritical() Option {
+	return func(r *registration) {
+		r.critical = false
+	}
+}
+
+// WithTimeout bounds how long a single check may run
+func WithTimeout(d time.Duration) Option {
+	return func(r *registration) {
+		if d > 0 {
+			r.timeout = d
+		}
+	}
+}
+
+type registration struct {
+	name     string
+	checker  Checker
+	critical bool
+	timeout  time.Duration
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Status    Status        `json:"status"`
+	Critical  bool          `json:"critical"`
+	Latency   time.Duration `json:"-"`
+	LatencyMS float64       `json:"latency_ms"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Report aggregates all check results
+type Report struct {
+	Status    Status            `json:"status"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checked_at"`
+}
+
+// Registry holds the registered checks. It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	checks []registration
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check. Checks are critical unless NonCritical is given.
+// Registering a name twice replaces the earlier check.
+func (r *Registry) Register(name string, checker Checker, opts ...Option) {
+	if name == "" {
+		panic("health check name cannot be empty")
+	}
+	if checker == nil {
+		panic("health checker cannot be nil")
+	}
+
+	reg := registration{name: name, checker: checker, critical: true, timeout: defaultTimeout}
+	for _, opt := range opts {
+		opt(&reg)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.checks {
+		if r.checks[i].name == name {
+			r.checks[i] = reg
+			return
+		}
+	}
+	r.checks = append(r.checks, reg)
+}
+
+// Names returns the registered check names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.checks))
+	for _, c := range r.checks {
+		names = append(names, c.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check runs all checks concurrently and aggregates the results. The overall
+// status is down if any critical check fails, degraded if only non-critical
+// checks fail, and up otherwise.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]registration(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c registration) {
+			defer wg.Done()
+			results[i] = run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusUp,
+		Checks:    make(map[string]Result, len(checks)),
+		CheckedAt: time.Now().UTC(),
+	}
+	for i, c := range checks {
+		res := results[i]
+		report.Checks[c.name] = res
+		switch {
+		case res.Status == StatusDown && c.critical:
+			report.Status = StatusDown
+		case res.Status != StatusUp && report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run executes one check. Checks that ignore ctx are abandoned at the
+// timeout so a hung dependency cannot stall the probe.
+func run(ctx context.Context, c registration) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- c.checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", c.timeout)
+	}
+
+	res := Result{Status: StatusUp, Critical: c.critical, Latency: time.Since(start)}
+	res.LatencyMS = float64(res.Latency.Microseconds()) / 1000
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+		if errors.As(err, &degradedError{}) {
+			res.Status = StatusDegraded
+		}
+	}
+	return res
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func healthy() Checker {
+	return CheckerFunc(func(ctx context.Context) error { return nil })
+}
+
+func failing(msg string) Checker {
+	return CheckerFunc(func(ctx context.Context) error { return errors.New(msg) })
+}
+
+func TestRegistry_Check(t *testing.T) {
+	t.Run("all checks up", func(t *testing.T) {
+		r := NewRegistry()
+		r.Register("postgres", healthy())
+		r.Register("redis", healthy(), NonCritical())
+
+		report := r.Check(context.Background())
+		assert.Equal(t, StatusUp, report.Status)
+		assert.Equal(t, StatusUp, report.Checks["postgres"].Status)
+		assert.True(t, report.Checks["postgres"].Critical)
+		assert.False(t, report.Checks["redis"].Critical)
+	})
+
+	t.Run("non-critical failure degrades", func(t *testing.T) {
+		r := NewRegistry()
+		r.Register("postgres", healthy())
+		r.Register("redis", failing("connection refused"), NonCritical())
+
+		report := r.Check(context.Background())
+		assert.Equal(t, StatusDegraded, report.Status)
+		assert.Equal(t, StatusDown, report.Checks["redis"].Status)
+		assert.Equal(t, "connection refused", report.Checks["redis"].Error)
+	})
+
+	t.Run("critical failure is down", func(t *testing.T) {
+		r := NewRegistry()
+		r.Register("postgres", failing("timeout"))
+		r.Register("redis", failing("connection refused"), NonCritical())
+
+		assert.Equal(t, StatusDown, r.Check(context.Background()).Status)
+	})
+
+	t.Run("degraded critical failure degrades", func(t *testing.T) {
+		r := NewRegistry()
+		r.Register("postgres", CheckerFunc(func(ctx context.Context) error {
+			return Degraded(errors.New("reconnecting"))
+		}))
+
+		report := r.Check(context.Background())
+		assert.Equal(t, StatusDegraded, report.Status)
+		assert.Equal(t, StatusDegraded, report.Checks["postgres"].Status)
+		assert.Equal(t, "reconnecting", report.Checks["postgres"].Error)
+		assert.NoError(t, Degraded(nil))
+	})
+
+	t.Run("hung check times out", func(t *testing.T) {
+		r := NewRegistry()
+		block := make(chan struct{})
+		defer close(block)
+		r.Register("stuck", CheckerFunc(func(ctx context.Context) error {
+			<-block
+			return nil
+		}), WithTimeout(20*time.Millisecond))
+
+		start := time.Now()
+		report := r.Check(context.Background())
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Contains(t, report.Checks["stuck"].Error, "timed out")
+	})
+
+	t.Run("panicking check is down", func(t *testing.T) {
+		r := NewRegistry()
+		r.Register("broken", CheckerFunc(func(ctx context.Context) error { panic("boom") }))
+
+		report := r.Check(context.Background())
+		assert.Contains(t, report.Checks["broken"].Error, "boom")
+	})
+}
+
+func TestRegistry_RegisterReplacesByName(t *testing.T) {
+	r := NewRegistry()
+	r.Register("custom", failing("old"))
+	r.Register("custom", healthy())
+
+	assert.Equal(t, []string{"custom"}, r.Names())
+	assert.Equal(t, StatusUp, r.Check(context.Background()).Status)
+}
+
+func TestRegistry_RegisterValidation(t *testing.T) {
+	r := NewRegistry()
+	assert.Panics(t, func() { r.Register("", healthy()) })
+	assert.Panics(t, func() { r.Register("nil", nil) })
+}
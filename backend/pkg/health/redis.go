@@ -0,0 +1,61 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// RedisChecker pings a Redis server over the RESP protocol. It opens a short
+// connection per check so it works without a Redis client dependency.
+func RedisChecker(addr, password string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return fmt.Errorf("connect to redis %s: %w", addr, err)
+		}
+		defer conn.Close()
+
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		} else {
+			conn.SetDeadline(time.Now().Add(defaultTimeout))
+		}
+
+		r := bufio.NewReader(conn)
+		if password != "" {
+			if err := redisCommand(conn, r, "+OK", "AUTH", password); err != nil {
+				return fmt.Errorf("redis auth: %w", err)
+			}
+		}
+		if err := redisCommand(conn, r, "+PONG", "PING"); err != nil {
+			return fmt.Errorf("redis ping: %w", err)
+		}
+		return nil
+	})
+}
+
+func redisCommand(conn net.Conn, r *bufio.Reader, want string, args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return err
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line != want {
+		return fmt.Errorf("unexpected reply %q", line)
+	}
+	return nil
+}
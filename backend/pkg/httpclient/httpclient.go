@@ -0,0 +1,294 @@
+// Package httpclient builds *http.Client instances for calls to external
+// services. Clients time out, pool connections per host, retry idempotent
+// requests that fail transiently, forward the caller's trace and
+// correlation IDs and report every attempt to an observer.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/retry"
+)
+
+// Headers forwarded to the services we call. The request ID identifies a
+// single hop and is not forwarded; the callee assigns its own.
+const (
+	TraceIDHeader       = "X-Trace-ID"
+	CorrelationIDHeader = "X-Correlation-ID"
+	// IdempotencyKeyHeader marks a POST or PATCH as safe to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+)
+
+// Context keys set by the trace middleware. They are read directly so the
+// client works without importing the HTTP layer.
+const (
+	traceIDKey       = "trace_id"
+	correlationIDKey = "correlation_id"
+)
+
+// Config configures a client
+type Config struct {
+	// Name identifies the client in metrics, e.g. "webhooks"
+	Name string
+	// Timeout bounds a whole call, including retries and reading the body
+	Timeout time.Duration
+	// DialTimeout, TLSHandshakeTimeout and ResponseHeaderTimeout bound the
+	// phases of each attempt
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	// IdleConnTimeout closes pooled connections unused for this long
+	IdleConnTimeout time.Duration
+	// MaxIdleConnsPerHost and MaxConnsPerHost size the pool of each host;
+	// zero MaxConnsPerHost means unlimited
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	// Retry is applied to idempotent requests: GET, HEAD, OPTIONS, PUT,
+	// DELETE and requests with an Idempotency-Key header. Network errors
+	// and 429, 502, 503 and 504 responses are retried.
+	Retry retry.Policy
+	// Observer, if set, is called after every attempt
+	Observer func(Observation)
+	// DialControl, if set, is called before each connection with the
+	// resolved address and may refuse it, e.g. netguard.Control. Proxies
+	// are not used then, since the proxy rather than the target would be
+	// checked.
+	DialControl func(network, address string, c syscall.RawConn) error
+}
+
+// Observation describes one attempt of a request
+type Observation struct {
+	Client string
+	Method string
+	Host   string
+	// Status is zero when no response was received
+	Status   int
+	Duration time.Duration
+	// Attempt starts at 1
+	Attempt int
+	Err     error
+}
+
+// DefaultConfig returns the defaults for a client called name
+func DefaultConfig(name string) Config {
+	return Config{
+		Name:                  name,
+		Timeout:               30 * time.Second,
+		DialTimeout:           5 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   16,
+		Retry:                 retry.DefaultPolicy(),
+	}
+}
+
+// New creates a client. Zero durations and pool sizes use the defaults.
+func New(cfg Config) *http.Client {
+	defaults := DefaultConfig(cfg.Name)
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = defaults.DialTimeout
+	}
+	if cfg.TLSHandshakeTimeout <= 0 {
+		cfg.TLSHandshakeTimeout = defaults.TLSHandshakeTimeout
+	}
+	if cfg.ResponseHeaderTimeout <= 0 {
+		cfg.ResponseHeaderTimeout = defaults.ResponseHeaderTimeout
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = defaults.IdleConnTimeout
+	}
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}
+	proxy := http.ProxyFromEnvironment
+	if cfg.DialControl != nil {
+		dialer.Control = cfg.DialControl
+		proxy = nil
+	}
+	base := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: &Transport{Base: base, Config: cfg},
+	}
+}
+
+// Transport adds retries, ID propagation and observations to Base. It is
+// exported for clients that need their own http.Client settings, e.g. a
+// redirect policy.
+type Transport struct {
+	Base   http.RoundTripper
+	Config Config
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = propagate(req)
+
+	policy := t.Config.Retry
+	if !retryable(req) {
+		policy.MaxAttempts = 1
+	}
+	policy.Retryable = func(err error) bool {
+		var status *statusError
+		return errors.As(err, &status) || isNetworkError(err)
+	}
+
+	attempt := 0
+	var last *http.Response
+	resp, err := retry.DoValue(req.Context(), policy, func(ctx context.Context) (*http.Response, error) {
+		attempt++
+		if last != nil {
+			// The previous attempt's response is superseded
+			drain(last)
+			last = nil
+		}
+		try := req
+		if attempt > 1 {
+			var err error
+			if try, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		start := time.Now()
+		resp, err := t.Base.RoundTrip(try)
+		t.observe(req, resp, err, time.Since(start), attempt)
+		if err != nil {
+			return nil, err
+		}
+		if retryStatus(resp.StatusCode) {
+			last = resp
+			return resp, &statusError{code: resp.StatusCode}
+		}
+		return resp, nil
+	})
+
+	// A retryable status is returned as the response once attempts are
+	// used up, like any other status
+	var status *statusError
+	if errors.As(err, &status) {
+		return resp, nil
+	}
+	return resp, err
+}
+
+func (t *Transport) observe(req *http.Request, resp *http.Response, err error, d time.Duration, attempt int) {
+	if t.Config.Observer == nil {
+		return
+	}
+	o := Observation{
+		Client:   t.Config.Name,
+		Method:   req.Method,
+		Host:     req.URL.Host,
+		Duration: d,
+		Attempt:  attempt,
+		Err:      err,
+	}
+	if resp != nil {
+		o.Status = resp.StatusCode
+	}
+	t.Config.Observer(o)
+}
+
+// propagate returns req with the trace and correlation IDs of its context
+// unless the caller set them
+func propagate(req *http.Request) *http.Request {
+	ctx := req.Context()
+	var cloned bool
+	for header, key := range map[string]string{TraceIDHeader: traceIDKey, CorrelationIDHeader: correlationIDKey} {
+		id, _ := ctx.Value(key).(string)
+		if id == "" || req.Header.Get(header) != "" {
+			continue
+		}
+		// RoundTrippers must not modify the caller's request
+		if !cloned {
+			req = req.Clone(ctx)
+			cloned = true
+		}
+		req.Header.Set(header, id)
+	}
+	return req
+}
+
+// retryable reports whether req may be sent more than once
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body cannot be replayed
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// rewind returns a copy of req with a fresh body
+func rewind(req *http.Request) (*http.Request, error) {
+	try := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		try.Body = body
+	}
+	return try, nil
+}
+
+func retryStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isNetworkError reports whether err is a transport failure worth retrying.
+// Cancellation and deadlines of the caller are not.
+func isNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// drain discards a response so its connection can be reused
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+}
+
+// statusError marks a response with a retryable status
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d", e.code)
+}
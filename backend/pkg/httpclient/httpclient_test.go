@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/retry"
+)
+
+func testConfig(observations *[]Observation) Config {
+	cfg := DefaultConfig("test")
+	cfg.Retry = retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	cfg.Observer = func(o Observation) { *observations = append(*observations, o) }
+	return cfg
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var observations []Observation
+	client := New(testConfig(&observations))
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"a":1}`))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 3, calls.Load())
+	assert.Equal(t, []string{`{"a":1}`, `{"a":1}`, `{"a":1}`}, bodies, "the body is replayed")
+	require.Len(t, observations, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, observations[0].Status)
+	assert.Equal(t, 3, observations[2].Attempt)
+	assert.Equal(t, "test", observations[2].Client)
+}
+
+func TestClient_DoesNotRetryNonIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var observations []Observation
+	client := New(testConfig(&observations))
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 1, calls.Load())
+
+	// An idempotency key makes the POST safe to resend
+	calls.Store(0)
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "the last response is returned when retries run out")
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var observations []Observation
+	resp, err := New(testConfig(&observations)).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestClient_PropagatesIDs(t *testing.T) {
+	var trace, correlation, request string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace, correlation = r.Header.Get(TraceIDHeader), r.Header.Get(CorrelationIDHeader)
+		request = r.Header.Get("X-Request-ID")
+	}))
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), traceIDKey, "trace-1")
+	ctx = context.WithValue(ctx, correlationIDKey, "checkout-42")
+	ctx = context.WithValue(ctx, "request_id", "req-1")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	var observations []Observation
+	resp, err := New(testConfig(&observations)).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "trace-1", trace)
+	assert.Equal(t, "checkout-42", correlation)
+	assert.Empty(t, request, "request IDs are per hop")
+	assert.Empty(t, req.Header.Get(TraceIDHeader), "the caller's request is not modified")
+}
+
+func TestClient_StopsWhenContextIsDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	var observations []Observation
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	_, err := New(testConfig(&observations)).Do(req)
+	require.Error(t, err)
+	assert.Len(t, observations, 1, "deadlines are not retried")
+}
@@ -0,0 +1,196 @@
+// Package i18n holds the message catalogs responses are translated with
+// and negotiates the language of a request from its Accept-Language
+// header. English is the source language: every key has an English
+// message, and messages missing from another catalog fall back to it.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLanguage is the source language of the catalogs
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Bundle translates messages into a fixed set of languages
+type Bundle struct {
+	languages []string
+	catalogs  map[string]map[string]string
+	matcher   language.Matcher
+}
+
+// New creates a bundle serving the given languages, which must have an
+// embedded catalog. The default language is always served and is chosen
+// when nothing in Accept-Language matches.
+func New(languages []string) (*Bundle, error) {
+	b := &Bundle{
+		languages: []string{DefaultLanguage},
+		catalogs:  make(map[string]map[string]string),
+	}
+	for _, lang := range languages {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang != "" && !slices.Contains(b.languages, lang) {
+			b.languages = append(b.languages, lang)
+		}
+	}
+
+	tags := make([]language.Tag, 0, len(b.languages))
+	for _, lang := range b.languages {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return nil, fmt.Errorf("invalid language %q: %w", lang, err)
+		}
+		catalog, err := loadCatalog(lang)
+		if err != nil {
+			return nil, err
+		}
+		b.catalogs[lang] = catalog
+		tags = append(tags, tag)
+	}
+	b.matcher = language.NewMatcher(tags)
+	return b, nil
+}
+
+// Available returns the languages with an embedded catalog
+func Available() []string {
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		return nil
+	}
+	languages := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func loadCatalog(lang string) (map[string]string, error) {
+	data, err := locales.ReadFile("locales/" + lang + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("no message catalog for language %q", lang)
+	}
+	catalog := make(map[string]string)
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("invalid message catalog for language %q: %w", lang, err)
+	}
+	return catalog, nil
+}
+
+// Languages returns the languages served, the default language first
+func (b *Bundle) Languages() []string {
+	return append([]string(nil), b.languages...)
+}
+
+// Negotiate returns the served language best matching an Accept-Language
+// header, or the default language when none matches
+func (b *Bundle) Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLanguage
+	}
+	_, index, confidence := b.matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLanguage
+	}
+	return b.languages[index]
+}
+
+// Lookup returns the message for key in lang without falling back
+func (b *Bundle) Lookup(lang, key string) (string, bool) {
+	msg, ok := b.catalogs[lang][key]
+	return msg, ok
+}
+
+// Has reports whether the default language has a message for key
+func (b *Bundle) Has(key string) bool {
+	_, ok := b.Lookup(DefaultLanguage, key)
+	return ok
+}
+
+// Translate returns the message for key in lang, falling back to English
+// and then to the key itself. {name} placeholders are replaced by args.
+func (b *Bundle) Translate(lang, key string, args map[string]interface{}) string {
+	msg, ok := b.Lookup(lang, key)
+	if !ok {
+		if msg, ok = b.Lookup(DefaultLanguage, key); !ok {
+			msg = key
+		}
+	}
+	return Format(msg, args)
+}
+
+// Format replaces the {name} placeholders of msg with args
+func Format(msg string, args map[string]interface{}) string {
+	if len(args) == 0 || !strings.Contains(msg, "{") {
+		return msg
+	}
+	pairs := make([]string, 0, 2*len(args))
+	for name, value := range args {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+var (
+	mu     sync.RWMutex
+	bundle *Bundle
+)
+
+// Use replaces the process-wide bundle; nil restores the bundle serving
+// every embedded language
+func Use(b *Bundle) {
+	mu.Lock()
+	defer mu.Unlock()
+	bundle = b
+}
+
+// Get returns the process-wide bundle
+func Get() *Bundle {
+	mu.RLock()
+	b := bundle
+	mu.RUnlock()
+	if b != nil {
+		return b
+	}
+	return defaultBundle()
+}
+
+var defaultBundle = sync.OnceValue(func() *Bundle {
+	b, err := New(Available())
+	if err != nil {
+		panic(err)
+	}
+	return b
+})
+
+type languageKey struct{}
+
+// WithLanguage returns ctx carrying the language negotiated for a request
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// LanguageFromContext returns the language negotiated for the request, or
+// the default language when none was
+func LanguageFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return DefaultLanguage
+	}
+	if lang, ok := ctx.Value(languageKey{}).(string); ok && lang != "" {
+		return lang
+	}
+	return DefaultLanguage
+}
@@ -0,0 +1,82 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	b, err := New([]string{"ZH", "en", "zh"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en", "zh"}, b.Languages())
+
+	b, err = New(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en"}, b.Languages())
+
+	_, err = New([]string{"fr"})
+	assert.Error(t, err, "languages without a catalog are refused")
+}
+
+func TestBundle_Negotiate(t *testing.T) {
+	b, err := New([]string{"zh"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "en", b.Negotiate(""))
+	assert.Equal(t, "zh", b.Negotiate("zh-CN"))
+	assert.Equal(t, "zh", b.Negotiate("fr;q=0.9, zh-Hans;q=0.8"))
+	assert.Equal(t, "en", b.Negotiate("en-GB, zh;q=0.5"))
+	assert.Equal(t, "en", b.Negotiate("ja"))
+	assert.Equal(t, "en", b.Negotiate(";;;"))
+
+	english, err := New(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "en", english.Negotiate("zh-CN"), "only served languages are chosen")
+}
+
+func TestBundle_Translate(t *testing.T) {
+	b, err := New([]string{"zh"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "name 为必填项", b.Translate("zh", "field.required", map[string]interface{}{"field": "name"}))
+	assert.Equal(t, "name is required", b.Translate("en", "field.required", map[string]interface{}{"field": "name"}))
+	assert.Equal(t, "Resource not found", b.Translate("fr", "error.ENTITY_NOT_FOUND", nil), "falls back to English")
+	assert.Equal(t, "no.such.key", b.Translate("zh", "no.such.key", nil), "falls back to the key")
+
+	_, ok := b.Lookup("zh", "no.such.key")
+	assert.False(t, ok)
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "age must be at least 18", Format("{field} must be at least {min}", map[string]interface{}{"field": "age", "min": 18}))
+	assert.Equal(t, "{field} stays", Format("{field} stays", nil))
+}
+
+func TestCatalogs(t *testing.T) {
+	english, err := loadCatalog(DefaultLanguage)
+	require.NoError(t, err)
+
+	// Every error code has an English message
+	for _, entry := range errors.Catalog() {
+		assert.Contains(t, english, "error."+string(entry.Code))
+	}
+
+	// Other catalogs only translate English keys
+	for _, lang := range Available() {
+		catalog, err := loadCatalog(lang)
+		require.NoError(t, err, lang)
+		for key := range catalog {
+			assert.Contains(t, english, key, "%s: key missing from the English catalog", lang)
+		}
+	}
+}
+
+func TestLanguageFromContext(t *testing.T) {
+	assert.Equal(t, "en", LanguageFromContext(context.Background()))
+	assert.Equal(t, "zh", LanguageFromContext(WithLanguage(context.Background(), "zh")))
+}
@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestSquareThumbnail_CropsAndScalesOpaqueToJPEG(t *testing.T) {
+	// 400x200: the centered square is x 100..300, painted red; the rest is blue
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.RGBA{B: 255, A: 255}
+			if x >= 100 && x < 300 {
+				c = color.RGBA{R: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	thumb, err := SquareThumbnail(encodePNG(t, src), 64, 1_000_000)
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", thumb.ContentType)
+	assert.Equal(t, "jpg", thumb.Ext)
+
+	img, err := jpeg.Decode(bytes.NewReader(thumb.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 64), img.Bounds())
+	for _, p := range []image.Point{{0, 0}, {63, 63}, {32, 32}} {
+		r, _, b, _ := img.At(p.X, p.Y).RGBA()
+		assert.Greater(t, r>>8, uint32(200), "red at %v", p)
+		assert.Less(t, b>>8, uint32(60), "no blue at %v", p)
+	}
+}
+
+func TestSquareThumbnail_KeepsTransparencyAndDoesNotUpscale(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 128})
+
+	thumb, err := SquareThumbnail(encodePNG(t, src), 256, 1_000_000)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", thumb.ContentType)
+
+	img, err := png.Decode(bytes.NewReader(thumb.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 10, 10), img.Bounds())
+	assert.Equal(t, color.NRGBA{R: 255, A: 128}, color.NRGBAModel.Convert(img.At(0, 0)))
+}
+
+func TestSquareThumbnail_Rejects(t *testing.T) {
+	_, err := SquareThumbnail([]byte("<svg></svg>"), 64, 1_000_000)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	big := encodePNG(t, image.NewGray(image.Rect(0, 0, 2000, 1000)))
+	_, err = SquareThumbnail(big, 64, 1_000_000)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestSquareThumbnail_AveragesFineDetail(t *testing.T) {
+	// A one-pixel checkerboard scaled down twentyfold must turn gray, not
+	// alias to black or white
+	src := image.NewGray(image.Rect(0, 0, 200, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			if (x+y)%2 == 0 {
+				src.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	thumb, err := SquareThumbnail(encodePNG(t, src), 10, 1_000_000)
+	require.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(thumb.Data))
+	require.NoError(t, err)
+	for _, p := range []image.Point{{0, 0}, {5, 5}, {9, 9}} {
+		gray := color.GrayModel.Convert(img.At(p.X, p.Y)).(color.Gray).Y
+		assert.InDelta(t, 128, int(gray), 12, "at %v", p)
+	}
+}
@@ -0,0 +1,142 @@
+// Package jobs runs background work from a queue. Failed jobs are retried
+// with backoff and moved to a dead-letter set once their attempts are used
+// up, where an operator can retry or delete them.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultMaxAttempts is the worker's attempt limit unless set with
+// WithMaxAttempts
+const DefaultMaxAttempts = 5
+
+// ErrNotFound is returned for a dead job that does not exist
+var ErrNotFound = errors.New("jobs: job not found")
+
+// Job is a unit of background work
+type Job struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Attempts counts the runs that failed so far
+	Attempts int `json:"attempts"`
+	// MaxAttempts overrides the worker's attempt limit when set
+	MaxAttempts int       `json:"max_attempts,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	EnqueuedAt  time.Time `json:"enqueued_at"`
+	// RunAt is when the job is due; later for scheduled jobs and retries
+	RunAt    time.Time  `json:"run_at"`
+	FailedAt *time.Time `json:"failed_at,omitempty"`
+}
+
+// NewJob creates a job of jobType with payload encoded as JSON
+func NewJob(jobType string, payload interface{}) (*Job, error) {
+	job := &Job{Type: jobType}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s payload: %w", jobType, err)
+		}
+		job.Payload = data
+	}
+	return job, nil
+}
+
+// Decode unmarshals the payload into v
+func (j *Job) Decode(v interface{}) error {
+	if len(j.Payload) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("decode %s payload: %w", j.Type, err))
+	}
+	return nil
+}
+
+// prepare fills in the ID and timestamps of a new job
+func (j *Job) prepare(now time.Time) error {
+	if j.Type == "" {
+		return fmt.Errorf("jobs: job type is required")
+	}
+	if j.ID == "" {
+		j.ID = newJobID()
+	}
+	j.EnqueuedAt = now
+	if j.RunAt.IsZero() {
+		j.RunAt = now
+	}
+	return nil
+}
+
+func newJobID() string {
+	var id [12]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// Stats counts the jobs in each state
+type Stats struct {
+	// Pending jobs are due now
+	Pending int64 `json:"pending"`
+	// Scheduled jobs wait for their RunAt, including retries in backoff
+	Scheduled int64 `json:"scheduled"`
+	// Active jobs are leased by a worker
+	Active int64 `json:"active"`
+	Dead   int64 `json:"dead"`
+}
+
+// Queue stores jobs between enqueueing and completion. A job is leased to
+// one worker at a time; a lease that runs out before the job is completed,
+// retried or buried, e.g. because the worker crashed, makes the job due
+// again. Jobs therefore run at least once.
+type Queue interface {
+	// Enqueue stores a new job
+	Enqueue(ctx context.Context, job *Job) error
+	// Claim leases the next due job until now+lease. It returns nil, nil
+	// when no job is due.
+	Claim(ctx context.Context, lease time.Duration) (*Job, error)
+	// Complete removes a finished job
+	Complete(ctx context.Context, job *Job) error
+	// Retry releases a failed job to run again at job.RunAt
+	Retry(ctx context.Context, job *Job) error
+	// Bury moves a failed job to the dead-letter set
+	Bury(ctx context.Context, job *Job) error
+	// Stats counts the jobs in each state
+	Stats(ctx context.Context) (Stats, error)
+	// Dead lists up to limit dead jobs from offset, most recently failed
+	// first, with their total count
+	Dead(ctx context.Context, offset, limit int) ([]*Job, int64, error)
+	// RetryDead makes a dead job due now with its attempts reset
+	RetryDead(ctx context.Context, id string) error
+	// DeleteDead discards a dead job
+	DeleteDead(ctx context.Context, id string) error
+}
+
+// permanentError marks an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err so the job is buried at once instead of retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,191 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryQueue keeps jobs in process memory. Jobs are lost on restart, so
+// use it for development, tests and single instances that can afford that.
+type MemoryQueue struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	pending map[string]*Job
+	// active maps leased jobs to their lease deadline
+	active map[string]leasedJob
+	dead   map[string]*Job
+}
+
+type leasedJob struct {
+	job   *Job
+	until time.Time
+}
+
+var _ Queue = (*MemoryQueue)(nil)
+
+// NewMemoryQueue creates an empty in-memory queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		now:     time.Now,
+		pending: make(map[string]*Job),
+		active:  make(map[string]leasedJob),
+		dead:    make(map[string]*Job),
+	}
+}
+
+// Enqueue implements Queue
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := job.prepare(q.now()); err != nil {
+		return err
+	}
+	q.pending[job.ID] = clone(job)
+	return nil
+}
+
+// Claim implements Queue
+func (q *MemoryQueue) Claim(ctx context.Context, lease time.Duration) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+
+	for id, leased := range q.active {
+		if !leased.until.After(now) {
+			delete(q.active, id)
+			q.pending[id] = leased.job
+		}
+	}
+
+	var next *Job
+	for _, job := range q.pending {
+		if job.RunAt.After(now) {
+			continue
+		}
+		if next == nil || job.RunAt.Before(next.RunAt) || (job.RunAt.Equal(next.RunAt) && job.ID < next.ID) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	delete(q.pending, next.ID)
+	q.active[next.ID] = leasedJob{job: next, until: now.Add(lease)}
+	return clone(next), nil
+}
+
+// Complete implements Queue
+func (q *MemoryQueue) Complete(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.active, job.ID)
+	return nil
+}
+
+// Retry implements Queue
+func (q *MemoryQueue) Retry(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.active, job.ID)
+	q.pending[job.ID] = clone(job)
+	return nil
+}
+
+// Bury implements Queue
+func (q *MemoryQueue) Bury(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.active, job.ID)
+	dead := clone(job)
+	if dead.FailedAt == nil {
+		now := q.now()
+		dead.FailedAt = &now
+	}
+	q.dead[job.ID] = dead
+	return nil
+}
+
+// Stats implements Queue
+func (q *MemoryQueue) Stats(ctx context.Context) (Stats, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+
+	stats := Stats{Active: int64(len(q.active)), Dead: int64(len(q.dead))}
+	for _, job := range q.pending {
+		if job.RunAt.After(now) {
+			stats.Scheduled++
+		} else {
+			stats.Pending++
+		}
+	}
+	return stats, nil
+}
+
+// Dead implements Queue
+func (q *MemoryQueue) Dead(ctx context.Context, offset, limit int) ([]*Job, int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	dead := make([]*Job, 0, len(q.dead))
+	for _, job := range q.dead {
+		dead = append(dead, clone(job))
+	}
+	sort.Slice(dead, func(i, j int) bool {
+		if !dead[i].FailedAt.Equal(*dead[j].FailedAt) {
+			return dead[i].FailedAt.After(*dead[j].FailedAt)
+		}
+		return dead[i].ID > dead[j].ID
+	})
+
+	total := int64(len(dead))
+	if offset >= len(dead) {
+		return []*Job{}, total, nil
+	}
+	dead = dead[offset:]
+	if limit < len(dead) {
+		dead = dead[:limit]
+	}
+	return dead, total, nil
+}
+
+// RetryDead implements Queue
+func (q *MemoryQueue) RetryDead(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.dead[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(q.dead, id)
+	resurrect(job, q.now())
+	q.pending[id] = job
+	return nil
+}
+
+// DeleteDead implements Queue
+func (q *MemoryQueue) DeleteDead(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.dead[id]; !ok {
+		return ErrNotFound
+	}
+	delete(q.dead, id)
+	return nil
+}
+
+// resurrect makes a dead job due at now with a fresh set of attempts. The
+// last error is kept for reference.
+func resurrect(job *Job, now time.Time) {
+	job.Attempts = 0
+	job.FailedAt = nil
+	job.RunAt = now
+}
+
+func clone(job *Job) *Job {
+	copied := *job
+	return &copied
+}
@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+func TestQueues(t *testing.T) {
+	queues := map[string]func(t *testing.T) Queue{
+		"memory": func(t *testing.T) Queue { return NewMemoryQueue() },
+		"redis": func(t *testing.T) Queue {
+			client := redis.NewClient(redis.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { client.Close() })
+			return NewRedisQueue(client, "")
+		},
+	}
+
+	for name, newQueue := range queues {
+		t.Run(name, func(t *testing.T) {
+			t.Run("lifecycle", func(t *testing.T) { testQueueLifecycle(t, newQueue(t)) })
+			t.Run("expired lease", func(t *testing.T) { testQueueExpiredLease(t, newQueue(t)) })
+		})
+	}
+}
+
+func testQueueLifecycle(t *testing.T, q Queue) {
+	ctx := context.Background()
+	now := time.Now()
+
+	enqueue := func(jobType string, runAt time.Time) *Job {
+		job, err := NewJob(jobType, map[string]string{"key": jobType})
+		require.NoError(t, err)
+		job.RunAt = runAt
+		require.NoError(t, q.Enqueue(ctx, job))
+		return job
+	}
+	soon := enqueue("soon", now.Add(-time.Second))
+	due := enqueue("due", time.Time{})
+	enqueue("later", now.Add(time.Hour))
+	assert.NotEmpty(t, due.ID)
+	assert.False(t, due.EnqueuedAt.IsZero())
+
+	// Due jobs come out earliest first; later is not due yet
+	first, err := q.Claim(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, soon.ID, first.ID)
+
+	second, err := q.Claim(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	assert.Equal(t, due.ID, second.ID)
+	var payload map[string]string
+	require.NoError(t, second.Decode(&payload))
+	assert.Equal(t, "due", payload["key"])
+
+	none, err := q.Claim(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	stats, err := q.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Scheduled: 1, Active: 2}, stats)
+
+	require.NoError(t, q.Complete(ctx, first))
+
+	// A retried job is due again at its RunAt
+	second.Attempts = 1
+	second.LastError = "boom"
+	second.RunAt = time.Now().Add(-time.Millisecond)
+	require.NoError(t, q.Retry(ctx, second))
+	retried, err := q.Claim(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, retried)
+	assert.Equal(t, 1, retried.Attempts)
+	assert.Equal(t, "boom", retried.LastError)
+
+	require.NoError(t, q.Bury(ctx, retried))
+	dead, total, err := q.Dead(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, dead, 1)
+	assert.Equal(t, due.ID, dead[0].ID)
+	assert.NotNil(t, dead[0].FailedAt)
+
+	stats, err = q.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Scheduled: 1, Dead: 1}, stats)
+
+	// Retrying a dead job resets its attempts
+	require.NoError(t, q.RetryDead(ctx, due.ID))
+	assert.ErrorIs(t, q.RetryDead(ctx, due.ID), ErrNotFound)
+	revived, err := q.Claim(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, revived)
+	assert.Equal(t, 0, revived.Attempts)
+	assert.Nil(t, revived.FailedAt)
+
+	require.NoError(t, q.Bury(ctx, revived))
+	require.NoError(t, q.DeleteDead(ctx, due.ID))
+	assert.ErrorIs(t, q.DeleteDead(ctx, due.ID), ErrNotFound)
+	dead, total, err = q.Dead(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+	assert.Zero(t, total)
+}
+
+func testQueueExpiredLease(t *testing.T, q Queue) {
+	ctx := context.Background()
+	job, err := NewJob("lost", nil)
+	require.NoError(t, err)
+	require.NoError(t, q.Enqueue(ctx, job))
+
+	claimed, err := q.Claim(ctx, 5*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+
+	none, err := q.Claim(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, none, "a leased job is not handed out twice")
+
+	// The worker holding it went away; the job comes back after the lease
+	time.Sleep(10 * time.Millisecond)
+	again, err := q.Claim(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, again)
+	assert.Equal(t, job.ID, again.ID)
+}
+
+func TestNewJob(t *testing.T) {
+	_, err := NewJob("bad", func() {})
+	assert.Error(t, err)
+
+	job := &Job{Type: "raw", Payload: []byte("{")}
+	var v map[string]string
+	err = job.Decode(&v)
+	assert.True(t, IsPermanent(err))
+
+	assert.Error(t, NewMemoryQueue().Enqueue(context.Background(), &Job{}), "type is required")
+}
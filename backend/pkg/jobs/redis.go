@@ -0,0 +1,256 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+// DefaultRedisKeyPrefix namespaces the queue's keys. The braces keep them
+// in one Redis Cluster slot, as the claim script needs.
+const DefaultRedisKeyPrefix = "wonder:{jobs}"
+
+// redisClaimScript requeues jobs whose lease ran out, then leases the next
+// due job and returns its ID, or nil when none is due
+// KEYS[1] pending set  KEYS[2] active set  ARGV[1] now ms  ARGV[2] lease deadline ms
+var redisClaimScript = redis.NewScript(`
+for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('ZADD', KEYS[1], ARGV[1], id)
+end
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #due == 0 then
+	return false
+end
+redis.call('ZREM', KEYS[1], due[1])
+redis.call('ZADD', KEYS[2], ARGV[2], due[1])
+return due[1]`)
+
+// RedisQueue keeps jobs in Redis so every instance shares one queue. Each
+// job is a JSON string; sorted sets order pending jobs by due time, active
+// jobs by lease deadline and dead jobs by failure time.
+type RedisQueue struct {
+	client *redis.Client
+	prefix string
+}
+
+var _ Queue = (*RedisQueue)(nil)
+
+// NewRedisQueue creates a queue on client. An empty prefix uses
+// DefaultRedisKeyPrefix.
+func NewRedisQueue(client *redis.Client, prefix string) *RedisQueue {
+	if client == nil {
+		panic("redis client cannot be nil")
+	}
+	if prefix == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+	return &RedisQueue{client: client, prefix: prefix}
+}
+
+func (q *RedisQueue) jobKey(id string) string { return q.prefix + ":job:" + id }
+func (q *RedisQueue) pendingKey() string      { return q.prefix + ":pending" }
+func (q *RedisQueue) activeKey() string       { return q.prefix + ":active" }
+func (q *RedisQueue) deadKey() string         { return q.prefix + ":dead" }
+
+// Enqueue implements Queue
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) error {
+	if err := job.prepare(time.Now()); err != nil {
+		return err
+	}
+	if err := q.save(ctx, job); err != nil {
+		return err
+	}
+	_, err := q.client.Do(ctx, "ZADD", q.pendingKey(), millis(job.RunAt), job.ID)
+	return err
+}
+
+// Claim implements Queue
+func (q *RedisQueue) Claim(ctx context.Context, lease time.Duration) (*Job, error) {
+	for {
+		now := time.Now()
+		reply, err := redisClaimScript.Run(ctx, q.client,
+			[]string{q.pendingKey(), q.activeKey()},
+			millis(now), millis(now.Add(lease)))
+		if errors.Is(err, redis.ErrNil) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		id, ok := reply.(string)
+		if !ok {
+			return nil, fmt.Errorf("jobs: unexpected claim reply %T", reply)
+		}
+
+		job, err := q.load(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			// The job data is gone; drop the orphaned ID and try the next
+			if _, err := q.client.Do(ctx, "ZREM", q.activeKey(), id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return job, err
+	}
+}
+
+// Complete implements Queue
+func (q *RedisQueue) Complete(ctx context.Context, job *Job) error {
+	if _, err := q.client.Do(ctx, "ZREM", q.activeKey(), job.ID); err != nil {
+		return err
+	}
+	_, err := q.client.Do(ctx, "DEL", q.jobKey(job.ID))
+	return err
+}
+
+// Retry implements Queue
+func (q *RedisQueue) Retry(ctx context.Context, job *Job) error {
+	if err := q.save(ctx, job); err != nil {
+		return err
+	}
+	if _, err := q.client.Do(ctx, "ZADD", q.pendingKey(), millis(job.RunAt), job.ID); err != nil {
+		return err
+	}
+	_, err := q.client.Do(ctx, "ZREM", q.activeKey(), job.ID)
+	return err
+}
+
+// Bury implements Queue
+func (q *RedisQueue) Bury(ctx context.Context, job *Job) error {
+	if job.FailedAt == nil {
+		now := time.Now()
+		job.FailedAt = &now
+	}
+	if err := q.save(ctx, job); err != nil {
+		return err
+	}
+	if _, err := q.client.Do(ctx, "ZADD", q.deadKey(), millis(*job.FailedAt), job.ID); err != nil {
+		return err
+	}
+	_, err := q.client.Do(ctx, "ZREM", q.activeKey(), job.ID)
+	return err
+}
+
+// Stats implements Queue
+func (q *RedisQueue) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	var queued int64
+	for _, count := range []struct {
+		dst  *int64
+		args []string
+	}{
+		{&queued, []string{"ZCARD", q.pendingKey()}},
+		{&stats.Pending, []string{"ZCOUNT", q.pendingKey(), "-inf", millis(time.Now())}},
+		{&stats.Active, []string{"ZCARD", q.activeKey()}},
+		{&stats.Dead, []string{"ZCARD", q.deadKey()}},
+	} {
+		n, err := q.client.Int(ctx, count.args...)
+		if err != nil {
+			return Stats{}, err
+		}
+		*count.dst = n
+	}
+	stats.Scheduled = queued - stats.Pending
+	return stats, nil
+}
+
+// Dead implements Queue
+func (q *RedisQueue) Dead(ctx context.Context, offset, limit int) ([]*Job, int64, error) {
+	total, err := q.client.Int(ctx, "ZCARD", q.deadKey())
+	if err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 || int64(offset) >= total {
+		return []*Job{}, total, nil
+	}
+
+	reply, err := q.client.Do(ctx, "ZREVRANGE", q.deadKey(), strconv.Itoa(offset), strconv.Itoa(offset+limit-1))
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, _ := reply.([]interface{})
+
+	dead := make([]*Job, 0, len(ids))
+	for _, id := range ids {
+		job, err := q.load(ctx, fmt.Sprint(id))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		dead = append(dead, job)
+	}
+	return dead, total, nil
+}
+
+// RetryDead implements Queue
+func (q *RedisQueue) RetryDead(ctx context.Context, id string) error {
+	job, err := q.takeDead(ctx, id)
+	if err != nil {
+		return err
+	}
+	resurrect(job, time.Now())
+	if err := q.save(ctx, job); err != nil {
+		return err
+	}
+	_, err = q.client.Do(ctx, "ZADD", q.pendingKey(), millis(job.RunAt), job.ID)
+	return err
+}
+
+// DeleteDead implements Queue
+func (q *RedisQueue) DeleteDead(ctx context.Context, id string) error {
+	if _, err := q.takeDead(ctx, id); err != nil {
+		return err
+	}
+	_, err := q.client.Do(ctx, "DEL", q.jobKey(id))
+	return err
+}
+
+// takeDead removes id from the dead-letter set. Removal is the claim, so
+// two operators acting on the same job do not both succeed.
+func (q *RedisQueue) takeDead(ctx context.Context, id string) (*Job, error) {
+	removed, err := q.client.Int(ctx, "ZREM", q.deadKey(), id)
+	if err != nil {
+		return nil, err
+	}
+	if removed == 0 {
+		return nil, ErrNotFound
+	}
+	return q.load(ctx, id)
+}
+
+func (q *RedisQueue) save(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.Do(ctx, "SET", q.jobKey(job.ID), string(data))
+	return err
+}
+
+func (q *RedisQueue) load(ctx context.Context, id string) (*Job, error) {
+	raw, err := q.client.String(ctx, "GET", q.jobKey(id))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, fmt.Errorf("decode job %s: %w", id, err)
+	}
+	return &job, nil
+}
+
+func millis(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
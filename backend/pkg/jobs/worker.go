@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/retry"
+)
+
+// Handler runs one job. Returning an error retries the job with backoff
+// until its attempts are used up; wrap the error with Permanent to bury
+// the job at once.
+type Handler func(ctx context.Context, job *Job) error
+
+// WorkerOption configures a Worker
+type WorkerOption func(*Worker)
+
+// WithConcurrency sets how many jobs run at once
+func WithConcurrency(n int) WorkerOption {
+	return func(w *Worker) {
+		if n > 0 {
+			w.concurrency = n
+		}
+	}
+}
+
+// WithPollInterval sets how long an idle worker waits before looking for
+// new jobs
+func WithPollInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if d > 0 {
+			w.pollInterval = d
+		}
+	}
+}
+
+// WithLease sets how long a job may run. It is cancelled when the lease
+// ends, and another worker may pick it up after that.
+func WithLease(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if d > 0 {
+			w.lease = d
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubling up to max
+func WithBackoff(base, max time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if base > 0 {
+			w.backoff.BaseDelay = base
+			w.backoff.MaxDelay = max
+		}
+	}
+}
+
+// WithMaxAttempts sets how often a job may fail before it is buried. A
+// job's own MaxAttempts takes precedence.
+func WithMaxAttempts(n int) WorkerOption {
+	return func(w *Worker) {
+		if n > 0 {
+			w.maxAttempts = n
+		}
+	}
+}
+
+// WithWorkerLogger sets the logger
+func WithWorkerLogger(log logger.Logger) WorkerOption {
+	return func(w *Worker) {
+		if log != nil {
+			w.log = log
+		}
+	}
+}
+
+// Worker runs jobs from a queue on a pool of goroutines
+type Worker struct {
+	queue        Queue
+	concurrency  int
+	pollInterval time.Duration
+	lease        time.Duration
+	maxAttempts  int
+	backoff      retry.Policy
+	log          logger.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	running  bool
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+// NewWorker creates a stopped worker; register handlers, then Start it
+func NewWorker(queue Queue, opts ...WorkerOption) *Worker {
+	if queue == nil {
+		panic("job queue cannot be nil")
+	}
+	w := &Worker{
+		queue:        queue,
+		concurrency:  4,
+		pollInterval: time.Second,
+		lease:        5 * time.Minute,
+		maxAttempts:  DefaultMaxAttempts,
+		backoff: retry.Policy{
+			BaseDelay:  5 * time.Second,
+			MaxDelay:   10 * time.Minute,
+			Multiplier: 2,
+		},
+		log:      logger.Get().WithLayer("infrastructure").WithComponent("job_worker"),
+		handlers: make(map[string]Handler),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Register sets the handler of jobType, replacing any earlier one
+func (w *Worker) Register(jobType string, handler Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[jobType] = handler
+}
+
+// Handles reports whether jobType has a handler
+func (w *Worker) Handles(jobType string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	_, ok := w.handlers[jobType]
+	return ok
+}
+
+// Start launches the pool. ctx is the parent of every job's context.
+func (w *Worker) Start(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running {
+		return
+	}
+	w.running = true
+	w.stop = make(chan struct{})
+
+	for i := 0; i < w.concurrency; i++ {
+		w.done.Add(1)
+		go w.loop(ctx, w.stop)
+	}
+	w.log.Info(ctx, "job worker started", "concurrency", w.concurrency)
+}
+
+// Stop stops claiming jobs and waits for running ones to finish or ctx to
+// end. Jobs cut off by ctx are picked up again when their lease runs out.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return nil
+	}
+	w.running = false
+	close(w.stop)
+	w.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		w.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) loop(ctx context.Context, stop <-chan struct{}) {
+	defer w.done.Done()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		ran, err := w.RunOnce(ctx)
+		if err != nil {
+			w.log.Warn(ctx, "job queue unavailable", "error", err)
+		}
+		if ran {
+			continue
+		}
+
+		timer := time.NewTimer(w.pollInterval)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// RunOnce claims and runs one due job. It reports whether there was one.
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	job, err := w.queue.Claim(ctx, w.lease)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	runErr := w.run(ctx, job)
+	if runErr == nil {
+		w.log.Info(ctx, "job completed", "job_id", job.ID, "type", job.Type)
+		return true, w.queue.Complete(ctx, job)
+	}
+
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = w.maxAttempts
+	}
+	job.Attempts++
+	job.LastError = runErr.Error()
+	if IsPermanent(runErr) || job.Attempts >= maxAttempts {
+		w.log.Error(ctx, "job failed, moved to dead letter", "error", runErr, "job_id", job.ID, "type", job.Type, "attempts", job.Attempts)
+		return true, w.queue.Bury(ctx, job)
+	}
+
+	delay := w.backoff.Delay(job.Attempts)
+	job.RunAt = time.Now().Add(delay)
+	w.log.Warn(ctx, "job failed, will retry", "error", runErr, "job_id", job.ID, "type", job.Type, "attempts", job.Attempts, "retry_in", delay.String())
+	return true, w.queue.Retry(ctx, job)
+}
+
+// run calls the job's handler within its lease, turning panics into errors
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	w.mu.RLock()
+	handler, ok := w.handlers[job.Type]
+	w.mu.RUnlock()
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job type %q", job.Type))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.lease)
+	defer cancel()
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job handler panicked: %v", p)
+		}
+	}()
+	return handler(ctx, job)
+}
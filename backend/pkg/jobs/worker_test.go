@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+func enqueueJob(t *testing.T, q Queue, jobType string, maxAttempts int) *Job {
+	t.Helper()
+	job, err := NewJob(jobType, nil)
+	require.NoError(t, err)
+	job.MaxAttempts = maxAttempts
+	require.NoError(t, q.Enqueue(context.Background(), job))
+	return job
+}
+
+func TestWorker_RunOnce(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		handler     Handler
+		maxAttempts int
+		wantStats   Stats
+		wantError   string
+	}{
+		{
+			name:      "success completes the job",
+			handler:   func(context.Context, *Job) error { return nil },
+			wantStats: Stats{},
+		},
+		{
+			name:        "failure schedules a retry",
+			handler:     func(context.Context, *Job) error { return errors.New("smtp down") },
+			maxAttempts: 3,
+			wantStats:   Stats{Scheduled: 1},
+		},
+		{
+			name:        "last attempt buries the job",
+			handler:     func(context.Context, *Job) error { return errors.New("smtp down") },
+			maxAttempts: 1,
+			wantStats:   Stats{Dead: 1},
+			wantError:   "smtp down",
+		},
+		{
+			name:        "permanent failure buries the job",
+			handler:     func(context.Context, *Job) error { return Permanent(errors.New("bad payload")) },
+			maxAttempts: 3,
+			wantStats:   Stats{Dead: 1},
+			wantError:   "bad payload",
+		},
+		{
+			name:        "panic counts as failure",
+			handler:     func(context.Context, *Job) error { panic("boom") },
+			maxAttempts: 1,
+			wantStats:   Stats{Dead: 1},
+			wantError:   "job handler panicked: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewMemoryQueue()
+			w := NewWorker(q)
+			w.Register("task", tt.handler)
+			enqueueJob(t, q, "task", tt.maxAttempts)
+
+			ran, err := w.RunOnce(ctx)
+			require.NoError(t, err)
+			assert.True(t, ran)
+
+			stats, err := q.Stats(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStats, stats)
+
+			if tt.wantError != "" {
+				dead, _, err := q.Dead(ctx, 0, 1)
+				require.NoError(t, err)
+				require.Len(t, dead, 1)
+				assert.Equal(t, tt.wantError, dead[0].LastError)
+			}
+		})
+	}
+}
+
+func TestWorker_MaxAttempts(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	w := NewWorker(q, WithMaxAttempts(1))
+	w.Register("task", func(context.Context, *Job) error { return errors.New("smtp down") })
+
+	enqueueJob(t, q, "task", 0)
+	enqueueJob(t, q, "task", 2)
+	for i := 0; i < 2; i++ {
+		_, err := w.RunOnce(ctx)
+		require.NoError(t, err)
+	}
+
+	// The worker's limit buries the first; the second has one attempt left
+	stats, err := q.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Scheduled: 1, Dead: 1}, stats)
+}
+
+func TestWorker_UnknownTypeIsBuried(t *testing.T) {
+	logger.Initialize()
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	enqueueJob(t, q, "unknown", 5)
+
+	ran, err := NewWorker(q).RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	dead, _, err := q.Dead(ctx, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Contains(t, dead[0].LastError, `no handler for job type "unknown"`)
+}
+
+func TestWorker_StartStop(t *testing.T) {
+	logger.Initialize()
+	q := NewMemoryQueue()
+	w := NewWorker(q, WithConcurrency(2), WithPollInterval(5*time.Millisecond), WithBackoff(time.Millisecond, time.Millisecond))
+
+	var runs atomic.Int32
+	w.Register("flaky", func(ctx context.Context, job *Job) error {
+		if runs.Add(1) == 1 {
+			return errors.New("first run fails")
+		}
+		return nil
+	})
+	assert.True(t, w.Handles("flaky"))
+	assert.False(t, w.Handles("other"))
+
+	w.Start(context.Background())
+	enqueueJob(t, q, "flaky", 3)
+
+	require.Eventually(t, func() bool {
+		stats, _ := q.Stats(context.Background())
+		return runs.Load() == 2 && stats == Stats{}
+	}, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, w.Stop(ctx))
+	require.NoError(t, w.Stop(ctx), "stopping twice is a no-op")
+}
@@ -0,0 +1,39 @@
+// Package jsoncodec encodes API responses and decodes request bodies with
+// the JSON engine selected at build time: encoding/json by default, or
+// json-iterator when built with the jsoniter tag, which also switches gin's
+// own JSON rendering and binding:
+//
+//	go build -tags jsoniter ./cmd/server
+//
+// Both engines produce the same bytes for the API's payloads, and decoding
+// fails with the same encoding/json errors whichever engine runs.
+package jsoncodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Encoder writes JSON values to a stream, like json.Encoder
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// NewEncoder returns an encoder writing to w with the selected engine
+func NewEncoder(w io.Writer) Encoder {
+	return newEncoder(w)
+}
+
+// Decode decodes the first JSON value in data into v, as a json.Decoder
+// reading data would, so trailing data after it is ignored. Errors are
+// those of encoding/json, such as *json.SyntaxError and
+// *json.UnmarshalTypeError, so callers can report what was wrong.
+func Decode(data []byte, v interface{}) error {
+	return decode(data, v)
+}
+
+// decodeStandard decodes with encoding/json
+func decodeStandard(data []byte, v interface{}) error {
+	return json.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
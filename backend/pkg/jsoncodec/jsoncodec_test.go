@@ -0,0 +1,59 @@
+package jsoncodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Email string  `json:"email"`
+	Age   int     `json:"age"`
+	Name  *string `json:"name"`
+}
+
+func TestEncoder_MatchesMarshal(t *testing.T) {
+	v := map[string]interface{}{"b": "<a&b>", "a": []int{1, 2}, "c": nil}
+	want, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewEncoder(&buf).Encode(v))
+	assert.Equal(t, string(want)+"\n", buf.String())
+}
+
+func TestDecode(t *testing.T) {
+	var req request
+	require.NoError(t, Decode([]byte(`{"email":"ada@example.com","age":36,"name":null} trailing`), &req))
+	assert.Equal(t, request{Email: "ada@example.com", Age: 36}, req)
+}
+
+func TestDecode_StandardErrors(t *testing.T) {
+	var syntaxErr *json.SyntaxError
+	assert.ErrorIs(t, Decode([]byte(`{"email":`), &request{}), io.ErrUnexpectedEOF)
+	assert.ErrorAs(t, Decode([]byte(`{"email" "ada"}`), &request{}), &syntaxErr)
+
+	var typeErr *json.UnmarshalTypeError
+	require.ErrorAs(t, Decode([]byte(`{"age":"old"}`), &request{}), &typeErr)
+	assert.Equal(t, "age", typeErr.Field)
+}
+
+func TestDecode_FailingHalfway(t *testing.T) {
+	// The body breaks after two valid fields, which json-iterator has
+	// already decoded by the time it fails
+	body := []byte(`{"email":"ada@example.com","age":36,"email":`)
+
+	want := request{Age: 7}
+	wantErr := decodeStandard(body, &want)
+
+	got := request{Age: 7}
+	err := Decode(body, &got)
+
+	assert.Equal(t, wantErr, err)
+	assert.Equal(t, want, got, "the value is left as encoding/json leaves it")
+	assert.Equal(t, request{Age: 7}, got, "preset fields are kept")
+}
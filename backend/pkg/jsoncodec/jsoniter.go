@@ -0,0 +1,49 @@
+//go:build jsoniter
+
+package jsoncodec
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Engine names the JSON engine this binary was built with
+const Engine = "jsoniter"
+
+// api matches encoding/json: HTML escaping, sorted map keys and the same
+// handling of json tags and Marshaler implementations
+var api = jsoniter.ConfigCompatibleWithStandardLibrary
+
+func newEncoder(w io.Writer) Encoder {
+	return api.NewEncoder(w)
+}
+
+// decode runs json-iterator and, only when it fails, encoding/json again
+// on the same data: json-iterator's errors say neither which field nor
+// what kind of mistake, and the rare invalid body can afford a second pass.
+// The result is encoding/json's, so both engines accept the same bodies.
+//
+// json-iterator decodes as it parses, so a body failing halfway has
+// already filled some fields. The second pass therefore decodes into a
+// fresh copy of v as it was before, which then replaces v; fields the
+// caller preset are kept, and nothing of the failed pass leaks into v.
+func decode(data []byte, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return decodeStandard(data, v)
+	}
+	before := reflect.New(target.Elem().Type()).Elem()
+	before.Set(target.Elem())
+
+	if err := api.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		fresh := reflect.New(before.Type())
+		fresh.Elem().Set(before)
+		err = decodeStandard(data, fresh.Interface())
+		target.Elem().Set(fresh.Elem())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !jsoniter
+
+package jsoncodec
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Engine names the JSON engine this binary was built with
+const Engine = "encoding/json"
+
+func newEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func decode(data []byte, v interface{}) error {
+	return decodeStandard(data, v)
+}
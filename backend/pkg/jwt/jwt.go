@@ -10,53 +10,170 @@ import (
 // TokenService provides JWT token management
 type TokenService interface {
 	GenerateToken(userID string) (string, error)
+	// GenerateTokenForTenant generates a token bound to a tenant. An empty
+	// tenant ID is the same as GenerateToken.
+	GenerateTokenForTenant(userID, tenantID string) (string, error)
+	// ValidateToken validates an access token; purpose tokens are rejected
 	ValidateToken(tokenString string) (*Claims, error)
+	// GeneratePurposeToken generates a short-lived token that is only
+	// accepted by ValidatePurposeToken for the same purpose, e.g. to carry
+	// a login that still needs a second factor
+	GeneratePurposeToken(userID, tenantID, purpose string, ttl time.Duration) (string, error)
+	ValidatePurposeToken(tokenString, purpose string) (*Claims, error)
+	// GenerateInvitationToken generates the token of an invitation link. It
+	// names no user, only the invitation in its InvitationID claim, and is
+	// validated with ValidatePurposeToken for PurposeInvitation.
+	GenerateInvitationToken(invitationID, tenantID string, ttl time.Duration) (string, error)
+	// GenerateEmailVerificationToken generates the token of an email
+	// verification link, bound to the address in its Email claim. It is
+	// validated with ValidatePurposeToken for PurposeEmailVerification.
+	GenerateEmailVerificationToken(userID, tenantID, email string, ttl time.Duration) (string, error)
 	GetSigningKey() []byte
+	JWKS() JWKS
 }
 
 // Claims represents JWT token claims
 type Claims struct {
 	UserID string `json:"user_id"`
+	// TenantID is the tenant the user belongs to; empty for the default
+	// tenant
+	TenantID string `json:"tenant_id,omitempty"`
+	// Purpose restricts the token to one step, such as PurposeMFA; access
+	// tokens have none
+	Purpose string `json:"purpose,omitempty"`
+	// InvitationID is the invitation a PurposeInvitation token was issued
+	// for; such tokens have no user ID
+	InvitationID string `json:"invitation_id,omitempty"`
+	// Email is the address a PurposeEmailVerification token confirms
+	Email string `json:"email,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// PurposeMFA marks a token issued after the password was checked that can
+// only be exchanged for an access token with a second factor
+const PurposeMFA = "mfa"
+
+// PurposeInvitation marks the token of an organization invitation link,
+// see GenerateInvitationToken
+const PurposeInvitation = "invitation"
+
+// PurposeEmailVerification marks the token of an email verification link,
+// see GenerateEmailVerificationToken
+const PurposeEmailVerification = "email_verification"
+
 // JWTService implements TokenService
 type JWTService struct {
-	signingKey []byte
-	expiry     time.Duration
+	keys   *KeySet
+	expiry time.Duration
 }
 
-// NewTokenService creates a new JWT token service
+// NewTokenService creates a JWT token service that signs HS256 tokens with
+// a single shared secret and no key ID
 func NewTokenService(signingKey string, expiry time.Duration) TokenService {
+	keys, _ := NewKeySet("", NewHMACKey("", []byte(signingKey)))
+	return NewTokenServiceWithKeys(keys, expiry)
+}
+
+// NewTokenServiceWithKeys creates a JWT token service backed by a key set.
+// Tokens carry the active key's ID in the kid header.
+func NewTokenServiceWithKeys(keys *KeySet, expiry time.Duration) TokenService {
+	if keys == nil {
+		panic("jwt key set cannot be nil")
+	}
 	return &JWTService{
-		signingKey: []byte(signingKey),
-		expiry:     expiry,
+		keys:   keys,
+		expiry: expiry,
 	}
 }
 
 // GenerateToken generates a JWT token for the given user ID
 func (j *JWTService) GenerateToken(userID string) (string, error) {
+	return j.GenerateTokenForTenant(userID, "")
+}
+
+// GenerateTokenForTenant generates a JWT token for a user of the given tenant
+func (j *JWTService) GenerateTokenForTenant(userID, tenantID string) (string, error) {
+	return j.generate(userID, tenantID, "", j.expiry)
+}
+
+// GeneratePurposeToken generates a JWT token restricted to purpose
+func (j *JWTService) GeneratePurposeToken(userID, tenantID, purpose string, ttl time.Duration) (string, error) {
+	if purpose == "" {
+		return "", errors.NewRequiredFieldError("purpose", purpose)
+	}
+	if purpose == PurposeInvitation {
+		return "", errors.NewBusinessLogicError("token_generation", "invitation tokens are issued by GenerateInvitationToken")
+	}
+	if purpose == PurposeEmailVerification {
+		return "", errors.NewBusinessLogicError("token_generation", "email verification tokens are issued by GenerateEmailVerificationToken")
+	}
+	return j.generate(userID, tenantID, purpose, ttl)
+}
+
+// GenerateInvitationToken generates the token of an invitation link
+func (j *JWTService) GenerateInvitationToken(invitationID, tenantID string, ttl time.Duration) (string, error) {
+	if invitationID == "" {
+		return "", errors.NewRequiredFieldError("invitation_id", invitationID)
+	}
+	return j.sign(&Claims{
+		TenantID:         tenantID,
+		Purpose:          PurposeInvitation,
+		InvitationID:     invitationID,
+		RegisteredClaims: registeredClaims("", ttl),
+	})
+}
+
+// GenerateEmailVerificationToken generates the token of an email
+// verification link
+func (j *JWTService) GenerateEmailVerificationToken(userID, tenantID, email string, ttl time.Duration) (string, error) {
+	if userID == "" {
+		return "", errors.NewRequiredFieldError("user_id", userID)
+	}
+	if email == "" {
+		return "", errors.NewRequiredFieldError("email", email)
+	}
+	return j.sign(&Claims{
+		UserID:           userID,
+		TenantID:         tenantID,
+		Purpose:          PurposeEmailVerification,
+		Email:            email,
+		RegisteredClaims: registeredClaims(userID, ttl),
+	})
+}
+
+func (j *JWTService) generate(userID, tenantID, purpose string, ttl time.Duration) (string, error) {
 	if userID == "" {
 		return "", errors.NewRequiredFieldError("user_id", userID)
 	}
 
-	// Create claims
-	claims := &Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "wonder-api",
-			Subject:   userID,
-		},
+	return j.sign(&Claims{
+		UserID:           userID,
+		TenantID:         tenantID,
+		Purpose:          purpose,
+		RegisteredClaims: registeredClaims(userID, ttl),
+	})
+}
+
+func registeredClaims(subject string, ttl time.Duration) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		Issuer:    "wonder-api",
+		Subject:   subject,
 	}
+}
 
+func (j *JWTService) sign(claims *Claims) (string, error) {
 	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	key := j.keys.Active()
+	token := jwt.NewWithClaims(key.method(), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
 
 	// Sign token
-	tokenString, err := token.SignedString(j.signingKey)
+	tokenString, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", errors.NewBusinessLogicError("token_generation", "failed to sign JWT token")
 	}
@@ -64,19 +181,37 @@ func (j *JWTService) GenerateToken(userID string) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken validates and parses a JWT token
+// ValidateToken validates and parses an access token
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	return j.validate(tokenString, "")
+}
+
+// ValidatePurposeToken validates and parses a token issued for purpose
+func (j *JWTService) ValidatePurposeToken(tokenString, purpose string) (*Claims, error) {
+	if purpose == "" {
+		return nil, errors.NewRequiredFieldError("purpose", purpose)
+	}
+	return j.validate(tokenString, purpose)
+}
+
+func (j *JWTService) validate(tokenString, purpose string) (*Claims, error) {
 	if tokenString == "" {
 		return nil, errors.NewRequiredFieldError("token", tokenString)
 	}
 
 	// Parse token
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		// Select the key by kid; tokens without one use the unnamed key
+		kid, _ := token.Header["kid"].(string)
+		key, ok := j.keys.Lookup(kid)
+		if !ok {
+			return nil, errors.NewUnauthorizedError("token_validation", "", "unknown signing key")
+		}
+		// The algorithm is pinned per key so a token cannot pick its own
+		if token.Method.Alg() != key.Algorithm {
 			return nil, errors.NewUnauthorizedError("token_validation", "", "invalid signing method")
 		}
-		return j.signingKey, nil
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
@@ -99,10 +234,32 @@ func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, errors.NewUnauthorizedError("token_validation", "", "token expired")
 	}
 
+	// A purpose token must never pass as an access token, nor the reverse
+	if claims.Purpose != purpose {
+		return nil, errors.NewUnauthorizedError("token_validation", "", "invalid token")
+	}
+	// Invitation tokens name an invitation and never a user; no other
+	// token names an invitation
+	invitation := claims.InvitationID != "" && claims.UserID == ""
+	if (purpose == PurposeInvitation) != invitation {
+		return nil, errors.NewUnauthorizedError("token_validation", "", "invalid token")
+	}
+	// Only email verification tokens carry an address
+	if (purpose == PurposeEmailVerification) != (claims.Email != "") {
+		return nil, errors.NewUnauthorizedError("token_validation", "", "invalid token")
+	}
+
 	return claims, nil
 }
 
-// GetSigningKey returns the signing key (for testing purposes)
+// GetSigningKey returns the active shared secret, or nil when the active
+// key is asymmetric (for testing purposes)
 func (j *JWTService) GetSigningKey() []byte {
-	return j.signingKey
+	secret, _ := j.keys.Active().signKey.([]byte)
+	return secret
+}
+
+// JWKS returns the public keys accepted by ValidateToken
+func (j *JWTService) JWKS() JWKS {
+	return j.keys.JWKS()
 }
@@ -17,7 +17,7 @@ func TestNewTokenService(t *testing.T) {
 
 	jwtService, ok := service.(*JWTService)
 	require.True(t, ok)
-	assert.Equal(t, []byte(signingKey), jwtService.signingKey)
+	assert.Equal(t, []byte(signingKey), jwtService.GetSigningKey())
 	assert.Equal(t, expiry, jwtService.expiry)
 }
 
@@ -205,3 +205,95 @@ func TestJWTService_TokenLifecycle(t *testing.T) {
 	assert.True(t, timeDiff > -10*time.Second && timeDiff < 10*time.Second,
 		"Token expiry should be close to expected time")
 }
+
+func TestJWTService_GenerateTokenForTenant(t *testing.T) {
+	service := NewTokenService("test-signing-key-32-chars-minimum", time.Hour)
+
+	token, err := service.GenerateTokenForTenant("user123", "acme")
+	require.NoError(t, err)
+	claims, err := service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, "acme", claims.TenantID)
+
+	// Tokens without a tenant omit the claim
+	token, err = service.GenerateToken("user123")
+	require.NoError(t, err)
+	claims, err = service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Empty(t, claims.TenantID)
+}
+
+func TestJWTService_PurposeToken(t *testing.T) {
+	service := NewTokenService("test-signing-key-32-chars-minimum", time.Hour)
+
+	token, err := service.GeneratePurposeToken("user123", "acme", PurposeMFA, 5*time.Minute)
+	require.NoError(t, err)
+
+	claims, err := service.ValidatePurposeToken(token, PurposeMFA)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, "acme", claims.TenantID)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), claims.ExpiresAt.Time, 10*time.Second)
+
+	// A purpose token is not an access token, and an access token has no purpose
+	_, err = service.ValidateToken(token)
+	assert.Error(t, err)
+	_, err = service.ValidatePurposeToken(token, "password_reset")
+	assert.Error(t, err)
+	access, err := service.GenerateToken("user123")
+	require.NoError(t, err)
+	_, err = service.ValidatePurposeToken(access, PurposeMFA)
+	assert.Error(t, err)
+}
+
+func TestJWTService_InvitationToken(t *testing.T) {
+	service := NewTokenService("test-signing-key-32-chars-minimum", time.Hour)
+
+	token, err := service.GenerateInvitationToken("inv-1", "acme", time.Hour)
+	require.NoError(t, err)
+
+	claims, err := service.ValidatePurposeToken(token, PurposeInvitation)
+	require.NoError(t, err)
+	assert.Equal(t, "inv-1", claims.InvitationID)
+	assert.Empty(t, claims.UserID, "invitation tokens name no user")
+	assert.Empty(t, claims.Subject)
+	assert.Equal(t, "acme", claims.TenantID)
+
+	_, err = service.ValidateToken(token)
+	assert.Error(t, err)
+	_, err = service.ValidatePurposeToken(token, PurposeMFA)
+	assert.Error(t, err)
+
+	// A user's purpose token cannot be minted or passed off as an invitation
+	_, err = service.GeneratePurposeToken("inv-1", "acme", PurposeInvitation, time.Hour)
+	assert.Error(t, err)
+	mfa, err := service.GeneratePurposeToken("user123", "acme", PurposeMFA, time.Hour)
+	require.NoError(t, err)
+	_, err = service.ValidatePurposeToken(mfa, PurposeInvitation)
+	assert.Error(t, err)
+}
+
+func TestJWTService_EmailVerificationToken(t *testing.T) {
+	service := NewTokenService("test-signing-key-32-chars-minimum", time.Hour)
+
+	token, err := service.GenerateEmailVerificationToken("user123", "acme", "ada@example.com", time.Hour)
+	require.NoError(t, err)
+
+	claims, err := service.ValidatePurposeToken(token, PurposeEmailVerification)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, "ada@example.com", claims.Email)
+	assert.Equal(t, "acme", claims.TenantID)
+
+	_, err = service.ValidateToken(token)
+	assert.Error(t, err)
+	_, err = service.ValidatePurposeToken(token, PurposeMFA)
+	assert.Error(t, err)
+
+	// Verification tokens always name the address they confirm
+	_, err = service.GeneratePurposeToken("user123", "acme", PurposeEmailVerification, time.Hour)
+	assert.Error(t, err)
+	_, err = service.GenerateEmailVerificationToken("user123", "acme", "", time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,242 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// Key is a signing or verification key identified by its key ID (kid).
+// Keys without private material only verify tokens.
+type Key struct {
+	ID        string
+	Algorithm string
+
+	signKey   interface{} // []byte, *rsa.PrivateKey or *ecdsa.PrivateKey
+	verifyKey interface{} // []byte, *rsa.PublicKey or *ecdsa.PublicKey
+}
+
+// NewHMACKey creates an HS256 key from a shared secret
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Algorithm: AlgHS256, signKey: secret, verifyKey: secret}
+}
+
+// ParsePrivateKeyPEM creates a signing key from a PEM encoded RSA (RS256) or
+// P-256 EC (ES256) private key in PKCS#1, PKCS#8 or SEC 1 form
+func ParsePrivateKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM data found", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Algorithm: AlgRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("key %q: ES256 requires a P-256 key", id)
+		}
+		return &Key{ID: id, Algorithm: AlgES256, signKey: k, verifyKey: &k.PublicKey}, nil
+	default:
+		return nil, fmt.Errorf("key %q: unsupported private key type %T", id, parsed)
+	}
+}
+
+// ParsePublicKeyPEM creates a verification-only key from a PEM encoded RSA
+// or P-256 EC public key, e.g. one retired from signing during rotation
+func ParsePublicKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM data found", id)
+	}
+
+	var parsed interface{}
+	var err error
+	if block.Type == "RSA PUBLIC KEY" {
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: id, Algorithm: AlgRS256, verifyKey: k}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("key %q: ES256 requires a P-256 key", id)
+		}
+		return &Key{ID: id, Algorithm: AlgES256, verifyKey: k}, nil
+	default:
+		return nil, fmt.Errorf("key %q: unsupported public key type %T", id, parsed)
+	}
+}
+
+// CanSign reports whether the key holds private material
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// JWK is the public part of a key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwk returns the public JWK of an asymmetric key. Shared secrets are never
+// published.
+func (k *Key) jwk() (JWK, bool) {
+	enc := base64.RawURLEncoding
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: k.Algorithm,
+			KeyID:     k.ID,
+			N:         enc.EncodeToString(pub.N.Bytes()),
+			E:         enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			KeyType:   "EC",
+			Use:       "sig",
+			Algorithm: k.Algorithm,
+			KeyID:     k.ID,
+			Curve:     pub.Curve.Params().Name,
+			X:         enc.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+			Y:         enc.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// KeySet holds the key that signs new tokens and every key accepted when
+// validating. Replace swaps keys atomically, so tokens signed by a retired
+// key keep validating for as long as that key stays in the set.
+type KeySet struct {
+	mu     sync.RWMutex
+	active *Key
+	keys   map[string]*Key
+}
+
+// NewKeySet creates a key set signing with the key whose ID is activeID
+func NewKeySet(activeID string, keys ...*Key) (*KeySet, error) {
+	s := &KeySet{}
+	if err := s.Replace(activeID, keys...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Replace installs a new set of keys, e.g. after a rotation
+func (s *KeySet) Replace(activeID string, keys ...*Key) error {
+	byID := make(map[string]*Key, len(keys))
+	for _, k := range keys {
+		if k == nil {
+			return fmt.Errorf("key cannot be nil")
+		}
+		if _, dup := byID[k.ID]; dup {
+			return fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		if k.method() == nil {
+			return fmt.Errorf("key %q: unsupported algorithm %q", k.ID, k.Algorithm)
+		}
+		byID[k.ID] = k
+	}
+
+	active, ok := byID[activeID]
+	if !ok {
+		return fmt.Errorf("active key %q is not in the key set", activeID)
+	}
+	if !active.CanSign() {
+		return fmt.Errorf("active key %q has no private key", activeID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = active
+	s.keys = byID
+	return nil
+}
+
+// Active returns the signing key
+func (s *KeySet) Active() *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active
+}
+
+// Lookup returns the key with the given ID. Tokens without a kid header
+// are looked up under the empty ID.
+func (s *KeySet) Lookup(kid string) (*Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[kid]
+	return k, ok
+}
+
+// JWKS returns the public keys of the set, sorted by key ID
+func (s *KeySet) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range s.keys {
+		if jwk, ok := k.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
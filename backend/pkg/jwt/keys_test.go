@@ -0,0 +1,181 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaPEM(t *testing.T) (private, public []byte) {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+}
+
+func ecPEM(t *testing.T) []byte {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(k)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestParseKeys(t *testing.T) {
+	private, public := rsaPEM(t)
+
+	rsaKey, err := ParsePrivateKeyPEM("rsa-1", private)
+	require.NoError(t, err)
+	assert.Equal(t, AlgRS256, rsaKey.Algorithm)
+	assert.True(t, rsaKey.CanSign())
+
+	pubKey, err := ParsePublicKeyPEM("rsa-1", public)
+	require.NoError(t, err)
+	assert.Equal(t, AlgRS256, pubKey.Algorithm)
+	assert.False(t, pubKey.CanSign())
+
+	ecKey, err := ParsePrivateKeyPEM("ec-1", ecPEM(t))
+	require.NoError(t, err)
+	assert.Equal(t, AlgES256, ecKey.Algorithm)
+
+	_, err = ParsePrivateKeyPEM("bad", []byte("not pem"))
+	assert.Error(t, err)
+}
+
+func TestKeySet_Replace(t *testing.T) {
+	private, public := rsaPEM(t)
+	signer, err := ParsePrivateKeyPEM("a", private)
+	require.NoError(t, err)
+	verifier, err := ParsePublicKeyPEM("b", public)
+	require.NoError(t, err)
+
+	_, err = NewKeySet("missing", signer)
+	assert.ErrorContains(t, err, "not in the key set")
+
+	_, err = NewKeySet("b", signer, verifier)
+	assert.ErrorContains(t, err, "no private key")
+
+	_, err = NewKeySet("a", signer, NewHMACKey("a", []byte("x")))
+	assert.ErrorContains(t, err, "duplicate key id")
+}
+
+func TestJWTService_KeyRotation(t *testing.T) {
+	oldPrivate, oldPublic := rsaPEM(t)
+	oldKey, err := ParsePrivateKeyPEM("2025-01", oldPrivate)
+	require.NoError(t, err)
+	newKey, err := ParsePrivateKeyPEM("2025-02", ecPEM(t))
+	require.NoError(t, err)
+
+	keys, err := NewKeySet("2025-01", oldKey)
+	require.NoError(t, err)
+	service := NewTokenServiceWithKeys(keys, time.Hour)
+
+	oldToken, err := service.GenerateToken("user-1")
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(oldToken, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01", parsed.Header["kid"])
+	assert.Equal(t, AlgRS256, parsed.Method.Alg())
+
+	// Rotate: sign with the new key, keep the old public key for validation
+	retired, err := ParsePublicKeyPEM("2025-01", oldPublic)
+	require.NoError(t, err)
+	require.NoError(t, keys.Replace("2025-02", newKey, retired))
+
+	newToken, err := service.GenerateToken("user-2")
+	require.NoError(t, err)
+
+	claims, err := service.ValidateToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+
+	claims, err = service.ValidateToken(newToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-2", claims.UserID)
+
+	// Dropping the old key ends its grace period
+	require.NoError(t, keys.Replace("2025-02", newKey))
+	_, err = service.ValidateToken(oldToken)
+	assert.Error(t, err)
+}
+
+func TestJWTService_RejectsAlgorithmConfusion(t *testing.T) {
+	private, public := rsaPEM(t)
+	key, err := ParsePrivateKeyPEM("rsa", private)
+	require.NoError(t, err)
+	keys, err := NewKeySet("rsa", key)
+	require.NoError(t, err)
+	service := NewTokenServiceWithKeys(keys, time.Hour)
+
+	// HS256 token keyed with the published RSA public key
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: "attacker"})
+	forged.Header["kid"] = "rsa"
+	tokenString, err := forged.SignedString(public)
+	require.NoError(t, err)
+
+	_, err = service.ValidateToken(tokenString)
+	assert.Error(t, err)
+}
+
+func TestJWTService_LegacyTokensWithoutKid(t *testing.T) {
+	legacy := NewTokenService("test-signing-key-32-chars-minimum", time.Hour)
+	legacyToken, err := legacy.GenerateToken("user-1")
+	require.NoError(t, err)
+
+	private, _ := rsaPEM(t)
+	current, err := ParsePrivateKeyPEM("2025-01", private)
+	require.NoError(t, err)
+
+	withLegacy, err := NewKeySet("2025-01", current, NewHMACKey("", []byte("test-signing-key-32-chars-minimum")))
+	require.NoError(t, err)
+	_, err = NewTokenServiceWithKeys(withLegacy, time.Hour).ValidateToken(legacyToken)
+	assert.NoError(t, err)
+
+	withoutLegacy, err := NewKeySet("2025-01", current)
+	require.NoError(t, err)
+	_, err = NewTokenServiceWithKeys(withoutLegacy, time.Hour).ValidateToken(legacyToken)
+	assert.Error(t, err)
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	private, _ := rsaPEM(t)
+	rsaKey, err := ParsePrivateKeyPEM("b-rsa", private)
+	require.NoError(t, err)
+	ecKey, err := ParsePrivateKeyPEM("a-ec", ecPEM(t))
+	require.NoError(t, err)
+
+	keys, err := NewKeySet("b-rsa", rsaKey, ecKey, NewHMACKey("c-hmac", []byte("secret")))
+	require.NoError(t, err)
+
+	set := keys.JWKS()
+	require.Len(t, set.Keys, 2, "shared secrets must not be published")
+
+	ec := set.Keys[0]
+	assert.Equal(t, "a-ec", ec.KeyID)
+	assert.Equal(t, "EC", ec.KeyType)
+	assert.Equal(t, "P-256", ec.Curve)
+	x, err := base64.RawURLEncoding.DecodeString(ec.X)
+	require.NoError(t, err)
+	assert.Len(t, x, 32)
+
+	rsaJWK := set.Keys[1]
+	assert.Equal(t, "b-rsa", rsaJWK.KeyID)
+	assert.Equal(t, "RSA", rsaJWK.KeyType)
+	assert.Equal(t, AlgRS256, rsaJWK.Algorithm)
+	assert.Equal(t, "AQAB", rsaJWK.E)
+	assert.Equal(t, "sig", rsaJWK.Use)
+}
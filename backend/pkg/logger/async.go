@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// AsyncConfig moves writing log entries off the calling goroutine. Entries
+// are queued in a bounded buffer and written by one background goroutine;
+// when the buffer is full new entries are dropped rather than blocking.
+type AsyncConfig struct {
+	Enabled bool
+	// Capacity is how many entries the buffer holds; 0 means
+	// DefaultAsyncCapacity
+	Capacity int
+}
+
+// DefaultAsyncCapacity is the buffer size of an async logger configured
+// without one
+const DefaultAsyncCapacity = 8192
+
+// asyncEntry is a queued log entry, or a flush marker when flushed is set
+type asyncEntry struct {
+	data    []byte
+	flushed chan struct{}
+}
+
+// asyncWriter writes entries to out from a background goroutine. Once
+// closed it writes synchronously, so entries logged during shutdown are
+// kept.
+type asyncWriter struct {
+	out     io.Writer
+	entries chan asyncEntry
+	done    chan struct{}
+	dropped atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+	// writeMu serializes writes to out once closed
+	writeMu sync.Mutex
+}
+
+func newAsyncWriter(out io.Writer, capacity int) *asyncWriter {
+	if capacity <= 0 {
+		capacity = DefaultAsyncCapacity
+	}
+	w := &asyncWriter{
+		out:     out,
+		entries: make(chan asyncEntry, capacity),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write implements io.Writer. It never blocks on out while the writer is
+// open.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.writeMu.Lock()
+		defer w.writeMu.Unlock()
+		return w.out.Write(p)
+	}
+
+	// The formatter reuses its buffer, so the entry is copied
+	data := make([]byte, len(p))
+	copy(data, p)
+	select {
+	case w.entries <- asyncEntry{data: data}:
+	default:
+		w.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Flush waits until the entries queued before it are written or ctx is done
+func (w *asyncWriter) Flush(ctx context.Context) error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case w.entries <- asyncEntry{flushed: flushed}:
+		w.mu.RUnlock()
+	case <-ctx.Done():
+		w.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes the queued entries and switches to synchronous writes. It
+// returns when the queue is drained or ctx is done.
+func (w *asyncWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.entries)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dropped returns how many entries were dropped because the buffer was full
+func (w *asyncWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	for entry := range w.entries {
+		if entry.flushed != nil {
+			close(entry.flushed)
+			continue
+		}
+		w.writeMu.Lock()
+		// Nowhere to report a failed write to but the log itself
+		_, _ = w.out.Write(entry.data)
+		w.writeMu.Unlock()
+	}
+}
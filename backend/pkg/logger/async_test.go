@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingWriter records writes once released
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	w := newAsyncWriter(out, 2)
+
+	// Writes return while the output is stuck, and the overflow is dropped
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		n, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+	}
+	assert.GreaterOrEqual(t, w.Dropped(), int64(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Flush(ctx), context.DeadlineExceeded)
+
+	close(out.release)
+	require.NoError(t, w.Flush(context.Background()))
+	assert.True(t, strings.HasPrefix(out.String(), "a\n"))
+
+	// Once closed, writes go straight through
+	require.NoError(t, w.Close(context.Background()))
+	_, err := w.Write([]byte("late\n"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(out.String(), "late\n"))
+	require.NoError(t, w.Flush(context.Background()))
+}
+
+func TestLogger_Async(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	close(out.release)
+
+	log := NewLoggerWithConfig(LogConfig{Level: "info", Format: "json", Async: AsyncConfig{Enabled: true}}).(*simpleLogger)
+	log.async.out = out
+	derived := log.WithComponent("user_service").(*simpleLogger)
+	assert.Same(t, log.async, derived.async)
+
+	derived.Info(context.Background(), "user created")
+	require.NoError(t, log.async.Close(context.Background()))
+	assert.Contains(t, out.String(), `"message":"user created"`)
+}
+
+func TestInitializeWithConfig_ClosesReplacedWriter(t *testing.T) {
+	defer Initialize()
+
+	InitializeWithConfig(LogConfig{Level: "info", Format: "json", Async: AsyncConfig{Enabled: true, Capacity: 1}})
+	previous := Get().(*simpleLogger)
+	out := &blockingWriter{release: make(chan struct{})}
+	previous.async.out = out
+	derived := previous.WithComponent("user_service")
+	for i := 0; i < 5; i++ {
+		previous.Info(context.Background(), "queued")
+	}
+	dropped := DroppedEntries()
+	require.Positive(t, dropped)
+
+	close(out.release)
+	InitializeWithConfig(LogConfig{Level: "info", Format: "json"})
+	assert.NotSame(t, previous, Get())
+	assert.Equal(t, dropped, DroppedEntries(), "drops of the replaced writer still count")
+
+	// The replaced writer was drained and loggers derived from it write
+	// synchronously
+	assert.Contains(t, out.String(), `"message":"queued"`)
+	derived.Info(context.Background(), "after replace")
+	assert.Contains(t, out.String(), `"message":"after replace"`)
+}
@@ -0,0 +1,37 @@
+package logger
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a context carrying l. Whatever logger later logs with
+// the context, its entries carry the key-values bound to l, so request
+// metadata reaches logs written by long-lived component loggers too.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	if l == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger bound to ctx, or the global logger when
+// none is
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+			return l
+		}
+	}
+	return Get()
+}
+
+// contextFields returns the key-values of the logger bound to ctx
+func contextFields(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	if sl, ok := ctx.Value(contextKey{}).(*simpleLogger); ok {
+		return sl.baseKV
+	}
+	return nil
+}
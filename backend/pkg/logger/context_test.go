@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextLogger(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "context.log")
+	base := NewLoggerWithConfig(LogConfig{Level: "info", Format: "json", Output: "file", FilePath: logFile})
+
+	// Component loggers created before the request pick up its key-values
+	component := base.WithComponent("user_service")
+	ctx := NewContext(context.Background(), base.With("user_id", "user-1", "tenant_id", "acme"))
+	component.Info(ctx, "profile updated")
+
+	assert.Equal(t, Get(), FromContext(context.Background()))
+	assert.NotEqual(t, Get(), FromContext(ctx))
+
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"user_id":"user-1"`)
+	assert.Contains(t, string(content), `"tenant_id":"acme"`)
+	assert.Contains(t, string(content), `"component":"user_service"`)
+}
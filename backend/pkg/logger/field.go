@@ -0,0 +1,55 @@
+package logger
+
+import "time"
+
+// Field is a typed key-value pair. Fields may be passed wherever key-values
+// are accepted and mixed with plain pairs:
+//
+//	log.Info(ctx, "user created", logger.String("user_id", id), "role", role)
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Any returns a field with an arbitrary value
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// String returns a string field
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int returns an int field
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int64 returns an int64 field
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool returns a bool field
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Time returns a time field
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Dur returns a duration field, logged in its string form
+func Dur(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value.String()}
+}
+
+// Error returns the "error" field of err; a nil err is not logged
+func Error(err error) Field {
+	if err == nil {
+		return Field{}
+	}
+	return Field{Key: "error", Value: err.Error()}
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeyvals_Fields(t *testing.T) {
+	s := NewLogger().(*simpleLogger)
+
+	fields := s.parseKeyvals(
+		String("user_id", "u-1"),
+		"role", "admin",
+		Int("attempts", 3),
+		Dur("elapsed", 1500*time.Millisecond),
+		Error(nil),
+		Error(errors.New("boom")),
+		"unpaired",
+	)
+
+	assert.Equal(t, logrus.Fields{
+		"user_id":  "u-1",
+		"role":     "admin",
+		"attempts": 3,
+		"elapsed":  "1.5s",
+		"error":    "boom",
+		"unpaired": "MISSING_VALUE",
+	}, fields)
+}
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelOverride is a temporary change of the global logger's level, e.g.
+// "debug for 10 minutes" while investigating an incident
+type LevelOverride struct {
+	Level string `json:"level"`
+	// ConfiguredLevel is restored when the override ends
+	ConfiguredLevel string `json:"configured_level"`
+	// ExpiresAt is zero for overrides that last until cleared
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+var override struct {
+	mu     sync.Mutex
+	active *LevelOverride
+	timer  *time.Timer
+}
+
+// OverrideLevel sets the level of the global logger until ttl passes or the
+// override is cleared; a zero ttl never expires. An active override is
+// replaced, keeping the configured level to return to.
+func OverrideLevel(level string, ttl time.Duration) (LevelOverride, error) {
+	if _, err := logrus.ParseLevel(level); err != nil {
+		return LevelOverride{}, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	if ttl < 0 {
+		return LevelOverride{}, fmt.Errorf("override duration must not be negative")
+	}
+
+	override.mu.Lock()
+	defer override.mu.Unlock()
+
+	configured := GetLevel()
+	if override.active != nil {
+		configured = override.active.ConfiguredLevel
+		stopOverrideTimer()
+	}
+	if err := applyLevel(level); err != nil {
+		return LevelOverride{}, err
+	}
+
+	o := &LevelOverride{Level: level, ConfiguredLevel: configured}
+	override.active = o
+	if ttl > 0 {
+		o.ExpiresAt = time.Now().Add(ttl)
+		override.timer = time.AfterFunc(ttl, func() { clearOverride(o) })
+	}
+	return *o, nil
+}
+
+// ShiftLevel overrides the level with the one steps more verbose (negative)
+// or less verbose (positive) than the current level, staying between debug
+// and error
+func ShiftLevel(steps int, ttl time.Duration) (LevelOverride, error) {
+	current, err := logrus.ParseLevel(GetLevel())
+	if err != nil {
+		return LevelOverride{}, err
+	}
+	// logrus levels grow more verbose as they increase
+	next := min(max(int(current)-steps, int(logrus.ErrorLevel)), int(logrus.DebugLevel))
+	return OverrideLevel(logrus.Level(next).String(), ttl)
+}
+
+// ClearLevelOverride restores the configured level and reports whether an
+// override was active
+func ClearLevelOverride() bool {
+	override.mu.Lock()
+	active := override.active
+	override.mu.Unlock()
+
+	if active == nil {
+		return false
+	}
+	return clearOverride(active)
+}
+
+// ActiveLevelOverride returns the override in effect, if any
+func ActiveLevelOverride() (LevelOverride, bool) {
+	override.mu.Lock()
+	defer override.mu.Unlock()
+
+	if override.active == nil {
+		return LevelOverride{}, false
+	}
+	return *override.active, true
+}
+
+// clearOverride ends o unless another override replaced it
+func clearOverride(o *LevelOverride) bool {
+	override.mu.Lock()
+	defer override.mu.Unlock()
+
+	if override.active != o {
+		return false
+	}
+	stopOverrideTimer()
+	override.active = nil
+	// The configured level was valid when it was set
+	_ = applyLevel(o.ConfiguredLevel)
+	return true
+}
+
+// stopOverrideTimer stops the expiry of the active override; override.mu
+// must be held
+func stopOverrideTimer() {
+	if override.timer != nil {
+		override.timer.Stop()
+		override.timer = nil
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrideLevel(t *testing.T) {
+	InitializeWithConfig(LogConfig{Level: "info", Output: "stdout"})
+	t.Cleanup(func() { ClearLevelOverride() })
+
+	o, err := OverrideLevel("debug", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "info", o.ConfiguredLevel)
+	assert.True(t, o.ExpiresAt.IsZero())
+	assert.Equal(t, "debug", GetLevel())
+
+	// Reloaded configuration takes effect once the override ends
+	require.NoError(t, SetLevel("warn"))
+	assert.Equal(t, "debug", GetLevel())
+	active, ok := ActiveLevelOverride()
+	require.True(t, ok)
+	assert.Equal(t, "warn", active.ConfiguredLevel)
+
+	assert.True(t, ClearLevelOverride())
+	assert.Equal(t, "warning", GetLevel())
+	assert.False(t, ClearLevelOverride())
+
+	_, err = OverrideLevel("loud", 0)
+	assert.Error(t, err)
+}
+
+func TestOverrideLevel_Expires(t *testing.T) {
+	InitializeWithConfig(LogConfig{Level: "info", Output: "stdout"})
+	t.Cleanup(func() { ClearLevelOverride() })
+
+	o, err := OverrideLevel("debug", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.False(t, o.ExpiresAt.IsZero())
+
+	assert.Eventually(t, func() bool { return GetLevel() == "info" }, time.Second, 5*time.Millisecond)
+	_, ok := ActiveLevelOverride()
+	assert.False(t, ok)
+}
+
+func TestShiftLevel(t *testing.T) {
+	InitializeWithConfig(LogConfig{Level: "info", Output: "stdout"})
+	t.Cleanup(func() { ClearLevelOverride() })
+
+	o, err := ShiftLevel(-1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "debug", o.Level)
+
+	// Stays at debug
+	o, err = ShiftLevel(-1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "debug", o.Level)
+	assert.Equal(t, "info", o.ConfiguredLevel, "the configured level survives stacked overrides")
+
+	o, err = ShiftLevel(5, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "error", o.Level)
+}
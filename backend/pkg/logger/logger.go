@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -15,9 +16,16 @@ import (
 type LogConfig struct {
 	Level      string // debug, info, warn, error
 	Format     string // json, text
-	Output     string // stdout, file, both
+	Output     string // stdout, file, both, loki, elasticsearch
 	FilePath   string // path to log file (when Output is file or both)
 	EnableFile bool   // enable file logging
+
+	// Sampling rate limits noisy messages; the zero value logs everything
+	Sampling SamplingConfig
+	// Async writes entries from a background goroutine
+	Async AsyncConfig
+	// Ship configures the network outputs, loki and elasticsearch
+	Ship ShipConfig
 }
 
 // simpleLogger implements Logger interface with minimal overhead
@@ -26,6 +34,9 @@ type simpleLogger struct {
 	baseKV    map[string]interface{} // Pre-stored key-values for performance
 	component string
 	layer     string
+	sampler   *sampler     // Shared by derived loggers; nil logs everything
+	async     *asyncWriter // Shared by derived loggers; nil writes synchronously
+	shipper   *shipper     // Shared by derived loggers; nil unless shipping
 }
 
 // NewLogger creates a new simplified logger instance with default configuration
@@ -67,8 +78,11 @@ func NewLoggerWithConfig(config LogConfig) Logger {
 
 	// Set output destination
 	var output io.Writer = os.Stdout
+	var shipper *shipper
 
 	switch config.Output {
+	case "stderr":
+		output = os.Stderr
 	case "file":
 		if config.FilePath != "" {
 			fileOutput, err := createLogFile(config.FilePath)
@@ -88,15 +102,32 @@ func NewLoggerWithConfig(config LogConfig) Logger {
 				output = io.MultiWriter(os.Stdout, fileOutput)
 			}
 		}
+	case OutputLoki, OutputElasticsearch:
+		sh, err := newShipper(config.Output, config.Ship)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to set up %s log output: %v. Using stdout.\n", config.Output, err)
+		} else {
+			shipper = sh
+			output = sh
+		}
 	default: // "stdout" or any other value
 		output = os.Stdout
 	}
 
+	var async *asyncWriter
+	if config.Async.Enabled {
+		async = newAsyncWriter(output, config.Async.Capacity)
+		output = async
+	}
+
 	l.SetOutput(output)
 
 	return &simpleLogger{
-		logger: l,
-		baseKV: make(map[string]interface{}),
+		logger:  l,
+		baseKV:  make(map[string]interface{}),
+		sampler: newSampler(config.Sampling),
+		async:   async,
+		shipper: shipper,
 	}
 }
 
@@ -155,23 +186,21 @@ func (s *simpleLogger) InfoEnabled() bool {
 
 // With returns a new logger with additional key-value pairs
 func (s *simpleLogger) With(keyvals ...interface{}) Logger {
-	newKV := make(map[string]interface{})
-	// Copy existing key-values
+	// Sized for the existing and new key-values, counting keyvals as pairs
+	newKV := make(map[string]interface{}, len(s.baseKV)+len(keyvals)/2+1)
 	for k, v := range s.baseKV {
 		newKV[k] = v
 	}
-
-	// Add new key-values
-	fields := s.parseKeyvals(keyvals...)
-	for k, v := range fields {
-		newKV[k] = v
-	}
+	addKeyvals(newKV, keyvals)
 
 	return &simpleLogger{
 		logger:    s.logger,
 		baseKV:    newKV,
 		component: s.component,
 		layer:     s.layer,
+		sampler:   s.sampler,
+		async:     s.async,
+		shipper:   s.shipper,
 	}
 }
 
@@ -203,7 +232,25 @@ func (s *simpleLogger) WithError(err error) Logger {
 
 // logWithLevel performs the actual logging with automatic context extraction
 func (s *simpleLogger) logWithLevel(ctx context.Context, level logrus.Level, msg string, keyvals ...interface{}) {
-	fields := logrus.Fields{}
+	if !s.logger.IsLevelEnabled(level) {
+		return
+	}
+	allowed, dropped := s.sampler.allow(level, msg)
+	if !allowed {
+		return
+	}
+
+	// Key-values are added straight to the entry's fields. The context's
+	// logger is usually this logger or the one it derives from, so their
+	// key-values overlap and the larger set bounds both; oversizing the
+	// hint past eight entries would cost a larger map.
+	ctxKV := contextFields(ctx)
+	fields := make(logrus.Fields, max(len(ctxKV), len(s.baseKV))+len(keyvals))
+
+	// Add key-values of the logger bound to the context, e.g. the request's
+	for k, v := range ctxKV {
+		fields[k] = v
+	}
 
 	// Add base key-values
 	for k, v := range s.baseKV {
@@ -216,10 +263,7 @@ func (s *simpleLogger) logWithLevel(ctx context.Context, level logrus.Level, msg
 	}
 
 	// Add provided key-values
-	kvFields := s.parseKeyvals(keyvals...)
-	for k, v := range kvFields {
-		fields[k] = v
-	}
+	addKeyvals(fields, keyvals)
 
 	// Add layer and component if set
 	if s.layer != "" {
@@ -228,28 +272,43 @@ func (s *simpleLogger) logWithLevel(ctx context.Context, level logrus.Level, msg
 	if s.component != "" {
 		fields["component"] = s.component
 	}
+	if dropped > 0 {
+		fields["sampled_dropped"] = dropped
+	}
 
 	s.logger.WithFields(fields).Log(level, msg)
 }
 
-// parseKeyvals converts variadic interface{} to logrus.Fields
+// parseKeyvals converts variadic interface{} to logrus.Fields. Typed
+// fields count as a whole pair.
 func (s *simpleLogger) parseKeyvals(keyvals ...interface{}) logrus.Fields {
-	fields := logrus.Fields{}
+	fields := make(logrus.Fields, len(keyvals)/2+1)
+	addKeyvals(fields, keyvals)
+	return fields
+}
 
-	if len(keyvals)%2 != 0 {
-		// Add unpaired value as "extra"
-		keyvals = append(keyvals, "MISSING_VALUE")
-	}
+// addKeyvals adds key-value pairs and typed fields to fields
+func addKeyvals(fields map[string]interface{}, keyvals []interface{}) {
+	for i := 0; i < len(keyvals); i++ {
+		if field, ok := keyvals[i].(Field); ok {
+			if field.Key != "" {
+				fields[field.Key] = field.Value
+			}
+			continue
+		}
 
-	for i := 0; i < len(keyvals); i += 2 {
 		key, ok := keyvals[i].(string)
 		if !ok {
 			key = fmt.Sprintf("key_%d", i)
 		}
+		if i+1 == len(keyvals) {
+			// Unpaired key
+			fields[key] = "MISSING_VALUE"
+			break
+		}
 		fields[key] = keyvals[i+1]
+		i++
 	}
-
-	return fields
 }
 
 // extractTraceID extracts trace ID from context
@@ -284,14 +343,50 @@ func extractTraceID(ctx context.Context) string {
 // Global logger instance for convenience
 var defaultLogger Logger
 
+// retireTimeout bounds how long replacing the global logger waits for the
+// entries the previous one queued
+const retireTimeout = 5 * time.Second
+
+// retired are the queued writers of global loggers that were replaced.
+// Loggers derived from them before keep writing through them, and their
+// drops still count in DroppedEntries.
+var (
+	retiredMu sync.Mutex
+	retired   []interface{ Dropped() int64 }
+)
+
 // Initialize sets up the global logger with default configuration
 func Initialize() {
-	defaultLogger = NewLogger()
+	replaceDefault(NewLogger())
 }
 
-// InitializeWithConfig sets up the global logger with custom configuration
+// InitializeWithConfig sets up the global logger with custom configuration.
+// The previous global logger's queued entries are written or shipped first.
 func InitializeWithConfig(config LogConfig) {
-	defaultLogger = NewLoggerWithConfig(config)
+	replaceDefault(NewLoggerWithConfig(config))
+}
+
+// replaceDefault makes l the global logger and closes the writers of the
+// one it replaces, so their goroutines stop and queued entries are kept
+func replaceDefault(l Logger) {
+	previous, _ := defaultLogger.(*simpleLogger)
+	defaultLogger = l
+	if previous == nil || (previous.async == nil && previous.shipper == nil) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), retireTimeout)
+	defer cancel()
+	retiredMu.Lock()
+	defer retiredMu.Unlock()
+	if previous.async != nil {
+		_ = previous.async.Close(ctx)
+		retired = append(retired, previous.async)
+	}
+	if previous.shipper != nil {
+		_ = previous.shipper.Close(ctx)
+		retired = append(retired, previous.shipper)
+	}
 }
 
 // Get returns the global logger instance
@@ -302,6 +397,107 @@ func Get() Logger {
 	return defaultLogger
 }
 
+// Flush waits until entries the global logger queued are written or
+// shipped. It returns at once when the logger writes synchronously.
+func Flush(ctx context.Context) error {
+	sl, ok := Get().(*simpleLogger)
+	if !ok {
+		return nil
+	}
+	if sl.async != nil {
+		if err := sl.async.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	if sl.shipper != nil {
+		return sl.shipper.Flush(ctx)
+	}
+	return nil
+}
+
+// Close writes or ships the entries the global logger queued and makes it
+// write synchronously from then on, to stderr when shipping; call it last
+// during shutdown
+func Close(ctx context.Context) error {
+	sl, ok := Get().(*simpleLogger)
+	if !ok {
+		return nil
+	}
+	if sl.async != nil {
+		if err := sl.async.Close(ctx); err != nil {
+			return err
+		}
+	}
+	if sl.shipper != nil {
+		return sl.shipper.Close(ctx)
+	}
+	return nil
+}
+
+// DroppedEntries returns how many entries the global logger, and those it
+// replaced, dropped because a buffer was full or shipping them failed
+func DroppedEntries() int64 {
+	var dropped int64
+	retiredMu.Lock()
+	for _, w := range retired {
+		dropped += w.Dropped()
+	}
+	retiredMu.Unlock()
+
+	sl, ok := Get().(*simpleLogger)
+	if !ok {
+		return dropped
+	}
+	if sl.async != nil {
+		dropped += sl.async.Dropped()
+	}
+	if sl.shipper != nil {
+		dropped += sl.shipper.Dropped()
+	}
+	return dropped
+}
+
+// SetLevel changes the configured level of the global logger at runtime.
+// Loggers derived via With/WithLayer/WithComponent share the same backend
+// and pick up the new level immediately. While a level override is active
+// the new level takes effect once it ends.
+func SetLevel(level string) error {
+	if _, err := logrus.ParseLevel(level); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	override.mu.Lock()
+	defer override.mu.Unlock()
+	if override.active != nil {
+		override.active.ConfiguredLevel = level
+		return nil
+	}
+	return applyLevel(level)
+}
+
+// applyLevel sets the level the global logger logs at
+func applyLevel(level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	sl, ok := Get().(*simpleLogger)
+	if !ok {
+		return fmt.Errorf("global logger does not support runtime level changes")
+	}
+	sl.logger.SetLevel(parsed)
+	return nil
+}
+
+// GetLevel returns the current level of the global logger
+func GetLevel() string {
+	if sl, ok := Get().(*simpleLogger); ok {
+		return sl.logger.GetLevel().String()
+	}
+	return ""
+}
+
 // Convenience functions for global logger
 func LogDebug(ctx context.Context, msg string, keyvals ...interface{}) {
 	Get().Debug(ctx, msg, keyvals...)
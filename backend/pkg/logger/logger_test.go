@@ -2,6 +2,7 @@ package logger
 
 import (
 	"context"
+	"io"
 	"testing"
 	"time"
 
@@ -203,6 +204,22 @@ func BenchmarkLogger_WithChaining(b *testing.B) {
 	}
 }
 
+// The access log path: a request-bound logger in the context and typed
+// fields
+func BenchmarkLogger_InfoWithContext(b *testing.B) {
+	base := NewLoggerWithConfig(LogConfig{Level: "info", Format: "json"}).(*simpleLogger)
+	base.logger.SetOutput(io.Discard)
+	request := base.With("trace_id", "trace-1", "request_id", "request-1", "tenant_id", "default", "client_ip", "127.0.0.1")
+	ctx := NewContext(context.Background(), request)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		request.Info(ctx, "request completed",
+			String("method", "GET"), String("route", "/api/v1/users/:id"), Int("status", 200), Dur("duration", time.Millisecond))
+	}
+}
+
 // Example usage tests that demonstrate the API
 func ExampleLogger_basic() {
 	logger := NewLogger()
@@ -242,3 +259,18 @@ func ExampleLogger_helpers() {
 		)...,
 	)
 }
+
+func TestSetLevel(t *testing.T) {
+	InitializeWithConfig(LogConfig{Level: "info", Format: "json", Output: "stdout"})
+	defer Initialize()
+
+	derived := Get().WithLayer("test").WithComponent("set_level")
+	assert.False(t, derived.DebugEnabled())
+
+	require.NoError(t, SetLevel("debug"))
+	assert.Equal(t, "debug", GetLevel())
+	assert.True(t, derived.DebugEnabled())
+
+	assert.Error(t, SetLevel("verbose"))
+	assert.Equal(t, "debug", GetLevel())
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SamplingConfig keeps noisy log lines from dominating output under load.
+// Rate limits apply per message, so a flood of one message does not
+// crowd out others, and only to info and debug entries: warnings and
+// errors are always logged. The zero value logs everything.
+type SamplingConfig struct {
+	// MessageRate is how many entries per second each message may log;
+	// 0 disables rate limiting
+	MessageRate int
+	// Burst is how many entries of a message may be logged at once before
+	// the rate applies; 0 means MessageRate
+	Burst int
+	// Messages sets the rate of individual messages, overriding
+	// MessageRate; 0 leaves a message unlimited
+	Messages map[string]int
+	// DebugPercent is the share of debug entries kept, from 1 to 99;
+	// 0 or 100 keeps all of them
+	DebugPercent int
+}
+
+// maxSampledMessages bounds how many messages are tracked. Messages are
+// usually constant strings; when they are not, tracking starts over.
+const maxSampledMessages = 10000
+
+// sampler decides which entries are logged. It is shared by all loggers
+// derived from the same root.
+type sampler struct {
+	config SamplingConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket is the token bucket of one message
+type bucket struct {
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+// newSampler returns a sampler for config, or nil when it logs everything
+func newSampler(config SamplingConfig) *sampler {
+	if config.MessageRate <= 0 && len(config.Messages) == 0 && (config.DebugPercent <= 0 || config.DebugPercent >= 100) {
+		return nil
+	}
+	return &sampler{config: config, now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// allow reports whether an entry is logged and how many entries of the
+// same message were dropped since the last one logged
+func (s *sampler) allow(level logrus.Level, msg string) (bool, int) {
+	// Warnings and errors are what an incident is diagnosed from
+	if s == nil || level < logrus.InfoLevel {
+		return true, 0
+	}
+	if level == logrus.DebugLevel && s.config.DebugPercent > 0 && s.config.DebugPercent < 100 &&
+		rand.IntN(100) >= s.config.DebugPercent {
+		return false, 0
+	}
+
+	rate := s.config.MessageRate
+	if override, ok := s.config.Messages[msg]; ok {
+		rate = override
+	}
+	if rate <= 0 {
+		return true, 0
+	}
+	burst := s.config.Burst
+	if burst <= 0 {
+		burst = rate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[msg]
+	if !ok {
+		if len(s.buckets) >= maxSampledMessages {
+			s.buckets = make(map[string]*bucket)
+		}
+		b = &bucket{tokens: float64(burst), last: now}
+		s.buckets[msg] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * float64(rate)
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		b.dropped++
+		return false, 0
+	}
+	b.tokens--
+	dropped := b.dropped
+	b.dropped = 0
+	return true, dropped
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler_RateLimitsPerMessage(t *testing.T) {
+	s := newSampler(SamplingConfig{MessageRate: 2, Burst: 3, Messages: map[string]int{"duplicate email": 1, "unlimited": 0}})
+	require.NotNil(t, s)
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+
+	// The burst is spent, further entries are dropped
+	for i := 0; i < 3; i++ {
+		allowed, _ := s.allow(logrus.InfoLevel, "user created")
+		assert.True(t, allowed)
+	}
+	allowed, _ := s.allow(logrus.InfoLevel, "user created")
+	assert.False(t, allowed)
+	allowed, _ = s.allow(logrus.InfoLevel, "user created")
+	assert.False(t, allowed)
+
+	// Other messages have their own budget
+	allowed, _ = s.allow(logrus.InfoLevel, "user deleted")
+	assert.True(t, allowed)
+
+	// Tokens refill at the rate, and the next entry reports the drops
+	now = now.Add(500 * time.Millisecond)
+	allowed, dropped := s.allow(logrus.InfoLevel, "user created")
+	assert.True(t, allowed)
+	assert.Equal(t, 2, dropped)
+
+	// Overrides replace the rate of single messages
+	for i := 0; i < 3; i++ {
+		s.allow(logrus.InfoLevel, "duplicate email")
+	}
+	now = now.Add(time.Second)
+	allowed, dropped = s.allow(logrus.InfoLevel, "duplicate email")
+	assert.True(t, allowed)
+	assert.Zero(t, dropped, "the override rate refills the burst within a second")
+
+	for i := 0; i < 100; i++ {
+		allowed, _ = s.allow(logrus.InfoLevel, "unlimited")
+		assert.True(t, allowed)
+	}
+
+	// Warnings and errors are never dropped
+	for i := 0; i < 100; i++ {
+		allowed, _ = s.allow(logrus.WarnLevel, "user created")
+		assert.True(t, allowed)
+		allowed, _ = s.allow(logrus.ErrorLevel, "user created")
+		assert.True(t, allowed)
+	}
+}
+
+func TestSampler_DebugPercent(t *testing.T) {
+	s := newSampler(SamplingConfig{DebugPercent: 10})
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		if allowed, _ := s.allow(logrus.DebugLevel, "cache miss"); allowed {
+			kept++
+		}
+		allowed, _ := s.allow(logrus.InfoLevel, "cache miss")
+		assert.True(t, allowed, "only debug entries are sampled")
+	}
+	assert.InDelta(t, 1000, kept, 300)
+
+	assert.Nil(t, newSampler(SamplingConfig{DebugPercent: 100}), "keeping everything needs no sampler")
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "sampled.log")
+	log := NewLoggerWithConfig(LogConfig{
+		Level:    "info",
+		Format:   "json",
+		Output:   "file",
+		FilePath: logFile,
+		Sampling: SamplingConfig{MessageRate: 1, Burst: 2},
+	}).WithComponent("user_service")
+
+	for i := 0; i < 10; i++ {
+		log.Info(context.Background(), "duplicate email")
+		log.Error(context.Background(), "database unavailable")
+	}
+
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(content), "duplicate email"))
+	assert.Equal(t, 10, strings.Count(string(content), "database unavailable"))
+}
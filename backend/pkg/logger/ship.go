@@ -0,0 +1,395 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Network outputs ship entries to a log store without a sidecar agent.
+// Both need the json format.
+const (
+	// OutputLoki pushes entries to Loki's push API
+	OutputLoki = "loki"
+	// OutputElasticsearch indexes entries with Elasticsearch's bulk API
+	OutputElasticsearch = "elasticsearch"
+)
+
+// ShipConfig configures the network outputs
+type ShipConfig struct {
+	// URL is Loki's push endpoint, e.g. http://loki:3100/loki/api/v1/push,
+	// or the Elasticsearch base URL, e.g. http://elasticsearch:9200
+	URL string
+	// Labels are the Loki stream labels
+	Labels map[string]string
+	// Index is the Elasticsearch index; "{date}" is replaced with the UTC
+	// date of the entry, e.g. "wonder-logs-{date}"
+	Index string
+	// Username and Password authenticate with basic auth when set
+	Username string
+	Password string
+	// BatchSize is how many entries are sent at most per request
+	BatchSize int
+	// FlushInterval is how long entries wait for a batch to fill
+	FlushInterval time.Duration
+	// QueueSize is how many entries wait to be sent. When it is full, new
+	// entries are dropped rather than blocking logging.
+	QueueSize int
+	// MaxRetries is how often a failed batch, or the documents of a bulk
+	// request Elasticsearch throttled, are resent before they are dropped
+	MaxRetries int
+	// Timeout bounds each request
+	Timeout time.Duration
+}
+
+// DefaultShipConfig returns the network output defaults
+func DefaultShipConfig() ShipConfig {
+	return ShipConfig{
+		Labels:        map[string]string{"service": "wonder"},
+		Index:         "wonder-logs-{date}",
+		BatchSize:     500,
+		FlushInterval: time.Second,
+		QueueSize:     10000,
+		MaxRetries:    3,
+		Timeout:       5 * time.Second,
+	}
+}
+
+// shipEntry is a queued entry, or a flush marker when flushed is set
+type shipEntry struct {
+	line    []byte
+	at      time.Time
+	flushed chan struct{}
+}
+
+// encoder turns a batch into a request body and its content type
+type encoder func(batch []shipEntry) ([]byte, string, error)
+
+// shipper batches entries and sends them from a background goroutine.
+// Failed batches are retried with backoff; batches still failing are
+// dropped and reported on stderr.
+type shipper struct {
+	config  ShipConfig
+	url     string
+	encode  encoder
+	client  *http.Client
+	queue   chan shipEntry
+	done    chan struct{}
+	dropped atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+	// fallback receives entries written once closed
+	fallback io.Writer
+}
+
+// newShipper returns the shipper of output, which must be a network output
+func newShipper(output string, config ShipConfig) (*shipper, error) {
+	defaults := DefaultShipConfig()
+	if config.URL == "" {
+		return nil, fmt.Errorf("%s output needs a URL", output)
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+
+	s := &shipper{
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		queue:    make(chan shipEntry, config.QueueSize),
+		done:     make(chan struct{}),
+		fallback: os.Stderr,
+	}
+	switch output {
+	case OutputLoki:
+		s.url, s.encode = config.URL, encodeLoki(config.Labels)
+	case OutputElasticsearch:
+		index := config.Index
+		if index == "" {
+			index = defaults.Index
+		}
+		s.url, s.encode = strings.TrimSuffix(config.URL, "/")+"/_bulk", encodeBulk(index)
+	default:
+		return nil, fmt.Errorf("%q is not a network output", output)
+	}
+
+	go s.run()
+	return s, nil
+}
+
+// Write implements io.Writer. It never blocks on the log store: when the
+// queue is full the entry is dropped and counted.
+func (s *shipper) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return s.fallback.Write(p)
+	}
+
+	entry := shipEntry{line: bytes.TrimRight(bytes.Clone(p), "\n"), at: time.Now()}
+	select {
+	case s.queue <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Flush waits until the entries queued before it are sent or ctx is done
+func (s *shipper) Flush(ctx context.Context) error {
+	s.mu.RLock()
+	if s.closed {
+		s.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case s.queue <- shipEntry{flushed: flushed}:
+		s.mu.RUnlock()
+	case <-ctx.Done():
+		s.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close sends the queued entries and writes later ones to stderr
+func (s *shipper) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dropped returns how many entries were dropped, whether the queue was full
+// or their batch could not be sent
+func (s *shipper) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *shipper) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]shipEntry, 0, s.config.BatchSize)
+	send := func() {
+		if len(batch) > 0 {
+			s.send(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case entry, ok := <-s.queue:
+			if !ok {
+				send()
+				return
+			}
+			if entry.flushed != nil {
+				send()
+				close(entry.flushed)
+				continue
+			}
+			batch = append(batch, entry)
+			if len(batch) >= s.config.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		}
+	}
+}
+
+// send delivers batch, retrying transient failures with backoff. When
+// Elasticsearch throttles single documents of a bulk request, only those
+// are resent.
+func (s *shipper) send(batch []shipEntry) {
+	delay := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		body, contentType, err := s.encode(batch)
+		var retryable bool
+		if err == nil {
+			retryable, err = s.post(body, contentType)
+		}
+		if err == nil {
+			return
+		}
+		last := attempt >= s.config.MaxRetries
+
+		var rejection *bulkRejection
+		switch {
+		case errors.As(err, &rejection) && !last && len(rejection.throttled) > 0:
+			if rejected := rejection.rejected - len(rejection.throttled); rejected > 0 {
+				s.dropped.Add(int64(rejected))
+				fmt.Fprintf(s.fallback, "Failed to ship %d log entries to %s: %v\n", rejected, s.url, err)
+			}
+			batch = rejection.retry(batch)
+		case errors.As(err, &rejection):
+			s.dropped.Add(int64(rejection.rejected))
+			fmt.Fprintf(s.fallback, "Failed to ship %d log entries to %s: %v\n", rejection.rejected, s.url, err)
+			return
+		case !retryable || last:
+			s.dropped.Add(int64(len(batch)))
+			fmt.Fprintf(s.fallback, "Failed to ship %d log entries to %s: %v\n", len(batch), s.url, err)
+			return
+		}
+		time.Sleep(delay)
+		delay = min(delay*2, 5*time.Second)
+	}
+}
+
+// post sends one request and reports whether a failure is worth retrying
+func (s *shipper) post(body []byte, contentType string) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.config.Username != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return true, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if contentType == bulkContentType {
+		return bulkFailures(resp.Body)
+	}
+	return false, nil
+}
+
+// encodeLoki encodes batches as one Loki stream
+func encodeLoki(labels map[string]string) encoder {
+	return func(batch []shipEntry) ([]byte, string, error) {
+		values := make([][2]string, len(batch))
+		for i, e := range batch {
+			values[i] = [2]string{strconv.FormatInt(e.at.UnixNano(), 10), string(e.line)}
+		}
+		body, err := json.Marshal(map[string]interface{}{
+			"streams": []map[string]interface{}{{"stream": labels, "values": values}},
+		})
+		return body, "application/json", err
+	}
+}
+
+const bulkContentType = "application/x-ndjson"
+
+// encodeBulk encodes batches as Elasticsearch bulk index actions
+func encodeBulk(index string) encoder {
+	return func(batch []shipEntry) ([]byte, string, error) {
+		var body bytes.Buffer
+		for _, e := range batch {
+			action, err := json.Marshal(map[string]interface{}{
+				"index": map[string]string{"_index": strings.ReplaceAll(index, "{date}", e.at.UTC().Format("2006.01.02"))},
+			})
+			if err != nil {
+				return nil, "", err
+			}
+			body.Write(action)
+			body.WriteByte('\n')
+			body.Write(e.line)
+			body.WriteByte('\n')
+		}
+		return body.Bytes(), bulkContentType, nil
+	}
+}
+
+// bulkFailures reads a bulk response, which succeeds even when single
+// documents were rejected. Documents rejected with 429 because the cluster
+// is overloaded are worth resending; others are not.
+func bulkFailures(body io.Reader) (bool, error) {
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return false, fmt.Errorf("invalid bulk response: %w", err)
+	}
+	if !resp.Errors {
+		return false, nil
+	}
+	rejection := &bulkRejection{total: len(resp.Items)}
+	for i, item := range resp.Items {
+		for _, result := range item {
+			if result.Status >= 300 {
+				rejection.rejected++
+			}
+			if result.Status == http.StatusTooManyRequests {
+				rejection.throttled = append(rejection.throttled, i)
+			}
+		}
+	}
+	return false, rejection
+}
+
+// bulkRejection reports the documents of a bulk request that were rejected
+type bulkRejection struct {
+	rejected, total int
+	// throttled are the positions of the documents rejected with 429
+	throttled []int
+}
+
+// retry returns the entries of batch that were throttled. Bulk responses
+// list items in request order.
+func (e *bulkRejection) retry(batch []shipEntry) []shipEntry {
+	retry := make([]shipEntry, 0, len(e.throttled))
+	for _, i := range e.throttled {
+		if i < len(batch) {
+			retry = append(retry, batch[i])
+		}
+	}
+	return retry
+}
+
+func (e *bulkRejection) Error() string {
+	return fmt.Sprintf("%d of %d documents rejected", e.rejected, e.total)
+}
@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testShipConfig(url string) ShipConfig {
+	config := DefaultShipConfig()
+	config.URL = url
+	config.FlushInterval = time.Hour
+	return config
+}
+
+func TestShipper_Loki(t *testing.T) {
+	var mu sync.Mutex
+	var pushes []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		pushes = append(pushes, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := testShipConfig(server.URL)
+	config.BatchSize = 2
+	s, err := newShipper(OutputLoki, config)
+	require.NoError(t, err)
+
+	for _, line := range []string{`{"message":"a"}`, `{"message":"b"}`, `{"message":"c"}`} {
+		_, err := s.Write([]byte(line + "\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.Close(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, pushes, 2, "batches are split at the batch size")
+	stream := pushes[0]["streams"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"service": "wonder"}, stream["stream"])
+	values := stream["values"].([]interface{})
+	require.Len(t, values, 2)
+	assert.Equal(t, `{"message":"a"}`, values[0].([]interface{})[1])
+	assert.Zero(t, s.Dropped())
+}
+
+func TestShipper_ElasticsearchRetries(t *testing.T) {
+	var attempts atomic.Int32
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		assert.Equal(t, bulkContentType, r.Header.Get("Content-Type"))
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+		_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400}}]}`))
+	}))
+	defer server.Close()
+
+	config := testShipConfig(server.URL + "/")
+	config.Index = "logs-{date}"
+	s, err := newShipper(OutputElasticsearch, config)
+	require.NoError(t, err)
+	var fallback bytes.Buffer
+	s.fallback = &fallback
+
+	_, _ = s.Write([]byte(`{"message":"a"}` + "\n"))
+	_, _ = s.Write([]byte(`{"message":"b"}` + "\n"))
+	require.NoError(t, s.Flush(context.Background()))
+
+	assert.Equal(t, int32(2), attempts.Load(), "unavailable stores are retried")
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, `{"index":{"_index":"logs-`+time.Now().UTC().Format("2006.01.02")+`"}}`, lines[0])
+	assert.Equal(t, `{"message":"a"}`, lines[1])
+	assert.Equal(t, int64(1), s.Dropped(), "only the rejected document is dropped")
+
+	// Entries logged once closed go to the fallback
+	require.NoError(t, s.Close(context.Background()))
+	_, _ = s.Write([]byte("late\n"))
+	assert.Contains(t, fallback.String(), "late")
+}
+
+func TestShipper_ElasticsearchThrottledDocuments(t *testing.T) {
+	var mu sync.Mutex
+	var requests [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+		mu.Lock()
+		requests = append(requests, lines)
+		first := len(requests) == 1
+		mu.Unlock()
+		if first {
+			_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":429}},{"index":{"status":400}},{"index":{"status":429}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}},{"index":{"status":201}}]}`))
+	}))
+	defer server.Close()
+
+	s, err := newShipper(OutputElasticsearch, testShipConfig(server.URL))
+	require.NoError(t, err)
+	s.fallback = io.Discard
+
+	for _, message := range []string{"a", "b", "c", "d"} {
+		_, _ = s.Write([]byte(`{"message":"` + message + `"}` + "\n"))
+	}
+	require.NoError(t, s.Flush(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 2)
+	require.Len(t, requests[1], 4, "only the throttled documents are resent")
+	assert.Equal(t, `{"message":"b"}`, requests[1][1])
+	assert.Equal(t, `{"message":"d"}`, requests[1][3])
+	assert.Equal(t, int64(1), s.Dropped(), "the document rejected for good is dropped")
+}
+
+func TestShipper_Backpressure(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := testShipConfig(server.URL)
+	config.BatchSize, config.QueueSize = 1, 1
+	s, err := newShipper(OutputLoki, config)
+	require.NoError(t, err)
+
+	// One entry is in flight and one queued; the rest are dropped without
+	// waiting for the store
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, _ = s.Write([]byte("entry\n"))
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+	assert.GreaterOrEqual(t, s.Dropped(), int64(1))
+
+	_, err = newShipper(OutputLoki, ShipConfig{})
+	assert.ErrorContains(t, err, "URL")
+}
@@ -0,0 +1,123 @@
+// Package mailer sends email over SMTP and renders it from templates
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+	"github.com/cctw-zed/wonder/pkg/logger"
+	"github.com/cctw-zed/wonder/pkg/retry"
+)
+
+// Message is an email to one recipient. Text is required; HTML, when set,
+// is sent as an alternative part.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer sends email
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Nop discards every message
+type Nop struct{}
+
+// Send implements Mailer
+func (Nop) Send(context.Context, *Message) error { return nil }
+
+// LogMailer writes messages to the log instead of sending them, for
+// development
+type LogMailer struct {
+	log logger.Logger
+}
+
+// NewLogMailer creates a LogMailer
+func NewLogMailer(log logger.Logger) *LogMailer {
+	return &LogMailer{log: log}
+}
+
+// Send implements Mailer
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	m.log.Info(ctx, "email not sent, logged instead",
+		"to", msg.To,
+		"subject", msg.Subject,
+		"text", msg.Text)
+	return nil
+}
+
+// IsTransient reports whether a send error may succeed on retry: network
+// failures and SMTP 4xx replies. 5xx replies, such as an unknown
+// recipient, are permanent.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code >= 400 && reply.Code < 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// RetryMailer retries sends that fail with a transient error
+type RetryMailer struct {
+	next   Mailer
+	policy retry.Policy
+}
+
+// WithRetry wraps next so transient failures are retried up to maxAttempts
+// times in total
+func WithRetry(next Mailer, maxAttempts int) *RetryMailer {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = maxAttempts
+	policy.BaseDelay = 500 * time.Millisecond
+	policy.MaxDelay = 10 * time.Second
+	policy.Retryable = IsTransient
+	return &RetryMailer{next: next, policy: policy}
+}
+
+// Send implements Mailer
+func (m *RetryMailer) Send(ctx context.Context, msg *Message) error {
+	return retry.Do(ctx, m.policy, func(ctx context.Context) error {
+		return m.next.Send(ctx, msg)
+	})
+}
+
+// BreakerMailer stops sending while the mail server keeps failing. Only
+// transient errors count against the server; a rejected recipient does not.
+type BreakerMailer struct {
+	next    Mailer
+	breaker *circuitbreaker.Breaker
+}
+
+// WithBreaker wraps next in breaker. Wrap the RetryMailer, not the other
+// way round, so one message counts as one failure.
+func WithBreaker(next Mailer, breaker *circuitbreaker.Breaker) *BreakerMailer {
+	return &BreakerMailer{next: next, breaker: breaker}
+}
+
+// Send implements Mailer
+func (m *BreakerMailer) Send(ctx context.Context, msg *Message) error {
+	var permanent error
+	err := m.breaker.Execute(func() error {
+		err := m.next.Send(ctx, msg)
+		if err != nil && !IsTransient(err) {
+			permanent = err
+			return nil
+		}
+		return err
+	})
+	if permanent != nil {
+		return permanent
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"context"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+)
+
+type flakyMailer struct {
+	errs  []error
+	calls int
+}
+
+func (m *flakyMailer) Send(context.Context, *Message) error {
+	m.calls++
+	if len(m.errs) == 0 {
+		return nil
+	}
+	err := m.errs[0]
+	m.errs = m.errs[1:]
+	return err
+}
+
+func TestRetryMailer(t *testing.T) {
+	tempFail := &textproto.Error{Code: 421, Msg: "Service not available"}
+	permFail := &textproto.Error{Code: 550, Msg: "Mailbox unavailable"}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "transient failure is retried", errs: []error{tempFail}, wantCalls: 2},
+		{name: "permanent failure is not retried", errs: []error{permFail}, wantCalls: 1, wantErr: true},
+		{name: "attempts are bounded", errs: []error{tempFail, tempFail, tempFail}, wantCalls: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &flakyMailer{errs: tt.errs}
+			m := WithRetry(next, 3)
+			m.policy.BaseDelay = 0
+
+			err := m.Send(context.Background(), &Message{To: "ada@example.com"})
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantCalls, next.calls)
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.False(t, IsTransient(nil))
+	assert.False(t, IsTransient(context.Canceled))
+	assert.True(t, IsTransient(context.DeadlineExceeded))
+	assert.True(t, IsTransient(&textproto.Error{Code: 452}))
+	assert.False(t, IsTransient(&textproto.Error{Code: 554}))
+	assert.False(t, IsTransient(assert.AnError))
+}
+
+func TestBreakerMailer(t *testing.T) {
+	next := &flakyMailer{errs: []error{
+		&textproto.Error{Code: 550, Msg: "Mailbox unavailable"},
+		&textproto.Error{Code: 550, Msg: "Mailbox unavailable"},
+		&textproto.Error{Code: 421, Msg: "Service not available"},
+		&textproto.Error{Code: 421, Msg: "Service not available"},
+	}}
+	m := WithBreaker(next, circuitbreaker.New(circuitbreaker.Settings{Name: "smtp", FailureThreshold: 2}))
+	msg := &Message{To: "ada@example.com"}
+
+	// Rejected recipients do not open the breaker
+	assert.Error(t, m.Send(context.Background(), msg))
+	assert.Error(t, m.Send(context.Background(), msg))
+	assert.Equal(t, circuitbreaker.StateClosed, m.breaker.State())
+
+	assert.Error(t, m.Send(context.Background(), msg))
+	assert.Error(t, m.Send(context.Background(), msg))
+	assert.Equal(t, circuitbreaker.StateOpen, m.breaker.State())
+
+	assert.ErrorIs(t, m.Send(context.Background(), msg), circuitbreaker.ErrOpen)
+	assert.Equal(t, 4, next.calls)
+}
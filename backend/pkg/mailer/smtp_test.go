@@ -0,0 +1,179 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts one plaintext SMTP session at a time and records
+// what it receives. rcptReply overrides the reply to RCPT TO.
+type fakeSMTPServer struct {
+	listener  net.Listener
+	rcptReply string
+
+	mu   sync.Mutex
+	auth string
+	from string
+	to   string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &fakeSMTPServer{listener: listener, rcptReply: "250 OK"}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			srv.serve(conn)
+		}
+	}()
+	return srv
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(line string) { _ = tp.PrintfLine("%s", line) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		s.mu.Lock()
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			s.auth = string(decoded)
+			reply("235 Authenticated")
+		case "MAIL":
+			s.from = arg
+			reply("250 OK")
+		case "RCPT":
+			s.to = arg
+			reply(s.rcptReply)
+		case "DATA":
+			reply("354 Go ahead")
+			lines, _ := tp.ReadDotLines()
+			s.data = strings.Join(lines, "\n")
+			reply("250 Queued")
+		case "QUIT":
+			reply("221 Bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("502 Not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	m, err := NewSMTPMailer(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     srv.port(),
+		Username: "mailer",
+		Password: "secret",
+		From:     "Wonder <no-reply@example.com>",
+		TLS:      TLSNone,
+	})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), &Message{
+		To:      "Ada <ada@example.com>",
+		Subject: "Grüße",
+		Text:    "Hello Ada",
+		HTML:    "<p>Hello Ada</p>",
+	})
+	require.NoError(t, err)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal(t, "\x00mailer\x00secret", srv.auth)
+	assert.Equal(t, "FROM:<no-reply@example.com>", srv.from)
+	assert.Equal(t, "TO:<ada@example.com>", srv.to)
+
+	reader := textproto.NewReader(bufio.NewReader(strings.NewReader(srv.data + "\n")))
+	header, err := reader.ReadMIMEHeader()
+	require.NoError(t, err)
+	assert.Equal(t, `"Wonder" <no-reply@example.com>`, header.Get("From"))
+	assert.Equal(t, `"Ada" <ada@example.com>`, header.Get("To"))
+	assert.Equal(t, "=?utf-8?q?Gr=C3=BC=C3=9Fe?=", header.Get("Subject"))
+	assert.Contains(t, header.Get("Message-Id"), "@example.com>")
+	assert.True(t, strings.HasPrefix(header.Get("Content-Type"), "multipart/alternative; boundary="))
+	assert.Contains(t, srv.data, "Content-Type: text/plain; charset=utf-8")
+	assert.Contains(t, srv.data, "Content-Type: text/html; charset=utf-8")
+	assert.Contains(t, srv.data, "<p>Hello Ada</p>")
+}
+
+func TestSMTPMailer_SendRejected(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	srv.rcptReply = "550 No such user"
+	m, err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: srv.port(), From: "no-reply@example.com", TLS: TLSNone})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), &Message{To: "ghost@example.com", Subject: "Hi", Text: "Hi"})
+	require.Error(t, err)
+	assert.False(t, IsTransient(err))
+
+	srv.rcptReply = "451 Try again later"
+	err = m.Send(context.Background(), &Message{To: "ghost@example.com", Subject: "Hi", Text: "Hi"})
+	require.Error(t, err)
+	assert.True(t, IsTransient(err))
+}
+
+func TestSMTPMailer_RequiresStartTLS(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	m, err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: srv.port(), From: "no-reply@example.com"})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), &Message{To: "ada@example.com", Subject: "Hi", Text: "Hi"})
+	assert.ErrorContains(t, err, "does not support STARTTLS")
+}
+
+func TestSMTPMailer_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	m, err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: port, From: "no-reply@example.com", TLS: TLSNone})
+	require.NoError(t, err)
+	err = m.Send(context.Background(), &Message{To: "ada@example.com", Subject: "Hi", Text: "Hi"})
+	require.Error(t, err)
+	assert.True(t, IsTransient(err), strconv.Quote(err.Error()))
+}
+
+func TestNewSMTPMailer_Invalid(t *testing.T) {
+	_, err := NewSMTPMailer(SMTPConfig{Host: "localhost", From: "not an address"})
+	assert.Error(t, err)
+	_, err = NewSMTPMailer(SMTPConfig{Host: "localhost", From: "a@example.com", TLS: "ssl"})
+	assert.Error(t, err)
+}
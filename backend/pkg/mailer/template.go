@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates holds the built-in emails. Each email is a <name>.txt text
+// template that defines a "subject" template, and an optional <name>.html
+// body.
+//
+//go:embed templates
+var Templates embed.FS
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer renders messages from a set of email templates
+type Renderer struct {
+	templates map[string]emailTemplate
+}
+
+// NewRenderer parses the email templates of fsys, which is laid out like
+// Templates
+func NewRenderer(fsys fs.FS) (*Renderer, error) {
+	textFiles, err := fs.Glob(fsys, "templates/*.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Renderer{templates: make(map[string]emailTemplate, len(textFiles))}
+	for _, file := range textFiles {
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+
+		text, err := texttemplate.ParseFS(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("parse email template %s: %w", file, err)
+		}
+		if text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("email template %s does not define a subject", file)
+		}
+		tmpl := emailTemplate{text: text}
+
+		htmlFile := "templates/" + name + ".html"
+		if _, err := fs.Stat(fsys, htmlFile); err == nil {
+			if tmpl.html, err = htmltemplate.ParseFS(fsys, htmlFile); err != nil {
+				return nil, fmt.Errorf("parse email template %s: %w", htmlFile, err)
+			}
+		}
+		r.templates[name] = tmpl
+	}
+	return r, nil
+}
+
+// Render renders the named email addressed to to. data is escaped in the
+// HTML body only.
+func (r *Renderer) Render(name, to string, data any) (*Message, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("render %s subject: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("render %s text: %w", name, err)
+	}
+	if tmpl.html != nil {
+		if err := tmpl.html.Execute(&html, data); err != nil {
+			return nil, fmt.Errorf("render %s html: %w", name, err)
+		}
+	}
+
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_Templates(t *testing.T) {
+	r, err := NewRenderer(Templates)
+	require.NoError(t, err)
+
+	data := map[string]string{
+		"Name":     "Ada <Admin>",
+		"Email":    "ada@example.com",
+		"AppURL":   "https://wonder.example.com",
+		"Link":     "https://wonder.example.com/verify?token=abc",
+		"DeleteOn": "16 November 2026",
+		"Title":    "Your email address was changed",
+		"Body":     "Your account now uses ada@example.com.",
+	}
+	for _, name := range []string{"welcome", "verification", "password_reset", "deletion_scheduled", "deletion_cancelled", "account_deleted", "data_export_ready", "notification"} {
+		msg, err := r.Render(name, "ada@example.com", data)
+		require.NoError(t, err, name)
+		assert.Equal(t, "ada@example.com", msg.To)
+		assert.NotEmpty(t, msg.Subject, name)
+		assert.Contains(t, msg.Text, "Hi Ada <Admin>,", name)
+		assert.Contains(t, msg.HTML, "Hi Ada &lt;Admin&gt;,", name)
+	}
+
+	msg, err := r.Render("welcome", "ada@example.com", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome to Wonder, Ada <Admin>", msg.Subject)
+	assert.Contains(t, msg.Text, "Sign in at https://wonder.example.com")
+
+	msg, err = r.Render("verification", "ada@example.com", data)
+	require.NoError(t, err)
+	assert.Contains(t, msg.Text, "https://wonder.example.com/verify?token=abc")
+
+	msg, err = r.Render("deletion_scheduled", "ada@example.com", data)
+	require.NoError(t, err)
+	assert.Contains(t, msg.Text, "deleted permanently on 16 November 2026")
+
+	msg, err = r.Render("notification", "ada@example.com", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Your email address was changed", msg.Subject)
+	assert.Contains(t, msg.Text, "Your account now uses ada@example.com.")
+
+	msg, err = r.Render("invitation", "grace@example.com", map[string]string{
+		"Inviter":      "Ada",
+		"Organization": "Research",
+		"Link":         "https://wonder.example.com/invite?token=abc",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Ada invited you to join Research", msg.Subject)
+	assert.Contains(t, msg.Text, "Hi,\n")
+	assert.Contains(t, msg.HTML, `href="https://wonder.example.com/invite?token=abc"`)
+
+	_, err = r.Render("missing", "ada@example.com", data)
+	assert.Error(t, err)
+}
+
+func TestNewRenderer_TextOnlyAndInvalid(t *testing.T) {
+	r, err := NewRenderer(fstest.MapFS{
+		"templates/note.txt": {Data: []byte(`{{define "subject"}}Note{{end}}Body`)},
+	})
+	require.NoError(t, err)
+	msg, err := r.Render("note", "ada@example.com", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Note", msg.Subject)
+	assert.Equal(t, "Body\n", msg.Text)
+	assert.Empty(t, msg.HTML)
+
+	_, err = NewRenderer(fstest.MapFS{
+		"templates/note.txt": {Data: []byte(`Body without subject`)},
+	})
+	assert.ErrorContains(t, err, "does not define a subject")
+}
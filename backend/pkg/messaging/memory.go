@@ -0,0 +1,102 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker is an in-process Broker for tests and single-node setups.
+// Delivery is synchronous: Publish returns after every handler ran, and the
+// first handler error is returned.
+type MemoryBroker struct {
+	mu     sync.RWMutex
+	subs   map[string][]*memorySubscription
+	next   map[string]int // round-robin cursor per topic+group
+	closed bool
+}
+
+type memorySubscription struct {
+	broker  *MemoryBroker
+	topic   string
+	group   string
+	handler Handler
+}
+
+// NewMemoryBroker creates an in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subs: make(map[string][]*memorySubscription),
+		next: make(map[string]int),
+	}
+}
+
+// Publish delivers msg to every ungrouped subscriber and to one member of each group
+func (b *MemoryBroker) Publish(ctx context.Context, msg *Message) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+
+	var targets []*memorySubscription
+	groups := make(map[string][]*memorySubscription)
+	for _, s := range b.subs[msg.Topic] {
+		if s.group == "" {
+			targets = append(targets, s)
+			continue
+		}
+		groups[s.group] = append(groups[s.group], s)
+	}
+	for group, members := range groups {
+		cursor := msg.Topic + "\x00" + group
+		targets = append(targets, members[b.next[cursor]%len(members)])
+		b.next[cursor]++
+	}
+	b.mu.Unlock()
+
+	var firstErr error
+	for _, s := range targets {
+		if err := s.handler(ctx, msg); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Subscribe registers handler for topic
+func (b *MemoryBroker) Subscribe(ctx context.Context, topic, group string, handler Handler) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	s := &memorySubscription{broker: b, topic: topic, group: group, handler: handler}
+	b.subs[topic] = append(b.subs[topic], s)
+	return s, nil
+}
+
+// Close drops all subscriptions and rejects further use
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.subs = make(map[string][]*memorySubscription)
+	return nil
+}
+
+// Unsubscribe removes the subscription
+func (s *memorySubscription) Unsubscribe() error {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subs[s.topic]
+	for i, candidate := range subs {
+		if candidate == s {
+			b.subs[s.topic] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
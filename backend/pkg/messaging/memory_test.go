@@ -0,0 +1,57 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBroker_FanOutAndQueueGroups(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx := context.Background()
+
+	var broadcast, groupA, groupB int
+	_, err := b.Subscribe(ctx, "orders", "", func(context.Context, *Message) error { broadcast++; return nil })
+	require.NoError(t, err)
+	_, err = b.Subscribe(ctx, "orders", "workers", func(context.Context, *Message) error { groupA++; return nil })
+	require.NoError(t, err)
+	_, err = b.Subscribe(ctx, "orders", "workers", func(context.Context, *Message) error { groupB++; return nil })
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, b.Publish(ctx, &Message{Topic: "orders"}))
+	}
+
+	assert.Equal(t, 4, broadcast)
+	assert.Equal(t, 2, groupA)
+	assert.Equal(t, 2, groupB)
+}
+
+func TestMemoryBroker_UnsubscribeAndClose(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx := context.Background()
+
+	calls := 0
+	sub, err := b.Subscribe(ctx, "t", "", func(context.Context, *Message) error { calls++; return nil })
+	require.NoError(t, err)
+	require.NoError(t, sub.Unsubscribe())
+	require.NoError(t, b.Publish(ctx, &Message{Topic: "t"}))
+	assert.Zero(t, calls)
+
+	require.NoError(t, b.Close())
+	assert.ErrorIs(t, b.Publish(ctx, &Message{Topic: "t"}), ErrClosed)
+	_, err = b.Subscribe(ctx, "t", "", func(context.Context, *Message) error { return nil })
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestMemoryBroker_ReturnsHandlerError(t *testing.T) {
+	b := NewMemoryBroker()
+	wantErr := errors.New("handler failed")
+	_, err := b.Subscribe(context.Background(), "t", "", func(context.Context, *Message) error { return wantErr })
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, b.Publish(context.Background(), &Message{Topic: "t"}), wantErr)
+}
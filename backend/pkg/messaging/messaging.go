@@ -0,0 +1,72 @@
+// Package messaging provides a broker-agnostic publish/subscribe API used to
+// exchange events between services.
+package messaging
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Well-known message headers
+const (
+	HeaderMessageID   = "Message-Id"
+	HeaderMessageKey  = "Message-Key"
+	HeaderTimestamp   = "Message-Timestamp"
+	HeaderEventName   = "Event-Name"
+	HeaderAggregateID = "Aggregate-Id"
+	HeaderOccurredAt  = "Occurred-At"
+	HeaderContentType = "Content-Type"
+)
+
+// ErrClosed is returned when using a broker after Close
+var ErrClosed = errors.New("messaging: broker closed")
+
+// Message is a broker-agnostic envelope
+type Message struct {
+	// ID identifies the message; consumers use it to drop redeliveries
+	ID string
+	// Topic is the destination subject or topic
+	Topic string
+	// Key groups related messages, e.g. by aggregate, for ordering
+	Key       string
+	Headers   map[string]string
+	Payload   []byte
+	Timestamp time.Time
+}
+
+// Header returns a header value or ""
+func (m *Message) Header(name string) string {
+	if m.Headers == nil {
+		return ""
+	}
+	return m.Headers[name]
+}
+
+// Handler processes a received message. Returning an error reports the
+// failure to the broker implementation; redelivery semantics depend on it.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Publisher sends messages to a broker
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+// Subscription is an active consumer registration
+type Subscription interface {
+	Unsubscribe() error
+}
+
+// Subscriber receives messages from a broker. Subscribers sharing a
+// non-empty group split the topic's messages between them.
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic, group string, handler Handler) (Subscription, error)
+	Close() error
+}
+
+// Broker both publishes and subscribes
+type Broker interface {
+	Publisher
+	Subscriber
+}
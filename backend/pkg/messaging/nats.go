@@ -0,0 +1,144 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/cctw-zed/wonder/pkg/logger"
+)
+
+// natsMsgIDHeader lets JetStream streams deduplicate republished messages
+const natsMsgIDHeader = "Nats-Msg-Id"
+
+// NATSConfig configures a NATS connection
+type NATSConfig struct {
+	URL            string
+	ClientName     string
+	ConnectTimeout time.Duration
+	ReconnectWait  time.Duration
+	MaxReconnects  int
+}
+
+// NATSBroker is a Broker backed by core NATS. Groups map to NATS queue
+// groups. Core NATS delivers at most once; pair it with a JetStream stream
+// on the subjects when consumers need durability.
+type NATSBroker struct {
+	conn *nats.Conn
+	log  logger.Logger
+}
+
+// NewNATSBroker connects to NATS
+func NewNATSBroker(cfg NATSConfig) (*NATSBroker, error) {
+	opts := []nats.Option{nats.Name(cfg.ClientName)}
+	if cfg.ConnectTimeout > 0 {
+		opts = append(opts, nats.Timeout(cfg.ConnectTimeout))
+	}
+	if cfg.ReconnectWait > 0 {
+		opts = append(opts, nats.ReconnectWait(cfg.ReconnectWait))
+	}
+	if cfg.MaxReconnects != 0 {
+		opts = append(opts, nats.MaxReconnects(cfg.MaxReconnects))
+	}
+
+	conn, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats %s: %w", cfg.URL, err)
+	}
+
+	return &NATSBroker{
+		conn: conn,
+		log:  logger.Get().WithLayer("infrastructure").WithComponent("nats_broker"),
+	}, nil
+}
+
+// Publish sends msg and waits for the server to acknowledge the flush, so a
+// nil error means the message left the process
+func (b *NATSBroker) Publish(ctx context.Context, msg *Message) error {
+	if b.conn.IsClosed() {
+		return ErrClosed
+	}
+
+	if err := b.conn.PublishMsg(toNATSMsg(msg)); err != nil {
+		return fmt.Errorf("publish to %s: %w", msg.Topic, err)
+	}
+	if err := b.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("flush %s: %w", msg.Topic, err)
+	}
+	return nil
+}
+
+// Subscribe registers handler on topic, in a queue group when group is set
+func (b *NATSBroker) Subscribe(ctx context.Context, topic, group string, handler Handler) (Subscription, error) {
+	if b.conn.IsClosed() {
+		return nil, ErrClosed
+	}
+
+	cb := func(m *nats.Msg) {
+		msg := fromNATSMsg(m)
+		if err := handler(context.Background(), msg); err != nil {
+			b.log.Error(context.Background(), "message handler failed", "topic", msg.Topic, "message_id", msg.ID, "error", err)
+		}
+	}
+
+	var (
+		sub *nats.Subscription
+		err error
+	)
+	if group != "" {
+		sub, err = b.conn.QueueSubscribe(topic, group, cb)
+	} else {
+		sub, err = b.conn.Subscribe(topic, cb)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to %s: %w", topic, err)
+	}
+	return sub, nil
+}
+
+// Close drains in-flight messages and closes the connection
+func (b *NATSBroker) Close() error {
+	if b.conn.IsClosed() {
+		return nil
+	}
+	return b.conn.Drain()
+}
+
+func toNATSMsg(msg *Message) *nats.Msg {
+	m := nats.NewMsg(msg.Topic)
+	m.Data = msg.Payload
+	for k, v := range msg.Headers {
+		m.Header.Set(k, v)
+	}
+	if msg.ID != "" {
+		m.Header.Set(HeaderMessageID, msg.ID)
+		m.Header.Set(natsMsgIDHeader, msg.ID)
+	}
+	if msg.Key != "" {
+		m.Header.Set(HeaderMessageKey, msg.Key)
+	}
+	if !msg.Timestamp.IsZero() {
+		m.Header.Set(HeaderTimestamp, strconv.FormatInt(msg.Timestamp.UnixNano(), 10))
+	}
+	return m
+}
+
+func fromNATSMsg(m *nats.Msg) *Message {
+	msg := &Message{
+		Topic:   m.Subject,
+		Payload: m.Data,
+		Headers: make(map[string]string, len(m.Header)),
+	}
+	for k := range m.Header {
+		msg.Headers[k] = m.Header.Get(k)
+	}
+	msg.ID = msg.Headers[HeaderMessageID]
+	msg.Key = msg.Headers[HeaderMessageKey]
+	if ts, err := strconv.ParseInt(msg.Headers[HeaderTimestamp], 10, 64); err == nil {
+		msg.Timestamp = time.Unix(0, ts).UTC()
+	}
+	return msg
+}
@@ -0,0 +1,36 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNATSMessageConversion_RoundTrip(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+	in := &Message{
+		ID:        "key-1",
+		Topic:     "wonder.user.registered",
+		Key:       "user-1",
+		Headers:   map[string]string{HeaderEventName: "user.registered"},
+		Payload:   []byte(`{"email":"a@example.com"}`),
+		Timestamp: ts,
+	}
+
+	m := toNATSMsg(in)
+	assert.Equal(t, "key-1", m.Header.Get(natsMsgIDHeader))
+
+	out := fromNATSMsg(m)
+	assert.Equal(t, in.ID, out.ID)
+	assert.Equal(t, in.Topic, out.Topic)
+	assert.Equal(t, in.Key, out.Key)
+	assert.Equal(t, in.Payload, out.Payload)
+	assert.Equal(t, ts, out.Timestamp)
+	assert.Equal(t, "user.registered", out.Header(HeaderEventName))
+}
+
+func TestNewNATSBroker_ConnectError(t *testing.T) {
+	_, err := NewNATSBroker(NATSConfig{URL: "nats://127.0.0.1:1", ConnectTimeout: 100 * time.Millisecond})
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+// Package netguard keeps requests to URLs that users or administrators
+// supply away from internal networks. Loopback, private, link-local
+// (including cloud metadata endpoints), shared, unspecified and multicast
+// addresses are refused, both when a URL is registered and when a
+// connection is made, since a name may resolve elsewhere by then.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// ErrBlocked reports an address outside the public internet
+var ErrBlocked = errors.New("address is not publicly routable")
+
+// blockedPrefixes are non-public ranges the netip predicates do not cover
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, which embeds IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/32"),       // Teredo, which embeds IPv4
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4, which embeds IPv4
+	netip.MustParsePrefix("fec0::/10"),       // deprecated site-local
+}
+
+// Allowed reports whether ip is a public unicast address
+func Allowed(ip netip.Addr) bool {
+	if !ip.IsValid() {
+		return false
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost refuses a URL host that is a blocked address literal or a
+// name that always means this machine. It does not resolve names; see
+// CheckResolved.
+func CheckHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return fmt.Errorf("%w: empty host", ErrBlocked)
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrBlocked, host)
+	}
+	if ip, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil && !Allowed(ip) {
+		return fmt.Errorf("%w: %s", ErrBlocked, host)
+	}
+	return nil
+}
+
+// CheckResolved refuses host when it is blocked by CheckHost or any of the
+// addresses it resolves to is not public
+func CheckResolved(ctx context.Context, host string) error {
+	if err := CheckHost(host); err != nil {
+		return err
+	}
+	if _, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, ip := range addrs {
+		if !Allowed(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrBlocked, host, ip)
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control function that refuses to connect to
+// blocked addresses. It runs after name resolution, on the address
+// actually dialed.
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlocked, address)
+	}
+	if !Allowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlocked, addrPort.Addr())
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/httpclient"
+)
+
+func TestAllowed(t *testing.T) {
+	blocked := []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"0.0.0.0", "100.64.0.1", "224.0.0.1", "255.255.255.255", "198.18.0.1",
+		"::1", "::", "fe80::1", "fc00::1", "fd00:ec2::254", "ff02::1",
+		"::ffff:127.0.0.1", "::ffff:169.254.169.254", "64:ff9b::a9fe:a9fe",
+	}
+	for _, addr := range blocked {
+		assert.False(t, Allowed(netip.MustParseAddr(addr)), addr)
+	}
+
+	for _, addr := range []string{"93.184.216.34", "8.8.8.8", "2606:4700:4700::1111"} {
+		assert.True(t, Allowed(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"localhost", "LOCALHOST.", "api.localhost", "127.0.0.1", "[::1]", "169.254.169.254", ""} {
+		assert.ErrorIs(t, CheckHost(host), ErrBlocked, host)
+	}
+	for _, host := range []string{"example.com", "93.184.216.34"} {
+		assert.NoError(t, CheckHost(host), host)
+	}
+}
+
+func TestCheckResolved_LiteralAddress(t *testing.T) {
+	assert.ErrorIs(t, CheckResolved(context.Background(), "10.0.0.1"), ErrBlocked)
+	assert.NoError(t, CheckResolved(context.Background(), "93.184.216.34"))
+}
+
+func TestControl_RefusesDialsToBlockedAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := httpclient.DefaultConfig("test")
+	cfg.DialControl = Control
+	client := httpclient.New(cfg)
+
+	resp, err := client.Post(server.URL, "application/json", nil)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBlocked), err.Error())
+}
@@ -0,0 +1,147 @@
+// Package pagination encodes opaque keyset cursors for collection endpoints.
+package pagination
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for cursors that were not produced by the
+// Codec decoding them
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// MinKeySize is the shortest key a Codec signs cursors with, in bytes
+const MinKeySize = 32
+
+// Cursor marks the position after the last item of a page in a collection
+// ordered by one sort key and then ID, both in Order. The cursor carries
+// the item's value of the sort key, so the next page does not depend on the
+// item still existing.
+type Cursor struct {
+	// Sort names the sort key; empty is the creation time
+	Sort string `json:"s,omitempty"`
+	// Order is the direction of the page the cursor continues, "asc" or
+	// "desc"
+	Order     string    `json:"o"`
+	CreatedAt time.Time `json:"t"`
+	// Key is the item's value of Sort, formatted by the caller; empty when
+	// sorting by the creation time
+	Key string `json:"k,omitempty"`
+	// Private keys, such as names and email addresses, are encrypted in
+	// the token so that clients cannot read them
+	Private bool   `json:"-"`
+	ID      string `json:"id"`
+}
+
+// token is the signed form of a cursor, with a private key sealed
+type token struct {
+	Cursor
+	Sealed []byte `json:"p,omitempty"`
+}
+
+// Codec turns cursors into signed tokens, so clients cannot forge a
+// position they were not given
+type Codec struct {
+	key  []byte
+	aead cipher.AEAD
+}
+
+// NewCodec creates a codec signing cursors with key, which must be at
+// least MinKeySize bytes. Servers that share a key accept each other's
+// cursors.
+func NewCodec(key []byte) (*Codec, error) {
+	if len(key) < MinKeySize {
+		return nil, errors.New("cursor signing key must be at least 32 bytes")
+	}
+	return newCodec(append([]byte(nil), key...)), nil
+}
+
+// NewRandomCodec creates a codec with a random key. Its cursors are only
+// accepted by the same codec, so they do not survive a restart.
+func NewRandomCodec() *Codec {
+	key := make([]byte, MinKeySize)
+	if _, err := rand.Read(key); err != nil {
+		panic("failed to generate cursor signing key: " + err.Error())
+	}
+	return newCodec(key)
+}
+
+// newCodec derives the key private sort keys are encrypted with from the
+// signing key
+func newCodec(key []byte) *Codec {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("pagination cursor encryption"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		panic("failed to create cursor cipher: " + err.Error())
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic("failed to create cursor cipher: " + err.Error())
+	}
+	return &Codec{key: key, aead: aead}
+}
+
+// Encode returns the opaque, URL-safe form of the cursor
+func (c *Codec) Encode(cursor Cursor) string {
+	t := token{Cursor: cursor}
+	if cursor.Private && cursor.Key != "" {
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			panic("failed to generate cursor nonce: " + err.Error())
+		}
+		t.Sealed = c.aead.Seal(nonce, nonce, []byte(cursor.Key), nil)
+		t.Key = ""
+	}
+	b, _ := json.Marshal(t)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
+
+// Decode verifies and parses a cursor produced by Encode
+func (c *Codec) Decode(s string) (Cursor, error) {
+	payload, signature, ok := strings.Cut(s, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, c.sign(payload)) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var t token
+	if err := json.Unmarshal(b, &t); err != nil || t.ID == "" || t.CreatedAt.IsZero() ||
+		(t.Order != "asc" && t.Order != "desc") {
+		return Cursor{}, ErrInvalidCursor
+	}
+	cursor := t.Cursor
+	if t.Sealed != nil {
+		size := c.aead.NonceSize()
+		if len(t.Sealed) < size {
+			return Cursor{}, ErrInvalidCursor
+		}
+		key, err := c.aead.Open(nil, t.Sealed[:size], t.Sealed[size:], nil)
+		if err != nil {
+			return Cursor{}, ErrInvalidCursor
+		}
+		cursor.Key, cursor.Private = string(key), true
+	}
+	return cursor, nil
+}
+
+func (c *Codec) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
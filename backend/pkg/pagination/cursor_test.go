@@ -0,0 +1,92 @@
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	codec, err := NewCodec(bytes.Repeat([]byte{7}, MinKeySize))
+	require.NoError(t, err)
+	original := Cursor{Sort: "name", Order: "asc", CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC), ID: "user-42"}
+
+	encoded := codec.Encode(original)
+	assert.NotContains(t, encoded, "=")
+
+	decoded, err := codec.Decode(encoded)
+	require.NoError(t, err)
+	assert.True(t, original.CreatedAt.Equal(decoded.CreatedAt))
+	decoded.CreatedAt = original.CreatedAt
+	assert.Equal(t, original, decoded)
+
+	// Another server sharing the key accepts it
+	other, err := NewCodec(bytes.Repeat([]byte{7}, MinKeySize))
+	require.NoError(t, err)
+	_, err = other.Decode(encoded)
+	assert.NoError(t, err)
+}
+
+func TestCodec_PrivateKey(t *testing.T) {
+	codec := NewRandomCodec()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	public := codec.Encode(Cursor{Sort: "updated_at", Order: "asc", CreatedAt: at, Key: "2026-03-02T08:00:00Z", ID: "user-42"})
+	payload, _, _ := strings.Cut(public, ".")
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "2026-03-02T08:00:00Z")
+
+	private := codec.Encode(Cursor{Sort: "email", Order: "asc", CreatedAt: at, Key: "ada@example.com", Private: true, ID: "user-42"})
+	payload, _, _ = strings.Cut(private, ".")
+	b, err = base64.RawURLEncoding.DecodeString(payload)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "ada@example.com")
+
+	decoded, err := codec.Decode(private)
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", decoded.Key)
+	assert.True(t, decoded.Private)
+
+	// Encryption is randomized, so equal keys do not show as equal tokens
+	assert.NotEqual(t, private, codec.Encode(Cursor{Sort: "email", Order: "asc", CreatedAt: at, Key: "ada@example.com", Private: true, ID: "user-42"}))
+}
+
+func TestNewCodec_ShortKey(t *testing.T) {
+	_, err := NewCodec([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestCodec_DecodeInvalid(t *testing.T) {
+	codec := NewRandomCodec()
+	valid := codec.Encode(Cursor{Order: "desc", CreatedAt: time.Now(), ID: "user-1"})
+	payload, signature, _ := strings.Cut(valid, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"o":"desc","t":"2026-01-01T00:00:00Z","id":"user-9"}`))
+
+	for _, s := range []string{
+		"",
+		"not base64!",
+		payload,
+		forged + "." + signature,
+		NewRandomCodec().Encode(Cursor{Order: "desc", CreatedAt: time.Now(), ID: "user-1"}),
+		codec.Encode(Cursor{Order: "desc", ID: "user-1"}),
+		codec.Encode(Cursor{Order: "sideways", CreatedAt: time.Now(), ID: "user-1"}),
+		codec.sealedBy(NewRandomCodec(), Cursor{Sort: "name", Order: "asc", CreatedAt: time.Now(), Key: "Ada", Private: true, ID: "user-1"}),
+	} {
+		_, err := codec.Decode(s)
+		assert.ErrorIs(t, err, ErrInvalidCursor, s)
+	}
+}
+
+// sealedBy encodes cursor with the private key sealed by other but signed
+// by c, as a token whose key was encrypted with another codec's key
+func (c *Codec) sealedBy(other *Codec, cursor Cursor) string {
+	sealed := other.Encode(cursor)
+	payload, _, _ := strings.Cut(sealed, ".")
+	return payload + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
@@ -0,0 +1,199 @@
+// Package redis runs Redis commands through go-redis. It keeps the small,
+// untyped API the service's stores are written against and puts the
+// connection pool behind an optional circuit breaker.
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+)
+
+// ErrNil is returned when a reply is the RESP null value, e.g. GET of a missing key
+var ErrNil = errors.New("redis: nil reply")
+
+// ErrClosed is returned when using a client after Close
+var ErrClosed = errors.New("redis: client closed")
+
+// Error is an error reply sent by the server
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// Options configures a Client
+type Options struct {
+	Addr     string
+	Password string
+	DB       int
+	// PoolSize caps the connections open at once; commands wait up to
+	// Timeout for one to be free
+	PoolSize int
+	// Timeout bounds dialing and each command; a sooner ctx deadline wins
+	Timeout time.Duration
+	// Breaker, if set, fails commands fast after repeated connection
+	// failures. Error replies and nil replies do not count as failures.
+	Breaker *circuitbreaker.Breaker
+}
+
+// Client runs commands on pooled connections. It is safe for concurrent use.
+type Client struct {
+	rdb     *goredis.Client
+	breaker *circuitbreaker.Breaker
+}
+
+// NewClient creates a client. Connections are dialed lazily.
+func NewClient(opts Options) *Client {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 3 * time.Second
+	}
+	return &Client{
+		rdb: goredis.NewClient(&goredis.Options{
+			Addr:     opts.Addr,
+			Password: opts.Password,
+			DB:       opts.DB,
+			// RESP2 keeps replies to strings, integers and arrays
+			Protocol:              2,
+			DisableIdentity:       true,
+			PoolSize:              opts.PoolSize,
+			PoolTimeout:           opts.Timeout,
+			DialTimeout:           opts.Timeout,
+			ReadTimeout:           opts.Timeout,
+			WriteTimeout:          opts.Timeout,
+			ContextTimeoutEnabled: true,
+			// Commands such as INCR are not safe to send twice
+			MaxRetries: -1,
+		}),
+		breaker: opts.Breaker,
+	}
+}
+
+// Do sends a command and returns its reply: string for simple and bulk
+// strings, int64 for integers, []interface{} for arrays. Server error
+// replies are returned as Error, including the first error element of an
+// array; null replies as ErrNil.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("redis: empty command")
+	}
+	if c.breaker == nil {
+		return c.do(ctx, args)
+	}
+
+	var reply interface{}
+	var cmdErr error
+	err := c.breaker.Execute(func() error {
+		reply, cmdErr = c.do(ctx, args)
+		if isReplyError(cmdErr) {
+			return nil
+		}
+		return cmdErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply, cmdErr
+}
+
+func (c *Client) do(ctx context.Context, args []string) (interface{}, error) {
+	cmd := make([]interface{}, len(args))
+	for i, arg := range args {
+		cmd[i] = arg
+	}
+
+	reply, err := c.rdb.Do(ctx, cmd...).Result()
+	if err != nil {
+		return nil, replyError(err)
+	}
+	if err := elementError(reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// replyError translates go-redis errors into this package's
+func replyError(err error) error {
+	var serverErr goredis.Error
+	switch {
+	case errors.Is(err, goredis.Nil):
+		return ErrNil
+	case errors.Is(err, goredis.ErrClosed):
+		return ErrClosed
+	case errors.As(err, &serverErr):
+		return Error(serverErr.Error())
+	}
+	return err
+}
+
+// elementError returns the first error element of an array reply. The
+// whole array has been read by then, so the connection stays in sync.
+func elementError(reply interface{}) error {
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, item := range items {
+		if serverErr, ok := item.(goredis.Error); ok {
+			return Error(serverErr.Error())
+		}
+		if err := elementError(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isReplyError reports whether err is a well-formed reply from the server,
+// which says nothing about the server's health
+func isReplyError(err error) bool {
+	var serverErr Error
+	return errors.Is(err, ErrNil) || errors.As(err, &serverErr)
+}
+
+// Int runs a command with an integer reply
+func (c *Client) Int(ctx context.Context, args ...string) (int64, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply %T for %s", reply, args[0])
+	}
+	return n, nil
+}
+
+// String runs a command with a string reply
+func (c *Client) String(ctx context.Context, args ...string) (string, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	s, ok := reply.(string)
+	if !ok {
+		return "", fmt.Errorf("redis: unexpected reply %T for %s", reply, args[0])
+	}
+	return s, nil
+}
+
+// Ping checks connectivity
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.String(ctx, "PING")
+	return err
+}
+
+// Close closes the connections; later commands fail with ErrClosed
+func (c *Client) Close() error {
+	err := c.rdb.Close()
+	if errors.Is(err, goredis.ErrClosed) {
+		return nil
+	}
+	return err
+}
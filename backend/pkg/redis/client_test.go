@@ -0,0 +1,177 @@
+package redis_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+func TestClient_Commands(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	require.NoError(t, client.Ping(ctx))
+
+	n, err := client.Int(ctx, "INCR", "counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	ok, err := client.String(ctx, "SET", "greeting", "hello world", "PX", "60000")
+	require.NoError(t, err)
+	assert.Equal(t, "OK", ok)
+
+	v, err := client.String(ctx, "GET", "greeting")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", v)
+
+	ttl, err := client.Int(ctx, "PTTL", "greeting")
+	require.NoError(t, err)
+	assert.Greater(t, ttl, int64(0))
+
+	_, err = client.String(ctx, "GET", "missing")
+	assert.ErrorIs(t, err, redis.ErrNil)
+
+	_, err = client.Do(ctx, "INCR", "greeting")
+	var serverErr redis.Error
+	assert.ErrorAs(t, err, &serverErr)
+
+	// The connection survives an error reply
+	n, err = client.Int(ctx, "INCR", "counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestClient_ArrayElementError(t *testing.T) {
+	srv := miniredis.RunT(t)
+	// A single connection makes the next command read what this one left
+	client := redis.NewClient(redis.Options{Addr: srv.Addr(), PoolSize: 1})
+	defer client.Close()
+	ctx := context.Background()
+
+	_, err := client.Do(ctx, "EVAL", "return {1, redis.error_reply('ERR first'), redis.error_reply('ERR second'), 'last'}", "0")
+	var serverErr redis.Error
+	require.ErrorAs(t, err, &serverErr)
+	assert.Equal(t, "ERR first", err.Error())
+
+	v, err := client.String(ctx, "ECHO", "in sync")
+	require.NoError(t, err)
+	assert.Equal(t, "in sync", v)
+}
+
+func TestClient_SortedSets(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	added, err := client.Int(ctx, "ZADD", "queue", "30", "c", "10", "a", "20", "b")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), added)
+
+	due, err := client.Do(ctx, "ZRANGEBYSCORE", "queue", "-inf", "20", "LIMIT", "0", "1")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a"}, due)
+
+	count, err := client.Int(ctx, "ZCOUNT", "queue", "-inf", "20")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	newest, err := client.Do(ctx, "ZREVRANGE", "queue", "0", "-1")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"c", "b", "a"}, newest)
+
+	removed, err := client.Int(ctx, "ZREM", "queue", "a", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	card, err := client.Int(ctx, "ZCARD", "queue")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), card)
+}
+
+func TestClient_Auth(t *testing.T) {
+	srv := miniredis.RunT(t)
+	srv.RequireAuth("s3cret")
+
+	ctx := context.Background()
+	assert.Error(t, redis.NewClient(redis.Options{Addr: srv.Addr(), Password: "wrong"}).Ping(ctx))
+	assert.NoError(t, redis.NewClient(redis.Options{Addr: srv.Addr(), Password: "s3cret"}).Ping(ctx))
+}
+
+func TestClient_Closed(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	require.NoError(t, client.Close())
+
+	assert.ErrorIs(t, client.Ping(context.Background()), redis.ErrClosed)
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	srv := miniredis.RunT(t)
+	breaker := circuitbreaker.New(circuitbreaker.Settings{Name: "redis", FailureThreshold: 2, OpenTimeout: time.Hour})
+	ctx := context.Background()
+
+	// Error replies come from a healthy server and do not open the breaker
+	client := redis.NewClient(redis.Options{Addr: srv.Addr(), Breaker: breaker})
+	for i := 0; i < 3; i++ {
+		_, err := client.Do(ctx, "NOSUCHCOMMAND")
+		var serverErr redis.Error
+		require.ErrorAs(t, err, &serverErr)
+	}
+	assert.Equal(t, circuitbreaker.StateClosed, breaker.State())
+
+	// Connection failures do
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	down := redis.NewClient(redis.Options{Addr: addr, Timeout: 100 * time.Millisecond, Breaker: breaker})
+	for i := 0; i < 2; i++ {
+		assert.Error(t, down.Ping(ctx))
+	}
+	assert.Equal(t, circuitbreaker.StateOpen, breaker.State())
+	assert.ErrorIs(t, down.Ping(ctx), circuitbreaker.ErrOpen)
+}
+
+func TestScript_Run(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	script := redis.NewScript("return redis.call('SET', KEYS[1], ARGV[1], 'NX') and 1 or 0")
+
+	// The first run is not cached by the server and falls back to EVAL
+	reply, err := script.Run(ctx, client, []string{"owner"}, "a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reply)
+
+	reply, err = script.Run(ctx, client, []string{"owner"}, "b")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), reply)
+
+	v, err := client.String(ctx, "GET", "owner")
+	require.NoError(t, err)
+	assert.Equal(t, "a", v)
+
+	_, err = client.Do(ctx, "SCRIPT", "FLUSH")
+	require.NoError(t, err)
+	_, err = client.Do(ctx, "EVALSHA", script.Hash(), "1", "owner", "c")
+	var serverErr redis.Error
+	require.ErrorAs(t, err, &serverErr)
+	assert.Contains(t, err.Error(), "NOSCRIPT")
+
+	reply, err = script.Run(ctx, client, []string{"other"}, "c")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reply)
+}
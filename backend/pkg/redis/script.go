@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Script is a Lua script. Run sends it by hash and only sends the source
+// when the server has not cached it yet.
+type Script struct {
+	src  string
+	hash string
+}
+
+// NewScript creates a script from Lua source
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{src: src, hash: hex.EncodeToString(sum[:])}
+}
+
+// Source returns the Lua source
+func (s *Script) Source() string {
+	return s.src
+}
+
+// Hash returns the SHA1 digest the server caches the script under
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+// Run executes the script with EVALSHA, falling back to EVAL on NOSCRIPT.
+// Replies are decoded as in Client.Do.
+func (s *Script) Run(ctx context.Context, c *Client, keys []string, args ...string) (interface{}, error) {
+	reply, err := c.Do(ctx, s.command("EVALSHA", s.hash, keys, args)...)
+	var serverErr Error
+	if errors.As(err, &serverErr) && strings.HasPrefix(string(serverErr), "NOSCRIPT") {
+		return c.Do(ctx, s.command("EVAL", s.src, keys, args)...)
+	}
+	return reply, err
+}
+
+func (s *Script) command(name, script string, keys, args []string) []string {
+	cmd := make([]string, 0, 3+len(keys)+len(args))
+	cmd = append(cmd, name, script, strconv.Itoa(len(keys)))
+	cmd = append(cmd, keys...)
+	return append(cmd, args...)
+}
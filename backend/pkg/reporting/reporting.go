@@ -0,0 +1,117 @@
+// Package reporting forwards server errors and panics to an error tracker.
+// The process-wide reporter is set once at startup; until then reports are
+// dropped.
+package reporting
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/cctw-zed/wonder/pkg/sentry"
+)
+
+// Request describes the request an error occurred in
+type Request struct {
+	TraceID   string
+	RequestID string
+	UserID    string
+	Method    string
+	Route     string
+	Path      string
+	ClientIP  string
+	UserAgent string
+	Status    int
+}
+
+// Reporter captures errors for an error tracker. Implementations must not
+// block the request.
+type Reporter interface {
+	// CaptureError reports an error answered with a 5xx status
+	CaptureError(err error, req Request)
+	// CapturePanic reports a recovered panic. Call it from the deferred
+	// recover so the panicking stack is still available.
+	CapturePanic(recovered interface{}, req Request)
+}
+
+var (
+	mu       sync.RWMutex
+	reporter Reporter = Nop{}
+)
+
+// Set replaces the process-wide reporter; nil restores the no-op reporter
+func Set(r Reporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	if r == nil {
+		r = Nop{}
+	}
+	reporter = r
+}
+
+// Get returns the process-wide reporter
+func Get() Reporter {
+	mu.RLock()
+	defer mu.RUnlock()
+	return reporter
+}
+
+// Nop drops every report
+type Nop struct{}
+
+func (Nop) CaptureError(error, Request)       {}
+func (Nop) CapturePanic(interface{}, Request) {}
+
+// Sentry reports to Sentry. Events carry the client's release and
+// environment, the user ID and client IP, and the trace ID, route and status
+// as tags.
+type Sentry struct {
+	client *sentry.Client
+}
+
+// NewSentry creates a reporter sending through client
+func NewSentry(client *sentry.Client) *Sentry {
+	return &Sentry{client: client}
+}
+
+// CaptureError implements Reporter
+func (s *Sentry) CaptureError(err error, req Request) {
+	// Start the stack at the caller
+	event := s.client.NewErrorEvent(err, 1)
+	s.enrich(event, req)
+	s.client.SendAsync(event)
+}
+
+// CapturePanic implements Reporter
+func (s *Sentry) CapturePanic(recovered interface{}, req Request) {
+	// Skip the deferred function calling CapturePanic
+	event := s.client.NewPanicEvent(recovered, 2)
+	s.enrich(event, req)
+	s.client.SendAsync(event)
+}
+
+func (s *Sentry) enrich(event *sentry.Event, req Request) {
+	if event.Tags == nil {
+		event.Tags = map[string]string{}
+	}
+	for key, value := range map[string]string{
+		"trace_id":   req.TraceID,
+		"request_id": req.RequestID,
+		"route":      req.Route,
+	} {
+		if value != "" {
+			event.Tags[key] = value
+		}
+	}
+	if req.Status != 0 {
+		event.Tags["status"] = strconv.Itoa(req.Status)
+	}
+	if req.UserID != "" || req.ClientIP != "" {
+		event.User = &sentry.User{ID: req.UserID, IPAddress: req.ClientIP}
+	}
+	if req.Method != "" {
+		event.Request = &sentry.Request{Method: req.Method, URL: req.Path}
+		if req.UserAgent != "" {
+			event.Request.Headers = map[string]string{"User-Agent": req.UserAgent}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package reporting
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/sentry"
+)
+
+func newSentryServer(t *testing.T) (*sentry.Client, <-chan sentry.Event) {
+	events := make(chan sentry.Event, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		var last string
+		for scanner.Scan() {
+			last = scanner.Text()
+		}
+		var event sentry.Event
+		if assert.NoError(t, json.Unmarshal([]byte(last), &event)) {
+			events <- event
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sentry.NewClient(sentry.Config{
+		DSN:     strings.Replace(server.URL, "://", "://key@", 1) + "/1",
+		Release: "1.4.0",
+		Timeout: time.Second,
+		OnError: func(err error) { t.Error(err) },
+	}, nil)
+	require.NoError(t, err)
+	return client, events
+}
+
+func receive(t *testing.T, events <-chan sentry.Event) sentry.Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event reported")
+		return sentry.Event{}
+	}
+}
+
+func TestSentry_CaptureError(t *testing.T) {
+	client, events := newSentryServer(t)
+	reporter := NewSentry(client)
+
+	reporter.CaptureError(errors.New("connection reset"), Request{
+		TraceID:   "trace-1",
+		UserID:    "user-7",
+		Method:    http.MethodPut,
+		Route:     "/api/v1/users/:id",
+		Path:      "/api/v1/users/7",
+		ClientIP:  "10.0.0.1",
+		UserAgent: "curl/8.0",
+		Status:    http.StatusServiceUnavailable,
+	})
+
+	event := receive(t, events)
+	assert.Equal(t, "error", event.Level)
+	assert.Equal(t, "1.4.0", event.Release)
+	assert.Equal(t, map[string]string{"trace_id": "trace-1", "route": "/api/v1/users/:id", "status": "503"}, event.Tags)
+	require.NotNil(t, event.User)
+	assert.Equal(t, "user-7", event.User.ID)
+	assert.Equal(t, "10.0.0.1", event.User.IPAddress)
+	require.NotNil(t, event.Request)
+	assert.Equal(t, "/api/v1/users/7", event.Request.URL)
+	assert.Equal(t, "curl/8.0", event.Request.Headers["User-Agent"])
+	require.Len(t, event.Exception, 1)
+	assert.Equal(t, "connection reset", event.Exception[0].Value)
+}
+
+func TestSentry_CapturePanic(t *testing.T) {
+	client, events := newSentryServer(t)
+	reporter := NewSentry(client)
+
+	func() {
+		defer func() {
+			reporter.CapturePanic(recover(), Request{TraceID: "trace-2"})
+		}()
+		panicking()
+	}()
+
+	event := receive(t, events)
+	assert.Equal(t, "fatal", event.Level)
+	assert.Equal(t, "trace-2", event.Tags["trace_id"])
+	assert.Nil(t, event.User)
+
+	frames := event.Exception[0].Stacktrace.Frames
+	require.NotEmpty(t, frames)
+	assert.Equal(t, "panicking", frames[len(frames)-1].Function, "the stack starts where the panic happened")
+}
+
+func panicking() {
+	panic("boom")
+}
+
+func TestSetAndGet(t *testing.T) {
+	assert.IsType(t, Nop{}, Get())
+
+	reporter := &Sentry{}
+	Set(reporter)
+	assert.Same(t, reporter, Get())
+
+	Set(nil)
+	assert.IsType(t, Nop{}, Get())
+}
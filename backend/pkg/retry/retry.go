@@ -0,0 +1,120 @@
+// Package retry re-runs operations that fail with transient errors, waiting
+// with exponential backoff and jitter between attempts.
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+// Policy controls how often and how patiently an operation is retried
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below two disable retries.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts
+	MaxDelay time.Duration
+	// Multiplier grows the delay after each retry; values below one mean 2
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction (0 to 1) so
+	// clients failing together do not retry in lockstep
+	Jitter float64
+	// Retryable decides whether an error is transient. Nil uses
+	// errors.IsRetryable.
+	Retryable func(error) bool
+	// OnRetry, if set, is called before waiting for the next attempt
+	OnRetry func(attempt int, err error, delay time.Duration)
+	// OnGiveUp, if set, is called when a transient error is returned
+	// because the attempts are used up or ctx is done
+	OnGiveUp func(attempts int, err error)
+}
+
+// DefaultPolicy returns three attempts starting at 50ms
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   50 * time.Millisecond,
+		MaxDelay:    time.Second,
+		Multiplier:  2,
+		Jitter:      0.2,
+	}
+}
+
+// Delay returns the wait before retry number attempt, starting at 1,
+// without jitter
+func (p Policy) Delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// Do runs fn until it succeeds, fails with a non-retryable error, the
+// attempts are used up or ctx is done. It returns fn's last error.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	_, err := DoValue(ctx, p, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// DoValue is Do for operations that return a value
+func DoValue[T any](ctx context.Context, p Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = errors.IsRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		value, err := fn(ctx)
+		if err == nil || !retryable(err) {
+			return value, err
+		}
+		if attempt >= p.MaxAttempts {
+			p.giveUp(attempt, err)
+			return value, err
+		}
+
+		delay := p.jittered(p.Delay(attempt))
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			p.giveUp(attempt, err)
+			return value, err
+		case <-timer.C:
+		}
+	}
+}
+
+func (p Policy) giveUp(attempts int, err error) {
+	if p.OnGiveUp != nil && p.MaxAttempts > 1 {
+		p.OnGiveUp(attempts, err)
+	}
+}
+
+// jittered spreads delay uniformly over ±Jitter of its value
+func (p Policy) jittered(delay time.Duration) time.Duration {
+	if p.Jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	jitter := math.Min(p.Jitter, 1)
+	factor := 1 + jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(delay) * factor)
+}
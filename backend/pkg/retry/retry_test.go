@@ -0,0 +1,110 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/errors"
+)
+
+func transient() error {
+	return errors.NewDatabaseError("get_by_id", "users", fmt.Errorf("connection reset"), true)
+}
+
+func fastPolicy() Policy {
+	return Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+}
+
+func TestDo_RetriesTransientErrors(t *testing.T) {
+	var retries []int
+	p := fastPolicy()
+	p.OnRetry = func(attempt int, err error, delay time.Duration) {
+		retries = append(retries, attempt)
+	}
+
+	calls := 0
+	err := Do(context.Background(), p, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return transient()
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{1, 2}, retries)
+}
+
+func TestDo_GivesUp(t *testing.T) {
+	t.Run("after max attempts", func(t *testing.T) {
+		gaveUpAfter := 0
+		p := fastPolicy()
+		p.OnGiveUp = func(attempts int, err error) { gaveUpAfter = attempts }
+
+		calls := 0
+		err := Do(context.Background(), p, func(ctx context.Context) error {
+			calls++
+			return transient()
+		})
+		assert.True(t, errors.IsRetryable(err))
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 3, gaveUpAfter)
+	})
+
+	t.Run("on permanent errors", func(t *testing.T) {
+		calls := 0
+		err := Do(context.Background(), fastPolicy(), func(ctx context.Context) error {
+			calls++
+			return errors.NewEntityNotFoundError("user", "1")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := Policy{MaxAttempts: 5, BaseDelay: time.Hour}
+		p.OnRetry = func(int, error, time.Duration) { cancel() }
+
+		calls := 0
+		err := Do(ctx, p, func(ctx context.Context) error {
+			calls++
+			return transient()
+		})
+		assert.True(t, errors.IsRetryable(err), "the operation's error is returned, not the context's")
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestDoValue(t *testing.T) {
+	calls := 0
+	value, err := DoValue(context.Background(), fastPolicy(), func(ctx context.Context) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", transient()
+		}
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", value)
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	p := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond, Multiplier: 2}
+	assert.Equal(t, 100*time.Millisecond, p.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, p.Delay(2))
+	assert.Equal(t, 300*time.Millisecond, p.Delay(3), "capped at MaxDelay")
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.jittered(100 * time.Millisecond)
+		assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+		assert.LessOrEqual(t, d, 150*time.Millisecond)
+	}
+}
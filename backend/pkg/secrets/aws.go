@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/awssig"
+)
+
+// SchemeAWSSecretsManager is the reference scheme served by AWSProvider
+const SchemeAWSSecretsManager = "aws-sm"
+
+const awsService = "secretsmanager"
+
+// AWSConfig configures access to AWS Secrets Manager with static
+// credentials
+type AWSConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Endpoint overrides the regional endpoint, e.g. for LocalStack
+	Endpoint string
+	Timeout  time.Duration
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager. Paths are secret IDs
+// (names or ARNs); a SecretString holding a JSON object exposes its fields
+// as keys, any other value is returned whole.
+type AWSProvider struct {
+	signer     awssig.Signer
+	endpoint   string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewAWSProvider creates a Secrets Manager provider. A nil httpClient uses a
+// client with the configured timeout.
+func NewAWSProvider(cfg AWSConfig, httpClient *http.Client) (*AWSProvider, error) {
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("aws region is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws access key id and secret access key are required")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", awsService, cfg.Region)
+	}
+
+	return &AWSProvider{
+		signer: awssig.Signer{
+			Credentials: awssig.Credentials{
+				AccessKeyID:     cfg.AccessKeyID,
+				SecretAccessKey: cfg.SecretAccessKey,
+				SessionToken:    cfg.SessionToken,
+			},
+			Region:  cfg.Region,
+			Service: awsService,
+		},
+		endpoint:   endpoint,
+		httpClient: httpClient,
+		now:        time.Now,
+	}, nil
+}
+
+// Fetch implements Provider
+func (p *AWSProvider) Fetch(ctx context.Context, path string) (map[string]string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	p.signer.Sign(req, awssig.PayloadHash(body), p.now())
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("secrets manager returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out struct {
+		SecretString *string `json:"SecretString"`
+		SecretBinary *string `json:"SecretBinary"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode secrets manager response: %w", err)
+	}
+
+	var value string
+	switch {
+	case out.SecretString != nil:
+		value = *out.SecretString
+	case out.SecretBinary != nil:
+		raw, err := base64.StdEncoding.DecodeString(*out.SecretBinary)
+		if err != nil {
+			return nil, fmt.Errorf("decode secret binary: %w", err)
+		}
+		value = string(raw)
+	default:
+		return nil, fmt.Errorf("secrets manager response has no secret value")
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &object); err == nil {
+		return decodeFields(object), nil
+	}
+	return map[string]string{"": value}, nil
+}
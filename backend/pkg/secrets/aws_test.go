@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSProvider_Fetch(t *testing.T) {
+	secretValues := map[string]string{
+		"wonder/jwt": "plain-signing-key",
+		"wonder/db":  `{"password":"s3cret","username":"wonder"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "token", r.Header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKID/20250101/eu-west-1/secretsmanager/aws4_request"))
+
+		var in struct{ SecretId string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		value, ok := secretValues[in.SecretId]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"Name": in.SecretId, "SecretString": value})
+	}))
+	defer server.Close()
+
+	provider, err := NewAWSProvider(AWSConfig{
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+		Endpoint:        server.URL,
+	}, nil)
+	require.NoError(t, err)
+	provider.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	r := NewResolver()
+	r.Register(SchemeAWSSecretsManager, provider)
+	ctx := context.Background()
+
+	t.Run("should return plain secret strings whole", func(t *testing.T) {
+		v, err := r.Resolve(ctx, "aws-sm:wonder/jwt")
+		require.NoError(t, err)
+		assert.Equal(t, "plain-signing-key", v)
+	})
+
+	t.Run("should select fields of JSON secrets", func(t *testing.T) {
+		v, err := r.Resolve(ctx, "aws-sm:wonder/db#password")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", v)
+	})
+
+	t.Run("should report service errors", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "aws-sm:wonder/missing")
+		assert.ErrorContains(t, err, "ResourceNotFoundException")
+	})
+}
@@ -0,0 +1,162 @@
+// Package secrets resolves references to values held in external secret
+// stores. A reference is a string of the form
+//
+//	<scheme>:<path>[#<key>]
+//
+// for example "vault:secret/data/wonder#db_password" or "aws-sm:wonder/jwt".
+// Strings with an unknown scheme are not references and resolve to themselves.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Reference points at one value in a secret store
+type Reference struct {
+	Scheme string
+	Path   string
+	// Key selects a field of a structured secret; empty means the whole secret
+	Key string
+}
+
+// String returns the reference in its textual form
+func (r Reference) String() string {
+	if r.Key == "" {
+		return r.Scheme + ":" + r.Path
+	}
+	return r.Scheme + ":" + r.Path + "#" + r.Key
+}
+
+// Provider fetches secrets from one store. Fetch returns every field of the
+// secret at path; unstructured secrets are returned under the empty key.
+type Provider interface {
+	Fetch(ctx context.Context, path string) (map[string]string, error)
+}
+
+// ProviderFunc adapts a function to Provider
+type ProviderFunc func(ctx context.Context, path string) (map[string]string, error)
+
+// Fetch implements Provider
+func (f ProviderFunc) Fetch(ctx context.Context, path string) (map[string]string, error) {
+	return f(ctx, path)
+}
+
+// Resolver maps reference schemes to providers
+type Resolver struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewResolver creates a resolver with no providers
+func NewResolver() *Resolver {
+	return &Resolver{providers: make(map[string]Provider)}
+}
+
+// Register installs the provider for scheme, replacing any previous one
+func (r *Resolver) Register(scheme string, provider Provider) {
+	if provider == nil {
+		panic("secret provider cannot be nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = provider
+}
+
+// Schemes returns the registered schemes in sorted order
+func (r *Resolver) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schemes := make([]string, 0, len(r.providers))
+	for s := range r.providers {
+		schemes = append(schemes, s)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Parse reports whether value is a reference to a registered scheme
+func (r *Resolver) Parse(value string) (Reference, bool) {
+	scheme, rest, ok := strings.Cut(value, ":")
+	if !ok || rest == "" {
+		return Reference{}, false
+	}
+	r.mu.RLock()
+	_, known := r.providers[scheme]
+	r.mu.RUnlock()
+	if !known {
+		return Reference{}, false
+	}
+
+	path, key, _ := strings.Cut(rest, "#")
+	return Reference{Scheme: scheme, Path: path, Key: key}, true
+}
+
+// Resolve returns the secret value value refers to, or value unchanged when
+// it is not a reference
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok := r.Parse(value)
+	if !ok {
+		return value, nil
+	}
+	return r.ResolveReference(ctx, ref)
+}
+
+// ResolveReference fetches the value of ref
+func (r *Resolver) ResolveReference(ctx context.Context, ref Reference) (string, error) {
+	r.mu.RLock()
+	provider, ok := r.providers[ref.Scheme]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no secret provider for scheme %q", ref.Scheme)
+	}
+	if ref.Path == "" {
+		return "", fmt.Errorf("secret reference %s has no path", ref)
+	}
+
+	fields, err := provider.Fetch(ctx, ref.Path)
+	if err != nil {
+		return "", fmt.Errorf("fetch %s:%s: %w", ref.Scheme, ref.Path, err)
+	}
+	return selectField(ref, fields)
+}
+
+// selectField picks the referenced key. Without a key the secret must hold
+// exactly one value.
+func selectField(ref Reference, fields map[string]string) (string, error) {
+	if ref.Key != "" {
+		v, ok := fields[ref.Key]
+		if !ok {
+			return "", fmt.Errorf("secret %s:%s has no key %q", ref.Scheme, ref.Path, ref.Key)
+		}
+		return v, nil
+	}
+	if len(fields) != 1 {
+		return "", fmt.Errorf("secret %s:%s has %d keys, reference must select one with #key", ref.Scheme, ref.Path, len(fields))
+	}
+	for _, v := range fields {
+		return v, nil
+	}
+	return "", nil
+}
+
+// decodeFields converts a JSON object of scalars into string fields
+func decodeFields(raw map[string]interface{}) map[string]string {
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch val := v.(type) {
+		case string:
+			fields[k] = val
+		case nil:
+			fields[k] = ""
+		default:
+			b, _ := json.Marshal(val)
+			fields[k] = string(b)
+		}
+	}
+	return fields
+}
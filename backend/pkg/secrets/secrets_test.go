@@ -0,0 +1,75 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func staticProvider(secrets map[string]map[string]string) Provider {
+	return ProviderFunc(func(ctx context.Context, path string) (map[string]string, error) {
+		fields, ok := secrets[path]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return fields, nil
+	})
+}
+
+func TestResolver_Parse(t *testing.T) {
+	r := NewResolver()
+	r.Register(SchemeVault, staticProvider(nil))
+
+	ref, ok := r.Parse("vault:secret/data/wonder#db_password")
+	require.True(t, ok)
+	assert.Equal(t, Reference{Scheme: "vault", Path: "secret/data/wonder", Key: "db_password"}, ref)
+	assert.Equal(t, "vault:secret/data/wonder#db_password", ref.String())
+
+	for _, value := range []string{"plain-password", "vault:", "postgres://u:p@host/db", "aws-sm:wonder/jwt"} {
+		_, ok := r.Parse(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	r := NewResolver()
+	r.Register(SchemeVault, staticProvider(map[string]map[string]string{
+		"secret/data/wonder": {"db_password": "s3cret", "jwt_key": "k"},
+		"secret/data/single": {"value": "only"},
+	}))
+	ctx := context.Background()
+
+	t.Run("should pass through non-references", func(t *testing.T) {
+		v, err := r.Resolve(ctx, "literal")
+		require.NoError(t, err)
+		assert.Equal(t, "literal", v)
+	})
+
+	t.Run("should select the referenced key", func(t *testing.T) {
+		v, err := r.Resolve(ctx, "vault:secret/data/wonder#db_password")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", v)
+	})
+
+	t.Run("should use the only value when no key is given", func(t *testing.T) {
+		v, err := r.Resolve(ctx, "vault:secret/data/single")
+		require.NoError(t, err)
+		assert.Equal(t, "only", v)
+	})
+
+	t.Run("should require a key for multi-value secrets", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "vault:secret/data/wonder")
+		assert.ErrorContains(t, err, "#key")
+	})
+
+	t.Run("should report missing keys and secrets", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "vault:secret/data/wonder#missing")
+		assert.ErrorContains(t, err, `no key "missing"`)
+
+		_, err = r.Resolve(ctx, "vault:secret/data/absent#x")
+		assert.ErrorContains(t, err, "not found")
+	})
+}
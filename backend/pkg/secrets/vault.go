@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SchemeVault is the reference scheme served by VaultProvider
+const SchemeVault = "vault"
+
+// VaultConfig configures access to a HashiCorp Vault server
+type VaultConfig struct {
+	Address   string
+	Token     string
+	Namespace string
+	Timeout   time.Duration
+}
+
+// VaultProvider reads secrets through the Vault HTTP API. Paths are API
+// paths below /v1, so a KV v2 secret is addressed as "secret/data/<name>".
+// Both KV v1 and KV v2 response layouts are understood.
+type VaultProvider struct {
+	address    string
+	token      string
+	namespace  string
+	httpClient *http.Client
+}
+
+// NewVaultProvider creates a Vault provider. A nil httpClient uses a client
+// with the configured timeout.
+func NewVaultProvider(cfg VaultConfig, httpClient *http.Client) (*VaultProvider, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("vault address is required")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("vault token is required")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	return &VaultProvider{
+		address:    strings.TrimRight(cfg.Address, "/"),
+		token:      cfg.Token,
+		namespace:  cfg.Namespace,
+		httpClient: httpClient,
+	}, nil
+}
+
+// Fetch implements Provider
+func (p *VaultProvider) Fetch(ctx context.Context, path string) (map[string]string, error) {
+	endpoint := p.address + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Vault error bodies never contain secret material
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode vault response: %w", err)
+	}
+	if payload.Data == nil {
+		return nil, fmt.Errorf("vault response has no data")
+	}
+
+	// KV v2 nests the secret under data.data next to data.metadata
+	if inner, ok := payload.Data["data"].(map[string]interface{}); ok {
+		if _, versioned := payload.Data["metadata"]; versioned {
+			return decodeFields(inner), nil
+		}
+	}
+	return decodeFields(payload.Data), nil
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultProvider_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		assert.Equal(t, "team-a", r.Header.Get("X-Vault-Namespace"))
+
+		switch r.URL.Path {
+		case "/v1/secret/data/wonder":
+			_, _ = w.Write([]byte(`{"data":{"data":{"db_password":"s3cret","port":5432},"metadata":{"version":3}}}`))
+		case "/v1/kv/wonder":
+			_, _ = w.Write([]byte(`{"data":{"jwt_key":"v1-key"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewVaultProvider(VaultConfig{Address: server.URL + "/", Token: "root", Namespace: "team-a"}, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("should unwrap KV v2 secrets", func(t *testing.T) {
+		fields, err := provider.Fetch(ctx, "secret/data/wonder")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"db_password": "s3cret", "port": "5432"}, fields)
+	})
+
+	t.Run("should read KV v1 secrets", func(t *testing.T) {
+		fields, err := provider.Fetch(ctx, "/kv/wonder")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"jwt_key": "v1-key"}, fields)
+	})
+
+	t.Run("should report error statuses", func(t *testing.T) {
+		_, err := provider.Fetch(ctx, "secret/data/missing")
+		assert.ErrorContains(t, err, "status 404")
+	})
+
+	t.Run("should require address and token", func(t *testing.T) {
+		_, err := NewVaultProvider(VaultConfig{Token: "t"}, nil)
+		assert.Error(t, err)
+		_, err = NewVaultProvider(VaultConfig{Address: server.URL}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,315 @@
+// Package sentry reports errors to Sentry through its HTTP envelope API
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const clientName = "wonder-sentry/1.0"
+
+// Config configures a Sentry client
+type Config struct {
+	// DSN is the project's client key URL,
+	// e.g. https://<key>@o1.ingest.sentry.io/<project>
+	DSN         string
+	Environment string
+	Release     string
+	ServerName  string
+	Timeout     time.Duration
+	// Tags are added to every event, e.g. the commit the binary was built
+	// from
+	Tags map[string]string
+	// OnError is called when SendAsync fails to deliver an event
+	OnError func(err error)
+}
+
+// DSN is a parsed Sentry DSN
+type DSN struct {
+	PublicKey string
+	ProjectID string
+	// Endpoint is the envelope URL events are posted to
+	Endpoint string
+}
+
+// ParseDSN parses a Sentry DSN
+func ParseDSN(raw string) (*DSN, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid sentry dsn: scheme must be http or https")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: missing public key")
+	}
+	path := strings.Trim(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if projectID == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: missing project id")
+	}
+
+	// Self-hosted Sentry may be served below a path prefix
+	prefix := ""
+	if slash >= 0 {
+		prefix = "/" + path[:slash]
+	}
+	return &DSN{
+		PublicKey: u.User.Username(),
+		ProjectID: projectID,
+		Endpoint:  fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, projectID),
+	}, nil
+}
+
+// Event is the subset of the Sentry event payload the client sends
+type Event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	User        *User             `json:"user,omitempty"`
+	Request     *Request          `json:"request,omitempty"`
+	Exception   []Exception       `json:"exception,omitempty"`
+}
+
+// User identifies the user affected by an event
+type User struct {
+	ID        string `json:"id,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+}
+
+// Request describes the HTTP request an event occurred in
+type Request struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Exception is an error with the stack it was raised from
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+// Stacktrace lists frames oldest first, as Sentry expects
+type Stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is one stack frame
+type Frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Client sends events to one Sentry project
+type Client struct {
+	dsn         *DSN
+	environment string
+	release     string
+	serverName  string
+	timeout     time.Duration
+	tags        map[string]string
+	onError     func(err error)
+	httpClient  *http.Client
+}
+
+// NewClient creates a client. A nil httpClient uses a client with the
+// configured timeout.
+func NewClient(cfg Config, httpClient *http.Client) (*Client, error) {
+	dsn, err := ParseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: cfg.Timeout}
+	}
+	serverName := cfg.ServerName
+	if serverName == "" {
+		serverName, _ = os.Hostname()
+	}
+
+	return &Client{
+		dsn:         dsn,
+		environment: cfg.Environment,
+		release:     cfg.Release,
+		serverName:  serverName,
+		timeout:     cfg.Timeout,
+		tags:        cfg.Tags,
+		onError:     cfg.OnError,
+		httpClient:  httpClient,
+	}, nil
+}
+
+// NewPanicEvent builds a fatal event for a recovered panic. The stack is
+// taken from the caller, so call it from the deferred recover; skip drops
+// that many frames above the caller.
+func (c *Client) NewPanicEvent(recovered interface{}, skip int) *Event {
+	event := c.newEvent("fatal", fmt.Sprintf("panic: %v", recovered))
+	event.Exception = []Exception{{
+		Type:       fmt.Sprintf("%T", recovered),
+		Value:      fmt.Sprint(recovered),
+		Stacktrace: NewStacktrace(skip + 1),
+	}}
+	return event
+}
+
+// NewErrorEvent builds an error event. The stack is taken from the caller;
+// skip drops that many frames above the caller.
+func (c *Client) NewErrorEvent(err error, skip int) *Event {
+	event := c.newEvent("error", err.Error())
+	event.Exception = []Exception{{
+		Type:       fmt.Sprintf("%T", err),
+		Value:      err.Error(),
+		Stacktrace: NewStacktrace(skip + 1),
+	}}
+	return event
+}
+
+func (c *Client) newEvent(level, message string) *Event {
+	var tags map[string]string
+	if len(c.tags) > 0 {
+		tags = maps.Clone(c.tags)
+	}
+	return &Event{
+		EventID:     newEventID(),
+		Timestamp:   time.Now().UTC(),
+		Level:       level,
+		Platform:    "go",
+		Environment: c.environment,
+		Release:     c.release,
+		ServerName:  c.serverName,
+		Message:     message,
+		Tags:        tags,
+	}
+}
+
+// SendAsync posts an event in the background so the caller is not delayed.
+// Delivery errors are passed to Config.OnError.
+func (c *Client) SendAsync(event *Event) {
+	go func() {
+		ctx := context.Background()
+		if c.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+		}
+		if err := c.Send(ctx, event); err != nil && c.onError != nil {
+			c.onError(err)
+		}
+	}()
+}
+
+// Send posts an event to Sentry
+func (c *Client) Send(ctx context.Context, event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode sentry event: %w", err)
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, `{"event_id":%q,"sent_at":%q}`+"\n", event.EventID, time.Now().UTC().Format(time.RFC3339Nano))
+	fmt.Fprintf(&body, `{"type":"event","length":%d}`+"\n", len(payload))
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.dsn.Endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", clientName, c.dsn.PublicKey))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sentry returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// NewStacktrace captures the stack of the caller; skip drops that many
+// frames above the caller. Runtime frames are omitted.
+func NewStacktrace(skip int) *Stacktrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			module, function := splitFunction(frame.Function)
+			stack = append(stack, Frame{
+				Function: function,
+				Module:   module,
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+				InApp:    inApp(module, frame.File),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+
+	// Sentry wants the innermost frame last
+	for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+		stack[i], stack[j] = stack[j], stack[i]
+	}
+	return &Stacktrace{Frames: stack}
+}
+
+// splitFunction splits "github.com/a/b.(*T).M" into the package path and
+// the function name
+func splitFunction(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	dot += slash + 1
+	return name[:dot], name[dot+1:]
+}
+
+// inApp reports whether a frame belongs to the application rather than the
+// standard library or a dependency in the module cache
+func inApp(module, file string) bool {
+	first, _, _ := strings.Cut(module, "/")
+	return strings.Contains(first, ".") && !strings.Contains(file, "/pkg/mod/")
+}
+
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,86 @@
+package sentry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDSN(t *testing.T) {
+	dsn, err := ParseDSN("https://abc123@o1.ingest.sentry.io/42")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", dsn.PublicKey)
+	assert.Equal(t, "42", dsn.ProjectID)
+	assert.Equal(t, "https://o1.ingest.sentry.io/api/42/envelope/", dsn.Endpoint)
+
+	dsn, err = ParseDSN("http://key@sentry.internal:9000/prefix/7")
+	require.NoError(t, err)
+	assert.Equal(t, "http://sentry.internal:9000/prefix/api/7/envelope/", dsn.Endpoint)
+
+	for _, invalid := range []string{"", "ftp://key@host/1", "https://host/1", "https://key@host/"} {
+		_, err := ParseDSN(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestClient_Send(t *testing.T) {
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/42/envelope/", r.URL.Path)
+		assert.Contains(t, r.Header.Get("X-Sentry-Auth"), "sentry_key=abc123")
+
+		// Envelope header, item header, event
+		scanner := bufio.NewScanner(r.Body)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		require.Len(t, lines, 3)
+		assert.Contains(t, lines[1], `"type":"event"`)
+
+		var event Event
+		require.NoError(t, json.Unmarshal([]byte(lines[2]), &event))
+		received <- event
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "://", "://abc123@", 1) + "/42"
+	client, err := NewClient(Config{DSN: dsn, Environment: "test", Release: "1.2.3", Tags: map[string]string{"git_commit": "abc1234"}}, nil)
+	require.NoError(t, err)
+
+	event := client.NewPanicEvent("boom", 0)
+	require.NoError(t, client.Send(context.Background(), event))
+
+	got := <-received
+	assert.Equal(t, event.EventID, got.EventID)
+	assert.Equal(t, "fatal", got.Level)
+	assert.Equal(t, "test", got.Environment)
+	assert.Equal(t, "1.2.3", got.Release)
+	assert.Equal(t, "abc1234", got.Tags["git_commit"])
+	require.Len(t, got.Exception, 1)
+	assert.Equal(t, "boom", got.Exception[0].Value)
+
+	frames := got.Exception[0].Stacktrace.Frames
+	require.NotEmpty(t, frames)
+	assert.Equal(t, "TestClient_Send", frames[len(frames)-1].Function)
+	assert.Equal(t, "github.com/cctw-zed/wonder/pkg/sentry", frames[len(frames)-1].Module)
+}
+
+func TestClient_SendRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{DSN: strings.Replace(server.URL, "://", "://k@", 1) + "/1"}, nil)
+	require.NoError(t, err)
+	assert.ErrorContains(t, client.Send(context.Background(), client.NewPanicEvent("boom", 0)), "status 429")
+}
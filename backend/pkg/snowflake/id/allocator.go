@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -22,6 +23,27 @@ type NodeIDAllocator interface {
 	RefreshLease(ctx context.Context, serviceType ServiceType, nodeID int64) error
 }
 
+// ErrAllocatorClosed 分配器已关闭，不再分配节点ID
+var ErrAllocatorClosed = errors.New("node ID allocator is closed")
+
+// errLeaseLost 续租时发现租约已过期或被其他实例接管
+var errLeaseLost = errors.New("node ID lease lost")
+
+// LeaseLostHandler 在租约丢失后被调用，此时节点ID可能已被其他实例占用。
+// 分配器已清除自身状态，再次调用AllocateNodeID会分配新的节点ID
+type LeaseLostHandler func(serviceType ServiceType, nodeID int64, err error)
+
+// LeaseWatcher 由基于租约的分配器实现，用于在租约丢失时通知调用方
+type LeaseWatcher interface {
+	OnLeaseLost(handler LeaseLostHandler)
+}
+
+// leaseLost 判断续租失败后是否应视为租约丢失：明确丢失，或租约可能在
+// 下次续租之前过期。后者留出一个续租间隔的余量，以便在其他实例接管前暂停生成
+func leaseLost(err error, lastRenew time.Time, leaseTimeout, renewInterval time.Duration) bool {
+	return errors.Is(err, errLeaseLost) || time.Since(lastRenew) >= leaseTimeout-renewInterval
+}
+
 // MachineBasedAllocator 基于机器特征的分配器
 type MachineBasedAllocator struct {
 	machineID string
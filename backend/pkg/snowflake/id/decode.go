@@ -0,0 +1,42 @@
+// pkg/snowflake/id/decode.go - Snowflake ID introspection
+package id
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// DecodedID 雪花ID的组成部分，用于排查ID来自哪个服务实例
+type DecodedID struct {
+	ID          string    `json:"id"`
+	Timestamp   time.Time `json:"timestamp"`
+	NodeID      int64     `json:"node_id"`
+	ServiceType string    `json:"service_type"`
+	InstanceID  int64     `json:"instance_id"`
+	Sequence    int64     `json:"sequence"`
+}
+
+// Decode 解析由serviceType的服务生成的字符串ID。节点ID只有10位，
+// 各服务的号段无法从ID中区分，因此服务类型由调用方给出，通常取自
+// 配置的id.service_type
+func Decode(idString string, serviceType ServiceType) (*DecodedID, error) {
+	sf, err := snowflake.ParseString(idString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snowflake ID %q: %w", idString, err)
+	}
+	if sf.Int64() <= 0 {
+		return nil, fmt.Errorf("invalid snowflake ID %q: must be positive", idString)
+	}
+
+	nodeID := sf.Node()
+	return &DecodedID{
+		ID:          sf.String(),
+		Timestamp:   time.UnixMilli(sf.Time()).UTC(),
+		NodeID:      nodeID,
+		ServiceType: serviceType.String(),
+		InstanceID:  nodeID % 1024,
+		Sequence:    sf.Step(),
+	}, nil
+}
@@ -0,0 +1,43 @@
+package id
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	gen, err := NewSnowflakeGeneratorForService(ServiceTypeUser, 42)
+	require.NoError(t, err)
+
+	before := time.Now().Add(-time.Millisecond)
+	first := gen.Generate()
+	second := gen.Generate()
+
+	decoded, err := Decode(first, ServiceTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, first, decoded.ID)
+	assert.Equal(t, int64(42), decoded.NodeID)
+	assert.Equal(t, "user", decoded.ServiceType)
+	assert.Equal(t, int64(42), decoded.InstanceID)
+	assert.WithinRange(t, decoded.Timestamp, before.Truncate(time.Millisecond), time.Now())
+
+	next, err := Decode(second, ServiceTypeUser)
+	require.NoError(t, err)
+	if next.Timestamp.Equal(decoded.Timestamp) {
+		assert.Equal(t, decoded.Sequence+1, next.Sequence)
+	}
+
+	// The service type is the caller's; the node ID cannot tell
+	other, err := Decode(first, ServiceTypeAuth)
+	require.NoError(t, err)
+	assert.Equal(t, "auth", other.ServiceType)
+	assert.Equal(t, int64(42), other.InstanceID)
+
+	for _, invalid := range []string{"", "abc", "-5", "0", "99999999999999999999"} {
+		_, err := Decode(invalid, ServiceTypeUser)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -4,6 +4,7 @@ package id
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -11,8 +12,11 @@ import (
 	"sync"
 	"time"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/concurrency"
+
+	"github.com/cctw-zed/wonder/pkg/circuitbreaker"
 )
 
 const (
@@ -44,6 +48,7 @@ type EtcdAllocator struct {
 	leaseTimeout  time.Duration
 	renewInterval time.Duration
 	retryInterval time.Duration
+	breaker       *circuitbreaker.Breaker
 
 	// 当前分配的nodeID和租约
 	mu           sync.RWMutex
@@ -51,14 +56,16 @@ type EtcdAllocator struct {
 	leaseID      clientv3.LeaseID
 	serviceType  ServiceType
 	instanceInfo *InstanceInfo
+	lastRenew    time.Time
+	onLost       LeaseLostHandler
 
-	// 控制续租的context和cancel
-	renewCtx    context.Context
+	// 控制续租goroutine的cancel，renewDone在goroutine退出时关闭
 	renewCancel context.CancelFunc
+	renewDone   chan struct{}
 
 	// 状态
 	isAllocated bool
-	renewDone   chan struct{}
+	closed      bool
 }
 
 // NewEtcdAllocator 创建etcd分配器
@@ -87,7 +94,7 @@ func NewEtcdAllocator(endpoints []string, opts ...EtcdOption) (*EtcdAllocator, e
 		leaseTimeout:  config.LeaseTimeout,
 		renewInterval: config.RenewInterval,
 		retryInterval: config.RetryInterval,
-		renewDone:     make(chan struct{}),
+		breaker:       config.Breaker,
 	}
 
 	return allocator, nil
@@ -99,6 +106,8 @@ type EtcdConfig struct {
 	LeaseTimeout  time.Duration
 	RenewInterval time.Duration
 	RetryInterval time.Duration
+	// Breaker 连续失败后快速失败，为 nil 时不启用
+	Breaker *circuitbreaker.Breaker
 }
 
 // EtcdOption etcd配置选项
@@ -118,15 +127,52 @@ func WithRenewInterval(interval time.Duration) EtcdOption {
 	}
 }
 
+// WithCircuitBreaker 设置etcd调用的熔断器
+func WithCircuitBreaker(breaker *circuitbreaker.Breaker) EtcdOption {
+	return func(c *EtcdConfig) {
+		c.Breaker = breaker
+	}
+}
+
+// call 在配置了熔断器时通过熔断器执行etcd调用
+func (e *EtcdAllocator) call(fn func() error) error {
+	if e.breaker == nil {
+		return fn()
+	}
+	return e.breaker.Execute(fn)
+}
+
 // AllocateNodeID 分配节点ID
 func (e *EtcdAllocator) AllocateNodeID(ctx context.Context, serviceType ServiceType) (int64, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if e.closed {
+		return 0, ErrAllocatorClosed
+	}
 	if e.isAllocated {
 		return e.nodeID, nil
 	}
 
+	var nodeID int64
+	err := e.call(func() error {
+		var err error
+		nodeID, err = e.allocate(ctx, serviceType)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// 启动续租goroutine
+	e.startRenewLease()
+
+	log.Printf("Successfully allocated node ID %d for service %s", nodeID, serviceType)
+	return nodeID, nil
+}
+
+// allocate 在分布式锁内查找并注册nodeID，调用方需持有e.mu
+func (e *EtcdAllocator) allocate(ctx context.Context, serviceType ServiceType) (int64, error) {
 	// 创建分配上下文
 	allocCtx, cancel := context.WithTimeout(ctx, allocateTimeout)
 	defer cancel()
@@ -169,11 +215,7 @@ func (e *EtcdAllocator) AllocateNodeID(ctx context.Context, serviceType ServiceT
 	e.leaseID = lease.ID
 	e.serviceType = serviceType
 	e.isAllocated = true
-
-	// 启动续租goroutine
-	e.startRenewLease()
-
-	log.Printf("Successfully allocated node ID %d for service %s", nodeID, serviceType)
+	e.lastRenew = time.Now()
 	return nodeID, nil
 }
 
@@ -241,32 +283,84 @@ func (e *EtcdAllocator) registerNodeID(ctx context.Context, serviceType ServiceT
 	return nil
 }
 
-// startRenewLease 启动续租
+// startRenewLease 启动续租，调用方需持有e.mu
 func (e *EtcdAllocator) startRenewLease() {
-	e.renewCtx, e.renewCancel = context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	e.renewCancel, e.renewDone = cancel, done
 
 	go func() {
-		defer close(e.renewDone)
+		defer close(done)
 
 		ticker := time.NewTicker(e.renewInterval)
 		defer ticker.Stop()
 
 		for {
 			select {
-			case <-e.renewCtx.Done():
+			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				if err := e.renewLease(); err != nil {
-					log.Printf("Failed to renew lease for node %d: %v", e.nodeID, err)
-					// 在实际应用中，这里可能需要触发重新分配
+				if err := e.renewLease(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("Failed to renew lease for node %d: %v", e.currentNodeID(), err)
+					if e.leaseLost(err) {
+						e.loseLease(err)
+						return
+					}
 				}
 			}
 		}
 	}()
 }
 
+func (e *EtcdAllocator) currentNodeID() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.nodeID
+}
+
+func (e *EtcdAllocator) leaseLost(err error) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return leaseLost(err, e.lastRenew, e.leaseTimeout, e.renewInterval)
+}
+
+// loseLease 在续租goroutine中调用：清除分配状态并通知处理函数，
+// 续租失败期间其他实例可能已注册同一nodeID
+func (e *EtcdAllocator) loseLease(cause error) {
+	e.mu.Lock()
+	if !e.isAllocated {
+		e.mu.Unlock()
+		return
+	}
+	serviceType, nodeID, leaseID, handler := e.serviceType, e.nodeID, e.leaseID, e.onLost
+	e.renewCancel()
+	e.nodeID = 0
+	e.leaseID = 0
+	e.isAllocated = false
+	e.instanceInfo = nil
+	e.renewCancel, e.renewDone = nil, nil
+	e.mu.Unlock()
+
+	// 租约可能仍然存在（例如etcd暂时不可达），尽力撤销以免占用nodeID直到过期
+	revokeCtx, cancel := context.WithTimeout(context.Background(), e.retryInterval)
+	e.client.Revoke(revokeCtx, leaseID)
+	cancel()
+
+	log.Printf("Lost lease for node ID %d of service %s: %v", nodeID, serviceType, cause)
+	if handler != nil {
+		go handler(serviceType, nodeID, cause)
+	}
+}
+
+// OnLeaseLost 设置租约丢失时的处理函数
+func (e *EtcdAllocator) OnLeaseLost(handler LeaseLostHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onLost = handler
+}
+
 // renewLease 续租
-func (e *EtcdAllocator) renewLease() error {
+func (e *EtcdAllocator) renewLease(ctx context.Context) error {
 	e.mu.RLock()
 	leaseID := e.leaseID
 	nodeID := e.nodeID
@@ -277,36 +371,64 @@ func (e *EtcdAllocator) renewLease() error {
 		return fmt.Errorf("no lease to renew")
 	}
 
-	// 续租
-	_, err := e.client.KeepAliveOnce(e.renewCtx, leaseID)
+	renewedAt := time.Now()
+	err := e.call(func() error {
+		// 续租
+		_, err := e.client.KeepAliveOnce(ctx, leaseID)
+		if err != nil {
+			return err
+		}
+
+		// 更新实例信息中的续租时间
+		e.mu.Lock()
+		var data []byte
+		if e.instanceInfo != nil && e.leaseID == leaseID {
+			e.instanceInfo.LastRenew = renewedAt
+			data, _ = json.Marshal(e.instanceInfo)
+		}
+		e.mu.Unlock()
+		if data != nil {
+			key := e.getNodeKey(serviceType, nodeID)
+			e.client.Put(ctx, key, string(data), clientv3.WithLease(leaseID))
+		}
+
+		return nil
+	})
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return fmt.Errorf("lease %x expired: %w", int64(leaseID), errLeaseLost)
+	}
 	if err != nil {
 		return err
 	}
 
-	// 更新实例信息中的续租时间
-	if e.instanceInfo != nil {
-		e.instanceInfo.LastRenew = time.Now()
-		data, _ := json.Marshal(e.instanceInfo)
-		key := e.getNodeKey(serviceType, nodeID)
-		e.client.Put(e.renewCtx, key, string(data), clientv3.WithLease(leaseID))
+	e.mu.Lock()
+	if e.leaseID == leaseID {
+		e.lastRenew = renewedAt
 	}
-
+	e.mu.Unlock()
 	return nil
 }
 
 // ReleaseNodeID 释放节点ID
 func (e *EtcdAllocator) ReleaseNodeID(ctx context.Context, serviceType ServiceType, nodeID int64) error {
 	e.mu.Lock()
-	defer e.mu.Unlock()
-
 	if !e.isAllocated || e.nodeID != nodeID {
+		e.mu.Unlock()
 		return nil // 已经释放或不是当前分配的ID
 	}
+	cancel, done := e.renewCancel, e.renewDone
+	e.mu.Unlock()
 
-	// 停止续租
-	if e.renewCancel != nil {
-		e.renewCancel()
-		<-e.renewDone // 等待续租goroutine结束
+	// 停止续租，续租goroutine需要e.mu，因此在锁外等待
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.isAllocated {
+		return nil
 	}
 
 	// 撤销租约
@@ -319,6 +441,7 @@ func (e *EtcdAllocator) ReleaseNodeID(ctx context.Context, serviceType ServiceTy
 	e.leaseID = 0
 	e.isAllocated = false
 	e.instanceInfo = nil
+	e.renewCancel, e.renewDone = nil, nil
 
 	log.Printf("Successfully released node ID %d for service %s", nodeID, serviceType)
 	return nil
@@ -326,23 +449,47 @@ func (e *EtcdAllocator) ReleaseNodeID(ctx context.Context, serviceType ServiceTy
 
 // RefreshLease 刷新租约（外部调用）
 func (e *EtcdAllocator) RefreshLease(ctx context.Context, serviceType ServiceType, nodeID int64) error {
-	return e.renewLease()
+	return e.renewLease(ctx)
 }
 
-// Close 关闭分配器
+// Close 释放当前的nodeID并关闭etcd客户端，之后不再分配
 func (e *EtcdAllocator) Close() error {
 	e.mu.Lock()
-	defer e.mu.Unlock()
+	e.closed = true
+	allocated, serviceType, nodeID := e.isAllocated, e.serviceType, e.nodeID
+	e.mu.Unlock()
 
 	// 释放当前的nodeID
-	if e.isAllocated {
-		e.ReleaseNodeID(context.Background(), e.serviceType, e.nodeID)
+	if allocated {
+		e.ReleaseNodeID(context.Background(), serviceType, nodeID)
 	}
 
 	// 关闭etcd客户端
 	return e.client.Close()
 }
 
+// Ping 检查etcd集群连通性，任一端点响应即视为可用
+func (e *EtcdAllocator) Ping(ctx context.Context) error {
+	return e.call(func() error {
+		return e.ping(ctx)
+	})
+}
+
+func (e *EtcdAllocator) ping(ctx context.Context) error {
+	var lastErr error
+	for _, endpoint := range e.client.Endpoints() {
+		if _, err := e.client.Status(ctx, endpoint); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no etcd endpoints configured")
+	}
+	return fmt.Errorf("etcd unreachable: %w", lastErr)
+}
+
 // 辅助方法
 func (e *EtcdAllocator) getLockKey(serviceType ServiceType) string {
 	return path.Join(etcdKeyPrefix, serviceType.String(), "lock")
@@ -3,11 +3,14 @@ package id
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/bwmarrin/snowflake"
+	"log"
 	"os"
 	"strconv"
 	"sync"
+	"time"
 )
 
 // ServiceType 服务类型枚举
@@ -69,11 +72,17 @@ type Generator interface {
 
 // SnowflakeGenerator 分布式雪花ID生成器
 type SnowflakeGenerator struct {
+	// mu 保护节点，重新分配nodeID期间持有写锁以暂停生成
+	mu          sync.RWMutex
 	node        *snowflake.Node
 	nodeID      int64
 	serviceType ServiceType
 	instanceID  int64
 	allocator   NodeIDAllocator // 可选的分配器，用于生命周期管理
+
+	// leaseErr 租约丢失且尚未重新分配时非nil；单独加锁，暂停期间仍可查询
+	leaseMu  sync.Mutex
+	leaseErr error
 }
 
 var (
@@ -169,18 +178,24 @@ func ParseNodeIDFromEnv() (int64, ServiceType, error) {
 	return nodeID, serviceType, nil
 }
 
-// Generate 生成字符串ID
+// Generate 生成字符串ID，重新分配nodeID期间阻塞
 func (s *SnowflakeGenerator) Generate() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.node.Generate().String()
 }
 
-// GenerateInt64 生成int64 ID
+// GenerateInt64 生成int64 ID，重新分配nodeID期间阻塞
 func (s *SnowflakeGenerator) GenerateInt64() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.node.Generate().Int64()
 }
 
 // GetNodeID 获取节点ID
 func (s *SnowflakeGenerator) GetNodeID() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.nodeID
 }
 
@@ -189,10 +204,54 @@ func (s *SnowflakeGenerator) GetServiceType() ServiceType {
 	return s.serviceType
 }
 
+// LeaseError 在nodeID租约丢失、生成暂停时返回原因，用于就绪检查
+func (s *SnowflakeGenerator) LeaseError() error {
+	s.leaseMu.Lock()
+	defer s.leaseMu.Unlock()
+	return s.leaseErr
+}
+
+func (s *SnowflakeGenerator) setLeaseError(err error) {
+	s.leaseMu.Lock()
+	defer s.leaseMu.Unlock()
+	s.leaseErr = err
+}
+
+// handleLeaseLost 暂停生成，直到分配到新的nodeID并替换雪花节点。
+// 分配器关闭后放弃重试
+func (s *SnowflakeGenerator) handleLeaseLost(serviceType ServiceType, nodeID int64, cause error) {
+	s.setLeaseError(fmt.Errorf("node ID %d lease lost, generation paused: %w", nodeID, cause))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		newNodeID, err := s.allocator.AllocateNodeID(context.Background(), serviceType)
+		if errors.Is(err, ErrAllocatorClosed) {
+			return
+		}
+		if err == nil {
+			var node *snowflake.Node
+			if node, err = snowflake.NewNode(newNodeID); err == nil {
+				s.node = node
+				s.nodeID = newNodeID
+				s.instanceID = newNodeID % 1024
+				s.setLeaseError(nil)
+				log.Printf("Re-allocated node ID %d for service %s after losing node ID %d", newNodeID, serviceType, nodeID)
+				return
+			}
+			s.allocator.ReleaseNodeID(context.Background(), serviceType, newNodeID)
+		}
+
+		log.Printf("Failed to re-allocate node ID for service %s: %v", serviceType, err)
+		time.Sleep(defaultRetryInterval)
+	}
+}
+
 // Close 关闭生成器并释放资源
 func (s *SnowflakeGenerator) Close() error {
 	if s.allocator != nil {
-		return s.allocator.ReleaseNodeID(context.Background(), s.serviceType, s.nodeID)
+		return s.allocator.ReleaseNodeID(context.Background(), s.serviceType, s.GetNodeID())
 	}
 	return nil
 }
@@ -258,6 +317,11 @@ func NewSnowflakeGeneratorWithAllocator(ctx context.Context, serviceType Service
 		allocator:   allocator,
 	}
 
+	// 租约丢失时其他实例可能占用同一nodeID，暂停生成并重新分配
+	if watcher, ok := allocator.(LeaseWatcher); ok {
+		watcher.OnLeaseLost(generator.handleLeaseLost)
+	}
+
 	return generator, nil
 }
 
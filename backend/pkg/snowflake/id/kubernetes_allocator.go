@@ -0,0 +1,501 @@
+// pkg/snowflake/id/kubernetes_allocator.go - Kubernetes-native node ID allocation
+package id
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// Pod内ServiceAccount凭据的挂载路径
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// Lease对象上标记服务类型的标签
+	leaseServiceLabel = "snowflake.wonder.io/service"
+)
+
+// KubernetesOrdinalAllocator 从StatefulSet的Pod序号推导节点ID，无需外部协调。
+// 每种服务类型只能由一个StatefulSet运行，序号必须小于1024。
+type KubernetesOrdinalAllocator struct {
+	ordinal int64
+}
+
+// NewKubernetesOrdinalAllocator 创建基于Pod序号的分配器。序号依次取自
+// POD_ORDINAL（通过Downward API暴露apps.kubernetes.io/pod-index标签）、
+// POD_NAME（通过Downward API暴露metadata.name）和主机名的"-N"后缀。
+func NewKubernetesOrdinalAllocator() (*KubernetesOrdinalAllocator, error) {
+	ordinal, err := podOrdinal(os.Getenv, os.Hostname)
+	if err != nil {
+		return nil, err
+	}
+	return &KubernetesOrdinalAllocator{ordinal: ordinal}, nil
+}
+
+// podOrdinal 按优先级解析Pod序号
+func podOrdinal(getenv func(string) string, hostname func() (string, error)) (int64, error) {
+	if v := getenv("POD_ORDINAL"); v != "" {
+		ordinal, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid POD_ORDINAL %q: %w", v, err)
+		}
+		return checkOrdinal(ordinal)
+	}
+
+	name := getenv("POD_NAME")
+	if name == "" {
+		var err error
+		if name, err = hostname(); err != nil {
+			return 0, fmt.Errorf("failed to determine pod name: %w", err)
+		}
+	}
+
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return 0, fmt.Errorf("pod name %q has no StatefulSet ordinal suffix", name)
+	}
+	ordinal, err := strconv.ParseInt(name[i+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("pod name %q has no StatefulSet ordinal suffix", name)
+	}
+	return checkOrdinal(ordinal)
+}
+
+func checkOrdinal(ordinal int64) (int64, error) {
+	if ordinal < 0 || ordinal >= 1024 {
+		return 0, fmt.Errorf("pod ordinal must be in range [0, 1023], got: %d", ordinal)
+	}
+	return ordinal, nil
+}
+
+// AllocateNodeID 返回服务号段内与Pod序号对应的节点ID
+func (k *KubernetesOrdinalAllocator) AllocateNodeID(ctx context.Context, serviceType ServiceType) (int64, error) {
+	return CalculateNodeID(serviceType, k.ordinal)
+}
+
+// ReleaseNodeID 序号由StatefulSet保证唯一，无需释放
+func (k *KubernetesOrdinalAllocator) ReleaseNodeID(ctx context.Context, serviceType ServiceType, nodeID int64) error {
+	return nil
+}
+
+// RefreshLease 序号分配没有租约
+func (k *KubernetesOrdinalAllocator) RefreshLease(ctx context.Context, serviceType ServiceType, nodeID int64) error {
+	return nil
+}
+
+// KubernetesLeaseConfig Lease分配器配置
+type KubernetesLeaseConfig struct {
+	// Client 为nil时使用Pod内ServiceAccount的in-cluster配置
+	Client kubernetes.Interface
+	// Namespace 默认取自ServiceAccount挂载的namespace文件或POD_NAMESPACE
+	Namespace string
+	// Identity 写入Lease的holderIdentity，默认取POD_NAME或生成的实例ID
+	Identity      string
+	LeaseDuration time.Duration
+	RenewInterval time.Duration
+}
+
+// KubernetesOption Lease分配器配置选项
+type KubernetesOption func(*KubernetesLeaseConfig)
+
+// WithKubernetesClient 设置访问API Server的客户端
+func WithKubernetesClient(client kubernetes.Interface) KubernetesOption {
+	return func(c *KubernetesLeaseConfig) {
+		c.Client = client
+	}
+}
+
+// WithKubernetesNamespace 设置创建Lease的命名空间
+func WithKubernetesNamespace(namespace string) KubernetesOption {
+	return func(c *KubernetesLeaseConfig) {
+		c.Namespace = namespace
+	}
+}
+
+// WithKubernetesIdentity 设置Lease持有者标识
+func WithKubernetesIdentity(identity string) KubernetesOption {
+	return func(c *KubernetesLeaseConfig) {
+		c.Identity = identity
+	}
+}
+
+// WithKubernetesLeaseDuration 设置Lease有效期和续约间隔
+func WithKubernetesLeaseDuration(duration, renewInterval time.Duration) KubernetesOption {
+	return func(c *KubernetesLeaseConfig) {
+		c.LeaseDuration = duration
+		c.RenewInterval = renewInterval
+	}
+}
+
+// KubernetesLeaseAllocator 基于coordination.k8s.io/v1 Lease的节点ID分配器。
+// 每个节点ID对应一个Lease，占用后由client-go的leaderelection续约；Pod异常
+// 退出后Lease过期，其节点ID可被其他Pod接管。
+type KubernetesLeaseAllocator struct {
+	client        kubernetes.Interface
+	namespace     string
+	identity      string
+	leaseDuration time.Duration
+	renewInterval time.Duration
+
+	// 当前持有的Lease
+	mu          sync.Mutex
+	held        string
+	nodeID      int64
+	serviceType ServiceType
+	onLost      LeaseLostHandler
+	closed      bool
+
+	stopRenew context.CancelFunc
+	renewDone chan struct{}
+}
+
+// NewKubernetesLeaseAllocator 创建Lease分配器，默认使用Pod内的ServiceAccount
+// 访问API Server。ServiceAccount需要在命名空间内对leases拥有get、list、
+// create和update权限。
+func NewKubernetesLeaseAllocator(opts ...KubernetesOption) (*KubernetesLeaseAllocator, error) {
+	config := &KubernetesLeaseConfig{
+		LeaseDuration: defaultLeaseTimeout,
+		RenewInterval: defaultRenewInterval,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// 续约持续失败到只剩一个续约间隔时放弃Lease，leaderelection要求这段时间
+	// 长于带抖动的续约间隔
+	if config.LeaseDuration < time.Second || config.RenewInterval <= 0 ||
+		float64(config.LeaseDuration-config.RenewInterval) <= leaderelection.JitterFactor*float64(config.RenewInterval) {
+		return nil, fmt.Errorf("lease duration must be at least 1s and more than %.1f times the renew interval", 1+leaderelection.JitterFactor)
+	}
+
+	if config.Client == nil {
+		restConfig, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("not running in a Kubernetes cluster: %w", err)
+		}
+		restConfig.Timeout = allocateTimeout
+		if config.Client, err = kubernetes.NewForConfig(restConfig); err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+		}
+	}
+
+	if config.Namespace == "" {
+		if data, err := os.ReadFile(serviceAccountDir + "/namespace"); err == nil {
+			config.Namespace = strings.TrimSpace(string(data))
+		} else {
+			config.Namespace = os.Getenv("POD_NAMESPACE")
+		}
+		if config.Namespace == "" {
+			return nil, fmt.Errorf("kubernetes namespace is unknown: mount a service account or set POD_NAMESPACE")
+		}
+	}
+
+	if config.Identity == "" {
+		config.Identity = os.Getenv("POD_NAME")
+		if config.Identity == "" {
+			config.Identity = generateInstanceID()
+		}
+	}
+
+	return &KubernetesLeaseAllocator{
+		client:        config.Client,
+		namespace:     config.Namespace,
+		identity:      config.Identity,
+		leaseDuration: config.LeaseDuration,
+		renewInterval: config.RenewInterval,
+	}, nil
+}
+
+// AllocateNodeID 占用服务号段内第一个空闲或已过期的Lease，并在leaderelection
+// 确认持有后返回
+func (k *KubernetesLeaseAllocator) AllocateNodeID(ctx context.Context, serviceType ServiceType) (int64, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.closed {
+		return 0, ErrAllocatorClosed
+	}
+	if k.held != "" {
+		return k.nodeID, nil
+	}
+
+	allocCtx, cancel := context.WithTimeout(ctx, allocateTimeout)
+	defer cancel()
+
+	list, err := k.leases().List(allocCtx, metav1.ListOptions{
+		LabelSelector: leaseServiceLabel + "=" + serviceType.String(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list leases: %w", err)
+	}
+	existing := make(map[string]*coordinationv1.Lease, len(list.Items))
+	for i := range list.Items {
+		existing[list.Items[i].Name] = &list.Items[i]
+	}
+
+	now := time.Now()
+	for instanceID := int64(0); instanceID < 1024; instanceID++ {
+		name := k.leaseName(serviceType, instanceID)
+		current, found := existing[name]
+		if found && !leaseExpired(current, now) {
+			continue
+		}
+
+		err := k.claim(allocCtx, serviceType, name, current)
+		if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+			// 其他Pod抢先占用
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to claim lease %s: %w", name, err)
+		}
+		if err := k.hold(allocCtx, name); err != nil {
+			return 0, fmt.Errorf("failed to hold lease %s: %w", name, err)
+		}
+
+		k.held = name
+		k.nodeID = int64(serviceType) + instanceID
+		k.serviceType = serviceType
+
+		log.Printf("Successfully allocated node ID %d for service %s (lease %s/%s)", k.nodeID, serviceType, k.namespace, name)
+		return k.nodeID, nil
+	}
+
+	return 0, fmt.Errorf("no available node ID in range [%d, %d] for service %s",
+		int64(serviceType), int64(serviceType)+1023, serviceType)
+}
+
+// claim 创建Lease，或在已过期时以resourceVersion为前提接管
+func (k *KubernetesLeaseAllocator) claim(ctx context.Context, serviceType ServiceType, name string, expired *coordinationv1.Lease) error {
+	now := metav1.Now()
+	record := resourcelock.LeaderElectionRecord{
+		HolderIdentity:       k.identity,
+		LeaseDurationSeconds: int(k.leaseDuration / time.Second),
+		AcquireTime:          now,
+		RenewTime:            now,
+	}
+
+	if expired == nil {
+		_, err := k.leases().Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{leaseServiceLabel: serviceType.String()},
+			},
+			Spec: resourcelock.LeaderElectionRecordToLeaseSpec(&record),
+		}, metav1.CreateOptions{})
+		return err
+	}
+
+	lease := expired.DeepCopy()
+	record.LeaderTransitions = resourcelock.LeaseSpecToLeaderElectionRecord(&lease.Spec).LeaderTransitions + 1
+	lease.Spec = resourcelock.LeaderElectionRecordToLeaseSpec(&record)
+	_, err := k.leases().Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// hold 启动leaderelection续约已占用的Lease，等到它确认持有为止。调用方需持有k.mu。
+// 续约持续失败到Lease可能过期时，leaderelection停止续约并报告Lease丢失。
+func (k *KubernetesLeaseAllocator) hold(ctx context.Context, name string) error {
+	renewCtx, stop := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Name: name, Namespace: k.namespace},
+			Client:     k.client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: k.identity},
+		},
+		LeaseDuration: k.leaseDuration,
+		RenewDeadline: k.leaseDuration - k.renewInterval,
+		RetryPeriod:   k.renewInterval,
+		Name:          name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) { close(started) },
+			OnStoppedLeading: func() {
+				if renewCtx.Err() == nil {
+					k.loseLease(name, fmt.Errorf("lease %s could not be renewed: %w", name, errLeaseLost))
+				}
+			},
+		},
+	})
+	if err != nil {
+		stop()
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.Run(renewCtx)
+	}()
+
+	select {
+	case <-started:
+		k.stopRenew, k.renewDone = stop, done
+		return nil
+	case <-ctx.Done():
+		stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// loseLease 在leaderelection停止续约后调用：清除持有状态并通知处理函数
+func (k *KubernetesLeaseAllocator) loseLease(name string, cause error) {
+	k.mu.Lock()
+	if k.held != name {
+		k.mu.Unlock()
+		return
+	}
+	serviceType, nodeID, handler := k.serviceType, k.nodeID, k.onLost
+	k.held = ""
+	k.nodeID = 0
+	k.stopRenew, k.renewDone = nil, nil
+	k.mu.Unlock()
+
+	log.Printf("Lost lease for node ID %d of service %s: %v", nodeID, serviceType, cause)
+	if handler != nil {
+		go handler(serviceType, nodeID, cause)
+	}
+}
+
+// OnLeaseLost 设置Lease丢失时的处理函数
+func (k *KubernetesLeaseAllocator) OnLeaseLost(handler LeaseLostHandler) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.onLost = handler
+}
+
+// ReleaseNodeID 停止续约并清空仍由本实例持有的Lease
+func (k *KubernetesLeaseAllocator) ReleaseNodeID(ctx context.Context, serviceType ServiceType, nodeID int64) error {
+	k.mu.Lock()
+	if k.held == "" || k.nodeID != nodeID {
+		k.mu.Unlock()
+		return nil // 已经释放或不是当前分配的ID
+	}
+	name, stop, done := k.held, k.stopRenew, k.renewDone
+	k.held = ""
+	k.nodeID = 0
+	k.stopRenew, k.renewDone = nil, nil
+	k.mu.Unlock()
+
+	stop()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// 以最新版本释放，而不是leaderelection缓存的Lease，后者在RefreshLease后已过时
+	err := k.updateHeld(ctx, name, func(lease *coordinationv1.Lease) {
+		record := resourcelock.LeaseSpecToLeaderElectionRecord(&lease.Spec)
+		now := metav1.Now()
+		lease.Spec = resourcelock.LeaderElectionRecordToLeaseSpec(&resourcelock.LeaderElectionRecord{
+			LeaseDurationSeconds: 1,
+			AcquireTime:          now,
+			RenewTime:            now,
+			LeaderTransitions:    record.LeaderTransitions,
+		})
+	})
+	switch {
+	case errors.Is(err, errLeaseLost):
+		log.Printf("Lease %s was taken over, leaving it in place", name)
+	case err != nil:
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+
+	log.Printf("Successfully released node ID %d for service %s", nodeID, serviceType)
+	return nil
+}
+
+// RefreshLease 立即续约（外部调用）。Lease已被接管或删除时返回errLeaseLost
+func (k *KubernetesLeaseAllocator) RefreshLease(ctx context.Context, serviceType ServiceType, nodeID int64) error {
+	k.mu.Lock()
+	name := k.held
+	k.mu.Unlock()
+	if name == "" {
+		return fmt.Errorf("no lease to renew")
+	}
+
+	return k.updateHeld(ctx, name, func(lease *coordinationv1.Lease) {
+		lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
+	})
+}
+
+// updateHeld 读取最新的Lease，确认仍由本实例持有后修改并写回；
+// 与续约goroutine冲突时重试
+func (k *KubernetesLeaseAllocator) updateHeld(ctx context.Context, name string, update func(*coordinationv1.Lease)) error {
+	for {
+		lease, err := k.leases().Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("lease %s was deleted: %w", name, errLeaseLost)
+		}
+		if err != nil {
+			return err
+		}
+		if holder := resourcelock.LeaseSpecToLeaderElectionRecord(&lease.Spec).HolderIdentity; holder != k.identity {
+			return fmt.Errorf("lease %s was taken over by %q: %w", name, holder, errLeaseLost)
+		}
+
+		update(lease)
+		if _, err = k.leases().Update(ctx, lease, metav1.UpdateOptions{}); !apierrors.IsConflict(err) {
+			return err
+		}
+	}
+}
+
+// Close 释放当前持有的节点ID，之后不再分配
+func (k *KubernetesLeaseAllocator) Close() error {
+	k.mu.Lock()
+	k.closed = true
+	allocated, serviceType, nodeID := k.held != "", k.serviceType, k.nodeID
+	k.mu.Unlock()
+
+	if !allocated {
+		return nil
+	}
+	return k.ReleaseNodeID(context.Background(), serviceType, nodeID)
+}
+
+// Ping 检查API Server是否可访问且有权读取Lease
+func (k *KubernetesLeaseAllocator) Ping(ctx context.Context) error {
+	if _, err := k.leases().List(ctx, metav1.ListOptions{Limit: 1}); err != nil {
+		return fmt.Errorf("kubernetes API unreachable: %w", err)
+	}
+	return nil
+}
+
+func (k *KubernetesLeaseAllocator) leases() coordinationv1client.LeaseInterface {
+	return k.client.CoordinationV1().Leases(k.namespace)
+}
+
+func (k *KubernetesLeaseAllocator) leaseName(serviceType ServiceType, instanceID int64) string {
+	return fmt.Sprintf("snowflake-%s-%d", serviceType, instanceID)
+}
+
+// leaseExpired 判断Lease是否无人持有或已超过有效期
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	record := resourcelock.LeaseSpecToLeaderElectionRecord(&lease.Spec)
+	if record.HolderIdentity == "" {
+		return true
+	}
+	if lease.Spec.RenewTime == nil {
+		// 无法判断时视为仍被持有
+		return false
+	}
+	return now.After(record.RenewTime.Add(time.Duration(record.LeaseDurationSeconds) * time.Second))
+}
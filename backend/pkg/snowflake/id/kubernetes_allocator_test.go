@@ -0,0 +1,253 @@
+package id
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestPodOrdinal(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+	host := func(name string) func() (string, error) {
+		return func() (string, error) { return name, nil }
+	}
+
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		host    string
+		want    int64
+		wantErr string
+	}{
+		{name: "pod index label", vars: map[string]string{"POD_ORDINAL": "7", "POD_NAME": "wonder-3"}, want: 7},
+		{name: "pod name", vars: map[string]string{"POD_NAME": "wonder-api-12"}, host: "other-1", want: 12},
+		{name: "hostname", host: "wonder-4", want: 4},
+		{name: "deployment pod", host: "wonder-7d9f8-abcde", wantErr: "no StatefulSet ordinal suffix"},
+		{name: "out of range", vars: map[string]string{"POD_ORDINAL": "1024"}, wantErr: "range [0, 1023]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := podOrdinal(env(tt.vars), host(tt.host))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// fakeLeaseAPI implements the subset of the coordination API the allocator
+// uses, including the optimistic locking the fake clientset lacks
+type fakeLeaseAPI struct {
+	mu      sync.Mutex
+	version int
+	leases  map[string]*coordinationv1.Lease
+}
+
+var leaseResource = schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}
+
+func (f *fakeLeaseAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const prefix = "/apis/coordination.k8s.io/v1/namespaces/wonder/leases"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		f.fail(w, apierrors.NewNotFound(leaseResource, r.URL.Path))
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		list := &coordinationv1.LeaseList{}
+		for _, lease := range f.leases {
+			list.Items = append(list.Items, *lease)
+		}
+		f.write(w, http.StatusOK, "LeaseList", list)
+	case r.Method == http.MethodGet:
+		if lease, ok := f.leases[name]; ok {
+			f.write(w, http.StatusOK, "Lease", lease)
+			return
+		}
+		f.fail(w, apierrors.NewNotFound(leaseResource, name))
+	case r.Method == http.MethodPost:
+		var lease coordinationv1.Lease
+		json.NewDecoder(r.Body).Decode(&lease)
+		if _, ok := f.leases[lease.Name]; ok {
+			f.fail(w, apierrors.NewAlreadyExists(leaseResource, lease.Name))
+			return
+		}
+		f.store(w, &lease, http.StatusCreated)
+	case r.Method == http.MethodPut:
+		var lease coordinationv1.Lease
+		json.NewDecoder(r.Body).Decode(&lease)
+		current, ok := f.leases[name]
+		if !ok {
+			f.fail(w, apierrors.NewNotFound(leaseResource, name))
+			return
+		}
+		if current.ResourceVersion != lease.ResourceVersion {
+			f.fail(w, apierrors.NewConflict(leaseResource, name, nil))
+			return
+		}
+		f.store(w, &lease, http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeLeaseAPI) store(w http.ResponseWriter, lease *coordinationv1.Lease, status int) {
+	f.version++
+	lease.Namespace = "wonder"
+	lease.ResourceVersion = strconv.Itoa(f.version)
+	f.leases[lease.Name] = lease
+	f.write(w, status, "Lease", lease)
+}
+
+func (f *fakeLeaseAPI) write(w http.ResponseWriter, status int, kind string, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	data, _ := json.Marshal(obj)
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	fields["apiVersion"], fields["kind"] = "coordination.k8s.io/v1", kind
+	json.NewEncoder(w).Encode(fields)
+}
+
+func (f *fakeLeaseAPI) fail(w http.ResponseWriter, err *apierrors.StatusError) {
+	status := err.Status()
+	status.APIVersion, status.Kind = "v1", "Status"
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(status.Code))
+	json.NewEncoder(w).Encode(status)
+}
+
+func (f *fakeLeaseAPI) holder(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if lease, ok := f.leases[name]; ok && lease.Spec.HolderIdentity != nil {
+		return *lease.Spec.HolderIdentity
+	}
+	return ""
+}
+
+func (f *fakeLeaseAPI) takeOver(name, holder string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.version++
+	f.leases[name].Spec.HolderIdentity = &holder
+	f.leases[name].ResourceVersion = strconv.Itoa(f.version)
+}
+
+func newTestLeaseAllocator(t *testing.T, server *httptest.Server, identity string) *KubernetesLeaseAllocator {
+	t.Helper()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL, ContentConfig: rest.ContentConfig{ContentType: "application/json"}})
+	require.NoError(t, err)
+	allocator, err := NewKubernetesLeaseAllocator(
+		WithKubernetesClient(client),
+		WithKubernetesNamespace("wonder"),
+		WithKubernetesIdentity(identity),
+		WithKubernetesLeaseDuration(time.Hour, 10*time.Minute),
+	)
+	require.NoError(t, err)
+	return allocator
+}
+
+func TestKubernetesLeaseAllocator(t *testing.T) {
+	api := &fakeLeaseAPI{leases: map[string]*coordinationv1.Lease{}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	ctx := context.Background()
+
+	// A lease abandoned by a crashed pod can be taken over
+	crashed, duration := "crashed-pod", int32(30)
+	api.leases["snowflake-order-1"] = &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "snowflake-order-1", ResourceVersion: "0"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &crashed,
+			LeaseDurationSeconds: &duration,
+			RenewTime:            &metav1.MicroTime{Time: time.Now().Add(-time.Hour)},
+		},
+	}
+
+	first := newTestLeaseAllocator(t, server, "pod-a")
+	second := newTestLeaseAllocator(t, server, "pod-b")
+	third := newTestLeaseAllocator(t, server, "pod-c")
+
+	nodeA, err := first.AllocateNodeID(ctx, ServiceTypeOrder)
+	require.NoError(t, err)
+	nodeB, err := second.AllocateNodeID(ctx, ServiceTypeOrder)
+	require.NoError(t, err)
+	nodeC, err := third.AllocateNodeID(ctx, ServiceTypeOrder)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(ServiceTypeOrder), nodeA)
+	assert.Equal(t, int64(ServiceTypeOrder)+1, nodeB)
+	assert.Equal(t, int64(ServiceTypeOrder)+2, nodeC)
+	assert.Equal(t, "pod-b", api.holder("snowflake-order-1"))
+
+	// Renewing keeps the lease through the API's optimistic locking
+	require.NoError(t, first.RefreshLease(ctx, ServiceTypeOrder, nodeA))
+	require.NoError(t, first.RefreshLease(ctx, ServiceTypeOrder, nodeA))
+
+	// Released IDs are handed out again
+	require.NoError(t, first.Close())
+	assert.Equal(t, "", api.holder("snowflake-order-0"))
+
+	again := newTestLeaseAllocator(t, server, "pod-d")
+	nodeD, err := again.AllocateNodeID(ctx, ServiceTypeOrder)
+	require.NoError(t, err)
+	assert.Equal(t, nodeA, nodeD)
+
+	for _, allocator := range []*KubernetesLeaseAllocator{second, third, again} {
+		require.NoError(t, allocator.Close())
+	}
+}
+
+func TestKubernetesLeaseAllocator_LostLease(t *testing.T) {
+	api := &fakeLeaseAPI{leases: map[string]*coordinationv1.Lease{}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	ctx := context.Background()
+
+	allocator := newTestLeaseAllocator(t, server, "pod-a")
+	nodeID, err := allocator.AllocateNodeID(ctx, ServiceTypeUser)
+	require.NoError(t, err)
+
+	// Another pod took the lease over while this one was partitioned
+	api.takeOver("snowflake-user-0", "pod-b")
+
+	err = allocator.RefreshLease(ctx, ServiceTypeUser, nodeID)
+	assert.ErrorContains(t, err, `taken over by "pod-b"`)
+
+	// Releasing must not clear the other pod's lease
+	require.NoError(t, allocator.ReleaseNodeID(ctx, ServiceTypeUser, nodeID))
+	assert.Equal(t, "pod-b", api.holder("snowflake-user-0"))
+}
+
+func TestNewKubernetesLeaseAllocator_RenewInterval(t *testing.T) {
+	_, err := NewKubernetesLeaseAllocator(
+		WithKubernetesNamespace("wonder"),
+		WithKubernetesLeaseDuration(30*time.Second, 20*time.Second),
+	)
+	assert.ErrorContains(t, err, "renew interval")
+}
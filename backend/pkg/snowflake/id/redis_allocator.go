@@ -0,0 +1,338 @@
+// pkg/snowflake/id/redis_allocator.go - Redis-based dynamic node ID allocation
+package id
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+// redis key前缀，完整的键为 <prefix>:{<service>}:node:<n>
+const defaultRedisKeyPrefix = "snowflake"
+
+// 节点键以服务类型作为hash tag，同一服务的所有键位于同一个slot，
+// 因此脚本在Redis Cluster中也可以原子地访问整个号段
+var (
+	// redisClaimScript 占用号段内第一个不存在的节点键，返回序号，号段已满时返回-1
+	// KEYS[1] 节点键前缀  ARGV[1] 实例标识  ARGV[2] 租约毫秒数  ARGV[3] 号段大小
+	redisClaimScript = redis.NewScript(`
+for i = 0, tonumber(ARGV[3]) - 1 do
+	if redis.call('SET', KEYS[1] .. i, ARGV[1], 'NX', 'PX', ARGV[2]) then
+		return i
+	end
+end
+return -1`)
+
+	// redisRenewScript 仍由本实例持有时续租；键已过期且未被占用时重新占用。
+	// 被其他实例接管时返回0
+	// KEYS[1] 节点键  ARGV[1] 实例标识  ARGV[2] 租约毫秒数
+	redisRenewScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if holder == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if not holder then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+return 0`)
+
+	// redisReleaseScript 仅删除由本实例持有的节点键
+	// KEYS[1] 节点键  ARGV[1] 实例标识
+	redisReleaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+)
+
+// RedisAllocatorConfig Redis分配器配置
+type RedisAllocatorConfig struct {
+	KeyPrefix     string
+	LeaseTimeout  time.Duration
+	RenewInterval time.Duration
+}
+
+// RedisOption Redis分配器配置选项
+type RedisOption func(*RedisAllocatorConfig)
+
+// WithRedisKeyPrefix 设置节点键前缀，多个部署共用一个Redis时用于隔离
+func WithRedisKeyPrefix(prefix string) RedisOption {
+	return func(c *RedisAllocatorConfig) {
+		c.KeyPrefix = prefix
+	}
+}
+
+// WithRedisLeaseDuration 设置租约有效期和续租间隔
+func WithRedisLeaseDuration(timeout, renewInterval time.Duration) RedisOption {
+	return func(c *RedisAllocatorConfig) {
+		c.LeaseTimeout = timeout
+		c.RenewInterval = renewInterval
+	}
+}
+
+// RedisAllocator 基于Redis的节点ID分配器。每个节点ID对应一个带TTL的键，
+// 值为持有者的实例标识；持有者定期续租，实例异常退出后键过期，
+// 其节点ID可被其他实例占用。
+type RedisAllocator struct {
+	client        *redis.Client
+	keyPrefix     string
+	identity      string
+	leaseTimeout  time.Duration
+	renewInterval time.Duration
+
+	// 当前分配的nodeID
+	mu          sync.Mutex
+	nodeID      int64
+	serviceType ServiceType
+	isAllocated bool
+	lastRenew   time.Time
+	onLost      LeaseLostHandler
+	closed      bool
+
+	renewCancel context.CancelFunc
+	renewDone   chan struct{}
+}
+
+// NewRedisAllocator 创建Redis分配器。client由调用方管理，Close不会关闭它
+func NewRedisAllocator(client *redis.Client, opts ...RedisOption) (*RedisAllocator, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client cannot be nil")
+	}
+
+	config := &RedisAllocatorConfig{
+		KeyPrefix:     defaultRedisKeyPrefix,
+		LeaseTimeout:  defaultLeaseTimeout,
+		RenewInterval: defaultRenewInterval,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.LeaseTimeout < time.Millisecond || config.RenewInterval <= 0 || config.RenewInterval >= config.LeaseTimeout {
+		return nil, fmt.Errorf("lease renew interval must be positive and shorter than the lease timeout")
+	}
+
+	return &RedisAllocator{
+		client:        client,
+		keyPrefix:     config.KeyPrefix,
+		identity:      generateInstanceID(),
+		leaseTimeout:  config.LeaseTimeout,
+		renewInterval: config.RenewInterval,
+	}, nil
+}
+
+// AllocateNodeID 原子地占用服务号段内第一个空闲的节点ID
+func (r *RedisAllocator) AllocateNodeID(ctx context.Context, serviceType ServiceType) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, ErrAllocatorClosed
+	}
+	if r.isAllocated {
+		return r.nodeID, nil
+	}
+
+	claimedAt := time.Now()
+	allocCtx, cancel := context.WithTimeout(ctx, allocateTimeout)
+	defer cancel()
+
+	reply, err := redisClaimScript.Run(allocCtx, r.client,
+		[]string{r.nodeKeyPrefix(serviceType)}, r.identity, r.leaseMillis(), "1024")
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim node ID: %w", err)
+	}
+	instanceID, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected claim reply %T", reply)
+	}
+	if instanceID < 0 {
+		return 0, fmt.Errorf("no available node ID in range [%d, %d] for service %s",
+			int64(serviceType), int64(serviceType)+1023, serviceType)
+	}
+
+	r.nodeID = int64(serviceType) + instanceID
+	r.serviceType = serviceType
+	r.isAllocated = true
+	r.lastRenew = claimedAt
+	r.startRenewLease()
+
+	log.Printf("Successfully allocated node ID %d for service %s", r.nodeID, serviceType)
+	return r.nodeID, nil
+}
+
+// startRenewLease 启动续租，调用方需持有r.mu
+func (r *RedisAllocator) startRenewLease() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	r.renewCancel, r.renewDone = cancel, done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(r.renewInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.renew(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("Failed to renew lease for node %d: %v", r.currentNodeID(), err)
+					if r.leaseLost(err) {
+						r.loseLease(err)
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+func (r *RedisAllocator) currentNodeID() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.nodeID
+}
+
+func (r *RedisAllocator) leaseLost(err error) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return leaseLost(err, r.lastRenew, r.leaseTimeout, r.renewInterval)
+}
+
+// loseLease 在续租goroutine中调用：清除分配状态并通知处理函数
+func (r *RedisAllocator) loseLease(cause error) {
+	r.mu.Lock()
+	if !r.isAllocated {
+		r.mu.Unlock()
+		return
+	}
+	serviceType, nodeID, handler := r.serviceType, r.nodeID, r.onLost
+	r.renewCancel()
+	r.nodeID = 0
+	r.isAllocated = false
+	r.renewCancel, r.renewDone = nil, nil
+	r.mu.Unlock()
+
+	log.Printf("Lost lease for node ID %d of service %s: %v", nodeID, serviceType, cause)
+	if handler != nil {
+		go handler(serviceType, nodeID, cause)
+	}
+}
+
+// OnLeaseLost 设置租约丢失时的处理函数
+func (r *RedisAllocator) OnLeaseLost(handler LeaseLostHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onLost = handler
+}
+
+// renew 延长节点键的TTL
+func (r *RedisAllocator) renew(ctx context.Context) error {
+	r.mu.Lock()
+	allocated, key := r.isAllocated, r.nodeKey(r.serviceType, r.nodeID)
+	r.mu.Unlock()
+
+	if !allocated {
+		return fmt.Errorf("no lease to renew")
+	}
+
+	renewedAt := time.Now()
+	renewed, err := redisRenewScript.Run(ctx, r.client, []string{key}, r.identity, r.leaseMillis())
+	if err != nil {
+		return err
+	}
+	if renewed == int64(0) {
+		return fmt.Errorf("node key %s was taken over by another instance: %w", key, errLeaseLost)
+	}
+
+	r.mu.Lock()
+	r.lastRenew = renewedAt
+	r.mu.Unlock()
+	return nil
+}
+
+// ReleaseNodeID 停止续租并删除节点键
+func (r *RedisAllocator) ReleaseNodeID(ctx context.Context, serviceType ServiceType, nodeID int64) error {
+	r.mu.Lock()
+	if !r.isAllocated || r.nodeID != nodeID {
+		r.mu.Unlock()
+		return nil // 已经释放或不是当前分配的ID
+	}
+	cancel, done := r.renewCancel, r.renewDone
+	r.mu.Unlock()
+
+	// 停止续租，续租goroutine需要r.mu，因此在锁外等待
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.isAllocated {
+		return nil
+	}
+	key := r.nodeKey(r.serviceType, r.nodeID)
+	r.nodeID = 0
+	r.isAllocated = false
+	r.renewCancel, r.renewDone = nil, nil
+
+	// 仅删除仍由本实例持有的键，避免删除已被他人接管的节点ID
+	deleted, err := redisReleaseScript.Run(ctx, r.client, []string{key}, r.identity)
+	if err != nil {
+		return fmt.Errorf("failed to release node key %s: %w", key, err)
+	}
+	if deleted == int64(0) {
+		log.Printf("Node key %s was taken over, leaving it in place", key)
+	}
+
+	log.Printf("Successfully released node ID %d for service %s", nodeID, serviceType)
+	return nil
+}
+
+// RefreshLease 立即续租（外部调用）
+func (r *RedisAllocator) RefreshLease(ctx context.Context, serviceType ServiceType, nodeID int64) error {
+	return r.renew(ctx)
+}
+
+// Close 释放当前持有的节点ID，之后不再分配
+func (r *RedisAllocator) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	allocated, serviceType, nodeID := r.isAllocated, r.serviceType, r.nodeID
+	r.mu.Unlock()
+
+	if !allocated {
+		return nil
+	}
+	return r.ReleaseNodeID(context.Background(), serviceType, nodeID)
+}
+
+// Ping 检查Redis连通性
+func (r *RedisAllocator) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx); err != nil {
+		return fmt.Errorf("redis unreachable: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisAllocator) leaseMillis() string {
+	return strconv.FormatInt(r.leaseTimeout.Milliseconds(), 10)
+}
+
+func (r *RedisAllocator) nodeKeyPrefix(serviceType ServiceType) string {
+	return fmt.Sprintf("%s:{%s}:node:", r.keyPrefix, serviceType)
+}
+
+func (r *RedisAllocator) nodeKey(serviceType ServiceType, nodeID int64) string {
+	return r.nodeKeyPrefix(serviceType) + strconv.FormatInt(nodeID-int64(serviceType), 10)
+}
@@ -0,0 +1,131 @@
+package id
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/pkg/redis"
+)
+
+func TestRedisAllocator(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	first, err := NewRedisAllocator(client, WithRedisLeaseDuration(time.Hour, 30*time.Minute))
+	require.NoError(t, err)
+	second, err := NewRedisAllocator(client, WithRedisLeaseDuration(time.Hour, 30*time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, first.Ping(ctx))
+
+	nodeID, err := first.AllocateNodeID(ctx, ServiceTypeOrder)
+	require.NoError(t, err)
+	assert.Equal(t, int64(ServiceTypeOrder), nodeID)
+
+	// Allocation is idempotent
+	again, err := first.AllocateNodeID(ctx, ServiceTypeOrder)
+	require.NoError(t, err)
+	assert.Equal(t, nodeID, again)
+
+	otherID, err := second.AllocateNodeID(ctx, ServiceTypeOrder)
+	require.NoError(t, err)
+	assert.Equal(t, int64(ServiceTypeOrder)+1, otherID)
+
+	holder, err := srv.Get("snowflake:{order}:node:0")
+	require.NoError(t, err)
+	assert.Equal(t, first.identity, holder)
+	require.NoError(t, first.RefreshLease(ctx, ServiceTypeOrder, nodeID))
+
+	// A released node ID is free for the next instance
+	require.NoError(t, first.Close())
+	assert.False(t, srv.Exists("snowflake:{order}:node:0"))
+
+	third, err := NewRedisAllocator(client)
+	require.NoError(t, err)
+	reused, err := third.AllocateNodeID(ctx, ServiceTypeOrder)
+	require.NoError(t, err)
+	assert.Equal(t, nodeID, reused)
+
+	require.NoError(t, second.Close())
+	require.NoError(t, third.Close())
+}
+
+func TestRedisAllocator_LostLease(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	first, err := NewRedisAllocator(client, WithRedisLeaseDuration(50*time.Millisecond, 40*time.Millisecond))
+	require.NoError(t, err)
+	nodeID, err := first.AllocateNodeID(ctx, ServiceTypeUser)
+	require.NoError(t, err)
+
+	// Stop renewing so the key expires, then let another instance take it
+	first.renewCancel()
+	<-first.renewDone
+	srv.FastForward(60 * time.Millisecond)
+
+	second, err := NewRedisAllocator(client)
+	require.NoError(t, err)
+	takenOver, err := second.AllocateNodeID(ctx, ServiceTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, nodeID, takenOver)
+
+	assert.ErrorContains(t, first.RefreshLease(ctx, ServiceTypeUser, nodeID), "taken over")
+
+	// Releasing must not delete the new holder's key
+	first.renewCancel = nil
+	require.NoError(t, first.Close())
+	holder, err := srv.Get("snowflake:{user}:node:0")
+	require.NoError(t, err)
+	assert.Equal(t, second.identity, holder)
+
+	require.NoError(t, second.Close())
+}
+
+func TestSnowflakeGenerator_ReallocatesOnLeaseLoss(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(redis.Options{Addr: srv.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	allocator, err := NewRedisAllocator(client, WithRedisLeaseDuration(time.Second, 20*time.Millisecond))
+	require.NoError(t, err)
+	defer allocator.Close()
+
+	gen, err := NewSnowflakeGeneratorWithAllocator(ctx, ServiceTypeUser, allocator)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), gen.GetNodeID())
+
+	// Another instance takes over node ID 0
+	_, err = client.Do(ctx, "SET", "snowflake:{user}:node:0", "intruder", "PX", "60000")
+	require.NoError(t, err)
+
+	sf := gen.(*SnowflakeGenerator)
+	require.Eventually(t, func() bool {
+		return gen.GetNodeID() == 1 && sf.LeaseError() == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	decoded, err := Decode(gen.Generate(), ServiceTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), decoded.NodeID)
+
+	holder, err := srv.Get("snowflake:{user}:node:1")
+	require.NoError(t, err)
+	assert.Equal(t, allocator.identity, holder)
+}
+
+func TestLeaseLost(t *testing.T) {
+	now := time.Now()
+	assert.True(t, leaseLost(errLeaseLost, now, 30*time.Second, 10*time.Second))
+	assert.False(t, leaseLost(assert.AnError, now.Add(-15*time.Second), 30*time.Second, 10*time.Second))
+	// The lease could expire before the next renewal
+	assert.True(t, leaseLost(assert.AnError, now.Add(-20*time.Second), 30*time.Second, 10*time.Second))
+}
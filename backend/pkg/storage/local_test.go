@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir(), "/api/v1/objects/", []byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, s.Put(ctx, "avatars/a.png", strings.NewReader("png"), 3, "image/png"))
+	assert.Error(t, s.Put(ctx, "avatars/short.png", strings.NewReader("pn"), 3, "image/png"), "size mismatch")
+	_, err = os.Stat(s.path("avatars/short.png"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	raw, err := s.PresignGet(ctx, "avatars/a.png", time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/objects/avatars/a.png", u.Path)
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+
+	f, err := s.Open("avatars/a.png", expires, signature)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(f)
+	f.Close()
+	assert.Equal(t, "png", string(body))
+
+	_, err = s.Open("avatars/b.png", expires, signature)
+	assert.ErrorIs(t, err, ErrInvalidSignature, "signature is bound to the key")
+	_, err = s.Open("avatars/a.png", expires+"0", signature)
+	assert.ErrorIs(t, err, ErrInvalidSignature, "signature is bound to the expiry")
+
+	now = now.Add(2 * time.Minute)
+	_, err = s.Open("avatars/a.png", expires, signature)
+	assert.ErrorIs(t, err, ErrInvalidSignature, "expired")
+
+	require.NoError(t, s.Delete(ctx, "avatars/a.png"))
+	require.NoError(t, s.Delete(ctx, "avatars/a.png"), "deleting a missing object")
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"a", "avatars/0f3a.jpg", "x/y/z_1-2.png"} {
+		assert.NoError(t, ValidateKey(key), key)
+	}
+	for _, key := range []string{"", "/a", "a/", "a//b", "../a", "a/./b", "a b", "a?b", "ä"} {
+		assert.ErrorIs(t, ValidateKey(key), ErrInvalidKey, key)
+	}
+}
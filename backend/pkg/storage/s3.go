@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cctw-zed/wonder/pkg/awssig"
+)
+
+// s3MaxPresignTTL is the longest validity S3 accepts for a presigned URL
+const s3MaxPresignTTL = 7 * 24 * time.Hour
+
+// S3Config configures an S3 bucket or a bucket of a compatible store such
+// as MinIO
+type S3Config struct {
+	// Endpoint is the store's base URL, e.g. https://s3.eu-west-1.amazonaws.com
+	// or http://minio:9000
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set with temporary credentials
+	SessionToken string
+	// PathStyle addresses the bucket as the first path segment instead of
+	// a subdomain, as MinIO expects
+	PathStyle bool
+}
+
+// S3Storage keeps objects in an S3 bucket, signing requests with AWS
+// Signature Version 4. Uploads are streamed with an unsigned payload; TLS
+// protects them in transit.
+type S3Storage struct {
+	cfg        S3Config
+	signer     awssig.Signer
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3Storage creates an S3 object store. A nil httpClient uses
+// http.DefaultClient.
+func NewS3Storage(cfg S3Config, httpClient *http.Client) (*S3Storage, error) {
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint %q must be an http or https URL", cfg.Endpoint)
+	}
+	if cfg.Region == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 region and bucket are required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 access key id and secret access key are required")
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	signer := awssig.Signer{
+		Credentials: awssig.Credentials{
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			SessionToken:    cfg.SessionToken,
+		},
+		Region:  cfg.Region,
+		Service: "s3",
+	}
+	return &S3Storage{cfg: cfg, signer: signer, endpoint: endpoint, httpClient: httpClient, now: time.Now}, nil
+}
+
+// Put implements ObjectStorage
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	return s.do(req)
+}
+
+// Delete implements ObjectStorage. S3 answers deletes of missing objects
+// with success.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req)
+}
+
+// PresignGet implements ObjectStorage. Validity is capped at seven days.
+func (s *S3Storage) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	ttl = min(ttl, s3MaxPresignTTL)
+	return s.signer.Presign(http.MethodGet, s.objectURL(key), ttl, s.now()).String(), nil
+}
+
+// do signs and sends req, failing on any reply but 2xx
+func (s *S3Storage) do(req *http.Request) error {
+	req.Header.Set("X-Amz-Content-Sha256", awssig.UnsignedPayload)
+	s.signer.Sign(req, awssig.UnsignedPayload, s.now())
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("s3 %s %s returned status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// objectURL addresses key in the bucket. Keys are limited to characters
+// that need no escaping, so the path is the same escaped or not.
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	if s.cfg.PathStyle {
+		u.Path = u.Path + "/" + s.cfg.Bucket + "/" + key
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = u.Path + "/" + key
+	}
+	return &u
+}
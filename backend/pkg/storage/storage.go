@@ -0,0 +1,48 @@
+// Package storage keeps binary objects, such as user avatars, on local disk
+// or in an S3-compatible object store and hands out time-limited URLs to
+// read them.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrInvalidKey is returned for keys that are not relative slash-separated
+// paths of letters, digits, '-', '_' and '.'
+var ErrInvalidKey = errors.New("invalid object key")
+
+// ObjectStorage stores objects under slash-separated keys
+type ObjectStorage interface {
+	// Put stores size bytes of body under key, replacing any object there
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Delete removes the object under key. Deleting a missing object is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+	// PresignGet returns a URL that reads the object under key without
+	// further credentials until ttl has passed
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// ValidateKey rejects keys that could escape the storage root or need
+// escaping in URLs
+func ValidateKey(key string) error {
+	if key == "" || len(key) > 1024 || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return fmt.Errorf("%w %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w %q", ErrInvalidKey, key)
+		}
+		for _, r := range segment {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+				return fmt.Errorf("%w %q", ErrInvalidKey, key)
+			}
+		}
+	}
+	return nil
+}
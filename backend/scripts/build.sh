@@ -19,7 +19,7 @@ NC='\033[0m' # No Color
 # Project information
 PROJECT_NAME="wonder"
 VERSION=${VERSION:-"1.0.0"}
-BUILD_TIME=$(date -u '+%Y-%m-%d_%H:%M:%S_UTC')
+BUILD_TIME=$(date -u '+%Y-%m-%dT%H:%M:%SZ')
 GIT_COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo "unknown")
 
 # Build directories
@@ -39,8 +39,9 @@ echo ""
 mkdir -p $BIN_DIR
 mkdir -p $BUILD_DIR
 
-# Build flags
-LDFLAGS="-X main.version=${VERSION} -X main.buildTime=${BUILD_TIME} -X main.gitCommit=${GIT_COMMIT}"
+# Build flags, stamped into pkg/buildinfo like the Makefile does
+BUILDINFO="github.com/cctw-zed/wonder/pkg/buildinfo"
+LDFLAGS="-X ${BUILDINFO}.Version=${VERSION} -X ${BUILDINFO}.BuildTime=${BUILD_TIME} -X ${BUILDINFO}.GitCommit=${GIT_COMMIT}"
 
 # Function to build for specific platform
 build_server() {
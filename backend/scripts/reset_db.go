@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/cctw-zed/wonder/internal/infrastructure/database"
@@ -18,20 +19,19 @@ func main() {
 	// Create migrator
 	migrator := database.NewMigrator(conn.DB())
 
-	// Drop all tables
-	if err := migrator.DropAll(); err != nil {
+	ctx := context.Background()
+
+	// Roll back every migration
+	if err := migrator.DropAll(ctx); err != nil {
 		log.Fatalf("Failed to drop tables: %v", err)
 	}
 	log.Println("✅ Dropped all tables")
 
 	// Run migrations
-	if err := migrator.MigrateAll(); err != nil {
+	if err := migrator.Up(ctx); err != nil {
 		log.Fatalf("Failed to migrate: %v", err)
 	}
 	log.Println("✅ Created tables with new schema")
 
-	// Indexes are created automatically by GORM via model tags
-	log.Println("✅ Indexes created automatically via model tags")
-
 	log.Println("🎉 Database reset completed successfully!")
 }
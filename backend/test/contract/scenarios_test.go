@@ -0,0 +1,284 @@
+package contract
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cctw-zed/wonder/internal/domain/audit"
+	"github.com/cctw-zed/wonder/internal/domain/notification"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/testutil/contract"
+	"github.com/cctw-zed/wonder/internal/testutil/servertest"
+	"github.com/cctw-zed/wonder/pkg/totp"
+)
+
+// password is the password of every account the scenarios create
+const password = "Str0ng!Passw0rd"
+
+type object = map[string]interface{}
+
+// register creates an account and signs it in, returning its ID and
+// access token
+func register(t *testing.T, s *contract.Session, email, name string) (string, string) {
+	t.Helper()
+	res := s.Do("register "+name, http.MethodPost, "/api/v1/users/register", object{"email": email, "name": name, "password": password})
+	require.Equal(t, http.StatusCreated, res.Status, string(res.Body))
+	return res.Get("data.id"), login(t, s, email)
+}
+
+// login signs in and returns the access token
+func login(t *testing.T, s *contract.Session, email string) string {
+	t.Helper()
+	res := s.Do("login "+email, http.MethodPost, "/api/v1/auth/login", object{"email": email, "password": password})
+	require.Equal(t, http.StatusOK, res.Status, string(res.Body))
+	return res.Get("data.access_token")
+}
+
+func publicScenario(t *testing.T, _ *servertest.Server, s *contract.Session) {
+	s.Do("health", http.MethodGet, "/health", nil)
+	s.Do("liveness", http.MethodGet, "/healthz", nil)
+	s.Do("readiness", http.MethodGet, "/readyz", nil)
+	s.Do("token verification keys", http.MethodGet, "/.well-known/jwks.json", nil)
+	s.Do("build", http.MethodGet, "/api/v1/version", nil)
+	s.Do("error catalog", http.MethodGet, "/api/v1/errors", nil)
+	s.Do("error code", http.MethodGet, "/api/v1/errors/ENTITY_NOT_FOUND", nil)
+	s.Do("unknown error code", http.MethodGet, "/api/v1/errors/NO_SUCH_CODE", nil)
+	s.Do("unknown route", http.MethodGet, "/api/v1/nothing-here", nil)
+	s.Do("unsupported method", http.MethodDelete, "/api/v1/version", nil)
+	s.Do("setup status", http.MethodGet, "/api/v1/setup/status", nil)
+}
+
+func usersScenario(t *testing.T, _ *servertest.Server, s *contract.Session) {
+	s.Do("register with invalid fields", http.MethodPost, "/api/v1/users/register", object{"email": "not-an-email", "name": "A"})
+	adaID, ada := register(t, s, "ada@example.com", "Ada Lovelace")
+	s.Do("register taken email", http.MethodPost, "/api/v1/users/register", object{"email": "ada@example.com", "name": "Ada", "password": password})
+	s.Do("login with wrong password", http.MethodPost, "/api/v1/auth/login", object{"email": "ada@example.com", "password": "wrong-password"})
+	_, grace := register(t, s, "grace@example.com", "Grace Hopper")
+
+	s.Do("current user", http.MethodGet, "/api/v1/auth/me", nil, contract.Bearer(ada))
+	s.Do("own profile without token", http.MethodGet, "/api/v1/users/me", nil)
+	s.Do("own profile", http.MethodGet, "/api/v1/users/me", nil, contract.Bearer(ada))
+	s.Do("update own profile", http.MethodPut, "/api/v1/users/me", object{"handle": "ada", "timezone": "Europe/London"}, contract.Bearer(ada))
+	s.Do("merge patch own profile", http.MethodPatch, "/api/v1/users/me", contract.Raw{ContentType: "application/merge-patch+json", Data: []byte(`{"locale":"en-GB"}`)}, contract.Bearer(ada))
+	s.Do("handle taken", http.MethodGet, "/api/v1/users/handle-availability?handle=ada", nil)
+	s.Do("handle free", http.MethodGet, "/api/v1/users/handle-availability?handle=babbage", nil)
+	s.Do("profile by handle", http.MethodGet, "/api/v1/users/by-handle/ada", nil, contract.Bearer(grace))
+	s.Do("list users", http.MethodGet, "/api/v1/users?page=1&page_size=10", nil, contract.Bearer(grace))
+	s.Do("search users", http.MethodGet, "/api/v1/users/search?q=ada", nil, contract.Bearer(grace))
+	profile := s.Do("profile", http.MethodGet, "/api/v1/users/"+adaID, nil, contract.Bearer(ada))
+	s.Do("unchanged profile", http.MethodGet, "/api/v1/users/"+adaID, nil, contract.Bearer(ada), contract.Header("If-None-Match", profile.Header.Get("ETag")))
+	s.Do("profile of unknown user", http.MethodGet, "/api/v1/users/404404404404404404", nil, contract.Bearer(ada))
+	s.Do("update profile", http.MethodPut, "/api/v1/users/"+adaID, object{"name": "Augusta Ada King"}, contract.Bearer(ada))
+	s.Do("patch profile", http.MethodPatch, "/api/v1/users/"+adaID, contract.Raw{ContentType: "application/json-patch+json", Data: []byte(`[{"op":"replace","path":"/name","value":"Ada King"}]`)}, contract.Bearer(ada))
+
+	s.Do("change own password", http.MethodPatch, "/api/v1/users/me/password", object{"old_password": password, "new_password": password + "2"}, contract.Bearer(ada))
+	s.Do("change password", http.MethodPut, "/api/v1/users/"+adaID+"/password", object{"old_password": password + "2", "new_password": password}, contract.Bearer(ada))
+	s.Do("change password with wrong old password", http.MethodPut, "/api/v1/users/"+adaID+"/password", object{"old_password": "wrong-password", "new_password": password}, contract.Bearer(ada))
+
+	s.Do("schedule own deletion", http.MethodDelete, "/api/v1/users/me", nil, contract.Bearer(ada))
+	s.Do("cancel own deletion", http.MethodPost, "/api/v1/users/me/deletion/cancel", nil, contract.Bearer(ada))
+
+	s.Do("logout", http.MethodPost, "/api/v1/auth/logout", nil, contract.Bearer(grace))
+	s.Do("schedule own deletion by ID", http.MethodDelete, "/api/v1/users/"+adaID, nil, contract.Bearer(ada))
+	s.Do("profile pending deletion", http.MethodGet, "/api/v1/users/"+adaID, nil, contract.Bearer(ada))
+}
+
+// setupToken claims the bootstrap admin in the admin scenario
+const setupToken = "contract-setup-token-0123456789"
+
+// withBootstrapAdmin configures the account the admin scenario claims
+func withBootstrapAdmin(cfg *config.Config) {
+	cfg.Bootstrap.AdminEmail = "admin@example.com"
+	cfg.Bootstrap.AdminName = "Site Admin"
+	cfg.Bootstrap.SetupToken = setupToken
+}
+
+func adminScenario(t *testing.T, srv *servertest.Server, s *contract.Session) {
+	s.Do("setup status", http.MethodGet, "/api/v1/setup/status", nil)
+	s.Do("claim admin with wrong token", http.MethodPost, "/api/v1/setup/admin", object{"setup_token": "wrong-setup-token-0123456789", "password": password})
+	res := s.Do("claim admin", http.MethodPost, "/api/v1/setup/admin", object{"setup_token": setupToken, "password": password})
+	require.Equal(t, http.StatusCreated, res.Status, string(res.Body))
+	s.Do("claim admin again", http.MethodPost, "/api/v1/setup/admin", object{"setup_token": setupToken, "password": password})
+	admin := login(t, s, "admin@example.com")
+	userID, user := register(t, s, "ada@example.com", "Ada Lovelace")
+
+	s.Do("administration as a user", http.MethodGet, "/api/v1/admin/stats", nil, contract.Bearer(user))
+	s.Do("suspend user", http.MethodPost, "/api/v1/admin/users/"+userID+"/suspend", object{"reason": "testing"}, contract.Bearer(admin))
+	s.Do("login while suspended", http.MethodPost, "/api/v1/auth/login", object{"email": "ada@example.com", "password": password})
+	s.Do("reactivate user", http.MethodPost, "/api/v1/admin/users/"+userID+"/reactivate", nil, contract.Bearer(admin))
+	s.Do("deactivate user", http.MethodPost, "/api/v1/admin/users/"+userID+"/deactivate", object{"reason": "testing"}, contract.Bearer(admin))
+	s.Do("suspend deactivated user", http.MethodPost, "/api/v1/admin/users/"+userID+"/suspend", nil, contract.Bearer(admin))
+	s.Do("reactivate deactivated user", http.MethodPost, "/api/v1/admin/users/"+userID+"/reactivate", nil, contract.Bearer(admin))
+	s.Do("decode ID", http.MethodGet, "/api/v1/admin/ids/"+userID+"/decode", nil, contract.Bearer(admin))
+	s.Do("decode invalid ID", http.MethodGet, "/api/v1/admin/ids/not-an-id/decode", nil, contract.Bearer(admin))
+	s.Do("export users", http.MethodGet, "/api/v1/admin/users/export?format=ndjson&email=ada@example.com", nil, contract.Bearer(admin))
+	s.Do("import users", http.MethodPost, "/api/v1/admin/users/import", contract.Raw{
+		ContentType: "text/csv",
+		Data:        []byte("email,name,password\ngrace@example.com,Grace Hopper," + password + "\nada@example.com,Ada Lovelace," + password + "\n"),
+	}, contract.Bearer(admin))
+	s.Do("import users as JSON", http.MethodPost, "/api/v1/admin/users/import", object{"email": "x@example.com"}, contract.Bearer(admin))
+	s.Do("statistics", http.MethodGet, "/api/v1/admin/stats?days=7", nil, contract.Bearer(admin), contract.ShapeOnly())
+
+	// Audit entries are written in the background
+	require.Eventually(t, func() bool {
+		var count int64
+		srv.DB.Model(&audit.Entry{}).Where("action = ?", audit.ActionSuspend).Count(&count)
+		return count > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Do("audit log", http.MethodGet, "/api/v1/admin/audit-logs?action="+audit.ActionSuspend, nil, contract.Bearer(admin))
+
+	tenant := s.Do("create tenant", http.MethodPost, "/api/v1/admin/tenants", object{"slug": "acme", "name": "Acme"}, contract.Bearer(admin))
+	s.Do("create taken tenant", http.MethodPost, "/api/v1/admin/tenants", object{"slug": "acme", "name": "Acme"}, contract.Bearer(admin))
+	s.Do("list tenants", http.MethodGet, "/api/v1/admin/tenants", nil, contract.Bearer(admin))
+	s.Do("tenant", http.MethodGet, "/api/v1/admin/tenants/"+tenant.Get("data.id"), nil, contract.Bearer(admin))
+	s.Do("delete tenant", http.MethodDelete, "/api/v1/admin/tenants/"+tenant.Get("data.id"), nil, contract.Bearer(admin))
+	s.Do("deleted tenant", http.MethodGet, "/api/v1/admin/tenants/"+tenant.Get("data.id"), nil, contract.Bearer(admin))
+
+	s.Do("log level", http.MethodGet, "/api/v1/admin/log-level", nil, contract.Bearer(admin))
+	s.Do("raise log level", http.MethodPut, "/api/v1/admin/log-level", object{"level": "debug", "duration": "10m"}, contract.Bearer(admin))
+	s.Do("set invalid log level", http.MethodPut, "/api/v1/admin/log-level", object{"level": "verbose"}, contract.Bearer(admin))
+	s.Do("restore log level", http.MethodDelete, "/api/v1/admin/log-level", nil, contract.Bearer(admin))
+}
+
+func mfaScenario(t *testing.T, _ *servertest.Server, s *contract.Session) {
+	_, token := register(t, s, "ada@example.com", "Ada Lovelace")
+
+	s.Do("two-factor status", http.MethodGet, "/api/v1/users/me/mfa", nil, contract.Bearer(token))
+	enrollment := s.Do("enroll authenticator", http.MethodPost, "/api/v1/users/me/mfa", nil, contract.Bearer(token))
+	secret := enrollment.Get("data.secret")
+	s.Do("confirm with wrong code", http.MethodPost, "/api/v1/users/me/mfa/confirm", object{"code": "000000"}, contract.Bearer(token))
+	step := totp.Step(time.Now())
+	confirmed := s.Do("confirm authenticator", http.MethodPost, "/api/v1/users/me/mfa/confirm", object{"code": code(t, secret, step)}, contract.Bearer(token))
+	require.Equal(t, http.StatusOK, confirmed.Status, string(confirmed.Body))
+	codes := s.Do("replace recovery codes", http.MethodPost, "/api/v1/users/me/mfa/recovery-codes", object{"code": code(t, secret, step+1)}, contract.Bearer(token))
+	require.Equal(t, http.StatusOK, codes.Status, string(codes.Body))
+
+	challenge := s.Do("login with two-factor", http.MethodPost, "/api/v1/auth/login", object{"email": "ada@example.com", "password": password})
+	mfaToken := challenge.Get("data.mfa_token")
+	s.Do("verify wrong code", http.MethodPost, "/api/v1/auth/mfa/verify", object{"mfa_token": mfaToken, "code": "000000"})
+	verified := s.Do("verify recovery code", http.MethodPost, "/api/v1/auth/mfa/verify", object{
+		"mfa_token": mfaToken, "code": codes.Get("data.recovery_codes.0"), "remember_device": true, "device_name": "Laptop",
+	})
+	require.Equal(t, http.StatusOK, verified.Status, string(verified.Body))
+	s.Do("enroll during login when not required", http.MethodPost, "/api/v1/auth/mfa/enroll", object{"mfa_token": mfaToken})
+	s.Do("confirm enrollment during login when not required", http.MethodPost, "/api/v1/auth/mfa/enroll/confirm", object{"mfa_token": mfaToken, "code": "000000"})
+
+	devices := s.Do("trusted devices", http.MethodGet, "/api/v1/users/me/mfa/devices", nil, contract.Bearer(token))
+	s.Do("revoke trusted device", http.MethodDelete, "/api/v1/users/me/mfa/devices/"+devices.Get("data.0.id"), nil, contract.Bearer(token))
+	s.Do("revoke unknown device", http.MethodDelete, "/api/v1/users/me/mfa/devices/404404404404404404", nil, contract.Bearer(token))
+	s.Do("two-factor status when enabled", http.MethodGet, "/api/v1/users/me/mfa", nil, contract.Bearer(token))
+	s.Do("disable two-factor", http.MethodDelete, "/api/v1/users/me/mfa", object{"code": codes.Get("data.recovery_codes.1")}, contract.Bearer(token))
+}
+
+// code returns the authenticator code of secret for a time step
+func code(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	c, err := totp.Code(secret, step)
+	require.NoError(t, err)
+	return c
+}
+
+func accountScenario(t *testing.T, srv *servertest.Server, s *contract.Session) {
+	_, token := register(t, s, "ada@example.com", "Ada Lovelace")
+
+	s.Do("resend email verification", http.MethodPost, "/api/v1/users/me/email/verification", nil, contract.Bearer(token))
+	s.Do("verify email", http.MethodPost, "/api/v1/auth/verify-email", object{"token": lastLinkToken(t, srv, "ada@example.com", "/verify-email")})
+	s.Do("verify email with an invalid link", http.MethodPost, "/api/v1/auth/verify-email", object{"token": "not-a-token"})
+
+	s.Do("set preference", http.MethodPut, "/api/v1/users/me/preferences/ui.theme", object{"value": "dark"}, contract.Bearer(token))
+	s.Do("set invalid preference", http.MethodPut, "/api/v1/users/me/preferences/ui.theme", object{}, contract.Bearer(token))
+	s.Do("preference", http.MethodGet, "/api/v1/users/me/preferences/ui.theme", nil, contract.Bearer(token))
+	s.Do("preferences", http.MethodGet, "/api/v1/users/me/preferences", nil, contract.Bearer(token))
+	s.Do("delete preference", http.MethodDelete, "/api/v1/users/me/preferences/ui.theme", nil, contract.Bearer(token))
+	s.Do("deleted preference", http.MethodGet, "/api/v1/users/me/preferences/ui.theme", nil, contract.Bearer(token))
+
+	// The welcome notification is raised by the registration event
+	require.Eventually(t, func() bool {
+		var count int64
+		srv.DB.Model(&notification.Notification{}).Count(&count)
+		return count > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Do("unread notification count", http.MethodGet, "/api/v1/users/me/notifications/unread-count", nil, contract.Bearer(token))
+	list := s.Do("notifications", http.MethodGet, "/api/v1/users/me/notifications?page=1&page_size=10", nil, contract.Bearer(token))
+	s.Do("mark notification read", http.MethodPost, "/api/v1/users/me/notifications/"+list.Get("data.0.id")+"/read", nil, contract.Bearer(token))
+	s.Do("mark unknown notification read", http.MethodPost, "/api/v1/users/me/notifications/404404404404404404/read", nil, contract.Bearer(token))
+	s.Do("mark all notifications read", http.MethodPost, "/api/v1/users/me/notifications/read", nil, contract.Bearer(token))
+	s.Do("unread notifications", http.MethodGet, "/api/v1/users/me/notifications?unread=true", nil, contract.Bearer(token))
+}
+
+// linkToken matches the token of a link to page in an email, such as /invite
+// or /verify-email
+func linkToken(page string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(page) + `\?token=([A-Za-z0-9._-]+)`)
+}
+
+// lastLinkToken is the token of the link to page last emailed to address
+func lastLinkToken(t *testing.T, srv *servertest.Server, address, page string) string {
+	t.Helper()
+	pattern := linkToken(page)
+	var token string
+	require.Eventually(t, func() bool {
+		for _, msg := range srv.Mailer.SentTo(address) {
+			if m := pattern.FindStringSubmatch(msg.Text); m != nil {
+				token = m[1]
+			}
+		}
+		return token != ""
+	}, 5*time.Second, 10*time.Millisecond)
+	return token
+}
+
+func organizationsScenario(t *testing.T, srv *servertest.Server, s *contract.Session) {
+	_, owner := register(t, s, "ada@example.com", "Ada Lovelace")
+	graceID, grace := register(t, s, "grace@example.com", "Grace Hopper")
+
+	org := s.Do("create organization", http.MethodPost, "/api/v1/organizations", object{"name": "Analytical Engines"}, contract.Bearer(owner))
+	orgID := org.Get("data.id")
+	s.Do("organizations", http.MethodGet, "/api/v1/organizations", nil, contract.Bearer(owner))
+	s.Do("organization", http.MethodGet, "/api/v1/organizations/"+orgID, nil, contract.Bearer(owner))
+	s.Do("organization of others", http.MethodGet, "/api/v1/organizations/"+orgID, nil, contract.Bearer(grace))
+
+	invitation := s.Do("invite member", http.MethodPost, "/api/v1/invitations", object{"org_id": orgID, "email": "grace@example.com"}, contract.Bearer(owner))
+	s.Do("invite as a non-member", http.MethodPost, "/api/v1/invitations", object{"org_id": orgID, "email": "grace@example.com"}, contract.Bearer(grace))
+	s.Do("resend invitation", http.MethodPost, "/api/v1/invitations/"+invitation.Get("data.id")+"/resend", nil, contract.Bearer(owner))
+	s.Do("invitations", http.MethodGet, "/api/v1/invitations?org_id="+orgID, nil, contract.Bearer(owner))
+	token := lastLinkToken(t, srv, "grace@example.com", "/invite")
+	s.Do("preview invitation", http.MethodGet, "/api/v1/invitations/preview?token="+token, nil)
+	s.Do("preview invalid invitation", http.MethodGet, "/api/v1/invitations/preview?token=not-a-token", nil)
+	s.Do("accept invitation", http.MethodPost, "/api/v1/invitations/accept", object{"token": token}, contract.Bearer(grace))
+	s.Do("members", http.MethodGet, "/api/v1/organizations/"+orgID+"/members", nil, contract.Bearer(grace))
+
+	newcomer := s.Do("invite newcomer", http.MethodPost, "/api/v1/invitations", object{"org_id": orgID, "email": "edsger@example.com"}, contract.Bearer(owner))
+	s.Do("register with invitation", http.MethodPost, "/api/v1/invitations/register", object{
+		"token": lastLinkToken(t, srv, "edsger@example.com", "/invite"), "name": "Edsger Dijkstra", "password": password,
+	})
+	s.Do("revoke used invitation", http.MethodDelete, "/api/v1/invitations/"+newcomer.Get("data.id"), nil, contract.Bearer(owner))
+	revoked := s.Do("invite to revoke", http.MethodPost, "/api/v1/invitations", object{"org_id": orgID, "email": "barbara@example.com"}, contract.Bearer(owner))
+	s.Do("revoke invitation", http.MethodDelete, "/api/v1/invitations/"+revoked.Get("data.id"), nil, contract.Bearer(owner))
+
+	s.Do("transfer ownership", http.MethodPost, "/api/v1/organizations/"+orgID+"/transfer-ownership", object{"user_id": graceID}, contract.Bearer(owner))
+	s.Do("transfer ownership as former owner", http.MethodPost, "/api/v1/organizations/"+orgID+"/transfer-ownership", object{"user_id": graceID}, contract.Bearer(owner))
+}
+
+// withV1Deprecated schedules the retirement of v1
+func withV1Deprecated(cfg *config.Config) {
+	cfg.API.V1DeprecatedAt = "2027-01-01T00:00:00Z"
+	cfg.API.V1SunsetAt = "2027-07-01T00:00:00Z"
+	cfg.API.V1DeprecationLink = url.URL{Scheme: "https", Host: "docs.example.com", Path: "/api/v2"}
+}
+
+func versionsScenario(t *testing.T, _ *servertest.Server, s *contract.Session) {
+	userID, token := register(t, s, "ada@example.com", "Ada Lovelace")
+	s.Do("update own profile", http.MethodPut, "/api/v1/users/me", object{"timezone": "Europe/London"}, contract.Bearer(token))
+
+	s.Do("v1 profile", http.MethodGet, "/api/v1/users/"+userID, nil, contract.Bearer(token))
+	s.Do("v2 profile", http.MethodGet, "/api/v2/users/"+userID, nil, contract.Bearer(token))
+	s.Do("v1 error", http.MethodGet, "/api/v1/users/404404404404404404", nil, contract.Bearer(token))
+	s.Do("v2 error", http.MethodGet, "/api/v2/users/404404404404404404", nil, contract.Bearer(token))
+	s.Do("v2 login", http.MethodPost, "/api/v2/auth/login", object{"email": "ada@example.com", "password": password})
+}
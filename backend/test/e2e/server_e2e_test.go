@@ -2,94 +2,65 @@ package e2e
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/cctw-zed/wonder/internal/container"
 	"github.com/cctw-zed/wonder/internal/domain/user"
-	"github.com/cctw-zed/wonder/internal/server"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/testutil/servertest"
 )
 
 // E2ETestSuite represents an end-to-end test suite that starts a real server
 type E2ETestSuite struct {
-	container  *container.Container
-	server     *server.Server
-	httpServer *http.Server
+	server     *servertest.Server
 	baseURL    string
 	httpClient *http.Client
-	ctx        context.Context
-	cancel     context.CancelFunc
+	postgres   bool
 }
 
-// NewE2ETestSuite creates a new E2E test suite
-func NewE2ETestSuite(t *testing.T) *E2ETestSuite {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	// Create container with testing configuration
-	c, err := container.NewContainerForEnvironment(ctx, "testing")
-	require.NoError(t, err, "Failed to create container for testing")
-
-	// Get server configuration
-	port := c.Config.Server.Port
-	baseURL := fmt.Sprintf("http://localhost:%d", port)
+// NewE2ETestSuite starts the server on a random port and tears it down when
+// tb ends. It runs against a throwaway SQLite database, or with
+// E2E_DATABASE=postgres against the testing environment's database, whose
+// test users are then deleted when tb ends too.
+func NewE2ETestSuite(tb testing.TB) *E2ETestSuite {
+	var opts []servertest.Option
+	postgres := os.Getenv("E2E_DATABASE") == "postgres"
+	if postgres {
+		cfg, err := config.LoadForEnvironment("testing", "../../configs")
+		require.NoError(tb, err, "Failed to load testing configuration")
+		opts = append(opts, servertest.WithPostgres(cfg.Database))
+	}
 
+	srv := servertest.New(tb, opts...)
 	suite := &E2ETestSuite{
-		container: c,
-		baseURL:   baseURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		ctx:    ctx,
-		cancel: cancel,
+		server:     srv,
+		baseURL:    srv.BaseURL,
+		httpClient: srv.Client,
+		postgres:   postgres,
+	}
+	if postgres {
+		tb.Cleanup(func() { suite.CleanupDatabase(tb) })
 	}
-
 	return suite
 }
 
-// StartServer starts the HTTP server for testing
+// StartServer waits until the server answers health checks
 func (s *E2ETestSuite) StartServer(t *testing.T) {
-	// Create server instance
-	s.server = server.New(s.container)
-
-	// Start server in a goroutine
-	go func() {
-		if err := s.server.Start(); err != nil && err != http.ErrServerClosed {
-			t.Errorf("Server failed to start: %v", err)
-		}
-	}()
-
-	// Wait for server to be ready
 	s.waitForServerReady(t)
 }
 
 // CleanupDatabase cleans up test data from database
-func (s *E2ETestSuite) CleanupDatabase(t *testing.T) {
+func (s *E2ETestSuite) CleanupDatabase(tb testing.TB) {
 	// Clean users table using GORM
-	err := s.container.Database.DB().Where("email LIKE ?", "%test.com").Delete(&user.User{}).Error
+	err := s.server.DB.Where("email LIKE ?", "%test.com").Delete(&user.User{}).Error
 	if err != nil {
-		t.Logf("Warning: Failed to cleanup test data: %v", err)
-	}
-}
-
-// Cleanup cleans up resources after tests
-func (s *E2ETestSuite) Cleanup() {
-	if s.server != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		s.server.Shutdown(ctx)
-	}
-	if s.cancel != nil {
-		s.cancel()
-	}
-	if s.container != nil {
-		s.container.Close()
+		tb.Logf("Warning: Failed to cleanup test data: %v", err)
 	}
 }
 
@@ -125,7 +96,6 @@ func TestServerE2E(t *testing.T) {
 	}
 
 	suite := NewE2ETestSuite(t)
-	defer suite.Cleanup()
 
 	// Clean database before tests
 	suite.CleanupDatabase(t)
@@ -175,8 +145,8 @@ func TestServerE2E(t *testing.T) {
 		require.NoError(t, err)
 
 		// Check if user field exists and is not nil
-		userInterface, exists := response["user"]
-		require.True(t, exists, "Response should contain 'user' field")
+		userInterface, exists := response["data"]
+		require.True(t, exists, "Response should contain 'data' field")
 		require.NotNil(t, userInterface, "User field should not be nil")
 
 		user, ok := userInterface.(map[string]interface{})
@@ -233,19 +203,9 @@ func TestServerE2E(t *testing.T) {
 		err = json.NewDecoder(resp.Body).Decode(&errorResp)
 		require.NoError(t, err)
 
-		// The error could be in different fields depending on the error type
-		var errorMessage string
-		if errorResp["error"] != nil {
-			errorMessage = errorResp["error"].(string)
-		} else if errorResp["message"] != nil {
-			errorMessage = errorResp["message"].(string)
-		} else if errorResp["details"] != nil {
-			if details, ok := errorResp["details"].(map[string]interface{}); ok {
-				if msg, ok := details["message"].(string); ok {
-					errorMessage = msg
-				}
-			}
-		}
+		errorBody, ok := errorResp["error"].(map[string]interface{})
+		require.True(t, ok, "Response should contain an 'error' object")
+		errorMessage, _ := errorBody["message"].(string)
 		require.NotEmpty(t, errorMessage, "Expected error message in response")
 		assert.Contains(t, errorMessage, "conflict")
 	})
@@ -275,8 +235,8 @@ func TestServerE2E(t *testing.T) {
 		err = json.NewDecoder(createResp.Body).Decode(&createResponse)
 		require.NoError(t, err)
 
-		userInterface, exists := createResponse["user"]
-		require.True(t, exists, "Response should contain 'user' field")
+		userInterface, exists := createResponse["data"]
+		require.True(t, exists, "Response should contain 'data' field")
 		require.NotNil(t, userInterface, "User field should not be nil")
 
 		createdUser, ok := userInterface.(map[string]interface{})
@@ -335,7 +295,7 @@ func TestServerE2E(t *testing.T) {
 		err = json.NewDecoder(getResp.Body).Decode(&getResponse)
 		require.NoError(t, err)
 
-		retrievedUser := getResponse["user"].(map[string]interface{})
+		retrievedUser := getResponse["data"].(map[string]interface{})
 		assert.Equal(t, userID, retrievedUser["id"])
 		assert.Equal(t, "e2e_lifecycle@test.com", retrievedUser["email"])
 		assert.Equal(t, "E2E Lifecycle User", retrievedUser["name"])
@@ -367,7 +327,7 @@ func TestServerE2E(t *testing.T) {
 		err = json.NewDecoder(updateNameResp.Body).Decode(&updateNameResponse)
 		require.NoError(t, err)
 
-		updatedUser := updateNameResponse["user"].(map[string]interface{})
+		updatedUser := updateNameResponse["data"].(map[string]interface{})
 		assert.Equal(t, userID, updatedUser["id"])
 		assert.Equal(t, "e2e_lifecycle@test.com", updatedUser["email"])
 		assert.Equal(t, "Updated Lifecycle User", updatedUser["name"])
@@ -399,7 +359,7 @@ func TestServerE2E(t *testing.T) {
 		err = json.NewDecoder(updateEmailResp.Body).Decode(&updateEmailResponse)
 		require.NoError(t, err)
 
-		updatedUserWithEmail := updateEmailResponse["user"].(map[string]interface{})
+		updatedUserWithEmail := updateEmailResponse["data"].(map[string]interface{})
 		assert.Equal(t, userID, updatedUserWithEmail["id"])
 		assert.Equal(t, "e2e_updated@test.com", updatedUserWithEmail["email"])
 		assert.Equal(t, "Updated Lifecycle User", updatedUserWithEmail["name"])
@@ -420,16 +380,8 @@ func TestServerE2E(t *testing.T) {
 		require.True(t, exists, "Response should contain 'data' field")
 		require.NotNil(t, dataInterface, "Data field should not be nil")
 
-		data, ok = dataInterface.(map[string]interface{})
-		require.True(t, ok, "Data field should be an object")
-
-		// Safe type assertion for users field within data
-		usersInterface, exists := data["users"]
-		require.True(t, exists, "Data should contain 'users' field")
-		require.NotNil(t, usersInterface, "Users field should not be nil")
-
-		users, ok := usersInterface.([]interface{})
-		require.True(t, ok, "Users field should be an array")
+		users, ok := dataInterface.([]interface{})
+		require.True(t, ok, "Data field should be an array of users")
 		assert.GreaterOrEqual(t, len(users), 1)
 
 		// Find our updated user in the list
@@ -446,7 +398,7 @@ func TestServerE2E(t *testing.T) {
 		assert.Equal(t, "e2e_updated@test.com", foundUser["email"])
 		assert.Equal(t, "Updated Lifecycle User", foundUser["name"])
 
-		// Step 6: Delete the user
+		// Step 6: Delete the user; deleting one's own account schedules it
 		deleteReq, err := http.NewRequest(
 			http.MethodDelete,
 			suite.baseURL+"/api/v1/users/"+userID,
@@ -459,9 +411,9 @@ func TestServerE2E(t *testing.T) {
 		require.NoError(t, err)
 		defer deleteResp.Body.Close()
 
-		assert.Equal(t, http.StatusOK, deleteResp.StatusCode)
+		assert.Equal(t, http.StatusAccepted, deleteResp.StatusCode)
 
-		// Step 7: Verify user is deleted - should return 404
+		// Step 7: Verify the deletion is pending
 		verifyDeleteReq, err := http.NewRequest("GET", suite.baseURL+"/api/v1/users/"+userID, nil)
 		require.NoError(t, err)
 		verifyDeleteReq.Header.Set("Authorization", "Bearer "+accessToken)
@@ -470,7 +422,14 @@ func TestServerE2E(t *testing.T) {
 		require.NoError(t, err)
 		defer verifyDeleteResp.Body.Close()
 
-		assert.Equal(t, http.StatusNotFound, verifyDeleteResp.StatusCode)
+		assert.Equal(t, http.StatusOK, verifyDeleteResp.StatusCode)
+
+		var verifyDeleteResponse map[string]interface{}
+		err = json.NewDecoder(verifyDeleteResp.Body).Decode(&verifyDeleteResponse)
+		require.NoError(t, err)
+
+		pendingUser := verifyDeleteResponse["data"].(map[string]interface{})
+		assert.NotEmpty(t, pendingUser["deletion_scheduled_at"])
 	})
 
 	t.Run("User List with Pagination and Filters E2E", func(t *testing.T) {
@@ -482,16 +441,6 @@ func TestServerE2E(t *testing.T) {
 		}
 
 		userIDs := make([]string, 0, len(testUsers))
-		defer func() {
-			// Clean up created users
-			for _, userID := range userIDs {
-				deleteReq, _ := http.NewRequest(http.MethodDelete, suite.baseURL+"/api/v1/users/"+userID, nil)
-				if deleteReq != nil {
-					suite.httpClient.Do(deleteReq)
-				}
-			}
-		}()
-
 		// Create test users
 		for _, testUser := range testUsers {
 			jsonBody, err := json.Marshal(testUser)
@@ -509,8 +458,8 @@ func TestServerE2E(t *testing.T) {
 			resp.Body.Close()
 			require.NoError(t, err)
 
-			userInterface, exists := createResponse["user"]
-			require.True(t, exists, "Response should contain 'user' field")
+			userInterface, exists := createResponse["data"]
+			require.True(t, exists, "Response should contain 'data' field")
 			require.NotNil(t, userInterface, "User field should not be nil")
 
 			createdUser, ok := userInterface.(map[string]interface{})
@@ -542,29 +491,24 @@ func TestServerE2E(t *testing.T) {
 		require.True(t, exists, "Response should contain 'data' field")
 		require.NotNil(t, dataInterface, "Data field should not be nil")
 
-		data, ok := dataInterface.(map[string]interface{})
-		require.True(t, ok, "Data field should be an object")
-
-		// Safe type assertion for users field within data
-		usersInterface, exists := data["users"]
-		require.True(t, exists, "Data should contain 'users' field")
-		require.NotNil(t, usersInterface, "Users field should not be nil")
-
-		users, ok := usersInterface.([]interface{})
-		require.True(t, ok, "Users field should be an array")
+		users, ok := dataInterface.([]interface{})
+		require.True(t, ok, "Data field should be an array of users")
 		assert.LessOrEqual(t, len(users), 2) // Should return at most 2 users
 
-		// Verify pagination metadata within data
-		pageInterface, exists := data["page"]
-		require.True(t, exists, "Data should contain 'page' field")
+		// Verify pagination metadata
+		meta, ok := listResponse["meta"].(map[string]interface{})
+		require.True(t, ok, "Response should contain a 'meta' object")
+
+		pageInterface, exists := meta["page"]
+		require.True(t, exists, "Meta should contain 'page' field")
 		assert.Equal(t, float64(1), pageInterface)
 
-		pageSizeInterface, exists := data["page_size"]
-		require.True(t, exists, "Data should contain 'page_size' field")
+		pageSizeInterface, exists := meta["page_size"]
+		require.True(t, exists, "Meta should contain 'page_size' field")
 		assert.Equal(t, float64(2), pageSizeInterface)
 
-		totalInterface, exists := data["total"]
-		require.True(t, exists, "Data should contain 'total' field")
+		totalInterface, exists := meta["total"]
+		require.True(t, exists, "Meta should contain 'total' field")
 		require.NotNil(t, totalInterface, "Total should not be nil")
 
 		total, ok := totalInterface.(float64)
@@ -587,16 +531,8 @@ func TestServerE2E(t *testing.T) {
 		require.True(t, exists, "Response should contain 'data' field")
 		require.NotNil(t, filterDataInterface, "Data field should not be nil")
 
-		filterData, ok := filterDataInterface.(map[string]interface{})
-		require.True(t, ok, "Data field should be an object")
-
-		// Safe type assertion for users field within data
-		filteredUsersInterface, exists := filterData["users"]
-		require.True(t, exists, "Data should contain 'users' field")
-		require.NotNil(t, filteredUsersInterface, "Users field should not be nil")
-
-		filteredUsers, ok := filteredUsersInterface.([]interface{})
-		require.True(t, ok, "Users field should be an array")
+		filteredUsers, ok := filterDataInterface.([]interface{})
+		require.True(t, ok, "Data field should be an array of users")
 		assert.GreaterOrEqual(t, len(filteredUsers), 3) // Should find our pagination test users
 
 		// Verify all returned users have "Pagination" in their name
@@ -686,10 +622,11 @@ func TestServerE2E(t *testing.T) {
 		updateReq.Header.Set("Content-Type", "application/json")
 		updateReq.Header.Set("Authorization", "Bearer "+accessToken)
 
+		// Users cannot change other users, whether or not they exist
 		updateResp, err := suite.httpClient.Do(updateReq)
 		require.NoError(t, err)
 		defer updateResp.Body.Close()
-		assert.Equal(t, http.StatusNotFound, updateResp.StatusCode)
+		assert.Equal(t, http.StatusForbidden, updateResp.StatusCode)
 
 		// Test 3: Delete non-existent user
 		deleteReq, err := http.NewRequest(
@@ -703,7 +640,7 @@ func TestServerE2E(t *testing.T) {
 		deleteResp, err := suite.httpClient.Do(deleteReq)
 		require.NoError(t, err)
 		defer deleteResp.Body.Close()
-		assert.Equal(t, http.StatusNotFound, deleteResp.StatusCode)
+		assert.Equal(t, http.StatusForbidden, deleteResp.StatusCode)
 
 		// Test 4: Invalid update data
 		invalidUpdateBody := map[string]string{
@@ -733,8 +670,8 @@ func TestServerE2E(t *testing.T) {
 		createResp2.Body.Close()
 		require.NoError(t, err)
 
-		userInterface, exists := createResponse2["user"]
-		require.True(t, exists, "Response should contain 'user' field")
+		userInterface, exists := createResponse2["data"]
+		require.True(t, exists, "Response should contain 'data' field")
 		require.NotNil(t, userInterface, "User field should not be nil")
 
 		createdUser, ok := userInterface.(map[string]interface{})
@@ -747,10 +684,50 @@ func TestServerE2E(t *testing.T) {
 		userID, ok := userIDInterface.(string)
 		require.True(t, ok, "User ID should be a string")
 
+		// The first user may not touch it; its owner gets the validation error
+		otherUpdateReq, err := http.NewRequest(
+			http.MethodPatch,
+			suite.baseURL+"/api/v1/users/"+userID,
+			bytes.NewBufferString(`{"email":"taken-over@test.com"}`),
+		)
+		require.NoError(t, err)
+		otherUpdateReq.Header.Set("Content-Type", "application/merge-patch+json")
+		otherUpdateReq.Header.Set("Authorization", "Bearer "+accessToken)
+
+		otherUpdateResp, err := suite.httpClient.Do(otherUpdateReq)
+		require.NoError(t, err)
+		otherUpdateResp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, otherUpdateResp.StatusCode)
+
+		loginJsonBody2, err := json.Marshal(map[string]string{
+			"email":    "e2e_invalid_update@test.com",
+			"password": "password123",
+		})
+		require.NoError(t, err)
+
+		loginResp2, err := suite.httpClient.Post(
+			suite.baseURL+"/api/v1/auth/login",
+			"application/json",
+			bytes.NewBuffer(loginJsonBody2),
+		)
+		require.NoError(t, err)
+
+		var loginResponse2 map[string]interface{}
+		err = json.NewDecoder(loginResp2.Body).Decode(&loginResponse2)
+		loginResp2.Body.Close()
+		require.NoError(t, err)
+
+		loginData2, ok := loginResponse2["data"].(map[string]interface{})
+		require.True(t, ok, "Login response should contain data")
+
+		ownerToken, ok := loginData2["access_token"].(string)
+		require.True(t, ok, "Login response should contain access_token")
+
 		defer func() {
 			// Clean up
 			deleteReq, _ := http.NewRequest(http.MethodDelete, suite.baseURL+"/api/v1/users/"+userID, nil)
 			if deleteReq != nil {
+				deleteReq.Header.Set("Authorization", "Bearer "+ownerToken)
 				suite.httpClient.Do(deleteReq)
 			}
 		}()
@@ -763,7 +740,7 @@ func TestServerE2E(t *testing.T) {
 		)
 		require.NoError(t, err)
 		invalidUpdateReq.Header.Set("Content-Type", "application/json")
-		invalidUpdateReq.Header.Set("Authorization", "Bearer "+accessToken)
+		invalidUpdateReq.Header.Set("Authorization", "Bearer "+ownerToken)
 
 		invalidUpdateResp, err := suite.httpClient.Do(invalidUpdateReq)
 		require.NoError(t, err)
@@ -785,8 +762,7 @@ func BenchmarkServerE2E(b *testing.B) {
 		b.Skip("Skipping E2E benchmarks in short mode")
 	}
 
-	suite := NewE2ETestSuite(&testing.T{})
-	defer suite.Cleanup()
+	suite := NewE2ETestSuite(b)
 
 	// suite.StartServer(&testing.T{})
 
@@ -808,10 +784,9 @@ func BenchmarkLifecycleAPIsE2E(b *testing.B) {
 		b.Skip("Skipping E2E benchmarks in short mode")
 	}
 
-	suite := NewE2ETestSuite(&testing.T{})
-	defer suite.Cleanup()
+	suite := NewE2ETestSuite(b)
 
-	suite.CleanupDatabase(&testing.T{})
+	suite.CleanupDatabase(b)
 	// suite.StartServer(&testing.T{})
 
 	// Create a test user for benchmarking get/update/delete operations
@@ -835,9 +810,9 @@ func BenchmarkLifecycleAPIsE2E(b *testing.B) {
 	json.NewDecoder(createResp.Body).Decode(&createResponse)
 	createResp.Body.Close()
 
-	userInterface := createResponse["user"]
+	userInterface := createResponse["data"]
 	if userInterface == nil {
-		b.Fatalf("Response should contain 'user' field")
+		b.Fatalf("Response should contain 'data' field")
 	}
 
 	createdUser, ok := userInterface.(map[string]interface{})
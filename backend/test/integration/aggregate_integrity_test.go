@@ -2,6 +2,7 @@ package integration
 
 import (
 	"context"
+	"os"
 	"testing"
 	"time"
 
@@ -10,13 +11,14 @@ import (
 
 	"github.com/cctw-zed/wonder/internal/application/service"
 	"github.com/cctw-zed/wonder/internal/domain/user"
+	"github.com/cctw-zed/wonder/internal/infrastructure/config"
+	"github.com/cctw-zed/wonder/internal/infrastructure/database"
 	"github.com/cctw-zed/wonder/internal/infrastructure/repository"
 	"github.com/cctw-zed/wonder/internal/testutil/builder"
 	"github.com/cctw-zed/wonder/pkg/logger"
 	idMocks "github.com/cctw-zed/wonder/pkg/snowflake/id/mocks"
 
 	"go.uber.org/mock/gomock"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -219,31 +221,71 @@ func TestUserAggregateIntegrity(t *testing.T) {
 			require.NoError(t, err)
 		})
 
-		// Test 7: Domain events (placeholder for future implementation)
-		t.Run("domain events placeholder", func(t *testing.T) {
-			// This test serves as a placeholder for when domain events are implemented
-			// Domain events should be raised when aggregate state changes
-			t.Skip("Domain events not yet implemented")
+		// Test 7: Domain events are raised on aggregate state changes
+		t.Run("domain events", func(t *testing.T) {
+			u := builder.NewUserBuilder().
+				WithEmail("events@example.com").
+				Build()
+
+			u.MarkRegistered()
+			require.NoError(t, u.UpdateEmail(ctx, "events-new@example.com"))
+
+			// Recorded events are not persisted with the aggregate
+			err := repo.Create(ctx, u)
+			require.NoError(t, err)
+			retrieved, err := repo.GetByID(ctx, u.ID)
+			require.NoError(t, err)
+			assert.Empty(t, retrieved.PendingEvents())
+
+			events := u.PullEvents()
+			require.Len(t, events, 2)
+			assert.Equal(t, user.EventUserRegistered, events[0].EventName())
+			assert.Equal(t, user.EventUserEmailChanged, events[1].EventName())
+			assert.Empty(t, u.PendingEvents())
 		})
 	})
 }
 
+// setupIntegrationTestDB connects to the database selected by
+// TEST_DB_DRIVER (postgres, the default, mysql or sqlite) and TEST_DB_DSN, and
+// migrates a clean schema. The test is skipped when no database answers.
 func setupIntegrationTestDB(t *testing.T) *gorm.DB {
-	// Skip integration tests if no test database is available
-	dsn := "host=localhost port=5432 user=test password=test dbname=wonder_test sslmode=disable timezone=UTC"
+	cfg := &config.DatabaseConfig{
+		Driver:   config.DriverPostgres,
+		Host:     "localhost",
+		Port:     5432,
+		Username: "test",
+		Password: "test",
+		Database: "wonder_test",
+		SSLMode:  "disable",
+		Timezone: "UTC",
+	}
+	if driver := os.Getenv("TEST_DB_DRIVER"); driver != "" {
+		cfg.Driver = driver
+	}
+	if cfg.Driver == config.DriverMySQL {
+		cfg.Port = 3306
+	}
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		dsn = cfg.DSN()
+	}
+	driver, err := database.LookupDriver(cfg.Driver)
+	require.NoError(t, err)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(driver.Dialector(dsn), &gorm.Config{})
 	if err != nil {
 		t.Skip("No test database available, skipping integration tests")
 		return nil
 	}
 
-	// Clean up any existing data
+	// Clean up any existing data, including tables created by AutoMigrate
+	// before the suite used migrations
+	ctx := context.Background()
+	migrator := database.NewMigrator(db)
+	require.NoError(t, migrator.DropAll(ctx))
 	db.Exec("DROP TABLE IF EXISTS users")
-
-	// Auto-migrate the schema
-	err = db.AutoMigrate(&user.User{})
-	require.NoError(t, err)
+	require.NoError(t, migrator.Up(ctx))
 
 	return db
 }
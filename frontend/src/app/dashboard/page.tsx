@@ -12,7 +12,7 @@ import { Label } from '@/components/ui/label';
 import { z } from 'zod';
 import { useForm } from 'react-hook-form';
 import { zodResolver } from '@hookform/resolvers/zod';
-import { userAPI } from '@/lib/api';
+import { apiErrorMessage, userAPI } from '@/lib/api';
 
 const profileSchema = z.object({
   name: z
@@ -108,12 +108,11 @@ export default function DashboardPage() {
     setProfileStatus(null);
 
     try {
-      const updatedUser = await userAPI.updateProfile(user.id, values);
+      const updatedUser = await userAPI.updateProfile(values);
       useAuthStore.setState({ user: updatedUser });
       setProfileStatus({ type: 'success', message: '个人资料已更新' });
     } catch (error: unknown) {
-      const message =
-        (error as { response?: { data?: { message?: string } } })?.response?.data?.message || '更新个人资料失败，请稍后重试';
+      const message = apiErrorMessage(error, '更新个人资料失败，请稍后重试');
       setProfileStatus({ type: 'error', message });
     }
   });
@@ -124,15 +123,14 @@ export default function DashboardPage() {
     setPasswordStatus(null);
 
     try {
-      await userAPI.changePassword(user.id, {
+      await userAPI.changePassword({
         old_password: values.oldPassword,
         new_password: values.newPassword,
       });
       setPasswordStatus({ type: 'success', message: '密码更新成功' });
       passwordForm.reset();
     } catch (error: unknown) {
-      const message =
-        (error as { response?: { data?: { message?: string } } })?.response?.data?.message || '更新密码失败，请稍后重试';
+      const message = apiErrorMessage(error, '更新密码失败，请稍后重试');
       setPasswordStatus({ type: 'error', message });
     }
   });
@@ -68,17 +68,38 @@ export interface AuthResponseData {
   user: User;
 }
 
-export interface AuthResponse {
-  data: AuthResponseData;
-  trace_id: string;
+// Every API response is wrapped in the same envelope
+export interface ApiMeta {
+  page?: number;
+  page_size: number;
+  total: number;
+  total_pages?: number;
+  next_cursor?: string;
+  has_more: boolean;
+}
+
+export interface ApiErrorBody {
+  status_code: number;
+  code: string;
+  message: string;
+  details?: Record<string, unknown>;
 }
 
-interface RawMeResponse {
-  user_id?: string;
-  data?: Partial<User> & { id?: string };
+export interface ApiEnvelope<T> {
+  data?: T;
+  meta?: ApiMeta;
+  error?: ApiErrorBody;
   trace_id: string;
 }
 
+export type AuthResponse = ApiEnvelope<AuthResponseData> & { data: AuthResponseData };
+
+// Extracts the server error message from a failed request
+export const apiErrorMessage = (error: unknown, fallback: string): string => {
+  const envelope = (error as { response?: { data?: ApiEnvelope<unknown> } })?.response?.data;
+  return envelope?.error?.message || fallback;
+};
+
 export interface UpdateProfilePayload {
   email?: string;
   name?: string;
@@ -89,46 +110,21 @@ export interface ChangePasswordPayload {
   new_password: string;
 }
 
-const resolveUserId = (response: RawMeResponse): string | undefined => {
-  if (response.user_id) {
-    return response.user_id;
-  }
-
-  const candidateId = response.data?.id;
-  if (candidateId) {
-    return candidateId;
-  }
-
-  return undefined;
-};
-
 // API helpers grouped by concern
 export const authAPI = {
-  register: async (userData: RegisterRequest): Promise<{ user: User; trace_id: string }> => {
-    const response = await api.post('/users/register', userData);
-    return response.data;
+  register: async (userData: RegisterRequest): Promise<User> => {
+    const response = await api.post<ApiEnvelope<User>>('/users/register', userData);
+    return response.data.data as User;
   },
 
   login: async (credentials: LoginRequest): Promise<AuthResponse> => {
-    const response = await api.post('/auth/login', credentials);
+    const response = await api.post<AuthResponse>('/auth/login', credentials);
     return response.data;
   },
 
   getProfile: async (): Promise<User> => {
-    const response = await api.get<RawMeResponse>('/auth/me');
-    const directUser = response.data.data;
-
-    if (directUser && directUser.email && directUser.name && directUser.id) {
-      return directUser as User;
-    }
-
-    const userId = resolveUserId(response.data);
-    if (!userId) {
-      throw new Error('Unable to resolve authenticated user id from /auth/me response');
-    }
-
-    const profileResponse = await api.get<{ user: User }>(`/users/${userId}`);
-    const user = profileResponse.data.user;
+    const response = await api.get<ApiEnvelope<User>>('/users/me');
+    const user = response.data.data;
 
     if (!user) {
       throw new Error('User profile response did not include user data');
@@ -139,13 +135,13 @@ export const authAPI = {
 };
 
 export const userAPI = {
-  updateProfile: async (userId: string, payload: UpdateProfilePayload): Promise<User> => {
-    const response = await api.put<{ user: User }>(`/users/${userId}`, payload);
-    return response.data.user;
+  updateProfile: async (payload: UpdateProfilePayload): Promise<User> => {
+    const response = await api.put<ApiEnvelope<User>>('/users/me', payload);
+    return response.data.data as User;
   },
 
-  changePassword: async (userId: string, payload: ChangePasswordPayload): Promise<void> => {
-    await api.put(`/users/${userId}/password`, payload);
+  changePassword: async (payload: ChangePasswordPayload): Promise<void> => {
+    await api.patch('/users/me/password', payload);
   },
 };
 
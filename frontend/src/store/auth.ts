@@ -1,6 +1,6 @@
 import { create } from 'zustand';
 import { persist } from 'zustand/middleware';
-import { User, authAPI, LoginRequest, RegisterRequest, setAuthToken, removeAuthToken } from '@/lib/api';
+import { User, authAPI, LoginRequest, RegisterRequest, setAuthToken, removeAuthToken, apiErrorMessage } from '@/lib/api';
 
 interface AuthState {
   user: User | null;
@@ -55,7 +55,7 @@ export const useAuthStore = create<AuthState & AuthActions>()(
           return true;
         } catch (error: unknown) {
           console.error('❌ Login error:', error);
-          const errorMessage = (error as { response?: { data?: { message?: string } } })?.response?.data?.message || '登录失败，请检查邮箱和密码';
+          const errorMessage = apiErrorMessage(error, '登录失败，请检查邮箱和密码');
           set({
             error: errorMessage,
             isLoading: false,
@@ -80,7 +80,7 @@ export const useAuthStore = create<AuthState & AuthActions>()(
 
           return loginSuccess;
         } catch (error: unknown) {
-          const errorMessage = (error as { response?: { data?: { message?: string } } })?.response?.data?.message || '注册失败，请检查输入信息';
+          const errorMessage = apiErrorMessage(error, '注册失败，请检查输入信息');
           set({
             error: errorMessage,
             isLoading: false,